@@ -18,6 +18,6 @@ var (
 func main() {
 	cli.SetVersion(version, commit, date)
 	if err := cli.Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(cli.ExitCode(err))
 	}
 }
@@ -0,0 +1,81 @@
+// Command export shows how to embed get-out's exporter in a Go program.
+// It exports the conversations marked for export in a conversations.json
+// to Google Docs, using the credentials `get-out init` and `get-out auth
+// login` saved in the config directory, and a Slack token from the
+// environment or, without one, the Slack tab of Chrome:
+//
+//	GET_OUT_SLACK_TOKEN=xoxp-... go run ./examples/export -sync
+//
+// Unlike the CLI it does not read settings.json; options given to
+// exporter.New take its place.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/exporter"
+	"github.com/jflowers/get-out/pkg/secrets"
+)
+
+func main() {
+	configDir := flag.String("config-dir", config.DefaultConfigDir(), "get-out config directory")
+	folder := flag.String("folder", exporter.DefaultRootFolderName, "Google Drive root folder name")
+	sync := flag.Bool("sync", false, "export only messages newer than the last export")
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	if err := run(ctx, *configDir, *folder, *sync); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(ctx context.Context, configDir, folder string, sync bool) error {
+	convs, err := config.LoadConversations(filepath.Join(configDir, "conversations.json"))
+	if err != nil {
+		return err
+	}
+
+	logf := func(msg string) { log.Print(msg) }
+	opts := []exporter.Option{
+		exporter.WithRootFolder(folder),
+		exporter.WithProgress(logf, nil),
+		exporter.WithNotices(logf),
+	}
+	if token := os.Getenv("GET_OUT_SLACK_TOKEN"); token != "" {
+		opts = append(opts, exporter.WithSlackToken(token, os.Getenv("GET_OUT_SLACK_COOKIE")))
+	}
+	if sync {
+		opts = append(opts, exporter.WithSync())
+	}
+	exp := exporter.New(configDir, opts...)
+
+	// NewStore cannot fail: without a usable OS keychain it falls back to
+	// files in the config directory, and says which it chose.
+	store, backend := secrets.NewStore(false, configDir)
+	logf(fmt.Sprintf("Reading Google credentials from the %s", backend))
+	if err := exp.Initialize(ctx, store); err != nil {
+		return err
+	}
+	results, err := exp.ExportAll(ctx, convs.FilterByExport())
+	if err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		if r.Error != nil {
+			fmt.Printf("%s: failed: %v\n", r.Name, r.Error)
+			continue
+		}
+		fmt.Printf("%s: %d messages, %d new docs, %s\n", r.Name, r.MessageCount, r.DocsCreated, r.FolderURL)
+	}
+	fmt.Println("Exported to", exp.GetRootFolderURL())
+	return nil
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/chrome"
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/exporter"
+	"github.com/jflowers/get-out/pkg/slackapi"
+	"github.com/spf13/cobra"
+)
+
+var testCmd = &cobra.Command{
+	Use:   "test [conversation_id]",
+	Short: "Check which Slack API methods and export features work in this workspace",
+	Long: `Probe every Slack API method the exporter uses with one cheap call each,
+then print which export features will work, which fall back to another
+strategy, and which will be skipped.
+
+Conversation methods (history, replies, members, info) are probed against
+the given conversation, or against the first public channel Slack lists.
+
+Prerequisites:
+  - Chrome/Chromium running with remote debugging enabled
+  - An active Slack tab in the browser with an authenticated session`,
+	Example: `  # Probe using the first public channel
+  get-out test
+
+  # Probe using a conversation you plan to export
+  get-out test C789DEF012`,
+	Args:              cobra.MaximumNArgs(1),
+	SilenceUsage:      true,
+	RunE:              runTest,
+	ValidArgsFunction: completeFirstConversationID,
+}
+
+func init() {
+	rootCmd.AddCommand(testCmd)
+}
+
+func runTest(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	// settings.json is optional here; it only names the Slack workspace.
+	settings, _ := config.LoadSettings(filepath.Join(configDir, "settings.json"))
+	chromeCfg := chrome.DefaultConfig()
+	chromeCfg.DebugPort = chromePort
+	chromeCfg.OpenSlackURL = slackTabURL(settings)
+	chromeCfg.KeepOpenedTab = keepSlackTab
+	chromeCfg.PreferTeam = preferredSlackTeam(settings)
+	session, err := chrome.Connect(ctx, chromeCfg)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Chrome: %w", err)
+	}
+	defer session.Close()
+
+	creds, err := session.ExtractCredentialsForTeam(ctx, resolveSlackTeam(slackTeam, settings))
+	if err != nil {
+		return fmt.Errorf("failed to extract credentials: %w", err)
+	}
+	client := slackapi.NewBrowserClient(creds.Token, creds.Cookie)
+	if _, err := client.ValidateAuth(ctx); err != nil {
+		return fmt.Errorf("Slack session expired or invalid: %w", err)
+	}
+
+	var channelID string
+	if len(args) > 0 {
+		channelID = args[0]
+	}
+	fmt.Printf("Testing Slack API access for %s...\n\n", creds.TeamDomain)
+	formatAccessReport(os.Stdout, slackapi.TestAccess(ctx, client, channelID))
+	return nil
+}
+
+// formatAccessReport writes the probed methods and the resulting export
+// feature status to w.
+func formatAccessReport(w io.Writer, caps *slackapi.Capabilities) {
+	fmt.Fprintln(w, "Slack API methods:")
+	for _, method := range slackapi.ProbedMethods {
+		line := fmt.Sprintf("  %-24s %s", method, caps.Access(method))
+		if reason := caps.Reason(method); reason != "" {
+			line += "  (" + reason + ")"
+		}
+		fmt.Fprintln(w, line)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Export features:")
+	for _, f := range exporter.ExportFeatures {
+		status, note := f.Evaluate(caps)
+		var mark string
+		switch status {
+		case exporter.FeatureAvailable:
+			mark = "✓"
+		case exporter.FeatureDegraded:
+			mark = "⚠"
+		case exporter.FeatureUnavailable:
+			mark = "✗"
+		default:
+			mark = "?"
+		}
+		line := fmt.Sprintf("  %s %-28s %s", mark, f.Name, status)
+		if note != "" {
+			line += ": " + note
+		}
+		fmt.Fprintln(w, line)
+	}
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+func TestFormatAccessReport(t *testing.T) {
+	caps := slackapi.NewCapabilities()
+	caps.Record(slackapi.MethodAuthTest, nil)
+	caps.Record(slackapi.MethodConversationsHistory, nil)
+	caps.Record(slackapi.MethodConversationsReplies, &slackapi.APIError{Code: slackapi.ErrCodeMissingScope})
+	caps.Record(slackapi.MethodSearchMessages, &slackapi.APIError{Code: slackapi.ErrCodeNotAllowedTokenType})
+
+	var buf bytes.Buffer
+	formatAccessReport(&buf, caps)
+	out := buf.String()
+
+	for _, want := range []string{
+		"conversations.replies    restricted  (slack api error: missing_scope)",
+		"emoji.list               unknown",
+		"✓ Conversation messages",
+		"⚠ Thread replies               degraded: main messages are exported without their threads",
+		"✗ Search",
+		"? Custom emoji",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -43,7 +43,16 @@ Prerequisites:
 To get credentials.json:
   1. Go to https://console.cloud.google.com/apis/credentials
   2. Create a new OAuth 2.0 Client ID (Desktop application)
-  3. Download the JSON and save as credentials.json in your config directory`,
+  3. Download the JSON and save as credentials.json in your config directory
+
+Without credentials.json, set GOOGLE_CLIENT_ID and GOOGLE_CLIENT_SECRET
+instead. GOOGLE_REFRESH_TOKEN, when also set, is used instead of a saved
+token, so no login is needed at all.
+
+On a machine without a local browser (a container, a remote server), use
+--device: get-out prints a URL and a code to enter on any other device.
+The device flow needs an OAuth client of type "TVs and Limited Input
+devices" and grants only the drive.file scope.`,
 	RunE: runAuthLogin,
 }
 
@@ -56,7 +65,10 @@ var authStatusCmd = &cobra.Command{
 	RunE:         runAuthStatus,
 }
 
+var authLoginDevice bool
+
 func init() {
+	authLoginCmd.Flags().BoolVar(&authLoginDevice, "device", false, "Use the device-code flow (no local browser needed)")
 	authCmd.AddCommand(authLoginCmd)
 	authCmd.AddCommand(authStatusCmd)
 	rootCmd.AddCommand(authCmd)
@@ -78,15 +90,19 @@ func runAuthLogin(cmd *cobra.Command, args []string) error {
 	if settings.GoogleCredentialsFile != "" {
 		cfg.CredentialsPath = settings.GoogleCredentialsFile
 	}
+	cfg.DeviceFlow = authLoginDevice || headless
+	cfg.GmailSend = settings.EmailDigest.UsesGmail()
 
-	// Check for credentials via store
-	if _, err := secretStore.Get(secrets.KeyClientCredentials); err != nil {
+	// Check for credentials via environment or store
+	if cfg.HasEnvCredentials() {
+		fmt.Printf("Using client credentials from %s and %s\n", gdrive.EnvClientID, gdrive.EnvClientSecret)
+	} else if _, err := secretStore.Get(secrets.KeyClientCredentials); err != nil {
 		fmt.Println("ERROR: credentials not found")
 		fmt.Println()
 		fmt.Printf("Please download OAuth credentials from Google Cloud Console\n")
 		fmt.Printf("and save them to: %s\n", cfg.CredentialsPath)
 		fmt.Println()
-		fmt.Println("Steps:")
+		fmt.Println("Run 'get-out setup-google' for a guided setup, or:")
 		fmt.Println("  1. Go to https://console.cloud.google.com/apis/credentials")
 		fmt.Println("  2. Create OAuth 2.0 Client ID (Desktop application)")
 		fmt.Println("  3. Download JSON and save as credentials.json")
@@ -95,6 +111,8 @@ func runAuthLogin(cmd *cobra.Command, args []string) error {
 		fmt.Println("Also ensure these APIs are enabled:")
 		fmt.Println("  - Google Drive API")
 		fmt.Println("  - Google Docs API")
+		fmt.Println()
+		fmt.Printf("Or set %s and %s.\n", gdrive.EnvClientID, gdrive.EnvClientSecret)
 		return fmt.Errorf("credentials not found in store or at %s", cfg.CredentialsPath)
 	}
 
@@ -104,6 +122,11 @@ func runAuthLogin(cmd *cobra.Command, args []string) error {
 		fmt.Println()
 		fmt.Println("To re-authenticate, delete the stored token and run this command again:")
 		fmt.Println("  get-out auth login  (will re-run the OAuth flow)")
+		if cfg.GmailSend {
+			fmt.Println()
+			fmt.Println("Email digests sent with Gmail need permission to send mail; authenticate")
+			fmt.Println("again this way if you logged in before setting emailDigest.transport.")
+		}
 		return nil
 	}
 
@@ -160,21 +183,26 @@ func authStatusCore(cfgDir string, store secrets.SecretStore, backend secrets.Ba
 		cfg.CredentialsPath = settings.GoogleCredentialsFile
 	}
 
-	// Check 1: credentials in store
-	if _, err := store.Get(secrets.KeyClientCredentials); err == nil {
+	// Check 1: credentials from environment or store
+	if cfg.HasEnvCredentials() {
+		fmt.Printf("Credentials: ✓ from %s / %s\n", gdrive.EnvClientID, gdrive.EnvClientSecret)
+	} else if _, err := store.Get(secrets.KeyClientCredentials); err == nil {
 		fmt.Printf("Credentials: ✓ found (%s)\n", backend)
 	} else {
 		fmt.Printf("Credentials: ✗ not found\n")
 		fmt.Printf("             → Place credentials.json at %s and run: get-out init\n", cfg.CredentialsPath)
 	}
 
-	// Check 2: token in store
-	if _, err := store.Get(secrets.KeyOAuthToken); err != nil {
+	// Check 2: token from environment or store
+	if cfg.RefreshToken != "" {
+		fmt.Printf("Token:       ✓ from %s\n", gdrive.EnvRefreshToken)
+	} else if _, err := store.Get(secrets.KeyOAuthToken); err != nil {
 		fmt.Printf("Token:       ✗ not found\n")
 		fmt.Println("             → Run: get-out auth login")
 		return fmt.Errorf("not authenticated")
+	} else {
+		fmt.Printf("Token:       ✓ found (%s)\n", backend)
 	}
-	fmt.Printf("Token:       ✓ found (%s)\n", backend)
 
 	// Check 3: token validity + silent refresh
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
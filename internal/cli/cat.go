@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/exporter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	catFrom                string
+	catTo                  string
+	catFormat              string
+	catNoSensitivityFilter bool
+)
+
+var catCmd = &cobra.Command{
+	Use:   "cat <conversation_id>",
+	Short: "Print a conversation's messages to stdout as markdown or JSON",
+	Long: `Print a conversation's messages to stdout, oldest first, for reading in a
+pager or piping into other tools.
+
+Messages are fetched from Slack; nothing is written to Google Drive, the local
+export directory, or the export index. Markdown is one daily document per day,
+as written by the markdown format, with thread replies after each day's
+messages. JSON is one Slack message object per line (JSON Lines).
+
+The conversation does not need to be in conversations.json; one that is not is
+named from Slack. The sensitivity filter applies as for local markdown.
+Progress messages (-v) go to stderr.`,
+	Example: `  # Read a DM in a pager
+  get-out cat D123ABC456 | less
+
+  # One week of a channel as JSON Lines
+  get-out cat C789DEF012 --from 2026-04-13 --to 2026-04-19 --format json | jq -r .text`,
+	Args:              cobra.ExactArgs(1),
+	SilenceUsage:      true,
+	RunE:              runCat,
+	ValidArgsFunction: completeFirstConversationID,
+}
+
+func init() {
+	catCmd.Flags().StringVar(&catFrom, "from", "", "Print messages from this date (YYYY-MM-DD)")
+	catCmd.Flags().StringVar(&catTo, "to", "", "Print messages up to this date (YYYY-MM-DD)")
+	catCmd.Flags().StringVar(&catFormat, "format", string(config.OutputFormatMarkdown), "Output format: markdown or json")
+	catCmd.Flags().BoolVar(&catNoSensitivityFilter, "no-sensitivity-filter", false, "Disable sensitivity filtering for this run")
+	rootCmd.AddCommand(catCmd)
+}
+
+func runCat(cmd *cobra.Command, args []string) error {
+	format, err := parseCatFormat(catFormat)
+	if err != nil {
+		return err
+	}
+	dateFrom, dateTo, err := parseDateRange(catFrom, catTo)
+	if err != nil {
+		return &usageError{err: err}
+	}
+
+	settings, err := config.LoadSettings(filepath.Join(configDir, "settings.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+	applyTimeSettings(settings)
+	messageFilter, err := buildMessageFilter(settings, "", catNoSensitivityFilter)
+	if err != nil {
+		return err
+	}
+	slackToken, slackCookie, err := slackCredentialsFromEnv()
+	if err != nil {
+		return err
+	}
+
+	level := outputLevel()
+	exp := exporter.NewExporter(&exporter.ExporterConfig{
+		ConfigDir:     configDir,
+		Debug:         level >= levelDebug,
+		DateFrom:      dateFrom,
+		DateTo:        dateTo,
+		MessageFilter: messageFilter,
+		NamePolicy:    settings.NamePolicy,
+		Version:       buildVersion,
+		SlackToken:    slackToken,
+		SlackCookie:   slackCookie,
+		OnProgress:    levelProgress(os.Stderr, level, levelVerbose, nil),
+		OnDetail:      levelProgress(os.Stderr, level, levelDetail, nil),
+	})
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	if err := exp.InitializeStream(ctx, chromePort); err != nil {
+		return err
+	}
+
+	conv, err := catConversation(ctx, exp, args[0])
+	if err != nil {
+		return err
+	}
+	return exp.StreamConversation(ctx, os.Stdout, conv, format)
+}
+
+// parseCatFormat parses --format for cat, which prints markdown or json.
+func parseCatFormat(value string) (config.OutputFormat, error) {
+	f, err := config.ParseOutputFormat(value)
+	if err == nil && f != config.OutputFormatMarkdown && f != config.OutputFormatJSON {
+		err = fmt.Errorf("cat prints %s or %s, not %s", config.OutputFormatMarkdown, config.OutputFormatJSON, f)
+	}
+	if err != nil {
+		return "", &usageError{err: fmt.Errorf("--format: %w", err)}
+	}
+	return f, nil
+}
+
+// catConversation returns the config of conversation id from
+// conversations.json, or, when it is not configured, as Slack describes it.
+func catConversation(ctx context.Context, exp *exporter.Exporter, id string) (config.ConversationConfig, error) {
+	path := filepath.Join(configDir, "conversations.json")
+	if _, err := os.Stat(path); err == nil {
+		cfg, err := config.LoadConversations(path)
+		if err != nil {
+			return config.ConversationConfig{}, fmt.Errorf("failed to load config: %w", err)
+		}
+		if conv := cfg.GetByID(id); conv != nil {
+			return *conv, nil
+		}
+	}
+	return exp.DescribeConversation(ctx, id), nil
+}
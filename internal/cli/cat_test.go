@@ -0,0 +1,21 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/config"
+)
+
+func TestParseCatFormat(t *testing.T) {
+	for value, want := range map[string]config.OutputFormat{"markdown": config.OutputFormatMarkdown, "JSON": config.OutputFormatJSON} {
+		if f, err := parseCatFormat(value); err != nil || f != want {
+			t.Errorf("parseCatFormat(%q) = %q, %v; want %q", value, f, err, want)
+		}
+	}
+	for _, value := range []string{"html", "docs", "pdf"} {
+		_, err := parseCatFormat(value)
+		if code := ExitCode(err); err == nil || code != ExitConfig {
+			t.Errorf("parseCatFormat(%q): err = %v, exit code %d", value, err, code)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+var docsManDir string
+
+var docsCmd = &cobra.Command{
+	Use:   "docs",
+	Short: "Generate documentation for get-out",
+}
+
+var docsManCmd = &cobra.Command{
+	Use:   "man",
+	Short: "Generate man pages for every command",
+	Long: `Generate a man page (section 1) for get-out and each of its subcommands.
+
+Shell completion scripts are generated by 'get-out completion'.`,
+	Example: `  # Write man pages to ./man
+  get-out docs man
+
+  # Install them for the current user
+  get-out docs man --dir ~/.local/share/man/man1`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runDocsMan,
+}
+
+func init() {
+	docsManCmd.Flags().StringVar(&docsManDir, "dir", "man", "Directory to write man pages to")
+	docsCmd.AddCommand(docsManCmd)
+	rootCmd.AddCommand(docsCmd)
+}
+
+func runDocsMan(cmd *cobra.Command, args []string) error {
+	if err := os.MkdirAll(docsManDir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	header := &doc.GenManHeader{
+		Title:   "GET-OUT",
+		Section: "1",
+		Source:  "get-out " + buildVersion,
+		Manual:  "get-out Manual",
+	}
+	root := cmd.Root()
+	root.DisableAutoGenTag = true
+	if err := doc.GenManTree(root, header, docsManDir); err != nil {
+		return fmt.Errorf("failed to generate man pages: %w", err)
+	}
+	fmt.Printf("Wrote man pages to %s\n", docsManDir)
+	return nil
+}
+
+// completeConversationIDs completes conversation IDs from conversations.json,
+// showing each conversation's name as the description. IDs already on the
+// command line are left out.
+func completeConversationIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg, err := config.LoadConversations(filepath.Join(configDir, "conversations.json"))
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return conversationCompletions(cfg, args, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeFirstConversationID completes only the first positional argument,
+// for commands like doc-requests whose later arguments are not IDs.
+func completeFirstConversationID(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeConversationIDs(cmd, args, toComplete)
+}
+
+// conversationCompletions returns "ID\tName" completions for conversations
+// whose ID or name starts with toComplete (case-insensitive) and that are
+// not already in args.
+func conversationCompletions(cfg *config.ConversationsConfig, args []string, toComplete string) []string {
+	used := make(map[string]bool, len(args))
+	for _, a := range args {
+		used[a] = true
+	}
+	prefix := strings.ToLower(toComplete)
+
+	var out []string
+	for _, c := range cfg.Conversations {
+		if used[c.ID] {
+			continue
+		}
+		if !strings.HasPrefix(strings.ToLower(c.ID), prefix) && !strings.HasPrefix(strings.ToLower(c.Name), prefix) {
+			continue
+		}
+		if c.Name != "" {
+			out = append(out, c.ID+"\t"+c.Name)
+		} else {
+			out = append(out, c.ID)
+		}
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/config"
+)
+
+func TestConversationCompletions(t *testing.T) {
+	cfg := &config.ConversationsConfig{Conversations: []config.ConversationConfig{
+		{ID: "C001", Name: "general"},
+		{ID: "C002", Name: "Engineering"},
+		{ID: "D001"},
+	}}
+
+	tests := []struct {
+		name       string
+		args       []string
+		toComplete string
+		want       []string
+	}{
+		{"all", nil, "", []string{"C001\tgeneral", "C002\tEngineering", "D001"}},
+		{"by ID prefix", nil, "c", []string{"C001\tgeneral", "C002\tEngineering"}},
+		{"by name prefix", nil, "eng", []string{"C002\tEngineering"}},
+		{"skips used IDs", []string{"C001"}, "C", []string{"C002\tEngineering"}},
+		{"no match", nil, "X", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := conversationCompletions(cfg, tt.args, tt.toComplete)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("conversationCompletions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunDocsMan(t *testing.T) {
+	orig := docsManDir
+	defer func() { docsManDir = orig }()
+	docsManDir = filepath.Join(t.TempDir(), "man")
+
+	if err := runDocsMan(docsManCmd, nil); err != nil {
+		t.Fatalf("runDocsMan() error: %v", err)
+	}
+	for _, name := range []string{"get-out.1", "get-out-export.1", "get-out-docs-man.1"} {
+		if _, err := os.Stat(filepath.Join(docsManDir, name)); err != nil {
+			t.Errorf("missing man page %s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/errcat"
+	"github.com/spf13/cobra"
+)
+
+// configCmd is the parent command group for config file sub-commands.
+var configCmd = &cobra.Command{
+	Use:          "config",
+	Short:        "Check config files and print their schemas",
+	SilenceUsage: true,
+	Long: `Work with settings.json, conversations.json, and people.json.
+
+Sub-commands:
+  validate  Check the config files against their schemas
+  schema    Print the JSON Schema of a config file`,
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:          "validate",
+	Short:        "Check the config files against their schemas",
+	SilenceUsage: true,
+	Long: `Check settings.json, conversations.json, and people.json in the config
+directory against their published JSON Schemas and the rules applied when
+they are loaded.
+
+Each problem is reported with its file, line, and column: syntax errors,
+unknown fields (with the closest known field), invalid values, and missing
+required fields. Deprecated fields and shareMembers emails that belong to
+no person in people.json are reported as warnings.
+
+Exits with code 2 if any file has errors; warnings alone do not fail.`,
+	Example: `  get-out config validate
+  get-out config validate --config ./config`,
+	Args: cobra.NoArgs,
+	RunE: runConfigValidate,
+}
+
+var configSchemaCmd = &cobra.Command{
+	Use:          "schema <settings|conversations|people>",
+	Short:        "Print the JSON Schema of a config file",
+	SilenceUsage: true,
+	Long: `Print the JSON Schema of settings.json, conversations.json, or
+people.json. Point an editor at it with a "$schema" key to get completion
+and checking while editing.`,
+	Example:   `  get-out config schema conversations > conversations.schema.json`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: config.SchemaNames,
+	RunE:      runConfigSchema,
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	issues, err := config.ValidateDir(configDir)
+	if err != nil {
+		return err
+	}
+	return formatConfigIssues(os.Stdout, issues)
+}
+
+func runConfigSchema(cmd *cobra.Command, args []string) error {
+	data, err := config.Schema(args[0])
+	if err != nil {
+		return &usageError{err: err}
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+// formatConfigIssues prints the issues found by config validation and
+// returns an error when any of them is an error rather than a warning.
+func formatConfigIssues(w io.Writer, issues []config.Issue) error {
+	errorCount, warningCount := 0, 0
+	for _, issue := range issues {
+		fmt.Fprintln(w, issue)
+		if issue.Warning {
+			warningCount++
+		} else {
+			errorCount++
+		}
+	}
+	if errorCount > 0 {
+		return errcat.Wrap(errcat.ConfigInvalid, fmt.Errorf("config validation found %d error(s), %d warning(s)", errorCount, warningCount))
+	}
+	if warningCount > 0 {
+		fmt.Fprintf(w, "✓ Config is valid (%d warning(s))\n", warningCount)
+	} else {
+		fmt.Fprintln(w, "✓ Config is valid")
+	}
+	return nil
+}
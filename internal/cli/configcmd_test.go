@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/config"
+)
+
+func TestFormatConfigIssues(t *testing.T) {
+	t.Run("clean", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := formatConfigIssues(&buf, nil); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if buf.String() != "✓ Config is valid\n" {
+			t.Errorf("output = %q", buf.String())
+		}
+	})
+
+	t.Run("warnings only", func(t *testing.T) {
+		var buf bytes.Buffer
+		issues := []config.Issue{{File: "conversations.json", Line: 3, Column: 5, Path: "conversations[0].mode", Message: `"mode" is deprecated and ignored`, Warning: true}}
+		if err := formatConfigIssues(&buf, issues); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		out := buf.String()
+		for _, want := range []string{"conversations.json:3:5: warning: conversations[0].mode:", "valid (1 warning(s))"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("output missing %q:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var buf bytes.Buffer
+		issues := []config.Issue{
+			{File: "settings.json", Message: "failed to parse settings"},
+			{File: "people.json", Warning: true, Message: "w"},
+		}
+		err := formatConfigIssues(&buf, issues)
+		if err == nil || !strings.Contains(err.Error(), "1 error(s), 1 warning(s)") {
+			t.Fatalf("error = %v, want counts", err)
+		}
+		if ExitCode(err) != ExitConfig {
+			t.Errorf("ExitCode() = %d, want %d", ExitCode(err), ExitConfig)
+		}
+		if strings.Contains(buf.String(), "✓") {
+			t.Errorf("output = %q, want no success line", buf.String())
+		}
+	})
+}
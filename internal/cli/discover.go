@@ -12,6 +12,7 @@ import (
 	"github.com/jflowers/get-out/pkg/chrome"
 	"github.com/jflowers/get-out/pkg/config"
 	"github.com/jflowers/get-out/pkg/models"
+	"github.com/jflowers/get-out/pkg/parser"
 	"github.com/jflowers/get-out/pkg/slackapi"
 	"github.com/spf13/cobra"
 )
@@ -29,6 +30,9 @@ and generates a people.json file with user details (name, email, display name).
 By default, it merges with any existing people.json, skipping users already present.
 Use --no-merge to overwrite the existing file.
 
+To fill conversations.json itself from the conversations you belong to in
+Slack, use 'get-out discover conversations'.
+
 Prerequisites:
   - Chrome/Chromium running with remote debugging enabled
   - An active Slack tab in the browser with an authenticated session
@@ -67,6 +71,11 @@ func runDiscover(cmd *cobra.Command, args []string) error {
 		cancel()
 	}()
 
+	settings, err := config.LoadSettings(filepath.Join(configDir, "settings.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+
 	// Load conversations config
 	configPath := filepath.Join(configDir, "conversations.json")
 	cfg, err := config.LoadConversations(configPath)
@@ -81,24 +90,11 @@ func runDiscover(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Found %d conversations in config\n", len(cfg.Conversations))
 
 	// Connect to Chrome and extract Slack credentials
-	fmt.Println("Connecting to Chrome...")
-	chromeCfg := chrome.DefaultConfig()
-	chromeCfg.DebugPort = chromePort
-	session, err := chrome.Connect(ctx, chromeCfg)
+	client, closeSession, err := connectBrowserSlack(ctx, settings)
 	if err != nil {
-		return fmt.Errorf("failed to connect to Chrome: %w", err)
-	}
-	defer session.Close()
-
-	fmt.Println("Extracting Slack credentials...")
-	creds, err := session.ExtractCredentials(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to extract credentials: %w", err)
+		return err
 	}
-	fmt.Printf("Found Slack team: %s\n\n", creds.TeamDomain)
-
-	// Create Slack client
-	client := slackapi.NewBrowserClient(creds.Token, creds.Cookie)
+	defer closeSession()
 
 	// Create spinner for interactive mode
 	var spin *StatusSpinner
@@ -152,7 +148,7 @@ func runDiscover(cmd *cobra.Command, args []string) error {
 	fmt.Println()
 
 	// Phase 3: Write people.json
-	count, err := writePeopleJSON(peoplePath, fetchedUsers, existingPeople, merge)
+	count, err := writePeopleJSON(peoplePath, fetchedUsers, existingPeople, merge, settings.NamePolicy)
 	if err != nil {
 		return err
 	}
@@ -165,6 +161,32 @@ func runDiscover(cmd *cobra.Command, args []string) error {
 // Extracted functions
 // ---------------------------------------------------------------------------
 
+// connectBrowserSlack connects to Chrome, extracts the Slack session's
+// credentials, and returns a client using them. The returned function
+// closes the Chrome session.
+func connectBrowserSlack(ctx context.Context, settings *config.Settings) (*slackapi.Client, func(), error) {
+	fmt.Println("Connecting to Chrome...")
+	chromeCfg := chrome.DefaultConfig()
+	chromeCfg.DebugPort = chromePort
+	chromeCfg.OpenSlackURL = slackTabURL(settings)
+	chromeCfg.KeepOpenedTab = keepSlackTab
+	chromeCfg.PreferTeam = preferredSlackTeam(settings)
+	session, err := chrome.Connect(ctx, chromeCfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to Chrome: %w", err)
+	}
+
+	fmt.Println("Extracting Slack credentials...")
+	creds, err := session.ExtractCredentialsForTeam(ctx, resolveSlackTeam(slackTeam, settings))
+	if err != nil {
+		session.Close()
+		return nil, nil, fmt.Errorf("failed to extract credentials: %w", err)
+	}
+	fmt.Printf("Found Slack team: %s\n\n", creds.TeamDomain)
+
+	return slackapi.NewBrowserClient(creds.Token, creds.Cookie), session.Close, nil
+}
+
 // makeSpinnerProgress returns a progress callback that updates the spinner if
 // available, or is a no-op otherwise.
 func makeSpinnerProgress(spin *StatusSpinner) func(string) {
@@ -280,9 +302,9 @@ func loadExistingPeople(peoplePath string, merge bool) map[string]config.PersonC
 }
 
 // writePeopleJSON converts fetched users to PersonConfig, merges with existing
-// people if needed, and writes the result to disk.
-func writePeopleJSON(path string, fetchedUsers []*slackapi.User, existingPeople map[string]config.PersonConfig, merge bool) (int, error) {
-	newPeople := buildPeopleFromUsers(fetchedUsers)
+// people if needed, and writes the result to disk. Display names follow policy.
+func writePeopleJSON(path string, fetchedUsers []*slackapi.User, existingPeople map[string]config.PersonConfig, merge bool, policy config.NamePolicy) (int, error) {
+	newPeople := buildPeopleFromUsers(fetchedUsers, policy)
 
 	var existingList []config.PersonConfig
 	if merge {
@@ -292,7 +314,7 @@ func writePeopleJSON(path string, fetchedUsers []*slackapi.User, existingPeople
 	}
 	people := mergePeople(newPeople, existingList)
 
-	peopleCfg := config.PeopleConfig{People: people}
+	peopleCfg := config.PeopleConfig{Version: config.PeopleMigrations.Current(), People: people}
 	data, err := json.MarshalIndent(peopleCfg, "", "  ")
 	if err != nil {
 		return 0, fmt.Errorf("failed to marshal people config: %w", err)
@@ -306,8 +328,9 @@ func writePeopleJSON(path string, fetchedUsers []*slackapi.User, existingPeople
 }
 
 // buildPeopleFromUsers converts Slack user info to PersonConfig entries,
-// filtering out bots, app users, and deleted users.
-func buildPeopleFromUsers(users []*slackapi.User) []config.PersonConfig {
+// filtering out bots, app users, and deleted users. Display names are
+// formatted with the given name policy.
+func buildPeopleFromUsers(users []*slackapi.User, policy config.NamePolicy) []config.PersonConfig {
 	var people []config.PersonConfig
 	for _, user := range users {
 		if user.IsBot || user.IsAppUser || user.Deleted {
@@ -316,7 +339,7 @@ func buildPeopleFromUsers(users []*slackapi.User) []config.PersonConfig {
 		people = append(people, config.PersonConfig{
 			SlackID:     user.ID,
 			Email:       user.Profile.Email,
-			DisplayName: user.GetDisplayName(),
+			DisplayName: parser.FormatUserName(user, policy),
 		})
 	}
 	return people
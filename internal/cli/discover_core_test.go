@@ -20,7 +20,7 @@ func TestWritePeopleJSON_NoMerge(t *testing.T) {
 		{ID: "U2", Name: "bob", Profile: slackapi.UserProfile{DisplayName: "Bob"}},
 	}
 
-	count, err := writePeopleJSON(path, users, nil, false)
+	count, err := writePeopleJSON(path, users, nil, false, "")
 	if err != nil {
 		t.Fatalf("writePeopleJSON: %v", err)
 	}
@@ -57,7 +57,7 @@ func TestWritePeopleJSON_WithMerge(t *testing.T) {
 		{ID: "U1", Name: "alice", Profile: slackapi.UserProfile{DisplayName: "Alice"}},
 	}
 
-	count, err := writePeopleJSON(path, users, existing, true)
+	count, err := writePeopleJSON(path, users, existing, true, "")
 	if err != nil {
 		t.Fatalf("writePeopleJSON: %v", err)
 	}
@@ -77,7 +77,7 @@ func TestWritePeopleJSON_FiltersBots(t *testing.T) {
 		{ID: "D1", Name: "deleted", Deleted: true},
 	}
 
-	count, err := writePeopleJSON(path, users, nil, false)
+	count, err := writePeopleJSON(path, users, nil, false, "")
 	if err != nil {
 		t.Fatalf("writePeopleJSON: %v", err)
 	}
@@ -90,7 +90,7 @@ func TestWritePeopleJSON_EmptyUsers(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "people.json")
 
-	count, err := writePeopleJSON(path, nil, nil, false)
+	count, err := writePeopleJSON(path, nil, nil, false, "")
 	if err != nil {
 		t.Fatalf("writePeopleJSON: %v", err)
 	}
@@ -16,7 +16,7 @@ func TestBuildPeopleFromUsers_FiltersBotsAndDeleted(t *testing.T) {
 		{ID: "U005", Name: "bob", Profile: slackapi.UserProfile{Email: "bob@example.com", RealName: "Bob Smith"}},
 	}
 
-	result := buildPeopleFromUsers(users)
+	result := buildPeopleFromUsers(users, "")
 
 	if len(result) != 2 {
 		t.Fatalf("expected 2 people (filtered bots/deleted), got %d", len(result))
@@ -42,12 +42,12 @@ func TestBuildPeopleFromUsers_FiltersBotsAndDeleted(t *testing.T) {
 }
 
 func TestBuildPeopleFromUsers_Empty(t *testing.T) {
-	result := buildPeopleFromUsers(nil)
+	result := buildPeopleFromUsers(nil, "")
 	if result != nil {
 		t.Errorf("expected nil for empty input, got %v", result)
 	}
 
-	result = buildPeopleFromUsers([]*slackapi.User{})
+	result = buildPeopleFromUsers([]*slackapi.User{}, "")
 	if result != nil {
 		t.Errorf("expected nil for empty slice, got %v", result)
 	}
@@ -59,7 +59,7 @@ func TestBuildPeopleFromUsers_Normal(t *testing.T) {
 		{ID: "U011", Name: "dave", Profile: slackapi.UserProfile{Email: "dave@example.com", DisplayName: "Dave"}},
 	}
 
-	result := buildPeopleFromUsers(users)
+	result := buildPeopleFromUsers(users, "")
 
 	if len(result) != 2 {
 		t.Fatalf("expected 2 people, got %d", len(result))
@@ -69,6 +69,18 @@ func TestBuildPeopleFromUsers_Normal(t *testing.T) {
 	}
 }
 
+func TestBuildPeopleFromUsers_NamePolicy(t *testing.T) {
+	users := []*slackapi.User{
+		{ID: "U012", Name: "jdoe", Profile: slackapi.UserProfile{RealName: "Jane Doe", DisplayName: "jd"}},
+	}
+
+	result := buildPeopleFromUsers(users, config.NamePolicyBoth)
+
+	if len(result) != 1 || result[0].DisplayName != "Jane Doe (@jd)" {
+		t.Errorf("result = %+v, want DisplayName %q", result, "Jane Doe (@jd)")
+	}
+}
+
 func TestMergePeople_NoExisting(t *testing.T) {
 	newPeople := []config.PersonConfig{
 		{SlackID: "U001", DisplayName: "Alice"},
@@ -0,0 +1,239 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/exporter"
+	"github.com/jflowers/get-out/pkg/models"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/spf13/cobra"
+)
+
+var (
+	discoverConvTypes       []string
+	discoverConvActiveSince string
+	discoverConvMember      string
+	discoverConvNoExport    bool
+	discoverConvDryRun      bool
+)
+
+var discoverConversationsCmd = &cobra.Command{
+	Use:   "conversations",
+	Short: "Add the Slack conversations you belong to to conversations.json",
+	Long: `List the DMs, group DMs, and channels you belong to in Slack and add the
+ones missing from conversations.json, so you don't have to look up IDs by hand.
+DMs are named after the other person (from people.json when present), and group
+DMs after their members.
+
+Entries already in conversations.json, by ID or alias, are left as they are, and
+new entries are appended with export set to true (false with --no-export).
+If conversations.json does not exist yet, it is created.
+
+Filter what is added with --type, --active-since (skip conversations without a
+message since a date), and --member (only conversations a given user is in).
+--active-since costs one Slack request per conversation, and --member one per
+group DM or channel.
+
+Prerequisites:
+  - Chrome/Chromium running with remote debugging enabled
+  - An active Slack tab in the browser with an authenticated session`,
+	Example: `  # Add every DM, group DM, and channel you belong to
+  get-out discover conversations
+
+  # Preview DMs and group DMs active this year without writing
+  get-out discover conversations --type dm,mpim --active-since 2026-01-01 --dry-run
+
+  # Add the channels a teammate is in, to be enabled by hand
+  get-out discover conversations --type channel,private_channel --member U01ABC2DEF --no-export`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runDiscoverConversations,
+}
+
+func init() {
+	discoverConversationsCmd.Flags().StringSliceVar(&discoverConvTypes, "type", nil, "Conversation types to add: dm, mpim, channel, private_channel (default all)")
+	discoverConversationsCmd.Flags().StringVar(&discoverConvActiveSince, "active-since", "", "Skip conversations without a message since this date (YYYY-MM-DD)")
+	discoverConversationsCmd.Flags().StringVar(&discoverConvMember, "member", "", "Only add conversations this Slack user ID belongs to")
+	discoverConversationsCmd.Flags().BoolVar(&discoverConvNoExport, "no-export", false, "Add new entries with export set to false")
+	discoverConversationsCmd.Flags().BoolVar(&discoverConvDryRun, "dry-run", false, "List the conversations that would be added without writing conversations.json")
+	discoverCmd.AddCommand(discoverConversationsCmd)
+}
+
+func runDiscoverConversations(cmd *cobra.Command, args []string) error {
+	types, err := parseConversationTypes(discoverConvTypes)
+	if err != nil {
+		return err
+	}
+	activeSince, err := parseDateFlag(discoverConvActiveSince)
+	if err != nil {
+		return &usageError{err: fmt.Errorf("invalid --active-since: %w", err)}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		fmt.Println("\nInterrupt received, stopping...")
+		cancel()
+	}()
+
+	settings, err := config.LoadSettings(filepath.Join(configDir, "settings.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	configPath := filepath.Join(configDir, "conversations.json")
+	var known []config.ConversationConfig
+	if cfg, err := config.LoadConversations(configPath); err == nil {
+		known = cfg.Conversations
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to load conversations config: %w", err)
+	}
+
+	client, closeSession, err := connectBrowserSlack(ctx, settings)
+	if err != nil {
+		return err
+	}
+	defer closeSession()
+
+	users := parser.NewUserResolver()
+	users.SetNamePolicy(settings.NamePolicy)
+	var people *parser.PersonResolver
+	if p, err := config.LoadPeople(filepath.Join(configDir, "people.json")); err == nil {
+		people = parser.NewPersonResolver(p)
+	}
+
+	var spin *StatusSpinner
+	if isTerminal() {
+		spin = NewStatusSpinner()
+		spin.Start()
+		spin.Update("Listing Slack conversations...")
+	}
+	found, err := exporter.DiscoverConversations(ctx, client, known, exporter.ConversationDiscoveryOptions{
+		Types:       types,
+		ActiveSince: activeSince,
+		Member:      discoverConvMember,
+		Users:       users,
+		People:      people,
+	})
+	if spin != nil {
+		spin.Stop()
+	}
+	if err != nil {
+		return err
+	}
+
+	for i := range found {
+		found[i].Export = !discoverConvNoExport
+	}
+	formatDiscoveredConversations(os.Stdout, found)
+	if len(found) == 0 {
+		return nil
+	}
+	if discoverConvDryRun {
+		fmt.Printf("\nDry run: %s was not changed.\n", configPath)
+		return nil
+	}
+	if err := appendConversationsJSON(configPath, found); err != nil {
+		return err
+	}
+	fmt.Printf("\nAdded %d conversations to %s\n", len(found), configPath)
+	return nil
+}
+
+// parseConversationTypes validates --type values.
+func parseConversationTypes(names []string) ([]models.ConversationType, error) {
+	var types []models.ConversationType
+	for _, name := range names {
+		t := models.ConversationType(name)
+		switch t {
+		case models.ConversationTypeDM, models.ConversationTypeMPIM, models.ConversationTypeChannel, models.ConversationTypePrivateChannel:
+			types = append(types, t)
+		default:
+			return nil, fmt.Errorf("invalid --type %q: must be dm, mpim, channel, or private_channel", name)
+		}
+	}
+	return types, nil
+}
+
+// formatDiscoveredConversations lists the conversations found in Slack.
+func formatDiscoveredConversations(w io.Writer, found []config.ConversationConfig) {
+	if len(found) == 0 {
+		fmt.Fprintln(w, "Discovered no conversations missing from conversations.json")
+		return
+	}
+	fmt.Fprintf(w, "Discovered %d conversations not in conversations.json:\n", len(found))
+	for _, c := range found {
+		fmt.Fprintf(w, "  %-16s %-12s %s\n", c.Type, c.ID, c.Name)
+	}
+}
+
+// discoveredEntry is a conversations.json entry written by discover
+// conversations. Fields it leaves out take their defaults from
+// settings.json's conversationDefaults.
+type discoveredEntry struct {
+	ID     string                  `json:"id"`
+	Name   string                  `json:"name"`
+	Type   models.ConversationType `json:"type"`
+	Export bool                    `json:"export"`
+}
+
+// appendConversationsJSON appends found to the conversations.json at path,
+// creating it if needed. Existing entries and other top-level fields are
+// kept verbatim, so fields they leave unset still take their defaults.
+func appendConversationsJSON(path string, found []config.ConversationConfig) error {
+	doc := make(map[string]json.RawMessage)
+	var entries []json.RawMessage
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("failed to parse conversations config: %w", err)
+		}
+		if raw, ok := doc["conversations"]; ok {
+			if err := json.Unmarshal(raw, &entries); err != nil {
+				return fmt.Errorf("failed to parse conversations config: %w", err)
+			}
+		}
+	case errors.Is(err, fs.ErrNotExist):
+		version, _ := json.Marshal(config.ConversationsMigrations.Current())
+		doc["version"] = version
+	default:
+		return fmt.Errorf("failed to read conversations config: %w", err)
+	}
+
+	for _, c := range found {
+		entry, err := json.Marshal(discoveredEntry{ID: c.ID, Name: c.Name, Type: c.Type, Export: c.Export})
+		if err != nil {
+			return fmt.Errorf("failed to marshal conversation %s: %w", c.ID, err)
+		}
+		entries = append(entries, entry)
+	}
+	raw, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal conversations: %w", err)
+	}
+	doc["conversations"] = raw
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal conversations config: %w", err)
+	}
+	if err := os.WriteFile(path, append(out, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write conversations.json: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/config"
+)
+
+func TestParseConversationTypes(t *testing.T) {
+	types, err := parseConversationTypes([]string{"dm", "private_channel"})
+	if err != nil || len(types) != 2 || types[1] != "private_channel" {
+		t.Errorf("parseConversationTypes() = %v, %v", types, err)
+	}
+	if types, err := parseConversationTypes(nil); err != nil || types != nil {
+		t.Errorf("parseConversationTypes(nil) = %v, %v, want all types", types, err)
+	}
+	if _, err := parseConversationTypes([]string{"group"}); err == nil {
+		t.Error("parseConversationTypes(group): want error")
+	}
+}
+
+func TestAppendConversationsJSON(t *testing.T) {
+	found := []config.ConversationConfig{
+		{ID: "D010", Name: "carol", Type: "dm", Export: true},
+		{ID: "C100", Name: "general", Type: "channel"},
+	}
+
+	t.Run("merges into existing file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "conversations.json")
+		existing := `{
+  "$schema": "./conversations.schema.json",
+  "conversations": [
+    {"id": "D001", "name": "alice", "type": "dm", "export": true, "layout": "year"}
+  ]
+}`
+		if err := os.WriteFile(path, []byte(existing), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := appendConversationsJSON(path, found); err != nil {
+			t.Fatalf("appendConversationsJSON() error: %v", err)
+		}
+		data, _ := os.ReadFile(path)
+		if !strings.Contains(string(data), `"$schema"`) {
+			t.Errorf("top-level fields were dropped:\n%s", data)
+		}
+		if strings.Count(string(data), `"share"`) != 0 {
+			t.Errorf("unset fields were written, overriding conversationDefaults:\n%s", data)
+		}
+		cfg, err := config.LoadConversations(path)
+		if err != nil {
+			t.Fatalf("LoadConversations() error: %v", err)
+		}
+		if len(cfg.Conversations) != 3 || cfg.Conversations[0].Layout != "year" {
+			t.Fatalf("conversations = %+v, want alice kept and two appended", cfg.Conversations)
+		}
+		if c := cfg.GetByID("C100"); c == nil || c.Name != "general" || c.Export {
+			t.Errorf("C100 = %+v, want an unexported channel named general", c)
+		}
+	})
+
+	t.Run("creates missing file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "conversations.json")
+		if err := appendConversationsJSON(path, found); err != nil {
+			t.Fatalf("appendConversationsJSON() error: %v", err)
+		}
+		cfg, err := config.LoadConversations(path)
+		if err != nil {
+			t.Fatalf("LoadConversations() error: %v", err)
+		}
+		if len(cfg.Conversations) != 2 || cfg.Version != config.ConversationsMigrations.Current() {
+			t.Errorf("config = %+v, want two conversations at the current version", cfg)
+		}
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "conversations.json")
+		if err := os.WriteFile(path, []byte("bad{json"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := appendConversationsJSON(path, found); err == nil {
+			t.Error("appendConversationsJSON() on invalid JSON: want error")
+		}
+	})
+}
+
+func TestFormatDiscoveredConversations(t *testing.T) {
+	var buf bytes.Buffer
+	formatDiscoveredConversations(&buf, nil)
+	if !strings.Contains(buf.String(), "no conversations") {
+		t.Errorf("empty output = %q", buf.String())
+	}
+
+	buf.Reset()
+	formatDiscoveredConversations(&buf, []config.ConversationConfig{{ID: "D010", Name: "carol", Type: "dm"}})
+	if !strings.Contains(buf.String(), "Discovered 1 conversations") || !strings.Contains(buf.String(), "D010") || !strings.Contains(buf.String(), "carol") {
+		t.Errorf("output = %q", buf.String())
+	}
+}
@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/exporter"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/docs/v1"
+)
+
+var (
+	docRequestsRawDir     string
+	docRequestsOutput     string
+	docRequestsStartIndex int64
+)
+
+var docRequestsCmd = &cobra.Command{
+	Use:   "doc-requests <conversation_id> <YYYY-MM-DD>",
+	Short: "Print the Google Docs requests export would send for one day",
+	Long: `Print, as JSON, the Google Docs batchUpdate request that 'get-out export'
+would send to write one conversation's messages for a single day.
+
+Messages come from the raw archive saved by 'get-out export --raw', and links
+to other docs and threads come from the export index. Nothing is sent to Slack
+or Google, so formatting changes can be iterated on without spending API quota.
+Images are shown as [File: ...] references, since embedding them requires a
+Drive upload.`,
+	Example: `  # Print the requests for a day
+  get-out doc-requests C789DEF012 2026-04-20
+
+  # Save them to a file, as if appending to a doc whose body ends at index 120
+  get-out doc-requests C789DEF012 2026-04-20 --start-index 120 -o requests.json`,
+	Args:              cobra.ExactArgs(2),
+	SilenceUsage:      true,
+	RunE:              runDocRequests,
+	ValidArgsFunction: completeFirstConversationID,
+}
+
+func init() {
+	docRequestsCmd.Flags().StringVar(&docRequestsRawDir, "raw-dir", "", "Raw archive directory (default: <config-dir>/_raw)")
+	docRequestsCmd.Flags().StringVarP(&docRequestsOutput, "output", "o", "", "Write the JSON to this file instead of stdout")
+	docRequestsCmd.Flags().Int64Var(&docRequestsStartIndex, "start-index", 1, "Document end index to append at (1 = new, empty doc)")
+	rootCmd.AddCommand(docRequestsCmd)
+}
+
+func runDocRequests(cmd *cobra.Command, args []string) error {
+	convID, date := args[0], args[1]
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return fmt.Errorf("invalid date %q: expected YYYY-MM-DD", date)
+	}
+	if docRequestsStartIndex < 1 {
+		return fmt.Errorf("--start-index must be at least 1")
+	}
+
+	settings, err := config.LoadSettings(filepath.Join(configDir, "settings.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+	applyTimeSettings(settings)
+
+	cfg, err := config.LoadConversations(filepath.Join(configDir, "conversations.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	cfg.ApplyDefaults(settings.ConversationDefaults)
+	conv := cfg.GetByID(convID)
+	if conv == nil {
+		return fmt.Errorf("conversation not found in config: %s", convID)
+	}
+
+	rawDir := docRequestsRawDir
+	if rawDir == "" {
+		rawDir = exporter.DefaultRawDir(configDir)
+	}
+
+	var personResolver *parser.PersonResolver
+	if people, err := config.LoadPeople(filepath.Join(configDir, "people.json")); err == nil {
+		personResolver = parser.NewPersonResolver(people)
+	}
+
+	index, err := exporter.LoadExportIndex(exporter.DefaultIndexPath(configDir))
+	if err != nil {
+		return fmt.Errorf("failed to load export index: %w", err)
+	}
+
+	renderer := exporter.NewRenderer(&exporter.RendererConfig{
+		RawDir:         rawDir,
+		PersonResolver: personResolver,
+		NamePolicy:     settings.NamePolicy,
+	})
+	if !renderer.HasRawArchive(*conv) {
+		return fmt.Errorf("no raw archive for %s (%s)\n\nRun 'get-out export --raw' first", conv.Name, conv.ID)
+	}
+	if err := renderer.LoadWorkspace(); err != nil {
+		return fmt.Errorf("failed to load raw workspace data: %w", err)
+	}
+
+	req, err := renderer.DocRequests(context.Background(), *conv, date, docRequestsStartIndex, index)
+	if err != nil {
+		return err
+	}
+
+	if docRequestsOutput == "" {
+		return writeDocRequests(os.Stdout, req)
+	}
+	f, err := os.Create(docRequestsOutput)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	if err := writeDocRequests(f, req); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	fmt.Printf("Wrote %d requests to %s\n", len(req.Requests), docRequestsOutput)
+	return nil
+}
+
+// writeDocRequests writes req as indented JSON.
+func writeDocRequests(w io.Writer, req *docs.BatchUpdateDocumentRequest) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(req); err != nil {
+		return fmt.Errorf("failed to write requests: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"google.golang.org/api/docs/v1"
+)
+
+func TestWriteDocRequests(t *testing.T) {
+	req := &docs.BatchUpdateDocumentRequest{Requests: []*docs.Request{
+		{InsertText: &docs.InsertTextRequest{Location: &docs.Location{Index: 1}, Text: "Alice  9:00 AM\n"}},
+	}}
+
+	var buf bytes.Buffer
+	if err := writeDocRequests(&buf, req); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded docs.BatchUpdateDocumentRequest
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if len(decoded.Requests) != 1 || decoded.Requests[0].InsertText.Text != "Alice  9:00 AM\n" {
+		t.Errorf("decoded = %+v", decoded.Requests)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("\n  \"requests\"")) {
+		t.Errorf("output should be indented:\n%s", buf.String())
+	}
+}
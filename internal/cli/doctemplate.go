@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/gdrive"
+	"github.com/spf13/cobra"
+)
+
+// docTemplateTitle is the title of the doc created by 'get-out doc-template'.
+const docTemplateTitle = "Daily Doc Template"
+
+var docTemplateCmd = &cobra.Command{
+	Use:   "doc-template",
+	Short: "Create a Google Doc to use as the template for new daily docs",
+	Long: `Create an empty Google Doc to design the look of new daily docs in, and print
+the docTemplate setting that uses it.
+
+With docTemplate set, 'get-out export' creates each new daily doc as a copy of
+the template, so it starts with the template's headers, footers, logo, page
+setup, and paragraph styles, and messages are appended after its content.
+
+get-out only has access to the Drive files it creates, so it cannot copy a doc
+made by hand. Create the template with this command, then edit it in Google Docs.
+The doc is created in the export folder (folder_id), or in My Drive without one.`,
+	Example: `  # Create the template, then add its URL to settings.json as docTemplate
+  get-out doc-template`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runDocTemplate,
+}
+
+func init() {
+	rootCmd.AddCommand(docTemplateCmd)
+}
+
+func runDocTemplate(cmd *cobra.Command, args []string) error {
+	settings, err := config.LoadSettings(filepath.Join(configDir, "settings.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	ctx := context.Background()
+	client, err := newDriveClient(ctx, settings)
+	if err != nil {
+		return err
+	}
+	doc, err := client.CreateDocument(ctx, docTemplateTitle, resolveExportFolderID("", settings))
+	if err != nil {
+		return err
+	}
+	formatDocTemplateResult(os.Stdout, doc, settings.DocTemplate)
+	return nil
+}
+
+// formatDocTemplateResult tells the user how to edit and use the new
+// template doc, noting a template already configured.
+func formatDocTemplateResult(w io.Writer, doc *gdrive.DocInfo, current string) {
+	fmt.Fprintf(w, "Created %q: %s\n\n", doc.Title, doc.URL)
+	fmt.Fprintln(w, "Edit it in Google Docs, then add to settings.json:")
+	fmt.Fprintf(w, "  \"docTemplate\": %q\n", doc.ID)
+	if current != "" {
+		fmt.Fprintf(w, "\nThis replaces the current docTemplate, %s.\n", current)
+	}
+}
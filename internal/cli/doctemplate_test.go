@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/gdrive"
+)
+
+func TestFormatDocTemplateResult(t *testing.T) {
+	doc := &gdrive.DocInfo{ID: "doc-1", Title: docTemplateTitle, URL: "https://docs.google.com/document/d/doc-1/edit"}
+	tests := []struct {
+		name    string
+		current string
+		want    []string
+		notWant string
+	}{
+		{"first template", "", []string{doc.URL, `"docTemplate": "doc-1"`}, "replaces"},
+		{"replacing a template", "old-doc", []string{`"docTemplate": "doc-1"`, "replaces the current docTemplate, old-doc"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			formatDocTemplateResult(&buf, doc, tt.current)
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("output missing %q:\n%s", want, buf.String())
+				}
+			}
+			if tt.notWant != "" && strings.Contains(buf.String(), tt.notWant) {
+				t.Errorf("output contains %q:\n%s", tt.notWant, buf.String())
+			}
+		})
+	}
+}
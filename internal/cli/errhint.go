@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/jflowers/get-out/pkg/errcat"
+)
+
+// printErrorHint writes the remediation hint, README link, and stable code
+// for err when it matches the error catalog. Cobra has already printed the
+// error itself.
+func printErrorHint(w io.Writer, err error) {
+	e := errcat.Classify(err)
+	if e == nil {
+		return
+	}
+	if e.Hint != "" {
+		fmt.Fprintf(w, "Hint: %s\n", e.Hint)
+	}
+	if url := e.DocsURL(); url != "" {
+		fmt.Fprintf(w, "See: %s\n", url)
+	}
+	fmt.Fprintf(w, "Error code: %s\n", e.Code)
+}
@@ -0,0 +1,28 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/errcat"
+)
+
+func TestPrintErrorHint(t *testing.T) {
+	var buf bytes.Buffer
+	printErrorHint(&buf, fmt.Errorf("initialization failed: %w", errcat.Wrap(errcat.ChromeUnavailable, errors.New("refused"))))
+	output := buf.String()
+	for _, want := range []string{"Hint: ", "setup-browser", "See: " + errcat.DocsBaseURL + "chrome_unavailable", "Error code: CHROME_UNAVAILABLE"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+
+	buf.Reset()
+	printErrorHint(&buf, errors.New("something else"))
+	if buf.Len() != 0 {
+		t.Errorf("uncatalogued error printed %q, want nothing", buf.String())
+	}
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"errors"
+
+	"github.com/jflowers/get-out/pkg/errcat"
+)
+
+// Exit codes of get-out. They are part of the CLI contract so scripts can
+// branch on the result without parsing output; do not renumber them.
+const (
+	// ExitOK means the command completed successfully.
+	ExitOK = 0
+	// ExitFailure is any failure without a more specific code.
+	ExitFailure = 1
+	// ExitConfig means a config file or command-line flag is invalid.
+	ExitConfig = 2
+	// ExitAuth means Slack or Google credentials are missing or rejected.
+	ExitAuth = 3
+	// ExitPartial means the run finished but some conversations failed.
+	ExitPartial = 4
+	// ExitRateLimited means the run was aborted by a Slack rate limit or a
+	// Google quota.
+	ExitRateLimited = 5
+)
+
+// usageError is a command-line flag error reported by cobra.
+type usageError struct {
+	err error
+}
+
+func (e *usageError) Error() string { return e.err.Error() }
+
+func (e *usageError) Unwrap() error { return e.err }
+
+// partialCode returns the catalog code for a run in which the conversations
+// with errs failed: the code most of them share when it is a rate limit,
+// quota, or credentials failure, which stops the rest of the run as well,
+// and ExportPartial otherwise.
+func partialCode(errs []error) errcat.Code {
+	counts := make(map[errcat.Code]int)
+	for _, err := range errs {
+		code := errcat.ExportPartial
+		if e := errcat.Classify(err); e != nil {
+			switch e.Code {
+			case errcat.SlackRateLimited, errcat.DocsQuota, errcat.SlackAuth, errcat.GoogleAuth:
+				code = e.Code
+			}
+		}
+		counts[code]++
+	}
+	dominant := errcat.ExportPartial
+	for _, code := range []errcat.Code{errcat.SlackRateLimited, errcat.DocsQuota, errcat.SlackAuth, errcat.GoogleAuth} {
+		if counts[code] > counts[dominant] {
+			dominant = code
+		}
+	}
+	return dominant
+}
+
+// ExitCode returns the process exit code for the error returned by Execute.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var ue *usageError
+	if errors.As(err, &ue) {
+		return ExitConfig
+	}
+	e := errcat.Classify(err)
+	if e == nil {
+		return ExitFailure
+	}
+	switch e.Code {
+	case errcat.ConfigInvalid:
+		return ExitConfig
+	case errcat.SlackAuth, errcat.GoogleAuth:
+		return ExitAuth
+	case errcat.ExportPartial:
+		return ExitPartial
+	case errcat.SlackRateLimited, errcat.DocsQuota:
+		return ExitRateLimited
+	}
+	return ExitFailure
+}
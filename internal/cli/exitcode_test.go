@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/errcat"
+	"github.com/jflowers/get-out/pkg/exporter"
+	"github.com/jflowers/get-out/pkg/slackapi"
+	"google.golang.org/api/googleapi"
+)
+
+func TestExitCode(t *testing.T) {
+	_, configErr := config.LoadConversations(filepath.Join(t.TempDir(), "conversations.json"))
+	partialResults := []*exporter.ExportResult{
+		{Name: "general", MessageCount: 10},
+		{Name: "random", Error: errors.New("boom")},
+	}
+	rateLimited := fmt.Errorf("history: %w", &slackapi.RateLimitError{})
+	rateLimitedResults := []*exporter.ExportResult{
+		{Name: "general", Error: rateLimited},
+		{Name: "random", Error: rateLimited},
+		{Name: "dev", Error: errors.New("boom")},
+	}
+	mixedResults := []*exporter.ExportResult{
+		{Name: "general", Error: rateLimited},
+		{Name: "random", Error: errors.New("boom")},
+		{Name: "dev", Error: errors.New("bang")},
+	}
+	flagErr := rootCmd.FlagErrorFunc()(rootCmd, errors.New("unknown flag: --bogus"))
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"success", nil, ExitOK},
+		{"uncatalogued", errors.New("disk full"), ExitFailure},
+		{"config file", fmt.Errorf("failed to load config: %w", configErr), ExitConfig},
+		{"flag", flagErr, ExitConfig},
+		{"slack auth", fmt.Errorf("init: %w", &slackapi.AuthError{Code: slackapi.ErrCodeInvalidAuth}), ExitAuth},
+		{"google auth", errcat.Wrap(errcat.GoogleAuth, errors.New("token expired")), ExitAuth},
+		{"partial", printExportResults(io.Discard, partialResults, "", false), ExitPartial},
+		{"mostly rate limited", printExportResults(io.Discard, rateLimitedResults, "", false), ExitRateLimited},
+		{"partly rate limited", printExportResults(io.Discard, mixedResults, "", false), ExitPartial},
+		{"slack rate limit", rateLimited, ExitRateLimited},
+		{"docs quota", fmt.Errorf("append: %w", &googleapi.Error{Code: 429}), ExitRateLimited},
+		{"chrome", errcat.Wrap(errcat.ChromeUnavailable, errors.New("refused")), ExitFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
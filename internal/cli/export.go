@@ -2,39 +2,87 @@ package cli
 
 import (
 	"context"
+	"crypto/ed25519"
 	"fmt"
 	"io"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/jflowers/get-out/pkg/chrome"
 	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/errcat"
 	"github.com/jflowers/get-out/pkg/exporter"
+	"github.com/jflowers/get-out/pkg/gdrive"
+	"github.com/jflowers/get-out/pkg/mailer"
 	"github.com/jflowers/get-out/pkg/models"
 	"github.com/jflowers/get-out/pkg/ollama"
+	"github.com/jflowers/get-out/pkg/parser"
 	"github.com/jflowers/get-out/pkg/secrets"
+	"github.com/jflowers/get-out/pkg/slackapi"
 	"github.com/spf13/cobra"
 )
 
 var (
-	exportFolder               string
-	exportFolderID             string
-	exportDryRun               bool
-	exportResume               bool
-	exportFrom                 string
-	exportTo                   string
-	exportSync                 bool
-	exportUserMapping          string
-	exportAllDMs               bool
-	exportAllGroups            bool
-	exportParallel             int
-	exportLocalExportDir       string
-	exportNoSensitivityFilter  bool
-	exportOllamaEndpoint       string
+	exportFolder              string
+	exportFolderID            string
+	exportDryRun              bool
+	exportResume              bool
+	exportFrom                string
+	exportTo                  string
+	exportSync                bool
+	exportUserMapping         string
+	exportAllDMs              bool
+	exportAllGroups           bool
+	exportDiscoverDMs         bool
+	exportDMMinMessages       int
+	exportDMActiveSince       string
+	exportAllChannels         bool
+	exportAllPrivateChannels  bool
+	exportIncludeNonMember    bool
+	exportYes                 bool
+	exportEstimate            bool
+	exportParallel            int
+	exportLocalExportDir      string
+	exportNoSensitivityFilter bool
+	exportOllamaEndpoint      string
+	exportMaxMessages         int
+	exportMaxNewDocs          int
+	exportNoEmailDigest       bool
+	exportDigestOnly          bool
+	exportNoTranslation       bool
+	exportRaw                 bool
+	exportDownloadFiles       bool
+	exportProfileStatus       bool
+	exportProvenance          bool
+	exportJSONRendered        bool
+	exportSkipEmojiMessages   bool
+	exportPrefetchUsers       bool
+	exportSample              int
+	exportFormat              string
+	exportTags                []string
+	exportEvery               time.Duration
+	exportHealthAddr          string
+	exportS3                  string
+	exportSlackMaxRetries     int
+	exportForce               bool
+	exportLaunchBrowser       bool
+	exportStatusAddr          string
+	exportMetricsAddr         string
+	exportActivity            bool
+	exportActivityKinds       []string
+
+	// exportStats collects live statistics for --status-addr (nil otherwise).
+	exportStats *exporter.RunStats
+
+	// exportMetrics collects Prometheus metrics for --metrics-addr (nil
+	// otherwise).
+	exportMetrics *exporter.Metrics
 )
 
 var exportCmd = &cobra.Command{
@@ -57,15 +105,25 @@ Examples:
   # Export specific conversations
   get-out export D123ABC456 C789DEF012
 
-  # Resume a previous export
+  # Finish an interrupted export: completed conversations are skipped,
+  # in-progress ones continue from their last checkpoint
   get-out export --resume
 
   # Dry run to see what would be exported
   get-out export --dry-run
 
+  # Keep a copy of every attachment alongside the messages
+  get-out export --download-files
+
+  # Also probe Slack for each conversation's size, largest first
+  get-out export --dry-run --estimate
+
   # Use custom Chrome port
   get-out export --chrome-port 9223
 
+  # Start Chrome with the get-out profile and wait for the Slack sign-in
+  get-out export --launch-browser
+
   # Export to an existing Google Drive folder by ID
   get-out export --folder-id 1ABC123xyz
 
@@ -79,30 +137,150 @@ Examples:
   get-out export --all-dms
   get-out export --all-groups
 
-  # Export in parallel (max 5 concurrent)
-  get-out export --parallel 5`,
-	RunE: runExport,
+  # Also export DMs missing from conversations.json that have at least
+  # 5 messages since the start of 2025
+  get-out export --discover-dms --dm-min-messages 5 --dm-active-since 2025-01-01
+
+  # Export every channel you are a member of, including ones missing from
+  # conversations.json (asks before exporting the ones found in Slack)
+  get-out export --all-channels --all-private-channels
+
+  # Export your mentions, reactions, and saved messages instead of
+  # conversations, into the Activity folder
+  get-out export --activity
+  get-out export --activity --activity-kinds mentions
+  get-out export --activity --activity-kinds pending
+  get-out export --activity --activity-kinds threads
+
+  # Export in parallel (max 5 concurrent requests)
+  get-out export --parallel 5
+
+  # Try settings on real data: stop after 200 messages or 5 new docs,
+  # then continue where it stopped with --sync
+  get-out export --max-messages 200 --max-new-docs 5
+  get-out export --sync
+
+  # Export only conversations tagged with 'get-out tag'
+  get-out export --tag legal-hold
+
+  # Skip the email digest configured in settings.json for this run
+  get-out export --sync --no-email-digest
+
+  # Only email the digest of messages posted since the last digest
+  get-out export --sync --digest-only
+
+  # Write every selected conversation as a static HTML site in the local
+  # export directory instead of Google Docs
+  get-out export --format html --local-export-dir ~/slack-archive
+
+  # Keep raw Slack responses so the export can be re-rendered later
+  get-out export --raw
+
+  # Check formatting and sharing on the newest 20 messages per conversation
+  # (written to a separate "(sample)" folder) before a full export
+  get-out export --sample 20
+
+  # Break the lock left by an export that crashed on another machine
+  get-out export --sync --force
+
+  # Follow a long export from a browser at http://localhost:8081/
+  get-out export --status-addr localhost:8081
+
+  # Let Prometheus scrape a multi-day export at http://<host>:9090/metrics
+  get-out export --metrics-addr :9090
+
+  # Run as a container sidecar: sync every hour, report health on :8080
+  GET_OUT_HEADLESS=1 get-out export --sync --every 1h --health-addr :8080`,
+	RunE:              runExport,
+	ValidArgsFunction: completeConversationIDs,
 }
 
 func init() {
 	exportCmd.Flags().StringVar(&exportFolder, "folder", "Slack Exports", "Google Drive root folder name (ignored if --folder-id is set)")
 	exportCmd.Flags().StringVar(&exportFolderID, "folder-id", "", "Google Drive folder ID to export into (uses existing folder)")
 	exportCmd.Flags().BoolVar(&exportDryRun, "dry-run", false, "Show what would be exported without actually exporting")
-	exportCmd.Flags().BoolVar(&exportResume, "resume", false, "Resume from last checkpoint")
+	exportCmd.Flags().BoolVar(&exportEstimate, "estimate", false, "Estimate each conversation's message count from Slack before exporting (with --dry-run, connects to Slack only)")
+	exportCmd.Flags().BoolVar(&exportResume, "resume", false, "Finish interrupted exports: skip completed conversations and continue in-progress ones from their checkpoint")
 	exportCmd.Flags().StringVar(&exportFrom, "from", "", "Export messages from this date (YYYY-MM-DD)")
 	exportCmd.Flags().StringVar(&exportTo, "to", "", "Export messages up to this date (YYYY-MM-DD)")
 	exportCmd.Flags().BoolVar(&exportSync, "sync", false, "Only export messages since last successful export")
 	exportCmd.Flags().StringVar(&exportUserMapping, "user-mapping", "", "Path to people.json for @mention linking (default: <config-dir>/people.json)")
 	exportCmd.Flags().BoolVar(&exportAllDMs, "all-dms", false, "Export all DM conversations")
 	exportCmd.Flags().BoolVar(&exportAllGroups, "all-groups", false, "Export all group (MPIM) conversations")
-	exportCmd.Flags().IntVar(&exportParallel, "parallel", 1, "Number of conversations to export concurrently (max 5)")
+	exportCmd.Flags().BoolVar(&exportDiscoverDMs, "discover-dms", false, "Export all DMs, including ones found in Slack that are not in conversations.json")
+	exportCmd.Flags().IntVar(&exportDMMinMessages, "dm-min-messages", 1, "With --discover-dms, skip discovered DMs with fewer messages than this")
+	exportCmd.Flags().StringVar(&exportDMActiveSince, "dm-active-since", "", "With --discover-dms, only count messages since this date (YYYY-MM-DD), skipping DMs dormant since then")
+	exportCmd.Flags().BoolVar(&exportAllChannels, "all-channels", false, "Export all public channels you are a member of, including ones found in Slack that are not in conversations.json")
+	exportCmd.Flags().BoolVar(&exportAllPrivateChannels, "all-private-channels", false, "Export all private channels you are a member of, including ones found in Slack that are not in conversations.json")
+	exportCmd.Flags().BoolVar(&exportIncludeNonMember, "include-non-member", false, "With --all-channels, also export public channels you have not joined")
+	exportCmd.Flags().BoolVarP(&exportYes, "yes", "y", false, "Export channels found by --all-channels or --all-private-channels without asking")
+	exportCmd.Flags().BoolVar(&exportActivity, "activity", false, "Export your activity (messages mentioning you, messages you reacted to, saved messages, threads you follow, scheduled messages and reminders) instead of conversations")
+	exportCmd.Flags().StringSliceVar(&exportActivityKinds, "activity-kinds", activityKindNames(), "With --activity, the feeds to export (mentions, reactions, saved, threads, pending)")
+	exportCmd.Flags().IntVar(&exportSlackMaxRetries, "slack-max-retries", slackapi.DefaultMaxRetries, "Times a Slack request is retried after a rate limit or server error (0 disables; overrides slackMaxRetries in settings.json)")
+	exportCmd.Flags().IntVar(&exportParallel, "parallel", 1, "Number of Slack requests to make concurrently: conversations, thread replies, and file downloads (max 5)")
 	exportCmd.Flags().StringVar(&exportLocalExportDir, "local-export-dir", "", "Directory for local markdown export (overrides settings)")
 	exportCmd.Flags().BoolVar(&exportNoSensitivityFilter, "no-sensitivity-filter", false, "Disable sensitivity filtering for this run")
 	exportCmd.Flags().StringVar(&exportOllamaEndpoint, "ollama-endpoint", "", "Override Ollama endpoint URL")
+	exportCmd.Flags().IntVar(&exportMaxMessages, "max-messages", 0, "Stop after writing this many messages in this run (0 = unlimited)")
+	exportCmd.Flags().IntVar(&exportMaxNewDocs, "max-new-docs", 0, "Stop after creating this many new daily docs in this run (0 = unlimited)")
+	exportCmd.Flags().BoolVar(&exportNoEmailDigest, "no-email-digest", false, "Disable the email digest for this run")
+	exportCmd.Flags().BoolVar(&exportDigestOnly, "digest-only", false, "Only send the email digest of new messages, writing no docs or files (tracked apart from the export)")
+	exportCmd.Flags().BoolVar(&exportNoTranslation, "no-translation", false, "Disable message translation for this run")
+	exportCmd.Flags().BoolVar(&exportRaw, "raw", false, "Also archive every raw Slack API response (gzip JSONL per conversation)")
+	exportCmd.Flags().BoolVar(&exportDownloadFiles, "download-files", false, "Save message attachments with the export (a files/ directory locally, a Files folder on Drive) and link to the saved copies")
+	exportCmd.Flags().BoolVar(&exportProfileStatus, "include-profile-status", false, "Keep users' status, presence, and do-not-disturb details in users.json and the raw archive")
+	exportCmd.Flags().BoolVar(&exportProvenance, "provenance", false, "Append a provenance line to each day written: when it was fetched, from which Slack credential, and the get-out version (also provenance in settings.json)")
+	exportCmd.Flags().BoolVar(&exportJSONRendered, "json-rendered", false, "Add each message's rendered text and its mentions, links, and emoji to json day files, beside the raw mrkdwn (also jsonRendered in settings.json)")
+	exportCmd.Flags().BoolVar(&exportSkipEmojiMessages, "skip-emoji-messages", false, "Leave out messages that are only emoji or a GIF, which are otherwise shown on one line (also skipEmojiMessages in settings.json)")
+	exportCmd.Flags().BoolVar(&exportPrefetchUsers, "prefetch-users", false, "Fetch every member of the exported conversations up front instead of looking users up as messages mention them")
+	exportCmd.Flags().StringSliceVar(&exportTags, "tag", nil, "Only export conversations with any of these tags (repeatable, see 'get-out tag')")
+	exportCmd.Flags().DurationVar(&exportEvery, "every", 0, "Run again at this interval until stopped (e.g. 1h), for containers without cron")
+	exportCmd.Flags().StringVar(&exportHealthAddr, "health-addr", "", "Serve run health as JSON at http://<addr>/healthz (e.g. :8080)")
+	exportCmd.Flags().StringVar(&exportS3, "s3", "", "Upload the local export directory to S3 after the run (s3://bucket/prefix)")
+	exportCmd.Flags().StringVar(&exportStatusAddr, "status-addr", "", "Serve live run status at http://<addr>/ (page) and /status (JSON), e.g. localhost:8081")
+	exportCmd.Flags().StringVar(&exportMetricsAddr, "metrics-addr", "", "Serve Prometheus metrics at http://<addr>/metrics (e.g. :9090)")
+	exportCmd.Flags().BoolVar(&exportLaunchBrowser, "launch-browser", false, "Start Chrome with get-out's profile if it is not running on --chrome-port, and wait for the Slack sign-in")
+	exportCmd.Flags().BoolVar(&exportForce, "force", false, "Break the export lock held by another run (use after a crash)")
+	exportCmd.Flags().IntVar(&exportSample, "sample", 0, "Export only the newest N messages per conversation (plus threads) to a separate sample folder")
+	exportCmd.Flags().StringVar(&exportFormat, "format", "", "Output format for every conversation in this run: docs, markdown, json, html, slack, or sqlite (overrides conversations.json)")
 	rootCmd.AddCommand(exportCmd)
 }
 
 func runExport(cmd *cobra.Command, args []string) error {
+	if exportEvery < 0 {
+		return fmt.Errorf("--every must be positive")
+	}
+	if exportStatusAddr != "" && !exportDryRun {
+		exportStats = exporter.NewRunStats()
+		srv, err := serveStatus(exportStatusAddr, exportStats)
+		if err != nil {
+			return err
+		}
+		defer srv.Close()
+		statusf("Status page: http://%s/\n", exportStatusAddr)
+	}
+	if exportMetricsAddr != "" && !exportDryRun {
+		exportMetrics = exporter.NewMetrics()
+		srv, err := serveMetrics(exportMetricsAddr, exportMetrics)
+		if err != nil {
+			return err
+		}
+		defer srv.Close()
+		statusf("Metrics endpoint: http://%s/metrics\n", exportMetricsAddr)
+	}
+	if exportEvery == 0 && exportHealthAddr == "" {
+		return runExportOnce(cmd, args)
+	}
+	if exportDryRun {
+		return fmt.Errorf("--every and --health-addr cannot be combined with --dry-run")
+	}
+	return runScheduled(exportEvery, exportHealthAddr, func() error {
+		return runExportOnce(cmd, args)
+	})
+}
+
+// runExportOnce performs a single export run.
+func runExportOnce(cmd *cobra.Command, args []string) error {
 	fmt.Println("Slack Message Export")
 	fmt.Println("====================")
 	fmt.Println()
@@ -113,6 +291,7 @@ func runExport(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load settings: %w", err)
 	}
+	applyTimeSettings(settings)
 
 	// Resolve folder ID from flags and settings
 	exportFolderID = resolveExportFolderID(exportFolderID, settings)
@@ -126,28 +305,28 @@ func runExport(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("invalid local export directory: %w", pathErr)
 		}
 	}
+	s3URL, s3Client, err := resolveS3Sync(exportS3, settings, localExportDir)
+	if err != nil {
+		return err
+	}
 
 	// Sensitivity filter initialization: validate Ollama prerequisites and
 	// create the MessageFilter before building ExporterConfig (US2: fail fast).
-	var messageFilter exporter.MessageFilter
-	if settings.Ollama != nil && settings.Ollama.Enabled && !exportNoSensitivityFilter {
-		ollamaEndpoint := resolveOllamaEndpoint(exportOllamaEndpoint, settings)
-		ollamaModel := settings.Ollama.Model
-		if ollamaModel == "" {
-			ollamaModel = config.DefaultOllamaModel
-		}
-
-		ollamaClient := ollama.NewClient(ollamaEndpoint, ollamaModel)
+	messageFilter, err := buildMessageFilter(settings, exportOllamaEndpoint, exportNoSensitivityFilter)
+	if err != nil {
+		return err
+	}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		if err := validateOllamaPrerequisites(ctx, ollamaClient); err != nil {
-			cancel()
-			return err
-		}
-		cancel()
+	// Translation step (optional)
+	var translator exporter.Translator
+	if !exportNoTranslation {
+		translator = exporter.NewTranslator(settings.Translation)
+	}
 
-		guardian := ollama.NewGuardian(ollamaClient)
-		messageFilter = exporter.NewOllamaFilter(guardian)
+	// Email digest destination (optional)
+	var digestSink exporter.DigestSink
+	if !exportNoEmailDigest && exportSample == 0 {
+		digestSink = buildEmailDigestSink(settings.EmailDigest, os.Getenv(smtpPasswordEnv))
 	}
 
 	// Load conversations config
@@ -156,6 +335,11 @@ func runExport(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
+	cfg.ApplyDefaults(settings.ConversationDefaults)
+	layouts, err := loadLayouts(settings, cfg)
+	if err != nil {
+		return err
+	}
 
 	// Load people config (optional)
 	peoplePath := filepath.Join(configDir, "people.json")
@@ -164,19 +348,50 @@ func runExport(cmd *cobra.Command, args []string) error {
 	}
 	people, err := config.LoadPeople(peoplePath)
 	if err != nil {
-		if debugMode {
+		if outputLevel() >= levelDebug {
 			fmt.Printf("Note: Could not load people.json: %v\n", err)
 		}
 		people = &config.PeopleConfig{}
 	}
 
 	// Determine which conversations to export
-	toExport, err := selectConversations(cfg, args, exportAllDMs, exportAllGroups)
+	if err := validateDiscoverFlags(exportDiscoverDMs, args, exportDMMinMessages); err != nil {
+		return &usageError{err: err}
+	}
+	if err := validateChannelDiscoverFlags(exportAllChannels, exportAllPrivateChannels, exportIncludeNonMember, args); err != nil {
+		return &usageError{err: err}
+	}
+	discoverChannels := exportAllChannels || exportAllPrivateChannels
+	dmActiveSince, err := parseDateFlag(exportDMActiveSince)
+	if err != nil {
+		return &usageError{err: fmt.Errorf("invalid --dm-active-since date: %w", err)}
+	}
+	toExport, err := selectConversations(cfg, args, selectedTypes(exportAllDMs || exportDiscoverDMs, exportAllGroups, exportAllChannels, exportAllPrivateChannels))
+	if err != nil {
+		return err
+	}
+	tags, tagIndex, err := loadTagFilter(exportTags)
+	if err != nil {
+		return err
+	}
+	toExport = filterByTags(toExport, tagIndex, tags)
+	formatOverride, err := parseFormatFlag(exportFormat, localExportDir)
 	if err != nil {
 		return err
 	}
+	toExport = overrideFormat(toExport, formatOverride)
+	if err := validateLocalFormats(toExport, localExportDir); err != nil {
+		return err
+	}
+	activityKinds, err := activityMode(exportActivity, exportActivityKinds, args, exportAllDMs || exportAllGroups || exportDiscoverDMs || discoverChannels, exportSample)
+	if err != nil {
+		return err
+	}
+	if exportActivity {
+		toExport = nil
+	}
 
-	if len(toExport) == 0 {
+	if len(toExport) == 0 && !exportDiscoverDMs && !discoverChannels && !exportActivity {
 		fmt.Println("No conversations to export.")
 		fmt.Println()
 		fmt.Println("Make sure you have conversations configured in:")
@@ -186,27 +401,68 @@ func runExport(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	fmt.Printf("Found %d conversations to export\n", len(toExport))
+	if exportActivity {
+		statusf("Activity to export: %s\n", joinActivityKinds(activityKinds))
+	} else {
+		statusf("Found %d conversations to export\n", len(toExport))
+	}
+	if exportDiscoverDMs {
+		statusf("DM discovery: on (at least %d messages%s)\n", max(exportDMMinMessages, 1), activeSinceSuffix(exportDMActiveSince))
+	}
+	if discoverChannels {
+		statusf("Channel discovery: on (%s)\n", channelDiscoveryScope(exportAllChannels, exportAllPrivateChannels, exportIncludeNonMember))
+	}
 	if len(people.People) > 0 {
-		fmt.Printf("People mapping: %d entries\n", len(people.People))
+		statusf("People mapping: %d entries\n", len(people.People))
 	}
 	if exportFolderID != "" {
-		fmt.Printf("Drive folder ID: %s\n", exportFolderID)
+		statusf("Drive folder ID: %s\n", exportFolderID)
 	} else {
-		fmt.Printf("Drive folder: %s\n", exportFolder)
+		statusf("Drive folder: %s\n", exportFolder)
+	}
+	if os.Getenv(slackTokenEnv) != "" {
+		statusf("Slack credentials: %s\n", slackTokenEnv)
+	} else {
+		statusf("Chrome port: %d\n", chromePort)
 	}
-	fmt.Printf("Chrome port: %d\n", chromePort)
 	if localExportDir != "" {
-		fmt.Printf("Local export: %s\n", localExportDir)
+		statusf("Local export: %s\n", localExportDir)
 	}
-	fmt.Println()
+	statusf("\n")
 
 	// Dry run mode - just show what would be exported
+	if exportDryRun && exportActivity {
+		fmt.Printf("DRY RUN - Would export activity (%s) to the %s folder\n", joinActivityKinds(activityKinds), exporter.ActivityFolderName)
+		return nil
+	}
 	if exportDryRun {
 		formatExportDryRun(os.Stdout, toExport)
+		if exportDiscoverDMs {
+			fmt.Println("  DMs not in conversations.json are discovered in Slack when the export runs.")
+			fmt.Println()
+		}
+		if discoverChannels {
+			fmt.Println("  Channels not in conversations.json are discovered in Slack when the export runs.")
+			fmt.Println()
+		}
 		if localExportDir != "" {
 			formatLocalExportDryRun(os.Stdout, toExport, localExportDir)
 		}
+		if s3URL != "" {
+			fmt.Printf("  The local export is uploaded to %s after the run.\n", s3URL)
+			fmt.Println()
+		}
+		if exportDownloadFiles {
+			fmt.Printf("  Attachments are saved to each conversation's %s/ directory (local) or %s folder (Drive).\n", exporter.FilesDir, exporter.DriveFilesFolder)
+			fmt.Println()
+		}
+		if exportEstimate {
+			estimates, err := estimateExportSizes(toExport)
+			if err != nil {
+				return err
+			}
+			formatSizeEstimates(os.Stdout, estimates)
+		}
 		return nil
 	}
 
@@ -214,22 +470,32 @@ func runExport(cmd *cobra.Command, args []string) error {
 	if err := checkExportPrerequisites(settings, secretStore); err != nil {
 		return err
 	}
+	slackToken, slackCookie, err := slackCredentialsFromEnv()
+	if err != nil {
+		return err
+	}
 
 	// Create context with cancellation
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Create spinner for non-verbose interactive mode
+	// Create spinner for the default level in interactive mode
+	level := outputLevel()
 	var spin *StatusSpinner
-	if !verbose && !debugMode && isTerminal() {
+	if level == levelNormal && isTerminal() {
 		spin = NewStatusSpinner()
 	}
 
 	// Handle interrupt gracefully
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
 	go func() {
-		<-sigChan
+		select {
+		case <-sigChan:
+		case <-ctx.Done():
+			return
+		}
 		if spin != nil {
 			spin.Stop()
 		}
@@ -239,47 +505,180 @@ func runExport(cmd *cobra.Command, args []string) error {
 
 	// Validate flag combinations
 	if err := validateExportFlags(exportSync, exportResume, exportFrom, exportTo); err != nil {
-		return err
+		return &usageError{err: err}
+	}
+	if err := validateBudgetFlags(exportMaxMessages, exportMaxNewDocs); err != nil {
+		return &usageError{err: err}
+	}
+	if err := validateSampleFlags(exportSample, exportSync, exportResume); err != nil {
+		return &usageError{err: err}
+	}
+	if exportSlackMaxRetries < 0 {
+		return &usageError{err: fmt.Errorf("--slack-max-retries must not be negative")}
+	}
+	if err := validateDigestOnlyFlags(exportDigestOnly, exportNoEmailDigest, exportSample, exportActivity, settings.EmailDigest); err != nil {
+		return &usageError{err: err}
 	}
 
 	// Parse date range flags into Slack timestamps
 	dateFrom, dateTo, err := parseDateRange(exportFrom, exportTo)
+	if err != nil {
+		return &usageError{err: err}
+	}
+
+	// Only one run may write the index at a time
+	runLock, err := exporter.AcquireRunLock(exporter.DefaultRunLockPath(configDir), "export", exportForce)
 	if err != nil {
 		return err
 	}
+	defer runLock.Release()
+
+	// Raw response archive (optional)
+	var rawRecorder slackapi.ResponseRecorder
+	if exportRaw {
+		rawArchive := exporter.NewRawArchive(exporter.DefaultRawDir(configDir))
+		rawRecorder = rawArchive
+		defer func() {
+			if err := rawArchive.Close(); err != nil {
+				fmt.Printf("Warning: raw archive incomplete: %v\n", err)
+			}
+		}()
+	}
+
+	// Load the signing key before writing anything, so a run under legal
+	// hold cannot finish without its manifest.
+	var holdKey ed25519.PrivateKey
+	if settings.LegalHold && exportSample == 0 && !exportDigestOnly {
+		if holdKey, err = exporter.LoadOrCreateHoldKey(secretStore); err != nil {
+			return err
+		}
+	}
 
 	exp := exporter.NewExporter(&exporter.ExporterConfig{
 		ConfigDir:             configDir,
 		RootFolderName:        exportFolder,
 		RootFolderID:          exportFolderID,
 		ChromePort:            chromePort,
-		Debug:                 debugMode,
+		SlackTeam:             resolveSlackTeam(slackTeam, settings),
+		PreferredSlackTeam:    preferredSlackTeam(settings),
+		SlackTabURL:           slackTabURL(settings),
+		KeepSlackTab:          keepSlackTab,
+		Debug:                 level >= levelDebug,
 		GoogleCredentialsFile: settings.GoogleCredentialsFile,
 		DateFrom:              dateFrom,
 		DateTo:                dateTo,
 		SyncMode:              exportSync,
 		ResumeMode:            exportResume,
 		LocalExportDir:        localExportDir,
+		DownloadFiles:         exportDownloadFiles,
 		MessageFilter:         messageFilter,
-		OnProgress: func(msg string) {
-			if verbose || debugMode {
-				fmt.Printf("  %s\n", msg)
-			} else if spin != nil {
-				spin.Update(msg)
-			}
-		},
+		Translator:            translator,
+		MaxMessages:           exportMaxMessages,
+		MaxNewDocs:            exportMaxNewDocs,
+		DigestSink:            digestSink,
+		DigestOnly:            exportDigestOnly,
+		RawRecorder:           rawRecorder,
+		NamePolicy:            settings.NamePolicy,
+		Version:               buildVersion,
+		SampleSize:            exportSample,
+		LegalHold:             settings.LegalHold,
+		IncludeProfileStatus:  exportProfileStatus,
+		Provenance:            exportProvenance || settings.Provenance,
+		JSONRendered:          exportJSONRendered || settings.JSONRendered,
+		SkipEmojiMessages:     exportSkipEmojiMessages || settings.SkipEmojiMessages,
+		PrefetchUsers:         exportPrefetchUsers,
+		StyleTemplate:         settings.DocStyleTemplate,
+		DocTemplate:           settings.DocTemplate,
+		FolderWarnItems:       settings.FolderWarnItems,
+		AutoFolderLayout:      settings.AutoFolderLayout,
+		PeerConfigDirs:        settings.PeerConfigDirs,
+		Parallel:              exportParallel,
+		GoogleQuota:           settings.GoogleQuota,
+		SlackMaxRetries:       resolveSlackMaxRetries(cmd, exportSlackMaxRetries, settings),
+		Images:                settings.Images,
+		Layouts:               layouts,
+		SlackToken:            slackToken,
+		SlackCookie:           slackCookie,
+		RunLock:               runLock,
+		Stats:                 exportStats,
+		Metrics:               exportMetrics,
+		OnProgress:            levelProgress(os.Stdout, level, levelVerbose, spin),
+		OnDetail:              levelProgress(os.Stdout, level, levelDetail, spin),
 	})
 
+	// Start Chrome on the get-out profile and wait for the Slack sign-in,
+	// unless a token from the environment makes Chrome unnecessary.
+	if exportLaunchBrowser && slackToken == "" {
+		profilePath, err := chromeProfilePath(settings.ChromeProfilePath)
+		if err != nil {
+			return err
+		}
+		if err := launchBrowserForExport(ctx, os.Stdout, profilePath, chromePort, settings.SlackWorkspaceURL, resolveSlackTeam(slackTeam, settings)); err != nil {
+			return errcat.Wrap(errcat.ChromeUnavailable, fmt.Errorf("failed to launch browser: %w", err))
+		}
+	}
+
 	// Initialize connections using the active SecretStore (keychain or file).
-	fmt.Println("Initializing...")
+	statusf("Initializing...\n")
 	if err := exp.InitializeWithStore(ctx, chromePort, secretStore); err != nil {
 		return fmt.Errorf("initialization failed: %w", err)
 	}
-	fmt.Println()
+	exp.SeedChannels(cfg.Conversations)
+	if exportActivity {
+		statusf("\nStarting activity export...\n\n")
+		if spin != nil {
+			spin.Start()
+		}
+		results, err := exp.ExportActivity(ctx, activityKinds)
+		if spin != nil {
+			spin.Stop()
+		}
+		if err != nil {
+			return fmt.Errorf("activity export failed: %w", err)
+		}
+		return printActivityResults(os.Stdout, results)
+	}
+	if exportDiscoverDMs {
+		discovered, err := exp.DiscoverDMs(ctx, cfg.Conversations, exporter.DMDiscoveryOptions{
+			MinMessages: exportDMMinMessages,
+			ActiveSince: dmActiveSince,
+		})
+		if err != nil {
+			return err
+		}
+		statusf("Discovered %d active DMs not in conversations.json\n", len(discovered))
+		toExport = append(toExport, overrideFormat(filterByTags(discovered, tagIndex, tags), formatOverride)...)
+	}
+	if discoverChannels {
+		discovered, err := exp.DiscoverChannels(ctx, cfg.Conversations, exporter.ChannelDiscoveryOptions{
+			Public:           exportAllChannels,
+			Private:          exportAllPrivateChannels,
+			IncludeNonMember: exportIncludeNonMember,
+		})
+		if err != nil {
+			return err
+		}
+		discovered = overrideFormat(filterByTags(discovered, tagIndex, tags), formatOverride)
+		ok, err := confirmDiscoveredChannels(os.Stdout, discovered, exportYes, isTerminal(), promptConfirm)
+		if err != nil {
+			return err
+		}
+		if ok {
+			toExport = append(toExport, discovered...)
+		}
+	}
+	if (exportDiscoverDMs || discoverChannels) && len(toExport) == 0 {
+		fmt.Println("No conversations to export.")
+		return nil
+	}
+	if exportEstimate {
+		statusf("\n")
+		formatSizeEstimates(os.Stdout, exp.EstimateSizes(ctx, toExport))
+	}
+	statusf("\n")
 
 	// Run export
-	fmt.Println("Starting export...")
-	fmt.Println()
+	statusf("Starting export...\n\n")
 
 	if spin != nil {
 		spin.Start()
@@ -290,18 +689,42 @@ func runExport(cmd *cobra.Command, args []string) error {
 	if spin != nil {
 		spin.Stop()
 	}
+	statusf("Google API requests: %s\n", exp.QuotaSummary())
+
+	if holdKey != nil {
+		manifest, holdErr := exporter.WriteHoldManifest(exporter.DefaultHoldDir(configDir), holdKey)
+		if holdErr != nil {
+			return fmt.Errorf("failed to write legal hold manifest: %w", holdErr)
+		}
+		statusf("Legal hold manifest: %s\n", manifest)
+	}
 
 	if err != nil {
 		return fmt.Errorf("export failed: %w", err)
 	}
 
 	// Print summary
-	return printExportResults(os.Stdout, results, exp.GetRootFolderURL(), verbose || debugMode)
+	if err := printExportResults(os.Stdout, results, exp.GetRootFolderURL(), level != levelNormal); err != nil {
+		return err
+	}
+	if s3Client != nil {
+		uploaded, err := s3Client.SyncDir(ctx, localExportDir, filepath.Join(configDir, s3SyncStateFile))
+		if err != nil {
+			return fmt.Errorf("S3 upload failed after %d files: %w", uploaded, err)
+		}
+		statusf("Uploaded %d changed files to %s\n", uploaded, s3URL)
+	}
+	if exportSample > 0 {
+		fmt.Printf("\nThis was a sample of the newest %d messages per conversation.\n", exportSample)
+		fmt.Println("Run 'get-out export' without --sample for the full history.")
+	}
+	return nil
 }
 
 // selectConversations determines which conversations to export based on
-// args, flags (allDMs, allGroups), and the config's export field.
-func selectConversations(cfg *config.ConversationsConfig, args []string, allDMs, allGroups bool) ([]config.ConversationConfig, error) {
+// args, the conversation types selected by flags (see selectedTypes), and
+// the config's export field.
+func selectConversations(cfg *config.ConversationsConfig, args []string, types []models.ConversationType) ([]config.ConversationConfig, error) {
 	if len(args) > 0 {
 		var result []config.ConversationConfig
 		for _, id := range args {
@@ -313,13 +736,189 @@ func selectConversations(cfg *config.ConversationsConfig, args []string, allDMs,
 		}
 		return result, nil
 	}
+	if len(types) > 0 {
+		var result []config.ConversationConfig
+		for _, t := range types {
+			result = append(result, cfg.FilterByType(t)...)
+		}
+		return result, nil
+	}
+	return cfg.FilterByExport(), nil
+}
+
+// selectedTypes returns the conversation types selected by the --all-*
+// flags, in that order.
+func selectedTypes(allDMs, allGroups, allChannels, allPrivateChannels bool) []models.ConversationType {
+	var types []models.ConversationType
 	if allDMs {
-		return cfg.FilterByType(models.ConversationTypeDM), nil
+		types = append(types, models.ConversationTypeDM)
 	}
 	if allGroups {
-		return cfg.FilterByType(models.ConversationTypeMPIM), nil
+		types = append(types, models.ConversationTypeMPIM)
 	}
-	return cfg.FilterByExport(), nil
+	if allChannels {
+		types = append(types, models.ConversationTypeChannel)
+	}
+	if allPrivateChannels {
+		types = append(types, models.ConversationTypePrivateChannel)
+	}
+	return types
+}
+
+// validateChannelDiscoverFlags rejects --all-channels and
+// --all-private-channels with explicit conversation IDs, and
+// --include-non-member without --all-channels.
+func validateChannelDiscoverFlags(allChannels, allPrivateChannels, includeNonMember bool, args []string) error {
+	if (allChannels || allPrivateChannels) && len(args) > 0 {
+		return fmt.Errorf("--all-channels and --all-private-channels cannot be combined with conversation IDs")
+	}
+	if includeNonMember && !allChannels {
+		return fmt.Errorf("--include-non-member requires --all-channels")
+	}
+	return nil
+}
+
+// activityMode validates the --activity flags and returns the activity
+// feeds to export, or nil without --activity. Activity replaces the
+// conversation export, so flags selecting conversations are rejected.
+func activityMode(activity bool, kindNames, args []string, selectsConversations bool, sample int) ([]exporter.ActivityKind, error) {
+	if !activity {
+		return nil, nil
+	}
+	if len(args) > 0 || selectsConversations {
+		return nil, &usageError{err: fmt.Errorf("--activity exports your activity instead of conversations and cannot be combined with conversation IDs or conversation selection flags")}
+	}
+	if sample > 0 {
+		return nil, &usageError{err: fmt.Errorf("--activity cannot be combined with --sample")}
+	}
+	var kinds []exporter.ActivityKind
+	for _, name := range kindNames {
+		kind, err := exporter.ParseActivityKind(strings.TrimSpace(name))
+		if err != nil {
+			return nil, &usageError{err: err}
+		}
+		if !slices.Contains(kinds, kind) {
+			kinds = append(kinds, kind)
+		}
+	}
+	if len(kinds) == 0 {
+		return nil, &usageError{err: fmt.Errorf("--activity-kinds must name at least one feed")}
+	}
+	return kinds, nil
+}
+
+// activityKindNames returns the names of every activity feed.
+func activityKindNames() []string {
+	names := make([]string, len(exporter.ActivityKinds))
+	for i, k := range exporter.ActivityKinds {
+		names[i] = string(k)
+	}
+	return names
+}
+
+func joinActivityKinds(kinds []exporter.ActivityKind) string {
+	names := make([]string, len(kinds))
+	for i, k := range kinds {
+		names[i] = string(k)
+	}
+	return strings.Join(names, ", ")
+}
+
+// printActivityResults prints one line per activity feed and returns an
+// ExportPartial error (see partialCode) when any feed failed.
+func printActivityResults(w io.Writer, results []*exporter.ActivityResult) error {
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Activity Export Summary")
+	fmt.Fprintln(w, "=======================")
+	var errs []error
+	for _, r := range results {
+		if r.Error != nil {
+			errs = append(errs, r.Error)
+			fmt.Fprintf(w, "  ✗ %s\n", r)
+			continue
+		}
+		fmt.Fprintf(w, "  ✓ %s\n", r)
+		if r.FolderURL != "" {
+			fmt.Fprintf(w, "    %s\n", r.FolderURL)
+		}
+	}
+	if len(errs) > 0 {
+		return errcat.Wrap(partialCode(errs), fmt.Errorf("%d activity feed(s) failed", len(errs)))
+	}
+	return nil
+}
+
+// channelDiscoveryScope describes the channel discovery flags for status
+// output.
+func channelDiscoveryScope(allChannels, allPrivateChannels, includeNonMember bool) string {
+	var kinds []string
+	if allChannels {
+		kinds = append(kinds, "public")
+	}
+	if allPrivateChannels {
+		kinds = append(kinds, "private")
+	}
+	scope := strings.Join(kinds, " and ") + " channels"
+	if includeNonMember {
+		return scope + ", including public channels you have not joined"
+	}
+	return scope + " you are a member of"
+}
+
+// maxListedChannels is how many discovered channels are named before
+// asking to export them.
+const maxListedChannels = 20
+
+// confirmDiscoveredChannels lists the channels found in Slack and asks
+// whether to export them, unless yes is set. Without a terminal to ask on,
+// it fails instead of exporting a possibly large set unasked.
+func confirmDiscoveredChannels(w io.Writer, found []config.ConversationConfig, yes, interactive bool, ask func(string) (bool, error)) (bool, error) {
+	if len(found) == 0 {
+		statusf("Discovered no channels missing from conversations.json\n")
+		return false, nil
+	}
+	fmt.Fprintf(w, "Discovered %d channels not in conversations.json:\n", len(found))
+	for i, c := range found {
+		if i == maxListedChannels {
+			fmt.Fprintf(w, "  ... and %d more\n", len(found)-maxListedChannels)
+			break
+		}
+		fmt.Fprintf(w, "  #%s (%s)\n", c.Name, c.ID)
+	}
+	if yes {
+		return true, nil
+	}
+	if !interactive {
+		return false, fmt.Errorf("pass --yes to export the %d discovered channels without a prompt", len(found))
+	}
+	ok, err := ask(fmt.Sprintf("Export these %d channels?", len(found)))
+	if err != nil {
+		return false, fmt.Errorf("prompt failed: %w", err)
+	}
+	if !ok {
+		fmt.Fprintln(w, "Skipping the discovered channels.")
+	}
+	return ok, nil
+}
+
+// validateDiscoverFlags rejects --discover-dms with explicit conversation
+// IDs and a negative --dm-min-messages.
+func validateDiscoverFlags(discoverDMs bool, args []string, minMessages int) error {
+	if discoverDMs && len(args) > 0 {
+		return fmt.Errorf("--discover-dms cannot be combined with conversation IDs")
+	}
+	if minMessages < 0 {
+		return fmt.Errorf("--dm-min-messages must be >= 0, got %d", minMessages)
+	}
+	return nil
+}
+
+// activeSinceSuffix describes a --dm-active-since date for status output.
+func activeSinceSuffix(date string) string {
+	if date == "" {
+		return ""
+	}
+	return " since " + date
 }
 
 // validateExportFlags checks for invalid flag combinations.
@@ -330,9 +929,98 @@ func validateExportFlags(syncMode, resumeMode bool, dateFrom, dateTo string) err
 	if resumeMode && (dateFrom != "" || dateTo != "") {
 		return fmt.Errorf("--resume cannot be combined with --from or --to")
 	}
+	if syncMode && resumeMode {
+		return fmt.Errorf("--resume cannot be combined with --sync: --resume finishes interrupted exports, --sync adds new messages to all of them")
+	}
+	return nil
+}
+
+// validateSampleFlags rejects a negative --sample and combining it with
+// --sync or --resume, which track the full export's index.
+func validateSampleFlags(sample int, syncMode, resumeMode bool) error {
+	if sample < 0 {
+		return fmt.Errorf("--sample must be >= 0, got %d", sample)
+	}
+	if sample > 0 && (syncMode || resumeMode) {
+		return fmt.Errorf("--sample cannot be combined with --sync or --resume")
+	}
+	return nil
+}
+
+// validateDigestOnlyFlags rejects --digest-only without an enabled
+// emailDigest in settings.json, and with flags that send no digest.
+func validateDigestOnlyFlags(digestOnly, noDigest bool, sample int, activity bool, digest *config.EmailDigestConfig) error {
+	if !digestOnly {
+		return nil
+	}
+	if noDigest || sample > 0 || activity {
+		return fmt.Errorf("--digest-only cannot be combined with --no-email-digest, --sample, or --activity")
+	}
+	if digest == nil || !digest.Enabled {
+		return fmt.Errorf("--digest-only needs an enabled emailDigest in settings.json")
+	}
 	return nil
 }
 
+// validateBudgetFlags rejects negative --max-messages / --max-new-docs values.
+func validateBudgetFlags(maxMessages, maxNewDocs int) error {
+	if maxMessages < 0 {
+		return fmt.Errorf("--max-messages must be >= 0, got %d", maxMessages)
+	}
+	if maxNewDocs < 0 {
+		return fmt.Errorf("--max-new-docs must be >= 0, got %d", maxNewDocs)
+	}
+	return nil
+}
+
+// estimateExportSizes connects to Slack alone and estimates the size of each
+// conversation, for --dry-run --estimate.
+func estimateExportSizes(conversations []config.ConversationConfig) ([]exporter.SizeEstimate, error) {
+	dateFrom, err := parseDateFlag(exportFrom)
+	if err != nil {
+		return nil, &usageError{err: fmt.Errorf("invalid --from date: %w", err)}
+	}
+	slackToken, slackCookie, err := slackCredentialsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	level := outputLevel()
+	exp := exporter.NewExporter(&exporter.ExporterConfig{
+		ConfigDir:   configDir,
+		DateFrom:    dateFrom,
+		Debug:       level >= levelDebug,
+		SlackToken:  slackToken,
+		SlackCookie: slackCookie,
+		OnProgress:  levelProgress(os.Stdout, level, levelVerbose, nil),
+		OnDetail:    levelProgress(os.Stdout, level, levelDetail, nil),
+	})
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	if err := exp.ConnectSlack(ctx, chromePort); err != nil {
+		return nil, err
+	}
+	return exp.EstimateSizes(ctx, conversations), nil
+}
+
+// formatSizeEstimates writes the estimated size of each conversation,
+// largest first.
+func formatSizeEstimates(w io.Writer, estimates []exporter.SizeEstimate) {
+	fmt.Fprintln(w, "Estimated size (largest first):")
+	fmt.Fprintln(w)
+	for _, est := range estimates {
+		line := fmt.Sprintf("  %-18s %-30s", est.FormatSize(), truncateName(est.Conversation.Name, 30))
+		switch {
+		case est.Err != nil:
+			line += fmt.Sprintf("  (%v)", est.Err)
+		case !est.Latest.IsZero():
+			line += "  newest " + est.Latest.Format("2006-01-02")
+		}
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Estimates count main messages only, extrapolated from the newest page of history.")
+}
+
 // formatExportDryRun writes the dry-run output showing what would be exported.
 func formatExportDryRun(w io.Writer, conversations []config.ConversationConfig) {
 	fmt.Fprintln(w, "DRY RUN - Would export:")
@@ -340,6 +1028,9 @@ func formatExportDryRun(w io.Writer, conversations []config.ConversationConfig)
 	for _, c := range conversations {
 		fmt.Fprintf(w, "  - %s (%s)\n", c.Name, c.ID)
 		fmt.Fprintf(w, "    Type: %s\n", c.Type)
+		if !c.WritesDocs() {
+			fmt.Fprintf(w, "    Format: %s (local only)\n", c.OutputFormat())
+		}
 		if c.Share {
 			fmt.Fprintf(w, "    Sharing: enabled")
 			if len(c.ShareMembers) > 0 {
@@ -357,6 +1048,7 @@ type ExportResultSummary struct {
 	DocsCreated     int
 	ThreadsExported int
 	Error           error
+	BudgetExhausted bool
 }
 
 // formatExportSummary writes the export results summary and returns the error count.
@@ -370,12 +1062,16 @@ func formatExportSummary(w io.Writer, results []ExportResultSummary, rootURL str
 	totalDocs := 0
 	totalThreads := 0
 	errorCount := 0
+	budgetHit := false
 
 	for _, r := range results {
 		status := "OK"
 		if r.Error != nil {
 			status = "FAILED"
 			errorCount++
+		} else if r.BudgetExhausted {
+			status = "PARTIAL"
+			budgetHit = true
 		}
 
 		fmt.Fprintf(w, "%-30s %6d msgs  %3d docs  %3d threads  [%s]\n",
@@ -400,6 +1096,9 @@ func formatExportSummary(w io.Writer, results []ExportResultSummary, rootURL str
 	if errorCount > 0 {
 		fmt.Fprintf(w, "Errors: %d conversation(s) failed\n", errorCount)
 	}
+	if budgetHit {
+		fmt.Fprintln(w, "Run budget reached — run 'get-out export --sync' to continue where this run stopped")
+	}
 
 	if rootURL != "" {
 		fmt.Fprintln(w)
@@ -437,18 +1136,23 @@ func parseDateFlag(dateStr string) (string, error) {
 // printExportResults converts export results to summaries and prints the table.
 func printExportResults(w io.Writer, results []*exporter.ExportResult, rootURL string, showErrors bool) error {
 	summaries := make([]ExportResultSummary, len(results))
+	var errs []error
 	for i, r := range results {
+		if r.Error != nil {
+			errs = append(errs, r.Error)
+		}
 		summaries[i] = ExportResultSummary{
 			Name:            r.Name,
 			MessageCount:    r.MessageCount,
 			DocsCreated:     r.DocsCreated,
 			ThreadsExported: r.ThreadsExported,
 			Error:           r.Error,
+			BudgetExhausted: r.BudgetExhausted,
 		}
 	}
 	errorCount := formatExportSummary(w, summaries, rootURL, showErrors)
 	if errorCount > 0 {
-		return fmt.Errorf("%d export(s) failed", errorCount)
+		return errcat.Wrap(partialCode(errs), fmt.Errorf("%d export(s) failed", errorCount))
 	}
 	return nil
 }
@@ -458,12 +1162,13 @@ func checkExportPrerequisites(settings *config.Settings, store secrets.SecretSto
 	if settings.GoogleCredentialsFile != "" {
 		// Custom credentials path — still check via store
 	}
-	if _, err := store.Get(secrets.KeyClientCredentials); err != nil {
-		return fmt.Errorf("Google credentials not found — run: get-out init\n\nDownload credentials.json from Google Cloud Console")
+	gcfg := gdrive.DefaultConfig(configDir)
+	if _, err := store.Get(secrets.KeyClientCredentials); err != nil && !gcfg.HasEnvCredentials() {
+		return errcat.Wrap(errcat.GoogleAuth, fmt.Errorf("Google credentials not found — run: get-out setup-google\n\nOr set %s and %s", gdrive.EnvClientID, gdrive.EnvClientSecret))
 	}
-	if _, err := store.Get(secrets.KeyOAuthToken); err != nil {
+	if _, err := store.Get(secrets.KeyOAuthToken); err != nil && gcfg.RefreshToken == "" {
 		fmt.Println("Google authorization required. Run 'get-out auth login' first.")
-		return fmt.Errorf("no Google token found — run: get-out auth login")
+		return errcat.Wrap(errcat.GoogleAuth, fmt.Errorf("no Google token found — run: get-out auth login"))
 	}
 	return nil
 }
@@ -481,6 +1186,24 @@ func resolveExportFolderID(flagValue string, settings *config.Settings) string {
 	return settings.GoogleDriveFolderID
 }
 
+// loadLayouts loads the layouts of settings.Templates and the config
+// directory's templates/ folder, and checks that every layout cfg's
+// conversations select exists.
+func loadLayouts(settings *config.Settings, cfg *config.ConversationsConfig) (map[string]*exporter.Layout, error) {
+	templates, err := config.LoadTemplates(filepath.Join(configDir, "templates"), settings.Templates)
+	if err != nil {
+		return nil, errcat.Wrap(errcat.ConfigInvalid, err)
+	}
+	if err := config.CheckTemplates(cfg.Conversations, templates); err != nil {
+		return nil, errcat.Wrap(errcat.ConfigInvalid, err)
+	}
+	layouts, err := exporter.ParseLayouts(templates)
+	if err != nil {
+		return nil, errcat.Wrap(errcat.ConfigInvalid, err)
+	}
+	return layouts, nil
+}
+
 // resolveLocalExportDir determines the local export directory from the CLI
 // flag and settings. The flag takes priority over settings.LocalExportOutputDir.
 func resolveLocalExportDir(flagValue string, settings *config.Settings) string {
@@ -490,17 +1213,81 @@ func resolveLocalExportDir(flagValue string, settings *config.Settings) string {
 	return settings.LocalExportOutputDir
 }
 
-// formatLocalExportDryRun writes the local markdown export section of the
-// dry-run output, showing which conversations have localExport enabled and
-// where markdown files would be written.
+// resolveSlackMaxRetries returns the Slack retry limit from the
+// --slack-max-retries flag when given, else from settings.json (nil leaves
+// the client's default).
+func resolveSlackMaxRetries(cmd *cobra.Command, flagValue int, settings *config.Settings) *int {
+	if cmd.Flags().Changed("slack-max-retries") {
+		return &flagValue
+	}
+	return settings.SlackMaxRetries
+}
+
+// applyTimeSettings sets the time zone and layouts exported times are
+// written with from settings.json.
+func applyTimeSettings(settings *config.Settings) {
+	parser.SetTimeSettings(parser.TimeSettings{
+		Location:   settings.TimeLocation(),
+		TimeLayout: settings.TimeFormat,
+		DateLayout: settings.DateFormat,
+	})
+}
+
+// slackTabURL returns the URL get-out opens in a background tab to read
+// Slack credentials from when Chrome has no Slack tab open.
+func slackTabURL(settings *config.Settings) string {
+	workspaceURL := ""
+	if settings != nil {
+		workspaceURL = settings.SlackWorkspaceURL
+	}
+	return chrome.SlackClientURL(workspaceURL, resolveSlackTeam(slackTeam, settings))
+}
+
+// resolveSlackTeam determines the Slack workspace whose browser session is
+// used: the --slack-team flag, then settings.SlackTeam. Empty means any
+// single workspace, or the preferred one of several (see
+// preferredSlackTeam).
+func resolveSlackTeam(flagValue string, settings *config.Settings) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if settings == nil {
+		return ""
+	}
+	return settings.SlackTeam
+}
+
+// preferredSlackTeam returns the workspace named by
+// settings.SlackWorkspaceURL, used only to choose among several signed-in
+// workspaces when none is requested.
+func preferredSlackTeam(settings *config.Settings) string {
+	if settings == nil {
+		return ""
+	}
+	return chrome.SlackTeamOfURL(settings.SlackWorkspaceURL)
+}
+
+// formatLocalExportDryRun writes the local export section of the dry-run
+// output, showing which conversations are written locally (by localExport
+// or a markdown or json format) and where the files would go.
 func formatLocalExportDryRun(w io.Writer, conversations []config.ConversationConfig, localExportDir string) {
 	fmt.Fprintln(w)
 	fmt.Fprintf(w, "Local Markdown Export: %s\n", localExportDir)
 	hasLocal := false
 	for _, c := range conversations {
-		if c.LocalExport {
-			typeName := exporter.SanitizeDirectoryName(string(c.Type), c.Name)
-			fmt.Fprintf(w, "  - %s → %s/%s/\n", c.Name, localExportDir, typeName)
+		if c.WritesLocal() {
+			dir := exporter.SanitizeDirectoryName(string(c.Type), c.Name) + "/"
+			switch c.OutputFormat() {
+			case config.OutputFormatSlack:
+				dir = exporter.SlackArchiveConversationDir(c) + "/"
+			case config.OutputFormatSQLite:
+				dir = exporter.SQLiteArchiveFile
+			}
+			fmt.Fprintf(w, "  - %s → %s/%s", c.Name, localExportDir, dir)
+			if f := c.OutputFormat(); f != config.OutputFormatDocs && f != config.OutputFormatMarkdown {
+				fmt.Fprintf(w, " (%s)", f)
+			}
+			fmt.Fprintln(w)
 			hasLocal = true
 		}
 	}
@@ -509,6 +1296,73 @@ func formatLocalExportDryRun(w io.Writer, conversations []config.ConversationCon
 	}
 }
 
+// parseFormatFlag parses --format, returning "" when it is not set. A
+// local format needs a local export directory to write to.
+func parseFormatFlag(value, localExportDir string) (config.OutputFormat, error) {
+	if value == "" {
+		return "", nil
+	}
+	f, err := config.ParseOutputFormat(value)
+	if err != nil {
+		return "", &usageError{err: fmt.Errorf("--format: %w", err)}
+	}
+	if f != config.OutputFormatDocs && localExportDir == "" {
+		return "", &usageError{err: fmt.Errorf("--format %s needs a local export directory: set localExportOutputDir in settings.json or pass --local-export-dir", f)}
+	}
+	return f, nil
+}
+
+// overrideFormat returns conversations with their format set to f, or
+// unchanged when f is empty.
+func overrideFormat(conversations []config.ConversationConfig, f config.OutputFormat) []config.ConversationConfig {
+	if f == "" {
+		return conversations
+	}
+	result := make([]config.ConversationConfig, len(conversations))
+	for i, c := range conversations {
+		c.Format = f
+		result[i] = c
+	}
+	return result
+}
+
+// validateLocalFormats rejects conversations with a local-only format
+// when there is no local export directory to write them to.
+func validateLocalFormats(conversations []config.ConversationConfig, localExportDir string) error {
+	if localExportDir != "" {
+		return nil
+	}
+	for _, c := range conversations {
+		if !c.WritesDocs() {
+			return errcat.Wrap(errcat.ConfigInvalid, fmt.Errorf("%s has format %q, which needs a local export directory: set localExportOutputDir in settings.json or pass --local-export-dir", c.Name, c.OutputFormat()))
+		}
+	}
+	return nil
+}
+
+// buildMessageFilter creates the sensitivity filter when Ollama is enabled in
+// settings and not disabled for this run, validating that Ollama is ready.
+// Returns nil when no filter applies.
+func buildMessageFilter(settings *config.Settings, endpointFlag string, disabled bool) (exporter.MessageFilter, error) {
+	if settings.Ollama == nil || !settings.Ollama.Enabled || disabled {
+		return nil, nil
+	}
+
+	ollamaModel := settings.Ollama.Model
+	if ollamaModel == "" {
+		ollamaModel = config.DefaultOllamaModel
+	}
+	ollamaClient := ollama.NewClient(resolveOllamaEndpoint(endpointFlag, settings), ollamaModel)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := validateOllamaPrerequisites(ctx, ollamaClient); err != nil {
+		return nil, err
+	}
+
+	return exporter.NewOllamaFilter(ollama.NewGuardian(ollamaClient)), nil
+}
+
 // validateOllamaPrerequisites checks that the Ollama server is reachable and
 // the configured model is available. Returns a user-friendly error with
 // remediation hints on failure (SC-002: fail within 5 seconds).
@@ -540,6 +1394,31 @@ func resolveOllamaEndpoint(flagValue string, settings *config.Settings) string {
 	return config.DefaultOllamaEndpoint
 }
 
+// smtpPasswordEnv names the environment variable holding the SMTP password
+// for the email digest. Passwords are never stored in settings.json.
+const smtpPasswordEnv = "GET_OUT_SMTP_PASSWORD"
+
+// buildEmailDigestSink creates an email DigestSink from settings, or returns
+// nil when the email digest is not configured or disabled. The SMTP
+// password is unused with the gmail transport.
+func buildEmailDigestSink(cfg *config.EmailDigestConfig, password string) exporter.DigestSink {
+	if cfg == nil || !cfg.Enabled {
+		return nil
+	}
+	if cfg.UsesGmail() {
+		return exporter.NewGmailDigestSink(cfg.From, cfg.To)
+	}
+	client := mailer.NewClient(mailer.Config{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.Username,
+		Password: password,
+		From:     cfg.From,
+		To:       cfg.To,
+	})
+	return exporter.NewEmailDigestSink(client)
+}
+
 // parseDateRange converts --from and --to flag values into Slack timestamp
 // strings. The --to value is adjusted to end-of-day (23:59:59).
 func parseDateRange(from, to string) (dateFrom, dateTo string, err error) {
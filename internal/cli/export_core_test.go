@@ -2,11 +2,16 @@ package cli
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/exporter"
 	"github.com/jflowers/get-out/pkg/models"
 )
 
@@ -27,7 +32,7 @@ func testConversationsConfig() *config.ConversationsConfig {
 func TestSelectConversations_ByArgs(t *testing.T) {
 	cfg := testConversationsConfig()
 
-	result, err := selectConversations(cfg, []string{"C001ABC", "D003GHI"}, false, false)
+	result, err := selectConversations(cfg, []string{"C001ABC", "D003GHI"}, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -45,7 +50,7 @@ func TestSelectConversations_ByArgs(t *testing.T) {
 func TestSelectConversations_ByArgs_NotFound(t *testing.T) {
 	cfg := testConversationsConfig()
 
-	result, err := selectConversations(cfg, []string{"CNOTEXIST"}, false, false)
+	result, err := selectConversations(cfg, []string{"CNOTEXIST"}, nil)
 	if err == nil {
 		t.Fatal("expected error for missing conversation, got nil")
 	}
@@ -60,7 +65,7 @@ func TestSelectConversations_ByArgs_NotFound(t *testing.T) {
 func TestSelectConversations_AllDMs(t *testing.T) {
 	cfg := testConversationsConfig()
 
-	result, err := selectConversations(cfg, nil, true, false)
+	result, err := selectConversations(cfg, nil, selectedTypes(true, false, false, false))
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -77,7 +82,7 @@ func TestSelectConversations_AllDMs(t *testing.T) {
 func TestSelectConversations_AllGroups(t *testing.T) {
 	cfg := testConversationsConfig()
 
-	result, err := selectConversations(cfg, nil, false, true)
+	result, err := selectConversations(cfg, nil, selectedTypes(false, true, false, false))
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -92,7 +97,7 @@ func TestSelectConversations_AllGroups(t *testing.T) {
 func TestSelectConversations_Default(t *testing.T) {
 	cfg := testConversationsConfig()
 
-	result, err := selectConversations(cfg, nil, false, false)
+	result, err := selectConversations(cfg, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -107,6 +112,83 @@ func TestSelectConversations_Default(t *testing.T) {
 	}
 }
 
+func TestSelectConversations_AllChannelTypes(t *testing.T) {
+	cfg := testConversationsConfig()
+
+	result, err := selectConversations(cfg, nil, selectedTypes(true, false, true, true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var ids []string
+	for _, r := range result {
+		ids = append(ids, r.ID)
+	}
+	// DMs then channels, whatever their export field says.
+	if got := strings.Join(ids, ","); got != "D003GHI,D004JKL,C001ABC,C002DEF" {
+		t.Errorf("selected = %s", got)
+	}
+}
+
+func TestValidateChannelDiscoverFlags(t *testing.T) {
+	if err := validateChannelDiscoverFlags(true, true, true, nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := validateChannelDiscoverFlags(false, true, false, []string{"C001"}); err == nil {
+		t.Error("expected an error for conversation IDs with --all-private-channels")
+	}
+	if err := validateChannelDiscoverFlags(false, true, true, nil); err == nil {
+		t.Error("expected an error for --include-non-member without --all-channels")
+	}
+}
+
+func TestChannelDiscoveryScope(t *testing.T) {
+	if got := channelDiscoveryScope(true, true, false); got != "public and private channels you are a member of" {
+		t.Errorf("scope = %q", got)
+	}
+	if got := channelDiscoveryScope(true, false, true); got != "public channels, including public channels you have not joined" {
+		t.Errorf("scope = %q", got)
+	}
+}
+
+func TestConfirmDiscoveredChannels(t *testing.T) {
+	found := make([]config.ConversationConfig, maxListedChannels+2)
+	for i := range found {
+		found[i] = config.ConversationConfig{ID: fmt.Sprintf("C%03d", i), Name: fmt.Sprintf("chan-%d", i)}
+	}
+	never := func(string) (bool, error) {
+		t.Error("unexpected prompt")
+		return false, nil
+	}
+
+	var buf bytes.Buffer
+	ok, err := confirmDiscoveredChannels(&buf, found, true, false, never)
+	if err != nil || !ok {
+		t.Errorf("with --yes = %v, %v, want confirmed", ok, err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "Discovered 22 channels") || !strings.Contains(out, "#chan-0 (C000)") || !strings.Contains(out, "... and 2 more") {
+		t.Errorf("output = %s", out)
+	}
+
+	if _, err := confirmDiscoveredChannels(io.Discard, found, false, false, never); err == nil || !strings.Contains(err.Error(), "--yes") {
+		t.Errorf("without a terminal error = %v, want a hint to pass --yes", err)
+	}
+
+	var asked string
+	buf.Reset()
+	ok, err = confirmDiscoveredChannels(&buf, found, false, true, func(msg string) (bool, error) {
+		asked = msg
+		return false, nil
+	})
+	if err != nil || ok || asked != "Export these 22 channels?" || !strings.Contains(buf.String(), "Skipping") {
+		t.Errorf("declined = %v, %v, asked %q, output %s", ok, err, asked, buf.String())
+	}
+
+	if ok, err := confirmDiscoveredChannels(io.Discard, nil, false, false, never); ok || err != nil {
+		t.Errorf("nothing found = %v, %v", ok, err)
+	}
+}
+
 func TestValidateExportFlags_Valid(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -180,6 +262,13 @@ func TestValidateExportFlags_ResumeWithDate(t *testing.T) {
 	}
 }
 
+func TestValidateExportFlags_SyncWithResume(t *testing.T) {
+	err := validateExportFlags(true, true, "", "")
+	if err == nil || !strings.Contains(err.Error(), "--resume") || !strings.Contains(err.Error(), "--sync") {
+		t.Errorf("expected an error naming --resume and --sync, got: %v", err)
+	}
+}
+
 func TestFormatExportDryRun(t *testing.T) {
 	convs := []config.ConversationConfig{
 		{
@@ -301,3 +390,207 @@ func TestFormatExportSummary(t *testing.T) {
 		t.Error("should not print export folder when rootURL is empty")
 	}
 }
+
+func TestValidateBudgetFlags(t *testing.T) {
+	if err := validateBudgetFlags(0, 0); err != nil {
+		t.Errorf("unexpected error for zero budgets: %v", err)
+	}
+	if err := validateBudgetFlags(100, 5); err != nil {
+		t.Errorf("unexpected error for positive budgets: %v", err)
+	}
+	if err := validateBudgetFlags(-1, 0); err == nil || !strings.Contains(err.Error(), "--max-messages") {
+		t.Errorf("expected --max-messages error, got %v", err)
+	}
+	if err := validateBudgetFlags(0, -1); err == nil || !strings.Contains(err.Error(), "--max-new-docs") {
+		t.Errorf("expected --max-new-docs error, got %v", err)
+	}
+}
+
+func TestValidateSampleFlags(t *testing.T) {
+	if err := validateSampleFlags(0, true, true); err != nil {
+		t.Errorf("unexpected error without --sample: %v", err)
+	}
+	if err := validateSampleFlags(20, false, false); err != nil {
+		t.Errorf("unexpected error for --sample 20: %v", err)
+	}
+	if err := validateSampleFlags(-1, false, false); err == nil || !strings.Contains(err.Error(), "--sample") {
+		t.Errorf("expected --sample error, got %v", err)
+	}
+	if err := validateSampleFlags(5, true, false); err == nil {
+		t.Error("expected error combining --sample with --sync")
+	}
+	if err := validateSampleFlags(5, false, true); err == nil {
+		t.Error("expected error combining --sample with --resume")
+	}
+}
+
+func TestFormatSizeEstimates(t *testing.T) {
+	estimates := []exporter.SizeEstimate{
+		{Conversation: config.ConversationConfig{Name: "firehose"}, Messages: 2_100_000, Latest: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)},
+		{Conversation: config.ConversationConfig{Name: "general"}, Messages: 42, Exact: true},
+		{Conversation: config.ConversationConfig{Name: "secret"}, Err: fmt.Errorf("not_in_channel")},
+	}
+
+	var buf bytes.Buffer
+	formatSizeEstimates(&buf, estimates)
+	output := buf.String()
+
+	for _, want := range []string{"~2.1M messages", "firehose", "newest 2026-10-", "42 messages", "unknown", "(not_in_channel)"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+	if strings.Index(output, "firehose") > strings.Index(output, "general") {
+		t.Error("estimates should keep their largest-first order")
+	}
+}
+
+func TestValidateDiscoverFlags(t *testing.T) {
+	if err := validateDiscoverFlags(true, nil, 5); err != nil {
+		t.Errorf("unexpected error for --discover-dms: %v", err)
+	}
+	if err := validateDiscoverFlags(false, []string{"D001"}, 1); err != nil {
+		t.Errorf("unexpected error without --discover-dms: %v", err)
+	}
+	if err := validateDiscoverFlags(true, []string{"D001"}, 1); err == nil {
+		t.Error("expected error combining --discover-dms with conversation IDs")
+	}
+	if err := validateDiscoverFlags(true, nil, -1); err == nil || !strings.Contains(err.Error(), "--dm-min-messages") {
+		t.Errorf("expected --dm-min-messages error, got %v", err)
+	}
+}
+
+func TestFormatExportSummary_BudgetExhausted(t *testing.T) {
+	results := []ExportResultSummary{
+		{Name: "general", MessageCount: 200, DocsCreated: 3, BudgetExhausted: true},
+	}
+
+	var buf bytes.Buffer
+	errorCount := formatExportSummary(&buf, results, "", false)
+	output := buf.String()
+
+	if errorCount != 0 {
+		t.Errorf("errorCount = %d, want 0 (budget stop is not a failure)", errorCount)
+	}
+	if !strings.Contains(output, "[PARTIAL]") {
+		t.Error("missing PARTIAL status")
+	}
+	if !strings.Contains(output, "--sync") {
+		t.Error("missing hint to continue with --sync")
+	}
+}
+
+func TestValidateLocalFormats(t *testing.T) {
+	convs := []config.ConversationConfig{
+		{ID: "C001", Name: "general", Type: models.ConversationTypeChannel},
+		{ID: "D001", Name: "alice", Type: models.ConversationTypeDM, Format: config.OutputFormatMarkdown},
+	}
+	if err := validateLocalFormats(convs, "/tmp/export"); err != nil {
+		t.Errorf("with a local export dir: %v", err)
+	}
+	if err := validateLocalFormats(convs[:1], ""); err != nil {
+		t.Errorf("docs only, no local export dir: %v", err)
+	}
+	err := validateLocalFormats(convs, "")
+	if err == nil || !strings.Contains(err.Error(), "alice") {
+		t.Fatalf("markdown without a local export dir: err = %v, want an error naming alice", err)
+	}
+	if code := ExitCode(err); code != ExitConfig {
+		t.Errorf("ExitCode() = %d, want %d", code, ExitConfig)
+	}
+
+	var buf bytes.Buffer
+	formatExportDryRun(&buf, convs)
+	if !strings.Contains(buf.String(), "Format: markdown (local only)") {
+		t.Errorf("dry run should show the local format, got:\n%s", buf.String())
+	}
+}
+
+func TestParseFormatFlag(t *testing.T) {
+	if f, err := parseFormatFlag("", ""); err != nil || f != "" {
+		t.Errorf("unset: %q, %v", f, err)
+	}
+	if f, err := parseFormatFlag("HTML", "/tmp/export"); err != nil || f != config.OutputFormatHTML {
+		t.Errorf("html: %q, %v", f, err)
+	}
+	if f, err := parseFormatFlag("docs", ""); err != nil || f != config.OutputFormatDocs {
+		t.Errorf("docs without a local export dir: %q, %v", f, err)
+	}
+	for _, value := range []string{"pdf", "html"} {
+		_, err := parseFormatFlag(value, "")
+		if code := ExitCode(err); err == nil || code != ExitConfig {
+			t.Errorf("%s without a local export dir: err = %v, exit code %d", value, err, code)
+		}
+	}
+
+	convs := []config.ConversationConfig{{ID: "C001", Name: "general", Type: models.ConversationTypeChannel, Format: config.OutputFormatMarkdown}}
+	got := overrideFormat(convs, config.OutputFormatHTML)
+	if got[0].Format != config.OutputFormatHTML || convs[0].Format != config.OutputFormatMarkdown {
+		t.Errorf("overrideFormat() = %q, input %q; want html without changing the input", got[0].Format, convs[0].Format)
+	}
+	var buf bytes.Buffer
+	formatLocalExportDryRun(&buf, got, "/tmp/export")
+	if !strings.Contains(buf.String(), "channel-general/ (html)") {
+		t.Errorf("local export dry run = %s", buf.String())
+	}
+
+	buf.Reset()
+	formatLocalExportDryRun(&buf, overrideFormat(convs, config.OutputFormatSlack), "/tmp/export")
+	if !strings.Contains(buf.String(), "/tmp/export/slack-export/general/ (slack)") {
+		t.Errorf("local export dry run = %s", buf.String())
+	}
+
+	buf.Reset()
+	formatLocalExportDryRun(&buf, overrideFormat(convs, config.OutputFormatSQLite), "/tmp/export")
+	if !strings.Contains(buf.String(), "/tmp/export/get-out.sqlite (sqlite)") {
+		t.Errorf("local export dry run = %s", buf.String())
+	}
+}
+
+func TestActivityMode(t *testing.T) {
+	kinds, err := activityMode(false, activityKindNames(), []string{"C001"}, true, 5)
+	if err != nil || kinds != nil {
+		t.Errorf("without --activity = %v, %v; want nil, nil", kinds, err)
+	}
+
+	kinds, err = activityMode(true, []string{"saved", "mentions", "saved"}, nil, false, 0)
+	if err != nil {
+		t.Fatalf("activityMode() error: %v", err)
+	}
+	if want := []exporter.ActivityKind{exporter.ActivitySaved, exporter.ActivityMentions}; !slices.Equal(kinds, want) {
+		t.Errorf("kinds = %v, want %v", kinds, want)
+	}
+
+	for name, call := range map[string]func() error{
+		"conversation IDs": func() error {
+			_, err := activityMode(true, activityKindNames(), []string{"C001"}, false, 0)
+			return err
+		},
+		"selection flags":   func() error { _, err := activityMode(true, activityKindNames(), nil, true, 0); return err },
+		"sample":            func() error { _, err := activityMode(true, activityKindNames(), nil, false, 10); return err },
+		"unknown kind":      func() error { _, err := activityMode(true, []string{"stars"}, nil, false, 0); return err },
+		"no kinds selected": func() error { _, err := activityMode(true, nil, nil, false, 0); return err },
+	} {
+		if err := call(); ExitCode(err) != ExitConfig {
+			t.Errorf("%s: err = %v, want a usage error", name, err)
+		}
+	}
+}
+
+func TestPrintActivityResults(t *testing.T) {
+	var buf bytes.Buffer
+	err := printActivityResults(&buf, []*exporter.ActivityResult{
+		{Kind: exporter.ActivityMentions, Messages: 3, DocsCreated: 2, FolderURL: "https://drive.example/mentions"},
+		{Kind: exporter.ActivitySaved, Error: errors.New("stars.list is restricted in this workspace")},
+	})
+	out := buf.String()
+	if !strings.Contains(out, "✓ mentions: 3 messages, 2 docs") || !strings.Contains(out, "https://drive.example/mentions") {
+		t.Errorf("missing the mentions line and folder, got:\n%s", out)
+	}
+	if !strings.Contains(out, "✗ saved: ERROR: stars.list is restricted") {
+		t.Errorf("missing the failed feed, got:\n%s", out)
+	}
+	if ExitCode(err) != ExitPartial {
+		t.Errorf("err = %v, want a partial failure", err)
+	}
+}
@@ -11,7 +11,9 @@ import (
 	"time"
 
 	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/exporter"
 	"github.com/jflowers/get-out/pkg/ollama"
+	"github.com/spf13/cobra"
 )
 
 func TestParseDateFlag(t *testing.T) {
@@ -243,3 +245,104 @@ func TestResolveOllamaEndpoint_NilOllamaConfig(t *testing.T) {
 		t.Errorf("resolveOllamaEndpoint() = %q, want default %q", got, config.DefaultOllamaEndpoint)
 	}
 }
+
+func TestBuildEmailDigestSink(t *testing.T) {
+	t.Parallel()
+
+	if sink := buildEmailDigestSink(nil, ""); sink != nil {
+		t.Errorf("buildEmailDigestSink(nil) = %v, want nil", sink)
+	}
+	if sink := buildEmailDigestSink(&config.EmailDigestConfig{Enabled: false, SMTPHost: "smtp"}, ""); sink != nil {
+		t.Errorf("buildEmailDigestSink(disabled) = %v, want nil", sink)
+	}
+
+	cfg := &config.EmailDigestConfig{
+		Enabled:  true,
+		SMTPHost: "smtp.example.com",
+		SMTPPort: 587,
+		From:     "me@example.com",
+		To:       []string{"archive@example.com"},
+	}
+	if sink := buildEmailDigestSink(cfg, "secret"); sink == nil {
+		t.Error("buildEmailDigestSink(enabled) = nil, want sink")
+	}
+
+	gmailCfg := &config.EmailDigestConfig{Enabled: true, Transport: config.DigestTransportGmail, To: []string{"archive@example.com"}}
+	if _, ok := buildEmailDigestSink(gmailCfg, "").(*exporter.GmailDigestSink); !ok {
+		t.Error("buildEmailDigestSink(gmail) should send with Gmail")
+	}
+}
+
+func TestValidateDigestOnlyFlags(t *testing.T) {
+	enabled := &config.EmailDigestConfig{Enabled: true}
+	tests := []struct {
+		name       string
+		digestOnly bool
+		noDigest   bool
+		sample     int
+		activity   bool
+		digest     *config.EmailDigestConfig
+		wantErr    bool
+	}{
+		{"off", false, true, 5, true, nil, false},
+		{"with digest", true, false, 0, false, enabled, false},
+		{"no digest configured", true, false, 0, false, nil, true},
+		{"digest disabled", true, false, 0, false, &config.EmailDigestConfig{}, true},
+		{"no-email-digest", true, true, 0, false, enabled, true},
+		{"sample", true, false, 5, false, enabled, true},
+		{"activity", true, false, 0, true, enabled, true},
+	}
+	for _, tt := range tests {
+		err := validateDigestOnlyFlags(tt.digestOnly, tt.noDigest, tt.sample, tt.activity, tt.digest)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: validateDigestOnlyFlags() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestResolveSlackMaxRetries(t *testing.T) {
+	five := 5
+	settings := &config.Settings{SlackMaxRetries: &five}
+	newCmd := func() *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().Int("slack-max-retries", 3, "")
+		return cmd
+	}
+
+	if got := resolveSlackMaxRetries(newCmd(), 3, &config.Settings{}); got != nil {
+		t.Errorf("without flag or setting = %d, want the client default", *got)
+	}
+	if got := resolveSlackMaxRetries(newCmd(), 3, settings); got == nil || *got != 5 {
+		t.Errorf("from settings = %v, want 5", got)
+	}
+	cmd := newCmd()
+	cmd.Flags().Set("slack-max-retries", "0")
+	if got := resolveSlackMaxRetries(cmd, 0, settings); got == nil || *got != 0 {
+		t.Errorf("with --slack-max-retries 0 = %v, want retries disabled", got)
+	}
+}
+
+func TestResolveSlackTeam(t *testing.T) {
+	tests := []struct {
+		flag     string
+		settings *config.Settings
+		want     string
+	}{
+		{"T1", &config.Settings{SlackTeam: "acme"}, "T1"},
+		{"", &config.Settings{SlackTeam: "acme", SlackWorkspaceURL: "https://beta.slack.com"}, "acme"},
+		{"", &config.Settings{SlackWorkspaceURL: "https://beta.slack.com"}, ""},
+		{"", nil, ""},
+	}
+	for _, tt := range tests {
+		if got := resolveSlackTeam(tt.flag, tt.settings); got != tt.want {
+			t.Errorf("resolveSlackTeam(%q, %+v) = %q, want %q", tt.flag, tt.settings, got, tt.want)
+		}
+	}
+
+	if got := preferredSlackTeam(&config.Settings{SlackWorkspaceURL: "https://beta.slack.com"}); got != "beta" {
+		t.Errorf("preferredSlackTeam() = %q, want the workspace URL's team", got)
+	}
+	if got := preferredSlackTeam(&config.Settings{SlackWorkspaceURL: "https://app.slack.com"}); got != "" {
+		t.Errorf("preferredSlackTeam(app.slack.com) = %q, want none", got)
+	}
+}
@@ -0,0 +1,204 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/s3sync"
+)
+
+// Environment variables for running without a terminal or a browser, e.g.
+// as a container sidecar.
+const (
+	// headlessEnv, when true, is the same as --headless.
+	headlessEnv = "GET_OUT_HEADLESS"
+
+	// slackTokenEnv and slackCookieEnv supply Slack credentials instead of
+	// extracting them from Chrome. An xoxc- browser token needs its xoxd-
+	// cookie; xoxp- and xoxb- tokens are used on their own.
+	slackTokenEnv  = "GET_OUT_SLACK_TOKEN"
+	slackCookieEnv = "GET_OUT_SLACK_COOKIE"
+)
+
+// s3SyncStateFile, in the config directory, records what --s3 has uploaded.
+const s3SyncStateFile = "s3-sync.json"
+
+// headless disables everything that needs a person at a terminal or a
+// local browser: prompts, spinners, the OS keychain, and Chrome.
+var headless bool
+
+// applyHeadlessEnv turns on headless mode from GET_OUT_HEADLESS and applies
+// its implications, warning on w about those the user did not ask for.
+// Called before any command runs.
+func applyHeadlessEnv(w io.Writer) error {
+	if v := os.Getenv(headlessEnv); v != "" && !headless {
+		on, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q (use true or false)", headlessEnv, v)
+		}
+		headless = on
+	}
+	if headless && !noKeyring {
+		// Containers have no keychain service to talk to.
+		fmt.Fprintf(w, "Warning: headless mode stores secrets in plaintext files (0600) in %s, not the OS keychain; pass --no-keyring to acknowledge\n", configDir)
+		noKeyring = true
+	}
+	return nil
+}
+
+// slackCredentialsFromEnv returns the Slack token and cookie from the
+// environment. In headless mode a token is required, since there is no
+// Chrome to extract one from.
+func slackCredentialsFromEnv() (token, cookie string, err error) {
+	token, cookie = os.Getenv(slackTokenEnv), os.Getenv(slackCookieEnv)
+	if token == "" && headless {
+		return "", "", fmt.Errorf("headless mode needs Slack credentials: set %s (and %s for an xoxc- token)", slackTokenEnv, slackCookieEnv)
+	}
+	return token, cookie, nil
+}
+
+// resolveS3Sync returns the S3 URL the local export is uploaded to, from
+// the --s3 flag or settings.json, and a client for it with credentials from
+// the environment. Both are empty without either setting.
+func resolveS3Sync(flagValue string, settings *config.Settings, localExportDir string) (string, *s3sync.Client, error) {
+	raw := flagValue
+	if raw == "" {
+		raw = settings.S3ExportURL
+	}
+	if raw == "" {
+		return "", nil, nil
+	}
+	if localExportDir == "" {
+		return "", nil, fmt.Errorf("S3 upload (%s) needs a local export directory: set --local-export-dir or localExportOutputDir", raw)
+	}
+	cfg, err := s3sync.ConfigFromEnv(raw)
+	if err != nil {
+		return "", nil, err
+	}
+	return raw, s3sync.NewClient(cfg), nil
+}
+
+// healthState tracks scheduled export runs for the health endpoint.
+type healthState struct {
+	mu          sync.Mutex
+	Status      string    `json:"status"` // starting, running, ok, failing
+	Runs        int       `json:"runs"`
+	Failures    int       `json:"failures"`
+	LastStart   time.Time `json:"last_start,omitzero"`
+	LastSuccess time.Time `json:"last_success,omitzero"`
+	LastError   string    `json:"last_error,omitempty"`
+	NextRun     time.Time `json:"next_run,omitzero"`
+}
+
+func newHealthState() *healthState {
+	return &healthState{Status: "starting"}
+}
+
+func (h *healthState) start(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.Status = "running"
+	h.LastStart = now
+	h.NextRun = time.Time{}
+}
+
+func (h *healthState) finish(now time.Time, err error, next time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.Runs++
+	h.NextRun = next
+	if err != nil {
+		h.Status = "failing"
+		h.Failures++
+		h.LastError = err.Error()
+		return
+	}
+	h.Status = "ok"
+	h.LastSuccess = now
+	h.LastError = ""
+}
+
+// ServeHTTP reports the state as JSON: 200 unless the last run failed.
+func (h *healthState) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	data, err := json.MarshalIndent(h, "", "  ")
+	failing := h.Status == "failing"
+	h.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if failing {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_, _ = w.Write(append(data, '\n'))
+}
+
+// serveHealth serves h at /healthz on addr until the returned server is
+// closed.
+func serveHealth(addr string, h *healthState) (*http.Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start health endpoint: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", h)
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go srv.Serve(ln) //nolint:errcheck
+	return srv, nil
+}
+
+// runScheduled runs once every `every` (or once, when every is 0),
+// reporting each run to the health endpoint at healthAddr when set. A
+// failed run is reported and retried at the next interval rather than
+// ending the loop. SIGINT/SIGTERM stop the loop after the current run.
+func runScheduled(every time.Duration, healthAddr string, once func() error) error {
+	health := newHealthState()
+	if healthAddr != "" {
+		srv, err := serveHealth(healthAddr, health)
+		if err != nil {
+			return err
+		}
+		defer srv.Close()
+		statusf("Health endpoint: http://%s/healthz\n", healthAddr)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	for {
+		health.start(time.Now())
+		err := once()
+		var next time.Time
+		if every > 0 {
+			next = time.Now().Add(every)
+		}
+		health.finish(time.Now(), err, next)
+		if every == 0 {
+			return err
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Export failed: %v\n", err)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		statusf("\nNext export at %s\n\n", next.Local().Format("2006-01-02 15:04:05"))
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Until(next)):
+		}
+	}
+}
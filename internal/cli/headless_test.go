@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/s3sync"
+	"github.com/jflowers/get-out/pkg/secrets"
+)
+
+func TestApplyHeadlessEnv(t *testing.T) {
+	defer func(h, k bool) { headless, noKeyring = h, k }(headless, noKeyring)
+
+	tests := []struct {
+		env          string
+		wantHeadless bool
+		wantErr      bool
+	}{
+		{env: "", wantHeadless: false},
+		{env: "1", wantHeadless: true},
+		{env: "false", wantHeadless: false},
+		{env: "sometimes", wantErr: true},
+	}
+	for _, tt := range tests {
+		headless, noKeyring = false, false
+		t.Setenv(headlessEnv, tt.env)
+		var warnings bytes.Buffer
+		err := applyHeadlessEnv(&warnings)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("applyHeadlessEnv() with %q error = %v", tt.env, err)
+			continue
+		}
+		if headless != tt.wantHeadless || noKeyring != tt.wantHeadless {
+			t.Errorf("applyHeadlessEnv() with %q: headless=%v noKeyring=%v", tt.env, headless, noKeyring)
+		}
+		if got := strings.Contains(warnings.String(), "--no-keyring"); got != tt.wantHeadless {
+			t.Errorf("applyHeadlessEnv() with %q warned %q", tt.env, warnings.String())
+		}
+	}
+
+	// Asking for file storage explicitly silences the warning.
+	headless, noKeyring = true, true
+	var warnings bytes.Buffer
+	if err := applyHeadlessEnv(&warnings); err != nil || warnings.Len() != 0 {
+		t.Errorf("applyHeadlessEnv() with --no-keyring = %v, warned %q", err, warnings.String())
+	}
+}
+
+func TestResolveS3Sync(t *testing.T) {
+	t.Setenv(s3sync.EnvAccessKeyID, "AKID")
+	t.Setenv(s3sync.EnvSecretAccessKey, "secret")
+
+	if raw, client, err := resolveS3Sync("", &config.Settings{}, "/data/export"); raw != "" || client != nil || err != nil {
+		t.Errorf("without a URL = %q, %v, %v; want no upload", raw, client, err)
+	}
+	if _, _, err := resolveS3Sync("s3://exports", &config.Settings{}, ""); err == nil || !strings.Contains(err.Error(), "local export directory") {
+		t.Errorf("without a local export dir error = %v", err)
+	}
+	settings := &config.Settings{S3ExportURL: "s3://settings/slack"}
+	if raw, client, err := resolveS3Sync("s3://flag/slack", settings, "/data/export"); raw != "s3://flag/slack" || client == nil || err != nil {
+		t.Errorf("with flag = %q, %v, %v; want the flag's URL", raw, client, err)
+	}
+	if raw, _, err := resolveS3Sync("", settings, "/data/export"); raw != "s3://settings/slack" || err != nil {
+		t.Errorf("from settings = %q, %v", raw, err)
+	}
+
+	t.Setenv(s3sync.EnvAccessKeyID, "")
+	if _, _, err := resolveS3Sync("s3://flag/slack", settings, "/data/export"); err == nil {
+		t.Error("expected an error without AWS credentials")
+	}
+}
+
+func TestSlackCredentialsFromEnv(t *testing.T) {
+	defer func(h bool) { headless = h }(headless)
+	t.Setenv(slackTokenEnv, "")
+	t.Setenv(slackCookieEnv, "")
+
+	headless = false
+	if token, _, err := slackCredentialsFromEnv(); token != "" || err != nil {
+		t.Errorf("without token = %q, %v; want Chrome fallback", token, err)
+	}
+
+	headless = true
+	if _, _, err := slackCredentialsFromEnv(); err == nil || !strings.Contains(err.Error(), slackTokenEnv) {
+		t.Errorf("headless without token error = %v, want it to name %s", err, slackTokenEnv)
+	}
+
+	t.Setenv(slackTokenEnv, "xoxc-123")
+	t.Setenv(slackCookieEnv, "xoxd-456")
+	if token, cookie, err := slackCredentialsFromEnv(); token != "xoxc-123" || cookie != "xoxd-456" || err != nil {
+		t.Errorf("slackCredentialsFromEnv() = %q, %q, %v", token, cookie, err)
+	}
+}
+
+func TestCheckExportPrerequisites_EnvCredentials(t *testing.T) {
+	store := &secrets.FileStore{ConfigDir: t.TempDir()}
+	t.Setenv("GOOGLE_CLIENT_ID", "")
+	t.Setenv("GOOGLE_CLIENT_SECRET", "")
+	t.Setenv("GOOGLE_REFRESH_TOKEN", "")
+	if err := checkExportPrerequisites(&config.Settings{}, store); err == nil {
+		t.Fatal("checkExportPrerequisites() passed with no credentials")
+	}
+
+	t.Setenv("GOOGLE_CLIENT_ID", "id")
+	t.Setenv("GOOGLE_CLIENT_SECRET", "secret")
+	if err := checkExportPrerequisites(&config.Settings{}, store); err == nil || !strings.Contains(err.Error(), "token") {
+		t.Errorf("checkExportPrerequisites() without token error = %v", err)
+	}
+
+	t.Setenv("GOOGLE_REFRESH_TOKEN", "1//env")
+	if err := checkExportPrerequisites(&config.Settings{}, store); err != nil {
+		t.Errorf("checkExportPrerequisites() with environment credentials: %v", err)
+	}
+}
+
+func TestHealthState_ServeHTTP(t *testing.T) {
+	h := newHealthState()
+	get := func() (int, map[string]any) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		var body map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("health body is not JSON: %v", err)
+		}
+		return rec.Code, body
+	}
+
+	if code, body := get(); code != http.StatusOK || body["status"] != "starting" || body["last_start"] != nil {
+		t.Errorf("before any run = %d %v", code, body)
+	}
+
+	now := time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC)
+	h.start(now)
+	h.finish(now.Add(time.Minute), errors.New("Slack session expired"), now.Add(time.Hour))
+	if code, body := get(); code != http.StatusServiceUnavailable || body["status"] != "failing" || body["last_error"] != "Slack session expired" {
+		t.Errorf("after failed run = %d %v", code, body)
+	}
+
+	h.start(now.Add(time.Hour))
+	h.finish(now.Add(time.Hour+time.Minute), nil, now.Add(2*time.Hour))
+	code, body := get()
+	if code != http.StatusOK || body["status"] != "ok" || body["runs"] != float64(2) || body["failures"] != float64(1) || body["last_error"] != nil {
+		t.Errorf("after recovery = %d %v", code, body)
+	}
+}
+
+func TestRunScheduled_Once(t *testing.T) {
+	calls := 0
+	want := errors.New("boom")
+	err := runScheduled(0, "", func() error {
+		calls++
+		return want
+	})
+	if calls != 1 || err != want {
+		t.Errorf("runScheduled(0) = %v after %d runs, want one run returning its error", err, calls)
+	}
+}
@@ -3,6 +3,8 @@ package cli
 import (
 	"fmt"
 	"os"
+
+	"github.com/charmbracelet/huh"
 )
 
 // safePreview returns a masked preview of a credential string to avoid
@@ -22,12 +24,32 @@ func truncateURL(url string) string {
 	return url
 }
 
-// isTerminal returns true when stdin is a real TTY (interactive terminal).
-// Used to decide whether to show spinners and interactive prompts.
+// isTerminal returns true when stdin is a real TTY (interactive terminal)
+// and headless mode is off. Used to decide whether to show spinners and
+// interactive prompts.
 func isTerminal() bool {
+	if headless {
+		return false
+	}
 	fi, err := os.Stdin.Stat()
 	if err != nil {
 		return false
 	}
 	return (fi.Mode() & os.ModeCharDevice) != 0
 }
+
+// promptConfirm asks a yes/no question at the terminal.
+func promptConfirm(message string) (bool, error) {
+	var confirm bool
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title(message).
+				Value(&confirm),
+		),
+	)
+	if err := form.Run(); err != nil {
+		return false, err
+	}
+	return confirm, nil
+}
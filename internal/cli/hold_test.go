@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/exporter"
+)
+
+func TestFormatHoldReport(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := formatHoldReport(&buf, &exporter.HoldReport{}, true); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if !strings.Contains(buf.String(), "No legal hold records") {
+			t.Errorf("output = %q", buf.String())
+		}
+	})
+
+	t.Run("clean", func(t *testing.T) {
+		var buf bytes.Buffer
+		report := &exporter.HoldReport{Conversations: 2, Entries: 10, Manifests: 3}
+		if err := formatHoldReport(&buf, report, false); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		out := buf.String()
+		for _, want := range []string{"2 conversations, 10 entries", "not checked", "3 signed", "All legal hold records verified"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("output missing %q:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("problems", func(t *testing.T) {
+		var buf bytes.Buffer
+		report := &exporter.HoldReport{Conversations: 1, Entries: 1, Problems: []string{"C001: entry 1 (2024-02-01) has been modified"}}
+		err := formatHoldReport(&buf, report, true)
+		if err == nil || !strings.Contains(err.Error(), "1 problems") {
+			t.Errorf("error = %v, want problem count", err)
+		}
+		if !strings.Contains(buf.String(), "✗ C001: entry 1") {
+			t.Errorf("output = %q", buf.String())
+		}
+	})
+}
@@ -11,19 +11,24 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var listType string
+var (
+	listType string
+	listTags []string
+)
 
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List configured conversations",
 	Long: `List all conversations configured for export in conversations.json.
 
-Optionally filter by type (dm, mpim, channel, private_channel).`,
+Optionally filter by type (dm, mpim, channel, private_channel) or by tags
+set with 'get-out tag'.`,
 	RunE: runList,
 }
 
 func init() {
 	listCmd.Flags().StringVar(&listType, "type", "", "Filter by type: dm, mpim, channel, private_channel")
+	listCmd.Flags().StringSliceVar(&listTags, "tag", nil, "Only list conversations with any of these tags (repeatable)")
 	rootCmd.AddCommand(listCmd)
 }
 
@@ -33,7 +38,16 @@ func runList(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
-	listCore(os.Stdout, cfg.FilterByExport(), listType)
+	settings, err := config.LoadSettings(filepath.Join(configDir, "settings.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+	cfg.ApplyDefaults(settings.ConversationDefaults)
+	tags, index, err := loadTagFilter(listTags)
+	if err != nil {
+		return err
+	}
+	listCore(os.Stdout, filterByTags(cfg.FilterByExport(), index, tags), listType)
 	return nil
 }
 
@@ -83,11 +97,14 @@ func listCore(w io.Writer, conversations []config.ConversationConfig, typeFilter
 
 		fmt.Fprintf(w, "%s (%d):\n", typeNames[t], len(convs))
 		for _, c := range convs {
-			shareStr := ""
+			marks := ""
 			if c.Share {
-				shareStr = " [share]"
+				marks = " [share]"
+			}
+			if !c.WritesDocs() {
+				marks += fmt.Sprintf(" [%s]", c.OutputFormat())
 			}
-			fmt.Fprintf(w, "  %-12s %-30s%s\n", c.ID, c.Name, shareStr)
+			fmt.Fprintf(w, "  %-12s %-30s%s\n", c.ID, c.Name, marks)
 		}
 		fmt.Fprintln(w)
 	}
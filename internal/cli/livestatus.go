@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/exporter"
+)
+
+// statusPage renders a RunStatsSnapshot as a page that refreshes itself.
+var statusPage = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="5">
+<title>get-out export: {{.State}}</title>
+<style>
+body { font-family: -apple-system, sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; }
+td { padding: 0.2em 1em 0.2em 0; vertical-align: top; }
+.err { color: #b00; }
+</style>
+</head>
+<body>
+<h1>get-out export: {{.State}}</h1>
+<table>
+<tr><td>Conversations</td><td>{{.Done}} / {{.Conversations}}</td></tr>
+{{- range .Current}}
+<tr><td>Exporting</td><td>{{.}}</td></tr>
+{{- end}}
+<tr><td>Messages</td><td>{{.Messages}} ({{printf "%.1f" .MessagesPerSec}}/s)</td></tr>
+{{- if not .StartedAt.IsZero}}
+<tr><td>Started</td><td>{{.StartedAt.Format "2006-01-02 15:04:05"}}</td></tr>
+{{- end}}
+{{- if not .FinishedAt.IsZero}}
+<tr><td>Finished</td><td>{{.FinishedAt.Format "2006-01-02 15:04:05"}}</td></tr>
+{{- end}}
+</table>
+{{- if .RecentErrors}}
+<h2>Recent errors</h2>
+<ul>
+{{- range .RecentErrors}}
+<li class="err">{{.Time.Format "15:04:05"}} {{.Conversation}}: {{.Error}}</li>
+{{- end}}
+</ul>
+{{- end}}
+</body>
+</html>
+`))
+
+// statusHandler serves live run statistics: JSON at /status and a page
+// that refreshes every few seconds at /.
+func statusHandler(stats *exporter.RunStats) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		data, err := json.MarshalIndent(stats.Snapshot(), "", "  ")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(append(data, '\n'))
+	})
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := statusPage.Execute(w, stats.Snapshot()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+	return mux
+}
+
+// serveStatus serves stats on addr until the returned server is closed.
+func serveStatus(addr string, stats *exporter.RunStats) (*http.Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start status endpoint: %w", err)
+	}
+	srv := &http.Server{Handler: statusHandler(stats), ReadHeaderTimeout: 10 * time.Second}
+	go srv.Serve(ln) //nolint:errcheck
+	return srv, nil
+}
+
+// metricsHandler serves metrics in the Prometheus text format at /metrics.
+func metricsHandler(metrics *exporter.Metrics) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = metrics.WritePrometheus(w)
+	})
+	return mux
+}
+
+// serveMetrics serves metrics on addr until the returned server is closed.
+func serveMetrics(addr string, metrics *exporter.Metrics) (*http.Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start metrics endpoint: %w", err)
+	}
+	srv := &http.Server{Handler: metricsHandler(metrics), ReadHeaderTimeout: 10 * time.Second}
+	go srv.Serve(ln) //nolint:errcheck
+	return srv, nil
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/exporter"
+)
+
+func TestStatusHandler(t *testing.T) {
+	stats := exporter.NewRunStats()
+	stats.Start(2)
+	stats.Begin("general")
+	stats.AddMessages(5)
+	stats.Error("general", errors.New("Docs rejected <message>"))
+	h := statusHandler(stats)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("/status Content-Type = %q", ct)
+	}
+	var snap exporter.RunStatsSnapshot
+	if err := json.Unmarshal(rec.Body.Bytes(), &snap); err != nil {
+		t.Fatalf("/status body is not JSON: %v\n%s", err, rec.Body)
+	}
+	if snap.State != "running" || snap.Conversations != 2 || snap.Messages != 5 || len(snap.Current) != 1 {
+		t.Errorf("/status = %+v", snap)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	page := rec.Body.String()
+	for _, want := range []string{"0 / 2", "Exporting</td><td>general", "&lt;message&gt;"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page missing %q:\n%s", want, page)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/other", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("/other status = %d, want 404", rec.Code)
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	metrics := exporter.NewMetrics()
+	metrics.AddFetched("general", 3)
+	h := metricsHandler(metrics)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("/metrics Content-Type = %q", ct)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "get_out_messages_fetched_total 3\n") {
+		t.Errorf("/metrics body missing the fetched count:\n%s", body)
+	}
+}
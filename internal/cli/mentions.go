@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/exporter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mentionsOutput         string
+	mentionsLocalExportDir string
+	mentionsGraph          string
+)
+
+var mentionsCmd = &cobra.Command{
+	Use:   "mentions [user_id...]",
+	Short: "Write per-person pages of every message that @-mentions them",
+	Long: `Write one markdown page per person listing every exported message in which
+they were @-mentioned, grouped by conversation and linked to the daily Google Doc.
+
+Mentions are recorded by 'get-out export' into _metadata/mentions-index.json and
+accumulate across runs. This command only reads that index; no Slack or Google
+requests are made.
+
+If no user IDs are provided, a page is written for everyone with at least one
+mention. Pages go to --output, or to _mentions/ under the local export directory.
+
+With --graph csv or --graph graphml, the pages are not written. Instead, who
+mentions and replies to whom is written as a graph, with message counts per
+pair, to mentions-graph.csv or mentions-graph.graphml.`,
+	Example: `  # Write pages for everyone into <localExportOutputDir>/_mentions
+  get-out mentions
+
+  # Write a single person's page into a specific directory
+  get-out mentions U01ABC2DEF --output ~/Desktop/mentions
+
+  # Write the interaction graph for Gephi or networkx
+  get-out mentions --graph graphml`,
+	SilenceUsage: true,
+	RunE:         runMentions,
+}
+
+func init() {
+	mentionsCmd.Flags().StringVarP(&mentionsOutput, "output", "o", "", "Directory to write pages to (default: <local-export-dir>/_mentions)")
+	mentionsCmd.Flags().StringVar(&mentionsLocalExportDir, "local-export-dir", "", "Local export directory (overrides settings)")
+	mentionsCmd.Flags().StringVar(&mentionsGraph, "graph", "", "Write the mentions and replies graph instead of pages: csv or graphml")
+	rootCmd.AddCommand(mentionsCmd)
+}
+
+func runMentions(cmd *cobra.Command, args []string) error {
+	switch mentionsGraph {
+	case "", "csv", "graphml":
+	default:
+		return fmt.Errorf("invalid --graph %q: must be csv or graphml", mentionsGraph)
+	}
+	if mentionsGraph != "" && len(args) > 0 {
+		return fmt.Errorf("--graph covers everyone and cannot be combined with user IDs")
+	}
+
+	outDir := mentionsOutput
+	if outDir == "" {
+		settings, err := config.LoadSettings(filepath.Join(configDir, "settings.json"))
+		if err != nil {
+			return fmt.Errorf("failed to load settings: %w", err)
+		}
+		localExportDir := resolveLocalExportDir(mentionsLocalExportDir, settings)
+		if localExportDir == "" {
+			return fmt.Errorf("no output directory\n\nPass --output, or set localExportOutputDir in settings.json")
+		}
+		outDir = filepath.Join(localExportDir, "_mentions")
+	}
+	outDir, err := exporter.ExpandAndValidatePath(outDir)
+	if err != nil {
+		return fmt.Errorf("invalid output directory: %w", err)
+	}
+
+	index, err := exporter.LoadMentionIndex(exporter.DefaultMentionIndexPath(configDir))
+	if err != nil {
+		return err
+	}
+
+	if mentionsGraph != "" {
+		graph := exporter.BuildInteractionGraph(index)
+		path, err := writeMentionsGraph(outDir, mentionsGraph, graph)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Wrote a graph of %d people and %d pairs to %s\n", len(graph.Nodes), len(graph.Edges), path)
+		return nil
+	}
+
+	for _, id := range args {
+		if index.Person(id) == nil {
+			return fmt.Errorf("no mentions recorded for %s", id)
+		}
+	}
+
+	written, err := exporter.WriteMentionFiles(outDir, index, args)
+	if err != nil {
+		return fmt.Errorf("failed to write mention pages: %w", err)
+	}
+	formatMentionsResult(os.Stdout, index, args, written, outDir)
+	return nil
+}
+
+// formatMentionsResult writes a summary of the pages written.
+func formatMentionsResult(w io.Writer, index *exporter.MentionIndex, userIDs []string, written int, outDir string) {
+	if len(userIDs) == 0 {
+		userIDs = index.PersonIDs()
+	}
+	if len(userIDs) == 0 {
+		fmt.Fprintln(w, "No mentions recorded yet. Run 'get-out export' first.")
+		return
+	}
+	for _, id := range userIDs {
+		pm := index.Person(id)
+		if pm == nil {
+			continue
+		}
+		fmt.Fprintf(w, "  %-30s %5d mentions\n", truncateName(pm.Name, 30), len(pm.Mentions))
+	}
+	fmt.Fprintf(w, "\nWrote %d pages to %s\n", written, outDir)
+}
+
+// writeMentionsGraph writes graph into dir as mentions-graph.<format> and
+// returns the file's path.
+func writeMentionsGraph(dir, format string, graph *exporter.InteractionGraph) (string, error) {
+	var buf bytes.Buffer
+	var err error
+	if format == "graphml" {
+		err = graph.WriteGraphML(&buf)
+	} else {
+		err = graph.WriteCSV(&buf)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to render graph: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	path := filepath.Join(dir, "mentions-graph."+format)
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("failed to write graph: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/exporter"
+)
+
+func TestFormatMentionsResult(t *testing.T) {
+	idx := exporter.NewMentionIndex("")
+	idx.Add("U001", "Alice", &exporter.Mention{ConversationID: "C001", TS: "1.1"})
+	idx.Add("U001", "Alice", &exporter.Mention{ConversationID: "C001", TS: "1.2"})
+
+	var buf bytes.Buffer
+	formatMentionsResult(&buf, idx, nil, 1, "/tmp/export/_mentions")
+	out := buf.String()
+	if !strings.Contains(out, "Alice") || !strings.Contains(out, "2 mentions") {
+		t.Errorf("output missing per-person line:\n%s", out)
+	}
+	if !strings.Contains(out, "Wrote 1 pages to /tmp/export/_mentions") {
+		t.Errorf("output missing summary:\n%s", out)
+	}
+
+	buf.Reset()
+	formatMentionsResult(&buf, exporter.NewMentionIndex(""), nil, 0, "/tmp")
+	if !strings.Contains(buf.String(), "No mentions recorded yet") {
+		t.Errorf("empty output = %q", buf.String())
+	}
+}
+
+func TestWriteMentionsGraph(t *testing.T) {
+	idx := exporter.NewMentionIndex("")
+	idx.Add("U002", "Bob", &exporter.Mention{ConversationID: "C001", TS: "1.1", AuthorID: "U001", Author: "Alice"})
+	graph := exporter.BuildInteractionGraph(idx)
+
+	dir := filepath.Join(t.TempDir(), "out")
+	path, err := writeMentionsGraph(dir, "csv", graph)
+	if err != nil {
+		t.Fatalf("writeMentionsGraph() error: %v", err)
+	}
+	if path != filepath.Join(dir, "mentions-graph.csv") {
+		t.Errorf("path = %q", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "U001,Alice,U002,Bob,1,0,1") {
+		t.Errorf("csv =\n%s", data)
+	}
+}
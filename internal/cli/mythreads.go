@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/exporter"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/spf13/cobra"
+)
+
+var (
+	myThreadsUser   string
+	myThreadsOutput string
+)
+
+var myThreadsCmd = &cobra.Command{
+	Use:   "my-threads",
+	Short: "Report every exported thread you started or replied to",
+	Long: `Report every exported thread you started or replied to, across all
+conversations, with links to the thread folders in Google Drive, message
+counts, and last activity (most recent first).
+
+The report is built from the export index; no Slack or Google requests are
+made. Your Slack user ID is recorded by 'get-out export', so run an export
+first, or pass --user to report on someone else.`,
+	Example: `  # Print your thread report as markdown
+  get-out my-threads
+
+  # Save it to a file
+  get-out my-threads --output ~/Desktop/my-threads.md`,
+	SilenceUsage: true,
+	RunE:         runMyThreads,
+}
+
+func init() {
+	myThreadsCmd.Flags().StringVar(&myThreadsUser, "user", "", "Slack user ID to report on (default: the exporting user)")
+	myThreadsCmd.Flags().StringVarP(&myThreadsOutput, "output", "o", "", "Write the report to this file instead of stdout")
+	rootCmd.AddCommand(myThreadsCmd)
+}
+
+func runMyThreads(cmd *cobra.Command, args []string) error {
+	index, err := exporter.LoadExportIndex(exporter.DefaultIndexPath(configDir))
+	if err != nil {
+		return fmt.Errorf("failed to load export index: %w", err)
+	}
+
+	userID := myThreadsUser
+	if userID == "" {
+		userID = index.SelfUserID
+	}
+	if userID == "" {
+		return fmt.Errorf("your Slack user ID is not known yet\n\nRun 'get-out export' first, or pass --user")
+	}
+
+	var people *config.PeopleConfig
+	if p, err := config.LoadPeople(filepath.Join(configDir, "people.json")); err == nil {
+		people = p
+	}
+
+	if myThreadsOutput == "" {
+		return writeThreadReport(os.Stdout, index, userID, people)
+	}
+
+	f, err := os.Create(myThreadsOutput)
+	if err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+	if err := writeThreadReport(f, index, userID, people); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	fmt.Printf("Wrote thread report to %s\n", myThreadsOutput)
+	return nil
+}
+
+// writeThreadReport renders the thread report for userID to w. The name in
+// the heading comes from people.json or the export index user cache.
+func writeThreadReport(w io.Writer, index *exporter.ExportIndex, userID string, people *config.PeopleConfig) error {
+	name := parser.NewPersonResolver(people).ResolveName(userID)
+	if name == "" {
+		if u := index.GetUser(userID); u != nil && u.DisplayName != "" {
+			name = u.DisplayName
+		} else {
+			name = userID
+		}
+	}
+
+	if _, err := w.Write(exporter.RenderThreadReport(name, index.ThreadsForUser(userID))); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
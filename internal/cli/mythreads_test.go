@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/exporter"
+)
+
+func TestWriteThreadReport(t *testing.T) {
+	index := exporter.NewExportIndex("")
+	conv := index.GetOrCreateConversation("C001", "general", "channel")
+	conv.Threads["1700000000.000100"] = &exporter.ThreadExport{
+		ThreadTS: "1700000000.000100", FolderName: "Launch plan", StartedBy: "U001", ReplyCount: 5,
+	}
+	index.SetUser(&exporter.UserCache{ID: "U001", DisplayName: "alice"})
+
+	t.Run("name from people.json", func(t *testing.T) {
+		var buf bytes.Buffer
+		people := &config.PeopleConfig{People: []config.PersonConfig{{SlackID: "U001", DisplayName: "Alice A."}}}
+		if err := writeThreadReport(&buf, index, "U001", people); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), "# Threads for Alice A.") || !strings.Contains(buf.String(), "Launch plan") {
+			t.Errorf("unexpected report:\n%s", buf.String())
+		}
+	})
+
+	t.Run("name from user cache", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeThreadReport(&buf, index, "U001", nil); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), "# Threads for alice") {
+			t.Errorf("unexpected report:\n%s", buf.String())
+		}
+	})
+}
@@ -0,0 +1,416 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/jflowers/get-out/pkg/archive"
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/errcat"
+	"github.com/jflowers/get-out/pkg/exporter"
+	"github.com/jflowers/get-out/pkg/gdrive"
+	"github.com/spf13/cobra"
+)
+
+var (
+	packageLocalExportDir string
+	packageOutputDir      string
+	packageSplit          bool
+	packageSplitSizeMB    int
+	packageEncrypt        bool
+	packageDecryptOutput  string
+	packageUpload         bool
+	packageUploadFolderID string
+	packageForce          bool
+)
+
+// archiveFolderName is the Drive folder created under the export root for
+// uploaded archives when --upload-folder-id is not given.
+const archiveFolderName = "Archives"
+
+// archivePassphraseEnv names the environment variable that supplies the
+// archive passphrase non-interactively.
+const archivePassphraseEnv = "GET_OUT_ARCHIVE_PASSPHRASE"
+
+var packageCmd = &cobra.Command{
+	Use:   "package",
+	Short: "Bundle local export output into a zip archive",
+	Long: `Bundle local export output into a single timestamped zip archive.
+
+The archive contains the local markdown export directory and the export
+index, plus a manifest.json listing every file with its SHA-256 checksum
+and every exported conversation with its tags and notes.
+Use it to hand an archive to legal or move it off the machine.
+
+With --split, the archive is written as standalone parts of at most 2 GB
+(or --split-size MB). With --encrypt, each part is encrypted with a
+passphrase (AES-256-GCM) and gets a .enc extension; the passphrase is read
+from GET_OUT_ARCHIVE_PASSPHRASE or prompted for.
+
+With --upload, the parts are uploaded to Google Drive with resumable
+uploads and verified against Drive's MD5 checksum, keeping a second copy
+independent of the Google Docs export. Parts go to --upload-folder-id, or
+to an "Archives" folder under the configured export folder.`,
+	Example: `  # Package the local export into the current directory
+  get-out package
+
+  # Split into 2 GB parts and encrypt
+  get-out package --split --encrypt --output ~/Desktop
+
+  # Package and upload the parts to Drive
+  get-out package --split --upload
+
+  # Decrypt a part
+  get-out package decrypt get-out-export-20260421-103000.zip.enc`,
+	SilenceUsage: true,
+	RunE:         runPackage,
+}
+
+var packageDecryptCmd = &cobra.Command{
+	Use:          "decrypt <file.enc>",
+	Short:        "Decrypt an encrypted archive part",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runPackageDecrypt,
+}
+
+func init() {
+	packageCmd.Flags().StringVar(&packageLocalExportDir, "local-export-dir", "", "Local export directory to package (overrides settings)")
+	packageCmd.Flags().StringVarP(&packageOutputDir, "output", "o", ".", "Directory to write the archive to")
+	packageCmd.Flags().BoolVar(&packageSplit, "split", false, "Split the archive into parts of at most 2 GB")
+	packageCmd.Flags().IntVar(&packageSplitSizeMB, "split-size", 0, "Split the archive into parts of at most this many MB (implies --split)")
+	packageCmd.Flags().BoolVar(&packageEncrypt, "encrypt", false, "Encrypt each archive part with a passphrase")
+	packageCmd.Flags().BoolVar(&packageUpload, "upload", false, "Upload the archive parts to Google Drive")
+	packageCmd.Flags().StringVar(&packageUploadFolderID, "upload-folder-id", "", "Google Drive folder ID to upload into (default: Archives folder under the export folder)")
+	packageCmd.Flags().BoolVar(&packageForce, "force", false, "Break the export lock held by another run (use after a crash)")
+	packageDecryptCmd.Flags().StringVarP(&packageDecryptOutput, "output", "o", "", "Output path (default: input path without .enc)")
+	packageCmd.AddCommand(packageDecryptCmd)
+	rootCmd.AddCommand(packageCmd)
+}
+
+func runPackage(cmd *cobra.Command, args []string) error {
+	settings, err := config.LoadSettings(filepath.Join(configDir, "settings.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	localExportDir := resolveLocalExportDir(packageLocalExportDir, settings)
+	if localExportDir == "" {
+		return fmt.Errorf("no local export directory configured\n\nSet localExportOutputDir in settings.json or pass --local-export-dir")
+	}
+	localExportDir, err = exporter.ExpandAndValidatePath(localExportDir)
+	if err != nil {
+		return fmt.Errorf("invalid local export directory: %w", err)
+	}
+
+	splitSize, err := resolveSplitSize(packageSplit, packageSplitSizeMB)
+	if err != nil {
+		return err
+	}
+
+	// Check Google credentials before spending time on packaging.
+	if packageUpload {
+		if err := checkExportPrerequisites(settings, secretStore); err != nil {
+			return err
+		}
+	}
+
+	var passphrase string
+	if packageEncrypt {
+		passphrase, err = readArchivePassphrase(true)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The index is saved whole and packaged, so no export may be
+	// checkpointing it meanwhile.
+	runLock, err := exporter.AcquireRunLock(exporter.DefaultRunLockPath(configDir), "package", packageForce)
+	if err != nil {
+		return err
+	}
+	defer runLock.Release()
+
+	index, err := loadPackageIndex(configDir)
+	if err != nil {
+		return err
+	}
+
+	workspace, err := exporter.LoadWorkspaceSnapshot(exporter.DefaultWorkspacePath(configDir))
+	if err != nil {
+		return err
+	}
+
+	result, err := archive.Package(archive.Options{
+		Sources:       packageSources(localExportDir, configDir),
+		OutputDir:     packageOutputDir,
+		SplitSize:     splitSize,
+		Passphrase:    passphrase,
+		Conversations: manifestConversations(index),
+		Workspace:     manifestWorkspace(workspace),
+		OnProgress:    levelProgress(os.Stdout, outputLevel(), levelVerbose, nil),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to package export: %w", err)
+	}
+
+	runLock.Release()
+	formatPackageResult(os.Stdout, result)
+
+	if packageUpload {
+		return uploadPackage(settings, result.Parts)
+	}
+	return nil
+}
+
+// uploadPackage authenticates with Google Drive and uploads the archive parts.
+func uploadPackage(settings *config.Settings, parts []string) error {
+	ctx := context.Background()
+
+	client, err := newDriveClient(ctx, settings)
+	if err != nil {
+		return err
+	}
+
+	folderID := packageUploadFolderID
+	if folderID == "" {
+		folder, err := client.FindOrCreateFolder(ctx, archiveFolderName, resolveExportFolderID("", settings))
+		if err != nil {
+			return fmt.Errorf("failed to create archive folder: %w", err)
+		}
+		folderID = folder.ID
+	}
+
+	fmt.Println()
+	uploaded, err := uploadArchiveParts(ctx, client, parts, folderID, os.Stdout)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Uploaded and verified %d archive(s)\n", len(uploaded))
+	return nil
+}
+
+// newDriveClient authenticates with Google Drive using the credentials
+// file from settings, or the default one in the config directory.
+func newDriveClient(ctx context.Context, settings *config.Settings) (*gdrive.Client, error) {
+	gdriveCfg := gdrive.DefaultConfig(configDir)
+	if settings.GoogleCredentialsFile != "" {
+		gdriveCfg.CredentialsPath = settings.GoogleCredentialsFile
+		gdriveCfg.TokenPath = filepath.Join(filepath.Dir(settings.GoogleCredentialsFile), "token.json")
+	}
+	client, err := gdrive.NewClientFromStore(ctx, gdriveCfg, secretStore)
+	if err != nil {
+		return nil, errcat.Wrap(errcat.GoogleAuth, fmt.Errorf("failed to authenticate with Google: %w", err))
+	}
+	return client, nil
+}
+
+// archiveUploader uploads a local file to Drive. Satisfied by *gdrive.Client.
+type archiveUploader interface {
+	UploadLargeFile(ctx context.Context, path, mimeType, parentID string, onProgress gdrive.UploadProgress) (*gdrive.UploadedFile, error)
+}
+
+// uploadArchiveParts uploads each part in order, reporting progress in 10%
+// steps to w. It stops at the first failed part.
+func uploadArchiveParts(ctx context.Context, up archiveUploader, parts []string, folderID string, w io.Writer) ([]*gdrive.UploadedFile, error) {
+	var uploaded []*gdrive.UploadedFile
+	for _, part := range parts {
+		name := filepath.Base(part)
+		fmt.Fprintf(w, "Uploading %s...\n", name)
+
+		lastStep := int64(-1)
+		file, err := up.UploadLargeFile(ctx, part, archiveMimeType(part), folderID, func(sent, total int64) {
+			if total <= 0 {
+				return
+			}
+			if step := sent * 10 / total; step > lastStep {
+				lastStep = step
+				fmt.Fprintf(w, "  %3d%% (%s / %s)\n", step*10, formatBytes(sent), formatBytes(total))
+			}
+		})
+		if err != nil {
+			return uploaded, fmt.Errorf("failed to upload %s: %w", name, err)
+		}
+		fmt.Fprintf(w, "  verified md5 %s\n", file.MD5)
+		if file.URL != "" {
+			fmt.Fprintf(w, "  %s\n", file.URL)
+		}
+		uploaded = append(uploaded, file)
+	}
+	return uploaded, nil
+}
+
+// archiveMimeType returns the upload MIME type for an archive part.
+func archiveMimeType(path string) string {
+	if strings.HasSuffix(path, ".enc") {
+		return "application/octet-stream"
+	}
+	return "application/zip"
+}
+
+func runPackageDecrypt(cmd *cobra.Command, args []string) error {
+	src := args[0]
+	dst := packageDecryptOutput
+	if dst == "" {
+		if !strings.HasSuffix(src, ".enc") {
+			return fmt.Errorf("cannot derive output name from %s; pass --output", src)
+		}
+		dst = strings.TrimSuffix(src, ".enc")
+	}
+
+	passphrase, err := readArchivePassphrase(false)
+	if err != nil {
+		return err
+	}
+	if err := archive.DecryptFile(src, dst, passphrase); err != nil {
+		return err
+	}
+	fmt.Printf("Decrypted %s\n", dst)
+	return nil
+}
+
+// loadPackageIndex loads the export index and saves it whole, folding in
+// the checkpoints an export recorded in the index journal since the last
+// full save, so the export-index.json packaged is complete on its own.
+// The caller holds the run lock.
+func loadPackageIndex(configDir string) (*exporter.ExportIndex, error) {
+	index, err := exporter.LoadExportIndex(exporter.DefaultIndexPath(configDir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load export index: %w", err)
+	}
+	if err := index.Save(); err != nil {
+		return nil, fmt.Errorf("failed to save export index: %w", err)
+	}
+	return index, nil
+}
+
+// packageSources returns the directories and files included in an archive:
+// the local markdown export, the export index, the workspace snapshot, and
+// raw Slack responses (when archived with export --raw).
+func packageSources(localExportDir, configDir string) []archive.Source {
+	return []archive.Source{
+		{Path: localExportDir, Prefix: "markdown"},
+		{Path: exporter.DefaultIndexPath(configDir), Prefix: "_metadata"},
+		{Path: exporter.DefaultWorkspacePath(configDir), Prefix: "_metadata"},
+		{Path: exporter.DefaultRawDir(configDir), Prefix: "_raw"},
+	}
+}
+
+// manifestWorkspace converts the workspace snapshot taken at export time
+// for the archive manifest; nil when there is none.
+func manifestWorkspace(snap *exporter.WorkspaceSnapshot) *archive.ManifestWorkspace {
+	if snap == nil {
+		return nil
+	}
+	return &archive.ManifestWorkspace{
+		ID:             snap.TeamID,
+		Name:           snap.Name,
+		Domain:         snap.Domain,
+		EnterpriseID:   snap.EnterpriseID,
+		EnterpriseName: snap.EnterpriseName,
+		Plan:           snap.Plan,
+		IconURL:        snap.IconURL,
+		CapturedAt:     snap.CapturedAt,
+	}
+}
+
+// manifestConversations lists the conversations in the export index, with
+// their tags and notes, for the archive manifest.
+func manifestConversations(index *exporter.ExportIndex) []archive.ManifestConversation {
+	var convs []archive.ManifestConversation
+	for _, c := range index.AllConversations() {
+		convs = append(convs, archive.ManifestConversation{
+			ID:    c.ID,
+			Name:  c.Name,
+			Type:  c.Type,
+			Tags:  c.Tags,
+			Notes: c.Notes,
+		})
+	}
+	return convs
+}
+
+// resolveSplitSize converts the --split / --split-size flags into a part
+// size in bytes (0 = no split).
+func resolveSplitSize(split bool, sizeMB int) (int64, error) {
+	if sizeMB < 0 {
+		return 0, fmt.Errorf("--split-size must be >= 0, got %d", sizeMB)
+	}
+	if sizeMB > 0 {
+		return int64(sizeMB) << 20, nil
+	}
+	if split {
+		return archive.DefaultSplitSize, nil
+	}
+	return 0, nil
+}
+
+// readArchivePassphrase returns the passphrase from GET_OUT_ARCHIVE_PASSPHRASE
+// or, on a terminal, prompts for it (asking twice when confirm is set).
+func readArchivePassphrase(confirm bool) (string, error) {
+	if p := os.Getenv(archivePassphraseEnv); p != "" {
+		return p, nil
+	}
+	if !isTerminal() {
+		return "", fmt.Errorf("no passphrase: set %s or run interactively", archivePassphraseEnv)
+	}
+
+	var passphrase, again string
+	fields := []huh.Field{
+		huh.NewInput().
+			Title("Archive passphrase").
+			EchoMode(huh.EchoModePassword).
+			Value(&passphrase).
+			Validate(func(s string) error {
+				if s == "" {
+					return fmt.Errorf("passphrase must not be empty")
+				}
+				return nil
+			}),
+	}
+	if confirm {
+		fields = append(fields, huh.NewInput().
+			Title("Confirm passphrase").
+			EchoMode(huh.EchoModePassword).
+			Value(&again))
+	}
+	if err := huh.NewForm(huh.NewGroup(fields...)).Run(); err != nil {
+		return "", fmt.Errorf("prompt failed: %w", err)
+	}
+	if confirm && passphrase != again {
+		return "", fmt.Errorf("passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+// formatPackageResult writes a summary of the written archive parts to w.
+func formatPackageResult(w io.Writer, result *archive.Result) {
+	fmt.Fprintf(w, "Packaged %d files (%s uncompressed) into %d archive(s):\n",
+		result.Files, formatBytes(result.Bytes), len(result.Parts))
+	for _, part := range result.Parts {
+		size := ""
+		if info, err := os.Stat(part); err == nil {
+			size = " (" + formatBytes(info.Size()) + ")"
+		}
+		fmt.Fprintf(w, "  %s%s\n", part, size)
+	}
+}
+
+// formatBytes renders a byte count with a binary unit suffix.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
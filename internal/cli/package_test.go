@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/archive"
+	"github.com/jflowers/get-out/pkg/exporter"
+	"github.com/jflowers/get-out/pkg/gdrive"
+)
+
+func TestResolveSplitSize(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		split   bool
+		sizeMB  int
+		want    int64
+		wantErr bool
+	}{
+		{"no split", false, 0, 0, false},
+		{"default split", true, 0, archive.DefaultSplitSize, false},
+		{"explicit size", false, 100, 100 << 20, false},
+		{"explicit size wins", true, 10, 10 << 20, false},
+		{"negative", false, -1, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveSplitSize(tt.split, tt.sizeMB)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveSplitSize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolveSplitSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPackageSources(t *testing.T) {
+	t.Parallel()
+	sources := packageSources("/tmp/export", "/home/me/.get-out")
+	if len(sources) != 4 {
+		t.Fatalf("got %d sources, want 4", len(sources))
+	}
+	if sources[0].Path != "/tmp/export" || sources[0].Prefix != "markdown" {
+		t.Errorf("sources[0] = %+v", sources[0])
+	}
+	if sources[1].Path != filepath.Join("/home/me/.get-out", "_metadata", "export-index.json") {
+		t.Errorf("sources[1] = %+v", sources[1])
+	}
+	if sources[2].Path != filepath.Join("/home/me/.get-out", "_metadata", "workspace.json") || sources[2].Prefix != "_metadata" {
+		t.Errorf("sources[2] = %+v", sources[2])
+	}
+	if sources[3].Path != filepath.Join("/home/me/.get-out", "_raw") || sources[3].Prefix != "_raw" {
+		t.Errorf("sources[3] = %+v", sources[3])
+	}
+}
+
+func TestLoadPackageIndex_FoldsInJournal(t *testing.T) {
+	t.Parallel()
+	configDir := t.TempDir()
+	path := exporter.DefaultIndexPath(configDir)
+	index, err := exporter.LoadExportIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := index.Save(); err != nil {
+		t.Fatal(err)
+	}
+	// A checkpoint after the last full save goes only to the journal.
+	index.GetOrCreateConversation("C001", "general", "channel").MessageCount = 3
+	if err := index.SaveConversation("C001"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := loadPackageIndex(configDir); err != nil {
+		t.Fatalf("loadPackageIndex() error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"C001"`) {
+		t.Error("export-index.json lacks the journaled checkpoint, so the archive would too")
+	}
+}
+
+func TestFormatPackageResult(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	formatPackageResult(&buf, &archive.Result{
+		Parts: []string{"/nonexistent/a-001.zip", "/nonexistent/a-002.zip"},
+		Files: 12,
+		Bytes: 3 << 20,
+	})
+	out := buf.String()
+	for _, want := range []string{"12 files", "3.0 MiB", "2 archive(s)", "a-001.zip", "a-002.zip"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	t.Parallel()
+	tests := map[int64]string{
+		0:       "0 B",
+		1023:    "1023 B",
+		1024:    "1.0 KiB",
+		2 << 30: "2.0 GiB",
+	}
+	for n, want := range tests {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+// fakeArchiveUploader records uploads and simulates progress.
+type fakeArchiveUploader struct {
+	uploaded []string
+	failOn   string
+}
+
+func (f *fakeArchiveUploader) UploadLargeFile(_ context.Context, path, mimeType, parentID string, onProgress gdrive.UploadProgress) (*gdrive.UploadedFile, error) {
+	if filepath.Base(path) == f.failOn {
+		return nil, errors.New("network down")
+	}
+	f.uploaded = append(f.uploaded, path+"|"+mimeType+"|"+parentID)
+	onProgress(0, 100)
+	onProgress(55, 100)
+	onProgress(100, 100)
+	return &gdrive.UploadedFile{ID: "id", Name: filepath.Base(path), MD5: "abc123", URL: "https://drive.google.com/file/d/id/view"}, nil
+}
+
+func TestUploadArchiveParts(t *testing.T) {
+	t.Parallel()
+	up := &fakeArchiveUploader{}
+	var buf bytes.Buffer
+
+	files, err := uploadArchiveParts(context.Background(), up, []string{"/out/a-001.zip", "/out/a-002.zip.enc"}, "folder-1", &buf)
+	if err != nil {
+		t.Fatalf("uploadArchiveParts() error: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("got %d uploaded files, want 2", len(files))
+	}
+	if up.uploaded[0] != "/out/a-001.zip|application/zip|folder-1" ||
+		up.uploaded[1] != "/out/a-002.zip.enc|application/octet-stream|folder-1" {
+		t.Errorf("uploaded = %v", up.uploaded)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"Uploading a-001.zip", "  0%", " 50%", "100%", "verified md5 abc123"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestUploadArchiveParts_StopsOnError(t *testing.T) {
+	t.Parallel()
+	up := &fakeArchiveUploader{failOn: "a-001.zip"}
+
+	files, err := uploadArchiveParts(context.Background(), up, []string{"/out/a-001.zip", "/out/a-002.zip"}, "", io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "network down") {
+		t.Fatalf("expected upload error, got %v", err)
+	}
+	if len(files) != 0 || len(up.uploaded) != 0 {
+		t.Errorf("expected no further uploads after failure, got %v", up.uploaded)
+	}
+}
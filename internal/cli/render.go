@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/exporter"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/spf13/cobra"
+)
+
+var (
+	renderLocalExportDir      string
+	renderRawDir              string
+	renderNoSensitivityFilter bool
+	renderOllamaEndpoint      string
+	renderProfileStatus       bool
+)
+
+var renderCmd = &cobra.Command{
+	Use:   "render [conversation_id...]",
+	Short: "Re-render the local markdown export from raw Slack responses",
+	Long: `Re-render the local markdown export from raw Slack responses saved by
+'get-out export --raw', using the current version of get-out and the current
+settings (formatting, people.json, sensitivity filter).
+
+No Slack or Google requests are made. The sensitivity filter, when enabled,
+still uses the local Ollama server.
+
+If no conversation IDs are provided, every conversation in conversations.json
+with a raw archive is rendered. Existing daily markdown files are overwritten.`,
+	Example: `  # Re-render everything that has a raw archive
+  get-out render
+
+  # Re-render specific conversations into another directory
+  get-out render C789DEF012 --local-export-dir ~/export-v2`,
+	SilenceUsage:      true,
+	RunE:              runRender,
+	ValidArgsFunction: completeConversationIDs,
+}
+
+func init() {
+	renderCmd.Flags().StringVar(&renderLocalExportDir, "local-export-dir", "", "Directory to write markdown to (overrides settings)")
+	renderCmd.Flags().StringVar(&renderRawDir, "raw-dir", "", "Raw archive directory (default: <config-dir>/_raw)")
+	renderCmd.Flags().BoolVar(&renderNoSensitivityFilter, "no-sensitivity-filter", false, "Disable sensitivity filtering for this run")
+	renderCmd.Flags().StringVar(&renderOllamaEndpoint, "ollama-endpoint", "", "Override Ollama endpoint URL")
+	renderCmd.Flags().BoolVar(&renderProfileStatus, "include-profile-status", false, "Keep users' status in users.json")
+	rootCmd.AddCommand(renderCmd)
+}
+
+func runRender(cmd *cobra.Command, args []string) error {
+	settings, err := config.LoadSettings(filepath.Join(configDir, "settings.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+	applyTimeSettings(settings)
+	if settings.LegalHold {
+		return fmt.Errorf("render rewrites existing markdown files, which legal hold forbids\n\nDisable legalHold in settings.json to render")
+	}
+
+	localExportDir := resolveLocalExportDir(renderLocalExportDir, settings)
+	if localExportDir == "" {
+		return fmt.Errorf("no local export directory configured\n\nSet localExportOutputDir in settings.json or pass --local-export-dir")
+	}
+	localExportDir, err = exporter.ExpandAndValidatePath(localExportDir)
+	if err != nil {
+		return fmt.Errorf("invalid local export directory: %w", err)
+	}
+
+	rawDir := renderRawDir
+	if rawDir == "" {
+		rawDir = exporter.DefaultRawDir(configDir)
+	}
+
+	messageFilter, err := buildMessageFilter(settings, renderOllamaEndpoint, renderNoSensitivityFilter)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConversations(filepath.Join(configDir, "conversations.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	cfg.ApplyDefaults(settings.ConversationDefaults)
+	layouts, err := loadLayouts(settings, cfg)
+	if err != nil {
+		return err
+	}
+
+	var personResolver *parser.PersonResolver
+	if people, err := config.LoadPeople(filepath.Join(configDir, "people.json")); err == nil {
+		personResolver = parser.NewPersonResolver(people)
+	}
+
+	index, err := exporter.LoadExportIndex(exporter.DefaultIndexPath(configDir))
+	if err != nil {
+		return fmt.Errorf("failed to load export index: %w", err)
+	}
+
+	renderer := exporter.NewRenderer(&exporter.RendererConfig{
+		RawDir:               rawDir,
+		OutputDir:            localExportDir,
+		Index:                index,
+		MessageFilter:        messageFilter,
+		PersonResolver:       personResolver,
+		NamePolicy:           settings.NamePolicy,
+		Version:              buildVersion,
+		IncludeProfileStatus: renderProfileStatus,
+		Layouts:              layouts,
+		SkipEmojiMessages:    settings.SkipEmojiMessages,
+		OnProgress:           levelProgress(os.Stdout, outputLevel(), levelVerbose, nil),
+	})
+
+	conversations, err := selectRenderConversations(cfg, args, renderer.HasRawArchive)
+	if err != nil {
+		return err
+	}
+	if len(conversations) == 0 {
+		fmt.Printf("No raw archives found in %s\n\nRun 'get-out export --raw' first.\n", rawDir)
+		return nil
+	}
+
+	if err := renderer.LoadWorkspace(); err != nil {
+		return fmt.Errorf("failed to load raw workspace data: %w", err)
+	}
+
+	ctx := context.Background()
+	var results []*exporter.RenderResult
+	for _, conv := range conversations {
+		result, err := renderer.RenderConversation(ctx, conv)
+		if err != nil {
+			return fmt.Errorf("failed to render %s: %w", conv.Name, err)
+		}
+		results = append(results, result)
+	}
+	if err := renderer.WriteSlackExportFiles(conversations); err != nil {
+		return err
+	}
+
+	formatRenderResults(os.Stdout, results, localExportDir)
+	return nil
+}
+
+// selectRenderConversations returns the conversations named in args, or every
+// configured conversation for which hasRaw reports a raw archive.
+func selectRenderConversations(cfg *config.ConversationsConfig, args []string, hasRaw func(config.ConversationConfig) bool) ([]config.ConversationConfig, error) {
+	var result []config.ConversationConfig
+	if len(args) > 0 {
+		for _, id := range args {
+			conv := cfg.GetByID(id)
+			if conv == nil {
+				return nil, fmt.Errorf("conversation not found in config: %s", id)
+			}
+			if !hasRaw(*conv) {
+				return nil, fmt.Errorf("no raw archive for %s (%s)", conv.Name, conv.ID)
+			}
+			result = append(result, *conv)
+		}
+		return result, nil
+	}
+	for _, conv := range cfg.Conversations {
+		if hasRaw(conv) {
+			result = append(result, conv)
+		}
+	}
+	return result, nil
+}
+
+// formatRenderResults writes a per-conversation summary of a render run.
+func formatRenderResults(w io.Writer, results []*exporter.RenderResult, outputDir string) {
+	files, filtered := 0, 0
+	for _, r := range results {
+		line := fmt.Sprintf("  %s: %d messages, %d files", truncateName(r.Name, 30), r.MessageCount, r.FilesWritten)
+		if r.FilteredCount > 0 {
+			line += fmt.Sprintf(" (%d filtered)", r.FilteredCount)
+		}
+		fmt.Fprintln(w, line)
+		files += r.FilesWritten
+		filtered += r.FilteredCount
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Rendered %d conversations (%d files) into %s\n", len(results), files, outputDir)
+	if filtered > 0 {
+		fmt.Fprintf(w, "Sensitivity filter removed %d messages\n", filtered)
+	}
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/exporter"
+)
+
+func TestSelectRenderConversations(t *testing.T) {
+	cfg := testConversationsConfig()
+	hasRaw := func(c config.ConversationConfig) bool {
+		return c.ID == "C001ABC" || c.ID == "D004JKL"
+	}
+
+	t.Run("all with raw archives", func(t *testing.T) {
+		result, err := selectRenderConversations(cfg, nil, hasRaw)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(result) != 2 || result[0].ID != "C001ABC" || result[1].ID != "D004JKL" {
+			t.Errorf("result = %+v", result)
+		}
+	})
+
+	t.Run("by args", func(t *testing.T) {
+		result, err := selectRenderConversations(cfg, []string{"D004JKL"}, hasRaw)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(result) != 1 || result[0].ID != "D004JKL" {
+			t.Errorf("result = %+v", result)
+		}
+	})
+
+	t.Run("arg without raw archive", func(t *testing.T) {
+		_, err := selectRenderConversations(cfg, []string{"D003GHI"}, hasRaw)
+		if err == nil || !strings.Contains(err.Error(), "no raw archive") {
+			t.Errorf("error = %v, want no raw archive", err)
+		}
+	})
+
+	t.Run("unknown arg", func(t *testing.T) {
+		_, err := selectRenderConversations(cfg, []string{"CNOTEXIST"}, hasRaw)
+		if err == nil || !strings.Contains(err.Error(), "CNOTEXIST") {
+			t.Errorf("error = %v, want mention of missing ID", err)
+		}
+	})
+}
+
+func TestFormatRenderResults(t *testing.T) {
+	var buf bytes.Buffer
+	formatRenderResults(&buf, []*exporter.RenderResult{
+		{Name: "general", MessageCount: 10, FilesWritten: 3},
+		{Name: "dm-alice", MessageCount: 4, FilesWritten: 2, FilteredCount: 1},
+	}, "/tmp/export")
+
+	out := buf.String()
+	for _, want := range []string{
+		"general: 10 messages, 3 files",
+		"dm-alice: 4 messages, 2 files (1 filtered)",
+		"Rendered 2 conversations (5 files) into /tmp/export",
+		"Sensitivity filter removed 1 messages",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
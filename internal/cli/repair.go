@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jflowers/get-out/pkg/exporter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	repairFoldersDryRun bool
+	repairFoldersForce  bool
+)
+
+var repairFoldersCmd = &cobra.Command{
+	Use:   "repair-folders",
+	Short: "Separate conversations that were exported into the same Drive folder",
+	Long: `Find Drive folders that more than one conversation was exported into and
+give each of those conversations but one a folder of its own.
+
+Before conversation folder names were made unique, two DMs with people who
+share a display name were both exported into one "DM - John Smith" folder,
+their messages merged into the same daily docs. The conversation with the most
+exported messages keeps the folder. The others are detached in the export
+index: the next export writes their whole history again into a new folder
+named with a suffix of the conversation ID, such as "DM - John Smith (X4Y5Z)".
+
+Nothing is changed in Google Drive. Messages the detached conversations already
+wrote into the shared docs stay there; remove them by hand if needed.`,
+	Example: `  # List merged folders without changing anything
+  get-out repair-folders --dry-run
+
+  # Detach, then re-export the detached conversations
+  get-out repair-folders
+  get-out export --sync`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runRepairFolders,
+}
+
+func init() {
+	repairFoldersCmd.Flags().BoolVar(&repairFoldersDryRun, "dry-run", false, "List merged folders without changing the export index")
+	repairFoldersCmd.Flags().BoolVar(&repairFoldersForce, "force", false, "Break the export lock held by another run (use after a crash)")
+	rootCmd.AddCommand(repairFoldersCmd)
+}
+
+func runRepairFolders(cmd *cobra.Command, args []string) error {
+	// The index is loaded, changed, and saved whole, so no export may be
+	// checkpointing it meanwhile.
+	if !repairFoldersDryRun {
+		runLock, err := exporter.AcquireRunLock(exporter.DefaultRunLockPath(configDir), "repair-folders", repairFoldersForce)
+		if err != nil {
+			return err
+		}
+		defer runLock.Release()
+	}
+	index, err := exporter.LoadExportIndex(exporter.DefaultIndexPath(configDir))
+	if err != nil {
+		return fmt.Errorf("failed to load export index: %w", err)
+	}
+	return repairFoldersCore(os.Stdout, index, repairFoldersDryRun)
+}
+
+// repairFoldersCore reports the merged folders of index and, unless dryRun,
+// detaches every conversation but each folder's owner and saves the index.
+// Unless dryRun, the caller holds the run lock.
+func repairFoldersCore(w io.Writer, index *exporter.ExportIndex, dryRun bool) error {
+	merged := index.FindMergedFolders()
+	if len(merged) == 0 {
+		fmt.Fprintln(w, "No conversations share a Drive folder.")
+		return nil
+	}
+
+	name := func(id string) string {
+		if conv := index.GetConversation(id); conv != nil && conv.Name != "" && conv.Name != id {
+			return fmt.Sprintf("%s (%s)", conv.Name, id)
+		}
+		return id
+	}
+	detached := 0
+	for _, m := range merged {
+		fmt.Fprintf(w, "%s\n", m.FolderName)
+		if m.FolderURL != "" {
+			fmt.Fprintf(w, "  %s\n", m.FolderURL)
+		}
+		fmt.Fprintf(w, "  Keeps the folder: %s\n", name(m.Owner))
+		for _, id := range m.Moved {
+			fmt.Fprintf(w, "  Gets its own:     %s\n", name(id))
+			if !dryRun && index.DetachConversation(id) {
+				detached++
+			}
+		}
+	}
+
+	if dryRun {
+		fmt.Fprintf(w, "\n%d merged folders. Run without --dry-run to detach the conversations that get their own folder.\n", len(merged))
+		return nil
+	}
+	if err := index.Save(); err != nil {
+		return fmt.Errorf("failed to save export index: %w", err)
+	}
+	fmt.Fprintf(w, "\nDetached %d conversations. Run 'get-out export --sync' to export them into their own folders.\n", detached)
+	return nil
+}
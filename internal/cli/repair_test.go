@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/exporter"
+)
+
+func TestRepairFoldersCore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export-index.json")
+	index, err := exporter.LoadExportIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, messages := range map[string]int{"D001": 40, "D002": 7} {
+		conv := index.GetOrCreateConversation(id, "John Smith", "dm")
+		conv.FolderID = "folder-john"
+		conv.MessageCount = messages
+		conv.LastMessageTS = "1706788800.000100"
+	}
+
+	var buf bytes.Buffer
+	if err := repairFoldersCore(&buf, index, true); err != nil {
+		t.Fatalf("repairFoldersCore(dry run) error: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "DM - John Smith") || !strings.Contains(out, "Keeps the folder: John Smith (D001)") || !strings.Contains(out, "Gets its own:     John Smith (D002)") {
+		t.Errorf("dry run output:\n%s", out)
+	}
+	if index.GetConversation("D002").FolderID == "" {
+		t.Error("dry run detached a conversation")
+	}
+
+	buf.Reset()
+	if err := repairFoldersCore(&buf, index, false); err != nil {
+		t.Fatalf("repairFoldersCore() error: %v", err)
+	}
+	reloaded, err := exporter.LoadExportIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reloaded.GetConversation("D002"); got.FolderID != "" || got.LastMessageTS != "" {
+		t.Errorf("D002 after repair = %+v, want detached and saved", got)
+	}
+	if reloaded.GetConversation("D001").FolderID != "folder-john" {
+		t.Error("the folder's owner was detached")
+	}
+
+	buf.Reset()
+	if err := repairFoldersCore(&buf, reloaded, false); err != nil || !strings.Contains(buf.String(), "No conversations share") {
+		t.Errorf("second repair = %v:\n%s", err, buf.String())
+	}
+}
@@ -0,0 +1,227 @@
+package cli
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/exporter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reprocessLocalExportDir      string
+	reprocessNoSensitivityFilter bool
+	reprocessOllamaEndpoint      string
+	reprocessForce               bool
+)
+
+var reprocessCmd = &cobra.Command{
+	Use:   "reprocess [conversation_id...]",
+	Short: "Write messages that previously failed to render or write",
+	Long: `Write the messages that an export set aside in the dead-letter store
+(<config-dir>/_deadletter/<conversationID>.jsonl) because the Docs API
+rejected them or local markdown could not be written.
+
+Run it after fixing the cause (for example, upgrading get-out). Docs messages
+are appended to the end of their daily doc; markdown messages are written to
+their daily file. Messages that fail again stay in the store.
+
+If no conversation IDs are provided, every conversation with dead letters is
+reprocessed.
+
+Prerequisites are the same as for 'get-out export'.`,
+	Example: `  # Reprocess everything in the dead-letter store
+  get-out reprocess
+
+  # Reprocess one conversation
+  get-out reprocess C789DEF012`,
+	SilenceUsage:      true,
+	RunE:              runReprocess,
+	ValidArgsFunction: completeConversationIDs,
+}
+
+func init() {
+	reprocessCmd.Flags().StringVar(&reprocessLocalExportDir, "local-export-dir", "", "Directory for local markdown export (overrides settings)")
+	reprocessCmd.Flags().BoolVar(&reprocessNoSensitivityFilter, "no-sensitivity-filter", false, "Disable sensitivity filtering for this run")
+	reprocessCmd.Flags().StringVar(&reprocessOllamaEndpoint, "ollama-endpoint", "", "Override Ollama endpoint URL")
+	reprocessCmd.Flags().BoolVar(&reprocessForce, "force", false, "Break the export lock held by another run (use after a crash)")
+	rootCmd.AddCommand(reprocessCmd)
+}
+
+func runReprocess(cmd *cobra.Command, args []string) error {
+	settings, err := config.LoadSettings(filepath.Join(configDir, "settings.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+	applyTimeSettings(settings)
+	cfg, err := config.LoadConversations(filepath.Join(configDir, "conversations.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	cfg.ApplyDefaults(settings.ConversationDefaults)
+	layouts, err := loadLayouts(settings, cfg)
+	if err != nil {
+		return err
+	}
+
+	store := exporter.NewDeadLetterStore(exporter.DefaultDeadLetterDir(configDir))
+	pending, err := store.Conversations()
+	if err != nil {
+		return err
+	}
+	conversations, err := selectReprocessConversations(cfg, args, pending)
+	if err != nil {
+		return err
+	}
+	if len(conversations) == 0 {
+		fmt.Println("No dead letters to reprocess.")
+		return nil
+	}
+
+	localExportDir := resolveLocalExportDir(reprocessLocalExportDir, settings)
+	if localExportDir != "" {
+		localExportDir, err = exporter.ExpandAndValidatePath(localExportDir)
+		if err != nil {
+			return fmt.Errorf("invalid local export directory: %w", err)
+		}
+	}
+	messageFilter, err := buildMessageFilter(settings, reprocessOllamaEndpoint, reprocessNoSensitivityFilter)
+	if err != nil {
+		return err
+	}
+	if err := checkExportPrerequisites(settings, secretStore); err != nil {
+		return err
+	}
+
+	slackToken, slackCookie, err := slackCredentialsFromEnv()
+	if err != nil {
+		return err
+	}
+
+	runLock, err := exporter.AcquireRunLock(exporter.DefaultRunLockPath(configDir), "reprocess", reprocessForce)
+	if err != nil {
+		return err
+	}
+	defer runLock.Release()
+
+	var holdKey ed25519.PrivateKey
+	if settings.LegalHold {
+		if holdKey, err = exporter.LoadOrCreateHoldKey(secretStore); err != nil {
+			return err
+		}
+	}
+
+	level := outputLevel()
+	exp := exporter.NewExporter(&exporter.ExporterConfig{
+		ConfigDir:             configDir,
+		RootFolderName:        "Slack Exports",
+		RootFolderID:          resolveExportFolderID("", settings),
+		ChromePort:            chromePort,
+		Debug:                 level >= levelDebug,
+		GoogleCredentialsFile: settings.GoogleCredentialsFile,
+		LocalExportDir:        localExportDir,
+		MessageFilter:         messageFilter,
+		NamePolicy:            settings.NamePolicy,
+		Version:               buildVersion,
+		LegalHold:             settings.LegalHold,
+		FolderWarnItems:       settings.FolderWarnItems,
+		AutoFolderLayout:      settings.AutoFolderLayout,
+		GoogleQuota:           settings.GoogleQuota,
+		Layouts:               layouts,
+		SkipEmojiMessages:     settings.SkipEmojiMessages,
+		Images:                settings.Images,
+		SlackToken:            slackToken,
+		SlackCookie:           slackCookie,
+		OnProgress:            levelProgress(os.Stdout, level, levelVerbose, nil),
+		OnDetail:              levelProgress(os.Stdout, level, levelDetail, nil),
+	})
+
+	ctx := context.Background()
+	statusf("Initializing...\n")
+	if err := exp.InitializeWithStore(ctx, chromePort, secretStore); err != nil {
+		return fmt.Errorf("initialization failed: %w", err)
+	}
+	exp.SeedChannels(cfg.Conversations)
+	if err := exp.ValidateConnections(ctx); err != nil {
+		return err
+	}
+	ids := make([]string, len(conversations))
+	for i, conv := range conversations {
+		ids[i] = conv.ID
+	}
+	if err := exp.LoadUsersForConversations(ctx, ids); err != nil {
+		return err
+	}
+
+	runLock.Start(len(conversations))
+	var results []*exporter.ReprocessResult
+	for _, conv := range conversations {
+		runLock.Begin(conv.Name)
+		result, err := exp.ReprocessDeadLetters(ctx, conv)
+		runLock.Finish()
+		if err != nil {
+			return fmt.Errorf("failed to reprocess %s: %w", conv.Name, err)
+		}
+		results = append(results, result)
+	}
+	statusf("Google API requests: %s\n", exp.QuotaSummary())
+	if holdKey != nil {
+		manifest, err := exporter.WriteHoldManifest(exporter.DefaultHoldDir(configDir), holdKey)
+		if err != nil {
+			return fmt.Errorf("failed to write legal hold manifest: %w", err)
+		}
+		statusf("Legal hold manifest: %s\n", manifest)
+	}
+
+	formatReprocessResults(os.Stdout, results)
+	return nil
+}
+
+// selectReprocessConversations returns the conversations named in args, or
+// every configured conversation listed in pending.
+func selectReprocessConversations(cfg *config.ConversationsConfig, args []string, pending []string) ([]config.ConversationConfig, error) {
+	var result []config.ConversationConfig
+	if len(args) > 0 {
+		for _, id := range args {
+			conv := cfg.GetByID(id)
+			if conv == nil {
+				return nil, fmt.Errorf("conversation not found in config: %s", id)
+			}
+			result = append(result, *conv)
+		}
+		return result, nil
+	}
+	for _, id := range pending {
+		conv := cfg.GetByID(id)
+		if conv == nil {
+			fmt.Printf("Skipping dead letters for %s: not in conversations.json\n", id)
+			continue
+		}
+		result = append(result, *conv)
+	}
+	return result, nil
+}
+
+// formatReprocessResults writes a per-conversation summary of a reprocess run.
+func formatReprocessResults(w io.Writer, results []*exporter.ReprocessResult) {
+	written, failed := 0, 0
+	for _, r := range results {
+		line := fmt.Sprintf("  %s: %d of %d written", truncateName(r.Name, 30), r.Written, r.Attempted)
+		if r.Failed > 0 {
+			line += fmt.Sprintf(", %d still failing", r.Failed)
+		}
+		fmt.Fprintln(w, line)
+		written += r.Written
+		failed += r.Failed
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Reprocessed %d messages in %d conversations\n", written, len(results))
+	if failed > 0 {
+		fmt.Fprintf(w, "%d messages still fail and remain in %s\n", failed, exporter.DefaultDeadLetterDir(configDir))
+	}
+}
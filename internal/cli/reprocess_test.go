@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/exporter"
+)
+
+func TestSelectReprocessConversations(t *testing.T) {
+	cfg := testConversationsConfig()
+
+	t.Run("all pending", func(t *testing.T) {
+		result, err := selectReprocessConversations(cfg, nil, []string{"D004JKL", "CNOTEXIST"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(result) != 1 || result[0].ID != "D004JKL" {
+			t.Errorf("result = %+v, want only the configured pending conversation", result)
+		}
+	})
+
+	t.Run("by args", func(t *testing.T) {
+		result, err := selectReprocessConversations(cfg, []string{"C001ABC"}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(result) != 1 || result[0].ID != "C001ABC" {
+			t.Errorf("result = %+v", result)
+		}
+	})
+
+	t.Run("unknown arg", func(t *testing.T) {
+		_, err := selectReprocessConversations(cfg, []string{"CNOTEXIST"}, nil)
+		if err == nil || !strings.Contains(err.Error(), "CNOTEXIST") {
+			t.Errorf("error = %v, want mention of missing ID", err)
+		}
+	})
+}
+
+func TestFormatReprocessResults(t *testing.T) {
+	var buf bytes.Buffer
+	formatReprocessResults(&buf, []*exporter.ReprocessResult{
+		{Name: "general", Attempted: 3, Written: 3},
+		{Name: "dm-alice", Attempted: 2, Written: 1, Failed: 1},
+	})
+
+	out := buf.String()
+	for _, want := range []string{
+		"general: 3 of 3 written",
+		"dm-alice: 1 of 2 written, 1 still failing",
+		"Reprocessed 4 messages in 2 conversations",
+		"1 messages still fail",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
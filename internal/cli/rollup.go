@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/exporter"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rollupRawDir string
+	rollupSince  string
+)
+
+var rollupCmd = &cobra.Command{
+	Use:   "rollup [conversation_id...]",
+	Short: "Write weekly rollup docs summarizing each exported conversation",
+	Long: `Write one Google Doc per week for each exported conversation, in a
+"Weekly Rollups" folder inside the conversation's Drive folder. Each rollup
+gives the week's message and reply counts, its most active participants, its
+top threads by replies and reactions, and links to the week's daily docs.
+
+Messages come from the raw archive saved by 'get-out export --raw', and links
+come from the export index, so no Slack requests are made. Only messages the
+export index records as written to docs are summarized. Conversations
+without a raw archive, or not exported to Google Drive, are skipped. Running
+rollup again rewrites the docs of the weeks it covers; under legal hold,
+weeks that already have a rollup are left as they are.
+
+If no conversation IDs are provided, rollups are written for every
+conversation in conversations.json.`,
+	Example: `  # Write rollups for every conversation
+  get-out rollup
+
+  # Only recent weeks of one conversation
+  get-out rollup C789DEF012 --since 2026-01-01`,
+	SilenceUsage:      true,
+	RunE:              runRollup,
+	ValidArgsFunction: completeConversationIDs,
+}
+
+func init() {
+	rollupCmd.Flags().StringVar(&rollupRawDir, "raw-dir", "", "Raw archive directory (default: <config-dir>/_raw)")
+	rollupCmd.Flags().StringVar(&rollupSince, "since", "", "Only write weeks containing or after this date (YYYY-MM-DD)")
+	rootCmd.AddCommand(rollupCmd)
+}
+
+func runRollup(cmd *cobra.Command, args []string) error {
+	if rollupSince != "" {
+		if _, err := time.Parse("2006-01-02", rollupSince); err != nil {
+			return fmt.Errorf("invalid --since %q: expected YYYY-MM-DD", rollupSince)
+		}
+	}
+
+	settings, err := config.LoadSettings(filepath.Join(configDir, "settings.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+	applyTimeSettings(settings)
+
+	cfg, err := config.LoadConversations(filepath.Join(configDir, "conversations.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	for _, id := range args {
+		if cfg.GetByID(id) == nil {
+			return fmt.Errorf("conversation not found in config: %s", id)
+		}
+	}
+	index, err := exporter.LoadExportIndex(exporter.DefaultIndexPath(configDir))
+	if err != nil {
+		return fmt.Errorf("failed to load export index: %w", err)
+	}
+
+	rawDir := rollupRawDir
+	if rawDir == "" {
+		rawDir = exporter.DefaultRawDir(configDir)
+	}
+	users := parser.NewUserResolver()
+	users.SetNamePolicy(settings.NamePolicy)
+	channels := parser.NewChannelResolver()
+	if err := exporter.LoadRawWorkspace(rawDir, users, channels); err != nil {
+		return fmt.Errorf("failed to load raw workspace data: %w", err)
+	}
+	var people *parser.PersonResolver
+	if p, err := config.LoadPeople(filepath.Join(configDir, "people.json")); err == nil {
+		people = parser.NewPersonResolver(p)
+	}
+
+	ctx := context.Background()
+	client, err := newDriveClient(ctx, settings)
+	if err != nil {
+		return err
+	}
+	if settings.DocStyleTemplate != "" {
+		styles, err := client.LoadStylePolicy(ctx, settings.DocStyleTemplate)
+		if err != nil {
+			return fmt.Errorf("failed to read docStyleTemplate: %w", err)
+		}
+		client.SetStylePolicy(styles)
+	}
+
+	report := exporter.WriteRollups(ctx, client, index, cfg.Conversations, exporter.RollupOptions{
+		RawDir:          rawDir,
+		Since:           rollupSince,
+		ConversationIDs: args,
+		Users:           users,
+		Channels:        channels,
+		People:          people,
+		LegalHold:       settings.LegalHold,
+	})
+	formatRollupReport(os.Stdout, report)
+	if failed := report.Failed(); failed > 0 {
+		return fmt.Errorf("rollups failed for %d conversations", failed)
+	}
+	return nil
+}
+
+// formatRollupReport prints one line per conversation and a total.
+func formatRollupReport(w io.Writer, report *exporter.RollupReport) {
+	weeks := 0
+	for _, res := range report.Results {
+		name := res.Name
+		if name == "" {
+			name = res.ConversationID
+		}
+		name = truncateName(name, 30)
+		switch {
+		case res.Err != nil:
+			fmt.Fprintf(w, "  %-30s failed: %v\n", name, res.Err)
+		case res.Skipped != "":
+			fmt.Fprintf(w, "  %-30s skipped: %s\n", name, res.Skipped)
+		default:
+			fmt.Fprintf(w, "  %-30s %4d weeks  %s\n", name, res.Weeks, res.FolderURL)
+		}
+		weeks += res.Weeks
+	}
+	fmt.Fprintf(w, "\nWrote %d weekly rollups\n", weeks)
+}
@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/exporter"
+)
+
+func TestFormatRollupReport(t *testing.T) {
+	var buf bytes.Buffer
+	formatRollupReport(&buf, &exporter.RollupReport{Results: []exporter.RollupResult{
+		{ConversationID: "C001", Name: "general", Weeks: 3, FolderURL: "https://drive.google.com/f"},
+		{ConversationID: "C002", Skipped: "no messages"},
+		{ConversationID: "C003", Name: "random", Err: errors.New("quota")},
+	}})
+	out := buf.String()
+	for _, want := range []string{"general", "3 weeks  https://drive.google.com/f", "C002", "skipped: no messages", "failed: quota", "Wrote 3 weekly rollups"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
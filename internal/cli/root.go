@@ -3,6 +3,7 @@ package cli
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/jflowers/get-out/pkg/config"
 	"github.com/jflowers/get-out/pkg/secrets"
@@ -11,11 +12,14 @@ import (
 
 var (
 	// Global flags
-	debugMode  bool
-	chromePort int
-	configDir  string
-	verbose    bool
-	noKeyring  bool
+	debugMode    bool
+	chromePort   int
+	slackTeam    string
+	keepSlackTab bool
+	configDir    string
+	verbosity    int
+	quiet        bool
+	noKeyring    bool
 
 	// secretStore is the active SecretStore, initialized by PersistentPreRunE.
 	secretStore secrets.SecretStore
@@ -64,23 +68,43 @@ Quick Start:
   get-out export`,
 	SilenceUsage: true,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFlags(); err != nil {
+			return &usageError{err: err}
+		}
+		if err := applyHeadlessEnv(os.Stderr); err != nil {
+			return err
+		}
 		secretStore, secretBackend = secrets.NewStore(noKeyring, configDir)
 		return nil
 	},
 }
 
-// Execute runs the root command.
+// Execute runs the root command. Pass its error to ExitCode for the
+// process exit code.
 func Execute() error {
-	return rootCmd.Execute()
+	err := rootCmd.Execute()
+	if err != nil {
+		printErrorHint(rootCmd.ErrOrStderr(), err)
+	}
+	return err
 }
 
 func init() {
+	rootCmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return &usageError{err: err}
+	})
+
 	// Global flags
-	rootCmd.PersistentFlags().BoolVar(&debugMode, "debug", false, "Enable debug output")
+	rootCmd.PersistentFlags().BoolVar(&debugMode, "debug", false, "Enable debug output (same as -vvv)")
+	_ = rootCmd.PersistentFlags().MarkDeprecated("debug", "use -vvv instead")
 	rootCmd.PersistentFlags().IntVar(&chromePort, "chrome-port", 9222, "Chrome DevTools Protocol port")
+	rootCmd.PersistentFlags().StringVar(&slackTeam, "slack-team", "", "Slack workspace (team ID or domain) to use when Chrome is signed in to several (overrides settings)")
+	rootCmd.PersistentFlags().BoolVar(&keepSlackTab, "keep-slack-tab", false, "Leave open the Slack tab get-out opens to read credentials when none is open")
 	rootCmd.PersistentFlags().StringVar(&configDir, "config", defaultConfigDir(), "Config directory path")
-	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output")
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Increase output: -v progress, -vv detail, -vvv debug")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Print only the final summary and errors")
 	rootCmd.PersistentFlags().BoolVar(&noKeyring, "no-keyring", false, "Disable OS keychain; store secrets in plaintext files (0600)")
+	rootCmd.PersistentFlags().BoolVar(&headless, "headless", false, "Run without a terminal or browser: no prompts, no keychain, Slack token from GET_OUT_SLACK_TOKEN (also GET_OUT_HEADLESS=1)")
 }
 
 func defaultConfigDir() string {
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -13,6 +14,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/huh"
@@ -207,8 +209,8 @@ func runInit(cmd *cobra.Command, args []string) error {
 	// Step 7: Print next steps.
 	fmt.Println()
 	nextSteps := `Next Steps:
-  1. Copy credentials.json to ~/.get-out/
-     (Download from: https://console.cloud.google.com/apis/credentials)
+  1. Run: get-out setup-google
+     (creates the OAuth client and installs credentials.json)
   2. Run: get-out auth login
   3. Run: get-out setup-browser
   4. Run: get-out export`
@@ -332,17 +334,30 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 	// Check 1: Config directory (also reports active secret backend)
 	checkConfigDir(configDir, secretBackend, &passCount, &warnCount, &failCount)
 
-	// Check 2: credentials (via SecretStore)
-	checkSecret("credentials", secrets.KeyClientCredentials, secretStore, "Download credentials.json from: https://console.cloud.google.com/apis/credentials\n    Then run: get-out init  (to migrate to keychain)", &passCount, &failCount)
+	// Check 2: credentials (via environment or SecretStore)
+	envCfg := gdrive.DefaultConfig(configDir)
+	if envCfg.HasEnvCredentials() {
+		pass(fmt.Sprintf("credentials from %s / %s", gdrive.EnvClientID, gdrive.EnvClientSecret))
+		passCount++
+	} else {
+		checkSecret("credentials", secrets.KeyClientCredentials, secretStore, "Run: get-out setup-google", &passCount, &failCount)
+	}
 
-	// Check 3: token (via SecretStore)
-	tokenPresent := checkSecret("token", secrets.KeyOAuthToken, secretStore, "Run: get-out auth login", &passCount, &failCount)
+	// Check 3: token (via environment or SecretStore)
+	var tokenPresent bool
+	if envCfg.RefreshToken != "" {
+		pass("token from " + gdrive.EnvRefreshToken)
+		passCount++
+	} else {
+		tokenPresent = checkSecret("token", secrets.KeyOAuthToken, secretStore, "Run: get-out auth login", &passCount, &failCount)
+	}
 
-	// Check 4: OAuth token validity
-	gdriveAPIOK := false
+	// Check 4: OAuth token validity (an environment refresh token is
+	// exercised by the Drive API check instead)
+	gdriveAPIOK := envCfg.RefreshToken != ""
 	if tokenPresent {
 		gdriveAPIOK = checkTokenValidity(configDir, secretStore, &passCount, &warnCount, &failCount)
-	} else {
+	} else if !gdriveAPIOK {
 		fmt.Println(dimStyle.Render("  — OAuth token check skipped (token absent)"))
 	}
 
@@ -456,7 +471,7 @@ func checkConfigDir(dir string, backend secrets.Backend, pass_, warn_, fail_ *in
 		return
 	}
 	pass(fmt.Sprintf("Config directory exists (secret storage: %s)", backend))
-	if verbose {
+	if outputLevel() >= levelVerbose {
 		fmt.Println(dimStyle.Render("    " + dir))
 	}
 	*pass_++
@@ -492,7 +507,7 @@ func checkTokenValidity(dir string, store secrets.SecretStore, pass_, warn_, fai
 	}
 	if token.Valid() {
 		pass("OAuth token is valid")
-		if verbose {
+		if outputLevel() >= levelVerbose {
 			fmt.Println(dimStyle.Render(fmt.Sprintf("    Expires: %s", token.Expiry.Format(time.RFC3339))))
 		}
 		*pass_++
@@ -559,7 +574,7 @@ func checkConversations(dir string, pass_, warn_, fail_ *int) {
 		return
 	}
 	pass(fmt.Sprintf("conversations.json: %d conversation(s) configured", len(cfg.Conversations)))
-	if verbose {
+	if outputLevel() >= levelVerbose {
 		fmt.Println(dimStyle.Render("    " + path))
 	}
 	*pass_++
@@ -575,7 +590,7 @@ func checkPeople(dir string, pass_, warn_, fail_ *int) {
 		return
 	}
 	pass("people.json exists")
-	if verbose {
+	if outputLevel() >= levelVerbose {
 		fmt.Println(dimStyle.Render("    " + path))
 	}
 	*pass_++
@@ -659,15 +674,21 @@ func checkExportIndex(dir string, pass_, warn_, fail_ *int) {
 		*warn_++
 		return
 	}
-	_, err := exporter.LoadExportIndex(path)
+	index, err := exporter.LoadExportIndex(path)
 	if err != nil {
 		fail("export-index.json is corrupt: " + err.Error())
 		hint("Delete the file and re-run export to rebuild: rm " + path)
 		*fail_++
 		return
 	}
+	if err := index.Recovered(); err != nil {
+		warn("export-index.json is corrupt; its backup will be used: " + err.Error())
+		hint("The next export replaces it; progress saved after the backup is exported again")
+		*warn_++
+		return
+	}
 	pass("export-index.json is healthy")
-	if verbose {
+	if outputLevel() >= levelVerbose {
 		fmt.Println(dimStyle.Render("    " + path))
 	}
 	*pass_++
@@ -711,12 +732,20 @@ func checkOllama(endpoint, model string, pass_, warn_, fail_ *int) {
 // Auto-launch Chrome helpers
 // ---------------------------------------------------------------------------
 
-// chromeProfilePath returns the path to get-out's dedicated Chrome profile directory.
-func chromeProfilePath() (string, error) {
+// chromeProfilePath returns the path to get-out's dedicated Chrome profile
+// directory: configured (settings.json chromeProfilePath), with a leading ~
+// expanded, or ~/.get-out/chrome-data when it is empty.
+func chromeProfilePath(configured string) (string, error) {
+	if configured != "" && configured != "~" && !strings.HasPrefix(configured, "~/") {
+		return configured, nil
+	}
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("cannot determine home directory: %w", err)
 	}
+	if configured != "" {
+		return filepath.Join(home, strings.TrimPrefix(configured, "~")), nil
+	}
 	return filepath.Join(home, ".get-out", "chrome-data"), nil
 }
 
@@ -772,6 +801,91 @@ func launchChrome(profilePath string, port int, slackURL string) (*exec.Cmd, err
 	return cmd, nil
 }
 
+// slackLoginTimeout bounds how long export --launch-browser waits for the
+// user to sign in to Slack in the launched Chrome.
+const slackLoginTimeout = 10 * time.Minute
+
+// launchBrowserForExport makes sure Chrome runs with remote debugging on
+// port, starting it with the profile at profilePath on slackURL when
+// nothing listens there, and waits until its Slack tab holds credentials,
+// meaning the user has signed in. An existing profile keeps the Slack
+// session from the last run, so the wait is usually over at once.
+func launchBrowserForExport(ctx context.Context, w io.Writer, profilePath string, port int, slackURL, team string) error {
+	if isPortOpen(port) {
+		fmt.Fprintf(w, "Chrome already running on port %d\n", port)
+	} else {
+		if err := os.MkdirAll(profilePath, 0700); err != nil {
+			return fmt.Errorf("failed to create Chrome profile: %w", err)
+		}
+		fmt.Fprintf(w, "Launching Chrome with profile %s...\n", profilePath)
+		if _, err := launchChrome(profilePath, port, slackURL); err != nil {
+			return err
+		}
+		if err := pollUntil(ctx, 500*time.Millisecond, 10*time.Second, func(context.Context) error {
+			if !isPortOpen(port) {
+				return fmt.Errorf("Chrome did not open remote debugging port %d", port)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	prompted := false
+	return pollUntil(ctx, 2*time.Second, slackLoginTimeout, func(ctx context.Context) error {
+		err := slackSignedIn(ctx, port, team)
+		if err != nil && !prompted {
+			fmt.Fprintf(w, "Sign in to Slack in the Chrome window (%s); the export starts once you are signed in...\n", slackURL)
+			prompted = true
+		}
+		return err
+	})
+}
+
+// slackSignedIn reports whether the Chrome on port has a Slack tab from
+// which credentials of team (any workspace when empty) can be extracted.
+// Being signed in to several workspaces counts; the export then reports
+// that one must be chosen.
+func slackSignedIn(ctx context.Context, port int, team string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	session, err := chrome.Connect(ctx, &chrome.Config{DebugPort: port, Timeout: 5 * time.Second})
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+	_, err = session.ExtractCredentialsForTeam(ctx, team)
+	var ambiguous *chrome.AmbiguousTeamError
+	if errors.As(err, &ambiguous) {
+		return nil
+	}
+	return err
+}
+
+// pollUntil calls check every interval until it returns nil, returning the
+// last error of check once timeout has passed, or ctx's error when ctx is
+// cancelled first.
+func pollUntil(ctx context.Context, interval, timeout time.Duration, check func(context.Context) error) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := check(ctx)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return fmt.Errorf("timed out after %v: %w", timeout, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
 // ---------------------------------------------------------------------------
 // T028: setup-browser command
 // ---------------------------------------------------------------------------
@@ -813,7 +927,7 @@ func runSetupBrowser(cmd *cobra.Command, args []string) error {
 
 	// Step 1: Chrome profile
 	fmt.Print("Step 1  Chrome profile ... ")
-	profilePath, err := chromeProfilePath()
+	profilePath, err := chromeProfilePath(settings.ChromeProfilePath)
 	if err != nil {
 		fmt.Println(failStyle.Render("FAIL"))
 		fmt.Println(dimStyle.Render("  " + err.Error()))
@@ -911,7 +1025,7 @@ func runSetupBrowser(cmd *cobra.Command, args []string) error {
 	if stepFailed {
 		fmt.Println(dimStyle.Render("Skipped"))
 	} else {
-		cfg := &chrome.Config{DebugPort: chromePort, Timeout: 5 * time.Second}
+		cfg := &chrome.Config{DebugPort: chromePort, Timeout: 5 * time.Second, PreferTeam: preferredSlackTeam(settings)}
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
@@ -945,7 +1059,7 @@ func runSetupBrowser(cmd *cobra.Command, args []string) error {
 				} else {
 					slackTabFound = true
 					msg := fmt.Sprintf("found %d Slack tab(s)", found)
-					if verbose {
+					if outputLevel() >= levelVerbose {
 						msg += "  " + truncateURL(slackURL)
 					}
 					fmt.Println()
@@ -954,7 +1068,7 @@ func runSetupBrowser(cmd *cobra.Command, args []string) error {
 					// Extract credentials
 					ctx3, cancel3 := context.WithTimeout(context.Background(), 15*time.Second)
 					defer cancel3()
-					extracted, err := session.ExtractCredentials(ctx3)
+					extracted, err := session.ExtractCredentialsForTeam(ctx3, resolveSlackTeam(slackTeam, settings))
 					if err != nil {
 						fail("Credential extraction failed: " + err.Error())
 						stepFailed = true
@@ -1,7 +1,9 @@
 package cli
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -699,7 +701,7 @@ func TestChromeLaunchCmd(t *testing.T) {
 func TestChromeProfilePath(t *testing.T) {
 	t.Parallel()
 
-	path, err := chromeProfilePath()
+	path, err := chromeProfilePath("")
 	if err != nil {
 		t.Fatalf("chromeProfilePath() returned error: %v", err)
 	}
@@ -716,6 +718,60 @@ func TestChromeProfilePath(t *testing.T) {
 	}
 }
 
+func TestChromeProfilePath_Configured(t *testing.T) {
+	t.Parallel()
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Fatalf("os.UserHomeDir() error: %v", err)
+	}
+	for _, tt := range []struct{ configured, want string }{
+		{"/srv/chrome-profile", "/srv/chrome-profile"},
+		{"~/profiles/get-out", filepath.Join(home, "profiles", "get-out")},
+		{"~", home},
+	} {
+		got, err := chromeProfilePath(tt.configured)
+		if err != nil {
+			t.Fatalf("chromeProfilePath(%q) returned error: %v", tt.configured, err)
+		}
+		if got != tt.want {
+			t.Errorf("chromeProfilePath(%q) = %q, want %q", tt.configured, got, tt.want)
+		}
+	}
+}
+
+func TestPollUntil(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	err := pollUntil(context.Background(), time.Millisecond, time.Second, func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("not signed in")
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("pollUntil() = %v after %d calls, want nil after 3", err, calls)
+	}
+
+	err = pollUntil(context.Background(), time.Millisecond, 5*time.Millisecond, func(context.Context) error {
+		return errors.New("not signed in")
+	})
+	if err == nil || !strings.Contains(err.Error(), "timed out") || !strings.Contains(err.Error(), "not signed in") {
+		t.Errorf("pollUntil() = %v, want a timeout wrapping the last error", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = pollUntil(ctx, time.Hour, time.Hour, func(context.Context) error {
+		return errors.New("not signed in")
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("pollUntil() with a cancelled context = %v, want context.Canceled", err)
+	}
+}
+
 func TestFindChromeBinary(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,263 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/jflowers/get-out/pkg/exporter"
+	"github.com/jflowers/get-out/pkg/secrets"
+	"github.com/spf13/cobra"
+)
+
+// googleAPIs are the APIs get-out calls; both must be enabled in the
+// Cloud project that owns the OAuth client.
+var googleAPIs = []string{"drive.googleapis.com", "docs.googleapis.com"}
+
+var (
+	setupGoogleProject     string
+	setupGoogleCredentials string
+	setupGoogleNoGcloud    bool
+)
+
+var setupGoogleCmd = &cobra.Command{
+	Use:          "setup-google",
+	Short:        "Set up a Google Cloud project and OAuth client for get-out",
+	SilenceUsage: true,
+	Long: `Walk through creating the Google Cloud pieces get-out needs and install
+the resulting OAuth client credentials.
+
+Steps:
+  1. Pick the Google Cloud project (--project, or gcloud's current project)
+  2. Enable the Google Drive and Google Docs APIs
+  3. Configure the OAuth consent screen
+  4. Create an OAuth client ID of type "Desktop app" and download its JSON
+  5. Validate the downloaded JSON and save it to the secret store
+
+When the gcloud CLI is installed and signed in, steps 1 and 2 run
+automatically. Steps 3 and 4 have no gcloud equivalent for Desktop
+clients, so the wizard prints the console page to open for each.
+
+Examples:
+  get-out setup-google
+  get-out setup-google --project my-project
+  get-out setup-google --credentials-file ~/Downloads/client_secret_123.json`,
+	RunE: runSetupGoogle,
+}
+
+func init() {
+	setupGoogleCmd.Flags().StringVar(&setupGoogleProject, "project", "", "Google Cloud project ID")
+	setupGoogleCmd.Flags().StringVar(&setupGoogleCredentials, "credentials-file", "", "Downloaded OAuth client JSON to install")
+	setupGoogleCmd.Flags().BoolVar(&setupGoogleNoGcloud, "no-gcloud", false, "Do not use the gcloud CLI even if it is installed")
+	rootCmd.AddCommand(setupGoogleCmd)
+}
+
+// gcloudRunner runs a gcloud command and returns its trimmed stdout.
+type gcloudRunner func(args ...string) (string, error)
+
+// execGcloud runs the real gcloud binary.
+func execGcloud(args ...string) (string, error) {
+	cmd := exec.Command("gcloud", args...)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("gcloud %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func runSetupGoogle(cmd *cobra.Command, args []string) error {
+	fmt.Println("─────────────────────────────────────────")
+	fmt.Println("  get-out setup-google")
+	fmt.Println("─────────────────────────────────────────")
+	fmt.Println()
+
+	var gcloud gcloudRunner
+	if !setupGoogleNoGcloud {
+		if _, err := exec.LookPath("gcloud"); err == nil {
+			gcloud = execGcloud
+		}
+	}
+
+	// Step 1: Project
+	fmt.Print("Step 1  Google Cloud project ... ")
+	project := setupGoogleProject
+	if project == "" && gcloud != nil {
+		project = gcloudProject(gcloud)
+	}
+	switch {
+	case project != "":
+		fmt.Println(passStyle.Render("OK (" + project + ")"))
+	case gcloud != nil:
+		fmt.Println(warnStyle.Render("not set"))
+		hint("Create one at https://console.cloud.google.com/projectcreate, then re-run with --project <id>")
+	default:
+		fmt.Println(dimStyle.Render("gcloud not found — using the console"))
+		hint("Select or create a project at https://console.cloud.google.com/projectcreate")
+	}
+
+	// Step 2: APIs
+	fmt.Print("Step 2  Enable Drive and Docs APIs ... ")
+	if gcloud != nil && project != "" {
+		if err := enableGoogleAPIs(gcloud, project); err != nil {
+			fmt.Println(failStyle.Render("FAIL"))
+			hint(err.Error())
+			printAPILinks(project)
+		} else {
+			fmt.Println(passStyle.Render("OK"))
+		}
+	} else {
+		fmt.Println()
+		printAPILinks(project)
+	}
+
+	// Steps 3 and 4: console-only
+	fmt.Println("Step 3  OAuth consent screen")
+	hint("Open " + consoleURL("apis/credentials/consent", project))
+	hint("User type: Internal for Workspace accounts, otherwise External")
+	hint("For External, add your own Google account under Test users")
+	fmt.Println("Step 4  OAuth client ID")
+	hint("Open " + consoleURL("apis/credentials/oauthclient", project))
+	hint(`Application type: "Desktop app", then download the JSON`)
+	fmt.Println()
+
+	// Step 5: Install credentials
+	path := setupGoogleCredentials
+	if path == "" && isTerminal() {
+		var err error
+		if path, err = promptCredentialsPath(); err != nil && err != huh.ErrUserAborted {
+			return fmt.Errorf("prompt failed: %w", err)
+		}
+	}
+	fmt.Print("Step 5  Install OAuth client credentials ... ")
+	if path == "" {
+		fmt.Println(dimStyle.Render("Skipped"))
+		hint("Re-run with --credentials-file <downloaded JSON> when you have it")
+		return nil
+	}
+	clientID, err := installClientCredentials(secretStore, path)
+	if err != nil {
+		fmt.Println(failStyle.Render("FAIL"))
+		return err
+	}
+	fmt.Println(passStyle.Render("OK"))
+	fmt.Println(dimStyle.Render(fmt.Sprintf("  Client %s saved to %s", safePreview(clientID), secretBackend)))
+
+	fmt.Println()
+	fmt.Println("─────────────────────────────────────────")
+	fmt.Println(passStyle.Render("  Google setup complete. Run: get-out auth login"))
+	return nil
+}
+
+// gcloudProject returns gcloud's current project, or "" when none is set.
+func gcloudProject(gcloud gcloudRunner) string {
+	project, err := gcloud("config", "get-value", "project")
+	if err != nil || project == "(unset)" {
+		return ""
+	}
+	return project
+}
+
+// enableGoogleAPIs enables the Drive and Docs APIs in project. Enabling an
+// API that is already enabled is a no-op.
+func enableGoogleAPIs(gcloud gcloudRunner, project string) error {
+	args := append([]string{"services", "enable"}, googleAPIs...)
+	_, err := gcloud(append(args, "--project", project)...)
+	return err
+}
+
+func printAPILinks(project string) {
+	for _, api := range googleAPIs {
+		hint("Enable " + consoleURL("apis/library/"+api, project))
+	}
+}
+
+// consoleURL returns a Cloud Console link, scoped to project when known.
+func consoleURL(page, project string) string {
+	u := "https://console.cloud.google.com/" + page
+	if project != "" {
+		u += "?project=" + url.QueryEscape(project)
+	}
+	return u
+}
+
+// promptCredentialsPath asks for the downloaded OAuth client JSON.
+func promptCredentialsPath() (string, error) {
+	var path string
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Downloaded OAuth client JSON").
+				Description("Path to the client_secret_*.json file (leave empty to skip)").
+				Value(&path),
+		),
+	)
+	if err := form.Run(); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(path), nil
+}
+
+// clientCredentialsFile is the shape of an OAuth client JSON downloaded
+// from the Cloud Console.
+type clientCredentialsFile struct {
+	Installed *struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+	} `json:"installed"`
+	Web json.RawMessage `json:"web"`
+}
+
+// parseClientCredentials checks that data is a Desktop app OAuth client
+// and returns its client ID. Web clients are rejected because the login
+// flow needs a loopback redirect, which only Desktop clients allow.
+func parseClientCredentials(data []byte) (string, error) {
+	var creds clientCredentialsFile
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return "", fmt.Errorf("not an OAuth client JSON file: %w", err)
+	}
+	if creds.Installed == nil {
+		if creds.Web != nil {
+			return "", fmt.Errorf(`this is a "Web application" client; create a "Desktop app" client instead`)
+		}
+		return "", fmt.Errorf(`not an OAuth client JSON file (no "installed" section)`)
+	}
+	if creds.Installed.ClientID == "" || creds.Installed.ClientSecret == "" {
+		return "", fmt.Errorf("OAuth client JSON is missing client_id or client_secret")
+	}
+	return creds.Installed.ClientID, nil
+}
+
+// installClientCredentials validates the OAuth client JSON at path and
+// saves it to the secret store, replacing any existing credentials. A
+// saved token issued to a different client is deleted, since it cannot be
+// refreshed with the new one.
+func installClientCredentials(store secrets.SecretStore, path string) (string, error) {
+	path, err := exporter.ExpandAndValidatePath(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid credentials path: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read credentials: %w", err)
+	}
+	clientID, err := parseClientCredentials(data)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	if old, err := store.Get(secrets.KeyClientCredentials); err == nil {
+		if oldID, _ := parseClientCredentials([]byte(old)); oldID != clientID {
+			if err := store.Delete(secrets.KeyOAuthToken); err != nil {
+				return "", fmt.Errorf("failed to remove token for the previous client: %w", err)
+			}
+		}
+	}
+	if err := store.Set(secrets.KeyClientCredentials, string(data)); err != nil {
+		return "", fmt.Errorf("failed to save credentials: %w", err)
+	}
+	return clientID, nil
+}
@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/secrets"
+)
+
+const testDesktopClient = `{"installed":{"client_id":"123.apps.googleusercontent.com","client_secret":"s3cret","redirect_uris":["http://localhost"]}}`
+
+func TestParseClientCredentials(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantID  string
+		wantErr string
+	}{
+		{name: "desktop client", data: testDesktopClient, wantID: "123.apps.googleusercontent.com"},
+		{name: "web client", data: `{"web":{"client_id":"x","client_secret":"y"}}`, wantErr: "Desktop app"},
+		{name: "missing secret", data: `{"installed":{"client_id":"x"}}`, wantErr: "missing client_id or client_secret"},
+		{name: "service account", data: `{"type":"service_account"}`, wantErr: `no "installed" section`},
+		{name: "not json", data: `client_id=x`, wantErr: "not an OAuth client JSON file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := parseClientCredentials([]byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("parseClientCredentials() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || id != tt.wantID {
+				t.Errorf("parseClientCredentials() = %q, %v; want %q", id, err, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestInstallClientCredentials(t *testing.T) {
+	dir := t.TempDir()
+	store := &secrets.FileStore{ConfigDir: t.TempDir()}
+	path := filepath.Join(dir, "client_secret.json")
+	if err := os.WriteFile(path, []byte(testDesktopClient), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(secrets.KeyOAuthToken, `{"access_token":"old"}`); err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := installClientCredentials(store, path)
+	if err != nil {
+		t.Fatalf("installClientCredentials() error: %v", err)
+	}
+	if id != "123.apps.googleusercontent.com" {
+		t.Errorf("client ID = %q", id)
+	}
+	if got, _ := store.Get(secrets.KeyClientCredentials); got != testDesktopClient {
+		t.Errorf("stored credentials = %q", got)
+	}
+	// No previous credentials: the token is kept.
+	if _, err := store.Get(secrets.KeyOAuthToken); err != nil {
+		t.Errorf("token removed on first install: %v", err)
+	}
+
+	// A different client invalidates the saved token.
+	other := strings.Replace(testDesktopClient, "123.", "456.", 1)
+	if err := os.WriteFile(path, []byte(other), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := installClientCredentials(store, path); err != nil {
+		t.Fatalf("installClientCredentials() error: %v", err)
+	}
+	if _, err := store.Get(secrets.KeyOAuthToken); err == nil {
+		t.Error("token for the previous client was kept")
+	}
+
+	// Invalid files leave the store untouched.
+	if err := os.WriteFile(path, []byte(`{"web":{}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := installClientCredentials(store, path); err == nil {
+		t.Error("installClientCredentials() accepted a web client")
+	}
+	if got, _ := store.Get(secrets.KeyClientCredentials); got != other {
+		t.Errorf("stored credentials changed after a failed install")
+	}
+}
+
+func TestEnableGoogleAPIs(t *testing.T) {
+	var calls [][]string
+	gcloud := func(args ...string) (string, error) {
+		calls = append(calls, args)
+		return "", nil
+	}
+	if err := enableGoogleAPIs(gcloud, "my-project"); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"services", "enable", "drive.googleapis.com", "docs.googleapis.com", "--project", "my-project"}}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("gcloud calls = %v, want %v", calls, want)
+	}
+}
+
+func TestGcloudProject(t *testing.T) {
+	tests := []struct {
+		out  string
+		err  error
+		want string
+	}{
+		{out: "my-project", want: "my-project"},
+		{out: "(unset)", want: ""},
+		{err: fmt.Errorf("not signed in"), want: ""},
+	}
+	for _, tt := range tests {
+		gcloud := func(args ...string) (string, error) { return tt.out, tt.err }
+		if got := gcloudProject(gcloud); got != tt.want {
+			t.Errorf("gcloudProject() with %q, %v = %q, want %q", tt.out, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestConsoleURL(t *testing.T) {
+	if got := consoleURL("apis/credentials/consent", ""); got != "https://console.cloud.google.com/apis/credentials/consent" {
+		t.Errorf("consoleURL() without project = %q", got)
+	}
+	if got := consoleURL("apis/library/drive.googleapis.com", "my project"); got != "https://console.cloud.google.com/apis/library/drive.googleapis.com?project=my+project" {
+		t.Errorf("consoleURL() with project = %q", got)
+	}
+}
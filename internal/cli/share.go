@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/exporter"
+	"github.com/spf13/cobra"
+)
+
+var shareDryRun bool
+
+// shareCmd is the parent command group for Drive sharing sub-commands.
+var shareCmd = &cobra.Command{
+	Use:          "share",
+	Short:        "Reconcile who exported Drive folders are shared with",
+	SilenceUsage: true,
+	Long: `Compare who each exported conversation folder is shared with against
+conversations.json and people.json, and bring Drive in line with the config.
+
+A conversation with "share": true should be shared with its shareMembers,
+using each person's googleEmail from people.json when set. People marked
+"noShare" in people.json are not shared with, and "noNotifications" shares
+without an email. A conversation without "share" should be shared with no
+one. Anyone else with access, such as a person removed from shareMembers, is
+revoked.
+
+Owners, people the root export folder is shared with, and access inherited
+from a shared drive are left alone. Access that is not a person's, such as a
+link shared with anyone or a group, is reported as drift and left alone.
+Conversations in the export index that are no longer in conversations.json
+are skipped.
+
+Sub-commands:
+  sync    Share with missing members and revoke everyone else
+  revoke  Only revoke; report missing members without inviting them`,
+}
+
+var shareSyncCmd = &cobra.Command{
+	Use:   "sync [conversation-id...]",
+	Short: "Share exported folders with their members and revoke everyone else",
+	Example: `  # Show what would change
+  get-out share sync --dry-run
+
+  # Reconcile one conversation
+  get-out share sync C01ABCDEF`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runShare(args, false)
+	},
+}
+
+var shareRevokeCmd = &cobra.Command{
+	Use:   "revoke [conversation-id...]",
+	Short: "Revoke access of people no longer in the config",
+	Example: `  # Revoke access after removing someone from shareMembers
+  get-out share revoke`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runShare(args, true)
+	},
+}
+
+func init() {
+	for _, c := range []*cobra.Command{shareSyncCmd, shareRevokeCmd} {
+		c.Flags().BoolVar(&shareDryRun, "dry-run", false, "Report the changes without making them")
+		shareCmd.AddCommand(c)
+	}
+	rootCmd.AddCommand(shareCmd)
+}
+
+func runShare(convIDs []string, revokeOnly bool) error {
+	ctx := context.Background()
+
+	settings, err := config.LoadSettings(filepath.Join(configDir, "settings.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+	convs, err := config.LoadConversations(filepath.Join(configDir, "conversations.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load conversations: %w", err)
+	}
+	people, err := config.LoadPeople(filepath.Join(configDir, "people.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load people: %w", err)
+	}
+	index, err := exporter.LoadExportIndex(exporter.DefaultIndexPath(configDir))
+	if err != nil {
+		return fmt.Errorf("failed to load export index: %w", err)
+	}
+
+	client, err := newDriveClient(ctx, settings)
+	if err != nil {
+		return err
+	}
+
+	return shareCore(ctx, os.Stdout, client, index, convs.Conversations, people, exporter.ShareOptions{
+		RevokeOnly:      revokeOnly,
+		DryRun:          shareDryRun,
+		ConversationIDs: convIDs,
+	})
+}
+
+// shareCore reconciles sharing and prints the changes, grouped by
+// conversation. It returns an error when any change failed.
+func shareCore(ctx context.Context, w io.Writer, drive exporter.SharingDrive, index *exporter.ExportIndex, convs []config.ConversationConfig, people *config.PeopleConfig, opts exporter.ShareOptions) error {
+	report, err := exporter.ReconcileSharing(ctx, drive, index, convs, people, opts)
+	if err != nil {
+		return fmt.Errorf("failed to reconcile sharing: %w", err)
+	}
+	formatShareReport(w, report, opts.DryRun)
+	if failed := report.Failed(); failed > 0 {
+		return fmt.Errorf("%d sharing changes failed", failed)
+	}
+	return nil
+}
+
+// formatShareReport prints a ShareReport.
+func formatShareReport(w io.Writer, report *exporter.ShareReport, dryRun bool) {
+	grants, revokes, drift := 0, 0, 0
+	current := ""
+	for _, c := range report.Changes {
+		if c.ConversationID != current {
+			current = c.ConversationID
+			fmt.Fprintf(w, "%s (%s)\n", c.ConversationName, c.ConversationID)
+			if c.FolderURL != "" {
+				fmt.Fprintf(w, "  %s\n", c.FolderURL)
+			}
+		}
+		var line string
+		switch c.Action {
+		case exporter.ShareGrant:
+			grants++
+			line = fmt.Sprintf("+ share with %s (%s)", c.Who, c.Role)
+		case exporter.ShareRevoke:
+			revokes++
+			line = fmt.Sprintf("- revoke %s (%s)", c.Who, c.Role)
+		default:
+			drift++
+			line = fmt.Sprintf("! drift: %s (%s), left as is", c.Who, c.Role)
+		}
+		if c.Err != nil {
+			line += fmt.Sprintf(": failed: %v", c.Err)
+		}
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+	for _, id := range report.Skipped {
+		fmt.Fprintf(w, "Skipped %s: not in conversations.json\n", id)
+	}
+
+	if len(report.Changes) > 0 || len(report.Skipped) > 0 {
+		fmt.Fprintln(w)
+	}
+	if grants+revokes+drift == 0 {
+		fmt.Fprintf(w, "✓ %d folders match the config\n", report.Folders)
+		return
+	}
+	verb := "Made"
+	if dryRun {
+		verb = "Would make"
+	}
+	fmt.Fprintf(w, "%s %d shares and %d revocations in %d folders; %d drift left as is.\n", verb, grants, revokes, report.Folders, drift)
+	if dryRun {
+		fmt.Fprintln(w, "Run without --dry-run to apply them.")
+	}
+}
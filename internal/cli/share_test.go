@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/internal/testutil"
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/exporter"
+	"github.com/jflowers/get-out/pkg/gdrive"
+)
+
+func TestShareCore(t *testing.T) {
+	index := exporter.NewExportIndex(filepath.Join(t.TempDir(), "export-index.json"))
+	conv := index.GetOrCreateConversation("C001", "general", "channel")
+	conv.FolderID = "folder-general"
+	conv.FolderURL = "https://drive.google.com/drive/folders/folder-general"
+	index.GetOrCreateConversation("C009", "old", "channel").FolderID = "folder-old"
+
+	drive := testutil.NewFakeDrive()
+	drive.Permissions["folder-general"] = []gdrive.Permission{{ID: "p1", Type: "user", Role: "reader", EmailAddress: "bob@example.com"}}
+	convs := []config.ConversationConfig{{ID: "C001", Name: "general", Share: true, ShareMembers: []string{"alice@example.com"}}}
+
+	var buf bytes.Buffer
+	if err := shareCore(context.Background(), &buf, drive, index, convs, &config.PeopleConfig{}, exporter.ShareOptions{DryRun: true}); err != nil {
+		t.Fatalf("shareCore(dry run) error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"general (C001)\n  https://drive.google.com/drive/folders/folder-general\n",
+		"  - revoke bob@example.com (reader)\n",
+		"  + share with alice@example.com (reader)\n",
+		"Skipped C009: not in conversations.json",
+		"Would make 1 shares and 1 revocations in 1 folders",
+		"Run without --dry-run",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dry run output missing %q:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	if err := shareCore(context.Background(), &buf, drive, index, convs, &config.PeopleConfig{}, exporter.ShareOptions{}); err != nil {
+		t.Fatalf("shareCore() error: %v", err)
+	}
+	buf.Reset()
+	if err := shareCore(context.Background(), &buf, drive, index, convs, &config.PeopleConfig{}, exporter.ShareOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, "✓ 1 folders match the config") {
+		t.Errorf("output after sync:\n%s", out)
+	}
+}
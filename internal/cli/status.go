@@ -4,23 +4,37 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/jflowers/get-out/pkg/config"
 	"github.com/jflowers/get-out/pkg/exporter"
 	"github.com/spf13/cobra"
 )
 
+var statusTags []string
+
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show export status for all conversations",
 	Long: `Show the current export status for all conversations in the export index.
 
 Displays which conversations have been exported, their status (complete/in-progress),
-message counts, number of docs created, and last updated time.`,
+message counts, number of docs created, last updated time, and tags.
+Use --tag to show only conversations with any of the given tags.
+
+Conversations with a Drive folder of folderWarnItems (settings.json, default
+400) or more items are listed at the end, since Drive lists large folders
+slowly; set "layout": "year" on them in conversations.json.
+
+While an export or reprocess run holds the export lock, its PID and
+progress through the conversations are shown first.`,
 	RunE: runStatus,
 }
 
 func init() {
+	statusCmd.Flags().StringSliceVar(&statusTags, "tag", nil, "Only show conversations with any of these tags (repeatable)")
 	rootCmd.AddCommand(statusCmd)
 }
 
@@ -30,14 +44,65 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load export index: %w", err)
 	}
-	statusCore(os.Stdout, index)
+	tags, err := normalizeTags(statusTags)
+	if err != nil {
+		return err
+	}
+	settings, err := config.LoadSettings(filepath.Join(configDir, "settings.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+	lock, err := exporter.ReadRunLock(exporter.DefaultRunLockPath(configDir))
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	formatRunLock(os.Stdout, lock)
+	statusCore(os.Stdout, index, tags, settings.FolderWarnItems)
 	return nil
 }
 
-// statusCore formats and writes export status to w.
+// formatRunLock reports a held export lock: the running export's progress,
+// or that it was left behind by a crashed run. Writes nothing for nil.
+func formatRunLock(w io.Writer, lock *exporter.RunLockInfo) {
+	if lock == nil {
+		return
+	}
+	if lock.Stale() {
+		fmt.Fprintf(w, "Stale export lock from %s: the process is no longer running; the next export will clear it.\n\n", lock.Describe())
+		return
+	}
+	fmt.Fprintf(w, "Export in progress (PID %d", lock.PID)
+	if lock.Total > 0 {
+		fmt.Fprintf(w, ", %d%% of conversations", lock.Percent())
+	}
+	fmt.Fprintf(w, ")\n")
+	fmt.Fprintf(w, "  %s started %s", lock.Command, lock.StartedAt.Local().Format("2006-01-02 15:04"))
+	if lock.Total > 0 {
+		fmt.Fprintf(w, ", %d/%d conversations done", lock.Done, lock.Total)
+	}
+	fmt.Fprintln(w)
+	if lock.Current != "" {
+		fmt.Fprintf(w, "  Current: %s (updated %s)\n", lock.Current, lock.UpdatedAt.Local().Format("15:04:05"))
+	}
+	fmt.Fprintln(w)
+}
+
+// statusCore formats and writes export status to w, limited to
+// conversations with any of tags when tags is non-empty, and lists
+// conversations with a Drive folder of at least warnItems items
+// (0 = config.DefaultFolderWarnItems).
 // Returns (totalConversations, completeCount) for summary.
-func statusCore(w io.Writer, index *exporter.ExportIndex) (int, int) {
-	convs := index.AllConversations()
+func statusCore(w io.Writer, index *exporter.ExportIndex, tags []string, warnItems int) (int, int) {
+	var convs []*exporter.ConversationExport
+	for _, conv := range index.AllConversations() {
+		if conv.HasAnyTag(tags) {
+			convs = append(convs, conv)
+		}
+	}
+	if len(convs) == 0 && len(tags) > 0 {
+		fmt.Fprintf(w, "No exported conversations tagged %s.\n", strings.Join(tags, " or "))
+		return 0, 0
+	}
 	if len(convs) == 0 {
 		fmt.Fprintln(w, "No exports found. Run 'get-out export' to start exporting.")
 		return 0, 0
@@ -60,6 +125,7 @@ func statusCore(w io.Writer, index *exporter.ExportIndex) (int, int) {
 	totalDocs := 0
 	totalThreads := 0
 	complete := 0
+	var failed, shared []*exporter.ConversationExport
 
 	for _, conv := range convs {
 		status := conv.Status
@@ -67,11 +133,20 @@ func statusCore(w io.Writer, index *exporter.ExportIndex) (int, int) {
 			status = "unknown"
 		}
 		statusIcon := "⏸"
-		if status == "complete" {
+		switch status {
+		case exporter.StatusComplete:
 			statusIcon = "✅"
 			complete++
-		} else if status == "in_progress" {
+		case exporter.StatusInProgress:
 			statusIcon = "🔄"
+		case exporter.StatusPending:
+			statusIcon = "⏳"
+		case exporter.StatusFailed:
+			statusIcon = "❌"
+			failed = append(failed, conv)
+		case exporter.StatusShared:
+			statusIcon = "🔗"
+			shared = append(shared, conv)
 		}
 
 		docCount := len(conv.DailyDocs)
@@ -87,8 +162,12 @@ func statusCore(w io.Writer, index *exporter.ExportIndex) (int, int) {
 			name = name[:27] + "..."
 		}
 
-		fmt.Fprintf(w, "  %s %-8s %-8s %-30s %6d %5d %5d  %s\n",
+		line := fmt.Sprintf("  %s %-8s %-8s %-30s %6d %5d %5d  %s",
 			statusIcon, status, conv.Type, name, conv.MessageCount, docCount, threadCount, lastUpdated)
+		if len(conv.Tags) > 0 {
+			line += "  [" + strings.Join(conv.Tags, ", ") + "]"
+		}
+		fmt.Fprintln(w, line)
 
 		totalMsgs += conv.MessageCount
 		totalDocs += docCount
@@ -98,5 +177,47 @@ func statusCore(w io.Writer, index *exporter.ExportIndex) (int, int) {
 	fmt.Fprintf(w, "\nSummary: %d conversations (%d complete), %d messages, %d docs, %d threads\n",
 		len(convs), complete, totalMsgs, totalDocs, totalThreads)
 
+	if len(failed) > 0 {
+		fmt.Fprintf(w, "\nFailed exports (run 'get-out export --resume' to retry from the checkpoint):\n")
+		for _, conv := range failed {
+			detail := conv.Error
+			if detail == "" {
+				detail = "no error recorded"
+			}
+			fmt.Fprintf(w, "  %s: %s\n", conv.Name, detail)
+		}
+	}
+
+	if len(shared) > 0 {
+		fmt.Fprintf(w, "\nShared channels exported from another workspace:\n")
+		for _, conv := range shared {
+			fmt.Fprintf(w, "  %s: %s\n", conv.Name, conv.SharedWith)
+		}
+	}
+
+	if warnItems <= 0 {
+		warnItems = config.DefaultFolderWarnItems
+	}
+	var large []string
+	for _, conv := range convs {
+		if n := conv.LargestFolder(); n >= warnItems {
+			large = append(large, fmt.Sprintf("  %s: %d items (layout: %s)", conv.Name, n, layoutName(conv.Layout)))
+		}
+	}
+	if len(large) > 0 {
+		fmt.Fprintf(w, "\nLarge Drive folders (%d+ items; consider \"layout\": \"year\" in conversations.json):\n", warnItems)
+		for _, line := range large {
+			fmt.Fprintln(w, line)
+		}
+	}
+
 	return len(convs), complete
 }
+
+// layoutName returns a conversation's folder layout for display.
+func layoutName(layout string) string {
+	if layout == "" {
+		return string(config.FolderLayoutFlat)
+	}
+	return layout
+}
@@ -2,6 +2,8 @@ package cli
 
 import (
 	"bytes"
+	"fmt"
+	"os"
 	"strings"
 	"testing"
 	"time"
@@ -13,7 +15,7 @@ func TestStatusCore_NoConversations(t *testing.T) {
 	index := exporter.NewExportIndex("")
 
 	var buf bytes.Buffer
-	total, complete := statusCore(&buf, index)
+	total, complete := statusCore(&buf, index, nil, 0)
 	out := buf.String()
 
 	if total != 0 {
@@ -72,7 +74,7 @@ func TestStatusCore_WithConversations(t *testing.T) {
 	})
 
 	var buf bytes.Buffer
-	total, complete := statusCore(&buf, index)
+	total, complete := statusCore(&buf, index, nil, 0)
 	out := buf.String()
 
 	// Verify return values
@@ -125,3 +127,99 @@ func TestStatusCore_WithConversations(t *testing.T) {
 		t.Errorf("expected 'STATUS' table header in output, got:\n%s", out)
 	}
 }
+
+func TestStatusCore_FailedAndPending(t *testing.T) {
+	index := exporter.NewExportIndex("")
+	index.SetConversation(&exporter.ConversationExport{
+		ID: "C001", Name: "general", Type: "channel", Status: exporter.StatusFailed,
+		Error: "failed to write messages for 2024-02-01: quota exceeded",
+	})
+	index.SetConversation(&exporter.ConversationExport{
+		ID: "C002", Name: "random", Type: "channel", Status: exporter.StatusPending,
+	})
+
+	var buf bytes.Buffer
+	if _, complete := statusCore(&buf, index, nil, 0); complete != 0 {
+		t.Errorf("complete = %d, want 0", complete)
+	}
+	out := buf.String()
+	for _, want := range []string{"❌ failed", "⏳ pending", "Failed exports", "general: failed to write messages for 2024-02-01: quota exceeded"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestStatusCore_Shared(t *testing.T) {
+	index := exporter.NewExportIndex("")
+	index.SetConversation(&exporter.ConversationExport{
+		ID: "C001", Name: "partner-launch", Type: "channel", Status: exporter.StatusShared,
+		SharedWith: "/home/me/.get-out-partner",
+	})
+
+	var buf bytes.Buffer
+	statusCore(&buf, index, nil, 0)
+	out := buf.String()
+	for _, want := range []string{"🔗 shared", "Shared channels exported from another workspace", "partner-launch: /home/me/.get-out-partner"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestStatusCore_LargeFolders(t *testing.T) {
+	index := exporter.NewExportIndex("")
+	index.SetConversation(&exporter.ConversationExport{
+		ID: "C001", Name: "general", Type: "channel", Status: "complete",
+		FolderItems: map[string]int{"f1": 450, "f2": 12},
+	})
+	index.SetConversation(&exporter.ConversationExport{
+		ID: "C002", Name: "random", Type: "channel", Status: "complete", Layout: "year",
+		FolderItems: map[string]int{"f3": 30},
+	})
+
+	var buf bytes.Buffer
+	statusCore(&buf, index, nil, 0)
+	out := buf.String()
+	if !strings.Contains(out, "Large Drive folders (400+ items") || !strings.Contains(out, "general: 450 items (layout: flat)") {
+		t.Errorf("expected general listed as a large folder, got:\n%s", out)
+	}
+	if strings.Contains(out, "random:") {
+		t.Errorf("random should not be listed, got:\n%s", out)
+	}
+
+	buf.Reset()
+	statusCore(&buf, index, nil, 500)
+	if strings.Contains(buf.String(), "Large Drive folders") {
+		t.Errorf("no folder reaches 500 items, got:\n%s", buf.String())
+	}
+}
+
+func TestFormatRunLock(t *testing.T) {
+	var buf bytes.Buffer
+	formatRunLock(&buf, nil)
+	if buf.Len() != 0 {
+		t.Errorf("expected no output without a lock, got %q", buf.String())
+	}
+
+	host, _ := os.Hostname()
+	formatRunLock(&buf, &exporter.RunLockInfo{
+		PID:       os.Getpid(),
+		Hostname:  host,
+		Command:   "export",
+		StartedAt: time.Now(),
+		Total:     7,
+		Done:      3,
+		Current:   "general",
+	})
+	out := buf.String()
+	for _, want := range []string{
+		fmt.Sprintf("Export in progress (PID %d, 42%% of conversations)", os.Getpid()),
+		"3/7 conversations done",
+		"Current: general",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
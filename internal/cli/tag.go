@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/exporter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tagRemove bool
+	tagNote   string
+	tagForce  bool
+)
+
+var tagCmd = &cobra.Command{
+	Use:   "tag <conversation_id> [tag...]",
+	Short: "Tag a conversation or attach notes to it",
+	Long: `Attach tags and free-text notes to a conversation. They are stored in the
+export index, shown by 'get-out status', usable with --tag in list, status,
+and export, and recorded in the manifest of 'get-out package' archives.
+
+Tags are lowercased and may contain letters, digits, and - _ . :
+
+With no tags and no --note, prints the conversation's current tags and notes.`,
+	Example: `  # Tag a DM for legal hold
+  get-out tag D123ABC456 legal-hold
+
+  # Add a note (pass --note "" to clear it)
+  get-out tag D123ABC456 --note "Requested by legal, ticket 4411"
+
+  # Remove a tag
+  get-out tag D123ABC456 legal-hold --remove
+
+  # Export only conversations tagged legal-hold
+  get-out export --tag legal-hold`,
+	Args:              cobra.MinimumNArgs(1),
+	SilenceUsage:      true,
+	RunE:              runTag,
+	ValidArgsFunction: completeFirstConversationID,
+}
+
+func init() {
+	tagCmd.Flags().BoolVar(&tagRemove, "remove", false, "Remove the given tags instead of adding them")
+	tagCmd.Flags().StringVar(&tagNote, "note", "", "Set the conversation's notes (empty clears them)")
+	tagCmd.Flags().BoolVar(&tagForce, "force", false, "Break the export lock held by another run (use after a crash)")
+	rootCmd.AddCommand(tagCmd)
+}
+
+func runTag(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConversations(filepath.Join(configDir, "conversations.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	tags, err := normalizeTags(args[1:])
+	if err != nil {
+		return err
+	}
+	var note *string
+	if cmd.Flags().Changed("note") {
+		note = &tagNote
+	}
+
+	// Changes save the whole index, which must not happen while an export
+	// is checkpointing it.
+	if len(tags) > 0 || note != nil {
+		runLock, err := exporter.AcquireRunLock(exporter.DefaultRunLockPath(configDir), "tag", tagForce)
+		if err != nil {
+			return err
+		}
+		defer runLock.Release()
+	}
+	index, err := exporter.LoadExportIndex(exporter.DefaultIndexPath(configDir))
+	if err != nil {
+		return fmt.Errorf("failed to load export index: %w", err)
+	}
+	return tagCore(os.Stdout, cfg, index, args[0], tags, tagRemove, note)
+}
+
+// tagCore applies tag and note changes to convID and saves the index when
+// anything changed, then prints the conversation's annotations. A nil note
+// leaves the notes unchanged. When anything changes, the caller holds the
+// run lock.
+func tagCore(w io.Writer, cfg *config.ConversationsConfig, index *exporter.ExportIndex, convID string, tags []string, remove bool, note *string) error {
+	name, convType := convID, ""
+	if conv := cfg.GetByID(convID); conv != nil {
+		name, convType = conv.Name, string(conv.Type)
+	} else if existing := index.GetConversation(convID); existing != nil {
+		name, convType = existing.Name, existing.Type
+	} else {
+		return fmt.Errorf("conversation not found in config or export index: %s", convID)
+	}
+
+	changed := len(tags) > 0 || note != nil
+	switch {
+	case len(tags) > 0 && remove:
+		index.UntagConversation(convID, tags...)
+	case len(tags) > 0:
+		index.TagConversation(convID, name, convType, tags...)
+	}
+	if note != nil {
+		index.SetConversationNotes(convID, name, convType, *note)
+	}
+	if changed {
+		if err := index.Save(); err != nil {
+			return err
+		}
+	}
+
+	conv := index.GetConversation(convID)
+	fmt.Fprintf(w, "%s (%s)\n", name, convID)
+	if conv == nil || len(conv.Tags) == 0 {
+		fmt.Fprintln(w, "  Tags:  (none)")
+	} else {
+		fmt.Fprintf(w, "  Tags:  %s\n", strings.Join(conv.Tags, ", "))
+	}
+	if conv != nil && conv.Notes != "" {
+		fmt.Fprintf(w, "  Notes: %s\n", conv.Notes)
+	}
+	return nil
+}
+
+// normalizeTags validates and normalizes tags given on the command line.
+func normalizeTags(tags []string) ([]string, error) {
+	var result []string
+	for _, tag := range tags {
+		normalized, err := exporter.NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, normalized)
+	}
+	return result, nil
+}
+
+// filterByTags keeps the conversations that carry any of tags in the export
+// index. An empty tags list keeps them all.
+func filterByTags(conversations []config.ConversationConfig, index *exporter.ExportIndex, tags []string) []config.ConversationConfig {
+	if len(tags) == 0 {
+		return conversations
+	}
+	tagged := index.ConversationsTagged(tags)
+	var result []config.ConversationConfig
+	for _, c := range conversations {
+		if tagged[c.ID] {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// loadTagFilter normalizes --tag values and, when any are given, loads the
+// export index to filter by.
+func loadTagFilter(tags []string) ([]string, *exporter.ExportIndex, error) {
+	tags, err := normalizeTags(tags)
+	if err != nil || len(tags) == 0 {
+		return nil, nil, err
+	}
+	index, err := exporter.LoadExportIndex(exporter.DefaultIndexPath(configDir))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load export index: %w", err)
+	}
+	return tags, index, nil
+}
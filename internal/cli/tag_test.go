@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/exporter"
+)
+
+func TestTagCore(t *testing.T) {
+	cfg := testConversationsConfig()
+	path := filepath.Join(t.TempDir(), "export-index.json")
+	index := exporter.NewExportIndex(path)
+
+	var buf bytes.Buffer
+	if err := tagCore(&buf, cfg, index, "D003GHI", []string{"legal-hold", "personal"}, false, nil); err != nil {
+		t.Fatalf("tagCore() error: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "dm-alice (D003GHI)") || !strings.Contains(out, "Tags:  legal-hold, personal") {
+		t.Errorf("output = %q", out)
+	}
+
+	note := "Requested by legal"
+	buf.Reset()
+	if err := tagCore(&buf, cfg, index, "D003GHI", []string{"personal"}, true, &note); err != nil {
+		t.Fatalf("tagCore() error: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "Tags:  legal-hold\n") || !strings.Contains(out, "Notes: Requested by legal") {
+		t.Errorf("output = %q", out)
+	}
+
+	// Changes are saved to the index.
+	loaded, err := exporter.LoadExportIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conv := loaded.GetConversation("D003GHI")
+	if conv == nil || conv.Type != "dm" || len(conv.Tags) != 1 || conv.Notes != note {
+		t.Errorf("saved conversation = %+v", conv)
+	}
+
+	if err := tagCore(&buf, cfg, index, "CNOTEXIST", []string{"x"}, false, nil); err == nil {
+		t.Error("expected error for unknown conversation")
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := normalizeTags([]string{"Legal-Hold", "project-x"})
+	if err != nil || strings.Join(tags, ",") != "legal-hold,project-x" {
+		t.Errorf("normalizeTags() = %v, %v", tags, err)
+	}
+	if _, err := normalizeTags([]string{"bad tag"}); err == nil {
+		t.Error("expected error for tag with a space")
+	}
+}
+
+func TestFilterByTags(t *testing.T) {
+	cfg := testConversationsConfig()
+	index := exporter.NewExportIndex("")
+	index.TagConversation("C001ABC", "general", "channel", "project-x")
+	index.TagConversation("G005MNO", "group-chat", "mpim", "legal-hold")
+
+	if got := filterByTags(cfg.Conversations, index, nil); len(got) != len(cfg.Conversations) {
+		t.Errorf("no tags should keep all, got %d", len(got))
+	}
+	got := filterByTags(cfg.Conversations, index, []string{"legal-hold", "personal"})
+	if len(got) != 1 || got[0].ID != "G005MNO" {
+		t.Errorf("filterByTags() = %+v", got)
+	}
+}
+
+func TestStatusCore_FilterByTag(t *testing.T) {
+	index := exporter.NewExportIndex("")
+	index.TagConversation("D001", "alice", "dm", "legal-hold")
+	index.GetOrCreateConversation("C001", "general", "channel")
+
+	var buf bytes.Buffer
+	total, _ := statusCore(&buf, index, []string{"legal-hold"}, 0)
+	out := buf.String()
+	if total != 1 || !strings.Contains(out, "alice") || strings.Contains(out, "general") {
+		t.Errorf("total = %d, output:\n%s", total, out)
+	}
+	if !strings.Contains(out, "[legal-hold]") {
+		t.Errorf("expected tags in output:\n%s", out)
+	}
+
+	buf.Reset()
+	if total, _ := statusCore(&buf, index, []string{"personal"}, 0); total != 0 || !strings.Contains(buf.String(), "No exported conversations tagged personal") {
+		t.Errorf("total = %d, output:\n%s", total, buf.String())
+	}
+}
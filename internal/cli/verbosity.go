@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+)
+
+// Output levels, selected with --quiet or one or more -v flags.
+const (
+	levelQuiet   = -1 // --quiet: only the final summary and errors
+	levelNormal  = 0  // default: headers and a progress spinner
+	levelVerbose = 1  // -v: one line per progress step
+	levelDetail  = 2  // -vv: plus per-batch detail (fetch counts, per-day writes)
+	levelDebug   = 3  // -vvv: plus rate limiter waits and other debug output
+)
+
+// validateOutputFlags rejects --quiet combined with -v or --debug.
+func validateOutputFlags() error {
+	if quiet && (verbosity > 0 || debugMode) {
+		return fmt.Errorf("--quiet cannot be combined with -v or --debug")
+	}
+	return nil
+}
+
+// outputLevel returns the level selected by the global output flags.
+// --debug is kept as an alias for -vvv.
+func outputLevel() int {
+	return resolveOutputLevel(quiet, verbosity, debugMode)
+}
+
+// resolveOutputLevel maps the output flags to a level.
+func resolveOutputLevel(quiet bool, count int, debug bool) int {
+	switch {
+	case quiet:
+		return levelQuiet
+	case debug || count >= levelDebug:
+		return levelDebug
+	default:
+		return count
+	}
+}
+
+// levelProgress returns a progress callback for a subsystem whose messages
+// belong at level: they are printed to w when the output level is at least
+// level, otherwise shown on the spinner (if any), and dropped in quiet mode.
+func levelProgress(w io.Writer, current, level int, spin *StatusSpinner) func(string) {
+	return func(msg string) {
+		switch {
+		case current >= level:
+			fmt.Fprintf(w, "  %s\n", msg)
+		case current > levelQuiet && spin != nil:
+			spin.Update(msg)
+		}
+	}
+}
+
+// statusf prints a status line unless --quiet is set.
+func statusf(format string, args ...interface{}) {
+	if outputLevel() > levelQuiet {
+		fmt.Printf(format, args...)
+	}
+}
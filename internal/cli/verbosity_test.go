@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestResolveOutputLevel(t *testing.T) {
+	tests := []struct {
+		name  string
+		quiet bool
+		count int
+		debug bool
+		want  int
+	}{
+		{"default", false, 0, false, levelNormal},
+		{"quiet", true, 0, false, levelQuiet},
+		{"-v", false, 1, false, levelVerbose},
+		{"-vv", false, 2, false, levelDetail},
+		{"-vvv", false, 3, false, levelDebug},
+		{"-vvvv caps at debug", false, 4, false, levelDebug},
+		{"--debug alias", false, 0, true, levelDebug},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveOutputLevel(tt.quiet, tt.count, tt.debug); got != tt.want {
+				t.Errorf("resolveOutputLevel() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateOutputFlags(t *testing.T) {
+	defer func() { quiet, verbosity, debugMode = false, 0, false }()
+
+	quiet, verbosity = true, 1
+	if err := validateOutputFlags(); err == nil {
+		t.Error("expected error for --quiet with -v")
+	}
+	quiet, verbosity = true, 0
+	if err := validateOutputFlags(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestLevelProgress(t *testing.T) {
+	var buf bytes.Buffer
+
+	levelProgress(&buf, levelVerbose, levelVerbose, nil)("step")
+	if buf.String() != "  step\n" {
+		t.Errorf("at level: got %q", buf.String())
+	}
+
+	buf.Reset()
+	levelProgress(&buf, levelVerbose, levelDetail, nil)("batch")
+	if buf.Len() != 0 {
+		t.Errorf("detail printed at -v: %q", buf.String())
+	}
+
+	spin := NewStatusSpinner()
+	levelProgress(&buf, levelNormal, levelVerbose, spin)("spun")
+	if buf.Len() != 0 {
+		t.Errorf("progress printed at normal level: %q", buf.String())
+	}
+	spin.mu.Lock()
+	msg := spin.message
+	spin.mu.Unlock()
+	if msg != "spun" {
+		t.Errorf("spinner message = %q, want %q", msg, "spun")
+	}
+
+	quietSpin := NewStatusSpinner()
+	levelProgress(&buf, levelQuiet, levelVerbose, quietSpin)("hidden")
+	quietSpin.mu.Lock()
+	msg = quietSpin.message
+	quietSpin.mu.Unlock()
+	if buf.Len() != 0 || msg == "hidden" {
+		t.Errorf("quiet level produced output: %q / spinner %q", buf.String(), msg)
+	}
+}
@@ -0,0 +1,588 @@
+// Package testutil provides in-memory fakes of the Google Drive and Slack
+// clients for exercising the exporter without network access.
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/jflowers/get-out/pkg/gdrive"
+)
+
+// FakeDrive is an in-memory stand-in for *gdrive.Client. Folders and
+// documents are keyed by name and parent, so FindOrCreate calls are
+// idempotent the same way they are against Drive. It is safe for
+// concurrent use.
+type FakeDrive struct {
+	mu sync.Mutex
+
+	// Errors maps a method name (e.g. "BatchAppendMessages") to the error
+	// that method returns. Methods not listed succeed.
+	Errors map[string]error
+
+	// Reject, when set, is called for each block passed to
+	// BatchAppendMessages; an error rejects the whole batch, as the Docs
+	// API rejects a batchUpdate with one bad request.
+	Reject func(gdrive.MessageBlock) error
+
+	// Permissions maps a file or folder ID to who it is shared with.
+	// ShareFolder and DeletePermission change it.
+	Permissions map[string][]gdrive.Permission
+
+	nextID  int
+	folders map[string]*gdrive.FolderInfo // by ID
+	byName  map[string]*gdrive.FolderInfo // by parentID + "/" + name
+	docs    map[string]*fakeDoc           // by ID
+	sheets  map[string]*fakeSheet         // by ID
+	files   map[string][]byte             // uploaded files by ID
+	public  map[string]bool
+	calls   map[string]int
+}
+
+type fakeDoc struct {
+	info     gdrive.DocInfo
+	folderID string
+	template string
+	content  strings.Builder
+	appends  [][]gdrive.MessageBlock
+}
+
+type fakeSheet struct {
+	info     gdrive.SheetInfo
+	folderID string
+	rows     [][]gdrive.SheetCell
+}
+
+// NewFakeDrive returns an empty FakeDrive.
+func NewFakeDrive() *FakeDrive {
+	return &FakeDrive{
+		Errors:      make(map[string]error),
+		Permissions: make(map[string][]gdrive.Permission),
+		folders:     make(map[string]*gdrive.FolderInfo),
+		byName:      make(map[string]*gdrive.FolderInfo),
+		docs:        make(map[string]*fakeDoc),
+		sheets:      make(map[string]*fakeSheet),
+		files:       make(map[string][]byte),
+		public:      make(map[string]bool),
+		calls:       make(map[string]int),
+	}
+}
+
+// call records a call to method and returns its configured error, if any.
+// The caller must hold d.mu.
+func (d *FakeDrive) call(method string) error {
+	d.calls[method]++
+	return d.Errors[method]
+}
+
+func (d *FakeDrive) newID(prefix string) string {
+	d.nextID++
+	return fmt.Sprintf("%s%03d", prefix, d.nextID)
+}
+
+// Calls returns how many times method has been called.
+func (d *FakeDrive) Calls(method string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.calls[method]
+}
+
+// AddFolder registers an existing folder, e.g. a configured root folder.
+func (d *FakeDrive) AddFolder(id, name string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.folders[id] = &gdrive.FolderInfo{ID: id, Name: name, URL: "https://drive.google.com/drive/folders/" + id}
+}
+
+// Documents returns the titles of every document created, in no particular
+// order.
+func (d *FakeDrive) Documents() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	titles := make([]string, 0, len(d.docs))
+	for _, doc := range d.docs {
+		titles = append(titles, doc.info.Title)
+	}
+	return titles
+}
+
+// DocumentFolder returns the ID of the folder docID is in.
+func (d *FakeDrive) DocumentFolder(docID string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if doc, ok := d.docs[docID]; ok {
+		return doc.folderID
+	}
+	return ""
+}
+
+// DocumentTemplate returns the template docID was copied from, or "" for
+// a blank document.
+func (d *FakeDrive) DocumentTemplate(docID string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if doc, ok := d.docs[docID]; ok {
+		return doc.template
+	}
+	return ""
+}
+
+// FolderName returns the name of folderID.
+func (d *FakeDrive) FolderName(folderID string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if f, ok := d.folders[folderID]; ok {
+		return f.Name
+	}
+	return ""
+}
+
+// Appended returns the message blocks appended to docID, one slice per
+// BatchAppendMessages call.
+func (d *FakeDrive) Appended(docID string) [][]gdrive.MessageBlock {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if doc, ok := d.docs[docID]; ok {
+		return doc.appends
+	}
+	return nil
+}
+
+// Files returns the number of uploaded files that have not been deleted.
+func (d *FakeDrive) Files() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.files)
+}
+
+// GetFolder returns a folder registered with AddFolder or created through
+// FindOrCreateFolder.
+func (d *FakeDrive) GetFolder(_ context.Context, folderID string) (*gdrive.FolderInfo, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.call("GetFolder"); err != nil {
+		return nil, err
+	}
+	f, ok := d.folders[folderID]
+	if !ok {
+		return nil, fmt.Errorf("failed to get folder: %s not found", folderID)
+	}
+	return f, nil
+}
+
+// FindOrCreateFolder returns the folder named name under parentID, creating
+// it if needed.
+func (d *FakeDrive) FindOrCreateFolder(_ context.Context, name string, parentID string) (*gdrive.FolderInfo, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.call("FindOrCreateFolder"); err != nil {
+		return nil, err
+	}
+	key := parentID + "/" + name
+	if f, ok := d.byName[key]; ok {
+		return f, nil
+	}
+	id := d.newID("folder")
+	f := &gdrive.FolderInfo{ID: id, Name: name, URL: "https://drive.google.com/drive/folders/" + id}
+	d.folders[id] = f
+	d.byName[key] = f
+	return f, nil
+}
+
+// FindDocument returns the document titled title in folderID, or nil.
+func (d *FakeDrive) FindDocument(_ context.Context, title string, folderID string) (*gdrive.DocInfo, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.call("FindDocument"); err != nil {
+		return nil, err
+	}
+	for _, doc := range d.docs {
+		if doc.info.Title == title && doc.folderID == folderID {
+			info := doc.info
+			return &info, nil
+		}
+	}
+	return nil, nil
+}
+
+// FindOrCreateDocument returns the document titled title in folderID,
+// creating it if needed.
+func (d *FakeDrive) FindOrCreateDocument(_ context.Context, title string, folderID string) (*gdrive.DocInfo, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.call("FindOrCreateDocument"); err != nil {
+		return nil, err
+	}
+	return d.findOrCreateDocument(title, folderID, ""), nil
+}
+
+// FindOrCreateDocumentFromTemplate is FindOrCreateDocument that records
+// templateID as the template a new document was copied from (see
+// DocumentTemplate).
+func (d *FakeDrive) FindOrCreateDocumentFromTemplate(_ context.Context, title string, folderID string, templateID string) (*gdrive.DocInfo, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.call("FindOrCreateDocumentFromTemplate"); err != nil {
+		return nil, err
+	}
+	return d.findOrCreateDocument(title, folderID, templateID), nil
+}
+
+// findOrCreateDocument returns the document titled title in folderID,
+// creating it from templateID if needed, with the template's content when
+// it is a fake document too. d.mu must be held.
+func (d *FakeDrive) findOrCreateDocument(title string, folderID string, templateID string) *gdrive.DocInfo {
+	for _, doc := range d.docs {
+		if doc.info.Title == title && doc.folderID == folderID {
+			info := doc.info
+			return &info
+		}
+	}
+	id := d.newID("doc")
+	doc := &fakeDoc{
+		info:     gdrive.DocInfo{ID: id, Title: title, URL: "https://docs.google.com/document/d/" + id + "/edit"},
+		folderID: folderID,
+		template: templateID,
+	}
+	if tmpl, ok := d.docs[templateID]; ok {
+		doc.content.WriteString(tmpl.content.String())
+		doc.appends = append(doc.appends, tmpl.appends...)
+	}
+	d.docs[id] = doc
+	info := doc.info
+	return &info
+}
+
+// GetDocumentContent returns the plain text appended to docID.
+func (d *FakeDrive) GetDocumentContent(_ context.Context, docID string) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.call("GetDocumentContent"); err != nil {
+		return "", err
+	}
+	doc, ok := d.docs[docID]
+	if !ok {
+		return "", fmt.Errorf("failed to get document: %s not found", docID)
+	}
+	return doc.content.String(), nil
+}
+
+// GetDocumentEndIndex returns the end index docID has once its appended
+// messages are laid out as gdrive.BuildAppendRequests lays them out.
+func (d *FakeDrive) GetDocumentEndIndex(_ context.Context, docID string) (int64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.call("GetDocumentEndIndex"); err != nil {
+		return 0, err
+	}
+	doc, ok := d.docs[docID]
+	if !ok {
+		return 0, fmt.Errorf("failed to get document: %s not found", docID)
+	}
+	end := int64(1)
+	for _, batch := range doc.appends {
+		for _, msg := range batch {
+			end += gdrive.AppendedLength(msg)
+		}
+	}
+	return end, nil
+}
+
+// BatchAppendMessages appends messages to docID, laid out as
+// gdrive.BuildAppendRequests lays them out.
+func (d *FakeDrive) BatchAppendMessages(_ context.Context, docID string, messages []gdrive.MessageBlock) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.call("BatchAppendMessages"); err != nil {
+		return err
+	}
+	if d.Reject != nil {
+		for _, msg := range messages {
+			if err := d.Reject(msg); err != nil {
+				return err
+			}
+		}
+	}
+	doc, ok := d.docs[docID]
+	if !ok {
+		return fmt.Errorf("failed to get document: %s not found", docID)
+	}
+	for _, msg := range messages {
+		fmt.Fprintf(&doc.content, "%s  %s\n%s\n\n", msg.SenderName, msg.Timestamp, msg.Content)
+	}
+	doc.appends = append(doc.appends, messages)
+	return nil
+}
+
+// ReplaceDocumentContent replaces the content of docID with messages, so
+// Appended then returns only them.
+func (d *FakeDrive) ReplaceDocumentContent(_ context.Context, docID string, messages []gdrive.MessageBlock) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.call("ReplaceDocumentContent"); err != nil {
+		return err
+	}
+	doc, ok := d.docs[docID]
+	if !ok {
+		return fmt.Errorf("failed to get document: %s not found", docID)
+	}
+	doc.content.Reset()
+	for _, msg := range messages {
+		fmt.Fprintf(&doc.content, "%s  %s\n%s\n\n", msg.SenderName, msg.Timestamp, msg.Content)
+	}
+	doc.appends = [][]gdrive.MessageBlock{messages}
+	return nil
+}
+
+// ReplaceText replaces every occurrence of each key in docID with its value
+// and returns the number of replacements made.
+func (d *FakeDrive) ReplaceText(_ context.Context, docID string, replacements map[string]string) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.call("ReplaceText"); err != nil {
+		return 0, err
+	}
+	doc, ok := d.docs[docID]
+	if !ok {
+		return 0, fmt.Errorf("failed to replace text: %s not found", docID)
+	}
+	content := doc.content.String()
+	total := 0
+	for old, repl := range replacements {
+		total += strings.Count(content, old)
+		content = strings.ReplaceAll(content, old, repl)
+	}
+	doc.content.Reset()
+	doc.content.WriteString(content)
+	return total, nil
+}
+
+// UploadFile stores data and returns its new file ID.
+func (d *FakeDrive) UploadFile(_ context.Context, _ string, _ string, data []byte, _ string) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.call("UploadFile"); err != nil {
+		return "", err
+	}
+	id := d.newID("file")
+	d.files[id] = data
+	return id, nil
+}
+
+// GetWebContentLink returns a download link for fileID.
+func (d *FakeDrive) GetWebContentLink(_ context.Context, fileID string) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.call("GetWebContentLink"); err != nil {
+		return "", err
+	}
+	if _, ok := d.files[fileID]; !ok {
+		return "", fmt.Errorf("failed to get file: %s not found", fileID)
+	}
+	return "https://drive.google.com/uc?id=" + fileID + "&export=download", nil
+}
+
+// MakePublic marks fileID as readable by anyone with the link.
+func (d *FakeDrive) MakePublic(_ context.Context, fileID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.call("MakePublic"); err != nil {
+		return err
+	}
+	d.public[fileID] = true
+	return nil
+}
+
+// DeleteFile removes an uploaded file.
+func (d *FakeDrive) DeleteFile(_ context.Context, fileID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.call("DeleteFile"); err != nil {
+		return err
+	}
+	delete(d.files, fileID)
+	delete(d.public, fileID)
+	return nil
+}
+
+// ListChildren lists the folders and documents in parentID.
+func (d *FakeDrive) ListChildren(_ context.Context, parentID string) ([]*gdrive.DriveItem, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.call("ListChildren"); err != nil {
+		return nil, err
+	}
+	var items []*gdrive.DriveItem
+	for key, f := range d.byName {
+		if strings.HasPrefix(key, parentID+"/") && d.folders[f.ID] == f {
+			items = append(items, &gdrive.DriveItem{ID: f.ID, Name: f.Name, MimeType: gdrive.MimeTypeFolder})
+		}
+	}
+	for id, doc := range d.docs {
+		if doc.folderID == parentID {
+			items = append(items, &gdrive.DriveItem{ID: id, Name: doc.info.Title, MimeType: gdrive.MimeTypeDoc})
+		}
+	}
+	return items, nil
+}
+
+// MoveFile moves a folder or document from fromParentID to toParentID.
+func (d *FakeDrive) MoveFile(_ context.Context, fileID, fromParentID, toParentID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.call("MoveFile"); err != nil {
+		return err
+	}
+	if doc, ok := d.docs[fileID]; ok && doc.folderID == fromParentID {
+		doc.folderID = toParentID
+		return nil
+	}
+	if f, ok := d.folders[fileID]; ok {
+		key := fromParentID + "/" + f.Name
+		if d.byName[key] == f {
+			delete(d.byName, key)
+			d.byName[toParentID+"/"+f.Name] = f
+			return nil
+		}
+	}
+	return fmt.Errorf("%s not found in %s", fileID, fromParentID)
+}
+
+// DeleteFolder removes folderID, as trashing it does in Drive.
+func (d *FakeDrive) DeleteFolder(_ context.Context, folderID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.call("DeleteFolder"); err != nil {
+		return err
+	}
+	delete(d.folders, folderID)
+	for key, f := range d.byName {
+		if f.ID == folderID {
+			delete(d.byName, key)
+		}
+	}
+	return nil
+}
+
+// ListPermissions returns the Permissions of fileID.
+func (d *FakeDrive) ListPermissions(_ context.Context, fileID string) ([]gdrive.Permission, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.call("ListPermissions"); err != nil {
+		return nil, err
+	}
+	return append([]gdrive.Permission(nil), d.Permissions[fileID]...), nil
+}
+
+// ShareFolder gives email reader access to folderID.
+func (d *FakeDrive) ShareFolder(_ context.Context, folderID, email string, _ bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.call("ShareFolder"); err != nil {
+		return err
+	}
+	d.Permissions[folderID] = append(d.Permissions[folderID], gdrive.Permission{
+		ID: d.newID("perm"), Type: "user", Role: "reader", EmailAddress: email,
+	})
+	return nil
+}
+
+// DeletePermission removes permissionID from fileID.
+func (d *FakeDrive) DeletePermission(_ context.Context, fileID, permissionID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.call("DeletePermission"); err != nil {
+		return err
+	}
+	perms := d.Permissions[fileID]
+	for i, p := range perms {
+		if p.ID == permissionID {
+			d.Permissions[fileID] = append(perms[:i:i], perms[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("permission %s not found on %s", permissionID, fileID)
+}
+
+// FindOrCreateSpreadsheet returns the spreadsheet titled title in
+// folderID, creating it if needed.
+func (d *FakeDrive) FindOrCreateSpreadsheet(_ context.Context, title string, folderID string) (*gdrive.SheetInfo, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.call("FindOrCreateSpreadsheet"); err != nil {
+		return nil, err
+	}
+	for _, sheet := range d.sheets {
+		if sheet.info.Title == title && sheet.folderID == folderID {
+			info := sheet.info
+			return &info, nil
+		}
+	}
+	id := d.newID("sheet")
+	sheet := &fakeSheet{
+		info:     gdrive.SheetInfo{ID: id, Title: title, URL: "https://docs.google.com/spreadsheets/d/" + id + "/edit"},
+		folderID: folderID,
+	}
+	d.sheets[id] = sheet
+	info := sheet.info
+	return &info, nil
+}
+
+// ReplaceSheetRows replaces the rows of spreadsheetID.
+func (d *FakeDrive) ReplaceSheetRows(_ context.Context, spreadsheetID string, rows [][]gdrive.SheetCell) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.call("ReplaceSheetRows"); err != nil {
+		return err
+	}
+	sheet, ok := d.sheets[spreadsheetID]
+	if !ok {
+		return fmt.Errorf("spreadsheet %s not found", spreadsheetID)
+	}
+	sheet.rows = append([][]gdrive.SheetCell(nil), rows...)
+	return nil
+}
+
+// AppendSheetRows adds rows after the rows of spreadsheetID.
+func (d *FakeDrive) AppendSheetRows(_ context.Context, spreadsheetID string, rows [][]gdrive.SheetCell) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.call("AppendSheetRows"); err != nil {
+		return err
+	}
+	sheet, ok := d.sheets[spreadsheetID]
+	if !ok {
+		return fmt.Errorf("spreadsheet %s not found", spreadsheetID)
+	}
+	sheet.rows = append(sheet.rows, rows...)
+	return nil
+}
+
+// Spreadsheets returns the titles of every spreadsheet created, in no
+// particular order.
+func (d *FakeDrive) Spreadsheets() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	titles := make([]string, 0, len(d.sheets))
+	for _, sheet := range d.sheets {
+		titles = append(titles, sheet.info.Title)
+	}
+	return titles
+}
+
+// SheetRows returns the rows of spreadsheetID and the folder it was
+// created in.
+func (d *FakeDrive) SheetRows(spreadsheetID string) ([][]gdrive.SheetCell, string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	sheet, ok := d.sheets[spreadsheetID]
+	if !ok {
+		return nil, ""
+	}
+	return append([][]gdrive.SheetCell(nil), sheet.rows...), sheet.folderID
+}
@@ -0,0 +1,396 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// FakeSlack is an in-memory stand-in for *slackapi.Client. Populate the
+// exported maps before use; it is safe for concurrent reads once populated.
+type FakeSlack struct {
+	mu sync.Mutex
+
+	// Messages holds each conversation's history by channel ID, in any order.
+	Messages map[string][]slackapi.Message
+
+	// PageSize splits the history GetAllMessagesFrom returns into pages of
+	// that many messages. Zero returns it in a single page.
+	PageSize int
+
+	// Replies holds thread replies by channel ID and thread TS (see
+	// ThreadKey), including the parent as Slack returns it.
+	Replies map[string][]slackapi.Message
+
+	// Users is returned by GetUsers and GetUserInfo.
+	Users []slackapi.User
+
+	// Members holds conversation members by channel ID.
+	Members map[string][]string
+
+	// Files holds downloadable file contents by URL.
+	Files map[string][]byte
+
+	// Channels holds channel names by ID, returned by GetConversationInfo.
+	Channels map[string]string
+
+	// Conversations is returned by ListConversations, filtered by type.
+	Conversations []slackapi.Conversation
+
+	// SearchResults is returned by SearchMessages for any query, in one
+	// page; Reactions and Saved by ListReactions and ListSaved.
+	SearchResults []slackapi.SearchMatch
+	Reactions     []slackapi.ListedItem
+	Saved         []slackapi.ListedItem
+
+	// Scheduled and Reminders are returned by ListScheduledMessages and
+	// ListReminders.
+	Scheduled []slackapi.ScheduledMessage
+	Reminders []slackapi.Reminder
+
+	// Subscribed is returned by ListSubscribedThreads, in one page.
+	Subscribed []slackapi.SubscribedThread
+
+	// Team is returned by GetTeamInfo.
+	Team slackapi.Team
+
+	// FileInfo holds the files returned by GetFileInfo, by file ID.
+	FileInfo map[string]slackapi.File
+
+	// Emoji is returned by ListEmoji.
+	Emoji map[string]string
+
+	// Errors maps a method name (e.g. "GetAllMessages") to the error that
+	// method returns. Methods not listed succeed.
+	Errors map[string]error
+
+	calls map[string]int
+}
+
+// NewFakeSlack returns an empty FakeSlack.
+func NewFakeSlack() *FakeSlack {
+	return &FakeSlack{
+		Messages: make(map[string][]slackapi.Message),
+		Replies:  make(map[string][]slackapi.Message),
+		Members:  make(map[string][]string),
+		Files:    make(map[string][]byte),
+		Channels: make(map[string]string),
+		Errors:   make(map[string]error),
+		calls:    make(map[string]int),
+	}
+}
+
+// ThreadKey returns the Replies key for a thread.
+func ThreadKey(channelID, threadTS string) string {
+	return channelID + "/" + threadTS
+}
+
+func (s *FakeSlack) call(method string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls[method]++
+	return s.Errors[method]
+}
+
+// Calls returns how many times method has been called.
+func (s *FakeSlack) Calls(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[method]
+}
+
+// ValidateAuth reports a valid session.
+func (s *FakeSlack) ValidateAuth(_ context.Context) (*slackapi.AuthTestResponse, error) {
+	if err := s.call("ValidateAuth"); err != nil {
+		return nil, err
+	}
+	return &slackapi.AuthTestResponse{OK: true, Team: "Test", User: "tester", TeamID: "T001", UserID: "U000"}, nil
+}
+
+// GetAllMessages passes the messages of channelID that fall strictly inside
+// (oldest, latest) to callback in a single batch, newest first as Slack
+// returns them.
+func (s *FakeSlack) GetAllMessages(_ context.Context, channelID string, oldest, latest string, callback func([]slackapi.Message) error) error {
+	if err := s.call("GetAllMessages"); err != nil {
+		return err
+	}
+	batch := s.history(channelID, oldest, latest)
+	if len(batch) == 0 {
+		return nil
+	}
+	return callback(batch)
+}
+
+// GetAllMessagesFrom passes the messages of channelID that fall strictly
+// inside (oldest, latest) to callback, newest first, in pages of PageSize
+// starting at cursor. Cursors are the offsets of pages in the history.
+func (s *FakeSlack) GetAllMessagesFrom(_ context.Context, channelID string, oldest, latest, cursor string, callback func(batch []slackapi.Message, next string) error) error {
+	if err := s.call("GetAllMessagesFrom"); err != nil {
+		return err
+	}
+	history := s.history(channelID, oldest, latest)
+	start := 0
+	if cursor != "" {
+		var err error
+		if start, err = strconv.Atoi(cursor); err != nil || start < 0 || start > len(history) {
+			return fmt.Errorf("invalid cursor %q", cursor)
+		}
+	}
+	size := s.PageSize
+	if size <= 0 {
+		size = len(history)
+	}
+	for start < len(history) {
+		end := min(start+size, len(history))
+		next := ""
+		if end < len(history) {
+			next = strconv.Itoa(end)
+		}
+		if err := callback(history[start:end], next); err != nil {
+			return err
+		}
+		start = end
+	}
+	return nil
+}
+
+// history returns the messages of channelID inside (oldest, latest), newest
+// first.
+func (s *FakeSlack) history(channelID, oldest, latest string) []slackapi.Message {
+	var batch []slackapi.Message
+	for _, m := range s.Messages[channelID] {
+		if oldest != "" && tsFloat(m.TS) <= tsFloat(oldest) {
+			continue
+		}
+		if latest != "" && tsFloat(m.TS) >= tsFloat(latest) {
+			continue
+		}
+		batch = append(batch, m)
+	}
+	sort.SliceStable(batch, func(i, j int) bool { return tsFloat(batch[i].TS) > tsFloat(batch[j].TS) })
+	return batch
+}
+
+// GetAllReplies passes the replies stored under ThreadKey(channelID,
+// threadTS) to callback in a single batch.
+func (s *FakeSlack) GetAllReplies(_ context.Context, channelID, threadTS string, callback func([]slackapi.Message) error) error {
+	if err := s.call("GetAllReplies"); err != nil {
+		return err
+	}
+	replies := s.Replies[ThreadKey(channelID, threadTS)]
+	if len(replies) == 0 {
+		return nil
+	}
+	return callback(replies)
+}
+
+// DownloadFile returns the contents stored for url.
+func (s *FakeSlack) DownloadFile(_ context.Context, url string) ([]byte, error) {
+	if err := s.call("DownloadFile"); err != nil {
+		return nil, err
+	}
+	data, ok := s.Files[url]
+	if !ok {
+		return nil, fmt.Errorf("download failed with status 404")
+	}
+	return data, nil
+}
+
+// GetUsers returns every user in a single page.
+func (s *FakeSlack) GetUsers(_ context.Context, _ string) (*slackapi.UsersListResponse, error) {
+	if err := s.call("GetUsers"); err != nil {
+		return nil, err
+	}
+	return &slackapi.UsersListResponse{OK: true, Members: s.Users}, nil
+}
+
+// GetUserInfo returns the user with userID.
+func (s *FakeSlack) GetUserInfo(_ context.Context, userID string) (*slackapi.User, error) {
+	if err := s.call("GetUserInfo"); err != nil {
+		return nil, err
+	}
+	for i := range s.Users {
+		if s.Users[i].ID == userID {
+			u := s.Users[i]
+			return &u, nil
+		}
+	}
+	return nil, fmt.Errorf("user_not_found")
+}
+
+// GetConversationMembers returns the members of channelID in a single page.
+func (s *FakeSlack) GetConversationMembers(_ context.Context, channelID, _ string) (*slackapi.MembersResponse, error) {
+	if err := s.call("GetConversationMembers"); err != nil {
+		return nil, err
+	}
+	return &slackapi.MembersResponse{OK: true, Members: s.Members[channelID]}, nil
+}
+
+// ListConversations returns the Conversations of the requested types (all
+// of them when no type is given) in a single page.
+func (s *FakeSlack) ListConversations(_ context.Context, opts *slackapi.ListConversationsOptions) (*slackapi.ConversationsListResponse, error) {
+	if err := s.call("ListConversations"); err != nil {
+		return nil, err
+	}
+	resp := &slackapi.ConversationsListResponse{OK: true}
+	for _, c := range s.Conversations {
+		if opts == nil || len(opts.Types) == 0 || slices.Contains(opts.Types, conversationKind(c)) {
+			resp.Channels = append(resp.Channels, c)
+		}
+	}
+	return resp, nil
+}
+
+// conversationKind returns the conversations.list type of c.
+func conversationKind(c slackapi.Conversation) string {
+	switch {
+	case c.IsIM:
+		return "im"
+	case c.IsMPIM:
+		return "mpim"
+	case c.IsPrivate:
+		return "private_channel"
+	default:
+		return "public_channel"
+	}
+}
+
+// GetConversationHistory returns up to opts.Limit of the newest messages of
+// channelID inside (opts.Oldest, opts.Latest) in a single page.
+func (s *FakeSlack) GetConversationHistory(_ context.Context, channelID string, opts *slackapi.HistoryOptions) (*slackapi.HistoryResponse, error) {
+	if err := s.call("GetConversationHistory"); err != nil {
+		return nil, err
+	}
+	var msgs []slackapi.Message
+	if opts != nil {
+		msgs = s.history(channelID, opts.Oldest, opts.Latest)
+	} else {
+		msgs = s.history(channelID, "", "")
+	}
+	hasMore := false
+	if opts != nil && opts.Limit > 0 && len(msgs) > opts.Limit {
+		msgs, hasMore = msgs[:opts.Limit], true
+	}
+	return &slackapi.HistoryResponse{OK: true, Messages: msgs, HasMore: hasMore}, nil
+}
+
+// GetConversationReplies returns the replies stored under
+// ThreadKey(channelID, threadTS) in a single page.
+func (s *FakeSlack) GetConversationReplies(_ context.Context, channelID, threadTS string, _ *slackapi.RepliesOptions) (*slackapi.RepliesResponse, error) {
+	if err := s.call("GetConversationReplies"); err != nil {
+		return nil, err
+	}
+	return &slackapi.RepliesResponse{OK: true, Messages: s.Replies[ThreadKey(channelID, threadTS)]}, nil
+}
+
+// GetConversationInfo returns the conversation with channelID from
+// Conversations, or else one with its ID and, when listed in Channels, its
+// name.
+func (s *FakeSlack) GetConversationInfo(_ context.Context, channelID string) (*slackapi.Conversation, error) {
+	if err := s.call("GetConversationInfo"); err != nil {
+		return nil, err
+	}
+	for _, c := range s.Conversations {
+		if c.ID == channelID {
+			return &c, nil
+		}
+	}
+	return &slackapi.Conversation{ID: channelID, Name: s.Channels[channelID]}, nil
+}
+
+// SearchMessages returns SearchResults as a single page.
+func (s *FakeSlack) SearchMessages(_ context.Context, _ string, _ int) (*slackapi.SearchMessagesResponse, error) {
+	if err := s.call("SearchMessages"); err != nil {
+		return nil, err
+	}
+	resp := &slackapi.SearchMessagesResponse{OK: true}
+	resp.Messages.Matches = s.SearchResults
+	resp.Messages.Paging = slackapi.Paging{Page: 1, Pages: 1}
+	return resp, nil
+}
+
+// ListReactions returns Reactions in a single page.
+func (s *FakeSlack) ListReactions(_ context.Context, _, _ string) (*slackapi.ItemsListResponse, error) {
+	if err := s.call("ListReactions"); err != nil {
+		return nil, err
+	}
+	return &slackapi.ItemsListResponse{OK: true, Items: s.Reactions}, nil
+}
+
+// ListSaved returns Saved in a single page.
+func (s *FakeSlack) ListSaved(_ context.Context, _ string) (*slackapi.ItemsListResponse, error) {
+	if err := s.call("ListSaved"); err != nil {
+		return nil, err
+	}
+	return &slackapi.ItemsListResponse{OK: true, Items: s.Saved}, nil
+}
+
+// ListScheduledMessages returns Scheduled in a single page.
+func (s *FakeSlack) ListScheduledMessages(_ context.Context, _ string) (*slackapi.ScheduledMessagesResponse, error) {
+	if err := s.call("ListScheduledMessages"); err != nil {
+		return nil, err
+	}
+	return &slackapi.ScheduledMessagesResponse{OK: true, ScheduledMessages: s.Scheduled}, nil
+}
+
+// ListReminders returns Reminders.
+func (s *FakeSlack) ListReminders(_ context.Context) ([]slackapi.Reminder, error) {
+	if err := s.call("ListReminders"); err != nil {
+		return nil, err
+	}
+	return s.Reminders, nil
+}
+
+// ListSubscribedThreads returns Subscribed in a single page.
+func (s *FakeSlack) ListSubscribedThreads(_ context.Context, _ string) (*slackapi.SubscribedThreadsResponse, error) {
+	if err := s.call("ListSubscribedThreads"); err != nil {
+		return nil, err
+	}
+	return &slackapi.SubscribedThreadsResponse{OK: true, Threads: s.Subscribed}, nil
+}
+
+// GetFileInfo returns the file stored under fileID in FileInfo.
+func (s *FakeSlack) GetFileInfo(_ context.Context, fileID string) (*slackapi.File, error) {
+	if err := s.call("GetFileInfo"); err != nil {
+		return nil, err
+	}
+	f, ok := s.FileInfo[fileID]
+	if !ok {
+		return nil, &slackapi.APIError{Code: "file_not_found"}
+	}
+	return &f, nil
+}
+
+// ListEmoji returns Emoji.
+func (s *FakeSlack) ListEmoji(_ context.Context) (map[string]string, error) {
+	if err := s.call("ListEmoji"); err != nil {
+		return nil, err
+	}
+	return s.Emoji, nil
+}
+
+// GetTeamInfo returns Team.
+func (s *FakeSlack) GetTeamInfo(_ context.Context) (*slackapi.Team, error) {
+	if err := s.call("GetTeamInfo"); err != nil {
+		return nil, err
+	}
+	team := s.Team
+	return &team, nil
+}
+
+// Probe succeeds unless Errors has an entry for the Slack method name
+// (e.g. "search.messages").
+func (s *FakeSlack) Probe(_ context.Context, method string, _ url.Values) error {
+	return s.call(method)
+}
+
+func tsFloat(ts string) float64 {
+	f, _ := strconv.ParseFloat(ts, 64)
+	return f
+}
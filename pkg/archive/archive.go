@@ -0,0 +1,354 @@
+package archive
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// DefaultSplitSize is the part size used when splitting is requested
+// without an explicit size (2 GB, matching Google Takeout).
+const DefaultSplitSize int64 = 2 << 30
+
+// ManifestName is the name of the manifest stored at the root of each part.
+const ManifestName = "manifest.json"
+
+// Source is a file or directory to include in the archive.
+type Source struct {
+	// Path is the file or directory on disk.
+	Path string
+	// Prefix is the directory inside the archive the source is stored under.
+	Prefix string
+}
+
+// Options configures Package.
+type Options struct {
+	Sources   []Source
+	OutputDir string
+
+	// BaseName is the archive file name without extension. Defaults to
+	// "get-out-export-<timestamp>".
+	BaseName string
+
+	// SplitSize starts a new part when the next file would push the current
+	// part past this many bytes (0 = single archive). Files larger than
+	// SplitSize get a part of their own.
+	SplitSize int64
+
+	// Passphrase, when set, encrypts each part (see EncryptFile).
+	Passphrase string
+
+	// Conversations is recorded in every part's manifest, so the tags and
+	// notes of archived conversations travel with the archive.
+	Conversations []ManifestConversation
+
+	// Workspace, when set, is recorded in every part's manifest as the
+	// provenance of the archive.
+	Workspace *ManifestWorkspace
+
+	// OnProgress receives human-readable progress messages.
+	OnProgress func(msg string)
+
+	// now is overridable for tests.
+	now func() time.Time
+}
+
+// Manifest lists the contents of one archive part.
+type Manifest struct {
+	CreatedAt     time.Time              `json:"created_at"`
+	Part          int                    `json:"part"`
+	Workspace     *ManifestWorkspace     `json:"workspace,omitempty"`
+	Conversations []ManifestConversation `json:"conversations,omitempty"`
+	Files         []ManifestEntry        `json:"files"`
+}
+
+// ManifestConversation describes an archived conversation and its
+// annotations.
+type ManifestConversation struct {
+	ID    string   `json:"id"`
+	Name  string   `json:"name"`
+	Type  string   `json:"type"`
+	Tags  []string `json:"tags,omitempty"`
+	Notes string   `json:"notes,omitempty"`
+}
+
+// ManifestWorkspace describes the Slack workspace the archived export was
+// taken from, as recorded at export time.
+type ManifestWorkspace struct {
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	Domain         string    `json:"domain,omitempty"`
+	EnterpriseID   string    `json:"enterprise_id,omitempty"`
+	EnterpriseName string    `json:"enterprise_name,omitempty"`
+	Plan           string    `json:"plan,omitempty"`
+	IconURL        string    `json:"icon_url,omitempty"`
+	CapturedAt     time.Time `json:"captured_at"`
+}
+
+// ManifestEntry describes a single archived file.
+type ManifestEntry struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Result summarizes a Package run.
+type Result struct {
+	Parts []string // Paths of the written archive parts, in order
+	Files int      // Number of files archived (excluding manifests)
+	Bytes int64    // Total uncompressed bytes archived
+}
+
+// file is a source file resolved to its archive name.
+type file struct {
+	diskPath string
+	name     string
+	size     int64
+}
+
+// Package writes the sources into one or more zip archives in OutputDir.
+// Each part is a standalone zip containing a manifest.json with SHA-256
+// checksums of its files. With a single part the archive is named
+// <BaseName>.zip; split archives are named <BaseName>-001.zip, -002.zip, ...
+// Encrypted parts get an additional .enc extension.
+func Package(opts Options) (*Result, error) {
+	now := time.Now
+	if opts.now != nil {
+		now = opts.now
+	}
+	createdAt := now().UTC()
+	if opts.BaseName == "" {
+		opts.BaseName = "get-out-export-" + createdAt.Format("20060102-150405")
+	}
+
+	files, err := collectFiles(opts.Sources)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("nothing to package: no files found in sources")
+	}
+
+	if err := os.MkdirAll(opts.OutputDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	groups := splitFiles(files, opts.SplitSize)
+	result := &Result{}
+
+	for i, group := range groups {
+		name := opts.BaseName + ".zip"
+		if len(groups) > 1 {
+			name = fmt.Sprintf("%s-%03d.zip", opts.BaseName, i+1)
+		}
+		zipPath := filepath.Join(opts.OutputDir, name)
+
+		progress(opts.OnProgress, "Writing %s (%d files)...", name, len(group))
+		if err := writePart(zipPath, group, Manifest{CreatedAt: createdAt, Part: i + 1, Workspace: opts.Workspace, Conversations: opts.Conversations}); err != nil {
+			return result, err
+		}
+
+		partPath := zipPath
+		if opts.Passphrase != "" {
+			partPath = zipPath + ".enc"
+			progress(opts.OnProgress, "Encrypting %s...", name)
+			if err := EncryptFile(zipPath, partPath, opts.Passphrase); err != nil {
+				os.Remove(zipPath)
+				return result, fmt.Errorf("failed to encrypt %s: %w", name, err)
+			}
+			if err := os.Remove(zipPath); err != nil {
+				return result, fmt.Errorf("failed to remove unencrypted %s: %w", name, err)
+			}
+		}
+
+		result.Parts = append(result.Parts, partPath)
+		for _, f := range group {
+			result.Files++
+			result.Bytes += f.size
+		}
+	}
+
+	return result, nil
+}
+
+// collectFiles walks the sources and returns regular files sorted by
+// archive name. Missing sources are skipped.
+func collectFiles(sources []Source) ([]file, error) {
+	var files []file
+	for _, src := range sources {
+		info, err := os.Stat(src.Path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %w", src.Path, err)
+		}
+
+		if !info.IsDir() {
+			files = append(files, file{
+				diskPath: src.Path,
+				name:     archiveName(src.Prefix, filepath.Base(src.Path)),
+				size:     info.Size(),
+			})
+			continue
+		}
+
+		err = filepath.WalkDir(src.Path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			fi, err := d.Info()
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(src.Path, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, file{
+				diskPath: path,
+				name:     archiveName(src.Prefix, rel),
+				size:     fi.Size(),
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to walk %s: %w", src.Path, err)
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].name < files[j].name
+	})
+	return files, nil
+}
+
+// archiveName joins prefix and rel into a forward-slash zip entry name.
+func archiveName(prefix, rel string) string {
+	name := filepath.ToSlash(rel)
+	if prefix != "" {
+		name = prefix + "/" + name
+	}
+	return name
+}
+
+// splitFiles groups files into parts of at most splitSize uncompressed
+// bytes. Compression means parts usually end up smaller than the limit.
+func splitFiles(files []file, splitSize int64) [][]file {
+	if splitSize <= 0 {
+		return [][]file{files}
+	}
+
+	var groups [][]file
+	var current []file
+	var currentSize int64
+	for _, f := range files {
+		if len(current) > 0 && currentSize+f.size > splitSize {
+			groups = append(groups, current)
+			current, currentSize = nil, 0
+		}
+		current = append(current, f)
+		currentSize += f.size
+	}
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+	return groups
+}
+
+// writePart writes one zip archive with the given files followed by its
+// manifest. The zip is written to a temp file and renamed into place so a
+// failed run never leaves a truncated archive behind.
+func writePart(zipPath string, files []file, manifest Manifest) (retErr error) {
+	tmp, err := os.CreateTemp(filepath.Dir(zipPath), ".get-out-package-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	tmpName := tmp.Name()
+	defer func() {
+		if retErr != nil {
+			tmp.Close()
+			os.Remove(tmpName)
+		}
+	}()
+
+	zw := zip.NewWriter(tmp)
+	for _, f := range files {
+		entry, err := addFile(zw, f, manifest.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to archive %s: %w", f.diskPath, err)
+		}
+		manifest.Files = append(manifest.Files, entry)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: ManifestName, Method: zip.Deflate, Modified: manifest.CreatedAt})
+	if err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to finalize archive: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close archive: %w", err)
+	}
+	if err := os.Rename(tmpName, zipPath); err != nil {
+		return fmt.Errorf("failed to rename archive: %w", err)
+	}
+	return nil
+}
+
+// addFile copies one file into the zip, hashing it on the way.
+func addFile(zw *zip.Writer, f file, fallbackTime time.Time) (ManifestEntry, error) {
+	src, err := os.Open(f.diskPath)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	defer src.Close()
+
+	modified := fallbackTime
+	if info, err := src.Stat(); err == nil {
+		modified = info.ModTime()
+	}
+
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(w, h), src)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+
+	return ManifestEntry{
+		Path:   f.name,
+		Size:   n,
+		SHA256: hex.EncodeToString(h.Sum(nil)),
+	}, nil
+}
+
+func progress(fn func(string), format string, args ...interface{}) {
+	if fn != nil {
+		fn(fmt.Sprintf(format, args...))
+	}
+}
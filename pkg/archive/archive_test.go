@@ -0,0 +1,203 @@
+package archive
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readZip returns the entries of a zip archive keyed by name.
+func readZip(t *testing.T, path string) map[string]string {
+	t.Helper()
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer zr.Close()
+
+	entries := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[f.Name] = string(data)
+	}
+	return entries
+}
+
+func fixedNow() time.Time {
+	return time.Date(2026, 4, 21, 10, 30, 0, 0, time.UTC)
+}
+
+func TestPackage_SingleArchiveWithManifest(t *testing.T) {
+	src := t.TempDir()
+	writeTestFile(t, filepath.Join(src, "channel-general", "2026-04-20.md"), "# hello")
+	writeTestFile(t, filepath.Join(src, "dm-alice", "2026-04-21.md"), "# hi")
+	index := filepath.Join(t.TempDir(), "export-index.json")
+	writeTestFile(t, index, `{"conversations":{}}`)
+	out := t.TempDir()
+
+	result, err := Package(Options{
+		Sources: []Source{
+			{Path: src, Prefix: "markdown"},
+			{Path: index, Prefix: "_metadata"},
+			{Path: filepath.Join(src, "does-not-exist")},
+		},
+		OutputDir:     out,
+		Conversations: []ManifestConversation{{ID: "D001", Name: "alice", Type: "dm", Tags: []string{"legal-hold"}}},
+		Workspace:     &ManifestWorkspace{ID: "T001", Name: "Acme", Domain: "acme"},
+		now:           fixedNow,
+	})
+	if err != nil {
+		t.Fatalf("Package() error: %v", err)
+	}
+
+	wantPath := filepath.Join(out, "get-out-export-20260421-103000.zip")
+	if len(result.Parts) != 1 || result.Parts[0] != wantPath {
+		t.Fatalf("Parts = %v, want [%s]", result.Parts, wantPath)
+	}
+	if result.Files != 3 {
+		t.Errorf("Files = %d, want 3", result.Files)
+	}
+
+	entries := readZip(t, wantPath)
+	if entries["markdown/channel-general/2026-04-20.md"] != "# hello" {
+		t.Errorf("missing or wrong markdown entry: %v", entries)
+	}
+	if _, ok := entries["_metadata/export-index.json"]; !ok {
+		t.Error("missing export index entry")
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal([]byte(entries[ManifestName]), &manifest); err != nil {
+		t.Fatalf("manifest: %v", err)
+	}
+	if manifest.Part != 1 || len(manifest.Files) != 3 {
+		t.Errorf("manifest = %+v", manifest)
+	}
+	if len(manifest.Conversations) != 1 || manifest.Conversations[0].Tags[0] != "legal-hold" {
+		t.Errorf("manifest conversations = %+v", manifest.Conversations)
+	}
+	if manifest.Workspace == nil || manifest.Workspace.ID != "T001" || manifest.Workspace.Domain != "acme" {
+		t.Errorf("manifest workspace = %+v", manifest.Workspace)
+	}
+	sum := sha256.Sum256([]byte("# hello"))
+	for _, f := range manifest.Files {
+		if f.Path == "markdown/channel-general/2026-04-20.md" && f.SHA256 != hex.EncodeToString(sum[:]) {
+			t.Errorf("checksum = %s, want %x", f.SHA256, sum)
+		}
+	}
+}
+
+func TestPackage_Split(t *testing.T) {
+	src := t.TempDir()
+	for _, name := range []string{"a.md", "b.md", "c.md"} {
+		writeTestFile(t, filepath.Join(src, name), strings.Repeat("x", 100))
+	}
+	out := t.TempDir()
+
+	result, err := Package(Options{
+		Sources:   []Source{{Path: src}},
+		OutputDir: out,
+		BaseName:  "archive",
+		SplitSize: 150,
+	})
+	if err != nil {
+		t.Fatalf("Package() error: %v", err)
+	}
+
+	if len(result.Parts) != 3 {
+		t.Fatalf("Parts = %v, want 3 parts", result.Parts)
+	}
+	for i, want := range []string{"archive-001.zip", "archive-002.zip", "archive-003.zip"} {
+		if filepath.Base(result.Parts[i]) != want {
+			t.Errorf("part %d = %s, want %s", i, result.Parts[i], want)
+		}
+	}
+	if _, ok := readZip(t, result.Parts[1])["b.md"]; !ok {
+		t.Error("second part should contain b.md")
+	}
+}
+
+func TestPackage_Encrypted(t *testing.T) {
+	src := t.TempDir()
+	writeTestFile(t, filepath.Join(src, "a.md"), "secret notes")
+	out := t.TempDir()
+
+	result, err := Package(Options{
+		Sources:    []Source{{Path: src}},
+		OutputDir:  out,
+		BaseName:   "archive",
+		Passphrase: "correct horse",
+	})
+	if err != nil {
+		t.Fatalf("Package() error: %v", err)
+	}
+
+	if len(result.Parts) != 1 || filepath.Base(result.Parts[0]) != "archive.zip.enc" {
+		t.Fatalf("Parts = %v, want [archive.zip.enc]", result.Parts)
+	}
+	if _, err := os.Stat(filepath.Join(out, "archive.zip")); !os.IsNotExist(err) {
+		t.Error("unencrypted archive should be removed")
+	}
+
+	decrypted := filepath.Join(out, "archive.zip")
+	if err := DecryptFile(result.Parts[0], decrypted, "correct horse"); err != nil {
+		t.Fatalf("DecryptFile() error: %v", err)
+	}
+	if readZip(t, decrypted)["a.md"] != "secret notes" {
+		t.Error("decrypted archive has wrong contents")
+	}
+}
+
+func TestPackage_NoFiles(t *testing.T) {
+	_, err := Package(Options{
+		Sources:   []Source{{Path: t.TempDir()}},
+		OutputDir: t.TempDir(),
+	})
+	if err == nil {
+		t.Fatal("expected error when there is nothing to package")
+	}
+}
+
+func TestSplitFiles(t *testing.T) {
+	files := []file{{name: "a", size: 10}, {name: "big", size: 500}, {name: "c", size: 10}, {name: "d", size: 10}}
+
+	if got := splitFiles(files, 0); len(got) != 1 {
+		t.Errorf("no split: got %d groups, want 1", len(got))
+	}
+
+	got := splitFiles(files, 100)
+	if len(got) != 3 {
+		t.Fatalf("got %d groups, want 3", len(got))
+	}
+	if len(got[1]) != 1 || got[1][0].name != "big" {
+		t.Errorf("oversized file should get its own part, got %+v", got[1])
+	}
+	if len(got[2]) != 2 {
+		t.Errorf("small trailing files should share a part, got %+v", got[2])
+	}
+}
@@ -0,0 +1,4 @@
+// Package archive bundles local export output into timestamped zip
+// archives, optionally split into size-limited parts and encrypted with a
+// passphrase.
+package archive
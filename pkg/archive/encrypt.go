@@ -0,0 +1,192 @@
+package archive
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Encrypted file format:
+//
+//	magic (10 bytes) | salt (16 bytes) | chunk...
+//	chunk = ciphertext length (uint32 big-endian) | AES-256-GCM ciphertext
+//
+// The key is derived from the passphrase with PBKDF2-SHA256. Each chunk is
+// sealed with a nonce built from its sequence number and a final-chunk
+// flag, so reordered, dropped, or truncated chunks fail authentication.
+const (
+	encMagic      = "GETOUTENC1"
+	saltSize      = 16
+	keySize       = 32
+	encChunkSize  = 1 << 20
+	pbkdf2Rounds  = 600_000
+	finalChunkBit = 1
+)
+
+// ErrDecrypt is returned when a file cannot be decrypted, either because
+// the passphrase is wrong or the file was modified or truncated.
+var ErrDecrypt = errors.New("decryption failed: wrong passphrase or corrupted file")
+
+// EncryptFile encrypts src into dst using a key derived from passphrase.
+func EncryptFile(src, dst, passphrase string) (retErr error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := out.Close(); retErr == nil {
+			retErr = cerr
+		}
+		if retErr != nil {
+			os.Remove(dst)
+		}
+	}()
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+	header := append([]byte(encMagic), salt...)
+	aead, err := newAEAD(passphrase, salt)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(out)
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	buf := make([]byte, encChunkSize)
+	var seq uint64
+	for {
+		n, readErr := io.ReadFull(in, buf)
+		final := readErr == io.EOF || readErr == io.ErrUnexpectedEOF
+		if readErr != nil && !final {
+			return readErr
+		}
+
+		ct := aead.Seal(nil, chunkNonce(seq, final), buf[:n], header)
+		var lenBuf [4]byte
+		binary.BigEndian.PutUint32(lenBuf[:], uint32(len(ct)))
+		if _, err := w.Write(lenBuf[:]); err != nil {
+			return err
+		}
+		if _, err := w.Write(ct); err != nil {
+			return err
+		}
+		if final {
+			break
+		}
+		seq++
+	}
+	return w.Flush()
+}
+
+// DecryptFile decrypts src (written by EncryptFile) into dst. It returns
+// ErrDecrypt when authentication fails; dst is removed on any error.
+func DecryptFile(src, dst, passphrase string) (retErr error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	r := bufio.NewReader(in)
+
+	header := make([]byte, len(encMagic)+saltSize)
+	if _, err := io.ReadFull(r, header); err != nil || string(header[:len(encMagic)]) != encMagic {
+		return fmt.Errorf("%s is not an encrypted get-out archive", src)
+	}
+	aead, err := newAEAD(passphrase, header[len(encMagic):])
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := out.Close(); retErr == nil {
+			retErr = cerr
+		}
+		if retErr != nil {
+			os.Remove(dst)
+		}
+	}()
+	w := bufio.NewWriter(out)
+
+	maxCT := encChunkSize + aead.Overhead()
+	ct := make([]byte, maxCT)
+	var seq uint64
+	for {
+		var lenBuf [4]byte
+		if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+			// Ran out of data before a final chunk: truncated.
+			return ErrDecrypt
+		}
+		n := int(binary.BigEndian.Uint32(lenBuf[:]))
+		if n > maxCT {
+			return ErrDecrypt
+		}
+		if _, err := io.ReadFull(r, ct[:n]); err != nil {
+			return ErrDecrypt
+		}
+
+		_, peekErr := r.Peek(1)
+		final := peekErr == io.EOF
+
+		pt, err := aead.Open(nil, chunkNonce(seq, final), ct[:n], header)
+		if err != nil {
+			return ErrDecrypt
+		}
+		if _, err := w.Write(pt); err != nil {
+			return err
+		}
+		if final {
+			break
+		}
+		seq++
+	}
+	return w.Flush()
+}
+
+// newAEAD derives the key and returns an AES-256-GCM cipher.
+func newAEAD(passphrase string, salt []byte) (cipher.AEAD, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("passphrase must not be empty")
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, pbkdf2Rounds, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce builds the 12-byte GCM nonce for chunk seq.
+func chunkNonce(seq uint64, final bool) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint64(nonce[:8], seq)
+	if final {
+		nonce[11] = finalChunkBit
+	}
+	return nonce
+}
@@ -0,0 +1,95 @@
+package archive
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptDecrypt_RoundTrip(t *testing.T) {
+	sizes := []int{0, 10, encChunkSize, encChunkSize + 1, 2*encChunkSize + 17}
+	for _, size := range sizes {
+		dir := t.TempDir()
+		plain := make([]byte, size)
+		rand.Read(plain)
+
+		src := filepath.Join(dir, "plain")
+		enc := filepath.Join(dir, "plain.enc")
+		dec := filepath.Join(dir, "plain.dec")
+		if err := os.WriteFile(src, plain, 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := EncryptFile(src, enc, "pass"); err != nil {
+			t.Fatalf("size %d: EncryptFile() error: %v", size, err)
+		}
+		if err := DecryptFile(enc, dec, "pass"); err != nil {
+			t.Fatalf("size %d: DecryptFile() error: %v", size, err)
+		}
+		got, _ := os.ReadFile(dec)
+		if !bytes.Equal(got, plain) {
+			t.Errorf("size %d: round trip mismatch", size)
+		}
+	}
+}
+
+func TestDecryptFile_Failures(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "plain")
+	enc := filepath.Join(dir, "plain.enc")
+	plain := bytes.Repeat([]byte("a"), encChunkSize+100)
+	if err := os.WriteFile(src, plain, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := EncryptFile(src, enc, "pass"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(enc)
+
+	t.Run("wrong passphrase", func(t *testing.T) {
+		dst := filepath.Join(dir, "wrong.dec")
+		if err := DecryptFile(enc, dst, "nope"); !errors.Is(err, ErrDecrypt) {
+			t.Errorf("err = %v, want ErrDecrypt", err)
+		}
+		if _, err := os.Stat(dst); !os.IsNotExist(err) {
+			t.Error("output should be removed on failure")
+		}
+	})
+
+	t.Run("truncated after first chunk", func(t *testing.T) {
+		firstChunkEnd := len(encMagic) + saltSize + 4 + encChunkSize + 16
+		truncated := filepath.Join(dir, "truncated.enc")
+		os.WriteFile(truncated, data[:firstChunkEnd], 0600)
+		if err := DecryptFile(truncated, filepath.Join(dir, "t.dec"), "pass"); !errors.Is(err, ErrDecrypt) {
+			t.Errorf("err = %v, want ErrDecrypt", err)
+		}
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		tampered := append([]byte(nil), data...)
+		tampered[len(tampered)-1] ^= 0xff
+		path := filepath.Join(dir, "tampered.enc")
+		os.WriteFile(path, tampered, 0600)
+		if err := DecryptFile(path, filepath.Join(dir, "x.dec"), "pass"); !errors.Is(err, ErrDecrypt) {
+			t.Errorf("err = %v, want ErrDecrypt", err)
+		}
+	})
+
+	t.Run("not encrypted", func(t *testing.T) {
+		if err := DecryptFile(src, filepath.Join(dir, "y.dec"), "pass"); err == nil {
+			t.Error("expected error for a plain file")
+		}
+	})
+}
+
+func TestEncryptFile_EmptyPassphrase(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "plain")
+	os.WriteFile(src, []byte("x"), 0600)
+	if err := EncryptFile(src, filepath.Join(dir, "out.enc"), ""); err == nil {
+		t.Error("expected error for empty passphrase")
+	}
+}
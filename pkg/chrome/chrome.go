@@ -5,14 +5,19 @@ package chrome
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	neturl "net/url"
+	"regexp"
 	"strings"
 	"time"
 
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/target"
 	"github.com/chromedp/chromedp"
+	"github.com/jflowers/get-out/pkg/errcat"
 )
 
 // Session represents a connection to a Chrome browser with an active Slack session.
@@ -22,6 +27,10 @@ type Session struct {
 	ctx         context.Context
 	debugPort   int
 
+	openSlackURL  string
+	keepOpenedTab bool
+	preferTeam    string
+
 	// Extracted credentials
 	Token  string // xoxc token from localStorage
 	Cookie string // xoxd cookie value
@@ -34,6 +43,22 @@ type Config struct {
 
 	// Timeout for operations
 	Timeout time.Duration
+
+	// OpenSlackURL, when set, is opened in a background tab when no Slack
+	// tab is open, so credentials can be extracted from the browser's
+	// existing Slack sign-in (see SlackClientURL). When empty, a Slack tab
+	// must be open.
+	OpenSlackURL string
+
+	// KeepOpenedTab leaves a tab opened for OpenSlackURL open once
+	// credentials are extracted; by default it is closed.
+	KeepOpenedTab bool
+
+	// PreferTeam is the workspace (team ID or domain), such as the one a
+	// configured workspace URL names, to use when several are signed in
+	// and no team is requested. Unlike a requested team it need not be
+	// signed in: a single signed-in workspace is used whatever it is.
+	PreferTeam string
 }
 
 // DefaultConfig returns a config with sensible defaults.
@@ -82,14 +107,17 @@ func Connect(ctx context.Context, cfg *Config) (*Session, error) {
 	)
 	if err != nil {
 		allocCancel()
-		return nil, fmt.Errorf("failed to connect to browser at %s: %w", debugURL, err)
+		return nil, errcat.Wrap(errcat.ChromeUnavailable, fmt.Errorf("failed to connect to browser at %s: %w", debugURL, err))
 	}
 
 	return &Session{
-		allocCtx:    allocCtx,
-		allocCancel: allocCancel,
-		ctx:         browserCtx,
-		debugPort:   cfg.DebugPort,
+		allocCtx:      allocCtx,
+		allocCancel:   allocCancel,
+		ctx:           browserCtx,
+		debugPort:     cfg.DebugPort,
+		openSlackURL:  cfg.OpenSlackURL,
+		keepOpenedTab: cfg.KeepOpenedTab,
+		preferTeam:    cfg.PreferTeam,
 	}, nil
 }
 
@@ -167,18 +195,133 @@ type TargetInfo struct {
 // FindSlackTarget finds a browser tab with Slack loaded.
 // It looks for tabs with URLs containing "slack.com".
 func (s *Session) FindSlackTarget(ctx context.Context) (*TargetInfo, error) {
+	targets, err := s.FindSlackTargets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &targets[0], nil
+}
+
+// FindSlackTargets returns every browser tab with Slack loaded, in the
+// browser's order. It fails when there is none.
+func (s *Session) FindSlackTargets(ctx context.Context) ([]TargetInfo, error) {
 	targets, err := s.ListTargets(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	var slack []TargetInfo
 	for _, t := range targets {
 		if t.Type == "page" && isSlackURL(t.URL) {
-			return &t, nil
+			slack = append(slack, t)
 		}
 	}
+	if len(slack) == 0 {
+		return nil, ErrNoSlackTab
+	}
+	return slack, nil
+}
+
+// ErrNoSlackTab is returned when the browser has no Slack tab open.
+var ErrNoSlackTab = errors.New("no Slack tab found in browser")
+
+// slackBootTimeout bounds how long OpenSlackTab waits for Slack to load.
+const slackBootTimeout = 45 * time.Second
 
-	return nil, fmt.Errorf("no Slack tab found in browser")
+// OpenSlackTab opens url in a new background tab and waits until Slack has
+// loaded in it and stored a signed-in workspace in localStorage. The tab
+// shares the browser's cookies, so no sign-in is needed when the browser
+// was signed in to Slack before its Slack tabs were closed. It fails when
+// Slack does not finish loading within ctx's deadline or 45 seconds, as
+// when the browser is signed out and Slack shows its sign-in page; the tab
+// is then left open to sign in in.
+func (s *Session) OpenSlackTab(ctx context.Context, url string) (TargetInfo, error) {
+	var id target.ID
+	if err := chromedp.Run(s.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		id, err = target.CreateTarget(url).WithBackground(true).Do(cdp.WithExecutor(ctx, chromedp.FromContext(ctx).Browser))
+		return err
+	})); err != nil {
+		return TargetInfo{}, fmt.Errorf("failed to open %s: %w", url, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, slackBootTimeout)
+	defer cancel()
+	// Don't cancel the tab's context — that closes the tab.
+	tabCtx, _ := chromedp.NewContext(s.allocCtx, chromedp.WithTargetID(id))
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		var raw, location string
+		err := chromedp.Run(tabCtx,
+			chromedp.Evaluate(`localStorage.getItem('localConfig_v2') || ''`, &raw),
+			chromedp.Location(&location),
+		)
+		if err == nil {
+			if teams, perr := parseLocalConfig(raw); perr == nil && hasSignedInTeam(teams) {
+				return TargetInfo{TargetID: string(id), Type: "page", Title: "Slack", URL: location}, nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			// The tab is left open: it is where the user signs in.
+			return TargetInfo{}, fmt.Errorf("Slack did not finish loading in the tab opened at %s; sign in to Slack there and try again", url)
+		case <-ticker.C:
+		}
+	}
+}
+
+// CloseTab closes the browser tab targetID.
+func (s *Session) CloseTab(targetID string) error {
+	return chromedp.Run(s.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		return target.CloseTarget(target.ID(targetID)).Do(cdp.WithExecutor(ctx, chromedp.FromContext(ctx).Browser))
+	}))
+}
+
+// SlackClientURL returns the URL to open Slack at for team (a team ID or
+// domain, or empty) and the configured workspace URL: the team's client
+// for a team ID, its workspace for a domain, else the workspace URL when
+// it names a workspace, else https://app.slack.com/client.
+func SlackClientURL(workspaceURL, team string) string {
+	switch {
+	case teamIDPattern.MatchString(team):
+		return "https://app.slack.com/client/" + team
+	case team != "":
+		return "https://" + team + ".slack.com"
+	case SlackTeamOfURL(workspaceURL) != "":
+		return workspaceURL
+	}
+	return "https://app.slack.com/client"
+}
+
+// teamIDPattern matches a Slack team ID, such as T0123ABC.
+var teamIDPattern = regexp.MustCompile(`^[TE][A-Z0-9]{2,}$`)
+
+// SlackTeamOfURL returns the workspace a Slack URL shows: the team ID of
+// an app.slack.com/client/<team> URL or the subdomain of a workspace URL
+// such as https://mycompany.slack.com. It returns "" for other URLs,
+// including plain https://app.slack.com.
+func SlackTeamOfURL(rawURL string) string {
+	if !IsSlackURL(rawURL) {
+		return ""
+	}
+	u, err := neturl.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	host := strings.TrimSuffix(u.Hostname(), ".slack.com")
+	if host == "app" {
+		if rest, ok := strings.CutPrefix(u.Path, "/client/"); ok {
+			team, _, _ := strings.Cut(rest, "/")
+			return team
+		}
+		return ""
+	}
+	if host == "slack.com" || host == "" {
+		return ""
+	}
+	sub, _, _ := strings.Cut(host, ".")
+	return sub
 }
 
 // IsSlackURL checks if a URL belongs to Slack (slack.com or any *.slack.com subdomain).
@@ -2,9 +2,11 @@ package chrome
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -426,8 +428,8 @@ func TestFindSlackTarget_NotFound(t *testing.T) {
 
 	s := &Session{debugPort: port}
 	target, err := s.FindSlackTarget(t.Context())
-	if err == nil {
-		t.Fatal("expected error when no Slack tab, got nil")
+	if !errors.Is(err, ErrNoSlackTab) {
+		t.Fatalf("err = %v, want ErrNoSlackTab", err)
 	}
 	if target != nil {
 		t.Error("expected nil target on error")
@@ -463,3 +465,147 @@ func TestFindSlackTarget_IgnoresNonPageTargets(t *testing.T) {
 		t.Error("expected nil target on error")
 	}
 }
+
+func TestFindSlackTargets_All(t *testing.T) {
+	targets := []cdpTarget{
+		{ID: "1", Type: "page", Title: "Acme", URL: "https://app.slack.com/client/T1/C2"},
+		{ID: "2", Type: "page", Title: "Google", URL: "https://www.google.com"},
+		{ID: "3", Type: "page", Title: "Beta", URL: "https://beta.slack.com/"},
+	}
+	body, _ := json.Marshal(targets)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	var port int
+	fmt.Sscanf(srv.URL, "http://127.0.0.1:%d", &port)
+	if port == 0 {
+		t.Skipf("httptest bound to unexpected address %s", srv.URL)
+	}
+
+	s := &Session{debugPort: port}
+	found, err := s.FindSlackTargets(t.Context())
+	if err != nil {
+		t.Fatalf("FindSlackTargets() error: %v", err)
+	}
+	if len(found) != 2 || found[0].TargetID != "1" || found[1].TargetID != "3" {
+		t.Errorf("FindSlackTargets() = %+v, want tabs 1 and 3", found)
+	}
+}
+
+func TestSlackTeamOfURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://app.slack.com/client/T0123ABC/C456", "T0123ABC"},
+		{"https://app.slack.com/client/T0123ABC", "T0123ABC"},
+		{"https://app.slack.com", ""},
+		{"https://app.slack.com/", ""},
+		{"https://mycompany.slack.com/archives/C1", "mycompany"},
+		{"https://acme.enterprise.slack.com/", "acme"},
+		{"https://slack.com/signin", ""},
+		{"https://example.com/client/T1", ""},
+	}
+	for _, tt := range tests {
+		if got := SlackTeamOfURL(tt.url); got != tt.want {
+			t.Errorf("SlackTeamOfURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestSlackClientURL(t *testing.T) {
+	tests := []struct {
+		workspaceURL, team string
+		want               string
+	}{
+		{"https://app.slack.com", "T0123ABC", "https://app.slack.com/client/T0123ABC"},
+		{"https://app.slack.com", "mycompany", "https://mycompany.slack.com"},
+		{"https://mycompany.slack.com/archives/C1", "", "https://mycompany.slack.com/archives/C1"},
+		{"https://app.slack.com", "", "https://app.slack.com/client"},
+		{"", "", "https://app.slack.com/client"},
+	}
+	for _, tt := range tests {
+		if got := SlackClientURL(tt.workspaceURL, tt.team); got != tt.want {
+			t.Errorf("SlackClientURL(%q, %q) = %q, want %q", tt.workspaceURL, tt.team, got, tt.want)
+		}
+	}
+}
+
+func TestHasSignedInTeam(t *testing.T) {
+	if hasSignedInTeam([]teamConfig{{ID: "T1", Token: ""}, {ID: "T2", Token: "xoxb-bot"}}) {
+		t.Error("hasSignedInTeam() = true for teams without an xoxc token")
+	}
+	if !hasSignedInTeam([]teamConfig{{ID: "T1"}, {ID: "T2", Token: "xoxc-123"}}) {
+		t.Error("hasSignedInTeam() = false with a signed-in team")
+	}
+}
+
+func TestParseLocalConfig(t *testing.T) {
+	teams, err := parseLocalConfig(`{"teams":{"T2":{"id":"T2","domain":"beta","token":"xoxc-2"},"T1":{"id":"T1","domain":"acme","token":"xoxc-1"}}}`)
+	if err != nil {
+		t.Fatalf("parseLocalConfig() error: %v", err)
+	}
+	if len(teams) != 2 || teams[0].Domain != "acme" || teams[1].Domain != "beta" {
+		t.Errorf("teams = %+v, want acme then beta", teams)
+	}
+	for _, raw := range []string{"", "not json", `{"teams":{}}`} {
+		if _, err := parseLocalConfig(raw); err == nil {
+			t.Errorf("parseLocalConfig(%q) succeeded", raw)
+		}
+	}
+}
+
+func TestChooseTeam(t *testing.T) {
+	acme := teamConfig{ID: "T1", Domain: "acme", Token: "xoxc-1"}
+	beta := teamConfig{ID: "T2", Domain: "beta", Token: "xoxc-2"}
+	expired := teamConfig{ID: "T3", Domain: "gone"}
+	tab := func(id, url string, teams ...teamConfig) slackTab {
+		return slackTab{target: TargetInfo{TargetID: id, Type: "page", URL: url}, teams: teams}
+	}
+
+	tests := []struct {
+		name      string
+		tabs      []slackTab
+		want      string
+		prefer    string
+		wantTeam  string
+		wantTab   string
+		ambiguous bool
+		wantErr   bool
+	}{
+		{name: "one team", tabs: []slackTab{tab("1", "https://app.slack.com", acme, expired)}, wantTeam: "T1", wantTab: "1"},
+		{name: "configured by domain", tabs: []slackTab{tab("1", "https://app.slack.com/client/T1", acme, beta)}, want: "beta", wantTeam: "T2", wantTab: "1"},
+		{name: "configured by ID, tab showing it", tabs: []slackTab{tab("1", "https://app.slack.com/client/T1", acme, beta), tab("2", "https://app.slack.com/client/T2", acme, beta)}, want: "t2", wantTeam: "T2", wantTab: "2"},
+		{name: "configured but not signed in", tabs: []slackTab{tab("1", "https://app.slack.com", acme, beta)}, want: "gone", wantErr: true},
+		{name: "preferred of several", tabs: []slackTab{tab("1", "https://app.slack.com/client/T1", acme, beta)}, prefer: "beta", wantTeam: "T2", wantTab: "1"},
+		{name: "preferred but not signed in", tabs: []slackTab{tab("1", "https://app.slack.com", acme, expired)}, prefer: "gone", wantTeam: "T1", wantTab: "1"},
+		{name: "preferred not signed in, one shown", tabs: []slackTab{tab("1", "https://acme.slack.com", acme, beta)}, prefer: "gone", wantTeam: "T1", wantTab: "1"},
+		{name: "requested over preferred", tabs: []slackTab{tab("1", "https://app.slack.com", acme, beta)}, want: "acme", prefer: "beta", wantTeam: "T1", wantTab: "1"},
+		{name: "one workspace shown", tabs: []slackTab{tab("1", "https://app.slack.com/client/T2/C9", acme, beta), tab("2", "https://beta.slack.com/", acme, beta)}, wantTeam: "T2", wantTab: "1"},
+		{name: "two workspaces shown", tabs: []slackTab{tab("1", "https://app.slack.com/client/T1", acme, beta), tab("2", "https://app.slack.com/client/T2", acme, beta)}, ambiguous: true},
+		{name: "none shown", tabs: []slackTab{tab("1", "https://app.slack.com", acme, beta)}, ambiguous: true},
+		{name: "no tokens", tabs: []slackTab{tab("1", "https://app.slack.com", expired)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		team, target, err := chooseTeam(tt.tabs, tt.want, tt.prefer)
+		var ambiguous *AmbiguousTeamError
+		switch {
+		case tt.ambiguous:
+			if !errors.As(err, &ambiguous) || len(ambiguous.Teams) != 2 || !strings.Contains(err.Error(), "acme (T1), beta (T2)") {
+				t.Errorf("%s: error = %v, want an AmbiguousTeamError listing both teams", tt.name, err)
+			}
+		case tt.wantErr:
+			if err == nil {
+				t.Errorf("%s: chooseTeam() succeeded, want an error", tt.name)
+			}
+		case err != nil:
+			t.Errorf("%s: chooseTeam() error: %v", tt.name, err)
+		case team.ID != tt.wantTeam || target.TargetID != tt.wantTab:
+			t.Errorf("%s: chooseTeam() = %s from tab %s, want %s from tab %s", tt.name, team.ID, target.TargetID, tt.wantTeam, tt.wantTab)
+		}
+	}
+}
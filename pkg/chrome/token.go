@@ -3,7 +3,9 @@ package chrome
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/chromedp/cdproto/cdp"
@@ -32,70 +34,245 @@ type teamConfig struct {
 	Name   string `json:"name"`
 }
 
+// AmbiguousTeamError is returned when the browser is signed in to several
+// Slack workspaces and none was chosen.
+type AmbiguousTeamError struct {
+	Teams []TeamInfo
+}
+
+func (e *AmbiguousTeamError) Error() string {
+	return fmt.Sprintf("Slack is signed in to %d workspaces in Chrome (%s); set \"slackTeam\" in settings.json or pass --slack-team to choose one", len(e.Teams), teamList(e.Teams))
+}
+
+// slackTab is a Slack tab and the teams its localStorage is signed in to.
+type slackTab struct {
+	target TargetInfo
+	teams  []teamConfig
+}
+
 // ExtractCredentials extracts Slack credentials from the browser session.
-// It navigates to a Slack tab and extracts the xoxc token and xoxd cookie.
+// It reads the xoxc token and xoxd cookie from a Slack tab. With several
+// workspaces signed in it uses the one every open Slack tab shows, and
+// fails with an *AmbiguousTeamError when the tabs show more than one.
 func (s *Session) ExtractCredentials(ctx context.Context) (*SlackCredentials, error) {
-	// Find the Slack tab
-	slackTarget, err := s.FindSlackTarget(ctx)
+	return s.ExtractCredentialsForTeam(ctx, "")
+}
+
+// ExtractCredentialsForTeam extracts credentials for a specific workspace,
+// given by its team ID (T0123ABC) or domain (mycompany). An empty team
+// behaves like ExtractCredentials, choosing Config.PreferTeam among several
+// signed-in workspaces.
+//
+// When no Slack tab is open and the session was connected with
+// Config.OpenSlackURL, that URL is opened in a background tab to read the
+// credentials from, and the tab is closed afterwards unless
+// Config.KeepOpenedTab is set.
+func (s *Session) ExtractCredentialsForTeam(ctx context.Context, team string) (*SlackCredentials, error) {
+	tabs, err := s.readSlackTabs(ctx)
+	if errors.Is(err, ErrNoSlackTab) && s.openSlackURL != "" {
+		opened, openErr := s.OpenSlackTab(ctx, s.openSlackURL)
+		if openErr != nil {
+			return nil, fmt.Errorf("%w, and opening one failed: %w", err, openErr)
+		}
+		if !s.keepOpenedTab {
+			defer s.CloseTab(opened.TargetID)
+		}
+		tabs, err = s.readSlackTabs(ctx)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	// Switch to the Slack tab
-	// Note: We intentionally discard the cancel func. Calling cancel() would
-	// send Target.closeTarget to Chrome, closing the user's Slack tab.
-	tabCtx, _ := chromedp.NewContext(s.allocCtx, chromedp.WithTargetID(target.ID(slackTarget.TargetID)))
+	chosen, tab, err := chooseTeam(tabs, team, s.preferTeam)
+	if err != nil {
+		return nil, err
+	}
 
-	// Extract token from localStorage
-	var localConfigRaw string
-	err = chromedp.Run(tabCtx,
-		chromedp.Evaluate(`localStorage.getItem('localConfig_v2')`, &localConfigRaw),
-	)
+	// Switch to the team's tab (don't cancel — that closes the tab)
+	tabCtx, _ := chromedp.NewContext(s.allocCtx, chromedp.WithTargetID(target.ID(tab.TargetID)))
+	cookie, err := s.extractSlackCookie(tabCtx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read localStorage: %w", err)
+		return nil, fmt.Errorf("failed to extract cookie: %w", err)
 	}
 
-	if localConfigRaw == "" {
-		return nil, fmt.Errorf("no Slack config found in localStorage (localConfig_v2 is empty)")
+	creds := &SlackCredentials{
+		Token:      chosen.Token,
+		Cookie:     cookie,
+		TeamID:     chosen.ID,
+		TeamDomain: chosen.Domain,
+	}
+
+	// Store in session for convenience
+	s.Token = creds.Token
+	s.Cookie = creds.Cookie
+
+	return creds, nil
+}
+
+// readSlackTabs reads the teams of every Slack tab's localStorage. Tabs
+// whose localStorage cannot be read are skipped; it fails when none has
+// any team.
+func (s *Session) readSlackTabs(ctx context.Context) ([]slackTab, error) {
+	targets, err := s.FindSlackTargets(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	// Parse the config
+	var tabs []slackTab
+	var lastErr error
+	for _, t := range targets {
+		// Note: We intentionally discard the cancel func. Calling cancel() would
+		// send Target.closeTarget to Chrome, closing the user's Slack tab.
+		tabCtx, _ := chromedp.NewContext(s.allocCtx, chromedp.WithTargetID(target.ID(t.TargetID)))
+
+		var localConfigRaw string
+		if err := chromedp.Run(tabCtx,
+			chromedp.Evaluate(`localStorage.getItem('localConfig_v2')`, &localConfigRaw),
+		); err != nil {
+			lastErr = fmt.Errorf("failed to read localStorage: %w", err)
+			continue
+		}
+		teams, err := parseLocalConfig(localConfigRaw)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		tabs = append(tabs, slackTab{target: t, teams: teams})
+	}
+	if len(tabs) == 0 {
+		if lastErr == nil {
+			lastErr = fmt.Errorf("no Slack config found in localStorage (localConfig_v2 is empty)")
+		}
+		return nil, lastErr
+	}
+	return tabs, nil
+}
+
+// parseLocalConfig returns the teams of Slack's localConfig_v2, sorted by
+// domain.
+func parseLocalConfig(raw string) ([]teamConfig, error) {
+	if raw == "" {
+		return nil, fmt.Errorf("no Slack config found in localStorage (localConfig_v2 is empty)")
+	}
 	var config localConfigV2
-	if err := json.Unmarshal([]byte(localConfigRaw), &config); err != nil {
+	if err := json.Unmarshal([]byte(raw), &config); err != nil {
 		return nil, fmt.Errorf("failed to parse localStorage config: %w", err)
 	}
-
 	if len(config.Teams) == 0 {
 		return nil, fmt.Errorf("no teams found in localStorage config")
 	}
+	teams := make([]teamConfig, 0, len(config.Teams))
+	for _, t := range config.Teams {
+		teams = append(teams, t)
+	}
+	sort.Slice(teams, func(i, j int) bool { return teams[i].Domain < teams[j].Domain })
+	return teams, nil
+}
 
-	// Get the first team's token (or we could let user specify which workspace)
-	var creds SlackCredentials
-	for _, team := range config.Teams {
-		if team.Token != "" && strings.HasPrefix(team.Token, "xoxc-") {
-			creds.Token = team.Token
-			creds.TeamID = team.ID
-			creds.TeamDomain = team.Domain
-			break
+// chooseTeam picks the team to use from the teams signed in across tabs,
+// and the tab to read its cookie from.
+//
+// A requested team (ID or domain) must be signed in. Without one, a single
+// signed-in team is used; with several, the preferred one (ID or domain)
+// if it is signed in, else the one the open Slack tabs show, if they all
+// show the same one. Otherwise the choice is ambiguous.
+func chooseTeam(tabs []slackTab, want, prefer string) (teamConfig, TargetInfo, error) {
+	var signedIn []teamConfig
+	tabOf := make(map[string]TargetInfo)
+	showing := make(map[string]bool)
+	for _, tab := range tabs {
+		for _, t := range tab.teams {
+			if !strings.HasPrefix(t.Token, "xoxc-") {
+				continue
+			}
+			if _, seen := tabOf[t.ID]; !seen {
+				signedIn = append(signedIn, t)
+				tabOf[t.ID] = tab.target
+			}
+			// Prefer the first tab showing the team for its cookie.
+			if !showing[t.ID] && teamMatches(t, SlackTeamOfURL(tab.target.URL)) {
+				showing[t.ID] = true
+				tabOf[t.ID] = tab.target
+			}
 		}
 	}
+	if len(signedIn) == 0 {
+		return teamConfig{}, TargetInfo{}, fmt.Errorf("no valid xoxc token found in localStorage")
+	}
 
-	if creds.Token == "" {
-		return nil, fmt.Errorf("no valid xoxc token found in localStorage")
+	if want != "" {
+		for _, t := range signedIn {
+			if teamMatches(t, want) {
+				return t, tabOf[t.ID], nil
+			}
+		}
+		return teamConfig{}, TargetInfo{}, fmt.Errorf("Slack workspace %q is not signed in in Chrome; signed in: %s", want, teamList(teamInfos(signedIn)))
+	}
+	if len(signedIn) == 1 {
+		return signedIn[0], tabOf[signedIn[0].ID], nil
+	}
+	if prefer != "" {
+		for _, t := range signedIn {
+			if teamMatches(t, prefer) {
+				return t, tabOf[t.ID], nil
+			}
+		}
 	}
 
-	// Extract the d cookie (xoxd-...)
-	cookie, err := s.extractSlackCookie(tabCtx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to extract cookie: %w", err)
+	// Several workspaces: use the one the tabs show, if only one is shown.
+	var shown *teamConfig
+	for _, tab := range tabs {
+		team := SlackTeamOfURL(tab.target.URL)
+		if team == "" {
+			continue
+		}
+		for i := range signedIn {
+			if !teamMatches(signedIn[i], team) {
+				continue
+			}
+			if shown != nil && shown.ID != signedIn[i].ID {
+				return teamConfig{}, TargetInfo{}, &AmbiguousTeamError{Teams: teamInfos(signedIn)}
+			}
+			shown = &signedIn[i]
+		}
 	}
-	creds.Cookie = cookie
+	if shown == nil {
+		return teamConfig{}, TargetInfo{}, &AmbiguousTeamError{Teams: teamInfos(signedIn)}
+	}
+	return *shown, tabOf[shown.ID], nil
+}
 
-	// Store in session for convenience
-	s.Token = creds.Token
-	s.Cookie = creds.Cookie
+// hasSignedInTeam reports whether any of teams has a browser session token.
+func hasSignedInTeam(teams []teamConfig) bool {
+	for _, t := range teams {
+		if strings.HasPrefix(t.Token, "xoxc-") {
+			return true
+		}
+	}
+	return false
+}
+
+// teamMatches reports whether want names t by ID or domain.
+func teamMatches(t teamConfig, want string) bool {
+	return want != "" && (strings.EqualFold(t.ID, want) || strings.EqualFold(t.Domain, want))
+}
 
-	return &creds, nil
+// teamInfos converts signed-in teams to TeamInfo.
+func teamInfos(teams []teamConfig) []TeamInfo {
+	infos := make([]TeamInfo, len(teams))
+	for i, t := range teams {
+		infos[i] = TeamInfo{ID: t.ID, Domain: t.Domain, Name: t.Name, HasToken: strings.HasPrefix(t.Token, "xoxc-")}
+	}
+	return infos
+}
+
+// teamList formats teams as "domain (ID)" for error messages.
+func teamList(teams []TeamInfo) string {
+	parts := make([]string, len(teams))
+	for i, t := range teams {
+		parts[i] = fmt.Sprintf("%s (%s)", t.Domain, t.ID)
+	}
+	return strings.Join(parts, ", ")
 }
 
 // extractSlackCookie extracts the 'd' cookie from Slack domain.
@@ -123,100 +300,24 @@ func (s *Session) extractSlackCookie(ctx context.Context) (string, error) {
 	return "", fmt.Errorf("no 'd' cookie found for slack.com")
 }
 
-// ExtractCredentialsForTeam extracts credentials for a specific team/workspace.
-func (s *Session) ExtractCredentialsForTeam(ctx context.Context, teamDomain string) (*SlackCredentials, error) {
-	// Find the Slack tab
-	slackTarget, err := s.FindSlackTarget(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	// Switch to the Slack tab (don't cancel — that closes the tab)
-	tabCtx, _ := chromedp.NewContext(s.allocCtx, chromedp.WithTargetID(target.ID(slackTarget.TargetID)))
-
-	// Extract token from localStorage
-	var localConfigRaw string
-	err = chromedp.Run(tabCtx,
-		chromedp.Evaluate(`localStorage.getItem('localConfig_v2')`, &localConfigRaw),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read localStorage: %w", err)
-	}
-
-	if localConfigRaw == "" {
-		return nil, fmt.Errorf("no Slack config found in localStorage")
-	}
-
-	// Parse the config
-	var config localConfigV2
-	if err := json.Unmarshal([]byte(localConfigRaw), &config); err != nil {
-		return nil, fmt.Errorf("failed to parse localStorage config: %w", err)
-	}
-
-	// Find the specific team
-	var creds SlackCredentials
-	for _, team := range config.Teams {
-		if team.Domain == teamDomain && strings.HasPrefix(team.Token, "xoxc-") {
-			creds.Token = team.Token
-			creds.TeamID = team.ID
-			creds.TeamDomain = team.Domain
-			break
-		}
-	}
-
-	if creds.Token == "" {
-		return nil, fmt.Errorf("no token found for team domain %q", teamDomain)
-	}
-
-	// Extract the d cookie
-	cookie, err := s.extractSlackCookie(tabCtx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to extract cookie: %w", err)
-	}
-	creds.Cookie = cookie
-
-	s.Token = creds.Token
-	s.Cookie = creds.Cookie
-
-	return &creds, nil
-}
-
-// ListAvailableTeams returns all teams/workspaces available in the browser session.
+// ListAvailableTeams returns all teams/workspaces available in the browser
+// session, across its Slack tabs.
 func (s *Session) ListAvailableTeams(ctx context.Context) ([]TeamInfo, error) {
-	slackTarget, err := s.FindSlackTarget(ctx)
+	tabs, err := s.readSlackTabs(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	tabCtx, _ := chromedp.NewContext(s.allocCtx, chromedp.WithTargetID(target.ID(slackTarget.TargetID)))
-
-	var localConfigRaw string
-	err = chromedp.Run(tabCtx,
-		chromedp.Evaluate(`localStorage.getItem('localConfig_v2')`, &localConfigRaw),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read localStorage: %w", err)
-	}
-
-	if localConfigRaw == "" {
-		return nil, nil
-	}
-
-	var config localConfigV2
-	if err := json.Unmarshal([]byte(localConfigRaw), &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config: %w", err)
-	}
-
 	var teams []TeamInfo
-	for _, t := range config.Teams {
-		teams = append(teams, TeamInfo{
-			ID:       t.ID,
-			Domain:   t.Domain,
-			Name:     t.Name,
-			HasToken: t.Token != "" && strings.HasPrefix(t.Token, "xoxc-"),
-		})
+	seen := make(map[string]bool)
+	for _, tab := range tabs {
+		for _, t := range teamInfos(tab.teams) {
+			if !seen[t.ID] {
+				seen[t.ID] = true
+				teams = append(teams, t)
+			}
+		}
 	}
-
 	return teams, nil
 }
 
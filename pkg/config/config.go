@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
+	"github.com/jflowers/get-out/pkg/errcat"
 	"github.com/jflowers/get-out/pkg/models"
 )
 
@@ -49,9 +52,18 @@ func ValidateSlackURL(rawURL string) error {
 }
 
 // LoadSettings loads settings.json from the config directory.
-// Returns default settings if the file doesn't exist.
+// Returns default settings if the file doesn't exist. A file from an older
+// release is upgraded in place (see SettingsMigrations). Errors are tagged
+// errcat.ConfigInvalid.
 func LoadSettings(path string) (*Settings, error) {
-	data, err := os.ReadFile(path)
+	settings, err := loadSettings(path, SettingsMigrations.LoadFile)
+	return settings, errcat.Wrap(errcat.ConfigInvalid, err)
+}
+
+// loadSettings parses and validates the settings read by readFile, which
+// also applies migrations.
+func loadSettings(path string, readFile func(string) ([]byte, error)) (*Settings, error) {
+	data, err := readFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			// settings.json is optional, return defaults
@@ -76,17 +88,156 @@ func LoadSettings(path string) (*Settings, error) {
 		return nil, fmt.Errorf("invalid slackWorkspaceUrl in settings: %w", err)
 	}
 
+	if err := validateEmailDigestConfig(settings.EmailDigest); err != nil {
+		return nil, fmt.Errorf("invalid emailDigest in settings: %w", err)
+	}
+
+	if err := validateTranslationConfig(settings.Translation); err != nil {
+		return nil, fmt.Errorf("invalid translation in settings: %w", err)
+	}
+
+	if !isValidNamePolicy(settings.NamePolicy) {
+		return nil, fmt.Errorf("invalid namePolicy in settings: %q (must be %s, %s, or %s)",
+			settings.NamePolicy, NamePolicyDisplayFirst, NamePolicyRealFirst, NamePolicyBoth)
+	}
+
+	if settings.Timezone != "" {
+		if _, err := time.LoadLocation(settings.Timezone); err != nil {
+			return nil, fmt.Errorf("invalid timezone in settings: %q is not a known IANA time zone", settings.Timezone)
+		}
+	}
+	if err := validateTimeLayout(settings.TimeFormat); err != nil {
+		return nil, fmt.Errorf("invalid timeFormat in settings: %w", err)
+	}
+	if err := validateTimeLayout(settings.DateFormat); err != nil {
+		return nil, fmt.Errorf("invalid dateFormat in settings: %w", err)
+	}
+
+	if settings.FolderWarnItems < 0 {
+		return nil, fmt.Errorf("invalid folderWarnItems in settings: %d (must be >= 0)", settings.FolderWarnItems)
+	}
+	if settings.AutoFolderLayout != "" && settings.AutoFolderLayout != FolderLayoutYear && settings.AutoFolderLayout != FolderLayoutMonth {
+		return nil, fmt.Errorf("invalid autoFolderLayout in settings: %q (must be %s or %s)",
+			settings.AutoFolderLayout, FolderLayoutYear, FolderLayoutMonth)
+	}
+	if q := settings.GoogleQuota; q != nil && (q.DailyDocsWrites < 0 || q.DailyDriveQueries < 0) {
+		return nil, fmt.Errorf("invalid googleQuota in settings: budgets must be >= 0")
+	}
+	for convType, d := range settings.ConversationDefaults {
+		if !isValidConversationType(convType) {
+			return nil, fmt.Errorf("invalid conversationDefaults in settings: unknown type %q", convType)
+		}
+		if d != nil && !isValidFolderLayout(d.Layout) {
+			return nil, fmt.Errorf("invalid conversationDefaults.%s.layout in settings: %q (must be %s, %s, or %s)",
+				convType, d.Layout, FolderLayoutFlat, FolderLayoutYear, FolderLayoutMonth)
+		}
+		if d != nil && !isValidDocGranularity(d.DocGranularity) {
+			return nil, fmt.Errorf("invalid conversationDefaults.%s.docGranularity in settings: %q (must be %s, %s, %s, or %s)",
+				convType, d.DocGranularity, DocGranularityDaily, DocGranularityWeekly, DocGranularityMonthly, DocGranularitySingle)
+		}
+		if d != nil && !isValidOutputFormat(d.Format) {
+			return nil, fmt.Errorf("invalid conversationDefaults.%s.format in settings: %q (must be %s, %s, %s, %s, %s, or %s)",
+				convType, d.Format, OutputFormatDocs, OutputFormatMarkdown, OutputFormatJSON, OutputFormatHTML, OutputFormatSlack, OutputFormatSQLite)
+		}
+	}
+
 	return settings, nil
 }
 
+// validateTimeLayout checks that a non-empty Go time layout formats
+// something of the time rather than printing itself.
+func validateTimeLayout(layout string) error {
+	if layout == "" {
+		return nil
+	}
+	if time.Date(2001, 11, 22, 7, 8, 9, 0, time.UTC).Format(layout) == layout {
+		return fmt.Errorf("%q has no date or time fields; write it with the reference time 15:04:05 on Mon Jan 2 2006, e.g. \"15:04\"", layout)
+	}
+	return nil
+}
+
+// validateEmailDigestConfig checks that an enabled email digest has
+// everything its transport needs to send mail. A zero smtpPort is left for
+// the mailer to default.
+func validateEmailDigestConfig(cfg *EmailDigestConfig) error {
+	if cfg == nil || !cfg.Enabled {
+		return nil
+	}
+	if len(cfg.To) == 0 {
+		return fmt.Errorf("at least one recipient in to is required")
+	}
+	switch cfg.Transport {
+	case DigestTransportGmail:
+		return nil
+	case "", DigestTransportSMTP:
+	default:
+		return fmt.Errorf("transport %q is not %s or %s", cfg.Transport, DigestTransportSMTP, DigestTransportGmail)
+	}
+	if cfg.SMTPHost == "" {
+		return fmt.Errorf("smtpHost is required")
+	}
+	if cfg.From == "" {
+		return fmt.Errorf("from is required")
+	}
+	if cfg.SMTPPort < 0 || cfg.SMTPPort > 65535 {
+		return fmt.Errorf("smtpPort %d is out of range", cfg.SMTPPort)
+	}
+	return nil
+}
+
+// validateTranslationConfig checks that an enabled translation step has a
+// target language and exactly one translator, and applies the default
+// timeout.
+func validateTranslationConfig(cfg *TranslationConfig) error {
+	if cfg == nil || !cfg.Enabled {
+		return nil
+	}
+	if cfg.TargetLanguage == "" {
+		return fmt.Errorf("targetLanguage is required")
+	}
+	if (len(cfg.Command) == 0) == (cfg.URL == "") {
+		return fmt.Errorf("exactly one of command and url is required")
+	}
+	if cfg.URL != "" {
+		u, err := url.Parse(cfg.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("url must be an http or https URL, got %q", cfg.URL)
+		}
+	}
+	if cfg.TimeoutSeconds < 0 {
+		return fmt.Errorf("timeoutSeconds %d must be >= 0", cfg.TimeoutSeconds)
+	}
+	if cfg.TimeoutSeconds == 0 {
+		cfg.TimeoutSeconds = DefaultTranslationTimeoutSeconds
+	}
+	return nil
+}
+
+// isValidNamePolicy reports whether p is a known name policy. Empty means
+// the default (display-first).
+func isValidNamePolicy(p NamePolicy) bool {
+	switch p {
+	case "", NamePolicyDisplayFirst, NamePolicyRealFirst, NamePolicyBoth:
+		return true
+	}
+	return false
+}
+
 // LoadConversations loads and validates conversations.json from the given path.
 // It returns a non-nil *ConversationsConfig on success with all conversation
 // entries validated and defaults applied.
 // It returns (nil, error) if the file cannot be read, the JSON is malformed,
 // or any conversation entry fails validation. Errors are wrapped with context
-// describing the failure stage (read, parse, or validation at a specific index).
+// describing the failure stage (read, parse, or validation at a specific index)
+// and tagged errcat.ConfigInvalid. A file from an older release is upgraded
+// in place (see ConversationsMigrations).
 func LoadConversations(path string) (*ConversationsConfig, error) {
-	data, err := os.ReadFile(path)
+	cfg, err := loadConversations(path, ConversationsMigrations.LoadFile)
+	return cfg, errcat.Wrap(errcat.ConfigInvalid, err)
+}
+
+func loadConversations(path string, readFile func(string) ([]byte, error)) (*ConversationsConfig, error) {
+	data, err := readFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read conversations config: %w", err)
 	}
@@ -109,12 +260,23 @@ func LoadConversations(path string) (*ConversationsConfig, error) {
 		}
 	}
 
+	if err := validateConversationAliases(cfg.Conversations); err != nil {
+		return nil, err
+	}
+
 	return &cfg, nil
 }
 
-// LoadPeople loads and validates people.json.
+// LoadPeople loads and validates people.json. A file from an older release
+// is upgraded in place (see PeopleMigrations). Errors are tagged
+// errcat.ConfigInvalid.
 func LoadPeople(path string) (*PeopleConfig, error) {
-	data, err := os.ReadFile(path)
+	cfg, err := loadPeople(path, PeopleMigrations.LoadFile)
+	return cfg, errcat.Wrap(errcat.ConfigInvalid, err)
+}
+
+func loadPeople(path string, readFile func(string) ([]byte, error)) (*PeopleConfig, error) {
+	data, err := readFile(path)
 	if err != nil {
 		// people.json is optional
 		if os.IsNotExist(err) {
@@ -152,6 +314,193 @@ func validateConversationConfig(c *ConversationConfig) error {
 	if !isValidConversationType(c.Type) {
 		return fmt.Errorf("invalid type: %s", c.Type)
 	}
+	for _, alias := range c.Aliases {
+		if !conversationIDPattern.MatchString(alias) {
+			return fmt.Errorf("invalid alias id format: %s", alias)
+		}
+		if alias == c.ID {
+			return fmt.Errorf("alias %s is the conversation's own id", alias)
+		}
+	}
+	if !isValidFolderLayout(c.Layout) {
+		return fmt.Errorf("invalid layout: %q (must be %s, %s, or %s)", c.Layout, FolderLayoutFlat, FolderLayoutYear, FolderLayoutMonth)
+	}
+	if !isValidDocGranularity(c.DocGranularity) {
+		return fmt.Errorf("invalid docGranularity: %q (must be %s, %s, %s, or %s)", c.DocGranularity, DocGranularityDaily, DocGranularityWeekly, DocGranularityMonthly, DocGranularitySingle)
+	}
+	if !isValidOutputFormat(c.Format) {
+		return fmt.Errorf("invalid format: %q (must be %s, %s, %s, %s, %s, or %s)", c.Format, OutputFormatDocs, OutputFormatMarkdown, OutputFormatJSON, OutputFormatHTML, OutputFormatSlack, OutputFormatSQLite)
+	}
+	return nil
+}
+
+// isValidFolderLayout reports whether l is a known folder layout. Empty
+// means the default (flat).
+func isValidFolderLayout(l FolderLayout) bool {
+	switch l {
+	case "", FolderLayoutFlat, FolderLayoutYear, FolderLayoutMonth:
+		return true
+	}
+	return false
+}
+
+// isValidDocGranularity reports whether g is a known doc granularity.
+// Empty means the default (daily).
+func isValidDocGranularity(g DocGranularity) bool {
+	switch g {
+	case "", DocGranularityDaily, DocGranularityWeekly, DocGranularityMonthly, DocGranularitySingle:
+		return true
+	}
+	return false
+}
+
+// isValidOutputFormat reports whether f is a known output format. Empty
+// means the default (docs).
+func isValidOutputFormat(f OutputFormat) bool {
+	switch f {
+	case "", OutputFormatDocs, OutputFormatMarkdown, OutputFormatJSON, OutputFormatHTML, OutputFormatSlack, OutputFormatSQLite:
+		return true
+	}
+	return false
+}
+
+// ParseOutputFormat parses an output format name, as given to
+// export --format.
+func ParseOutputFormat(s string) (OutputFormat, error) {
+	f := OutputFormat(strings.ToLower(strings.TrimSpace(s)))
+	if f == "" || !isValidOutputFormat(f) {
+		return "", fmt.Errorf("invalid format: %q (must be %s, %s, %s, %s, %s, or %s)", s, OutputFormatDocs, OutputFormatMarkdown, OutputFormatJSON, OutputFormatHTML, OutputFormatSlack, OutputFormatSQLite)
+	}
+	return f, nil
+}
+
+// OutputFormat returns the conversation's format, docs when unset.
+func (c ConversationConfig) OutputFormat() OutputFormat {
+	if c.Format == "" {
+		return OutputFormatDocs
+	}
+	return c.Format
+}
+
+// WritesDocs reports whether the conversation is exported to Google Docs.
+func (c ConversationConfig) WritesDocs() bool {
+	return c.OutputFormat() == OutputFormatDocs
+}
+
+// WritesMarkdown reports whether the conversation is written as local
+// markdown: by its markdown format, or by localExport alongside docs.
+func (c ConversationConfig) WritesMarkdown() bool {
+	switch c.OutputFormat() {
+	case OutputFormatMarkdown:
+		return true
+	case OutputFormatDocs:
+		return c.LocalExport
+	}
+	return false
+}
+
+// WritesLocal reports whether the conversation writes anything to the
+// local export directory.
+func (c ConversationConfig) WritesLocal() bool {
+	return !c.WritesDocs() || c.LocalExport
+}
+
+// Template file suffixes in the templates directory (see LoadTemplates).
+const (
+	MarkdownTemplateSuffix = ".md.tmpl"
+	HTMLTemplateSuffix     = ".html.tmpl"
+)
+
+// LoadTemplates returns the layouts defined in settings (see
+// Settings.Templates) together with those in dir, the templates directory:
+// {name}.md.tmpl holds a layout's markdown template and {name}.html.tmpl
+// its html template. A missing directory adds none. A template defined
+// both in settings and in a file is an error, as it is unclear which is
+// meant.
+func LoadTemplates(dir string, defined map[string]*TemplateConfig) (map[string]*TemplateConfig, error) {
+	templates := make(map[string]*TemplateConfig, len(defined))
+	for name, t := range defined {
+		if name == "" || t == nil {
+			return nil, fmt.Errorf("invalid templates in settings: a layout needs a name and a template")
+		}
+		copied := *t
+		templates[name] = &copied
+	}
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return templates, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read templates directory: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		file := entry.Name()
+		var name string
+		var field func(*TemplateConfig) *string
+		switch {
+		case strings.HasSuffix(file, MarkdownTemplateSuffix):
+			name = strings.TrimSuffix(file, MarkdownTemplateSuffix)
+			field = func(t *TemplateConfig) *string { return &t.Markdown }
+		case strings.HasSuffix(file, HTMLTemplateSuffix):
+			name = strings.TrimSuffix(file, HTMLTemplateSuffix)
+			field = func(t *TemplateConfig) *string { return &t.HTML }
+		default:
+			continue
+		}
+		if name == "" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template: %w", err)
+		}
+		t := templates[name]
+		if t == nil {
+			t = &TemplateConfig{}
+			templates[name] = t
+		}
+		if *field(t) != "" {
+			return nil, fmt.Errorf("template %s is defined both in settings and in %s", name, file)
+		}
+		*field(t) = string(data)
+	}
+	return templates, nil
+}
+
+// CheckTemplates returns an error for the first conversation that selects
+// a layout templates does not define.
+func CheckTemplates(convs []ConversationConfig, templates map[string]*TemplateConfig) error {
+	for _, c := range convs {
+		if c.Template != "" && templates[c.Template] == nil {
+			return fmt.Errorf("conversation %s (%s) uses template %q, which is not defined in settings.json templates or the templates directory", c.Name, c.ID, c.Template)
+		}
+	}
+	return nil
+}
+
+// validateConversationAliases ensures each alias belongs to exactly one
+// conversation and does not shadow another configured conversation ID.
+func validateConversationAliases(convs []ConversationConfig) error {
+	ids := make(map[string]bool, len(convs))
+	for _, c := range convs {
+		ids[c.ID] = true
+	}
+	owner := make(map[string]string)
+	for _, c := range convs {
+		for _, alias := range c.Aliases {
+			if ids[alias] {
+				return fmt.Errorf("alias %s of %s is also configured as a conversation", alias, c.ID)
+			}
+			if prev, ok := owner[alias]; ok && prev != c.ID {
+				return fmt.Errorf("alias %s is declared by both %s and %s", alias, prev, c.ID)
+			}
+			owner[alias] = c.ID
+		}
+	}
 	return nil
 }
 
@@ -183,6 +532,62 @@ func isValidConversationType(t models.ConversationType) bool {
 	return false
 }
 
+// UnmarshalJSON decodes a conversations.json entry, recording which fields
+// it sets so ApplyDefaults leaves them alone.
+func (c *ConversationConfig) UnmarshalJSON(data []byte) error {
+	type plain ConversationConfig
+	if err := json.Unmarshal(data, (*plain)(c)); err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	c.explicit = make(map[string]bool, len(fields))
+	for key := range fields {
+		c.explicit[key] = true
+	}
+	return nil
+}
+
+// ApplyDefaults fills the fields each conversation did not set in
+// conversations.json from the defaults for its type (see
+// Settings.ConversationDefaults). Conversations not read from the file
+// are left as they are.
+func (c *ConversationsConfig) ApplyDefaults(defaults map[models.ConversationType]*ConversationDefaults) {
+	for i := range c.Conversations {
+		conv := &c.Conversations[i]
+		d := defaults[conv.Type]
+		if d == nil || conv.explicit == nil {
+			continue
+		}
+		if d.Export != nil && !conv.explicit["export"] {
+			conv.Export = *d.Export
+		}
+		if d.LocalExport != nil && !conv.explicit["localExport"] {
+			conv.LocalExport = *d.LocalExport
+		}
+		if d.Share != nil && !conv.explicit["share"] {
+			conv.Share = *d.Share
+		}
+		if d.ShareMembers != nil && !conv.explicit["shareMembers"] {
+			conv.ShareMembers = append([]string(nil), d.ShareMembers...)
+		}
+		if d.Layout != "" && !conv.explicit["layout"] {
+			conv.Layout = d.Layout
+		}
+		if d.DocGranularity != "" && !conv.explicit["docGranularity"] {
+			conv.DocGranularity = d.DocGranularity
+		}
+		if d.Format != "" && !conv.explicit["format"] {
+			conv.Format = d.Format
+		}
+		if d.Template != "" && !conv.explicit["template"] {
+			conv.Template = d.Template
+		}
+	}
+}
+
 // FilterByExport returns only conversations where export=true.
 func (c *ConversationsConfig) FilterByExport() []ConversationConfig {
 	var result []ConversationConfig
@@ -2,9 +2,13 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/jflowers/get-out/pkg/models"
 )
 
 func TestLoadConversations(t *testing.T) {
@@ -191,8 +195,9 @@ func TestLoadSettings(t *testing.T) {
 	if err != nil {
 		t.Fatalf("LoadSettings() error: %v", err)
 	}
-	if s.GoogleDriveFolderID != "folder123" {
-		t.Errorf("GoogleDriveFolderID = %q, want folder123", s.GoogleDriveFolderID)
+	// The legacy field is migrated to folder_id.
+	if s.FolderID != "folder123" || s.GoogleDriveFolderID != "" {
+		t.Errorf("FolderID = %q, GoogleDriveFolderID = %q, want folder123 moved to FolderID", s.FolderID, s.GoogleDriveFolderID)
 	}
 	if s.LogLevel != "DEBUG" {
 		t.Errorf("LogLevel = %q, want DEBUG", s.LogLevel)
@@ -752,3 +757,515 @@ func TestDefaultSettings_DistinctInstances(t *testing.T) {
 		t.Errorf("mutation leaked between instances: b.LogLevel = %q, want 'INFO'", b.LogLevel)
 	}
 }
+
+// ---------------------------------------------------------------------------
+// EmailDigest settings
+// ---------------------------------------------------------------------------
+
+func TestLoadSettings_WithEmailDigestConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "settings.json")
+	data := `{
+		"emailDigest": {
+			"enabled": true,
+			"smtpHost": "smtp.gmail.com",
+			"username": "me@example.com",
+			"from": "me@example.com",
+			"to": ["archive@example.com"]
+		}
+	}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := LoadSettings(path)
+	if err != nil {
+		t.Fatalf("LoadSettings() error: %v", err)
+	}
+	if s.EmailDigest == nil || !s.EmailDigest.Enabled {
+		t.Fatal("EmailDigest should be enabled")
+	}
+	if s.EmailDigest.SMTPPort != 0 {
+		t.Errorf("SMTPPort = %d, want 0 for the mailer's default", s.EmailDigest.SMTPPort)
+	}
+	if s.EmailDigest.UsesGmail() {
+		t.Error("UsesGmail() = true without a gmail transport")
+	}
+	if len(s.EmailDigest.To) != 1 || s.EmailDigest.To[0] != "archive@example.com" {
+		t.Errorf("To = %v", s.EmailDigest.To)
+	}
+}
+
+func TestLoadSettings_EmailDigestValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		digest  string
+		wantErr bool
+	}{
+		{"disabled skips validation", `{"enabled": false}`, false},
+		{"missing host", `{"enabled": true, "from": "a@example.com", "to": ["b@example.com"]}`, true},
+		{"missing from", `{"enabled": true, "smtpHost": "smtp", "to": ["b@example.com"]}`, true},
+		{"missing to", `{"enabled": true, "smtpHost": "smtp", "from": "a@example.com"}`, true},
+		{"bad port", `{"enabled": true, "smtpHost": "smtp", "smtpPort": 70000, "from": "a@example.com", "to": ["b@example.com"]}`, true},
+		{"valid", `{"enabled": true, "smtpHost": "smtp", "smtpPort": 25, "from": "a@example.com", "to": ["b@example.com"]}`, false},
+		{"unknown transport", `{"enabled": true, "transport": "pigeon", "to": ["b@example.com"]}`, true},
+		{"gmail needs no smtp settings", `{"enabled": true, "transport": "gmail", "to": ["b@example.com"]}`, false},
+		{"gmail missing to", `{"enabled": true, "transport": "gmail"}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "settings.json")
+			data := `{"emailDigest": ` + tt.digest + `}`
+			if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := LoadSettings(path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadSettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadSettings_TranslationValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		translation string
+		wantErr     bool
+	}{
+		{"disabled skips validation", `{"enabled": false}`, false},
+		{"missing target", `{"enabled": true, "command": ["trans"]}`, true},
+		{"no translator", `{"enabled": true, "targetLanguage": "en"}`, true},
+		{"both translators", `{"enabled": true, "targetLanguage": "en", "command": ["trans"], "url": "http://localhost:5000/translate"}`, true},
+		{"bad url", `{"enabled": true, "targetLanguage": "en", "url": "localhost:5000"}`, true},
+		{"negative timeout", `{"enabled": true, "targetLanguage": "en", "command": ["trans"], "timeoutSeconds": -1}`, true},
+		{"command", `{"enabled": true, "targetLanguage": "en", "command": ["trans", "-b"]}`, false},
+		{"url", `{"enabled": true, "targetLanguage": "en", "url": "https://translate.example.com/v1"}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "settings.json")
+			if err := os.WriteFile(path, []byte(`{"translation": `+tt.translation+`}`), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			s, err := LoadSettings(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadSettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && s.Translation.Enabled && s.Translation.TimeoutSeconds != DefaultTranslationTimeoutSeconds {
+				t.Errorf("TimeoutSeconds = %d, want default %d", s.Translation.TimeoutSeconds, DefaultTranslationTimeoutSeconds)
+			}
+		})
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Name policy
+// ---------------------------------------------------------------------------
+
+func TestLoadSettings_NamePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    NamePolicy
+		wantErr bool
+	}{
+		{"omitted", `{}`, "", false},
+		{"real-first", `{"namePolicy": "real-first"}`, NamePolicyRealFirst, false},
+		{"both", `{"namePolicy": "both"}`, NamePolicyBoth, false},
+		{"unknown", `{"namePolicy": "nickname"}`, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "settings.json")
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			s, err := LoadSettings(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadSettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && s.NamePolicy != tt.want {
+				t.Errorf("NamePolicy = %q, want %q", s.NamePolicy, tt.want)
+			}
+		})
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Conversation aliases
+// ---------------------------------------------------------------------------
+
+func TestLoadConversations_Aliases(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{
+			name:    "valid aliases",
+			data:    `{"conversations": [{"id": "G222", "name": "alice-bob", "type": "mpim", "export": true, "aliases": ["D111"]}]}`,
+			wantErr: false,
+		},
+		{
+			name:    "invalid alias format",
+			data:    `{"conversations": [{"id": "G222", "name": "alice-bob", "type": "mpim", "export": true, "aliases": ["nope"]}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "alias is own id",
+			data:    `{"conversations": [{"id": "G222", "name": "alice-bob", "type": "mpim", "export": true, "aliases": ["G222"]}]}`,
+			wantErr: true,
+		},
+		{
+			name: "alias shadows configured conversation",
+			data: `{"conversations": [
+				{"id": "G222", "name": "alice-bob", "type": "mpim", "export": true, "aliases": ["D111"]},
+				{"id": "D111", "name": "alice", "type": "dm", "export": true}
+			]}`,
+			wantErr: true,
+		},
+		{
+			name: "alias declared twice",
+			data: `{"conversations": [
+				{"id": "G222", "name": "alice-bob", "type": "mpim", "export": true, "aliases": ["D111"]},
+				{"id": "G333", "name": "alice-carol", "type": "mpim", "export": true, "aliases": ["D111"]}
+			]}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "conversations.json")
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := LoadConversations(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadConversations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (len(cfg.Conversations[0].Aliases) != 1 || cfg.Conversations[0].Aliases[0] != "D111") {
+				t.Errorf("Aliases = %v, want [D111]", cfg.Conversations[0].Aliases)
+			}
+		})
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Folder layout
+// ---------------------------------------------------------------------------
+
+func TestLoadConversations_Layout(t *testing.T) {
+	tests := []struct {
+		name    string
+		layout  string
+		want    FolderLayout
+		wantErr bool
+	}{
+		{name: "default", layout: "", want: ""},
+		{name: "flat", layout: "flat", want: FolderLayoutFlat},
+		{name: "year", layout: "year", want: FolderLayoutYear},
+		{name: "month", layout: "month", want: FolderLayoutMonth},
+		{name: "invalid", layout: "week", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "conversations.json")
+			data := `{"conversations": [{"id": "C111", "name": "general", "type": "channel", "export": true, "layout": "` + tt.layout + `"}]}`
+			if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := LoadConversations(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadConversations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && cfg.Conversations[0].Layout != tt.want {
+				t.Errorf("Layout = %q, want %q", cfg.Conversations[0].Layout, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConversations_DocGranularity(t *testing.T) {
+	tests := []struct {
+		name        string
+		granularity string
+		want        DocGranularity
+		wantErr     bool
+	}{
+		{name: "default", granularity: "", want: ""},
+		{name: "daily", granularity: "daily", want: DocGranularityDaily},
+		{name: "weekly", granularity: "weekly", want: DocGranularityWeekly},
+		{name: "monthly", granularity: "monthly", want: DocGranularityMonthly},
+		{name: "single", granularity: "single", want: DocGranularitySingle},
+		{name: "invalid", granularity: "yearly", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "conversations.json")
+			data := `{"conversations": [{"id": "C111", "name": "general", "type": "channel", "export": true, "docGranularity": "` + tt.granularity + `"}]}`
+			if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := LoadConversations(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadConversations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && cfg.Conversations[0].DocGranularity != tt.want {
+				t.Errorf("DocGranularity = %q, want %q", cfg.Conversations[0].DocGranularity, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConversations_Format(t *testing.T) {
+	tests := []struct {
+		name                          string
+		format                        string
+		localExport                   bool
+		wantDocs, wantMarkdown, local bool
+		wantErr                       bool
+	}{
+		{name: "default", wantDocs: true},
+		{name: "docs with localExport", format: "docs", localExport: true, wantDocs: true, wantMarkdown: true, local: true},
+		{name: "markdown", format: "markdown", wantMarkdown: true, local: true},
+		{name: "json", format: "json", local: true},
+		{name: "json ignores localExport", format: "json", localExport: true, local: true},
+		{name: "html", format: "html", local: true},
+		{name: "slack", format: "slack", local: true},
+		{name: "invalid", format: "pdf", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "conversations.json")
+			data := fmt.Sprintf(`{"conversations": [{"id": "D111", "name": "alice", "type": "dm", "export": true, "localExport": %t, "format": %q}]}`, tt.localExport, tt.format)
+			if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := LoadConversations(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadConversations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			c := cfg.Conversations[0]
+			if c.WritesDocs() != tt.wantDocs || c.WritesMarkdown() != tt.wantMarkdown || c.WritesLocal() != tt.local {
+				t.Errorf("WritesDocs/WritesMarkdown/WritesLocal = %t/%t/%t, want %t/%t/%t",
+					c.WritesDocs(), c.WritesMarkdown(), c.WritesLocal(), tt.wantDocs, tt.wantMarkdown, tt.local)
+			}
+		})
+	}
+}
+
+func TestLoadSettings_FolderWarnings(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{name: "defaults", data: `{}`},
+		{name: "threshold and auto layout", data: `{"folderWarnItems": 300, "autoFolderLayout": "month"}`},
+		{name: "negative threshold", data: `{"folderWarnItems": -1}`, wantErr: true},
+		{name: "flat auto layout", data: `{"autoFolderLayout": "flat"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "settings.json")
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadSettings(path); (err != nil) != tt.wantErr {
+				t.Errorf("LoadSettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadSettings_GoogleQuota(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{name: "budgets", data: `{"googleQuota": {"dailyDocsWrites": 5000, "dailyDriveQueries": 20000}}`},
+		{name: "negative docs writes", data: `{"googleQuota": {"dailyDocsWrites": -1}}`, wantErr: true},
+		{name: "negative drive queries", data: `{"googleQuota": {"dailyDriveQueries": -5}}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "settings.json")
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadSettings(path); (err != nil) != tt.wantErr {
+				t.Errorf("LoadSettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conversations.json")
+	data := `{"conversations": [
+		{"id": "D001", "name": "Alice", "type": "dm"},
+		{"id": "D002", "name": "Bob", "type": "dm", "export": true, "layout": "flat", "docGranularity": "daily", "format": "docs"},
+		{"id": "C001", "name": "general", "type": "channel", "export": true, "template": "minutes"}
+	]}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConversations(path)
+	if err != nil {
+		t.Fatalf("LoadConversations() error: %v", err)
+	}
+	yes, no := true, false
+	cfg.Conversations = append(cfg.Conversations, ConversationConfig{ID: "D003", Name: "discovered", Type: "dm", Export: true})
+	cfg.ApplyDefaults(map[models.ConversationType]*ConversationDefaults{
+		models.ConversationTypeDM:      {Export: &no, Share: &no, LocalExport: &yes, Layout: FolderLayoutYear, DocGranularity: DocGranularityMonthly, Format: OutputFormatMarkdown},
+		models.ConversationTypeChannel: {Share: &yes, ShareMembers: []string{"team@example.com"}, Template: "compact"},
+	})
+
+	alice, bob, general, discovered := cfg.Conversations[0], cfg.Conversations[1], cfg.Conversations[2], cfg.Conversations[3]
+	if alice.Export || !alice.LocalExport || alice.Layout != FolderLayoutYear || alice.DocGranularity != DocGranularityMonthly || alice.Format != OutputFormatMarkdown {
+		t.Errorf("alice = %+v, want the dm defaults", alice)
+	}
+	if !bob.Export || !bob.LocalExport || bob.Layout != FolderLayoutFlat || bob.DocGranularity != DocGranularityDaily || bob.Format != OutputFormatDocs {
+		t.Errorf("bob = %+v, want export, layout, granularity, and format kept, localExport defaulted", bob)
+	}
+	if !general.Export || !general.Share || len(general.ShareMembers) != 1 || general.Template != "minutes" {
+		t.Errorf("general = %+v, want the channel defaults and its own template", general)
+	}
+	if !discovered.Export || discovered.LocalExport {
+		t.Errorf("discovered = %+v, want entries not from the file untouched", discovered)
+	}
+}
+
+func TestLoadTemplates(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "templates")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"minutes.md.tmpl":   `{{define "header"}}# {{.Conversation}}{{end}}`,
+		"minutes.html.tmpl": `{{define "footer"}}<footer>end</footer>{{end}}`,
+		"compact.html.tmpl": `{{define "message"}}{{.Text}}{{end}}`,
+		"notes.txt":         "ignored",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	templates, err := LoadTemplates(dir, map[string]*TemplateConfig{
+		"compact": {Markdown: `{{define "message"}}{{.Text}}{{end}}`},
+	})
+	if err != nil {
+		t.Fatalf("LoadTemplates() error: %v", err)
+	}
+	if len(templates) != 2 {
+		t.Errorf("got %d templates, want minutes and compact", len(templates))
+	}
+	if m := templates["minutes"]; m == nil || !strings.Contains(m.Markdown, "header") || !strings.Contains(m.HTML, "footer") {
+		t.Errorf("minutes = %+v, want both its files", m)
+	}
+	if c := templates["compact"]; c == nil || c.Markdown == "" || c.HTML == "" {
+		t.Errorf("compact = %+v, want markdown from settings and html from its file", c)
+	}
+
+	if _, err := LoadTemplates(dir, map[string]*TemplateConfig{"minutes": {Markdown: "x"}}); err == nil {
+		t.Error("LoadTemplates() with minutes markdown in settings and a file: want an error")
+	}
+	if got, err := LoadTemplates(filepath.Join(t.TempDir(), "missing"), nil); err != nil || len(got) != 0 {
+		t.Errorf("LoadTemplates() without a directory = %v, %v, want none", got, err)
+	}
+
+	convs := []ConversationConfig{{ID: "C001", Name: "general", Template: "minutes"}, {ID: "C002", Name: "random"}}
+	if err := CheckTemplates(convs, templates); err != nil {
+		t.Errorf("CheckTemplates() error: %v", err)
+	}
+	convs[1].Template = "weekly"
+	if err := CheckTemplates(convs, templates); err == nil || !strings.Contains(err.Error(), "weekly") {
+		t.Errorf("CheckTemplates() with an unknown template = %v, want an error naming it", err)
+	}
+}
+
+func TestLoadSettings_ConversationDefaults(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "settings.json")
+	if err := os.WriteFile(path, []byte(`{"conversationDefaults": {"dm": {"export": false, "layout": "month"}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := LoadSettings(path)
+	if err != nil {
+		t.Fatalf("LoadSettings() error: %v", err)
+	}
+	d := s.ConversationDefaults[models.ConversationTypeDM]
+	if d == nil || d.Export == nil || *d.Export || d.Layout != FolderLayoutMonth {
+		t.Errorf("dm defaults = %+v", d)
+	}
+
+	for _, bad := range []string{
+		`{"conversationDefaults": {"dms": {"export": false}}}`,
+		`{"conversationDefaults": {"dm": {"layout": "weekly"}}}`,
+		`{"conversationDefaults": {"dm": {"format": "pdf"}}}`,
+		`{"conversationDefaults": {"dm": {"docGranularity": "yearly"}}}`,
+	} {
+		if err := os.WriteFile(path, []byte(bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadSettings(path); err == nil {
+			t.Errorf("LoadSettings(%s) should fail", bad)
+		}
+	}
+}
+
+func TestLoadSettings_TimeSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{name: "defaults", data: `{}`},
+		{name: "zone and layouts", data: `{"timezone": "America/New_York", "timeFormat": "15:04", "dateFormat": "2006-01-02"}`},
+		{name: "unknown zone", data: `{"timezone": "Mars/Olympus"}`, wantErr: true},
+		{name: "time format without fields", data: `{"timeFormat": "HH:mm"}`, wantErr: true},
+		{name: "date format without fields", data: `{"dateFormat": "yyyy-MM-dd"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "settings.json")
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			settings, err := LoadSettings(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadSettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && settings.Timezone != "" && settings.TimeLocation().String() != settings.Timezone {
+				t.Errorf("TimeLocation() = %v, want %s", settings.TimeLocation(), settings.Timezone)
+			}
+		})
+	}
+	if loc := (&Settings{}).TimeLocation(); loc != nil {
+		t.Errorf("TimeLocation() without a timezone = %v, want nil", loc)
+	}
+}
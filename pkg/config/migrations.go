@@ -0,0 +1,32 @@
+package config
+
+import "github.com/jflowers/get-out/pkg/migrate"
+
+// Format versions of the config files. Add a step to change a file's
+// format; files from older releases are upgraded (with a backup) when
+// loaded.
+var (
+	SettingsMigrations = migrate.Schema{
+		Name: "settings.json",
+		Steps: []migrate.Step{
+			{Description: "move googleDriveFolderId to folder_id", Apply: moveLegacyFolderID},
+		},
+	}
+	ConversationsMigrations = migrate.Schema{Name: "conversations.json"}
+	PeopleMigrations        = migrate.Schema{Name: "people.json"}
+)
+
+// moveLegacyFolderID renames googleDriveFolderId to folder_id. When both
+// are set folder_id already took precedence, so the legacy value is dropped
+// (it stays in the backup).
+func moveLegacyFolderID(doc map[string]any) error {
+	legacy, ok := doc["googleDriveFolderId"]
+	if !ok {
+		return nil
+	}
+	delete(doc, "googleDriveFolderId")
+	if id, _ := doc["folder_id"].(string); id == "" {
+		doc["folder_id"] = legacy
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/migrate"
+)
+
+func TestLoadSettings_MigratesWithBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	original := `{"googleDriveFolderId": "legacy", "logLevel": "DEBUG"}`
+	if err := os.WriteFile(path, []byte(original), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := LoadSettings(path)
+	if err != nil {
+		t.Fatalf("LoadSettings() error = %v", err)
+	}
+	if s.FolderID != "legacy" || s.Version != SettingsMigrations.Current() {
+		t.Errorf("settings = %+v, want folder_id migrated at version %d", s, SettingsMigrations.Current())
+	}
+
+	backup, err := os.ReadFile(migrate.BackupPath(path, 1))
+	if err != nil || string(backup) != original {
+		t.Errorf("backup = %q, %v, want the original file", backup, err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "googleDriveFolderId") || !strings.Contains(string(data), `"folder_id": "legacy"`) {
+		t.Errorf("migrated file = %s", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("migrated file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	// Loading the migrated file changes nothing.
+	if _, err := LoadSettings(path); err != nil {
+		t.Fatalf("second LoadSettings() error = %v", err)
+	}
+	again, _ := os.ReadFile(path)
+	if string(again) != string(data) {
+		t.Errorf("second load rewrote the file:\n%s", again)
+	}
+}
+
+func TestMoveLegacyFolderID_KeepsFolderID(t *testing.T) {
+	doc := map[string]any{"googleDriveFolderId": "old", "folder_id": "new"}
+	if err := moveLegacyFolderID(doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["folder_id"] != "new" || doc["googleDriveFolderId"] != nil {
+		t.Errorf("doc = %v, want folder_id kept and the legacy field dropped", doc)
+	}
+}
+
+func TestLoadConversations_NewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conversations.json")
+	if err := os.WriteFile(path, []byte(`{"version": 99, "conversations": []}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadConversations(path)
+	if err == nil || !strings.Contains(err.Error(), "upgrade get-out") {
+		t.Fatalf("LoadConversations() error = %v, want a newer-version error", err)
+	}
+}
@@ -0,0 +1,322 @@
+package config
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// The published JSON Schemas of the config files. Editors can use them
+// through a "$schema" key; `get-out config schema` prints them.
+//
+//go:embed schemas/*.schema.json
+var schemaFS embed.FS
+
+// SchemaNames lists the config files that have a schema, by base name.
+var SchemaNames = []string{"settings", "conversations", "people"}
+
+// Schema returns the JSON Schema of the named config file ("settings",
+// "conversations", or "people").
+func Schema(name string) ([]byte, error) {
+	data, err := schemaFS.ReadFile("schemas/" + name + ".schema.json")
+	if err != nil {
+		return nil, fmt.Errorf("unknown config file %q (must be %s)", name, strings.Join(SchemaNames, ", "))
+	}
+	return data, nil
+}
+
+// schema is the subset of JSON Schema the published schemas use.
+type schema struct {
+	Type                 string             `json:"type"`
+	Properties           map[string]*schema `json:"properties"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Required             []string           `json:"required"`
+	Items                *schema            `json:"items"`
+	Enum                 []string           `json:"enum"`
+	Pattern              string             `json:"pattern"`
+	MinLength            *int               `json:"minLength"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	Deprecated           bool               `json:"deprecated"`
+	Description          string             `json:"description"`
+
+	pattern *regexp.Regexp
+}
+
+// loadSchema parses the named schema and compiles its patterns.
+func loadSchema(name string) (*schema, error) {
+	data, err := Schema(name)
+	if err != nil {
+		return nil, err
+	}
+	var s schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse %s schema: %w", name, err)
+	}
+	if err := s.compile(); err != nil {
+		return nil, fmt.Errorf("invalid %s schema: %w", name, err)
+	}
+	return &s, nil
+}
+
+func (s *schema) compile() error {
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return err
+		}
+		s.pattern = re
+	}
+	for _, p := range s.Properties {
+		if err := p.compile(); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile()
+	}
+	return nil
+}
+
+// jsonNode is a parsed JSON value with the byte offset it starts at, so
+// issues can be reported by line.
+type jsonNode struct {
+	offset int
+
+	// Exactly one of these is set, except for null.
+	object []jsonMember
+	array  []*jsonNode
+	scalar any // string, json.Number, or bool
+
+	isObject, isArray bool
+}
+
+// member returns the value of the object member key, or nil.
+func (n *jsonNode) member(key string) *jsonNode {
+	for _, m := range n.object {
+		if m.key == key {
+			return m.value
+		}
+	}
+	return nil
+}
+
+// jsonMember is an object member with the offset of its key.
+type jsonMember struct {
+	key       string
+	keyOffset int
+	value     *jsonNode
+}
+
+// parseJSON parses data into a jsonNode tree.
+func parseJSON(data []byte) (*jsonNode, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	node, err := parseNode(dec, data)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err == nil {
+		return nil, &json.SyntaxError{Offset: dec.InputOffset()}
+	}
+	return node, nil
+}
+
+func parseNode(dec *json.Decoder, data []byte) (*jsonNode, error) {
+	node := &jsonNode{offset: skipSeparators(data, int(dec.InputOffset()))}
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		node.isObject = true
+		for dec.More() {
+			keyOffset := skipSeparators(data, int(dec.InputOffset()))
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := parseNode(dec, data)
+			if err != nil {
+				return nil, err
+			}
+			node.object = append(node.object, jsonMember{key: keyTok.(string), keyOffset: keyOffset, value: value})
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	case json.Delim('['):
+		node.isArray = true
+		for dec.More() {
+			item, err := parseNode(dec, data)
+			if err != nil {
+				return nil, err
+			}
+			node.array = append(node.array, item)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	default:
+		node.scalar = tok
+	}
+	return node, nil
+}
+
+// skipSeparators returns the offset of the next token at or after i.
+func skipSeparators(data []byte, i int) int {
+	for i < len(data) {
+		switch data[i] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// kind returns the JSON Schema type name of the node's value.
+func (n *jsonNode) kind() string {
+	switch v := n.scalar.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	}
+	switch {
+	case n.isObject:
+		return "object"
+	case n.isArray:
+		return "array"
+	}
+	return "null"
+}
+
+// validate checks node against s, reporting each problem through report
+// with the offset and JSON path it applies to.
+func (s *schema) validate(node *jsonNode, path string, report func(offset int, path, msg string, warning bool)) {
+	kind := node.kind()
+	if s.Type != "" && kind != s.Type && !(s.Type == "number" && kind == "integer") {
+		report(node.offset, path, fmt.Sprintf("must be %s, got %s", withArticle(s.Type), kind), false)
+		return
+	}
+
+	switch kind {
+	case "object":
+		seen := make(map[string]bool, len(node.object))
+		for _, m := range node.object {
+			seen[m.key] = true
+			memberPath := joinPath(path, m.key)
+			prop, ok := s.Properties[m.key]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					report(m.keyOffset, memberPath, fmt.Sprintf("unknown field %q%s", m.key, suggestField(m.key, s.Properties)), false)
+				}
+				continue
+			}
+			if prop.Deprecated {
+				report(m.keyOffset, memberPath, fmt.Sprintf("%q is deprecated and ignored", m.key), true)
+			}
+			prop.validate(m.value, memberPath, report)
+		}
+		for _, req := range s.Required {
+			if !seen[req] {
+				report(node.offset, path, fmt.Sprintf("missing required field %q", req), false)
+			}
+		}
+	case "array":
+		if s.Items != nil {
+			for i, item := range node.array {
+				s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i), report)
+			}
+		}
+	case "string":
+		str := node.scalar.(string)
+		if len(s.Enum) > 0 && !slices.Contains(s.Enum, str) {
+			report(node.offset, path, fmt.Sprintf("invalid value %q (must be %s)", str, strings.Join(s.Enum, ", ")), false)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(str) {
+			report(node.offset, path, fmt.Sprintf("invalid value %q (must match %s)", str, s.Pattern), false)
+		}
+		if s.MinLength != nil && len(str) < *s.MinLength {
+			report(node.offset, path, "must not be empty", false)
+		}
+	case "integer", "number":
+		f, _ := node.scalar.(json.Number).Float64()
+		if s.Minimum != nil && f < *s.Minimum {
+			report(node.offset, path, fmt.Sprintf("must be >= %v, got %v", *s.Minimum, f), false)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			report(node.offset, path, fmt.Sprintf("must be <= %v, got %v", *s.Maximum, f), false)
+		}
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func withArticle(typ string) string {
+	switch typ {
+	case "object", "array", "integer":
+		return "an " + typ
+	}
+	return "a " + typ
+}
+
+// suggestField returns a " (did you mean ...?)" hint naming the known field
+// closest to key, or "" when none is close.
+func suggestField(key string, props map[string]*schema) string {
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	best, bestDist := "", math.MaxInt
+	for _, name := range names {
+		if d := editDistance(strings.ToLower(key), strings.ToLower(name)); d < bestDist {
+			best, bestDist = name, d
+		}
+	}
+	if best == "" || bestDist > 2 {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %q?)", best)
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
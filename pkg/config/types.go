@@ -1,7 +1,11 @@
 // Package config handles configuration file loading and validation.
 package config
 
-import "github.com/jflowers/get-out/pkg/models"
+import (
+	"time"
+
+	"github.com/jflowers/get-out/pkg/models"
+)
 
 // DefaultOllamaEndpoint is the default Ollama REST API endpoint.
 const DefaultOllamaEndpoint = "http://localhost:11434"
@@ -26,12 +30,228 @@ type OllamaConfig struct {
 	Model string `json:"model,omitempty"`
 }
 
+// NamePolicy controls how a Slack user's display name and real name are
+// combined wherever a user is named (sender headers, @mentions, people.json).
+type NamePolicy string
+
+const (
+	// NamePolicyDisplayFirst uses the display name, falling back to the real
+	// name and then the username. This is the default.
+	NamePolicyDisplayFirst NamePolicy = "display-first"
+
+	// NamePolicyRealFirst uses the real name, falling back to the display
+	// name and then the username.
+	NamePolicyRealFirst NamePolicy = "real-first"
+
+	// NamePolicyBoth uses the real name followed by the handle, e.g.
+	// "Jane Doe (@jdoe)".
+	NamePolicyBoth NamePolicy = "both"
+)
+
+// FolderLayout controls how a conversation's daily docs are arranged in its
+// Drive folder.
+type FolderLayout string
+
+const (
+	// FolderLayoutFlat puts every daily doc directly in the conversation
+	// folder. This is the default.
+	FolderLayoutFlat FolderLayout = "flat"
+
+	// FolderLayoutYear nests daily docs, and thread folders under Threads,
+	// in one folder per calendar year ("2019", "2020", ...), keeping each
+	// folder small for conversations with many years of history.
+	FolderLayoutYear FolderLayout = "year"
+
+	// FolderLayoutMonth nests them one level deeper, in a folder per month
+	// inside each year folder ("2019/2019-03"), for very busy conversations.
+	FolderLayoutMonth FolderLayout = "month"
+)
+
+// DocGranularity controls how many days of a conversation's messages go
+// into each Google Doc.
+type DocGranularity string
+
+const (
+	// DocGranularityDaily writes a doc per day. This is the default.
+	DocGranularityDaily DocGranularity = "daily"
+
+	// DocGranularityWeekly writes a doc per ISO week, Monday to Sunday,
+	// titled like "2024-W05".
+	DocGranularityWeekly DocGranularity = "weekly"
+
+	// DocGranularityMonthly writes a doc per calendar month, titled like
+	// "2024-02".
+	DocGranularityMonthly DocGranularity = "monthly"
+
+	// DocGranularitySingle writes every message to one rolling doc, for
+	// conversations with too little traffic to be worth splitting.
+	DocGranularitySingle DocGranularity = "single"
+)
+
+// OutputFormat selects where a conversation is exported to.
+type OutputFormat string
+
+const (
+	// OutputFormatDocs writes Google Docs in the shared Drive folder, plus
+	// local markdown when localExport is set. This is the default.
+	OutputFormatDocs OutputFormat = "docs"
+
+	// OutputFormatMarkdown writes only local markdown files; nothing of
+	// the conversation is uploaded to Drive.
+	OutputFormatMarkdown OutputFormat = "markdown"
+
+	// OutputFormatJSON writes only local JSON files, one per day in the
+	// layout of Slack's workspace export; nothing is uploaded to Drive.
+	OutputFormatJSON OutputFormat = "json"
+
+	// OutputFormatHTML writes only a local static site: a page per day,
+	// a conversation index, and a top-level index of conversations;
+	// nothing is uploaded to Drive.
+	OutputFormatHTML OutputFormat = "html"
+
+	// OutputFormatSlack writes only a local archive in Slack's workspace
+	// export format, with directories named after the channels and the
+	// channels.json, groups.json, mpims.json, dms.json, and users.json
+	// index files, for slack-export-viewer or importing into another
+	// workspace; nothing is uploaded to Drive.
+	OutputFormatSlack OutputFormat = "slack"
+
+	// OutputFormatSQLite writes only a local SQLite database of the
+	// conversations, users, messages, reactions, and files, with a
+	// full-text index of the messages; nothing is uploaded to Drive.
+	OutputFormatSQLite OutputFormat = "sqlite"
+)
+
+// DefaultFolderWarnItems is the number of items in one Drive folder at
+// which exports warn that listing it is getting slow.
+const DefaultFolderWarnItems = 400
+
+// DigestTransport selects how email digests are sent.
+type DigestTransport string
+
+const (
+	// DigestTransportSMTP sends digests through an SMTP server (the
+	// default).
+	DigestTransportSMTP DigestTransport = "smtp"
+
+	// DigestTransportGmail sends digests with the Gmail API, as the Google
+	// account get-out is authorized with.
+	DigestTransportGmail DigestTransport = "gmail"
+)
+
+// EmailDigestConfig holds configuration for the email digest destination.
+// When present and Enabled is true, each export run emails an HTML digest
+// of the new messages written for every conversation. The SMTP password is
+// read from the GET_OUT_SMTP_PASSWORD environment variable, never from
+// settings.json.
+type EmailDigestConfig struct {
+	// Enabled controls whether digests are sent.
+	// Default: false (feature must be explicitly opted into).
+	Enabled bool `json:"enabled"`
+
+	// Transport is "smtp" (the default) or "gmail". Gmail needs no SMTP
+	// settings, but the Google account must be authorized to send mail.
+	Transport DigestTransport `json:"transport,omitempty"`
+
+	// SMTPHost is the SMTP server hostname (e.g. "smtp.gmail.com").
+	SMTPHost string `json:"smtpHost,omitempty"`
+
+	// SMTPPort is the SMTP submission port.
+	// Default: 587 (mailer.DefaultPort)
+	SMTPPort int `json:"smtpPort,omitempty"`
+
+	// Username for SMTP PLAIN auth. Leave empty for unauthenticated relays.
+	Username string `json:"username,omitempty"`
+
+	// From is the sender address. With Gmail it may be left empty to send
+	// from the authorized account.
+	From string `json:"from,omitempty"`
+
+	// To lists the recipient addresses.
+	To []string `json:"to"`
+}
+
+// UsesGmail reports whether digests are sent with the Gmail API, which
+// needs the Gmail send scope when authorizing Google.
+func (c *EmailDigestConfig) UsesGmail() bool {
+	return c != nil && c.Enabled && c.Transport == DigestTransportGmail
+}
+
+// DefaultTranslationTimeoutSeconds bounds the translation of one message.
+const DefaultTranslationTimeoutSeconds = 30
+
+// TranslationConfig configures the translation step, which translates each
+// message into TargetLanguage before it is written, keeping the original
+// beneath the translation. Exactly one of Command and URL is used.
+type TranslationConfig struct {
+	// Enabled controls whether messages are translated.
+	// Default: false (feature must be explicitly opted into).
+	Enabled bool `json:"enabled"`
+
+	// TargetLanguage is the language to translate into, as the
+	// translator expects it (e.g. "en").
+	TargetLanguage string `json:"targetLanguage"`
+
+	// Command is an external command and its arguments. It is run once
+	// per message with the text on stdin and TargetLanguage in the
+	// GET_OUT_TARGET_LANGUAGE environment variable, and prints the
+	// translation on stdout.
+	Command []string `json:"command,omitempty"`
+
+	// URL is an HTTP endpoint that receives a POST of
+	// {"text": ..., "target": ...} per message and answers
+	// {"text": <translation>}.
+	URL string `json:"url,omitempty"`
+
+	// TimeoutSeconds bounds each translation (default
+	// DefaultTranslationTimeoutSeconds).
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+}
+
+// GoogleQuotaConfig sets daily request budgets for the Google APIs. They
+// are counted per Google quota day (midnight to midnight Pacific time)
+// across all runs on this machine. Set them below the Cloud project's
+// quotas: near a budget requests are slowed down, and at the budget the
+// export pauses until the next quota day. 0 means unlimited.
+type GoogleQuotaConfig struct {
+	// DailyDocsWrites budgets Docs create and batchUpdate requests.
+	DailyDocsWrites int `json:"dailyDocsWrites,omitempty"`
+
+	// DailyDriveQueries budgets Drive list and get requests.
+	DailyDriveQueries int `json:"dailyDriveQueries,omitempty"`
+}
+
+// Default limits of ImageConfig.
+const (
+	DefaultImageMaxDimension = 1600
+	DefaultImageMaxBytes     = 5 << 20
+)
+
+// ImageConfig limits the images embedded in Google Docs. An image over a
+// limit is scaled down before it is uploaded; one that cannot be brought
+// under MaxBytes is referenced by name instead. 0 uses the default.
+type ImageConfig struct {
+	// MaxDimension is the longest side, in pixels, an embedded image may
+	// have (default DefaultImageMaxDimension).
+	MaxDimension int `json:"maxDimension,omitempty"`
+
+	// MaxBytes is the largest image file embedded (default
+	// DefaultImageMaxBytes).
+	MaxBytes int64 `json:"maxBytes,omitempty"`
+}
+
 // Settings is the root structure for settings.json.
 // It contains application-wide configuration options.
 type Settings struct {
+	// Version is the file's format version (see SettingsMigrations).
+	Version int `json:"version,omitempty"`
+
 	// Google Drive configuration
 	GoogleCredentialsFile string `json:"googleCredentialsFile,omitempty"`
-	GoogleDriveFolderID   string `json:"googleDriveFolderId,omitempty"`
+
+	// GoogleDriveFolderID is the legacy name of FolderID. Loading
+	// settings.json moves it to FolderID.
+	GoogleDriveFolderID string `json:"googleDriveFolderId,omitempty"`
 
 	// FolderID is the Google Drive folder ID used by default for exports.
 	// Set via `get-out init` or directly in settings.json.
@@ -40,20 +260,148 @@ type Settings struct {
 	// Local export configuration (for future use)
 	LocalExportOutputDir string `json:"localExportOutputDir,omitempty"`
 
+	// S3ExportURL, an s3://bucket/prefix URL, uploads the local export
+	// directory to S3 after each export run.
+	S3ExportURL string `json:"s3ExportUrl,omitempty"`
+
 	// Slack configuration
 	SlackWorkspaceURL string `json:"slackWorkspaceUrl,omitempty"`
 
+	// SlackTeam picks the workspace, by team ID or domain, whose browser
+	// session is used when Chrome is signed in to several. When empty, the
+	// subdomain of SlackWorkspaceURL is used, if it names one.
+	SlackTeam string `json:"slackTeam,omitempty"`
+
+	// ChromeProfilePath is the Chrome profile directory setup-browser and
+	// export --launch-browser start Chrome with (default:
+	// ~/.get-out/chrome-data).
+	ChromeProfilePath string `json:"chromeProfilePath,omitempty"`
+
+	// Timezone is the IANA time zone, such as "America/New_York", that
+	// message times are shown in and messages are grouped into days by.
+	// Empty is the zone of the machine running the export.
+	Timezone string `json:"timezone,omitempty"`
+
+	// TimeFormat is the Go layout of message times (default "3:04 PM").
+	TimeFormat string `json:"timeFormat,omitempty"`
+
+	// DateFormat is the Go layout of dates shown with a time (default
+	// "Jan 2, 2006"). Doc and folder names always use YYYY-MM-DD.
+	DateFormat string `json:"dateFormat,omitempty"`
+
 	// Logging
 	LogLevel string `json:"logLevel,omitempty"`
 
+	// NamePolicy selects how user names are rendered: "display-first"
+	// (default), "real-first", or "both".
+	NamePolicy NamePolicy `json:"namePolicy,omitempty"`
+
 	// Ollama configuration for sensitivity filtering (optional).
 	// When nil or Enabled is false, sensitivity filtering is disabled.
 	Ollama *OllamaConfig `json:"ollama,omitempty"`
+
+	// Translation configuration for translating messages before they are
+	// written (optional). When nil or Enabled is false, messages are kept
+	// as written.
+	Translation *TranslationConfig `json:"translation,omitempty"`
+
+	// EmailDigest configuration for emailing per-run digests (optional).
+	// When nil or Enabled is false, no digests are sent.
+	EmailDigest *EmailDigestConfig `json:"emailDigest,omitempty"`
+
+	// LegalHold makes exports append-only and tamper-evident: every day
+	// written is hash-chained, each run ends with a signed manifest, and
+	// artifacts from earlier runs are never overwritten.
+	LegalHold bool `json:"legalHold,omitempty"`
+
+	// Provenance appends a line to each day written recording when its
+	// messages were fetched, from which Slack credential, and by which
+	// get-out version.
+	Provenance bool `json:"provenance,omitempty"`
+
+	// JSONRendered adds each message's rendered text and the mentions,
+	// links, and emoji found in it to the json format's day files, beside
+	// the raw mrkdwn Slack returned.
+	JSONRendered bool `json:"jsonRendered,omitempty"`
+
+	// SkipEmojiMessages leaves out messages that are only emoji or a GIF,
+	// which are otherwise shown on a single line.
+	SkipEmojiMessages bool `json:"skipEmojiMessages,omitempty"`
+
+	// DocStyleTemplate is the ID or URL of a Google Doc whose styled
+	// paragraphs set how message headers, code, and quotes look in
+	// exported docs (see gdrive.LoadStylePolicy). Empty keeps the
+	// built-in styles.
+	DocStyleTemplate string `json:"docStyleTemplate,omitempty"`
+
+	// DocTemplate is the ID or URL of a Google Doc that new daily docs
+	// are copied from, keeping its headers, footers, page setup, and
+	// fonts. Empty creates blank docs.
+	DocTemplate string `json:"docTemplate,omitempty"`
+
+	// FolderWarnItems is the number of items in one Drive folder at which
+	// exports warn (default DefaultFolderWarnItems).
+	FolderWarnItems int `json:"folderWarnItems,omitempty"`
+
+	// AutoFolderLayout, when "year" or "month", switches a conversation
+	// without an explicit layout to that layout once its folder reaches
+	// FolderWarnItems. New docs go to the nested folders; existing docs
+	// are not moved.
+	AutoFolderLayout FolderLayout `json:"autoFolderLayout,omitempty"`
+
+	// ConversationDefaults holds defaults for conversations.json entries
+	// by conversation type ("dm", "mpim", "channel", "private_channel").
+	ConversationDefaults map[models.ConversationType]*ConversationDefaults `json:"conversationDefaults,omitempty"`
+
+	// PeerConfigDirs lists the config directories of get-out exports from
+	// other Slack workspaces. Slack Connect channels one of them has
+	// already exported are skipped here, so a channel shared by several
+	// workspaces is archived once.
+	PeerConfigDirs []string `json:"peerConfigDirs,omitempty"`
+
+	// GoogleQuota sets daily Google API request budgets (optional).
+	// Requests are always counted; without budgets they are never slowed.
+	GoogleQuota *GoogleQuotaConfig `json:"googleQuota,omitempty"`
+
+	// SlackMaxRetries is how many times a Slack request is retried after a
+	// rate limit or server error (optional; default 3, 0 disables retries).
+	SlackMaxRetries *int `json:"slackMaxRetries,omitempty"`
+
+	// Images limits the size of images embedded in Google Docs (optional).
+	Images *ImageConfig `json:"images,omitempty"`
+
+	// Templates defines layouts of the markdown and html formats by name,
+	// which conversations select with their Template field. Layouts can
+	// also be kept in the templates directory (see LoadTemplates).
+	Templates map[string]*TemplateConfig `json:"templates,omitempty"`
+}
+
+// TemplateConfig is a named layout of the markdown and html formats: Go
+// templates (text/template for markdown, html/template for html) that
+// define any of the blocks "header", "message", "thread", and "footer".
+// Blocks a layout leaves out keep the built-in layout.
+type TemplateConfig struct {
+	Markdown string `json:"markdown,omitempty"`
+	HTML     string `json:"html,omitempty"`
+}
+
+// TimeLocation returns the location named by Timezone, or nil when it is
+// empty or unknown. LoadSettings rejects an unknown zone.
+func (s *Settings) TimeLocation() *time.Location {
+	if s == nil || s.Timezone == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil
+	}
+	return loc
 }
 
 // DefaultSettings returns settings with default values.
 func DefaultSettings() *Settings {
 	return &Settings{
+		Version:           SettingsMigrations.Current(),
 		SlackWorkspaceURL: "https://app.slack.com",
 		LogLevel:          "INFO",
 	}
@@ -61,6 +409,9 @@ func DefaultSettings() *Settings {
 
 // ConversationsConfig is the root structure for conversations.json.
 type ConversationsConfig struct {
+	// Version is the file's format version (see ConversationsMigrations).
+	Version int `json:"version,omitempty"`
+
 	Conversations []ConversationConfig `json:"conversations"`
 }
 
@@ -73,10 +424,57 @@ type ConversationConfig struct {
 	LocalExport  bool                    `json:"localExport,omitempty"`
 	Share        bool                    `json:"share"`
 	ShareMembers []string                `json:"shareMembers,omitempty"`
+
+	// Aliases lists previous IDs for this conversation (e.g. a DM that
+	// became an MPIM). History exported under an alias is merged into this
+	// conversation's folder and sync state.
+	Aliases []string `json:"aliases,omitempty"`
+
+	// Layout arranges the conversation's Drive folder: "flat" (default),
+	// "year" for one subfolder per calendar year, or "month" for year and
+	// month subfolders.
+	Layout FolderLayout `json:"layout,omitempty"`
+
+	// DocGranularity sets how many days go into each of the conversation's
+	// Google Docs: "daily" (default), "weekly", "monthly", or "single" for
+	// one doc holding every message. Threads keep a doc per day.
+	DocGranularity DocGranularity `json:"docGranularity,omitempty"`
+
+	// Format selects the output: "docs" (default), "markdown", "json",
+	// "html", or "slack".
+	// The local formats keep the conversation off Drive and are written to
+	// the local export directory.
+	Format OutputFormat `json:"format,omitempty"`
+
+	// Template names the layout (see Settings.Templates) its markdown and
+	// html output is written with; empty uses the built-in layout.
+	Template string `json:"template,omitempty"`
+
+	// explicit records the fields set in conversations.json, which take
+	// precedence over the type's ConversationDefaults. It is nil for
+	// entries not read from the file.
+	explicit map[string]bool
+}
+
+// ConversationDefaults are settings.json defaults for the conversations.json
+// entries of one type. A field an entry sets overrides the default; unset
+// fields here leave the entry's own value.
+type ConversationDefaults struct {
+	Export         *bool          `json:"export,omitempty"`
+	LocalExport    *bool          `json:"localExport,omitempty"`
+	Share          *bool          `json:"share,omitempty"`
+	ShareMembers   []string       `json:"shareMembers,omitempty"`
+	Layout         FolderLayout   `json:"layout,omitempty"`
+	DocGranularity DocGranularity `json:"docGranularity,omitempty"`
+	Format         OutputFormat   `json:"format,omitempty"`
+	Template       string         `json:"template,omitempty"`
 }
 
 // PeopleConfig is the root structure for people.json.
 type PeopleConfig struct {
+	// Version is the file's format version (see PeopleMigrations).
+	Version int `json:"version,omitempty"`
+
 	People []PersonConfig `json:"people"`
 }
 
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Issue is a problem found in a config file by ValidateDir.
+type Issue struct {
+	// File is the config file's base name, e.g. "conversations.json".
+	File string
+
+	// Line and Column locate the problem, 1-based; 0 when the problem is
+	// not tied to one place in the file.
+	Line, Column int
+
+	// Path is the JSON path of the offending value, e.g.
+	// "conversations[2].layout".
+	Path string
+
+	Message string
+
+	// Warning marks problems that do not stop the file from loading.
+	Warning bool
+}
+
+// String formats the issue like a compiler message:
+// "conversations.json:12:9: conversations[1].mdoe: unknown field".
+func (i Issue) String() string {
+	var b strings.Builder
+	b.WriteString(i.File)
+	if i.Line > 0 {
+		fmt.Fprintf(&b, ":%d:%d", i.Line, i.Column)
+	}
+	b.WriteString(": ")
+	if i.Warning {
+		b.WriteString("warning: ")
+	}
+	if i.Path != "" {
+		b.WriteString(i.Path + ": ")
+	}
+	b.WriteString(i.Message)
+	return b.String()
+}
+
+// configFile is one file checked by ValidateDir.
+type configFile struct {
+	name     string
+	schema   string
+	required bool
+	load     func(path string) error
+}
+
+var configFiles = []configFile{
+	{"settings.json", "settings", false, func(path string) error {
+		_, err := loadSettings(path, SettingsMigrations.ReadFile)
+		return err
+	}},
+	{"conversations.json", "conversations", true, func(path string) error {
+		_, err := loadConversations(path, ConversationsMigrations.ReadFile)
+		return err
+	}},
+	{"people.json", "people", false, func(path string) error {
+		_, err := loadPeople(path, PeopleMigrations.ReadFile)
+		return err
+	}},
+}
+
+// ValidateDir checks the config files in dir against their schemas and
+// the rules applied when they are loaded (without saving migrations), and
+// cross-checks them: every
+// shareMembers email should belong to a person in people.json. Missing
+// optional files are skipped. The error is non-nil only when a file cannot
+// be read.
+func ValidateDir(dir string) ([]Issue, error) {
+	var issues []Issue
+	parsed := make(map[string]*jsonNode)
+	data := make(map[string][]byte)
+	for _, f := range configFiles {
+		path := filepath.Join(dir, f.name)
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				return nil, fmt.Errorf("failed to read %s: %w", f.name, err)
+			}
+			if f.required {
+				issues = append(issues, Issue{File: f.name, Message: "file not found"})
+			}
+			continue
+		}
+		fileIssues, node := validateFile(f, raw)
+		if !hasErrors(fileIssues) {
+			if err := f.load(path); err != nil {
+				fileIssues = append(fileIssues, Issue{File: f.name, Message: err.Error()})
+			}
+		}
+		issues = append(issues, fileIssues...)
+		if !hasErrors(fileIssues) {
+			parsed[f.name], data[f.name] = node, raw
+		}
+	}
+
+	if convs := parsed["conversations.json"]; convs != nil {
+		issues = append(issues, danglingShareMembers(convs, data["conversations.json"], parsed["people.json"])...)
+	}
+	return issues, nil
+}
+
+// validateFile parses data and checks it against f's schema.
+func validateFile(f configFile, data []byte) ([]Issue, *jsonNode) {
+	node, err := parseJSON(data)
+	if err != nil {
+		issue := Issue{File: f.name, Message: err.Error()}
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			issue.Line, issue.Column = position(data, int(syntaxErr.Offset))
+		}
+		return []Issue{issue}, nil
+	}
+	s, err := loadSchema(f.schema)
+	if err != nil {
+		return []Issue{{File: f.name, Message: err.Error()}}, nil
+	}
+	var issues []Issue
+	s.validate(node, "", func(offset int, path, msg string, warning bool) {
+		line, col := position(data, offset)
+		issues = append(issues, Issue{File: f.name, Line: line, Column: col, Path: path, Message: msg, Warning: warning})
+	})
+	return issues, node
+}
+
+// danglingShareMembers warns about shareMembers emails that match no
+// person's email or googleEmail in people.json (people may be nil).
+func danglingShareMembers(convs *jsonNode, data []byte, people *jsonNode) []Issue {
+	known := make(map[string]bool)
+	if people != nil {
+		if list := people.member("people"); list != nil {
+			for _, p := range list.array {
+				for _, key := range []string{"email", "googleEmail"} {
+					if v := p.member(key); v != nil {
+						if email, ok := v.scalar.(string); ok && email != "" {
+							known[strings.ToLower(email)] = true
+						}
+					}
+				}
+			}
+		}
+	}
+
+	var issues []Issue
+	list := convs.member("conversations")
+	if list == nil {
+		return nil
+	}
+	for i, c := range list.array {
+		members := c.member("shareMembers")
+		if members == nil {
+			continue
+		}
+		for j, m := range members.array {
+			email, _ := m.scalar.(string)
+			if known[strings.ToLower(email)] {
+				continue
+			}
+			line, col := position(data, m.offset)
+			issues = append(issues, Issue{
+				File:    "conversations.json",
+				Line:    line,
+				Column:  col,
+				Path:    fmt.Sprintf("conversations[%d].shareMembers[%d]", i, j),
+				Message: fmt.Sprintf("%q is not a person in people.json", email),
+				Warning: true,
+			})
+		}
+	}
+	return issues
+}
+
+// hasErrors reports whether any of issues is an error rather than a warning.
+func hasErrors(issues []Issue) bool {
+	for _, i := range issues {
+		if !i.Warning {
+			return true
+		}
+	}
+	return false
+}
+
+// position converts a byte offset in data to a 1-based line and column.
+func position(data []byte, offset int) (line, col int) {
+	offset = min(offset, len(data))
+	line = 1 + strings.Count(string(data[:offset]), "\n")
+	col = offset - strings.LastIndexByte(string(data[:offset]), '\n')
+	return line, col
+}
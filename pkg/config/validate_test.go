@@ -0,0 +1,171 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestValidateDir(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"conversations.json": `{
+  "conversations": [
+    {
+      "id": "C001",
+      "name": "general",
+      "type": "channel",
+      "mdoe": "api",
+      "layout": "weekly",
+      "shareMembers": ["alice@example.com", "carol@example.com"]
+    },
+    {"id": "bad", "type": "dm"}
+  ]
+}`,
+		"settings.json": `{"folderWarnItems": -1, "slackBotToken": "xoxb-1"}`,
+	})
+
+	issues, err := ValidateDir(dir)
+	if err != nil {
+		t.Fatalf("ValidateDir() error = %v", err)
+	}
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.String())
+	}
+	want := []string{
+		`settings.json:1:21: folderWarnItems: must be >= 0, got -1`,
+		`settings.json:1:25: warning: slackBotToken: "slackBotToken" is deprecated and ignored`,
+		`conversations.json:7:7: conversations[0].mdoe: unknown field "mdoe" (did you mean "mode"?)`,
+		`conversations.json:8:17: conversations[0].layout: invalid value "weekly" (must be flat, year, month)`,
+		`conversations.json:11:12: conversations[1].id: invalid value "bad" (must match ^[CDGW][A-Z0-9]+$)`,
+		`conversations.json:11:5: conversations[1]: missing required field "name"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("issues =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestValidateDir_SyntaxError(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"conversations.json": "{\n  \"conversations\": [\n    {\"id\": \"C001\",}\n  ]\n}",
+	})
+	issues, err := ValidateDir(dir)
+	if err != nil {
+		t.Fatalf("ValidateDir() error = %v", err)
+	}
+	if len(issues) != 1 || issues[0].Line != 3 || issues[0].Warning {
+		t.Fatalf("issues = %+v, want one error on line 3", issues)
+	}
+}
+
+func TestValidateDir_LoaderRulesAndShareMembers(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"conversations.json": `{"conversations": [
+			{"id": "C001", "name": "a", "type": "channel", "aliases": ["C002"], "shareMembers": ["Alice@Example.com", "bob@example.com"]},
+			{"id": "C002", "name": "b", "type": "channel"}
+		]}`,
+		"people.json": `{"people": [{"slackId": "U001", "email": "alice@example.com"}]}`,
+	})
+	issues, err := ValidateDir(dir)
+	if err != nil {
+		t.Fatalf("ValidateDir() error = %v", err)
+	}
+	if len(issues) != 1 || issues[0].Warning || !strings.Contains(issues[0].Message, "alias C002") {
+		t.Fatalf("issues = %+v, want the alias conflict only", issues)
+	}
+
+	// Once the file loads, dangling shareMembers are warned about.
+	dir = writeConfigFiles(t, map[string]string{
+		"conversations.json": `{"conversations": [
+			{"id": "C001", "name": "a", "type": "channel", "shareMembers": ["Alice@Example.com", "bob@example.com"]}
+		]}`,
+		"people.json": `{"people": [{"slackId": "U001", "email": "alice@example.com"}]}`,
+	})
+	issues, err = ValidateDir(dir)
+	if err != nil {
+		t.Fatalf("ValidateDir() error = %v", err)
+	}
+	if len(issues) != 1 || !issues[0].Warning || issues[0].Path != "conversations[0].shareMembers[1]" {
+		t.Fatalf("issues = %+v, want a warning for bob", issues)
+	}
+}
+
+func TestValidateDir_MissingConversations(t *testing.T) {
+	issues, err := ValidateDir(t.TempDir())
+	if err != nil {
+		t.Fatalf("ValidateDir() error = %v", err)
+	}
+	if len(issues) != 1 || issues[0].File != "conversations.json" || issues[0].Message != "file not found" {
+		t.Fatalf("issues = %+v, want conversations.json not found", issues)
+	}
+}
+
+// The example configs shipped with the repo must stay valid.
+func TestValidateDir_Examples(t *testing.T) {
+	files := make(map[string]string)
+	for _, name := range []string{"settings.json", "conversations.json", "people.json"} {
+		data, err := os.ReadFile(filepath.Join("..", "..", "config", name+".example"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[name] = string(data)
+	}
+	issues, err := ValidateDir(writeConfigFiles(t, files))
+	if err != nil {
+		t.Fatalf("ValidateDir() error = %v", err)
+	}
+	for _, issue := range issues {
+		if !issue.Warning {
+			t.Errorf("example config has error: %s", issue)
+		}
+	}
+}
+
+func TestSchema(t *testing.T) {
+	for _, name := range SchemaNames {
+		data, err := Schema(name)
+		if err != nil {
+			t.Fatalf("Schema(%q) error = %v", name, err)
+		}
+		if !json.Valid(data) {
+			t.Errorf("Schema(%q) is not valid JSON", name)
+		}
+		if _, err := loadSchema(name); err != nil {
+			t.Errorf("loadSchema(%q) error = %v", name, err)
+		}
+	}
+	if _, err := Schema("channels"); err == nil {
+		t.Error("Schema(channels) should fail")
+	}
+}
+
+func TestSchema_CoversSettings(t *testing.T) {
+	s, err := loadSchema("settings")
+	if err != nil {
+		t.Fatalf("loadSchema(settings) error = %v", err)
+	}
+	typ := reflect.TypeOf(Settings{})
+	for i := 0; i < typ.NumField(); i++ {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		if _, ok := s.Properties[name]; !ok {
+			t.Errorf("settings schema has no property %q, so validate rejects it", name)
+		}
+	}
+}
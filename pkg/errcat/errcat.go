@@ -0,0 +1,202 @@
+// Package errcat is the catalog of user-facing failures: each common way an
+// export fails has a stable code, a remediation hint, and a section of the
+// README that explains it, so the CLI can tell users what to do next and
+// scripts can match on the code instead of the message.
+package errcat
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/jflowers/get-out/pkg/slackapi"
+	"google.golang.org/api/googleapi"
+)
+
+// Code identifies a failure mode. Codes are stable across releases.
+type Code string
+
+const (
+	// SlackRestricted means the workspace does not allow a Slack API
+	// method the command cannot work without.
+	SlackRestricted Code = "SLACK_RESTRICTED"
+	// SlackAuth means the Slack session or token is invalid or expired.
+	SlackAuth Code = "SLACK_AUTH"
+	// DocsQuota means a Google Docs or Drive quota or rate limit was hit.
+	DocsQuota Code = "DOCS_QUOTA"
+	// DriveFolderAccess means the export folder cannot be read or written.
+	DriveFolderAccess Code = "DRIVE_FOLDER_ACCESS"
+	// ChromeUnavailable means Chrome could not be reached for the Slack
+	// session.
+	ChromeUnavailable Code = "CHROME_UNAVAILABLE"
+	// SlackRateLimited means Slack kept rate limiting requests after the
+	// client's retries.
+	SlackRateLimited Code = "SLACK_RATE_LIMITED"
+	// GoogleAuth means Google credentials are missing or were rejected.
+	GoogleAuth Code = "GOOGLE_AUTH"
+	// ConfigInvalid means a config file could not be read, parsed, or
+	// validated.
+	ConfigInvalid Code = "CONFIG_INVALID"
+	// ExportPartial means the run finished but some conversations failed.
+	ExportPartial Code = "EXPORT_PARTIAL"
+)
+
+// DocsBaseURL is where the anchors of catalog entries point.
+const DocsBaseURL = "https://github.com/jflowers/get-out#"
+
+// entry is the catalog's description of one code.
+type entry struct {
+	hint   string
+	anchor string
+}
+
+var catalog = map[Code]entry{
+	SlackRestricted: {
+		hint:   "Your workspace blocks a Slack API method this needs. Run 'get-out test' to see which methods are allowed.",
+		anchor: "slack_restricted",
+	},
+	SlackAuth: {
+		hint:   "Refresh the Slack tab in Chrome (or renew GET_OUT_SLACK_TOKEN) and run the command again.",
+		anchor: "slack_auth",
+	},
+	DocsQuota: {
+		hint:   "Google quota reached. Wait for it to reset, lower --parallel, or set googleQuota in settings.json, then continue with --resume.",
+		anchor: "docs_quota",
+	},
+	DriveFolderAccess: {
+		hint:   "Check that the Google account you authorized can edit the export folder, or pass a different --folder-id.",
+		anchor: "drive_folder_access",
+	},
+	ChromeUnavailable: {
+		hint:   "Run 'get-out setup-browser' to start Chrome with remote debugging, or check that --chrome-port matches it.",
+		anchor: "chrome_unavailable",
+	},
+	SlackRateLimited: {
+		hint:   "Slack is rate limiting requests. Wait a few minutes, lower --parallel, then continue with --resume.",
+		anchor: "slack_rate_limited",
+	},
+	GoogleAuth: {
+		hint:   "Run 'get-out auth login' to authorize Google again, or 'get-out setup-google' if credentials are missing.",
+		anchor: "google_auth",
+	},
+	ConfigInvalid: {
+		hint:   "Fix the config file named in the error, then run the command again.",
+		anchor: "config_invalid",
+	},
+	ExportPartial: {
+		hint:   "Some conversations failed; their errors are listed above. Run the export again with --resume to retry them.",
+		anchor: "export_partial",
+	},
+}
+
+// Error is a failure with a catalog code. It wraps the underlying error,
+// whose message it keeps.
+type Error struct {
+	Code Code
+	Hint string
+	// Anchor is the README section describing the failure.
+	Anchor string
+	Err    error
+}
+
+// Wrap returns err tagged with code, or nil when err is nil.
+func Wrap(code Code, err error) error {
+	if err == nil {
+		return nil
+	}
+	return newError(code, err)
+}
+
+func newError(code Code, err error) *Error {
+	e := catalog[code]
+	return &Error{Code: code, Hint: e.hint, Anchor: e.anchor, Err: err}
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// DocsURL returns the link to the README section for the failure.
+func (e *Error) DocsURL() string {
+	if e.Anchor == "" {
+		return ""
+	}
+	return DocsBaseURL + e.Anchor
+}
+
+// Classify returns the catalog error for err: the first *Error in its
+// chain, or else one inferred from the Slack and Google errors it wraps.
+// It returns nil when err matches no catalog entry.
+func Classify(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	var code Code
+	switch {
+	case isSlackAuth(err):
+		code = SlackAuth
+	case slackapi.IsRestrictedError(err):
+		code = SlackRestricted
+	case isSlackRateLimit(err):
+		code = SlackRateLimited
+	case IsGoogleQuota(err):
+		code = DocsQuota
+	default:
+		return nil
+	}
+	return newError(code, err)
+}
+
+// FolderAccess tags err as DriveFolderAccess when Google refused or could
+// not find the folder, and returns any other error unchanged.
+func FolderAccess(err error) error {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && !IsGoogleQuota(err) &&
+		(apiErr.Code == http.StatusForbidden || apiErr.Code == http.StatusNotFound) {
+		return Wrap(DriveFolderAccess, err)
+	}
+	return err
+}
+
+// IsGoogleQuota reports whether err is a Google API rate limit or quota
+// error.
+func IsGoogleQuota(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if apiErr.Code == http.StatusTooManyRequests {
+		return true
+	}
+	if apiErr.Code != http.StatusForbidden {
+		return false
+	}
+	for _, item := range apiErr.Errors {
+		switch item.Reason {
+		case "rateLimitExceeded", "userRateLimitExceeded", "quotaExceeded", "dailyLimitExceeded":
+			return true
+		}
+	}
+	return false
+}
+
+// isSlackAuth reports whether err's chain holds a Slack authentication
+// error.
+func isSlackAuth(err error) bool {
+	var authErr *slackapi.AuthError
+	if errors.As(err, &authErr) {
+		return true
+	}
+	var apiErr *slackapi.APIError
+	return errors.As(err, &apiErr) && slackapi.IsAuthError(apiErr)
+}
+
+// isSlackRateLimit reports whether err's chain holds a Slack rate limit
+// error.
+func isSlackRateLimit(err error) bool {
+	var rlErr *slackapi.RateLimitError
+	return errors.As(err, &rlErr)
+}
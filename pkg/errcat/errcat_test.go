@@ -0,0 +1,78 @@
+package errcat
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/slackapi"
+	"google.golang.org/api/googleapi"
+)
+
+func TestClassify(t *testing.T) {
+	quota := &googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "userRateLimitExceeded"}}}
+	tests := []struct {
+		name string
+		err  error
+		want Code
+	}{
+		{"nil", nil, ""},
+		{"unrelated", errors.New("disk full"), ""},
+		{"explicit", fmt.Errorf("connect: %w", Wrap(ChromeUnavailable, errors.New("refused"))), ChromeUnavailable},
+		{"slack auth", fmt.Errorf("validate: %w", &slackapi.AuthError{Code: slackapi.ErrCodeInvalidAuth}), SlackAuth},
+		{"slack api auth code", &slackapi.APIError{Code: slackapi.ErrCodeTokenRevoked}, SlackAuth},
+		{"slack restricted", fmt.Errorf("history: %w", &slackapi.APIError{Code: slackapi.ErrCodeEKMAccessDenied}), SlackRestricted},
+		{"slack rate limit", fmt.Errorf("history: %w", &slackapi.RateLimitError{RetryAfter: time.Minute}), SlackRateLimited},
+		{"docs 429", fmt.Errorf("append: %w", &googleapi.Error{Code: 429}), DocsQuota},
+		{"docs 403 quota", quota, DocsQuota},
+		{"docs 403 other", &googleapi.Error{Code: 403}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := Classify(tt.err)
+			var got Code
+			if e != nil {
+				got = e.Code
+			}
+			if got != tt.want {
+				t.Errorf("Classify() code = %q, want %q", got, tt.want)
+			}
+			if e != nil && (e.Hint == "" || e.DocsURL() == "") {
+				t.Errorf("Classify() = %+v, want a hint and docs URL", e)
+			}
+		})
+	}
+}
+
+func TestWrap_KeepsMessageAndChain(t *testing.T) {
+	cause := &slackapi.AuthError{Code: slackapi.ErrCodeInvalidAuth}
+	err := Wrap(SlackAuth, cause)
+	if err.Error() != cause.Error() {
+		t.Errorf("Error() = %q, want the cause's message %q", err.Error(), cause.Error())
+	}
+	var authErr *slackapi.AuthError
+	if !errors.As(err, &authErr) {
+		t.Error("wrapped error should unwrap to the cause")
+	}
+	if Wrap(SlackAuth, nil) != nil {
+		t.Error("Wrap(nil) should be nil")
+	}
+}
+
+func TestFolderAccess(t *testing.T) {
+	for _, code := range []int{403, 404} {
+		err := FolderAccess(fmt.Errorf("folder: %w", &googleapi.Error{Code: code}))
+		if e := Classify(err); e == nil || e.Code != DriveFolderAccess {
+			t.Errorf("FolderAccess(%d) = %v, want DRIVE_FOLDER_ACCESS", code, err)
+		}
+	}
+	quota := &googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "rateLimitExceeded"}}}
+	if e := Classify(FolderAccess(quota)); e == nil || e.Code != DocsQuota {
+		t.Errorf("FolderAccess(quota) classified as %v, want DOCS_QUOTA", e)
+	}
+	plain := errors.New("network down")
+	if got := FolderAccess(plain); got != plain {
+		t.Errorf("FolderAccess(other) = %v, want it unchanged", got)
+	}
+}
@@ -0,0 +1,479 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/gdrive"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// ActivityKind is one of the personal activity feeds exported by
+// ExportActivity.
+type ActivityKind string
+
+const (
+	// ActivityMentions is messages that @-mention the exporting user,
+	// found with search.messages.
+	ActivityMentions ActivityKind = "mentions"
+
+	// ActivityReactions is messages the exporting user reacted to, from
+	// reactions.list.
+	ActivityReactions ActivityKind = "reactions"
+
+	// ActivitySaved is messages the exporting user saved for later, from
+	// stars.list.
+	ActivitySaved ActivityKind = "saved"
+
+	// ActivityThreads is the threads the exporting user follows, from the
+	// Threads view (subscriptions.thread.getView), one doc per thread.
+	ActivityThreads ActivityKind = "threads"
+
+	// ActivityPending is the exporting user's scheduled messages and open
+	// reminders, from chat.scheduledMessages.list and reminders.list.
+	ActivityPending ActivityKind = "pending"
+)
+
+// ActivityKinds lists every activity feed, in export order.
+var ActivityKinds = []ActivityKind{ActivityMentions, ActivityReactions, ActivitySaved, ActivityThreads, ActivityPending}
+
+// ActivityFolderName is the folder under the export root that holds one
+// subfolder per activity feed.
+const ActivityFolderName = "Activity"
+
+// ParseActivityKind returns the activity kind named s.
+func ParseActivityKind(s string) (ActivityKind, error) {
+	for _, k := range ActivityKinds {
+		if string(k) == s {
+			return k, nil
+		}
+	}
+	names := make([]string, len(ActivityKinds))
+	for i, k := range ActivityKinds {
+		names[i] = string(k)
+	}
+	return "", fmt.Errorf("unknown activity kind %q (must be %s)", s, strings.Join(names, ", "))
+}
+
+// folderName returns the Drive folder name of the feed.
+func (k ActivityKind) folderName() string {
+	switch k {
+	case ActivityMentions:
+		return "Mentions"
+	case ActivityReactions:
+		return "Reactions"
+	case ActivityThreads:
+		return "My Threads"
+	default:
+		return "Saved"
+	}
+}
+
+// ActivityIndex records where each activity feed is written and which
+// messages it already holds, so repeated runs only add new activity.
+type ActivityIndex struct {
+	mu sync.Mutex
+
+	// FolderID and FolderURL locate the Activity folder.
+	FolderID  string `json:"folder_id,omitempty"`
+	FolderURL string `json:"folder_url,omitempty"`
+
+	Feeds map[ActivityKind]*ActivityFeed `json:"feeds"`
+
+	// UpdatedAt is the last time this index was modified
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// path is where this index is saved (not serialized)
+	path string
+}
+
+// ActivityFeed is the export state of one activity feed.
+type ActivityFeed struct {
+	FolderID  string `json:"folder_id,omitempty"`
+	FolderURL string `json:"folder_url,omitempty"`
+
+	// DailyDocs maps message date (YYYY-MM-DD) to the feed's doc for it.
+	DailyDocs map[string]*DocExport `json:"daily_docs,omitempty"`
+
+	// Written holds the messages already in the feed, as
+	// "<conversation ID>/<ts>".
+	Written map[string]bool `json:"written,omitempty"`
+
+	// Doc is the single doc of a feed that is not split by day (pending).
+	Doc *DocExport `json:"doc,omitempty"`
+
+	// Threads maps "<conversation ID>/<thread ts>" to the doc of a
+	// followed thread (threads).
+	Threads map[string]*DocExport `json:"threads,omitempty"`
+}
+
+// LoadActivityIndex loads an activity index from a file, or creates a new
+// one.
+func LoadActivityIndex(path string) (*ActivityIndex, error) {
+	idx := &ActivityIndex{path: path}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read activity index: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, idx); err != nil {
+			return nil, fmt.Errorf("failed to parse activity index: %w", err)
+		}
+	}
+	if idx.Feeds == nil {
+		idx.Feeds = make(map[ActivityKind]*ActivityFeed)
+	}
+	return idx, nil
+}
+
+// DefaultActivityIndexPath returns the default path for the activity index.
+func DefaultActivityIndexPath(configDir string) string {
+	return filepath.Join(configDir, "_metadata", "activity-index.json")
+}
+
+// Save writes the activity index to disk.
+func (idx *ActivityIndex) Save() error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.UpdatedAt = time.Now()
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal activity index: %w", err)
+	}
+	if err := atomicWriteFile(filepath.Dir(idx.path), idx.path, data); err != nil {
+		return fmt.Errorf("failed to write activity index: %w", err)
+	}
+	return nil
+}
+
+// feed returns the state of kind, creating it.
+func (idx *ActivityIndex) feed(kind ActivityKind) *ActivityFeed {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	f := idx.Feeds[kind]
+	if f == nil {
+		f = &ActivityFeed{}
+		idx.Feeds[kind] = f
+	}
+	if f.DailyDocs == nil {
+		f.DailyDocs = make(map[string]*DocExport)
+	}
+	if f.Written == nil {
+		f.Written = make(map[string]bool)
+	}
+	if f.Threads == nil {
+		f.Threads = make(map[string]*DocExport)
+	}
+	return f
+}
+
+// activityItem is one message of an activity feed.
+type activityItem struct {
+	channelID string
+	msg       slackapi.Message
+}
+
+func (i activityItem) key() string {
+	return i.channelID + "/" + i.msg.TS
+}
+
+// ActivityResult is the outcome of exporting one activity feed.
+type ActivityResult struct {
+	Kind        ActivityKind
+	Messages    int
+	DocsCreated int
+	FolderURL   string
+	Error       error
+}
+
+// String returns a summary of the result.
+func (r *ActivityResult) String() string {
+	if r.Error != nil {
+		return fmt.Sprintf("%s: ERROR: %v", r.Kind, r.Error)
+	}
+	return fmt.Sprintf("%s: %d messages, %d docs", r.Kind, r.Messages, r.DocsCreated)
+}
+
+// ExportActivity exports the exporting user's activity feeds into daily
+// docs under the Activity folder of the export root, one subfolder per
+// feed. Each run adds only messages not already in a feed. A feed that
+// fails (e.g. because its Slack method is restricted) is reported in its
+// result and the others are still exported.
+func (e *Exporter) ExportActivity(ctx context.Context, kinds []ActivityKind) ([]*ActivityResult, error) {
+	if err := e.ValidateConnections(ctx); err != nil {
+		return nil, fmt.Errorf("pre-export validation failed: %w", err)
+	}
+	userID := e.index.SelfUserID
+	if userID == "" {
+		return nil, fmt.Errorf("could not determine your Slack user ID")
+	}
+	activity, err := LoadActivityIndex(DefaultActivityIndexPath(e.configDir))
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*ActivityResult
+	for _, kind := range kinds {
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+		e.Progress("Exporting activity: %s", kind)
+		result := &ActivityResult{Kind: kind}
+		results = append(results, result)
+
+		if kind == ActivityPending {
+			if err := e.exportPending(ctx, activity, userID, result); err != nil {
+				result.Error = err
+				e.Progress("Error exporting %s: %v", kind, err)
+			}
+			continue
+		}
+		if kind == ActivityThreads {
+			if err := e.exportSubscribedThreads(ctx, activity, result); err != nil {
+				result.Error = err
+				e.Progress("Error exporting %s: %v", kind, err)
+			}
+			continue
+		}
+
+		feed := activity.feed(kind)
+		items, err := e.collectActivity(ctx, kind, userID, feed.Written)
+		if err != nil {
+			result.Error = err
+			e.Progress("Error exporting %s: %v", kind, err)
+			continue
+		}
+		if err := e.writeActivity(ctx, activity, kind, items, result); err != nil {
+			result.Error = err
+			e.Progress("Error exporting %s: %v", kind, err)
+		}
+		result.FolderURL = feed.FolderURL
+	}
+	return results, nil
+}
+
+// collectActivity returns the feed's messages not in written, oldest
+// first. Slack lists activity newest first, so listing stops at the first
+// message already written.
+func (e *Exporter) collectActivity(ctx context.Context, kind ActivityKind, userID string, written map[string]bool) ([]activityItem, error) {
+	var items []activityItem
+	add := func(item activityItem) bool {
+		if written[item.key()] {
+			return false
+		}
+		items = append(items, item)
+		return true
+	}
+
+	switch kind {
+	case ActivityMentions:
+		for page := 1; ; page++ {
+			resp, err := e.slackClient.SearchMessages(ctx, fmt.Sprintf("<@%s>", userID), page)
+			if err != nil {
+				return nil, activityListError(slackapi.MethodSearchMessages, err)
+			}
+			more := true
+			for _, m := range resp.Messages.Matches {
+				if m.Channel.Name != "" && !e.channelResolver.Has(m.Channel.ID) {
+					e.channelResolver.AddChannel(m.Channel.ID, m.Channel.Name)
+				}
+				if more = add(activityItem{channelID: m.Channel.ID, msg: m.Message}); !more {
+					break
+				}
+			}
+			if !more || page >= resp.Messages.Paging.Pages {
+				break
+			}
+		}
+	case ActivityReactions, ActivitySaved:
+		method := slackapi.MethodReactionsList
+		if kind == ActivitySaved {
+			method = slackapi.MethodStarsList
+		}
+		cursor := ""
+		for {
+			var resp *slackapi.ItemsListResponse
+			var err error
+			if kind == ActivitySaved {
+				resp, err = e.slackClient.ListSaved(ctx, cursor)
+			} else {
+				resp, err = e.slackClient.ListReactions(ctx, userID, cursor)
+			}
+			if err != nil {
+				return nil, activityListError(method, err)
+			}
+			more := true
+			for _, it := range resp.Items {
+				if it.Type != "message" || it.Message == nil || it.Message.TS == "" {
+					continue
+				}
+				if more = add(activityItem{channelID: it.Channel, msg: *it.Message}); !more {
+					break
+				}
+			}
+			cursor = resp.ResponseMetadata.NextCursor
+			if !more || cursor == "" {
+				break
+			}
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].msg.TS < items[j].msg.TS
+	})
+	e.Detail("Found %d new %s", len(items), kind)
+	return items, nil
+}
+
+func activityListError(method string, err error) error {
+	if slackapi.IsRestrictedError(err) {
+		return fmt.Errorf("%s is restricted in this workspace: %w", method, err)
+	}
+	return fmt.Errorf("failed to list activity: %w", err)
+}
+
+// writeActivity appends items to the feed's daily docs, by message date,
+// saving the activity index after each day.
+func (e *Exporter) writeActivity(ctx context.Context, activity *ActivityIndex, kind ActivityKind, items []activityItem, result *ActivityResult) error {
+	if len(items) == 0 {
+		e.Progress("No new %s", kind)
+		return nil
+	}
+	feed := activity.feed(kind)
+	folderID, err := e.ensureActivityFolder(ctx, activity, kind)
+	if err != nil {
+		return err
+	}
+
+	msgs := make([]slackapi.Message, len(items))
+	byDate := make(map[string][]activityItem)
+	for i, item := range items {
+		msgs[i] = item.msg
+		date := DateFromTS(item.msg.TS)
+		byDate[date] = append(byDate[date], item)
+	}
+	e.loadMessageAuthors(ctx, msgs)
+	e.loadMentionedChannels(ctx, msgs)
+	e.loadActivityChannels(ctx, items)
+
+	dates := make([]string, 0, len(byDate))
+	for date := range byDate {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	for _, date := range dates {
+		doc := feed.DailyDocs[date]
+		if doc == nil || doc.DocID == "" {
+			gdoc, err := e.gdriveClient.FindOrCreateDocumentFromTemplate(ctx, date, folderID, e.docTemplate)
+			if err != nil {
+				return fmt.Errorf("failed to create doc for %s: %w", date, err)
+			}
+			doc = &DocExport{DocID: gdoc.ID, DocURL: gdoc.URL, Title: date, Date: date}
+			feed.DailyDocs[date] = doc
+			result.DocsCreated++
+		}
+
+		var blocks []gdrive.MessageBlock
+		for _, item := range byDate[date] {
+			for _, block := range e.docWriter.BuildBlocks(ctx, item.channelID, folderID, []slackapi.Message{item.msg}) {
+				block.SenderName += " in " + e.activityConversationLabel(item.channelID)
+				blocks = append(blocks, block)
+			}
+		}
+		if len(blocks) > 0 {
+			if err := e.gdriveClient.BatchAppendMessages(ctx, doc.DocID, blocks); err != nil {
+				return fmt.Errorf("failed to write %s for %s: %w", kind, date, err)
+			}
+		}
+
+		for _, item := range byDate[date] {
+			feed.Written[item.key()] = true
+		}
+		doc.MessageCount += len(byDate[date])
+		result.Messages += len(byDate[date])
+		e.addMessages(len(byDate[date]))
+		if err := activity.Save(); err != nil {
+			e.Progress("Warning: %v", err)
+		}
+	}
+	return nil
+}
+
+// ensureActivityFolder creates or finds the feed's folder under the
+// Activity folder of the export root.
+func (e *Exporter) ensureActivityFolder(ctx context.Context, activity *ActivityIndex, kind ActivityKind) (string, error) {
+	feed := activity.feed(kind)
+	if feed.FolderID != "" {
+		return feed.FolderID, nil
+	}
+	activityFolderID, err := e.ensureActivityRoot(ctx, activity)
+	if err != nil {
+		return "", err
+	}
+	folder, err := e.gdriveClient.FindOrCreateFolder(ctx, kind.folderName(), activityFolderID)
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s folder: %w", kind, err)
+	}
+	feed.FolderID, feed.FolderURL = folder.ID, folder.URL
+	return feed.FolderID, nil
+}
+
+// ensureActivityRoot creates or finds the Activity folder of the export
+// root.
+func (e *Exporter) ensureActivityRoot(ctx context.Context, activity *ActivityIndex) (string, error) {
+	if activity.FolderID != "" {
+		return activity.FolderID, nil
+	}
+	root, err := e.folderStructure.EnsureRootFolder(ctx)
+	if err != nil {
+		return "", err
+	}
+	folder, err := e.gdriveClient.FindOrCreateFolder(ctx, ActivityFolderName, root.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to create activity folder: %w", err)
+	}
+	activity.FolderID, activity.FolderURL = folder.ID, folder.URL
+	return activity.FolderID, nil
+}
+
+// loadActivityChannels looks up the names of the conversations the items
+// were posted in, so each message can say where it is from.
+func (e *Exporter) loadActivityChannels(ctx context.Context, items []activityItem) {
+	seen := make(map[string]bool)
+	for _, item := range items {
+		id := item.channelID
+		if id == "" || seen[id] || e.channelResolver.Has(id) || e.index.GetConversation(id) != nil {
+			continue
+		}
+		seen[id] = true
+		if !e.capabilities.Usable(slackapi.MethodConversationsInfo) || ctx.Err() != nil {
+			return
+		}
+		if conv, err := e.slackClient.GetConversationInfo(ctx, id); err == nil && conv.Name != "" {
+			e.channelResolver.AddChannel(id, conv.Name)
+		}
+	}
+}
+
+// activityConversationLabel names the conversation a feed message was
+// posted in: its exported name, its channel name, or its ID.
+func (e *Exporter) activityConversationLabel(channelID string) string {
+	if conv := e.index.GetConversation(channelID); conv != nil && conv.Name != "" {
+		return conv.Name
+	}
+	if name := e.channelResolver.Resolve(channelID); name != channelID {
+		return "#" + name
+	}
+	return channelID
+}
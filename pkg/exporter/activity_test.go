@@ -0,0 +1,244 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/internal/testutil"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+func activitySlack() *testutil.FakeSlack {
+	slack := testutil.NewFakeSlack()
+	slack.SearchResults = []slackapi.SearchMatch{
+		{Message: slackapi.Message{User: "U002", Text: "ping <@U000>", TS: "1706875200.000300"}, Channel: slackapi.SearchChannel{ID: "C001", Name: "general"}}, // 2024-02-02
+		{Message: slackapi.Message{User: "U002", Text: "hey <@U000>", TS: "1706788800.000100"}, Channel: slackapi.SearchChannel{ID: "C001", Name: "general"}},  // 2024-02-01
+	}
+	slack.Reactions = []slackapi.ListedItem{
+		{Type: "message", Channel: "C002", Message: &slackapi.Message{User: "U003", Text: "ship it", TS: "1706792400.000200"}},
+		{Type: "file"},
+	}
+	slack.Channels["C002"] = "releases"
+	return slack
+}
+
+func TestExportActivity(t *testing.T) {
+	drive, slack := testutil.NewFakeDrive(), activitySlack()
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+
+	results, err := exp.ExportActivity(context.Background(), ActivityKinds)
+	if err != nil {
+		t.Fatalf("ExportActivity() error: %v", err)
+	}
+	if len(results) != len(ActivityKinds) {
+		t.Fatalf("results = %d, want one per kind", len(results))
+	}
+	mentions, reactions, saved := results[0], results[1], results[2]
+	if mentions.Messages != 2 || mentions.DocsCreated != 2 || mentions.Error != nil {
+		t.Errorf("mentions = %+v, want 2 messages in 2 daily docs", mentions)
+	}
+	if reactions.Messages != 1 || reactions.Error != nil {
+		t.Errorf("reactions = %+v, want 1 message (files skipped)", reactions)
+	}
+	if saved.Messages != 0 || saved.Error != nil {
+		t.Errorf("saved = %+v, want nothing", saved)
+	}
+
+	activity, err := LoadActivityIndex(DefaultActivityIndexPath(exp.configDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed := activity.Feeds[ActivityReactions]
+	doc := feed.DailyDocs["2024-02-01"]
+	if doc == nil {
+		t.Fatal("no reactions doc for 2024-02-01")
+	}
+	if got := drive.FolderName(drive.DocumentFolder(doc.DocID)); got != "Reactions" {
+		t.Errorf("reactions doc folder = %q, want Reactions", got)
+	}
+	blocks := drive.Appended(doc.DocID)
+	if len(blocks) != 1 || !strings.HasSuffix(blocks[0][0].SenderName, " in #releases") {
+		t.Errorf("reactions blocks = %+v, want the sender labelled with the conversation", blocks)
+	}
+
+	// A second run adds only new activity.
+	slack.SearchResults = append([]slackapi.SearchMatch{
+		{Message: slackapi.Message{User: "U002", Text: "again <@U000>", TS: "1706878800.000500"}, Channel: slackapi.SearchChannel{ID: "C001", Name: "general"}},
+	}, slack.SearchResults...)
+	results, err = exp.ExportActivity(context.Background(), []ActivityKind{ActivityMentions, ActivityReactions})
+	if err != nil {
+		t.Fatalf("second ExportActivity() error: %v", err)
+	}
+	if results[0].Messages != 1 || results[0].DocsCreated != 0 || results[1].Messages != 0 {
+		t.Errorf("second run = %+v, %+v; want only the new mention, in the existing doc", results[0], results[1])
+	}
+}
+
+func TestExportActivity_Pending(t *testing.T) {
+	drive, slack := testutil.NewFakeDrive(), activitySlack()
+	slack.Scheduled = []slackapi.ScheduledMessage{
+		{ID: "Q002", ChannelID: "C002", PostAt: 1706961600, Text: "release notes"},
+		{ID: "Q001", ChannelID: "C002", PostAt: 1706875200, Text: "heads up"},
+	}
+	slack.Reminders = []slackapi.Reminder{
+		{ID: "Rm001", User: "U000", Text: "renew badge", Time: 1706878800},
+		{ID: "Rm002", User: "U000", Text: "done already", Time: 1706792400, CompleteTS: 1706792500},
+		{ID: "Rm003", User: "U002", Text: "standup", Recurring: true},
+	}
+	slack.Users = []slackapi.User{{ID: "U002", Name: "bob"}}
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+
+	results, err := exp.ExportActivity(context.Background(), []ActivityKind{ActivityPending})
+	if err != nil {
+		t.Fatalf("ExportActivity() error: %v", err)
+	}
+	if r := results[0]; r.Error != nil || r.Messages != 4 || r.DocsCreated != 1 {
+		t.Fatalf("pending = %+v, want 2 scheduled messages and 2 open reminders in one doc", r)
+	}
+
+	activity, err := LoadActivityIndex(DefaultActivityIndexPath(exp.configDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := activity.Feeds[ActivityPending].Doc
+	if doc == nil {
+		t.Fatal("no pending doc in the activity index")
+	}
+	if got := drive.FolderName(drive.DocumentFolder(doc.DocID)); got != ActivityFolderName {
+		t.Errorf("pending doc folder = %q, want %s", got, ActivityFolderName)
+	}
+	var senders []string
+	for _, batch := range drive.Appended(doc.DocID) {
+		for _, b := range batch {
+			senders = append(senders, b.SenderName)
+		}
+	}
+	want := "Snapshot|Scheduled in #releases|Scheduled in #releases|Recurring reminder for bob|Reminder"
+	if got := strings.Join(senders, "|"); got != want {
+		t.Errorf("pending blocks = %q, want %q", got, want)
+	}
+
+	// Each run appends a new snapshot to the same doc.
+	results, err = exp.ExportActivity(context.Background(), []ActivityKind{ActivityPending})
+	if err != nil {
+		t.Fatalf("second ExportActivity() error: %v", err)
+	}
+	if results[0].DocsCreated != 0 || len(drive.Appended(doc.DocID)) != 2 {
+		t.Errorf("second run = %+v with %d appends, want a second snapshot in the existing doc", results[0], len(drive.Appended(doc.DocID)))
+	}
+}
+
+func TestExportActivity_PendingPartial(t *testing.T) {
+	drive, slack := testutil.NewFakeDrive(), activitySlack()
+	slack.Errors["ListReminders"] = &slackapi.APIError{Code: "not_allowed_token_type"}
+	slack.Scheduled = []slackapi.ScheduledMessage{{ID: "Q001", ChannelID: "C002", PostAt: 1706875200, Text: "heads up"}}
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+
+	results, err := exp.ExportActivity(context.Background(), []ActivityKind{ActivityPending})
+	if err != nil {
+		t.Fatalf("ExportActivity() error: %v", err)
+	}
+	if r := results[0]; r.Error == nil || r.Messages != 1 {
+		t.Errorf("pending = %+v, want the scheduled message written and the reminders error reported", r)
+	}
+}
+
+func TestExportActivity_Threads(t *testing.T) {
+	drive, slack := testutil.NewFakeDrive(), activitySlack()
+	root := slackapi.Message{User: "U002", Text: "Design review", TS: "1706792400.000200", ThreadTS: "1706792400.000200", ReplyCount: 1}
+	slack.Subscribed = []slackapi.SubscribedThread{
+		{RootMsg: slackapi.ThreadRoot{Message: root, Channel: "C002", LatestReply: "1706792460.000400"}},
+		{RootMsg: slackapi.ThreadRoot{Message: slackapi.Message{User: "U001", Text: "exported", TS: "1706788800.000100"}, Channel: "C001"}},
+	}
+	slack.Replies[testutil.ThreadKey("C002", root.TS)] = []slackapi.Message{
+		root,
+		{User: "U000", Text: "looks good", TS: "1706792460.000400", ThreadTS: root.TS},
+	}
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.index.GetOrCreateConversation("C001", "general", "channel")
+	exp.docTemplate = "template-doc"
+
+	results, err := exp.ExportActivity(context.Background(), []ActivityKind{ActivityThreads})
+	if err != nil {
+		t.Fatalf("ExportActivity() error: %v", err)
+	}
+	if r := results[0]; r.Error != nil || r.Messages != 2 || r.DocsCreated != 1 {
+		t.Fatalf("threads = %+v, want the thread in exported #general skipped and the other in one doc", r)
+	}
+
+	activity, err := LoadActivityIndex(DefaultActivityIndexPath(exp.configDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := activity.Feeds[ActivityThreads].Threads["C002/"+root.TS]
+	if doc == nil {
+		t.Fatal("no thread doc in the activity index")
+	}
+	if got := drive.FolderName(drive.DocumentFolder(doc.DocID)); got != "My Threads" {
+		t.Errorf("thread doc folder = %q, want My Threads", got)
+	}
+	if got := drive.DocumentTemplate(doc.DocID); got != "template-doc" {
+		t.Errorf("thread doc template = %q, want the docTemplate like other docs", got)
+	}
+	if !strings.HasSuffix(doc.Title, " in #releases") || !strings.Contains(doc.Title, "Design review") {
+		t.Errorf("thread doc title = %q, want the topic and the conversation", doc.Title)
+	}
+
+	// A second run appends only the new reply to the same doc.
+	slack.Replies[testutil.ThreadKey("C002", root.TS)] = append(slack.Replies[testutil.ThreadKey("C002", root.TS)],
+		slackapi.Message{User: "U002", Text: "merged", TS: "1706796000.000500", ThreadTS: root.TS})
+	slack.Subscribed[0].RootMsg.LatestReply = "1706796000.000500"
+	results, err = exp.ExportActivity(context.Background(), []ActivityKind{ActivityThreads})
+	if err != nil {
+		t.Fatalf("second ExportActivity() error: %v", err)
+	}
+	if r := results[0]; r.Messages != 1 || r.DocsCreated != 0 || len(drive.Appended(doc.DocID)) != 2 {
+		t.Errorf("second run = %+v, want the new reply appended to the existing doc", r)
+	}
+
+	// Nothing is fetched for a thread without new replies.
+	before := slack.Calls("GetAllReplies")
+	if _, err := exp.ExportActivity(context.Background(), []ActivityKind{ActivityThreads}); err != nil {
+		t.Fatalf("third ExportActivity() error: %v", err)
+	}
+	if got := slack.Calls("GetAllReplies"); got != before {
+		t.Errorf("GetAllReplies called %d times for an up-to-date thread, want 0", got-before)
+	}
+}
+
+func TestExportActivity_RestrictedFeed(t *testing.T) {
+	drive, slack := testutil.NewFakeDrive(), activitySlack()
+	slack.Errors["SearchMessages"] = &slackapi.APIError{Code: "missing_scope"}
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+
+	results, err := exp.ExportActivity(context.Background(), []ActivityKind{ActivityMentions, ActivityReactions})
+	if err != nil {
+		t.Fatalf("ExportActivity() error: %v", err)
+	}
+	if results[0].Error == nil || !strings.Contains(results[0].Error.Error(), "search.messages is restricted") {
+		t.Errorf("mentions error = %v, want restricted search.messages", results[0].Error)
+	}
+	if results[1].Error != nil || results[1].Messages != 1 {
+		t.Errorf("reactions = %+v, want exported despite the failed feed", results[1])
+	}
+}
+
+func TestExportActivity_InvalidSession(t *testing.T) {
+	drive, slack := testutil.NewFakeDrive(), activitySlack()
+	slack.Errors["ValidateAuth"] = errors.New("invalid_auth")
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	if _, err := exp.ExportActivity(context.Background(), ActivityKinds); err == nil {
+		t.Error("ExportActivity() should fail when the Slack session is invalid")
+	}
+}
+
+func TestParseActivityKind(t *testing.T) {
+	if k, err := ParseActivityKind("saved"); err != nil || k != ActivitySaved {
+		t.Errorf("ParseActivityKind(saved) = %q, %v", k, err)
+	}
+	if _, err := ParseActivityKind("stars"); err == nil || !strings.Contains(err.Error(), "mentions, reactions, saved") {
+		t.Errorf("ParseActivityKind(stars) error = %v, want the valid kinds listed", err)
+	}
+}
@@ -0,0 +1,167 @@
+package exporter
+
+import (
+	"context"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/gdrive"
+)
+
+// FolderMerger is the part of the Drive client that empties an alias's
+// Drive folder into its primary conversation's folder. It is satisfied by
+// *gdrive.Client.
+type FolderMerger interface {
+	ListChildren(ctx context.Context, parentID string) ([]*gdrive.DriveItem, error)
+	MoveFile(ctx context.Context, fileID, fromParentID, toParentID string) error
+	DeleteFolder(ctx context.Context, folderID string) error
+}
+
+var _ FolderMerger = (*gdrive.Client)(nil)
+
+// mergeAliasFolders moves the contents of the Drive folders queued in the
+// MergedFolders of convID into its own folder and trashes them, so a
+// conversation exported under several IDs ends up in one folder. A folder
+// that cannot be merged stays queued for the next run.
+func (e *Exporter) mergeAliasFolders(ctx context.Context, convID string) {
+	convExport := e.index.GetConversation(convID)
+	drive, ok := e.gdriveClient.(FolderMerger)
+	if convExport == nil || !ok {
+		return
+	}
+	convExport.mu.Lock()
+	target := convExport.FolderID
+	pending := append([]string(nil), convExport.MergedFolders...)
+	convExport.mu.Unlock()
+	if target == "" || len(pending) == 0 {
+		return
+	}
+
+	var remaining []string
+	for _, folderID := range pending {
+		if folderID == target {
+			continue
+		}
+		if err := mergeFolder(ctx, drive, folderID, target); err != nil {
+			e.Progress("Warning: failed to merge folder %s into %s: %v", folderID, target, err)
+			remaining = append(remaining, folderID)
+			continue
+		}
+		e.Progress("Merged folder %s into the conversation's folder", folderID)
+	}
+
+	convExport.mu.Lock()
+	convExport.MergedFolders = remaining
+	convExport.mu.Unlock()
+	if err := e.index.SaveConversation(convID); err != nil {
+		e.Progress("Warning: failed to save index: %v", err)
+	}
+}
+
+// mergeFolder moves everything in folder src into dst and trashes src. A
+// subfolder with the same name as one in dst (Threads, a year or month
+// folder) is merged into it the same way.
+func mergeFolder(ctx context.Context, drive FolderMerger, src, dst string) error {
+	items, err := drive.ListChildren(ctx, src)
+	if err != nil {
+		return err
+	}
+	existing, err := drive.ListChildren(ctx, dst)
+	if err != nil {
+		return err
+	}
+	folders := make(map[string]string)
+	for _, item := range existing {
+		if item.IsFolder() {
+			folders[item.Name] = item.ID
+		}
+	}
+
+	for _, item := range items {
+		if into, ok := folders[item.Name]; ok && item.IsFolder() {
+			if err := mergeFolder(ctx, drive, item.ID, into); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := drive.MoveFile(ctx, item.ID, src, dst); err != nil {
+			return err
+		}
+	}
+	return drive.DeleteFolder(ctx, src)
+}
+
+// exportAliasSources exports the messages posted under conv's alias IDs
+// since each one's own cursor in SourceCursors, into conv's docs. Slack
+// keeps posting to an old ID until everyone has moved over (a DM that
+// became a group DM, a channel shared before it was migrated), and its
+// timestamps are unrelated to those of the primary ID, so each ID is
+// fetched and checkpointed on its own.
+func (e *Exporter) exportAliasSources(ctx context.Context, conv config.ConversationConfig, backend Backend, convExport *ConversationExport, oldest, latest string, result *ExportResult) {
+	for _, alias := range conv.Aliases {
+		if alias == conv.ID || ctx.Err() != nil {
+			continue
+		}
+		from := oldest
+		if e.syncMode || e.resumeMode {
+			convExport.mu.Lock()
+			from = convExport.SourceCursors[alias]
+			convExport.mu.Unlock()
+		}
+
+		msgs, err := e.fetchMessages(ctx, alias, from, latest, nil)
+		if err != nil {
+			e.Progress("Warning: failed to fetch messages from previous ID %s: %v", alias, err)
+			continue
+		}
+		if len(msgs) == 0 {
+			continue
+		}
+		e.Progress("Exporting %d messages posted under previous ID %s", len(msgs), alias)
+		e.loadMessageAuthors(ctx, msgs)
+		e.loadMentionedChannels(ctx, msgs)
+
+		mainMessages := e.skipEmojiMessages(conv, FilterMainMessages(msgs), result)
+		messagesByDate := GroupMessagesByDate(mainMessages)
+		e.exportThreadsFrom(ctx, conv, alias, msgs, result)
+
+		// Like the primary ID's, the cursor is checkpointed after each day
+		// and moves to the newest fetched message once all are written.
+		newest := msgs[0].TS // Messages come in reverse order
+		for _, date := range SortedDates(messagesByDate) {
+			written, err := backend.WriteMessages(ctx, conv, date, messagesByDate[date], result)
+			result.MessageCount += written
+			if err != nil {
+				e.Progress("Warning: failed to write %s messages from %s: %v", date, alias, err)
+				newest = ""
+				break
+			}
+			convExport.mu.Lock()
+			setSourceCursor(convExport, alias, newestTS([]budgetDay{{date: date, messages: messagesByDate[date]}}))
+			convExport.MessageCount += written
+			convExport.LastUpdated = time.Now()
+			convExport.mu.Unlock()
+			if err := e.index.SaveConversation(conv.ID); err != nil {
+				e.Progress("Warning: failed to save checkpoint: %v", err)
+			}
+		}
+		convExport.mu.Lock()
+		setSourceCursor(convExport, alias, newest)
+		convExport.mu.Unlock()
+	}
+	if err := e.index.SaveConversation(conv.ID); err != nil {
+		e.Progress("Warning: failed to save index: %v", err)
+	}
+}
+
+// newestMessageTS returns the newest message exported into conv from any
+// of its IDs. The caller holds conv.mu or reads a snapshot.
+func (conv *ConversationExport) newestMessageTS() string {
+	newest := conv.LastMessageTS
+	for _, ts := range conv.SourceCursors {
+		if ts > newest {
+			newest = ts
+		}
+	}
+	return newest
+}
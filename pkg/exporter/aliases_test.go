@@ -0,0 +1,67 @@
+package exporter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jflowers/get-out/internal/testutil"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+func TestExportConversation_SyncsAliasFromItsOwnCursor(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	conv.Aliases = []string{"D001"}
+	// Posted under the old ID after it was last exported, but before the
+	// newest message exported under C001.
+	slack.Messages["D001"] = []slackapi.Message{
+		{User: "U001", Text: "Still on the old DM", TS: "1706800000.000100"},
+		{User: "U001", Text: "Already exported", TS: "1706700000.000100"},
+	}
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+
+	old := exp.index.GetOrCreateConversation("D001", "general", "channel")
+	old.LastMessageTS = "1706700000.000100"
+	primary := exp.index.GetOrCreateConversation("C001", "general", "channel")
+	primary.LastMessageTS = "1706875200.000300"
+	exp.syncMode = true
+
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	if result.MessageCount != 1 {
+		t.Errorf("MessageCount = %d, want only the alias's new message", result.MessageCount)
+	}
+	got := exp.index.GetConversation("C001")
+	if got.SourceCursors["D001"] != "1706800000.000100" {
+		t.Errorf("SourceCursors = %v, want D001 moved to its newest message", got.SourceCursors)
+	}
+	if got.LastMessageTS != "1706875200.000300" {
+		t.Errorf("LastMessageTS = %q, the primary cursor should not move", got.LastMessageTS)
+	}
+}
+
+func TestMergeFolder(t *testing.T) {
+	ctx := context.Background()
+	drive := testutil.NewFakeDrive()
+	drive.AddFolder("root", "Test Exports")
+	dst, _ := drive.FindOrCreateFolder(ctx, "DM - alice-bob", "root")
+	src, _ := drive.FindOrCreateFolder(ctx, "DM - alice", "root")
+	dstThreads, _ := drive.FindOrCreateFolder(ctx, "Threads", dst.ID)
+	srcThreads, _ := drive.FindOrCreateFolder(ctx, "Threads", src.ID)
+	day, _ := drive.FindOrCreateDocument(ctx, "2024-02-01", src.ID)
+	thread, _ := drive.FindOrCreateDocument(ctx, "Thread", srcThreads.ID)
+
+	if err := mergeFolder(ctx, drive, src.ID, dst.ID); err != nil {
+		t.Fatalf("mergeFolder() error: %v", err)
+	}
+	if got := drive.DocumentFolder(day.ID); got != dst.ID {
+		t.Errorf("daily doc in %q, want %q", got, dst.ID)
+	}
+	if got := drive.DocumentFolder(thread.ID); got != dstThreads.ID {
+		t.Errorf("thread doc in %q, want the primary's Threads folder %q", got, dstThreads.ID)
+	}
+	if drive.FolderName(src.ID) != "" || drive.FolderName(srcThreads.ID) != "" {
+		t.Error("emptied alias folders should be trashed")
+	}
+}
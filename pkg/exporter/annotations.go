@@ -0,0 +1,106 @@
+package exporter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// NormalizeTag lowercases and trims a conversation tag and checks that it
+// only uses letters, digits, and - _ . : so tags stay easy to type and
+// match.
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return "", fmt.Errorf("tag must not be empty")
+	}
+	for _, r := range tag {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_', r == '.', r == ':':
+		default:
+			return "", fmt.Errorf("invalid tag %q: use letters, digits, and - _ . :", tag)
+		}
+	}
+	return tag, nil
+}
+
+// TagConversation adds tags to a conversation, creating its index entry if
+// it has not been exported yet, and returns the conversation's tags.
+func (idx *ExportIndex) TagConversation(id, name, convType string, tags ...string) []string {
+	conv := idx.GetOrCreateConversation(id, name, convType)
+	conv.mu.Lock()
+	defer conv.mu.Unlock()
+	for _, tag := range tags {
+		if !containsString(conv.Tags, tag) {
+			conv.Tags = append(conv.Tags, tag)
+		}
+	}
+	sort.Strings(conv.Tags)
+	return append([]string(nil), conv.Tags...)
+}
+
+// UntagConversation removes tags from a conversation and returns the tags
+// left. A conversation not in the index has none.
+func (idx *ExportIndex) UntagConversation(id string, tags ...string) []string {
+	conv := idx.GetConversation(id)
+	if conv == nil {
+		return nil
+	}
+	conv.mu.Lock()
+	defer conv.mu.Unlock()
+	kept := conv.Tags[:0]
+	for _, tag := range conv.Tags {
+		if !containsString(tags, tag) {
+			kept = append(kept, tag)
+		}
+	}
+	conv.Tags = kept
+	if len(conv.Tags) == 0 {
+		conv.Tags = nil
+	}
+	return append([]string(nil), conv.Tags...)
+}
+
+// SetConversationNotes replaces a conversation's notes, creating its index
+// entry if needed. Empty notes clear them.
+func (idx *ExportIndex) SetConversationNotes(id, name, convType, notes string) {
+	conv := idx.GetOrCreateConversation(id, name, convType)
+	conv.mu.Lock()
+	defer conv.mu.Unlock()
+	conv.Notes = strings.TrimSpace(notes)
+}
+
+// ConversationsTagged returns the IDs of conversations that have any of
+// tags.
+func (idx *ExportIndex) ConversationsTagged(tags []string) map[string]bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	ids := make(map[string]bool)
+	for id, conv := range idx.Conversations {
+		conv.mu.Lock()
+		for _, tag := range conv.Tags {
+			if containsString(tags, tag) {
+				ids[id] = true
+				break
+			}
+		}
+		conv.mu.Unlock()
+	}
+	return ids
+}
+
+// HasAnyTag reports whether the conversation has any of tags. An empty tags
+// list matches every conversation.
+func (c *ConversationExport) HasAnyTag(tags []string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, tag := range c.Tags {
+		if containsString(tags, tag) {
+			return true
+		}
+	}
+	return false
+}
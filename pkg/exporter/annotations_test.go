@@ -0,0 +1,65 @@
+package exporter
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	tests := []struct {
+		in, want string
+		wantErr  bool
+	}{
+		{in: "legal-hold", want: "legal-hold"},
+		{in: "  Project-X ", want: "project-x"},
+		{in: "team:infra.v2_old", want: "team:infra.v2_old"},
+		{in: "", wantErr: true},
+		{in: "two words", wantErr: true},
+		{in: "legal/hold", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeTag(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("NormalizeTag(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestExportIndex_Annotations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export-index.json")
+	idx := NewExportIndex(path)
+
+	if got := idx.TagConversation("D001", "alice", "dm", "personal", "legal-hold", "personal"); strings.Join(got, ",") != "legal-hold,personal" {
+		t.Errorf("TagConversation() = %v, want sorted, deduplicated tags", got)
+	}
+	idx.TagConversation("C001", "general", "channel", "project-x")
+	idx.SetConversationNotes("D001", "alice", "dm", "  Requested by legal  ")
+
+	if err := idx.Save(); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadExportIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conv := loaded.GetConversation("D001")
+	if conv == nil || conv.Name != "alice" || conv.Notes != "Requested by legal" || len(conv.Tags) != 2 {
+		t.Fatalf("reloaded conversation = %+v", conv)
+	}
+
+	tagged := loaded.ConversationsTagged([]string{"legal-hold", "project-x"})
+	if len(tagged) != 2 || !tagged["D001"] || !tagged["C001"] {
+		t.Errorf("ConversationsTagged() = %v", tagged)
+	}
+	if !conv.HasAnyTag(nil) || !conv.HasAnyTag([]string{"personal"}) || conv.HasAnyTag([]string{"project-x"}) {
+		t.Error("HasAnyTag() mismatch")
+	}
+
+	if got := loaded.UntagConversation("D001", "personal", "legal-hold"); got != nil {
+		t.Errorf("UntagConversation() = %v, want none left", got)
+	}
+	if got := loaded.UntagConversation("CNOTEXIST", "x"); got != nil {
+		t.Errorf("UntagConversation() on unknown = %v", got)
+	}
+}
@@ -0,0 +1,223 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// Backend is a destination for exported conversations. ExportConversation
+// does the orchestration common to every destination: it fetches messages,
+// applies the date range and run budget, fetches thread replies, and
+// checkpoints progress in the export index. A Backend only writes.
+//
+// One Backend value serves all conversations of a run, concurrently with
+// ExportAllParallel, so per-conversation state belongs in the index entry
+// or the ExportResult, not in the Backend.
+type Backend interface {
+	// EnsureConversationContainer creates or finds the conversation's
+	// destination (a Drive folder, a local directory) and returns its index
+	// entry, creating it when needed.
+	EnsureConversationContainer(ctx context.Context, conv config.ConversationConfig) (*ConversationExport, error)
+
+	// WriteMessages writes one day's top-level messages, oldest first, and
+	// returns how many were written. Messages it could not write are
+	// dead-lettered rather than failing the day.
+	WriteMessages(ctx context.Context, conv config.ConversationConfig, date string, msgs []slackapi.Message, result *ExportResult) (int, error)
+
+	// WriteThread writes a thread's replies, oldest first. replies may be
+	// empty when the thread has no replies left to export.
+	WriteThread(ctx context.Context, conv config.ConversationConfig, parent slackapi.Message, replies []slackapi.Message, result *ExportResult) error
+
+	// Finalize runs once the conversation's days and threads are written
+	// and its index entry saved.
+	Finalize(ctx context.Context, conv config.ConversationConfig, result *ExportResult) error
+}
+
+// backendFor returns the backend that writes conv: the configured
+// Backend, else Google Docs, or the local export directory for the
+// markdown, json, html, slack, and sqlite formats.
+func (e *Exporter) backendFor(conv config.ConversationConfig) Backend {
+	if e.backend != nil {
+		return e.backend
+	}
+	switch conv.OutputFormat() {
+	case config.OutputFormatHTML:
+		return htmlBackend{e}
+	case config.OutputFormatSlack:
+		return slackBackend{e}
+	case config.OutputFormatSQLite:
+		return sqliteBackend{e}
+	case config.OutputFormatMarkdown, config.OutputFormatJSON:
+		return localBackend{e}
+	}
+	return docsBackend{e}
+}
+
+// docsBackend writes daily Google Docs in the conversation's Drive folder,
+// with a subfolder per thread, and mirrors each day to local markdown when
+// the conversation has localExport set.
+type docsBackend struct {
+	e *Exporter
+}
+
+func (b docsBackend) EnsureConversationContainer(ctx context.Context, conv config.ConversationConfig) (*ConversationExport, error) {
+	b.e.Progress("Creating folder structure...")
+	convExport, err := b.e.folderStructure.EnsureConversationFolder(ctx, conv.ID, string(conv.Type), conv.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create folder: %w", err)
+	}
+	if conv.Layout != "" {
+		// Without an explicit layout, keep the one recorded in the index,
+		// which autoFolderLayout may have switched.
+		convExport.mu.Lock()
+		convExport.Layout = string(conv.Layout)
+		convExport.mu.Unlock()
+	}
+	convExport.mu.Lock()
+	convExport.Granularity = string(conv.DocGranularity)
+	convExport.mu.Unlock()
+	return convExport, nil
+}
+
+func (b docsBackend) WriteMessages(ctx context.Context, conv config.ConversationConfig, date string, msgs []slackapi.Message, result *ExportResult) (int, error) {
+	e := b.e
+	docExport, err := e.folderStructure.EnsureDailyDoc(ctx, conv.ID, date)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create doc for %s: %w", date, err)
+	}
+	convExport := e.index.GetConversation(conv.ID)
+
+	// The doc's end as last recorded, from when EnsureDailyDoc created it
+	// or the last write; past it, a doc may hold messages an interrupted
+	// run wrote before recording them.
+	convExport.mu.Lock()
+	recordedEnd := docExport.EndIndex
+	convExport.mu.Unlock()
+
+	// The doc is only read when resuming; otherwise its new end is worked
+	// out from what was appended, and stays unknown (0) when its end
+	// before was.
+	var written int
+	var grown int64
+	docMsgs := e.translateMessages(ctx, conv, msgs, result)
+	if e.resumeMode && recordedEnd > 0 {
+		written, grown, err = e.resumeDocMessages(ctx, conv.ID, date, docExport.DocID, convExport.FolderID, recordedEnd, docMsgs, result)
+	} else {
+		written, grown, err = e.writeDocMessages(ctx, conv.ID, "", date, docExport.DocID, convExport.FolderID, docMsgs, result)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to write messages for %s: %w", date, err)
+	}
+	grown += e.appendDocProvenance(ctx, docExport.DocID, date, result)
+	var endIndex int64
+	if recordedEnd > 0 {
+		endIndex = recordedEnd + grown
+	}
+	result.DocsCreated++
+	e.Detail("Wrote %d messages to %s", written, date)
+	if e.mentionRecorder != nil {
+		e.mentionRecorder.RecordMessages(conv.ID, conv.Name, string(conv.Type), docExport.DocURL, msgs)
+	}
+	e.recordMessageMap(conv.ID, conv.Name, docExport.DocURL, msgs)
+
+	// Hold the per-struct mutex so a concurrent index Save() sees a
+	// consistent view of the doc entry.
+	convExport.mu.Lock()
+	docExport.MessageCount += written
+	docExport.EndIndex = endIndex
+	if len(msgs) > 0 {
+		docExport.LastMessageTS = msgs[len(msgs)-1].TS
+	}
+	convExport.mu.Unlock()
+
+	file, err := e.writeMarkdownDay(ctx, conv, SanitizeDirectoryName(string(conv.Type), conv.Name), date, msgs, e.markdownDayMode(), result)
+	if err != nil {
+		return written, err
+	}
+	if err := e.recordHold(conv.ID, "", date, msgs, file); err != nil {
+		return written, err
+	}
+	return written, nil
+}
+
+func (b docsBackend) WriteThread(ctx context.Context, conv config.ConversationConfig, parent slackapi.Message, replies []slackapi.Message, result *ExportResult) error {
+	e := b.e
+	convID := conv.ID
+	topicPreview := ThreadTopic(parent, replies, e.userResolver, e.channelResolver, e.personResolver)
+
+	threadExport, err := e.folderStructure.EnsureThreadFolder(ctx, convID, parent.TS, topicPreview)
+	if err != nil {
+		return fmt.Errorf("failed to create thread folder: %w", err)
+	}
+	if len(replies) == 0 {
+		return nil
+	}
+
+	replyByDate := GroupMessagesByDate(e.unheldReplies(convID, parent.TS, replies))
+	for _, date := range SortedDates(replyByDate) {
+		msgs := replyByDate[date]
+
+		docExport, err := e.folderStructure.EnsureThreadDailyDoc(ctx, convID, parent.TS, date)
+		if err != nil {
+			return fmt.Errorf("failed to create thread doc: %w", err)
+		}
+
+		written, _, err := e.writeDocMessages(ctx, convID, parent.TS, date, docExport.DocID, threadExport.FolderID, e.translateMessages(ctx, conv, msgs, result), result)
+		if err != nil {
+			return fmt.Errorf("failed to write thread messages: %w", err)
+		}
+		e.appendDocProvenance(ctx, docExport.DocID, date, result)
+		if e.mentionRecorder != nil {
+			if conv := e.index.GetConversation(convID); conv != nil {
+				e.mentionRecorder.RecordMessages(convID, conv.Name, conv.Type, docExport.DocURL, msgs)
+			}
+			e.mentionRecorder.RecordReplies(convID, parent, msgs)
+		}
+		e.recordMessageMap(convID, conv.Name, docExport.DocURL, msgs)
+
+		docExport.MessageCount += written
+
+		file, err := e.writeMarkdownDay(ctx, conv, e.localThreadDir(conv, parent, replies), date, msgs, mdReplace, result)
+		if err != nil {
+			return err
+		}
+		if err := e.recordHold(convID, parent.TS, date, msgs, file); err != nil {
+			return err
+		}
+	}
+
+	threadExport.ReplyCount = len(replies)
+	threadExport.StartedBy = parent.User
+	threadExport.Participants = threadParticipants(threadExport.Participants, replies)
+	e.setLastReply(convID, parent.TS, replies)
+	return nil
+}
+
+// Finalize saves the mention index and the conversation's message map,
+// which record doc URLs.
+func (b docsBackend) Finalize(_ context.Context, conv config.ConversationConfig, _ *ExportResult) error {
+	e := b.e
+	if e.mentionIndex != nil {
+		if err := e.mentionIndex.Save(); err != nil {
+			e.Progress("Warning: failed to save mention index: %v", err)
+		}
+	}
+	if e.messageMap != nil {
+		if err := e.messageMap.Save(conv.ID); err != nil {
+			e.Progress("Warning: failed to save message map: %v", err)
+		}
+	}
+	return nil
+}
+
+// markdownDayMode is how a day's markdown is written when its file
+// exists: appended to in --sync and sample runs, otherwise left alone.
+func (e *Exporter) markdownDayMode() markdownWriteMode {
+	if e.syncMode || e.sampleSize > 0 {
+		return mdAppend
+	}
+	return mdSkipExisting
+}
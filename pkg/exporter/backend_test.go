@@ -0,0 +1,177 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// recordingBackend records the calls ExportConversation makes.
+type recordingBackend struct {
+	index    *ExportIndex
+	calls    []string
+	writeErr error
+	failDate string // fail WriteMessages with writeErr only for this day
+}
+
+func (b *recordingBackend) EnsureConversationContainer(_ context.Context, conv config.ConversationConfig) (*ConversationExport, error) {
+	b.calls = append(b.calls, "ensure")
+	return b.index.GetOrCreateConversation(conv.ID, conv.Name, string(conv.Type)), nil
+}
+
+func (b *recordingBackend) WriteMessages(_ context.Context, _ config.ConversationConfig, date string, msgs []slackapi.Message, _ *ExportResult) (int, error) {
+	b.calls = append(b.calls, "messages "+date)
+	if b.writeErr != nil && (b.failDate == "" || b.failDate == date) {
+		return 0, b.writeErr
+	}
+	return len(msgs), nil
+}
+
+func (b *recordingBackend) WriteThread(_ context.Context, _ config.ConversationConfig, parent slackapi.Message, replies []slackapi.Message, _ *ExportResult) error {
+	b.calls = append(b.calls, "thread "+parent.TS+" "+replies[len(replies)-1].Text)
+	return nil
+}
+
+func (b *recordingBackend) Finalize(context.Context, config.ConversationConfig, *ExportResult) error {
+	b.calls = append(b.calls, "finalize")
+	return nil
+}
+
+func TestExportConversation_Backend(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	backend := &recordingBackend{index: exp.index}
+	exp.backend = backend
+
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	want := "ensure|thread 1706792400.000200 A reply|messages 2024-02-01|messages 2024-02-02|finalize"
+	if got := strings.Join(backend.calls, "|"); got != want {
+		t.Errorf("calls = %q, want %q", got, want)
+	}
+	if n := len(drive.Documents()); n != 0 {
+		t.Errorf("documents = %d, want 0 (the backend replaces Docs)", n)
+	}
+	if result.MessageCount != 3 || result.ThreadsExported != 1 {
+		t.Errorf("MessageCount = %d, ThreadsExported = %d, want 3 and 1", result.MessageCount, result.ThreadsExported)
+	}
+
+	ce := exp.index.GetConversation("C001")
+	if ce.Status != "complete" || ce.MessageCount != 3 || ce.LastMessageTS != "1706875200.000300" {
+		t.Errorf("index entry = status %q, %d messages, last %q; want the run checkpointed", ce.Status, ce.MessageCount, ce.LastMessageTS)
+	}
+}
+
+func TestExportConversation_BackendWriteError(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	backend := &recordingBackend{index: exp.index, writeErr: errors.New("disk full")}
+	exp.backend = backend
+
+	if _, err := exp.ExportConversation(context.Background(), conv); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("ExportConversation() error = %v, want the backend's error", err)
+	}
+	if ce := exp.index.GetConversation("C001"); ce.Status != StatusFailed || ce.Error != "disk full" {
+		t.Errorf("status = %q, error = %q; want failed with the backend's error so --resume retries it", ce.Status, ce.Error)
+	}
+	if last := backend.calls[len(backend.calls)-1]; last != "messages 2024-02-01" {
+		t.Errorf("last call = %q, want the export to stop at the failed day", last)
+	}
+}
+
+func TestExportConversation_ResumeFromCheckpoint(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.backend = &recordingBackend{index: exp.index, writeErr: errors.New("disk full"), failDate: "2024-02-02"}
+
+	if _, err := exp.ExportConversation(context.Background(), conv); err == nil {
+		t.Fatal("ExportConversation() error = nil, want the second day to fail")
+	}
+	ce := exp.index.GetConversation("C001")
+	if ce.Status != StatusFailed || ce.LastMessageTS != "1706792400.000200" {
+		t.Fatalf("index entry = status %q, last %q; want failed checkpointed at the end of the first day", ce.Status, ce.LastMessageTS)
+	}
+	if want := map[string]string{"2024-02-01": "1706792400.000200"}; !reflect.DeepEqual(ce.CompletedDays, want) {
+		t.Errorf("completed days = %v, want %v", ce.CompletedDays, want)
+	}
+
+	backend := &recordingBackend{index: exp.index}
+	exp.backend = backend
+	exp.resumeMode = true
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("resumed ExportConversation() error: %v", err)
+	}
+	if got, want := strings.Join(backend.calls, "|"), "ensure|messages 2024-02-02|finalize"; got != want {
+		t.Errorf("resumed calls = %q, want %q", got, want)
+	}
+	if result.MessageCount != 1 || ce.Status != "complete" || ce.LastMessageTS != "1706875200.000300" {
+		t.Errorf("resumed %d messages, status %q, last %q; want 1, complete, the newest message", result.MessageCount, ce.Status, ce.LastMessageTS)
+	}
+	if ce.Error != "" {
+		t.Errorf("error = %q after a successful resume, want it cleared", ce.Error)
+	}
+	if ce.CompletedDays != nil {
+		t.Errorf("completed days = %v after the export completed, want them cleared", ce.CompletedDays)
+	}
+}
+
+func TestSkipCompletedDays(t *testing.T) {
+	ce := &ConversationExport{CompletedDays: map[string]string{
+		"2024-02-01": "1706792400.000200",
+		"2024-02-02": "1706875200.000100",
+	}}
+	byDate := map[string][]slackapi.Message{
+		"2024-02-01": {{TS: "1706788800.000100"}, {TS: "1706792400.000200"}},
+		"2024-02-02": {{TS: "1706875200.000100"}, {TS: "1706875200.000300"}},
+		"2024-02-03": {{TS: "1706961600.000100"}},
+	}
+
+	if skipped := skipCompletedDays(ce, byDate); skipped != 1 {
+		t.Errorf("skipped = %d, want the fully written day", skipped)
+	}
+	want := map[string][]slackapi.Message{
+		"2024-02-02": {{TS: "1706875200.000300"}},
+		"2024-02-03": {{TS: "1706961600.000100"}},
+	}
+	if !reflect.DeepEqual(byDate, want) {
+		t.Errorf("messages = %v, want %v", byDate, want)
+	}
+}
+
+func TestExportConversation_InterruptedStaysInProgress(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.backend = &recordingBackend{index: exp.index, writeErr: context.Canceled}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := exp.ExportConversation(ctx, conv); err == nil {
+		t.Fatal("ExportConversation() error = nil, want the interruption")
+	}
+	if ce := exp.index.GetConversation("C001"); ce.Status != StatusInProgress || ce.Error != "" {
+		t.Errorf("status = %q, error = %q; want in_progress with no error", ce.Status, ce.Error)
+	}
+}
+
+func TestExportConversation_NoNewMessagesCompletes(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	slack.Messages["C001"] = nil
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.backend = &recordingBackend{index: exp.index}
+	exp.index.GetOrCreateConversation("C001", "general", "channel").Status = StatusFailed
+
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatal(err)
+	}
+	if ce := exp.index.GetConversation("C001"); ce.Status != StatusComplete {
+		t.Errorf("status = %q, want complete", ce.Status)
+	}
+}
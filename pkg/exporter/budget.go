@@ -0,0 +1,176 @@
+package exporter
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// RunBudget caps how much work a single export run performs. A zero limit
+// means unlimited. It is safe for concurrent use by ExportAllParallel
+// goroutines, which draw from the same budget.
+type RunBudget struct {
+	mu sync.Mutex
+
+	maxMessages int
+	maxNewDocs  int
+
+	messages int
+	newDocs  int
+}
+
+// NewRunBudget creates a budget with the given limits. It returns nil when
+// both limits are zero so callers can skip budget checks entirely; all
+// methods treat a nil *RunBudget as unlimited.
+func NewRunBudget(maxMessages, maxNewDocs int) *RunBudget {
+	if maxMessages <= 0 && maxNewDocs <= 0 {
+		return nil
+	}
+	return &RunBudget{
+		maxMessages: maxMessages,
+		maxNewDocs:  maxNewDocs,
+	}
+}
+
+// Exhausted reports whether either limit has been reached.
+func (b *RunBudget) Exhausted() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.exhaustedLocked()
+}
+
+func (b *RunBudget) exhaustedLocked() bool {
+	if b.maxMessages > 0 && b.messages >= b.maxMessages {
+		return true
+	}
+	if b.maxNewDocs > 0 && b.newDocs >= b.maxNewDocs {
+		return true
+	}
+	return false
+}
+
+// Reserve claims budget for writing up to want messages to a daily doc.
+// newDoc reports whether the doc would have to be created. It returns the
+// number of messages the caller may write (0 when the budget is exhausted
+// or the doc cannot be created), consuming that amount from the budget.
+func (b *RunBudget) Reserve(want int, newDoc bool) int {
+	if b == nil {
+		return want
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.exhaustedLocked() || want <= 0 {
+		return 0
+	}
+	if newDoc && b.maxNewDocs > 0 && b.newDocs >= b.maxNewDocs {
+		return 0
+	}
+
+	allowed := want
+	if b.maxMessages > 0 {
+		if remaining := b.maxMessages - b.messages; allowed > remaining {
+			allowed = remaining
+		}
+	}
+
+	b.messages += allowed
+	if newDoc {
+		b.newDocs++
+	}
+	return allowed
+}
+
+// budgetDay is one daily doc's worth of messages selected by planBudget.
+type budgetDay struct {
+	date     string
+	messages []slackapi.Message
+}
+
+// planBudget walks dates oldest-first and reserves budget for each day.
+// Messages within a partially-funded day are kept oldest-first so the
+// next run can pick up after the newest message written.
+//
+// Returns the days that fit within the budget and whether the plan was
+// cut short by the budget.
+func (e *Exporter) planBudget(convID string, dates []string, messagesByDate map[string][]slackapi.Message) ([]budgetDay, bool) {
+	days := make([]budgetDay, 0, len(dates))
+	planned := make(map[string]bool)
+	for _, date := range dates {
+		msgs := messagesByDate[date]
+		existing := e.index.GetDailyDoc(convID, date)
+		// Days of a week or month share the doc the first of them creates
+		period := e.index.docPeriod(convID, date)
+		newDoc := (existing == nil || existing.DocID == "") && !planned[period.key]
+		planned[period.key] = true
+
+		allowed := e.budget.Reserve(len(msgs), newDoc)
+		if allowed == 0 {
+			return days, true
+		}
+		if allowed < len(msgs) {
+			days = append(days, budgetDay{date: date, messages: oldestN(msgs, allowed)})
+			return days, true
+		}
+		days = append(days, budgetDay{date: date, messages: msgs})
+	}
+	return days, false
+}
+
+// oldestN returns the n oldest messages from msgs, sorted oldest first.
+func oldestN(msgs []slackapi.Message, n int) []slackapi.Message {
+	sorted := make([]slackapi.Message, len(msgs))
+	copy(sorted, msgs)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].TS < sorted[j].TS
+	})
+	return sorted[:n]
+}
+
+// newestTS returns the largest Slack timestamp among the planned days.
+func newestTS(days []budgetDay) string {
+	newest := ""
+	for _, d := range days {
+		for _, m := range d.messages {
+			if m.TS > newest {
+				newest = m.TS
+			}
+		}
+	}
+	return newest
+}
+
+// oldestTS returns the smallest Slack timestamp among the planned days.
+func oldestTS(days []budgetDay) string {
+	oldest := ""
+	for _, d := range days {
+		for _, m := range d.messages {
+			if oldest == "" || m.TS < oldest {
+				oldest = m.TS
+			}
+		}
+	}
+	return oldest
+}
+
+// messagesInDays returns the thread parents and replies that belong to the
+// planned days, so threads outside the budget are not exported early.
+func messagesInDays(all []slackapi.Message, days []budgetDay) []slackapi.Message {
+	keep := make(map[string]bool)
+	for _, d := range days {
+		for _, m := range d.messages {
+			keep[m.TS] = true
+		}
+	}
+	var result []slackapi.Message
+	for _, m := range all {
+		if keep[m.TS] {
+			result = append(result, m)
+		}
+	}
+	return result
+}
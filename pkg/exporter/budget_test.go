@@ -0,0 +1,178 @@
+package exporter
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/models"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+func TestNewRunBudget_UnlimitedIsNil(t *testing.T) {
+	if b := NewRunBudget(0, 0); b != nil {
+		t.Fatalf("expected nil budget for zero limits, got %+v", b)
+	}
+	var b *RunBudget
+	if b.Exhausted() {
+		t.Error("nil budget should never be exhausted")
+	}
+	if got := b.Reserve(42, true); got != 42 {
+		t.Errorf("nil budget Reserve = %d, want 42", got)
+	}
+}
+
+func TestRunBudget_MaxMessages(t *testing.T) {
+	b := NewRunBudget(5, 0)
+
+	if got := b.Reserve(3, true); got != 3 {
+		t.Errorf("first Reserve = %d, want 3", got)
+	}
+	if got := b.Reserve(4, false); got != 2 {
+		t.Errorf("second Reserve = %d, want 2 (remaining budget)", got)
+	}
+	if !b.Exhausted() {
+		t.Error("expected budget to be exhausted")
+	}
+	if got := b.Reserve(1, false); got != 0 {
+		t.Errorf("Reserve after exhaustion = %d, want 0", got)
+	}
+}
+
+func TestRunBudget_MaxNewDocs(t *testing.T) {
+	b := NewRunBudget(0, 1)
+
+	if got := b.Reserve(10, true); got != 10 {
+		t.Errorf("first new doc Reserve = %d, want 10", got)
+	}
+	if !b.Exhausted() {
+		t.Error("expected budget to be exhausted after 1 new doc")
+	}
+	if got := b.Reserve(10, false); got != 0 {
+		t.Errorf("Reserve for existing doc after exhaustion = %d, want 0", got)
+	}
+}
+
+func TestRunBudget_ConcurrentReserve(t *testing.T) {
+	b := NewRunBudget(100, 0)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	total := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got := b.Reserve(7, false)
+			mu.Lock()
+			total += got
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if total != 100 {
+		t.Errorf("total reserved = %d, want exactly 100", total)
+	}
+}
+
+func TestOldestN(t *testing.T) {
+	msgs := []slackapi.Message{
+		{TS: "1706788803.000000"},
+		{TS: "1706788801.000000"},
+		{TS: "1706788802.000000"},
+	}
+	got := oldestN(msgs, 2)
+	if len(got) != 2 || got[0].TS != "1706788801.000000" || got[1].TS != "1706788802.000000" {
+		t.Errorf("oldestN = %+v, want the two oldest in ascending order", got)
+	}
+}
+
+func TestExportConversation_MaxNewDocsStopsAndCheckpoints(t *testing.T) {
+	msgs := []map[string]interface{}{
+		{"user": "U002", "text": "Day 2 message", "ts": "1706875200.000200"}, // 2024-02-02
+		{"user": "U001", "text": "Day 1 message", "ts": "1706788800.000100"}, // 2024-02-01
+	}
+
+	slackMux := fullMockSlackMux(t, msgs)
+	driveMux, _, _ := fullMockDriveMux(t)
+
+	exp := testExporter(t, driveMux, slackMux)
+	exp.docWriter = NewDocWriter(exp.gdriveClient, exp.slackClient, exp.userResolver, exp.channelResolver, nil, nil, nil)
+	exp.budget = NewRunBudget(0, 1)
+
+	conv := config.ConversationConfig{ID: "C001", Name: "general", Type: models.ConversationTypeChannel}
+
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.BudgetExhausted {
+		t.Error("expected BudgetExhausted = true")
+	}
+	if result.DocsCreated != 1 || result.MessageCount != 1 {
+		t.Errorf("got %d docs / %d msgs, want 1 / 1", result.DocsCreated, result.MessageCount)
+	}
+
+	convExport := exp.index.GetConversation("C001")
+	if convExport.Status != "in_progress" {
+		t.Errorf("Status = %q, want in_progress so the next run continues", convExport.Status)
+	}
+	if convExport.LastMessageTS != "1706788800.000100" {
+		t.Errorf("LastMessageTS = %q, want the newest written message 1706788800.000100", convExport.LastMessageTS)
+	}
+}
+
+func TestExportConversation_MaxMessagesSplitsDay(t *testing.T) {
+	msgs := []map[string]interface{}{
+		{"user": "U001", "text": "third", "ts": "1706788803.000000"},
+		{"user": "U001", "text": "second", "ts": "1706788802.000000"},
+		{"user": "U001", "text": "first", "ts": "1706788801.000000"},
+	}
+
+	slackMux := fullMockSlackMux(t, msgs)
+	driveMux, _, _ := fullMockDriveMux(t)
+
+	exp := testExporter(t, driveMux, slackMux)
+	exp.docWriter = NewDocWriter(exp.gdriveClient, exp.slackClient, exp.userResolver, exp.channelResolver, nil, nil, nil)
+	exp.budget = NewRunBudget(2, 0)
+
+	conv := config.ConversationConfig{ID: "C001", Name: "general", Type: models.ConversationTypeChannel}
+
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.MessageCount != 2 {
+		t.Errorf("MessageCount = %d, want 2", result.MessageCount)
+	}
+	if got := exp.index.GetConversation("C001").LastMessageTS; got != "1706788802.000000" {
+		t.Errorf("LastMessageTS = %q, want 1706788802.000000", got)
+	}
+}
+
+func TestExportAll_StopsWhenBudgetExhausted(t *testing.T) {
+	slackMux := fullMockSlackMux(t, nil) // 2 messages per conversation
+	driveMux, _, _ := fullMockDriveMux(t)
+
+	exp := testExporter(t, driveMux, slackMux)
+	exp.docWriter = NewDocWriter(exp.gdriveClient, exp.slackClient, exp.userResolver, exp.channelResolver, nil, nil, nil)
+	exp.budget = NewRunBudget(2, 0)
+
+	convs := []config.ConversationConfig{
+		{ID: "C001", Name: "first", Type: models.ConversationTypeChannel},
+		{ID: "C002", Name: "second", Type: models.ConversationTypeChannel},
+	}
+
+	results, err := exp.ExportAll(context.Background(), convs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result before the budget stopped the run, got %d", len(results))
+	}
+	if ce := exp.index.GetConversation("C002"); ce == nil || ce.Status != StatusPending {
+		t.Error("second conversation should have been queued but not started")
+	}
+}
@@ -0,0 +1,638 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/gdrive"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// CanvasesDir is the directory, inside a conversation's local directory,
+// holding the markdown copies of its canvases and posts.
+const CanvasesDir = "canvases"
+
+// isCanvasFile reports whether f is a Slack canvas or a legacy post, whose
+// content is a document rather than a file to download.
+func isCanvasFile(f slackapi.File) bool {
+	switch f.Filetype {
+	case "quip", "canvas", "space", "post":
+		return true
+	}
+	return false
+}
+
+// canvasKind names f's kind in doc titles: "Post" for legacy posts,
+// otherwise "Canvas".
+func canvasKind(f slackapi.File) string {
+	if f.Filetype == "space" || f.Filetype == "post" {
+		return "Post"
+	}
+	return "Canvas"
+}
+
+// CanvasDocTitle returns the title of the doc a canvas or post is exported
+// to: "Canvas - <title>" or "Post - <title>".
+func CanvasDocTitle(f slackapi.File) string {
+	return canvasKind(f) + " - " + orDefault(f.Title, orDefault(f.Name, f.ID))
+}
+
+// exportCanvases exports the canvases and posts shared in msgs, each as
+// its own doc in the conversation's Drive folder and, when the
+// conversation is written as markdown, as a file in its canvases
+// directory. Each is fetched with files.info and written again only when
+// it was edited since the last export; under legal hold a changed canvas
+// is appended to its doc and written to a new markdown part as a new
+// version instead of replacing the old one, and each version is recorded
+// in the hold ledger.
+// Failures are reported and counted without failing the export, and the
+// message keeps its [File: name] reference.
+func (e *Exporter) exportCanvases(ctx context.Context, conv config.ConversationConfig, msgs []slackapi.Message, result *ExportResult) {
+	convExport := e.index.GetConversation(conv.ID)
+	if convExport == nil {
+		return
+	}
+	writesDocs := conv.WritesDocs() && e.gdriveClient != nil && !e.digestOnly
+	writesMarkdown := conv.WritesMarkdown() && e.localExportDir != ""
+	if !writesDocs && !writesMarkdown {
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, msg := range msgs {
+		for _, f := range msg.Files {
+			if !isCanvasFile(f) || f.ID == "" || f.Mode == "tombstone" || seen[f.ID] {
+				continue
+			}
+			seen[f.ID] = true
+			if ctx.Err() != nil {
+				return
+			}
+			written, err := e.exportCanvas(ctx, conv, convExport, f, writesDocs, writesMarkdown, result)
+			if err != nil {
+				e.Progress("Warning: failed to export %s: %v", CanvasDocTitle(f), err)
+				result.CanvasErrors++
+				continue
+			}
+			if written {
+				result.CanvasesExported++
+			}
+		}
+	}
+}
+
+// exportCanvas exports one canvas or post and reports whether it was
+// written, which it is not when unchanged since the last export.
+func (e *Exporter) exportCanvas(ctx context.Context, conv config.ConversationConfig, convExport *ConversationExport, f slackapi.File, writesDocs, writesMarkdown bool, result *ExportResult) (bool, error) {
+	if info, err := e.slackClient.GetFileInfo(ctx, f.ID); err == nil {
+		f = *info
+	} else {
+		e.Detail("files.info failed for %s, using the message's copy: %v", f.ID, err)
+	}
+
+	convExport.mu.Lock()
+	exported := convExport.Canvases[f.ID]
+	var prev CanvasExport
+	if exported != nil {
+		prev = *exported
+	}
+	convExport.mu.Unlock()
+	current := exported != nil && f.Updated != 0 && prev.Updated >= f.Updated &&
+		(!writesDocs || prev.DocID != "") && (!writesMarkdown || prev.File != "")
+	if current {
+		return false, nil
+	}
+
+	blocks, err := e.fetchCanvas(ctx, f)
+	if err != nil {
+		return false, err
+	}
+
+	next := prev
+	next.Title = CanvasDocTitle(f)
+	next.Updated = f.Updated
+	meta := e.canvasMeta(f)
+	md := canvasMarkdown(next.Title, meta, blocks)
+	if writesDocs {
+		if err := e.writeCanvasDoc(ctx, convExport, &next, canvasMessageBlocks(next.Title, meta, blocks), result); err != nil {
+			return false, err
+		}
+	}
+	var file string
+	if writesMarkdown {
+		if file, err = e.writeCanvasMarkdown(ctx, conv, f, md); err != nil {
+			return false, err
+		}
+		next.File = orDefault(file, next.File)
+	}
+	if err := e.recordCanvasHold(conv.ID, f, md, file); err != nil {
+		return false, err
+	}
+
+	convExport.mu.Lock()
+	if convExport.Canvases == nil {
+		convExport.Canvases = make(map[string]*CanvasExport)
+	}
+	convExport.Canvases[f.ID] = &next
+	convExport.mu.Unlock()
+	if err := e.index.SaveConversation(conv.ID); err != nil {
+		e.Progress("Warning: failed to save index: %v", err)
+	}
+	return true, nil
+}
+
+// fetchCanvas returns the content of a canvas or post: a post's text from
+// files.info when Slack includes it, otherwise the downloaded HTML
+// document, converted to blocks.
+func (e *Exporter) fetchCanvas(ctx context.Context, f slackapi.File) ([]canvasBlock, error) {
+	if f.PlainText != "" {
+		return plainTextBlocks(f.PlainText), nil
+	}
+	if fileDownloadURL(f) == "" {
+		return nil, fmt.Errorf("no content to download")
+	}
+	data, err := e.fetchFile(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	return parseCanvasHTML(data)
+}
+
+// canvasMeta describes who created the canvas and when it was last edited.
+func (e *Exporter) canvasMeta(f slackapi.File) string {
+	meta := canvasKind(f)
+	if f.User != "" {
+		meta += " by " + e.userResolver.Resolve(f.User)
+	}
+	if edited := orDefaultInt64(f.Updated, f.Created); edited > 0 {
+		meta += ", last edited " + parser.FormatTime(time.Unix(edited, 0))
+	}
+	return meta
+}
+
+// writeCanvasDoc writes blocks to the canvas's doc in the conversation
+// folder, creating the doc on first export and otherwise replacing its
+// content (appending it under legal hold).
+func (e *Exporter) writeCanvasDoc(ctx context.Context, convExport *ConversationExport, canvas *CanvasExport, blocks []gdrive.MessageBlock, result *ExportResult) error {
+	if canvas.DocID == "" {
+		convExport.mu.Lock()
+		folderID := convExport.FolderID
+		convExport.mu.Unlock()
+		doc, err := e.gdriveClient.FindOrCreateDocument(ctx, canvas.Title, folderID)
+		if err != nil {
+			return fmt.Errorf("failed to create doc: %w", err)
+		}
+		canvas.DocID, canvas.DocURL = doc.ID, doc.URL
+		e.folderStructure.addItem(convExport, folderID)
+		result.DocsCreated++
+		return e.gdriveClient.BatchAppendMessages(ctx, canvas.DocID, blocks)
+	}
+	if e.legalHold {
+		return e.gdriveClient.BatchAppendMessages(ctx, canvas.DocID, blocks)
+	}
+	return e.gdriveClient.ReplaceDocumentContent(ctx, canvas.DocID, blocks)
+}
+
+// writeCanvasMarkdown writes the canvas's markdown to the conversation's
+// canvases directory and returns its path relative to the local export
+// directory, or "" when the sensitivity filter holds it back. Under legal
+// hold an existing file is left as it is and the version goes to a new
+// part beside it ({file ID}-{title}-2.md, -3.md, ...).
+func (e *Exporter) writeCanvasMarkdown(ctx context.Context, conv config.ConversationConfig, f slackapi.File, md string) (string, error) {
+	rel := CanvasMarkdownPath(string(conv.Type), conv.Name, f)
+	path := filepath.Join(e.localExportDir, rel)
+	if e.messageFilter != nil {
+		filtered, err := e.messageFilter.FilterMessages(ctx, []slackapi.Message{{User: f.User, Text: md, TS: unixTS(f.Created)}})
+		if err != nil {
+			return "", fmt.Errorf("sensitivity classification failed: %w", err)
+		}
+		if filtered.AllFiltered() {
+			e.Progress("%s filtered as sensitive — skipping markdown", CanvasDocTitle(f))
+			return "", nil
+		}
+	}
+	if e.legalHold {
+		name, err := WriteMarkdownPart(e.localExportDir, filepath.Dir(rel), strings.TrimSuffix(filepath.Base(rel), ".md"), []byte(md))
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(filepath.Dir(rel), name), nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := atomicWriteFile(filepath.Dir(path), path, []byte(md)); err != nil {
+		return "", err
+	}
+	return rel, nil
+}
+
+// recordCanvasHold records the version of canvas f just written, as md
+// and to file (relative to the local export directory, "" when no
+// markdown was written), in the hold ledger under the day it was last
+// edited.
+func (e *Exporter) recordCanvasHold(convID string, f slackapi.File, md, file string) error {
+	if e.holdLedger == nil {
+		return nil
+	}
+	content, err := e.holdFileContent(file)
+	if err != nil {
+		return err
+	}
+	ts := unixTS(orDefaultInt64(f.Updated, f.Created))
+	canvas := slackapi.Message{User: f.User, Text: md, TS: ts}
+	if _, err := e.holdLedger.RecordCanvas(convID, f.ID, DateFromTS(ts), canvas, file, content); err != nil {
+		return fmt.Errorf("failed to record legal hold entry for %s: %w", CanvasDocTitle(f), err)
+	}
+	return nil
+}
+
+// CanvasMarkdownPath returns the markdown file of a canvas or post,
+// relative to the local export directory:
+// {conversation}/canvases/{file ID}-{title}.md.
+func CanvasMarkdownPath(convType, convName string, f slackapi.File) string {
+	return filepath.Join(SanitizeDirectoryName(convType, convName), CanvasesDir, canvasFileName(f))
+}
+
+// canvasFileName returns the name of a canvas's markdown file.
+func canvasFileName(f slackapi.File) string {
+	return LocalFileName(slackapi.File{ID: f.ID, Name: orDefault(f.Title, f.Name)}) + ".md"
+}
+
+// orDefaultInt64 returns v, or def when v is zero.
+func orDefaultInt64(v, def int64) int64 {
+	if v == 0 {
+		return def
+	}
+	return v
+}
+
+// canvasBlockKind is the kind of a block of canvas content.
+type canvasBlockKind int
+
+const (
+	canvasParagraph canvasBlockKind = iota
+	canvasHeading
+	canvasBullet
+	canvasNumbered
+	canvasTodo
+	canvasDone
+	canvasQuote
+	canvasCode
+)
+
+// canvasBlock is a heading, paragraph, list item, quote or code block of a
+// canvas. level is a heading's level or a list item's nesting depth, and
+// number an ordered list item's position.
+type canvasBlock struct {
+	kind   canvasBlockKind
+	level  int
+	number int
+	runs   []canvasRun
+}
+
+// canvasRun is a span of text, linked to url when it is set.
+type canvasRun struct {
+	text string
+	url  string
+}
+
+// text returns the block's text without links.
+func (b canvasBlock) text() string {
+	var s strings.Builder
+	for _, r := range b.runs {
+		s.WriteString(r.text)
+	}
+	return s.String()
+}
+
+// markdown returns the block's text with links as markdown links.
+func (b canvasBlock) markdown() string {
+	var s strings.Builder
+	for _, r := range b.runs {
+		if text := strings.TrimSpace(r.text); r.url != "" && text != "" {
+			lead, trail := r.text[:strings.Index(r.text, text)], r.text[strings.Index(r.text, text)+len(text):]
+			fmt.Fprintf(&s, "%s[%s](%s)%s", lead, text, r.url, trail)
+		} else {
+			s.WriteString(r.text)
+		}
+	}
+	return s.String()
+}
+
+// listPrefix returns the marker of a list item, indented by its depth, in
+// the style of the Docs output or, with markdown, of markdown.
+func (b canvasBlock) listPrefix(markdown bool) string {
+	indent := strings.Repeat("  ", max(b.level-1, 0))
+	switch {
+	case b.kind == canvasNumbered:
+		return fmt.Sprintf("%s%d. ", indent, b.number)
+	case b.kind == canvasTodo && markdown:
+		return indent + "- [ ] "
+	case b.kind == canvasDone && markdown:
+		return indent + "- [x] "
+	case b.kind == canvasTodo:
+		return indent + "☐ "
+	case b.kind == canvasDone:
+		return indent + "☑ "
+	case markdown:
+		return indent + "- "
+	}
+	return indent + "• "
+}
+
+func (b canvasBlock) isListItem() bool {
+	switch b.kind {
+	case canvasBullet, canvasNumbered, canvasTodo, canvasDone:
+		return true
+	}
+	return false
+}
+
+// plainTextBlocks splits a post's plain text into paragraphs at blank
+// lines.
+func plainTextBlocks(text string) []canvasBlock {
+	var blocks []canvasBlock
+	for _, para := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		if para = strings.TrimSpace(para); para != "" {
+			blocks = append(blocks, canvasBlock{kind: canvasParagraph, runs: []canvasRun{{text: para}}})
+		}
+	}
+	return blocks
+}
+
+// parseCanvasHTML converts the HTML document Slack serves for a canvas into
+// blocks, keeping headings, lists, checklists, quotes, code, links and
+// line breaks, and table rows as " | "-separated lines.
+func parseCanvasHTML(data []byte) ([]canvasBlock, error) {
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse canvas: %w", err)
+	}
+	var p canvasParser
+	p.walk(doc)
+	p.flush()
+	return p.blocks, nil
+}
+
+// canvasParser walks a canvas's HTML, collecting text into the block being
+// built.
+type canvasParser struct {
+	blocks []canvasBlock
+	cur    *canvasBlock
+	lists  []canvasList
+	href   string
+	quote  int
+	cell   int
+	pre    bool
+}
+
+// canvasList is an open <ul> or <ol>, with the items seen so far.
+type canvasList struct {
+	ordered   bool
+	checklist bool
+	items     int
+}
+
+func (p *canvasParser) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		p.text(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		p.children(n)
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Head, atom.Script, atom.Style, atom.Title:
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		p.start(canvasBlock{kind: canvasHeading, level: int(n.Data[1] - '0')})
+		p.children(n)
+		p.flush()
+	case atom.P, atom.Div:
+		if p.cell > 0 {
+			p.children(n)
+			return
+		}
+		p.flush()
+		p.children(n)
+		p.flush()
+	case atom.Blockquote:
+		p.flush()
+		p.quote++
+		p.children(n)
+		p.flush()
+		p.quote--
+	case atom.Pre:
+		p.start(canvasBlock{kind: canvasCode})
+		p.pre = true
+		p.children(n)
+		p.pre = false
+		p.flush()
+	case atom.Ul, atom.Ol:
+		p.flush()
+		p.lists = append(p.lists, canvasList{
+			ordered:   n.DataAtom == atom.Ol,
+			checklist: hasClass(n, "checklist"),
+		})
+		p.children(n)
+		p.flush()
+		p.lists = p.lists[:len(p.lists)-1]
+	case atom.Li:
+		block := canvasBlock{kind: canvasBullet, level: max(len(p.lists), 1)}
+		if len(p.lists) > 0 {
+			list := &p.lists[len(p.lists)-1]
+			list.items++
+			switch {
+			case list.checklist && hasClass(n, "checked"):
+				block.kind = canvasDone
+			case list.checklist:
+				block.kind = canvasTodo
+			case list.ordered:
+				block.kind, block.number = canvasNumbered, list.items
+			}
+		}
+		p.start(block)
+		p.children(n)
+		p.flush()
+	case atom.Tr:
+		p.start(canvasBlock{kind: canvasParagraph})
+		p.children(n)
+		p.flush()
+	case atom.Td, atom.Th:
+		if p.cur != nil && len(p.cur.runs) > 0 {
+			p.cur.runs = append(p.cur.runs, canvasRun{text: " | "})
+		}
+		p.cell++
+		p.children(n)
+		p.cell--
+	case atom.Br:
+		if p.cur != nil {
+			p.cur.runs = append(p.cur.runs, canvasRun{text: "\n"})
+		}
+	case atom.A:
+		href := p.href
+		p.href = attr(n, "href")
+		p.children(n)
+		p.href = href
+	default:
+		p.children(n)
+	}
+}
+
+func (p *canvasParser) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		p.walk(c)
+	}
+}
+
+// start begins a new block, ending the current one. Paragraphs inside a
+// blockquote become quotes.
+func (p *canvasParser) start(b canvasBlock) {
+	p.flush()
+	if b.kind == canvasParagraph && p.quote > 0 {
+		b.kind = canvasQuote
+	}
+	p.cur = &b
+}
+
+// text adds text to the current block, collapsing whitespace outside code.
+func (p *canvasParser) text(s string) {
+	if !p.pre {
+		words := strings.Join(strings.Fields(s), " ")
+		if words == "" {
+			if p.cur != nil && len(p.cur.runs) > 0 {
+				p.cur.runs = append(p.cur.runs, canvasRun{text: " "})
+			}
+			return
+		}
+		if unicode.IsSpace(rune(s[0])) {
+			words = " " + words
+		}
+		if unicode.IsSpace(rune(s[len(s)-1])) {
+			words += " "
+		}
+		s = words
+		if p.cur != nil && len(p.cur.runs) > 0 {
+			if last := p.cur.runs[len(p.cur.runs)-1].text; strings.HasSuffix(last, " ") || strings.HasSuffix(last, "\n") {
+				s = strings.TrimLeft(s, " ")
+			}
+		}
+	}
+	if p.cur == nil {
+		p.start(canvasBlock{kind: canvasParagraph})
+	}
+	p.cur.runs = append(p.cur.runs, canvasRun{text: s, url: p.href})
+}
+
+// flush ends the current block, dropping it when it holds no text.
+func (p *canvasParser) flush() {
+	if p.cur == nil {
+		return
+	}
+	b := *p.cur
+	p.cur = nil
+	if b.kind != canvasCode {
+		if n := len(b.runs); n > 0 {
+			b.runs[0].text = strings.TrimLeft(b.runs[0].text, " ")
+			b.runs[n-1].text = strings.TrimRight(b.runs[n-1].text, " ")
+		}
+	}
+	if strings.TrimSpace(b.text()) == "" {
+		return
+	}
+	p.blocks = append(p.blocks, b)
+}
+
+// hasClass reports whether n's class attribute includes class.
+func hasClass(n *html.Node, class string) bool {
+	for _, c := range strings.Fields(attr(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// attr returns the value of n's attribute key, or "".
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// canvasMessageBlocks lays out a canvas for its doc: a block headed by the
+// title and meta holding the content before the first heading, then one
+// block per heading holding the content under it.
+func canvasMessageBlocks(title, meta string, blocks []canvasBlock) []gdrive.MessageBlock {
+	out := []gdrive.MessageBlock{{SenderName: title, Timestamp: meta}}
+	var lines []string
+	flush := func() {
+		out[len(out)-1].Content = strings.Join(lines, "\n")
+		lines = nil
+	}
+	for _, b := range blocks {
+		if b.kind == canvasHeading {
+			flush()
+			out = append(out, gdrive.MessageBlock{SenderName: b.text()})
+			continue
+		}
+		line := b.text()
+		switch {
+		case b.isListItem():
+			line = b.listPrefix(false) + line
+		case b.kind == canvasQuote:
+			line = "> " + strings.ReplaceAll(line, "\n", "\n> ")
+		}
+		lines = append(lines, line)
+		for _, r := range b.runs {
+			if text := strings.TrimSpace(r.text); r.url != "" && text != "" {
+				out[len(out)-1].Links = append(out[len(out)-1].Links, gdrive.LinkAnnotation{Text: text, URL: r.url})
+			}
+		}
+	}
+	flush()
+	return out
+}
+
+// canvasMarkdown renders a canvas as a markdown document titled title.
+func canvasMarkdown(title, meta string, blocks []canvasBlock) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n_%s_\n", title, meta)
+	for i, block := range blocks {
+		// List items follow each other directly; other blocks are
+		// separated by a blank line.
+		if i == 0 || !block.isListItem() || !blocks[i-1].isListItem() {
+			b.WriteString("\n")
+		}
+		switch {
+		case block.kind == canvasHeading:
+			fmt.Fprintf(&b, "%s %s\n", strings.Repeat("#", min(block.level+1, 6)), block.text())
+		case block.isListItem():
+			fmt.Fprintf(&b, "%s%s\n", block.listPrefix(true), block.markdown())
+		case block.kind == canvasQuote:
+			fmt.Fprintf(&b, "> %s\n", strings.ReplaceAll(block.markdown(), "\n", "\n> "))
+		case block.kind == canvasCode:
+			fmt.Fprintf(&b, "```\n%s\n```\n", strings.Trim(block.text(), "\n"))
+		default:
+			fmt.Fprintf(&b, "%s\n", block.markdown())
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,272 @@
+package exporter
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/internal/testutil"
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+const testCanvasHTML = `<html><head><title>Launch plan</title></head><body>
+<h1>Goals</h1>
+<p>Ship the <b>beta</b> by March. See <a href="https://example.com/spec">the spec</a>.</p>
+<ul><li>Docs<ul><li>API reference</li></ul></li><li>Pricing</li></ul>
+<ol><li>Freeze</li><li>Release</li></ol>
+<ul class="checklist"><li class="checked">Kickoff</li><li>Retro</li></ul>
+<h2>Notes</h2>
+<blockquote><p>Move fast</p></blockquote>
+<pre>make release
+make announce</pre>
+<table><tr><td>Owner</td><td>Alice</td></tr></table>
+</body></html>`
+
+// withCanvas shares a canvas in the first message of fakeConversation.
+func withCanvas(slack *testutil.FakeSlack) slackapi.File {
+	f := slackapi.File{
+		ID:                 "F0CANVAS",
+		Title:              "Launch plan",
+		Filetype:           "quip",
+		User:               "U001",
+		Updated:            1706788800,
+		URLPrivateDownload: "https://files.slack.com/F0CANVAS/download",
+		Permalink:          "https://acme.slack.com/docs/T001/F0CANVAS",
+	}
+	slack.Files[f.URLPrivateDownload] = []byte(testCanvasHTML)
+	slack.FileInfo = map[string]slackapi.File{f.ID: f}
+	slack.Messages["C001"][0].Files = []slackapi.File{f}
+	return f
+}
+
+func TestParseCanvasHTML(t *testing.T) {
+	blocks, err := parseCanvasHTML([]byte(testCanvasHTML))
+	if err != nil {
+		t.Fatalf("parseCanvasHTML() error: %v", err)
+	}
+	want := []string{
+		"Goals",
+		"Ship the beta by March. See the spec.",
+		"Docs", "API reference", "Pricing",
+		"Freeze", "Release",
+		"Kickoff", "Retro",
+		"Notes",
+		"Move fast",
+		"make release\nmake announce",
+		"Owner | Alice",
+	}
+	var got []string
+	for _, b := range blocks {
+		got = append(got, b.text())
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("blocks = %q\nwant     %q", got, want)
+	}
+	if blocks[3].kind != canvasBullet || blocks[3].level != 2 {
+		t.Errorf("nested item = %+v, want a bullet at depth 2", blocks[3])
+	}
+	if blocks[6].kind != canvasNumbered || blocks[6].number != 2 {
+		t.Errorf("ordered item = %+v, want item 2", blocks[6])
+	}
+	if blocks[7].kind != canvasDone || blocks[8].kind != canvasTodo {
+		t.Errorf("checklist kinds = %v, %v; want done, todo", blocks[7].kind, blocks[8].kind)
+	}
+	if blocks[10].kind != canvasQuote || blocks[11].kind != canvasCode {
+		t.Errorf("kinds = %v, %v; want quote, code", blocks[10].kind, blocks[11].kind)
+	}
+}
+
+func TestCanvasMarkdown(t *testing.T) {
+	blocks, err := parseCanvasHTML([]byte(testCanvasHTML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := canvasMarkdown("Canvas - Launch plan", "Canvas by alice", blocks)
+	for _, want := range []string{
+		"# Canvas - Launch plan\n\n_Canvas by alice_\n\n## Goals\n",
+		"See [the spec](https://example.com/spec).\n",
+		"\n- Docs\n  - API reference\n- Pricing\n",
+		"\n1. Freeze\n2. Release\n",
+		"\n- [x] Kickoff\n- [ ] Retro\n",
+		"\n### Notes\n",
+		"\n> Move fast\n",
+		"\n```\nmake release\nmake announce\n```\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestCanvasMessageBlocks(t *testing.T) {
+	blocks, err := parseCanvasHTML([]byte(`<p>Intro</p><h1>Goals</h1><ul><li><a href="https://example.com">Spec</a></li></ul>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := canvasMessageBlocks("Canvas - Plan", "Canvas by alice", blocks)
+	if len(out) != 2 {
+		t.Fatalf("got %d blocks, want the title block and one section", len(out))
+	}
+	if out[0].SenderName != "Canvas - Plan" || out[0].Timestamp != "Canvas by alice" || out[0].Content != "Intro" {
+		t.Errorf("title block = %+v", out[0])
+	}
+	if out[1].SenderName != "Goals" || out[1].Content != "• Spec" || len(out[1].Links) != 1 || out[1].Links[0].Text != "Spec" {
+		t.Errorf("section = %+v, want the heading, a bullet and its link", out[1])
+	}
+}
+
+func TestExportConversation_CanvasDoc(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	withCanvas(slack)
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.docWriter.SetFileLinker(exp.linkFile)
+
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	if result.CanvasesExported != 1 || result.CanvasErrors != 0 {
+		t.Errorf("CanvasesExported = %d, CanvasErrors = %d; want 1 and 0", result.CanvasesExported, result.CanvasErrors)
+	}
+
+	convExport := exp.index.GetConversation("C001")
+	canvas := convExport.Canvases["F0CANVAS"]
+	if canvas == nil || canvas.Title != "Canvas - Launch plan" || canvas.Updated != 1706788800 {
+		t.Fatalf("index canvas = %+v", canvas)
+	}
+	if got := drive.DocumentFolder(canvas.DocID); got != convExport.FolderID {
+		t.Errorf("canvas doc folder = %q, want the conversation folder %q", got, convExport.FolderID)
+	}
+	if content, _ := drive.GetDocumentContent(context.Background(), canvas.DocID); !strings.Contains(content, "Goals") || !strings.Contains(content, "• Docs") {
+		t.Errorf("canvas doc content:\n%s", content)
+	}
+
+	var linked bool
+	for _, batch := range drive.Appended(convExport.DailyDocs["2024-02-01"].DocID) {
+		for _, b := range batch {
+			for _, l := range b.Links {
+				linked = linked || l.URL == canvas.DocURL
+			}
+		}
+	}
+	if !linked {
+		t.Error("the daily doc does not link the canvas reference to the canvas doc")
+	}
+
+	// An unchanged canvas is not downloaded again; an edited one replaces
+	// the doc's content.
+	exp.exportCanvases(context.Background(), conv, slack.Messages["C001"], result)
+	if n := slack.Calls("DownloadFile"); n != 1 {
+		t.Errorf("DownloadFile calls = %d after an unchanged canvas, want 1", n)
+	}
+	f := slack.FileInfo["F0CANVAS"]
+	f.Updated++
+	slack.FileInfo["F0CANVAS"] = f
+	exp.exportCanvases(context.Background(), conv, slack.Messages["C001"], result)
+	if n := drive.Calls("ReplaceDocumentContent"); n != 1 {
+		t.Errorf("ReplaceDocumentContent calls = %d after an edit, want 1", n)
+	}
+}
+
+func TestExportConversation_CanvasMarkdown(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	conv.Format = config.OutputFormatMarkdown
+	f := withCanvas(slack)
+	exp, localDir := localFormatExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	if result.CanvasesExported != 1 {
+		t.Errorf("CanvasesExported = %d, want 1", result.CanvasesExported)
+	}
+
+	rel := CanvasMarkdownPath(string(conv.Type), conv.Name, f)
+	if got := exp.index.GetConversation("C001").Canvases[f.ID].File; got != rel {
+		t.Errorf("index file = %q, want %q", got, rel)
+	}
+	if md := readFile(t, filepath.Join(localDir, rel)); !strings.HasPrefix(md, "# Canvas - Launch plan\n") {
+		t.Errorf("canvas markdown:\n%s", md)
+	}
+	dir := filepath.Join(localDir, SanitizeDirectoryName(string(conv.Type), conv.Name))
+	if day := readFile(t, filepath.Join(dir, "2024-02-01.md")); !strings.Contains(day, "(canvases/F0CANVAS-Launch-plan.md)") {
+		t.Errorf("day file does not link the canvas markdown:\n%s", day)
+	}
+}
+
+func TestExportCanvases_LegalHoldWritesNewVersion(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	conv.LocalExport = true
+	f := withCanvas(slack)
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.localExportDir = t.TempDir()
+	exp.mdWriter = NewMarkdownWriter(exp.userResolver, exp.channelResolver, nil)
+	exp.legalHold = true
+	exp.holdLedger = NewHoldLedger(DefaultHoldDir(exp.configDir))
+
+	ctx := context.Background()
+	result := &ExportResult{}
+	if _, err := exp.backendFor(conv).EnsureConversationContainer(ctx, conv); err != nil {
+		t.Fatal(err)
+	}
+	exp.exportCanvases(ctx, conv, slack.Messages["C001"], result)
+	rel := CanvasMarkdownPath(string(conv.Type), conv.Name, f)
+	original := readFile(t, filepath.Join(exp.localExportDir, rel))
+
+	// An edited canvas goes to a new part, and to the end of its doc.
+	f.Updated += 60
+	slack.FileInfo[f.ID] = f
+	slack.Files[f.URLPrivateDownload] = []byte(strings.Replace(testCanvasHTML, "Goals", "Revised goals", 1))
+	exp.exportCanvases(ctx, conv, slack.Messages["C001"], result)
+
+	if got := readFile(t, filepath.Join(exp.localExportDir, rel)); got != original {
+		t.Error("legal hold modified the canvas's earlier markdown")
+	}
+	part := strings.TrimSuffix(rel, ".md") + "-2.md"
+	if md := readFile(t, filepath.Join(exp.localExportDir, part)); !strings.Contains(md, "Revised goals") {
+		t.Errorf("new version:\n%s", md)
+	}
+	if got := exp.index.GetConversation("C001").Canvases[f.ID].File; got != part {
+		t.Errorf("index file = %q, want the new part %q", got, part)
+	}
+	if n := drive.Calls("ReplaceDocumentContent"); n != 0 {
+		t.Errorf("ReplaceDocumentContent calls = %d, want the doc appended to", n)
+	}
+
+	entries, err := loadHoldEntries(HoldLedgerPath(DefaultHoldDir(exp.configDir), "C001"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[1].CanvasID != f.ID || filepath.FromSlash(entries[1].File) != part {
+		t.Fatalf("hold entries = %+v, want one per canvas version", entries)
+	}
+	report, _ := VerifyHold(DefaultHoldDir(exp.configDir), exp.localExportDir, nil)
+	if !report.OK() {
+		t.Errorf("VerifyHold() problems: %v", report.Problems)
+	}
+}
+
+func TestExportConversation_CanvasFailure(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	f := withCanvas(slack)
+	delete(slack.Files, f.URLPrivateDownload)
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("ExportConversation() error = %v, want a failed canvas not to fail the export", err)
+	}
+	if result.CanvasErrors != 1 || result.CanvasesExported != 0 {
+		t.Errorf("CanvasErrors = %d, CanvasesExported = %d; want 1 and 0", result.CanvasErrors, result.CanvasesExported)
+	}
+}
+
+func TestPlainTextBlocks(t *testing.T) {
+	blocks := plainTextBlocks("First para\nsame para\r\n\r\n\nSecond")
+	if len(blocks) != 2 || blocks[0].text() != "First para\nsame para" || blocks[1].text() != "Second" {
+		t.Errorf("plainTextBlocks() = %+v", blocks)
+	}
+}
@@ -0,0 +1,268 @@
+package exporter
+
+import (
+	"context"
+
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// restrictedFallbacks describes, for each Slack method the export depends
+// on, what it does instead when the workspace restricts that method.
+var restrictedFallbacks = map[string]string{
+	slackapi.MethodConversationsMembers: "users will be looked up from message authors",
+	slackapi.MethodUsersInfo:            "the full user list will be loaded instead",
+	slackapi.MethodUsersList:            "users will be looked up one at a time",
+	slackapi.MethodConversationsReplies: "thread replies will be skipped",
+}
+
+// recordingSlack passes calls through to a SlackSource and records each
+// outcome in the exporter's capability matrix.
+type recordingSlack struct {
+	SlackSource
+	caps *slackapi.Capabilities
+}
+
+func (s recordingSlack) GetUsers(ctx context.Context, cursor string) (*slackapi.UsersListResponse, error) {
+	resp, err := s.SlackSource.GetUsers(ctx, cursor)
+	s.caps.Record(slackapi.MethodUsersList, err)
+	return resp, err
+}
+
+func (s recordingSlack) GetUserInfo(ctx context.Context, userID string) (*slackapi.User, error) {
+	user, err := s.SlackSource.GetUserInfo(ctx, userID)
+	s.caps.Record(slackapi.MethodUsersInfo, err)
+	return user, err
+}
+
+func (s recordingSlack) GetConversationMembers(ctx context.Context, channelID, cursor string) (*slackapi.MembersResponse, error) {
+	resp, err := s.SlackSource.GetConversationMembers(ctx, channelID, cursor)
+	s.caps.Record(slackapi.MethodConversationsMembers, err)
+	return resp, err
+}
+
+func (s recordingSlack) GetConversationInfo(ctx context.Context, channelID string) (*slackapi.Conversation, error) {
+	conv, err := s.SlackSource.GetConversationInfo(ctx, channelID)
+	s.caps.Record(slackapi.MethodConversationsInfo, err)
+	return conv, err
+}
+
+func (s recordingSlack) ListConversations(ctx context.Context, opts *slackapi.ListConversationsOptions) (*slackapi.ConversationsListResponse, error) {
+	resp, err := s.SlackSource.ListConversations(ctx, opts)
+	s.caps.Record(slackapi.MethodConversationsList, err)
+	return resp, err
+}
+
+func (s recordingSlack) GetAllReplies(ctx context.Context, channelID, threadTS string, callback func([]slackapi.Message) error) error {
+	err := s.SlackSource.GetAllReplies(ctx, channelID, threadTS, callback)
+	s.caps.Record(slackapi.MethodConversationsReplies, err)
+	return err
+}
+
+// probeAccess builds the capability matrix with slackapi.TestAccess, reports
+// restricted methods and their fallbacks, and routes later Slack calls
+// through the matrix so restrictions found mid-export are recorded too.
+func (e *Exporter) probeAccess(ctx context.Context) {
+	if _, ok := e.slackClient.(recordingSlack); ok {
+		return
+	}
+	e.capabilities = slackapi.TestAccess(ctx, e.slackClient, "")
+	for _, method := range e.capabilities.Restricted() {
+		if fallback, ok := restrictedFallbacks[method]; ok {
+			e.Progress("Slack %s is restricted in this workspace (%s); %s", method, e.capabilities.Reason(method), fallback)
+		}
+	}
+	e.slackClient = recordingSlack{SlackSource: e.slackClient, caps: e.capabilities}
+}
+
+// loadUsersWithFallback loads the users needed up front for channelIDs.
+// By default that is none: loadMessageAuthors looks users up as messages
+// reference them. With prefetchUsers, conversation members are looked up
+// via users.info when the capability matrix allows it. Only when users.info
+// is restricted, so users cannot be looked up one by one, is the full
+// users.list loaded.
+func (e *Exporter) loadUsersWithFallback(ctx context.Context, channelIDs []string) error {
+	caps := e.capabilities
+	if e.prefetchUsers && caps.Usable(slackapi.MethodConversationsMembers) && caps.Usable(slackapi.MethodUsersInfo) {
+		e.Progress("Loading users from %d conversations...", len(channelIDs))
+		if err := e.userResolver.LoadUsersForConversations(ctx, e.slackClient, channelIDs, func(id string, count int) {
+			if count < 0 {
+				e.Detail("Could not access members for %s (will resolve on-the-fly)", id)
+			} else if id == "users" {
+				e.Detail("Fetched %d user profiles...", count)
+			} else {
+				e.Detail("Found %d unique members so far...", count)
+			}
+		}); err != nil {
+			return err
+		}
+	}
+
+	if !caps.Usable(slackapi.MethodUsersInfo) && caps.Usable(slackapi.MethodUsersList) {
+		e.Progress("users.info is restricted; loading the full user list")
+		if err := e.userResolver.LoadUsers(ctx, e.slackClient); err != nil {
+			if !slackapi.IsRestrictedError(err) {
+				return err
+			}
+			e.Progress("Warning: users.list is restricted too; users will be shown by ID")
+		}
+	}
+	return nil
+}
+
+// loadMessageAuthors looks up the authors and @-mentioned users of messages
+// that are not cached yet. This is how users are loaded unless members are
+// prefetched (see loadUsersWithFallback).
+func (e *Exporter) loadMessageAuthors(ctx context.Context, messages []slackapi.Message) {
+	if !e.capabilities.Usable(slackapi.MethodUsersInfo) {
+		return
+	}
+	var ids []string
+	seen := make(map[string]bool)
+	add := func(id string) {
+		if id == "" || seen[id] {
+			return
+		}
+		seen[id] = true
+		if e.userResolver.GetUser(id) == nil {
+			ids = append(ids, id)
+		}
+	}
+	for _, m := range messages {
+		add(m.User)
+		for _, id := range parser.ExtractUserMentions(parser.MessageText(m)) {
+			add(id)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+	e.Detail("Looking up %d users from messages...", len(ids))
+	if err := e.userResolver.LoadUsersByID(ctx, e.slackClient, ids); err != nil {
+		e.Progress("Warning: failed to look up message authors: %v", err)
+	}
+}
+
+// loadMentionedChannels looks up channels #-mentioned by ID only that the
+// channel resolver does not know, via conversations.info. A channel that
+// cannot be looked up is remembered by its ID, so it is tried only once.
+func (e *Exporter) loadMentionedChannels(ctx context.Context, messages []slackapi.Message) {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, m := range messages {
+		for _, id := range parser.ExtractUnnamedChannelMentions(parser.MessageText(m)) {
+			if !seen[id] && !e.channelResolver.Has(id) {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+	e.Detail("Looking up %d mentioned channels...", len(ids))
+	for _, id := range ids {
+		if !e.capabilities.Usable(slackapi.MethodConversationsInfo) || ctx.Err() != nil {
+			return
+		}
+		name := id
+		if conv, err := e.slackClient.GetConversationInfo(ctx, id); err == nil && conv.Name != "" {
+			name = conv.Name
+		}
+		e.channelResolver.AddChannel(id, name)
+	}
+}
+
+// FeatureStatus is whether an export feature works with a capability matrix.
+type FeatureStatus int
+
+const (
+	// FeatureUntested means a method the feature needs was not probed.
+	FeatureUntested FeatureStatus = iota
+	// FeatureAvailable means every method the feature needs is allowed.
+	FeatureAvailable
+	// FeatureDegraded means the feature works through a fallback.
+	FeatureDegraded
+	// FeatureUnavailable means the feature is skipped in this workspace.
+	FeatureUnavailable
+)
+
+// String returns a short label for the status.
+func (s FeatureStatus) String() string {
+	switch s {
+	case FeatureAvailable:
+		return "works"
+	case FeatureDegraded:
+		return "degraded"
+	case FeatureUnavailable:
+		return "unavailable"
+	default:
+		return "untested"
+	}
+}
+
+// Feature is an export feature and the Slack methods it relies on.
+type Feature struct {
+	Name      string
+	Methods   []string
+	Fallbacks []FeatureFallback // tried in order when a method is restricted
+}
+
+// FeatureFallback is an alternative strategy for a Feature. A fallback with
+// no Methods always applies.
+type FeatureFallback struct {
+	Methods []string
+	Note    string
+}
+
+// ExportFeatures lists the export features that depend on Slack API access.
+var ExportFeatures = []Feature{
+	{Name: "Session validation", Methods: []string{slackapi.MethodAuthTest}},
+	{Name: "Conversation messages", Methods: []string{slackapi.MethodConversationsHistory}},
+	{Name: "Thread replies", Methods: []string{slackapi.MethodConversationsReplies},
+		Fallbacks: []FeatureFallback{{Note: "main messages are exported without their threads"}}},
+	{Name: "User names", Methods: []string{slackapi.MethodConversationsMembers, slackapi.MethodUsersInfo},
+		Fallbacks: []FeatureFallback{
+			{Methods: []string{slackapi.MethodUsersInfo}, Note: "message authors are looked up one at a time"},
+			{Methods: []string{slackapi.MethodUsersList}, Note: "the full user list is loaded"},
+		}},
+	{Name: "Channel details", Methods: []string{slackapi.MethodConversationsInfo, slackapi.MethodConversationsList},
+		Fallbacks: []FeatureFallback{{Note: "names come from conversations.json"}}},
+	{Name: "Images and file attachments", Methods: []string{slackapi.MethodFilesList},
+		Fallbacks: []FeatureFallback{{Note: "files are listed by name instead of embedded"}}},
+	{Name: "Search", Methods: []string{slackapi.MethodSearchMessages}},
+	{Name: "Custom emoji", Methods: []string{slackapi.MethodEmojiList},
+		Fallbacks: []FeatureFallback{{Note: "custom emoji appear as :name:"}}},
+}
+
+// Evaluate reports the feature's status under caps, and the fallback note
+// when it is degraded.
+func (f Feature) Evaluate(caps *slackapi.Capabilities) (FeatureStatus, string) {
+	restricted, untested := false, false
+	for _, m := range f.Methods {
+		switch caps.Access(m) {
+		case slackapi.AccessRestricted:
+			restricted = true
+		case slackapi.AccessUnknown:
+			untested = true
+		}
+	}
+	if !restricted {
+		if untested {
+			return FeatureUntested, ""
+		}
+		return FeatureAvailable, ""
+	}
+	for _, fb := range f.Fallbacks {
+		usable := true
+		for _, m := range fb.Methods {
+			if !caps.Usable(m) {
+				usable = false
+			}
+		}
+		if usable {
+			return FeatureDegraded, fb.Note
+		}
+	}
+	return FeatureUnavailable, ""
+}
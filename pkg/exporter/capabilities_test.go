@@ -0,0 +1,230 @@
+package exporter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+var errMissingScope = &slackapi.APIError{Code: slackapi.ErrCodeMissingScope}
+
+func TestLoadUsers_MembersRestrictedFallsBackToAuthors(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	slack.Users = []slackapi.User{{ID: "U001", Name: "alice"}, {ID: "U002", Name: "bob"}}
+	slack.Errors["GetConversationMembers"] = errMissingScope
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.prefetchUsers = true
+	ctx := context.Background()
+
+	if err := exp.ValidateConnections(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := exp.LoadUsersForConversations(ctx, []string{"C001"}); err != nil {
+		t.Fatalf("LoadUsersForConversations() error: %v", err)
+	}
+	if exp.capabilities.Usable(slackapi.MethodConversationsMembers) {
+		t.Fatal("conversations.members should be recorded as restricted")
+	}
+	before := slack.Calls("GetUserInfo")
+	if _, err := exp.ExportConversation(ctx, conv); err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	for _, id := range []string{"U001", "U002"} {
+		if exp.userResolver.GetUser(id) == nil {
+			t.Errorf("author %s should be resolved via users.info", id)
+		}
+	}
+	if n := slack.Calls("GetUserInfo") - before; n != 2 {
+		t.Errorf("users.info calls = %d, want one per author", n)
+	}
+}
+
+func TestLoadUsers_LazyByDefault(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	slack.Users = []slackapi.User{{ID: "U001", Name: "alice"}, {ID: "U002", Name: "bob"}, {ID: "U003", Name: "carol"}}
+	slack.Members["C001"] = []string{"U001", "U002", "U003"}
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	ctx := context.Background()
+
+	if err := exp.ValidateConnections(ctx); err != nil {
+		t.Fatal(err)
+	}
+	before := slack.Calls("GetConversationMembers") + slack.Calls("GetUserInfo")
+	if err := exp.LoadUsersForConversations(ctx, []string{"C001"}); err != nil {
+		t.Fatalf("LoadUsersForConversations() error: %v", err)
+	}
+	if n := slack.Calls("GetConversationMembers") + slack.Calls("GetUserInfo") - before; n != 0 {
+		t.Errorf("Slack calls before the export = %d, want users left to be looked up lazily", n)
+	}
+	if _, err := exp.ExportConversation(ctx, conv); err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	if exp.userResolver.GetUser("U003") != nil {
+		t.Error("a member who neither posted nor was mentioned was fetched")
+	}
+	if exp.userResolver.GetUser("U001") == nil || exp.userResolver.GetUser("U002") == nil {
+		t.Error("authors should be looked up as their messages are exported")
+	}
+}
+
+func TestLoadUsers_InfoRestrictedFallsBackToList(t *testing.T) {
+	drive, slack, _ := fakeConversation()
+	slack.Users = []slackapi.User{{ID: "U001", Name: "alice"}}
+	slack.Members["C001"] = []string{"U001"}
+	slack.Errors["GetUserInfo"] = errMissingScope
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	ctx := context.Background()
+
+	if err := exp.ValidateConnections(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := exp.LoadUsersForConversations(ctx, []string{"C001"}); err != nil {
+		t.Fatalf("LoadUsersForConversations() error: %v", err)
+	}
+	if exp.userResolver.GetUser("U001") == nil {
+		t.Error("U001 should be loaded from users.list")
+	}
+	if n := slack.Calls("GetUsers"); n != 2 {
+		t.Errorf("users.list calls = %d, want probe plus full load", n)
+	}
+}
+
+func TestExportThreads_RepliesRestricted(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	slack.Messages["C001"] = append(slack.Messages["C001"], slackapi.Message{
+		User: "U001", Text: "Second thread", TS: "1706796000.000600", ThreadTS: "1706796000.000600", ReplyCount: 2,
+	})
+	slack.Errors["GetAllReplies"] = errMissingScope
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	ctx := context.Background()
+
+	if err := exp.ValidateConnections(ctx); err != nil {
+		t.Fatal(err)
+	}
+	result, err := exp.ExportConversation(ctx, conv)
+	if err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	if n := slack.Calls("GetAllReplies"); n != 1 {
+		t.Errorf("conversations.replies calls = %d, want 1 (stop after the restriction)", n)
+	}
+	if result.DocsCreated != 2 {
+		t.Errorf("DocsCreated = %d, want daily docs written despite restricted replies", result.DocsCreated)
+	}
+
+	// A second conversation skips threads without calling the method.
+	if got := exp.exportThreads(ctx, conv, slack.Messages["C001"], result); got != 0 {
+		t.Errorf("exportThreads() = %d, want 0 once replies are restricted", got)
+	}
+	if n := slack.Calls("GetAllReplies"); n != 1 {
+		t.Errorf("conversations.replies calls = %d after restriction is known, want 1", n)
+	}
+}
+
+func TestFeatureEvaluate(t *testing.T) {
+	users := ExportFeatures[3]
+	if users.Name != "User names" {
+		t.Fatalf("ExportFeatures[3] = %q", users.Name)
+	}
+
+	tests := []struct {
+		name       string
+		allowed    []string
+		restricted []string
+		want       FeatureStatus
+		wantNote   string
+	}{
+		{"untested", nil, nil, FeatureUntested, ""},
+		{"all allowed", []string{slackapi.MethodConversationsMembers, slackapi.MethodUsersInfo}, nil, FeatureAvailable, ""},
+		{"members restricted", []string{slackapi.MethodUsersInfo}, []string{slackapi.MethodConversationsMembers}, FeatureDegraded, "message authors are looked up one at a time"},
+		{"info restricted", []string{slackapi.MethodConversationsMembers, slackapi.MethodUsersList}, []string{slackapi.MethodUsersInfo}, FeatureDegraded, "the full user list is loaded"},
+		{"no way to load users", nil, []string{slackapi.MethodUsersInfo, slackapi.MethodUsersList}, FeatureUnavailable, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			caps := slackapi.NewCapabilities()
+			for _, m := range tt.allowed {
+				caps.Record(m, nil)
+			}
+			for _, m := range tt.restricted {
+				caps.Record(m, errMissingScope)
+			}
+			got, note := users.Evaluate(caps)
+			if got != tt.want || note != tt.wantNote {
+				t.Errorf("Evaluate() = %v, %q; want %v, %q", got, note, tt.want, tt.wantNote)
+			}
+		})
+	}
+}
+
+func TestLoadMentionedChannels(t *testing.T) {
+	drive, slack, _ := fakeConversation()
+	slack.Channels["C100"] = "design"
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.channelResolver.AddChannel("C200", "known")
+	ctx := context.Background()
+
+	messages := []slackapi.Message{
+		{Text: "see <#C100> and <#C200> and <#C300|named>"},
+		{Text: "again <#C100>, plus <#C400>"},
+	}
+	exp.loadMentionedChannels(ctx, messages)
+	if got := exp.channelResolver.Resolve("C100"); got != "design" {
+		t.Errorf("Resolve(C100) = %q, want design", got)
+	}
+	if !exp.channelResolver.Has("C400") {
+		t.Error("a channel without a name should be remembered so it is not looked up again")
+	}
+	if n := slack.Calls("GetConversationInfo"); n != 2 {
+		t.Errorf("conversations.info calls = %d, want one per unknown unnamed mention", n)
+	}
+
+	exp.loadMentionedChannels(ctx, messages)
+	if n := slack.Calls("GetConversationInfo"); n != 2 {
+		t.Errorf("conversations.info calls = %d after a repeat, want 2", n)
+	}
+}
+
+func TestLoadMentionedChannels_InfoRestricted(t *testing.T) {
+	drive, slack, _ := fakeConversation()
+	slack.Errors["GetConversationInfo"] = errMissingScope
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	ctx := context.Background()
+	if err := exp.ValidateConnections(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	before := slack.Calls("GetConversationInfo")
+	exp.loadMentionedChannels(ctx, []slackapi.Message{{Text: "<#C100> <#C101>"}})
+	if n := slack.Calls("GetConversationInfo") - before; n > 1 {
+		t.Errorf("conversations.info calls = %d, want none once it is known to be restricted", n)
+	}
+}
+
+func TestSeedChannels(t *testing.T) {
+	drive, slack, _ := fakeConversation()
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.index.GetOrCreateConversation("C010", "old-name", "channel")
+	exp.index.GetOrCreateConversation("C011", "retired", "channel")
+	exp.index.MergeAliases("C010", []string{"C011"})
+	exp.index.GetOrCreateConversation("D001", "alice", "dm")
+	exp.seedChannelsFromIndex()
+
+	exp.SeedChannels([]config.ConversationConfig{
+		{ID: "C020", Name: "#eng", Type: "private_channel", Aliases: []string{"C021"}},
+		{ID: "D002", Name: "bob", Type: "dm"},
+	})
+
+	for id, want := range map[string]string{"C010": "old-name", "C011": "old-name", "C020": "eng", "C021": "eng"} {
+		if got := exp.channelResolver.Resolve(id); got != want {
+			t.Errorf("Resolve(%s) = %q, want %q", id, got, want)
+		}
+	}
+	for _, id := range []string{"D001", "D002"} {
+		if exp.channelResolver.Has(id) {
+			t.Errorf("DM %s should not be seeded as a channel", id)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/models"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// ChannelDiscoveryOptions select the channels DiscoverChannels returns.
+type ChannelDiscoveryOptions struct {
+	// Public and Private select public and private channels.
+	Public, Private bool
+
+	// IncludeNonMember also returns public channels the current user has
+	// not joined. Private channels are only listed for members anyway.
+	IncludeNonMember bool
+}
+
+// DiscoverChannels lists the workspace's channels via conversations.list
+// and returns those not in known (by ID or alias) that match opts, named
+// after the channel. Archived channels are skipped.
+func (e *Exporter) DiscoverChannels(ctx context.Context, known []config.ConversationConfig, opts ChannelDiscoveryOptions) ([]config.ConversationConfig, error) {
+	var types []string
+	if opts.Public {
+		types = append(types, "public_channel")
+	}
+	if opts.Private {
+		types = append(types, "private_channel")
+	}
+	if len(types) == 0 {
+		return nil, nil
+	}
+
+	skip := make(map[string]bool)
+	for _, c := range known {
+		skip[c.ID] = true
+		for _, alias := range c.Aliases {
+			skip[alias] = true
+		}
+	}
+
+	var found []config.ConversationConfig
+	cursor := ""
+	for {
+		resp, err := e.slackClient.ListConversations(ctx, &slackapi.ListConversationsOptions{
+			Cursor:          cursor,
+			Types:           types,
+			ExcludeArchived: true,
+		})
+		if err != nil {
+			if slackapi.IsRestrictedError(err) {
+				return nil, fmt.Errorf("cannot discover channels: conversations.list is restricted in this workspace; add channels to conversations.json instead: %w", err)
+			}
+			return nil, fmt.Errorf("failed to list channels: %w", err)
+		}
+		for _, c := range resp.Channels {
+			if skip[c.ID] || c.IsArchived || (!c.IsMember && !opts.IncludeNonMember) {
+				continue
+			}
+			convType := models.ConversationTypeChannel
+			if c.IsPrivate {
+				convType = models.ConversationTypePrivateChannel
+			}
+			found = append(found, config.ConversationConfig{ID: c.ID, Name: c.Name, Type: convType, Export: true})
+		}
+		cursor = resp.ResponseMetadata.NextCursor
+		if cursor == "" {
+			break
+		}
+	}
+	e.Detail("Found %d channels not in conversations.json", len(found))
+	return found, nil
+}
@@ -0,0 +1,59 @@
+package exporter
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+func TestDiscoverChannels(t *testing.T) {
+	drive, slack, _ := fakeConversation()
+	slack.Conversations = []slackapi.Conversation{
+		{ID: "C001", Name: "general", IsMember: true},               // configured
+		{ID: "C010", Name: "eng", IsMember: true},                   // member
+		{ID: "C011", Name: "random"},                                // not a member
+		{ID: "C012", Name: "old", IsMember: true, IsArchived: true}, // archived
+		{ID: "G010", Name: "secret", IsPrivate: true, IsMember: true},
+		{ID: "D010", IsIM: true, User: "U010"},
+	}
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	known := []config.ConversationConfig{{ID: "C001", Name: "general", Type: "channel"}}
+
+	found, err := exp.DiscoverChannels(context.Background(), known, ChannelDiscoveryOptions{Public: true})
+	if err != nil {
+		t.Fatalf("DiscoverChannels() error: %v", err)
+	}
+	if len(found) != 1 || found[0].ID != "C010" || found[0].Name != "eng" || found[0].Type != "channel" || !found[0].Export {
+		t.Fatalf("DiscoverChannels(public) = %+v, want only the joined channel eng", found)
+	}
+
+	found, err = exp.DiscoverChannels(context.Background(), known, ChannelDiscoveryOptions{Public: true, Private: true, IncludeNonMember: true})
+	if err != nil {
+		t.Fatalf("DiscoverChannels() error: %v", err)
+	}
+	var ids []string
+	for _, c := range found {
+		ids = append(ids, c.ID+":"+string(c.Type))
+	}
+	if got := strings.Join(ids, ","); got != "C010:channel,C011:channel,G010:private_channel" {
+		t.Errorf("DiscoverChannels(all) = %s", got)
+	}
+
+	if found, _ := exp.DiscoverChannels(context.Background(), known, ChannelDiscoveryOptions{}); len(found) != 0 || slack.Calls("ListConversations") != 2 {
+		t.Errorf("DiscoverChannels() with no types = %+v, want nothing and no list call", found)
+	}
+}
+
+func TestDiscoverChannels_ListRestricted(t *testing.T) {
+	drive, slack, _ := fakeConversation()
+	slack.Errors["ListConversations"] = errMissingScope
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+
+	_, err := exp.DiscoverChannels(context.Background(), nil, ChannelDiscoveryOptions{Public: true})
+	if err == nil || !strings.Contains(err.Error(), "conversations.json") {
+		t.Errorf("DiscoverChannels() error = %v, want a hint to configure channels instead", err)
+	}
+}
@@ -0,0 +1,58 @@
+package exporter
+
+import (
+	"context"
+
+	"github.com/jflowers/get-out/pkg/gdrive"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// DriveSink is the subset of the Google Drive/Docs client that the exporter
+// writes through. It is satisfied by *gdrive.Client.
+type DriveSink interface {
+	GetFolder(ctx context.Context, folderID string) (*gdrive.FolderInfo, error)
+	FindOrCreateFolder(ctx context.Context, name string, parentID string) (*gdrive.FolderInfo, error)
+	FindOrCreateDocument(ctx context.Context, title string, folderID string) (*gdrive.DocInfo, error)
+	FindOrCreateDocumentFromTemplate(ctx context.Context, title string, folderID string, templateID string) (*gdrive.DocInfo, error)
+	GetDocumentContent(ctx context.Context, docID string) (string, error)
+	GetDocumentEndIndex(ctx context.Context, docID string) (int64, error)
+	BatchAppendMessages(ctx context.Context, docID string, messages []gdrive.MessageBlock) error
+	ReplaceDocumentContent(ctx context.Context, docID string, messages []gdrive.MessageBlock) error
+	ReplaceText(ctx context.Context, docID string, replacements map[string]string) (int, error)
+	UploadFile(ctx context.Context, name string, mimeType string, data []byte, parentID string) (string, error)
+	GetWebContentLink(ctx context.Context, fileID string) (string, error)
+	MakePublic(ctx context.Context, fileID string) error
+	DeleteFile(ctx context.Context, fileID string) error
+}
+
+// SlackSource is the subset of the Slack API client that the exporter reads
+// from. It is satisfied by *slackapi.Client.
+type SlackSource interface {
+	parser.SlackAPI
+	slackapi.AccessProber
+	GetAllMessages(ctx context.Context, channelID string, oldest, latest string, callback func([]slackapi.Message) error) error
+	GetAllMessagesFrom(ctx context.Context, channelID string, oldest, latest, cursor string, callback func(batch []slackapi.Message, next string) error) error
+	GetAllReplies(ctx context.Context, channelID, threadTS string, callback func([]slackapi.Message) error) error
+	DownloadFile(ctx context.Context, url string) ([]byte, error)
+	GetTeamInfo(ctx context.Context) (*slackapi.Team, error)
+	GetFileInfo(ctx context.Context, fileID string) (*slackapi.File, error)
+	ListEmoji(ctx context.Context) (map[string]string, error)
+	ActivitySource
+}
+
+// ActivitySource is the part of the Slack API client that lists the
+// exporting user's activity (see ExportActivity).
+type ActivitySource interface {
+	SearchMessages(ctx context.Context, query string, page int) (*slackapi.SearchMessagesResponse, error)
+	ListReactions(ctx context.Context, userID, cursor string) (*slackapi.ItemsListResponse, error)
+	ListSaved(ctx context.Context, cursor string) (*slackapi.ItemsListResponse, error)
+	ListScheduledMessages(ctx context.Context, cursor string) (*slackapi.ScheduledMessagesResponse, error)
+	ListReminders(ctx context.Context) ([]slackapi.Reminder, error)
+	ListSubscribedThreads(ctx context.Context, before string) (*slackapi.SubscribedThreadsResponse, error)
+}
+
+var (
+	_ DriveSink   = (*gdrive.Client)(nil)
+	_ SlackSource = (*slackapi.Client)(nil)
+)
@@ -0,0 +1,198 @@
+package exporter
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// emojiOnlyPattern matches message text made of nothing but emoji
+// shortcodes, skin tone modifiers included, and spaces.
+var emojiOnlyPattern = regexp.MustCompile(`^(\s*:[a-z0-9_+'-]+:)+\s*$`)
+
+// emojiShortcode matches one emoji shortcode.
+var emojiShortcode = regexp.MustCompile(`:([a-z0-9_+'-]+):`)
+
+// compactMessage is a message with nothing to it but emoji or a GIF, which
+// every rendered format shows on a single line instead of the blocks of a
+// full message.
+type compactMessage struct {
+	// Emoji are the names of the emoji a message of only emoji is made
+	// of, as parser.EmojiResolver.Lookup takes them ("+1::skin-tone-3").
+	Emoji []string
+
+	// GIF is the title of a GIF posted alone, and GIFURL its link.
+	GIF    string
+	GIFURL string
+}
+
+// compactMessageOf returns the compact form of msg, and whether it has
+// one: msg is only emoji, or only a GIF from Giphy, and has no files and no
+// thread. Reactions do not matter.
+func compactMessageOf(msg slackapi.Message) (compactMessage, bool) {
+	if len(msg.Files) > 0 || msg.ReplyCount > 0 || msg.Metadata != nil {
+		return compactMessage{}, false
+	}
+	if len(msg.Attachments) == 0 && !hasBlock(msg.Blocks, "image") {
+		text := parser.MessageText(msg)
+		if !emojiOnlyPattern.MatchString(text) {
+			return compactMessage{}, false
+		}
+		var c compactMessage
+		for _, m := range emojiShortcode.FindAllStringSubmatch(text, -1) {
+			if n := len(c.Emoji); n > 0 && strings.HasPrefix(m[1], "skin-tone-") {
+				c.Emoji[n-1] += "::" + m[1]
+				continue
+			}
+			c.Emoji = append(c.Emoji, m[1])
+		}
+		return c, len(c.Emoji) > 0
+	}
+	return gifMessageOf(msg)
+}
+
+// gifMessageOf returns the compact form of a message that is only a GIF:
+// one Giphy attachment or image block, with no text besides the /giphy
+// command that posted it.
+func gifMessageOf(msg slackapi.Message) (compactMessage, bool) {
+	text := strings.TrimSpace(msg.Text)
+	if text != "" && !strings.HasPrefix(text, "/giphy") {
+		return compactMessage{}, false
+	}
+	var c compactMessage
+	switch {
+	case len(msg.Attachments) == 1 && len(msg.Blocks) == 0:
+		att := msg.Attachments[0]
+		if !strings.EqualFold(att.ServiceName, "giphy") && !isGiphyURL(att.ImageURL) {
+			return compactMessage{}, false
+		}
+		c.GIF, c.GIFURL = att.Title, att.TitleLink
+		if c.GIFURL == "" {
+			c.GIFURL = att.ImageURL
+		}
+	case len(msg.Attachments) == 0 && len(msg.Blocks) == 1:
+		b := msg.Blocks[0]
+		if b.Type != "image" || !isGiphyURL(b.ImageURL) {
+			return compactMessage{}, false
+		}
+		c.GIF, c.GIFURL = b.AltText, b.ImageURL
+		if b.Title != nil && b.Title.Text != "" {
+			c.GIF = b.Title.Text
+		}
+	default:
+		return compactMessage{}, false
+	}
+	if c.GIF == "" {
+		c.GIF = strings.TrimSpace(strings.TrimPrefix(text, "/giphy"))
+	}
+	return c, c.GIFURL != ""
+}
+
+// isGiphyURL reports whether u is on giphy.com or one of its subdomains.
+func isGiphyURL(u string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(parsed.Hostname())
+	return host == "giphy.com" || strings.HasSuffix(host, ".giphy.com")
+}
+
+// hasBlock reports whether blocks include one of type typ.
+func hasBlock(blocks []slackapi.Block, typ string) bool {
+	for _, b := range blocks {
+		if b.Type == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// label returns the text of a GIF's line, "" for emoji.
+func (c compactMessage) label() string {
+	if c.GIFURL == "" {
+		return ""
+	}
+	if c.GIF == "" {
+		return "GIF"
+	}
+	return "GIF: " + c.GIF
+}
+
+// text returns c as plain text: its emoji as Unicode when emoji resolves
+// them and as :name: otherwise, or its GIF's label.
+func (c compactMessage) text(emoji *parser.EmojiResolver) string {
+	if c.GIFURL != "" {
+		return c.label()
+	}
+	parts := make([]string, len(c.Emoji))
+	for i, name := range c.Emoji {
+		parts[i] = emoji.Lookup(name).String()
+	}
+	return strings.Join(parts, " ")
+}
+
+// links returns the links of c's text: its GIF's label to the GIF, or
+// each custom emoji to its image.
+func (c compactMessage) links(emoji *parser.EmojiResolver) []parser.LinkAnnotation {
+	if c.GIFURL != "" {
+		return []parser.LinkAnnotation{{Text: c.label(), URL: c.GIFURL}}
+	}
+	var links []parser.LinkAnnotation
+	for _, name := range c.Emoji {
+		if em := emoji.Lookup(name); em.Custom() {
+			links = append(links, parser.LinkAnnotation{Text: em.String(), URL: em.ImageURL})
+		}
+	}
+	return links
+}
+
+// markdown returns c as markdown: custom emoji as images titled with their
+// name, and a GIF as a link.
+func (c compactMessage) markdown(emoji *parser.EmojiResolver) string {
+	if c.GIFURL != "" {
+		return "[" + c.label() + "](" + c.GIFURL + ")"
+	}
+	parts := make([]string, len(c.Emoji))
+	for i, name := range c.Emoji {
+		em := emoji.Lookup(name)
+		parts[i] = em.String()
+		if em.Custom() {
+			parts[i] = "![" + em.String() + "](" + em.ImageURL + " \"" + name + "\")"
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// skipEmojiMessages returns msgs without the messages that are only emoji
+// or a GIF, when the export skips them (see
+// ExporterConfig.SkipEmojiMessages), counting them in result. The json,
+// slack, and sqlite formats keep Slack's data as it is and skip nothing.
+// msgs is not modified.
+func (e *Exporter) skipEmojiMessages(conv config.ConversationConfig, msgs []slackapi.Message, result *ExportResult) []slackapi.Message {
+	if !e.skipEmoji {
+		return msgs
+	}
+	if f := conv.OutputFormat(); f == config.OutputFormatJSON || f == config.OutputFormatSlack || f == config.OutputFormatSQLite {
+		return msgs
+	}
+	kept, skipped := withoutEmojiMessages(msgs)
+	result.EmojiMessagesSkipped += skipped
+	return kept
+}
+
+// withoutEmojiMessages returns msgs without the messages that are only
+// emoji or a GIF, and how many it left out.
+func withoutEmojiMessages(msgs []slackapi.Message) ([]slackapi.Message, int) {
+	kept := make([]slackapi.Message, 0, len(msgs))
+	for _, msg := range msgs {
+		if _, ok := compactMessageOf(msg); !ok {
+			kept = append(kept, msg)
+		}
+	}
+	return kept, len(msgs) - len(kept)
+}
@@ -0,0 +1,164 @@
+package exporter
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+func TestCompactMessageOf(t *testing.T) {
+	giphyAttachment := slackapi.Attachment{
+		ServiceName: "giphy",
+		Title:       "cats",
+		TitleLink:   "https://giphy.com/gifs/cats-abc",
+		ImageURL:    "https://media.giphy.com/media/abc/giphy.gif",
+	}
+	tests := []struct {
+		name string
+		msg  slackapi.Message
+		want *compactMessage
+	}{
+		{name: "one emoji", msg: slackapi.Message{Text: ":partyparrot:"}, want: &compactMessage{Emoji: []string{"partyparrot"}}},
+		{name: "several emoji", msg: slackapi.Message{Text: " :tada: :+1::skin-tone-3: "}, want: &compactMessage{Emoji: []string{"tada", "+1::skin-tone-3"}}},
+		{name: "text and emoji", msg: slackapi.Message{Text: "nice :tada:"}},
+		{name: "empty", msg: slackapi.Message{Text: ""}},
+		{name: "emoji with a file", msg: slackapi.Message{Text: ":tada:", Files: []slackapi.File{{Name: "a.png"}}}},
+		{name: "emoji starting a thread", msg: slackapi.Message{Text: ":tada:", ReplyCount: 2}},
+		{name: "emoji with reactions", msg: slackapi.Message{Text: ":tada:", Reactions: []slackapi.Reaction{{Name: "eyes", Count: 1}}}, want: &compactMessage{Emoji: []string{"tada"}}},
+		{
+			name: "giphy attachment",
+			msg:  slackapi.Message{Text: "/giphy cats", Attachments: []slackapi.Attachment{giphyAttachment}},
+			want: &compactMessage{GIF: "cats", GIFURL: "https://giphy.com/gifs/cats-abc"},
+		},
+		{
+			name: "giphy attachment titled by its command",
+			msg:  slackapi.Message{Text: "/giphy dogs", Attachments: []slackapi.Attachment{{ImageURL: "https://media.giphy.com/media/def/giphy.gif"}}},
+			want: &compactMessage{GIF: "dogs", GIFURL: "https://media.giphy.com/media/def/giphy.gif"},
+		},
+		{
+			name: "giphy image block",
+			msg: slackapi.Message{Blocks: []slackapi.Block{{
+				Type:     "image",
+				ImageURL: "https://media0.giphy.com/media/abc/giphy.gif",
+				AltText:  "cats",
+				Title:    &slackapi.TextObject{Type: "plain_text", Text: "cats on a boat"},
+			}}},
+			want: &compactMessage{GIF: "cats on a boat", GIFURL: "https://media0.giphy.com/media/abc/giphy.gif"},
+		},
+		{name: "giphy with a comment", msg: slackapi.Message{Text: "look", Attachments: []slackapi.Attachment{giphyAttachment}}},
+		{name: "link unfurl", msg: slackapi.Message{Attachments: []slackapi.Attachment{{Title: "Spec", TitleLink: "https://example.com", ImageURL: "https://example.com/a.png"}}}},
+		{name: "lookalike host", msg: slackapi.Message{Blocks: []slackapi.Block{{Type: "image", ImageURL: "https://notgiphy.com/a.gif"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := compactMessageOf(tt.msg)
+			if tt.want == nil {
+				if ok {
+					t.Errorf("compactMessageOf() = %+v, want none", got)
+				}
+				return
+			}
+			if !ok || !reflect.DeepEqual(got, *tt.want) {
+				t.Errorf("compactMessageOf() = %+v, %v, want %+v", got, ok, *tt.want)
+			}
+		})
+	}
+}
+
+func TestCompactMessage_Render(t *testing.T) {
+	emoji := parser.NewEmojiResolver()
+	emoji.SetCustomEmoji(map[string]string{"partyparrot": "https://emoji.slack-edge.com/T1/partyparrot/abc.gif"})
+	c := compactMessage{Emoji: []string{"partyparrot", "tada"}}
+	gif := compactMessage{GIF: "cats", GIFURL: "https://giphy.com/gifs/cats-abc"}
+
+	if got := c.text(emoji); got != ":partyparrot: 🎉" {
+		t.Errorf("text() = %q", got)
+	}
+	if got := c.links(emoji); len(got) != 1 || got[0].Text != ":partyparrot:" {
+		t.Errorf("links() = %+v, want the custom emoji linked to its image", got)
+	}
+	if got, want := c.markdown(emoji), `![:partyparrot:](https://emoji.slack-edge.com/T1/partyparrot/abc.gif "partyparrot") 🎉`; got != want {
+		t.Errorf("markdown() = %q, want %q", got, want)
+	}
+	if got := gif.text(emoji); got != "GIF: cats" {
+		t.Errorf("GIF text() = %q", got)
+	}
+	if got := gif.markdown(emoji); got != "[GIF: cats](https://giphy.com/gifs/cats-abc)" {
+		t.Errorf("GIF markdown() = %q", got)
+	}
+}
+
+func TestRenderMessages_Compact(t *testing.T) {
+	w := newTestMarkdownWriter()
+	msgs := []slackapi.Message{
+		{User: "U001", Text: ":tada:", TS: "1706788800.000100", Reactions: []slackapi.Reaction{{Name: "eyes", Count: 2}}},
+		{User: "U001", Text: "/giphy cats", TS: "1706788900.000100", Attachments: []slackapi.Attachment{{
+			ServiceName: "giphy", Title: "cats", TitleLink: "https://giphy.com/gifs/cats-abc", Text: "long giphy blurb",
+		}}},
+	}
+	got, err := w.RenderMessages(msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "**" + parser.FormatTimestamp(msgs[0].TS) + " -- U001** :tada:  Reactions: :eyes: (2)\n\n" +
+		"**" + parser.FormatTimestamp(msgs[1].TS) + " -- U001** [GIF: cats](https://giphy.com/gifs/cats-abc)\n\n"
+	if string(got) != want {
+		t.Errorf("RenderMessages() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestMessageToBlock_Compact(t *testing.T) {
+	w := NewDocWriter(nil, nil, nil, nil, nil, nil, nil)
+	block := w.messageToBlock(context.Background(), "C001", "", slackapi.Message{
+		User: "U001",
+		TS:   "1706788800.000100",
+		Blocks: []slackapi.Block{{
+			Type:     "image",
+			ImageURL: "https://media.giphy.com/media/abc/giphy.gif",
+			AltText:  "cats",
+		}},
+		Reactions: []slackapi.Reaction{{Name: "joy", Count: 1}},
+	})
+	if !block.Compact || block.Content != "GIF: cats  Reactions: :joy: (1)" {
+		t.Errorf("block = %+v, want the GIF and reactions on one line", block)
+	}
+	if len(block.Links) != 1 || block.Links[0].URL != "https://media.giphy.com/media/abc/giphy.gif" {
+		t.Errorf("Links = %+v, want the GIF linked", block.Links)
+	}
+}
+
+func TestExportConversation_CompactAndSkipEmojiMessages(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	conv.Format = config.OutputFormatHTML
+	slack.Messages["C001"] = append(slack.Messages["C001"],
+		slackapi.Message{User: "U002", Text: ":tada:", TS: "1706788860.000500"}, // 2024-02-01
+	)
+	exp, localDir := localFormatExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	dir := filepath.Join(localDir, SanitizeDirectoryName(string(conv.Type), conv.Name))
+	if day := readFile(t, filepath.Join(dir, "2024-02-01.html")); !strings.Contains(day, `<article class="msg compact"`) || !strings.Contains(day, `<span class="text">:tada:</span></header>`) {
+		t.Errorf("day page without the compact message:\n%s", day)
+	}
+
+	exp, localDir = localFormatExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.skipEmoji = true
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("ExportConversation() with skipped emoji messages error: %v", err)
+	}
+	if result.EmojiMessagesSkipped != 1 || result.MessageCount != 3 {
+		t.Errorf("EmojiMessagesSkipped = %d, MessageCount = %d, want 1 and 3", result.EmojiMessagesSkipped, result.MessageCount)
+	}
+	dir = filepath.Join(localDir, SanitizeDirectoryName(string(conv.Type), conv.Name))
+	if day := readFile(t, filepath.Join(dir, "2024-02-01.html")); strings.Contains(day, `class="msg compact"`) || !strings.Contains(day, "Good morning") {
+		t.Errorf("day page still has the emoji message:\n%s", day)
+	}
+}
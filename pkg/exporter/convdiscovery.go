@@ -0,0 +1,225 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/models"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// ConversationDiscoveryOptions select the conversations DiscoverConversations
+// returns.
+type ConversationDiscoveryOptions struct {
+	// Types are the conversation types to list. Empty lists every type.
+	Types []models.ConversationType
+
+	// ActiveSince is a Slack timestamp; conversations without a message
+	// after it are skipped. Empty keeps dormant conversations too.
+	ActiveSince string
+
+	// Member is a Slack user ID; only conversations they belong to are
+	// returned. Empty returns every conversation.
+	Member string
+
+	// Users and People name DMs after the other person. People takes
+	// precedence and may be nil; Users is required.
+	Users  *parser.UserResolver
+	People *parser.PersonResolver
+}
+
+// conversationListTypes maps conversation types to conversations.list types.
+var conversationListTypes = map[models.ConversationType]string{
+	models.ConversationTypeDM:             "im",
+	models.ConversationTypeMPIM:           "mpim",
+	models.ConversationTypeChannel:        "public_channel",
+	models.ConversationTypePrivateChannel: "private_channel",
+}
+
+// discoveryTypeOrder is every conversation type, in the order discovered
+// conversations are listed.
+var discoveryTypeOrder = []models.ConversationType{
+	models.ConversationTypeDM,
+	models.ConversationTypeMPIM,
+	models.ConversationTypeChannel,
+	models.ConversationTypePrivateChannel,
+}
+
+// DiscoverConversations lists the DMs, group DMs, and channels the current
+// user belongs to via conversations.list, and returns those not in known
+// (by ID or alias) that match opts, as conversations.json entries sorted by
+// type and name. Archived conversations are skipped.
+//
+// ActiveSince costs one conversations.history call per conversation and
+// Member one conversations.members call per group DM or channel, so both
+// filters are applied after the cheaper ones.
+func DiscoverConversations(ctx context.Context, client slackapi.AccessProber, known []config.ConversationConfig, opts ConversationDiscoveryOptions) ([]config.ConversationConfig, error) {
+	convTypes := opts.Types
+	if len(convTypes) == 0 {
+		convTypes = discoveryTypeOrder
+	}
+	var types []string
+	for _, t := range convTypes {
+		listType, ok := conversationListTypes[t]
+		if !ok {
+			return nil, fmt.Errorf("unknown conversation type %q", t)
+		}
+		types = append(types, listType)
+	}
+
+	skip := make(map[string]bool)
+	for _, c := range known {
+		skip[c.ID] = true
+		for _, alias := range c.Aliases {
+			skip[alias] = true
+		}
+	}
+
+	var candidates []slackapi.Conversation
+	cursor := ""
+	for {
+		resp, err := client.ListConversations(ctx, &slackapi.ListConversationsOptions{
+			Cursor:          cursor,
+			Types:           types,
+			ExcludeArchived: true,
+		})
+		if err != nil {
+			if slackapi.IsRestrictedError(err) {
+				return nil, fmt.Errorf("cannot discover conversations: conversations.list is restricted in this workspace; add conversations to conversations.json instead: %w", err)
+			}
+			return nil, fmt.Errorf("failed to list conversations: %w", err)
+		}
+		for _, c := range resp.Channels {
+			if skip[c.ID] || c.IsArchived {
+				continue
+			}
+			// conversations.list returns public channels the user has not
+			// joined; DMs and group DMs are always the user's own.
+			if !c.IsIM && !c.IsMPIM && !c.IsMember {
+				continue
+			}
+			candidates = append(candidates, c)
+		}
+		cursor = resp.ResponseMetadata.NextCursor
+		if cursor == "" {
+			break
+		}
+	}
+
+	var found []config.ConversationConfig
+	var dmUsers []string
+	for _, c := range candidates {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if opts.Member != "" {
+			ok, err := hasMember(ctx, client, c, opts.Member)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		if opts.ActiveSince != "" {
+			resp, err := client.GetConversationHistory(ctx, c.ID, &slackapi.HistoryOptions{Limit: 1, Oldest: opts.ActiveSince})
+			if err != nil {
+				return nil, fmt.Errorf("failed to read history of %s: %w", c.ID, err)
+			}
+			if len(resp.Messages) == 0 {
+				continue
+			}
+		}
+
+		entry := config.ConversationConfig{ID: c.ID, Name: c.Name, Export: true}
+		switch {
+		case c.IsIM:
+			entry.Type = models.ConversationTypeDM
+			entry.Name = c.User
+			dmUsers = append(dmUsers, c.User)
+		case c.IsMPIM:
+			entry.Type = models.ConversationTypeMPIM
+			entry.Name = mpimName(c.Name)
+		case c.IsPrivate:
+			entry.Type = models.ConversationTypePrivateChannel
+		default:
+			entry.Type = models.ConversationTypeChannel
+		}
+		found = append(found, entry)
+	}
+
+	// Name each DM after the other person, as conversations.json does.
+	if len(dmUsers) > 0 && opts.Users != nil {
+		if err := opts.Users.LoadUsersByID(ctx, client, dmUsers); err != nil {
+			return nil, fmt.Errorf("failed to look up DM partners: %w", err)
+		}
+	}
+	for i := range found {
+		if found[i].Type == models.ConversationTypeDM {
+			found[i].Name = resolveUserName(found[i].Name, opts.Users, opts.People)
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].Type != found[j].Type {
+			return slices.Index(discoveryTypeOrder, found[i].Type) < slices.Index(discoveryTypeOrder, found[j].Type)
+		}
+		return strings.ToLower(found[i].Name) < strings.ToLower(found[j].Name)
+	})
+	return found, nil
+}
+
+// hasMember reports whether userID belongs to conversation c.
+func hasMember(ctx context.Context, client slackapi.AccessProber, c slackapi.Conversation, userID string) (bool, error) {
+	if c.IsIM {
+		return c.User == userID, nil
+	}
+	if slices.Contains(c.Members, userID) {
+		return true, nil
+	}
+	cursor := ""
+	for {
+		resp, err := client.GetConversationMembers(ctx, c.ID, cursor)
+		if err != nil {
+			return false, fmt.Errorf("failed to list members of %s: %w", c.ID, err)
+		}
+		if slices.Contains(resp.Members, userID) {
+			return true, nil
+		}
+		cursor = resp.ResponseMetadata.NextCursor
+		if cursor == "" {
+			return false, nil
+		}
+	}
+}
+
+// mpimName turns a group DM's Slack name ("mpdm-alice--bob--carol-1") into
+// its members' handles ("alice, bob, carol").
+func mpimName(name string) string {
+	trimmed := strings.TrimPrefix(name, "mpdm-")
+	if trimmed == name {
+		return name
+	}
+	if i := strings.LastIndex(trimmed, "-"); i > 0 && isDigits(trimmed[i+1:]) {
+		trimmed = trimmed[:i]
+	}
+	return strings.Join(strings.Split(trimmed, "--"), ", ")
+}
+
+// isDigits reports whether s is a non-empty run of ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+package exporter
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/internal/testutil"
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/models"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+func discoverySlack() *testutil.FakeSlack {
+	slack := testutil.NewFakeSlack()
+	slack.Users = []slackapi.User{{ID: "U010", Name: "carol"}, {ID: "U011", Name: "dave"}}
+	slack.Conversations = []slackapi.Conversation{
+		{ID: "D001", IsIM: true, User: "U001"}, // configured
+		{ID: "D010", IsIM: true, User: "U010"},
+		{ID: "D011", IsIM: true, User: "U011"},
+		{ID: "G001", IsMPIM: true, Name: "mpdm-alice--carol--mary-jane-1"},
+		{ID: "C100", Name: "general", IsMember: true},
+		{ID: "C101", Name: "random"}, // not joined
+		{ID: "C102", Name: "old", IsMember: true, IsArchived: true},
+		{ID: "C103", Name: "secret", IsPrivate: true, IsMember: true},
+	}
+	slack.Messages["D010"] = []slackapi.Message{{TS: "1706788800.000100"}}
+	slack.Messages["D011"] = []slackapi.Message{{TS: "1600000000.000100"}}
+	slack.Messages["C100"] = []slackapi.Message{{TS: "1706788800.000100"}}
+	slack.Members["G001"] = []string{"U001", "U010"}
+	slack.Members["C100"] = []string{"U001", "U011"}
+	slack.Members["C103"] = []string{"U010"}
+	return slack
+}
+
+func TestDiscoverConversations(t *testing.T) {
+	known := []config.ConversationConfig{{ID: "D001", Name: "alice", Type: "dm"}}
+	people := parser.NewPersonResolver(&config.PeopleConfig{People: []config.PersonConfig{{SlackID: "U011", DisplayName: "Dave D."}}})
+
+	tests := []struct {
+		name string
+		opts ConversationDiscoveryOptions
+		want []string
+	}{
+		{"everything", ConversationDiscoveryOptions{}, []string{"D010", "D011", "G001", "C100", "C103"}},
+		{"types", ConversationDiscoveryOptions{Types: []models.ConversationType{"mpim", "private_channel"}}, []string{"G001", "C103"}},
+		{"active since", ConversationDiscoveryOptions{ActiveSince: "1700000000.000000"}, []string{"D010", "C100"}},
+		{"member", ConversationDiscoveryOptions{Member: "U010"}, []string{"D010", "G001", "C103"}},
+		{"member and active", ConversationDiscoveryOptions{Member: "U011", ActiveSince: "1700000000.000000"}, []string{"C100"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Users = parser.NewUserResolver()
+			tt.opts.People = people
+			found, err := DiscoverConversations(context.Background(), discoverySlack(), known, tt.opts)
+			if err != nil {
+				t.Fatalf("DiscoverConversations() error: %v", err)
+			}
+			var ids []string
+			for _, c := range found {
+				ids = append(ids, c.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("DiscoverConversations() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscoverConversations_Names(t *testing.T) {
+	people := parser.NewPersonResolver(&config.PeopleConfig{People: []config.PersonConfig{{SlackID: "U011", DisplayName: "Dave D."}}})
+	found, err := DiscoverConversations(context.Background(), discoverySlack(), nil, ConversationDiscoveryOptions{
+		Users:  parser.NewUserResolver(),
+		People: people,
+	})
+	if err != nil {
+		t.Fatalf("DiscoverConversations() error: %v", err)
+	}
+	want := map[string]struct {
+		name string
+		typ  models.ConversationType
+	}{
+		"D001": {"U001", "dm"}, // unknown user keeps the ID
+		"D010": {"carol", "dm"},
+		"D011": {"Dave D.", "dm"},
+		"G001": {"alice, carol, mary-jane", "mpim"},
+		"C100": {"general", "channel"},
+		"C103": {"secret", "private_channel"},
+	}
+	if len(found) != len(want) {
+		t.Fatalf("DiscoverConversations() = %+v, want %d conversations", found, len(want))
+	}
+	for _, c := range found {
+		w, ok := want[c.ID]
+		if !ok || c.Name != w.name || c.Type != w.typ || !c.Export {
+			t.Errorf("discovered %+v, want name %q type %q exported", c, w.name, w.typ)
+		}
+	}
+}
+
+func TestDiscoverConversations_Errors(t *testing.T) {
+	slack := discoverySlack()
+	slack.Errors["ListConversations"] = errMissingScope
+	_, err := DiscoverConversations(context.Background(), slack, nil, ConversationDiscoveryOptions{})
+	if err == nil || !strings.Contains(err.Error(), "conversations.json") {
+		t.Errorf("DiscoverConversations() error = %v, want a hint to configure conversations instead", err)
+	}
+
+	_, err = DiscoverConversations(context.Background(), discoverySlack(), nil, ConversationDiscoveryOptions{Types: []models.ConversationType{"thread"}})
+	if err == nil {
+		t.Error("DiscoverConversations() with an unknown type: want error")
+	}
+}
+
+func TestMpimName(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"mpdm-alice--bob--carol-1", "alice, bob, carol"},
+		{"mpdm-mary-jane--bob-2", "mary-jane, bob"},
+		{"mpdm-alice--mary-jane", "alice, mary-jane"},
+		{"project-x", "project-x"},
+	}
+	for _, tt := range tests {
+		if got := mpimName(tt.in); got != tt.want {
+			t.Errorf("mpimName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
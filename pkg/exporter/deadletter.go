@@ -0,0 +1,374 @@
+package exporter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// Dead-letter stages: where in the export a message failed.
+const (
+	DeadLetterStageDocs     = "docs"     // the Docs API rejected the message
+	DeadLetterStageMarkdown = "markdown" // local markdown could not be rendered or written
+)
+
+// DeadLetter is a message that failed to render or write, stored as a JSONL
+// line with enough context to write it again with `get-out reprocess`.
+type DeadLetter struct {
+	FailedAt time.Time       `json:"failed_at"`
+	Stage    string          `json:"stage"`
+	Date     string          `json:"date"`                // daily doc / markdown file date
+	ThreadTS string          `json:"thread_ts,omitempty"` // set for thread docs
+	Dir      string          `json:"dir,omitempty"`       // markdown directory under the local export dir
+	Replace  bool            `json:"replace,omitempty"`   // markdown file is rewritten in full (thread days)
+	Error    string          `json:"error"`
+	Message  json.RawMessage `json:"message"`
+}
+
+// SlackMessage decodes the stored message, keeping its JSON in Raw so it
+// is stored unchanged if it fails again.
+func (d DeadLetter) SlackMessage() (slackapi.Message, error) {
+	var msg slackapi.Message
+	if err := json.Unmarshal(d.Message, &msg); err != nil {
+		return msg, fmt.Errorf("invalid dead-letter message: %w", err)
+	}
+	msg.Raw = d.Message
+	return msg, nil
+}
+
+// DeadLetterStore keeps failed messages in one JSONL file per conversation
+// (<dir>/<conversationID>.jsonl), so one bad message neither aborts its day
+// nor disappears. It is safe for concurrent use; a nil store drops entries.
+type DeadLetterStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewDeadLetterStore creates a DeadLetterStore that writes into dir.
+func NewDeadLetterStore(dir string) *DeadLetterStore {
+	return &DeadLetterStore{dir: dir}
+}
+
+// DefaultDeadLetterDir returns the default dead-letter directory.
+func DefaultDeadLetterDir(configDir string) string {
+	return filepath.Join(configDir, "_deadletter")
+}
+
+// DeadLetterPath returns the dead-letter file for a conversation ID.
+func DeadLetterPath(dir, convID string) string {
+	return filepath.Join(dir, convID+".jsonl")
+}
+
+// Add appends failed messages of a conversation, all with the same context
+// and error.
+func (s *DeadLetterStore) Add(convID string, entry DeadLetter, msgs []slackapi.Message) error {
+	if s == nil || len(msgs) == 0 {
+		return nil
+	}
+	if entry.FailedAt.IsZero() {
+		entry.FailedAt = time.Now().UTC()
+	}
+
+	var buf bytes.Buffer
+	for _, msg := range msgs {
+		entry.Message = msg.Raw
+		if len(msg.Raw) == 0 {
+			// Not decoded from a Slack response, such as a search match
+			raw, err := json.Marshal(msg)
+			if err != nil {
+				return fmt.Errorf("failed to encode message %s: %w", msg.TS, err)
+			}
+			entry.Message = raw
+		}
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to encode dead letter: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create dead-letter directory: %w", err)
+	}
+	f, err := os.OpenFile(DeadLetterPath(s.dir, convID), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open dead-letter file: %w", err)
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write dead-letter file: %w", err)
+	}
+	return f.Close()
+}
+
+// Load returns the dead letters of a conversation, oldest first. A
+// conversation without a dead-letter file has none.
+func (s *DeadLetterStore) Load(convID string) ([]DeadLetter, error) {
+	if s == nil {
+		return nil, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loadLocked(convID)
+}
+
+func (s *DeadLetterStore) loadLocked(convID string) ([]DeadLetter, error) {
+	path := DeadLetterPath(s.dir, convID)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var entries []DeadLetter
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var entry DeadLetter
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// Discard removes the first n dead letters of a conversation, keeping any
+// added since they were loaded. The file is removed when nothing is left.
+func (s *DeadLetterStore) Discard(convID string, n int) error {
+	if s == nil || n <= 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.loadLocked(convID)
+	if err != nil {
+		return err
+	}
+	path := DeadLetterPath(s.dir, convID)
+	if n >= len(entries) {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for _, entry := range entries[n:] {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to encode dead letter: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
+// Conversations returns the IDs of conversations with dead letters.
+func (s *DeadLetterStore) Conversations() ([]string, error) {
+	if s == nil {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dead-letter directory: %w", err)
+	}
+	var ids []string
+	for _, entry := range entries {
+		if name := entry.Name(); !entry.IsDir() && strings.HasSuffix(name, ".jsonl") {
+			ids = append(ids, strings.TrimSuffix(name, ".jsonl"))
+		}
+	}
+	return ids, nil
+}
+
+// ReprocessResult summarizes a reprocess run for one conversation.
+type ReprocessResult struct {
+	ConversationID string
+	Name           string
+	Attempted      int // dead letters loaded
+	Written        int // written this time and removed from the store
+	Failed         int // still failing, kept in the store
+}
+
+// ReprocessDeadLetters writes the dead letters of conv again: docs entries
+// are appended to their daily doc (after what is already there) and markdown
+// entries are written to their file. Entries that fail again, or that cannot
+// be written with the current settings, stay in the store. Call
+// LoadUsersForConversations first so senders resolve.
+func (e *Exporter) ReprocessDeadLetters(ctx context.Context, conv config.ConversationConfig) (*ReprocessResult, error) {
+	result := &ReprocessResult{ConversationID: conv.ID, Name: conv.Name}
+	defer e.forgetTranslations(conv.ID)
+	entries, err := e.deadLetters.Load(conv.ID)
+	if err != nil {
+		return result, err
+	}
+	result.Attempted = len(entries)
+	if len(entries) == 0 {
+		return result, nil
+	}
+	convExport := e.index.GetConversation(conv.ID)
+	if convExport == nil {
+		return result, fmt.Errorf("conversation %s is not in the export index", conv.ID)
+	}
+
+	// Group entries by the doc or file they belong to, in first-failure order.
+	type target struct {
+		stage, date, threadTS, dir string
+		replace                    bool
+	}
+	var order []target
+	groups := make(map[target][]DeadLetter)
+	var all []slackapi.Message
+	for _, entry := range entries {
+		msg, err := entry.SlackMessage()
+		if err != nil {
+			return result, err
+		}
+		all = append(all, msg)
+		t := target{entry.Stage, entry.Date, entry.ThreadTS, entry.Dir, entry.Replace}
+		if _, ok := groups[t]; !ok {
+			order = append(order, t)
+		}
+		groups[t] = append(groups[t], entry)
+	}
+	e.loadMessageAuthors(ctx, all)
+	e.loadMentionedChannels(ctx, all)
+
+	// Failures during the replay are added back to the store by the normal
+	// write paths; the replayed entries are discarded once it is done.
+	run := &ExportResult{ConversationID: conv.ID, Name: conv.Name}
+	for _, t := range order {
+		group := groups[t]
+		msgs := make([]slackapi.Message, len(group))
+		for i, entry := range group {
+			msgs[i], _ = entry.SlackMessage()
+		}
+		e.Progress("Reprocessing %d %s messages for %s", len(msgs), t.stage, t.date)
+
+		keep := func(reason string) {
+			for _, entry := range group {
+				if reason != "" {
+					entry.Error = reason
+					entry.FailedAt = time.Time{}
+				}
+				msg, _ := entry.SlackMessage()
+				e.deadLetter(conv.ID, entry, []slackapi.Message{msg}, run)
+			}
+		}
+
+		switch t.stage {
+		case DeadLetterStageDocs:
+			doc, folderID, err := e.deadLetterDoc(ctx, convExport, t.threadTS, t.date)
+			if err != nil {
+				keep(err.Error())
+				continue
+			}
+			written, grown, err := e.writeDocMessages(ctx, conv.ID, t.threadTS, t.date, doc.DocID, folderID, msgs, run)
+			if err != nil {
+				keep(err.Error())
+				continue
+			}
+			doc.MessageCount += written
+			if doc.EndIndex > 0 {
+				doc.EndIndex += grown
+			}
+			e.recordMessageMap(conv.ID, conv.Name, doc.DocURL, msgs)
+			if err := e.recordHold(conv.ID, t.threadTS, t.date, msgs, ""); err != nil {
+				return result, err
+			}
+		case DeadLetterStageMarkdown:
+			if e.mdWriter == nil || e.localExportDir == "" || !conv.WritesMarkdown() {
+				e.Progress("Skipping %d markdown messages: local export is not configured for %s", len(msgs), conv.Name)
+				keep("")
+				continue
+			}
+			mode := mdAppend
+			if t.replace {
+				mode = mdReplace
+			}
+			file, err := e.writeMarkdownDay(ctx, conv, t.dir, t.date, msgs, mode, run)
+			if err != nil {
+				keep(err.Error())
+				continue
+			}
+			if file != "" {
+				if err := e.recordHold(conv.ID, t.threadTS, t.date, msgs, file); err != nil {
+					return result, err
+				}
+			}
+		default:
+			keep("")
+		}
+	}
+
+	if err := e.deadLetters.Discard(conv.ID, len(entries)); err != nil {
+		return result, err
+	}
+	if err := e.index.SaveConversation(conv.ID); err != nil {
+		e.Progress("Warning: failed to save index: %v", err)
+	}
+	if e.messageMap != nil {
+		if err := e.messageMap.Save(conv.ID); err != nil {
+			e.Progress("Warning: failed to save message map: %v", err)
+		}
+	}
+	e.saveUserCache()
+	result.Failed = run.DeadLettered
+	result.Written = result.Attempted - result.Failed
+	return result, nil
+}
+
+// deadLetterDoc returns the daily doc a docs dead letter belongs to and the
+// folder its images are uploaded to.
+func (e *Exporter) deadLetterDoc(ctx context.Context, convExport *ConversationExport, threadTS, date string) (*DocExport, string, error) {
+	if threadTS == "" {
+		doc, err := e.folderStructure.EnsureDailyDoc(ctx, convExport.ID, date)
+		return doc, convExport.FolderID, err
+	}
+	thread := e.index.GetThread(convExport.ID, threadTS)
+	if thread == nil {
+		return nil, "", fmt.Errorf("thread not found in index: %s/%s", convExport.ID, threadTS)
+	}
+	doc, err := e.folderStructure.EnsureThreadDailyDoc(ctx, convExport.ID, threadTS, date)
+	return doc, thread.FolderID, err
+}
+
+// DeadLetterConversations returns the IDs of conversations with dead letters.
+func (e *Exporter) DeadLetterConversations() ([]string, error) {
+	return e.deadLetters.Conversations()
+}
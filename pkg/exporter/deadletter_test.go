@@ -0,0 +1,182 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/gdrive"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+func TestDeadLetterStore_AddLoadDiscard(t *testing.T) {
+	dir := t.TempDir()
+	store := NewDeadLetterStore(dir)
+
+	msgs := []slackapi.Message{{TS: "1.1", Text: "one"}, {TS: "2.2", Text: "two"}}
+	if err := store.Add("C001", DeadLetter{Stage: DeadLetterStageDocs, Date: "2024-02-01", Error: "bad request"}, msgs); err != nil {
+		t.Fatalf("Add() error: %v", err)
+	}
+	if err := store.Add("C001", DeadLetter{Stage: DeadLetterStageMarkdown, Date: "2024-02-02", Error: "disk full"}, msgs[:1]); err != nil {
+		t.Fatalf("Add() error: %v", err)
+	}
+
+	entries, err := store.Load("C001")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Load() = %d entries, want 3", len(entries))
+	}
+	msg, err := entries[1].SlackMessage()
+	if err != nil || msg.Text != "two" {
+		t.Errorf("SlackMessage() = %+v, %v", msg, err)
+	}
+	if string(entries[0].Message) != `{"type":"","user":"","text":"one","ts":"1.1"}` {
+		t.Errorf("Message = %s, want the encoded message when Slack's JSON is unknown", entries[0].Message)
+	}
+	if entries[2].Stage != DeadLetterStageMarkdown || entries[2].FailedAt.IsZero() {
+		t.Errorf("entry = %+v, want markdown stage with a failure time", entries[2])
+	}
+
+	ids, err := store.Conversations()
+	if err != nil || len(ids) != 1 || ids[0] != "C001" {
+		t.Errorf("Conversations() = %v, %v", ids, err)
+	}
+
+	if err := store.Discard("C001", 2); err != nil {
+		t.Fatalf("Discard() error: %v", err)
+	}
+	entries, _ = store.Load("C001")
+	if len(entries) != 1 || entries[0].Date != "2024-02-02" {
+		t.Errorf("after Discard(2) = %+v, want only the markdown entry", entries)
+	}
+
+	if err := store.Discard("C001", 1); err != nil {
+		t.Fatalf("Discard() error: %v", err)
+	}
+	if _, err := os.Stat(DeadLetterPath(dir, "C001")); !os.IsNotExist(err) {
+		t.Errorf("dead-letter file should be removed when empty, stat err = %v", err)
+	}
+}
+
+func TestDeadLetterStore_KeepsSlackJSON(t *testing.T) {
+	store := NewDeadLetterStore(t.TempDir())
+	raw := `{"type":"message","ts":"1.1","text":"one","client_msg_id":"abc"}`
+	msg := slackapi.Message{Type: "message", TS: "1.1", Text: "one, translated", Raw: json.RawMessage(raw)}
+	if err := store.Add("C001", DeadLetter{Stage: DeadLetterStageDocs, Date: "2024-02-01", Error: "bad request"}, []slackapi.Message{msg}); err != nil {
+		t.Fatalf("Add() error: %v", err)
+	}
+	entries, err := store.Load("C001")
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Load() = %+v, %v", entries, err)
+	}
+	if string(entries[0].Message) != raw {
+		t.Errorf("Message = %s, want Slack's JSON %s", entries[0].Message, raw)
+	}
+	got, err := entries[0].SlackMessage()
+	if err != nil || got.Text != "one" || string(got.Raw) != raw {
+		t.Errorf("SlackMessage() = %+v, %v; want Slack's message with its JSON", got, err)
+	}
+}
+
+func TestDeadLetterStore_Nil(t *testing.T) {
+	var store *DeadLetterStore
+	if err := store.Add("C001", DeadLetter{}, []slackapi.Message{{TS: "1"}}); err != nil {
+		t.Errorf("nil Add() error: %v", err)
+	}
+	if entries, err := store.Load("C001"); entries != nil || err != nil {
+		t.Errorf("nil Load() = %v, %v", entries, err)
+	}
+}
+
+func TestDeadLetterStore_LoadCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "C001.jsonl"), []byte("{not json}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewDeadLetterStore(dir).Load("C001"); err == nil || !strings.Contains(err.Error(), ":1:") {
+		t.Errorf("Load() error = %v, want line number", err)
+	}
+}
+
+func TestExportConversation_DeadLettersRejectedMessage(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	drive.Reject = func(b gdrive.MessageBlock) error {
+		if strings.HasPrefix(b.Content, "Good morning") {
+			return errors.New("invalid requests[0].insertText")
+		}
+		return nil
+	}
+	indexPath := t.TempDir() + "/export-index.json"
+	exp := fakeExporter(t, drive, slack, indexPath)
+
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	if result.DeadLettered != 1 || result.MessageCount != 2 {
+		t.Errorf("DeadLettered = %d, MessageCount = %d; want 1 and 2", result.DeadLettered, result.MessageCount)
+	}
+	ce := exp.index.GetConversation("C001")
+	if ce.Status != "complete" {
+		t.Errorf("Status = %q, want complete", ce.Status)
+	}
+	if got := appendedTexts(drive, ce.DailyDocs["2024-02-01"].DocID); len(got) != 1 || !strings.HasPrefix(got[0], "Thread starter") {
+		t.Errorf("2024-02-01 doc content = %q, want the day written without the rejected message", got)
+	}
+
+	entries, err := exp.deadLetters.Load("C001")
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("dead letters = %v, %v; want 1", entries, err)
+	}
+	if e := entries[0]; e.Stage != DeadLetterStageDocs || e.Date != "2024-02-01" || !strings.Contains(e.Error, "insertText") {
+		t.Errorf("dead letter = %+v", e)
+	}
+
+	// After the fix, reprocessing writes it and empties the store.
+	drive.Reject = nil
+	rp, err := exp.ReprocessDeadLetters(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("ReprocessDeadLetters() error: %v", err)
+	}
+	if rp.Attempted != 1 || rp.Written != 1 || rp.Failed != 0 {
+		t.Errorf("ReprocessDeadLetters() = %+v, want 1 written", rp)
+	}
+	got := appendedTexts(drive, ce.DailyDocs["2024-02-01"].DocID)
+	if len(got) != 2 || got[1] != "Good morning" {
+		t.Errorf("2024-02-01 doc content = %q, want the reprocessed message appended", got)
+	}
+	if entries, _ := exp.deadLetters.Load("C001"); len(entries) != 0 {
+		t.Errorf("dead letters after reprocess = %d, want 0", len(entries))
+	}
+}
+
+func TestReprocessDeadLetters_KeepsFailures(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatal(err)
+	}
+	msgs := []slackapi.Message{{User: "U001", Text: "still bad", TS: "1706788900.000100"}}
+	if err := exp.deadLetters.Add("C001", DeadLetter{Stage: DeadLetterStageDocs, Date: "2024-02-01", Error: "old"}, msgs); err != nil {
+		t.Fatal(err)
+	}
+
+	drive.Errors["BatchAppendMessages"] = errors.New("invalid request")
+	rp, err := exp.ReprocessDeadLetters(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("ReprocessDeadLetters() error: %v", err)
+	}
+	if rp.Failed != 1 || rp.Written != 0 {
+		t.Errorf("ReprocessDeadLetters() = %+v, want 1 still failing", rp)
+	}
+	entries, _ := exp.deadLetters.Load("C001")
+	if len(entries) != 1 || !strings.Contains(entries[0].Error, "invalid request") {
+		t.Errorf("dead letters = %+v, want the entry kept with the new error", entries)
+	}
+}
@@ -0,0 +1,222 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/mailer"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// DigestSink delivers a rendered per-conversation digest of the messages
+// written during an export run. Implementations may send email, post to a
+// webhook, etc.
+type DigestSink interface {
+	SendDigest(ctx context.Context, subject, htmlBody string) error
+}
+
+// DigestDay is one day's worth of newly exported messages.
+type DigestDay struct {
+	Date     string
+	Messages []slackapi.Message
+}
+
+// EmailDigestSink implements DigestSink by emailing the digest over SMTP.
+type EmailDigestSink struct {
+	client *mailer.Client
+}
+
+// NewEmailDigestSink creates an EmailDigestSink backed by the given client.
+func NewEmailDigestSink(client *mailer.Client) *EmailDigestSink {
+	return &EmailDigestSink{client: client}
+}
+
+// SendDigest emails the digest.
+func (s *EmailDigestSink) SendDigest(ctx context.Context, subject, htmlBody string) error {
+	return s.client.SendHTML(ctx, subject, htmlBody)
+}
+
+// GmailDigestSink implements DigestSink by sending the digest with the
+// Gmail API as the Google account the exporter is authorized with.
+// Initialize asks for permission to send mail when authorizing Google and
+// connects the sink to the client, so it sends nothing before then.
+type GmailDigestSink struct {
+	from   string
+	to     []string
+	client *mailer.Client
+}
+
+// NewGmailDigestSink creates a GmailDigestSink sending to the given
+// recipients. An empty from sends from the authorized account's address.
+func NewGmailDigestSink(from string, to []string) *GmailDigestSink {
+	return &GmailDigestSink{from: from, to: to}
+}
+
+// SendDigest emails the digest through Gmail.
+func (s *GmailDigestSink) SendDigest(ctx context.Context, subject, htmlBody string) error {
+	if s.client == nil {
+		return fmt.Errorf("gmail digest: Google is not connected")
+	}
+	return s.client.SendHTML(ctx, subject, htmlBody)
+}
+
+// connect sends the sink's mail with send.
+func (s *GmailDigestSink) connect(send mailer.RawSendFunc) {
+	s.client = mailer.NewClient(mailer.Config{From: s.from, To: s.to}, mailer.WithRawSender(send))
+}
+
+// DefaultDigestIndexPath returns the index used by digest-only runs (see
+// ExporterConfig.DigestOnly). It is kept apart from DefaultIndexPath so
+// sending digests never marks messages as exported.
+func DefaultDigestIndexPath(configDir string) string {
+	return filepath.Join(configDir, "_metadata", "export-index.digest.json")
+}
+
+// digestBackend is the Backend of digest-only runs. It writes nothing and
+// counts every message as written, so the run's digests hold them all and
+// its index moves past them.
+type digestBackend struct {
+	e *Exporter
+}
+
+func (b digestBackend) EnsureConversationContainer(_ context.Context, conv config.ConversationConfig) (*ConversationExport, error) {
+	return b.e.index.GetOrCreateConversation(conv.ID, conv.Name, string(conv.Type)), nil
+}
+
+func (digestBackend) WriteMessages(_ context.Context, _ config.ConversationConfig, _ string, msgs []slackapi.Message, _ *ExportResult) (int, error) {
+	return len(msgs), nil
+}
+
+func (digestBackend) WriteThread(context.Context, config.ConversationConfig, slackapi.Message, []slackapi.Message, *ExportResult) error {
+	return nil
+}
+
+func (digestBackend) Finalize(context.Context, config.ConversationConfig, *ExportResult) error {
+	return nil
+}
+
+// DigestWriter renders exported messages as a self-contained HTML digest.
+// Sender names and mrkdwn conversion match the local markdown export.
+type DigestWriter struct {
+	md *MarkdownWriter
+}
+
+// NewDigestWriter creates a new DigestWriter with the given resolvers.
+func NewDigestWriter(userResolver *parser.UserResolver, channelResolver *parser.ChannelResolver, personResolver *parser.PersonResolver) *DigestWriter {
+	return &DigestWriter{md: NewMarkdownWriter(userResolver, channelResolver, personResolver)}
+}
+
+// SetEmojiResolver renders reactions with emoji (see
+// MarkdownWriter.SetEmojiResolver).
+func (w *DigestWriter) SetEmojiResolver(emoji *parser.EmojiResolver) {
+	w.md.SetEmojiResolver(emoji)
+}
+
+// Subject returns the email subject for a conversation's digest.
+func (w *DigestWriter) Subject(convName string, days []DigestDay) string {
+	switch len(days) {
+	case 0:
+		return fmt.Sprintf("Slack digest: %s", convName)
+	case 1:
+		return fmt.Sprintf("Slack digest: %s (%s)", convName, days[0].Date)
+	default:
+		return fmt.Sprintf("Slack digest: %s (%s to %s)", convName, days[0].Date, days[len(days)-1].Date)
+	}
+}
+
+// RenderHTML produces an HTML document with one section per day, messages
+// oldest first. All Slack-provided text is HTML-escaped.
+func (w *DigestWriter) RenderHTML(convName, convType string, days []DigestDay, docURL func(date string) string) string {
+	var b strings.Builder
+
+	b.WriteString("<!DOCTYPE html>\n<html><body style=\"font-family: sans-serif;\">\n")
+	b.WriteString(fmt.Sprintf("<h1>%s</h1>\n", html.EscapeString(convName)))
+	b.WriteString(fmt.Sprintf("<p style=\"color: #666;\">%s</p>\n", html.EscapeString(convType)))
+
+	for _, day := range days {
+		b.WriteString(fmt.Sprintf("<h2>%s</h2>\n", html.EscapeString(day.Date)))
+		if docURL != nil {
+			if url := docURL(day.Date); url != "" {
+				b.WriteString(fmt.Sprintf("<p><a href=\"%s\">Open in Google Docs</a></p>\n", html.EscapeString(url)))
+			}
+		}
+
+		sorted := make([]slackapi.Message, len(day.Messages))
+		copy(sorted, day.Messages)
+		sort.Slice(sorted, func(i, j int) bool {
+			return sorted[i].TS < sorted[j].TS
+		})
+		for _, msg := range sorted {
+			w.renderMessage(&b, msg)
+		}
+	}
+
+	b.WriteString("</body></html>\n")
+	return b.String()
+}
+
+// renderMessage writes a single message as an HTML block.
+func (w *DigestWriter) renderMessage(b *strings.Builder, msg slackapi.Message) {
+	b.WriteString("<div style=\"margin-bottom: 12px;\">\n")
+	b.WriteString(fmt.Sprintf("<b>%s &mdash; %s</b><br>\n",
+		html.EscapeString(parser.FormatTimestamp(msg.TS)),
+		html.EscapeString(w.md.getSenderName(msg))))
+
+	content := parser.ConvertMrkdwnToMarkdown(parser.MessageText(msg), w.md.userResolver, w.md.channelResolver, w.md.personResolver)
+	if content != "" {
+		b.WriteString(escapeLines(content))
+		b.WriteString("\n")
+	}
+
+	if reactText := formatReactions(msg.Reactions, w.md.emoji); reactText != "" {
+		b.WriteString(fmt.Sprintf("<br><small>%s</small>\n", html.EscapeString(reactText)))
+	}
+
+	if md := msg.Metadata; md != nil && md.EventType != "" {
+		b.WriteString(fmt.Sprintf("<details><summary><small>Metadata: %s</small></summary><pre>%s</pre></details>\n",
+			html.EscapeString(md.EventType), html.EscapeString(metadataPayload(md, "  "))))
+	}
+
+	if msg.ReplyCount > 0 && (msg.ThreadTS == "" || msg.TS == msg.ThreadTS) {
+		b.WriteString(fmt.Sprintf("<br><i>%d thread replies</i>\n", msg.ReplyCount))
+	}
+	b.WriteString("</div>\n")
+}
+
+// escapeLines HTML-escapes text and converts newlines to <br>.
+func escapeLines(s string) string {
+	return strings.ReplaceAll(html.EscapeString(s), "\n", "<br>\n")
+}
+
+// sendDigest renders and delivers the digest for one conversation.
+// Failures are reported as warnings: the digest is a secondary destination
+// and must not fail an export whose docs were already written. The digest
+// links to daily docs, so conversations exported only to local files get
+// none.
+func (e *Exporter) sendDigest(ctx context.Context, conv config.ConversationConfig, days []DigestDay) {
+	if e.digestSink == nil || e.digestWriter == nil || len(days) == 0 || !conv.WritesDocs() {
+		return
+	}
+	convID, convName := conv.ID, conv.Name
+
+	docURL := func(date string) string {
+		if doc := e.index.GetDailyDoc(convID, date); doc != nil {
+			return doc.DocURL
+		}
+		return ""
+	}
+
+	subject := e.digestWriter.Subject(convName, days)
+	body := e.digestWriter.RenderHTML(convName, string(conv.Type), days, docURL)
+	if err := e.digestSink.SendDigest(ctx, subject, body); err != nil {
+		e.Progress("Warning: failed to send digest for %s: %v", convName, err)
+		return
+	}
+	e.Progress("Sent digest for %s (%d days)", convName, len(days))
+}
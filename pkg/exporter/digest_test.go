@@ -0,0 +1,197 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/models"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// recordingDigestSink captures digests for assertions.
+type recordingDigestSink struct {
+	subjects []string
+	bodies   []string
+	err      error
+}
+
+func (s *recordingDigestSink) SendDigest(_ context.Context, subject, htmlBody string) error {
+	s.subjects = append(s.subjects, subject)
+	s.bodies = append(s.bodies, htmlBody)
+	return s.err
+}
+
+func TestDigestWriter_Subject(t *testing.T) {
+	w := NewDigestWriter(parser.NewUserResolver(), parser.NewChannelResolver(), nil)
+
+	tests := []struct {
+		name string
+		days []DigestDay
+		want string
+	}{
+		{"no days", nil, "Slack digest: general"},
+		{"one day", []DigestDay{{Date: "2024-02-01"}}, "Slack digest: general (2024-02-01)"},
+		{"range", []DigestDay{{Date: "2024-02-01"}, {Date: "2024-02-03"}}, "Slack digest: general (2024-02-01 to 2024-02-03)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := w.Subject("general", tt.days); got != tt.want {
+				t.Errorf("Subject() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDigestWriter_RenderHTML(t *testing.T) {
+	w := NewDigestWriter(parser.NewUserResolver(), parser.NewChannelResolver(), nil)
+
+	days := []DigestDay{{
+		Date: "2024-02-01",
+		Messages: []slackapi.Message{
+			{User: "U001", Text: "second", TS: "1706788802.000000"},
+			{Username: "deploybot", Text: "<script>alert(1)</script>", TS: "1706788801.000000"},
+		},
+	}}
+	docURL := func(date string) string { return "https://docs.google.com/document/d/doc-" + date }
+
+	out := w.RenderHTML("eng & ops", "channel", days, docURL)
+
+	for _, want := range []string{
+		"<h1>eng &amp; ops</h1>",
+		"<h2>2024-02-01</h2>",
+		`href="https://docs.google.com/document/d/doc-2024-02-01"`,
+		"deploybot [bot]",
+		"&lt;script&gt;",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("RenderHTML() missing %q", want)
+		}
+	}
+	if strings.Contains(out, "<script>") {
+		t.Error("RenderHTML() must escape message text")
+	}
+	if strings.Index(out, "&lt;script&gt;") > strings.Index(out, "second") {
+		t.Error("messages should be rendered oldest first")
+	}
+}
+
+func TestExportConversation_SendsDigest(t *testing.T) {
+	slackMux := fullMockSlackMux(t, nil)
+	driveMux, _, _ := fullMockDriveMux(t)
+
+	exp := testExporter(t, driveMux, slackMux)
+	exp.docWriter = NewDocWriter(exp.gdriveClient, exp.slackClient, exp.userResolver, exp.channelResolver, nil, nil, nil)
+	sink := &recordingDigestSink{}
+	exp.digestSink = sink
+	exp.digestWriter = NewDigestWriter(exp.userResolver, exp.channelResolver, nil)
+
+	conv := config.ConversationConfig{ID: "C001", Name: "general", Type: models.ConversationTypeChannel}
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(sink.subjects) != 1 {
+		t.Fatalf("expected 1 digest, got %d", len(sink.subjects))
+	}
+	if !strings.Contains(sink.subjects[0], "general") {
+		t.Errorf("subject = %q, want conversation name", sink.subjects[0])
+	}
+	if !strings.Contains(sink.bodies[0], "<h2>") {
+		t.Error("digest body should contain a section per day")
+	}
+}
+
+func TestExportConversation_DigestErrorIsNotFatal(t *testing.T) {
+	slackMux := fullMockSlackMux(t, nil)
+	driveMux, _, _ := fullMockDriveMux(t)
+
+	exp := testExporter(t, driveMux, slackMux)
+	exp.docWriter = NewDocWriter(exp.gdriveClient, exp.slackClient, exp.userResolver, exp.channelResolver, nil, nil, nil)
+	exp.digestSink = &recordingDigestSink{err: errors.New("smtp down")}
+	exp.digestWriter = NewDigestWriter(exp.userResolver, exp.channelResolver, nil)
+
+	var warned bool
+	exp.onProgress = func(msg string) {
+		if strings.Contains(msg, "failed to send digest") {
+			warned = true
+		}
+	}
+
+	conv := config.ConversationConfig{ID: "C001", Name: "general", Type: models.ConversationTypeChannel}
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("digest failure should not fail the export: %v", err)
+	}
+	if result.MessageCount == 0 {
+		t.Error("expected messages to be written")
+	}
+	if !warned {
+		t.Error("expected a progress warning for the failed digest")
+	}
+}
+
+func TestSendDigest_SkipsLocalOnlyConversations(t *testing.T) {
+	exp := NewExporter(&ExporterConfig{ConfigDir: t.TempDir()})
+	sink := &recordingDigestSink{}
+	exp.digestSink = sink
+	exp.digestWriter = NewDigestWriter(parser.NewUserResolver(), parser.NewChannelResolver(), nil)
+
+	conv := config.ConversationConfig{ID: "C001", Name: "general", Type: models.ConversationTypeChannel, Format: config.OutputFormatMarkdown}
+	days := []DigestDay{{Date: "2024-02-01", Messages: []slackapi.Message{{User: "U001", Text: "hi", TS: "1706788800.000100"}}}}
+	exp.sendDigest(context.Background(), conv, days)
+	if len(sink.subjects) != 0 {
+		t.Errorf("sent %d digests for a markdown-only conversation, want none", len(sink.subjects))
+	}
+}
+
+func TestExportConversation_DigestOnly(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	exp := fakeExporter(t, drive, slack, DefaultDigestIndexPath(t.TempDir()))
+	exp.digestOnly = true
+	exp.backend = digestBackend{exp}
+	sink := &recordingDigestSink{}
+	exp.digestSink = sink
+	exp.digestWriter = NewDigestWriter(exp.userResolver, exp.channelResolver, nil)
+
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	if len(sink.subjects) != 1 || result.MessageCount != 3 {
+		t.Fatalf("sent %d digests of %d messages, want 1 of 3", len(sink.subjects), result.MessageCount)
+	}
+	for _, method := range []string{"FindOrCreateFolder", "FindOrCreateDocument", "BatchAppendMessages"} {
+		if n := drive.Calls(method); n != 0 {
+			t.Errorf("%s called %d times, want nothing written", method, n)
+		}
+	}
+	if n := slack.Calls("GetAllReplies"); n != 0 {
+		t.Errorf("fetched %d threads, want none", n)
+	}
+	if got := exp.index.GetConversation(conv.ID); got == nil || got.LastMessageTS != "1706875200.000300" {
+		t.Errorf("index entry = %+v, want the digest's cursor at the newest message", got)
+	}
+}
+
+func TestGmailDigestSink(t *testing.T) {
+	sink := NewGmailDigestSink("", []string{"archive@example.com"})
+	if err := sink.SendDigest(context.Background(), "s", "b"); err == nil {
+		t.Error("SendDigest() before Initialize should fail")
+	}
+
+	var sent []byte
+	sink.connect(func(_ context.Context, msg []byte) error {
+		sent = msg
+		return nil
+	})
+	if err := sink.SendDigest(context.Background(), "Slack digest: general", "<p>hi</p>"); err != nil {
+		t.Fatalf("SendDigest() error: %v", err)
+	}
+	if !strings.Contains(string(sent), "To: archive@example.com") {
+		t.Errorf("sent message:\n%s", sent)
+	}
+}
@@ -0,0 +1,103 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/models"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// DMDiscoveryOptions are the activity thresholds a DM must meet to be
+// discovered.
+type DMDiscoveryOptions struct {
+	// MinMessages is the number of messages a DM needs (counted from
+	// ActiveSince when set). Values below 1 are treated as 1, so empty DMs
+	// are always skipped.
+	MinMessages int
+
+	// ActiveSince is a Slack timestamp; only messages after it count.
+	// Empty counts the whole history.
+	ActiveSince string
+}
+
+// DiscoverDMs lists the current user's DMs via conversations.list and
+// returns those not in known (by ID or alias) that meet opts, named after
+// the other person. Each DM costs one conversations.history call of at most
+// MinMessages messages, so dormant DMs are skipped cheaply.
+func (e *Exporter) DiscoverDMs(ctx context.Context, known []config.ConversationConfig, opts DMDiscoveryOptions) ([]config.ConversationConfig, error) {
+	minMessages := max(opts.MinMessages, 1)
+
+	skip := make(map[string]bool)
+	for _, c := range known {
+		skip[c.ID] = true
+		for _, alias := range c.Aliases {
+			skip[alias] = true
+		}
+	}
+
+	var candidates []slackapi.Conversation
+	cursor := ""
+	for {
+		resp, err := e.slackClient.ListConversations(ctx, &slackapi.ListConversationsOptions{
+			Cursor: cursor,
+			Types:  []string{"im"},
+		})
+		if err != nil {
+			if slackapi.IsRestrictedError(err) {
+				return nil, fmt.Errorf("cannot discover DMs: conversations.list is restricted in this workspace; add DMs to conversations.json instead: %w", err)
+			}
+			return nil, fmt.Errorf("failed to list DMs: %w", err)
+		}
+		for _, c := range resp.Channels {
+			if !skip[c.ID] {
+				candidates = append(candidates, c)
+			}
+		}
+		cursor = resp.ResponseMetadata.NextCursor
+		if cursor == "" {
+			break
+		}
+	}
+	e.Detail("Found %d DMs not in conversations.json", len(candidates))
+
+	var found []config.ConversationConfig
+	var users []string
+	for _, c := range candidates {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		resp, err := e.slackClient.GetConversationHistory(ctx, c.ID, &slackapi.HistoryOptions{
+			Limit:  minMessages,
+			Oldest: opts.ActiveSince,
+		})
+		if err != nil {
+			e.Detail("Skipping DM %s: %v", c.ID, err)
+			continue
+		}
+		if len(resp.Messages) < minMessages {
+			continue
+		}
+		found = append(found, config.ConversationConfig{ID: c.ID, Name: c.User, Type: models.ConversationTypeDM, Export: true})
+		users = append(users, c.User)
+	}
+
+	// Name each DM after the other person, as conversations.json does.
+	if len(users) > 0 && e.capabilities.Usable(slackapi.MethodUsersInfo) {
+		if err := e.userResolver.LoadUsersByID(ctx, e.slackClient, users); err != nil {
+			e.Progress("Warning: failed to look up DM partners: %v", err)
+		}
+	}
+	for i := range found {
+		userID := found[i].Name
+		if e.personResolver != nil {
+			if name := e.personResolver.ResolveName(userID); name != "" {
+				found[i].Name = name
+				continue
+			}
+		}
+		found[i].Name = e.userResolver.Resolve(userID)
+	}
+	return found, nil
+}
@@ -0,0 +1,57 @@
+package exporter
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+func TestDiscoverDMs(t *testing.T) {
+	drive, slack, _ := fakeConversation()
+	slack.Users = []slackapi.User{{ID: "U010", Name: "carol"}, {ID: "U011", Name: "dave"}}
+	slack.Conversations = []slackapi.Conversation{
+		{ID: "D001", IsIM: true, User: "U001"}, // configured
+		{ID: "D002", IsIM: true, User: "U002"}, // alias of a configured DM
+		{ID: "D010", IsIM: true, User: "U010"}, // active
+		{ID: "D011", IsIM: true, User: "U011"}, // only old messages
+		{ID: "D012", IsIM: true, User: "U012"}, // empty
+		{ID: "C100", Name: "general"},
+	}
+	slack.Messages["D010"] = []slackapi.Message{{TS: "1706788800.000100"}, {TS: "1706788900.000100"}}
+	slack.Messages["D011"] = []slackapi.Message{{TS: "1600000000.000100"}, {TS: "1600000100.000100"}}
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	known := []config.ConversationConfig{{ID: "D001", Name: "alice", Type: "dm", Aliases: []string{"D002"}}}
+
+	found, err := exp.DiscoverDMs(context.Background(), known, DMDiscoveryOptions{MinMessages: 2, ActiveSince: "1700000000.000000"})
+	if err != nil {
+		t.Fatalf("DiscoverDMs() error: %v", err)
+	}
+	if len(found) != 1 || found[0].ID != "D010" {
+		t.Fatalf("DiscoverDMs() = %+v, want only D010", found)
+	}
+	if found[0].Name != "carol" || found[0].Type != "dm" || !found[0].Export {
+		t.Errorf("discovered DM = %+v, want an exported dm named carol", found[0])
+	}
+
+	found, err = exp.DiscoverDMs(context.Background(), known, DMDiscoveryOptions{})
+	if err != nil {
+		t.Fatalf("DiscoverDMs() error: %v", err)
+	}
+	if len(found) != 2 {
+		t.Errorf("DiscoverDMs() without thresholds = %+v, want D010 and D011 (empty DMs are skipped)", found)
+	}
+}
+
+func TestDiscoverDMs_ListRestricted(t *testing.T) {
+	drive, slack, _ := fakeConversation()
+	slack.Errors["ListConversations"] = errMissingScope
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+
+	_, err := exp.DiscoverDMs(context.Background(), nil, DMDiscoveryOptions{})
+	if err == nil || !strings.Contains(err.Error(), "conversations.json") {
+		t.Errorf("DiscoverDMs() error = %v, want a hint to configure DMs instead", err)
+	}
+}
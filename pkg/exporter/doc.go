@@ -0,0 +1,31 @@
+// Package exporter handles the export of Slack messages to Google Docs.
+//
+// It is the engine behind the get-out CLI and can be embedded in other Go
+// programs. New creates an Exporter that keeps its state (the export
+// index, user cache, and Google credentials) in a get-out config
+// directory; options choose the Drive folder, the Slack credentials, and
+// where progress goes. Initialize connects to Slack and Google, after
+// which ExportConversation, ExportAll, and ExportAllParallel export
+// conversations described by config.ConversationConfig:
+//
+//	logf := func(msg string) { log.Print(msg) }
+//	store, backend := secrets.NewStore(false, configDir) // keychain or files
+//	logf("Reading Google credentials from the " + backend.String())
+//	exp := exporter.New(configDir,
+//		exporter.WithSlackToken(token, ""),
+//		exporter.WithProgress(logf, nil),
+//		exporter.WithNotices(logf),
+//		exporter.WithSync(),
+//	)
+//	if err := exp.Initialize(ctx, store); err != nil {
+//		return err
+//	}
+//	results, err := exp.ExportAll(ctx, conversations)
+//
+// Every method that does I/O takes a context as its first argument, and
+// cancelling it stops the export. Unless ExporterConfig.Debug is set, the
+// exporter writes nothing to stdout or stderr itself: progress goes to the
+// callbacks given with WithProgress, and what the Slack and Google clients
+// would print (such as the Google authorization URL) to WithNotices. See
+// examples/export for a complete program.
+package exporter
@@ -1,12 +1,17 @@
 package exporter
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
 	"time"
+	"unicode/utf16"
 
+	"github.com/jflowers/get-out/pkg/config"
 	"github.com/jflowers/get-out/pkg/gdrive"
 	"github.com/jflowers/get-out/pkg/parser"
 	"github.com/jflowers/get-out/pkg/slackapi"
@@ -14,17 +19,28 @@ import (
 
 // DocWriter handles writing messages to Google Docs.
 type DocWriter struct {
-	client          *gdrive.Client
-	slackClient     *slackapi.Client
+	client          DriveSink
+	slackClient     SlackSource
 	userResolver    *parser.UserResolver
 	channelResolver *parser.ChannelResolver
 	personResolver  *parser.PersonResolver
 	linkResolver    parser.SlackLinkResolver
 	threadResolver  parser.SlackLinkResolver
+
+	channelLinkResolver parser.ChannelLinkResolver
+	fileLinker          FileLinker
+	emoji               *parser.EmojiResolver
+	images              *config.ImageConfig
+	multiDay            func(convID, date string) bool
 }
 
+// FileLinker returns the link to an archived copy of a message's
+// attachment, or "" to leave it unlinked. convID is the Slack conversation
+// the message belongs to.
+type FileLinker func(ctx context.Context, convID string, file slackapi.File) string
+
 // NewDocWriter creates a new doc writer.
-func NewDocWriter(client *gdrive.Client, slackClient *slackapi.Client, userResolver *parser.UserResolver, channelResolver *parser.ChannelResolver, personResolver *parser.PersonResolver, linkResolver parser.SlackLinkResolver, threadResolver parser.SlackLinkResolver) *DocWriter {
+func NewDocWriter(client DriveSink, slackClient SlackSource, userResolver *parser.UserResolver, channelResolver *parser.ChannelResolver, personResolver *parser.PersonResolver, linkResolver parser.SlackLinkResolver, threadResolver parser.SlackLinkResolver) *DocWriter {
 	return &DocWriter{
 		client:          client,
 		slackClient:     slackClient,
@@ -36,14 +52,224 @@ func NewDocWriter(client *gdrive.Client, slackClient *slackapi.Client, userResol
 	}
 }
 
+// SetChannelLinkResolver links #channel mentions to the channel's export
+// when resolver returns a URL for it.
+func (w *DocWriter) SetChannelLinkResolver(resolver parser.ChannelLinkResolver) {
+	w.channelLinkResolver = resolver
+}
+
+// SetFileLinker links each attachment's [File: name] reference to the URL
+// linker returns for it.
+func (w *DocWriter) SetFileLinker(linker FileLinker) {
+	w.fileLinker = linker
+}
+
+// SetImageLimits scales down embedded images to fit cfg, or to the
+// default limits when cfg is nil.
+func (w *DocWriter) SetImageLimits(cfg *config.ImageConfig) {
+	w.images = cfg
+}
+
+// SetEmojiResolver renders reactions with emoji: standard emoji as
+// Unicode, custom emoji as :name: linked to their image.
+func (w *DocWriter) SetEmojiResolver(emoji *parser.EmojiResolver) {
+	w.emoji = emoji
+}
+
+// SetMultiDayDocs shows the date along with the time of each message
+// whose conversation and date multiDay reports true for, as the doc
+// holding it holds more than one day.
+func (w *DocWriter) SetMultiDayDocs(multiDay func(convID, date string) bool) {
+	w.multiDay = multiDay
+}
+
 // WriteMessages writes messages to a Google Doc.
 // convID is the Slack conversation ID (for thread link resolution).
 // folderID is the ID of the conversation folder (used for temp image uploads).
 func (w *DocWriter) WriteMessages(ctx context.Context, docID string, convID string, folderID string, messages []slackapi.Message) error {
+	blocks := w.BuildBlocks(ctx, convID, folderID, messages)
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	return w.client.BatchAppendMessages(ctx, docID, blocks)
+}
+
+// BuildBlocks converts messages, oldest first, into the doc message blocks
+// that WriteMessages appends. Messages that render to nothing are dropped.
+// With a nil Drive or Slack client, images are listed as file references
+// instead of being uploaded.
+func (w *DocWriter) BuildBlocks(ctx context.Context, convID string, folderID string, messages []slackapi.Message) []gdrive.MessageBlock {
 	if len(messages) == 0 {
 		return nil
 	}
 
+	var blocks []gdrive.MessageBlock
+	for _, mb := range w.buildMessageBlocks(ctx, convID, folderID, messages) {
+		blocks = append(blocks, mb.block)
+	}
+	return blocks
+}
+
+// MessageError is a message that could not be written, with the reason.
+type MessageError struct {
+	Message slackapi.Message
+	Err     error
+}
+
+// DocAppend is the outcome of appending messages to a doc.
+type DocAppend struct {
+	// Skipped is how many of the oldest messages WriteMessagesAfter found
+	// already in the doc, or -1 when it found none of them and wrote all.
+	Skipped int
+
+	// Grown is how much the doc grew, as gdrive.AppendedLength counts it:
+	// through the appends, and for WriteMessagesAfter also since
+	// recordedEnd, so the doc now ends at recordedEnd + Grown.
+	Grown int64
+
+	// Failed lists the messages the Docs API rejected.
+	Failed []MessageError
+}
+
+// WriteMessagesIsolated writes messages like WriteMessages, but when the
+// Docs API rejects the batch it appends each message on its own, so one bad
+// message does not lose the whole day. It returns the messages that still
+// failed. The batch error is returned instead when no message could be
+// written, since that points at the doc or the connection rather than at a
+// message.
+func (w *DocWriter) WriteMessagesIsolated(ctx context.Context, docID string, convID string, folderID string, messages []slackapi.Message) (DocAppend, error) {
+	return w.appendIsolated(ctx, docID, w.buildMessageBlocks(ctx, convID, folderID, messages))
+}
+
+// WriteMessagesAfter writes messages like WriteMessagesIsolated to a doc
+// the export index last recorded as ending at recordedEnd. When the doc has
+// since grown by exactly the length of its oldest messages, as laid out by
+// BatchAppendMessages, and the end of the doc holds their headers, an
+// interrupted run wrote them without recording it, and only the rest are
+// appended. The doc is read only here, when resuming, so recordedEnd must
+// be kept current by every write to the doc (see DocAppend.Grown).
+func (w *DocWriter) WriteMessagesAfter(ctx context.Context, docID string, convID string, folderID string, recordedEnd int64, messages []slackapi.Message) (DocAppend, error) {
+	built := w.buildMessageBlocks(ctx, convID, folderID, messages)
+	if len(built) == 0 {
+		return DocAppend{}, nil
+	}
+	end, err := w.client.GetDocumentEndIndex(ctx, docID)
+	if err != nil {
+		return DocAppend{}, err
+	}
+	skipped := writtenPrefix(built, end-recordedEnd)
+	if skipped > 0 {
+		content, err := w.client.GetDocumentContent(ctx, docID)
+		if err != nil {
+			return DocAppend{}, err
+		}
+		if !headersAtEnd(content, end-recordedEnd, built[:skipped]) {
+			skipped = -1
+		}
+	}
+	var res DocAppend
+	if skipped < 0 {
+		res, err = w.appendIsolated(ctx, docID, built)
+	} else {
+		res, err = w.appendIsolated(ctx, docID, built[skipped:])
+	}
+	res.Skipped = skipped
+	res.Grown += end - recordedEnd
+	return res, err
+}
+
+// writtenPrefix returns how many of the oldest blocks add up to grown, the
+// length a doc gained after its last recorded write, or -1 when no prefix
+// of blocks does.
+func writtenPrefix(built []messageBlock, grown int64) int {
+	if grown < 0 {
+		return -1
+	}
+	var length int64
+	for i := 0; length < grown && i < len(built); i++ {
+		length += gdrive.AppendedLength(built[i].block)
+		if length == grown {
+			return i + 1
+		}
+	}
+	if grown == 0 {
+		return 0
+	}
+	return -1
+}
+
+// headersAtEnd reports whether the last grown characters of content, a
+// doc's text, hold the header lines of built in order, as they do when
+// BatchAppendMessages appended them. Matching the length alone would take
+// any other change of the same size for written messages.
+func headersAtEnd(content string, grown int64, built []messageBlock) bool {
+	units := utf16.Encode([]rune(content))
+	// Inline images take an index but no text, so the tail read may reach
+	// a little before the appended messages; the headers must still follow
+	// one another.
+	if int64(len(units)) > grown+1 {
+		units = units[int64(len(units))-grown-1:]
+	}
+	tail := string(utf16.Decode(units))
+	for _, mb := range built {
+		header := mb.block.SenderName + "  " + mb.block.Timestamp
+		i := strings.Index(tail, header)
+		if i < 0 {
+			return false
+		}
+		tail = tail[i+len(header):]
+	}
+	return true
+}
+
+// appendIsolated appends built blocks to docID for WriteMessagesIsolated.
+func (w *DocWriter) appendIsolated(ctx context.Context, docID string, built []messageBlock) (DocAppend, error) {
+	if len(built) == 0 {
+		return DocAppend{}, nil
+	}
+	blocks := make([]gdrive.MessageBlock, len(built))
+	var grown int64
+	for i, mb := range built {
+		blocks[i] = mb.block
+		grown += gdrive.AppendedLength(mb.block)
+	}
+	batchErr := w.client.BatchAppendMessages(ctx, docID, blocks)
+	if batchErr == nil {
+		return DocAppend{Grown: grown}, nil
+	}
+	if len(built) == 1 || ctx.Err() != nil {
+		return DocAppend{}, batchErr
+	}
+
+	// A rejected batchUpdate is applied atomically, so nothing was written
+	// and each message can be retried alone.
+	var res DocAppend
+	for _, mb := range built {
+		if err := w.client.BatchAppendMessages(ctx, docID, []gdrive.MessageBlock{mb.block}); err != nil {
+			if ctx.Err() != nil {
+				return res, err
+			}
+			res.Failed = append(res.Failed, MessageError{Message: mb.msg, Err: err})
+			continue
+		}
+		res.Grown += gdrive.AppendedLength(mb.block)
+	}
+	if len(res.Failed) == len(built) {
+		return DocAppend{}, batchErr
+	}
+	return res, nil
+}
+
+type messageBlock struct {
+	msg   slackapi.Message
+	block gdrive.MessageBlock
+}
+
+// buildMessageBlocks converts messages, oldest first, into doc message
+// blocks paired with their source message. Messages that render to nothing
+// are dropped.
+func (w *DocWriter) buildMessageBlocks(ctx context.Context, convID string, folderID string, messages []slackapi.Message) []messageBlock {
 	// Sort messages by timestamp (oldest first)
 	sorted := make([]slackapi.Message, len(messages))
 	copy(sorted, messages)
@@ -52,19 +278,14 @@ func (w *DocWriter) WriteMessages(ctx context.Context, docID string, convID stri
 	})
 
 	// Convert to message blocks
-	var blocks []gdrive.MessageBlock
+	var built []messageBlock
 	for _, msg := range sorted {
 		block := w.messageToBlock(ctx, convID, folderID, msg)
 		if block.Content != "" || block.SenderName != "" || len(block.Images) > 0 {
-			blocks = append(blocks, block)
+			built = append(built, messageBlock{msg: msg, block: block})
 		}
 	}
-
-	if len(blocks) == 0 {
-		return nil
-	}
-
-	return w.client.BatchAppendMessages(ctx, docID, blocks)
+	return built
 }
 
 // messageToBlock converts a Slack message to a doc message block.
@@ -72,11 +293,23 @@ func (w *DocWriter) messageToBlock(ctx context.Context, convID string, folderID
 	// Get sender name
 	senderName := w.getSenderName(msg)
 
-	// Format timestamp
+	// Format timestamp, dated in a doc holding more than one day
 	timestamp := formatMessageTime(msg.TS)
+	if w.multiDay != nil && w.multiDay(convID, DateFromTS(msg.TS)) {
+		timestamp = parser.FormatTimestampFull(msg.TS)
+	}
 
-	// Convert message text and collect link annotations
-	content, links := parser.ConvertMrkdwnWithLinks(msg.Text, w.userResolver, w.channelResolver, w.personResolver, w.linkResolver)
+	// A message of only emoji or a GIF goes on its header's line
+	if c, ok := compactMessageOf(msg); ok {
+		return w.compactBlock(senderName, timestamp, msg, c)
+	}
+
+	// Convert message text, keeping its formatting, and collect link
+	// annotations
+	text := parser.MessageText(msg)
+	segments, links := parser.ParseMrkdwnSegments(text, w.userResolver, w.channelResolver, w.personResolver, w.linkResolver)
+	links = append(links, parser.ChannelMentionLinks(text, w.channelResolver, w.channelLinkResolver)...)
+	content, formatted := formattedRuns(segments)
 
 	// Convert parser.LinkAnnotation to gdrive.LinkAnnotation
 	var docLinks []gdrive.LinkAnnotation
@@ -107,7 +340,8 @@ func (w *DocWriter) messageToBlock(ctx context.Context, convID string, folderID
 	}
 
 	// Process files (handle images)
-	fileText, docImages := w.processMessageFiles(ctx, msg.Files, folderID)
+	fileText, fileLinks, docImages := w.processMessageFiles(ctx, convID, msg.Files, folderID)
+	docLinks = append(docLinks, fileLinks...)
 	if fileText != "" {
 		if content != "" {
 			content += "\n"
@@ -116,38 +350,84 @@ func (w *DocWriter) messageToBlock(ctx context.Context, convID string, folderID
 	}
 
 	// Add reactions if present
-	reactText := formatReactions(msg.Reactions)
+	reactText := formatReactions(msg.Reactions, w.emoji)
 	if reactText != "" {
 		if content != "" {
 			content += "\n"
 		}
 		content += reactText
+		for _, l := range reactionLinks(msg.Reactions, w.emoji) {
+			docLinks = append(docLinks, gdrive.LinkAnnotation{Text: l.Text, URL: l.URL})
+		}
+	}
+
+	// Add app metadata if present (Docs has no collapsible blocks, so it
+	// is appended as a single compact line)
+	metaText := formatMetadata(msg.Metadata)
+	if metaText != "" {
+		if content != "" {
+			content += "\n"
+		}
+		content += metaText
 	}
 
 	return gdrive.MessageBlock{
 		SenderName: senderName,
 		Timestamp:  timestamp,
 		Content:    content,
+		Formatted:  formatted,
 		Links:      docLinks,
 		Images:     docImages,
 	}
 }
 
-// formatReactions converts a slice of Slack reactions into a display string.
-// Returns an empty string when there are no reactions.
-func formatReactions(reactions []slackapi.Reaction) string {
-	if len(reactions) == 0 {
-		return ""
+// compactBlock converts a message of only emoji or a GIF, c, to a doc
+// message block on a single line: its header, then c and its reactions.
+func (w *DocWriter) compactBlock(senderName, timestamp string, msg slackapi.Message, c compactMessage) gdrive.MessageBlock {
+	content := c.text(w.emoji)
+	var docLinks []gdrive.LinkAnnotation
+	for _, l := range c.links(w.emoji) {
+		docLinks = append(docLinks, gdrive.LinkAnnotation{Text: l.Text, URL: l.URL})
+	}
+	if reactText := formatReactions(msg.Reactions, w.emoji); reactText != "" {
+		content += "  " + reactText
+		for _, l := range reactionLinks(msg.Reactions, w.emoji) {
+			docLinks = append(docLinks, gdrive.LinkAnnotation{Text: l.Text, URL: l.URL})
+		}
+	}
+	return gdrive.MessageBlock{
+		SenderName: senderName,
+		Timestamp:  timestamp,
+		Content:    content,
+		Links:      docLinks,
+		Compact:    true,
 	}
-	var b strings.Builder
-	b.WriteString("Reactions: ")
-	for i, r := range reactions {
-		if i > 0 {
-			b.WriteString(" ")
+}
+
+// formattedRuns converts parsed message segments to doc text runs, and
+// returns their text. Text without formatting needs no runs of its own.
+func formattedRuns(segments []parser.Segment) (string, []gdrive.FormattedText) {
+	var content strings.Builder
+	var runs []gdrive.FormattedText
+	styled := false
+	for _, s := range segments {
+		content.WriteString(s.Text)
+		run := gdrive.FormattedText{
+			Text:      s.Text,
+			Bold:      s.Bold,
+			Italic:    s.Italic,
+			Strike:    s.Strike,
+			Monospace: s.Code,
+			CodeBlock: s.CodeBlock,
+			Quote:     s.Quote,
 		}
-		b.WriteString(fmt.Sprintf(":%s: (%d)", r.Name, r.Count))
+		styled = styled || run != gdrive.FormattedText{Text: s.Text}
+		runs = append(runs, run)
 	}
-	return b.String()
+	if !styled {
+		runs = nil
+	}
+	return content.String(), runs
 }
 
 // formatAttachments converts a slice of Slack attachments into a display string.
@@ -168,24 +448,77 @@ func formatAttachments(attachments []slackapi.Attachment) string {
 	return strings.Join(parts, "\n")
 }
 
+// formatMetadata converts app message metadata into a single display line.
+// Returns an empty string when there is no metadata.
+func formatMetadata(md *slackapi.MessageMetadata) string {
+	if md == nil || md.EventType == "" {
+		return ""
+	}
+	payload := metadataPayload(md, "")
+	if payload == "" {
+		return fmt.Sprintf("Metadata (%s)", md.EventType)
+	}
+	return fmt.Sprintf("Metadata (%s): %s", md.EventType, payload)
+}
+
+// metadataPayload returns the event payload as JSON: compact when indent is
+// empty, otherwise indented. Invalid JSON is returned as-is.
+func metadataPayload(md *slackapi.MessageMetadata, indent string) string {
+	raw := bytes.TrimSpace(md.EventPayload)
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var buf bytes.Buffer
+	var err error
+	if indent == "" {
+		err = json.Compact(&buf, raw)
+	} else {
+		err = json.Indent(&buf, raw, "", indent)
+	}
+	if err != nil {
+		return string(raw)
+	}
+	return buf.String()
+}
+
 // processMessageFiles handles file download/upload for images and text references
-// for non-image files. Returns any text to append and image annotations to embed.
-func (w *DocWriter) processMessageFiles(ctx context.Context, files []slackapi.File, folderID string) (string, []gdrive.ImageAnnotation) {
+// for non-image files. Returns any text to append, links to the archived copies of
+// the files (when a FileLinker is set), and image annotations to embed.
+func (w *DocWriter) processMessageFiles(ctx context.Context, convID string, files []slackapi.File, folderID string) (string, []gdrive.LinkAnnotation, []gdrive.ImageAnnotation) {
 	if len(files) == 0 {
-		return "", nil
+		return "", nil, nil
 	}
 
 	var textParts []string
+	var docLinks []gdrive.LinkAnnotation
 	var docImages []gdrive.ImageAnnotation
 
 	for _, file := range files {
+		// An archived copy gets a linked text reference, images included
+		linked := false
+		if w.fileLinker != nil {
+			if url := w.fileLinker(ctx, convID, file); url != "" {
+				ref := fmt.Sprintf("[File: %s]", file.Name)
+				textParts = append(textParts, ref)
+				docLinks = append(docLinks, gdrive.LinkAnnotation{Text: ref, URL: url})
+				linked = true
+			}
+		}
+
 		// If it's an image, try to embed it
 		if strings.HasPrefix(file.Mimetype, "image/") && w.slackClient != nil && w.client != nil {
-			// Download from Slack
+			// Download from Slack, then scale it down to the image limits
 			data, err := w.slackClient.DownloadFile(ctx, file.URLPrivateDownload)
+			var img fittedImage
+			if err == nil {
+				img, err = fitImage(data, file.Mimetype, newImageLimits(w.images))
+				if errors.Is(err, errImageTooLarge) && !linked {
+					textParts = append(textParts, fmt.Sprintf("[File: %s]", file.Name))
+				}
+			}
 			if err == nil {
 				// Upload to Drive temporarily
-				fileID, err := w.client.UploadFile(ctx, file.Name, file.Mimetype, data, folderID)
+				fileID, err := w.client.UploadFile(ctx, file.Name, img.mimetype, img.data, folderID)
 				if err == nil {
 					// Make public temporarily for Docs API embed, then delete to
 					// prevent private Slack content from remaining publicly accessible.
@@ -193,7 +526,13 @@ func (w *DocWriter) processMessageFiles(ctx context.Context, files []slackapi.Fi
 						// Get web content link
 						url, err := w.client.GetWebContentLink(ctx, fileID)
 						if err == nil {
-							docImages = append(docImages, gdrive.ImageAnnotation{URL: url})
+							width, height := img.displaySize()
+							docImages = append(docImages, gdrive.ImageAnnotation{
+								URL:     url,
+								Width:   width,
+								Height:  height,
+								Caption: w.imageCaption(file),
+							})
 						}
 						// Delete the temp Drive file regardless of whether we got
 						// the link — the public permission must not persist.
@@ -201,13 +540,26 @@ func (w *DocWriter) processMessageFiles(ctx context.Context, files []slackapi.Fi
 					}
 				}
 			}
-		} else {
+		} else if !linked {
 			// Non-image file: just add a text reference
 			textParts = append(textParts, fmt.Sprintf("[File: %s]", file.Name))
 		}
 	}
 
-	return strings.Join(textParts, "\n"), docImages
+	return strings.Join(textParts, "\n"), docLinks, docImages
+}
+
+// imageCaption returns the caption under an embedded image: the file's
+// name, who uploaded it, and when, so the archive can be searched for it.
+func (w *DocWriter) imageCaption(file slackapi.File) string {
+	parts := []string{file.Name}
+	if file.User != "" {
+		parts = append(parts, "uploaded by "+w.getSenderName(slackapi.Message{User: file.User}))
+	}
+	if file.Created > 0 {
+		parts = append(parts, parser.FormatTime(time.Unix(file.Created, 0)))
+	}
+	return strings.Join(parts, " · ")
 }
 
 // getSenderName returns the display name for a message sender.
@@ -264,20 +616,12 @@ func (w *DocWriter) getSenderName(msg slackapi.Message) string {
 
 // formatMessageTime formats a timestamp for display in the doc.
 func formatMessageTime(ts string) string {
-	t := parseSlackTS(ts)
-	return t.Format("3:04 PM")
+	return parser.FormatTimestamp(ts)
 }
 
-// parseSlackTS parses a Slack timestamp string.
+// parseSlackTS parses a Slack timestamp string into the export's time zone.
 func parseSlackTS(ts string) time.Time {
-	var sec int64
-	for i := 0; i < len(ts); i++ {
-		if ts[i] == '.' {
-			break
-		}
-		sec = sec*10 + int64(ts[i]-'0')
-	}
-	return time.Unix(sec, 0)
+	return parser.SlackTime(ts)
 }
 
 // GroupMessagesByDate groups messages by their date.
@@ -1,9 +1,16 @@
 package exporter
 
 import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/gdrive"
 	"github.com/jflowers/get-out/pkg/parser"
 	"github.com/jflowers/get-out/pkg/slackapi"
 )
@@ -38,6 +45,29 @@ func TestGroupMessagesByDate(t *testing.T) {
 	}
 }
 
+func TestGroupMessagesByDate_TimeZone(t *testing.T) {
+	t.Cleanup(func() { parser.SetTimeSettings(parser.TimeSettings{}) })
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("no time zone data: %v", err)
+	}
+	// 2024-01-31 23:30 UTC is 2024-02-01 08:30 in Tokyo.
+	msgs := []slackapi.Message{{TS: "1706743800.000100"}}
+
+	parser.SetTimeSettings(parser.TimeSettings{Location: time.UTC})
+	if groups := GroupMessagesByDate(msgs); len(groups["2024-01-31"]) != 1 {
+		t.Errorf("UTC: got keys %v, want 2024-01-31", dateKeys(groups))
+	}
+
+	parser.SetTimeSettings(parser.TimeSettings{Location: tokyo, TimeLayout: "15:04"})
+	if groups := GroupMessagesByDate(msgs); len(groups["2024-02-01"]) != 1 {
+		t.Errorf("Tokyo: got keys %v, want 2024-02-01", dateKeys(groups))
+	}
+	if got := formatMessageTime(msgs[0].TS); got != "08:30" {
+		t.Errorf("formatMessageTime() = %q, want 08:30", got)
+	}
+}
+
 // dateKeys returns the keys of a date-grouped messages map for error output.
 func dateKeys(groups map[string][]slackapi.Message) []string {
 	keys := make([]string, 0, len(groups))
@@ -171,6 +201,56 @@ func TestMessageToBlock_Decomposed_AllSections(t *testing.T) {
 	}
 }
 
+func TestMessageToBlock_ChannelMentionLinks(t *testing.T) {
+	channels := parser.NewChannelResolver()
+	channels.AddChannel("C002", "design")
+	w := NewDocWriter(nil, nil, nil, channels, nil, nil, nil)
+	w.SetChannelLinkResolver(func(id string) string {
+		if id == "C002" {
+			return "https://drive.google.com/drive/folders/design"
+		}
+		return ""
+	})
+	msg := slackapi.Message{
+		User: "U001",
+		Text: "see <#C002> and <#C003|random>",
+		TS:   "1706745603.000000",
+	}
+	block := w.messageToBlock(nil, "C123", "folder", msg)
+	if block.Content != "see #design and #random" {
+		t.Errorf("Content = %q", block.Content)
+	}
+	if len(block.Links) != 1 || block.Links[0].Text != "#design" || block.Links[0].URL != "https://drive.google.com/drive/folders/design" {
+		t.Errorf("Links = %+v, want only #design linked to its folder", block.Links)
+	}
+}
+
+func TestMessageToBlock_Formatting(t *testing.T) {
+	w := NewDocWriter(nil, nil, nil, nil, nil, nil, nil)
+	msg := slackapi.Message{
+		User:      "U001",
+		Text:      "*Note:* run `make`\n&gt; quoted",
+		TS:        "1706745603.000000",
+		Reactions: []slackapi.Reaction{{Name: "eyes", Count: 1}},
+	}
+	block := w.messageToBlock(nil, "C123", "folder", msg)
+	if want := "Note: run make\nquoted\nReactions: :eyes: (1)"; block.Content != want {
+		t.Errorf("Content = %q, want %q", block.Content, want)
+	}
+	want := []gdrive.FormattedText{
+		{Text: "Note:", Bold: true}, {Text: " run "}, {Text: "make", Monospace: true},
+		{Text: "\n"}, {Text: "quoted", Quote: true},
+	}
+	if !reflect.DeepEqual(block.Formatted, want) {
+		t.Errorf("Formatted = %+v, want %+v", block.Formatted, want)
+	}
+
+	plain := w.messageToBlock(nil, "C123", "folder", slackapi.Message{User: "U001", Text: "plain text", TS: "1706745603.000000"})
+	if plain.Formatted != nil {
+		t.Errorf("Formatted = %+v, want none for plain text", plain.Formatted)
+	}
+}
+
 func TestMessageToBlock_Decomposed_ReactionsOnly(t *testing.T) {
 	w := NewDocWriter(nil, nil, nil, nil, nil, nil, nil)
 	msg := slackapi.Message{
@@ -303,6 +383,38 @@ func TestGetSenderName(t *testing.T) {
 	}
 }
 
+func TestImageCaption(t *testing.T) {
+	t.Cleanup(func() { parser.SetTimeSettings(parser.TimeSettings{}) })
+	parser.SetTimeSettings(parser.TimeSettings{Location: time.UTC, DateLayout: "2006-01-02", TimeLayout: "15:04"})
+	resolver := parser.NewUserResolver()
+	resolver.AddUser(&slackapi.User{ID: "U001", Name: "jsmith", Profile: slackapi.UserProfile{DisplayName: "John Smith"}})
+	w := NewDocWriter(nil, nil, resolver, nil, nil, nil, nil)
+
+	tests := []struct {
+		name string
+		file slackapi.File
+		want string
+	}{
+		{
+			name: "name, uploader, and time",
+			file: slackapi.File{Name: "screenshot.png", User: "U001", Created: 1706788800},
+			want: "screenshot.png · uploaded by John Smith · 2024-02-01 12:00",
+		},
+		{
+			name: "name only",
+			file: slackapi.File{Name: "screenshot.png"},
+			want: "screenshot.png",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := w.imageCaption(tt.file); got != tt.want {
+				t.Errorf("imageCaption() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 // ---------------------------------------------------------------------------
 // formatReactions tests
 // ---------------------------------------------------------------------------
@@ -343,7 +455,7 @@ func TestFormatReactions(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := formatReactions(tt.reactions)
+			got := formatReactions(tt.reactions, nil)
 			if got != tt.want {
 				t.Errorf("formatReactions() = %q, want %q", got, tt.want)
 			}
@@ -351,6 +463,36 @@ func TestFormatReactions(t *testing.T) {
 	}
 }
 
+func TestFormatMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		md   *slackapi.MessageMetadata
+		want string
+	}{
+		{name: "nil", md: nil, want: ""},
+		{name: "no event type", md: &slackapi.MessageMetadata{}, want: ""},
+		{name: "no payload", md: &slackapi.MessageMetadata{EventType: "task_created"}, want: "Metadata (task_created)"},
+		{
+			name: "payload compacted",
+			md:   &slackapi.MessageMetadata{EventType: "task_created", EventPayload: json.RawMessage(`{ "id": "T1",  "priority": 2 }`)},
+			want: `Metadata (task_created): {"id":"T1","priority":2}`,
+		},
+		{
+			name: "invalid json kept verbatim",
+			md:   &slackapi.MessageMetadata{EventType: "x", EventPayload: json.RawMessage(`{oops`)},
+			want: "Metadata (x): {oops",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatMetadata(tt.md); got != tt.want {
+				t.Errorf("formatMetadata() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 // ---------------------------------------------------------------------------
 // formatAttachments tests
 // ---------------------------------------------------------------------------
@@ -432,7 +574,7 @@ func TestFormatAttachments(t *testing.T) {
 
 func TestProcessMessageFiles_NoFiles(t *testing.T) {
 	w := NewDocWriter(nil, nil, nil, nil, nil, nil, nil)
-	text, images := w.processMessageFiles(nil, nil, "folder123")
+	text, _, images := w.processMessageFiles(nil, "C001", nil, "folder123")
 	if text != "" {
 		t.Errorf("expected empty text, got %q", text)
 	}
@@ -443,7 +585,7 @@ func TestProcessMessageFiles_NoFiles(t *testing.T) {
 
 func TestProcessMessageFiles_EmptyFiles(t *testing.T) {
 	w := NewDocWriter(nil, nil, nil, nil, nil, nil, nil)
-	text, images := w.processMessageFiles(nil, []slackapi.File{}, "folder123")
+	text, _, images := w.processMessageFiles(nil, "C001", []slackapi.File{}, "folder123")
 	if text != "" {
 		t.Errorf("expected empty text, got %q", text)
 	}
@@ -458,7 +600,7 @@ func TestProcessMessageFiles_NonImageFiles(t *testing.T) {
 		{Name: "report.pdf", Mimetype: "application/pdf"},
 		{Name: "data.csv", Mimetype: "text/csv"},
 	}
-	text, images := w.processMessageFiles(nil, files, "folder123")
+	text, _, images := w.processMessageFiles(nil, "C001", files, "folder123")
 	if text != "[File: report.pdf]\n[File: data.csv]" {
 		t.Errorf("got text %q, want %q", text, "[File: report.pdf]\n[File: data.csv]")
 	}
@@ -473,7 +615,7 @@ func TestProcessMessageFiles_ImageWithoutClients(t *testing.T) {
 	files := []slackapi.File{
 		{Name: "photo.png", Mimetype: "image/png"},
 	}
-	text, images := w.processMessageFiles(nil, files, "folder123")
+	text, _, images := w.processMessageFiles(nil, "C001", files, "folder123")
 	if text != "[File: photo.png]" {
 		t.Errorf("got text %q, want %q", text, "[File: photo.png]")
 	}
@@ -489,7 +631,7 @@ func TestProcessMessageFiles_MixedFiles(t *testing.T) {
 		{Name: "photo.png", Mimetype: "image/png"},
 		{Name: "doc.txt", Mimetype: "text/plain"},
 	}
-	text, images := w.processMessageFiles(nil, files, "folder123")
+	text, _, images := w.processMessageFiles(nil, "C001", files, "folder123")
 	// Both should appear as text refs since there are no clients
 	if text != "[File: photo.png]\n[File: doc.txt]" {
 		t.Errorf("got text %q, want %q", text, "[File: photo.png]\n[File: doc.txt]")
@@ -514,3 +656,42 @@ func TestFormatMessageTime(t *testing.T) {
 		t.Errorf("formatMessageTime() = %q, does not end with AM or PM", got)
 	}
 }
+
+func TestExportConversation_BlockKitMessage(t *testing.T) {
+	blocks := []slackapi.Block{
+		{Type: "header", Text: &slackapi.TextObject{Type: "plain_text", Text: "Incident opened"}},
+		{Type: "section", Text: &slackapi.TextObject{Type: "mrkdwn", Text: "Paged <@U001>, see <https://status.example.com|status>"}},
+	}
+
+	drive, slack, conv := fakeConversation()
+	slack.Messages["C001"][0].Text = "Incident opened"
+	slack.Messages["C001"][0].Blocks = blocks
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	docID := exp.index.GetConversation("C001").DailyDocs["2024-02-01"].DocID
+	var found bool
+	for _, batch := range drive.Appended(docID) {
+		for _, b := range batch {
+			if strings.Contains(b.Content, "Incident opened\nPaged ") && strings.HasSuffix(b.Content, "see status") {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Errorf("daily doc has no message rendered from its blocks: %+v", drive.Appended(docID))
+	}
+
+	drive, slack, conv = fakeConversation()
+	slack.Messages["C001"][0].Blocks = blocks
+	conv.Format = config.OutputFormatMarkdown
+	exp, localDir := localFormatExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	day := readFile(t, filepath.Join(localDir, SanitizeDirectoryName(string(conv.Type), conv.Name), "2024-02-01.md"))
+	if !strings.Contains(day, "**Incident opened**") || !strings.Contains(day, "[status](https://status.example.com)") {
+		t.Errorf("markdown day does not render the blocks:\n%s", day)
+	}
+}
@@ -0,0 +1,172 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// EmojiDir is the directory, in the local export directory, holding the
+// images of custom emoji used in reactions on html pages.
+const EmojiDir = "_emoji"
+
+// loadCustomEmoji adds the workspace's custom emoji to the emoji resolver.
+// A workspace that restricts emoji.list is reported and its custom emoji
+// render as :name:.
+func (e *Exporter) loadCustomEmoji(ctx context.Context) {
+	if err := e.emoji.LoadCustomEmoji(ctx, e.slackClient); err != nil {
+		e.Progress("Warning: could not list custom emoji (%v); they will appear as :name:", err)
+	}
+}
+
+// formatReactions converts a slice of Slack reactions into a display string,
+// each emoji as its Unicode characters when emoji resolves it and as its
+// :name: shortcode otherwise. Returns an empty string when there are no
+// reactions.
+func formatReactions(reactions []slackapi.Reaction, emoji *parser.EmojiResolver) string {
+	if len(reactions) == 0 {
+		return ""
+	}
+	parts := make([]string, len(reactions))
+	for i, r := range reactions {
+		parts[i] = fmt.Sprintf("%s (%d)", emoji.Lookup(r.Name), r.Count)
+	}
+	return "Reactions: " + strings.Join(parts, " ")
+}
+
+// formatReactionsMarkdown is formatReactions for markdown: a custom emoji
+// is an image of the emoji, titled with its name.
+func formatReactionsMarkdown(reactions []slackapi.Reaction, emoji *parser.EmojiResolver) string {
+	if len(reactions) == 0 {
+		return ""
+	}
+	parts := make([]string, len(reactions))
+	for i, r := range reactions {
+		em := emoji.Lookup(r.Name)
+		text := em.String()
+		if em.Custom() {
+			text = "![" + text + "](" + em.ImageURL + " \"" + r.Name + "\")"
+		}
+		parts[i] = fmt.Sprintf("%s (%d)", text, r.Count)
+	}
+	return "Reactions: " + strings.Join(parts, " ")
+}
+
+// reactionLinks links the :name: of each custom emoji in text rendered by
+// formatReactions to the emoji's image, so its picture is a click away in
+// the doc.
+func reactionLinks(reactions []slackapi.Reaction, emoji *parser.EmojiResolver) []parser.LinkAnnotation {
+	var links []parser.LinkAnnotation
+	for _, r := range reactions {
+		if em := emoji.Lookup(r.Name); em.Custom() {
+			links = append(links, parser.LinkAnnotation{Text: em.String(), URL: em.ImageURL})
+		}
+	}
+	return links
+}
+
+// saveCustomEmoji downloads the images of the custom emoji msgs were
+// reacted with, or are made of (see compactMessageOf), into
+// {localExportDir}/_emoji, skipping ones already there. A failed download
+// is reported; the page then uses the image on Slack.
+func (e *Exporter) saveCustomEmoji(ctx context.Context, msgs []slackapi.Message) {
+	dir := filepath.Join(e.localExportDir, EmojiDir)
+	var pending []parser.Emoji
+	seen := make(map[string]bool)
+	for _, msg := range msgs {
+		var names []string
+		for _, r := range msg.Reactions {
+			names = append(names, r.Name)
+		}
+		if c, ok := compactMessageOf(msg); ok {
+			names = append(names, c.Emoji...)
+		}
+		for _, n := range names {
+			em := e.emoji.Lookup(n)
+			name := emojiFileName(em)
+			if name == "" || seen[name] {
+				continue
+			}
+			seen[name] = true
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				continue
+			}
+			pending = append(pending, em)
+		}
+	}
+
+	var mu sync.Mutex
+	e.fetches.forEach(len(pending), func(i int) {
+		em := pending[i]
+		data, err := e.slackClient.DownloadFile(ctx, em.ImageURL)
+		if err == nil {
+			err = os.MkdirAll(dir, 0755)
+		}
+		if err == nil {
+			err = atomicWriteFile(dir, filepath.Join(dir, emojiFileName(em)), data)
+		}
+		if err != nil {
+			mu.Lock()
+			defer mu.Unlock()
+			e.Progress("Warning: failed to download emoji :%s:: %v", em.Name, err)
+		}
+	})
+}
+
+// localEmojiImage returns the path, relative to the local export
+// directory, of the saved image of a custom emoji, or "" when it has none.
+func (e *Exporter) localEmojiImage(em parser.Emoji) string {
+	name := emojiFileName(em)
+	if name == "" {
+		return ""
+	}
+	if _, err := os.Stat(filepath.Join(e.localExportDir, EmojiDir, name)); err != nil {
+		return ""
+	}
+	return EmojiDir + "/" + name
+}
+
+// emojiFileName names the saved image of a custom emoji: its name, made
+// safe for a file name, and its image's extension. It returns "" for an
+// emoji that is not custom or whose image is not on Slack's servers, which
+// are the only hosts Slack credentials are sent to.
+func emojiFileName(em parser.Emoji) string {
+	if !em.Custom() {
+		return ""
+	}
+	u, err := url.Parse(em.ImageURL)
+	if err != nil || u.Scheme != "https" || !isSlackHost(u.Hostname()) {
+		return ""
+	}
+	ext := strings.ToLower(path.Ext(u.Path))
+	switch ext {
+	case ".png", ".gif", ".jpg", ".jpeg", ".webp":
+	default:
+		ext = ".png"
+	}
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, strings.ToLower(em.Name))
+	return name + ext
+}
+
+// isSlackHost reports whether host is Slack's or its CDN's.
+func isSlackHost(host string) bool {
+	for _, domain := range []string{"slack.com", "slack-edge.com"} {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
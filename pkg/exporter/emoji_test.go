@@ -0,0 +1,138 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/internal/testutil"
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+const testParrotURL = "https://emoji.slack-edge.com/T001/party_parrot/abc.gif"
+
+func testEmojiResolver() *parser.EmojiResolver {
+	r := parser.NewEmojiResolver()
+	r.SetCustomEmoji(map[string]string{"party_parrot": testParrotURL})
+	return r
+}
+
+func TestFormatReactions_Emoji(t *testing.T) {
+	reactions := []slackapi.Reaction{{Name: "tada", Count: 3}, {Name: "+1::skin-tone-2", Count: 1}, {Name: "party_parrot", Count: 2}}
+	emoji := testEmojiResolver()
+
+	if got, want := formatReactions(reactions, emoji), "Reactions: 🎉 (3) 👍\U0001F3FB (1) :party_parrot: (2)"; got != want {
+		t.Errorf("formatReactions() = %q, want %q", got, want)
+	}
+	if got, want := formatReactionsMarkdown(reactions, emoji), `Reactions: 🎉 (3) 👍`+"\U0001F3FB"+` (1) ![:party_parrot:](`+testParrotURL+` "party_parrot") (2)`; got != want {
+		t.Errorf("formatReactionsMarkdown() = %q, want %q", got, want)
+	}
+	links := reactionLinks(reactions, emoji)
+	if len(links) != 1 || links[0].Text != ":party_parrot:" || links[0].URL != testParrotURL {
+		t.Errorf("reactionLinks() = %+v, want the custom emoji linked to its image", links)
+	}
+}
+
+func TestDocWriter_EmojiReactions(t *testing.T) {
+	w := NewDocWriter(nil, nil, parser.NewUserResolver(), parser.NewChannelResolver(), nil, nil, nil)
+	w.SetEmojiResolver(testEmojiResolver())
+	block := w.messageToBlock(context.Background(), "C001", "", slackapi.Message{
+		User: "U001", Text: "shipped", TS: "1706788800.000100",
+		Reactions: []slackapi.Reaction{{Name: "rocket", Count: 2}, {Name: "party_parrot", Count: 1}},
+	})
+	if !strings.HasSuffix(block.Content, "Reactions: 🚀 (2) :party_parrot: (1)") {
+		t.Errorf("content = %q", block.Content)
+	}
+	if n := len(block.Links); n != 1 || block.Links[0].URL != testParrotURL {
+		t.Errorf("links = %+v, want the custom emoji linked", block.Links)
+	}
+}
+
+func TestExportConversation_HTMLCustomEmoji(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	conv.Format = config.OutputFormatHTML
+	slack.Messages["C001"][0].Reactions = []slackapi.Reaction{{Name: "party_parrot", Count: 2}, {Name: "wave", Count: 1}}
+	slack.Files[testParrotURL] = []byte("GIF89a")
+	exp, localDir := localFormatExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.emoji = testEmojiResolver()
+
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	if got := readFile(t, filepath.Join(localDir, EmojiDir, "party_parrot.gif")); got != "GIF89a" {
+		t.Errorf("saved emoji = %q", got)
+	}
+	day := readFile(t, filepath.Join(localDir, SanitizeDirectoryName(string(conv.Type), conv.Name), "2024-02-01.html"))
+	for _, want := range []string{`src="../_emoji/party_parrot.gif"`, `alt=":party_parrot:"`, "👋 1"} {
+		if !strings.Contains(day, want) {
+			t.Errorf("day page missing %q", want)
+		}
+	}
+
+	// The saved image is not downloaded again.
+	calls := slack.Calls("DownloadFile")
+	exp.saveCustomEmoji(context.Background(), slack.Messages["C001"])
+	if got := slack.Calls("DownloadFile"); got != calls {
+		t.Errorf("DownloadFile calls = %d, want %d", got, calls)
+	}
+}
+
+func TestSaveCustomEmoji_Failure(t *testing.T) {
+	drive, slack, _ := fakeConversation()
+	exp, localDir := localFormatExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.emoji = testEmojiResolver()
+
+	// No image at the URL: the page falls back to the one on Slack.
+	msgs := []slackapi.Message{{TS: "1.000100", Reactions: []slackapi.Reaction{{Name: "party_parrot", Count: 1}}}}
+	exp.saveCustomEmoji(context.Background(), msgs)
+	if _, err := os.Stat(filepath.Join(localDir, EmojiDir)); !os.IsNotExist(err) {
+		t.Errorf("emoji dir exists after a failed download: %v", err)
+	}
+	if got := exp.localEmojiImage(exp.emoji.Lookup("party_parrot")); got != "" {
+		t.Errorf("localEmojiImage() = %q, want none", got)
+	}
+}
+
+func TestEmojiFileName(t *testing.T) {
+	tests := []struct {
+		em   parser.Emoji
+		want string
+	}{
+		{parser.Emoji{Name: "party_parrot", ImageURL: testParrotURL}, "party_parrot.gif"},
+		{parser.Emoji{Name: "Ship.It", ImageURL: "https://emoji.slack-edge.com/T001/shipit/abc"}, "ship_it.png"},
+		{parser.Emoji{Name: "evil", ImageURL: "https://example.com/evil.png"}, ""},
+		{parser.Emoji{Name: "plain", ImageURL: "http://emoji.slack-edge.com/plain.png"}, ""},
+		{parser.Emoji{Name: "tada", Unicode: "🎉"}, ""},
+	}
+	for _, tt := range tests {
+		if got := emojiFileName(tt.em); got != tt.want {
+			t.Errorf("emojiFileName(%+v) = %q, want %q", tt.em, got, tt.want)
+		}
+	}
+}
+
+func TestLoadCustomEmoji(t *testing.T) {
+	slack := testutil.NewFakeSlack()
+	slack.Emoji = map[string]string{"party_parrot": testParrotURL}
+	exp := fakeExporter(t, testutil.NewFakeDrive(), slack, t.TempDir()+"/export-index.json")
+	exp.emoji = parser.NewEmojiResolver()
+	exp.loadCustomEmoji(context.Background())
+	if !exp.emoji.Lookup("party_parrot").Custom() {
+		t.Error("custom emoji not loaded")
+	}
+
+	// A restricted emoji.list is reported, not fatal.
+	var progress []string
+	exp.onProgress = func(msg string) { progress = append(progress, msg) }
+	slack.Errors = map[string]error{"ListEmoji": errors.New("missing_scope")}
+	exp.emoji = parser.NewEmojiResolver()
+	exp.loadCustomEmoji(context.Background())
+	if len(progress) != 1 || !strings.Contains(progress[0], "missing_scope") {
+		t.Errorf("progress = %q, want a warning", progress)
+	}
+}
@@ -2,17 +2,23 @@ package exporter
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/jflowers/get-out/pkg/chrome"
 	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/errcat"
 	"github.com/jflowers/get-out/pkg/gdrive"
+	"github.com/jflowers/get-out/pkg/models"
 	"github.com/jflowers/get-out/pkg/parser"
 	"github.com/jflowers/get-out/pkg/secrets"
 	"github.com/jflowers/get-out/pkg/slackapi"
+	"github.com/jflowers/get-out/pkg/sqlitearchive"
 )
 
 // Exporter orchestrates the export of Slack conversations to Google Docs.
@@ -24,8 +30,12 @@ type Exporter struct {
 	googleCredentialsFile string
 
 	// Clients
-	slackClient  *slackapi.Client
-	gdriveClient *gdrive.Client
+	slackClient  SlackSource
+	gdriveClient DriveSink
+	sheets       SheetSink
+
+	// Which Slack methods this workspace allows (nil until probed)
+	capabilities *slackapi.Capabilities
 
 	// Helpers
 	folderStructure *FolderStructure
@@ -34,23 +44,159 @@ type Exporter struct {
 	userResolver    *parser.UserResolver
 	channelResolver *parser.ChannelResolver
 	personResolver  *parser.PersonResolver
+	emoji           *parser.EmojiResolver
 	index           *ExportIndex
 
 	// Local markdown export
 	localExportDir string
+	version        string // recorded in markdown frontmatter
+
+	// Save message attachments with the export (see ExporterConfig.DownloadFiles)
+	downloadFiles bool
+
+	// Other workspaces' exports holding shared channels (see
+	// ExporterConfig.PeerConfigDirs)
+	peerConfigDirs []string
+	peers          []peerExport
+
+	// Bound on Slack requests in flight (see ExporterConfig.Parallel)
+	fetches *fetchPool
+
+	// Downloads of the days queued for writing (see prefetchDownloads)
+	prefetched downloadCache
+
+	// Serialize rewrites of the html format's top-level index page and of
+	// the slack format's index files
+	htmlIndexMu    sync.Mutex
+	slackArchiveMu sync.Mutex
+
+	// The sqlite format's database, opened by the first conversation
+	// written to it
+	sqliteMu sync.Mutex
+	sqliteDB *sqlitearchive.DB
 
 	// Sensitivity filter (optional)
 	messageFilter MessageFilter
 
-	// Progress callback
+	// Translation step (optional), and the translations made for the
+	// conversations being exported (see translateMessages)
+	translator     Translator
+	translationsMu sync.Mutex
+	translations   map[string]map[string]translation
+
+	// Run budget (nil when unlimited)
+	budget *RunBudget
+
+	// Digest destination (optional)
+	digestSink   DigestSink
+	digestWriter *DigestWriter
+
+	// Raw Slack response archive (optional)
+	rawRecorder slackapi.ResponseRecorder
+
+	// Destination for every conversation, overriding the per-format
+	// choice (optional)
+	backend Backend
+
+	// Per-person @-mention backlinks
+	mentionIndex    *MentionIndex
+	mentionRecorder *MentionRecorder
+
+	// Slack TS to doc URL for each exported message (nil for samples)
+	messageMap *MessageMap
+
+	// Messages that failed to render or write (nil for samples)
+	deadLetters *DeadLetterStore
+
+	// Legal hold: hash chain of everything written (nil unless legal hold)
+	holdLedger *HoldLedger
+
+	// Progress callbacks
 	onProgress func(msg string)
+	onDetail   func(msg string)
+	onNotice   func(msg string)
 
 	// Options
+	chromePort int
 	debug      bool
 	dateFrom   string // Slack timestamp: only messages after this
 	dateTo     string // Slack timestamp: only messages before this
 	syncMode   bool   // Use LastMessageTS from index as oldest
 	resumeMode bool   // Resume incomplete exports, skip completed ones
+	sampleSize int    // Export only the newest N messages per conversation (0 = all)
+	digestOnly bool   // Only send digests; write no docs or files
+	legalHold  bool   // Never modify earlier artifacts; record a hash chain
+
+	// Keep user status and presence in users.json and the raw archive
+	includeProfileStatus bool
+
+	// Append a provenance line to each day written (see
+	// ExporterConfig.Provenance), naming the Slack credential in use
+	provenance  bool
+	slackSource string
+
+	// Add rendered text and entities to json day files (see
+	// ExporterConfig.JSONRendered)
+	jsonRendered bool
+
+	// Leave out messages of only emoji or a GIF (see
+	// ExporterConfig.SkipEmojiMessages)
+	skipEmoji bool
+
+	// Fetch conversation members up front (see ExporterConfig.PrefetchUsers)
+	prefetchUsers bool
+
+	// Template doc for doc styles (see ExporterConfig.StyleTemplate)
+	styleTemplate string
+
+	// Template doc new daily docs are copied from (see
+	// ExporterConfig.DocTemplate)
+	docTemplate string
+
+	// Drive folder size monitoring (see FolderStructureConfig)
+	folderWarnItems  int
+	autoFolderLayout config.FolderLayout
+
+	// Google API request counting and daily budgets
+	googleQuota *config.GoogleQuotaConfig
+	quota       *gdrive.QuotaTracker
+
+	// Retries of rate-limited and failed Slack requests (nil = default)
+	slackMaxRetries *int
+
+	// Limits of images embedded in docs (see ExporterConfig.Images)
+	images *config.ImageConfig
+
+	// Layouts of the markdown and html formats by name (see
+	// ExporterConfig.Layouts)
+	layouts map[string]*Layout
+
+	// On-disk Slack user cache behind userResolver (nil in tests)
+	userCache *UserCacheStore
+
+	// Static Slack credentials (skip Chrome when set)
+	slackToken  string
+	slackCookie string
+
+	// Workspace to take the browser session from (see ExporterConfig.SlackTeam)
+	slackTeam          string
+	preferredSlackTeam string
+	// Slack tab to open when none is (see ExporterConfig.SlackTabURL)
+	slackTabURL  string
+	keepSlackTab bool
+
+	// Store for the Slack browser session between runs (nil when
+	// connecting without InitializeWithStore)
+	secretStore secrets.SecretStore
+
+	// Held export lock, updated with per-conversation progress (optional)
+	runLock *RunLock
+
+	// Live run statistics for a status endpoint (optional)
+	stats *RunStats
+
+	// Prometheus metrics for a metrics endpoint (optional)
+	metrics *Metrics
 }
 
 // ExporterConfig holds configuration for creating an Exporter.
@@ -58,9 +204,37 @@ type ExporterConfig struct {
 	ConfigDir      string
 	RootFolderName string
 	RootFolderID   string // Optional: use existing folder by ID instead of creating by name
-	ChromePort     int
-	Debug          bool
-	OnProgress     func(msg string)
+	ChromePort     int    // Chrome debugging port Initialize reads Slack credentials from
+	// SlackTeam is the workspace (team ID or domain) whose browser session
+	// is used when Chrome is signed in to several; see
+	// chrome.Session.ExtractCredentialsForTeam.
+	SlackTeam string
+	// PreferredSlackTeam is the workspace to use when Chrome is signed in
+	// to several and SlackTeam is unset, such as the one a configured
+	// workspace URL names (see chrome.Config.PreferTeam). Unlike SlackTeam,
+	// it does not rule out a single signed-in workspace or a saved session
+	// of another workspace.
+	PreferredSlackTeam string
+	// SlackTabURL, when set, is opened in a background tab to extract
+	// credentials from when Chrome has no Slack tab open (see
+	// chrome.Config.OpenSlackURL). KeepSlackTab leaves that tab open.
+	SlackTabURL  string
+	KeepSlackTab bool
+	Debug        bool
+	OnProgress   func(msg string)
+
+	// OnDetail, when set, receives fine-grained progress (per-batch fetch
+	// counts, per-day writes) separately from OnProgress so callers can show
+	// it at a higher verbosity. When nil, detail goes to OnProgress.
+	OnDetail func(msg string)
+
+	// OnNotice, when set, receives what the Google and Slack clients would
+	// otherwise print: the Google authorization URL when a new token is
+	// needed, token save warnings, and rate limit and server error
+	// retries. Programs embedding the exporter set it so nothing is
+	// written to stdout or stderr; it should not be gated on verbosity,
+	// since an authorization prompt waits for the user.
+	OnNotice func(msg string)
 
 	// Optional paths from settings.json
 	GoogleCredentialsFile string // Custom path to credentials.json
@@ -74,9 +248,159 @@ type ExporterConfig struct {
 	// Local markdown export directory (expanded absolute path)
 	LocalExportDir string
 
+	// DownloadFiles saves message attachments with the export: into a
+	// files/ directory beside each conversation's local output, and, for
+	// Docs exports, into a Files folder in the conversation's Drive folder.
+	// The output links to the saved copy instead of to Slack.
+	DownloadFiles bool
+
+	// PeerConfigDirs are the config directories of get-out exports from
+	// other Slack workspaces. A Slack Connect channel one of them has
+	// already exported is skipped and recorded as shared, so content from
+	// a channel shared by several workspaces is exported once.
+	PeerConfigDirs []string
+
+	// Parallel is the number of Slack requests the export makes at once
+	// (1-5): history, thread replies and file downloads, shared by all the
+	// conversations ExportAllParallel exports together. 0 means 1.
+	Parallel int
+
 	// MessageFilter is an optional sensitivity filter for local markdown exports.
 	// When set, messages are classified before writing markdown files.
 	MessageFilter MessageFilter
+
+	// Translator, when set, translates each message as it is written,
+	// after the sensitivity filter; the output keeps the original beneath
+	// the translation (see translateMessages).
+	Translator Translator
+
+	// Per-run budget: stop after writing this many messages or creating this
+	// many new daily docs (0 = unlimited). Progress is checkpointed so the
+	// next --sync run continues where this one stopped.
+	MaxMessages int
+	MaxNewDocs  int
+
+	// DigestSink is an optional destination that receives an HTML digest of
+	// each conversation's newly written messages, in addition to the docs.
+	DigestSink DigestSink
+
+	// DigestOnly sends the DigestSink's digests without writing docs,
+	// local files, or threads. Its progress is kept in its own index
+	// (DefaultDigestIndexPath), so a digest-only --sync run picks up after
+	// the last digest sent and never marks messages as exported.
+	DigestOnly bool
+
+	// RawRecorder, when set, receives every raw Slack API response body
+	// (see RawArchive).
+	RawRecorder slackapi.ResponseRecorder
+
+	// Backend, when set, writes every conversation instead of the Google
+	// Docs or local backend chosen by the conversation's format.
+	Backend Backend
+
+	// NamePolicy controls how user names are rendered in sender headers
+	// and @mentions (default: display-first).
+	NamePolicy config.NamePolicy
+
+	// Version is the get-out version recorded in local markdown frontmatter.
+	Version string
+
+	// SampleSize, when positive, exports only the newest SampleSize
+	// messages of each conversation (with their threads) for a quick check
+	// of output and sharing. Samples go to a separate Drive folder, index,
+	// and local subdirectory, and do not record mentions.
+	SampleSize int
+
+	// LegalHold makes the export append-only: each day written is recorded
+	// in a per-conversation hash chain under <config-dir>/_legalhold,
+	// markdown files from earlier runs are never modified (new messages go
+	// to a new part file), and cross-link rewriting of existing docs is
+	// skipped. Ignored for samples.
+	LegalHold bool
+
+	// IncludeProfileStatus keeps users' status text and emoji, and the
+	// presence and do-not-disturb fields Slack returns, in users.json and
+	// the raw archive. By default they are scrubbed (see ScrubUserProfiles
+	// and ProfileScrubber).
+	IncludeProfileStatus bool
+
+	// Provenance appends a line to each day written, in docs and local
+	// markdown, recording when its messages were fetched, whether through
+	// a browser session or a token, and the get-out version, so auditors
+	// can trace how the content was obtained.
+	Provenance bool
+
+	// JSONRendered adds, to each message of the json format's day files,
+	// its text as rendered in the other formats (rendered_text) and the
+	// mentions, links, and emoji found in it (entities, see
+	// parser.ExtractEntities). The text field keeps Slack's raw mrkdwn.
+	JSONRendered bool
+
+	// SkipEmojiMessages leaves out messages that are only emoji or a GIF
+	// posted with Giphy, which are otherwise shown on a single line. The
+	// json and slack formats keep every message.
+	SkipEmojiMessages bool
+
+	// PrefetchUsers fetches every member of the exported conversations
+	// before the export starts. By default users are looked up as messages
+	// author or @-mention them.
+	PrefetchUsers bool
+
+	// StyleTemplate is the ID or URL of a Google Doc that sets how message
+	// headers, code, and quotes are styled in the docs written (see
+	// gdrive.LoadStylePolicy). Empty keeps the built-in styles.
+	StyleTemplate string
+
+	// DocTemplate is the ID or URL of a Google Doc that new daily docs are
+	// copied from, so they start with its headers, footers, page setup,
+	// and fonts before messages are appended. Empty creates blank docs.
+	DocTemplate string
+
+	// FolderWarnItems is the number of items in one Drive folder at which
+	// the export warns (0 = config.DefaultFolderWarnItems). AutoFolderLayout,
+	// when "year" or "month", is applied to a conversation without an
+	// explicit layout once one of its folders reaches that size.
+	FolderWarnItems  int
+	AutoFolderLayout config.FolderLayout
+
+	// GoogleQuota sets daily Google API request budgets. Requests are
+	// counted in <config-dir>/_metadata/gdrive-quota.json either way.
+	GoogleQuota *config.GoogleQuotaConfig
+
+	// SlackMaxRetries is how many times a Slack request is retried after a
+	// rate limit or server error. Nil uses slackapi.DefaultMaxRetries; 0
+	// disables retries.
+	SlackMaxRetries *int
+
+	// Images limits the size of images embedded in docs; larger ones are
+	// scaled down before upload. Nil uses the defaults of
+	// config.ImageConfig.
+	Images *config.ImageConfig
+
+	// Layouts are the layouts of the markdown and html formats by name
+	// (see ParseLayouts), which conversations select with their Template.
+	// A conversation without one, or naming none of these, is written in
+	// the built-in layout.
+	Layouts map[string]*Layout
+
+	// SlackToken, when set, is used instead of extracting credentials from
+	// Chrome, so no browser is needed. An xoxc- browser token needs
+	// SlackCookie (the xoxd- "d" cookie); other tokens are used alone.
+	SlackToken  string
+	SlackCookie string
+
+	// RunLock, when set, is the lock held for this run; ExportAll records
+	// its progress through the conversations there for `get-out status`.
+	RunLock *RunLock
+
+	// Stats, when set, collects live progress, throughput, and recent
+	// errors (e.g. for `export --status-addr`).
+	Stats *RunStats
+
+	// Metrics, when set, counts messages, Slack and Google API requests,
+	// rate limit waits, and errors for a Prometheus endpoint (e.g. for
+	// `export --metrics-addr`).
+	Metrics *Metrics
 }
 
 // Progress is a helper to report progress.
@@ -86,25 +410,106 @@ func (e *Exporter) Progress(format string, args ...interface{}) {
 	}
 }
 
+// Detail reports fine-grained progress. It goes to OnDetail when set,
+// otherwise to OnProgress.
+func (e *Exporter) Detail(format string, args ...interface{}) {
+	if e.onDetail != nil {
+		e.onDetail(fmt.Sprintf(format, args...))
+		return
+	}
+	e.Progress(format, args...)
+}
+
 // NewExporter creates a new exporter with the given configuration.
-// It does NOT initialize connections - call Initialize() separately.
+// It does NOT initialize connections - call Initialize separately.
 func NewExporter(cfg *ExporterConfig) *Exporter {
-	return &Exporter{
+	userResolver := parser.NewUserResolver()
+	userResolver.SetNamePolicy(cfg.NamePolicy)
+	e := &Exporter{
 		configDir:             cfg.ConfigDir,
 		rootFolderName:        cfg.RootFolderName,
 		rootFolderID:          cfg.RootFolderID,
 		googleCredentialsFile: cfg.GoogleCredentialsFile,
 		debug:                 cfg.Debug,
 		onProgress:            cfg.OnProgress,
+		onDetail:              cfg.OnDetail,
+		onNotice:              cfg.OnNotice,
+		chromePort:            cfg.ChromePort,
 		dateFrom:              cfg.DateFrom,
 		dateTo:                cfg.DateTo,
 		syncMode:              cfg.SyncMode,
 		resumeMode:            cfg.ResumeMode,
 		localExportDir:        cfg.LocalExportDir,
+		downloadFiles:         cfg.DownloadFiles,
+		peerConfigDirs:        cfg.PeerConfigDirs,
+		fetches:               newFetchPool(cfg.Parallel),
+		version:               cfg.Version,
 		messageFilter:         cfg.MessageFilter,
-		userResolver:          parser.NewUserResolver(),
+		translator:            cfg.Translator,
+		budget:                NewRunBudget(cfg.MaxMessages, cfg.MaxNewDocs),
+		digestSink:            cfg.DigestSink,
+		rawRecorder:           cfg.RawRecorder,
+		backend:               cfg.Backend,
+		userResolver:          userResolver,
 		channelResolver:       parser.NewChannelResolver(),
+		emoji:                 parser.NewEmojiResolver(),
+		sampleSize:            cfg.SampleSize,
+		digestOnly:            cfg.DigestOnly,
+		folderWarnItems:       cfg.FolderWarnItems,
+		autoFolderLayout:      cfg.AutoFolderLayout,
+		googleQuota:           cfg.GoogleQuota,
+		slackMaxRetries:       cfg.SlackMaxRetries,
+		images:                cfg.Images,
+		layouts:               cfg.Layouts,
+		slackToken:            cfg.SlackToken,
+		slackCookie:           cfg.SlackCookie,
+		slackTeam:             cfg.SlackTeam,
+		preferredSlackTeam:    cfg.PreferredSlackTeam,
+		slackTabURL:           cfg.SlackTabURL,
+		keepSlackTab:          cfg.KeepSlackTab,
+		runLock:               cfg.RunLock,
+		stats:                 cfg.Stats,
+		metrics:               cfg.Metrics,
+		includeProfileStatus:  cfg.IncludeProfileStatus,
+		provenance:            cfg.Provenance,
+		jsonRendered:          cfg.JSONRendered,
+		skipEmoji:             cfg.SkipEmojiMessages,
+		prefetchUsers:         cfg.PrefetchUsers,
+		styleTemplate:         cfg.StyleTemplate,
+		docTemplate:           cfg.DocTemplate,
 	}
+	if e.rawRecorder != nil && !e.includeProfileStatus {
+		e.rawRecorder = ProfileScrubber{Next: e.rawRecorder}
+	}
+	if e.sampleSize > 0 {
+		e.rootFolderName = SampleFolderName(e.rootFolderName)
+		e.rootFolderID = ""
+		if e.localExportDir != "" {
+			e.localExportDir = filepath.Join(e.localExportDir, SampleDirName)
+		}
+	} else if e.digestOnly {
+		// Digest-only runs write nothing but the digests.
+		e.localExportDir = ""
+		if e.backend == nil {
+			e.backend = digestBackend{e}
+		}
+	} else {
+		// Sample failures are only reported: reprocessing works against the
+		// full export's index.
+		e.deadLetters = NewDeadLetterStore(DefaultDeadLetterDir(e.configDir))
+		if cfg.LegalHold {
+			e.legalHold = true
+			e.holdLedger = NewHoldLedger(DefaultHoldDir(e.configDir))
+		}
+	}
+	return e
+}
+
+// Initialize sets up connections to Slack and Google Drive like
+// InitializeWithStore, reading Slack credentials from the Chrome instance
+// on ExporterConfig.ChromePort (unless SlackToken is set).
+func (e *Exporter) Initialize(ctx context.Context, store secrets.SecretStore) error {
+	return e.InitializeWithStore(ctx, e.chromePort, store)
 }
 
 // InitializeWithStore sets up connections to Chrome/Slack and Google Drive,
@@ -113,10 +518,18 @@ func NewExporter(cfg *ExporterConfig) *Exporter {
 func (e *Exporter) InitializeWithStore(ctx context.Context, chromePort int, store secrets.SecretStore) error {
 	e.Progress("Loading export index...")
 	indexPath := DefaultIndexPath(e.configDir)
+	if e.sampleSize > 0 {
+		indexPath = DefaultSampleIndexPath(e.configDir)
+	} else if e.digestOnly {
+		indexPath = DefaultDigestIndexPath(e.configDir)
+	}
 	index, err := LoadExportIndex(indexPath)
 	if err != nil {
 		return fmt.Errorf("failed to load export index: %w", err)
 	}
+	if err := index.Recovered(); err != nil {
+		e.Progress("Warning: export index is damaged (%v); loaded its backup, %s", err, IndexBackupPath(indexPath))
+	}
 	e.index = index
 
 	e.Progress("Authenticating with Google Drive...")
@@ -125,50 +538,214 @@ func (e *Exporter) InitializeWithStore(ctx context.Context, chromePort int, stor
 		gdriveCfg.CredentialsPath = e.googleCredentialsFile
 		gdriveCfg.TokenPath = filepath.Join(filepath.Dir(e.googleCredentialsFile), "token.json")
 	}
+	e.loadQuotaTracker()
+	gdriveCfg.Quota = e.quota
+	gdriveCfg.Notify = e.onNotice
+	gmailSink, _ := e.digestSink.(*GmailDigestSink)
+	gdriveCfg.GmailSend = gmailSink != nil
+	if e.metrics != nil {
+		gdriveCfg.Observer = e.metrics
+	}
 	gdriveClient, err := gdrive.NewClientFromStore(ctx, gdriveCfg, store)
 	if err != nil {
-		return fmt.Errorf("failed to authenticate with Google: %w", err)
+		return errcat.Wrap(errcat.GoogleAuth, fmt.Errorf("failed to authenticate with Google: %w", err))
 	}
-	e.gdriveClient = gdriveClient
-
-	e.Progress("Connecting to Chrome (port %d)...", chromePort)
-	chromeCfg := &chrome.Config{
-		DebugPort: chromePort,
-		Timeout:   30 * time.Second,
+	if e.styleTemplate != "" {
+		styles, err := gdriveClient.LoadStylePolicy(ctx, e.styleTemplate)
+		if err != nil {
+			return fmt.Errorf("failed to read docStyleTemplate: %w", err)
+		}
+		gdriveClient.SetStylePolicy(styles)
 	}
-	session, err := chrome.Connect(ctx, chromeCfg)
-	if err != nil {
-		return fmt.Errorf("failed to connect to Chrome: %w", err)
+	e.gdriveClient = gdriveClient
+	e.sheets = gdriveClient
+	if gmailSink != nil {
+		gmailSink.connect(gdriveClient.SendMail)
 	}
-	defer session.Close()
 
-	e.Progress("Extracting Slack credentials...")
-	creds, err := session.ExtractCredentials(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to extract Slack credentials: %w", err)
+	e.secretStore = store
+	if err := e.ConnectSlack(ctx, chromePort); err != nil {
+		return err
 	}
-	e.Progress("Found Slack team: %s", creds.TeamDomain)
-
-	e.slackClient = slackapi.NewBrowserClient(creds.Token, creds.Cookie)
-	e.slackClient.SetDebug(e.debug)
 
 	e.folderStructure = NewFolderStructure(e.gdriveClient, e.index, &FolderStructureConfig{
 		RootFolderName: e.rootFolderName,
 		RootFolderID:   e.rootFolderID,
+		WarnItems:      e.folderWarnItems,
+		AutoLayout:     e.autoFolderLayout,
+		OnWarning:      e.onProgress,
+		DocTemplate:    e.docTemplate,
 	})
 
 	e.loadPersonResolver()
+	e.loadUserCache()
+	e.loadCustomEmoji(ctx)
+	e.seedChannelsFromIndex()
+	if e.sampleSize == 0 {
+		e.loadPeerExports()
+	}
 
-	e.docWriter = NewDocWriter(e.gdriveClient, e.slackClient, e.userResolver, e.channelResolver, e.personResolver, e.index.LookupDocURL, e.index.LookupThreadURL)
+	var slackDownloads SlackSource
+	if e.slackClient != nil {
+		slackDownloads = prefetchedSlack{e.slackClient, &e.prefetched}
+	}
+	e.docWriter = NewDocWriter(e.gdriveClient, slackDownloads, e.userResolver, e.channelResolver, e.personResolver, e.index.LookupDocURL, e.index.LookupThreadURL)
+	e.docWriter.SetChannelLinkResolver(e.index.LookupConversationURL)
+	e.docWriter.SetFileLinker(e.linkFile)
+	e.docWriter.SetEmojiResolver(e.emoji)
+	e.docWriter.SetImageLimits(e.images)
+	e.docWriter.SetMultiDayDocs(e.index.MultiDayDocs)
 
 	// Initialize MarkdownWriter for local markdown export when configured
 	if e.localExportDir != "" {
 		e.mdWriter = NewMarkdownWriter(e.userResolver, e.channelResolver, e.personResolver)
+		e.mdWriter.SetExporterVersion(e.version)
+		e.mdWriter.SetEmojiResolver(e.emoji)
+		if n, err := RemoveStaleTempFiles(e.localExportDir); err != nil {
+			e.Progress("Warning: %v", err)
+		} else if n > 0 {
+			e.Progress("Removed %d unfinished markdown writes from a previous run", n)
+		}
 	}
 
+	// Sample docs are not part of the real export, so keep them out of the
+	// mention backlinks.
+	if e.sampleSize == 0 {
+		mentionIndex, err := LoadMentionIndex(DefaultMentionIndexPath(e.configDir))
+		if err != nil {
+			e.Progress("Warning: %v (mentions will not be recorded)", err)
+		} else {
+			e.mentionIndex = mentionIndex
+			e.mentionRecorder = NewMentionRecorder(mentionIndex, e.userResolver, e.channelResolver, e.personResolver)
+		}
+		e.messageMap = NewMessageMap(DefaultMessageMapDir(e.configDir))
+	}
+
+	// Initialize DigestWriter when a digest destination is configured
+	if e.digestSink != nil {
+		e.digestWriter = NewDigestWriter(e.userResolver, e.channelResolver, e.personResolver)
+		e.digestWriter.SetEmojiResolver(e.emoji)
+	}
+
+	return nil
+}
+
+// ConnectSlack sets up the Slack client alone, from the configured token or
+// the browser session (see browserSession). InitializeWithStore calls it;
+// call it directly for work that needs only Slack, such as EstimateSizes
+// during a dry run. A browser session that expires mid-run is extracted
+// again from Chrome.
+func (e *Exporter) ConnectSlack(ctx context.Context, chromePort int) error {
+	var slackOpts []slackapi.ClientOption
+	token, cookie := e.slackToken, e.slackCookie
+	if token != "" {
+		e.Progress("Using the configured Slack token (Chrome not needed)")
+	} else {
+		var err error
+		if token, cookie, err = e.browserSession(ctx, chromePort); err != nil {
+			return err
+		}
+		slackOpts = append(slackOpts, slackapi.WithReauth(func(ctx context.Context) (string, string, error) {
+			e.Progress("Slack session expired; extracting new credentials from Chrome...")
+			return e.extractSlackCredentials(ctx, chromePort)
+		}))
+	}
+
+	if e.rawRecorder != nil {
+		slackOpts = append(slackOpts, slackapi.WithResponseRecorder(e.rawRecorder))
+	}
+	if e.metrics != nil {
+		slackOpts = append(slackOpts, slackapi.WithObserver(e.metrics))
+	}
+	if e.onNotice != nil {
+		slackOpts = append(slackOpts, slackapi.WithNotifier(e.onNotice))
+	}
+	if e.slackMaxRetries != nil {
+		slackOpts = append(slackOpts, slackapi.WithMaxRetries(*e.slackMaxRetries))
+	}
+	slackClient := newSlackClient(token, cookie, slackOpts...)
+	slackClient.SetDebug(e.debug)
+	e.slackSource = slackSource(token, cookie)
+	e.slackClient = limitedSlack{SlackSource: slackClient, pool: e.fetches}
 	return nil
 }
 
+// extractSlackCredentials reads the Slack token and cookie from the
+// Slack tab of the Chrome instance on chromePort, and saves them for later
+// runs.
+func (e *Exporter) extractSlackCredentials(ctx context.Context, chromePort int) (token, cookie string, err error) {
+	e.Progress("Connecting to Chrome (port %d)...", chromePort)
+	chromeCfg := &chrome.Config{
+		DebugPort:     chromePort,
+		Timeout:       30 * time.Second,
+		OpenSlackURL:  e.slackTabURL,
+		KeepOpenedTab: e.keepSlackTab,
+		PreferTeam:    e.preferredSlackTeam,
+	}
+	session, err := chrome.Connect(ctx, chromeCfg)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to connect to Chrome: %w", err)
+	}
+	defer session.Close()
+
+	e.Progress("Extracting Slack credentials...")
+	creds, err := session.ExtractCredentialsForTeam(ctx, e.slackTeam)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to extract Slack credentials: %w", err)
+	}
+	e.Progress("Found Slack team: %s", creds.TeamDomain)
+	e.saveSlackSession(slackSession{Token: creds.Token, Cookie: creds.Cookie, TeamID: creds.TeamID, TeamDomain: creds.TeamDomain})
+	return creds.Token, creds.Cookie, nil
+}
+
+// newSlackClient returns a browser-mode client for xoxc- tokens (which
+// need the cookie) and an API-mode client for user and bot tokens.
+func newSlackClient(token, cookie string, opts ...slackapi.ClientOption) *slackapi.Client {
+	if cookie != "" || strings.HasPrefix(token, "xoxc-") {
+		return slackapi.NewBrowserClient(token, cookie, opts...)
+	}
+	return slackapi.NewAPIClient(token, opts...)
+}
+
+// loadQuotaTracker sets up Google API request counting. A usage file that
+// cannot be read is reported and counting starts from zero rather than
+// failing the run.
+func (e *Exporter) loadQuotaTracker() {
+	var limits gdrive.QuotaLimits
+	if q := e.googleQuota; q != nil {
+		limits = gdrive.QuotaLimits{
+			gdrive.QuotaDocsWrite:  q.DailyDocsWrites,
+			gdrive.QuotaDriveQuery: q.DailyDriveQueries,
+		}
+	}
+	path := gdrive.DefaultQuotaPath(e.configDir)
+	tracker, err := gdrive.NewQuotaTracker(path, limits)
+	if err != nil {
+		e.Progress("Warning: %v; starting quota counts from zero", err)
+		_ = os.Remove(path)
+		if tracker, err = gdrive.NewQuotaTracker(path, limits); err != nil {
+			return
+		}
+	}
+	tracker.OnPause = func(kind gdrive.QuotaKind, until time.Time) {
+		e.Progress("Daily %s budget reached; pausing until %s", kind, until.Local().Format("2006-01-02 15:04 MST"))
+	}
+	e.quota = tracker
+}
+
+// QuotaSummary saves the Google API request counts and describes this
+// run's requests against today's budgets. Returns "" before
+// InitializeWithStore.
+func (e *Exporter) QuotaSummary() string {
+	if e.quota == nil {
+		return ""
+	}
+	if err := e.quota.Save(); err != nil {
+		e.Progress("Warning: %v", err)
+	}
+	return e.quota.Summary()
+}
+
 // loadPersonResolver loads people.json and creates a PersonResolver for @mention linking.
 func (e *Exporter) loadPersonResolver() {
 	peoplePath := filepath.Join(e.configDir, "people.json")
@@ -183,27 +760,91 @@ func (e *Exporter) loadPersonResolver() {
 	}
 }
 
-// LoadUsersForConversations loads user data for the specific conversations being exported.
+// LoadUsersForConversations loads the users of the conversations being
+// exported that are needed up front (see loadUsersWithFallback); the rest
+// are looked up as messages reference them.
 func (e *Exporter) LoadUsersForConversations(ctx context.Context, channelIDs []string) error {
-	e.Progress("Loading users from %d conversations...", len(channelIDs))
-	if err := e.userResolver.LoadUsersForConversations(ctx, e.slackClient, channelIDs, func(id string, count int) {
-		if count < 0 {
-			e.Progress("Could not access members for %s (will resolve on-the-fly)", id)
-		} else if id == "users" {
-			e.Progress("Fetched %d user profiles...", count)
-		} else {
-			e.Progress("Found %d unique members so far...", count)
-		}
-	}); err != nil {
+	if err := e.loadUsersWithFallback(ctx, channelIDs); err != nil {
 		return fmt.Errorf("failed to load users: %w", err)
 	}
-	e.Progress("Loaded %d users", e.userResolver.Count())
+	if n := e.userResolver.Count(); n > 0 {
+		e.Progress("Loaded %d users", n)
+	}
+	e.saveUserCache()
 	return nil
 }
 
+// seedChannelsFromIndex gives the channel resolver the names of channels
+// exported before, including under previous IDs, so their mentions render
+// by name without conversations.list.
+func (e *Exporter) seedChannelsFromIndex() {
+	for _, conv := range e.index.AllConversations() {
+		if isChannelType(conv.Type) {
+			e.channelResolver.AddChannel(conv.ID, conv.Name)
+		}
+	}
+	e.index.mu.RLock()
+	defer e.index.mu.RUnlock()
+	for alias, primary := range e.index.Aliases {
+		if conv, ok := e.index.Conversations[primary]; ok && isChannelType(conv.Type) {
+			e.channelResolver.AddChannel(alias, conv.Name)
+		}
+	}
+}
+
+// SeedChannels gives the channel resolver the names of the configured
+// channels (conversations.json), whether or not they are exported.
+func (e *Exporter) SeedChannels(conversations []config.ConversationConfig) {
+	for _, conv := range conversations {
+		if !isChannelType(string(conv.Type)) || conv.Name == "" {
+			continue
+		}
+		name := strings.TrimPrefix(conv.Name, "#")
+		e.channelResolver.AddChannel(conv.ID, name)
+		for _, alias := range conv.Aliases {
+			e.channelResolver.AddChannel(alias, name)
+		}
+	}
+}
+
+// isChannelType reports whether a conversation type can be #-mentioned.
+func isChannelType(convType string) bool {
+	return convType == string(models.ConversationTypeChannel) || convType == string(models.ConversationTypePrivateChannel)
+}
+
+// loadUserCache backs the user resolver with the on-disk user cache, so
+// users fetched by earlier runs are read as needed instead of fetched
+// again, and only DefaultUserMemoryLimit of them are held in memory.
+func (e *Exporter) loadUserCache() {
+	e.userCache = NewUserCacheStore(DefaultUserCacheDir(e.configDir), DefaultUserCacheTTL)
+	e.userResolver.SetStore(e.userCache)
+	e.userResolver.SetMaxUsers(DefaultUserMemoryLimit)
+}
+
+// saveUserCache writes users fetched so far to the on-disk cache.
+func (e *Exporter) saveUserCache() {
+	if e.userCache == nil {
+		return
+	}
+	if err := e.userCache.Flush(); err != nil {
+		e.Progress("Warning: failed to save user cache: %v", err)
+	}
+}
+
 // determineExportRange returns the oldest and latest Slack timestamps for
-// the export window based on sync mode, date flags, or defaults (full export).
+// the export window based on resume or sync mode, date flags, or defaults
+// (full export).
 func (e *Exporter) determineExportRange(convExport *ConversationExport) (oldest, latest string) {
+	// --resume continues an interrupted or failed export from its
+	// checkpoint; a conversation never exported, or without a checkpoint,
+	// starts over.
+	if e.resumeMode {
+		if (convExport.Status == StatusInProgress || convExport.Status == StatusFailed) && convExport.LastMessageTS != "" {
+			oldest = convExport.LastMessageTS
+			e.Progress("Resuming from checkpoint: %s", oldest)
+		}
+		return oldest, latest
+	}
 	if e.syncMode {
 		if convExport.LastMessageTS != "" {
 			oldest = convExport.LastMessageTS
@@ -218,6 +859,12 @@ func (e *Exporter) determineExportRange(convExport *ConversationExport) (oldest,
 	if e.dateFrom != "" {
 		oldest = e.dateFrom
 	}
+	// A repeated sample only adds messages newer than the last sample, so
+	// the sample docs don't collect duplicates.
+	if e.sampleSize > 0 && convExport.LastMessageTS != "" {
+		oldest = convExport.LastMessageTS
+		e.Progress("Sample already exported; fetching messages since %s", oldest)
+	}
 	if e.dateTo != "" {
 		latest = e.dateTo
 	}
@@ -228,176 +875,379 @@ func (e *Exporter) determineExportRange(convExport *ConversationExport) (oldest,
 }
 
 // exportThreads exports all thread parents found in the message batch and
-// returns the count of threads processed.
-func (e *Exporter) exportThreads(ctx context.Context, convID string, allMessages []slackapi.Message) int {
+// returns the count of threads processed. Replies are fetched concurrently
+// through the fetch pool while earlier threads are written, in order, one
+// at a time (see pipeline).
+func (e *Exporter) exportThreads(ctx context.Context, conv config.ConversationConfig, allMessages []slackapi.Message, result *ExportResult) int {
+	return e.exportThreadsFrom(ctx, conv, conv.ID, allMessages, result)
+}
+
+// exportThreadsFrom is exportThreads for messages fetched from sourceID,
+// conv's own ID or one of its aliases.
+func (e *Exporter) exportThreadsFrom(ctx context.Context, conv config.ConversationConfig, sourceID string, allMessages []slackapi.Message, result *ExportResult) int {
+	// Digests show only how many replies a thread has, so digest-only runs
+	// fetch none.
 	threadParents := GetThreadParents(allMessages)
-	if len(threadParents) == 0 {
+	if len(threadParents) == 0 || e.digestOnly {
+		return 0
+	}
+
+	if !e.capabilities.Usable(slackapi.MethodConversationsReplies) {
+		e.Progress("Skipping %d threads: conversations.replies is restricted", len(threadParents))
 		return 0
 	}
 
 	e.Progress("Exporting %d threads...", len(threadParents))
+	type fetched struct {
+		replies []slackapi.Message
+		err     error
+	}
 	exported := 0
-	for _, parent := range threadParents {
-		if err := e.exportThread(ctx, convID, parent); err != nil {
+	stats := pipeline(e.fetches, len(threadParents), func(i int) fetched {
+		if !e.capabilities.Usable(slackapi.MethodConversationsReplies) {
+			return fetched{err: errRepliesRestricted}
+		}
+		replies, err := e.fetchReplies(ctx, sourceID, threadParents[i].TS)
+		return fetched{replies, err}
+	}, func(i int, f fetched) bool {
+		parent := threadParents[i]
+		err := f.err
+		if err == nil {
+			err = e.writeThread(ctx, conv, parent, f.replies, result)
+		}
+		if err != nil {
+			if !e.capabilities.Usable(slackapi.MethodConversationsReplies) {
+				if !errors.Is(err, errRepliesRestricted) {
+					e.Progress("Warning: failed to export thread %s: %v", parent.TS, err)
+				}
+				e.Progress("conversations.replies is restricted; skipping remaining threads")
+				return false
+			}
 			e.Progress("Warning: failed to export thread %s: %v", parent.TS, err)
 		}
 		exported++
-	}
+		return true
+	})
+	result.Pipeline = stats
+	e.Detail("Threads pipeline: %s", stats)
 	return exported
 }
 
-// ExportConversation exports a single conversation to Google Docs.
-func (e *Exporter) ExportConversation(ctx context.Context, conv config.ConversationConfig) (*ExportResult, error) {
-	result := &ExportResult{
+// errRepliesRestricted marks a thread not fetched because
+// conversations.replies was found to be restricted.
+var errRepliesRestricted = errors.New("conversations.replies is restricted")
+
+// exportThread fetches a thread's replies and writes them with conv's
+// backend.
+func (e *Exporter) exportThread(ctx context.Context, conv config.ConversationConfig, parent slackapi.Message, result *ExportResult) error {
+	replies, err := e.fetchReplies(ctx, conv.ID, parent.TS)
+	if err != nil {
+		return err
+	}
+	return e.writeThread(ctx, conv, parent, replies, result)
+}
+
+// fetchReplies fetches the replies of thread threadTS in convID, including
+// the parent as Slack returns it.
+func (e *Exporter) fetchReplies(ctx context.Context, convID, threadTS string) ([]slackapi.Message, error) {
+	var replies []slackapi.Message
+	err := e.slackClient.GetAllReplies(ctx, convID, threadTS, func(batch []slackapi.Message) error {
+		replies = append(replies, batch...)
+		e.metrics.AddFetched(e.conversationName(convID), len(batch))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch replies: %w", err)
+	}
+	return replies, nil
+}
+
+// writeThread resolves the authors and channels of a thread's replies and
+// writes them with conv's backend.
+func (e *Exporter) writeThread(ctx context.Context, conv config.ConversationConfig, parent slackapi.Message, replies []slackapi.Message, result *ExportResult) error {
+	if len(replies) > 0 {
+		e.loadMessageAuthors(ctx, replies)
+		e.loadMentionedChannels(ctx, replies)
+		replies = e.skipEmojiMessages(conv, replies, result)
+		e.exportCanvases(ctx, conv, replies, result)
+	}
+	return e.backendFor(conv).WriteThread(ctx, conv, parent, replies, result)
+}
+
+// fetchMessages fetches the conversation's messages between oldest and
+// latest, newest first, keeping only the newest sample in sample mode.
+// With a spool, the fetch is checkpointed every few pages, and in resume
+// mode a fetch an earlier run checkpointed continues where it stopped.
+func (e *Exporter) fetchMessages(ctx context.Context, convID, oldest, latest string, spool *fetchSpool) ([]slackapi.Message, error) {
+	e.Progress("Fetching messages...")
+	var allMessages []slackapi.Message
+	messageCount := 0
+	cursor := ""
+
+	if spool != nil {
+		if !e.resumeMode {
+			spool.discard()
+		} else {
+			spooled, more := spool.resume()
+			if len(spooled) > 0 {
+				e.Progress("Resuming fetch: %d messages already fetched", len(spooled))
+			}
+			if !more {
+				return spooled, nil
+			}
+			allMessages = spooled
+			messageCount = len(spooled)
+			cursor = spool.cursor()
+			oldest, latest = spool.bounds()
+		}
+	}
+
+	err := e.slackClient.GetAllMessagesFrom(ctx, convID, oldest, latest, cursor, func(batch []slackapi.Message, next string) error {
+		allMessages = append(allMessages, batch...)
+		messageCount += len(batch)
+		e.metrics.AddFetched(e.conversationName(convID), len(batch))
+		e.Detail("Fetched %d messages...", messageCount)
+		if e.sampleSize > 0 && len(FilterMainMessages(allMessages)) >= e.sampleSize {
+			return errSampleFull
+		}
+		if spool != nil {
+			if err := spool.add(batch, next); err != nil {
+				e.Progress("Warning: failed to save fetch checkpoint: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errSampleFull) {
+		return nil, fmt.Errorf("failed to fetch messages: %w", err)
+	}
+	if spool != nil {
+		if err := spool.finish(); err != nil {
+			e.Progress("Warning: failed to save fetch checkpoint: %v", err)
+		}
+	}
+	if e.sampleSize > 0 {
+		allMessages, _ = newestMain(allMessages, e.sampleSize)
+	}
+	return allMessages, nil
+}
+
+// ExportConversation exports a single conversation to Google Docs, or to
+// local files when its format is markdown or json (see Backend).
+func (e *Exporter) ExportConversation(ctx context.Context, conv config.ConversationConfig) (result *ExportResult, err error) {
+	backend := e.backendFor(conv)
+	result = &ExportResult{
 		ConversationID: conv.ID,
 		Name:           conv.Name,
 	}
 
 	startTime := time.Now()
 	e.Progress("Exporting conversation: %s (%s)", conv.Name, conv.ID)
+	defer e.forgetTranslations(conv.ID)
 
-	// Create folder structure
-	e.Progress("Creating folder structure...")
-	convExport, err := e.folderStructure.EnsureConversationFolder(ctx, conv.ID, string(conv.Type), conv.Name)
+	// Fold history exported under previous IDs into this conversation.
+	if merged := e.index.MergeAliases(conv.ID, conv.Aliases); len(merged) > 0 {
+		e.Progress("Merged export history from previous IDs: %s", strings.Join(merged, ", "))
+		if err := e.index.Save(); err != nil {
+			e.Progress("Warning: failed to save index: %v", err)
+		}
+	}
+
+	// A shared channel another workspace's export already holds is left
+	// to it.
+	if peer, theirs := e.exportedByPeer(conv); peer != nil {
+		e.Progress("Skipping %s: shared channel already exported from %s", conv.Name, peer.configDir)
+		e.markShared(conv, peer, theirs)
+		result.Skipped = true
+		result.SharedWith = peer.configDir
+		result.FolderURL = e.index.LookupConversationURL(conv.ID)
+		return result, nil
+	}
+
+	defer func() {
+		if err != nil {
+			e.recordFailure(ctx, conv.ID, err)
+		}
+	}()
+
+	convExport, err := backend.EnsureConversationContainer(ctx, conv)
 	if err != nil {
-		return result, fmt.Errorf("failed to create folder: %w", err)
+		return result, err
 	}
 	result.FolderURL = convExport.FolderURL
 
+	// Determine oldest/latest bounds, before the status changes so --resume
+	// can tell an interrupted export from a new one
+	oldest, latest := e.determineExportRange(convExport)
+
 	// Set status to in_progress — hold the per-struct mutex so concurrent
 	// Save() calls that marshal this struct see a consistent snapshot.
 	convExport.mu.Lock()
-	convExport.Status = "in_progress"
+	convExport.Status = StatusInProgress
+	convExport.Error = ""
+	convExport.SharedWith = ""
+	if !e.resumeMode {
+		// Days recorded by an earlier, unfinished export are written again.
+		convExport.CompletedDays = nil
+	}
 	convExport.mu.Unlock()
+	if err := e.index.SaveConversation(conv.ID); err != nil {
+		e.Progress("Warning: failed to save index: %v", err)
+	}
 
-	// Determine oldest/latest bounds
-	oldest, latest := e.determineExportRange(convExport)
-
-	// Fetch all messages
-	e.Progress("Fetching messages...")
-	var allMessages []slackapi.Message
-	messageCount := 0
+	// Messages still posted under previous IDs, and alias folders left
+	// from before the merge, go into this conversation's folder.
+	if e.sampleSize == 0 && len(conv.Aliases) > 0 {
+		e.mergeAliasFolders(ctx, conv.ID)
+		e.exportAliasSources(ctx, conv, backend, convExport, oldest, latest, result)
+	}
 
-	err = e.slackClient.GetAllMessages(ctx, conv.ID, oldest, latest, func(batch []slackapi.Message) error {
-		allMessages = append(allMessages, batch...)
-		messageCount += len(batch)
-		e.Progress("Fetched %d messages...", messageCount)
-		return nil
-	})
+	// Fetch all messages, checkpointing the fetch so an export interrupted
+	// partway through a long history does not fetch it all again.
+	var spool *fetchSpool
+	if e.sampleSize == 0 {
+		spool = newFetchSpool(e.index, conv.ID, convExport, oldest, latest)
+	}
+	allMessages, err := e.fetchMessages(ctx, conv.ID, oldest, latest, spool)
 	if err != nil {
-		return result, fmt.Errorf("failed to fetch messages: %w", err)
+		return result, err
 	}
+	result.FetchedAt = time.Now()
 
 	if len(allMessages) == 0 {
 		e.Progress("No new messages to export for %s", conv.Name)
+		if spool != nil {
+			spool.discard()
+		}
+		convExport.mu.Lock()
+		convExport.Status = StatusComplete
+		convExport.LastUpdated = time.Now()
+		convExport.mu.Unlock()
+		if err := e.index.SaveConversation(conv.ID); err != nil {
+			e.Progress("Warning: failed to save index: %v", err)
+		}
 		result.Duration = time.Since(startTime)
 		return result, nil
 	}
 
 	e.Progress("Processing %d messages...", len(allMessages))
+	e.loadMessageAuthors(ctx, allMessages)
+	e.loadMentionedChannels(ctx, allMessages)
 
 	// Filter to main messages (not thread replies)
 	mainMessages := FilterMainMessages(allMessages)
-	e.Progress("Found %d main messages, %d thread replies", len(mainMessages), len(allMessages)-len(mainMessages))
+	e.Detail("Found %d main messages, %d thread replies", len(mainMessages), len(allMessages)-len(mainMessages))
+	mainMessages = e.skipEmojiMessages(conv, mainMessages, result)
 
 	// Group messages by date
 	messagesByDate := GroupMessagesByDate(mainMessages)
+	if e.resumeMode {
+		if skipped := skipCompletedDays(convExport, messagesByDate); skipped > 0 {
+			e.Progress("Skipping %d days already written before the interruption", skipped)
+		}
+	}
 	dates := SortedDates(messagesByDate)
 
-	// Export threads first so we have links for the daily docs
-	result.ThreadsExported = e.exportThreads(ctx, conv.ID, allMessages)
-
-	e.Progress("Writing to %d daily docs...", len(dates))
-
-	// Write each day's messages to a doc
-	for _, date := range dates {
-		msgs := messagesByDate[date]
+	// Apply the run budget before writing anything, so threads whose parents
+	// fall outside the budget are left for the next run as well.
+	days, budgetHit := e.planBudget(conv.ID, dates, messagesByDate)
+	threadSource := allMessages
+	latestTS := allMessages[0].TS // Messages come in reverse order
+	if budgetHit {
+		result.BudgetExhausted = true
+		threadSource = messagesInDays(allMessages, days)
+		latestTS = newestTS(days)
+		e.Progress("Run budget reached: writing %d of %d days for %s", len(days), len(dates), conv.Name)
+	}
 
-		// Create or find daily doc
-		docExport, err := e.folderStructure.EnsureDailyDoc(ctx, conv.ID, date)
+	// Export canvases and threads first so we have links for the daily docs
+	e.exportCanvases(ctx, conv, threadSource, result)
+	result.ThreadsExported = e.exportThreads(ctx, conv, threadSource, result)
+
+	e.Progress("Writing %d days...", len(days))
+
+	// A day's downloads are fetched while the days before it are written
+	// (see pipeline and prefetchDownloads).
+	var digestDays []DigestDay
+	var writeErr error
+	dayStats := pipeline(e.fetches, len(days), func(i int) []string {
+		return e.prefetchDownloads(ctx, conv, days[i].messages)
+	}, func(i int, prefetched []string) bool {
+		defer e.prefetched.drop(prefetched)
+		day := days[i]
+		written, err := backend.WriteMessages(ctx, conv, day.date, day.messages, result)
+		result.MessageCount += written
 		if err != nil {
-			return result, fmt.Errorf("failed to create doc for %s: %w", date, err)
+			writeErr = err
+			return false
 		}
-
-		// Write messages to doc
-		if err := e.docWriter.WriteMessages(ctx, docExport.DocID, conv.ID, convExport.FolderID, msgs); err != nil {
-			return result, fmt.Errorf("failed to write messages for %s: %w", date, err)
+		// Digests are only sent for docs conversations, whose messages
+		// were translated as their docs were written.
+		if e.digestSink != nil && conv.WritesDocs() {
+			digestDays = append(digestDays, DigestDay{Date: day.date, Messages: e.translateMessages(ctx, conv, day.messages, result)})
 		}
 
-		result.DocsCreated++
-		result.MessageCount += len(msgs)
-		e.Progress("Wrote %d messages to %s", len(msgs), date)
-
-		// Save checkpoint after each daily doc — hold the per-struct mutex so
-		// the index-level Save() sees a consistent view of this struct's fields.
+		// Save checkpoint after each day — hold the per-struct mutex so the
+		// index-level Save() sees a consistent view of this struct's fields.
 		// Save() itself also acquires convExport.mu, so we must release it first.
+		// The checkpoint is the newest message written so far, never moving
+		// back, so an interrupted export continues after the last whole day.
+		// The day is recorded as well, so --resume skips it even when the
+		// checkpoint does not bound the fetched messages.
 		convExport.mu.Lock()
-		docExport.MessageCount += len(msgs)
-		if len(msgs) > 0 {
-			docExport.LastMessageTS = msgs[len(msgs)-1].TS
+		ts := newestTS([]budgetDay{day})
+		if ts > convExport.LastMessageTS {
+			convExport.LastMessageTS = ts
+		}
+		if first := oldestTS([]budgetDay{day}); first != "" && (convExport.FirstMessageTS == "" || first < convExport.FirstMessageTS) {
+			convExport.FirstMessageTS = first
 		}
-		if len(allMessages) > 0 {
-			convExport.LastMessageTS = allMessages[0].TS
-			convExport.MessageCount += len(msgs)
-			convExport.LastUpdated = time.Now()
+		if ts > convExport.CompletedDays[day.date] {
+			if convExport.CompletedDays == nil {
+				convExport.CompletedDays = make(map[string]string)
+			}
+			convExport.CompletedDays[day.date] = ts
 		}
+		convExport.MessageCount += written
+		convExport.LastUpdated = time.Now()
 		convExport.mu.Unlock()
-		if err := e.index.Save(); err != nil {
+		if err := e.index.SaveConversation(conv.ID); err != nil {
 			e.Progress("Warning: failed to save checkpoint: %v", err)
 		}
-
-		// Write local markdown if configured and conversation opted in
-		if e.mdWriter != nil && e.localExportDir != "" && conv.LocalExport {
-			mdMsgs := msgs
-			var filterResult *FilterResult
-
-			// Apply sensitivity filter when configured (FR-006: only affects local markdown).
-			if e.messageFilter != nil {
-				var filterErr error
-				filterResult, filterErr = e.messageFilter.FilterMessages(ctx, msgs)
-				if filterErr != nil {
-				// Hard gate: filter errors are fatal (FR-007).
-				return result, fmt.Errorf("sensitivity classification failed for %q (%s): %w", conv.Name, date, filterErr)
-				}
-				if filterResult.AllFiltered() {
-					e.Progress("All %d messages filtered for %s — skipping markdown", filterResult.TotalCount, date)
-					continue
-				}
-				if filterResult.FilteredCount > 0 {
-					e.Progress("Filtered %d/%d sensitive messages for %s", filterResult.FilteredCount, filterResult.TotalCount, date)
-				}
-				mdMsgs = filterResult.PassedMessages
-			}
-
-			mdContent, mdErr := e.mdWriter.RenderDailyDoc(conv.Name, string(conv.Type), date, mdMsgs, filterResult)
-			if mdErr != nil {
-				e.Progress("Warning: failed to render markdown for %s: %v", date, mdErr)
-				result.MarkdownErrors++
-			} else {
-				typeName := SanitizeDirectoryName(string(conv.Type), conv.Name)
-				if writeErr := WriteMarkdownFile(e.localExportDir, typeName, date, mdContent); writeErr != nil {
-					e.Progress("Warning: failed to write markdown for %s: %v", date, writeErr)
-					result.MarkdownErrors++
-				} else {
-					result.MarkdownFilesWritten++
-				}
-			}
-		}
+		return true
+	})
+	result.DayPipeline = dayStats
+	e.Detail("Days pipeline: %s", dayStats)
+	if writeErr != nil {
+		return result, writeErr
 	}
 
-	// Update conversation export state — mark as complete.
+	// Update conversation export state — mark as complete unless the run
+	// budget cut it short, in which case it stays in_progress and
+	// LastMessageTS records where the next --sync run should continue.
 	convExport.mu.Lock()
-	convExport.Status = "complete"
+	if !budgetHit {
+		convExport.Status = StatusComplete
+		convExport.CompletedDays = nil
+	}
 	convExport.LastUpdated = time.Now()
-	if len(allMessages) > 0 {
-		convExport.LastMessageTS = allMessages[0].TS // Messages come in reverse order
+	if latestTS != "" {
+		convExport.LastMessageTS = latestTS
 	}
 	convExport.mu.Unlock()
+	if spool != nil {
+		spool.discard()
+	}
 
 	// Save final index
-	if err := e.index.Save(); err != nil {
+	if err := e.index.SaveConversation(conv.ID); err != nil {
 		e.Progress("Warning: failed to save index: %v", err)
 	}
+	e.saveUserCache()
+	if err := backend.Finalize(ctx, conv, result); err != nil {
+		return result, err
+	}
+
+	e.sendDigest(ctx, conv, digestDays)
 
 	result.Duration = time.Since(startTime)
 	e.Progress("Completed export of %s in %v", conv.Name, result.Duration)
@@ -405,66 +1255,346 @@ func (e *Exporter) ExportConversation(ctx context.Context, conv config.Conversat
 	return result, nil
 }
 
-// exportThread exports a single thread to its own folder.
-func (e *Exporter) exportThread(ctx context.Context, convID string, parent slackapi.Message) error {
-	// Create topic preview from parent message
-	// Resolve the raw Slack mrkdwn to readable text for the folder name
-	resolvedText, _ := parser.ConvertMrkdwnWithLinks(parent.Text, e.userResolver, e.channelResolver, e.personResolver, nil)
-	topicPreview := truncate(resolvedText, 40)
-	if topicPreview == "" {
-		topicPreview = "Thread"
+// skipCompletedDays removes from messagesByDate the messages an earlier,
+// interrupted export of convExport already wrote, dropping days left
+// empty, and returns how many days were dropped.
+func skipCompletedDays(convExport *ConversationExport, messagesByDate map[string][]slackapi.Message) int {
+	convExport.mu.Lock()
+	defer convExport.mu.Unlock()
+
+	skipped := 0
+	for date, msgs := range messagesByDate {
+		written, ok := convExport.CompletedDays[date]
+		if !ok {
+			continue
+		}
+		var pending []slackapi.Message
+		for _, m := range msgs {
+			if m.TS > written {
+				pending = append(pending, m)
+			}
+		}
+		if len(pending) == 0 {
+			delete(messagesByDate, date)
+			skipped++
+			continue
+		}
+		messagesByDate[date] = pending
 	}
+	return skipped
+}
 
-	// Create thread folder
-	threadExport, err := e.folderStructure.EnsureThreadFolder(ctx, convID, parent.TS, topicPreview)
-	if err != nil {
-		return fmt.Errorf("failed to create thread folder: %w", err)
+// recordFailure marks convID failed with err. An export stopped by the
+// run being interrupted is left in_progress instead, to be continued with
+// --resume.
+func (e *Exporter) recordFailure(ctx context.Context, convID string, err error) {
+	convExport := e.index.GetConversation(convID)
+	if convExport == nil || ctx.Err() != nil {
+		return
+	}
+	convExport.mu.Lock()
+	convExport.Status = StatusFailed
+	convExport.Error = err.Error()
+	convExport.LastUpdated = time.Now()
+	convExport.mu.Unlock()
+	if err := e.index.SaveConversation(convID); err != nil {
+		e.Progress("Warning: failed to save index: %v", err)
 	}
+}
 
-	// Fetch thread replies
-	var replies []slackapi.Message
-	err = e.slackClient.GetAllReplies(ctx, convID, parent.TS, func(batch []slackapi.Message) error {
-		replies = append(replies, batch...)
-		return nil
-	})
-	if err != nil {
-		return fmt.Errorf("failed to fetch replies: %w", err)
+// recordMessageMap records that msgs were written to docURL.
+func (e *Exporter) recordMessageMap(convID, convName, docURL string, msgs []slackapi.Message) {
+	if e.messageMap == nil {
+		return
+	}
+	if err := e.messageMap.Record(convID, convName, docURL, msgs); err != nil {
+		e.Progress("Warning: %v", err)
+	}
+}
+
+// markdownWriteMode controls what happens when a daily markdown file
+// already exists.
+type markdownWriteMode int
+
+const (
+	mdSkipExisting markdownWriteMode = iota // keep the existing file
+	mdAppend                                // append the new messages (--sync)
+	mdReplace                               // rewrite it (thread replies are refetched in full)
+)
+
+// writeMarkdownDay writes one day's messages to {localExportDir}/{dir}/{date}.md
+// when local markdown is configured and conv opted in, applying the
+// sensitivity filter first. It returns the file written, relative to the
+// local export dir ("" when none was). Only a filter failure is returned;
+// render and write failures are reported and counted in
+// result.MarkdownErrors. In legal hold mode an existing file is never
+// modified: new messages go to a new part file instead.
+func (e *Exporter) writeMarkdownDay(ctx context.Context, conv config.ConversationConfig, dir, date string, msgs []slackapi.Message, mode markdownWriteMode, result *ExportResult) (string, error) {
+	if e.mdWriter == nil || e.localExportDir == "" || !conv.WritesMarkdown() {
+		return "", nil
+	}
+	if e.legalHold && mode == mdSkipExisting {
+		if _, err := os.Stat(filepath.Join(e.localExportDir, dir, date+".md")); err == nil {
+			return "", nil
+		}
+	}
+
+	mdMsgs := msgs
+	var filterResult *FilterResult
+
+	// Apply sensitivity filter when configured (FR-006: only affects local markdown).
+	if e.messageFilter != nil {
+		var filterErr error
+		filterResult, filterErr = e.messageFilter.FilterMessages(ctx, msgs)
+		if filterErr != nil {
+			// Hard gate: filter errors are fatal (FR-007).
+			return "", fmt.Errorf("sensitivity classification failed for %q (%s): %w", conv.Name, date, filterErr)
+		}
+		if filterResult.AllFiltered() {
+			e.Progress("All %d messages filtered for %s — skipping markdown", filterResult.TotalCount, date)
+			return "", nil
+		}
+		if filterResult.FilteredCount > 0 {
+			e.Detail("Filtered %d/%d sensitive messages for %s", filterResult.FilteredCount, filterResult.TotalCount, date)
+		}
+		mdMsgs = filterResult.PassedMessages
+	}
+
+	convDir := SanitizeDirectoryName(string(conv.Type), conv.Name)
+	e.saveLocalFiles(ctx, convDir, mdMsgs, result)
+	mdMsgs = e.linkLocalFiles(convDir, dir, mdMsgs)
+
+	// Only messages the filter passed are translated; a dead letter keeps
+	// them untranslated, as a retry translates them again.
+	translated := e.translateMessages(ctx, conv, mdMsgs, result)
+	mdWriter := e.markdownWriter(conv)
+	mdContent, mdErr := mdWriter.RenderDailyDoc(conv.ID, conv.Name, string(conv.Type), date, translated, filterResult)
+	var mdBody []byte
+	if mdErr == nil && mode == mdAppend {
+		mdBody, mdErr = mdWriter.RenderMessages(translated)
 	}
+	if mdErr != nil {
+		e.Progress("Warning: failed to render markdown for %s: %v", date, mdErr)
+		result.MarkdownErrors++
+		e.deadLetter(conv.ID, DeadLetter{Stage: DeadLetterStageMarkdown, Date: date, Dir: dir, Replace: mode == mdReplace, Error: mdErr.Error()}, mdMsgs, result)
+		return "", nil
+	}
+	provenance := e.markdownProvenance(result)
+	mdContent = append(mdContent, provenance...)
+
+	name := date + ".md"
+	var writeErr error
+	switch {
+	case e.legalHold:
+		name, writeErr = WriteMarkdownPart(e.localExportDir, dir, date, mdContent)
+	case mode == mdAppend:
+		writeErr = AppendMarkdownFile(e.localExportDir, dir, date, mdContent, append(mdBody, provenance...))
+	case mode == mdReplace:
+		writeErr = ReplaceMarkdownFile(e.localExportDir, dir, date, mdContent)
+	default:
+		writeErr = WriteMarkdownFile(e.localExportDir, dir, date, mdContent)
+	}
+	if writeErr != nil {
+		e.Progress("Warning: failed to write markdown for %s: %v", date, writeErr)
+		result.MarkdownErrors++
+		e.deadLetter(conv.ID, DeadLetter{Stage: DeadLetterStageMarkdown, Date: date, Dir: dir, Replace: mode == mdReplace, Error: writeErr.Error()}, mdMsgs, result)
+		return "", nil
+	}
+	result.MarkdownFilesWritten++
+	return filepath.Join(dir, name), nil
+}
 
-	if len(replies) == 0 {
+// recordHold adds a day's messages, and the markdown file they were written
+// to (relative to the local export dir, "" for none), to the legal-hold
+// chain. It is a no-op outside legal hold mode. Failing to record is fatal:
+// an export under hold must not write what it cannot account for.
+func (e *Exporter) recordHold(convID, threadTS, date string, msgs []slackapi.Message, file string) error {
+	if e.holdLedger == nil {
 		return nil
 	}
+	content, err := e.holdFileContent(file)
+	if err != nil {
+		return err
+	}
+	if _, err := e.holdLedger.Record(convID, date, threadTS, msgs, file, content); err != nil {
+		return fmt.Errorf("failed to record legal hold entry for %s: %w", date, err)
+	}
+	return nil
+}
 
-	// Group by date and write
-	replyByDate := GroupMessagesByDate(replies)
-	dates := SortedDates(replyByDate)
+// holdFileContent reads file, relative to the local export directory, to
+// be hashed into a legal hold entry. No file has no content.
+func (e *Exporter) holdFileContent(file string) ([]byte, error) {
+	if file == "" {
+		return nil, nil
+	}
+	content, err := os.ReadFile(filepath.Join(e.localExportDir, file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash %s for legal hold: %w", file, err)
+	}
+	return content, nil
+}
 
-	for _, date := range dates {
-		msgs := replyByDate[date]
+// unheldReplies returns the replies of thread threadTS in convID to write.
+// Threads are refetched in full, and their markdown rewritten, but under
+// legal hold what earlier runs wrote stays as it is: only the replies newer
+// than the thread's last exported reply are written, to its docs and to a
+// new markdown part.
+func (e *Exporter) unheldReplies(convID, threadTS string, replies []slackapi.Message) []slackapi.Message {
+	convExport := e.index.GetConversation(convID)
+	thread := e.index.GetThread(convID, threadTS)
+	if !e.legalHold || convExport == nil || thread == nil {
+		return replies
+	}
+	convExport.mu.Lock()
+	last := thread.LastReplyTS
+	convExport.mu.Unlock()
 
-		// Create thread daily doc
-		docExport, err := e.folderStructure.EnsureThreadDailyDoc(ctx, convID, parent.TS, date)
-		if err != nil {
-			return fmt.Errorf("failed to create thread doc: %w", err)
+	var newer []slackapi.Message
+	for _, msg := range replies {
+		if msg.TS > last {
+			newer = append(newer, msg)
 		}
+	}
+	return newer
+}
 
-		if err := e.docWriter.WriteMessages(ctx, docExport.DocID, convID, threadExport.FolderID, msgs); err != nil {
-			return fmt.Errorf("failed to write thread messages: %w", err)
-		}
+// setLastReply records the newest of replies, oldest first, as the last
+// exported reply of thread threadTS in convID, when the index has the
+// thread.
+func (e *Exporter) setLastReply(convID, threadTS string, replies []slackapi.Message) {
+	convExport := e.index.GetConversation(convID)
+	thread := e.index.GetThread(convID, threadTS)
+	if convExport == nil || thread == nil || len(replies) == 0 {
+		return
+	}
+	convExport.mu.Lock()
+	thread.LastReplyTS = replies[len(replies)-1].TS
+	convExport.mu.Unlock()
+}
 
-		docExport.MessageCount += len(msgs)
+// writeDocMessages appends msgs to a daily doc, dead-lettering any message
+// the Docs API rejects instead of failing the day. threadTS is the thread
+// parent for thread docs. It returns how many messages were written, and
+// how much the doc grew (see DocAppend).
+func (e *Exporter) writeDocMessages(ctx context.Context, convID, threadTS, date, docID, folderID string, msgs []slackapi.Message, result *ExportResult) (int, int64, error) {
+	res, err := e.docWriter.WriteMessagesIsolated(ctx, docID, convID, folderID, msgs)
+	if err != nil {
+		return 0, 0, err
 	}
+	return e.reportDocFailures(convID, threadTS, date, msgs, res.Failed, result), res.Grown, nil
+}
 
-	// Update thread state
-	threadExport.ReplyCount = len(replies)
-	if len(replies) > 0 {
-		threadExport.LastReplyTS = replies[len(replies)-1].TS
+// resumeDocMessages writes msgs to a day's doc like writeDocMessages, but
+// leaves out the messages an interrupted run already appended to the doc
+// without recording them in the index (see DocWriter.WriteMessagesAfter).
+func (e *Exporter) resumeDocMessages(ctx context.Context, convID, date, docID, folderID string, recordedEnd int64, msgs []slackapi.Message, result *ExportResult) (int, int64, error) {
+	res, err := e.docWriter.WriteMessagesAfter(ctx, docID, convID, folderID, recordedEnd, msgs)
+	if err != nil {
+		return 0, 0, err
+	}
+	switch {
+	case res.Skipped > 0:
+		e.Progress("The doc for %s already has %d of its messages from the interrupted run; appending the rest", date, res.Skipped)
+	case res.Skipped < 0:
+		e.Progress("Warning: the doc for %s changed since it was last recorded; writing all %d of its messages", date, len(msgs))
+	}
+	return e.reportDocFailures(convID, "", date, msgs, res.Failed, result), res.Grown, nil
+}
+
+// reportDocFailures reports and dead-letters the messages the Docs API
+// rejected, and returns how many of msgs were written.
+func (e *Exporter) reportDocFailures(convID, threadTS, date string, msgs []slackapi.Message, failed []MessageError, result *ExportResult) int {
+	for _, f := range failed {
+		e.Progress("Warning: Docs rejected message %s on %s: %v", f.Message.TS, date, f.Err)
+		e.stats.Error(e.conversationName(convID), fmt.Errorf("Docs rejected message %s on %s: %w", f.Message.TS, date, f.Err))
+		e.metrics.Error(f.Err)
+		e.deadLetter(convID, DeadLetter{Stage: DeadLetterStageDocs, Date: date, ThreadTS: threadTS, Error: f.Err.Error()}, []slackapi.Message{f.Message}, result)
 	}
+	written := len(msgs) - len(failed)
+	e.addMessages(written)
+	return written
+}
 
-	return nil
+// deadLetter records msgs in the dead-letter store so `get-out reprocess`
+// can write them later.
+func (e *Exporter) deadLetter(convID string, entry DeadLetter, msgs []slackapi.Message, result *ExportResult) {
+	result.DeadLettered += len(msgs)
+	if err := e.deadLetters.Add(convID, entry, msgs); err != nil {
+		e.Progress("Warning: failed to record %d failed messages: %v", len(msgs), err)
+	}
+}
+
+// conversationName returns the indexed name of convID, or convID when it
+// is not in the index.
+func (e *Exporter) conversationName(convID string) string {
+	if e.index == nil {
+		return convID
+	}
+	if conv := e.index.GetConversation(convID); conv != nil && conv.Name != "" {
+		return conv.Name
+	}
+	return convID
+}
+
+// compactIndex rewrites the whole index once conversations have been
+// checkpointed, so the index file is complete between runs.
+func (e *Exporter) compactIndex() {
+	if err := e.index.Save(); err != nil {
+		e.Progress("Warning: failed to save index: %v", err)
+	}
 }
 
-// ExportAll exports all conversations in the provided list.
+// queueConversations records the conversations a run is about to export
+// that have never been exported as pending, so `get-out status` shows the
+// whole run from the start. Conversations with aliases are left to
+// ExportConversation, which adopts the history exported under the aliases.
+func (e *Exporter) queueConversations(conversations []config.ConversationConfig) {
+	for _, conv := range conversations {
+		if len(conv.Aliases) == 0 {
+			e.index.GetOrCreateConversation(conv.ID, conv.Name, string(conv.Type))
+		}
+	}
+	if err := e.index.Save(); err != nil {
+		e.Progress("Warning: failed to save index: %v", err)
+	}
+}
+
+// startRun records the start of a run over total conversations in the run
+// lock and live statistics.
+func (e *Exporter) startRun(total int) {
+	e.runLock.Start(total)
+	e.stats.Start(total)
+	e.metrics.Start(total)
+}
+
+// beginConversation records that a conversation's export has started.
+func (e *Exporter) beginConversation(name string) {
+	e.runLock.Begin(name)
+	e.stats.Begin(name)
+	e.metrics.Begin(name)
+}
+
+// finishConversation records that a conversation is done, with the error
+// it failed with, if any.
+func (e *Exporter) finishConversation(name string, err error) {
+	e.runLock.Finish()
+	e.stats.Finish(name)
+	e.stats.Error(name, err)
+	e.metrics.Finish(name, err)
+}
+
+// addMessages counts messages written in the live statistics and metrics.
+func (e *Exporter) addMessages(n int) {
+	e.stats.AddMessages(n)
+	e.metrics.AddWritten(n)
+}
+
+// ExportAll exports all conversations in the provided list, one after
+// another. A conversation that fails is recorded in its ExportResult and
+// the others are still exported; the error is for failures that stop the
+// whole run, such as connections or users that fail to load before it
+// starts.
 func (e *Exporter) ExportAll(ctx context.Context, conversations []config.ConversationConfig) ([]*ExportResult, error) {
 	// Pre-validate connections before starting the long export
 	if err := e.ValidateConnections(ctx); err != nil {
@@ -479,12 +1609,22 @@ func (e *Exporter) ExportAll(ctx context.Context, conversations []config.Convers
 	if err := e.LoadUsersForConversations(ctx, channelIDs); err != nil {
 		return nil, err
 	}
+	e.snapshotWorkspace(ctx)
+
+	e.queueConversations(conversations)
+	e.startRun(len(conversations))
+	defer e.stats.End()
 
 	var results []*ExportResult
 	for i, conv := range conversations {
+		if e.budget.Exhausted() {
+			e.Progress("Run budget reached, stopping before %d/%d: %s", i+1, len(conversations), conv.Name)
+			break
+		}
+
 		// In resume mode, skip conversations that are already complete
 		if e.resumeMode {
-			if existing := e.index.GetConversation(conv.ID); existing != nil && existing.Status == "complete" {
+			if existing := e.index.GetConversation(conv.ID); existing != nil && existing.Status == StatusComplete {
 				e.Progress("Skipping completed conversation %d/%d: %s", i+1, len(conversations), conv.Name)
 				results = append(results, &ExportResult{
 					ConversationID: conv.ID,
@@ -492,13 +1632,16 @@ func (e *Exporter) ExportAll(ctx context.Context, conversations []config.Convers
 					FolderURL:      existing.FolderURL,
 					Skipped:        true,
 				})
+				e.finishConversation(conv.Name, nil)
 				continue
 			}
 		}
 
 		e.Progress("Exporting conversation %d/%d: %s", i+1, len(conversations), conv.Name)
 
+		e.beginConversation(conv.Name)
 		result, err := e.ExportConversation(ctx, conv)
+		e.finishConversation(conv.Name, err)
 		results = append(results, result)
 
 		if err != nil {
@@ -508,6 +1651,10 @@ func (e *Exporter) ExportAll(ctx context.Context, conversations []config.Convers
 		}
 	}
 
+	e.writeSlackExportFiles(conversations)
+	e.writeSQLiteUsers()
+	e.compactIndex()
+
 	// Second pass: resolve cross-conversation Slack links — but only if any
 	// conversations actually exported new messages.
 	hasNewContent := false
@@ -518,13 +1665,17 @@ func (e *Exporter) ExportAll(ctx context.Context, conversations []config.Convers
 		}
 	}
 
-	if hasNewContent {
+	if hasNewContent && e.legalHold {
+		e.Progress("Legal hold: leaving cross-conversation links unresolved (existing docs are not modified)")
+	} else if hasNewContent {
 		if replaced, err := e.ResolveCrossLinks(ctx); err != nil {
 			e.Progress("Warning: cross-link resolution had errors: %v", err)
 		} else if replaced > 0 {
 			e.Progress("Resolved %d cross-conversation links", replaced)
 		}
 	}
+	e.writeStatusDoc(ctx, conversations)
+	e.writeMasterIndex(ctx, conversations)
 
 	return results, nil
 }
@@ -574,6 +1725,11 @@ func (e *Exporter) ExportAllParallel(ctx context.Context, conversations []config
 	if err := e.LoadUsersForConversations(ctx, channelIDs); err != nil {
 		return nil, err
 	}
+	e.snapshotWorkspace(ctx)
+
+	e.queueConversations(conversations)
+	e.startRun(len(conversations))
+	defer e.stats.End()
 
 	// Semaphore channel to limit concurrency
 	sem := make(chan struct{}, maxConcurrent)
@@ -596,7 +1752,7 @@ outer:
 
 		// In resume mode, skip completed conversations
 		if e.resumeMode {
-			if existing := e.index.GetConversation(conv.ID); existing != nil && existing.Status == "complete" {
+			if existing := e.index.GetConversation(conv.ID); existing != nil && existing.Status == StatusComplete {
 				e.Progress("Skipping completed conversation %d/%d: %s", i+1, len(conversations), conv.Name)
 				results[i] = &ExportResult{
 					ConversationID: conv.ID,
@@ -604,6 +1760,7 @@ outer:
 					FolderURL:      existing.FolderURL,
 					Skipped:        true,
 				}
+				e.finishConversation(conv.Name, nil)
 				continue
 			}
 		}
@@ -616,9 +1773,16 @@ outer:
 			sem <- struct{}{}
 			defer func() { <-sem }()
 
+			if e.budget.Exhausted() {
+				e.Progress("[parallel %d/%d] Run budget reached, skipping: %s", idx+1, len(conversations), c.Name)
+				return
+			}
+
 			e.Progress("[parallel %d/%d] Exporting: %s", idx+1, len(conversations), c.Name)
 
+			e.beginConversation(c.Name)
 			result, err := e.ExportConversation(ctx, c)
+			e.finishConversation(c.Name, err)
 			if err != nil {
 				result.Error = err
 				e.Progress("[parallel %d/%d] Error: %s: %v", idx+1, len(conversations), c.Name, err)
@@ -632,6 +1796,10 @@ outer:
 
 	wg.Wait()
 
+	e.writeSlackExportFiles(conversations)
+	e.writeSQLiteUsers()
+	e.compactIndex()
+
 	// Second pass: resolve cross-conversation links — but only if any
 	// conversations actually exported new messages (skip when sync mode
 	// found nothing new, to avoid scanning hundreds of docs pointlessly).
@@ -644,13 +1812,17 @@ outer:
 		}
 	}
 
-	if hasNewContent {
+	if hasNewContent && e.legalHold {
+		e.Progress("Legal hold: leaving cross-conversation links unresolved (existing docs are not modified)")
+	} else if hasNewContent {
 		if replaced, err := e.ResolveCrossLinks(ctx); err != nil {
 			e.Progress("Warning: cross-link resolution had errors: %v", err)
 		} else if replaced > 0 {
 			e.Progress("Resolved %d cross-conversation links", replaced)
 		}
 	}
+	e.writeStatusDoc(ctx, conversations)
+	e.writeMasterIndex(ctx, conversations)
 
 	return collected, nil
 }
@@ -707,11 +1879,14 @@ func (e *Exporter) ResolveCrossLinks(ctx context.Context) (int, error) {
 				}
 				continue
 			}
+			if replaced > 0 {
+				e.refreshDocEnd(ctx, conv, doc)
+			}
 			totalReplaced += replaced
 			scanned++
 
 			if scanned%10 == 0 {
-				e.Progress("Scanning docs for cross-links... %d/%d", scanned, totalDocs)
+				e.Detail("Scanning docs for cross-links... %d/%d", scanned, totalDocs)
 			}
 		}
 
@@ -737,7 +1912,7 @@ func (e *Exporter) ResolveCrossLinks(ctx context.Context) (int, error) {
 				scanned++
 
 				if scanned%10 == 0 {
-					e.Progress("Scanning docs for cross-links... %d/%d", scanned, totalDocs)
+					e.Detail("Scanning docs for cross-links... %d/%d", scanned, totalDocs)
 				}
 			}
 		}
@@ -746,6 +1921,20 @@ func (e *Exporter) ResolveCrossLinks(ctx context.Context) (int, error) {
 	return totalReplaced, nil
 }
 
+// refreshDocEnd records the end index of a daily doc whose length changed
+// outside of an append, such as by ReplaceText, so --resume compares the
+// doc against its real end (see DocWriter.WriteMessagesAfter). When it
+// cannot be read the end is recorded as unknown.
+func (e *Exporter) refreshDocEnd(ctx context.Context, conv *ConversationExport, doc *DocExport) {
+	end, err := e.gdriveClient.GetDocumentEndIndex(ctx, doc.DocID)
+	if err != nil {
+		end = 0
+	}
+	conv.mu.Lock()
+	doc.EndIndex = end
+	conv.mu.Unlock()
+}
+
 // resolveLinksInDoc reads a doc, finds Slack links, and replaces them with Google Docs URLs.
 func (e *Exporter) resolveLinksInDoc(ctx context.Context, docID string) (int, error) {
 	content, err := e.gdriveClient.GetDocumentContent(ctx, docID)
@@ -789,6 +1978,10 @@ func (e *Exporter) ValidateConnections(ctx context.Context) error {
 		return fmt.Errorf("Slack session expired or invalid: %w\n\nPlease refresh your Slack session in the browser and try again", err)
 	}
 	e.Progress("Slack session valid: %s @ %s", authResp.User, authResp.Team)
+	if e.index != nil && authResp.UserID != "" {
+		e.index.SetSelfUserID(authResp.UserID)
+	}
+	e.probeAccess(ctx)
 
 	return nil
 }
@@ -805,21 +1998,66 @@ func (e *Exporter) GetRootFolderURL() string {
 type ExportResult struct {
 	ConversationID  string
 	Name            string
-	FolderURL       string
-	MessageCount    int
-	DocsCreated     int
-	ThreadsExported int
-	Duration        time.Duration
-	Error           error
-	Skipped         bool // True if skipped during --resume (already complete)
+	FolderURL       string        // Drive folder of the conversation ("" for local formats)
+	MessageCount    int           // Messages written in this run
+	DocsCreated     int           // Daily docs created in this run
+	ThreadsExported int           // Threads whose replies were written
+	Duration        time.Duration // How long the conversation took
+	Error           error         // Why the export failed; nil on success
+	Skipped         bool          // True if skipped during --resume (already complete)
+	SharedWith      string        // Peer config dir that exports this shared channel, when skipped for it
+	BudgetExhausted bool          // True if the run budget stopped this export early
 
 	// Local markdown export stats
 	MarkdownFilesWritten int
 	MarkdownErrors       int
+
+	// JSONFilesWritten counts day files written for the json and slack
+	// formats.
+	JSONFilesWritten int
+
+	// HTMLPagesWritten counts day pages written for the html format.
+	HTMLPagesWritten int
+
+	// Attachments saved locally with --download-files, and downloads that
+	// failed
+	FilesDownloaded int
+	FileErrors      int
+
+	// Canvases and posts exported as their own docs, and ones that failed
+	CanvasesExported int
+	CanvasErrors     int
+
+	// Messages translated, and translations that failed (the message
+	// keeps its original text)
+	MessagesTranslated int
+	TranslationErrors  int
+
+	// Messages of only emoji or a GIF left out (see
+	// ExporterConfig.SkipEmojiMessages)
+	EmojiMessagesSkipped int
+
+	// Messages set aside in the dead-letter store
+	DeadLettered int
+
+	// FetchedAt is when the conversation's messages were fetched from
+	// Slack, recorded in provenance lines
+	FetchedAt time.Time
+
+	// Time the thread export spent fetching, writing, and waiting on
+	// either stage
+	Pipeline PipelineStats
+
+	// The same for the daily docs and files, whose fetch stage downloads
+	// their images and attachments
+	DayPipeline PipelineStats
 }
 
 // String returns a summary of the export result.
 func (r *ExportResult) String() string {
+	if r.Skipped && r.SharedWith != "" {
+		return fmt.Sprintf("%s: skipped (shared channel exported from %s)", r.Name, r.SharedWith)
+	}
 	if r.Skipped {
 		return fmt.Sprintf("%s: skipped (already complete)", r.Name)
 	}
@@ -835,6 +2073,39 @@ func (r *ExportResult) String() string {
 			summary += fmt.Sprintf(" (%d md errors)", r.MarkdownErrors)
 		}
 	}
+	if r.JSONFilesWritten > 0 {
+		summary += fmt.Sprintf(", %d json files", r.JSONFilesWritten)
+	}
+	if r.HTMLPagesWritten > 0 {
+		summary += fmt.Sprintf(", %d html pages", r.HTMLPagesWritten)
+	}
+	if r.CanvasesExported > 0 || r.CanvasErrors > 0 {
+		summary += fmt.Sprintf(", %d canvases", r.CanvasesExported)
+		if r.CanvasErrors > 0 {
+			summary += fmt.Sprintf(" (%d failed)", r.CanvasErrors)
+		}
+	}
+	if r.FilesDownloaded > 0 || r.FileErrors > 0 {
+		summary += fmt.Sprintf(", %d files downloaded", r.FilesDownloaded)
+		if r.FileErrors > 0 {
+			summary += fmt.Sprintf(" (%d failed)", r.FileErrors)
+		}
+	}
+	if r.MessagesTranslated > 0 || r.TranslationErrors > 0 {
+		summary += fmt.Sprintf(", %d translated", r.MessagesTranslated)
+		if r.TranslationErrors > 0 {
+			summary += fmt.Sprintf(" (%d failed)", r.TranslationErrors)
+		}
+	}
+	if r.EmojiMessagesSkipped > 0 {
+		summary += fmt.Sprintf(", %d emoji messages skipped", r.EmojiMessagesSkipped)
+	}
+	if r.DeadLettered > 0 {
+		summary += fmt.Sprintf(", %d dead-lettered", r.DeadLettered)
+	}
+	if r.BudgetExhausted {
+		summary += " (stopped at run budget)"
+	}
 	return fmt.Sprintf("%s (%v) - %s", summary, r.Duration, status)
 }
 
@@ -1050,6 +1050,7 @@ func TestLoadUsersForConversations_Success(t *testing.T) {
 	})
 
 	exp := testExporter(t, http.NewServeMux(), slackMux)
+	exp.prefetchUsers = true
 
 	err := exp.LoadUsersForConversations(context.Background(), []string{"C001"})
 	if err != nil {
@@ -1436,7 +1437,7 @@ func TestExportThread_NoReplies(t *testing.T) {
 		ReplyCount: 3,
 	}
 
-	err := exp.exportThread(context.Background(), "C001", parent)
+	err := exp.exportThread(context.Background(), config.ConversationConfig{ID: "C001"}, parent, &ExportResult{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1504,6 +1505,9 @@ func TestExportThread_WithReplies(t *testing.T) {
 
 	exp.docWriter = NewDocWriter(exp.gdriveClient, exp.slackClient, exp.userResolver, exp.channelResolver, nil, nil, nil)
 
+	exp.localExportDir = t.TempDir()
+	exp.mdWriter = NewMarkdownWriter(exp.userResolver, exp.channelResolver, nil)
+
 	parent := slackapi.Message{
 		User:       "U001",
 		Text:       "Thread parent",
@@ -1511,7 +1515,9 @@ func TestExportThread_WithReplies(t *testing.T) {
 		ReplyCount: 2,
 	}
 
-	err := exp.exportThread(context.Background(), "C001", parent)
+	cfg := config.ConversationConfig{ID: "C001", Name: "general", Type: "channel", LocalExport: true}
+	result := &ExportResult{}
+	err := exp.exportThread(context.Background(), cfg, parent, result)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1519,6 +1525,17 @@ func TestExportThread_WithReplies(t *testing.T) {
 		t.Error("expected batchUpdate to be called for thread replies")
 	}
 
+	// Replies are also written under the conversation's local threads folder
+	mdPath := filepath.Join(exp.localExportDir, LocalThreadDir("channel", "general", parent.TS, "Thread parent"), DateFromTS(parent.TS)+".md")
+	if data, err := os.ReadFile(mdPath); err != nil {
+		t.Errorf("thread markdown not written: %v", err)
+	} else if !strings.Contains(string(data), "Reply 2") {
+		t.Errorf("thread markdown missing reply:\n%s", data)
+	}
+	if result.MarkdownFilesWritten != 1 {
+		t.Errorf("MarkdownFilesWritten = %d, want 1", result.MarkdownFilesWritten)
+	}
+
 	// Verify thread metadata was updated
 	thread := exp.index.GetThread("C001", "1706788800.000100")
 	if thread == nil {
@@ -1549,7 +1566,7 @@ func TestExportThread_FolderCreationError(t *testing.T) {
 		ReplyCount: 1,
 	}
 
-	err := exp.exportThread(context.Background(), "C999", parent)
+	err := exp.exportThread(context.Background(), config.ConversationConfig{ID: "C999"}, parent, &ExportResult{})
 	if err == nil {
 		t.Fatal("expected error for folder creation failure")
 	}
@@ -1595,7 +1612,7 @@ func TestExportThread_FetchRepliesError(t *testing.T) {
 		ReplyCount: 1,
 	}
 
-	err := exp.exportThread(context.Background(), "C001", parent)
+	err := exp.exportThread(context.Background(), config.ConversationConfig{ID: "C001"}, parent, &ExportResult{})
 	if err == nil {
 		t.Fatal("expected error for fetch replies failure")
 	}
@@ -1656,7 +1673,7 @@ func TestExportThread_TopicPreviewTruncation(t *testing.T) {
 		ReplyCount: 1,
 	}
 
-	err := exp.exportThread(context.Background(), "C001", parent)
+	err := exp.exportThread(context.Background(), config.ConversationConfig{ID: "C001"}, parent, &ExportResult{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1717,7 +1734,7 @@ func TestExportThread_EmptyParentText(t *testing.T) {
 		ReplyCount: 1,
 	}
 
-	err := exp.exportThread(context.Background(), "C001", parent)
+	err := exp.exportThread(context.Background(), config.ConversationConfig{ID: "C001"}, parent, &ExportResult{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -3642,6 +3659,29 @@ func TestDetermineExportRange_OnlyDateFrom(t *testing.T) {
 	}
 }
 
+func TestDetermineExportRange_Resume(t *testing.T) {
+	exp := &Exporter{resumeMode: true}
+	tests := []struct {
+		name       string
+		convExport *ConversationExport
+		wantOldest string
+	}{
+		{name: "interrupted", convExport: &ConversationExport{Status: "in_progress", LastMessageTS: "1706700000.000000"}, wantOldest: "1706700000.000000"},
+		{name: "interrupted before the first day", convExport: &ConversationExport{Status: "in_progress"}},
+		{name: "failed", convExport: &ConversationExport{Status: StatusFailed, Error: "disk full", LastMessageTS: "1706700000.000000"}, wantOldest: "1706700000.000000"},
+		{name: "never exported", convExport: &ConversationExport{}},
+		{name: "queued", convExport: &ConversationExport{Status: StatusPending}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldest, latest := exp.determineExportRange(tt.convExport)
+			if oldest != tt.wantOldest || latest != "" {
+				t.Errorf("determineExportRange() = %q, %q; want %q, \"\"", oldest, latest, tt.wantOldest)
+			}
+		})
+	}
+}
+
 // ===========================================================================
 // exportThreads tests
 // ===========================================================================
@@ -3653,7 +3693,7 @@ func TestExportThreads_NoThreadParents(t *testing.T) {
 		{User: "U001", Text: "Hello", TS: "1706788800.000100"},
 		{User: "U002", Text: "World", TS: "1706788801.000200"},
 	}
-	count := exp.exportThreads(context.Background(), "C001", messages)
+	count := exp.exportThreads(context.Background(), config.ConversationConfig{ID: "C001"}, messages, &ExportResult{})
 	if count != 0 {
 		t.Errorf("expected 0 threads, got %d", count)
 	}
@@ -3717,7 +3757,7 @@ func TestExportThreads_WithThreadParents(t *testing.T) {
 		{User: "U002", Text: "Regular msg", TS: "1706788801.000200"},
 	}
 
-	count := exp.exportThreads(context.Background(), "C001", messages)
+	count := exp.exportThreads(context.Background(), config.ConversationConfig{ID: "C001"}, messages, &ExportResult{})
 	if count != 1 {
 		t.Errorf("expected 1 thread exported, got %d", count)
 	}
@@ -4128,3 +4168,50 @@ func TestExportConversation_NoFilterNoSensitivityFrontmatter(t *testing.T) {
 		t.Error("should NOT have sensitivity block when no filter is configured")
 	}
 }
+
+func TestExportConversation_MergesAliasHistory(t *testing.T) {
+	slackMux := fullMockSlackMux(t, nil)
+	driveMux, _, _ := fullMockDriveMux(t)
+
+	exp := testExporter(t, driveMux, slackMux)
+	exp.docWriter = NewDocWriter(exp.gdriveClient, exp.slackClient, exp.userResolver, exp.channelResolver, nil, nil, nil)
+
+	old := exp.index.GetOrCreateConversation("D111", "alice", "dm")
+	old.FolderID = "folder_old"
+	old.FolderURL = "https://drive.google.com/drive/folders/folder_old"
+	old.MessageCount = 4
+
+	conv := config.ConversationConfig{ID: "G222", Name: "alice-bob", Type: models.ConversationTypeMPIM, Aliases: []string{"D111"}}
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.FolderURL != old.FolderURL {
+		t.Errorf("FolderURL = %q, want the alias folder %q", result.FolderURL, old.FolderURL)
+	}
+	if exp.index.GetConversation("D111") != nil {
+		t.Error("alias entry should be merged away")
+	}
+	merged := exp.index.GetConversation("G222")
+	if merged == nil || merged.MessageCount != 4+result.MessageCount {
+		t.Errorf("merged conversation = %+v, want combined message count", merged)
+	}
+}
+
+func TestNewSlackClient_Mode(t *testing.T) {
+	tests := []struct {
+		token, cookie string
+		want          slackapi.AuthMode
+	}{
+		{token: "xoxc-1", cookie: "xoxd-1", want: slackapi.AuthModeBrowser},
+		{token: "xoxc-1", want: slackapi.AuthModeBrowser},
+		{token: "xoxp-1", want: slackapi.AuthModeAPI},
+		{token: "xoxb-1", want: slackapi.AuthModeAPI},
+	}
+	for _, tt := range tests {
+		if got := newSlackClient(tt.token, tt.cookie).Mode(); got != tt.want {
+			t.Errorf("newSlackClient(%q, %q).Mode() = %v, want %v", tt.token, tt.cookie, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/internal/testutil"
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/gdrive"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+var (
+	_ DriveSink   = (*testutil.FakeDrive)(nil)
+	_ SlackSource = (*testutil.FakeSlack)(nil)
+)
+
+// fakeExporter builds an Exporter wired to in-memory fakes, with its index
+// at indexPath.
+func fakeExporter(t *testing.T, drive *testutil.FakeDrive, slack *testutil.FakeSlack, indexPath string) *Exporter {
+	t.Helper()
+	index, err := LoadExportIndex(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := &Exporter{
+		configDir:       t.TempDir(),
+		rootFolderName:  "Test Exports",
+		gdriveClient:    drive,
+		slackClient:     slack,
+		index:           index,
+		userResolver:    parser.NewUserResolver(),
+		channelResolver: parser.NewChannelResolver(),
+		folderStructure: NewFolderStructure(drive, index, &FolderStructureConfig{RootFolderName: "Test Exports"}),
+		deadLetters:     NewDeadLetterStore(filepath.Join(filepath.Dir(indexPath), "_deadletter")),
+	}
+	exp.docWriter = NewDocWriter(drive, slack, exp.userResolver, exp.channelResolver, nil, index.LookupDocURL, index.LookupThreadURL)
+	return exp
+}
+
+func fakeConversation() (*testutil.FakeDrive, *testutil.FakeSlack, config.ConversationConfig) {
+	slack := testutil.NewFakeSlack()
+	slack.Messages["C001"] = []slackapi.Message{
+		{User: "U001", Text: "Good morning", TS: "1706788800.000100"},                                                 // 2024-02-01
+		{User: "U002", Text: "Thread starter", TS: "1706792400.000200", ThreadTS: "1706792400.000200", ReplyCount: 1}, // 2024-02-01
+		{User: "U001", Text: "Next day", TS: "1706875200.000300"},                                                     // 2024-02-02
+	}
+	slack.Replies[testutil.ThreadKey("C001", "1706792400.000200")] = []slackapi.Message{
+		{User: "U002", Text: "Thread starter", TS: "1706792400.000200", ThreadTS: "1706792400.000200", ReplyCount: 1},
+		{User: "U001", Text: "A reply", TS: "1706792460.000400", ThreadTS: "1706792400.000200"},
+	}
+	return testutil.NewFakeDrive(), slack, config.ConversationConfig{ID: "C001", Name: "general", Type: "channel"}
+}
+
+// appendedTexts returns the content of every block appended to docID.
+func appendedTexts(drive *testutil.FakeDrive, docID string) []string {
+	var texts []string
+	for _, batch := range drive.Appended(docID) {
+		for _, b := range batch {
+			texts = append(texts, b.Content)
+		}
+	}
+	return texts
+}
+
+func TestExportConversation_Fakes(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	if result.DocsCreated != 2 {
+		t.Errorf("DocsCreated = %d, want 2", result.DocsCreated)
+	}
+	if result.MessageCount != 3 {
+		t.Errorf("MessageCount = %d, want 3", result.MessageCount)
+	}
+	if result.ThreadsExported != 1 {
+		t.Errorf("ThreadsExported = %d, want 1", result.ThreadsExported)
+	}
+
+	ce := exp.index.GetConversation("C001")
+	if ce.Status != "complete" {
+		t.Errorf("Status = %q, want complete", ce.Status)
+	}
+	if ce.LastMessageTS != "1706875200.000300" {
+		t.Errorf("LastMessageTS = %q, want newest message TS", ce.LastMessageTS)
+	}
+	day := ce.DailyDocs["2024-02-01"]
+	if day == nil {
+		t.Fatal("no daily doc for 2024-02-01")
+	}
+	got := appendedTexts(drive, day.DocID)
+	if len(got) != 2 || got[0] != "Good morning" {
+		t.Errorf("2024-02-01 doc content = %q, want oldest message first", got)
+	}
+	if len(ce.Threads) != 1 {
+		t.Errorf("threads in index = %d, want 1", len(ce.Threads))
+	}
+}
+
+func TestExportConversation_FakesSyncAppendsOnlyNewMessages(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	indexPath := t.TempDir() + "/export-index.json"
+
+	if _, err := fakeExporter(t, drive, slack, indexPath).ExportConversation(context.Background(), conv); err != nil {
+		t.Fatalf("first export: %v", err)
+	}
+
+	slack.Messages["C001"] = append(slack.Messages["C001"],
+		slackapi.Message{User: "U002", Text: "Later that day", TS: "1706878800.000500"}) // 2024-02-02
+
+	exp := fakeExporter(t, drive, slack, indexPath)
+	exp.syncMode = true
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("sync export: %v", err)
+	}
+	if result.MessageCount != 1 {
+		t.Errorf("MessageCount = %d, want 1 (only the new message)", result.MessageCount)
+	}
+
+	ce := exp.index.GetConversation("C001")
+	got := appendedTexts(drive, ce.DailyDocs["2024-02-02"].DocID)
+	if want := []string{"Next day", "Later that day"}; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("2024-02-02 doc content = %q, want %q", got, want)
+	}
+	if n := len(drive.Documents()); n != 3 {
+		t.Errorf("documents = %d, want 3 (two days and one thread, none duplicated)", n)
+	}
+}
+
+func TestExportConversation_FakesSlackError(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	slack.Errors["GetAllMessagesFrom"] = errors.New("ratelimited")
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+
+	_, err := exp.ExportConversation(context.Background(), conv)
+	if err == nil || !strings.Contains(err.Error(), "failed to fetch messages") {
+		t.Fatalf("error = %v, want fetch failure", err)
+	}
+	if drive.Calls("BatchAppendMessages") != 0 {
+		t.Error("nothing should be written when fetching fails")
+	}
+}
+
+func TestExportConversation_FakesDriveWriteError(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	drive.Errors["BatchAppendMessages"] = errors.New("quota exceeded")
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+
+	_, err := exp.ExportConversation(context.Background(), conv)
+	if err == nil || !strings.Contains(err.Error(), "failed to write messages for 2024-02-01") {
+		t.Fatalf("error = %v, want write failure for the first day", err)
+	}
+	ce := exp.index.GetConversation("C001")
+	if ce.Status != StatusFailed || !strings.Contains(ce.Error, "quota exceeded") {
+		t.Errorf("Status = %q, Error = %q; want failed with the Drive error so the next run resumes", ce.Status, ce.Error)
+	}
+	if ce.LastMessageTS != "" {
+		t.Errorf("LastMessageTS = %q, want unset after a failed first day", ce.LastMessageTS)
+	}
+}
+
+func TestDocWriter_FakesImageUploadCleansUp(t *testing.T) {
+	drive := testutil.NewFakeDrive()
+	slack := testutil.NewFakeSlack()
+	slack.Files["https://files.slack.com/a.png"] = []byte("png")
+	w := NewDocWriter(drive, slack, nil, nil, nil, nil, nil)
+
+	blocks := w.BuildBlocks(context.Background(), "C001", "folder", []slackapi.Message{{
+		User: "U001", TS: "1706788800.000100",
+		Files: []slackapi.File{{Name: "a.png", Mimetype: "image/png", URLPrivateDownload: "https://files.slack.com/a.png"}},
+	}})
+	if len(blocks) != 1 || len(blocks[0].Images) != 1 {
+		t.Fatalf("blocks = %+v, want one block with one image", blocks)
+	}
+	if drive.Calls("UploadFile") != 1 || drive.Calls("MakePublic") != 1 {
+		t.Errorf("upload/make-public calls = %d/%d, want 1/1", drive.Calls("UploadFile"), drive.Calls("MakePublic"))
+	}
+	if drive.Files() != 0 {
+		t.Error("temporary public upload should be deleted after embedding")
+	}
+}
+
+func TestExportConversation_FakesTrackDocEndWithoutReading(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.provenance = true
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if n := drive.Calls("GetDocumentEndIndex"); n != 2 {
+		t.Errorf("GetDocumentEndIndex called %d times, want each daily doc read once, when created", n)
+	}
+	for date, doc := range exp.index.GetConversation("C001").DailyDocs {
+		end, _ := drive.GetDocumentEndIndex(context.Background(), doc.DocID)
+		if doc.EndIndex != end {
+			t.Errorf("%s EndIndex = %d, want the doc's end %d after messages and provenance", date, doc.EndIndex, end)
+		}
+	}
+}
+
+func TestWriteMessagesAfter_SameLengthOtherContent(t *testing.T) {
+	drive, slack, _ := fakeConversation()
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	doc, _ := drive.FindOrCreateDocument(context.Background(), "2024-02-02", "")
+	msg := slackapi.Message{User: "U001", Text: "Next day", TS: "1706875200.000300"}
+
+	// Something else of exactly the message's length was added to the doc.
+	block := exp.docWriter.BuildBlocks(context.Background(), "C001", "", []slackapi.Message{msg})[0]
+	other := block
+	other.SenderName = strings.Repeat("x", len(block.SenderName))
+	if err := drive.BatchAppendMessages(context.Background(), doc.ID, []gdrive.MessageBlock{other}); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := exp.docWriter.WriteMessagesAfter(context.Background(), doc.ID, "C001", "", 1, []slackapi.Message{msg})
+	if err != nil {
+		t.Fatalf("WriteMessagesAfter() error: %v", err)
+	}
+	if res.Skipped != -1 || len(appendedTexts(drive, doc.ID)) != 2 {
+		t.Errorf("Skipped = %d, want the message written although the length matched", res.Skipped)
+	}
+	if end, _ := drive.GetDocumentEndIndex(context.Background(), doc.ID); 1+res.Grown != end {
+		t.Errorf("Grown = %d, want the doc's growth since the recorded end (%d)", res.Grown, end-1)
+	}
+}
+
+func TestExportConversation_FakesResumeAppendsRemainderOfPartialDay(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatalf("first export: %v", err)
+	}
+
+	// Rewind the index to a run interrupted after appending the first
+	// message of 2024-02-02 but before recording it.
+	ce := exp.index.GetConversation("C001")
+	day := ce.DailyDocs["2024-02-02"]
+	if day.EndIndex == 0 {
+		t.Fatal("EndIndex not recorded after the first export")
+	}
+	day.MessageCount, day.EndIndex, day.LastMessageTS = 0, 1, ""
+	ce.Status = StatusFailed
+	ce.LastMessageTS = "1706792400.000200"
+	ce.CompletedDays = map[string]string{"2024-02-01": "1706792400.000200"}
+	slack.Messages["C001"] = append(slack.Messages["C001"],
+		slackapi.Message{User: "U002", Text: "Later that day", TS: "1706878800.000500"}) // 2024-02-02
+
+	exp.resumeMode = true
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatalf("resumed export: %v", err)
+	}
+	got := appendedTexts(drive, day.DocID)
+	if want := []string{"Next day", "Later that day"}; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("2024-02-02 doc content = %q, want %q", got, want)
+	}
+	if day.MessageCount != 2 {
+		t.Errorf("MessageCount = %d, want 2 (the message already in the doc and the appended one)", day.MessageCount)
+	}
+}
@@ -0,0 +1,233 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// fetchCheckpointPages is how many pages of history are fetched between
+// checkpoints of a conversation's fetch.
+const fetchCheckpointPages = 10
+
+// FetchCheckpoint records how far an unfinished export got through a
+// conversation's history, so --resume continues the fetch at Cursor instead
+// of fetching every page again. The pages fetched before it are spooled to
+// a file next to the index (see fetchSpoolPath), one page per line.
+type FetchCheckpoint struct {
+	// Oldest and Latest bound the fetch; Cursor is only valid with them.
+	Oldest string `json:"oldest,omitempty"`
+	Latest string `json:"latest,omitempty"`
+
+	// Cursor is the Slack pagination cursor of the next page to fetch.
+	// Done is set, and Cursor empty, once every page has been fetched.
+	Cursor string `json:"cursor,omitempty"`
+	Done   bool   `json:"done,omitempty"`
+
+	// Pages and Messages count what is spooled.
+	Pages    int `json:"pages"`
+	Messages int `json:"messages"`
+}
+
+// fetchSpoolPath returns the file spooling the pages fetched for convID,
+// in a _fetch directory next to the index, or "" for an index without a
+// path.
+func (idx *ExportIndex) fetchSpoolPath(convID string) string {
+	if idx.path == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(idx.path), "_fetch", convID+".jsonl")
+}
+
+// fetchSpool checkpoints one conversation's fetch: it appends fetched pages
+// to the spool file every fetchCheckpointPages pages, then records the
+// cursor after them in the conversation's FetchCheckpoint.
+type fetchSpool struct {
+	index  *ExportIndex
+	convID string
+	conv   *ConversationExport
+	path   string
+
+	checkpoint FetchCheckpoint
+	pending    [][]slackapi.Message // pages fetched since the last checkpoint
+}
+
+// newFetchSpool returns the spool for a fetch of convID between oldest and
+// latest, or nil when the index has nowhere to keep it.
+func newFetchSpool(index *ExportIndex, convID string, conv *ConversationExport, oldest, latest string) *fetchSpool {
+	path := index.fetchSpoolPath(convID)
+	if path == "" {
+		return nil
+	}
+	return &fetchSpool{
+		index:      index,
+		convID:     convID,
+		conv:       conv,
+		path:       path,
+		checkpoint: FetchCheckpoint{Oldest: oldest, Latest: latest},
+	}
+}
+
+// resume loads what an interrupted fetch of the conversation spooled, when
+// its checkpoint covers this fetch's bounds: a fetch interrupted partway
+// with the same bounds, or a finished one that started no later, as when
+// the export stopped while writing days. It returns the spooled messages
+// that fall inside the bounds and whether the fetch still has pages to go.
+// A checkpoint that does not match, or whose spool cannot be read, is
+// discarded.
+func (s *fetchSpool) resume() (messages []slackapi.Message, more bool) {
+	s.conv.mu.Lock()
+	var prev FetchCheckpoint
+	if s.conv.Fetch != nil {
+		prev = *s.conv.Fetch
+	}
+	s.conv.mu.Unlock()
+
+	want := s.checkpoint
+	matches := prev.Latest == want.Latest &&
+		(prev.Oldest == want.Oldest || (prev.Done && prev.Oldest <= want.Oldest))
+	if prev.Pages == 0 || !matches {
+		s.discard()
+		return nil, true
+	}
+	pages, err := readFetchSpool(s.path, prev.Pages)
+	if err != nil {
+		s.discard()
+		return nil, true
+	}
+	for _, page := range pages {
+		for _, m := range page {
+			if want.Oldest == "" || m.TS > want.Oldest {
+				messages = append(messages, m)
+			}
+		}
+	}
+	s.checkpoint = prev
+	s.checkpoint.Oldest = want.Oldest
+	return messages, !prev.Done
+}
+
+// cursor returns the cursor to continue the fetch at.
+func (s *fetchSpool) cursor() string {
+	return s.checkpoint.Cursor
+}
+
+// bounds returns the oldest and latest to continue the fetch with: those
+// the cursor was returned for.
+func (s *fetchSpool) bounds() (oldest, latest string) {
+	return s.checkpoint.Oldest, s.checkpoint.Latest
+}
+
+// add records a fetched page, checkpointing every fetchCheckpointPages
+// pages. next is the cursor of the page after it.
+func (s *fetchSpool) add(page []slackapi.Message, next string) error {
+	s.pending = append(s.pending, page)
+	if len(s.pending) < fetchCheckpointPages {
+		return nil
+	}
+	return s.save(next, false)
+}
+
+// finish checkpoints the pages not yet spooled and marks the fetch done, so
+// an export interrupted while writing does not fetch again.
+func (s *fetchSpool) finish() error {
+	return s.save("", true)
+}
+
+// save appends the pending pages to the spool file and syncs it before
+// recording the checkpoint, so the checkpoint never counts a page the file
+// lacks.
+func (s *fetchSpool) save(next string, done bool) error {
+	if len(s.pending) > 0 {
+		if err := appendFetchSpool(s.path, s.checkpoint.Pages == 0, s.pending); err != nil {
+			return err
+		}
+		for _, page := range s.pending {
+			s.checkpoint.Pages++
+			s.checkpoint.Messages += len(page)
+		}
+		s.pending = nil
+	}
+	if s.checkpoint.Pages == 0 {
+		return nil
+	}
+	s.checkpoint.Cursor = next
+	s.checkpoint.Done = done
+
+	checkpoint := s.checkpoint
+	s.conv.mu.Lock()
+	s.conv.Fetch = &checkpoint
+	s.conv.mu.Unlock()
+	return s.index.SaveConversation(s.convID)
+}
+
+// discard removes the spool file and the conversation's checkpoint, once
+// the export no longer needs them. The caller saves the index.
+func (s *fetchSpool) discard() {
+	s.conv.mu.Lock()
+	s.conv.Fetch = nil
+	s.conv.mu.Unlock()
+	s.checkpoint.Cursor, s.checkpoint.Done = "", false
+	s.checkpoint.Pages, s.checkpoint.Messages = 0, 0
+	s.pending = nil
+	_ = os.Remove(s.path)
+}
+
+// appendFetchSpool writes pages to the spool file at path, one JSON array
+// of messages per line, truncating it first when fresh.
+func appendFetchSpool(path string, fresh bool, pages [][]slackapi.Message) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if fresh {
+		flags |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open fetch spool: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, page := range pages {
+		if err := enc.Encode(page); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to write fetch spool: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write fetch spool: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write fetch spool: %w", err)
+	}
+	return f.Close()
+}
+
+// readFetchSpool reads the first n pages of the spool file at path. Lines
+// after them, written before a crash kept the checkpoint from counting
+// them, are ignored.
+func readFetchSpool(path string, n int) ([][]slackapi.Message, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(bufio.NewReader(f))
+	pages := make([][]slackapi.Message, 0, n)
+	for len(pages) < n {
+		var page []slackapi.Message
+		if err := dec.Decode(&page); err != nil {
+			return nil, fmt.Errorf("failed to read fetch spool: %w", err)
+		}
+		pages = append(pages, page)
+	}
+	return pages, nil
+}
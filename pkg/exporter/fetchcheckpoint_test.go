@@ -0,0 +1,164 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jflowers/get-out/internal/testutil"
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// interruptedSlack fails a history fetch after a number of pages, and
+// records the cursors fetches start at.
+type interruptedSlack struct {
+	*testutil.FakeSlack
+	failAfter int // pages; 0 never fails
+	cursors   *[]string
+}
+
+func (s interruptedSlack) GetAllMessagesFrom(ctx context.Context, channelID string, oldest, latest, cursor string, callback func([]slackapi.Message, string) error) error {
+	*s.cursors = append(*s.cursors, cursor)
+	pages := 0
+	return s.FakeSlack.GetAllMessagesFrom(ctx, channelID, oldest, latest, cursor, func(batch []slackapi.Message, next string) error {
+		if s.failAfter > 0 && pages == s.failAfter {
+			return errors.New("connection reset")
+		}
+		pages++
+		return callback(batch, next)
+	})
+}
+
+// longConversation returns a conversation of n messages, one a minute on
+// 2024-02-01, served two to a page.
+func longConversation(n int) (*testutil.FakeDrive, *testutil.FakeSlack, config.ConversationConfig) {
+	slack := testutil.NewFakeSlack()
+	slack.PageSize = 2
+	for i := range n {
+		slack.Messages["C001"] = append(slack.Messages["C001"], slackapi.Message{
+			User: "U001",
+			Text: fmt.Sprintf("message %d", i),
+			TS:   fmt.Sprintf("%d.000100", 1706788800+60*i),
+		})
+	}
+	return testutil.NewFakeDrive(), slack, config.ConversationConfig{ID: "C001", Name: "general", Type: "channel"}
+}
+
+func TestExportConversation_ResumesFetchAtCursor(t *testing.T) {
+	drive, slack, conv := longConversation(25) // 13 pages
+	indexPath := t.TempDir() + "/export-index.json"
+	var cursors []string
+
+	exp := fakeExporter(t, drive, slack, indexPath)
+	exp.slackClient = interruptedSlack{FakeSlack: slack, failAfter: fetchCheckpointPages + 1, cursors: &cursors}
+	if _, err := exp.ExportConversation(context.Background(), conv); err == nil {
+		t.Fatal("interrupted export succeeded")
+	}
+	ce := exp.index.GetConversation("C001")
+	if ce.Fetch == nil || ce.Fetch.Pages != fetchCheckpointPages || ce.Fetch.Cursor != "20" || ce.Fetch.Done {
+		t.Fatalf("Fetch = %+v, want a checkpoint after %d pages", ce.Fetch, fetchCheckpointPages)
+	}
+
+	exp = fakeExporter(t, drive, slack, indexPath)
+	exp.slackClient = interruptedSlack{FakeSlack: slack, cursors: &cursors}
+	exp.resumeMode = true
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("resumed export: %v", err)
+	}
+	if len(cursors) != 2 || cursors[1] != "20" {
+		t.Errorf("fetches started at %q, want the resumed one at the checkpoint", cursors)
+	}
+	if result.MessageCount != 25 {
+		t.Errorf("MessageCount = %d, want 25", result.MessageCount)
+	}
+	ce = exp.index.GetConversation("C001")
+	if got := appendedTexts(drive, ce.DailyDocs["2024-02-01"].DocID); len(got) != 25 || got[0] != "message 0" || got[24] != "message 24" {
+		t.Errorf("doc content = %q, want all 25 messages oldest first", got)
+	}
+	if ce.Fetch != nil {
+		t.Errorf("Fetch = %+v, want it cleared once the export completes", ce.Fetch)
+	}
+	if _, err := os.Stat(exp.index.fetchSpoolPath("C001")); !os.IsNotExist(err) {
+		t.Errorf("spool file left after the export completed: %v", err)
+	}
+}
+
+func TestExportConversation_ResumeAfterFetchDoesNotFetchAgain(t *testing.T) {
+	drive, slack, conv := longConversation(5)
+	indexPath := t.TempDir() + "/export-index.json"
+	drive.Errors["BatchAppendMessages"] = errors.New("quota exceeded")
+
+	if _, err := fakeExporter(t, drive, slack, indexPath).ExportConversation(context.Background(), conv); err == nil {
+		t.Fatal("export with failing writes succeeded")
+	}
+	fetches := slack.Calls("GetAllMessagesFrom")
+
+	delete(drive.Errors, "BatchAppendMessages")
+	exp := fakeExporter(t, drive, slack, indexPath)
+	exp.resumeMode = true
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("resumed export: %v", err)
+	}
+	if got := slack.Calls("GetAllMessagesFrom"); got != fetches {
+		t.Errorf("resumed export fetched history %d times, want the spooled pages used", got-fetches)
+	}
+	if result.MessageCount != 5 {
+		t.Errorf("MessageCount = %d, want 5", result.MessageCount)
+	}
+}
+
+func TestExportConversation_FetchCheckpointIgnoredWithoutResume(t *testing.T) {
+	drive, slack, conv := longConversation(25)
+	indexPath := t.TempDir() + "/export-index.json"
+	var cursors []string
+
+	exp := fakeExporter(t, drive, slack, indexPath)
+	exp.slackClient = interruptedSlack{FakeSlack: slack, failAfter: fetchCheckpointPages + 1, cursors: &cursors}
+	if _, err := exp.ExportConversation(context.Background(), conv); err == nil {
+		t.Fatal("interrupted export succeeded")
+	}
+
+	exp = fakeExporter(t, drive, slack, indexPath)
+	exp.slackClient = interruptedSlack{FakeSlack: slack, cursors: &cursors}
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatalf("second export: %v", err)
+	}
+	if len(cursors) != 2 || cursors[1] != "" {
+		t.Errorf("fetches started at %q, want the second from the newest page", cursors)
+	}
+}
+
+func TestReadFetchSpool(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "_fetch", "C001.jsonl")
+	pages := [][]slackapi.Message{{{TS: "2.0"}, {TS: "1.0"}}, {{TS: "0.5"}}}
+	if err := appendFetchSpool(path, true, pages); err != nil {
+		t.Fatal(err)
+	}
+	// A page written by a run that crashed before recording it.
+	if err := appendFetchSpool(path, false, [][]slackapi.Message{{{TS: "0.1"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readFetchSpool(path, 2)
+	if err != nil {
+		t.Fatalf("readFetchSpool() error: %v", err)
+	}
+	if len(got) != 2 || len(got[0]) != 2 || got[1][0].TS != "0.5" {
+		t.Errorf("readFetchSpool() = %+v, want the two recorded pages", got)
+	}
+	if _, err := readFetchSpool(path, 4); err == nil {
+		t.Error("readFetchSpool() past the end: want an error")
+	}
+	if err := appendFetchSpool(path, true, pages[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := readFetchSpool(path, 1); err != nil || len(got) != 1 || len(got[0]) != 2 {
+		t.Errorf("readFetchSpool() after a fresh write = %+v, %v, want the one page", got, err)
+	}
+}
@@ -0,0 +1,123 @@
+package exporter
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// fetchPool bounds the Slack requests an export has in flight (--parallel):
+// history, thread replies and file downloads, across every conversation
+// being exported. Requests still pass through the Slack client's rate
+// limiter, which paces each endpoint however many are waiting on it. A nil
+// pool does not limit requests and fans work out sequentially.
+type fetchPool struct {
+	slots chan struct{}
+}
+
+// newFetchPool creates a fetchPool allowing size requests at once, clamped
+// like the conversation-level concurrency to [1, 5].
+func newFetchPool(size int) *fetchPool {
+	return &fetchPool{slots: make(chan struct{}, clampConcurrency(size))}
+}
+
+// size returns the number of requests p allows at once.
+func (p *fetchPool) size() int {
+	if p == nil {
+		return 1
+	}
+	return cap(p.slots)
+}
+
+// acquire waits for a free slot, or for ctx to be done.
+func (p *fetchPool) acquire(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees a slot taken by acquire.
+func (p *fetchPool) release() {
+	if p != nil {
+		<-p.slots
+	}
+}
+
+// forEach calls fn for each index in [0, n) on up to p.size() goroutines
+// and returns when all calls have. fn must be safe to call concurrently;
+// results are best kept in slices indexed by i so they stay in order. With
+// a size of 1, fn is called in order on the calling goroutine.
+func (p *fetchPool) forEach(n int, fn func(i int)) {
+	workers := min(p.size(), n)
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
+
+// limitedSlack passes calls through to a SlackSource, taking a slot of the
+// pool for each history, replies and download request. A history or
+// replies fetch holds its slot across its pages, which Slack only serves
+// one after another.
+type limitedSlack struct {
+	SlackSource
+	pool *fetchPool
+}
+
+func (s limitedSlack) GetAllMessages(ctx context.Context, channelID string, oldest, latest string, callback func([]slackapi.Message) error) error {
+	if err := s.pool.acquire(ctx); err != nil {
+		return err
+	}
+	defer s.pool.release()
+	return s.SlackSource.GetAllMessages(ctx, channelID, oldest, latest, callback)
+}
+
+func (s limitedSlack) GetAllMessagesFrom(ctx context.Context, channelID string, oldest, latest, cursor string, callback func([]slackapi.Message, string) error) error {
+	if err := s.pool.acquire(ctx); err != nil {
+		return err
+	}
+	defer s.pool.release()
+	return s.SlackSource.GetAllMessagesFrom(ctx, channelID, oldest, latest, cursor, callback)
+}
+
+func (s limitedSlack) GetAllReplies(ctx context.Context, channelID, threadTS string, callback func([]slackapi.Message) error) error {
+	if err := s.pool.acquire(ctx); err != nil {
+		return err
+	}
+	defer s.pool.release()
+	return s.SlackSource.GetAllReplies(ctx, channelID, threadTS, callback)
+}
+
+func (s limitedSlack) DownloadFile(ctx context.Context, url string) ([]byte, error) {
+	if err := s.pool.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer s.pool.release()
+	return s.SlackSource.DownloadFile(ctx, url)
+}
@@ -0,0 +1,165 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jflowers/get-out/internal/testutil"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// concurrencyMeter records the most calls in progress at once.
+type concurrencyMeter struct {
+	current, peak atomic.Int32
+}
+
+func (m *concurrencyMeter) enter() {
+	n := m.current.Add(1)
+	for {
+		peak := m.peak.Load()
+		if n <= peak || m.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+}
+
+func (m *concurrencyMeter) leave() { m.current.Add(-1) }
+
+// meteredSlack measures the downloads and replies fetches in progress.
+type meteredSlack struct {
+	*testutil.FakeSlack
+	meter *concurrencyMeter
+}
+
+func (s meteredSlack) DownloadFile(ctx context.Context, url string) ([]byte, error) {
+	s.meter.enter()
+	defer s.meter.leave()
+	return s.FakeSlack.DownloadFile(ctx, url)
+}
+
+func (s meteredSlack) GetAllReplies(ctx context.Context, channelID, threadTS string, callback func([]slackapi.Message) error) error {
+	s.meter.enter()
+	defer s.meter.leave()
+	return s.FakeSlack.GetAllReplies(ctx, channelID, threadTS, callback)
+}
+
+func TestFetchPool_ForEach(t *testing.T) {
+	pool := newFetchPool(3)
+	var meter concurrencyMeter
+	visited := make([]bool, 12)
+	pool.forEach(len(visited), func(i int) {
+		meter.enter()
+		defer meter.leave()
+		visited[i] = true
+	})
+	for i, ok := range visited {
+		if !ok {
+			t.Errorf("index %d not visited", i)
+		}
+	}
+	if peak := meter.peak.Load(); peak < 2 || peak > 3 {
+		t.Errorf("peak concurrency = %d, want 2 or 3", peak)
+	}
+
+	var order []int
+	(*fetchPool)(nil).forEach(4, func(i int) { order = append(order, i) })
+	if fmt.Sprint(order) != "[0 1 2 3]" {
+		t.Errorf("nil pool order = %v, want sequential", order)
+	}
+}
+
+func TestNewFetchPool_Clamps(t *testing.T) {
+	for _, tt := range []struct{ in, want int }{{0, 1}, {1, 1}, {4, 4}, {50, 5}} {
+		if got := newFetchPool(tt.in).size(); got != tt.want {
+			t.Errorf("newFetchPool(%d).size() = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLimitedSlack_BoundsRequests(t *testing.T) {
+	slack := testutil.NewFakeSlack()
+	slack.Files["https://files.slack.com/x"] = []byte("x")
+	meter := &concurrencyMeter{}
+	client := limitedSlack{SlackSource: meteredSlack{FakeSlack: slack, meter: meter}, pool: newFetchPool(2)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.DownloadFile(context.Background(), "https://files.slack.com/x"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if peak := meter.peak.Load(); peak > 2 {
+		t.Errorf("peak downloads in flight = %d, want at most 2", peak)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	full := limitedSlack{SlackSource: slack, pool: newFetchPool(1)}
+	full.pool.slots <- struct{}{}
+	if _, err := full.DownloadFile(ctx, "https://files.slack.com/x"); err != context.Canceled {
+		t.Errorf("DownloadFile() with no free slot and a cancelled context = %v, want context.Canceled", err)
+	}
+}
+
+func TestExportThreads_Concurrent(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	for i := 1; i <= 5; i++ {
+		ts := fmt.Sprintf("17067960%02d.000000", i)
+		parent := slackapi.Message{User: "U001", Text: fmt.Sprintf("Topic %d", i), TS: ts, ThreadTS: ts, ReplyCount: 1}
+		slack.Messages["C001"] = append(slack.Messages["C001"], parent)
+		slack.Replies[testutil.ThreadKey("C001", ts)] = []slackapi.Message{
+			parent,
+			{User: "U002", Text: fmt.Sprintf("Reply %d", i), TS: fmt.Sprintf("17067961%02d.000000", i), ThreadTS: ts},
+		}
+	}
+	meter := &concurrencyMeter{}
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.fetches = newFetchPool(3)
+	exp.slackClient = limitedSlack{SlackSource: meteredSlack{FakeSlack: slack, meter: meter}, pool: exp.fetches}
+
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	if result.ThreadsExported != 6 {
+		t.Errorf("ThreadsExported = %d, want 6", result.ThreadsExported)
+	}
+	if peak := meter.peak.Load(); peak < 2 || peak > 3 {
+		t.Errorf("peak replies fetches in flight = %d, want 2 or 3", peak)
+	}
+
+	// Threads are written in the order a sequential export writes them,
+	// however their replies arrived.
+	sequential := testutil.NewFakeDrive()
+	if _, err := fakeExporter(t, sequential, slack, t.TempDir()+"/export-index.json").ExportConversation(context.Background(), conv); err != nil {
+		t.Fatalf("sequential ExportConversation() error: %v", err)
+	}
+	if got, want := threadReplies(drive), threadReplies(sequential); got != want || !strings.Contains(got, "Reply 5") {
+		t.Errorf("thread replies written as %q, want %q", got, want)
+	}
+}
+
+// threadReplies returns the "Reply N" texts appended to drive's documents
+// in the order the documents were created: the fake numbers them.
+func threadReplies(drive *testutil.FakeDrive) string {
+	var replies []string
+	for n := 1; n < 100; n++ {
+		for _, text := range appendedTexts(drive, fmt.Sprintf("doc%03d", n)) {
+			if strings.HasPrefix(text, "Reply ") {
+				replies = append(replies, text)
+			}
+		}
+	}
+	return strings.Join(replies, "|")
+}
@@ -0,0 +1,294 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/gdrive"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// FilesDir is the directory, inside a conversation's local directory,
+// holding the attachments saved with --download-files.
+const FilesDir = "files"
+
+// DriveFilesFolder is the folder, inside a conversation's Drive folder,
+// holding the attachments uploaded with --download-files.
+const DriveFilesFolder = "Files"
+
+// unsafeFileNameRe matches characters not kept in saved attachment names.
+var unsafeFileNameRe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// LocalFileName returns the name a downloaded attachment is saved under:
+// its Slack file ID and its name reduced to characters safe in paths and
+// links, so attachments sharing a name do not collide.
+func LocalFileName(f slackapi.File) string {
+	name := strings.Trim(unsafeFileNameRe.ReplaceAllString(f.Name, "-"), ".-")
+	if name == "" {
+		return f.ID
+	}
+	return f.ID + "-" + name
+}
+
+// fileDownloadURL returns the URL to download f from, or "" when it cannot
+// be downloaded: files hosted elsewhere (Google Drive, Dropbox, ...) and
+// files whose content Slack no longer serves.
+func fileDownloadURL(f slackapi.File) string {
+	if f.ID == "" || f.IsExternal || f.Mode == "tombstone" || f.Mode == "hidden_by_limit" {
+		return ""
+	}
+	return orDefault(f.URLPrivateDownload, f.URLPrivate)
+}
+
+// fetchFile downloads f from Slack. Files whose reported size is over
+// slackapi.MaxDownloadSize are refused without fetching them.
+func (e *Exporter) fetchFile(ctx context.Context, f slackapi.File) ([]byte, error) {
+	if f.Size > slackapi.MaxDownloadSize {
+		return nil, fmt.Errorf("%w (%d bytes)", slackapi.ErrFileTooLarge, f.Size)
+	}
+	return prefetchedSlack{e.slackClient, &e.prefetched}.DownloadFile(ctx, fileDownloadURL(f))
+}
+
+// downloadCache holds Slack downloads fetched ahead of the write that
+// needs them (see prefetchDownloads), by URL.
+type downloadCache struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func (c *downloadCache) put(url string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.data == nil {
+		c.data = make(map[string][]byte)
+	}
+	c.data[url] = data
+}
+
+func (c *downloadCache) get(url string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.data[url]
+	return data, ok
+}
+
+// drop releases the downloads of urls once their write is done.
+func (c *downloadCache) drop(urls []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, url := range urls {
+		delete(c.data, url)
+	}
+}
+
+// prefetchedSlack serves downloads found in cache and passes everything
+// else through to the SlackSource.
+type prefetchedSlack struct {
+	SlackSource
+	cache *downloadCache
+}
+
+func (s prefetchedSlack) DownloadFile(ctx context.Context, url string) ([]byte, error) {
+	if data, ok := s.cache.get(url); ok {
+		return data, nil
+	}
+	return s.SlackSource.DownloadFile(ctx, url)
+}
+
+// prefetchDownloads downloads what writing msgs to conv's daily doc will
+// fetch from Slack (images to embed and, with --download-files,
+// attachments not archived yet) into the prefetch cache, and returns their
+// URLs, to be dropped once the day is written. A failed download is left
+// for the write, which retries and reports it. Local formats fetch
+// nothing while they write.
+func (e *Exporter) prefetchDownloads(ctx context.Context, conv config.ConversationConfig, msgs []slackapi.Message) []string {
+	convExport := e.index.GetConversation(conv.ID)
+	if !conv.WritesDocs() || convExport == nil {
+		return nil
+	}
+	var urls []string
+	seen := make(map[string]bool)
+	add := func(url string) {
+		if url != "" && !seen[url] {
+			seen[url] = true
+			urls = append(urls, url)
+		}
+	}
+	for _, msg := range msgs {
+		for _, f := range msg.Files {
+			if isCanvasFile(f) || f.Size > slackapi.MaxDownloadSize {
+				continue
+			}
+			if strings.HasPrefix(f.Mimetype, "image/") {
+				add(f.URLPrivateDownload)
+			}
+			if e.downloadFiles {
+				convExport.mu.Lock()
+				archived := convExport.Files[f.ID] != ""
+				convExport.mu.Unlock()
+				if !archived {
+					add(fileDownloadURL(f))
+				}
+			}
+		}
+	}
+
+	var fetched []string
+	for _, url := range urls {
+		if ctx.Err() != nil {
+			break
+		}
+		if data, err := e.slackClient.DownloadFile(ctx, url); err == nil {
+			e.prefetched.put(url, data)
+			fetched = append(fetched, url)
+		}
+	}
+	return fetched
+}
+
+// saveLocalFiles saves the attachments of msgs into
+// {localExportDir}/{convDir}/files when --download-files is set.
+// Attachments already saved are skipped, so --sync does not fetch them
+// again; the rest are downloaded concurrently through the fetch pool. A
+// failed download is reported and counted but does not fail the export;
+// the message keeps its link to Slack.
+func (e *Exporter) saveLocalFiles(ctx context.Context, convDir string, msgs []slackapi.Message, result *ExportResult) {
+	if !e.downloadFiles {
+		return
+	}
+	dir := filepath.Join(e.localExportDir, convDir, FilesDir)
+	var pending []slackapi.File
+	seen := make(map[string]bool)
+	for _, msg := range msgs {
+		for _, f := range msg.Files {
+			if fileDownloadURL(f) == "" || isCanvasFile(f) {
+				continue
+			}
+			name := LocalFileName(f)
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				continue
+			}
+			pending = append(pending, f)
+		}
+	}
+
+	var mu sync.Mutex
+	e.fetches.forEach(len(pending), func(i int) {
+		f := pending[i]
+		data, err := e.fetchFile(ctx, f)
+		if err == nil {
+			err = os.MkdirAll(dir, 0755)
+		}
+		if err == nil {
+			err = atomicWriteFile(dir, filepath.Join(dir, LocalFileName(f)), data)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			e.Progress("Warning: failed to download file %s: %v", f.Name, err)
+			result.FileErrors++
+			return
+		}
+		result.FilesDownloaded++
+	})
+}
+
+// linkLocalFiles returns msgs with the permalink of each attachment saved
+// in {convDir}/files, and of each canvas or post saved in
+// {convDir}/canvases, replaced by its path relative to dir, the directory
+// of the file being written, so the output links to the local copy. msgs
+// is not modified.
+func (e *Exporter) linkLocalFiles(convDir, dir string, msgs []slackapi.Message) []slackapi.Message {
+	linked := make([]slackapi.Message, len(msgs))
+	for i, msg := range msgs {
+		linked[i] = msg
+		if len(msg.Files) == 0 {
+			continue
+		}
+		files := make([]slackapi.File, len(msg.Files))
+		for j, f := range msg.Files {
+			files[j] = f
+			var saved string
+			switch {
+			case isCanvasFile(f):
+				saved = filepath.Join(convDir, CanvasesDir, canvasFileName(f))
+			case e.downloadFiles:
+				saved = filepath.Join(convDir, FilesDir, LocalFileName(f))
+			default:
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(e.localExportDir, saved)); err != nil {
+				continue
+			}
+			if rel, err := filepath.Rel(dir, saved); err == nil {
+				files[j].Permalink = filepath.ToSlash(rel)
+			}
+		}
+		linked[i].Files = files
+	}
+	return linked
+}
+
+// linkFile is the DocWriter's FileLinker: a canvas or post links to the
+// doc it was exported to, and with --download-files any other attachment
+// links to its archived copy.
+func (e *Exporter) linkFile(ctx context.Context, convID string, f slackapi.File) string {
+	if isCanvasFile(f) {
+		return e.index.LookupCanvasURL(convID, f.ID)
+	}
+	if e.downloadFiles {
+		return e.archiveDriveFile(ctx, convID, f)
+	}
+	return ""
+}
+
+// archiveDriveFile uploads the attachment to the conversation's Files
+// folder, once, and returns the uploaded copy's Drive URL. Failures are
+// reported and leave the reference unlinked.
+func (e *Exporter) archiveDriveFile(ctx context.Context, convID string, f slackapi.File) string {
+	if fileDownloadURL(f) == "" {
+		return ""
+	}
+	conv := e.index.GetConversation(convID)
+	if conv == nil {
+		return ""
+	}
+	conv.mu.Lock()
+	driveID := conv.Files[f.ID]
+	conv.mu.Unlock()
+	if driveID != "" {
+		return gdrive.FileURL(driveID)
+	}
+
+	folderID, err := e.folderStructure.EnsureFilesFolder(ctx, convID)
+	if err == nil {
+		var data []byte
+		if data, err = e.fetchFile(ctx, f); err == nil {
+			driveID, err = e.gdriveClient.UploadFile(ctx, f.Name, f.Mimetype, data, folderID)
+		}
+	}
+	if err != nil {
+		e.Progress("Warning: failed to archive file %s: %v", f.Name, err)
+		return ""
+	}
+
+	e.folderStructure.addItem(conv, folderID)
+	conv.mu.Lock()
+	if conv.Files == nil {
+		conv.Files = make(map[string]string)
+	}
+	conv.Files[f.ID] = driveID
+	conv.mu.Unlock()
+	return gdrive.FileURL(driveID)
+}
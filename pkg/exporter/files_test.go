@@ -0,0 +1,162 @@
+package exporter
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/internal/testutil"
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/gdrive"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// withAttachment gives the first message of fakeConversation a
+// downloadable attachment.
+func withAttachment(slack *testutil.FakeSlack) slackapi.File {
+	f := slackapi.File{
+		ID:                 "F001",
+		Name:               "Q1 notes.txt",
+		Mimetype:           "text/plain",
+		URLPrivateDownload: "https://files.slack.com/F001/download",
+		Permalink:          "https://acme.slack.com/files/F001",
+	}
+	slack.Files[f.URLPrivateDownload] = []byte("the notes")
+	slack.Messages["C001"][0].Files = []slackapi.File{f}
+	return f
+}
+
+func TestExportConversation_DownloadFilesMarkdown(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	conv.Format = config.OutputFormatMarkdown
+	withAttachment(slack)
+	exp, localDir := localFormatExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.downloadFiles = true
+
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	if result.FilesDownloaded != 1 || result.FileErrors != 0 {
+		t.Errorf("FilesDownloaded = %d, FileErrors = %d, want 1 and 0", result.FilesDownloaded, result.FileErrors)
+	}
+
+	dir := filepath.Join(localDir, SanitizeDirectoryName(string(conv.Type), conv.Name))
+	if got := readFile(t, filepath.Join(dir, FilesDir, "F001-Q1-notes.txt")); got != "the notes" {
+		t.Errorf("saved file = %q", got)
+	}
+	if day := readFile(t, filepath.Join(dir, "2024-02-01.md")); !strings.Contains(day, "[File: Q1 notes.txt](files/F001-Q1-notes.txt)") {
+		t.Errorf("day file does not link the saved copy:\n%s", day)
+	}
+
+	// A later run finds the file saved and does not fetch it again.
+	exp.saveLocalFiles(context.Background(), SanitizeDirectoryName(string(conv.Type), conv.Name), slack.Messages["C001"], result)
+	if n := slack.Calls("DownloadFile"); n != 1 {
+		t.Errorf("DownloadFile calls = %d, want 1", n)
+	}
+}
+
+func TestExportConversation_DownloadFilesHTML(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	conv.Format = config.OutputFormatHTML
+	withAttachment(slack)
+	exp, localDir := localFormatExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.downloadFiles = true
+
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	dir := filepath.Join(localDir, SanitizeDirectoryName(string(conv.Type), conv.Name))
+	if day := readFile(t, filepath.Join(dir, "2024-02-01.html")); !strings.Contains(day, `<a href="files/F001-Q1-notes.txt">Q1 notes.txt</a>`) {
+		t.Errorf("day page does not link the saved copy:\n%s", day)
+	}
+}
+
+func TestExportConversation_DownloadFilesFailure(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	conv.Format = config.OutputFormatMarkdown
+	f := withAttachment(slack)
+	delete(slack.Files, f.URLPrivateDownload)
+	exp, localDir := localFormatExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.downloadFiles = true
+
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("ExportConversation() error = %v, want a failed download not to fail the export", err)
+	}
+	if result.FileErrors != 1 {
+		t.Errorf("FileErrors = %d, want 1", result.FileErrors)
+	}
+	day := readFile(t, filepath.Join(localDir, SanitizeDirectoryName(string(conv.Type), conv.Name), "2024-02-01.md"))
+	if !strings.Contains(day, "[File: Q1 notes.txt](https://acme.slack.com/files/F001)") {
+		t.Errorf("day file does not keep the Slack link:\n%s", day)
+	}
+}
+
+func TestExportConversation_DownloadFilesDrive(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	f := withAttachment(slack)
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.downloadFiles = true
+	exp.docWriter.SetFileLinker(exp.archiveDriveFile)
+
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	convExport := exp.index.GetConversation("C001")
+	driveID := convExport.Files["F001"]
+	if driveID == "" || drive.FolderName(convExport.FilesFolderID) != DriveFilesFolder {
+		t.Fatalf("index files = %v in folder %q, want F001 uploaded to %q", convExport.Files, drive.FolderName(convExport.FilesFolderID), DriveFilesFolder)
+	}
+
+	var linked bool
+	for _, batch := range drive.Appended(convExport.DailyDocs["2024-02-01"].DocID) {
+		for _, b := range batch {
+			for _, l := range b.Links {
+				linked = linked || (l.Text == "[File: Q1 notes.txt]" && l.URL == gdrive.FileURL(driveID))
+			}
+		}
+	}
+	if !linked {
+		t.Error("no doc links the reference to the uploaded copy")
+	}
+
+	// An attachment already uploaded is linked without uploading it again.
+	if url := exp.archiveDriveFile(context.Background(), "C001", f); url != gdrive.FileURL(driveID) {
+		t.Errorf("archiveDriveFile() = %q, want the earlier upload", url)
+	}
+	if n := drive.Calls("UploadFile"); n != 1 {
+		t.Errorf("UploadFile calls = %d, want 1", n)
+	}
+}
+
+func TestLocalFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"report.pdf", "F001-report.pdf"},
+		{"Q1 notes (final).txt", "F001-Q1-notes-final-.txt"},
+		{"../../etc/passwd", "F001-etc-passwd"},
+		{"", "F001"},
+	}
+	for _, tt := range tests {
+		if got := LocalFileName(slackapi.File{ID: "F001", Name: tt.name}); got != tt.want {
+			t.Errorf("LocalFileName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFetchFile_TooLarge(t *testing.T) {
+	_, slack, _ := fakeConversation()
+	exp := fakeExporter(t, testutil.NewFakeDrive(), slack, t.TempDir()+"/export-index.json")
+
+	f := slackapi.File{ID: "F001", Size: slackapi.MaxDownloadSize + 1, URLPrivateDownload: "https://files.slack.com/big"}
+	if _, err := exp.fetchFile(context.Background(), f); err == nil {
+		t.Error("fetchFile() error = nil, want oversize files refused")
+	}
+	if n := slack.Calls("DownloadFile"); n != 0 {
+		t.Errorf("DownloadFile calls = %d, want 0", n)
+	}
+}
@@ -0,0 +1,107 @@
+package exporter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/config"
+)
+
+// singleDocKey indexes the one doc of a conversation exported with
+// config.DocGranularitySingle in ConversationExport.DailyDocs.
+const singleDocKey = "all"
+
+// singleDocTitle is the title of that doc.
+const singleDocTitle = "Messages"
+
+// docGranularities lists every granularity, finest first, so a day can be
+// found in the doc it was written to before a conversation's granularity
+// changed.
+var docGranularities = []config.DocGranularity{
+	config.DocGranularityDaily,
+	config.DocGranularityWeekly,
+	config.DocGranularityMonthly,
+	config.DocGranularitySingle,
+}
+
+// docPeriod is the span of days one of a conversation's docs holds.
+type docPeriod struct {
+	// key indexes the doc in ConversationExport.DailyDocs: the date
+	// ("2024-02-01"), ISO week ("2024-W05"), month ("2024-02"), or
+	// singleDocKey. The formats never collide.
+	key string
+
+	// title is the doc's title.
+	title string
+
+	// start is the period's first day, which picks the year and month
+	// folders holding the doc under a nested layout. It is empty for the
+	// single doc, which stays in the conversation folder.
+	start string
+}
+
+// docPeriodOf returns the period holding date (YYYY-MM-DD) under
+// granularity g. Empty g, and dates that do not parse, are daily.
+func docPeriodOf(g config.DocGranularity, date string) docPeriod {
+	if g == config.DocGranularitySingle {
+		return docPeriod{key: singleDocKey, title: singleDocTitle}
+	}
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return docPeriod{key: date, title: date, start: date}
+	}
+	switch g {
+	case config.DocGranularityWeekly:
+		year, week := day.ISOWeek()
+		monday, _ := weekOf(date)
+		key := fmt.Sprintf("%04d-W%02d", year, week)
+		return docPeriod{key: key, title: key, start: monday}
+	case config.DocGranularityMonthly:
+		key := day.Format("2006-01")
+		return docPeriod{key: key, title: key, start: key + "-01"}
+	}
+	return docPeriod{key: date, title: date, start: date}
+}
+
+// granularity returns the conversation's doc granularity.
+func (c *ConversationExport) granularity() config.DocGranularity {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return config.DocGranularity(c.Granularity)
+}
+
+// multiDay reports whether the doc holding date holds more than one day.
+// It is decided by the period of the doc itself, as dailyDoc finds it, so
+// a daily doc written before the granularity changed stays a single day
+// and a week's or month's doc stays dated after a change back to daily.
+// Before the doc exists, the period it will be created for decides. The
+// caller holds the index lock.
+func (c *ConversationExport) multiDay(date string) bool {
+	key, doc := c.dailyDocEntry(date)
+	if doc == nil {
+		key = docPeriodOf(c.granularity(), date).key
+	}
+	return key != date
+}
+
+// dailyDoc returns the doc holding date: the finest-grained doc whose
+// period holds it, whichever granularity the conversation had when the
+// doc was created. So after the granularity changes, days already
+// exported keep their docs, and the rest of a period that already has a
+// doc goes on into it. The caller holds the index lock.
+func (c *ConversationExport) dailyDoc(date string) *DocExport {
+	_, doc := c.dailyDocEntry(date)
+	return doc
+}
+
+// dailyDocEntry returns the doc dailyDoc returns with its key in
+// DailyDocs, or a nil doc.
+func (c *ConversationExport) dailyDocEntry(date string) (string, *DocExport) {
+	for _, g := range docGranularities {
+		key := docPeriodOf(g, date).key
+		if doc := c.DailyDocs[key]; doc != nil {
+			return key, doc
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,170 @@
+package exporter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jflowers/get-out/internal/testutil"
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/parser"
+)
+
+func TestDocPeriodOf(t *testing.T) {
+	tests := []struct {
+		name        string
+		granularity config.DocGranularity
+		date        string
+		want        docPeriod
+	}{
+		{name: "default", date: "2024-02-07", want: docPeriod{key: "2024-02-07", title: "2024-02-07", start: "2024-02-07"}},
+		{name: "daily", granularity: config.DocGranularityDaily, date: "2024-02-07", want: docPeriod{key: "2024-02-07", title: "2024-02-07", start: "2024-02-07"}},
+		{name: "weekly", granularity: config.DocGranularityWeekly, date: "2024-02-07", want: docPeriod{key: "2024-W06", title: "2024-W06", start: "2024-02-05"}},
+		{name: "weekly on sunday", granularity: config.DocGranularityWeekly, date: "2024-02-11", want: docPeriod{key: "2024-W06", title: "2024-W06", start: "2024-02-05"}},
+		{name: "weekly across years", granularity: config.DocGranularityWeekly, date: "2025-01-01", want: docPeriod{key: "2025-W01", title: "2025-W01", start: "2024-12-30"}},
+		{name: "monthly", granularity: config.DocGranularityMonthly, date: "2024-02-29", want: docPeriod{key: "2024-02", title: "2024-02", start: "2024-02-01"}},
+		{name: "single", granularity: config.DocGranularitySingle, date: "2024-02-07", want: docPeriod{key: singleDocKey, title: singleDocTitle}},
+		{name: "unparsable date", granularity: config.DocGranularityMonthly, date: "unknown", want: docPeriod{key: "unknown", title: "unknown", start: "unknown"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := docPeriodOf(tt.granularity, tt.date); got != tt.want {
+				t.Errorf("docPeriodOf(%q, %q) = %+v, want %+v", tt.granularity, tt.date, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnsureDailyDoc_Granularity(t *testing.T) {
+	tests := []struct {
+		name        string
+		granularity config.DocGranularity
+		layout      config.FolderLayout
+		wantDocs    int
+		wantTitle   string
+		wantFolder  string
+	}{
+		{name: "daily", granularity: config.DocGranularityDaily, wantDocs: 3, wantTitle: "2024-01-31", wantFolder: "general"},
+		{name: "weekly", granularity: config.DocGranularityWeekly, wantDocs: 2, wantTitle: "2024-W05", wantFolder: "general"},
+		{name: "monthly by month", granularity: config.DocGranularityMonthly, layout: config.FolderLayoutMonth, wantDocs: 2, wantTitle: "2024-01", wantFolder: "2024-01"},
+		{name: "single by year", granularity: config.DocGranularitySingle, layout: config.FolderLayoutYear, wantDocs: 1, wantTitle: singleDocTitle, wantFolder: "general"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drive := testutil.NewFakeDrive()
+			drive.AddFolder("conv-folder", "general")
+			idx := NewExportIndex("")
+			conv := idx.GetOrCreateConversation("C001", "general", "channel")
+			conv.FolderID = "conv-folder"
+			conv.Granularity = string(tt.granularity)
+			conv.Layout = string(tt.layout)
+			fs := NewFolderStructure(drive, idx, nil)
+			ctx := context.Background()
+
+			var first *DocExport
+			for _, date := range []string{"2024-01-31", "2024-02-02", "2024-02-05"} {
+				doc, err := fs.EnsureDailyDoc(ctx, "C001", date)
+				if err != nil {
+					t.Fatalf("EnsureDailyDoc(%s) error: %v", date, err)
+				}
+				if first == nil {
+					first = doc
+				}
+				if got := idx.GetDailyDoc("C001", date); got != doc {
+					t.Errorf("GetDailyDoc(%s) = %+v, want the doc EnsureDailyDoc returned", date, got)
+				}
+			}
+			if n := len(drive.Documents()); n != tt.wantDocs {
+				t.Errorf("documents = %d, want %d", n, tt.wantDocs)
+			}
+			if first.Title != tt.wantTitle {
+				t.Errorf("Title = %q, want %q", first.Title, tt.wantTitle)
+			}
+			if got := drive.FolderName(drive.DocumentFolder(first.DocID)); got != tt.wantFolder {
+				t.Errorf("doc folder = %q, want %q", got, tt.wantFolder)
+			}
+		})
+	}
+}
+
+func TestExportIndex_DailyDocAfterGranularityChange(t *testing.T) {
+	idx := NewExportIndex("")
+	conv := idx.GetOrCreateConversation("C001", "general", "channel")
+	idx.SetDailyDoc("C001", "2024-02-01", &DocExport{DocID: "day", DocURL: "https://docs/day"})
+
+	conv.Granularity = string(config.DocGranularityMonthly)
+	idx.SetDailyDoc("C001", "2024-02-02", &DocExport{DocID: "month", DocURL: "https://docs/month"})
+
+	if doc := idx.GetDailyDoc("C001", "2024-02-01"); doc == nil || doc.DocID != "day" {
+		t.Errorf("GetDailyDoc(2024-02-01) = %+v, want the daily doc written before the change", doc)
+	}
+	if doc := idx.GetDailyDoc("C001", "2024-02-02"); doc == nil || doc.DocID != "month" {
+		t.Errorf("GetDailyDoc(2024-02-02) = %+v, want the month's doc", doc)
+	}
+	if doc := idx.GetDailyDoc("C001", "2024-02-20"); doc == nil || doc.DocID != "month" {
+		t.Errorf("GetDailyDoc(2024-02-20) = %+v, want the month's doc", doc)
+	}
+	if url := idx.LookupDocURL("C001", "1708387200.000100"); url != "https://docs/month" { // 2024-02-20
+		t.Errorf("LookupDocURL() = %q, want the month's doc", url)
+	}
+	if doc := idx.GetDailyDoc("C001", "2024-03-01"); doc != nil {
+		t.Errorf("GetDailyDoc(2024-03-01) = %+v, want none", doc)
+	}
+
+	// Timestamps are dated by the doc's own period.
+	if idx.MultiDayDocs("C001", "2024-02-01") {
+		t.Error("MultiDayDocs(2024-02-01) = true, want false for the daily doc")
+	}
+	if !idx.MultiDayDocs("C001", "2024-02-20") || !idx.MultiDayDocs("C001", "2024-03-01") {
+		t.Error("MultiDayDocs() = false, want true for the month's doc and a new monthly one")
+	}
+	conv.Granularity = string(config.DocGranularityDaily)
+	if !idx.MultiDayDocs("C001", "2024-02-20") {
+		t.Error("MultiDayDocs(2024-02-20) = false after the change back to daily, want true for the month's doc")
+	}
+	if idx.MultiDayDocs("C001", "2024-03-01") {
+		t.Error("MultiDayDocs(2024-03-01) = true after the change back to daily, want false for a new daily doc")
+	}
+}
+
+func TestExportConversation_MonthlyDocs(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	conv.DocGranularity = config.DocGranularityMonthly
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.docWriter.SetMultiDayDocs(exp.index.MultiDayDocs)
+
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	if result.MessageCount != 3 {
+		t.Errorf("MessageCount = %d, want 3", result.MessageCount)
+	}
+	convExport := exp.index.GetConversation("C001")
+	month := convExport.DailyDocs["2024-02"]
+	if len(convExport.DailyDocs) != 1 || month == nil {
+		t.Fatalf("DailyDocs = %v, want one doc for 2024-02", convExport.DailyDocs)
+	}
+	if month.MessageCount != 3 || month.LastMessageTS != "1706875200.000300" {
+		t.Errorf("month doc = %+v, want both days' messages", month)
+	}
+
+	var timestamps []string
+	for _, batch := range drive.Appended(month.DocID) {
+		for _, b := range batch {
+			timestamps = append(timestamps, b.Timestamp)
+		}
+	}
+	want := []string{
+		parser.FormatTimestampFull("1706788800.000100"),
+		parser.FormatTimestampFull("1706792400.000200"),
+		parser.FormatTimestampFull("1706875200.000300"),
+	}
+	if len(timestamps) != len(want) {
+		t.Fatalf("appended %d messages, want %d", len(timestamps), len(want))
+	}
+	for i := range want {
+		if timestamps[i] != want[i] {
+			t.Errorf("message %d timestamp = %q, want the dated %q", i, timestamps[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,568 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+	"github.com/jflowers/get-out/pkg/slackjson"
+)
+
+// htmlDataDir is the directory, inside each html conversation directory,
+// holding the messages behind its pages so later runs can merge new
+// messages in and render the pages again.
+const htmlDataDir = "_data"
+
+// htmlBackend writes a conversation whose format is html as a static site
+// in the local export directory: {dir}/{date}.html for each day, with
+// reactions, attachments, and threads inline, and {dir}/index.html listing
+// the days. {localExportDir}/index.html lists every html conversation.
+// Pages carry their own CSS and need nothing else to be browsed offline.
+type htmlBackend struct {
+	e *Exporter
+}
+
+// htmlManifest is {dir}/_data/conversation.json, from which the index
+// pages are rendered.
+type htmlManifest struct {
+	ID   string         `json:"id"`
+	Name string         `json:"name"`
+	Type string         `json:"type"`
+	Days map[string]int `json:"days"` // date -> messages on its page
+}
+
+func (b htmlBackend) EnsureConversationContainer(ctx context.Context, conv config.ConversationConfig) (*ConversationExport, error) {
+	return localBackend(b).EnsureConversationContainer(ctx, conv)
+}
+
+// WriteMessages merges msgs into the day's stored messages and renders the
+// day page again.
+func (b htmlBackend) WriteMessages(ctx context.Context, conv config.ConversationConfig, date string, msgs []slackapi.Message, result *ExportResult) (int, error) {
+	e := b.e
+	passed, err := e.filterLocalMessages(ctx, conv, date, msgs)
+	if err != nil {
+		return 0, err
+	}
+	if len(passed) > 0 {
+		passed = e.translateMessages(ctx, conv, passed, result)
+		dataDir := filepath.Join(b.dir(conv), htmlDataDir)
+		merged, err := slackjson.WriteDay(dataDir, date, passed)
+		if err != nil {
+			return 0, err
+		}
+		e.saveLocalFiles(ctx, b.relDir(conv), passed, result)
+		e.saveCustomEmoji(ctx, passed)
+		if err := b.renderDay(conv, date, merged); err != nil {
+			return 0, err
+		}
+		result.HTMLPagesWritten++
+
+		manifest, err := loadHTMLManifest(dataDir)
+		if err != nil {
+			return 0, err
+		}
+		manifest.Days[date] = len(merged)
+		if err := writeJSONFile(dataDir, "conversation.json", manifest); err != nil {
+			return 0, err
+		}
+	}
+	e.addMessages(len(msgs))
+	return len(msgs), nil
+}
+
+// WriteThread stores the thread's replies, which render inline under their
+// parent, and renders the parent's day page again when it already exists
+// so replies added by --sync show up.
+func (b htmlBackend) WriteThread(ctx context.Context, conv config.ConversationConfig, parent slackapi.Message, replies []slackapi.Message, result *ExportResult) error {
+	if len(replies) == 0 {
+		return nil
+	}
+	date := DateFromTS(parent.TS)
+	replies, err := b.e.filterLocalMessages(ctx, conv, date, replies)
+	if err != nil || len(replies) == 0 {
+		return err
+	}
+	replies = b.e.translateMessages(ctx, conv, replies, result)
+
+	dataDir := filepath.Join(b.dir(conv), htmlDataDir)
+	if _, err := slackjson.WriteDay(filepath.Join(dataDir, "threads"), parent.TS, replies); err != nil {
+		return err
+	}
+	b.e.saveLocalFiles(ctx, b.relDir(conv), replies, result)
+	b.e.saveCustomEmoji(ctx, replies)
+
+	day, err := slackjson.ReadDay(dataDir, date)
+	if err != nil || len(day) == 0 {
+		return err
+	}
+	return b.renderDay(conv, date, day)
+}
+
+// Finalize renders the conversation's index page and the top-level index
+// of conversations.
+func (b htmlBackend) Finalize(_ context.Context, conv config.ConversationConfig, _ *ExportResult) error {
+	e := b.e
+	dir := b.dir(conv)
+	dataDir := filepath.Join(dir, htmlDataDir)
+	manifest, err := loadHTMLManifest(dataDir)
+	if err != nil {
+		return err
+	}
+	manifest.ID, manifest.Name, manifest.Type = conv.ID, conv.Name, string(conv.Type)
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dataDir, err)
+	}
+	if err := writeJSONFile(dataDir, "conversation.json", manifest); err != nil {
+		return err
+	}
+
+	page := htmlConversationPage{Title: conv.Name}
+	for date, count := range manifest.Days {
+		page.Days = append(page.Days, htmlDay{Date: date, Count: count})
+	}
+	sort.Slice(page.Days, func(i, j int) bool {
+		return page.Days[i].Date < page.Days[j].Date
+	})
+	if err := writeHTMLPage(dir, "index.html", htmlPages, "conversation", page); err != nil {
+		return err
+	}
+
+	// Conversations exported in parallel each rewrite the top-level index;
+	// serialize so the last write sees every conversation.
+	e.htmlIndexMu.Lock()
+	defer e.htmlIndexMu.Unlock()
+	return writeHTMLSiteIndex(e.localExportDir)
+}
+
+// dir returns the conversation's directory in the local export directory.
+func (b htmlBackend) dir(conv config.ConversationConfig) string {
+	return filepath.Join(b.e.localExportDir, b.relDir(conv))
+}
+
+// relDir returns the conversation's directory relative to the local
+// export directory.
+func (b htmlBackend) relDir(conv config.ConversationConfig) string {
+	return SanitizeDirectoryName(string(conv.Type), conv.Name)
+}
+
+// renderDay writes {dir}/{date}.html from the day's stored messages and
+// the stored replies of its threads. Attachments saved with
+// --download-files link to the saved copy.
+func (b htmlBackend) renderDay(conv config.ConversationConfig, date string, msgs []slackapi.Message) error {
+	dir, relDir := b.dir(conv), b.relDir(conv)
+	msgs = b.e.linkLocalFiles(relDir, relDir, msgs)
+	threadDir := filepath.Join(dir, htmlDataDir, "threads")
+	page := htmlDayPage{Title: conv.Name + " - " + date, Conversation: conv.Name, Date: date}
+	for _, msg := range msgs {
+		m := b.message(msg)
+		if msg.ReplyCount > 0 && (msg.ThreadTS == "" || msg.ThreadTS == msg.TS) {
+			replies, err := slackjson.ReadDay(threadDir, msg.TS)
+			if err != nil {
+				return err
+			}
+			for _, reply := range b.e.linkLocalFiles(relDir, relDir, replies) {
+				if reply.TS != msg.TS {
+					m.Replies = append(m.Replies, b.message(reply))
+				}
+			}
+		}
+		page.Messages = append(page.Messages, m)
+	}
+	return writeHTMLPage(dir, date+".html", b.e.htmlDayPages(conv), "day", page)
+}
+
+// message converts msg for the day page template.
+func (b htmlBackend) message(msg slackapi.Message) htmlMessage {
+	e := b.e
+	text, links := parser.ConvertMrkdwnWithLinks(parser.MessageText(msg), e.userResolver, e.channelResolver, e.personResolver, nil)
+	m := htmlMessage{
+		ID:     msg.TS,
+		Sender: e.mdWriter.getSenderName(msg),
+		Time:   parser.FormatTimestamp(msg.TS),
+		Text:   linkifyHTML(text, links),
+		Edited: msg.Edited != nil,
+	}
+	c, compact := compactMessageOf(msg)
+	if compact {
+		m.Compact = true
+		m.Text = b.compactHTML(c)
+	}
+	for _, r := range msg.Reactions {
+		users := make([]string, 0, len(r.Users))
+		for _, u := range r.Users {
+			users = append(users, e.userResolver.Resolve(u))
+		}
+		reaction := htmlReaction{Name: r.Name, Count: r.Count, Users: strings.Join(users, ", ")}
+		em := e.emoji.Lookup(r.Name)
+		reaction.Emoji = em.Unicode
+		if em.Custom() {
+			// A saved image, relative to the page, else the one on Slack
+			if local := e.localEmojiImage(em); local != "" {
+				reaction.Image = "../" + local
+			} else {
+				reaction.Image = safeHTMLURL(em.ImageURL)
+			}
+		}
+		m.Reactions = append(m.Reactions, reaction)
+	}
+	for _, att := range msg.Attachments {
+		if compact {
+			break
+		}
+		m.Attachments = append(m.Attachments, htmlAttachment{
+			Pretext: att.Pretext,
+			Title:   att.Title,
+			Link:    safeHTMLURL(att.TitleLink),
+			Text:    att.Text,
+			Image:   safeHTMLURL(att.ImageURL),
+		})
+	}
+	for _, f := range msg.Files {
+		name := f.Title
+		if name == "" {
+			name = f.Name
+		}
+		url := f.Permalink
+		if url == "" {
+			url = f.URLPrivate
+		}
+		if strings.HasPrefix(url, FilesDir+"/") {
+			// A copy saved with --download-files, relative to the page
+			m.Files = append(m.Files, htmlFile{Name: name, URL: url})
+			continue
+		}
+		m.Files = append(m.Files, htmlFile{Name: name, URL: safeHTMLURL(url)})
+	}
+	return m
+}
+
+// compactHTML returns a message of only emoji or a GIF, c, as html: its
+// emoji as Unicode or images, or a link to the GIF.
+func (b htmlBackend) compactHTML(c compactMessage) template.HTML {
+	if c.GIFURL != "" {
+		return template.HTML(`<a href="` + html.EscapeString(safeHTMLURL(c.GIFURL)) + `">` + html.EscapeString(c.label()) + `</a>`)
+	}
+	parts := make([]string, len(c.Emoji))
+	for i, name := range c.Emoji {
+		em := b.e.emoji.Lookup(name)
+		parts[i] = html.EscapeString(em.String())
+		if em.Custom() {
+			image := safeHTMLURL(em.ImageURL)
+			if local := b.e.localEmojiImage(em); local != "" {
+				image = "../" + local
+			}
+			parts[i] = `<img class="emoji" src="` + html.EscapeString(image) + `" alt=":` + html.EscapeString(name) + `:" title=":` + html.EscapeString(name) + `:">`
+		}
+	}
+	return template.HTML(strings.Join(parts, " "))
+}
+
+// loadHTMLManifest reads {dataDir}/conversation.json, or returns an empty
+// manifest when there is none yet.
+func loadHTMLManifest(dataDir string) (*htmlManifest, error) {
+	m := &htmlManifest{Days: make(map[string]int)}
+	data, err := os.ReadFile(filepath.Join(dataDir, "conversation.json"))
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read conversation.json: %w", err)
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("failed to parse conversation.json: %w", err)
+	}
+	if m.Days == nil {
+		m.Days = make(map[string]int)
+	}
+	return m, nil
+}
+
+// writeHTMLSiteIndex writes {root}/index.html, listing each conversation
+// directory under root that holds an html export.
+func writeHTMLSiteIndex(root string) error {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return fmt.Errorf("failed to list %s: %w", root, err)
+	}
+	page := htmlIndexPage{Title: "Slack archive"}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dataDir := filepath.Join(root, entry.Name(), htmlDataDir)
+		if _, err := os.Stat(filepath.Join(dataDir, "conversation.json")); err != nil {
+			continue
+		}
+		m, err := loadHTMLManifest(dataDir)
+		if err != nil {
+			return err
+		}
+		c := htmlConversationEntry{Name: m.Name, Type: m.Type, Dir: entry.Name(), Days: len(m.Days)}
+		for date, count := range m.Days {
+			c.Messages += count
+			if date > c.Latest {
+				c.Latest = date
+			}
+		}
+		page.Conversations = append(page.Conversations, c)
+	}
+	sort.Slice(page.Conversations, func(i, j int) bool {
+		return page.Conversations[i].Name < page.Conversations[j].Name
+	})
+	return writeHTMLPage(root, "index.html", htmlPages, "site", page)
+}
+
+// writeHTMLPage renders the named template of pages into {dir}/{name}.
+func writeHTMLPage(dir, name string, pages *template.Template, tmpl string, data interface{}) error {
+	var buf bytes.Buffer
+	if err := pages.ExecuteTemplate(&buf, tmpl, data); err != nil {
+		return fmt.Errorf("failed to render %s: %w", name, err)
+	}
+	if err := atomicWriteFile(dir, filepath.Join(dir, name), buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// linkifyHTML escapes text, a message converted by ConvertMrkdwnWithLinks,
+// and turns the first free occurrence of each link's text into a link.
+func linkifyHTML(text string, links []parser.LinkAnnotation) template.HTML {
+	type span struct {
+		start, end int
+		url        string
+	}
+	var spans []span
+	for _, l := range links {
+		url := safeHTMLURL(l.URL)
+		if l.Text == "" || url == "" {
+			continue
+		}
+		for from := 0; ; {
+			i := strings.Index(text[from:], l.Text)
+			if i < 0 {
+				break
+			}
+			s := span{from + i, from + i + len(l.Text), url}
+			free := true
+			for _, o := range spans {
+				if s.start < o.end && o.start < s.end {
+					free = false
+					break
+				}
+			}
+			if free {
+				spans = append(spans, s)
+				break
+			}
+			from = s.end
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i].start < spans[j].start
+	})
+
+	var b strings.Builder
+	pos := 0
+	for _, s := range spans {
+		b.WriteString(template.HTMLEscapeString(text[pos:s.start]))
+		fmt.Fprintf(&b, `<a href="%s">%s</a>`, template.HTMLEscapeString(s.url), template.HTMLEscapeString(text[s.start:s.end]))
+		pos = s.end
+	}
+	b.WriteString(template.HTMLEscapeString(text[pos:]))
+	return template.HTML(b.String())
+}
+
+// safeHTMLURL returns u when it is a web or mail link, and "" otherwise.
+func safeHTMLURL(u string) string {
+	lower := strings.ToLower(u)
+	for _, scheme := range []string{"https://", "http://", "mailto:"} {
+		if strings.HasPrefix(lower, scheme) {
+			return u
+		}
+	}
+	return ""
+}
+
+type htmlMessage struct {
+	ID          string
+	Sender      string
+	Time        string
+	Text        template.HTML
+	Edited      bool
+	Compact     bool // only emoji or a GIF, shown on its header's line
+	Reactions   []htmlReaction
+	Attachments []htmlAttachment
+	Files       []htmlFile
+	Replies     []htmlMessage
+}
+
+type htmlReaction struct {
+	Name  string
+	Emoji string // Unicode characters of a standard emoji
+	Image string // image of a custom emoji
+	Count int
+	Users string
+}
+
+type htmlAttachment struct {
+	Pretext, Title, Link, Text, Image string
+}
+
+type htmlFile struct {
+	Name, URL string
+}
+
+type htmlDayPage struct {
+	Title        string
+	Conversation string
+	Date         string
+	Messages     []htmlMessage
+}
+
+type htmlDay struct {
+	Date  string
+	Count int
+}
+
+type htmlConversationPage struct {
+	Title string
+	Days  []htmlDay
+}
+
+type htmlConversationEntry struct {
+	Name, Type, Dir string
+	Days, Messages  int
+	Latest          string
+}
+
+type htmlIndexPage struct {
+	Title         string
+	Conversations []htmlConversationEntry
+}
+
+// htmlPages holds the templates of the html format: "day", "conversation",
+// and "site" (the top-level index). A day page is made of the blocks a
+// layout can replace (see ParseLayouts): "header", "message" for each
+// message, "thread" for a thread's replies, and "footer".
+var htmlPages = template.Must(template.New("html").Parse(htmlPagesSource))
+
+// htmlPagesSource is the source of htmlPages.
+const htmlPagesSource = `
+{{- define "head" -}}
+<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", sans-serif; max-width: 50em; margin: 2em auto; padding: 0 1em; color: #1d1c1d; }
+a { color: #1264a3; }
+nav { margin-bottom: 1em; font-size: 0.9em; }
+h1 small { color: #616061; font-weight: normal; }
+table { border-collapse: collapse; }
+td, th { padding: 0.25em 1em 0.25em 0; text-align: left; }
+.msg { padding: 0.5em 0; border-top: 1px solid #eee; }
+.msg header { margin-bottom: 0.2em; }
+.sender { font-weight: bold; }
+time, .edited { color: #616061; font-size: 0.85em; }
+.text { white-space: pre-wrap; overflow-wrap: anywhere; }
+.attachment { margin: 0.5em 0; padding-left: 0.8em; border-left: 4px solid #ddd; white-space: pre-wrap; }
+.attachment img { max-width: 100%; max-height: 20em; }
+.files, .reactions { list-style: none; padding: 0; margin: 0.3em 0; }
+.reactions li { display: inline-block; margin-right: 0.4em; padding: 0 0.4em; border: 1px solid #ddd; border-radius: 1em; font-size: 0.85em; }
+.reactions img.emoji { height: 1.2em; vertical-align: middle; }
+.compact header, .compact .reactions { display: inline; margin: 0 0.4em 0 0; }
+.compact .text img.emoji { height: 1.5em; vertical-align: middle; }
+.thread { margin: 0.3em 0 0 1.5em; }
+.thread summary { color: #1264a3; cursor: pointer; }
+</style>
+</head>
+<body>
+{{- end}}
+
+{{- define "message"}}
+<article class="msg{{if .Compact}} compact{{end}}" id="m{{.ID}}">
+<header><span class="sender">{{.Sender}}</span> <time>{{.Time}}</time>{{if .Edited}} <span class="edited">(edited)</span>{{end}}{{if .Compact}} <span class="text">{{.Text}}</span>{{end}}</header>
+{{- if and .Text (not .Compact)}}
+<div class="text">{{.Text}}</div>
+{{- end}}
+{{- range .Attachments}}
+<blockquote class="attachment">
+{{- if .Pretext}}<div>{{.Pretext}}</div>{{end}}
+{{- if .Title}}<div>{{if .Link}}<a href="{{.Link}}">{{.Title}}</a>{{else}}<strong>{{.Title}}</strong>{{end}}</div>{{end}}
+{{- if .Text}}<div>{{.Text}}</div>{{end}}
+{{- if .Image}}<img src="{{.Image}}" alt="{{.Title}}" loading="lazy">{{end}}
+</blockquote>
+{{- end}}
+{{- if .Files}}
+<ul class="files">
+{{- range .Files}}
+<li>&#128206; {{if .URL}}<a href="{{.URL}}">{{.Name}}</a>{{else}}{{.Name}}{{end}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Reactions}}
+<ul class="reactions">
+{{- range .Reactions}}
+<li title="{{.Users}}">{{if .Emoji}}{{.Emoji}}{{else if .Image}}<img class="emoji" src="{{.Image}}" alt=":{{.Name}}:" title=":{{.Name}}:">{{else}}:{{.Name}}:{{end}} {{.Count}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Replies}}{{template "thread" .}}{{end}}
+</article>
+{{- end}}
+
+{{- define "thread"}}
+<details class="thread">
+<summary>{{len .Replies}} {{if eq (len .Replies) 1}}reply{{else}}replies{{end}}</summary>
+{{- range .Replies}}{{template "message" .}}{{end}}
+</details>
+{{- end}}
+
+{{- define "header"}}
+<nav><a href="../index.html">All conversations</a> / <a href="index.html">{{.Conversation}}</a></nav>
+<h1>{{.Conversation}} <small>{{.Date}}</small></h1>
+{{- end}}
+
+{{- define "footer"}}{{end}}
+
+{{- define "day"}}{{template "head" .}}{{template "header" .}}
+{{- range .Messages}}{{template "message" .}}{{end}}{{template "footer" .}}
+</body>
+</html>
+{{end}}
+
+{{- define "conversation"}}{{template "head" .}}
+<nav><a href="../index.html">All conversations</a></nav>
+<h1>{{.Title}}</h1>
+<table>
+{{- range .Days}}
+<tr><td><a href="{{.Date}}.html">{{.Date}}</a></td><td>{{.Count}} messages</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+{{end}}
+
+{{- define "site"}}{{template "head" .}}
+<h1>{{.Title}}</h1>
+<table>
+<tr><th>Conversation</th><th>Type</th><th>Days</th><th>Messages</th><th>Latest</th></tr>
+{{- range .Conversations}}
+<tr><td><a href="{{.Dir}}/index.html">{{.Name}}</a></td><td>{{.Type}}</td><td>{{.Days}}</td><td>{{.Messages}}</td><td>{{.Latest}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+{{end}}`
@@ -0,0 +1,117 @@
+package exporter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+func TestExportConversation_HTMLFormat(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	conv.Format = config.OutputFormatHTML
+	msgs := slack.Messages["C001"]
+	msgs[0].Reactions = []slackapi.Reaction{{Name: "wave", Count: 2, Users: []string{"U001", "U002"}}}
+	msgs[0].Attachments = []slackapi.Attachment{{Title: "Spec <draft>", TitleLink: "https://example.com/spec"}}
+	msgs[0].Files = []slackapi.File{{Name: "notes.txt", Permalink: "https://files.example.com/notes"}}
+	exp, localDir := localFormatExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	if n := len(drive.Documents()); n != 0 {
+		t.Errorf("documents = %d, want 0 (html stays off Drive)", n)
+	}
+	if result.MessageCount != 3 || result.HTMLPagesWritten != 2 {
+		t.Errorf("MessageCount = %d, HTMLPagesWritten = %d, want 3 and 2", result.MessageCount, result.HTMLPagesWritten)
+	}
+
+	dir := filepath.Join(localDir, SanitizeDirectoryName(string(conv.Type), conv.Name))
+	day := readFile(t, filepath.Join(dir, "2024-02-01.html"))
+	for _, want := range []string{
+		"Good morning",
+		":wave: 2",
+		`<a href="https://example.com/spec">Spec &lt;draft&gt;</a>`,
+		`<a href="https://files.example.com/notes">notes.txt</a>`,
+		"<summary>1 reply</summary>",
+		"A reply",
+	} {
+		if !strings.Contains(day, want) {
+			t.Errorf("day page missing %q", want)
+		}
+	}
+	if strings.Contains(day, "Next day") {
+		t.Error("day page includes the next day's message")
+	}
+
+	index := readFile(t, filepath.Join(dir, "index.html"))
+	if !strings.Contains(index, `<a href="2024-02-01.html">2024-02-01</a></td><td>2 messages`) || !strings.Contains(index, "2024-02-02.html") {
+		t.Errorf("conversation index = %s", index)
+	}
+	site := readFile(t, filepath.Join(localDir, "index.html"))
+	if !strings.Contains(site, `<a href="channel-general/index.html">general</a>`) {
+		t.Errorf("site index = %s", site)
+	}
+}
+
+func TestExportConversation_HTMLFormatSyncMerges(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	conv.Format = config.OutputFormatHTML
+	exp, localDir := localFormatExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatal(err)
+	}
+
+	slack.Messages["C001"] = append(slack.Messages["C001"], slackapi.Message{User: "U002", Text: "Later that day", TS: "1706878800.000500"})
+	exp.syncMode = true
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(localDir, SanitizeDirectoryName(string(conv.Type), conv.Name))
+	day := readFile(t, filepath.Join(dir, "2024-02-02.html"))
+	if !strings.Contains(day, "Next day") || !strings.Contains(day, "Later that day") {
+		t.Errorf("day page after sync = %s, want both messages", day)
+	}
+	if index := readFile(t, filepath.Join(dir, "index.html")); !strings.Contains(index, "2024-02-02.html\">2024-02-02</a></td><td>2 messages") {
+		t.Errorf("conversation index after sync = %s", index)
+	}
+}
+
+func TestExportConversation_HTMLFormatLegalHold(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	conv.Format = config.OutputFormatHTML
+	exp, _ := localFormatExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.legalHold = true
+
+	if _, err := exp.ExportConversation(context.Background(), conv); err == nil || !strings.Contains(err.Error(), "legal hold") {
+		t.Errorf("ExportConversation() error = %v, want html rejected under legal hold", err)
+	}
+}
+
+func TestLinkifyHTML(t *testing.T) {
+	links := []parser.LinkAnnotation{
+		{Text: "docs", URL: "https://example.com/?a=1&b=2"},
+		{Text: "evil", URL: "javascript:alert(1)"},
+	}
+	got := string(linkifyHTML("see docs & <evil>", links))
+	want := `see <a href="https://example.com/?a=1&amp;b=2">docs</a> &amp; &lt;evil&gt;`
+	if got != want {
+		t.Errorf("linkifyHTML() = %q, want %q", got, want)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,188 @@
+package exporter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // decode GIF attachments
+	"image/jpeg"
+	"image/png"
+
+	"github.com/jflowers/get-out/pkg/config"
+)
+
+const (
+	// maxImageWidthPt is the widest an embedded image is shown, in points:
+	// the text width of a Letter page with 1" margins.
+	maxImageWidthPt = 468
+
+	// maxImageDecodePixels is the largest image, in pixels, decoded to be
+	// scaled down; anything larger is refused rather than risk exhausting
+	// memory on a decompression bomb.
+	maxImageDecodePixels = 100_000_000
+
+	// minImageDimension is the smallest side an image is scaled down to
+	// while trying to bring it under the byte limit.
+	minImageDimension = 64
+
+	// maxDocsImageBytes and maxDocsImagePixels are the largest image the
+	// Docs API inserts. GIFs are embedded as they are up to these limits,
+	// since scaling one would lose its animation.
+	maxDocsImageBytes  = 50 << 20
+	maxDocsImagePixels = 25_000_000
+)
+
+// errImageTooLarge reports an image that cannot be brought under the
+// limits, and is referenced by name instead of embedded.
+var errImageTooLarge = errors.New("image too large to embed")
+
+// imageLimits bound the images embedded in Google Docs (see
+// config.ImageConfig).
+type imageLimits struct {
+	maxDimension int
+	maxBytes     int64
+}
+
+// newImageLimits returns the limits set by cfg, defaults filled in.
+func newImageLimits(cfg *config.ImageConfig) imageLimits {
+	limits := imageLimits{
+		maxDimension: config.DefaultImageMaxDimension,
+		maxBytes:     config.DefaultImageMaxBytes,
+	}
+	if cfg != nil && cfg.MaxDimension > 0 {
+		limits.maxDimension = cfg.MaxDimension
+	}
+	if cfg != nil && cfg.MaxBytes > 0 {
+		limits.maxBytes = cfg.MaxBytes
+	}
+	return limits
+}
+
+// fittedImage is an image attachment ready to embed.
+type fittedImage struct {
+	data     []byte
+	mimetype string
+
+	// width and height are the image's size in pixels, 0 when its format
+	// could not be decoded.
+	width, height int
+}
+
+// displaySize returns the size to show img at in a doc, in points: its
+// pixel size at 96 dpi, scaled down to the page width. It is 0 by 0 when
+// the pixel size is unknown.
+func (img fittedImage) displaySize() (width, height float64) {
+	if img.width <= 0 || img.height <= 0 {
+		return 0, 0
+	}
+	width = float64(img.width) * 0.75
+	height = float64(img.height) * 0.75
+	if width > maxImageWidthPt {
+		height *= maxImageWidthPt / width
+		width = maxImageWidthPt
+	}
+	return width, height
+}
+
+// fitImage returns the image in data within limits: unchanged when it is
+// within them, otherwise scaled down, as JPEG when it was a JPEG and as
+// PNG otherwise, until it fits. A GIF is never re-encoded: it is returned
+// unchanged while within the Docs API's own limits. An image in a format
+// that cannot be decoded is returned unchanged if it is under the byte
+// limit. errImageTooLarge is returned for an image that cannot be made to
+// fit.
+func fitImage(data []byte, mimetype string, limits imageLimits) (fittedImage, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		if int64(len(data)) > limits.maxBytes {
+			return fittedImage{}, fmt.Errorf("%w: %d bytes", errImageTooLarge, len(data))
+		}
+		return fittedImage{data: data, mimetype: mimetype}, nil
+	}
+	if max(cfg.Width, cfg.Height) <= limits.maxDimension && int64(len(data)) <= limits.maxBytes {
+		return fittedImage{data: data, mimetype: mimetype, width: cfg.Width, height: cfg.Height}, nil
+	}
+	if format == "gif" {
+		if len(data) > maxDocsImageBytes || cfg.Width*cfg.Height > maxDocsImagePixels {
+			return fittedImage{}, fmt.Errorf("%w: GIF of %d bytes at %dx%d pixels", errImageTooLarge, len(data), cfg.Width, cfg.Height)
+		}
+		return fittedImage{data: data, mimetype: mimetype, width: cfg.Width, height: cfg.Height}, nil
+	}
+	if cfg.Width*cfg.Height > maxImageDecodePixels {
+		return fittedImage{}, fmt.Errorf("%w: %dx%d pixels", errImageTooLarge, cfg.Width, cfg.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fittedImage{}, fmt.Errorf("failed to decode image: %w", err)
+	}
+	width, height := fitDimensions(cfg.Width, cfg.Height, limits.maxDimension)
+	for {
+		scaled := downscale(src, width, height)
+		var buf bytes.Buffer
+		fitted := fittedImage{width: width, height: height}
+		if format == "jpeg" {
+			err = jpeg.Encode(&buf, scaled, &jpeg.Options{Quality: 85})
+			fitted.mimetype = "image/jpeg"
+		} else {
+			err = png.Encode(&buf, scaled)
+			fitted.mimetype = "image/png"
+		}
+		if err != nil {
+			return fittedImage{}, fmt.Errorf("failed to encode image: %w", err)
+		}
+		if int64(buf.Len()) <= limits.maxBytes {
+			fitted.data = buf.Bytes()
+			return fitted, nil
+		}
+
+		// Still over the byte limit: shrink further.
+		if min(width, height) <= minImageDimension {
+			return fittedImage{}, fmt.Errorf("%w: %d bytes at %dx%d pixels", errImageTooLarge, buf.Len(), width, height)
+		}
+		width, height = max(width*3/4, 1), max(height*3/4, 1)
+	}
+}
+
+// fitDimensions returns width and height scaled down, keeping the aspect
+// ratio, so that neither exceeds maxDimension.
+func fitDimensions(width, height, maxDimension int) (int, int) {
+	longest := max(width, height)
+	if longest <= maxDimension {
+		return width, height
+	}
+	return max(width*maxDimension/longest, 1), max(height*maxDimension/longest, 1)
+}
+
+// downscale returns src scaled down to width by height, each pixel the
+// average of the source pixels it covers.
+func downscale(src image.Image, width, height int) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		y0 := b.Min.Y + y*b.Dy()/height
+		y1 := max(b.Min.Y+(y+1)*b.Dy()/height, y0+1)
+		for x := range width {
+			x0 := b.Min.X + x*b.Dx()/width
+			x1 := max(b.Min.X+(x+1)*b.Dx()/width, x0+1)
+
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(bl / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,257 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"math/rand"
+	"testing"
+
+	"github.com/jflowers/get-out/internal/testutil"
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// testImage returns a width by height image encoded in format ("png",
+// "jpeg", or "gif"), filled with noise when noisy so it compresses poorly.
+func testImage(t *testing.T, format string, width, height int, noisy bool) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	rnd := rand.New(rand.NewSource(1))
+	for y := range height {
+		for x := range width {
+			c := color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255}
+			if noisy {
+				c = color.RGBA{R: uint8(rnd.Intn(256)), G: uint8(rnd.Intn(256)), B: uint8(rnd.Intn(256)), A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	default:
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// gifOfScreenSize returns a small GIF whose logical screen claims to be
+// width by height, which is the size image.DecodeConfig reports.
+func gifOfScreenSize(t *testing.T, width, height int) []byte {
+	t.Helper()
+	data := testImage(t, "gif", 2, 2, false)
+	binary.LittleEndian.PutUint16(data[6:8], uint16(width))
+	binary.LittleEndian.PutUint16(data[8:10], uint16(height))
+	return data
+}
+
+func TestFitImage(t *testing.T) {
+	small := testImage(t, "png", 200, 100, false)
+	tests := []struct {
+		name       string
+		data       []byte
+		mimetype   string
+		limits     imageLimits
+		wantSize   [2]int
+		wantMime   string
+		wantSame   bool
+		wantTooBig bool
+	}{
+		{
+			name:     "within limits unchanged",
+			data:     small,
+			mimetype: "image/png",
+			limits:   imageLimits{maxDimension: 1600, maxBytes: 1 << 20},
+			wantSize: [2]int{200, 100},
+			wantMime: "image/png",
+			wantSame: true,
+		},
+		{
+			name:     "png scaled to max dimension",
+			data:     testImage(t, "png", 800, 400, false),
+			mimetype: "image/png",
+			limits:   imageLimits{maxDimension: 400, maxBytes: 1 << 20},
+			wantSize: [2]int{400, 200},
+			wantMime: "image/png",
+		},
+		{
+			name:     "jpeg stays jpeg",
+			data:     testImage(t, "jpeg", 300, 600, false),
+			mimetype: "image/jpeg",
+			limits:   imageLimits{maxDimension: 300, maxBytes: 1 << 20},
+			wantSize: [2]int{150, 300},
+			wantMime: "image/jpeg",
+		},
+		{
+			name:     "shrunk under byte limit",
+			data:     testImage(t, "png", 400, 400, true),
+			mimetype: "image/png",
+			limits:   imageLimits{maxDimension: 1600, maxBytes: 100_000},
+			wantSize: [2]int{168, 168},
+			wantMime: "image/png",
+		},
+		{
+			name:     "gif over limits embedded as is",
+			data:     testImage(t, "gif", 800, 400, true),
+			mimetype: "image/gif",
+			limits:   imageLimits{maxDimension: 400, maxBytes: 1000},
+			wantSize: [2]int{800, 400},
+			wantMime: "image/gif",
+			wantSame: true,
+		},
+		{
+			name:       "gif over the Docs pixel limit",
+			data:       gifOfScreenSize(t, 6000, 5000),
+			mimetype:   "image/gif",
+			limits:     imageLimits{maxDimension: 1600, maxBytes: 1 << 20},
+			wantTooBig: true,
+		},
+		{
+			name:       "cannot fit",
+			data:       testImage(t, "png", 400, 400, true),
+			mimetype:   "image/png",
+			limits:     imageLimits{maxDimension: 1600, maxBytes: 1000},
+			wantTooBig: true,
+		},
+		{
+			name:     "undecodable under byte limit unchanged",
+			data:     []byte("<svg/>"),
+			mimetype: "image/svg+xml",
+			limits:   imageLimits{maxDimension: 1600, maxBytes: 1000},
+			wantMime: "image/svg+xml",
+			wantSame: true,
+		},
+		{
+			name:       "undecodable over byte limit",
+			data:       bytes.Repeat([]byte("x"), 2000),
+			mimetype:   "image/webp",
+			limits:     imageLimits{maxDimension: 1600, maxBytes: 1000},
+			wantTooBig: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fitImage(tt.data, tt.mimetype, tt.limits)
+			if tt.wantTooBig {
+				if !errors.Is(err, errImageTooLarge) {
+					t.Fatalf("fitImage() error = %v, want errImageTooLarge", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fitImage() error: %v", err)
+			}
+			if size := [2]int{got.width, got.height}; size != tt.wantSize {
+				t.Errorf("size = %v, want %v", size, tt.wantSize)
+			}
+			if got.mimetype != tt.wantMime {
+				t.Errorf("mimetype = %q, want %q", got.mimetype, tt.wantMime)
+			}
+			if same := bytes.Equal(got.data, tt.data); same != tt.wantSame {
+				t.Errorf("data unchanged = %v, want %v", same, tt.wantSame)
+			}
+			if int64(len(got.data)) > tt.limits.maxBytes && !tt.wantSame {
+				t.Errorf("%d bytes, over the %d byte limit", len(got.data), tt.limits.maxBytes)
+			}
+			if tt.wantSame {
+				return
+			}
+			cfg, _, err := image.DecodeConfig(bytes.NewReader(got.data))
+			if err != nil || cfg.Width != got.width || cfg.Height != got.height {
+				t.Errorf("encoded image is %dx%d (%v), want %dx%d", cfg.Width, cfg.Height, err, got.width, got.height)
+			}
+		})
+	}
+}
+
+func TestFitDimensions(t *testing.T) {
+	tests := []struct {
+		width, height, max int
+		want               [2]int
+	}{
+		{width: 100, height: 50, max: 200, want: [2]int{100, 50}},
+		{width: 4000, height: 3000, max: 1600, want: [2]int{1600, 1200}},
+		{width: 1000, height: 5000, max: 1000, want: [2]int{200, 1000}},
+		{width: 10000, height: 1, max: 100, want: [2]int{100, 1}},
+	}
+	for _, tt := range tests {
+		w, h := fitDimensions(tt.width, tt.height, tt.max)
+		if got := [2]int{w, h}; got != tt.want {
+			t.Errorf("fitDimensions(%d, %d, %d) = %v, want %v", tt.width, tt.height, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestFittedImage_DisplaySize(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		want          [2]float64
+	}{
+		{name: "unknown size", want: [2]float64{0, 0}},
+		{name: "small", width: 400, height: 200, want: [2]float64{300, 150}},
+		{name: "wider than the page", width: 1248, height: 624, want: [2]float64{468, 234}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, h := fittedImage{width: tt.width, height: tt.height}.displaySize()
+			if got := [2]float64{w, h}; got != tt.want {
+				t.Errorf("displaySize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewImageLimits(t *testing.T) {
+	if got := newImageLimits(nil); got.maxDimension != config.DefaultImageMaxDimension || got.maxBytes != config.DefaultImageMaxBytes {
+		t.Errorf("newImageLimits(nil) = %+v, want the defaults", got)
+	}
+	got := newImageLimits(&config.ImageConfig{MaxDimension: 800})
+	if got.maxDimension != 800 || got.maxBytes != config.DefaultImageMaxBytes {
+		t.Errorf("newImageLimits(maxDimension 800) = %+v, want 800 and the default byte limit", got)
+	}
+}
+
+func TestProcessMessageFiles_ScalesImages(t *testing.T) {
+	drive := testutil.NewFakeDrive()
+	slack := testutil.NewFakeSlack()
+	slack.Files["https://files.slack.com/big.png"] = testImage(t, "png", 2000, 1000, false)
+	slack.Files["https://files.slack.com/huge.webp"] = bytes.Repeat([]byte("x"), 200_000)
+
+	w := NewDocWriter(drive, slack, nil, nil, nil, nil, nil)
+	w.SetImageLimits(&config.ImageConfig{MaxDimension: 1000, MaxBytes: 100_000})
+	files := []slackapi.File{
+		{Name: "big.png", Mimetype: "image/png", URLPrivateDownload: "https://files.slack.com/big.png"},
+		{Name: "huge.webp", Mimetype: "image/webp", URLPrivateDownload: "https://files.slack.com/huge.webp"},
+	}
+	text, _, images := w.processMessageFiles(context.Background(), "C001", files, "folder123")
+	if len(images) != 1 {
+		t.Fatalf("got %d images, want 1", len(images))
+	}
+	if images[0].Width != 468 || images[0].Height != 234 {
+		t.Errorf("image size = %vx%v, want 468x234", images[0].Width, images[0].Height)
+	}
+	if images[0].Caption != "big.png" {
+		t.Errorf("Caption = %q, want the file name", images[0].Caption)
+	}
+	if text != "[File: huge.webp]" {
+		t.Errorf("got text %q, want a reference to the image too large to embed", text)
+	}
+	if n := drive.Files(); n != 0 {
+		t.Errorf("%d temporary uploads left", n)
+	}
+}
@@ -1,20 +1,31 @@
-// Package exporter handles the export of Slack messages to Google Docs.
 package exporter
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"sync"
 	"time"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/migrate"
+	"github.com/jflowers/get-out/pkg/parser"
 )
 
+// IndexMigrations lists the format versions of the export index. Indexes
+// from older releases are upgraded (with a backup) when loaded.
+var IndexMigrations = migrate.Schema{Name: "export index"}
+
 // ExportIndex tracks all exported content for checkpointing and link resolution.
 type ExportIndex struct {
 	mu sync.RWMutex
 
+	// Version is the file's format version (see IndexMigrations).
+	Version int `json:"version"`
+
 	// RootFolderID is the ID of the root "Slack Exports" folder in Drive
 	RootFolderID  string `json:"root_folder_id"`
 	RootFolderURL string `json:"root_folder_url"`
@@ -25,11 +36,38 @@ type ExportIndex struct {
 	// Users maps Slack user ID to cached user info
 	Users map[string]*UserCache `json:"users"`
 
+	// Aliases maps a previous conversation ID to the ID its history was
+	// merged into, so links to the old ID still resolve.
+	Aliases map[string]string `json:"aliases,omitempty"`
+
+	// SelfUserID is the Slack user ID of the account running the export
+	SelfUserID string `json:"self_user_id,omitempty"`
+
 	// UpdatedAt is the last time this index was modified
 	UpdatedAt time.Time `json:"updated_at"`
 
+	// JournalGeneration identifies the checkpoint journal that applies to
+	// this file (see SaveConversation); it changes on every full Save.
+	JournalGeneration int `json:"journal_generation,omitempty"`
+
 	// path is where this index is saved (not serialized)
 	path string
+
+	// Checkpoint journal state (see SaveConversation): the sizes of the
+	// index file and of the journal, and whether the journal ends in a
+	// partial line.
+	fileBytes    int64
+	journalBytes int64
+	journalTorn  bool
+
+	// fileInfo is the index file as this index last loaded or saved it,
+	// nil when there was none, so SaveConversation can tell when another
+	// process has saved it since.
+	fileInfo os.FileInfo
+
+	// recovered is why the index file could not be read when it was loaded
+	// from its backup instead (see LoadExportIndex), nil otherwise.
+	recovered error
 }
 
 // ConversationExport tracks the export state of a single conversation.
@@ -47,10 +85,49 @@ type ConversationExport struct {
 	FolderURL       string `json:"folder_url"`
 	ThreadsFolderID string `json:"threads_folder_id,omitempty"`
 
-	// Status tracks export completion: "in_progress" or "complete"
+	// FolderName is the name the conversation's Drive folder was created
+	// with (see FolderStructure.EnsureConversationFolder). Exports before it
+	// was recorded leave it empty; see folderName.
+	FolderName string `json:"folder_name,omitempty"`
+
+	// FilesFolderID is the "Files" subfolder holding attachments uploaded
+	// with --download-files, and Files maps each uploaded attachment's
+	// Slack file ID to its Drive file ID.
+	FilesFolderID string            `json:"files_folder_id,omitempty"`
+	Files         map[string]string `json:"files,omitempty"`
+
+	// Canvases maps the Slack file ID of each canvas or post shared in the
+	// conversation to its exported copy (see exportCanvases).
+	Canvases map[string]*CanvasExport `json:"canvases,omitempty"`
+
+	// Layout is the Drive folder layout (see config.FolderLayout). With a
+	// nested layout, DateFolders and ThreadDateFolders map a year ("2024")
+	// or month ("2024-01") to its folder under the conversation folder and
+	// under Threads.
+	Layout            string            `json:"layout,omitempty"`
+	DateFolders       map[string]string `json:"date_folders,omitempty"`
+	ThreadDateFolders map[string]string `json:"thread_date_folders,omitempty"`
+
+	// FolderItems counts the docs and folders get-out created in each of
+	// the conversation's Drive folders, by folder ID.
+	FolderItems map[string]int `json:"folder_items,omitempty"`
+
+	// Status tracks the export through its lifecycle (see StatusPending),
+	// and Error records why the last export failed when Status is
+	// StatusFailed.
 	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+
+	// SharedWith is the peer config directory whose export holds this
+	// shared channel when Status is StatusShared.
+	SharedWith string `json:"shared_with,omitempty"`
+
+	// Granularity is the conversation's doc granularity (see
+	// config.DocGranularity); empty is daily.
+	Granularity string `json:"granularity,omitempty"`
 
-	// DailyDocs maps date string (YYYY-MM-DD) to doc info
+	// DailyDocs maps the period each doc holds (see docPeriodOf), a date
+	// string (YYYY-MM-DD) for daily docs, to doc info
 	DailyDocs map[string]*DocExport `json:"daily_docs"`
 
 	// Threads maps thread_ts to thread export info
@@ -59,13 +136,57 @@ type ConversationExport struct {
 	// LastMessageTS is the timestamp of the last exported message
 	LastMessageTS string `json:"last_message_ts"`
 
+	// FirstMessageTS is the timestamp of the oldest exported message.
+	// Exports before it was recorded leave it empty.
+	FirstMessageTS string `json:"first_message_ts,omitempty"`
+
+	// SourceCursors maps each previous ID merged into this conversation
+	// (see MergeAliases) to the timestamp of the newest message exported
+	// from it. LastMessageTS is only this conversation's own cursor, so
+	// --sync continues every ID from where its own history left off.
+	SourceCursors map[string]string `json:"source_cursors,omitempty"`
+
+	// MergedFolders lists the Drive folders of merged aliases whose
+	// contents have yet to be moved into FolderID (see mergeAliasFolders).
+	MergedFolders []string `json:"merged_folders,omitempty"`
+
+	// CompletedDays maps each day (YYYY-MM-DD) written by an export that
+	// has not finished yet to the newest message written to it, so --resume
+	// skips what is already in the day's doc. It is cleared once the export
+	// completes.
+	CompletedDays map[string]string `json:"completed_days,omitempty"`
+
+	// Fetch checkpoints the fetch of an export that has not finished yet
+	// (see FetchCheckpoint). It is cleared once the export completes.
+	Fetch *FetchCheckpoint `json:"fetch,omitempty"`
+
 	// MessageCount is the total number of messages exported
 	MessageCount int `json:"message_count"`
 
 	// LastUpdated is when this conversation was last exported
 	LastUpdated time.Time `json:"last_updated"`
+
+	// Tags and Notes are user annotations set with `get-out tag`
+	Tags  []string `json:"tags,omitempty"`
+	Notes string   `json:"notes,omitempty"`
 }
 
+// Conversation export statuses. A conversation is pending from when a run
+// queues it until its export starts, and in_progress while it runs. It
+// stays in_progress when the run is interrupted or stopped at its budget,
+// so --resume and --sync continue from the checkpoint. It becomes complete
+// once every fetched message is written, or failed, with the error, when
+// its export stops on an error. A Slack Connect channel already exported
+// from another workspace's configuration (settings.json peerConfigDirs) is
+// shared instead, and not exported here.
+const (
+	StatusPending    = "pending"
+	StatusInProgress = "in_progress"
+	StatusComplete   = "complete"
+	StatusFailed     = "failed"
+	StatusShared     = "shared"
+)
+
 // DocExport tracks a single Google Doc.
 type DocExport struct {
 	DocID  string `json:"doc_id"`
@@ -78,6 +199,26 @@ type DocExport struct {
 
 	// MessageCount in this doc
 	MessageCount int `json:"message_count"`
+
+	// EndIndex is the doc's end index after the last write recorded here,
+	// so --resume can tell how much an interrupted run appended after it.
+	// Zero when unknown.
+	EndIndex int64 `json:"end_index,omitempty"`
+}
+
+// CanvasExport tracks a canvas or post exported as its own doc, next to
+// the conversation's daily docs, and as markdown with local export.
+type CanvasExport struct {
+	DocID  string `json:"doc_id,omitempty"`
+	DocURL string `json:"doc_url,omitempty"`
+	Title  string `json:"title"`
+
+	// File is the markdown file, relative to the local export directory.
+	File string `json:"file,omitempty"`
+
+	// Updated is when the canvas was last edited (Unix seconds) as of the
+	// export, so unchanged canvases are not fetched again.
+	Updated int64 `json:"updated,omitempty"`
 }
 
 // ThreadExport tracks an exported thread.
@@ -87,6 +228,10 @@ type ThreadExport struct {
 	FolderURL  string `json:"folder_url"`
 	FolderName string `json:"folder_name"`
 
+	// LocalDir is the directory, relative to the local export directory,
+	// holding the thread's markdown (see Exporter.localThreadDir)
+	LocalDir string `json:"local_dir,omitempty"`
+
 	// DailyDocs for this thread (threads can span multiple days)
 	DailyDocs map[string]*DocExport `json:"daily_docs"`
 
@@ -95,6 +240,12 @@ type ThreadExport struct {
 
 	// LastReplyTS is the timestamp of the last exported reply
 	LastReplyTS string `json:"last_reply_ts"`
+
+	// StartedBy is the user ID of the thread parent's author
+	StartedBy string `json:"started_by,omitempty"`
+
+	// Participants lists the user IDs who posted in the thread, sorted
+	Participants []string `json:"participants,omitempty"`
 }
 
 // UserCache caches Slack user information.
@@ -110,6 +261,7 @@ type UserCache struct {
 // NewExportIndex creates a new empty export index.
 func NewExportIndex(path string) *ExportIndex {
 	return &ExportIndex{
+		Version:       IndexMigrations.Current(),
 		Conversations: make(map[string]*ConversationExport),
 		Users:         make(map[string]*UserCache),
 		UpdatedAt:     time.Now(),
@@ -118,21 +270,28 @@ func NewExportIndex(path string) *ExportIndex {
 }
 
 // LoadExportIndex loads an export index from a file, or creates a new one.
+// An index from an older release is upgraded in place, and one from a
+// newer release is refused. Checkpoints saved since the file was last
+// written are applied.
+//
+// When the file cannot be parsed, as a crash or a full disk can leave it,
+// the backup kept by the last Save is loaded instead and Recovered reports
+// why.
 func LoadExportIndex(path string) (*ExportIndex, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return NewExportIndex(path), nil
+	index, err := loadExportIndexFile(path, path)
+	if isIndexParseError(err) {
+		backup, backupErr := loadExportIndexFile(path, IndexBackupPath(path))
+		if backupErr == nil && backup != nil {
+			backup.recovered = err
+			index, err = backup, nil
 		}
-		return nil, fmt.Errorf("failed to read export index: %w", err)
 	}
-
-	var index ExportIndex
-	if err := json.Unmarshal(data, &index); err != nil {
-		return nil, fmt.Errorf("failed to parse export index: %w", err)
+	if err != nil {
+		return nil, err
+	}
+	if index == nil {
+		index = NewExportIndex(path)
 	}
-
-	index.path = path
 
 	// Initialize maps if nil (for backwards compatibility)
 	if index.Conversations == nil {
@@ -142,13 +301,74 @@ func LoadExportIndex(path string) (*ExportIndex, error) {
 		index.Users = make(map[string]*UserCache)
 	}
 
-	return &index, nil
+	if err := index.replayJournal(); err != nil {
+		return nil, err
+	}
+	index.fileInfo, _ = os.Stat(path)
+
+	return index, nil
 }
 
-// Save writes the export index to disk.
+// indexParseError marks an index file that was read but is not valid JSON,
+// as a crash partway through writing it would leave.
+type indexParseError struct{ err error }
+
+func (e indexParseError) Error() string { return e.err.Error() }
+func (e indexParseError) Unwrap() error { return e.err }
+
+func isIndexParseError(err error) bool {
+	var parseErr indexParseError
+	return errors.As(err, &parseErr)
+}
+
+// loadExportIndexFile reads the index saved at file as the index at path.
+// It returns nil and no error when file does not exist.
+func loadExportIndexFile(path, file string) (*ExportIndex, error) {
+	data, err := IndexMigrations.LoadFile(file)
+	switch {
+	case os.IsNotExist(err):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to load export index: %w", err)
+	}
+	index := NewExportIndex(path)
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, indexParseError{fmt.Errorf("failed to parse export index: %w", err)}
+	}
+	index.fileBytes = int64(len(data))
+	return index, nil
+}
+
+// IndexBackupPath returns where Save keeps the previous version of the
+// index at path, e.g. export-index.json.bak.
+func IndexBackupPath(path string) string {
+	return path + ".bak"
+}
+
+// Recovered returns why the index file could not be parsed when
+// LoadExportIndex loaded its backup instead, or nil. Progress recorded
+// after the backup was made is lost, so the conversations it covered are
+// exported again from the backup's checkpoints.
+func (idx *ExportIndex) Recovered() error {
+	return idx.recovered
+}
+
+// Save writes the whole export index to disk and clears the checkpoint
+// journal. Export runs checkpoint with SaveConversation instead, which
+// costs only the size of one conversation.
 func (idx *ExportIndex) Save() error {
 	idx.mu.Lock()
 	defer idx.mu.Unlock()
+	return idx.saveLocked()
+}
+
+// saveLocked writes the whole index. Caller must hold idx.mu.
+func (idx *ExportIndex) saveLocked() error {
+	// Ensure directory exists
+	dir := filepath.Dir(idx.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
 
 	// Lock all per-conversation mutexes so json.MarshalIndent reads a
 	// consistent snapshot.  Concurrent ExportConversation goroutines
@@ -159,17 +379,14 @@ func (idx *ExportIndex) Save() error {
 	}
 
 	idx.UpdatedAt = time.Now()
-
-	// Ensure directory exists
-	dir := filepath.Dir(idx.path)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		for _, conv := range idx.Conversations {
-			conv.mu.Unlock()
-		}
-		return fmt.Errorf("failed to create directory: %w", err)
-	}
-
+	idx.Version = IndexMigrations.Current()
+	// A new generation makes the current journal obsolete even if it
+	// cannot be removed below. Keep the old one until the file is written,
+	// so checkpoints after a failed save still match the file on disk.
+	prevGeneration := idx.JournalGeneration
+	idx.JournalGeneration++
 	data, err := json.MarshalIndent(idx, "", "  ")
+	idx.JournalGeneration = prevGeneration
 
 	// Unlock all conversation mutexes now that serialization is done.
 	for _, conv := range idx.Conversations {
@@ -180,10 +397,73 @@ func (idx *ExportIndex) Save() error {
 		return fmt.Errorf("failed to marshal export index: %w", err)
 	}
 
-	if err := os.WriteFile(idx.path, data, 0644); err != nil {
+	if err := writeIndexFile(idx.path, data, idx.recovered == nil); err != nil {
+		return err
+	}
+	idx.JournalGeneration++
+	idx.fileBytes = int64(len(data))
+	idx.fileInfo, _ = os.Stat(idx.path)
+	idx.recovered = nil
+
+	idx.journalBytes = 0
+	idx.journalTorn = false
+	if err := os.Remove(journalPath(idx.path)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to clear index journal: %w", err)
+	}
+	return nil
+}
+
+// writeIndexFile replaces the index at path with data. The data is written
+// to a temp file and synced before it is renamed into place, so a crash
+// leaves either the old index or the new one, never a truncated file. With
+// backup, the old index is first copied to IndexBackupPath, so an index
+// damaged some other way can be recovered from the save before it; an
+// index that was found damaged is not copied over a good backup.
+func writeIndexFile(path string, data []byte, backup bool) error {
+	if backup {
+		old, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			if err := replaceIndexFile(IndexBackupPath(path), old); err != nil {
+				return fmt.Errorf("failed to back up export index: %w", err)
+			}
+		case !os.IsNotExist(err):
+			return fmt.Errorf("failed to back up export index: %w", err)
+		}
+	}
+	if err := replaceIndexFile(path, data); err != nil {
 		return fmt.Errorf("failed to write export index: %w", err)
 	}
+	return nil
+}
 
+// replaceIndexFile atomically replaces path with data: write to a temp
+// file, sync it, rename it into place, and sync the directory so the
+// rename survives a power loss where the platform allows.
+func replaceIndexFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if dir, err := os.Open(filepath.Dir(path)); err == nil {
+		_ = dir.Sync()
+		dir.Close()
+	}
 	return nil
 }
 
@@ -194,6 +474,15 @@ func (idx *ExportIndex) GetConversation(id string) *ConversationExport {
 	return idx.Conversations[id]
 }
 
+// ResolveConversation returns the export state for a conversation,
+// following aliases, or nil when it has none.
+func (idx *ExportIndex) ResolveConversation(id string) *ConversationExport {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	conv, _ := idx.resolveConversationLocked(id)
+	return conv
+}
+
 // SetConversation updates the export state for a conversation.
 func (idx *ExportIndex) SetConversation(conv *ConversationExport) {
 	idx.mu.Lock()
@@ -228,6 +517,7 @@ func (idx *ExportIndex) GetOrCreateConversation(id, name, convType string) *Conv
 		ID:        id,
 		Name:      name,
 		Type:      convType,
+		Status:    StatusPending,
 		DailyDocs: make(map[string]*DocExport),
 		Threads:   make(map[string]*ThreadExport),
 	}
@@ -235,6 +525,148 @@ func (idx *ExportIndex) GetOrCreateConversation(id, name, convType string) *Conv
 	return conv
 }
 
+// MergeAliases folds the export state recorded under alias IDs into the
+// conversation primaryID and records each alias for link resolution.
+// If primaryID has no state yet, the first alias's state (including its
+// Drive folder) is adopted. Daily docs and threads already present under
+// primaryID win over alias entries for the same date or thread, and an
+// alias's own Drive folder is queued in MergedFolders to be emptied into
+// the primary's. Each alias's sync cursor is kept apart in SourceCursors:
+// the IDs are different channels, so one's newest message says nothing
+// about what has been exported from the other.
+//
+// Returns the alias IDs that were merged.
+func (idx *ExportIndex) MergeAliases(primaryID string, aliases []string) []string {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	var merged []string
+	for _, alias := range aliases {
+		if alias == primaryID {
+			continue
+		}
+		src, ok := idx.Conversations[alias]
+		if !ok {
+			continue
+		}
+
+		dst, ok := idx.Conversations[primaryID]
+		if !ok {
+			src.mu.Lock()
+			src.ID = primaryID
+			setSourceCursor(src, alias, src.LastMessageTS)
+			src.LastMessageTS = ""
+			src.CompletedDays = nil
+			src.mu.Unlock()
+			idx.Conversations[primaryID] = src
+		} else {
+			mergeConversationExport(dst, src, alias)
+		}
+		delete(idx.Conversations, alias)
+
+		if idx.Aliases == nil {
+			idx.Aliases = make(map[string]string)
+		}
+		idx.Aliases[alias] = primaryID
+		merged = append(merged, alias)
+	}
+	return merged
+}
+
+// setSourceCursor records ts as the newest message exported from source,
+// never moving the cursor back. The caller holds conv.mu.
+func setSourceCursor(conv *ConversationExport, source, ts string) {
+	if ts == "" || ts <= conv.SourceCursors[source] {
+		return
+	}
+	if conv.SourceCursors == nil {
+		conv.SourceCursors = make(map[string]string)
+	}
+	conv.SourceCursors[source] = ts
+}
+
+// mergeConversationExport copies src, the state exported under alias, into
+// dst: its docs, threads, counters, and sync cursors.
+func mergeConversationExport(dst, src *ConversationExport, alias string) {
+	dst.mu.Lock()
+	defer dst.mu.Unlock()
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	if dst.DailyDocs == nil {
+		dst.DailyDocs = make(map[string]*DocExport)
+	}
+	for date, doc := range src.DailyDocs {
+		if _, exists := dst.DailyDocs[date]; !exists {
+			dst.DailyDocs[date] = doc
+		}
+	}
+	if dst.Threads == nil {
+		dst.Threads = make(map[string]*ThreadExport)
+	}
+	for ts, thread := range src.Threads {
+		if _, exists := dst.Threads[ts]; !exists {
+			dst.Threads[ts] = thread
+		}
+	}
+	for fileID, canvas := range src.Canvases {
+		if _, exists := dst.Canvases[fileID]; !exists {
+			if dst.Canvases == nil {
+				dst.Canvases = make(map[string]*CanvasExport)
+			}
+			dst.Canvases[fileID] = canvas
+		}
+	}
+	for fileID, driveID := range src.Files {
+		if _, exists := dst.Files[fileID]; !exists {
+			if dst.Files == nil {
+				dst.Files = make(map[string]string)
+			}
+			dst.Files[fileID] = driveID
+		}
+	}
+
+	if dst.FolderID == "" {
+		dst.FolderID = src.FolderID
+		dst.FolderURL = src.FolderURL
+		dst.ThreadsFolderID = src.ThreadsFolderID
+		dst.FilesFolderID = src.FilesFolderID
+		dst.DateFolders = src.DateFolders
+		dst.ThreadDateFolders = src.ThreadDateFolders
+		dst.FolderItems = src.FolderItems
+	} else if src.FolderID != "" && src.FolderID != dst.FolderID {
+		dst.MergedFolders = append(dst.MergedFolders, src.FolderID)
+	}
+	dst.MergedFolders = append(dst.MergedFolders, src.MergedFolders...)
+	setSourceCursor(dst, alias, src.LastMessageTS)
+	for source, ts := range src.SourceCursors {
+		setSourceCursor(dst, source, ts)
+	}
+	if src.FirstMessageTS != "" && (dst.FirstMessageTS == "" || src.FirstMessageTS < dst.FirstMessageTS) {
+		dst.FirstMessageTS = src.FirstMessageTS
+	}
+	if dst.Status == "" || dst.Status == StatusPending {
+		dst.Status, dst.Error, dst.SharedWith = src.Status, src.Error, src.SharedWith
+	}
+	dst.MessageCount += src.MessageCount
+	if src.LastUpdated.After(dst.LastUpdated) {
+		dst.LastUpdated = src.LastUpdated
+	}
+}
+
+// resolveConversationLocked returns the conversation for id, following
+// aliases. Caller must hold idx.mu.
+func (idx *ExportIndex) resolveConversationLocked(id string) (*ConversationExport, bool) {
+	if conv, ok := idx.Conversations[id]; ok {
+		return conv, true
+	}
+	if primary, ok := idx.Aliases[id]; ok {
+		conv, ok := idx.Conversations[primary]
+		return conv, ok
+	}
+	return nil, false
+}
+
 // GetUser returns cached user info.
 func (idx *ExportIndex) GetUser(id string) *UserCache {
 	idx.mu.RLock()
@@ -250,7 +682,16 @@ func (idx *ExportIndex) SetUser(user *UserCache) {
 	idx.Users[user.ID] = user
 }
 
-// GetDailyDoc returns the doc for a specific date in a conversation.
+// SetSelfUserID records the Slack user ID of the exporting account.
+func (idx *ExportIndex) SetSelfUserID(id string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.SelfUserID = id
+}
+
+// GetDailyDoc returns the doc holding a specific date in a conversation,
+// which covers more than the day when the conversation has a coarser
+// granularity.
 func (idx *ExportIndex) GetDailyDoc(convID, date string) *DocExport {
 	idx.mu.RLock()
 	defer idx.mu.RUnlock()
@@ -259,10 +700,33 @@ func (idx *ExportIndex) GetDailyDoc(convID, date string) *DocExport {
 	if !ok {
 		return nil
 	}
-	return conv.DailyDocs[date]
+	return conv.dailyDoc(date)
+}
+
+// MultiDayDocs reports whether the doc of a conversation holding date
+// holds more than one day (see config.DocGranularity).
+func (idx *ExportIndex) MultiDayDocs(convID, date string) bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	conv, ok := idx.Conversations[convID]
+	return ok && conv.multiDay(date)
+}
+
+// docPeriod returns the period of a conversation's docs holding date.
+func (idx *ExportIndex) docPeriod(convID, date string) docPeriod {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var g config.DocGranularity
+	if conv, ok := idx.Conversations[convID]; ok {
+		g = conv.granularity()
+	}
+	return docPeriodOf(g, date)
 }
 
-// SetDailyDoc sets the doc for a specific date in a conversation.
+// SetDailyDoc sets the doc for the period holding a specific date in a
+// conversation.
 func (idx *ExportIndex) SetDailyDoc(convID, date string, doc *DocExport) {
 	idx.mu.Lock()
 	defer idx.mu.Unlock()
@@ -274,7 +738,7 @@ func (idx *ExportIndex) SetDailyDoc(convID, date string, doc *DocExport) {
 	if conv.DailyDocs == nil {
 		conv.DailyDocs = make(map[string]*DocExport)
 	}
-	conv.DailyDocs[date] = doc
+	conv.DailyDocs[docPeriodOf(conv.granularity(), date).key] = doc
 }
 
 // GetThread returns thread export info.
@@ -304,20 +768,90 @@ func (idx *ExportIndex) SetThread(convID string, thread *ThreadExport) {
 	conv.Threads[thread.ThreadTS] = thread
 }
 
+// folderName returns the name of the conversation's Drive folder: the
+// recorded one, or the one its name and type give. The caller must hold
+// c.mu.
+func (c *ConversationExport) folderName() string {
+	if c.FolderName != "" {
+		return c.FolderName
+	}
+	return ConversationFolderName(c.Type, c.Name)
+}
+
+// uniqueConversationFolderName returns name, or, when the Drive folder of
+// another conversation already has that name, name followed by the end of
+// convID, or failing that all of it. Two DMs with people sharing a display
+// name would otherwise share, and merge into, one "DM - John Smith" folder.
+func (idx *ExportIndex) uniqueConversationFolderName(convID, name string) string {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	used := make(map[string]bool)
+	for id, conv := range idx.Conversations {
+		if id == convID {
+			continue
+		}
+		conv.mu.Lock()
+		if conv.FolderID != "" {
+			used[conv.folderName()] = true
+		}
+		conv.mu.Unlock()
+	}
+	candidates := []string{name, fmt.Sprintf("%s (%s)", name, shortConversationID(convID))}
+	for _, candidate := range candidates {
+		if !used[candidate] {
+			return candidate
+		}
+	}
+	return fmt.Sprintf("%s (%s)", name, convID)
+}
+
+// shortConversationID returns the end of a Slack conversation ID, enough
+// to tell conversations sharing a name apart.
+func shortConversationID(id string) string {
+	if len(id) > 5 {
+		return id[len(id)-5:]
+	}
+	return id
+}
+
+// uniqueThreadName returns name, or name followed by the first free
+// numeric suffix, formatted with suffix (e.g. " (%d)"), when another thread
+// of convID already uses it in the field read by field.
+func (idx *ExportIndex) uniqueThreadName(convID, threadTS, name, suffix string, field func(*ThreadExport) string) string {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	conv, ok := idx.Conversations[convID]
+	if !ok {
+		return name
+	}
+	used := make(map[string]bool)
+	for ts, thread := range conv.Threads {
+		if ts != threadTS {
+			used[field(thread)] = true
+		}
+	}
+	unique := name
+	for n := 2; used[unique]; n++ {
+		unique = name + fmt.Sprintf(suffix, n)
+	}
+	return unique
+}
+
 // LookupDocURL finds the Google Docs URL for a Slack message.
 // Used for replacing Slack links with Google Docs links.
 func (idx *ExportIndex) LookupDocURL(convID, messageTS string) string {
 	idx.mu.RLock()
 	defer idx.mu.RUnlock()
 
-	conv, ok := idx.Conversations[convID]
+	conv, ok := idx.resolveConversationLocked(convID)
 	if !ok {
 		return ""
 	}
 
-	// Convert timestamp to date and look up daily doc
-	date := tsToDate(messageTS)
-	if doc, ok := conv.DailyDocs[date]; ok {
+	// Convert timestamp to date and look up the doc holding it
+	if doc := conv.dailyDoc(tsToDate(messageTS)); doc != nil {
 		return doc.DocURL
 	}
 
@@ -329,7 +863,7 @@ func (idx *ExportIndex) LookupThreadURL(convID, threadTS string) string {
 	idx.mu.RLock()
 	defer idx.mu.RUnlock()
 
-	conv, ok := idx.Conversations[convID]
+	conv, ok := idx.resolveConversationLocked(convID)
 	if !ok {
 		return ""
 	}
@@ -341,29 +875,39 @@ func (idx *ExportIndex) LookupThreadURL(convID, threadTS string) string {
 	return ""
 }
 
+// LookupCanvasURL finds the Google Docs URL of an exported canvas or post.
+func (idx *ExportIndex) LookupCanvasURL(convID, fileID string) string {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	conv, ok := idx.resolveConversationLocked(convID)
+	if !ok {
+		return ""
+	}
+
+	conv.mu.Lock()
+	defer conv.mu.Unlock()
+	if canvas, ok := conv.Canvases[fileID]; ok {
+		return canvas.DocURL
+	}
+	return ""
+}
+
 // LookupConversationURL finds the Google Drive folder URL for a conversation.
 func (idx *ExportIndex) LookupConversationURL(convID string) string {
 	idx.mu.RLock()
 	defer idx.mu.RUnlock()
 
-	if conv, ok := idx.Conversations[convID]; ok {
+	if conv, ok := idx.resolveConversationLocked(convID); ok {
 		return conv.FolderURL
 	}
 	return ""
 }
 
-// tsToDate converts a Slack timestamp to a date string (YYYY-MM-DD).
+// tsToDate converts a Slack timestamp to a date string (YYYY-MM-DD) in the
+// export's time zone.
 func tsToDate(ts string) string {
-	// Parse the Unix timestamp part (before the dot)
-	var sec int64
-	for i := 0; i < len(ts); i++ {
-		if ts[i] == '.' {
-			break
-		}
-		sec = sec*10 + int64(ts[i]-'0')
-	}
-	t := time.Unix(sec, 0)
-	return t.Format("2006-01-02")
+	return parser.TimestampDate(ts)
 }
 
 // DefaultIndexPath returns the default path for the export index.
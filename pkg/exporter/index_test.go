@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/jflowers/get-out/pkg/migrate"
 )
 
 func TestNewExportIndex(t *testing.T) {
@@ -275,3 +277,224 @@ func TestExportIndex_LoadNonExistent(t *testing.T) {
 		t.Errorf("New index should have 0 conversations, got %d", len(idx.Conversations))
 	}
 }
+
+func TestExportIndex_SaveKeepsBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.json")
+	idx := NewExportIndex(path)
+	idx.RootFolderID = "first"
+	if err := idx.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(IndexBackupPath(path)); !os.IsNotExist(err) {
+		t.Errorf("backup after the first save: %v, want none", err)
+	}
+
+	idx.RootFolderID = "second"
+	if err := idx.Save(); err != nil {
+		t.Fatal(err)
+	}
+	backup, err := LoadExportIndex(IndexBackupPath(path))
+	if err != nil || backup.RootFolderID != "first" {
+		t.Errorf("backup = %+v, %v, want the first save", backup, err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temp file left after save: %v", err)
+	}
+}
+
+func TestExportIndex_LoadRecoversFromBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.json")
+	idx := NewExportIndex(path)
+	idx.GetOrCreateConversation("C001", "general", "channel")
+	for range 2 {
+		if err := idx.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A save torn by a crash on a filesystem that reordered the writes.
+	data, _ := os.ReadFile(path)
+	if err := os.WriteFile(path, data[:len(data)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := LoadExportIndex(path)
+	if err != nil {
+		t.Fatalf("LoadExportIndex() error: %v", err)
+	}
+	if idx.Recovered() == nil || idx.GetConversation("C001") == nil {
+		t.Fatalf("Recovered() = %v, conversations %d; want the backup loaded", idx.Recovered(), len(idx.Conversations))
+	}
+
+	// Saving the recovered index keeps the good backup.
+	if err := idx.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if idx.Recovered() != nil {
+		t.Errorf("Recovered() = %v after saving, want nil", idx.Recovered())
+	}
+	for _, p := range []string{path, IndexBackupPath(path)} {
+		loaded, err := LoadExportIndex(p)
+		if err != nil || loaded.Recovered() != nil || loaded.GetConversation("C001") == nil {
+			t.Errorf("%s = %v, %v after saving the recovered index, want it intact", filepath.Base(p), loaded, err)
+		}
+	}
+
+	// Without a usable backup the damage is reported.
+	if err := os.WriteFile(path, []byte(`{"conversations": `), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(IndexBackupPath(path)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadExportIndex(path); err == nil {
+		t.Error("LoadExportIndex() of a damaged index without a backup should fail")
+	}
+}
+
+func TestExportIndex_Version(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.json")
+
+	// An index written before versioning loads as version 1 and is saved
+	// with its version.
+	if err := os.WriteFile(path, []byte(`{"root_folder_id": "root"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := LoadExportIndex(path)
+	if err != nil {
+		t.Fatalf("LoadExportIndex() error: %v", err)
+	}
+	if idx.RootFolderID != "root" || idx.Version != IndexMigrations.Current() {
+		t.Errorf("index = root %q version %d", idx.RootFolderID, idx.Version)
+	}
+	if err := idx.Save(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if migrate.Version(data) != IndexMigrations.Current() {
+		t.Errorf("saved index has version %d", migrate.Version(data))
+	}
+
+	// An index from a newer release is refused rather than re-saved
+	// without the fields this release does not know.
+	if err := os.WriteFile(path, []byte(`{"version": 99}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadExportIndex(path); err == nil {
+		t.Error("LoadExportIndex() of a newer index should fail")
+	}
+}
+
+func TestExportIndex_MergeAliases_AdoptsAlias(t *testing.T) {
+	idx := NewExportIndex("")
+	old := idx.GetOrCreateConversation("D111", "alice", "dm")
+	old.FolderID = "folder_old"
+	old.FolderURL = "https://drive.google.com/drive/folders/folder_old"
+	old.LastMessageTS = "1700000000.000100"
+	old.MessageCount = 7
+	idx.SetDailyDoc("D111", "2023-11-14", &DocExport{DocID: "doc_old", DocURL: "https://docs.google.com/document/d/doc_old"})
+
+	merged := idx.MergeAliases("G222", []string{"D111", "D999"})
+	if len(merged) != 1 || merged[0] != "D111" {
+		t.Fatalf("merged = %v, want [D111]", merged)
+	}
+
+	if idx.GetConversation("D111") != nil {
+		t.Error("alias entry should be removed")
+	}
+	conv := idx.GetConversation("G222")
+	if conv == nil {
+		t.Fatal("primary entry should exist")
+	}
+	if conv.ID != "G222" || conv.FolderID != "folder_old" || conv.MessageCount != 7 {
+		t.Errorf("adopted conversation = %+v", conv)
+	}
+	// The old ID's cursor is its own; G222 has exported nothing yet.
+	if conv.LastMessageTS != "" || conv.SourceCursors["D111"] != "1700000000.000100" {
+		t.Errorf("LastMessageTS = %q, SourceCursors = %v; want the cursor kept under D111", conv.LastMessageTS, conv.SourceCursors)
+	}
+	if idx.GetDailyDoc("G222", "2023-11-14") == nil {
+		t.Error("daily docs should move with the adopted entry")
+	}
+
+	// Links to the old ID still resolve.
+	if got := idx.LookupConversationURL("D111"); got != conv.FolderURL {
+		t.Errorf("LookupConversationURL(alias) = %q, want %q", got, conv.FolderURL)
+	}
+	if got := idx.LookupDocURL("D111", "1700000000.000100"); got != "https://docs.google.com/document/d/doc_old" {
+		t.Errorf("LookupDocURL(alias) = %q", got)
+	}
+
+	// Merging again is a no-op.
+	if again := idx.MergeAliases("G222", []string{"D111"}); len(again) != 0 {
+		t.Errorf("second merge = %v, want none", again)
+	}
+}
+
+func TestExportIndex_MergeAliases_CombinesWithPrimary(t *testing.T) {
+	idx := NewExportIndex("")
+
+	primary := idx.GetOrCreateConversation("G222", "alice-bob", "mpim")
+	primary.FolderID = "folder_new"
+	primary.LastMessageTS = "1700000000.000100"
+	primary.MessageCount = 3
+	idx.SetDailyDoc("G222", "2024-01-02", &DocExport{DocID: "doc_new_shared"})
+	idx.SetThread("G222", &ThreadExport{ThreadTS: "1.1", FolderID: "thread_new"})
+
+	old := idx.GetOrCreateConversation("D111", "alice", "dm")
+	old.FolderID = "folder_old"
+	old.LastMessageTS = "1710000000.000100"
+	old.MessageCount = 5
+	idx.SetDailyDoc("D111", "2024-01-01", &DocExport{DocID: "doc_old_only"})
+	idx.SetDailyDoc("D111", "2024-01-02", &DocExport{DocID: "doc_old_shared"})
+	idx.SetThread("D111", &ThreadExport{ThreadTS: "1.1", FolderID: "thread_old"})
+	idx.SetThread("D111", &ThreadExport{ThreadTS: "2.2", FolderID: "thread_old_only"})
+
+	idx.MergeAliases("G222", []string{"D111"})
+
+	conv := idx.GetConversation("G222")
+	if conv.FolderID != "folder_new" {
+		t.Errorf("FolderID = %q, primary folder should win", conv.FolderID)
+	}
+	if conv.LastMessageTS != "1700000000.000100" || conv.SourceCursors["D111"] != "1710000000.000100" {
+		t.Errorf("LastMessageTS = %q, SourceCursors = %v; want a cursor per ID", conv.LastMessageTS, conv.SourceCursors)
+	}
+	if len(conv.MergedFolders) != 1 || conv.MergedFolders[0] != "folder_old" {
+		t.Errorf("MergedFolders = %v, want the alias folder queued for merging", conv.MergedFolders)
+	}
+	if conv.MessageCount != 8 {
+		t.Errorf("MessageCount = %d, want 8", conv.MessageCount)
+	}
+	if got := idx.GetDailyDoc("G222", "2024-01-01"); got == nil || got.DocID != "doc_old_only" {
+		t.Errorf("alias-only daily doc not merged: %+v", got)
+	}
+	if got := idx.GetDailyDoc("G222", "2024-01-02"); got.DocID != "doc_new_shared" {
+		t.Errorf("shared date DocID = %q, primary should win", got.DocID)
+	}
+	if got := idx.GetThread("G222", "1.1"); got.FolderID != "thread_new" {
+		t.Errorf("shared thread FolderID = %q, primary should win", got.FolderID)
+	}
+	if idx.GetThread("G222", "2.2") == nil {
+		t.Error("alias-only thread not merged")
+	}
+}
+
+func TestExportIndex_MergeAliases_PersistsAliasMap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export-index.json")
+	idx := NewExportIndex(path)
+	idx.GetOrCreateConversation("D111", "alice", "dm").FolderURL = "https://drive.google.com/drive/folders/x"
+	idx.MergeAliases("G222", []string{"D111"})
+	if err := idx.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadExportIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Aliases["D111"] != "G222" {
+		t.Errorf("Aliases = %v, want D111 -> G222", loaded.Aliases)
+	}
+	if loaded.LookupConversationURL("D111") != "https://drive.google.com/drive/folders/x" {
+		t.Error("alias lookup should survive a reload")
+	}
+}
@@ -0,0 +1,181 @@
+package exporter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// minJournalCompactBytes is the journal size below which SaveConversation
+// never compacts, so a small index is not rewritten on every checkpoint.
+const minJournalCompactBytes = 1 << 20
+
+// ErrIndexChanged is returned by SaveConversation when another process
+// has saved the index file since this index loaded or saved it. The file's
+// journal generation has moved on, so a checkpoint appended now would be
+// ignored when the index is next loaded.
+var ErrIndexChanged = errors.New("export index was saved by another process")
+
+// journalEntry is one checkpoint line: the full state of one conversation
+// plus the index's small top-level fields.
+type journalEntry struct {
+	Generation    int                 `json:"generation"`
+	RootFolderID  string              `json:"root_folder_id,omitempty"`
+	RootFolderURL string              `json:"root_folder_url,omitempty"`
+	SelfUserID    string              `json:"self_user_id,omitempty"`
+	Conversation  *ConversationExport `json:"conversation"`
+}
+
+// journalPath returns the checkpoint journal kept next to the index at
+// path, e.g. export-index.journal.jsonl for export-index.json.
+func journalPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".journal.jsonl"
+}
+
+// SaveConversation checkpoints one conversation by appending its state to
+// the index journal, instead of rewriting the whole index as Save does.
+// The journal is replayed by LoadExportIndex. Once the journal outgrows
+// the index file, the index is rewritten and the journal cleared, so the
+// cost of checkpointing stays proportional to what changed.
+func (idx *ExportIndex) SaveConversation(id string) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	conv, ok := idx.Conversations[id]
+	if !ok {
+		return nil
+	}
+	if err := idx.checkFileGeneration(); err != nil {
+		return err
+	}
+	if idx.journalBytes >= max(idx.fileBytes, minJournalCompactBytes) {
+		return idx.saveLocked()
+	}
+
+	conv.mu.Lock()
+	data, err := json.Marshal(journalEntry{
+		Generation:    idx.JournalGeneration,
+		RootFolderID:  idx.RootFolderID,
+		RootFolderURL: idx.RootFolderURL,
+		SelfUserID:    idx.SelfUserID,
+		Conversation:  conv,
+	})
+	conv.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+	if idx.journalTorn {
+		// End the partial line a crash left, so this entry starts fresh.
+		data = append([]byte{'\n'}, data...)
+	}
+	data = append(data, '\n')
+
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	f, err := os.OpenFile(journalPath(idx.path), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open index journal: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write index journal: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write index journal: %w", err)
+	}
+	idx.journalTorn = false
+	idx.journalBytes += int64(len(data))
+	idx.UpdatedAt = time.Now()
+	return nil
+}
+
+// checkFileGeneration returns an error wrapping ErrIndexChanged when the
+// index file on disk is not the one this index last loaded or saved and
+// belongs to another journal generation. The file is only read when it
+// has been replaced or modified. The caller holds idx.mu.
+func (idx *ExportIndex) checkFileGeneration() error {
+	info, err := os.Stat(idx.path)
+	switch {
+	case os.IsNotExist(err):
+		if idx.fileInfo != nil {
+			return fmt.Errorf("%w: %s was removed", ErrIndexChanged, idx.path)
+		}
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to check export index: %w", err)
+	case idx.fileInfo != nil && os.SameFile(info, idx.fileInfo) && info.ModTime().Equal(idx.fileInfo.ModTime()):
+		return nil
+	}
+
+	data, err := os.ReadFile(idx.path)
+	if err != nil {
+		return fmt.Errorf("failed to check export index: %w", err)
+	}
+	var onDisk struct {
+		JournalGeneration int `json:"journal_generation"`
+	}
+	if err := json.Unmarshal(data, &onDisk); err != nil {
+		return fmt.Errorf("%w: %s cannot be read: %v", ErrIndexChanged, idx.path, err)
+	}
+	if onDisk.JournalGeneration != idx.JournalGeneration {
+		return fmt.Errorf("%w: %s is at journal generation %d, this run at %d; its checkpoints would be lost",
+			ErrIndexChanged, idx.path, onDisk.JournalGeneration, idx.JournalGeneration)
+	}
+	idx.fileInfo = info
+	return nil
+}
+
+// replayJournal applies the checkpoints written since the index file was
+// last saved. Entries from an older generation (a journal that a crash
+// kept from being cleared) and a partial last line are ignored.
+func (idx *ExportIndex) replayJournal() error {
+	f, err := os.Open(journalPath(idx.path))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read index journal: %w", err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			idx.journalTorn = len(line) > 0
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read index journal: %w", err)
+		}
+		idx.journalBytes += int64(len(line))
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var entry journalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			// The tail of an interrupted append; later lines are intact.
+			continue
+		}
+		if entry.Generation != idx.JournalGeneration || entry.Conversation == nil {
+			continue
+		}
+		idx.Conversations[entry.Conversation.ID] = entry.Conversation
+		if entry.RootFolderID != "" {
+			idx.RootFolderID = entry.RootFolderID
+			idx.RootFolderURL = entry.RootFolderURL
+		}
+		if entry.SelfUserID != "" {
+			idx.SelfUserID = entry.SelfUserID
+		}
+	}
+}
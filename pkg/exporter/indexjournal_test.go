@@ -0,0 +1,154 @@
+package exporter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportIndex_SaveConversationJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export-index.json")
+	idx := NewExportIndex(path)
+	idx.GetOrCreateConversation("C1", "general", "channel")
+	if err := idx.Save(); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := os.ReadFile(path)
+
+	conv := idx.GetOrCreateConversation("C1", "general", "channel")
+	conv.MessageCount = 5
+	if err := idx.SaveConversation("C1"); err != nil {
+		t.Fatalf("SaveConversation() error: %v", err)
+	}
+	idx.GetOrCreateConversation("C2", "random", "channel").Status = "complete"
+	if err := idx.SaveConversation("C2"); err != nil {
+		t.Fatalf("SaveConversation() error: %v", err)
+	}
+
+	if after, _ := os.ReadFile(path); string(after) != string(before) {
+		t.Error("SaveConversation rewrote the index file")
+	}
+	if _, err := os.Stat(journalPath(path)); err != nil {
+		t.Fatalf("journal not written: %v", err)
+	}
+
+	loaded, err := LoadExportIndex(path)
+	if err != nil {
+		t.Fatalf("LoadExportIndex() error: %v", err)
+	}
+	if c := loaded.GetConversation("C1"); c == nil || c.MessageCount != 5 {
+		t.Errorf("C1 after replay = %+v, want MessageCount 5", c)
+	}
+	if c := loaded.GetConversation("C2"); c == nil || c.Status != "complete" {
+		t.Errorf("C2 after replay = %+v, want complete", c)
+	}
+
+	// A full save folds the journal into the file and clears it.
+	if err := loaded.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(journalPath(path)); !os.IsNotExist(err) {
+		t.Errorf("journal still present after Save: %v", err)
+	}
+	if reloaded, err := LoadExportIndex(path); err != nil || reloaded.GetConversation("C2") == nil {
+		t.Errorf("C2 lost after Save: %v", err)
+	}
+}
+
+func TestExportIndex_JournalIgnoresStaleGenerationAndTornLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export-index.json")
+	idx := NewExportIndex(path)
+	idx.GetOrCreateConversation("C1", "general", "channel").MessageCount = 1
+	if err := idx.SaveConversation("C1"); err != nil {
+		t.Fatal(err)
+	}
+	stale, err := os.ReadFile(journalPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A crash after the index was written but before the journal was
+	// cleared leaves a journal from the previous generation.
+	idx.GetOrCreateConversation("C1", "general", "channel").MessageCount = 2
+	if err := idx.Save(); err != nil {
+		t.Fatal(err)
+	}
+	torn := string(stale) + `{"generation":`
+	if err := os.WriteFile(journalPath(path), []byte(torn), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadExportIndex(path)
+	if err != nil {
+		t.Fatalf("LoadExportIndex() error: %v", err)
+	}
+	if got := loaded.GetConversation("C1").MessageCount; got != 2 {
+		t.Errorf("MessageCount = %d, want 2 (stale checkpoint ignored)", got)
+	}
+
+	// The next checkpoint starts on a new line after the torn one.
+	loaded.GetConversation("C1").MessageCount = 3
+	if err := loaded.SaveConversation("C1"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(journalPath(path))
+	if !strings.Contains(string(data), "{\"generation\":\n{") {
+		t.Errorf("checkpoint appended to the torn line:\n%s", data)
+	}
+	if reloaded, err := LoadExportIndex(path); err != nil || reloaded.GetConversation("C1").MessageCount != 3 {
+		t.Errorf("checkpoint after torn line not replayed: %v", err)
+	}
+}
+
+func TestExportIndex_SaveConversationCompacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export-index.json")
+	idx := NewExportIndex(path)
+	idx.GetOrCreateConversation("C1", "general", "channel")
+	idx.journalBytes = minJournalCompactBytes
+
+	if err := idx.SaveConversation("C1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("index file not written on compaction: %v", err)
+	}
+	if _, err := os.Stat(journalPath(path)); !os.IsNotExist(err) {
+		t.Errorf("journal present after compaction: %v", err)
+	}
+	if idx.journalBytes != 0 {
+		t.Errorf("journalBytes = %d after compaction, want 0", idx.journalBytes)
+	}
+}
+
+func TestExportIndex_SaveConversationDetectsConcurrentSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export-index.json")
+	idx := NewExportIndex(path)
+	idx.GetOrCreateConversation("C1", "general", "channel")
+	if err := idx.Save(); err != nil {
+		t.Fatal(err)
+	}
+	idx.GetOrCreateConversation("C1", "general", "channel").MessageCount = 5
+	if err := idx.SaveConversation("C1"); err != nil {
+		t.Fatalf("SaveConversation() error: %v", err)
+	}
+
+	// Another process loads the index and saves it whole, moving the
+	// journal to a new generation.
+	other, err := LoadExportIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	idx.GetOrCreateConversation("C1", "general", "channel").MessageCount = 42
+	if err := idx.SaveConversation("C1"); !errors.Is(err, ErrIndexChanged) {
+		t.Fatalf("SaveConversation() after another save = %v, want ErrIndexChanged", err)
+	}
+	if _, err := os.Stat(journalPath(path)); !os.IsNotExist(err) {
+		t.Errorf("checkpoint appended to a journal that would be ignored: %v", err)
+	}
+}
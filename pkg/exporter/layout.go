@@ -0,0 +1,118 @@
+package exporter
+
+import (
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/jflowers/get-out/pkg/config"
+)
+
+// The blocks a layout can define. Each replaces one part of the built-in
+// layout of a day file: its header, each message, what follows a thread's
+// parent, and its footer.
+const (
+	layoutHeader  = "header"
+	layoutMessage = "message"
+	layoutThread  = "thread"
+	layoutFooter  = "footer"
+)
+
+var layoutBlocks = []string{layoutHeader, layoutMessage, layoutThread, layoutFooter}
+
+// Layout is a parsed layout of the markdown and html formats (see
+// config.TemplateConfig), selected by a conversation's Template.
+type Layout struct {
+	name string
+
+	// markdown holds the layout's markdown blocks, nil when it has none.
+	markdown *texttemplate.Template
+
+	// html is the html pages with the layout's blocks in place of the
+	// built-in ones, nil when it has none.
+	html *template.Template
+}
+
+// markdownLayoutFuncs are the functions available to markdown layouts.
+var markdownLayoutFuncs = texttemplate.FuncMap{
+	"join": strings.Join,
+	"yaml": yamlString,
+}
+
+// ParseLayouts parses the layouts in templates by name. A layout that
+// fails to parse, or defines none of the blocks, is an error.
+func ParseLayouts(templates map[string]*config.TemplateConfig) (map[string]*Layout, error) {
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	layouts := make(map[string]*Layout, len(templates))
+	for _, name := range names {
+		t := templates[name]
+		layout := &Layout{name: name}
+		if t.Markdown != "" {
+			md, err := texttemplate.New(name).Funcs(markdownLayoutFuncs).Parse(t.Markdown)
+			if err != nil {
+				return nil, fmt.Errorf("template %s: invalid markdown: %w", name, err)
+			}
+			if !definesBlock(md.Lookup) {
+				return nil, fmt.Errorf("template %s: markdown defines none of the blocks %s", name, strings.Join(layoutBlocks, ", "))
+			}
+			layout.markdown = md
+		}
+		if t.HTML != "" {
+			pages, err := template.New("html").Parse(htmlPagesSource)
+			if err != nil {
+				return nil, err
+			}
+			// Blocks defined again replace the built-in ones.
+			custom, err := template.New(name).Parse(t.HTML)
+			if err != nil {
+				return nil, fmt.Errorf("template %s: invalid html: %w", name, err)
+			}
+			if !definesBlock(custom.Lookup) {
+				return nil, fmt.Errorf("template %s: html defines none of the blocks %s", name, strings.Join(layoutBlocks, ", "))
+			}
+			if _, err := pages.Parse(t.HTML); err != nil {
+				return nil, fmt.Errorf("template %s: invalid html: %w", name, err)
+			}
+			layout.html = pages
+		}
+		layouts[name] = layout
+	}
+	return layouts, nil
+}
+
+// definesBlock reports whether a template set, given by its Lookup,
+// defines one of the layout blocks.
+func definesBlock[T comparable](lookup func(string) T) bool {
+	var none T
+	for _, block := range layoutBlocks {
+		if lookup(block) != none {
+			return true
+		}
+	}
+	return false
+}
+
+// layout returns the layout conv selects, or nil for the built-in one.
+func (e *Exporter) layout(conv config.ConversationConfig) *Layout {
+	return e.layouts[conv.Template]
+}
+
+// htmlDayPages returns the pages a day of conv is rendered with.
+func (e *Exporter) htmlDayPages(conv config.ConversationConfig) *template.Template {
+	if l := e.layout(conv); l != nil && l.html != nil {
+		return l.html
+	}
+	return htmlPages
+}
+
+// markdownWriter returns the markdown writer for conv's layout.
+func (e *Exporter) markdownWriter(conv config.ConversationConfig) *MarkdownWriter {
+	return e.mdWriter.WithLayout(e.layout(conv))
+}
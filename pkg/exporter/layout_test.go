@@ -0,0 +1,174 @@
+package exporter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+func TestParseLayouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		tmpl    config.TemplateConfig
+		wantErr string
+	}{
+		{name: "markdown", tmpl: config.TemplateConfig{Markdown: `{{define "header"}}# {{.Conversation}}{{end}}`}},
+		{name: "html", tmpl: config.TemplateConfig{HTML: `{{define "footer"}}<footer></footer>{{end}}`}},
+		{name: "invalid markdown", tmpl: config.TemplateConfig{Markdown: `{{define "header"}}{{.Conversation}`}, wantErr: "invalid markdown"},
+		{name: "invalid html", tmpl: config.TemplateConfig{HTML: `{{define "message"}}{{end}`}, wantErr: "invalid html"},
+		{name: "no blocks", tmpl: config.TemplateConfig{Markdown: `# {{.Conversation}}`}, wantErr: "defines none of the blocks"},
+		{name: "unknown block only", tmpl: config.TemplateConfig{HTML: `{{define "sidebar"}}{{end}}`}, wantErr: "defines none of the blocks"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := tt.tmpl
+			layouts, err := ParseLayouts(map[string]*config.TemplateConfig{"custom": &tmpl})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "custom") {
+					t.Fatalf("ParseLayouts() error = %v, want %q naming the template", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseLayouts() error: %v", err)
+			}
+			l := layouts["custom"]
+			if l == nil || (l.markdown != nil) != (tmpl.Markdown != "") || (l.html != nil) != (tmpl.HTML != "") {
+				t.Errorf("layout = %+v, want the formats it defines", l)
+			}
+		})
+	}
+}
+
+func TestMarkdownWriter_WithLayout(t *testing.T) {
+	layouts, err := ParseLayouts(map[string]*config.TemplateConfig{"minutes": {Markdown: `
+{{- define "header"}}# {{.Conversation}} — {{.Date}}
+Attendees: {{join .Participants ", "}}
+
+{{end}}
+{{- define "message"}}- {{.Time}} {{.Sender}}: {{.Text}}
+{{end}}
+{{- define "thread"}}  ({{.ReplyCount}} replies)
+{{end}}
+{{- define "footer"}}{{.MessageCount}} messages{{end}}`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, _, _ := newTestMarkdownWriterWithResolvers()
+	msgs := []slackapi.Message{
+		{User: "U001", Text: "Good morning", TS: "1706788800.000100"},
+		{User: "U002", Text: "Plan", TS: "1706792400.000200", ThreadTS: "1706792400.000200", ReplyCount: 2},
+	}
+
+	got, err := w.WithLayout(layouts["minutes"]).RenderDailyDoc("C001", "standup", "channel", "2024-02-01", msgs, nil)
+	if err != nil {
+		t.Fatalf("RenderDailyDoc() error: %v", err)
+	}
+	want := "# standup — 2024-02-01\nAttendees: Alice, Bob\n\n" +
+		"- " + parser.FormatTimestamp(msgs[0].TS) + " Alice: Good morning\n" +
+		"- " + parser.FormatTimestamp(msgs[1].TS) + " Bob: Plan\n  (2 replies)\n" +
+		markdownFooterMarker + "2 messages"
+	if string(got) != want {
+		t.Errorf("RenderDailyDoc() =\n%s\nwant\n%s", got, want)
+	}
+
+	// The writer itself keeps the built-in layout.
+	builtin, err := w.RenderDailyDoc("C001", "standup", "channel", "2024-02-01", msgs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(builtin), "---\n") || strings.Contains(string(builtin), markdownFooterMarker) {
+		t.Errorf("built-in layout changed:\n%s", builtin)
+	}
+	if w.WithLayout(nil) != w {
+		t.Error("WithLayout(nil) should return the writer itself")
+	}
+}
+
+func TestMarkdownWriter_WithLayoutPartial(t *testing.T) {
+	layouts, err := ParseLayouts(map[string]*config.TemplateConfig{"quoted": {Markdown: `{{define "message"}}> {{.Default}}{{end}}`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := newTestMarkdownWriter()
+	msgs := []slackapi.Message{{User: "U001", Text: "hi", TS: "1706788800.000100"}}
+
+	got, err := w.WithLayout(layouts["quoted"]).RenderDailyDoc("C001", "general", "channel", "2024-02-01", msgs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	builtin, _ := w.RenderDailyDoc("C001", "general", "channel", "2024-02-01", msgs, nil)
+	header := string(builtin)[:strings.Index(string(builtin), "**")]
+	if !strings.HasPrefix(string(got), header+"> **") {
+		t.Errorf("got\n%s\nwant the built-in frontmatter and the message quoted", got)
+	}
+	if strings.Contains(string(got), markdownFooterMarker) {
+		t.Error("footer marker written without a footer block")
+	}
+}
+
+func TestMarkdownWriter_LayoutExecError(t *testing.T) {
+	layouts, err := ParseLayouts(map[string]*config.TemplateConfig{"broken": {Markdown: `{{define "message"}}{{.NoSuchField}}{{end}}`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs := []slackapi.Message{{User: "U001", Text: "hi", TS: "1706788800.000100"}}
+	if _, err := newTestMarkdownWriter().WithLayout(layouts["broken"]).RenderMessages(msgs); err == nil {
+		t.Error("RenderMessages() with a failing block: want an error")
+	}
+}
+
+func TestAppendMarkdownFile_BeforeFooter(t *testing.T) {
+	dir := t.TempDir()
+	existing := "# general\n- first\n" + markdownFooterMarker + "end\n"
+	if err := WriteMarkdownFile(dir, "channel-general", "2024-02-01", []byte(existing)); err != nil {
+		t.Fatal(err)
+	}
+	if err := AppendMarkdownFile(dir, "channel-general", "2024-02-01", nil, []byte("- second\n")); err != nil {
+		t.Fatalf("AppendMarkdownFile() error: %v", err)
+	}
+	got := readFile(t, filepath.Join(dir, "channel-general", "2024-02-01.md"))
+	if want := "# general\n- first\n- second\n" + markdownFooterMarker + "end\n"; got != want {
+		t.Errorf("file =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestExportConversation_HTMLLayout(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	conv.Format = config.OutputFormatHTML
+	conv.Template = "minutes"
+	exp, localDir := localFormatExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	layouts, err := ParseLayouts(map[string]*config.TemplateConfig{"minutes": {HTML: `
+{{- define "header"}}<h1>Minutes of {{.Conversation}}</h1>{{end}}
+{{- define "footer"}}<footer>{{len .Messages}} messages</footer>{{end}}`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp.layouts = layouts
+
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	dir := filepath.Join(localDir, SanitizeDirectoryName(string(conv.Type), conv.Name))
+	day := readFile(t, filepath.Join(dir, "2024-02-01.html"))
+	for _, want := range []string{"<h1>Minutes of general</h1>", "<footer>2 messages</footer>", "Good morning", "<summary>1 reply</summary>"} {
+		if !strings.Contains(day, want) {
+			t.Errorf("day page missing %q", want)
+		}
+	}
+	if strings.Contains(day, "All conversations") {
+		t.Error("day page kept the built-in header")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "index.html")); err != nil {
+		t.Errorf("conversation index: %v", err)
+	}
+	if index := readFile(t, filepath.Join(localDir, "index.html")); strings.Contains(index, "Minutes") {
+		t.Error("site index rendered with the conversation's layout")
+	}
+}
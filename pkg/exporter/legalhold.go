@@ -0,0 +1,464 @@
+package exporter
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/secrets"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// HoldEntry is one link in a conversation's legal-hold hash chain: a day's
+// messages (or a version of a canvas) written to a doc and, when local
+// export is on, the markdown file they were written to. Hash covers every other field,
+// including PrevHash, so changing or removing any earlier entry breaks the
+// chain.
+type HoldEntry struct {
+	Seq            int       `json:"seq"`
+	RecordedAt     time.Time `json:"recorded_at"`
+	ConversationID string    `json:"conversation_id"`
+	Date           string    `json:"date"`
+	ThreadTS       string    `json:"thread_ts,omitempty"`
+	CanvasID       string    `json:"canvas_id,omitempty"`
+	MessageCount   int       `json:"message_count"`
+	ContentSHA256  string    `json:"content_sha256"`        // the day's Slack messages as JSON
+	File           string    `json:"file,omitempty"`        // markdown file, relative to the local export dir
+	FileSHA256     string    `json:"file_sha256,omitempty"` // markdown file contents
+	PrevHash       string    `json:"prev_hash"`
+	Hash           string    `json:"hash"`
+}
+
+// computeHash returns the chain hash of the entry.
+func (h HoldEntry) computeHash() string {
+	h.Hash = ""
+	data, _ := json.Marshal(h)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// HoldLedger keeps the append-only legal-hold hash chain of each
+// conversation in <dir>/<conversationID>.jsonl. It is safe for concurrent
+// use.
+type HoldLedger struct {
+	dir string
+
+	mu    sync.Mutex
+	heads map[string]HoldEntry // last entry per conversation, loaded lazily
+}
+
+// NewHoldLedger creates a HoldLedger that writes into dir.
+func NewHoldLedger(dir string) *HoldLedger {
+	return &HoldLedger{dir: dir, heads: make(map[string]HoldEntry)}
+}
+
+// DefaultHoldDir returns the default legal-hold directory.
+func DefaultHoldDir(configDir string) string {
+	return filepath.Join(configDir, "_legalhold")
+}
+
+// HoldLedgerPath returns the chain file for a conversation ID.
+func HoldLedgerPath(dir, convID string) string {
+	return filepath.Join(dir, convID+".jsonl")
+}
+
+// Record hashes messages (and the markdown file, if one was written) and
+// appends the entry to the conversation's chain.
+func (l *HoldLedger) Record(convID, date, threadTS string, msgs []slackapi.Message, file string, fileContent []byte) (HoldEntry, error) {
+	entry, err := newHoldEntry(convID, date, threadTS, msgs, file, fileContent)
+	if err != nil {
+		return HoldEntry{}, err
+	}
+	return l.append(entry)
+}
+
+// RecordCanvas is Record for a version of canvas canvasID, whose content
+// is hashed as the single message canvas.
+func (l *HoldLedger) RecordCanvas(convID, canvasID, date string, canvas slackapi.Message, file string, fileContent []byte) (HoldEntry, error) {
+	entry, err := newHoldEntry(convID, date, "", []slackapi.Message{canvas}, file, fileContent)
+	if err != nil {
+		return HoldEntry{}, err
+	}
+	entry.CanvasID = canvasID
+	return l.append(entry)
+}
+
+// newHoldEntry returns the entry for msgs and file, not yet linked into a
+// chain.
+func newHoldEntry(convID, date, threadTS string, msgs []slackapi.Message, file string, fileContent []byte) (HoldEntry, error) {
+	sorted := make([]slackapi.Message, len(msgs))
+	copy(sorted, msgs)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].TS < sorted[j].TS })
+	content, err := json.Marshal(sorted)
+	if err != nil {
+		return HoldEntry{}, fmt.Errorf("failed to encode messages for %s: %w", date, err)
+	}
+
+	entry := HoldEntry{
+		RecordedAt:     time.Now().UTC(),
+		ConversationID: convID,
+		Date:           date,
+		ThreadTS:       threadTS,
+		MessageCount:   len(msgs),
+		ContentSHA256:  sha256Hex(content),
+		File:           filepath.ToSlash(file),
+	}
+	if file != "" {
+		entry.FileSHA256 = sha256Hex(fileContent)
+	}
+	return entry, nil
+}
+
+func (l *HoldLedger) append(entry HoldEntry) (HoldEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	head, ok := l.heads[entry.ConversationID]
+	if !ok {
+		entries, err := loadHoldEntries(HoldLedgerPath(l.dir, entry.ConversationID))
+		if err != nil {
+			return HoldEntry{}, err
+		}
+		if len(entries) > 0 {
+			head = entries[len(entries)-1]
+		}
+	}
+	entry.Seq = head.Seq + 1
+	entry.PrevHash = head.Hash
+	entry.Hash = entry.computeHash()
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return HoldEntry{}, fmt.Errorf("failed to encode hold entry: %w", err)
+	}
+	if err := os.MkdirAll(l.dir, 0700); err != nil {
+		return HoldEntry{}, fmt.Errorf("failed to create legal-hold directory: %w", err)
+	}
+	f, err := os.OpenFile(HoldLedgerPath(l.dir, entry.ConversationID), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return HoldEntry{}, fmt.Errorf("failed to open hold ledger: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return HoldEntry{}, fmt.Errorf("failed to write hold ledger: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return HoldEntry{}, fmt.Errorf("failed to write hold ledger: %w", err)
+	}
+	l.heads[entry.ConversationID] = entry
+	return entry, nil
+}
+
+// loadHoldEntries reads a chain file. A missing file is an empty chain.
+func loadHoldEntries(path string) ([]HoldEntry, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var entries []HoldEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var entry HoldEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// holdConversations returns the conversation IDs with a chain in dir.
+func holdConversations(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read legal-hold directory: %w", err)
+	}
+	var ids []string
+	for _, entry := range entries {
+		if name := entry.Name(); !entry.IsDir() && strings.HasSuffix(name, ".jsonl") {
+			ids = append(ids, strings.TrimSuffix(name, ".jsonl"))
+		}
+	}
+	return ids, nil
+}
+
+// HoldManifest records the head of every conversation's chain at the end of
+// a run. It is stored next to a detached ed25519 signature (.sig) and never
+// overwritten.
+type HoldManifest struct {
+	CreatedAt     time.Time          `json:"created_at"`
+	PublicKey     string             `json:"public_key"` // base64 ed25519 key that signed this manifest
+	Conversations []HoldManifestHead `json:"conversations"`
+}
+
+// HoldManifestHead is one conversation's chain head in a HoldManifest.
+type HoldManifestHead struct {
+	ConversationID string `json:"conversation_id"`
+	Entries        int    `json:"entries"`
+	HeadHash       string `json:"head_hash"`
+}
+
+// WriteHoldManifest signs the current chain heads in dir with key and writes
+// them to manifest-<timestamp>.json and .json.sig. It returns the manifest
+// path.
+func WriteHoldManifest(dir string, key ed25519.PrivateKey) (string, error) {
+	ids, err := holdConversations(dir)
+	if err != nil {
+		return "", err
+	}
+	manifest := HoldManifest{
+		CreatedAt: time.Now().UTC(),
+		PublicKey: base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+	}
+	for _, id := range ids {
+		entries, err := loadHoldEntries(HoldLedgerPath(dir, id))
+		if err != nil {
+			return "", err
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		manifest.Conversations = append(manifest.Conversations, HoldManifestHead{
+			ConversationID: id,
+			Entries:        len(entries),
+			HeadHash:       entries[len(entries)-1].Hash,
+		})
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode hold manifest: %w", err)
+	}
+	path := filepath.Join(dir, "manifest-"+manifest.CreatedAt.Format("20060102-150405.000000000")+".json")
+	if err := writeNewFile(path, data); err != nil {
+		return "", err
+	}
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(key, data))
+	if err := writeNewFile(path+".sig", []byte(sig+"\n")); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// writeNewFile creates path with data, failing if it already exists.
+func writeNewFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("refusing to overwrite %s: %w", path, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// HoldReport is the result of VerifyHold.
+type HoldReport struct {
+	Conversations int      // chains checked
+	Entries       int      // chain entries checked
+	Files         int      // markdown files checked
+	Manifests     int      // signed manifests checked
+	Problems      []string // every integrity failure found
+}
+
+// OK reports whether verification found no problems.
+func (r *HoldReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// VerifyHold checks every chain in dir: sequence numbers, hashes, and links
+// between entries; that each recorded markdown file under localExportDir
+// still has its recorded hash (skipped when localExportDir is empty); and
+// that each manifest's signature is valid, matches trustedKey when given,
+// and names chain heads that are still in the chain.
+func VerifyHold(dir, localExportDir string, trustedKey ed25519.PublicKey) (*HoldReport, error) {
+	report := &HoldReport{}
+	ids, err := holdConversations(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	chains := make(map[string][]HoldEntry)
+	for _, id := range ids {
+		entries, err := loadHoldEntries(HoldLedgerPath(dir, id))
+		if err != nil {
+			report.Problems = append(report.Problems, err.Error())
+			continue
+		}
+		chains[id] = entries
+		report.Conversations++
+		report.Entries += len(entries)
+		report.Problems = append(report.Problems, verifyChain(id, entries)...)
+		if localExportDir != "" {
+			for _, entry := range entries {
+				if entry.File == "" {
+					continue
+				}
+				report.Files++
+				data, err := os.ReadFile(filepath.Join(localExportDir, filepath.FromSlash(entry.File)))
+				if err != nil {
+					report.Problems = append(report.Problems, fmt.Sprintf("%s: %s: %v", id, entry.File, err))
+				} else if sha256Hex(data) != entry.FileSHA256 {
+					report.Problems = append(report.Problems, fmt.Sprintf("%s: %s has been modified", id, entry.File))
+				}
+			}
+		}
+	}
+
+	manifests, err := filepath.Glob(filepath.Join(dir, "manifest-*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range manifests {
+		report.Manifests++
+		report.Problems = append(report.Problems, verifyHoldManifest(path, chains, trustedKey)...)
+	}
+	return report, nil
+}
+
+// verifyChain checks the sequence and hash links of one conversation.
+func verifyChain(convID string, entries []HoldEntry) []string {
+	var problems []string
+	prev := ""
+	for i, entry := range entries {
+		if entry.Seq != i+1 {
+			problems = append(problems, fmt.Sprintf("%s: entry %d has sequence %d", convID, i+1, entry.Seq))
+		}
+		if entry.PrevHash != prev {
+			problems = append(problems, fmt.Sprintf("%s: entry %d (%s) does not link to the entry before it", convID, i+1, entry.Date))
+		}
+		if entry.computeHash() != entry.Hash {
+			problems = append(problems, fmt.Sprintf("%s: entry %d (%s) has been modified", convID, i+1, entry.Date))
+		}
+		prev = entry.Hash
+	}
+	return problems
+}
+
+// verifyHoldManifest checks one signed manifest against the chains.
+func verifyHoldManifest(path string, chains map[string][]HoldEntry, trustedKey ed25519.PublicKey) []string {
+	name := filepath.Base(path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return []string{fmt.Sprintf("%s: %v", name, err)}
+	}
+	sigText, err := os.ReadFile(path + ".sig")
+	if err != nil {
+		return []string{fmt.Sprintf("%s: missing signature: %v", name, err)}
+	}
+	var manifest HoldManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return []string{fmt.Sprintf("%s: %v", name, err)}
+	}
+	pub, err := base64.StdEncoding.DecodeString(manifest.PublicKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return []string{fmt.Sprintf("%s: invalid public key", name)}
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sigText)))
+	if err != nil || !ed25519.Verify(pub, data, sig) {
+		return []string{fmt.Sprintf("%s: signature does not match", name)}
+	}
+
+	// A manifest signed by another key proves nothing about the chains,
+	// whatever heads it names.
+	if trustedKey != nil && !bytes.Equal(pub, trustedKey) {
+		return []string{fmt.Sprintf("%s: signed by a different key than this machine's", name)}
+	}
+
+	var problems []string
+	for _, head := range manifest.Conversations {
+		entries := chains[head.ConversationID]
+		if head.Entries < 1 {
+			problems = append(problems, fmt.Sprintf("%s: chain head for %s has an invalid entry count %d", name, head.ConversationID, head.Entries))
+			continue
+		}
+		if len(entries) < head.Entries || entries[head.Entries-1].Hash != head.HeadHash {
+			problems = append(problems, fmt.Sprintf("%s: chain for %s no longer matches (entries removed or changed)", name, head.ConversationID))
+		}
+	}
+	return problems
+}
+
+// LoadOrCreateHoldKey returns the legal-hold signing key from store,
+// generating and storing one on first use.
+func LoadOrCreateHoldKey(store secrets.SecretStore) (ed25519.PrivateKey, error) {
+	key, err := loadHoldKey(store)
+	if err == nil {
+		return key, nil
+	}
+	if !errors.Is(err, secrets.ErrNotFound) {
+		return nil, err
+	}
+
+	_, key, err = ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate legal-hold signing key: %w", err)
+	}
+	if err := store.Set(secrets.KeyLegalHoldSigningKey, base64.StdEncoding.EncodeToString(key.Seed())); err != nil {
+		return nil, fmt.Errorf("failed to store legal-hold signing key: %w", err)
+	}
+	return key, nil
+}
+
+// HoldPublicKey returns the public half of the stored legal-hold signing
+// key, or nil when none has been created yet.
+func HoldPublicKey(store secrets.SecretStore) (ed25519.PublicKey, error) {
+	key, err := loadHoldKey(store)
+	if errors.Is(err, secrets.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return key.Public().(ed25519.PublicKey), nil
+}
+
+func loadHoldKey(store secrets.SecretStore) (ed25519.PrivateKey, error) {
+	encoded, err := store.Get(secrets.KeyLegalHoldSigningKey)
+	if errors.Is(err, secrets.ErrNotFound) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read legal-hold signing key: %w", err)
+	}
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("stored legal-hold signing key is invalid")
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
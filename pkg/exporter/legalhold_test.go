@@ -0,0 +1,310 @@
+package exporter
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/internal/testutil"
+	"github.com/jflowers/get-out/pkg/secrets"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+func TestHoldLedger_RecordChains(t *testing.T) {
+	dir := t.TempDir()
+	ledger := NewHoldLedger(dir)
+	msgs := []slackapi.Message{{TS: "2.2", Text: "b"}, {TS: "1.1", Text: "a"}}
+
+	first, err := ledger.Record("C001", "2024-02-01", "", msgs, "", nil)
+	if err != nil {
+		t.Fatalf("Record() error: %v", err)
+	}
+	if first.Seq != 1 || first.PrevHash != "" || first.Hash == "" {
+		t.Errorf("first entry = %+v", first)
+	}
+	// Message order does not change the content hash.
+	reordered, _ := NewHoldLedger(t.TempDir()).Record("C001", "2024-02-01", "", []slackapi.Message{msgs[1], msgs[0]}, "", nil)
+	if reordered.ContentSHA256 != first.ContentSHA256 {
+		t.Error("ContentSHA256 depends on message order")
+	}
+
+	// A new ledger on the same dir continues the chain from disk.
+	second, err := NewHoldLedger(dir).Record("C001", "2024-02-02", "", msgs[:1], "x/2024-02-02.md", []byte("# day"))
+	if err != nil {
+		t.Fatalf("Record() error: %v", err)
+	}
+	if second.Seq != 2 || second.PrevHash != first.Hash || second.FileSHA256 == "" {
+		t.Errorf("second entry = %+v, want seq 2 linked to the first", second)
+	}
+
+	report, err := VerifyHold(dir, "", nil)
+	if err != nil {
+		t.Fatalf("VerifyHold() error: %v", err)
+	}
+	if !report.OK() || report.Conversations != 1 || report.Entries != 2 {
+		t.Errorf("VerifyHold() = %+v, want 2 clean entries", report)
+	}
+}
+
+func TestVerifyHold_DetectsTampering(t *testing.T) {
+	dir := t.TempDir()
+	local := t.TempDir()
+	ledger := NewHoldLedger(dir)
+	msgs := []slackapi.Message{{TS: "1.1", Text: "a"}}
+
+	if err := os.MkdirAll(filepath.Join(local, "general"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join("general", "2024-02-01.md")
+	if err := os.WriteFile(filepath.Join(local, file), []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ledger.Record("C001", "2024-02-01", "", msgs, file, []byte("original")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ledger.Record("C001", "2024-02-02", "", msgs, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	_, key, _ := ed25519.GenerateKey(nil)
+	if _, err := WriteHoldManifest(dir, key); err != nil {
+		t.Fatalf("WriteHoldManifest() error: %v", err)
+	}
+	report, _ := VerifyHold(dir, local, key.Public().(ed25519.PublicKey))
+	if !report.OK() || report.Manifests != 1 || report.Files != 1 {
+		t.Fatalf("VerifyHold() before tampering = %+v", report)
+	}
+
+	// Modify the markdown file and rewrite the first entry's date.
+	if err := os.WriteFile(filepath.Join(local, file), []byte("edited"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := HoldLedgerPath(dir, "C001")
+	data, _ := os.ReadFile(path)
+	if err := os.WriteFile(path, []byte(strings.Replace(string(data), "2024-02-01", "2024-01-31", 1)), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, otherKey, _ := ed25519.GenerateKey(nil)
+	report, err := VerifyHold(dir, local, otherKey.Public().(ed25519.PublicKey))
+	if err != nil {
+		t.Fatalf("VerifyHold() error: %v", err)
+	}
+	problems := strings.Join(report.Problems, "\n")
+	for _, want := range []string{"entry 1 (2024-01-31) has been modified", "general/2024-02-01.md has been modified", "different key"} {
+		if !strings.Contains(problems, want) {
+			t.Errorf("problems missing %q:\n%s", want, problems)
+		}
+	}
+}
+
+func TestVerifyHold_TruncatedChain(t *testing.T) {
+	dir := t.TempDir()
+	ledger := NewHoldLedger(dir)
+	msgs := []slackapi.Message{{TS: "1.1"}}
+	for _, date := range []string{"2024-02-01", "2024-02-02"} {
+		if _, err := ledger.Record("C001", date, "", msgs, "", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, key, _ := ed25519.GenerateKey(nil)
+	if _, err := WriteHoldManifest(dir, key); err != nil {
+		t.Fatal(err)
+	}
+
+	// Dropping the last entry leaves a valid chain, but the manifest catches it.
+	path := HoldLedgerPath(dir, "C001")
+	data, _ := os.ReadFile(path)
+	lines := strings.SplitAfter(string(data), "\n")
+	if err := os.WriteFile(path, []byte(lines[0]), 0600); err != nil {
+		t.Fatal(err)
+	}
+	report, _ := VerifyHold(dir, "", nil)
+	if report.OK() || !strings.Contains(report.Problems[0], "no longer matches") {
+		t.Errorf("VerifyHold() = %+v, want the manifest mismatch", report.Problems)
+	}
+}
+
+// resignHoldManifest rewrites the manifest at path with edit applied,
+// signed by key.
+func resignHoldManifest(t *testing.T, path string, key ed25519.PrivateKey, edit func(*HoldManifest)) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest HoldManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	manifest.PublicKey = base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey))
+	edit(&manifest)
+	data, _ = json.MarshalIndent(manifest, "", "  ")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+".sig", []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(key, data))+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyHold_ManifestSignedByOtherKey(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewHoldLedger(dir).Record("C001", "2024-02-01", "", []slackapi.Message{{TS: "1.1"}}, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	_, key, _ := ed25519.GenerateKey(nil)
+	path, err := WriteHoldManifest(dir, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A manifest re-signed by another key, with heads that would no
+	// longer match, is rejected for its key alone.
+	_, otherKey, _ := ed25519.GenerateKey(nil)
+	resignHoldManifest(t, path, otherKey, func(m *HoldManifest) {
+		m.Conversations[0].HeadHash = "forged"
+	})
+	report, err := VerifyHold(dir, "", key.Public().(ed25519.PublicKey))
+	if err != nil {
+		t.Fatalf("VerifyHold() error: %v", err)
+	}
+	if len(report.Problems) != 1 || !strings.Contains(report.Problems[0], "different key") {
+		t.Errorf("VerifyHold() problems = %q, want only the key mismatch", report.Problems)
+	}
+}
+
+func TestVerifyHold_ManifestWithoutEntries(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewHoldLedger(dir).Record("C001", "2024-02-01", "", []slackapi.Message{{TS: "1.1"}}, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	_, key, _ := ed25519.GenerateKey(nil)
+	path, err := WriteHoldManifest(dir, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, entries := range []int{0, -1} {
+		resignHoldManifest(t, path, key, func(m *HoldManifest) {
+			m.Conversations[0].Entries = entries
+		})
+		report, err := VerifyHold(dir, "", key.Public().(ed25519.PublicKey))
+		if err != nil {
+			t.Fatalf("VerifyHold() error: %v", err)
+		}
+		if len(report.Problems) != 1 || !strings.Contains(report.Problems[0], "invalid entry count") {
+			t.Errorf("entries %d: VerifyHold() problems = %q, want the invalid entry count", entries, report.Problems)
+		}
+	}
+}
+
+func TestLoadOrCreateHoldKey(t *testing.T) {
+	store := &secrets.FileStore{ConfigDir: t.TempDir()}
+	if pub, err := HoldPublicKey(store); pub != nil || err != nil {
+		t.Errorf("HoldPublicKey() before creation = %v, %v", pub, err)
+	}
+	key, err := LoadOrCreateHoldKey(store)
+	if err != nil {
+		t.Fatalf("LoadOrCreateHoldKey() error: %v", err)
+	}
+	again, err := LoadOrCreateHoldKey(store)
+	if err != nil || !key.Equal(again) {
+		t.Errorf("second LoadOrCreateHoldKey() = different key, %v", err)
+	}
+	pub, err := HoldPublicKey(store)
+	if err != nil || !pub.Equal(key.Public()) {
+		t.Errorf("HoldPublicKey() = %v, %v", pub, err)
+	}
+}
+
+func TestExportConversation_LegalHold(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	conv.LocalExport = true
+	indexPath := t.TempDir() + "/export-index.json"
+	exp := fakeExporter(t, drive, slack, indexPath)
+	exp.localExportDir = t.TempDir()
+	exp.mdWriter = NewMarkdownWriter(exp.userResolver, exp.channelResolver, nil)
+	exp.syncMode = true
+	exp.legalHold = true
+	exp.holdLedger = NewHoldLedger(DefaultHoldDir(exp.configDir))
+
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	dayFile := filepath.Join(exp.localExportDir, SanitizeDirectoryName("channel", "general"), "2024-02-02.md")
+	original, err := os.ReadFile(dayFile)
+	if err != nil {
+		t.Fatalf("day file not written: %v", err)
+	}
+
+	// A later message for the same day goes to a new part file.
+	slack.Messages["C001"] = append(slack.Messages["C001"], slackapi.Message{User: "U001", Text: "Late", TS: "1706878800.000500"})
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatalf("second ExportConversation() error: %v", err)
+	}
+	if after, _ := os.ReadFile(dayFile); string(after) != string(original) {
+		t.Error("legal hold modified an existing markdown file")
+	}
+	if _, err := os.Stat(strings.TrimSuffix(dayFile, ".md") + "-2.md"); err != nil {
+		t.Errorf("part file not written: %v", err)
+	}
+
+	entries, err := loadHoldEntries(HoldLedgerPath(DefaultHoldDir(exp.configDir), "C001"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two main days and one thread day, then the late message's day.
+	if len(entries) != 4 {
+		t.Fatalf("hold entries = %d, want 4", len(entries))
+	}
+	if last := entries[3]; last.Date != "2024-02-02" || !strings.HasSuffix(last.File, "2024-02-02-2.md") || last.MessageCount != 1 {
+		t.Errorf("last entry = %+v", last)
+	}
+	report, _ := VerifyHold(DefaultHoldDir(exp.configDir), exp.localExportDir, nil)
+	if !report.OK() {
+		t.Errorf("VerifyHold() problems: %v", report.Problems)
+	}
+}
+
+func TestWriteThread_LegalHoldWritesOnlyNewReplies(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	conv.LocalExport = true
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.localExportDir = t.TempDir()
+	exp.mdWriter = NewMarkdownWriter(exp.userResolver, exp.channelResolver, nil)
+	exp.legalHold = true
+	exp.holdLedger = NewHoldLedger(DefaultHoldDir(exp.configDir))
+
+	ctx := context.Background()
+	if _, err := exp.ExportConversation(ctx, conv); err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	key := testutil.ThreadKey("C001", "1706792400.000200")
+	replies := append(slack.Replies[key], slackapi.Message{User: "U002", Text: "A later reply", TS: "1706792520.000500", ThreadTS: "1706792400.000200"})
+	thread := exp.index.GetThread("C001", "1706792400.000200")
+	docID := thread.DailyDocs["2024-02-01"].DocID
+	before := len(appendedTexts(drive, docID))
+
+	if err := (docsBackend{exp}).WriteThread(ctx, conv, replies[0], replies, &ExportResult{}); err != nil {
+		t.Fatalf("WriteThread() error: %v", err)
+	}
+	appended := appendedTexts(drive, docID)[before:]
+	if joined := strings.Join(appended, ""); !strings.Contains(joined, "A later reply") || strings.Contains(joined, "Thread starter") {
+		t.Errorf("appended to the thread doc %q, want only the new reply", appended)
+	}
+	part, err := os.ReadFile(filepath.Join(exp.localExportDir, thread.LocalDir, "2024-02-01-2.md"))
+	if err != nil {
+		t.Fatalf("part file not written: %v", err)
+	}
+	if !strings.Contains(string(part), "A later reply") || strings.Contains(string(part), "A reply\n") {
+		t.Errorf("part file = %q, want only the new reply", part)
+	}
+	if thread.LastReplyTS != "1706792520.000500" || thread.ReplyCount != 3 {
+		t.Errorf("thread = last reply %q, %d replies; want the new reply recorded", thread.LastReplyTS, thread.ReplyCount)
+	}
+}
@@ -0,0 +1,201 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+	"github.com/jflowers/get-out/pkg/slackjson"
+)
+
+// localBackend writes a conversation whose format is markdown or json to
+// the local export directory only. Progress is tracked in the index like a
+// Drive export, so --sync and --resume work the same way, but no Drive
+// folder or doc is created for it.
+type localBackend struct {
+	e *Exporter
+}
+
+func (b localBackend) EnsureConversationContainer(_ context.Context, conv config.ConversationConfig) (*ConversationExport, error) {
+	e := b.e
+	format := conv.OutputFormat()
+	if e.localExportDir == "" {
+		return nil, fmt.Errorf("format %q needs a local export directory: set localExportOutputDir in settings.json or pass --local-export-dir", format)
+	}
+	if format != config.OutputFormatMarkdown && e.legalHold {
+		return nil, fmt.Errorf("format %q is not supported in legal hold mode (its day files are rewritten as messages arrive)", format)
+	}
+	e.Detail("Writing local %s to %s", format, filepath.Join(e.localExportDir, SanitizeDirectoryName(string(conv.Type), conv.Name)))
+	return e.index.GetOrCreateConversation(conv.ID, conv.Name, string(conv.Type)), nil
+}
+
+func (b localBackend) WriteMessages(ctx context.Context, conv config.ConversationConfig, date string, msgs []slackapi.Message, result *ExportResult) (int, error) {
+	e := b.e
+	dir := SanitizeDirectoryName(string(conv.Type), conv.Name)
+	if conv.OutputFormat() == config.OutputFormatJSON {
+		if err := e.writeJSONDay(ctx, conv, dir, date, msgs, result); err != nil {
+			return 0, err
+		}
+	} else {
+		file, err := e.writeMarkdownDay(ctx, conv, dir, date, msgs, e.markdownDayMode(), result)
+		if err != nil {
+			return 0, err
+		}
+		if err := e.recordHold(conv.ID, "", date, msgs, file); err != nil {
+			return 0, err
+		}
+	}
+	e.addMessages(len(msgs))
+	return len(msgs), nil
+}
+
+// WriteThread gives markdown threads their own directory, as with
+// localExport, while json replies join the conversation's day files, as in
+// Slack's workspace export.
+func (b localBackend) WriteThread(ctx context.Context, conv config.ConversationConfig, parent slackapi.Message, replies []slackapi.Message, result *ExportResult) error {
+	e := b.e
+	if len(replies) == 0 {
+		return nil
+	}
+
+	replyByDate := GroupMessagesByDate(replies)
+	if conv.OutputFormat() == config.OutputFormatJSON {
+		dir := SanitizeDirectoryName(string(conv.Type), conv.Name)
+		for _, date := range SortedDates(replyByDate) {
+			if err := e.writeJSONDay(ctx, conv, dir, date, replyByDate[date], result); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	dir := e.localThreadDir(conv, parent, replies)
+	replyByDate = GroupMessagesByDate(e.unheldReplies(conv.ID, parent.TS, replies))
+	for _, date := range SortedDates(replyByDate) {
+		msgs := replyByDate[date]
+		file, err := e.writeMarkdownDay(ctx, conv, dir, date, msgs, mdReplace, result)
+		if err != nil {
+			return err
+		}
+		if err := e.recordHold(conv.ID, parent.TS, date, msgs, file); err != nil {
+			return err
+		}
+	}
+	e.setLastReply(conv.ID, parent.TS, replies)
+	return nil
+}
+
+// localThreadDir returns the directory, relative to the local export
+// directory, that a thread's markdown is written to, and records it in the
+// index. A thread keeps the directory it was first written to, so a topic
+// that changes as replies arrive does not move it; one written before
+// directories were recorded keeps its directory named after the parent
+// alone, unless another thread has claimed it. A thread started the same
+// day as another of conv's, with the same topic, gets a numeric suffix.
+func (e *Exporter) localThreadDir(conv config.ConversationConfig, parent slackapi.Message, replies []slackapi.Message) string {
+	if e.localExportDir == "" {
+		return LocalThreadDir(string(conv.Type), conv.Name, parent.TS, ThreadTopic(parent, replies, e.userResolver, e.channelResolver, e.personResolver))
+	}
+	convExport := e.index.GetConversation(conv.ID)
+	thread := e.index.GetThread(conv.ID, parent.TS)
+	if thread != nil {
+		convExport.mu.Lock()
+		dir := thread.LocalDir
+		convExport.mu.Unlock()
+		if dir != "" {
+			return dir
+		}
+	}
+
+	localDir := func(t *ThreadExport) string { return t.LocalDir }
+	legacy := LocalThreadDir(string(conv.Type), conv.Name, parent.TS, parentTopic(parent, e.userResolver, e.channelResolver, e.personResolver))
+	dir := legacy
+	_, err := os.Stat(filepath.Join(e.localExportDir, legacy))
+	if err != nil || e.index.uniqueThreadName(conv.ID, parent.TS, legacy, "-%d", localDir) != legacy {
+		topic := ThreadTopic(parent, replies, e.userResolver, e.channelResolver, e.personResolver)
+		dir = e.index.uniqueThreadName(conv.ID, parent.TS, LocalThreadDir(string(conv.Type), conv.Name, parent.TS, topic), "-%d", localDir)
+	}
+
+	if thread == nil {
+		thread = &ThreadExport{ThreadTS: parent.TS, DailyDocs: make(map[string]*DocExport)}
+		e.index.SetThread(conv.ID, thread)
+	}
+	if convExport != nil {
+		convExport.mu.Lock()
+		thread.LocalDir = dir
+		convExport.mu.Unlock()
+	}
+	return dir
+}
+
+func (b localBackend) Finalize(context.Context, config.ConversationConfig, *ExportResult) error {
+	return nil
+}
+
+// writeJSONDay merges msgs into {localExportDir}/{dir}/{date}.json, a
+// Slack-export day file holding the day's messages oldest first. Messages
+// already in the file are replaced by their newer copy. The sensitivity
+// filter applies as it does to markdown. Day files keep Slack's file URLs,
+// as in Slack's export, even for attachments saved with --download-files.
+func (e *Exporter) writeJSONDay(ctx context.Context, conv config.ConversationConfig, dir, date string, msgs []slackapi.Message, result *ExportResult) error {
+	msgs, err := e.filterLocalMessages(ctx, conv, date, msgs)
+	if err != nil || len(msgs) == 0 {
+		return err
+	}
+
+	var encode func([]slackapi.Message) interface{}
+	if e.jsonRendered {
+		encode = e.renderJSONMessages
+	}
+	if _, err := slackjson.WriteDayAs(filepath.Join(e.localExportDir, dir), date, msgs, encode); err != nil {
+		return err
+	}
+	result.JSONFilesWritten++
+	e.saveLocalFiles(ctx, dir, msgs, result)
+	return nil
+}
+
+// renderedMessage is a message of a json day file written with
+// JSONRendered: Slack's fields, text still the raw mrkdwn, plus the text as
+// the other formats render it and the entities found in it.
+type renderedMessage struct {
+	slackapi.Message
+	RenderedText string          `json:"rendered_text"`
+	Entities     []parser.Entity `json:"entities,omitempty"`
+}
+
+// renderJSONMessages adds the rendered text and entities to msgs for a
+// json day file.
+func (e *Exporter) renderJSONMessages(msgs []slackapi.Message) interface{} {
+	rendered := make([]renderedMessage, len(msgs))
+	for i, m := range msgs {
+		mrkdwn := parser.MessageText(m)
+		text, _ := parser.ConvertMrkdwnWithLinks(mrkdwn, e.userResolver, e.channelResolver, e.personResolver, nil)
+		rendered[i] = renderedMessage{
+			Message:      m,
+			RenderedText: text,
+			Entities:     parser.ExtractEntities(mrkdwn, e.userResolver, e.channelResolver, e.personResolver, e.emoji),
+		}
+	}
+	return rendered
+}
+
+// filterLocalMessages applies the sensitivity filter, when configured, to
+// one day's messages of a local-only format.
+func (e *Exporter) filterLocalMessages(ctx context.Context, conv config.ConversationConfig, date string, msgs []slackapi.Message) ([]slackapi.Message, error) {
+	if e.messageFilter == nil {
+		return msgs, nil
+	}
+	filterResult, err := e.messageFilter.FilterMessages(ctx, msgs)
+	if err != nil {
+		return nil, fmt.Errorf("sensitivity classification failed for %q (%s): %w", conv.Name, date, err)
+	}
+	if filterResult.FilteredCount > 0 {
+		e.Detail("Filtered %d/%d sensitive messages for %s", filterResult.FilteredCount, filterResult.TotalCount, date)
+	}
+	return filterResult.PassedMessages, nil
+}
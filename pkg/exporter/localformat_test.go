@@ -0,0 +1,199 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/internal/testutil"
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+	"github.com/jflowers/get-out/pkg/slackjson"
+)
+
+// localFormatExporter is fakeExporter writing local files to a temp dir.
+func localFormatExporter(t *testing.T, drive *testutil.FakeDrive, slack *testutil.FakeSlack, indexPath string) (*Exporter, string) {
+	t.Helper()
+	exp := fakeExporter(t, drive, slack, indexPath)
+	exp.localExportDir = t.TempDir()
+	exp.mdWriter = NewMarkdownWriter(exp.userResolver, exp.channelResolver, nil)
+	return exp, exp.localExportDir
+}
+
+func TestExportConversation_MarkdownFormat(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	conv.Format = config.OutputFormatMarkdown
+	exp, localDir := localFormatExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	if n := len(drive.Documents()); n != 0 {
+		t.Errorf("documents = %d, want 0 (markdown stays off Drive)", n)
+	}
+	if result.MessageCount != 3 || result.ThreadsExported != 1 {
+		t.Errorf("MessageCount = %d, ThreadsExported = %d, want 3 and 1", result.MessageCount, result.ThreadsExported)
+	}
+	// Two days and one thread day.
+	if result.MarkdownFilesWritten != 3 {
+		t.Errorf("MarkdownFilesWritten = %d, want 3", result.MarkdownFilesWritten)
+	}
+	dir := SanitizeDirectoryName(string(conv.Type), conv.Name)
+	for _, date := range []string{"2024-02-01", "2024-02-02"} {
+		if _, err := os.Stat(filepath.Join(localDir, dir, date+".md")); err != nil {
+			t.Errorf("missing %s.md: %v", date, err)
+		}
+	}
+
+	ce := exp.index.GetConversation("C001")
+	if ce.Status != "complete" || ce.FolderID != "" {
+		t.Errorf("index entry = status %q, folder %q; want complete without a Drive folder", ce.Status, ce.FolderID)
+	}
+	if ce.LastMessageTS != "1706875200.000300" {
+		t.Errorf("LastMessageTS = %q, want newest message TS", ce.LastMessageTS)
+	}
+}
+
+func TestExportConversation_JSONFormat(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	conv.Format = config.OutputFormatJSON
+	indexPath := t.TempDir() + "/export-index.json"
+	exp, localDir := localFormatExporter(t, drive, slack, indexPath)
+
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	if n := len(drive.Documents()); n != 0 {
+		t.Errorf("documents = %d, want 0 (json stays off Drive)", n)
+	}
+
+	dir := filepath.Join(localDir, SanitizeDirectoryName(string(conv.Type), conv.Name))
+	day, err := slackjson.ReadDay(dir, "2024-02-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	for _, m := range day {
+		texts = append(texts, m.Text)
+	}
+	if want := "Good morning|Thread starter|A reply"; strings.Join(texts, "|") != want {
+		t.Errorf("2024-02-01.json = %q, want %q (replies in the day file, oldest first)", texts, want)
+	}
+
+	// A sync adds the new message to the existing day file.
+	slack.Messages["C001"] = append(slack.Messages["C001"],
+		slackapi.Message{User: "U002", Text: "Later that day", TS: "1706878800.000500"}) // 2024-02-02
+	exp.syncMode = true
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatalf("sync export: %v", err)
+	}
+	day, err = slackjson.ReadDay(dir, "2024-02-02")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(day) != 2 || day[0].Text != "Next day" || day[1].Text != "Later that day" {
+		t.Errorf("2024-02-02.json after sync = %+v, want both messages once", day)
+	}
+}
+
+func TestExportConversation_JSONRendered(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	slack.Messages["C001"][0].Text = "*Good* morning <#C002|random>"
+	// A bot's message, whose text is only a notification fallback.
+	slack.Messages["C001"][2].Text = "Deploy finished"
+	slack.Messages["C001"][2].Blocks = []slackapi.Block{{
+		Type: "section",
+		Text: &slackapi.TextObject{Type: "mrkdwn", Text: "Deployed to <#C002|random>"},
+	}}
+	conv.Format = config.OutputFormatJSON
+	exp, localDir := localFormatExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.jsonRendered = true
+
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(localDir, SanitizeDirectoryName(string(conv.Type), conv.Name), "2024-02-01.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var day []renderedMessage
+	if err := json.Unmarshal(data, &day); err != nil {
+		t.Fatal(err)
+	}
+	first := day[0]
+	if first.Text != "*Good* morning <#C002|random>" || first.RenderedText != "Good morning #random" {
+		t.Errorf("text = %q, rendered_text = %q; want the raw mrkdwn and the rendered text", first.Text, first.RenderedText)
+	}
+	if len(first.Entities) != 1 || first.Entities[0].Type != parser.EntityChannel || first.Entities[0].ID != "C002" {
+		t.Errorf("entities = %+v, want the channel mention", first.Entities)
+	}
+	if day[1].RenderedText != "Thread starter" {
+		t.Errorf("rendered_text of a message without markup = %q, want its text", day[1].RenderedText)
+	}
+
+	data, err = os.ReadFile(filepath.Join(localDir, SanitizeDirectoryName(string(conv.Type), conv.Name), "2024-02-02.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	day = nil
+	if err := json.Unmarshal(data, &day); err != nil {
+		t.Fatal(err)
+	}
+	if last := day[len(day)-1]; last.RenderedText != "Deployed to #random" || len(last.Entities) != 1 || last.Entities[0].ID != "C002" {
+		t.Errorf("block message: rendered_text = %q, entities = %+v; want them from its blocks", last.RenderedText, last.Entities)
+	}
+}
+
+func TestExportConversation_LocalFormatErrors(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	conv.Format = config.OutputFormatMarkdown
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	if _, err := exp.ExportConversation(context.Background(), conv); err == nil || !strings.Contains(err.Error(), "local export directory") {
+		t.Errorf("without a local export dir: err = %v, want local export directory error", err)
+	}
+
+	conv.Format = config.OutputFormatJSON
+	exp, _ = localFormatExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.legalHold = true
+	if _, err := exp.ExportConversation(context.Background(), conv); err == nil || !strings.Contains(err.Error(), "legal hold") {
+		t.Errorf("json under legal hold: err = %v, want legal hold error", err)
+	}
+}
+
+func TestLocalThreadDir_StickyAndUnique(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	exp, localDir := localFormatExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.index.GetOrCreateConversation(conv.ID, conv.Name, string(conv.Type))
+
+	first := slackapi.Message{Text: "ok", TS: "1706792400.000200"}
+	second := slackapi.Message{Text: "ok", TS: "1706792500.000200"}
+	dir := exp.localThreadDir(conv, first, nil)
+	if got := exp.localThreadDir(conv, second, nil); got != dir+"-2" {
+		t.Errorf("second thread dir = %q, want %q", got, dir+"-2")
+	}
+
+	// A reply that would change the topic does not move the thread.
+	replies := []slackapi.Message{{Text: "The rollout is postponed to Monday", TS: "1706792460.000400"}}
+	if got := exp.localThreadDir(conv, first, replies); got != dir {
+		t.Errorf("thread dir after a new reply = %q, want %q", got, dir)
+	}
+
+	// A thread written before directories were recorded keeps its
+	// directory, named after the parent alone.
+	third := slackapi.Message{Text: "Rollout", TS: "1706792600.000200"}
+	legacy := LocalThreadDir(string(conv.Type), conv.Name, third.TS, "Rollout")
+	if err := os.MkdirAll(filepath.Join(localDir, legacy), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if got := exp.localThreadDir(conv, third, replies); got != legacy {
+		t.Errorf("legacy thread dir = %q, want %q", got, legacy)
+	}
+	if got := exp.localThreadDir(conv, slackapi.Message{Text: "Rollout", TS: "1706792700.000200"}, replies); got == legacy {
+		t.Errorf("new thread dir = %q, want its own once the legacy one is claimed", got)
+	}
+}
@@ -0,0 +1,139 @@
+package exporter
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/gdrive"
+	"github.com/jflowers/get-out/pkg/parser"
+)
+
+// MasterIndexTitle is the title of the spreadsheet in the export root
+// folder that lists every exported conversation.
+const MasterIndexTitle = "Export Index"
+
+// SheetSink is the part of the Google client that writes the master index
+// spreadsheet. It is satisfied by *gdrive.Client.
+type SheetSink interface {
+	FindOrCreateSpreadsheet(ctx context.Context, title string, folderID string) (*gdrive.SheetInfo, error)
+	ReplaceSheetRows(ctx context.Context, spreadsheetID string, rows [][]gdrive.SheetCell) error
+	AppendSheetRows(ctx context.Context, spreadsheetID string, rows [][]gdrive.SheetCell) error
+}
+
+var _ SheetSink = (*gdrive.Client)(nil)
+
+// masterIndexColumns are the header of the master index.
+var masterIndexColumns = []string{"Conversation", "Type", "Status", "Folder", "Docs", "Messages", "First message", "Last message", "Last sync"}
+
+// writeMasterIndex rewrites the Export Index spreadsheet at the end of a
+// run with a row per conversation exported to Google Docs, so the archive
+// can be browsed, sorted, and filtered without the CLI. Like the status
+// doc, it is left alone by a run of convs that writes no docs. Under legal
+// hold the sheet is not rewritten; each run appends a dated snapshot
+// instead. Failures are reported without failing the run.
+func (e *Exporter) writeMasterIndex(ctx context.Context, convs []config.ConversationConfig) {
+	exports := e.docsConversations(convs)
+	if e.sheets == nil || len(exports) == 0 || ctx.Err() != nil {
+		return
+	}
+	root, err := e.folderStructure.EnsureRootFolder(ctx)
+	if err != nil {
+		e.Progress("Warning: could not update %s: %v", MasterIndexTitle, err)
+		return
+	}
+	sheet, err := e.sheets.FindOrCreateSpreadsheet(ctx, MasterIndexTitle, root.ID)
+	if err != nil {
+		e.Progress("Warning: could not create %s sheet: %v", MasterIndexTitle, err)
+		return
+	}
+
+	rows := masterIndexRows(exports)
+	if e.legalHold {
+		now := time.Now()
+		snapshot := []gdrive.SheetCell{{Text: "Snapshot", Bold: true}, {Text: parser.InZone(now).Format("2006-01-02 15:04"), Bold: true}}
+		err = e.sheets.AppendSheetRows(ctx, sheet.ID, append([][]gdrive.SheetCell{snapshot}, rows...))
+	} else {
+		err = e.sheets.ReplaceSheetRows(ctx, sheet.ID, rows)
+	}
+	if err != nil {
+		e.Progress("Warning: could not update %s: %v", MasterIndexTitle, err)
+		return
+	}
+	e.Detail("Updated %s: %s", MasterIndexTitle, sheet.URL)
+}
+
+// masterIndexRows renders the master index: the header, then a row per
+// conversation sorted by name. The date range runs from the oldest to the
+// newest exported message.
+func masterIndexRows(convs []*ConversationExport) [][]gdrive.SheetCell {
+	convs = append([]*ConversationExport(nil), convs...)
+	sort.SliceStable(convs, func(i, j int) bool {
+		return strings.ToLower(orDefault(convs[i].Name, convs[i].ID)) < strings.ToLower(orDefault(convs[j].Name, convs[j].ID))
+	})
+
+	header := make([]gdrive.SheetCell, len(masterIndexColumns))
+	for i, name := range masterIndexColumns {
+		header[i] = gdrive.SheetCell{Text: name, Bold: true}
+	}
+	rows := [][]gdrive.SheetCell{header}
+	for _, conv := range convs {
+		var lastSync string
+		if !conv.LastUpdated.IsZero() {
+			lastSync = parser.InZone(conv.LastUpdated).Format("2006-01-02 15:04")
+		}
+		var lastMessage string
+		if newest := conv.newestMessageTS(); newest != "" {
+			lastMessage = tsToDate(newest)
+		}
+		rows = append(rows, []gdrive.SheetCell{
+			{Text: orDefault(conv.Name, conv.ID)},
+			{Text: conv.Type},
+			{Text: orDefault(conv.Status, "unknown")},
+			{Text: conv.FolderURL, URL: conv.FolderURL},
+			{Number: float64(conv.docCount()), IsNumber: true},
+			{Number: float64(conv.MessageCount), IsNumber: true},
+			{Text: conv.firstMessageDate()},
+			{Text: lastMessage},
+			{Text: lastSync},
+		})
+	}
+	return rows
+}
+
+// docCount returns how many Google Docs the conversation's messages are
+// in: its daily docs and its threads' docs.
+func (c *ConversationExport) docCount() int {
+	n := 0
+	for _, doc := range c.DailyDocs {
+		if doc.DocID != "" {
+			n++
+		}
+	}
+	for _, thread := range c.Threads {
+		for _, doc := range thread.DailyDocs {
+			if doc.DocID != "" {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// firstMessageDate returns the date (YYYY-MM-DD) of the conversation's
+// oldest exported message. Exports that did not record it fall back to
+// the first day of its oldest doc, or "" without one.
+func (c *ConversationExport) firstMessageDate() string {
+	if c.FirstMessageTS != "" {
+		return tsToDate(c.FirstMessageTS)
+	}
+	first := ""
+	for _, doc := range c.DailyDocs {
+		if doc.Date != "" && (first == "" || doc.Date < first) {
+			first = doc.Date
+		}
+	}
+	return first
+}
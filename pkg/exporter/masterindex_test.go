@@ -0,0 +1,150 @@
+package exporter
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jflowers/get-out/internal/testutil"
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/gdrive"
+)
+
+// cellTexts returns the text of each cell of row, numbers formatted.
+func cellTexts(row []gdrive.SheetCell) []string {
+	texts := make([]string, len(row))
+	for i, cell := range row {
+		texts[i] = cell.Text
+		if cell.IsNumber {
+			texts[i] = strconv.Itoa(int(cell.Number))
+		}
+	}
+	return texts
+}
+
+func TestMasterIndexRows(t *testing.T) {
+	convs := []*ConversationExport{
+		{
+			ID: "C002", Name: "releases", Type: "channel", Status: StatusComplete, FolderURL: "https://drive/releases",
+			FirstMessageTS: "1706788800.000100", LastMessageTS: "1706875200.000300", MessageCount: 5,
+			LastUpdated: time.Date(2024, 2, 3, 9, 30, 0, 0, time.Local),
+			DailyDocs: map[string]*DocExport{
+				"2024-02-01": {DocID: "d1", Date: "2024-02-01"},
+				"2024-02-02": {DocID: "d2", Date: "2024-02-02"},
+			},
+			Threads: map[string]*ThreadExport{
+				"1706792400.000200": {DailyDocs: map[string]*DocExport{"2024-02-01": {DocID: "t1"}}},
+			},
+		},
+		{
+			ID: "C001", Name: "General", Type: "channel", Status: StatusInProgress, MessageCount: 3,
+			DailyDocs: map[string]*DocExport{"2024-W05": {DocID: "w5", Date: "2024-01-29"}, "2024-W06": {DocID: "w6", Date: "2024-02-05"}},
+		},
+		{ID: "D001", Type: "dm"},
+	}
+
+	rows := masterIndexRows(convs)
+	if len(rows) != 4 {
+		t.Fatalf("got %d rows, want a header and one per conversation", len(rows))
+	}
+	for i, cell := range rows[0] {
+		if cell.Text != masterIndexColumns[i] || !cell.Bold {
+			t.Errorf("header cell %d = %+v, want %q in bold", i, cell, masterIndexColumns[i])
+		}
+	}
+
+	tests := []struct {
+		row  int
+		want []string
+	}{
+		{row: 1, want: []string{"D001", "dm", "unknown", "", "0", "0", "", "", ""}},
+		{row: 2, want: []string{"General", "channel", StatusInProgress, "", "2", "3", "2024-01-29", "", ""}},
+		{row: 3, want: []string{"releases", "channel", StatusComplete, "https://drive/releases", "3", "5", "2024-02-01", "2024-02-02", "2024-02-03 09:30"}},
+	}
+	for _, tt := range tests {
+		got := cellTexts(rows[tt.row])
+		if len(got) != len(tt.want) {
+			t.Fatalf("row %d = %v, want %v", tt.row, got, tt.want)
+		}
+		for i := range tt.want {
+			if got[i] != tt.want[i] {
+				t.Errorf("row %d column %s = %q, want %q", tt.row, masterIndexColumns[i], got[i], tt.want[i])
+			}
+		}
+	}
+	if folder := rows[3][3]; folder.URL != "https://drive/releases" {
+		t.Errorf("folder cell = %+v, want it linked to the folder", folder)
+	}
+}
+
+func TestWriteMasterIndex(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.sheets = drive
+	ctx := context.Background()
+	if _, err := exp.ExportConversation(ctx, conv); err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+
+	convs := []config.ConversationConfig{conv}
+	exp.writeMasterIndex(ctx, convs)
+	exp.writeMasterIndex(ctx, convs)
+
+	sheets := drive.Spreadsheets()
+	if len(sheets) != 1 || sheets[0] != MasterIndexTitle {
+		t.Fatalf("spreadsheets = %v, want a single %s", sheets, MasterIndexTitle)
+	}
+	sheet, err := drive.FindOrCreateSpreadsheet(ctx, MasterIndexTitle, exp.index.RootFolderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, folderID := drive.SheetRows(sheet.ID)
+	if got := drive.FolderName(folderID); got != "Test Exports" {
+		t.Errorf("sheet folder = %q, want the export root", got)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want the header and general", len(rows))
+	}
+	got := cellTexts(rows[1])
+	if got[0] != "general" || got[5] != "3" || got[6] != "2024-02-01" || got[7] != "2024-02-02" {
+		t.Errorf("general = %v, want its message count and date range", got)
+	}
+
+	exp.legalHold = true
+	exp.writeMasterIndex(ctx, convs)
+	rows, _ = drive.SheetRows(sheet.ID)
+	if len(rows) != 5 || rows[2][0].Text != "Snapshot" {
+		t.Errorf("got %d rows under legal hold, want the first rows kept and a snapshot appended", len(rows))
+	}
+}
+
+func TestWriteMasterIndex_Error(t *testing.T) {
+	drive, slack := testutil.NewFakeDrive(), testutil.NewFakeSlack()
+	drive.Errors["ReplaceSheetRows"] = context.DeadlineExceeded
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.sheets = drive
+	exp.index.GetOrCreateConversation("C001", "general", "channel")
+	var progress []string
+	exp.onProgress = func(msg string) { progress = append(progress, msg) }
+
+	exp.writeMasterIndex(context.Background(), []config.ConversationConfig{{ID: "C001", Name: "general", Type: "channel"}})
+
+	if len(progress) != 1 {
+		t.Errorf("progress = %v, want one warning", progress)
+	}
+}
+
+func TestWriteMasterIndex_LocalOnly(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.sheets = drive
+	exp.index.GetOrCreateConversation(conv.ID, conv.Name, string(conv.Type))
+	conv.Format = config.OutputFormatHTML
+
+	exp.writeMasterIndex(context.Background(), []config.ConversationConfig{conv})
+
+	if sheets := drive.Spreadsheets(); len(sheets) != 0 || drive.Calls("FindOrCreateFolder") != 0 {
+		t.Errorf("an html-only run created %v in Drive, want nothing", sheets)
+	}
+}
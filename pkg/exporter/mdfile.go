@@ -1,9 +1,12 @@
 package exporter
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -74,6 +77,189 @@ func WriteMarkdownFile(dir string, typeName string, date string, content []byte)
 	return atomicWriteFile(targetDir, targetPath, content)
 }
 
+// ReplaceMarkdownFile writes content to {dir}/{typeName}/{date}.md atomically,
+// overwriting any existing file. Used by render, which regenerates files
+// that an earlier export already wrote.
+func ReplaceMarkdownFile(dir string, typeName string, date string, content []byte) error {
+	targetDir := filepath.Join(dir, typeName)
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", targetDir, err)
+	}
+	return atomicWriteFile(targetDir, filepath.Join(targetDir, date+".md"), content)
+}
+
+// AppendMarkdownFile adds new messages to {dir}/{typeName}/{date}.md. When
+// the file does not exist, content (a full document) is written; otherwise
+// body (the new messages only) is added to the existing file, before its
+// layout's footer when it has one, and the file's frontmatter counts the
+// new messages and their senders too (see mergeFrontmatter). Either way the
+// result is staged in a temp file and renamed into place, so a crash
+// mid-write leaves the previous version intact. Used by --sync runs, which
+// can pick up new messages for a day that was already written.
+func AppendMarkdownFile(dir string, typeName string, date string, content, body []byte) error {
+	targetDir := filepath.Join(dir, typeName)
+	targetPath := filepath.Join(targetDir, date+".md")
+
+	existing, err := os.ReadFile(targetPath)
+	if os.IsNotExist(err) {
+		return WriteMarkdownFile(dir, typeName, date, content)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", targetPath, err)
+	}
+
+	existing = mergeFrontmatter(existing, content)
+	footer := len(existing)
+	if i := bytes.LastIndex(existing, []byte(markdownFooterMarker)); i >= 0 {
+		footer = i
+	}
+	combined := make([]byte, 0, len(existing)+len(body))
+	combined = append(combined, existing[:footer]...)
+	combined = append(combined, body...)
+	combined = append(combined, existing[footer:]...)
+	return atomicWriteFile(targetDir, targetPath, combined)
+}
+
+// frontmatter returns the lines of doc's YAML frontmatter, without its
+// fences, and the offset of the body after it. ok is false when doc does
+// not start with frontmatter.
+func frontmatter(doc []byte) (lines []string, end int, ok bool) {
+	if !bytes.HasPrefix(doc, []byte("---\n")) {
+		return nil, 0, false
+	}
+	i := bytes.Index(doc[4:], []byte("\n---\n"))
+	if i < 0 {
+		return nil, 0, false
+	}
+	return strings.Split(string(doc[4:4+i]), "\n"), 4 + i + len("\n---\n"), true
+}
+
+// frontmatterStats returns the message_count and participants of
+// frontmatter lines, participants as written (YAML scalars).
+func frontmatterStats(lines []string) (count int, participants []string) {
+	inParticipants := false
+	for _, line := range lines {
+		switch {
+		case inParticipants && strings.HasPrefix(line, "  - "):
+			participants = append(participants, strings.TrimPrefix(line, "  - "))
+			continue
+		case strings.HasPrefix(line, "message_count: "):
+			count, _ = strconv.Atoi(strings.TrimPrefix(line, "message_count: "))
+		}
+		inParticipants = line == "participants:"
+	}
+	return count, participants
+}
+
+// mergeFrontmatter returns existing, a day file, with the message_count and
+// participants of its frontmatter updated to include those of added, the
+// full document of the messages being appended to it. A file or document
+// without the built-in frontmatter (a layout may leave it out) is returned
+// unchanged.
+func mergeFrontmatter(existing, added []byte) []byte {
+	lines, end, ok := frontmatter(existing)
+	addedLines, _, addedOK := frontmatter(added)
+	if !ok || !addedOK {
+		return existing
+	}
+	count, participants := frontmatterStats(lines)
+	addedCount, addedParticipants := frontmatterStats(addedLines)
+
+	seen := make(map[string]bool)
+	var merged []string
+	for _, p := range append(participants, addedParticipants...) {
+		if !seen[p] {
+			seen[p] = true
+			merged = append(merged, p)
+		}
+	}
+	unquote := func(s string) string {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+		return s
+	}
+	sort.Slice(merged, func(i, j int) bool { return unquote(merged[i]) < unquote(merged[j]) })
+
+	var b strings.Builder
+	b.WriteString("---\n")
+	inParticipants := false
+	for _, line := range lines {
+		if inParticipants && strings.HasPrefix(line, "  - ") {
+			continue
+		}
+		inParticipants = false
+		switch {
+		case line == "participants:":
+			inParticipants = true
+			b.WriteString(line + "\n")
+			for _, p := range merged {
+				b.WriteString("  - " + p + "\n")
+			}
+			continue
+		case strings.HasPrefix(line, "message_count: "):
+			line = fmt.Sprintf("message_count: %d", count+addedCount)
+		}
+		b.WriteString(line + "\n")
+	}
+	b.WriteString("---\n")
+	return append([]byte(b.String()), existing[end:]...)
+}
+
+// WriteMarkdownPart writes content to a new file in {dir}/{typeName}:
+// {date}.md, or {date}-2.md, {date}-3.md, ... when earlier parts exist.
+// Existing files are never modified. Used in legal hold mode, where
+// artifacts from earlier runs are immutable. Returns the file name written.
+func WriteMarkdownPart(dir string, typeName string, date string, content []byte) (string, error) {
+	targetDir := filepath.Join(dir, typeName)
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory %s: %w", targetDir, err)
+	}
+	for part := 1; ; part++ {
+		name := date + ".md"
+		if part > 1 {
+			name = fmt.Sprintf("%s-%d.md", date, part)
+		}
+		targetPath := filepath.Join(targetDir, name)
+		f, err := os.OpenFile(targetPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to create %s: %w", targetPath, err)
+		}
+		if err := writeAndClose(f, content); err != nil {
+			_ = os.Remove(targetPath)
+			return "", err
+		}
+		return name, nil
+	}
+}
+
+// RemoveStaleTempFiles deletes .tmp-*.md files under dir left behind by a
+// run that was killed between creating a temp file and renaming it.
+// Returns the number of files removed.
+func RemoveStaleTempFiles(dir string) (int, error) {
+	removed := 0
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || !strings.HasPrefix(d.Name(), ".tmp-") || !strings.HasSuffix(d.Name(), ".md") {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove stale temp file %s: %w", path, err)
+		}
+		removed++
+		return nil
+	})
+	return removed, err
+}
+
 // atomicWriteFile creates a temp file in targetDir, writes content, sets
 // permissions, and atomically renames to targetPath. On any error the temp
 // file is cleaned up.
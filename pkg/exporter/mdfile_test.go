@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -434,3 +435,123 @@ func TestWriteMarkdownFile_AtomicCleanup(t *testing.T) {
 		}
 	})
 }
+
+func TestReplaceMarkdownFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := WriteMarkdownFile(dir, "dm-alice", "2026-03-15", []byte("old")); err != nil {
+		t.Fatalf("WriteMarkdownFile: %v", err)
+	}
+	if err := ReplaceMarkdownFile(dir, "dm-alice", "2026-03-15", []byte("new")); err != nil {
+		t.Fatalf("ReplaceMarkdownFile: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "dm-alice", "2026-03-15.md"))
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+	if string(data) != "new" {
+		t.Errorf("content = %q, want %q", data, "new")
+	}
+}
+
+func TestAppendMarkdownFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dm-alice", "2026-03-15.md")
+
+	// First write of a day uses the full document.
+	if err := AppendMarkdownFile(dir, "dm-alice", "2026-03-15", []byte("---\nfront\n---\n\nmsg1\n"), []byte("msg1\n")); err != nil {
+		t.Fatalf("AppendMarkdownFile (new): %v", err)
+	}
+	// Later sync runs add only the new messages.
+	if err := AppendMarkdownFile(dir, "dm-alice", "2026-03-15", []byte("---\nfront\n---\n\nmsg2\n"), []byte("msg2\n")); err != nil {
+		t.Fatalf("AppendMarkdownFile (existing): %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+	if want := "---\nfront\n---\n\nmsg1\nmsg2\n"; string(data) != want {
+		t.Errorf("content = %q, want %q", data, want)
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("expected only the target file, found %d entries", len(entries))
+	}
+}
+
+func TestAppendMarkdownFile_UpdatesFrontmatter(t *testing.T) {
+	dir := t.TempDir()
+	doc := func(count int, participants ...string) []byte {
+		fm := "---\nconversation_id: C001\nparticipants:\n"
+		for _, p := range participants {
+			fm += "  - " + p + "\n"
+		}
+		return []byte(fm + "message_count: " + strconv.Itoa(count) + "\nexporter_version: dev\n---\n\n")
+	}
+
+	if err := AppendMarkdownFile(dir, "dm-alice", "2026-03-15", append(doc(2, "Alice", `"Bob: PM"`), "msg1\n"...), nil); err != nil {
+		t.Fatalf("AppendMarkdownFile (new): %v", err)
+	}
+	if err := AppendMarkdownFile(dir, "dm-alice", "2026-03-15", append(doc(1, "Alice", "Carol"), "msg2\n"...), []byte("msg2\n")); err != nil {
+		t.Fatalf("AppendMarkdownFile (existing): %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "dm-alice", "2026-03-15.md"))
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+	if want := string(doc(3, "Alice", `"Bob: PM"`, "Carol")) + "msg1\nmsg2\n"; string(data) != want {
+		t.Errorf("content = %q, want %q", data, want)
+	}
+}
+
+func TestWriteMarkdownPart(t *testing.T) {
+	dir := t.TempDir()
+	for i, want := range []string{"2026-03-15.md", "2026-03-15-2.md", "2026-03-15-3.md"} {
+		name, err := WriteMarkdownPart(dir, "dm-alice", "2026-03-15", []byte{byte('a' + i)})
+		if err != nil {
+			t.Fatalf("WriteMarkdownPart #%d: %v", i+1, err)
+		}
+		if name != want {
+			t.Errorf("WriteMarkdownPart #%d = %q, want %q", i+1, name, want)
+		}
+	}
+	// Earlier parts are untouched.
+	data, err := os.ReadFile(filepath.Join(dir, "dm-alice", "2026-03-15.md"))
+	if err != nil || string(data) != "a" {
+		t.Errorf("first part = %q, %v; want \"a\"", data, err)
+	}
+}
+
+func TestRemoveStaleTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	convDir := filepath.Join(dir, "dm-alice")
+	if err := os.MkdirAll(convDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{".tmp-123.md", "2026-03-15.md", ".tmp-notes.txt"} {
+		if err := os.WriteFile(filepath.Join(convDir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := RemoveStaleTempFiles(dir)
+	if err != nil {
+		t.Fatalf("RemoveStaleTempFiles: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("removed = %d, want 1", n)
+	}
+	if _, err := os.Stat(filepath.Join(convDir, ".tmp-123.md")); !os.IsNotExist(err) {
+		t.Error("stale temp file still present")
+	}
+	if _, err := os.Stat(filepath.Join(convDir, "2026-03-15.md")); err != nil {
+		t.Errorf("daily file removed: %v", err)
+	}
+
+	if n, err := RemoveStaleTempFiles(filepath.Join(dir, "missing")); err != nil || n != 0 {
+		t.Errorf("missing dir: n=%d err=%v", n, err)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	texttemplate "text/template"
 
 	"github.com/jflowers/get-out/pkg/models"
 	"github.com/jflowers/get-out/pkg/parser"
@@ -16,6 +18,16 @@ type MarkdownWriter struct {
 	userResolver    *parser.UserResolver
 	channelResolver *parser.ChannelResolver
 	personResolver  *parser.PersonResolver
+
+	// exporterVersion is recorded in each file's frontmatter (optional).
+	exporterVersion string
+
+	// emoji renders reactions (optional; without it they are :name:).
+	emoji *parser.EmojiResolver
+
+	// layout holds the blocks of the conversation's layout (optional; see
+	// WithLayout).
+	layout *texttemplate.Template
 }
 
 // NewMarkdownWriter creates a new MarkdownWriter with the given resolvers.
@@ -27,13 +39,95 @@ func NewMarkdownWriter(userResolver *parser.UserResolver, channelResolver *parse
 	}
 }
 
+// SetExporterVersion sets the get-out version recorded in the frontmatter.
+func (w *MarkdownWriter) SetExporterVersion(version string) {
+	w.exporterVersion = version
+}
+
+// SetEmojiResolver renders reactions with emoji: standard emoji as
+// Unicode, custom emoji as images.
+func (w *MarkdownWriter) SetEmojiResolver(emoji *parser.EmojiResolver) {
+	w.emoji = emoji
+}
+
+// WithLayout returns a copy of w that renders the blocks layout defines
+// (see ParseLayouts) in place of the built-in ones, or w itself when
+// layout has no markdown blocks.
+func (w *MarkdownWriter) WithLayout(layout *Layout) *MarkdownWriter {
+	if layout == nil || layout.markdown == nil {
+		return w
+	}
+	c := *w
+	c.layout = layout.markdown
+	return &c
+}
+
+// markdownFooterMarker precedes a layout's footer in a day file, so
+// messages appended to the day later go before the footer (see
+// AppendMarkdownFile). Markdown renderers hide it.
+const markdownFooterMarker = "<!-- get-out:footer -->\n"
+
+// markdownDay is what a layout's header and footer blocks are executed
+// with.
+type markdownDay struct {
+	ConversationID  string
+	Conversation    string
+	Type            string
+	Date            string
+	Participants    []string
+	MessageCount    int
+	ExporterVersion string
+
+	// Frontmatter is the built-in YAML frontmatter, fences included.
+	Frontmatter string
+}
+
+// markdownMessage is what a layout's message and thread blocks are
+// executed with. Reactions, Attachments, Files, and Metadata are rendered
+// as in the built-in layout, "" when the message has none.
+type markdownMessage struct {
+	ID          string
+	Sender      string
+	Time        string
+	Text        string
+	Edited      bool
+	ReplyCount  int
+	Reactions   string
+	Attachments string
+	Files       string
+	Metadata    string
+
+	// Compact is set for a message of only emoji or a GIF, whose Text is
+	// then the emoji or a link to the GIF, and whose Default is one line.
+	Compact bool
+
+	// Default is the message in the built-in layout.
+	Default string
+}
+
+// renderBlock writes the layout's block name, executed with data, or
+// builtin when the layout does not define it.
+func (w *MarkdownWriter) renderBlock(b *strings.Builder, name string, data interface{}, builtin string) error {
+	if w.layout == nil || w.layout.Lookup(name) == nil {
+		b.WriteString(builtin)
+		return nil
+	}
+	if err := w.layout.ExecuteTemplate(b, name, data); err != nil {
+		return fmt.Errorf("failed to render %s: %w", name, err)
+	}
+	return nil
+}
+
 // RenderDailyDoc produces a complete markdown document with YAML frontmatter
-// for the given conversation's messages on a specific date.
+// for the given conversation's messages on a specific date. The frontmatter
+// carries the conversation ID, name, and type, the date, participants,
+// message count, and exporter version, so tools such as static site
+// generators or Obsidian Dataview can query the archive.
 //
 // When filterResult is non-nil, a sensitivity: block is added to the YAML
 // frontmatter for audit purposes. When filterResult is nil, no sensitivity
 // block is emitted (backward compatible with pre-filter exports).
-func (w *MarkdownWriter) RenderDailyDoc(convName string, convType string, date string, messages []slackapi.Message, filterResult *FilterResult) ([]byte, error) {
+func (w *MarkdownWriter) RenderDailyDoc(convID string, convName string, convType string, date string, messages []slackapi.Message, filterResult *FilterResult) ([]byte, error) {
 	// Sort messages by timestamp (oldest first)
 	sorted := make([]slackapi.Message, len(messages))
 	copy(sorted, messages)
@@ -41,20 +135,58 @@ func (w *MarkdownWriter) RenderDailyDoc(convName string, convType string, date s
 		return sorted[i].TS < sorted[j].TS
 	})
 
-	// Collect unique participant names
-	participants := w.collectParticipants(sorted)
+	day := markdownDay{
+		ConversationID:  convID,
+		Conversation:    convName,
+		Type:            convType,
+		Date:            date,
+		Participants:    w.collectParticipants(sorted),
+		MessageCount:    len(sorted),
+		ExporterVersion: w.exporterVersion,
+	}
+	day.Frontmatter = w.renderFrontmatter(day, filterResult)
 
-	// Build the document
 	var b strings.Builder
+	if err := w.renderBlock(&b, layoutHeader, day, day.Frontmatter); err != nil {
+		return nil, err
+	}
+	for _, msg := range sorted {
+		if err := w.renderMessage(&b, msg); err != nil {
+			return nil, err
+		}
+	}
+
+	// The footer, when the layout has one, follows its marker
+	var footer strings.Builder
+	if err := w.renderBlock(&footer, layoutFooter, day, ""); err != nil {
+		return nil, err
+	}
+	if footer.Len() > 0 {
+		b.WriteString(markdownFooterMarker)
+		b.WriteString(footer.String())
+	}
 
-	// YAML frontmatter
+	return []byte(b.String()), nil
+}
+
+// renderFrontmatter returns the YAML frontmatter of day, fences included.
+// When filterResult is non-nil, a sensitivity: block is added.
+func (w *MarkdownWriter) renderFrontmatter(day markdownDay, filterResult *FilterResult) string {
+	var b strings.Builder
 	b.WriteString("---\n")
-	b.WriteString(fmt.Sprintf("conversation: %s\n", convName))
-	b.WriteString(fmt.Sprintf("type: %s\n", convType))
-	b.WriteString(fmt.Sprintf("date: \"%s\"\n", date))
+	if day.ConversationID != "" {
+		b.WriteString(fmt.Sprintf("conversation_id: %s\n", yamlString(day.ConversationID)))
+	}
+	b.WriteString(fmt.Sprintf("conversation: %s\n", yamlString(day.Conversation)))
+	b.WriteString(fmt.Sprintf("type: %s\n", day.Type))
+	b.WriteString(fmt.Sprintf("date: \"%s\"\n", day.Date))
 	b.WriteString("participants:\n")
-	for _, p := range participants {
-		b.WriteString(fmt.Sprintf("  - %s\n", p))
+	for _, p := range day.Participants {
+		b.WriteString(fmt.Sprintf("  - %s\n", yamlString(p)))
+	}
+	b.WriteString(fmt.Sprintf("message_count: %d\n", day.MessageCount))
+	if day.ExporterVersion != "" {
+		b.WriteString(fmt.Sprintf("exporter_version: %s\n", yamlString(day.ExporterVersion)))
 	}
 
 	// Sensitivity metadata — only when a filter was applied.
@@ -63,15 +195,43 @@ func (w *MarkdownWriter) RenderDailyDoc(convName string, convType string, date s
 	}
 
 	b.WriteString("---\n\n")
+	return b.String()
+}
 
-	// Render each message
+// RenderMessages renders messages (oldest first) without frontmatter, for
+// appending to a daily file that already exists.
+func (w *MarkdownWriter) RenderMessages(messages []slackapi.Message) ([]byte, error) {
+	sorted := make([]slackapi.Message, len(messages))
+	copy(sorted, messages)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].TS < sorted[j].TS
+	})
+
+	var b strings.Builder
 	for _, msg := range sorted {
-		w.renderMessage(&b, msg)
+		if err := w.renderMessage(&b, msg); err != nil {
+			return nil, err
+		}
 	}
-
 	return []byte(b.String()), nil
 }
 
+// yamlString returns s as a YAML scalar, quoting it when it would otherwise
+// be misread (e.g. "team: ops", "#general", "yes", or leading spaces).
+func yamlString(s string) string {
+	if s == "" || yamlNeedsQuoteRe.MatchString(s) || yamlReservedRe.MatchString(s) ||
+		strings.TrimSpace(s) != s || strings.ContainsAny(s, ":#\"'\\\n\t") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// yamlNeedsQuoteRe matches scalars starting with a YAML indicator character.
+var yamlNeedsQuoteRe = regexp.MustCompile(`^[-?,\[\]{}&*!|>%@` + "`" + `]`)
+
+// yamlReservedRe matches scalars YAML would parse as booleans, null, or numbers.
+var yamlReservedRe = regexp.MustCompile(`(?i)^(true|false|yes|no|on|off|y|n|null|~|[-+]?[0-9][0-9_.]*([eE][-+]?[0-9]+)?)$`)
+
 // renderSensitivityFrontmatter writes the sensitivity: YAML block into the
 // frontmatter builder. Called only when a FilterResult is available.
 func (w *MarkdownWriter) renderSensitivityFrontmatter(b *strings.Builder, fr *FilterResult) {
@@ -166,39 +326,94 @@ func (w *MarkdownWriter) getSenderName(msg slackapi.Message) string {
 	return "Unknown"
 }
 
-// renderMessage formats a single message and writes it to the builder.
-func (w *MarkdownWriter) renderMessage(b *strings.Builder, msg slackapi.Message) {
-	senderName := w.getSenderName(msg)
-	timestamp := parser.FormatTimestamp(msg.TS)
+// renderMessage formats a single message, followed by the thread marker
+// when it starts a thread, and writes it to the builder.
+func (w *MarkdownWriter) renderMessage(b *strings.Builder, msg slackapi.Message) error {
+	m := w.markdownMessage(msg)
+	if err := w.renderBlock(b, layoutMessage, m, m.Default); err != nil {
+		return err
+	}
 
-	// Header line: **time -- sender**
-	b.WriteString(fmt.Sprintf("**%s -- %s**\n\n", timestamp, senderName))
+	// Thread parent marker
+	if msg.ReplyCount > 0 && (msg.ThreadTS == "" || msg.TS == msg.ThreadTS) {
+		return w.renderBlock(b, layoutThread, m, "**Thread replies:**\n\n")
+	}
+	return nil
+}
 
-	// Message content converted from Slack mrkdwn to standard Markdown
-	content := parser.ConvertMrkdwnToMarkdown(msg.Text, w.userResolver, w.channelResolver, w.personResolver)
-	if content != "" {
-		b.WriteString(content)
-		b.WriteString("\n\n")
+// markdownMessage converts msg for a layout's message block, rendering it
+// in the built-in layout as its Default.
+func (w *MarkdownWriter) markdownMessage(msg slackapi.Message) markdownMessage {
+	m := markdownMessage{
+		ID:         msg.TS,
+		Sender:     w.getSenderName(msg),
+		Time:       parser.FormatTimestamp(msg.TS),
+		Edited:     msg.Edited != nil,
+		ReplyCount: msg.ReplyCount,
+
+		// Message content converted from Slack mrkdwn to standard Markdown
+		Text:        parser.ConvertMrkdwnToMarkdown(parser.MessageText(msg), w.userResolver, w.channelResolver, w.personResolver),
+		Reactions:   formatReactionsMarkdown(msg.Reactions, w.emoji),
+		Attachments: w.formatAttachmentsMarkdown(msg.Attachments),
+		Files:       formatFilesMarkdown(msg.Files),
+		Metadata:    formatMetadataMarkdown(msg.Metadata),
 	}
 
-	// Reactions
-	reactText := formatReactions(msg.Reactions)
-	if reactText != "" {
-		b.WriteString(reactText)
-		b.WriteString("\n\n")
+	// A message of only emoji or a GIF goes on its header's line
+	if c, ok := compactMessageOf(msg); ok {
+		m.Compact = true
+		m.Text, m.Attachments = c.markdown(w.emoji), ""
+		line := fmt.Sprintf("**%s -- %s** %s", m.Time, m.Sender, m.Text)
+		if m.Reactions != "" {
+			line += "  " + m.Reactions
+		}
+		m.Default = line + "\n\n"
+		return m
 	}
 
-	// Attachments (blockquoted)
-	attText := w.formatAttachmentsMarkdown(msg.Attachments)
-	if attText != "" {
-		b.WriteString(attText)
-		b.WriteString("\n\n")
+	// Header line: **time -- sender**, then the content, reactions,
+	// attachments (blockquoted), files, and app metadata (collapsible)
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("**%s -- %s**\n\n", m.Time, m.Sender))
+	for _, part := range []string{m.Text, m.Reactions, m.Attachments, m.Files, m.Metadata} {
+		if part != "" {
+			b.WriteString(part)
+			b.WriteString("\n\n")
+		}
 	}
+	m.Default = b.String()
+	return m
+}
 
-	// Thread parent marker
-	if msg.ReplyCount > 0 && (msg.ThreadTS == "" || msg.TS == msg.ThreadTS) {
-		b.WriteString("**Thread replies:**\n\n")
+// formatMetadataMarkdown renders app message metadata as a collapsible
+// <details> block with the payload as an indented JSON code block.
+func formatMetadataMarkdown(md *slackapi.MessageMetadata) string {
+	if md == nil || md.EventType == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("<details>\n<summary>Metadata: %s</summary>\n\n", md.EventType))
+	if payload := metadataPayload(md, "  "); payload != "" {
+		b.WriteString("```json\n")
+		b.WriteString(payload)
+		b.WriteString("\n```\n\n")
+	}
+	b.WriteString("</details>")
+	return b.String()
+}
+
+// formatFilesMarkdown renders each file as a [File: name] reference,
+// linked to its permalink when it has one.
+func formatFilesMarkdown(files []slackapi.File) string {
+	var parts []string
+	for _, f := range files {
+		ref := fmt.Sprintf("[File: %s]", f.Name)
+		if link := orDefault(f.Permalink, f.URLPrivate); link != "" {
+			ref = fmt.Sprintf("[File: %s](%s)", f.Name, link)
+		}
+		parts = append(parts, ref)
 	}
+	return strings.Join(parts, "\n\n")
 }
 
 // formatAttachmentsMarkdown converts attachments to blockquoted markdown text.
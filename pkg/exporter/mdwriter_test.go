@@ -1,6 +1,7 @@
 package exporter
 
 import (
+	"encoding/json"
 	"strings"
 	"testing"
 
@@ -66,7 +67,7 @@ func TestRenderDailyDoc_Frontmatter(t *testing.T) {
 		{User: "U002", Text: "Hi there", TS: "1706788801.000002"},
 	}
 
-	doc, err := w.RenderDailyDoc("general", "channel", "2024-02-01", messages, nil)
+	doc, err := w.RenderDailyDoc("C001", "general", "channel", "2024-02-01", messages, nil)
 	if err != nil {
 		t.Fatalf("RenderDailyDoc() error = %v", err)
 	}
@@ -86,6 +87,50 @@ func TestRenderDailyDoc_Frontmatter(t *testing.T) {
 	mustContain(t, content, "  - Bob")
 }
 
+func TestRenderDailyDoc_FrontmatterMetadata(t *testing.T) {
+	w, _, _ := newTestMarkdownWriterWithResolvers()
+	w.SetExporterVersion("1.4.0")
+
+	messages := []slackapi.Message{
+		{User: "U001", Text: "Hello", TS: "1706788800.000001"},
+		{User: "U002", Text: "Hi there", TS: "1706788801.000002"},
+	}
+
+	doc, err := w.RenderDailyDoc("C001", "team: ops", "channel", "2024-02-01", messages, nil)
+	if err != nil {
+		t.Fatalf("RenderDailyDoc() error = %v", err)
+	}
+
+	content := string(doc)
+	mustContain(t, content, "conversation_id: C001")
+	mustContain(t, content, `conversation: "team: ops"`)
+	mustContain(t, content, "message_count: 2")
+	mustContain(t, content, `exporter_version: "1.4.0"`)
+}
+
+func TestYAMLString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"general", "general"},
+		{"John Smith", "John Smith"},
+		{"team: ops", `"team: ops"`},
+		{"#general", `"#general"`},
+		{"- list", `"- list"`},
+		{"yes", `"yes"`},
+		{"2024", `"2024"`},
+		{" padded", `" padded"`},
+		{"", `""`},
+		{`say "hi"`, `"say \"hi\""`},
+	}
+	for _, tt := range tests {
+		if got := yamlString(tt.in); got != tt.want {
+			t.Errorf("yamlString(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
 func TestRenderDailyDoc_FrontmatterPrivateChannelType(t *testing.T) {
 	w := newTestMarkdownWriter()
 
@@ -93,7 +138,7 @@ func TestRenderDailyDoc_FrontmatterPrivateChannelType(t *testing.T) {
 		{User: "U001", Text: "Secret stuff", TS: "1706788800.000001"},
 	}
 
-	doc, err := w.RenderDailyDoc("secret-channel", "private_channel", "2024-02-01", messages, nil)
+	doc, err := w.RenderDailyDoc("C001", "secret-channel", "private_channel", "2024-02-01", messages, nil)
 	if err != nil {
 		t.Fatalf("RenderDailyDoc() error = %v", err)
 	}
@@ -116,7 +161,7 @@ func TestRenderDailyDoc_MessageOrdering(t *testing.T) {
 		{User: "U001", Text: "Third", TS: "1706788810.000003"},
 	}
 
-	doc, err := w.RenderDailyDoc("general", "channel", "2024-02-01", messages, nil)
+	doc, err := w.RenderDailyDoc("C001", "general", "channel", "2024-02-01", messages, nil)
 	if err != nil {
 		t.Fatalf("RenderDailyDoc() error = %v", err)
 	}
@@ -134,6 +179,27 @@ func TestRenderDailyDoc_MessageOrdering(t *testing.T) {
 	}
 }
 
+func TestRenderMessages(t *testing.T) {
+	w, _, _ := newTestMarkdownWriterWithResolvers()
+
+	messages := []slackapi.Message{
+		{User: "U002", Text: "Second", TS: "1706788802.000002"},
+		{User: "U001", Text: "First", TS: "1706788800.000001"},
+	}
+
+	rendered, err := w.RenderMessages(messages)
+	if err != nil {
+		t.Fatalf("RenderMessages() error: %v", err)
+	}
+	content := string(rendered)
+	if strings.Contains(content, "---") {
+		t.Errorf("RenderMessages() should not include frontmatter:\n%s", content)
+	}
+	if strings.Index(content, "First") > strings.Index(content, "Second") {
+		t.Errorf("messages not in chronological order:\n%s", content)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // RenderDailyDoc: participants
 // ---------------------------------------------------------------------------
@@ -148,7 +214,7 @@ func TestRenderDailyDoc_UniqueParticipants(t *testing.T) {
 		{User: "U001", Text: "msg3", TS: "1706788802.000003"},
 	}
 
-	doc, err := w.RenderDailyDoc("general", "channel", "2024-02-01", messages, nil)
+	doc, err := w.RenderDailyDoc("C001", "general", "channel", "2024-02-01", messages, nil)
 	if err != nil {
 		t.Fatalf("RenderDailyDoc() error = %v", err)
 	}
@@ -178,7 +244,7 @@ func TestRenderDailyDoc_MultiSender(t *testing.T) {
 		{User: "U004", Text: "from Charlie", TS: "1706788802.000003"},
 	}
 
-	doc, err := w.RenderDailyDoc("team", "channel", "2024-02-01", messages, nil)
+	doc, err := w.RenderDailyDoc("C001", "team", "channel", "2024-02-01", messages, nil)
 	if err != nil {
 		t.Fatalf("RenderDailyDoc() error = %v", err)
 	}
@@ -196,7 +262,7 @@ func TestRenderDailyDoc_MultiSender(t *testing.T) {
 func TestRenderDailyDoc_EmptyMessages(t *testing.T) {
 	w := newTestMarkdownWriter()
 
-	doc, err := w.RenderDailyDoc("general", "channel", "2024-02-01", nil, nil)
+	doc, err := w.RenderDailyDoc("C001", "general", "channel", "2024-02-01", nil, nil)
 	if err != nil {
 		t.Fatalf("RenderDailyDoc() error = %v", err)
 	}
@@ -222,7 +288,7 @@ func TestRenderDailyDoc_EmptyMessages(t *testing.T) {
 func TestRenderDailyDoc_EmptySlice(t *testing.T) {
 	w := newTestMarkdownWriter()
 
-	doc, err := w.RenderDailyDoc("general", "channel", "2024-02-01", []slackapi.Message{}, nil)
+	doc, err := w.RenderDailyDoc("C001", "general", "channel", "2024-02-01", []slackapi.Message{}, nil)
 	if err != nil {
 		t.Fatalf("RenderDailyDoc() error = %v", err)
 	}
@@ -251,7 +317,7 @@ func TestRenderDailyDoc_Reactions(t *testing.T) {
 		},
 	}
 
-	doc, err := w.RenderDailyDoc("general", "channel", "2024-02-01", messages, nil)
+	doc, err := w.RenderDailyDoc("C001", "general", "channel", "2024-02-01", messages, nil)
 	if err != nil {
 		t.Fatalf("RenderDailyDoc() error = %v", err)
 	}
@@ -260,6 +326,32 @@ func TestRenderDailyDoc_Reactions(t *testing.T) {
 	mustContain(t, content, "Reactions: :thumbsup: (3) :heart: (1)")
 }
 
+func TestRenderDailyDoc_Metadata(t *testing.T) {
+	w := newTestMarkdownWriter()
+
+	messages := []slackapi.Message{
+		{
+			User: "U001",
+			Text: "Deploy finished",
+			TS:   "1706788800.000001",
+			Metadata: &slackapi.MessageMetadata{
+				EventType:    "deploy_finished",
+				EventPayload: json.RawMessage(`{"service":"api","ok":true}`),
+			},
+		},
+	}
+
+	doc, err := w.RenderDailyDoc("C001", "general", "channel", "2024-02-01", messages, nil)
+	if err != nil {
+		t.Fatalf("RenderDailyDoc() error = %v", err)
+	}
+
+	content := string(doc)
+	mustContain(t, content, "<details>\n<summary>Metadata: deploy_finished</summary>")
+	mustContain(t, content, "```json\n{\n  \"service\": \"api\",\n  \"ok\": true\n}\n```")
+	mustContain(t, content, "</details>")
+}
+
 // ---------------------------------------------------------------------------
 // RenderDailyDoc: attachments
 // ---------------------------------------------------------------------------
@@ -278,7 +370,7 @@ func TestRenderDailyDoc_Attachments(t *testing.T) {
 		},
 	}
 
-	doc, err := w.RenderDailyDoc("general", "channel", "2024-02-01", messages, nil)
+	doc, err := w.RenderDailyDoc("C001", "general", "channel", "2024-02-01", messages, nil)
 	if err != nil {
 		t.Fatalf("RenderDailyDoc() error = %v", err)
 	}
@@ -301,7 +393,7 @@ func TestRenderDailyDoc_AttachmentWithTitleLink(t *testing.T) {
 		},
 	}
 
-	doc, err := w.RenderDailyDoc("general", "channel", "2024-02-01", messages, nil)
+	doc, err := w.RenderDailyDoc("C001", "general", "channel", "2024-02-01", messages, nil)
 	if err != nil {
 		t.Fatalf("RenderDailyDoc() error = %v", err)
 	}
@@ -328,7 +420,7 @@ func TestRenderDailyDoc_ThreadParentMarker(t *testing.T) {
 		},
 	}
 
-	doc, err := w.RenderDailyDoc("general", "channel", "2024-02-01", messages, nil)
+	doc, err := w.RenderDailyDoc("C001", "general", "channel", "2024-02-01", messages, nil)
 	if err != nil {
 		t.Fatalf("RenderDailyDoc() error = %v", err)
 	}
@@ -348,7 +440,7 @@ func TestRenderDailyDoc_NoThreadMarkerForNonParent(t *testing.T) {
 		},
 	}
 
-	doc, err := w.RenderDailyDoc("general", "channel", "2024-02-01", messages, nil)
+	doc, err := w.RenderDailyDoc("C001", "general", "channel", "2024-02-01", messages, nil)
 	if err != nil {
 		t.Fatalf("RenderDailyDoc() error = %v", err)
 	}
@@ -369,7 +461,7 @@ func TestRenderDailyDoc_MessageFormat(t *testing.T) {
 		{User: "U001", Text: "Hello world", TS: "1706788800.000001"},
 	}
 
-	doc, err := w.RenderDailyDoc("general", "channel", "2024-02-01", messages, nil)
+	doc, err := w.RenderDailyDoc("C001", "general", "channel", "2024-02-01", messages, nil)
 	if err != nil {
 		t.Fatalf("RenderDailyDoc() error = %v", err)
 	}
@@ -393,7 +485,7 @@ func TestRenderDailyDoc_BotSender(t *testing.T) {
 		{Username: "deploy-bot", Text: "Deployed v1.0", TS: "1706788800.000001"},
 	}
 
-	doc, err := w.RenderDailyDoc("general", "channel", "2024-02-01", messages, nil)
+	doc, err := w.RenderDailyDoc("C001", "general", "channel", "2024-02-01", messages, nil)
 	if err != nil {
 		t.Fatalf("RenderDailyDoc() error = %v", err)
 	}
@@ -644,7 +736,7 @@ func TestRenderDailyDoc_FullIntegration(t *testing.T) {
 		},
 	}
 
-	doc, err := w.RenderDailyDoc("engineering", "channel", "2024-02-01", messages, nil)
+	doc, err := w.RenderDailyDoc("C001", "engineering", "channel", "2024-02-01", messages, nil)
 	if err != nil {
 		t.Fatalf("RenderDailyDoc() error = %v", err)
 	}
@@ -697,7 +789,7 @@ func TestRenderDailyDoc_WithFilterResult(t *testing.T) {
 		},
 	}
 
-	doc, err := w.RenderDailyDoc("general", "channel", "2024-02-01", messages, filterResult)
+	doc, err := w.RenderDailyDoc("C001", "general", "channel", "2024-02-01", messages, filterResult)
 	if err != nil {
 		t.Fatalf("RenderDailyDoc() error = %v", err)
 	}
@@ -733,7 +825,7 @@ func TestRenderDailyDoc_WithFilterResultZeroFiltered(t *testing.T) {
 		CategoryBreakdown: map[string]int{},
 	}
 
-	doc, err := w.RenderDailyDoc("general", "channel", "2024-02-01", messages, filterResult)
+	doc, err := w.RenderDailyDoc("C001", "general", "channel", "2024-02-01", messages, filterResult)
 	if err != nil {
 		t.Fatalf("RenderDailyDoc() error = %v", err)
 	}
@@ -757,7 +849,7 @@ func TestRenderDailyDoc_WithNilFilterResult(t *testing.T) {
 		{User: "U001", Text: "Hello", TS: "1706788800.000001"},
 	}
 
-	doc, err := w.RenderDailyDoc("general", "channel", "2024-02-01", messages, nil)
+	doc, err := w.RenderDailyDoc("C001", "general", "channel", "2024-02-01", messages, nil)
 	if err != nil {
 		t.Fatalf("RenderDailyDoc() error = %v", err)
 	}
@@ -0,0 +1,377 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// mentionSnippetLen bounds the message text kept for each mention.
+const mentionSnippetLen = 200
+
+// MentionIndex records, for every person, each exported message in which
+// they were @-mentioned, and every exported thread reply. It accumulates
+// across runs so per-person backlink files and the interaction graph cover
+// the whole archive, not just the latest sync.
+type MentionIndex struct {
+	mu sync.RWMutex
+
+	// People maps Slack user ID to that person's mentions
+	People map[string]*PersonMentions `json:"people"`
+
+	// Replies maps conversation ID and reply timestamp (see replyKey) to
+	// the thread replies exported
+	Replies map[string]*Reply `json:"replies,omitempty"`
+
+	// UpdatedAt is the last time this index was modified
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// path is where this index is saved (not serialized)
+	path string
+}
+
+// PersonMentions holds the mentions of a single person.
+type PersonMentions struct {
+	// Name is the person's name as resolved when last mentioned.
+	Name string `json:"name"`
+
+	Mentions []*Mention `json:"mentions"`
+}
+
+// Mention is one message that @-mentions a person.
+type Mention struct {
+	ConversationID   string `json:"conversation_id"`
+	ConversationName string `json:"conversation_name"`
+	ConversationType string `json:"conversation_type"`
+	Date             string `json:"date"`
+	TS               string `json:"ts"`
+	ThreadTS         string `json:"thread_ts,omitempty"`
+	Author           string `json:"author"`
+	AuthorID         string `json:"author_id,omitempty"`
+	Snippet          string `json:"snippet"`
+	DocURL           string `json:"doc_url,omitempty"`
+}
+
+// Reply is one thread reply: who replied to whose thread.
+type Reply struct {
+	ConversationID string `json:"conversation_id"`
+	TS             string `json:"ts"`
+	ThreadTS       string `json:"thread_ts"`
+	AuthorID       string `json:"author_id"`
+	Author         string `json:"author"`
+	ParentAuthorID string `json:"parent_author_id"`
+	ParentAuthor   string `json:"parent_author"`
+}
+
+// NewMentionIndex creates a new empty mention index.
+func NewMentionIndex(path string) *MentionIndex {
+	return &MentionIndex{
+		People:    make(map[string]*PersonMentions),
+		Replies:   make(map[string]*Reply),
+		UpdatedAt: time.Now(),
+		path:      path,
+	}
+}
+
+// LoadMentionIndex loads a mention index from a file, or creates a new one.
+func LoadMentionIndex(path string) (*MentionIndex, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return NewMentionIndex(path), nil
+		}
+		return nil, fmt.Errorf("failed to read mention index: %w", err)
+	}
+
+	var index MentionIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse mention index: %w", err)
+	}
+	index.path = path
+	if index.People == nil {
+		index.People = make(map[string]*PersonMentions)
+	}
+	if index.Replies == nil {
+		index.Replies = make(map[string]*Reply)
+	}
+	return &index, nil
+}
+
+// DefaultMentionIndexPath returns the default path for the mention index.
+func DefaultMentionIndexPath(configDir string) string {
+	return filepath.Join(configDir, "_metadata", "mentions-index.json")
+}
+
+// Save writes the mention index to disk.
+func (idx *MentionIndex) Save() error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.UpdatedAt = time.Now()
+
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal mention index: %w", err)
+	}
+
+	if err := os.WriteFile(idx.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write mention index: %w", err)
+	}
+	return nil
+}
+
+// Add records a mention of userID. A mention of the same person in the
+// same message replaces the earlier record (e.g. after an edit or a
+// re-export), so repeated runs do not create duplicates.
+func (idx *MentionIndex) Add(userID, name string, m *Mention) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	pm, ok := idx.People[userID]
+	if !ok {
+		pm = &PersonMentions{}
+		idx.People[userID] = pm
+	}
+	if name != "" {
+		pm.Name = name
+	}
+	for i, existing := range pm.Mentions {
+		if existing.ConversationID == m.ConversationID && existing.TS == m.TS {
+			pm.Mentions[i] = m
+			return
+		}
+	}
+	pm.Mentions = append(pm.Mentions, m)
+}
+
+// AddReply records a thread reply, replacing an earlier record of the same
+// reply.
+func (idx *MentionIndex) AddReply(r *Reply) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.Replies[replyKey(r.ConversationID, r.TS)] = r
+}
+
+// replyKey is the key of a reply in MentionIndex.Replies.
+func replyKey(convID, ts string) string {
+	return convID + "/" + ts
+}
+
+// Person returns the mentions recorded for userID, or nil.
+func (idx *MentionIndex) Person(userID string) *PersonMentions {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.People[userID]
+}
+
+// PersonIDs returns the IDs of everyone with at least one mention, sorted.
+func (idx *MentionIndex) PersonIDs() []string {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	ids := make([]string, 0, len(idx.People))
+	for id, pm := range idx.People {
+		if len(pm.Mentions) > 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// MentionRecorder extracts @-mentions from exported messages and records
+// them in a MentionIndex, resolving names the same way the writers do.
+type MentionRecorder struct {
+	index           *MentionIndex
+	userResolver    *parser.UserResolver
+	channelResolver *parser.ChannelResolver
+	personResolver  *parser.PersonResolver
+}
+
+// NewMentionRecorder creates a MentionRecorder writing into index.
+func NewMentionRecorder(index *MentionIndex, userResolver *parser.UserResolver, channelResolver *parser.ChannelResolver, personResolver *parser.PersonResolver) *MentionRecorder {
+	return &MentionRecorder{
+		index:           index,
+		userResolver:    userResolver,
+		channelResolver: channelResolver,
+		personResolver:  personResolver,
+	}
+}
+
+// RecordMessages records every mention in msgs, which were written to the
+// doc at docURL. Returns the number of mentions recorded.
+func (r *MentionRecorder) RecordMessages(convID, convName, convType, docURL string, msgs []slackapi.Message) int {
+	count := 0
+	for _, msg := range msgs {
+		msgText := parser.MessageText(msg)
+		ids := parser.ExtractUserMentions(msgText)
+		if len(ids) == 0 {
+			continue
+		}
+		text, _ := parser.ConvertMrkdwnWithLinks(msgText, r.userResolver, r.channelResolver, r.personResolver, nil)
+		for _, id := range ids {
+			threadTS := ""
+			if msg.ThreadTS != "" && msg.ThreadTS != msg.TS {
+				threadTS = msg.ThreadTS
+			}
+			r.index.Add(id, r.resolveName(id), &Mention{
+				ConversationID:   convID,
+				ConversationName: convName,
+				ConversationType: convType,
+				Date:             DateFromTS(msg.TS),
+				TS:               msg.TS,
+				ThreadTS:         threadTS,
+				Author:           r.resolveName(msg.User),
+				AuthorID:         msg.User,
+				Snippet:          truncate(text, mentionSnippetLen),
+				DocURL:           docURL,
+			})
+			count++
+		}
+	}
+	return count
+}
+
+// RecordReplies records who replied to parent's author in replies.
+// Returns the number of replies recorded.
+func (r *MentionRecorder) RecordReplies(convID string, parent slackapi.Message, replies []slackapi.Message) int {
+	if parent.User == "" {
+		return 0
+	}
+	count := 0
+	for _, msg := range replies {
+		if msg.User == "" || msg.TS == parent.TS {
+			continue
+		}
+		r.index.AddReply(&Reply{
+			ConversationID: convID,
+			TS:             msg.TS,
+			ThreadTS:       parent.TS,
+			AuthorID:       msg.User,
+			Author:         r.resolveName(msg.User),
+			ParentAuthorID: parent.User,
+			ParentAuthor:   r.resolveName(parent.User),
+		})
+		count++
+	}
+	return count
+}
+
+// resolveName returns the name for a user ID (see resolveUserName).
+func (r *MentionRecorder) resolveName(userID string) string {
+	return resolveUserName(userID, r.userResolver, r.personResolver)
+}
+
+// resolveUserName returns the name for a user ID: people.json first, then
+// the Slack user cache, then the ID itself.
+func resolveUserName(userID string, users *parser.UserResolver, people *parser.PersonResolver) string {
+	if userID == "" {
+		return ""
+	}
+	if name := people.ResolveName(userID); name != "" {
+		return name
+	}
+	if users != nil {
+		return users.Resolve(userID)
+	}
+	return userID
+}
+
+// RenderMentionsMarkdown renders the backlink page for one person: every
+// message that mentions them, newest first, grouped by conversation.
+func RenderMentionsMarkdown(userID string, pm *PersonMentions) []byte {
+	name := pm.Name
+	if name == "" {
+		name = userID
+	}
+
+	byConv := make(map[string][]*Mention)
+	convNames := make(map[string]string)
+	for _, m := range pm.Mentions {
+		byConv[m.ConversationID] = append(byConv[m.ConversationID], m)
+		convNames[m.ConversationID] = m.ConversationName
+	}
+	convIDs := make([]string, 0, len(byConv))
+	for id := range byConv {
+		convIDs = append(convIDs, id)
+	}
+	sort.Slice(convIDs, func(i, j int) bool {
+		return convNames[convIDs[i]] < convNames[convIDs[j]]
+	})
+
+	var b strings.Builder
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "person: %q\n", name)
+	fmt.Fprintf(&b, "slack_id: %s\n", userID)
+	fmt.Fprintf(&b, "mentions: %d\n", len(pm.Mentions))
+	b.WriteString("---\n\n")
+	fmt.Fprintf(&b, "# Mentions of %s\n", name)
+
+	for _, id := range convIDs {
+		mentions := byConv[id]
+		sort.Slice(mentions, func(i, j int) bool {
+			return mentions[i].TS > mentions[j].TS
+		})
+		fmt.Fprintf(&b, "\n## %s\n\n", convNames[id])
+		for _, m := range mentions {
+			when := TSToTime(m.TS).Format("2006-01-02 15:04")
+			line := fmt.Sprintf("- %s", when)
+			if m.DocURL != "" {
+				line = fmt.Sprintf("- [%s](%s)", when, m.DocURL)
+			}
+			if m.ThreadTS != "" {
+				line += " (thread reply)"
+			}
+			if m.Author != "" {
+				line += " **" + m.Author + "**:"
+			}
+			fmt.Fprintf(&b, "%s %s\n", line, m.Snippet)
+		}
+	}
+	return []byte(b.String())
+}
+
+// WriteMentionFiles writes one backlink page per person into dir, named
+// after the person (e.g. jane-doe.md), and returns the number written.
+// When userIDs is empty, pages are written for everyone in the index.
+func WriteMentionFiles(dir string, index *MentionIndex, userIDs []string) (int, error) {
+	if len(userIDs) == 0 {
+		userIDs = index.PersonIDs()
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	written := 0
+	for _, id := range userIDs {
+		pm := index.Person(id)
+		if pm == nil || len(pm.Mentions) == 0 {
+			continue
+		}
+		if err := atomicWriteFile(dir, filepath.Join(dir, MentionFileName(id, pm.Name)), RenderMentionsMarkdown(id, pm)); err != nil {
+			return written, err
+		}
+		written++
+	}
+	return written, nil
+}
+
+// MentionFileName returns the backlink file name for a person. The Slack
+// ID is appended so two people with the same name do not collide.
+func MentionFileName(userID, name string) string {
+	if s := sanitizeName(name); s != "" && name != userID {
+		return s + "-" + strings.ToLower(userID) + ".md"
+	}
+	return strings.ToLower(userID) + ".md"
+}
@@ -0,0 +1,119 @@
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+func TestMentionRecorder_RecordMessages(t *testing.T) {
+	users := parser.NewUserResolver()
+	users.AddUser(&slackapi.User{ID: "U001", Name: "alice", Profile: slackapi.UserProfile{DisplayName: "Alice"}})
+	users.AddUser(&slackapi.User{ID: "U002", Name: "bob", Profile: slackapi.UserProfile{DisplayName: "Bob"}})
+	people := parser.NewPersonResolver(&config.PeopleConfig{People: []config.PersonConfig{
+		{SlackID: "U003", DisplayName: "Carol (people.json)"},
+	}})
+
+	idx := NewMentionIndex(filepath.Join(t.TempDir(), "mentions.json"))
+	rec := NewMentionRecorder(idx, users, parser.NewChannelResolver(), people)
+
+	n := rec.RecordMessages("C001", "general", "channel", "https://docs.google.com/d/1", []slackapi.Message{
+		{TS: "1700000000.000100", User: "U001", Text: "hey <@U002> and <@U003>"},
+		{TS: "1700000100.000100", User: "U002", Text: "no mentions here"},
+		{TS: "1700000200.000100", User: "U002", ThreadTS: "1700000000.000100", Text: "<@U001> see above"},
+	})
+	if n != 3 {
+		t.Fatalf("RecordMessages() = %d, want 3", n)
+	}
+
+	bob := idx.Person("U002")
+	if bob == nil || len(bob.Mentions) != 1 {
+		t.Fatalf("U002 mentions = %+v", bob)
+	}
+	m := bob.Mentions[0]
+	if m.Author != "Alice" || m.Snippet != "hey @Bob and @Carol (people.json)" || m.DocURL != "https://docs.google.com/d/1" {
+		t.Errorf("mention = %+v", m)
+	}
+	if idx.Person("U003").Name != "Carol (people.json)" {
+		t.Errorf("U003 name = %q, want people.json name", idx.Person("U003").Name)
+	}
+	if alice := idx.Person("U001"); alice.Mentions[0].ThreadTS != "1700000000.000100" {
+		t.Errorf("thread reply ThreadTS = %q", alice.Mentions[0].ThreadTS)
+	}
+
+	// Re-recording the same message replaces rather than duplicates.
+	rec.RecordMessages("C001", "general", "channel", "https://docs.google.com/d/2", []slackapi.Message{
+		{TS: "1700000000.000100", User: "U001", Text: "hey <@U002> (edited)"},
+	})
+	if bob := idx.Person("U002"); len(bob.Mentions) != 1 || bob.Mentions[0].DocURL != "https://docs.google.com/d/2" {
+		t.Errorf("after re-record: %+v", bob.Mentions)
+	}
+}
+
+func TestMentionIndex_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "_metadata", "mentions-index.json")
+	idx := NewMentionIndex(path)
+	idx.Add("U001", "Alice", &Mention{ConversationID: "C001", TS: "1.1"})
+	if err := idx.Save(); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	loaded, err := LoadMentionIndex(path)
+	if err != nil {
+		t.Fatalf("LoadMentionIndex() error: %v", err)
+	}
+	if ids := loaded.PersonIDs(); len(ids) != 1 || ids[0] != "U001" {
+		t.Errorf("PersonIDs() = %v", ids)
+	}
+
+	missing, err := LoadMentionIndex(filepath.Join(t.TempDir(), "nope.json"))
+	if err != nil || len(missing.PersonIDs()) != 0 {
+		t.Errorf("missing index = %v, %v; want empty index", missing, err)
+	}
+}
+
+func TestRenderMentionsMarkdown(t *testing.T) {
+	pm := &PersonMentions{Name: "Bob", Mentions: []*Mention{
+		{ConversationID: "D001", ConversationName: "alice", TS: "1700000000.000100", Author: "Alice", Snippet: "hi @Bob"},
+		{ConversationID: "C001", ConversationName: "general", TS: "1700000000.000100", Author: "Alice", Snippet: "old", DocURL: "https://docs.google.com/d/1"},
+		{ConversationID: "C001", ConversationName: "general", TS: "1700090000.000100", ThreadTS: "1700000000.000100", Author: "Carol", Snippet: "new"},
+	}}
+
+	out := string(RenderMentionsMarkdown("U002", pm))
+	for _, want := range []string{"person: \"Bob\"", "mentions: 3", "# Mentions of Bob", "## alice", "](https://docs.google.com/d/1) **Alice**: old", "(thread reply) **Carol**: new"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "## alice") > strings.Index(out, "## general") {
+		t.Error("conversations should be sorted by name")
+	}
+	if strings.Index(out, "**Carol**: new") > strings.Index(out, "**Alice**: old") {
+		t.Error("mentions should be newest first")
+	}
+}
+
+func TestWriteMentionFiles(t *testing.T) {
+	idx := NewMentionIndex("")
+	idx.Add("U001", "Jane Doe", &Mention{ConversationID: "C001", TS: "1.1"})
+	idx.Add("U002", "", &Mention{ConversationID: "C001", TS: "1.2"})
+
+	dir := filepath.Join(t.TempDir(), "_mentions")
+	n, err := WriteMentionFiles(dir, idx, nil)
+	if err != nil {
+		t.Fatalf("WriteMentionFiles() error: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("written = %d, want 2", n)
+	}
+	for _, name := range []string{"jane-doe-u001.md", "u002.md"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected %s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// InteractionGraph is who mentions and replies to whom across the archive,
+// built from a MentionIndex for org-network analysis.
+type InteractionGraph struct {
+	// Nodes are the people in any edge, sorted by ID.
+	Nodes []GraphNode
+
+	// Edges are the interacting pairs, sorted by source then target.
+	Edges []GraphEdge
+}
+
+// GraphNode is a person in an InteractionGraph.
+type GraphNode struct {
+	ID   string
+	Name string
+}
+
+// GraphEdge counts the messages in which Source interacted with Target.
+type GraphEdge struct {
+	Source string
+	Target string
+
+	// Mentions is the number of messages by Source that @-mention Target.
+	Mentions int
+
+	// Replies is the number of replies by Source in threads Target started.
+	Replies int
+}
+
+// Messages is the number of messages behind the edge.
+func (e GraphEdge) Messages() int {
+	return e.Mentions + e.Replies
+}
+
+// BuildInteractionGraph aggregates the mentions and replies in index into
+// a graph. Mentions recorded without an author ID (by versions before the
+// graph existed) and people mentioning or replying to themselves are left
+// out.
+func BuildInteractionGraph(index *MentionIndex) *InteractionGraph {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+
+	names := make(map[string]string)
+	setName := func(id, name string) {
+		if name != "" && name != id {
+			names[id] = name
+		} else if _, ok := names[id]; !ok {
+			names[id] = ""
+		}
+	}
+	edges := make(map[[2]string]*GraphEdge)
+	edge := func(source, target string) *GraphEdge {
+		key := [2]string{source, target}
+		e, ok := edges[key]
+		if !ok {
+			e = &GraphEdge{Source: source, Target: target}
+			edges[key] = e
+		}
+		return e
+	}
+
+	for id, pm := range index.People {
+		for _, m := range pm.Mentions {
+			if m.AuthorID == "" || m.AuthorID == id {
+				continue
+			}
+			setName(m.AuthorID, m.Author)
+			setName(id, pm.Name)
+			edge(m.AuthorID, id).Mentions++
+		}
+	}
+	for _, r := range index.Replies {
+		if r.AuthorID == "" || r.ParentAuthorID == "" || r.AuthorID == r.ParentAuthorID {
+			continue
+		}
+		setName(r.AuthorID, r.Author)
+		setName(r.ParentAuthorID, r.ParentAuthor)
+		edge(r.AuthorID, r.ParentAuthorID).Replies++
+	}
+
+	g := &InteractionGraph{}
+	for id, name := range names {
+		g.Nodes = append(g.Nodes, GraphNode{ID: id, Name: name})
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].ID < g.Nodes[j].ID })
+	for _, e := range edges {
+		g.Edges = append(g.Edges, *e)
+	}
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].Source != g.Edges[j].Source {
+			return g.Edges[i].Source < g.Edges[j].Source
+		}
+		return g.Edges[i].Target < g.Edges[j].Target
+	})
+	return g
+}
+
+// name returns the name of the person with id, or the ID itself.
+func (g *InteractionGraph) name(id string) string {
+	i := sort.Search(len(g.Nodes), func(i int) bool { return g.Nodes[i].ID >= id })
+	if i < len(g.Nodes) && g.Nodes[i].ID == id && g.Nodes[i].Name != "" {
+		return g.Nodes[i].Name
+	}
+	return id
+}
+
+// WriteCSV writes one row per edge, with a header row.
+func (g *InteractionGraph) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"source_id", "source_name", "target_id", "target_name", "mentions", "replies", "messages"}); err != nil {
+		return err
+	}
+	for _, e := range g.Edges {
+		if err := cw.Write([]string{
+			e.Source, g.name(e.Source),
+			e.Target, g.name(e.Target),
+			strconv.Itoa(e.Mentions), strconv.Itoa(e.Replies), strconv.Itoa(e.Messages()),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteGraphML writes the graph as a directed GraphML document, with each
+// person's name on their node and the counts on each edge; weight is the
+// total number of messages.
+func (g *InteractionGraph) WriteGraphML(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	b.WriteString(`  <key id="name" for="node" attr.name="name" attr.type="string"/>` + "\n")
+	b.WriteString(`  <key id="mentions" for="edge" attr.name="mentions" attr.type="int"/>` + "\n")
+	b.WriteString(`  <key id="replies" for="edge" attr.name="replies" attr.type="int"/>` + "\n")
+	b.WriteString(`  <key id="weight" for="edge" attr.name="weight" attr.type="int"/>` + "\n")
+	b.WriteString(`  <graph id="interactions" edgedefault="directed">` + "\n")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&b, "    <node id=\"%s\"><data key=\"name\">%s</data></node>\n", xmlEscape(n.ID), xmlEscape(g.name(n.ID)))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "    <edge source=\"%s\" target=\"%s\"><data key=\"mentions\">%d</data><data key=\"replies\">%d</data><data key=\"weight\">%d</data></edge>\n",
+			xmlEscape(e.Source), xmlEscape(e.Target), e.Mentions, e.Replies, e.Messages())
+	}
+	b.WriteString("  </graph>\n</graphml>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// xmlEscape escapes s for use in XML text or a quoted attribute.
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,88 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+func TestBuildInteractionGraph(t *testing.T) {
+	users := parser.NewUserResolver()
+	users.AddUser(&slackapi.User{ID: "U001", Name: "alice", Profile: slackapi.UserProfile{DisplayName: "Alice"}})
+	users.AddUser(&slackapi.User{ID: "U002", Name: "bob", Profile: slackapi.UserProfile{DisplayName: "Bob, Jr."}})
+
+	idx := NewMentionIndex("")
+	rec := NewMentionRecorder(idx, users, parser.NewChannelResolver(), nil)
+	rec.RecordMessages("C001", "general", "channel", "", []slackapi.Message{
+		{TS: "1.1", User: "U001", Text: "hey <@U002>"},
+		{TS: "1.2", User: "U001", Text: "<@U002> again, and <@U003>"},
+		{TS: "1.3", User: "U001", Text: "note to self <@U001>"},
+	})
+	parent := slackapi.Message{TS: "1.1", User: "U001"}
+	if n := rec.RecordReplies("C001", parent, []slackapi.Message{
+		{TS: "1.1", User: "U001", ThreadTS: "1.1"},
+		{TS: "1.4", User: "U002", ThreadTS: "1.1"},
+		{TS: "1.5", User: "U001", ThreadTS: "1.1"},
+	}); n != 2 {
+		t.Fatalf("RecordReplies() = %d, want 2", n)
+	}
+	// A mention recorded before author IDs were kept is left out.
+	idx.Add("U002", "Bob, Jr.", &Mention{ConversationID: "C001", TS: "0.9", Author: "Alice"})
+
+	g := BuildInteractionGraph(idx)
+	if len(g.Nodes) != 3 || g.Nodes[0] != (GraphNode{ID: "U001", Name: "Alice"}) || g.Nodes[2] != (GraphNode{ID: "U003"}) {
+		t.Errorf("nodes = %+v", g.Nodes)
+	}
+	want := []GraphEdge{
+		{Source: "U001", Target: "U002", Mentions: 2},
+		{Source: "U001", Target: "U003", Mentions: 1},
+		{Source: "U002", Target: "U001", Replies: 1},
+	}
+	if len(g.Edges) != len(want) {
+		t.Fatalf("edges = %+v, want %+v", g.Edges, want)
+	}
+	for i := range want {
+		if g.Edges[i] != want[i] {
+			t.Errorf("edge %d = %+v, want %+v", i, g.Edges[i], want[i])
+		}
+	}
+
+	var csvOut bytes.Buffer
+	if err := g.WriteCSV(&csvOut); err != nil {
+		t.Fatalf("WriteCSV() error: %v", err)
+	}
+	wantCSV := "source_id,source_name,target_id,target_name,mentions,replies,messages\n" +
+		"U001,Alice,U002,\"Bob, Jr.\",2,0,2\n" +
+		"U001,Alice,U003,U003,1,0,1\n" +
+		"U002,\"Bob, Jr.\",U001,Alice,0,1,1\n"
+	if csvOut.String() != wantCSV {
+		t.Errorf("CSV =\n%s\nwant\n%s", csvOut.String(), wantCSV)
+	}
+
+	var graphML bytes.Buffer
+	if err := g.WriteGraphML(&graphML); err != nil {
+		t.Fatalf("WriteGraphML() error: %v", err)
+	}
+	var doc struct {
+		Nodes []struct {
+			ID string `xml:"id,attr"`
+		} `xml:"graph>node"`
+		Edges []struct {
+			Source string `xml:"source,attr"`
+			Data   []int  `xml:"data"`
+		} `xml:"graph>edge"`
+	}
+	if err := xml.Unmarshal(graphML.Bytes(), &doc); err != nil {
+		t.Fatalf("GraphML does not parse: %v\n%s", err, graphML.String())
+	}
+	if len(doc.Nodes) != 3 || len(doc.Edges) != 3 || len(doc.Edges[0].Data) != 3 || doc.Edges[0].Data[2] != 2 {
+		t.Errorf("GraphML = %+v", doc)
+	}
+	if !strings.Contains(graphML.String(), `edgedefault="directed"`) {
+		t.Errorf("GraphML graph is not directed:\n%s", graphML.String())
+	}
+}
@@ -0,0 +1,107 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// ConversationMessageMap maps each exported message of one conversation to
+// the Google Doc it was written to. It is saved as <conversation ID>.json
+// in the message map directory for tools that need to answer "where did
+// this Slack message end up in the archive" without reading the index.
+type ConversationMessageMap struct {
+	ConversationID   string    `json:"conversation_id"`
+	ConversationName string    `json:"conversation_name"`
+	UpdatedAt        time.Time `json:"updated_at"`
+
+	// Messages maps a message's Slack TS to its doc URL. Thread replies map
+	// to their thread doc.
+	Messages map[string]string `json:"messages"`
+}
+
+// MessageMap records where exported messages were written, one file per
+// conversation, accumulating across runs. Conversations are loaded when
+// first recorded and dropped from memory once saved. It is safe for
+// concurrent use.
+type MessageMap struct {
+	dir string
+
+	mu    sync.Mutex
+	convs map[string]*ConversationMessageMap
+}
+
+// DefaultMessageMapDir returns the default directory of the message map.
+func DefaultMessageMapDir(configDir string) string {
+	return filepath.Join(configDir, "_metadata", "message-map")
+}
+
+// NewMessageMap creates a message map stored in dir.
+func NewMessageMap(dir string) *MessageMap {
+	return &MessageMap{dir: dir, convs: make(map[string]*ConversationMessageMap)}
+}
+
+// LoadConversationMessageMap reads the message map of one conversation from
+// dir. A conversation with no map yet returns an empty one.
+func LoadConversationMessageMap(dir, convID string) (*ConversationMessageMap, error) {
+	cm := &ConversationMessageMap{ConversationID: convID}
+	data, err := os.ReadFile(filepath.Join(dir, convID+".json"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read message map: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, cm); err != nil {
+			return nil, fmt.Errorf("failed to parse message map for %s: %w", convID, err)
+		}
+	}
+	if cm.Messages == nil {
+		cm.Messages = make(map[string]string)
+	}
+	return cm, nil
+}
+
+// Record maps each of msgs to docURL.
+func (m *MessageMap) Record(convID, convName, docURL string, msgs []slackapi.Message) error {
+	if docURL == "" || len(msgs) == 0 {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cm, ok := m.convs[convID]
+	if !ok {
+		var err error
+		if cm, err = LoadConversationMessageMap(m.dir, convID); err != nil {
+			return err
+		}
+		m.convs[convID] = cm
+	}
+	cm.ConversationName = convName
+	for _, msg := range msgs {
+		cm.Messages[msg.TS] = docURL
+	}
+	return nil
+}
+
+// Save writes the message map of convID, if anything was recorded for it,
+// and drops it from memory.
+func (m *MessageMap) Save(convID string) error {
+	m.mu.Lock()
+	cm, ok := m.convs[convID]
+	delete(m.convs, convID)
+	m.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	cm.UpdatedAt = time.Now()
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create message map directory: %w", err)
+	}
+	return writeJSONFile(m.dir, convID+".json", cm)
+}
@@ -0,0 +1,91 @@
+package exporter
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+func TestMessageMap_AccumulatesAcrossRuns(t *testing.T) {
+	dir := t.TempDir()
+
+	m := NewMessageMap(dir)
+	if err := m.Record("C001", "general", "https://docs/a", []slackapi.Message{{TS: "1.1"}, {TS: "1.2"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Save("C001"); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	m = NewMessageMap(dir)
+	if err := m.Record("C001", "general", "https://docs/b", []slackapi.Message{{TS: "1.2"}, {TS: "2.1"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Save("C001"); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	cm, err := LoadConversationMessageMap(dir, "C001")
+	if err != nil {
+		t.Fatalf("LoadConversationMessageMap() error: %v", err)
+	}
+	want := map[string]string{"1.1": "https://docs/a", "1.2": "https://docs/b", "2.1": "https://docs/b"}
+	if len(cm.Messages) != len(want) {
+		t.Fatalf("Messages = %v, want %v", cm.Messages, want)
+	}
+	for ts, url := range want {
+		if cm.Messages[ts] != url {
+			t.Errorf("Messages[%s] = %q, want %q", ts, cm.Messages[ts], url)
+		}
+	}
+	if cm.ConversationName != "general" {
+		t.Errorf("ConversationName = %q, want general", cm.ConversationName)
+	}
+}
+
+func TestMessageMap_SaveWithoutRecords(t *testing.T) {
+	dir := t.TempDir()
+	if err := NewMessageMap(dir).Save("C001"); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	cm, err := LoadConversationMessageMap(dir, "C001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cm.Messages) != 0 {
+		t.Errorf("Messages = %v, want none", cm.Messages)
+	}
+}
+
+func TestExportConversation_WritesMessageMap(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	dir := filepath.Join(t.TempDir(), "message-map")
+	exp.messageMap = NewMessageMap(dir)
+
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+
+	cm, err := LoadConversationMessageMap(dir, "C001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	convExport := exp.index.GetConversation("C001")
+	mainDoc := convExport.DailyDocs["2024-02-01"].DocURL
+	threadDoc := convExport.Threads["1706792400.000200"].DailyDocs["2024-02-01"].DocURL
+	if mainDoc == "" || threadDoc == "" {
+		t.Fatal("expected main and thread docs in the index")
+	}
+	if got := cm.Messages["1706788800.000100"]; got != mainDoc {
+		t.Errorf("main message maps to %q, want %q", got, mainDoc)
+	}
+	if got := cm.Messages["1706792460.000400"]; got != threadDoc {
+		t.Errorf("thread reply maps to %q, want %q", got, threadDoc)
+	}
+	if len(cm.Messages) != 4 {
+		t.Errorf("len(Messages) = %d, want 4 (3 main messages and 1 reply)", len(cm.Messages))
+	}
+}
@@ -0,0 +1,275 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/errcat"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// Metrics collects counters and gauges about export runs and serves them in
+// the Prometheus text format (e.g. for `export --metrics-addr`). It
+// observes the Slack and Google API clients as a slackapi.Observer and a
+// gdrive.Observer, and is fed by the exporter. Unlike RunStats, counters
+// are kept across runs, as Prometheus expects. All methods are safe for
+// concurrent use and treat a nil *Metrics as a no-op.
+type Metrics struct {
+	mu                sync.Mutex
+	messagesFetched   int64
+	messagesWritten   int64
+	slackRequests     map[labelPair]int64   // endpoint, result
+	slackWaits        map[labelPair]int64   // endpoint, reason
+	slackWaitSeconds  map[labelPair]float64 // endpoint, reason
+	googleRequests    map[labelPair]int64   // method, status
+	docsBatchUpdates  int64
+	errors            map[string]int64 // type
+	runConversations  int
+	doneConversations int
+	fetchedByConv     map[string]int64
+	exporting         map[string]bool // conversation → exporting now
+}
+
+// labelPair is the values of a metric's two labels.
+type labelPair [2]string
+
+// NewMetrics creates an empty Metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		slackRequests:    make(map[labelPair]int64),
+		slackWaits:       make(map[labelPair]int64),
+		slackWaitSeconds: make(map[labelPair]float64),
+		googleRequests:   make(map[labelPair]int64),
+		errors:           make(map[string]int64),
+		fetchedByConv:    make(map[string]int64),
+		exporting:        make(map[string]bool),
+	}
+}
+
+// ObserveRequest counts a Slack API request attempt by its result.
+func (m *Metrics) ObserveRequest(endpoint string, err error) {
+	if m == nil {
+		return
+	}
+	result := "ok"
+	var rateLimit *slackapi.RateLimitError
+	var serverErr *slackapi.ServerError
+	var apiErr *slackapi.APIError
+	switch {
+	case err == nil:
+	case errors.As(err, &rateLimit):
+		result = "rate_limited"
+	case errors.As(err, &serverErr):
+		result = "server_error"
+	case errors.As(err, &apiErr):
+		result = "api_error"
+	default:
+		result = "error"
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.slackRequests[labelPair{endpoint, result}]++
+}
+
+// ObserveWait counts a sleep before a Slack API request.
+func (m *Metrics) ObserveWait(endpoint string, reason slackapi.WaitReason, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := labelPair{endpoint, string(reason)}
+	m.slackWaits[key]++
+	m.slackWaitSeconds[key] += d.Seconds()
+}
+
+// ObserveGoogleRequest counts a Google API request by method and status.
+func (m *Metrics) ObserveGoogleRequest(name string, status int, err error) {
+	if m == nil {
+		return
+	}
+	code := fmt.Sprint(status)
+	if err != nil {
+		code = "error"
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.googleRequests[labelPair{name, code}]++
+	if name == "docs.batchUpdate" {
+		m.docsBatchUpdates++
+	}
+}
+
+// Start records the start of a run over total conversations.
+func (m *Metrics) Start(total int) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runConversations = total
+	m.doneConversations = 0
+}
+
+// Begin records that a conversation is being exported.
+func (m *Metrics) Begin(name string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.exporting[name] = true
+}
+
+// Finish records that a conversation is done, and the error it failed
+// with, if any.
+func (m *Metrics) Finish(name string, err error) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.exporting[name] = false
+	m.doneConversations++
+	m.mu.Unlock()
+	m.Error(err)
+}
+
+// AddFetched counts messages fetched from Slack for a conversation.
+func (m *Metrics) AddFetched(conversation string, n int) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messagesFetched += int64(n)
+	m.fetchedByConv[conversation] += int64(n)
+}
+
+// AddWritten counts messages written.
+func (m *Metrics) AddWritten(n int) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messagesWritten += int64(n)
+}
+
+// Error counts an error by its catalog code (see errcat), or as "other".
+func (m *Metrics) Error(err error) {
+	if m == nil || err == nil {
+		return
+	}
+	kind := "other"
+	if e := errcat.Classify(err); e != nil {
+		kind = strings.ToLower(string(e.Code))
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errors[kind]++
+}
+
+// WritePrometheus writes the metrics in the Prometheus text exposition
+// format.
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	var b strings.Builder
+	if m != nil {
+		m.mu.Lock()
+		m.writeLocked(&b)
+		m.mu.Unlock()
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (m *Metrics) writeLocked(b *strings.Builder) {
+	header := func(name, kind, help string) {
+		fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+	single := func(name, kind, help string, v int64) {
+		header(name, kind, help)
+		fmt.Fprintf(b, "%s %d\n", name, v)
+	}
+	pairs := func(name, kind, help string, labels labelPair, values map[labelPair]float64) {
+		header(name, kind, help)
+		keys := make([]labelPair, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if keys[i][0] != keys[j][0] {
+				return keys[i][0] < keys[j][0]
+			}
+			return keys[i][1] < keys[j][1]
+		})
+		for _, k := range keys {
+			fmt.Fprintf(b, "%s{%s=\"%s\",%s=\"%s\"} %s\n", name, labels[0], labelEscaper.Replace(k[0]), labels[1], labelEscaper.Replace(k[1]), formatValue(values[k]))
+		}
+	}
+	byName := func(name, kind, help, label string, values map[string]float64) {
+		header(name, kind, help)
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(b, "%s{%s=\"%s\"} %s\n", name, label, labelEscaper.Replace(k), formatValue(values[k]))
+		}
+	}
+
+	single("get_out_messages_fetched_total", "counter", "Messages fetched from Slack.", m.messagesFetched)
+	single("get_out_messages_written_total", "counter", "Messages written to the export.", m.messagesWritten)
+	pairs("get_out_slack_requests_total", "counter", "Slack API request attempts by endpoint and result.",
+		labelPair{"endpoint", "result"}, floats(m.slackRequests))
+	pairs("get_out_slack_waits_total", "counter", "Sleeps before Slack API requests by endpoint and reason (rate_limit or retry).",
+		labelPair{"endpoint", "reason"}, floats(m.slackWaits))
+	pairs("get_out_slack_wait_seconds_total", "counter", "Time slept before Slack API requests by endpoint and reason.",
+		labelPair{"endpoint", "reason"}, m.slackWaitSeconds)
+	pairs("get_out_google_requests_total", "counter", "Google API requests by method and HTTP status.",
+		labelPair{"method", "status"}, floats(m.googleRequests))
+	single("get_out_docs_batch_updates_total", "counter", "Google Docs batchUpdate calls.", m.docsBatchUpdates)
+	errs := make(map[string]float64, len(m.errors))
+	for k, v := range m.errors {
+		errs[k] = float64(v)
+	}
+	byName("get_out_errors_total", "counter", "Errors by type.", "type", errs)
+	single("get_out_run_conversations", "gauge", "Conversations in the current or last run.", int64(m.runConversations))
+	single("get_out_run_conversations_done", "gauge", "Conversations done in the current or last run.", int64(m.doneConversations))
+	fetched := make(map[string]float64, len(m.fetchedByConv))
+	for k, v := range m.fetchedByConv {
+		fetched[k] = float64(v)
+	}
+	byName("get_out_conversation_messages_fetched_total", "counter", "Messages fetched from Slack by conversation.", "conversation", fetched)
+	exporting := make(map[string]float64, len(m.exporting))
+	for k, v := range m.exporting {
+		if v {
+			exporting[k] = 1
+		} else {
+			exporting[k] = 0
+		}
+	}
+	byName("get_out_conversation_exporting", "gauge", "1 while a conversation is being exported.", "conversation", exporting)
+}
+
+// floats converts counts to float64 for writing.
+func floats(counts map[labelPair]int64) map[labelPair]float64 {
+	out := make(map[labelPair]float64, len(counts))
+	for k, v := range counts {
+		out[k] = float64(v)
+	}
+	return out
+}
+
+// labelEscaper escapes a label value as the text format requires.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatValue writes v without an exponent or trailing zeros.
+func formatValue(v float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.6f", v), "0"), ".")
+}
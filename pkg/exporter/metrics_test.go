@@ -0,0 +1,84 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/errcat"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+func TestMetrics_WritePrometheus(t *testing.T) {
+	m := NewMetrics()
+	m.ObserveRequest("conversations.history", nil)
+	m.ObserveRequest("conversations.history", &slackapi.RateLimitError{RetryAfter: time.Second})
+	m.ObserveWait("conversations.history", slackapi.WaitRateLimit, 1500*time.Millisecond)
+	m.ObserveGoogleRequest("docs.batchUpdate", 200, nil)
+	m.ObserveGoogleRequest("drive.files.list", 0, errors.New("connection reset"))
+	m.Start(2)
+	m.Begin(`team "a"`)
+	m.AddFetched(`team "a"`, 7)
+	m.AddWritten(5)
+	m.Finish("general", errcat.Wrap(errcat.DocsQuota, errors.New("quota")))
+
+	var b strings.Builder
+	if err := m.WritePrometheus(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"# TYPE get_out_messages_fetched_total counter\nget_out_messages_fetched_total 7\n",
+		"get_out_messages_written_total 5\n",
+		`get_out_slack_requests_total{endpoint="conversations.history",result="ok"} 1`,
+		`get_out_slack_requests_total{endpoint="conversations.history",result="rate_limited"} 1`,
+		`get_out_slack_waits_total{endpoint="conversations.history",reason="rate_limit"} 1`,
+		`get_out_slack_wait_seconds_total{endpoint="conversations.history",reason="rate_limit"} 1.5`,
+		`get_out_google_requests_total{method="docs.batchUpdate",status="200"} 1`,
+		`get_out_google_requests_total{method="drive.files.list",status="error"} 1`,
+		"get_out_docs_batch_updates_total 1\n",
+		`get_out_errors_total{type="docs_quota"} 1`,
+		"get_out_run_conversations 2\n",
+		"get_out_run_conversations_done 1\n",
+		`get_out_conversation_messages_fetched_total{conversation="team \"a\""} 7`,
+		`get_out_conversation_exporting{conversation="team \"a\""} 1`,
+		`get_out_conversation_exporting{conversation="general"} 0`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestMetrics_Nil(t *testing.T) {
+	var m *Metrics
+	m.AddFetched("general", 1)
+	m.Error(errors.New("boom"))
+	var b strings.Builder
+	if err := m.WritePrometheus(&b); err != nil || b.Len() != 0 {
+		t.Errorf("nil WritePrometheus() = %q, %v; want nothing", b.String(), err)
+	}
+}
+
+func TestExportConversation_CountsMetrics(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.metrics = NewMetrics()
+
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	var b strings.Builder
+	if err := exp.metrics.WritePrometheus(&b); err != nil {
+		t.Fatal(err)
+	}
+	// Three messages, then the thread's parent and reply, fetched and
+	// written to their days and the thread's doc.
+	for _, want := range []string{"get_out_messages_fetched_total 5\n", "get_out_messages_written_total 5\n"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("metrics missing %q:\n%s", want, b.String())
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package exporter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// Option configures an Exporter created with New. Each sets fields of the
+// ExporterConfig New passes to NewExporter; WithConfig reaches the rest.
+type Option func(*ExporterConfig)
+
+// Defaults New starts from, matching the CLI's.
+const (
+	DefaultRootFolderName = "Slack Exports"
+	DefaultChromePort     = 9222
+)
+
+// New creates an exporter that keeps its export index, caches, and
+// credentials under configDir (the layout of get-out's config directory),
+// exporting to the DefaultRootFolderName Drive folder with Slack
+// credentials read from Chrome on DefaultChromePort, unless options say
+// otherwise. Like NewExporter, it makes no connections: call Initialize,
+// then ExportConversation, ExportAll, or ExportAllParallel.
+func New(configDir string, opts ...Option) *Exporter {
+	cfg := &ExporterConfig{
+		ConfigDir:      configDir,
+		RootFolderName: DefaultRootFolderName,
+		ChromePort:     DefaultChromePort,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return NewExporter(cfg)
+}
+
+// WithConfig applies fn to the configuration, for settings without an
+// option of their own.
+func WithConfig(fn func(*ExporterConfig)) Option {
+	return func(cfg *ExporterConfig) {
+		fn(cfg)
+	}
+}
+
+// WithRootFolder exports into the Drive folder with this name, created in
+// My Drive if it does not exist.
+func WithRootFolder(name string) Option {
+	return func(cfg *ExporterConfig) {
+		cfg.RootFolderName = name
+	}
+}
+
+// WithRootFolderID exports into an existing Drive folder, by ID.
+func WithRootFolderID(id string) Option {
+	return func(cfg *ExporterConfig) {
+		cfg.RootFolderID = id
+	}
+}
+
+// WithChromePort reads Slack credentials from the Chrome instance
+// debugging on port.
+func WithChromePort(port int) Option {
+	return func(cfg *ExporterConfig) {
+		cfg.ChromePort = port
+	}
+}
+
+// WithSlackToken uses a Slack token instead of Chrome. An xoxc- browser
+// token needs its xoxd- cookie; pass "" for other tokens.
+func WithSlackToken(token, cookie string) Option {
+	return func(cfg *ExporterConfig) {
+		cfg.SlackToken = token
+		cfg.SlackCookie = cookie
+	}
+}
+
+// WithProgress sends progress messages to onProgress, and fine-grained
+// progress to onDetail (to onProgress when nil).
+func WithProgress(onProgress, onDetail func(msg string)) Option {
+	return func(cfg *ExporterConfig) {
+		cfg.OnProgress = onProgress
+		cfg.OnDetail = onDetail
+	}
+}
+
+// WithNotices sends what the Google and Slack clients would otherwise
+// print, such as the Google authorization URL, to fn (see
+// ExporterConfig.OnNotice).
+func WithNotices(fn func(msg string)) Option {
+	return func(cfg *ExporterConfig) {
+		cfg.OnNotice = fn
+	}
+}
+
+// WithSync exports only messages newer than each conversation's last
+// export.
+func WithSync() Option {
+	return func(cfg *ExporterConfig) {
+		cfg.SyncMode = true
+	}
+}
+
+// WithDateRange exports only messages posted between from and to. A zero
+// time leaves that end of the range open.
+func WithDateRange(from, to time.Time) Option {
+	return func(cfg *ExporterConfig) {
+		cfg.DateFrom, cfg.DateTo = "", ""
+		if !from.IsZero() {
+			cfg.DateFrom = fmt.Sprintf("%d.000000", from.Unix())
+		}
+		if !to.IsZero() {
+			cfg.DateTo = fmt.Sprintf("%d.000000", to.Unix())
+		}
+	}
+}
+
+// WithLocalExportDir writes conversations whose format is a local one
+// (markdown, json, html, slack, sqlite) under dir, an absolute path.
+func WithLocalExportDir(dir string) Option {
+	return func(cfg *ExporterConfig) {
+		cfg.LocalExportDir = dir
+	}
+}
+
+// WithParallel makes up to n Slack requests at once (1-5).
+func WithParallel(n int) Option {
+	return func(cfg *ExporterConfig) {
+		cfg.Parallel = n
+	}
+}
+
+// WithBackend writes every conversation with b instead of the backend its
+// format selects.
+func WithBackend(b Backend) Option {
+	return func(cfg *ExporterConfig) {
+		cfg.Backend = b
+	}
+}
+
+// WithRawRecorder passes every raw Slack API response to r (see
+// RawArchive).
+func WithRawRecorder(r slackapi.ResponseRecorder) Option {
+	return func(cfg *ExporterConfig) {
+		cfg.RawRecorder = r
+	}
+}
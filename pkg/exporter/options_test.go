@@ -0,0 +1,55 @@
+package exporter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNew_Defaults(t *testing.T) {
+	exp := New(t.TempDir())
+	if exp.rootFolderName != DefaultRootFolderName || exp.chromePort != DefaultChromePort {
+		t.Errorf("New() root folder %q, chrome port %d; want the CLI defaults", exp.rootFolderName, exp.chromePort)
+	}
+	if exp.onNotice != nil || exp.syncMode {
+		t.Error("New() without options set notices or sync mode")
+	}
+}
+
+func TestNew_Options(t *testing.T) {
+	var notices []string
+	from := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	exp := New(t.TempDir(),
+		WithRootFolder("Archive"),
+		WithChromePort(9333),
+		WithSlackToken("xoxc-token", "xoxd-cookie"),
+		WithNotices(func(msg string) { notices = append(notices, msg) }),
+		WithSync(),
+		WithDateRange(from, time.Time{}),
+		WithConfig(func(cfg *ExporterConfig) { cfg.Provenance = true }),
+	)
+
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"root folder", exp.rootFolderName, "Archive"},
+		{"chrome port", exp.chromePort, 9333},
+		{"slack token", exp.slackToken, "xoxc-token"},
+		{"slack cookie", exp.slackCookie, "xoxd-cookie"},
+		{"sync", exp.syncMode, true},
+		{"date from", exp.dateFrom, "1706745600.000000"},
+		{"date to", exp.dateTo, ""},
+		{"provenance", exp.provenance, true},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	exp.onNotice("Rate limited")
+	if len(notices) != 1 {
+		t.Errorf("notices = %q, want the notice passed on", notices)
+	}
+}
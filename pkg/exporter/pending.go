@@ -0,0 +1,163 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/gdrive"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// PendingDocTitle is the title of the doc, in the Activity folder, that
+// holds snapshots of the exporting user's scheduled messages and reminders.
+const PendingDocTitle = "Pending items"
+
+// exportPending appends a snapshot of the exporting user's scheduled
+// messages and open reminders to the Pending items doc. Slack deletes both
+// with the account and neither appears in conversation history, so each run
+// records everything still queued. When one list fails the other is still
+// written and the failure is returned.
+func (e *Exporter) exportPending(ctx context.Context, activity *ActivityIndex, userID string, result *ActivityResult) error {
+	var errs []error
+	scheduled, err := e.listScheduledMessages(ctx)
+	if err != nil {
+		errs = append(errs, activityListError(slackapi.MethodScheduledList, err))
+	}
+	reminders, err := e.slackClient.ListReminders(ctx)
+	if err != nil {
+		errs = append(errs, activityListError(slackapi.MethodRemindersList, err))
+	}
+	if len(errs) == 2 {
+		return errors.Join(errs...)
+	}
+
+	var open []slackapi.Reminder
+	for _, r := range reminders {
+		if r.CompleteTS == 0 {
+			open = append(open, r)
+		}
+	}
+	sort.SliceStable(scheduled, func(i, j int) bool {
+		return scheduled[i].PostAt < scheduled[j].PostAt
+	})
+	sort.SliceStable(open, func(i, j int) bool {
+		return open[i].Time < open[j].Time
+	})
+	e.Detail("Found %d scheduled messages and %d open reminders", len(scheduled), len(open))
+
+	folderID, err := e.ensureActivityRoot(ctx, activity)
+	if err != nil {
+		return err
+	}
+	feed := activity.feed(ActivityPending)
+	if feed.Doc == nil || feed.Doc.DocID == "" {
+		gdoc, err := e.gdriveClient.FindOrCreateDocument(ctx, PendingDocTitle, folderID)
+		if err != nil {
+			return fmt.Errorf("failed to create %s doc: %w", PendingDocTitle, err)
+		}
+		feed.Doc = &DocExport{DocID: gdoc.ID, DocURL: gdoc.URL, Title: PendingDocTitle}
+		result.DocsCreated++
+	}
+
+	blocks := []gdrive.MessageBlock{{
+		SenderName: "Snapshot",
+		Timestamp:  parser.FormatTime(time.Now()),
+		Content:    fmt.Sprintf("%d scheduled messages, %d open reminders", len(scheduled), len(open)),
+	}}
+	blocks = append(blocks, e.scheduledBlocks(ctx, scheduled, userID, folderID)...)
+	blocks = append(blocks, e.reminderBlocks(ctx, open, userID, folderID)...)
+	if err := e.gdriveClient.BatchAppendMessages(ctx, feed.Doc.DocID, blocks); err != nil {
+		return fmt.Errorf("failed to write %s: %w", PendingDocTitle, err)
+	}
+
+	items := len(scheduled) + len(open)
+	feed.Doc.MessageCount += items
+	result.Messages = items
+	result.FolderURL = activity.FolderURL
+	e.addMessages(items)
+	if err := activity.Save(); err != nil {
+		e.Progress("Warning: %v", err)
+	}
+	return errors.Join(errs...)
+}
+
+// listScheduledMessages returns all of the user's scheduled messages.
+func (e *Exporter) listScheduledMessages(ctx context.Context) ([]slackapi.ScheduledMessage, error) {
+	var all []slackapi.ScheduledMessage
+	cursor := ""
+	for {
+		resp, err := e.slackClient.ListScheduledMessages(ctx, cursor)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, resp.ScheduledMessages...)
+		cursor = resp.ResponseMetadata.NextCursor
+		if cursor == "" {
+			return all, nil
+		}
+	}
+}
+
+// scheduledBlocks renders scheduled messages as "Scheduled in <conversation>"
+// blocks stamped with when Slack will post them.
+func (e *Exporter) scheduledBlocks(ctx context.Context, scheduled []slackapi.ScheduledMessage, userID, folderID string) []gdrive.MessageBlock {
+	items := make([]activityItem, len(scheduled))
+	for i, sm := range scheduled {
+		items[i] = activityItem{channelID: sm.ChannelID, msg: slackapi.Message{User: userID, Text: sm.Text, TS: unixTS(sm.PostAt)}}
+	}
+	e.loadActivityChannels(ctx, items)
+
+	var blocks []gdrive.MessageBlock
+	for i, item := range items {
+		label := "Scheduled in " + e.activityConversationLabel(item.channelID)
+		blocks = append(blocks, e.pendingBlocks(ctx, item, folderID, label, scheduled[i].PostAt)...)
+	}
+	return blocks
+}
+
+// reminderBlocks renders open reminders, naming whom a reminder is for when
+// it is not the exporting user. Recurring reminders carry no due time.
+func (e *Exporter) reminderBlocks(ctx context.Context, reminders []slackapi.Reminder, userID, folderID string) []gdrive.MessageBlock {
+	msgs := make([]slackapi.Message, len(reminders))
+	for i, r := range reminders {
+		msgs[i] = slackapi.Message{User: r.User, Text: r.Text, TS: unixTS(r.Time)}
+	}
+	e.loadMessageAuthors(ctx, msgs)
+
+	var blocks []gdrive.MessageBlock
+	for i, r := range reminders {
+		label := "Reminder"
+		if r.Recurring {
+			label = "Recurring reminder"
+		}
+		if r.User != "" && r.User != userID {
+			label += " for " + e.userResolver.Resolve(r.User)
+		}
+		blocks = append(blocks, e.pendingBlocks(ctx, activityItem{msg: msgs[i]}, folderID, label, r.Time)...)
+	}
+	return blocks
+}
+
+// pendingBlocks builds the doc blocks of one pending item, replacing the
+// sender with label and the time of day with the full due time.
+func (e *Exporter) pendingBlocks(ctx context.Context, item activityItem, folderID, label string, due int64) []gdrive.MessageBlock {
+	blocks := e.docWriter.BuildBlocks(ctx, item.channelID, folderID, []slackapi.Message{item.msg})
+	for i := range blocks {
+		blocks[i].SenderName = label
+		blocks[i].Timestamp = ""
+		if due > 0 {
+			blocks[i].Timestamp = parser.FormatTime(time.Unix(due, 0))
+		}
+	}
+	return blocks
+}
+
+// unixTS returns the Slack timestamp of a Unix time.
+func unixTS(sec int64) string {
+	return strconv.FormatInt(sec, 10) + ".000000"
+}
@@ -0,0 +1,113 @@
+package exporter
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// PipelineStats records where a conversation's thread or day export spent
+// its time. Fetching and writing run as two stages joined by a bounded
+// queue: FetchWait grows when the writer is the bottleneck and fetchers
+// wait for it to drain the queue, WriteWait when Slack is and the writer
+// waits for the next item to arrive.
+type PipelineStats struct {
+	Items     int           // threads or days that passed through the pipeline
+	FetchTime time.Duration // time spent fetching, summed over fetchers
+	WriteTime time.Duration // time spent writing
+	FetchWait time.Duration // time fetchers waited for room in the queue
+	WriteWait time.Duration // time the writer waited for a fetch to finish
+}
+
+// String summarizes the stats for progress output.
+func (s PipelineStats) String() string {
+	return fmt.Sprintf("%d items: fetched in %v, written in %v; writer waited %v for Slack, fetchers waited %v for writes",
+		s.Items, s.FetchTime.Round(time.Millisecond), s.WriteTime.Round(time.Millisecond),
+		s.WriteWait.Round(time.Millisecond), s.FetchWait.Round(time.Millisecond))
+}
+
+// pipeline fetches items [0, n) on up to pool.size() goroutines while
+// writing them, in order, on the calling goroutine, so slow writes and
+// slow fetches overlap instead of adding up. At most twice the pool size
+// items are fetched ahead of the writer; beyond that fetching waits, so a
+// slow writer does not pile up fetched items in memory. write returns
+// false to stop the pipeline: items not yet fetched are then skipped.
+func pipeline[T any](pool *fetchPool, n int, fetch func(i int) T, write func(i int, v T) bool) PipelineStats {
+	var stats PipelineStats
+	if n == 0 {
+		return stats
+	}
+
+	var mu sync.Mutex
+	add := func(d *time.Duration, since time.Time) {
+		mu.Lock()
+		*d += time.Since(since)
+		mu.Unlock()
+	}
+
+	// window holds a token for each item being fetched or waiting to be
+	// written. Tokens are taken in item order, so the item the writer
+	// waits for always holds one.
+	window := make(chan struct{}, 2*pool.size())
+	ready := make([]chan T, n)
+	for i := range ready {
+		ready[i] = make(chan T, 1)
+	}
+	next := make(chan int)
+	stop := make(chan struct{})
+	fed := make(chan struct{})
+
+	go func() {
+		defer close(fed)
+		defer close(next)
+		for i := 0; i < n; i++ {
+			start := time.Now()
+			select {
+			case window <- struct{}{}:
+			case <-stop:
+				return
+			}
+			add(&stats.FetchWait, start)
+			select {
+			case next <- i:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(pool.size(), n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				start := time.Now()
+				v := fetch(i)
+				add(&stats.FetchTime, start)
+				ready[i] <- v
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		start := time.Now()
+		v := <-ready[i]
+		add(&stats.WriteWait, start)
+
+		start = time.Now()
+		ok := write(i, v)
+		add(&stats.WriteTime, start)
+		stats.Items++
+		<-window
+		if !ok {
+			break
+		}
+	}
+	close(stop)
+	wg.Wait()
+	// The feeder may still be recording FetchWait; stats is complete
+	// only once it has returned.
+	<-fed
+	return stats
+}
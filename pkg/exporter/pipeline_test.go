@@ -0,0 +1,133 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jflowers/get-out/internal/testutil"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+func TestPipeline_WritesInOrder(t *testing.T) {
+	var written []int
+	stats := pipeline(newFetchPool(3), 20, func(i int) int {
+		time.Sleep(time.Duration(20-i) * time.Millisecond / 10)
+		return i * i
+	}, func(i, v int) bool {
+		if v != i*i {
+			t.Errorf("item %d written with %d, want %d", i, v, i*i)
+		}
+		written = append(written, i)
+		return true
+	})
+
+	want := fmt.Sprint([]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19})
+	if got := fmt.Sprint(written); got != want {
+		t.Errorf("written = %s, want %s", got, want)
+	}
+	if stats.Items != 20 || stats.FetchTime <= 0 {
+		t.Errorf("stats = %+v, want 20 items and fetch time", stats)
+	}
+}
+
+func TestPipeline_Backpressure(t *testing.T) {
+	var fetched, writtenCount atomic.Int32
+	var maxAhead int32
+	pipeline(newFetchPool(2), 30, func(i int) int {
+		fetched.Add(1)
+		return i
+	}, func(i, v int) bool {
+		// Give the fetchers time to run ahead as far as they may.
+		time.Sleep(2 * time.Millisecond)
+		if ahead := fetched.Load() - writtenCount.Load(); ahead > maxAhead {
+			maxAhead = ahead
+		}
+		writtenCount.Add(1)
+		return true
+	})
+
+	if maxAhead > 4 {
+		t.Errorf("fetchers ran %d items ahead of a slow writer, want at most 4", maxAhead)
+	}
+}
+
+func TestPipeline_WriteFastWhileFetching(t *testing.T) {
+	// A slow fetch of the last item does not hold back writing the others.
+	var firstWrite time.Duration
+	start := time.Now()
+	pipeline(newFetchPool(2), 2, func(i int) int {
+		if i == 1 {
+			time.Sleep(50 * time.Millisecond)
+		}
+		return i
+	}, func(i, v int) bool {
+		if i == 0 {
+			firstWrite = time.Since(start)
+		}
+		return true
+	})
+
+	if firstWrite >= 50*time.Millisecond {
+		t.Errorf("first item written after %v, want before the slow fetch finished", firstWrite)
+	}
+}
+
+func TestPipeline_Stop(t *testing.T) {
+	var fetched atomic.Int32
+	stats := pipeline(newFetchPool(1), 50, func(i int) int {
+		fetched.Add(1)
+		return i
+	}, func(i, v int) bool {
+		return i < 2
+	})
+
+	if stats.Items != 3 {
+		t.Errorf("Items = %d, want 3", stats.Items)
+	}
+	if n := fetched.Load(); n > 5 {
+		t.Errorf("fetched %d items after the writer stopped at 3, want at most 5", n)
+	}
+
+	if stats := pipeline(nil, 0, func(int) int { return 0 }, func(int, int) bool { return true }); stats.Items != 0 {
+		t.Errorf("empty pipeline Items = %d, want 0", stats.Items)
+	}
+}
+
+func TestExportConversation_PrefetchesDayDownloads(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	slack.Files = map[string][]byte{"https://files.slack.com/photo.png": img.Bytes()}
+	slack.Messages["C001"][2].Files = []slackapi.File{
+		{ID: "F001", Name: "photo.png", Mimetype: "image/png", URLPrivateDownload: "https://files.slack.com/photo.png"},
+	}
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	// The writer's own Slack client has no files, so the image can only be
+	// embedded from the prefetched copy.
+	exp.docWriter = NewDocWriter(drive, prefetchedSlack{testutil.NewFakeSlack(), &exp.prefetched}, exp.userResolver, exp.channelResolver, nil, exp.index.LookupDocURL, exp.index.LookupThreadURL)
+
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	if n := slack.Calls("DownloadFile"); n != 1 {
+		t.Errorf("DownloadFile called %d times, want once, ahead of the write", n)
+	}
+	if n := drive.Calls("UploadFile"); n != 1 {
+		t.Errorf("UploadFile called %d times, want the prefetched image embedded", n)
+	}
+	if result.DayPipeline.Items != 2 {
+		t.Errorf("DayPipeline.Items = %d, want both days", result.DayPipeline.Items)
+	}
+	if _, ok := exp.prefetched.get("https://files.slack.com/photo.png"); ok {
+		t.Error("prefetched download kept after its day was written")
+	}
+}
@@ -0,0 +1,130 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// sizeProbeLimit is the number of newest messages fetched to estimate a
+// conversation's size.
+const sizeProbeLimit = 200
+
+// SizeEstimate is the estimated message count of a conversation, from one
+// page of history and the conversation's creation time.
+type SizeEstimate struct {
+	Conversation config.ConversationConfig
+
+	// Messages is the exact count when Exact, otherwise an estimate of
+	// the main messages (thread replies are not counted).
+	Messages int
+	Exact    bool
+
+	// Latest is the time of the newest message, zero for an empty
+	// conversation.
+	Latest time.Time
+
+	// Err is why the conversation could not be probed.
+	Err error
+}
+
+// EstimateSizes probes each conversation with one conversations.history
+// page (and conversations.info when there is more than a page) and returns
+// the estimates largest first, so a huge channel configured by mistake
+// stands out before the export starts. Messages before the export's
+// --from date are not counted.
+func (e *Exporter) EstimateSizes(ctx context.Context, conversations []config.ConversationConfig) []SizeEstimate {
+	estimates := make([]SizeEstimate, 0, len(conversations))
+	for i, conv := range conversations {
+		if ctx.Err() != nil {
+			break
+		}
+		e.Detail("Estimating size of %s (%d/%d)...", conv.Name, i+1, len(conversations))
+		est := estimateSize(ctx, e.slackClient, conv.ID, e.dateFrom)
+		est.Conversation = conv
+		estimates = append(estimates, est)
+	}
+	SortSizeEstimates(estimates)
+	return estimates
+}
+
+// SortSizeEstimates orders estimates largest first, with conversations
+// that could not be probed last.
+func SortSizeEstimates(estimates []SizeEstimate) {
+	sort.SliceStable(estimates, func(i, j int) bool {
+		if (estimates[i].Err == nil) != (estimates[j].Err == nil) {
+			return estimates[i].Err == nil
+		}
+		return estimates[i].Messages > estimates[j].Messages
+	})
+}
+
+// estimateSize estimates the messages of convID after oldest (a Slack
+// timestamp, or "" for the whole history). When the first page is not the
+// whole history, the page's message rate is extrapolated back to the
+// conversation's creation (or oldest).
+func estimateSize(ctx context.Context, client SlackSource, convID, oldest string) SizeEstimate {
+	resp, err := client.GetConversationHistory(ctx, convID, &slackapi.HistoryOptions{
+		Limit:  sizeProbeLimit,
+		Oldest: oldest,
+	})
+	if err != nil {
+		return SizeEstimate{Err: err}
+	}
+	msgs := resp.Messages
+	est := SizeEstimate{Messages: len(msgs), Exact: !resp.HasMore}
+	if len(msgs) == 0 {
+		return est
+	}
+	newest, oldestInPage := tsSeconds(msgs[0].TS), tsSeconds(msgs[len(msgs)-1].TS)
+	est.Latest = time.Unix(int64(newest), 0)
+	if est.Exact {
+		return est
+	}
+
+	start := tsSeconds(oldest)
+	if info, err := client.GetConversationInfo(ctx, convID); err == nil && float64(info.Created) > start {
+		start = float64(info.Created)
+	}
+	span := newest - oldestInPage
+	if start <= 0 || span <= 0 || oldestInPage <= start {
+		// Without a start or a rate, the page is a lower bound.
+		return est
+	}
+	rate := float64(len(msgs)) / span
+	est.Messages += int(rate * (oldestInPage - start))
+	return est
+}
+
+// tsSeconds parses a Slack timestamp into seconds, 0 when empty or invalid.
+func tsSeconds(ts string) float64 {
+	f, err := strconv.ParseFloat(ts, 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
+
+// FormatSize describes an estimate for display, e.g. "~2.1M messages",
+// "350 messages", or "200+ messages".
+func (s SizeEstimate) FormatSize() string {
+	switch {
+	case s.Err != nil:
+		return "unknown"
+	case s.Exact:
+		return fmt.Sprintf("%d messages", s.Messages)
+	case s.Messages <= sizeProbeLimit:
+		return fmt.Sprintf("%d+ messages", s.Messages)
+	case s.Messages >= 1_000_000:
+		return fmt.Sprintf("~%.1fM messages", float64(s.Messages)/1_000_000)
+	case s.Messages >= 10_000:
+		return fmt.Sprintf("~%dk messages", s.Messages/1000)
+	default:
+		return fmt.Sprintf("~%d messages", s.Messages)
+	}
+}
@@ -0,0 +1,84 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// evenHistory returns n messages one minute apart, the newest at end.
+func evenHistory(n int, end int64) []slackapi.Message {
+	msgs := make([]slackapi.Message, n)
+	for i := range msgs {
+		msgs[i] = slackapi.Message{TS: fmt.Sprintf("%d.000100", end-int64(i)*60)}
+	}
+	return msgs
+}
+
+func TestEstimateSizes(t *testing.T) {
+	drive, slack, _ := fakeConversation()
+	const end = 1_700_000_000
+	// Small: fits in one page, counted exactly.
+	slack.Messages["C001"] = evenHistory(50, end)
+	// Firehose: one message a minute for 30 days, created when it began.
+	slack.Messages["C002"] = evenHistory(30*24*60, end)
+	slack.Conversations = []slackapi.Conversation{{ID: "C002", Name: "firehose", Created: end - 30*24*60*60 + 60}}
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+
+	estimates := exp.EstimateSizes(context.Background(), []config.ConversationConfig{
+		{ID: "C001", Name: "small"},
+		{ID: "C002", Name: "firehose"},
+	})
+	if len(estimates) != 2 {
+		t.Fatalf("len(estimates) = %d, want 2", len(estimates))
+	}
+	big, small := estimates[0], estimates[1]
+	if big.Conversation.ID != "C002" || small.Conversation.ID != "C001" {
+		t.Fatalf("order = %s, %s; want largest first", big.Conversation.ID, small.Conversation.ID)
+	}
+	if !small.Exact || small.Messages != 50 {
+		t.Errorf("small = %+v, want exactly 50 messages", small)
+	}
+	want := 30 * 24 * 60
+	if big.Exact || big.Messages < want*95/100 || big.Messages > want*105/100 {
+		t.Errorf("firehose estimate = %d (exact %v), want about %d", big.Messages, big.Exact, want)
+	}
+	if big.Latest.Unix() != end {
+		t.Errorf("Latest = %v, want %d", big.Latest.Unix(), end)
+	}
+}
+
+func TestEstimateSizes_ProbeFailsSortsLast(t *testing.T) {
+	drive, slack, _ := fakeConversation()
+	slack.Errors["GetConversationHistory"] = errMissingScope
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+
+	estimates := exp.EstimateSizes(context.Background(), []config.ConversationConfig{{ID: "C001", Name: "general"}})
+	if len(estimates) != 1 || estimates[0].Err == nil {
+		t.Fatalf("estimates = %+v, want one failed probe", estimates)
+	}
+	if got := estimates[0].FormatSize(); got != "unknown" {
+		t.Errorf("FormatSize() = %q, want unknown", got)
+	}
+}
+
+func TestSizeEstimate_FormatSize(t *testing.T) {
+	tests := []struct {
+		est  SizeEstimate
+		want string
+	}{
+		{SizeEstimate{Messages: 42, Exact: true}, "42 messages"},
+		{SizeEstimate{Messages: 200}, "200+ messages"},
+		{SizeEstimate{Messages: 3500}, "~3500 messages"},
+		{SizeEstimate{Messages: 45_300}, "~45k messages"},
+		{SizeEstimate{Messages: 2_100_000}, "~2.1M messages"},
+	}
+	for _, tt := range tests {
+		if got := tt.est.FormatSize(); got != tt.want {
+			t.Errorf("FormatSize(%d) = %q, want %q", tt.est.Messages, got, tt.want)
+		}
+	}
+}
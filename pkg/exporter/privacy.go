@@ -0,0 +1,119 @@
+package exporter
+
+import (
+	"encoding/json"
+	"net/url"
+
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// Profile fields dropped by the privacy scrubber: the status a user sets
+// and what Slack derives from their presence and do-not-disturb state.
+// These say where someone is or what they are doing at the moment of the
+// export and are not needed to attribute messages.
+var (
+	scrubbedUserFields    = []string{"presence", "online", "auto_away", "manual_away", "connection_count", "last_activity", "is_active", "dnd_enabled", "dnd_status"}
+	scrubbedProfileFields = []string{"status_text", "status_emoji", "status_expiration", "status_text_canonical", "status_emoji_display_info", "huddle_state", "huddle_state_expiration_ts"}
+)
+
+// ScrubUserProfiles returns copies of users with their status cleared, for
+// exports that leave status and presence out (see ExporterConfig
+// IncludeProfileStatus). users is not modified.
+func ScrubUserProfiles(users []*slackapi.User) []*slackapi.User {
+	scrubbed := make([]*slackapi.User, len(users))
+	for i, u := range users {
+		c := *u
+		c.Profile.StatusText = ""
+		c.Profile.StatusEmoji = ""
+		scrubbed[i] = &c
+	}
+	return scrubbed
+}
+
+// ProfileScrubber is a slackapi.ResponseRecorder that removes status and
+// presence fields from users.* responses before passing them to Next, so
+// the raw archive holds the same profiles as users.json. Other responses
+// are passed through unchanged.
+type ProfileScrubber struct {
+	Next slackapi.ResponseRecorder
+}
+
+// RecordResponse scrubs body when it holds user profiles and records it.
+func (s ProfileScrubber) RecordResponse(endpoint string, params url.Values, body []byte) {
+	switch endpoint {
+	case "users.info", "users.list", "users.profile.get", "users.getPresence":
+		body = scrubUserResponse(body)
+	}
+	s.Next.RecordResponse(endpoint, params, body)
+}
+
+// scrubUserResponse removes the scrubbed fields from the user, members,
+// and profile objects of a users.* response body. Bodies that are not a
+// JSON object are returned as is.
+func scrubUserResponse(body []byte) []byte {
+	var resp map[string]json.RawMessage
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return body
+	}
+	for _, field := range scrubbedUserFields {
+		delete(resp, field)
+	}
+	if user, ok := resp["user"]; ok {
+		resp["user"] = scrubUserObject(user)
+	}
+	if profile, ok := resp["profile"]; ok {
+		resp["profile"] = scrubObject(profile, scrubbedProfileFields)
+	}
+	if members, ok := resp["members"]; ok {
+		var list []json.RawMessage
+		if err := json.Unmarshal(members, &list); err == nil {
+			for i := range list {
+				list[i] = scrubUserObject(list[i])
+			}
+			if data, err := json.Marshal(list); err == nil {
+				resp["members"] = data
+			}
+		}
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return body
+	}
+	return data
+}
+
+// scrubUserObject removes the scrubbed fields from a user object and its
+// profile.
+func scrubUserObject(raw json.RawMessage) json.RawMessage {
+	var user map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &user); err != nil {
+		return raw
+	}
+	for _, field := range scrubbedUserFields {
+		delete(user, field)
+	}
+	if profile, ok := user["profile"]; ok {
+		user["profile"] = scrubObject(profile, scrubbedProfileFields)
+	}
+	data, err := json.Marshal(user)
+	if err != nil {
+		return raw
+	}
+	return data
+}
+
+// scrubObject removes fields from a JSON object.
+func scrubObject(raw json.RawMessage, fields []string) json.RawMessage {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return raw
+	}
+	for _, field := range fields {
+		delete(obj, field)
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return raw
+	}
+	return data
+}
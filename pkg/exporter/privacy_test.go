@@ -0,0 +1,96 @@
+package exporter
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+type capturedResponse struct {
+	endpoint string
+	body     []byte
+}
+
+type captureRecorder struct {
+	got []capturedResponse
+}
+
+func (c *captureRecorder) RecordResponse(endpoint string, _ url.Values, body []byte) {
+	c.got = append(c.got, capturedResponse{endpoint, body})
+}
+
+func TestScrubUserProfiles(t *testing.T) {
+	users := []*slackapi.User{{ID: "U001", Name: "alice", Profile: slackapi.UserProfile{
+		DisplayName: "Alice", StatusText: "Out sick", StatusEmoji: ":face_with_thermometer:",
+	}}}
+
+	got := ScrubUserProfiles(users)
+	if p := got[0].Profile; p.StatusText != "" || p.StatusEmoji != "" || p.DisplayName != "Alice" {
+		t.Errorf("scrubbed profile = %+v, want status cleared and name kept", p)
+	}
+	if users[0].Profile.StatusText != "Out sick" {
+		t.Error("ScrubUserProfiles() modified its input")
+	}
+}
+
+func TestProfileScrubber(t *testing.T) {
+	next := &captureRecorder{}
+	s := ProfileScrubber{Next: next}
+
+	s.RecordResponse("users.list", nil, []byte(`{"ok":true,"members":[{"id":"U001","presence":"away",
+		"profile":{"display_name":"Alice","status_text":"Vacation","status_emoji":":palm_tree:","status_expiration":1700000000}}]}`))
+	s.RecordResponse("users.getPresence", nil, []byte(`{"ok":true,"presence":"active","online":true,"last_activity":1700000000}`))
+	history := `{"ok":true,"messages":[{"text":"status_text stays in messages"}]}`
+	s.RecordResponse("conversations.history", nil, []byte(history))
+
+	if len(next.got) != 3 {
+		t.Fatalf("recorded %d responses, want 3", len(next.got))
+	}
+	var list struct {
+		Members []map[string]json.RawMessage `json:"members"`
+	}
+	if err := json.Unmarshal(next.got[0].body, &list); err != nil {
+		t.Fatal(err)
+	}
+	var profile map[string]interface{}
+	if err := json.Unmarshal(list.Members[0]["profile"], &profile); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := list.Members[0]["presence"]; ok {
+		t.Error("users.list member kept presence")
+	}
+	for _, field := range []string{"status_text", "status_emoji", "status_expiration"} {
+		if _, ok := profile[field]; ok {
+			t.Errorf("users.list profile kept %s", field)
+		}
+	}
+	if profile["display_name"] != "Alice" {
+		t.Errorf("display_name = %v, want it kept", profile["display_name"])
+	}
+
+	var presence map[string]interface{}
+	if err := json.Unmarshal(next.got[1].body, &presence); err != nil {
+		t.Fatal(err)
+	}
+	if len(presence) != 1 || presence["ok"] != true {
+		t.Errorf("users.getPresence = %v, want only ok", presence)
+	}
+	if string(next.got[2].body) != history {
+		t.Errorf("conversations.history body changed: %s", next.got[2].body)
+	}
+}
+
+func TestNewExporter_ScrubsRawRecorder(t *testing.T) {
+	rec := &captureRecorder{}
+	e := NewExporter(&ExporterConfig{ConfigDir: t.TempDir(), RawRecorder: rec})
+	if _, ok := e.rawRecorder.(ProfileScrubber); !ok {
+		t.Errorf("rawRecorder = %T, want ProfileScrubber by default", e.rawRecorder)
+	}
+
+	e = NewExporter(&ExporterConfig{ConfigDir: t.TempDir(), RawRecorder: rec, IncludeProfileStatus: true})
+	if e.rawRecorder != rec {
+		t.Errorf("rawRecorder = %T, want the recorder unwrapped with IncludeProfileStatus", e.rawRecorder)
+	}
+}
@@ -0,0 +1,63 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/gdrive"
+)
+
+// Slack sources recorded in provenance lines. get-out reads every message
+// through the Slack Web API; what differs is the credential.
+const (
+	SourceBrowserSession = "Slack Web API (browser session)"
+	SourceAPIToken       = "Slack Web API (token)"
+)
+
+// slackSource names the credential a client built by newSlackClient uses.
+func slackSource(token, cookie string) string {
+	if cookie != "" || strings.HasPrefix(token, "xoxc-") {
+		return SourceBrowserSession
+	}
+	return SourceAPIToken
+}
+
+// provenanceLine describes how a day section's messages were obtained:
+// when they were fetched, from which source, and by which get-out version.
+func (e *Exporter) provenanceLine(fetchedAt time.Time) string {
+	line := "Provenance: fetched "
+	if fetchedAt.IsZero() {
+		line += "at an unrecorded time"
+	} else {
+		line += fetchedAt.UTC().Format(time.RFC3339)
+	}
+	line += " from " + orDefault(e.slackSource, "the Slack Web API")
+	return line + " by get-out " + orDefault(e.version, "dev")
+}
+
+// appendDocProvenance appends the provenance line to a day's doc after its
+// messages, and returns how much the doc grew. It is a no-op unless
+// provenance is enabled. A failure is reported without failing the day,
+// whose messages are already written.
+func (e *Exporter) appendDocProvenance(ctx context.Context, docID, date string, result *ExportResult) int64 {
+	if !e.provenance {
+		return 0
+	}
+	block := gdrive.MessageBlock{Content: e.provenanceLine(result.FetchedAt)}
+	if err := e.gdriveClient.BatchAppendMessages(ctx, docID, []gdrive.MessageBlock{block}); err != nil {
+		e.Progress("Warning: failed to write provenance for %s: %v", date, err)
+		return 0
+	}
+	return gdrive.AppendedLength(block)
+}
+
+// markdownProvenance returns the provenance footer of a day's markdown
+// section, or nil unless provenance is enabled.
+func (e *Exporter) markdownProvenance(result *ExportResult) []byte {
+	if !e.provenance {
+		return nil
+	}
+	return []byte(fmt.Sprintf("*%s*\n\n", e.provenanceLine(result.FetchedAt)))
+}
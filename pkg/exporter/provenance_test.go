@@ -0,0 +1,92 @@
+package exporter
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/config"
+)
+
+func TestSlackSource(t *testing.T) {
+	tests := []struct {
+		token, cookie string
+		want          string
+	}{
+		{"xoxc-123", "xoxd-456", SourceBrowserSession},
+		{"xoxc-123", "", SourceBrowserSession},
+		{"xoxp-123", "", SourceAPIToken},
+		{"xoxb-123", "", SourceAPIToken},
+	}
+	for _, tt := range tests {
+		if got := slackSource(tt.token, tt.cookie); got != tt.want {
+			t.Errorf("slackSource(%q, %q) = %q, want %q", tt.token, tt.cookie, got, tt.want)
+		}
+	}
+}
+
+func TestProvenanceLine(t *testing.T) {
+	e := &Exporter{slackSource: SourceBrowserSession, version: "v1.2.0"}
+	got := e.provenanceLine(time.Date(2024, 2, 3, 14, 5, 0, 0, time.UTC))
+	want := "Provenance: fetched 2024-02-03T14:05:00Z from Slack Web API (browser session) by get-out v1.2.0"
+	if got != want {
+		t.Errorf("provenanceLine() = %q, want %q", got, want)
+	}
+	if got := (&Exporter{}).provenanceLine(time.Time{}); !strings.Contains(got, "at an unrecorded time") {
+		t.Errorf("provenanceLine(zero) = %q", got)
+	}
+}
+
+func TestExportConversation_Provenance(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.provenance = true
+	exp.slackSource = SourceAPIToken
+
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+
+	convExport := exp.index.GetConversation("C001")
+	batches := drive.Appended(convExport.DailyDocs["2024-02-01"].DocID)
+	last := batches[len(batches)-1]
+	if len(last) != 1 || !strings.HasPrefix(last[0].Content, "Provenance: fetched ") || !strings.Contains(last[0].Content, SourceAPIToken) {
+		t.Errorf("last write to the daily doc = %+v, want the provenance line", last)
+	}
+	for _, thread := range convExport.Threads {
+		for _, doc := range thread.DailyDocs {
+			batches := drive.Appended(doc.DocID)
+			if last := batches[len(batches)-1]; !strings.HasPrefix(last[0].Content, "Provenance: ") {
+				t.Errorf("last write to the thread doc = %+v, want the provenance line", last)
+			}
+		}
+	}
+}
+
+func TestExportConversation_ProvenanceMarkdown(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	conv.Format = config.OutputFormatMarkdown
+	exp, localDir := localFormatExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.provenance = true
+
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	day := readFile(t, filepath.Join(localDir, SanitizeDirectoryName(string(conv.Type), conv.Name), "2024-02-02.md"))
+	if !strings.HasSuffix(day, "by get-out dev*\n\n") || !strings.Contains(day, "\n*Provenance: fetched ") {
+		t.Errorf("day file does not end with the provenance line:\n%s", day)
+	}
+
+	// Without the option no line is written.
+	drive, slack, conv = fakeConversation()
+	conv.Format = config.OutputFormatMarkdown
+	exp, localDir = localFormatExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatal(err)
+	}
+	if day := readFile(t, filepath.Join(localDir, SanitizeDirectoryName(string(conv.Type), conv.Name), "2024-02-02.md")); strings.Contains(day, "Provenance") {
+		t.Errorf("provenance written without the option:\n%s", day)
+	}
+}
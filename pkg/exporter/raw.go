@@ -0,0 +1,149 @@
+package exporter
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// rawWorkspaceFile holds responses that are not tied to a conversation
+// (users.info, users.list, ...).
+const rawWorkspaceFile = "_workspace"
+
+// RawRecord is one archived Slack API response, stored as a JSONL line.
+type RawRecord struct {
+	FetchedAt time.Time         `json:"fetched_at"`
+	Endpoint  string            `json:"endpoint"`
+	Params    map[string]string `json:"params,omitempty"`
+	Response  json.RawMessage   `json:"response"`
+}
+
+// RawArchive writes every Slack API response body to gzip-compressed JSONL
+// files, one per conversation (<dir>/<conversationID>.jsonl.gz), so exports
+// can be re-rendered later without refetching from Slack.
+//
+// Each run appends a new gzip member to existing files; gzip readers treat
+// concatenated members as one stream. RawArchive implements
+// slackapi.ResponseRecorder and is safe for concurrent use.
+type RawArchive struct {
+	dir string
+
+	mu      sync.Mutex
+	files   map[string]*rawFile
+	lastErr error
+}
+
+type rawFile struct {
+	f  *os.File
+	gz *gzip.Writer
+}
+
+// NewRawArchive creates a RawArchive that writes into dir.
+func NewRawArchive(dir string) *RawArchive {
+	return &RawArchive{
+		dir:   dir,
+		files: make(map[string]*rawFile),
+	}
+}
+
+// DefaultRawDir returns the default raw archive directory.
+func DefaultRawDir(configDir string) string {
+	return filepath.Join(configDir, "_raw")
+}
+
+// RawArchivePath returns the archive file for a conversation ID.
+func RawArchivePath(dir, convID string) string {
+	return filepath.Join(dir, convID+".jsonl.gz")
+}
+
+// RecordResponse archives one response body. Responses for requests with a
+// "channel" parameter go to that conversation's file; everything else goes
+// to _workspace.jsonl.gz. Write errors are kept and reported by Close.
+func (a *RawArchive) RecordResponse(endpoint string, params url.Values, body []byte) {
+	rec := RawRecord{
+		FetchedAt: time.Now().UTC(),
+		Endpoint:  endpoint,
+		Response:  json.RawMessage(body),
+	}
+	if len(params) > 0 {
+		rec.Params = make(map[string]string, len(params))
+		for k := range params {
+			rec.Params[k] = params.Get(k)
+		}
+	}
+	if !json.Valid(body) {
+		// Keep the record as valid JSONL even if Slack returned garbage.
+		quoted, _ := json.Marshal(string(body))
+		rec.Response = quoted
+	}
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		a.setErr(err)
+		return
+	}
+
+	key := params.Get("channel")
+	if key == "" {
+		key = rawWorkspaceFile
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	rf, err := a.openLocked(key)
+	if err != nil {
+		a.lastErr = err
+		return
+	}
+	if _, err := rf.gz.Write(append(line, '\n')); err != nil {
+		a.lastErr = fmt.Errorf("failed to write raw archive for %s: %w", key, err)
+	}
+}
+
+// openLocked returns the open writer for key, creating it on first use.
+// Caller must hold a.mu.
+func (a *RawArchive) openLocked(key string) (*rawFile, error) {
+	if rf, ok := a.files[key]; ok {
+		return rf, nil
+	}
+	if err := os.MkdirAll(a.dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create raw archive directory: %w", err)
+	}
+	f, err := os.OpenFile(RawArchivePath(a.dir, key), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open raw archive for %s: %w", key, err)
+	}
+	rf := &rawFile{f: f, gz: gzip.NewWriter(f)}
+	a.files[key] = rf
+	return rf, nil
+}
+
+func (a *RawArchive) setErr(err error) {
+	a.mu.Lock()
+	a.lastErr = err
+	a.mu.Unlock()
+}
+
+// Close flushes and closes all archive files. It returns the last error
+// encountered while recording or closing.
+func (a *RawArchive) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for key, rf := range a.files {
+		if err := rf.gz.Close(); err != nil {
+			a.lastErr = fmt.Errorf("failed to finalize raw archive for %s: %w", key, err)
+		}
+		if err := rf.f.Close(); err != nil {
+			a.lastErr = fmt.Errorf("failed to close raw archive for %s: %w", key, err)
+		}
+		delete(a.files, key)
+	}
+	return a.lastErr
+}
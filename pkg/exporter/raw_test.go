@@ -0,0 +1,119 @@
+package exporter
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+)
+
+// readRawRecords decodes every JSONL record in a gzip archive file.
+func readRawRecords(t *testing.T, path string) []RawRecord {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+
+	var records []RawRecord
+	sc := bufio.NewScanner(gz)
+	for sc.Scan() {
+		var rec RawRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			t.Fatalf("decode %q: %v", sc.Text(), err)
+		}
+		records = append(records, rec)
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+func TestRawArchive_RoutesByChannel(t *testing.T) {
+	dir := t.TempDir()
+	a := NewRawArchive(dir)
+
+	a.RecordResponse("conversations.history", url.Values{"channel": {"C001"}, "cursor": {"abc"}}, []byte(`{"ok":true,"messages":[]}`))
+	a.RecordResponse("conversations.replies", url.Values{"channel": {"C001"}, "ts": {"1.1"}}, []byte(`{"ok":true}`))
+	a.RecordResponse("users.info", url.Values{"user": {"U001"}}, []byte(`{"ok":true,"user":{}}`))
+	a.RecordResponse("auth.test", nil, []byte(`not json`))
+
+	if err := a.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	conv := readRawRecords(t, RawArchivePath(dir, "C001"))
+	if len(conv) != 2 {
+		t.Fatalf("C001 records = %d, want 2", len(conv))
+	}
+	if conv[0].Endpoint != "conversations.history" || conv[0].Params["cursor"] != "abc" {
+		t.Errorf("first record = %+v", conv[0])
+	}
+	if string(conv[0].Response) != `{"ok":true,"messages":[]}` {
+		t.Errorf("Response = %s", conv[0].Response)
+	}
+
+	ws := readRawRecords(t, RawArchivePath(dir, rawWorkspaceFile))
+	if len(ws) != 2 {
+		t.Fatalf("workspace records = %d, want 2", len(ws))
+	}
+	if string(ws[1].Response) != `"not json"` {
+		t.Errorf("invalid JSON should be stored as a string, got %s", ws[1].Response)
+	}
+}
+
+func TestRawArchive_AppendsAcrossRuns(t *testing.T) {
+	dir := t.TempDir()
+
+	for run := 0; run < 2; run++ {
+		a := NewRawArchive(dir)
+		a.RecordResponse("conversations.history", url.Values{"channel": {"C001"}}, []byte(fmt.Sprintf(`{"run":%d}`, run)))
+		if err := a.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records := readRawRecords(t, RawArchivePath(dir, "C001"))
+	if len(records) != 2 {
+		t.Fatalf("records = %d, want 2 (one per run)", len(records))
+	}
+	if string(records[1].Response) != `{"run":1}` {
+		t.Errorf("second run record = %s", records[1].Response)
+	}
+}
+
+func TestRawArchive_Concurrent(t *testing.T) {
+	dir := t.TempDir()
+	a := NewRawArchive(dir)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			a.RecordResponse("conversations.history", url.Values{"channel": {fmt.Sprintf("C%03d", i%3)}}, []byte(`{}`))
+		}(i)
+	}
+	wg.Wait()
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	total := 0
+	for i := 0; i < 3; i++ {
+		total += len(readRawRecords(t, RawArchivePath(dir, fmt.Sprintf("C%03d", i))))
+	}
+	if total != 20 {
+		t.Errorf("total records = %d, want 20", total)
+	}
+}
@@ -0,0 +1,418 @@
+package exporter
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/gdrive"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+	"google.golang.org/api/docs/v1"
+)
+
+// maxRawLineSize bounds a single JSONL record when reading raw archives.
+// A full conversations.history page is well under this.
+const maxRawLineSize = 64 << 20
+
+// ReadRawArchive calls fn for every record in a raw archive file written by
+// RawArchive, in the order they were recorded.
+func ReadRawArchive(path string, fn func(RawRecord) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to read raw archive %s: %w", path, err)
+	}
+	defer gz.Close()
+
+	sc := bufio.NewScanner(gz)
+	sc.Buffer(make([]byte, 0, 64*1024), maxRawLineSize)
+	var parseErr error
+	for sc.Scan() {
+		if parseErr != nil {
+			return parseErr
+		}
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var rec RawRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			parseErr = fmt.Errorf("failed to parse raw archive %s: %w", path, err)
+			continue
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	if err := sc.Err(); err != nil {
+		// A truncated final gzip member (e.g. an interrupted run) still
+		// yields every complete record before it; only its partial last
+		// line is dropped.
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil
+		}
+		return fmt.Errorf("failed to read raw archive %s: %w", path, err)
+	}
+	return parseErr
+}
+
+// LoadRawMessages reconstructs a conversation's messages, including thread
+// replies, from its raw archive. Messages fetched more than once are
+// deduplicated by timestamp, keeping the most recently fetched copy.
+// Results are ordered newest first, like conversations.history.
+func LoadRawMessages(dir, convID string) ([]slackapi.Message, error) {
+	byTS := make(map[string]slackapi.Message)
+	err := ReadRawArchive(RawArchivePath(dir, convID), func(rec RawRecord) error {
+		switch rec.Endpoint {
+		case "conversations.history", "conversations.replies":
+		default:
+			return nil
+		}
+		var resp slackapi.HistoryResponse
+		if err := json.Unmarshal(rec.Response, &resp); err != nil || !resp.OK {
+			return nil
+		}
+		for _, msg := range resp.Messages {
+			byTS[msg.TS] = msg
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]slackapi.Message, 0, len(byTS))
+	for _, msg := range byTS {
+		messages = append(messages, msg)
+	}
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].TS > messages[j].TS
+	})
+	return messages, nil
+}
+
+// LoadRawWorkspace populates the resolvers from the workspace-level raw
+// archive (users.info, users.list, conversations.list). A missing archive
+// is not an error; names then fall back to IDs.
+func LoadRawWorkspace(dir string, users *parser.UserResolver, channels *parser.ChannelResolver) error {
+	err := ReadRawArchive(RawArchivePath(dir, rawWorkspaceFile), func(rec RawRecord) error {
+		switch rec.Endpoint {
+		case "users.info":
+			var resp slackapi.UserInfoResponse
+			if json.Unmarshal(rec.Response, &resp) == nil && resp.OK && resp.User.ID != "" {
+				user := resp.User
+				users.AddUser(&user)
+			}
+		case "users.list":
+			var resp slackapi.UsersListResponse
+			if json.Unmarshal(rec.Response, &resp) == nil && resp.OK {
+				for i := range resp.Members {
+					users.AddUser(&resp.Members[i])
+				}
+			}
+		case "conversations.list":
+			var resp slackapi.ConversationsListResponse
+			if json.Unmarshal(rec.Response, &resp) == nil && resp.OK {
+				for _, ch := range resp.Channels {
+					if ch.Name != "" {
+						channels.AddChannel(ch.ID, ch.Name)
+					}
+				}
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// RendererConfig holds configuration for creating a Renderer.
+type RendererConfig struct {
+	// RawDir is the raw archive directory written by export --raw.
+	RawDir string
+
+	// OutputDir is the local markdown export directory to (re)write.
+	OutputDir string
+
+	// Index is the export index, whose recorded thread directories (see
+	// ThreadExport.LocalDir) threads are rendered into. Optional: without
+	// it, or for a thread it has no directory for, the directory is named
+	// after the thread's topic.
+	Index *ExportIndex
+
+	// MessageFilter is an optional sensitivity filter, applied exactly as
+	// in a normal local markdown export.
+	MessageFilter MessageFilter
+
+	// PersonResolver is optional and adds Google links to @mentions.
+	PersonResolver *parser.PersonResolver
+
+	// NamePolicy controls how user names are rendered (default: display-first).
+	NamePolicy config.NamePolicy
+
+	// Version is the get-out version recorded in markdown frontmatter.
+	Version string
+
+	// IncludeProfileStatus keeps users' status in users.json (see
+	// ExporterConfig). Archives recorded before scrubbing was the default
+	// still hold it, so it is scrubbed here too.
+	IncludeProfileStatus bool
+
+	// Layouts are the markdown layouts conversations select (see
+	// ExporterConfig.Layouts).
+	Layouts map[string]*Layout
+
+	// SkipEmojiMessages leaves out messages that are only emoji or a GIF
+	// (see ExporterConfig.SkipEmojiMessages).
+	SkipEmojiMessages bool
+
+	OnProgress func(msg string)
+}
+
+// Renderer re-renders the local markdown export from raw archives, using
+// the current writers and settings, without contacting Slack or Google.
+type Renderer struct {
+	rawDir        string
+	outputDir     string
+	index         *ExportIndex
+	messageFilter MessageFilter
+	onProgress    func(msg string)
+
+	includeProfileStatus bool
+	skipEmoji            bool
+
+	userResolver    *parser.UserResolver
+	channelResolver *parser.ChannelResolver
+	personResolver  *parser.PersonResolver
+	emoji           *parser.EmojiResolver // standard emoji only; custom ones are not archived
+	mdWriter        *MarkdownWriter
+	layouts         map[string]*Layout
+}
+
+// RenderResult holds the results of re-rendering one conversation.
+type RenderResult struct {
+	ConversationID string
+	Name           string
+	MessageCount   int
+	FilesWritten   int
+	FilteredCount  int
+}
+
+// NewRenderer creates a new Renderer with the given configuration.
+func NewRenderer(cfg *RendererConfig) *Renderer {
+	users := parser.NewUserResolver()
+	users.SetNamePolicy(cfg.NamePolicy)
+	channels := parser.NewChannelResolver()
+	mdWriter := NewMarkdownWriter(users, channels, cfg.PersonResolver)
+	mdWriter.SetExporterVersion(cfg.Version)
+	emoji := parser.NewEmojiResolver()
+	mdWriter.SetEmojiResolver(emoji)
+	return &Renderer{
+		rawDir:               cfg.RawDir,
+		outputDir:            cfg.OutputDir,
+		index:                cfg.Index,
+		messageFilter:        cfg.MessageFilter,
+		onProgress:           cfg.OnProgress,
+		userResolver:         users,
+		channelResolver:      channels,
+		personResolver:       cfg.PersonResolver,
+		emoji:                emoji,
+		mdWriter:             mdWriter,
+		layouts:              cfg.Layouts,
+		includeProfileStatus: cfg.IncludeProfileStatus,
+		skipEmoji:            cfg.SkipEmojiMessages,
+	}
+}
+
+// Progress is a helper to report progress.
+func (r *Renderer) Progress(format string, args ...interface{}) {
+	if r.onProgress != nil {
+		r.onProgress(fmt.Sprintf(format, args...))
+	}
+}
+
+// LoadWorkspace loads user and channel names from the raw archive.
+func (r *Renderer) LoadWorkspace() error {
+	if err := LoadRawWorkspace(r.rawDir, r.userResolver, r.channelResolver); err != nil {
+		return err
+	}
+	r.Progress("Loaded %d users from raw archive", r.userResolver.Count())
+	return nil
+}
+
+// HasRawArchive reports whether a raw archive exists for the conversation
+// or any of its aliases.
+func (r *Renderer) HasRawArchive(conv config.ConversationConfig) bool {
+	for _, id := range append([]string{conv.ID}, conv.Aliases...) {
+		if _, err := os.Stat(RawArchivePath(r.rawDir, id)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderConversation rewrites the daily markdown files for one conversation,
+// and those of its threads, from its raw archive (and those of its aliases).
+func (r *Renderer) RenderConversation(ctx context.Context, conv config.ConversationConfig) (*RenderResult, error) {
+	result := &RenderResult{
+		ConversationID: conv.ID,
+		Name:           conv.Name,
+	}
+
+	allMessages, err := r.loadMessages(conv)
+	if err != nil {
+		return result, err
+	}
+	mainMessages := FilterMainMessages(allMessages)
+	if len(mainMessages) == 0 {
+		r.Progress("No archived messages for %s", conv.Name)
+		return result, nil
+	}
+
+	messagesByDate := GroupMessagesByDate(mainMessages)
+	typeName := SanitizeDirectoryName(string(conv.Type), conv.Name)
+	for _, date := range SortedDates(messagesByDate) {
+		if err := r.renderDay(ctx, conv, typeName, date, messagesByDate[date], result); err != nil {
+			return result, err
+		}
+	}
+
+	for _, parent := range GetThreadParents(mainMessages) {
+		replies := FilterThreadMessages(allMessages, parent.TS)
+		dir := r.threadDir(conv, parent, replies)
+		repliesByDate := GroupMessagesByDate(replies)
+		for _, date := range SortedDates(repliesByDate) {
+			if err := r.renderDay(ctx, conv, dir, date, repliesByDate[date], result); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	r.Progress("Rendered %d messages into %d files for %s", result.MessageCount, result.FilesWritten, conv.Name)
+	return result, nil
+}
+
+// threadDir returns the directory a thread's markdown is rendered into:
+// the one the export wrote it to, as recorded in the index, so rendering
+// rewrites the thread's files rather than starting new ones under a topic
+// that has since changed.
+func (r *Renderer) threadDir(conv config.ConversationConfig, parent slackapi.Message, replies []slackapi.Message) string {
+	if r.index != nil {
+		if thread := r.index.GetThread(conv.ID, parent.TS); thread != nil && thread.LocalDir != "" {
+			return thread.LocalDir
+		}
+	}
+	return LocalThreadDir(string(conv.Type), conv.Name, parent.TS, ThreadTopic(parent, replies, r.userResolver, r.channelResolver, r.personResolver))
+}
+
+// renderDay writes one day's messages to {outputDir}/{dir}/{date}.md,
+// applying the sensitivity filter. A day left with no messages once the
+// messages of only emoji or a GIF are skipped is not written.
+func (r *Renderer) renderDay(ctx context.Context, conv config.ConversationConfig, dir, date string, msgs []slackapi.Message, result *RenderResult) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if r.skipEmoji {
+		if msgs, _ = withoutEmojiMessages(msgs); len(msgs) == 0 {
+			return nil
+		}
+	}
+	result.MessageCount += len(msgs)
+
+	var filterResult *FilterResult
+	if r.messageFilter != nil {
+		var err error
+		filterResult, err = r.messageFilter.FilterMessages(ctx, msgs)
+		if err != nil {
+			return fmt.Errorf("sensitivity classification failed for %q (%s): %w", conv.Name, date, err)
+		}
+		result.FilteredCount += filterResult.FilteredCount
+		if filterResult.AllFiltered() {
+			r.Progress("All %d messages filtered for %s — skipping markdown", filterResult.TotalCount, date)
+			return nil
+		}
+		msgs = filterResult.PassedMessages
+	}
+
+	content, err := r.mdWriter.WithLayout(r.layouts[conv.Template]).RenderDailyDoc(conv.ID, conv.Name, string(conv.Type), date, msgs, filterResult)
+	if err != nil {
+		return fmt.Errorf("failed to render markdown for %s: %w", date, err)
+	}
+	if err := ReplaceMarkdownFile(r.outputDir, dir, date, content); err != nil {
+		return fmt.Errorf("failed to write markdown for %s: %w", date, err)
+	}
+	result.FilesWritten++
+	return nil
+}
+
+// loadMainMessages returns the top-level messages archived for conv and its
+// aliases.
+func (r *Renderer) loadMainMessages(conv config.ConversationConfig) ([]slackapi.Message, error) {
+	allMessages, err := r.loadMessages(conv)
+	if err != nil {
+		return nil, err
+	}
+	return FilterMainMessages(allMessages), nil
+}
+
+// loadMessages returns every message (including thread replies) archived
+// for conv and its aliases.
+func (r *Renderer) loadMessages(conv config.ConversationConfig) ([]slackapi.Message, error) {
+	var allMessages []slackapi.Message
+	for _, id := range append([]string{conv.ID}, conv.Aliases...) {
+		msgs, err := LoadRawMessages(r.rawDir, id)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		allMessages = append(allMessages, msgs...)
+	}
+	return allMessages, nil
+}
+
+// DocRequests returns the Docs API batchUpdate request that export would
+// send to append conv's messages for date (YYYY-MM-DD) to a daily doc whose
+// body ends at startIndex (1 for a new doc). Nothing is sent to Google and
+// images are listed as file references, so contributors can inspect doc
+// formatting without spending quota. index, when non-nil, supplies the doc
+// and thread links that were recorded by earlier exports.
+func (r *Renderer) DocRequests(ctx context.Context, conv config.ConversationConfig, date string, startIndex int64, index *ExportIndex) (*docs.BatchUpdateDocumentRequest, error) {
+	mainMessages, err := r.loadMainMessages(conv)
+	if err != nil {
+		return nil, err
+	}
+	msgs := GroupMessagesByDate(mainMessages)[date]
+	if len(msgs) == 0 {
+		return nil, fmt.Errorf("no archived messages for %s on %s", conv.Name, date)
+	}
+
+	var linkResolver, threadResolver parser.SlackLinkResolver
+	if index != nil {
+		linkResolver, threadResolver = index.LookupDocURL, index.LookupThreadURL
+	}
+	w := NewDocWriter(nil, nil, r.userResolver, r.channelResolver, r.personResolver, linkResolver, threadResolver)
+	w.SetEmojiResolver(r.emoji)
+	if index != nil {
+		w.SetChannelLinkResolver(index.LookupConversationURL)
+	}
+	blocks := w.BuildBlocks(ctx, conv.ID, "", msgs)
+	return &docs.BatchUpdateDocumentRequest{
+		Requests: gdrive.BuildAppendRequests(startIndex, blocks),
+	}, nil
+}
@@ -0,0 +1,239 @@
+package exporter
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/models"
+	"github.com/jflowers/get-out/pkg/parser"
+)
+
+// writeRawFixture records responses into a raw archive under dir.
+func writeRawFixture(t *testing.T, dir string, records []RawRecord) {
+	t.Helper()
+	a := NewRawArchive(dir)
+	for _, rec := range records {
+		params := url.Values{}
+		for k, v := range rec.Params {
+			params.Set(k, v)
+		}
+		a.RecordResponse(rec.Endpoint, params, rec.Response)
+	}
+	if err := a.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+}
+
+func TestLoadRawMessages_DedupesAndIncludesReplies(t *testing.T) {
+	dir := t.TempDir()
+	writeRawFixture(t, dir, []RawRecord{
+		{Endpoint: "conversations.history", Params: map[string]string{"channel": "C001"},
+			Response: []byte(`{"ok":true,"messages":[{"ts":"1700000000.000100","text":"old copy"},{"ts":"1700000100.000100","text":"second"}]}`)},
+		{Endpoint: "conversations.history", Params: map[string]string{"channel": "C001"},
+			Response: []byte(`{"ok":true,"messages":[{"ts":"1700000000.000100","text":"edited"}]}`)},
+		{Endpoint: "conversations.replies", Params: map[string]string{"channel": "C001", "ts": "1700000100.000100"},
+			Response: []byte(`{"ok":true,"messages":[{"ts":"1700000200.000100","thread_ts":"1700000100.000100","text":"reply"}]}`)},
+		{Endpoint: "conversations.history", Params: map[string]string{"channel": "C001"},
+			Response: []byte(`{"ok":false,"error":"ratelimited"}`)},
+		{Endpoint: "conversations.info", Params: map[string]string{"channel": "C001"},
+			Response: []byte(`{"ok":true}`)},
+	})
+
+	msgs, err := LoadRawMessages(dir, "C001")
+	if err != nil {
+		t.Fatalf("LoadRawMessages() error: %v", err)
+	}
+	if len(msgs) != 3 {
+		t.Fatalf("got %d messages, want 3", len(msgs))
+	}
+	if msgs[0].Text != "reply" {
+		t.Errorf("msgs[0] = %q, want newest first", msgs[0].Text)
+	}
+	if msgs[2].Text != "edited" {
+		t.Errorf("msgs[2] = %q, want most recently fetched copy", msgs[2].Text)
+	}
+}
+
+func TestLoadRawMessages_Missing(t *testing.T) {
+	_, err := LoadRawMessages(t.TempDir(), "C404")
+	if !os.IsNotExist(err) {
+		t.Errorf("error = %v, want not-exist", err)
+	}
+}
+
+func TestLoadRawWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	writeRawFixture(t, dir, []RawRecord{
+		{Endpoint: "users.info", Params: map[string]string{"user": "U001"},
+			Response: []byte(`{"ok":true,"user":{"id":"U001","name":"alice","profile":{"display_name":"Alice"}}}`)},
+		{Endpoint: "users.list",
+			Response: []byte(`{"ok":true,"members":[{"id":"U002","name":"bob"}]}`)},
+		{Endpoint: "conversations.list",
+			Response: []byte(`{"ok":true,"channels":[{"id":"C001","name":"general"}]}`)},
+	})
+
+	users := parser.NewUserResolver()
+	channels := parser.NewChannelResolver()
+	if err := LoadRawWorkspace(dir, users, channels); err != nil {
+		t.Fatalf("LoadRawWorkspace() error: %v", err)
+	}
+	if users.Count() != 2 {
+		t.Errorf("users = %d, want 2", users.Count())
+	}
+
+	// A missing workspace archive is not an error.
+	if err := LoadRawWorkspace(t.TempDir(), users, channels); err != nil {
+		t.Errorf("missing archive error: %v", err)
+	}
+}
+
+func TestRenderer_RenderConversation(t *testing.T) {
+	rawDir := t.TempDir()
+	outDir := t.TempDir()
+	writeRawFixture(t, rawDir, []RawRecord{
+		{Endpoint: "users.info", Params: map[string]string{"user": "U001"},
+			Response: []byte(`{"ok":true,"user":{"id":"U001","name":"alice","profile":{"display_name":"Alice"}}}`)},
+		{Endpoint: "conversations.history", Params: map[string]string{"channel": "D001"},
+			Response: []byte(`{"ok":true,"messages":[{"ts":"1700000000.000100","user":"U001","text":"hello"}]}`)},
+		{Endpoint: "conversations.history", Params: map[string]string{"channel": "D000"},
+			Response: []byte(`{"ok":true,"messages":[{"ts":"1600000000.000100","user":"U001","text":"from the old DM"}]}`)},
+	})
+
+	conv := config.ConversationConfig{ID: "D001", Name: "Alice", Type: models.ConversationTypeDM, Aliases: []string{"D000"}}
+	typeName := SanitizeDirectoryName(string(conv.Type), conv.Name)
+
+	// A stale file from an earlier export must be replaced.
+	stale := filepath.Join(outDir, typeName, DateFromTS("1700000000.000100")+".md")
+	if err := os.MkdirAll(filepath.Dir(stale), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(stale, []byte("stale"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRenderer(&RendererConfig{RawDir: rawDir, OutputDir: outDir})
+	if !r.HasRawArchive(conv) {
+		t.Fatal("HasRawArchive() = false, want true")
+	}
+	if r.HasRawArchive(config.ConversationConfig{ID: "D999"}) {
+		t.Error("HasRawArchive() = true for conversation without archive")
+	}
+	if err := r.LoadWorkspace(); err != nil {
+		t.Fatalf("LoadWorkspace() error: %v", err)
+	}
+
+	result, err := r.RenderConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("RenderConversation() error: %v", err)
+	}
+	if result.MessageCount != 2 || result.FilesWritten != 2 {
+		t.Errorf("result = %+v, want 2 messages in 2 files", result)
+	}
+
+	data, err := os.ReadFile(stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "hello") || !strings.Contains(string(data), "Alice") {
+		t.Errorf("re-rendered file missing content:\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, typeName, DateFromTS("1600000000.000100")+".md")); err != nil {
+		t.Errorf("alias messages not rendered: %v", err)
+	}
+}
+
+func TestRenderer_RenderConversation_Threads(t *testing.T) {
+	rawDir := t.TempDir()
+	outDir := t.TempDir()
+	writeRawFixture(t, rawDir, []RawRecord{
+		{Endpoint: "conversations.history", Params: map[string]string{"channel": "C001"},
+			Response: []byte(`{"ok":true,"messages":[{"ts":"1700000000.000100","thread_ts":"1700000000.000100","reply_count":1,"user":"U001","text":"Launch plan"}]}`)},
+		{Endpoint: "conversations.replies", Params: map[string]string{"channel": "C001", "ts": "1700000000.000100"},
+			Response: []byte(`{"ok":true,"messages":[{"ts":"1700000000.000100","thread_ts":"1700000000.000100","reply_count":1,"user":"U001","text":"Launch plan"},{"ts":"1700090000.000200","thread_ts":"1700000000.000100","user":"U002","text":"looks good"}]}`)},
+	})
+
+	conv := config.ConversationConfig{ID: "C001", Name: "general", Type: models.ConversationTypeChannel}
+	r := NewRenderer(&RendererConfig{RawDir: rawDir, OutputDir: outDir})
+	result, err := r.RenderConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("RenderConversation() error: %v", err)
+	}
+	// One conversation day, plus two thread days (parent day and reply day).
+	if result.FilesWritten != 3 {
+		t.Errorf("FilesWritten = %d, want 3", result.FilesWritten)
+	}
+
+	threadDir := filepath.Join(outDir, LocalThreadDir(string(conv.Type), conv.Name, "1700000000.000100", "Launch plan"))
+	data, err := os.ReadFile(filepath.Join(threadDir, DateFromTS("1700090000.000200")+".md"))
+	if err != nil {
+		t.Fatalf("thread file not written: %v", err)
+	}
+	if !strings.Contains(string(data), "looks good") {
+		t.Errorf("thread file missing reply:\n%s", data)
+	}
+}
+
+func TestRenderer_RenderConversation_RecordedThreadDir(t *testing.T) {
+	rawDir := t.TempDir()
+	outDir := t.TempDir()
+	writeRawFixture(t, rawDir, []RawRecord{
+		{Endpoint: "conversations.history", Params: map[string]string{"channel": "C001"},
+			Response: []byte(`{"ok":true,"messages":[{"ts":"1700000000.000100","thread_ts":"1700000000.000100","reply_count":1,"user":"U001","text":"Launch plan"}]}`)},
+		{Endpoint: "conversations.replies", Params: map[string]string{"channel": "C001", "ts": "1700000000.000100"},
+			Response: []byte(`{"ok":true,"messages":[{"ts":"1700000000.000100","thread_ts":"1700000000.000100","reply_count":1,"user":"U001","text":"Launch plan"},{"ts":"1700000100.000200","thread_ts":"1700000000.000100","user":"U002","text":"looks good"}]}`)},
+	})
+
+	conv := config.ConversationConfig{ID: "C001", Name: "general", Type: models.ConversationTypeChannel}
+	index := NewExportIndex("")
+	index.GetOrCreateConversation(conv.ID, conv.Name, string(conv.Type))
+	recorded := LocalThreadDir(string(conv.Type), conv.Name, "1700000000.000100", "Launch")
+	index.SetThread(conv.ID, &ThreadExport{ThreadTS: "1700000000.000100", LocalDir: recorded})
+
+	r := NewRenderer(&RendererConfig{RawDir: rawDir, OutputDir: outDir, Index: index})
+	if _, err := r.RenderConversation(context.Background(), conv); err != nil {
+		t.Fatalf("RenderConversation() error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, recorded, DateFromTS("1700000000.000100")+".md")); err != nil {
+		t.Errorf("thread not rendered into its recorded directory: %v", err)
+	}
+}
+
+func TestRenderer_DocRequests(t *testing.T) {
+	rawDir := t.TempDir()
+	writeRawFixture(t, rawDir, []RawRecord{
+		{Endpoint: "users.info", Params: map[string]string{"user": "U001"},
+			Response: []byte(`{"ok":true,"user":{"id":"U001","name":"alice","profile":{"display_name":"Alice"}}}`)},
+		{Endpoint: "conversations.history", Params: map[string]string{"channel": "C001"},
+			Response: []byte(`{"ok":true,"messages":[{"ts":"1700000000.000100","user":"U001","text":"hello","files":[{"name":"cat.png","mimetype":"image/png"}]}]}`)},
+	})
+
+	conv := config.ConversationConfig{ID: "C001", Name: "general", Type: models.ConversationTypeChannel}
+	r := NewRenderer(&RendererConfig{RawDir: rawDir})
+	if err := r.LoadWorkspace(); err != nil {
+		t.Fatal(err)
+	}
+
+	date := DateFromTS("1700000000.000100")
+	req, err := r.DocRequests(context.Background(), conv, date, 1, NewExportIndex(""))
+	if err != nil {
+		t.Fatalf("DocRequests() error: %v", err)
+	}
+	if len(req.Requests) != 3 {
+		t.Fatalf("got %d requests, want 3", len(req.Requests))
+	}
+	if header := req.Requests[0].InsertText; header == nil || !strings.HasPrefix(header.Text, "Alice  ") {
+		t.Errorf("header = %+v", req.Requests[0].InsertText)
+	}
+	if body := req.Requests[2].InsertText; body == nil || body.Text != "hello\n[File: cat.png]\n\n" {
+		t.Errorf("body = %+v", req.Requests[2].InsertText)
+	}
+
+	if _, err := r.DocRequests(context.Background(), conv, "1999-01-01", 1, nil); err == nil {
+		t.Error("expected error for a day with no messages")
+	}
+}
@@ -0,0 +1,106 @@
+package exporter
+
+import (
+	"sort"
+	"time"
+)
+
+// MergedFolder is a Drive folder that several conversations were exported
+// into, as happened to DMs with people sharing a display name before
+// conversation folder names were made unique.
+type MergedFolder struct {
+	FolderName string
+	FolderURL  string
+
+	// Owner is the conversation keeping the folder: the one with the most
+	// exported messages. Moved are the others, in ID order.
+	Owner string
+	Moved []string
+}
+
+// FindMergedFolders returns the conversation folders shared by more than
+// one conversation of the index, ordered by folder name.
+func (idx *ExportIndex) FindMergedFolders() []MergedFolder {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	type member struct {
+		id       string
+		messages int
+	}
+	byFolder := make(map[string][]member)
+	names := make(map[string]string)
+	urls := make(map[string]string)
+	for id, conv := range idx.Conversations {
+		conv.mu.Lock()
+		if conv.FolderID != "" {
+			byFolder[conv.FolderID] = append(byFolder[conv.FolderID], member{id: id, messages: conv.MessageCount})
+			names[conv.FolderID] = conv.folderName()
+			urls[conv.FolderID] = conv.FolderURL
+		}
+		conv.mu.Unlock()
+	}
+
+	var merged []MergedFolder
+	for folderID, members := range byFolder {
+		if len(members) < 2 {
+			continue
+		}
+		sort.Slice(members, func(i, j int) bool {
+			if members[i].messages != members[j].messages {
+				return members[i].messages > members[j].messages
+			}
+			return members[i].id < members[j].id
+		})
+		m := MergedFolder{FolderName: names[folderID], FolderURL: urls[folderID], Owner: members[0].id}
+		for _, other := range members[1:] {
+			m.Moved = append(m.Moved, other.id)
+		}
+		sort.Strings(m.Moved)
+		merged = append(merged, m)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		if merged[i].FolderName != merged[j].FolderName {
+			return merged[i].FolderName < merged[j].FolderName
+		}
+		return merged[i].Owner < merged[j].Owner
+	})
+	return merged
+}
+
+// DetachConversation forgets where a conversation was exported in Drive:
+// its folder, daily docs, threads, and sync checkpoint. Its status becomes
+// pending, so the next export writes its whole history again, into a
+// folder of its own. Annotations and settings such as its layout are
+// kept. It returns false when convID is not in the index.
+func (idx *ExportIndex) DetachConversation(convID string) bool {
+	conv := idx.GetConversation(convID)
+	if conv == nil {
+		return false
+	}
+
+	conv.mu.Lock()
+	conv.FolderID = ""
+	conv.FolderURL = ""
+	conv.FolderName = ""
+	conv.ThreadsFolderID = ""
+	conv.FilesFolderID = ""
+	conv.Files = nil
+	conv.DateFolders = nil
+	conv.ThreadDateFolders = nil
+	conv.FolderItems = nil
+	conv.DailyDocs = make(map[string]*DocExport)
+	conv.Threads = make(map[string]*ThreadExport)
+	conv.LastMessageTS = ""
+	conv.SourceCursors = nil
+	conv.MergedFolders = nil
+	conv.FirstMessageTS = ""
+	conv.CompletedDays = nil
+	conv.Fetch = nil
+	conv.MessageCount = 0
+	conv.Status = StatusPending
+	conv.Error = ""
+	conv.LastUpdated = time.Now()
+	conv.mu.Unlock()
+	return true
+}
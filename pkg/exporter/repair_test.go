@@ -0,0 +1,59 @@
+package exporter
+
+import (
+	"testing"
+)
+
+func TestFindMergedFolders(t *testing.T) {
+	idx := NewExportIndex("")
+	for _, c := range []struct {
+		id, folder string
+		messages   int
+	}{
+		{"D001", "folder-john", 10},
+		{"D002", "folder-john", 250},
+		{"D003", "folder-john", 10},
+		{"D004", "folder-jane", 5},
+		{"C001", "", 0},
+	} {
+		conv := idx.GetOrCreateConversation(c.id, "John Smith", "dm")
+		conv.FolderID = c.folder
+		conv.FolderURL = "https://drive.google.com/drive/folders/" + c.folder
+		conv.MessageCount = c.messages
+	}
+
+	merged := idx.FindMergedFolders()
+	if len(merged) != 1 {
+		t.Fatalf("FindMergedFolders() = %+v, want one folder", merged)
+	}
+	m := merged[0]
+	if m.FolderName != "DM - John Smith" || m.Owner != "D002" || len(m.Moved) != 2 || m.Moved[0] != "D001" || m.Moved[1] != "D003" {
+		t.Errorf("merged folder = %+v, want D002 (most messages) keeping it", m)
+	}
+}
+
+func TestDetachConversation(t *testing.T) {
+	idx := NewExportIndex("")
+	conv := idx.GetOrCreateConversation("D001", "John Smith", "dm")
+	conv.FolderID = "folder-john"
+	conv.FolderName = "DM - John Smith"
+	conv.DailyDocs["2024-02-01"] = &DocExport{DocID: "doc1"}
+	conv.Threads["1.0"] = &ThreadExport{ThreadTS: "1.0"}
+	conv.LastMessageTS = "1706788800.000100"
+	conv.MessageCount = 3
+	conv.Status = StatusComplete
+	conv.Tags = []string{"legal-hold"}
+
+	if !idx.DetachConversation("D001") {
+		t.Fatal("DetachConversation(D001) = false")
+	}
+	if conv.FolderID != "" || conv.FolderName != "" || len(conv.DailyDocs) != 0 || len(conv.Threads) != 0 || conv.LastMessageTS != "" || conv.Status != StatusPending {
+		t.Errorf("conversation after detach = %+v, want its Drive state forgotten", conv)
+	}
+	if len(conv.Tags) != 1 {
+		t.Errorf("Tags = %v, want annotations kept", conv.Tags)
+	}
+	if idx.DetachConversation("D404") {
+		t.Error("DetachConversation(D404) = true for a conversation not in the index")
+	}
+}
@@ -0,0 +1,462 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/gdrive"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// RollupFolderName is the subfolder of each conversation folder holding its
+// weekly rollup docs.
+const RollupFolderName = "Weekly Rollups"
+
+// How many participants and threads a weekly rollup lists.
+const (
+	rollupTopParticipants = 5
+	rollupTopThreads      = 5
+)
+
+// RollupDrive is the part of the Drive client that writes weekly rollup
+// docs. It is satisfied by *gdrive.Client.
+type RollupDrive interface {
+	FindOrCreateFolder(ctx context.Context, name string, parentID string) (*gdrive.FolderInfo, error)
+	FindDocument(ctx context.Context, title string, folderID string) (*gdrive.DocInfo, error)
+	FindOrCreateDocument(ctx context.Context, title string, folderID string) (*gdrive.DocInfo, error)
+	ReplaceDocumentContent(ctx context.Context, docID string, messages []gdrive.MessageBlock) error
+}
+
+var _ RollupDrive = (*gdrive.Client)(nil)
+
+// RollupWeek summarizes one week (Monday to Sunday) of a conversation.
+type RollupWeek struct {
+	// Start is the week's Monday and End its Sunday, as YYYY-MM-DD.
+	Start string
+	End   string
+
+	// Messages counts the messages posted in the week, and Replies the
+	// thread replies among them.
+	Messages int
+	Replies  int
+
+	// People is the number of people who posted in the week.
+	People int
+
+	// Days lists the days with messages, oldest first.
+	Days []RollupDay
+
+	// Participants are the most active people, most messages first.
+	Participants []RollupParticipant
+
+	// Threads are the threads started in the week with the most replies
+	// and reactions.
+	Threads []RollupThread
+}
+
+// RollupDay is one day's daily doc in a weekly rollup.
+type RollupDay struct {
+	Date     string
+	Messages int
+	DocURL   string
+}
+
+// RollupParticipant is a person and the messages they posted in a week.
+type RollupParticipant struct {
+	UserID   string
+	Name     string
+	Messages int
+}
+
+// RollupThread is a thread listed in a weekly rollup.
+type RollupThread struct {
+	ThreadTS  string
+	Topic     string
+	Author    string
+	Replies   int
+	Reactions int
+	URL       string
+}
+
+// score ranks threads in a rollup.
+func (t RollupThread) score() int {
+	return t.Replies + t.Reactions
+}
+
+// BuildRollupWeeks groups msgs, a conversation's messages and thread
+// replies in any order, into weekly summaries, oldest week first. Links to
+// daily docs and thread folders come from conv, which may be nil. Weeks
+// ending before since (YYYY-MM-DD, may be empty) are left out.
+func BuildRollupWeeks(conv *ConversationExport, msgs []slackapi.Message, since string, users *parser.UserResolver, channels *parser.ChannelResolver, people *parser.PersonResolver) []RollupWeek {
+	type weekData struct {
+		week    RollupWeek
+		days    map[string]int
+		authors map[string]int
+		parents []slackapi.Message
+	}
+	weeks := make(map[string]*weekData)
+	replies := make(map[string][]slackapi.Message)
+
+	for _, msg := range msgs {
+		date := DateFromTS(msg.TS)
+		start, end := weekOf(date)
+		if start == "" || end < since {
+			continue
+		}
+		w, ok := weeks[start]
+		if !ok {
+			w = &weekData{
+				week:    RollupWeek{Start: start, End: end},
+				days:    make(map[string]int),
+				authors: make(map[string]int),
+			}
+			weeks[start] = w
+		}
+		w.week.Messages++
+		if msg.User != "" {
+			w.authors[msg.User]++
+		}
+		if msg.ThreadTS != "" && msg.ThreadTS != msg.TS {
+			w.week.Replies++
+			replies[msg.ThreadTS] = append(replies[msg.ThreadTS], msg)
+			continue
+		}
+		w.days[date]++
+		if msg.ThreadTS == msg.TS || msg.ReplyCount > 0 {
+			w.parents = append(w.parents, msg)
+		}
+	}
+
+	starts := make([]string, 0, len(weeks))
+	for start := range weeks {
+		starts = append(starts, start)
+	}
+	sort.Strings(starts)
+
+	result := make([]RollupWeek, 0, len(starts))
+	for _, start := range starts {
+		w := weeks[start]
+
+		for date, n := range w.days {
+			day := RollupDay{Date: date, Messages: n}
+			if conv != nil {
+				if doc := conv.dailyDoc(date); doc != nil {
+					day.DocURL = doc.DocURL
+				}
+			}
+			w.week.Days = append(w.week.Days, day)
+		}
+		sort.Slice(w.week.Days, func(i, j int) bool { return w.week.Days[i].Date < w.week.Days[j].Date })
+
+		w.week.People = len(w.authors)
+		for id, n := range w.authors {
+			w.week.Participants = append(w.week.Participants, RollupParticipant{UserID: id, Name: resolveUserName(id, users, people), Messages: n})
+		}
+		sort.Slice(w.week.Participants, func(i, j int) bool {
+			a, b := w.week.Participants[i], w.week.Participants[j]
+			if a.Messages != b.Messages {
+				return a.Messages > b.Messages
+			}
+			return a.Name < b.Name
+		})
+		if len(w.week.Participants) > rollupTopParticipants {
+			w.week.Participants = w.week.Participants[:rollupTopParticipants]
+		}
+
+		for _, parent := range w.parents {
+			threadReplies := replies[parent.TS]
+			sort.Slice(threadReplies, func(i, j int) bool { return threadReplies[i].TS < threadReplies[j].TS })
+			t := RollupThread{
+				ThreadTS:  parent.TS,
+				Topic:     ThreadTopic(parent, threadReplies, users, channels, people),
+				Author:    resolveUserName(parent.User, users, people),
+				Replies:   len(threadReplies),
+				Reactions: reactionCount(parent),
+			}
+			if t.Replies < parent.ReplyCount {
+				t.Replies = parent.ReplyCount
+			}
+			for _, reply := range threadReplies {
+				t.Reactions += reactionCount(reply)
+			}
+			if conv != nil {
+				if thread := conv.Threads[parent.TS]; thread != nil {
+					t.URL = thread.FolderURL
+				}
+			}
+			w.week.Threads = append(w.week.Threads, t)
+		}
+		sort.SliceStable(w.week.Threads, func(i, j int) bool {
+			a, b := w.week.Threads[i], w.week.Threads[j]
+			if a.score() != b.score() {
+				return a.score() > b.score()
+			}
+			return a.ThreadTS < b.ThreadTS
+		})
+		if len(w.week.Threads) > rollupTopThreads {
+			w.week.Threads = w.week.Threads[:rollupTopThreads]
+		}
+
+		result = append(result, w.week)
+	}
+	return result
+}
+
+// weekOf returns the Monday and Sunday of the week containing date
+// (YYYY-MM-DD), or empty strings when date does not parse.
+func weekOf(date string) (string, string) {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return "", ""
+	}
+	monday := t.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+	return monday.Format("2006-01-02"), monday.AddDate(0, 0, 6).Format("2006-01-02")
+}
+
+// reactionCount returns the number of reactions on msg.
+func reactionCount(msg slackapi.Message) int {
+	n := 0
+	for _, r := range msg.Reactions {
+		n += r.Count
+	}
+	return n
+}
+
+// RollupDocTitle returns the title of the rollup doc for the week starting
+// on start.
+func RollupDocTitle(start string) string {
+	return "Week of " + start
+}
+
+// rollupBlocks renders a weekly rollup doc: a summary, the most active
+// participants, the top threads, and links to the week's daily docs.
+func rollupBlocks(convName string, w RollupWeek) []gdrive.MessageBlock {
+	summary := fmt.Sprintf("%d %s (%d thread %s) from %d %s",
+		w.Messages, plural(w.Messages, "message", "messages"), w.Replies, plural(w.Replies, "reply", "replies"),
+		w.People, plural(w.People, "person", "people"))
+	blocks := []gdrive.MessageBlock{{
+		SenderName: convName,
+		Timestamp:  fmt.Sprintf("%s to %s", w.Start, w.End),
+		Content:    summary + "\nGenerated by get-out rollup.",
+	}}
+
+	if len(w.Participants) > 0 {
+		lines := make([]string, 0, len(w.Participants))
+		for _, p := range w.Participants {
+			lines = append(lines, fmt.Sprintf("%s: %d %s", p.Name, p.Messages, plural(p.Messages, "message", "messages")))
+		}
+		blocks = append(blocks, gdrive.MessageBlock{SenderName: "Most active", Content: strings.Join(lines, "\n")})
+	}
+
+	for i, t := range w.Threads {
+		row := gdrive.MessageBlock{
+			SenderName: fmt.Sprintf("Top thread %d: %s", i+1, t.Topic),
+			Timestamp:  fmt.Sprintf("started by %s on %s", orDefault(t.Author, "unknown"), DateFromTS(t.ThreadTS)),
+			Content: fmt.Sprintf("%d %s, %d %s", t.Replies, plural(t.Replies, "reply", "replies"),
+				t.Reactions, plural(t.Reactions, "reaction", "reactions")),
+		}
+		if t.URL != "" {
+			row.Content += "\nOpen thread"
+			row.Links = []gdrive.LinkAnnotation{{Text: "Open thread", URL: t.URL}}
+		}
+		blocks = append(blocks, row)
+	}
+
+	if len(w.Days) > 0 {
+		row := gdrive.MessageBlock{SenderName: "Daily docs"}
+		lines := make([]string, 0, len(w.Days))
+		for _, d := range w.Days {
+			weekday := ""
+			if t, err := time.Parse("2006-01-02", d.Date); err == nil {
+				weekday = t.Weekday().String() + " "
+			}
+			lines = append(lines, fmt.Sprintf("%s%s: %d %s", weekday, d.Date, d.Messages, plural(d.Messages, "message", "messages")))
+			if d.DocURL != "" {
+				row.Links = append(row.Links, gdrive.LinkAnnotation{Text: d.Date, URL: d.DocURL})
+			}
+		}
+		row.Content = strings.Join(lines, "\n")
+		blocks = append(blocks, row)
+	}
+	return blocks
+}
+
+// plural returns one when n is 1, and many otherwise.
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// RollupOptions configures WriteRollups.
+type RollupOptions struct {
+	// RawDir is the raw archive directory messages are read from.
+	RawDir string
+
+	// Since leaves out weeks ending before this date (YYYY-MM-DD).
+	Since string
+
+	// ConversationIDs limits the rollups to these conversations; empty
+	// means every conversation.
+	ConversationIDs []string
+
+	// Resolvers used for names and thread topics; any may be nil.
+	Users    *parser.UserResolver
+	Channels *parser.ChannelResolver
+	People   *parser.PersonResolver
+
+	// LegalHold keeps rollup docs already written as they are; only weeks
+	// without a rollup get one.
+	LegalHold bool
+}
+
+// RollupResult is the outcome of writing one conversation's rollups.
+type RollupResult struct {
+	ConversationID string
+	Name           string
+
+	// Weeks is the number of rollup docs written.
+	Weeks int
+
+	// FolderURL is the conversation's Weekly Rollups folder.
+	FolderURL string
+
+	// Skipped says why the conversation has no rollups, and Err why
+	// writing them failed.
+	Skipped string
+	Err     error
+}
+
+// RollupReport lists the outcome for every conversation considered.
+type RollupReport struct {
+	Results []RollupResult
+}
+
+// Failed returns the number of conversations whose rollups failed.
+func (r *RollupReport) Failed() int {
+	n := 0
+	for _, res := range r.Results {
+		if res.Err != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// WriteRollups writes one rollup doc per week into a Weekly Rollups folder
+// in each exported conversation's Drive folder, replacing the content of
+// rollups written before (unless opts.LegalHold). Messages come from the
+// raw archive saved by `export --raw`, keeping only those the export index
+// records as written to docs, so messages the export left out are not
+// summarized either; conversations without an archive, or not exported to
+// Drive, are skipped. A conversation that fails is reported and the others
+// are still written.
+func WriteRollups(ctx context.Context, drive RollupDrive, index *ExportIndex, convs []config.ConversationConfig, opts RollupOptions) *RollupReport {
+	report := &RollupReport{}
+	for _, conv := range convs {
+		if len(opts.ConversationIDs) > 0 && !containsString(opts.ConversationIDs, conv.ID) {
+			continue
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		report.Results = append(report.Results, writeConversationRollups(ctx, drive, index.GetConversation(conv.ID), conv, opts))
+	}
+	return report
+}
+
+// writeConversationRollups writes the rollups of one conversation.
+func writeConversationRollups(ctx context.Context, drive RollupDrive, export *ConversationExport, conv config.ConversationConfig, opts RollupOptions) RollupResult {
+	result := RollupResult{ConversationID: conv.ID, Name: conv.Name}
+	if export == nil || export.FolderID == "" {
+		result.Skipped = "not exported to Google Drive"
+		return result
+	}
+
+	var msgs []slackapi.Message
+	found := false
+	for _, id := range append([]string{conv.ID}, conv.Aliases...) {
+		m, err := LoadRawMessages(opts.RawDir, id)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		found = true
+		msgs = append(msgs, m...)
+	}
+	if !found {
+		result.Skipped = "no raw archive (run 'get-out export --raw')"
+		return result
+	}
+
+	weeks := BuildRollupWeeks(export, exportedMessages(export, msgs), opts.Since, opts.Users, opts.Channels, opts.People)
+	if len(weeks) == 0 {
+		result.Skipped = "no messages"
+		return result
+	}
+
+	folder, err := drive.FindOrCreateFolder(ctx, RollupFolderName, export.FolderID)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to create %s folder: %w", RollupFolderName, err)
+		return result
+	}
+	result.FolderURL = folder.URL
+
+	for _, week := range weeks {
+		if opts.LegalHold {
+			existing, err := drive.FindDocument(ctx, RollupDocTitle(week.Start), folder.ID)
+			if err != nil {
+				result.Err = fmt.Errorf("failed to look up rollup for week of %s: %w", week.Start, err)
+				return result
+			}
+			if existing != nil {
+				continue
+			}
+		}
+		doc, err := drive.FindOrCreateDocument(ctx, RollupDocTitle(week.Start), folder.ID)
+		if err != nil {
+			result.Err = fmt.Errorf("failed to create rollup for week of %s: %w", week.Start, err)
+			return result
+		}
+		if err := drive.ReplaceDocumentContent(ctx, doc.ID, rollupBlocks(conv.Name, week)); err != nil {
+			result.Err = fmt.Errorf("failed to write rollup for week of %s: %w", week.Start, err)
+			return result
+		}
+		result.Weeks++
+	}
+	return result
+}
+
+// exportedMessages returns the messages of msgs that export wrote to docs:
+// main messages up to the newest recorded in their day's doc, and replies
+// up to the newest recorded for their thread. Messages an export skipped,
+// or has not reached yet, have no such record.
+func exportedMessages(export *ConversationExport, msgs []slackapi.Message) []slackapi.Message {
+	var kept []slackapi.Message
+	for _, msg := range msgs {
+		if msg.ThreadTS != "" && msg.ThreadTS != msg.TS {
+			thread := export.Threads[msg.ThreadTS]
+			if thread == nil || (thread.LastReplyTS != "" && msg.TS > thread.LastReplyTS) {
+				continue
+			}
+		} else {
+			doc := export.dailyDoc(DateFromTS(msg.TS))
+			if doc == nil || (doc.LastMessageTS != "" && msg.TS > doc.LastMessageTS) {
+				continue
+			}
+		}
+		kept = append(kept, msg)
+	}
+	return kept
+}
@@ -0,0 +1,198 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/internal/testutil"
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// Noon UTC on Monday 2024-01-01, and the days after it.
+const (
+	rollupMon     = "1704110400.000100"
+	rollupTue     = "1704196800.000100"
+	rollupWed     = "1704283200.000100"
+	rollupNextMon = "1704715200.000100"
+)
+
+func TestBuildRollupWeeks(t *testing.T) {
+	users := parser.NewUserResolver()
+	users.AddUser(&slackapi.User{ID: "U001", Name: "alice", Profile: slackapi.UserProfile{DisplayName: "Alice"}})
+	users.AddUser(&slackapi.User{ID: "U002", Name: "bob", Profile: slackapi.UserProfile{DisplayName: "Bob"}})
+
+	conv := &ConversationExport{
+		DailyDocs: map[string]*DocExport{"2024-01-01": {DocURL: "https://docs.google.com/d/mon"}},
+		Threads:   map[string]*ThreadExport{rollupTue: {FolderURL: "https://drive.google.com/t/tue"}},
+	}
+	msgs := []slackapi.Message{
+		{TS: rollupMon, User: "U001", Text: "quiet thread", ThreadTS: rollupMon, ReplyCount: 1},
+		{TS: "1704110500.000100", User: "U002", ThreadTS: rollupMon, Text: "ok"},
+		{TS: rollupTue, User: "U002", Text: "big thread", ThreadTS: rollupTue,
+			Reactions: []slackapi.Reaction{{Name: "tada", Count: 3}}},
+		{TS: "1704196900.000100", User: "U001", ThreadTS: rollupTue, Text: "reply one"},
+		{TS: "1704197000.000100", User: "U001", ThreadTS: rollupTue, Text: "reply two",
+			Reactions: []slackapi.Reaction{{Name: "+1", Count: 1}}},
+		{TS: rollupWed, User: "U001", Text: "plain"},
+		{TS: rollupNextMon, User: "U002", Text: "next week"},
+	}
+
+	weeks := BuildRollupWeeks(conv, msgs, "", users, nil, nil)
+	if len(weeks) != 2 {
+		t.Fatalf("got %d weeks, want 2", len(weeks))
+	}
+	w := weeks[0]
+	if w.Start != "2024-01-01" || w.End != "2024-01-07" {
+		t.Errorf("week = %s to %s", w.Start, w.End)
+	}
+	if w.Messages != 6 || w.Replies != 3 || w.People != 2 {
+		t.Errorf("counts = %d messages, %d replies, %d people", w.Messages, w.Replies, w.People)
+	}
+	if len(w.Days) != 3 || w.Days[0].Messages != 1 || w.Days[0].DocURL != "https://docs.google.com/d/mon" || w.Days[1].DocURL != "" {
+		t.Errorf("days = %+v", w.Days)
+	}
+	if len(w.Participants) != 2 || w.Participants[0] != (RollupParticipant{UserID: "U001", Name: "Alice", Messages: 4}) {
+		t.Errorf("participants = %+v", w.Participants)
+	}
+	if len(w.Threads) != 2 {
+		t.Fatalf("threads = %+v", w.Threads)
+	}
+	top := w.Threads[0]
+	if top.ThreadTS != rollupTue || top.Replies != 2 || top.Reactions != 4 || top.Author != "Bob" || top.URL != "https://drive.google.com/t/tue" {
+		t.Errorf("top thread = %+v", top)
+	}
+
+	if weeks := BuildRollupWeeks(conv, msgs, "2024-01-08", users, nil, nil); len(weeks) != 1 || weeks[0].Start != "2024-01-08" {
+		t.Errorf("since 2024-01-08: %+v", weeks)
+	}
+	if weeks := BuildRollupWeeks(conv, msgs, "2024-01-07", users, nil, nil); len(weeks) != 2 {
+		t.Errorf("since 2024-01-07 kept %d weeks, want the week it ends", len(weeks))
+	}
+}
+
+func TestRollupBlocks(t *testing.T) {
+	blocks := rollupBlocks("general", RollupWeek{
+		Start: "2024-01-01", End: "2024-01-07", Messages: 3, Replies: 1, People: 1,
+		Days:         []RollupDay{{Date: "2024-01-01", Messages: 2, DocURL: "https://docs.google.com/d/mon"}},
+		Participants: []RollupParticipant{{UserID: "U001", Name: "Alice", Messages: 3}},
+		Threads:      []RollupThread{{ThreadTS: rollupMon, Topic: "Launch", Author: "Alice", Replies: 1, URL: "https://drive.google.com/t"}},
+	})
+	if len(blocks) != 4 {
+		t.Fatalf("got %d blocks, want 4", len(blocks))
+	}
+	if !strings.HasPrefix(blocks[0].Content, "3 messages (1 thread reply) from 1 person") {
+		t.Errorf("summary = %q", blocks[0].Content)
+	}
+	if blocks[1].Content != "Alice: 3 messages" {
+		t.Errorf("participants = %q", blocks[1].Content)
+	}
+	if blocks[2].SenderName != "Top thread 1: Launch" || len(blocks[2].Links) != 1 {
+		t.Errorf("thread block = %+v", blocks[2])
+	}
+	if blocks[3].Content != "Monday 2024-01-01: 2 messages" || blocks[3].Links[0].Text != "2024-01-01" {
+		t.Errorf("days block = %+v", blocks[3])
+	}
+}
+
+func TestWriteRollups(t *testing.T) {
+	rawDir := t.TempDir()
+	writeRawFixture(t, rawDir, []RawRecord{
+		{Endpoint: "conversations.history", Params: map[string]string{"channel": "C001"},
+			Response: []byte(`{"ok":true,"messages":[{"ts":"` + rollupMon + `","user":"U001","text":"hi"},{"ts":"` + rollupNextMon + `","user":"U001","text":"later"}]}`)},
+	})
+
+	drive := testutil.NewFakeDrive()
+	drive.AddFolder("F001", "general")
+	index := NewExportIndex("")
+	index.SetConversation(&ConversationExport{ID: "C001", Name: "general", FolderID: "F001", DailyDocs: map[string]*DocExport{
+		"2024-01-01": {DocID: "D1", LastMessageTS: rollupMon},
+		"2024-01-08": {DocID: "D2", LastMessageTS: rollupNextMon},
+	}})
+	index.SetConversation(&ConversationExport{ID: "C002", Name: "random", FolderID: "F002"})
+	convs := []config.ConversationConfig{
+		{ID: "C001", Name: "general"},
+		{ID: "C002", Name: "random"},
+		{ID: "C003", Name: "local-only"},
+	}
+
+	report := WriteRollups(context.Background(), drive, index, convs, RollupOptions{RawDir: rawDir})
+	if len(report.Results) != 3 {
+		t.Fatalf("results = %+v", report.Results)
+	}
+	if res := report.Results[0]; res.Weeks != 2 || res.Err != nil {
+		t.Errorf("C001 = %+v", res)
+	}
+	if !strings.Contains(report.Results[1].Skipped, "raw archive") || !strings.Contains(report.Results[2].Skipped, "Google Drive") {
+		t.Errorf("skipped = %q, %q", report.Results[1].Skipped, report.Results[2].Skipped)
+	}
+	if n := len(drive.Documents()); n != 2 {
+		t.Errorf("created %d docs, want 2", n)
+	}
+
+	// Running again rewrites the same docs.
+	WriteRollups(context.Background(), drive, index, convs, RollupOptions{RawDir: rawDir, ConversationIDs: []string{"C001"}})
+	if n := len(drive.Documents()); n != 2 {
+		t.Errorf("after rerun: %d docs, want 2", n)
+	}
+
+	drive.Errors = map[string]error{"ReplaceDocumentContent": errors.New("quota")}
+	report = WriteRollups(context.Background(), drive, index, convs, RollupOptions{RawDir: rawDir, ConversationIDs: []string{"C001"}})
+	if report.Failed() != 1 {
+		t.Errorf("Failed() = %d, want 1", report.Failed())
+	}
+}
+
+func TestWriteRollups_OnlyExportedMessages(t *testing.T) {
+	rawDir := t.TempDir()
+	writeRawFixture(t, rawDir, []RawRecord{
+		{Endpoint: "conversations.history", Params: map[string]string{"channel": "C001"},
+			Response: []byte(`{"ok":true,"messages":[{"ts":"` + rollupMon + `","user":"U001","text":"hi"},{"ts":"` + rollupTue + `","user":"U002","text":"left out"},{"ts":"` + rollupNextMon + `","user":"U001","text":"not exported yet"}]}`)},
+	})
+	drive := testutil.NewFakeDrive()
+	drive.AddFolder("F001", "general")
+	index := NewExportIndex("")
+	// Only Monday has a doc; Tuesday's message was filtered out by export.
+	index.SetConversation(&ConversationExport{ID: "C001", Name: "general", FolderID: "F001", DailyDocs: map[string]*DocExport{
+		"2024-01-01": {DocID: "D1", LastMessageTS: rollupMon},
+	}})
+	convs := []config.ConversationConfig{{ID: "C001", Name: "general"}}
+
+	report := WriteRollups(context.Background(), drive, index, convs, RollupOptions{RawDir: rawDir})
+	if res := report.Results[0]; res.Weeks != 1 || res.Err != nil {
+		t.Fatalf("C001 = %+v, want only the week with exported messages", res)
+	}
+	folder, _ := drive.FindOrCreateFolder(context.Background(), RollupFolderName, "F001")
+	doc, _ := drive.FindOrCreateDocument(context.Background(), RollupDocTitle("2024-01-01"), folder.ID)
+	if content, _ := drive.GetDocumentContent(context.Background(), doc.ID); !strings.Contains(content, "1 message (0 thread replies) from 1 person") {
+		t.Errorf("rollup = %q, want only the exported message counted", content)
+	}
+}
+
+func TestWriteRollups_LegalHold(t *testing.T) {
+	rawDir := t.TempDir()
+	writeRawFixture(t, rawDir, []RawRecord{
+		{Endpoint: "conversations.history", Params: map[string]string{"channel": "C001"},
+			Response: []byte(`{"ok":true,"messages":[{"ts":"` + rollupMon + `","user":"U001","text":"hi"}]}`)},
+	})
+	drive := testutil.NewFakeDrive()
+	drive.AddFolder("F001", "general")
+	index := NewExportIndex("")
+	index.SetConversation(&ConversationExport{ID: "C001", Name: "general", FolderID: "F001", DailyDocs: map[string]*DocExport{
+		"2024-01-01": {DocID: "D1"},
+	}})
+	convs := []config.ConversationConfig{{ID: "C001", Name: "general"}}
+	opts := RollupOptions{RawDir: rawDir, LegalHold: true}
+
+	WriteRollups(context.Background(), drive, index, convs, opts)
+	report := WriteRollups(context.Background(), drive, index, convs, opts)
+	if res := report.Results[0]; res.Weeks != 0 || res.Err != nil {
+		t.Errorf("second run = %+v, want the existing rollup left alone", res)
+	}
+	if n := drive.Calls("ReplaceDocumentContent"); n != 1 {
+		t.Errorf("ReplaceDocumentContent called %d times, want only for the new rollup", n)
+	}
+}
@@ -0,0 +1,221 @@
+package exporter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// DefaultRunLockPath returns the lock file held while a run writes the
+// export index.
+func DefaultRunLockPath(configDir string) string {
+	return filepath.Join(configDir, "_metadata", "export.lock")
+}
+
+// RunLockInfo is the content of the lock file: who holds it and how far
+// the run has got.
+type RunLockInfo struct {
+	PID       int       `json:"pid"`
+	Hostname  string    `json:"hostname"`
+	Command   string    `json:"command"`
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Progress through the run's conversations.
+	Total   int    `json:"total"`
+	Done    int    `json:"done"`
+	Current string `json:"current,omitempty"`
+}
+
+// Stale reports whether the lock was left behind by a process that is no
+// longer running. Only locks taken on this host can be checked; a lock
+// from another host is never considered stale.
+func (i *RunLockInfo) Stale() bool {
+	host, _ := os.Hostname()
+	if i.Hostname != host {
+		return false
+	}
+	return !processAlive(i.PID)
+}
+
+// Percent returns the share of conversations finished, 0-100.
+func (i *RunLockInfo) Percent() int {
+	if i.Total <= 0 {
+		return 0
+	}
+	return i.Done * 100 / i.Total
+}
+
+// Describe summarizes the holder for messages, e.g.
+// "export (PID 1234, started 2024-02-01 15:04, 3/7 conversations, 42%)".
+func (i *RunLockInfo) Describe() string {
+	desc := fmt.Sprintf("%s (PID %d", i.Command, i.PID)
+	if host, _ := os.Hostname(); i.Hostname != host {
+		desc += " on " + i.Hostname
+	}
+	desc += ", started " + i.StartedAt.Local().Format("2006-01-02 15:04")
+	if i.Total > 0 {
+		desc += fmt.Sprintf(", %d/%d conversations, %d%%", i.Done, i.Total, i.Percent())
+	}
+	return desc + ")"
+}
+
+// ErrRunLocked is returned by AcquireRunLock when another run holds the lock.
+var ErrRunLocked = errors.New("another run holds the export lock")
+
+// RunLock is a held lock file. It keeps the file updated with the run's
+// progress so `get-out status` can show it. All methods are safe for
+// concurrent use and treat a nil *RunLock as a no-op.
+type RunLock struct {
+	path string
+	mu   sync.Mutex
+	info RunLockInfo
+}
+
+// AcquireRunLock takes the lock at path for command. A lock left by a
+// process that is no longer running on this host is replaced; any other
+// existing lock fails with an error wrapping ErrRunLocked unless force is
+// set, which breaks it (for a crash on another host, or a reused PID).
+func AcquireRunLock(path, command string, force bool) (*RunLock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+	host, _ := os.Hostname()
+	now := time.Now()
+	l := &RunLock{path: path, info: RunLockInfo{
+		PID:       os.Getpid(),
+		Hostname:  host,
+		Command:   command,
+		StartedAt: now,
+		UpdatedAt: now,
+	}}
+	data, err := json.MarshalIndent(l.info, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode lock: %w", err)
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			if err := writeAndClose(f, data); err != nil {
+				_ = os.Remove(path)
+				return nil, err
+			}
+			return l, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create lock %s: %w", path, err)
+		}
+
+		holder, err := ReadRunLock(path)
+		if err == nil && holder != nil && !force && !holder.Stale() {
+			return nil, fmt.Errorf("%w: %s; if it crashed, re-run with --force", ErrRunLocked, holder.Describe())
+		}
+		// Stale, unreadable, or forced: break it and try again.
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove stale lock: %w", err)
+		}
+	}
+	return nil, fmt.Errorf("%w: lock %s was taken while replacing a stale one", ErrRunLocked, path)
+}
+
+// ReadRunLock returns the lock at path, or nil when there is none.
+func ReadRunLock(path string) (*RunLockInfo, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lock: %w", err)
+	}
+	var info RunLockInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse lock %s: %w", path, err)
+	}
+	return &info, nil
+}
+
+// Start records how many conversations the run will process.
+func (l *RunLock) Start(total int) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.info.Total = total
+	l.info.Done = 0
+	l.saveLocked()
+}
+
+// Begin records the conversation now being processed.
+func (l *RunLock) Begin(name string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.info.Current = name
+	l.saveLocked()
+}
+
+// Finish records that one more conversation is done.
+func (l *RunLock) Finish() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.info.Done++
+	l.saveLocked()
+}
+
+// saveLocked rewrites the lock file with the current progress. Progress is
+// informational, so write errors are ignored. Caller must hold l.mu.
+func (l *RunLock) saveLocked() {
+	l.info.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(l.info, "", "  ")
+	if err != nil {
+		return
+	}
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return
+	}
+	_ = os.Rename(tmp, l.path)
+}
+
+// Release removes the lock file if it is still this run's (it may have
+// been broken with --force by another run).
+func (l *RunLock) Release() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	holder, err := ReadRunLock(l.path)
+	if err != nil || holder == nil {
+		return err
+	}
+	if holder.PID != l.info.PID || !holder.StartedAt.Equal(l.info.StartedAt) {
+		return nil
+	}
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to release lock: %w", err)
+	}
+	return nil
+}
+
+// processAlive reports whether a process with pid exists. EPERM means it
+// exists but belongs to another user.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
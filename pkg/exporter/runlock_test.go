@@ -0,0 +1,135 @@
+package exporter
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeLockFile writes a lock held by pid on this host.
+func writeLockFile(t *testing.T, path string, pid int) {
+	t.Helper()
+	host, _ := os.Hostname()
+	data, err := json.Marshal(RunLockInfo{PID: pid, Hostname: host, Command: "export", StartedAt: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// exitedPID returns the PID of a process that has already exited.
+func exitedPID(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Skipf("cannot run true: %v", err)
+	}
+	return cmd.Process.Pid
+}
+
+func TestAcquireRunLock_ProgressAndRelease(t *testing.T) {
+	path := DefaultRunLockPath(t.TempDir())
+
+	lock, err := AcquireRunLock(path, "export", false)
+	if err != nil {
+		t.Fatalf("AcquireRunLock: %v", err)
+	}
+	lock.Start(4)
+	lock.Finish()
+	lock.Begin("general")
+
+	info, err := ReadRunLock(path)
+	if err != nil || info == nil {
+		t.Fatalf("ReadRunLock = %v, %v", info, err)
+	}
+	if info.PID != os.Getpid() || info.Command != "export" {
+		t.Errorf("holder = PID %d %q, want PID %d export", info.PID, info.Command, os.Getpid())
+	}
+	if info.Done != 1 || info.Total != 4 || info.Percent() != 25 || info.Current != "general" {
+		t.Errorf("progress = %d/%d (%d%%) current %q, want 1/4 (25%%) general", info.Done, info.Total, info.Percent(), info.Current)
+	}
+	if info.Stale() {
+		t.Error("lock held by this process should not be stale")
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if info, _ := ReadRunLock(path); info != nil {
+		t.Errorf("lock still present after Release: %+v", info)
+	}
+}
+
+func TestAcquireRunLock_HeldByLiveProcess(t *testing.T) {
+	path := DefaultRunLockPath(t.TempDir())
+	writeLockFile(t, path, os.Getpid())
+
+	_, err := AcquireRunLock(path, "export", false)
+	if !errors.Is(err, ErrRunLocked) {
+		t.Fatalf("err = %v, want ErrRunLocked", err)
+	}
+
+	lock, err := AcquireRunLock(path, "export", true)
+	if err != nil {
+		t.Fatalf("AcquireRunLock with force: %v", err)
+	}
+	defer lock.Release()
+}
+
+func TestAcquireRunLock_RecoversStaleLock(t *testing.T) {
+	path := DefaultRunLockPath(t.TempDir())
+	writeLockFile(t, path, exitedPID(t))
+
+	info, err := ReadRunLock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Stale() {
+		t.Fatal("lock of an exited process should be stale")
+	}
+
+	lock, err := AcquireRunLock(path, "export", false)
+	if err != nil {
+		t.Fatalf("AcquireRunLock over stale lock: %v", err)
+	}
+	defer lock.Release()
+}
+
+func TestRunLock_ReleaseKeepsOtherHolder(t *testing.T) {
+	path := DefaultRunLockPath(t.TempDir())
+	lock, err := AcquireRunLock(path, "export", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Another run breaks the lock with --force.
+	other, err := AcquireRunLock(path, "reprocess", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if info, _ := ReadRunLock(path); info == nil || info.Command != "reprocess" {
+		t.Errorf("forced lock removed by the previous holder: %+v", info)
+	}
+	_ = other.Release()
+}
+
+func TestRunLock_NilIsNoop(t *testing.T) {
+	var lock *RunLock
+	lock.Start(3)
+	lock.Begin("general")
+	lock.Finish()
+	if err := lock.Release(); err != nil {
+		t.Errorf("nil Release = %v", err)
+	}
+}
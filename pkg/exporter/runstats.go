@@ -0,0 +1,155 @@
+package exporter
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxRecentErrors is how many errors RunStats keeps.
+const maxRecentErrors = 10
+
+// RunStats collects live statistics about an export run for a status
+// endpoint. All methods are safe for concurrent use and treat a nil
+// *RunStats as a no-op.
+type RunStats struct {
+	mu       sync.Mutex
+	started  time.Time
+	finished time.Time
+	total    int
+	done     int
+	current  map[string]time.Time // conversation name → start time
+	messages int
+	errors   []RunError
+	now      func() time.Time
+}
+
+// RunError is an error reported during a run.
+type RunError struct {
+	Time         time.Time `json:"time"`
+	Conversation string    `json:"conversation,omitempty"`
+	Error        string    `json:"error"`
+}
+
+// RunStatsSnapshot is a point-in-time copy of RunStats.
+type RunStatsSnapshot struct {
+	State          string     `json:"state"` // idle, running, finished
+	StartedAt      time.Time  `json:"started_at,omitzero"`
+	FinishedAt     time.Time  `json:"finished_at,omitzero"`
+	Conversations  int        `json:"conversations"`
+	Done           int        `json:"done"`
+	Current        []string   `json:"current,omitempty"`
+	Messages       int        `json:"messages"`
+	MessagesPerSec float64    `json:"messages_per_sec"`
+	RecentErrors   []RunError `json:"recent_errors,omitempty"`
+}
+
+// NewRunStats creates an idle RunStats.
+func NewRunStats() *RunStats {
+	return &RunStats{current: make(map[string]time.Time), now: time.Now}
+}
+
+// Start resets the statistics for a run over total conversations.
+func (s *RunStats) Start(total int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.started = s.now()
+	s.finished = time.Time{}
+	s.total = total
+	s.done = 0
+	s.current = make(map[string]time.Time)
+	s.messages = 0
+	s.errors = nil
+}
+
+// Begin records that a conversation is being exported.
+func (s *RunStats) Begin(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current[name] = s.now()
+}
+
+// Finish records that a conversation is done (exported or skipped).
+func (s *RunStats) Finish(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.current, name)
+	s.done++
+}
+
+// AddMessages counts messages written.
+func (s *RunStats) AddMessages(n int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages += n
+}
+
+// Error records an error, keeping only the most recent ones.
+func (s *RunStats) Error(conversation string, err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors = append(s.errors, RunError{Time: s.now(), Conversation: conversation, Error: err.Error()})
+	if len(s.errors) > maxRecentErrors {
+		s.errors = s.errors[len(s.errors)-maxRecentErrors:]
+	}
+}
+
+// End marks the run finished.
+func (s *RunStats) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.finished = s.now()
+}
+
+// Snapshot returns the current statistics.
+func (s *RunStats) Snapshot() RunStatsSnapshot {
+	if s == nil {
+		return RunStatsSnapshot{State: "idle"}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap := RunStatsSnapshot{
+		State:         "idle",
+		StartedAt:     s.started,
+		FinishedAt:    s.finished,
+		Conversations: s.total,
+		Done:          s.done,
+		Messages:      s.messages,
+		RecentErrors:  append([]RunError(nil), s.errors...),
+	}
+	if s.started.IsZero() {
+		return snap
+	}
+	end := s.finished
+	snap.State = "finished"
+	if end.IsZero() {
+		end = s.now()
+		snap.State = "running"
+	}
+	if secs := end.Sub(s.started).Seconds(); secs > 0 {
+		snap.MessagesPerSec = float64(s.messages) / secs
+	}
+	for name := range s.current {
+		snap.Current = append(snap.Current, name)
+	}
+	sort.Strings(snap.Current)
+	return snap
+}
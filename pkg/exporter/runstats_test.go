@@ -0,0 +1,81 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRunStats_Snapshot(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := NewRunStats()
+	s.now = func() time.Time { return now }
+
+	if got := s.Snapshot().State; got != "idle" {
+		t.Errorf("State before Start = %q, want idle", got)
+	}
+
+	s.Start(3)
+	s.Begin("general")
+	s.Begin("random")
+	s.AddMessages(40)
+	s.Finish("general")
+	s.Error("random", errors.New("boom"))
+	now = now.Add(10 * time.Second)
+
+	snap := s.Snapshot()
+	if snap.State != "running" || snap.Done != 1 || snap.Conversations != 3 || snap.Messages != 40 {
+		t.Errorf("snapshot = %+v", snap)
+	}
+	if snap.MessagesPerSec != 4 {
+		t.Errorf("MessagesPerSec = %v, want 4", snap.MessagesPerSec)
+	}
+	if len(snap.Current) != 1 || snap.Current[0] != "random" {
+		t.Errorf("Current = %v, want [random]", snap.Current)
+	}
+	if len(snap.RecentErrors) != 1 || snap.RecentErrors[0].Conversation != "random" {
+		t.Errorf("RecentErrors = %+v", snap.RecentErrors)
+	}
+
+	s.End()
+	now = now.Add(time.Minute)
+	if snap := s.Snapshot(); snap.State != "finished" || snap.MessagesPerSec != 4 {
+		t.Errorf("after End: State = %q, MessagesPerSec = %v; want finished, 4", snap.State, snap.MessagesPerSec)
+	}
+
+	s.Start(1)
+	if snap := s.Snapshot(); snap.Messages != 0 || snap.Done != 0 || len(snap.RecentErrors) != 0 {
+		t.Errorf("Start did not reset: %+v", snap)
+	}
+}
+
+func TestRunStats_KeepsRecentErrors(t *testing.T) {
+	s := NewRunStats()
+	s.Start(1)
+	for i := 0; i < maxRecentErrors+5; i++ {
+		s.Error("general", fmt.Errorf("error %d", i))
+	}
+	s.Error("general", nil)
+
+	errs := s.Snapshot().RecentErrors
+	if len(errs) != maxRecentErrors {
+		t.Fatalf("kept %d errors, want %d", len(errs), maxRecentErrors)
+	}
+	if errs[len(errs)-1].Error != fmt.Sprintf("error %d", maxRecentErrors+4) {
+		t.Errorf("last error = %q", errs[len(errs)-1].Error)
+	}
+}
+
+func TestRunStats_NilIsNoop(t *testing.T) {
+	var s *RunStats
+	s.Start(1)
+	s.Begin("general")
+	s.AddMessages(3)
+	s.Error("general", errors.New("boom"))
+	s.Finish("general")
+	s.End()
+	if got := s.Snapshot().State; got != "idle" {
+		t.Errorf("nil Snapshot State = %q, want idle", got)
+	}
+}
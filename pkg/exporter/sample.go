@@ -0,0 +1,46 @@
+package exporter
+
+import (
+	"errors"
+	"path/filepath"
+
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// SampleDirName is the subdirectory of the local export directory that
+// --sample runs write markdown into.
+const SampleDirName = "_sample"
+
+// errSampleFull stops message pagination once a sample has enough messages.
+var errSampleFull = errors.New("sample complete")
+
+// DefaultSampleIndexPath returns the index used by --sample runs. It is kept
+// apart from DefaultIndexPath so a sample never marks history as exported.
+func DefaultSampleIndexPath(configDir string) string {
+	return filepath.Join(configDir, "_metadata", "export-index.sample.json")
+}
+
+// SampleFolderName returns the Drive root folder name for --sample runs.
+func SampleFolderName(rootFolderName string) string {
+	if rootFolderName == "" {
+		rootFolderName = DefaultRootFolderName
+	}
+	return rootFolderName + " (sample)"
+}
+
+// newestMain trims messages, newest first as Slack returns them, to the n
+// newest top-level messages. Thread replies that appear in the history
+// (broadcasts) are kept when they are newer than the last message kept.
+// It reports whether anything was dropped.
+func newestMain(messages []slackapi.Message, n int) ([]slackapi.Message, bool) {
+	count := 0
+	for i, msg := range messages {
+		if msg.ThreadTS == "" || msg.TS == msg.ThreadTS {
+			count++
+			if count == n {
+				return messages[:i+1], i+1 < len(messages)
+			}
+		}
+	}
+	return messages, false
+}
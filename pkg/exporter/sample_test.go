@@ -0,0 +1,86 @@
+package exporter
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+func TestNewestMain(t *testing.T) {
+	messages := []slackapi.Message{
+		{TS: "5"},
+		{TS: "4", ThreadTS: "2"}, // broadcast reply
+		{TS: "3"},
+		{TS: "2", ThreadTS: "2"},
+		{TS: "1"},
+	}
+
+	got, trimmed := newestMain(messages, 2)
+	if len(got) != 3 || got[2].TS != "3" || !trimmed {
+		t.Errorf("newestMain(2) = %v, trimmed %v; want TS 5,4,3 and trimmed", got, trimmed)
+	}
+
+	got, trimmed = newestMain(messages, 10)
+	if len(got) != len(messages) || trimmed {
+		t.Errorf("newestMain(10) kept %d, trimmed %v; want all, not trimmed", len(got), trimmed)
+	}
+}
+
+func TestSampleFolderName(t *testing.T) {
+	if got := SampleFolderName("Team Archive"); got != "Team Archive (sample)" {
+		t.Errorf("SampleFolderName() = %q", got)
+	}
+	if got := SampleFolderName(""); got != "Slack Exports (sample)" {
+		t.Errorf("SampleFolderName(\"\") = %q", got)
+	}
+}
+
+func TestNewExporter_SampleIsolatesOutput(t *testing.T) {
+	exp := NewExporter(&ExporterConfig{
+		RootFolderName: "Slack Exports",
+		RootFolderID:   "folder123",
+		LocalExportDir: "/tmp/out",
+		SampleSize:     5,
+	})
+	if exp.rootFolderName != "Slack Exports (sample)" || exp.rootFolderID != "" {
+		t.Errorf("root folder = %q/%q, want a separate sample folder", exp.rootFolderName, exp.rootFolderID)
+	}
+	if exp.localExportDir != "/tmp/out/"+SampleDirName {
+		t.Errorf("localExportDir = %q", exp.localExportDir)
+	}
+}
+
+func TestExportConversation_Sample(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	indexPath := t.TempDir() + "/export-index.sample.json"
+	exp := fakeExporter(t, drive, slack, indexPath)
+	exp.sampleSize = 2
+
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	if result.MessageCount != 2 {
+		t.Errorf("MessageCount = %d, want 2", result.MessageCount)
+	}
+	if result.ThreadsExported != 1 {
+		t.Errorf("ThreadsExported = %d, want 1 (the sampled thread)", result.ThreadsExported)
+	}
+	ce := exp.index.GetConversation("C001")
+	if got := appendedTexts(drive, ce.DailyDocs["2024-02-01"].DocID); len(got) != 1 || !strings.HasPrefix(got[0], "Thread starter") {
+		t.Errorf("2024-02-01 doc content = %q, want only the sampled thread starter", got)
+	}
+
+	// Running the sample again adds nothing that is already there.
+	exp = fakeExporter(t, drive, slack, indexPath)
+	exp.sampleSize = 2
+	result, err = exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("second sample: %v", err)
+	}
+	if result.MessageCount != 0 {
+		t.Errorf("second sample MessageCount = %d, want 0", result.MessageCount)
+	}
+}
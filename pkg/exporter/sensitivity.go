@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/jflowers/get-out/pkg/ollama"
+	"github.com/jflowers/get-out/pkg/parser"
 	"github.com/jflowers/get-out/pkg/slackapi"
 )
 
@@ -75,7 +76,7 @@ func (f *OllamaFilter) FilterMessages(ctx context.Context, messages []slackapi.M
 	// Extract text from each message for classification.
 	texts := make([]string, len(messages))
 	for i, msg := range messages {
-		texts[i] = msg.Text
+		texts[i] = parser.MessageText(msg)
 	}
 
 	classifications, err := f.guardian.Classify(ctx, texts)
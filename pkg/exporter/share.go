@@ -0,0 +1,255 @@
+package exporter
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/gdrive"
+)
+
+// SharingDrive is the part of the Drive client that reads and changes who
+// exported folders are shared with. It is satisfied by *gdrive.Client.
+type SharingDrive interface {
+	ListPermissions(ctx context.Context, fileID string) ([]gdrive.Permission, error)
+	ShareFolder(ctx context.Context, folderID, email string, notify bool) error
+	DeletePermission(ctx context.Context, fileID, permissionID string) error
+}
+
+var _ SharingDrive = (*gdrive.Client)(nil)
+
+// ShareAction is what ReconcileSharing does, or would do, about one
+// person's access to a conversation folder.
+type ShareAction string
+
+const (
+	// ShareGrant shares the folder with a member who lacks access.
+	ShareGrant ShareAction = "grant"
+	// ShareRevoke removes access of a person who is not a member.
+	ShareRevoke ShareAction = "revoke"
+	// ShareDrift is access get-out does not manage, such as a link shared
+	// with anyone or a group, or a member left unshared by a revoke-only
+	// run. It is reported and left as it is.
+	ShareDrift ShareAction = "drift"
+)
+
+// ShareOptions configures ReconcileSharing.
+type ShareOptions struct {
+	// RevokeOnly removes access but shares with no one, so members missing
+	// access are reported as drift rather than invited.
+	RevokeOnly bool
+	// DryRun reports the changes without making them.
+	DryRun bool
+	// ConversationIDs limits the run to these conversations; empty means
+	// every conversation with a Drive folder.
+	ConversationIDs []string
+}
+
+// ShareChange is one difference between a folder's sharing and the config.
+type ShareChange struct {
+	ConversationID   string
+	ConversationName string
+	FolderURL        string
+	Action           ShareAction
+	// Who is the email address, or a description such as "anyone with the
+	// link", of the access changed.
+	Who  string
+	Role string
+	// Done reports the change was made; it is false in a dry run, for
+	// drift, and when Err is set.
+	Done bool
+	Err  error
+}
+
+// ShareReport is the result of ReconcileSharing.
+type ShareReport struct {
+	// Folders is the number of conversation folders checked.
+	Folders int
+	// Skipped lists conversations in the export index that are not in
+	// conversations.json; their folders are left alone.
+	Skipped []string
+	Changes []ShareChange
+}
+
+// Failed returns the number of changes that could not be made.
+func (r *ShareReport) Failed() int {
+	n := 0
+	for _, c := range r.Changes {
+		if c.Err != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// ReconcileSharing compares who each exported conversation folder is
+// shared with against the config, and shares or unshares folders to match.
+//
+// A conversation with "share": true should be shared with its
+// shareMembers, each as their googleEmail from people.json when they have
+// one; members marked noShare in people.json are not. Any other person
+// with access is revoked, so removing someone from shareMembers or people
+// marking them noShare takes their access away. A conversation without
+// "share" should be shared with no one.
+//
+// Owners, access inherited from a shared drive, and people the root
+// export folder is shared with are never revoked. Access that is not a
+// person's, such as a link shared with anyone, is reported as drift.
+func ReconcileSharing(ctx context.Context, drive SharingDrive, index *ExportIndex, convs []config.ConversationConfig, people *config.PeopleConfig, opts ShareOptions) (*ShareReport, error) {
+	byID := make(map[string]config.ConversationConfig, len(convs))
+	for _, c := range convs {
+		byID[c.ID] = c
+	}
+	emails := peopleByEmail(people)
+
+	rootAccess := make(map[string]bool)
+	if index.RootFolderID != "" {
+		perms, err := drive.ListPermissions(ctx, index.RootFolderID)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range perms {
+			if p.EmailAddress != "" {
+				rootAccess[strings.ToLower(p.EmailAddress)] = true
+			}
+		}
+	}
+
+	selected := make(map[string]bool, len(opts.ConversationIDs))
+	for _, id := range opts.ConversationIDs {
+		selected[id] = true
+	}
+
+	report := &ShareReport{}
+	for _, exp := range index.AllConversations() {
+		if exp.FolderID == "" || (len(selected) > 0 && !selected[exp.ID]) {
+			continue
+		}
+		conv, ok := byID[exp.ID]
+		if !ok {
+			report.Skipped = append(report.Skipped, exp.ID)
+			continue
+		}
+		perms, err := drive.ListPermissions(ctx, exp.FolderID)
+		if err != nil {
+			return report, err
+		}
+		report.Folders++
+
+		change := func(action ShareAction, who, role string) *ShareChange {
+			report.Changes = append(report.Changes, ShareChange{
+				ConversationID:   exp.ID,
+				ConversationName: exp.Name,
+				FolderURL:        exp.FolderURL,
+				Action:           action,
+				Who:              who,
+				Role:             role,
+			})
+			return &report.Changes[len(report.Changes)-1]
+		}
+
+		members := shareMembers(conv, emails)
+		shared := make(map[string]bool)
+		for _, p := range perms {
+			email := strings.ToLower(p.EmailAddress)
+			switch {
+			case p.Role == "owner" || p.Role == "organizer" || p.Inherited:
+				continue
+			case p.Type != "user":
+				change(ShareDrift, permissionHolder(p), p.Role)
+				continue
+			}
+			shared[email] = true
+			if _, ok := members[email]; ok || rootAccess[email] {
+				continue
+			}
+			c := change(ShareRevoke, p.EmailAddress, p.Role)
+			if !opts.DryRun {
+				c.Err = drive.DeletePermission(ctx, exp.FolderID, p.ID)
+				c.Done = c.Err == nil
+			}
+		}
+
+		for _, email := range sortedKeys(members) {
+			if shared[email] || rootAccess[email] {
+				continue
+			}
+			if opts.RevokeOnly {
+				change(ShareDrift, email, "reader")
+				continue
+			}
+			c := change(ShareGrant, email, "reader")
+			if !opts.DryRun {
+				c.Err = drive.ShareFolder(ctx, exp.FolderID, email, members[email])
+				c.Done = c.Err == nil
+			}
+		}
+	}
+	sort.Strings(report.Skipped)
+	return report, nil
+}
+
+// peopleByEmail maps each person's email and googleEmail, lowercased, to
+// the person.
+func peopleByEmail(people *config.PeopleConfig) map[string]*config.PersonConfig {
+	byEmail := make(map[string]*config.PersonConfig)
+	if people == nil {
+		return byEmail
+	}
+	for email, p := range people.BuildEmailMap() {
+		byEmail[strings.ToLower(email)] = p
+	}
+	return byEmail
+}
+
+// shareMembers returns the lowercased addresses conv should be shared with,
+// each mapped to whether its invitation notifies them.
+func shareMembers(conv config.ConversationConfig, people map[string]*config.PersonConfig) map[string]bool {
+	members := make(map[string]bool)
+	if !conv.Share {
+		return members
+	}
+	for _, email := range conv.ShareMembers {
+		email = strings.ToLower(strings.TrimSpace(email))
+		if email == "" {
+			continue
+		}
+		notify := true
+		if p := people[email]; p != nil {
+			if p.NoShare {
+				continue
+			}
+			if p.GoogleEmail != "" {
+				email = strings.ToLower(p.GoogleEmail)
+			}
+			notify = !p.NoNotifications
+		}
+		members[email] = notify
+	}
+	return members
+}
+
+// permissionHolder describes who a permission that is not a person's
+// shares with.
+func permissionHolder(p gdrive.Permission) string {
+	switch p.Type {
+	case "anyone":
+		return "anyone with the link"
+	case "domain":
+		return "domain " + orDefault(p.Domain, "(unknown)")
+	case "group":
+		return "group " + orDefault(p.EmailAddress, "(unknown)")
+	}
+	return orDefault(p.EmailAddress, p.Type)
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
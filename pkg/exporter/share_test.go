@@ -0,0 +1,158 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/jflowers/get-out/internal/testutil"
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/gdrive"
+)
+
+// shareFixture returns an index with two exported channels and a drive
+// with their folders' sharing:
+//
+//	root:     team@example.com
+//	C001:     owner, alice (member), bob (removed), team (from root), anyone with the link
+//	C002:     owner, carol (share is off)
+func shareFixture(t *testing.T) (*testutil.FakeDrive, *ExportIndex) {
+	t.Helper()
+	index := NewExportIndex(filepath.Join(t.TempDir(), "export-index.json"))
+	index.RootFolderID = "root"
+	for _, c := range []struct{ id, name string }{{"C001", "general"}, {"C002", "random"}} {
+		conv := index.GetOrCreateConversation(c.id, c.name, "channel")
+		conv.FolderID = "folder-" + c.id
+	}
+	index.GetOrCreateConversation("C003", "local-only", "channel")
+
+	drive := testutil.NewFakeDrive()
+	owner := gdrive.Permission{ID: "p-owner", Type: "user", Role: "owner", EmailAddress: "me@example.com"}
+	drive.Permissions["root"] = []gdrive.Permission{owner, {ID: "p-team", Type: "user", Role: "reader", EmailAddress: "team@example.com"}}
+	drive.Permissions["folder-C001"] = []gdrive.Permission{
+		owner,
+		{ID: "p-alice", Type: "user", Role: "reader", EmailAddress: "Alice@Example.com"},
+		{ID: "p-bob", Type: "user", Role: "writer", EmailAddress: "bob@example.com"},
+		{ID: "p-team", Type: "user", Role: "reader", EmailAddress: "team@example.com"},
+		{ID: "p-anyone", Type: "anyone", Role: "reader"},
+	}
+	drive.Permissions["folder-C002"] = []gdrive.Permission{owner, {ID: "p-carol", Type: "user", Role: "reader", EmailAddress: "carol@example.com"}}
+	return drive, index
+}
+
+var shareConvs = []config.ConversationConfig{
+	{ID: "C001", Name: "general", Share: true, ShareMembers: []string{"alice@example.com", "dave@corp.example.com", "erin@example.com", "frank@example.com"}},
+	{ID: "C002", Name: "random"},
+}
+
+var sharePeople = &config.PeopleConfig{People: []config.PersonConfig{
+	{SlackID: "U1", Email: "dave@corp.example.com", GoogleEmail: "dave@example.com", NoNotifications: true},
+	{SlackID: "U2", Email: "erin@example.com", NoShare: true},
+}}
+
+func TestReconcileSharing(t *testing.T) {
+	drive, index := shareFixture(t)
+	report, err := ReconcileSharing(context.Background(), drive, index, shareConvs, sharePeople, ShareOptions{})
+	if err != nil {
+		t.Fatalf("ReconcileSharing() error: %v", err)
+	}
+
+	type change struct {
+		conv   string
+		action ShareAction
+		who    string
+	}
+	var got []change
+	for _, c := range report.Changes {
+		if c.Err != nil {
+			t.Errorf("change %+v failed", c)
+		}
+		if c.Done != (c.Action != ShareDrift) {
+			t.Errorf("change %+v: Done = %v", c, c.Done)
+		}
+		got = append(got, change{c.ConversationID, c.Action, c.Who})
+	}
+	want := []change{
+		{"C001", ShareRevoke, "bob@example.com"},
+		{"C001", ShareDrift, "anyone with the link"},
+		{"C001", ShareGrant, "dave@example.com"},
+		{"C001", ShareGrant, "frank@example.com"},
+		{"C002", ShareRevoke, "carol@example.com"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("changes = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("change %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if report.Folders != 2 || len(report.Skipped) != 0 {
+		t.Errorf("Folders = %d, Skipped = %v", report.Folders, report.Skipped)
+	}
+
+	// The folders now match, so a second run changes nothing but the drift.
+	report, err = ReconcileSharing(context.Background(), drive, index, shareConvs, sharePeople, ShareOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Changes) != 1 || report.Changes[0].Action != ShareDrift {
+		t.Errorf("second run changes = %+v, want only the drift", report.Changes)
+	}
+}
+
+func TestReconcileSharing_RevokeOnlyAndDryRun(t *testing.T) {
+	drive, index := shareFixture(t)
+	report, err := ReconcileSharing(context.Background(), drive, index, shareConvs, sharePeople, ShareOptions{RevokeOnly: true, DryRun: true})
+	if err != nil {
+		t.Fatalf("ReconcileSharing() error: %v", err)
+	}
+	grants, revokes := 0, 0
+	for _, c := range report.Changes {
+		if c.Done {
+			t.Errorf("dry run made change %+v", c)
+		}
+		switch c.Action {
+		case ShareGrant:
+			grants++
+		case ShareRevoke:
+			revokes++
+		}
+	}
+	if grants != 0 || revokes != 2 || len(report.Changes) != 5 {
+		t.Errorf("changes = %+v, want 2 revocations and the rest drift", report.Changes)
+	}
+	if drive.Calls("ShareFolder")+drive.Calls("DeletePermission") != 0 {
+		t.Error("dry run changed Drive")
+	}
+
+	// Revoke-only, for real, and limited to one conversation.
+	report, err = ReconcileSharing(context.Background(), drive, index, shareConvs, sharePeople, ShareOptions{RevokeOnly: true, ConversationIDs: []string{"C002"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Folders != 1 || len(report.Changes) != 1 || !report.Changes[0].Done || drive.Calls("ShareFolder") != 0 {
+		t.Errorf("changes = %+v, want carol revoked", report.Changes)
+	}
+	if perms := drive.Permissions["folder-C002"]; len(perms) != 1 || perms[0].Role != "owner" {
+		t.Errorf("C002 permissions = %+v, want only the owner", perms)
+	}
+}
+
+func TestReconcileSharing_Errors(t *testing.T) {
+	drive, index := shareFixture(t)
+	drive.Errors["DeletePermission"] = errors.New("insufficientFilePermissions")
+	report, err := ReconcileSharing(context.Background(), drive, index, shareConvs[:1], sharePeople, ShareOptions{})
+	if err != nil {
+		t.Fatalf("ReconcileSharing() error: %v", err)
+	}
+	if report.Failed() != 1 || len(report.Skipped) != 1 || report.Skipped[0] != "C002" {
+		t.Errorf("Failed() = %d, Skipped = %v, want bob's revocation failed and C002 skipped", report.Failed(), report.Skipped)
+	}
+
+	drive.Errors["ListPermissions"] = errors.New("notFound")
+	if _, err := ReconcileSharing(context.Background(), drive, index, shareConvs, sharePeople, ShareOptions{}); err == nil {
+		t.Error("ReconcileSharing() succeeded without the root folder's permissions")
+	}
+}
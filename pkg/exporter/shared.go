@@ -0,0 +1,76 @@
+package exporter
+
+import (
+	"github.com/jflowers/get-out/pkg/config"
+)
+
+// peerExport is the export index of get-out configured for another Slack
+// workspace (settings.json peerConfigDirs), read to avoid exporting the
+// Slack Connect channels both workspaces share twice.
+type peerExport struct {
+	configDir string
+	index     *ExportIndex
+}
+
+// loadPeerExports reads the export index of each peer config directory. A
+// peer whose index cannot be read is reported and ignored, so its shared
+// channels are exported here as well.
+func (e *Exporter) loadPeerExports() {
+	for _, dir := range e.peerConfigDirs {
+		path, err := ExpandAndValidatePath(dir)
+		if err == nil {
+			var index *ExportIndex
+			if index, err = LoadExportIndex(DefaultIndexPath(path)); err == nil {
+				e.peers = append(e.peers, peerExport{configDir: path, index: index})
+				continue
+			}
+		}
+		e.Progress("Warning: ignoring peer config dir %s: %v", dir, err)
+	}
+	if len(e.peers) > 0 {
+		e.Detail("Checking %d peer workspace exports for shared channels", len(e.peers))
+	}
+}
+
+// exportedByPeer returns the peer export that already holds conv's history,
+// and its record of conv, or nil when conv should be exported here. Slack
+// gives a shared channel the same ID in every workspace it is shared with,
+// so that ID, or one of conv's aliases, is its canonical ID across exports.
+// The first workspace to export a shared channel keeps it: once this
+// export has written any of conv, peers are no longer consulted.
+func (e *Exporter) exportedByPeer(conv config.ConversationConfig) (*peerExport, *ConversationExport) {
+	if len(e.peers) == 0 {
+		return nil, nil
+	}
+	if own := e.index.ResolveConversation(conv.ID); own != nil && own.LastMessageTS != "" {
+		return nil, nil
+	}
+	ids := append([]string{conv.ID}, conv.Aliases...)
+	for i := range e.peers {
+		for _, id := range ids {
+			if theirs := e.peers[i].index.ResolveConversation(id); theirs != nil && theirs.LastMessageTS != "" {
+				return &e.peers[i], theirs
+			}
+		}
+	}
+	return nil, nil
+}
+
+// markShared records conv as exported by peer: its status becomes
+// StatusShared and its folder URL the peer's, so links to the channel from
+// this workspace's docs point at the one copy.
+func (e *Exporter) markShared(conv config.ConversationConfig, peer *peerExport, theirs *ConversationExport) {
+	own := e.index.GetOrCreateConversation(conv.ID, conv.Name, string(conv.Type))
+	theirs.mu.Lock()
+	folderURL := theirs.FolderURL
+	theirs.mu.Unlock()
+
+	own.mu.Lock()
+	own.Status = StatusShared
+	own.SharedWith = peer.configDir
+	own.FolderURL = folderURL
+	own.mu.Unlock()
+	if err := e.index.SaveConversation(conv.ID); err != nil {
+		e.Progress("Warning: failed to save index: %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package exporter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// peerIndex writes an export index under a new config dir recording that
+// the peer exported convID, and returns the dir.
+func peerIndex(t *testing.T, convID string) string {
+	t.Helper()
+	dir := t.TempDir()
+	index := NewExportIndex(DefaultIndexPath(dir))
+	conv := index.GetOrCreateConversation(convID, "partner-general", "channel")
+	conv.Status = StatusComplete
+	conv.FolderURL = "https://drive.google.com/drive/folders/peer"
+	conv.LastMessageTS = "1706875200.000300"
+	if err := index.Save(); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestExportConversation_SharedWithPeer(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	peerDir := peerIndex(t, conv.ID)
+	exp.peerConfigDirs = []string{peerDir}
+	exp.loadPeerExports()
+
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	if !result.Skipped || result.SharedWith != peerDir {
+		t.Errorf("result = %+v, want skipped for %s", result, peerDir)
+	}
+	if n := slack.Calls("GetConversationHistory"); n != 0 {
+		t.Errorf("GetConversationHistory calls = %d, want 0", n)
+	}
+	if n := len(drive.Documents()); n != 0 {
+		t.Errorf("documents = %d, want 0", n)
+	}
+	own := exp.index.GetConversation(conv.ID)
+	if own.Status != StatusShared || own.SharedWith != peerDir {
+		t.Errorf("index status = %q shared with %q, want shared with %s", own.Status, own.SharedWith, peerDir)
+	}
+	if url := exp.index.LookupConversationURL(conv.ID); url != "https://drive.google.com/drive/folders/peer" {
+		t.Errorf("LookupConversationURL() = %q, want the peer's folder", url)
+	}
+}
+
+func TestExportConversation_SharedAlreadyExportedHere(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatalf("first export: %v", err)
+	}
+
+	// A peer exporting the channel later does not take it over.
+	exp.peerConfigDirs = []string{peerIndex(t, conv.ID)}
+	exp.loadPeerExports()
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	if result.Skipped {
+		t.Error("skipped a channel this export already holds")
+	}
+	if got := exp.index.GetConversation(conv.ID).Status; got != StatusComplete {
+		t.Errorf("status = %q, want %q", got, StatusComplete)
+	}
+}
+
+func TestExportConversation_SharedByAlias(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	conv.Aliases = []string{"C999"}
+	broken := t.TempDir()
+	if err := os.MkdirAll(filepath.Dir(DefaultIndexPath(broken)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(DefaultIndexPath(broken), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	exp.peerConfigDirs = []string{peerIndex(t, "C999"), broken}
+	exp.loadPeerExports()
+	if len(exp.peers) != 1 {
+		t.Fatalf("peers = %d, want the unreadable dir ignored", len(exp.peers))
+	}
+
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	if !result.Skipped {
+		t.Error("not skipped, want the alias matched in the peer index")
+	}
+}
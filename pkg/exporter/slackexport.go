@@ -0,0 +1,123 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/models"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+	"github.com/jflowers/get-out/pkg/slackjson"
+)
+
+// File names used by Slack's workspace export, written at the top of the
+// local export directory so viewers built for Slack exports can read them.
+const (
+	SlackUsersFile    = slackjson.UsersFile
+	SlackChannelsFile = slackjson.ChannelsFile
+)
+
+// SlackExportChannel is one entry of channels.json in Slack's export schema.
+type SlackExportChannel = slackjson.Channel
+
+// SlackExportChannels returns the channels.json entries for the channel
+// conversations in convs (DMs and group DMs are not listed in Slack's
+// channels.json). Names come from the channel resolver when it knows the
+// channel, otherwise from conversations.json.
+func SlackExportChannels(convs []config.ConversationConfig, channels *parser.ChannelResolver) []SlackExportChannel {
+	result := make([]SlackExportChannel, 0, len(convs))
+	for _, c := range convs {
+		if c.Type != models.ConversationTypeChannel && c.Type != models.ConversationTypePrivateChannel {
+			continue
+		}
+		result = append(result, SlackExportChannel{
+			ID:        c.ID,
+			Name:      slackExportName(c, channels),
+			IsPrivate: c.Type == models.ConversationTypePrivateChannel,
+			Members:   []string{},
+		})
+	}
+	return result
+}
+
+// WriteSlackExportFiles writes users.json and channels.json into dir in
+// Slack's export schema.
+func WriteSlackExportFiles(dir string, users []*slackapi.User, channels []SlackExportChannel) error {
+	if users == nil {
+		users = []*slackapi.User{}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	if err := writeJSONFile(dir, SlackUsersFile, users); err != nil {
+		return err
+	}
+	return writeJSONFile(dir, SlackChannelsFile, channels)
+}
+
+// writeJSONFile atomically writes v as indented JSON to dir/name.
+func writeJSONFile(dir, name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", name, err)
+	}
+	if err := atomicWriteFile(dir, filepath.Join(dir, name), append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// localExportConversations returns the conversations written to the local
+// export directory, in any local format.
+func localExportConversations(convs []config.ConversationConfig) []config.ConversationConfig {
+	var result []config.ConversationConfig
+	for _, c := range convs {
+		if c.WritesLocal() {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// writeSlackExportFiles writes users.json and channels.json into the local
+// export directory, when one is configured, and users.json into the slack
+// format's archive when a conversation uses it.
+func (e *Exporter) writeSlackExportFiles(conversations []config.ConversationConfig) {
+	if e.localExportDir == "" {
+		return
+	}
+	local := localExportConversations(conversations)
+	if len(local) == 0 {
+		return
+	}
+	channels := SlackExportChannels(local, e.channelResolver)
+	users := e.userResolver.Users()
+	if !e.includeProfileStatus {
+		users = ScrubUserProfiles(users)
+	}
+	if err := WriteSlackExportFiles(e.localExportDir, users, channels); err != nil {
+		e.Progress("Warning: %v", err)
+	}
+	for _, c := range local {
+		if c.OutputFormat() == config.OutputFormatSlack {
+			if err := slackjson.WriteUsers(filepath.Join(e.localExportDir, SlackArchiveDir), users); err != nil {
+				e.Progress("Warning: %v", err)
+			}
+			break
+		}
+	}
+}
+
+// WriteSlackExportFiles writes users.json and channels.json for convs into
+// the output directory, from the users and channels in the raw workspace
+// archive.
+func (r *Renderer) WriteSlackExportFiles(convs []config.ConversationConfig) error {
+	users := r.userResolver.Users()
+	if !r.includeProfileStatus {
+		users = ScrubUserProfiles(users)
+	}
+	return WriteSlackExportFiles(r.outputDir, users, SlackExportChannels(convs, r.channelResolver))
+}
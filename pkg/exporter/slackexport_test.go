@@ -0,0 +1,73 @@
+package exporter
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/models"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+func TestSlackExportChannels(t *testing.T) {
+	channels := parser.NewChannelResolver()
+	channels.AddChannel("C001", "general")
+
+	got := SlackExportChannels([]config.ConversationConfig{
+		{ID: "C001", Name: "General Chat", Type: models.ConversationTypeChannel},
+		{ID: "G001", Name: "secret", Type: models.ConversationTypePrivateChannel},
+		{ID: "D001", Name: "Alice", Type: models.ConversationTypeDM},
+	}, channels)
+
+	if len(got) != 2 {
+		t.Fatalf("got %d channels, want 2 (DMs excluded)", len(got))
+	}
+	if got[0].Name != "general" {
+		t.Errorf("Name = %q, want resolver name %q", got[0].Name, "general")
+	}
+	if got[1].Name != "secret" || !got[1].IsPrivate {
+		t.Errorf("private channel = %+v", got[1])
+	}
+}
+
+func TestWriteSlackExportFiles(t *testing.T) {
+	dir := t.TempDir()
+	users := []*slackapi.User{{ID: "U001", Name: "alice", RealName: "Alice A."}}
+	channels := []SlackExportChannel{{ID: "C001", Name: "general", Members: []string{}}}
+
+	if err := WriteSlackExportFiles(dir, users, channels); err != nil {
+		t.Fatalf("WriteSlackExportFiles() error: %v", err)
+	}
+
+	var gotUsers []map[string]interface{}
+	readJSON(t, filepath.Join(dir, SlackUsersFile), &gotUsers)
+	if len(gotUsers) != 1 || gotUsers[0]["id"] != "U001" || gotUsers[0]["real_name"] != "Alice A." {
+		t.Errorf("users.json = %v", gotUsers)
+	}
+	if _, ok := gotUsers[0]["profile"]; !ok {
+		t.Error("users.json entry missing profile object")
+	}
+
+	var gotChannels []map[string]interface{}
+	readJSON(t, filepath.Join(dir, SlackChannelsFile), &gotChannels)
+	if len(gotChannels) != 1 || gotChannels[0]["name"] != "general" {
+		t.Errorf("channels.json = %v", gotChannels)
+	}
+	if members, ok := gotChannels[0]["members"].([]interface{}); !ok || members == nil {
+		t.Errorf("channels.json members should be an array, got %v", gotChannels[0]["members"])
+	}
+}
+
+func readJSON(t *testing.T, path string, v interface{}) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("failed to parse %s: %v", path, err)
+	}
+}
@@ -0,0 +1,122 @@
+package exporter
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/models"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+	"github.com/jflowers/get-out/pkg/slackjson"
+)
+
+// SlackArchiveDir is the directory, inside the local export directory,
+// holding conversations exported with the slack format.
+const SlackArchiveDir = "slack-export"
+
+// SlackArchiveConversationDir returns the directory of conv inside the
+// local export directory when it is exported with the slack format.
+func SlackArchiveConversationDir(conv config.ConversationConfig) string {
+	return filepath.Join(SlackArchiveDir, slackjson.DirName(slackjson.KindOf(conv.Type), conv.ID, conv.Name))
+}
+
+// slackBackend writes a conversation whose format is slack into
+// {localExportDir}/slack-export in Slack's workspace export format: a
+// directory per conversation named as Slack names it, holding a day file of
+// messages and thread replies, and the index files listing the
+// conversations, so the directory can be zipped and opened by tools built
+// for Slack exports.
+type slackBackend struct {
+	e *Exporter
+}
+
+func (b slackBackend) EnsureConversationContainer(ctx context.Context, conv config.ConversationConfig) (*ConversationExport, error) {
+	convExport, err := localBackend(b).EnsureConversationContainer(ctx, conv)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.updateIndex(conv, nil); err != nil {
+		return nil, err
+	}
+	return convExport, nil
+}
+
+func (b slackBackend) WriteMessages(ctx context.Context, conv config.ConversationConfig, date string, msgs []slackapi.Message, result *ExportResult) (int, error) {
+	if err := b.writeDay(ctx, conv, date, msgs, result); err != nil {
+		return 0, err
+	}
+	b.e.addMessages(len(msgs))
+	return len(msgs), nil
+}
+
+// WriteThread adds the replies to the conversation's day files, as in
+// Slack's export.
+func (b slackBackend) WriteThread(ctx context.Context, conv config.ConversationConfig, _ slackapi.Message, replies []slackapi.Message, result *ExportResult) error {
+	replyByDate := GroupMessagesByDate(replies)
+	for _, date := range SortedDates(replyByDate) {
+		if err := b.writeDay(ctx, conv, date, replyByDate[date], result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b slackBackend) Finalize(context.Context, config.ConversationConfig, *ExportResult) error {
+	return nil
+}
+
+// writeDay merges msgs into the conversation's day file and adds their
+// authors to the conversation's members in the index.
+func (b slackBackend) writeDay(ctx context.Context, conv config.ConversationConfig, date string, msgs []slackapi.Message, result *ExportResult) error {
+	e := b.e
+	msgs, err := e.filterLocalMessages(ctx, conv, date, msgs)
+	if err != nil || len(msgs) == 0 {
+		return err
+	}
+	kind := slackjson.KindOf(conv.Type)
+	dir := filepath.Join(SlackArchiveDir, slackjson.DirName(kind, conv.ID, slackExportName(conv, e.channelResolver)))
+	if _, err := slackjson.WriteDay(filepath.Join(e.localExportDir, dir), date, msgs); err != nil {
+		return err
+	}
+	result.JSONFilesWritten++
+	e.saveLocalFiles(ctx, dir, msgs, result)
+
+	var members []string
+	for _, m := range msgs {
+		members = append(members, m.User)
+	}
+	return b.updateIndex(conv, members)
+}
+
+// updateIndex adds conv to its index file, or adds members to its entry.
+func (b slackBackend) updateIndex(conv config.ConversationConfig, members []string) error {
+	e := b.e
+	root := filepath.Join(e.localExportDir, SlackArchiveDir)
+
+	// Conversations exported in parallel share the index files.
+	e.slackArchiveMu.Lock()
+	defer e.slackArchiveMu.Unlock()
+	index, err := slackjson.ReadIndex(root)
+	if err != nil {
+		return err
+	}
+	index.Upsert(slackjson.KindOf(conv.Type), slackjson.Channel{
+		ID:        conv.ID,
+		Name:      slackExportName(conv, e.channelResolver),
+		IsPrivate: conv.Type != models.ConversationTypeChannel,
+		Members:   members,
+	})
+	return index.Write(root)
+}
+
+// slackExportName returns conv's Slack name: the channel resolver's name
+// when it knows the conversation, otherwise the name in conversations.json.
+func slackExportName(conv config.ConversationConfig, channels *parser.ChannelResolver) string {
+	if channels != nil {
+		if resolved := channels.Resolve(conv.ID); resolved != conv.ID {
+			return resolved
+		}
+	}
+	return conv.Name
+}
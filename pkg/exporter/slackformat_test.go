@@ -0,0 +1,69 @@
+package exporter
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/slackjson"
+)
+
+func TestExportConversation_SlackFormat(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	conv.Format = config.OutputFormatSlack
+	exp, localDir := localFormatExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+
+	if _, err := exp.ExportAll(context.Background(), []config.ConversationConfig{conv}); err != nil {
+		t.Fatalf("ExportAll() error: %v", err)
+	}
+	if ce := exp.index.GetConversation("C001"); ce.FolderID != "" {
+		t.Errorf("folder = %q, want none (slack stays off Drive)", ce.FolderID)
+	}
+
+	root := filepath.Join(localDir, SlackArchiveDir)
+	day, err := slackjson.ReadDay(filepath.Join(root, "general"), "2024-02-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	for _, m := range day {
+		texts = append(texts, m.Text)
+	}
+	if want := "Good morning|Thread starter|A reply"; strings.Join(texts, "|") != want {
+		t.Errorf("2024-02-01.json = %q, want %q", texts, want)
+	}
+
+	index, err := slackjson.ReadIndex(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Channels) != 1 || index.Channels[0].ID != "C001" || strings.Join(index.Channels[0].Members, ",") != "U001,U002" {
+		t.Errorf("channels.json = %+v, want general with its authors as members", index.Channels)
+	}
+	// users.json sits next to the index files, as in Slack's export.
+	var users []map[string]interface{}
+	readJSON(t, filepath.Join(root, slackjson.UsersFile), &users)
+}
+
+func TestExportConversation_SlackFormatDM(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	conv.Format, conv.Type, conv.Name = config.OutputFormatSlack, "dm", "alice"
+	exp, localDir := localFormatExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	root := filepath.Join(localDir, SlackArchiveDir)
+	if day, err := slackjson.ReadDay(filepath.Join(root, "C001"), "2024-02-02"); err != nil || len(day) != 1 {
+		t.Errorf("DM day file = %d messages, %v; want it in a directory named by ID", len(day), err)
+	}
+	index, err := slackjson.ReadIndex(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index.DMs) != 1 || len(index.Channels) != 0 {
+		t.Errorf("index = %+v, want the DM in dms.json only", index)
+	}
+}
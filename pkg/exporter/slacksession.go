@@ -0,0 +1,103 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/jflowers/get-out/pkg/secrets"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// slackSession is a Slack browser session extracted from Chrome, saved in
+// the SecretStore (the OS keychain unless --no-keyring) under
+// secrets.KeySlackSession so later runs can skip Chrome until it expires.
+type slackSession struct {
+	Token      string `json:"token"`
+	Cookie     string `json:"cookie"`
+	TeamID     string `json:"teamId,omitempty"`
+	TeamDomain string `json:"teamDomain,omitempty"`
+}
+
+// isFor reports whether s is a session of team, given by ID or domain. A
+// session saved before the team ID was recorded matches by domain only.
+func (s slackSession) isFor(team string) bool {
+	return strings.EqualFold(s.TeamID, team) || strings.EqualFold(s.TeamDomain, team)
+}
+
+// validateSlackSession checks a session with auth.test; a variable so tests
+// can stand in for Slack.
+var validateSlackSession = func(ctx context.Context, s slackSession) error {
+	_, err := newSlackClient(s.Token, s.Cookie).ValidateAuth(ctx)
+	return err
+}
+
+// browserSession returns the Slack credentials of the browser session: the
+// saved session while auth.test still accepts it, otherwise ones extracted
+// from the Chrome instance on chromePort (and saved for the next run). A
+// saved session of another workspace than the configured one is not used.
+func (e *Exporter) browserSession(ctx context.Context, chromePort int) (token, cookie string, err error) {
+	saved := e.loadSlackSession()
+	if saved != nil && e.slackTeam != "" && !saved.isFor(e.slackTeam) {
+		e.Progress("Saved Slack session is for %s, not %s; extracting credentials from Chrome", orDefault(saved.TeamDomain, "another workspace"), e.slackTeam)
+		saved = nil
+	}
+	if saved != nil {
+		err := validateSlackSession(ctx, *saved)
+		var authErr *slackapi.AuthError
+		switch {
+		case err == nil:
+			e.Progress("Using the saved Slack session for %s (Chrome not needed)", orDefault(saved.TeamDomain, "the workspace"))
+			return saved.Token, saved.Cookie, nil
+		case errors.As(err, &authErr):
+			e.Progress("Saved Slack session has expired (%s); extracting new credentials from Chrome", authErr.Code)
+			e.forgetSlackSession()
+		default:
+			e.Progress("Warning: could not check the saved Slack session: %v", err)
+		}
+	}
+	return e.extractSlackCredentials(ctx, chromePort)
+}
+
+// loadSlackSession returns the saved Slack session, or nil when there is
+// none or it cannot be read.
+func (e *Exporter) loadSlackSession() *slackSession {
+	if e.secretStore == nil {
+		return nil
+	}
+	data, err := e.secretStore.Get(secrets.KeySlackSession)
+	if err != nil {
+		return nil
+	}
+	var s slackSession
+	if err := json.Unmarshal([]byte(data), &s); err != nil || s.Token == "" {
+		return nil
+	}
+	return &s
+}
+
+// saveSlackSession saves s for later runs. A failure only costs the next
+// run a trip through Chrome, so it is reported, not returned.
+func (e *Exporter) saveSlackSession(s slackSession) {
+	if e.secretStore == nil {
+		return
+	}
+	data, err := json.Marshal(s)
+	if err == nil {
+		err = e.secretStore.Set(secrets.KeySlackSession, string(data))
+	}
+	if err != nil {
+		e.Progress("Warning: failed to save the Slack session: %v", err)
+	}
+}
+
+// forgetSlackSession removes the saved Slack session.
+func (e *Exporter) forgetSlackSession() {
+	if e.secretStore == nil {
+		return
+	}
+	if err := e.secretStore.Delete(secrets.KeySlackSession); err != nil {
+		e.Progress("Warning: failed to remove the saved Slack session: %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/secrets"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// stubSlackSessionCheck makes validateSlackSession return err, restoring
+// it when the test ends.
+func stubSlackSessionCheck(t *testing.T, err error) {
+	t.Helper()
+	old := validateSlackSession
+	validateSlackSession = func(context.Context, slackSession) error { return err }
+	t.Cleanup(func() { validateSlackSession = old })
+}
+
+func TestSlackSession_SaveLoadForget(t *testing.T) {
+	e := &Exporter{secretStore: &secrets.FileStore{ConfigDir: t.TempDir()}}
+	if s := e.loadSlackSession(); s != nil {
+		t.Fatalf("loadSlackSession() on an empty store = %+v, want nil", s)
+	}
+
+	e.saveSlackSession(slackSession{Token: "xoxc-1", Cookie: "xoxd-1", TeamDomain: "acme"})
+	s := e.loadSlackSession()
+	if s == nil || s.Token != "xoxc-1" || s.Cookie != "xoxd-1" || s.TeamDomain != "acme" {
+		t.Fatalf("loadSlackSession() = %+v, want the saved session", s)
+	}
+
+	e.forgetSlackSession()
+	if s := e.loadSlackSession(); s != nil {
+		t.Errorf("loadSlackSession() after forgetSlackSession() = %+v, want nil", s)
+	}
+
+	// Without a store nothing is saved and nothing fails.
+	none := &Exporter{}
+	none.saveSlackSession(slackSession{Token: "xoxc-1"})
+	if s := none.loadSlackSession(); s != nil {
+		t.Errorf("loadSlackSession() without a store = %+v, want nil", s)
+	}
+}
+
+func TestBrowserSession_UsesValidSavedSession(t *testing.T) {
+	stubSlackSessionCheck(t, nil)
+	e := &Exporter{secretStore: &secrets.FileStore{ConfigDir: t.TempDir()}}
+	e.saveSlackSession(slackSession{Token: "xoxc-saved", Cookie: "xoxd-saved"})
+
+	// No Chrome listens on port 1: using it would fail.
+	token, cookie, err := e.browserSession(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("browserSession() error: %v", err)
+	}
+	if token != "xoxc-saved" || cookie != "xoxd-saved" {
+		t.Errorf("browserSession() = %q, %q; want the saved session", token, cookie)
+	}
+}
+
+func TestBrowserSession_ExpiredSessionFallsBackToChrome(t *testing.T) {
+	stubSlackSessionCheck(t, &slackapi.AuthError{Code: slackapi.ErrCodeInvalidAuth})
+	e := &Exporter{secretStore: &secrets.FileStore{ConfigDir: t.TempDir()}}
+	e.saveSlackSession(slackSession{Token: "xoxc-expired", Cookie: "xoxd-expired"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, _, err := e.browserSession(ctx, 1)
+	if err == nil {
+		t.Fatal("browserSession() = nil error, want the failure to reach Chrome")
+	}
+	if s := e.loadSlackSession(); s != nil {
+		t.Errorf("expired session still saved: %+v", s)
+	}
+}
+
+func TestBrowserSession_KeepsSessionWhenCheckFails(t *testing.T) {
+	stubSlackSessionCheck(t, errors.New("network is unreachable"))
+	e := &Exporter{secretStore: &secrets.FileStore{ConfigDir: t.TempDir()}}
+	e.saveSlackSession(slackSession{Token: "xoxc-saved", Cookie: "xoxd-saved"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, _, err := e.browserSession(ctx, 1); err == nil {
+		t.Fatal("browserSession() = nil error, want the failure to reach Chrome")
+	}
+	if s := e.loadSlackSession(); s == nil {
+		t.Error("saved session removed after a check that failed for another reason than auth")
+	}
+}
+
+func TestBrowserSession_SkipsSessionOfAnotherWorkspace(t *testing.T) {
+	stubSlackSessionCheck(t, nil)
+	e := &Exporter{secretStore: &secrets.FileStore{ConfigDir: t.TempDir()}, slackTeam: "beta"}
+	e.saveSlackSession(slackSession{Token: "xoxc-acme", Cookie: "xoxd-acme", TeamID: "T1", TeamDomain: "acme"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, _, err := e.browserSession(ctx, 1); err == nil {
+		t.Fatal("browserSession() = nil error, want the saved acme session skipped for Chrome")
+	}
+
+	// The configured workspace's session, named by ID or domain, is used.
+	for _, team := range []string{"T1", "ACME"} {
+		e.slackTeam = team
+		token, _, err := e.browserSession(ctx, 1)
+		if err != nil || token != "xoxc-acme" {
+			t.Errorf("slackTeam %q: browserSession() = %q, %v; want the saved session", team, token, err)
+		}
+	}
+}
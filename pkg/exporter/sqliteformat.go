@@ -0,0 +1,166 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+	"github.com/jflowers/get-out/pkg/sqlitearchive"
+)
+
+// SQLiteArchiveFile is the database, inside the local export directory,
+// holding conversations exported with the sqlite format.
+const SQLiteArchiveFile = "get-out.sqlite"
+
+// sqliteBackend writes a conversation whose format is sqlite into
+// {localExportDir}/get-out.sqlite: a row per message, with its reactions
+// and files, in one database shared by every such conversation, and a
+// full-text index of the messages' rendered text. A message exported
+// again replaces its row, so --sync and --resume never duplicate one.
+type sqliteBackend struct {
+	e *Exporter
+}
+
+func (b sqliteBackend) EnsureConversationContainer(ctx context.Context, conv config.ConversationConfig) (*ConversationExport, error) {
+	e := b.e
+	if e.localExportDir == "" {
+		return nil, fmt.Errorf("format %q needs a local export directory: set localExportOutputDir in settings.json or pass --local-export-dir", conv.OutputFormat())
+	}
+	if e.legalHold {
+		return nil, fmt.Errorf("format %q is not supported in legal hold mode (its rows are replaced as messages change)", conv.OutputFormat())
+	}
+	db, err := e.sqliteArchive(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.PutConversation(ctx, sqlitearchive.Conversation{ID: conv.ID, Name: conv.Name, Type: string(conv.Type)}); err != nil {
+		return nil, err
+	}
+	e.Detail("Writing %s to %s", conv.Name, db.Path())
+	return e.index.GetOrCreateConversation(conv.ID, conv.Name, string(conv.Type)), nil
+}
+
+func (b sqliteBackend) WriteMessages(ctx context.Context, conv config.ConversationConfig, date string, msgs []slackapi.Message, _ *ExportResult) (int, error) {
+	if err := b.write(ctx, conv, date, msgs); err != nil {
+		return 0, err
+	}
+	b.e.addMessages(len(msgs))
+	return len(msgs), nil
+}
+
+// WriteThread adds the replies to the messages table; their thread_ts
+// links them to the parent.
+func (b sqliteBackend) WriteThread(ctx context.Context, conv config.ConversationConfig, _ slackapi.Message, replies []slackapi.Message, _ *ExportResult) error {
+	replyByDate := GroupMessagesByDate(replies)
+	for _, date := range SortedDates(replyByDate) {
+		if err := b.write(ctx, conv, date, replyByDate[date]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b sqliteBackend) Finalize(context.Context, config.ConversationConfig, *ExportResult) error {
+	return nil
+}
+
+// write adds one day's messages of conv to the database.
+func (b sqliteBackend) write(ctx context.Context, conv config.ConversationConfig, date string, msgs []slackapi.Message) error {
+	e := b.e
+	msgs, err := e.filterLocalMessages(ctx, conv, date, msgs)
+	if err != nil || len(msgs) == 0 {
+		return err
+	}
+	db, err := e.sqliteArchive(ctx)
+	if err != nil {
+		return err
+	}
+	rows := make([]sqlitearchive.Message, len(msgs))
+	for i, m := range msgs {
+		rows[i] = e.sqliteMessage(conv, m)
+	}
+	return db.PutMessages(ctx, rows)
+}
+
+// sqliteMessage returns m as a row of the messages table, its text
+// rendered as the other formats show it.
+func (e *Exporter) sqliteMessage(conv config.ConversationConfig, m slackapi.Message) sqlitearchive.Message {
+	text, _ := parser.ConvertMrkdwnWithLinks(parser.MessageText(m), e.userResolver, e.channelResolver, e.personResolver, nil)
+	raw := []byte(m.Raw)
+	if len(raw) == 0 {
+		raw, _ = json.Marshal(m)
+	}
+	row := sqlitearchive.Message{
+		ConversationID: conv.ID,
+		TS:             m.TS,
+		ThreadTS:       m.ThreadTS,
+		User:           m.User,
+		Subtype:        m.Subtype,
+		Date:           DateFromTS(m.TS),
+		Text:           m.Text,
+		RenderedText:   text,
+		ReplyCount:     m.ReplyCount,
+		Raw:            raw,
+	}
+	if m.Edited != nil {
+		row.EditedTS = m.Edited.TS
+	}
+	for _, r := range m.Reactions {
+		row.Reactions = append(row.Reactions, sqlitearchive.Reaction{Name: r.Name, Users: r.Users})
+	}
+	for _, f := range m.Files {
+		row.Files = append(row.Files, sqlitearchive.File{
+			ID:       f.ID,
+			Name:     f.Name,
+			Title:    f.Title,
+			Mimetype: f.Mimetype,
+			Size:     f.Size,
+			URL:      f.URLPrivate,
+		})
+	}
+	return row
+}
+
+// sqliteArchive returns the sqlite format's database, opening it the
+// first time.
+func (e *Exporter) sqliteArchive(ctx context.Context) (*sqlitearchive.DB, error) {
+	e.sqliteMu.Lock()
+	defer e.sqliteMu.Unlock()
+	if e.sqliteDB == nil {
+		db, err := sqlitearchive.Open(ctx, filepath.Join(e.localExportDir, SQLiteArchiveFile))
+		if err != nil {
+			return nil, err
+		}
+		e.sqliteDB = db
+	}
+	return e.sqliteDB, nil
+}
+
+// writeSQLiteUsers writes the workspace's users into the sqlite format's
+// database when a conversation was written to it.
+func (e *Exporter) writeSQLiteUsers() {
+	e.sqliteMu.Lock()
+	db := e.sqliteDB
+	e.sqliteMu.Unlock()
+	if db == nil {
+		return
+	}
+	var users []sqlitearchive.User
+	for _, u := range e.userResolver.Users() {
+		users = append(users, sqlitearchive.User{
+			ID:          u.ID,
+			Name:        u.Name,
+			RealName:    u.RealName,
+			DisplayName: u.Profile.DisplayName,
+			IsBot:       u.IsBot,
+			Deleted:     u.Deleted,
+		})
+	}
+	if err := db.PutUsers(context.Background(), users); err != nil {
+		e.Progress("Warning: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package exporter
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/slackapi"
+	"github.com/jflowers/get-out/pkg/sqlitearchive"
+)
+
+func TestExportConversation_SQLiteFormat(t *testing.T) {
+	if _, err := exec.LookPath(sqlitearchive.DefaultBinary); err != nil {
+		t.Skip("sqlite3 is not on the PATH")
+	}
+	drive, slack, conv := fakeConversation()
+	slack.Messages["C001"][0].Text = "*Good* morning <#C002|random>"
+	slack.Messages["C001"][0].Reactions = []slackapi.Reaction{{Name: "wave", Users: []string{"U002"}, Count: 1}}
+	slack.Messages["C001"][2].Files = []slackapi.File{{ID: "F001", Name: "notes.txt", Title: "Notes", Mimetype: "text/plain", Size: 12}}
+	conv.Format = config.OutputFormatSQLite
+	exp, localDir := localFormatExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.userResolver.AddUser(&slackapi.User{ID: "U001", Name: "alice", RealName: "Alice"})
+
+	ctx := context.Background()
+	result, err := exp.ExportConversation(ctx, conv)
+	if err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	if n := len(drive.Documents()); n != 0 {
+		t.Errorf("documents = %d, want 0 (sqlite stays off Drive)", n)
+	}
+	if result.MessageCount != 3 || result.ThreadsExported != 1 {
+		t.Errorf("MessageCount = %d, ThreadsExported = %d, want 3 and 1", result.MessageCount, result.ThreadsExported)
+	}
+	exp.writeSQLiteUsers()
+
+	db, err := sqlitearchive.Open(ctx, filepath.Join(localDir, SQLiteArchiveFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := func(sql string) []map[string]interface{} {
+		t.Helper()
+		rows, err := db.Query(ctx, sql)
+		if err != nil {
+			t.Fatalf("%s: %v", sql, err)
+		}
+		return rows
+	}
+
+	var texts []string
+	for _, row := range query("SELECT rendered_text FROM messages ORDER BY ts") {
+		texts = append(texts, row["rendered_text"].(string))
+	}
+	if want := "Good morning #random|Thread starter|A reply|Next day"; strings.Join(texts, "|") != want {
+		t.Errorf("messages = %q, want %q (the parent once, with its reply)", texts, want)
+	}
+	if rows := query("SELECT ts FROM messages WHERE thread_ts = '1706792400.000200' AND ts != thread_ts"); len(rows) != 1 || rows[0]["ts"] != "1706792460.000400" {
+		t.Errorf("replies = %v, want the reply linked to its parent", rows)
+	}
+	if rows := query("SELECT name, type FROM conversations"); len(rows) != 1 || rows[0]["name"] != "general" || rows[0]["type"] != "channel" {
+		t.Errorf("conversations = %v", rows)
+	}
+	if rows := query("SELECT name, user_id FROM reactions"); len(rows) != 1 || rows[0]["name"] != "wave" || rows[0]["user_id"] != "U002" {
+		t.Errorf("reactions = %v", rows)
+	}
+	if rows := query("SELECT file_id, ts FROM files"); len(rows) != 1 || rows[0]["file_id"] != "F001" || rows[0]["ts"] != "1706875200.000300" {
+		t.Errorf("files = %v", rows)
+	}
+	if rows := query("SELECT real_name FROM users WHERE id = 'U001'"); len(rows) != 1 || rows[0]["real_name"] != "Alice" {
+		t.Errorf("users = %v", rows)
+	}
+	if rows := query("SELECT m.ts FROM messages_fts JOIN messages m ON m.rowid = messages_fts.rowid WHERE messages_fts MATCH 'random'"); len(rows) != 1 || rows[0]["ts"] != "1706788800.000100" {
+		t.Errorf("full-text search = %v, want the rendered channel mention found", rows)
+	}
+
+	// A sync writes the new message without duplicating the others.
+	slack.Messages["C001"] = append(slack.Messages["C001"],
+		slackapi.Message{User: "U002", Text: "Later that day", TS: "1706878800.000500"})
+	exp.syncMode = true
+	if _, err := exp.ExportConversation(ctx, conv); err != nil {
+		t.Fatalf("sync export: %v", err)
+	}
+	if rows := query("SELECT count(*) AS n FROM messages"); rows[0]["n"] != float64(5) {
+		t.Errorf("messages after sync = %v, want 5", rows[0]["n"])
+	}
+}
+
+func TestExportConversation_SQLiteFormatErrors(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	conv.Format = config.OutputFormatSQLite
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	if _, err := exp.ExportConversation(context.Background(), conv); err == nil || !strings.Contains(err.Error(), "local export directory") {
+		t.Errorf("without a local export dir: err = %v, want local export directory error", err)
+	}
+
+	exp, _ = localFormatExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.legalHold = true
+	if _, err := exp.ExportConversation(context.Background(), conv); err == nil || !strings.Contains(err.Error(), "legal hold") {
+		t.Errorf("sqlite under legal hold: err = %v, want legal hold error", err)
+	}
+}
@@ -0,0 +1,142 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/gdrive"
+	"github.com/jflowers/get-out/pkg/parser"
+)
+
+// StatusDocTitle is the title of the doc in the export root folder that
+// shows how current each exported conversation is.
+const StatusDocTitle = "Export Status"
+
+// writeStatusDoc rewrites the Export Status doc at the end of a run with
+// the freshness of every conversation exported to Google Docs, so people
+// without the CLI can see how far behind Slack the archive is. A run of
+// convs that writes no docs leaves Drive alone. Under legal hold the doc is
+// not rewritten; each run appends its status instead. Failures are
+// reported without failing the run.
+func (e *Exporter) writeStatusDoc(ctx context.Context, convs []config.ConversationConfig) {
+	exports := e.docsConversations(convs)
+	if e.gdriveClient == nil || len(exports) == 0 || ctx.Err() != nil {
+		return
+	}
+	root, err := e.folderStructure.EnsureRootFolder(ctx)
+	if err != nil {
+		e.Progress("Warning: could not update %s: %v", StatusDocTitle, err)
+		return
+	}
+	doc, err := e.gdriveClient.FindOrCreateDocument(ctx, StatusDocTitle, root.ID)
+	if err != nil {
+		e.Progress("Warning: could not create %s doc: %v", StatusDocTitle, err)
+		return
+	}
+
+	blocks := statusBlocks(exports, time.Now())
+	if e.legalHold {
+		err = e.gdriveClient.BatchAppendMessages(ctx, doc.ID, blocks)
+	} else {
+		err = e.gdriveClient.ReplaceDocumentContent(ctx, doc.ID, blocks)
+	}
+	if err != nil {
+		e.Progress("Warning: could not update %s: %v", StatusDocTitle, err)
+		return
+	}
+	e.Detail("Updated %s: %s", StatusDocTitle, doc.URL)
+}
+
+// docsConversations returns the index entries the docs in the export root
+// list: the conversations in convs exported to Google Docs, and those
+// earlier runs put in Drive. It returns nil when nothing in convs writes
+// docs, so a local-only or digest-only run creates nothing in Drive.
+func (e *Exporter) docsConversations(convs []config.ConversationConfig) []*ConversationExport {
+	if e.digestOnly {
+		return nil
+	}
+	inRun := make(map[string]bool)
+	for _, conv := range convs {
+		if conv.WritesDocs() {
+			inRun[conv.ID] = true
+		}
+	}
+	if len(inRun) == 0 {
+		return nil
+	}
+	var exports []*ConversationExport
+	for _, conv := range e.index.AllConversations() {
+		conv.mu.Lock()
+		inDrive := conv.FolderID != ""
+		conv.mu.Unlock()
+		if inRun[conv.ID] || inDrive {
+			exports = append(exports, conv)
+		}
+	}
+	return exports
+}
+
+// statusBlocks renders the Export Status doc: a summary, then one block per
+// conversation, furthest behind first. A conversation's lag is the time
+// between its newest exported message and now.
+func statusBlocks(convs []*ConversationExport, now time.Time) []gdrive.MessageBlock {
+	convs = append([]*ConversationExport(nil), convs...)
+	sort.SliceStable(convs, func(i, j int) bool {
+		return convs[i].newestMessageTS() < convs[j].newestMessageTS()
+	})
+
+	counts := make(map[string]int)
+	var rows []gdrive.MessageBlock
+	for _, conv := range convs {
+		status := orDefault(conv.Status, "unknown")
+		counts[status]++
+
+		lines := []string{"No messages exported yet"}
+		if newest := conv.newestMessageTS(); newest != "" {
+			last := TSToTime(newest)
+			lines[0] = fmt.Sprintf("Newest message exported: %s (%s behind)", parser.FormatTime(last), lagString(now.Sub(last)))
+		}
+		if !conv.LastUpdated.IsZero() {
+			lines = append(lines, "Last exported: "+parser.FormatTime(conv.LastUpdated))
+		}
+		lines = append(lines, fmt.Sprintf("Status: %s, %d messages", status, conv.MessageCount))
+		if conv.Status == StatusFailed && conv.Error != "" {
+			lines = append(lines, "Error: "+conv.Error)
+		}
+
+		row := gdrive.MessageBlock{SenderName: orDefault(conv.Name, conv.ID), Timestamp: conv.Type}
+		if conv.FolderURL != "" {
+			lines = append(lines, "Open folder")
+			row.Links = []gdrive.LinkAnnotation{{Text: "Open folder", URL: conv.FolderURL}}
+		}
+		row.Content = strings.Join(lines, "\n")
+		rows = append(rows, row)
+	}
+
+	summary := fmt.Sprintf("%d conversations: %d complete, %d in progress, %d failed",
+		len(convs), counts[StatusComplete], counts[StatusInProgress]+counts[StatusPending], counts[StatusFailed])
+	summary += "\nUpdated by get-out at the end of every export run. Conversations furthest behind Slack are listed first."
+	return append([]gdrive.MessageBlock{{
+		SenderName: StatusDocTitle,
+		Timestamp:  parser.FormatTime(now),
+		Content:    summary,
+	}}, rows...)
+}
+
+// lagString describes how long ago something happened, to the hour.
+func lagString(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return "less than an hour"
+	case d < 2*time.Hour:
+		return "1 hour"
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%d hours", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%d days", int(d.Hours()/24))
+	}
+}
@@ -0,0 +1,121 @@
+package exporter
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jflowers/get-out/internal/testutil"
+	"github.com/jflowers/get-out/pkg/config"
+)
+
+func TestStatusBlocks(t *testing.T) {
+	now := time.Unix(1706961600, 0) // 2024-02-03 12:00 UTC
+	convs := []*ConversationExport{
+		{ID: "C001", Name: "general", Type: "channel", Status: StatusComplete, LastMessageTS: "1706954400.000100", MessageCount: 10, FolderURL: "https://drive/general"}, // 2 hours behind
+		{ID: "C002", Name: "releases", Type: "channel", Status: StatusFailed, Error: "not_in_channel", LastMessageTS: "1706616000.000100"},                               // 4 days behind
+		{ID: "D001", Type: "dm", Status: StatusPending},
+	}
+
+	blocks := statusBlocks(convs, now)
+	if len(blocks) != 4 {
+		t.Fatalf("got %d blocks, want a summary and one per conversation", len(blocks))
+	}
+	if !strings.HasPrefix(blocks[0].Content, "3 conversations: 1 complete, 1 in progress, 1 failed") {
+		t.Errorf("summary = %q", blocks[0].Content)
+	}
+
+	var order []string
+	for _, b := range blocks[1:] {
+		order = append(order, b.SenderName)
+	}
+	if got := strings.Join(order, ","); got != "D001,releases,general" {
+		t.Errorf("order = %s, want never exported first, then furthest behind", got)
+	}
+	if !strings.Contains(blocks[2].Content, "(4 days behind)") || !strings.Contains(blocks[2].Content, "Error: not_in_channel") {
+		t.Errorf("releases = %q, want its lag and error", blocks[2].Content)
+	}
+	if !strings.Contains(blocks[3].Content, "(2 hours behind)") || len(blocks[3].Links) != 1 {
+		t.Errorf("general = %+v, want its lag and a folder link", blocks[3])
+	}
+}
+
+func TestWriteStatusDoc(t *testing.T) {
+	drive, slack := testutil.NewFakeDrive(), testutil.NewFakeSlack()
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.index.GetOrCreateConversation("C001", "general", "channel").LastMessageTS = "1706954400.000100"
+	convs := []config.ConversationConfig{{ID: "C001", Name: "general", Type: "channel"}}
+
+	exp.writeStatusDoc(context.Background(), convs)
+	exp.writeStatusDoc(context.Background(), convs)
+
+	if docs := drive.Documents(); len(docs) != 1 || docs[0] != StatusDocTitle {
+		t.Fatalf("docs = %v, want a single %s doc", docs, StatusDocTitle)
+	}
+	doc, err := drive.FindOrCreateDocument(context.Background(), StatusDocTitle, exp.index.RootFolderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := drive.FolderName(drive.DocumentFolder(doc.ID)); got != "Test Exports" {
+		t.Errorf("status doc folder = %q, want the export root", got)
+	}
+	if got := len(drive.Appended(doc.ID)); got != 1 {
+		t.Errorf("status doc has %d writes, want each run to replace the last", got)
+	}
+
+	// Under legal hold each run appends instead.
+	exp.legalHold = true
+	exp.writeStatusDoc(context.Background(), convs)
+	if got := len(drive.Appended(doc.ID)); got != 2 {
+		t.Errorf("status doc has %d writes under legal hold, want the run appended", got)
+	}
+}
+
+func TestWriteStatusDoc_LocalOnly(t *testing.T) {
+	drive, slack := testutil.NewFakeDrive(), testutil.NewFakeSlack()
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.index.GetOrCreateConversation("C001", "general", "channel").LastMessageTS = "1706954400.000100"
+
+	exp.writeStatusDoc(context.Background(), []config.ConversationConfig{{ID: "C001", Name: "general", Type: "channel", Format: config.OutputFormatMarkdown}})
+
+	if drive.Calls("FindOrCreateFolder") != 0 || len(drive.Documents()) != 0 {
+		t.Errorf("a markdown-only run created %v in Drive, want nothing", drive.Documents())
+	}
+}
+
+func TestDocsConversations(t *testing.T) {
+	exp := fakeExporter(t, testutil.NewFakeDrive(), testutil.NewFakeSlack(), t.TempDir()+"/export-index.json")
+	exp.index.GetOrCreateConversation("C001", "general", "channel")
+	exp.index.GetOrCreateConversation("C002", "notes", "channel")
+	exp.index.GetOrCreateConversation("C003", "archive", "channel").FolderID = "folder_archive"
+
+	convs := []config.ConversationConfig{
+		{ID: "C001", Name: "general", Type: "channel"},
+		{ID: "C002", Name: "notes", Type: "channel", Format: config.OutputFormatJSON},
+	}
+	var ids []string
+	for _, conv := range exp.docsConversations(convs) {
+		ids = append(ids, conv.ID)
+	}
+	if got := strings.Join(ids, ","); got != "C003,C001" {
+		t.Errorf("docsConversations() = %s, want the docs conversation and the one already in Drive", got)
+	}
+}
+
+func TestLagString(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{10 * time.Minute, "less than an hour"},
+		{90 * time.Minute, "1 hour"},
+		{30 * time.Hour, "30 hours"},
+		{100 * time.Hour, "4 days"},
+	}
+	for _, tt := range tests {
+		if got := lagString(tt.d); got != tt.want {
+			t.Errorf("lagString(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/models"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+	"github.com/jflowers/get-out/pkg/slackjson"
+)
+
+// InitializeStream sets up what StreamConversation needs: the Slack client,
+// people.json, the user cache, custom emoji, and a markdown writer. Unlike
+// InitializeWithStore it neither loads the export index nor authenticates
+// with Google, so streaming never touches Drive or export progress.
+func (e *Exporter) InitializeStream(ctx context.Context, chromePort int) error {
+	if err := e.ConnectSlack(ctx, chromePort); err != nil {
+		return err
+	}
+	e.loadPersonResolver()
+	e.loadUserCache()
+	e.loadCustomEmoji(ctx)
+	e.mdWriter = NewMarkdownWriter(e.userResolver, e.channelResolver, e.personResolver)
+	e.mdWriter.SetExporterVersion(e.version)
+	e.mdWriter.SetEmojiResolver(e.emoji)
+	return nil
+}
+
+// DescribeConversation returns a config for a conversation that is not in
+// conversations.json, named and typed from conversations.info. When the
+// lookup fails, the conversation is named by its ID.
+func (e *Exporter) DescribeConversation(ctx context.Context, id string) config.ConversationConfig {
+	conv := config.ConversationConfig{ID: id, Name: id}
+	info, err := e.slackClient.GetConversationInfo(ctx, id)
+	if err != nil {
+		e.Detail("Could not look up %s: %v", id, err)
+		return conv
+	}
+	if info.Name != "" {
+		conv.Name = info.Name
+	}
+	switch {
+	case info.IsIM:
+		conv.Type = models.ConversationTypeDM
+	case info.IsMPIM:
+		conv.Type = models.ConversationTypeMPIM
+	case info.IsPrivate:
+		conv.Type = models.ConversationTypePrivateChannel
+	default:
+		conv.Type = models.ConversationTypeChannel
+	}
+	return conv
+}
+
+// StreamConversation writes conv's messages between the exporter's --from
+// and --to dates to w, oldest first, as each day is rendered. Markdown is
+// one daily document per day, as written by the markdown format, with each
+// thread's replies following under a "### Thread:" heading; json is one
+// message per line (JSON Lines), thread replies in time order as in a
+// Slack export day file. The sensitivity filter applies as for local
+// markdown. Nothing is recorded in the export index.
+func (e *Exporter) StreamConversation(ctx context.Context, w io.Writer, conv config.ConversationConfig, format config.OutputFormat) error {
+	if format != config.OutputFormatMarkdown && format != config.OutputFormatJSON {
+		return fmt.Errorf("cannot stream format %q (must be %s or %s)", format, config.OutputFormatMarkdown, config.OutputFormatJSON)
+	}
+
+	allMessages, err := e.fetchMessages(ctx, conv.ID, e.dateFrom, e.dateTo, nil)
+	if err != nil {
+		return err
+	}
+	e.loadMessageAuthors(ctx, allMessages)
+	e.loadMentionedChannels(ctx, allMessages)
+
+	messagesByDate := GroupMessagesByDate(FilterMainMessages(allMessages))
+	for _, date := range SortedDates(messagesByDate) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		msgs, err := e.filterLocalMessages(ctx, conv, date, messagesByDate[date])
+		if err != nil {
+			return err
+		}
+		if len(msgs) == 0 {
+			continue
+		}
+		threads := e.streamThreads(ctx, conv, date, msgs)
+
+		if format == config.OutputFormatJSON {
+			var day []slackapi.Message
+			for _, t := range threads {
+				day = append(day, t.replies...)
+			}
+			err = writeJSONLines(w, slackjson.MergeMessages(msgs, day))
+		} else {
+			err = e.writeMarkdownStream(w, conv, date, msgs, threads)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	e.saveUserCache()
+	return nil
+}
+
+// streamThread is a thread parent and its replies, without the parent.
+type streamThread struct {
+	parent  slackapi.Message
+	replies []slackapi.Message
+}
+
+// streamThreads fetches the replies of the thread parents among msgs, one
+// day's messages of conv, filtered like the day. A thread that cannot be
+// fetched is reported and left out.
+func (e *Exporter) streamThreads(ctx context.Context, conv config.ConversationConfig, date string, msgs []slackapi.Message) []streamThread {
+	var threads []streamThread
+	for _, parent := range GetThreadParents(slackjson.MergeMessages(nil, msgs)) {
+		if !e.capabilities.Usable(slackapi.MethodConversationsReplies) {
+			e.Progress("Skipping threads: conversations.replies is restricted")
+			break
+		}
+		var replies []slackapi.Message
+		err := e.slackClient.GetAllReplies(ctx, conv.ID, parent.TS, func(batch []slackapi.Message) error {
+			e.metrics.AddFetched(conv.Name, len(batch))
+			for _, msg := range batch {
+				if msg.TS != parent.TS {
+					replies = append(replies, msg)
+				}
+			}
+			return nil
+		})
+		if err == nil {
+			e.loadMessageAuthors(ctx, replies)
+			e.loadMentionedChannels(ctx, replies)
+			replies, err = e.filterLocalMessages(ctx, conv, date, replies)
+		}
+		if err != nil {
+			e.Progress("Warning: failed to fetch thread %s: %v", parent.TS, err)
+			continue
+		}
+		if len(replies) > 0 {
+			threads = append(threads, streamThread{parent: parent, replies: replies})
+		}
+	}
+	return threads
+}
+
+// writeMarkdownStream writes one day's markdown document followed by the
+// replies of its threads.
+func (e *Exporter) writeMarkdownStream(w io.Writer, conv config.ConversationConfig, date string, msgs []slackapi.Message, threads []streamThread) error {
+	mdWriter := e.markdownWriter(conv)
+	doc, err := mdWriter.RenderDailyDoc(conv.ID, conv.Name, string(conv.Type), date, msgs, nil)
+	if err != nil {
+		return fmt.Errorf("failed to render %s: %w", date, err)
+	}
+	if _, err := w.Write(doc); err != nil {
+		return err
+	}
+	for _, t := range threads {
+		topic := ThreadTopic(t.parent, t.replies, e.userResolver, e.channelResolver, e.personResolver)
+		heading := fmt.Sprintf("### Thread: %s (%s)\n\n", topic, parser.FormatTimestamp(t.parent.TS))
+		if _, err := io.WriteString(w, heading); err != nil {
+			return err
+		}
+		replies, err := mdWriter.RenderMessages(t.replies)
+		if err != nil {
+			return fmt.Errorf("failed to render %s: %w", date, err)
+		}
+		if _, err := w.Write(replies); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeJSONLines writes each message as one line of JSON.
+func writeJSONLines(w io.Writer, msgs []slackapi.Message) error {
+	enc := json.NewEncoder(w)
+	for _, msg := range msgs {
+		if err := enc.Encode(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/models"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+func TestStreamConversation_Markdown(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.mdWriter = NewMarkdownWriter(exp.userResolver, exp.channelResolver, nil)
+
+	var buf bytes.Buffer
+	if err := exp.StreamConversation(context.Background(), &buf, conv, config.OutputFormatMarkdown); err != nil {
+		t.Fatalf("StreamConversation() error: %v", err)
+	}
+	out := buf.String()
+	order := []string{`date: "2024-02-01"`, "Good morning", "Thread starter", "### Thread: Thread starter", "A reply", `date: "2024-02-02"`, "Next day"}
+	pos := 0
+	for _, want := range order {
+		i := strings.Index(out[pos:], want)
+		if i < 0 {
+			t.Fatalf("output missing %q after position %d:\n%s", want, pos, out)
+		}
+		pos += i + len(want)
+	}
+	if n := len(drive.Documents()); n != 0 {
+		t.Errorf("documents = %d, want 0", n)
+	}
+	if exp.index.GetConversation(conv.ID) != nil {
+		t.Error("streaming recorded the conversation in the export index")
+	}
+}
+
+func TestStreamConversation_JSONLines(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.dateTo = "1706831999.000000" // end of 2024-02-01
+
+	var buf bytes.Buffer
+	if err := exp.StreamConversation(context.Background(), &buf, conv, config.OutputFormatJSON); err != nil {
+		t.Fatalf("StreamConversation() error: %v", err)
+	}
+	var texts []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var msg slackapi.Message
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			t.Fatalf("line %q is not a message: %v", line, err)
+		}
+		texts = append(texts, msg.Text)
+	}
+	want := []string{"Good morning", "Thread starter", "A reply"}
+	if strings.Join(texts, "|") != strings.Join(want, "|") {
+		t.Errorf("messages = %q, want %q", texts, want)
+	}
+}
+
+func TestStreamConversation_RejectsFormat(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	if err := exp.StreamConversation(context.Background(), &bytes.Buffer{}, conv, config.OutputFormatHTML); err == nil {
+		t.Error("StreamConversation(html) error = nil")
+	}
+	if n := slack.Calls("GetConversationHistory"); n != 0 {
+		t.Errorf("GetConversationHistory calls = %d, want 0", n)
+	}
+}
+
+func TestDescribeConversation(t *testing.T) {
+	drive, slack, _ := fakeConversation()
+	slack.Conversations = []slackapi.Conversation{{ID: "G001", Name: "secret", IsPrivate: true}}
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+
+	if got := exp.DescribeConversation(context.Background(), "G001"); got.Name != "secret" || got.Type != models.ConversationTypePrivateChannel {
+		t.Errorf("DescribeConversation(G001) = %+v", got)
+	}
+	if got := exp.DescribeConversation(context.Background(), "D404"); got.Name != "D404" || got.ID != "D404" {
+		t.Errorf("DescribeConversation(D404) = %+v, want it named by ID", got)
+	}
+}
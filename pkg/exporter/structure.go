@@ -3,15 +3,23 @@ package exporter
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/errcat"
 	"github.com/jflowers/get-out/pkg/gdrive"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
 )
 
 // FolderStructure manages the Google Drive folder organization for exports.
 type FolderStructure struct {
-	client *gdrive.Client
+	client DriveSink
 	index  *ExportIndex
 
 	// Root folder name in Google Drive (used when creating new folder)
@@ -19,6 +27,21 @@ type FolderStructure struct {
 
 	// Root folder ID in Google Drive (if specified, uses existing folder)
 	rootFolderID string
+
+	// Folder size monitoring
+	warnItems  int
+	autoLayout string
+	onWarning  func(msg string)
+
+	// Template new daily docs are copied from (see FolderStructureConfig)
+	docTemplate string
+
+	mu     sync.Mutex
+	warned map[string]bool // folder IDs already warned about this run
+
+	// convMu serializes conversation folder creation, so conversations
+	// exported in parallel cannot claim the same folder name
+	convMu sync.Mutex
 }
 
 // FolderStructureConfig holds configuration for folder structure.
@@ -29,21 +52,44 @@ type FolderStructureConfig struct {
 	// RootFolderID is an optional existing folder ID to use as the root.
 	// If provided, RootFolderName is ignored and this folder is used directly.
 	RootFolderID string
+
+	// WarnItems is the number of items in one conversation folder at which
+	// OnWarning is called (default config.DefaultFolderWarnItems).
+	WarnItems int
+
+	// AutoLayout, when set to a nested layout, is applied to a conversation
+	// without an explicit layout once its folder reaches WarnItems.
+	AutoLayout config.FolderLayout
+
+	// OnWarning receives folder size warnings.
+	OnWarning func(msg string)
+
+	// DocTemplate is the ID or URL of a Google Doc that new daily docs are
+	// copied from. Empty creates blank docs.
+	DocTemplate string
 }
 
 // NewFolderStructure creates a new folder structure manager.
-func NewFolderStructure(client *gdrive.Client, index *ExportIndex, cfg *FolderStructureConfig) *FolderStructure {
+func NewFolderStructure(client DriveSink, index *ExportIndex, cfg *FolderStructureConfig) *FolderStructure {
 	if cfg == nil {
 		cfg = &FolderStructureConfig{}
 	}
 	if cfg.RootFolderName == "" {
-		cfg.RootFolderName = "Slack Exports"
+		cfg.RootFolderName = DefaultRootFolderName
+	}
+	if cfg.WarnItems <= 0 {
+		cfg.WarnItems = config.DefaultFolderWarnItems
 	}
 	return &FolderStructure{
 		client:         client,
 		index:          index,
 		rootFolderName: cfg.RootFolderName,
 		rootFolderID:   cfg.RootFolderID,
+		warnItems:      cfg.WarnItems,
+		autoLayout:     string(cfg.AutoLayout),
+		onWarning:      cfg.OnWarning,
+		docTemplate:    cfg.DocTemplate,
+		warned:         make(map[string]bool),
 	}
 }
 
@@ -69,7 +115,7 @@ func (fs *FolderStructure) EnsureRootFolder(ctx context.Context) (*gdrive.Folder
 		// Verify the folder exists and get its info
 		folder, err := fs.client.GetFolder(ctx, fs.rootFolderID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to access folder %s: %w", fs.rootFolderID, err)
+			return nil, errcat.FolderAccess(fmt.Errorf("failed to access folder %s: %w", fs.rootFolderID, err))
 		}
 
 		// Update index with the provided folder (use mutex to avoid races with concurrent Save)
@@ -84,7 +130,7 @@ func (fs *FolderStructure) EnsureRootFolder(ctx context.Context) (*gdrive.Folder
 	// Find or create the root folder by name
 	folder, err := fs.client.FindOrCreateFolder(ctx, fs.rootFolderName, "")
 	if err != nil {
-		return nil, fmt.Errorf("failed to create root folder: %w", err)
+		return nil, errcat.FolderAccess(fmt.Errorf("failed to create root folder: %w", err))
 	}
 
 	// Update index (use mutex to avoid races with concurrent Save)
@@ -115,7 +161,12 @@ func ConversationFolderName(convType, name string) string {
 }
 
 // EnsureConversationFolder creates or finds the folder for a conversation.
+// A conversation whose folder name another conversation's folder already
+// has gets its own folder, named with a suffix of its ID.
 func (fs *FolderStructure) EnsureConversationFolder(ctx context.Context, convID, convType, name string) (*ConversationExport, error) {
+	fs.convMu.Lock()
+	defer fs.convMu.Unlock()
+
 	// Check if we already have it
 	conv := fs.index.GetConversation(convID)
 	if conv != nil && conv.FolderID != "" {
@@ -129,7 +180,10 @@ func (fs *FolderStructure) EnsureConversationFolder(ctx context.Context, convID,
 	}
 
 	// Create conversation folder
-	folderName := ConversationFolderName(convType, name)
+	folderName := fs.index.uniqueConversationFolderName(convID, ConversationFolderName(convType, name))
+	if folderName != ConversationFolderName(convType, name) && fs.onWarning != nil {
+		fs.onWarning(fmt.Sprintf("Folder %q belongs to another conversation; exporting %s into %q", ConversationFolderName(convType, name), convID, folderName))
+	}
 	folder, err := fs.client.FindOrCreateFolder(ctx, folderName, root.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create conversation folder: %w", err)
@@ -141,6 +195,7 @@ func (fs *FolderStructure) EnsureConversationFolder(ctx context.Context, convID,
 	}
 	conv.FolderID = folder.ID
 	conv.FolderURL = folder.URL
+	conv.FolderName = folderName
 	conv.Name = name
 	conv.Type = convType
 
@@ -165,10 +220,34 @@ func (fs *FolderStructure) EnsureThreadsFolder(ctx context.Context, convID strin
 		return "", fmt.Errorf("failed to create Threads folder: %w", err)
 	}
 
+	fs.addItem(conv, conv.FolderID)
 	conv.ThreadsFolderID = folder.ID
 	return folder.ID, nil
 }
 
+// EnsureFilesFolder creates or finds the "Files" subfolder for a
+// conversation's downloaded attachments.
+func (fs *FolderStructure) EnsureFilesFolder(ctx context.Context, convID string) (string, error) {
+	conv := fs.index.GetConversation(convID)
+	if conv == nil {
+		return "", fmt.Errorf("conversation not found in index: %s", convID)
+	}
+
+	// Check if we already have it
+	if conv.FilesFolderID != "" {
+		return conv.FilesFolderID, nil
+	}
+
+	folder, err := fs.client.FindOrCreateFolder(ctx, DriveFilesFolder, conv.FolderID)
+	if err != nil {
+		return "", fmt.Errorf("failed to create Files folder: %w", err)
+	}
+
+	fs.addItem(conv, conv.FolderID)
+	conv.FilesFolderID = folder.ID
+	return folder.ID, nil
+}
+
 // EnsureThreadFolder creates or finds a folder for a specific thread.
 func (fs *FolderStructure) EnsureThreadFolder(ctx context.Context, convID, threadTS, topicPreview string) (*ThreadExport, error) {
 	// Check if we already have it
@@ -182,15 +261,23 @@ func (fs *FolderStructure) EnsureThreadFolder(ctx context.Context, convID, threa
 	if err != nil {
 		return nil, err
 	}
+	conv := fs.index.GetConversation(convID)
+	parentID, err := fs.dateFolder(ctx, conv, threadsFolderID, tsToDate(threadTS), &conv.ThreadDateFolders)
+	if err != nil {
+		return nil, err
+	}
 
-	// Generate thread folder name: "YYYY-MM-DD - Topic preview"
-	date := tsToDate(threadTS)
-	folderName := fmt.Sprintf("%s - %s", date, sanitizeFolderName(truncate(topicPreview, 40)))
+	// Another thread started the same day with the same topic gets its
+	// own folder rather than sharing, and merging into, this one.
+	folderName := fs.index.uniqueThreadName(convID, threadTS, ThreadFolderName(threadTS, topicPreview), " (%d)", func(t *ThreadExport) string {
+		return t.FolderName
+	})
 
-	folder, err := fs.client.FindOrCreateFolder(ctx, folderName, threadsFolderID)
+	folder, err := fs.client.FindOrCreateFolder(ctx, folderName, parentID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create thread folder: %w", err)
 	}
+	fs.addItem(conv, parentID)
 
 	// Create or update thread export
 	if thread == nil {
@@ -208,7 +295,139 @@ func (fs *FolderStructure) EnsureThreadFolder(ctx context.Context, convID, threa
 	return thread, nil
 }
 
-// EnsureDailyDoc creates or finds a daily Google Doc for a conversation.
+const (
+	// threadTopicLen is the longest a thread topic may be in thread folder
+	// and directory names.
+	threadTopicLen = 40
+
+	// threadTopicWords is how many words of its message a thread topic keeps.
+	threadTopicWords = 8
+
+	// threadTopicReplies is how many of a thread's first replies ThreadTopic
+	// considers besides the parent.
+	threadTopicReplies = 2
+)
+
+// ThreadTopic returns the topic preview for a thread: the first words of
+// the most meaningful message among the parent and its first replies,
+// followed by the parent's author, e.g. "Deploy plan for billing (Alice)".
+// The most meaningful message is the one with the most words, not
+// counting emoji, links, and punctuation; the parent wins ties. A parent
+// that only says "ok" is thus named after the reply that explains it.
+// replies may include the parent, as Slack returns it. It returns "Thread"
+// when no message has words and the author is unknown.
+func ThreadTopic(parent slackapi.Message, replies []slackapi.Message, users *parser.UserResolver, channels *parser.ChannelResolver, people *parser.PersonResolver) string {
+	candidates := []slackapi.Message{parent}
+	for _, reply := range replies {
+		if len(candidates) > threadTopicReplies {
+			break
+		}
+		if reply.TS != parent.TS {
+			candidates = append(candidates, reply)
+		}
+	}
+	var words []string
+	for _, msg := range candidates {
+		resolvedText, _ := parser.ConvertMrkdwnWithLinks(parser.MessageText(msg), users, channels, people, nil)
+		if w := topicWords(resolvedText); len(w) > len(words) {
+			words = w
+		}
+	}
+	if len(words) > threadTopicWords {
+		words = words[:threadTopicWords]
+	}
+
+	author := threadAuthor(parent, users)
+	if author == "" {
+		if topic := fitWords(words, threadTopicLen); topic != "" {
+			return topic
+		}
+		return "Thread"
+	}
+	suffix := " (" + author + ")"
+	topic := fitWords(words, max(threadTopicLen-len(suffix), 12))
+	if topic == "" {
+		topic = "Thread"
+	}
+	return topic + suffix
+}
+
+// fitWords joins as many of words as fit in maxLen, cutting the first word
+// when it alone is longer.
+func fitWords(words []string, maxLen int) string {
+	if len(words) == 0 {
+		return ""
+	}
+	fitted := truncate(words[0], maxLen)
+	for _, word := range words[1:] {
+		if len(fitted)+1+len(word) > maxLen {
+			break
+		}
+		fitted += " " + word
+	}
+	return fitted
+}
+
+// parentTopic returns the topic preview threads were named with before
+// ThreadTopic considered replies and authors: the parent's text alone.
+func parentTopic(parent slackapi.Message, users *parser.UserResolver, channels *parser.ChannelResolver, people *parser.PersonResolver) string {
+	resolvedText, _ := parser.ConvertMrkdwnWithLinks(parent.Text, users, channels, people, nil)
+	if topic := truncate(resolvedText, threadTopicLen); topic != "" {
+		return topic
+	}
+	return "Thread"
+}
+
+// emojiCodeRe matches an emoji shortcode such as ":tada:".
+var emojiCodeRe = regexp.MustCompile(`^:[a-z0-9_+'-]+:$`)
+
+// topicWords returns the words of text that can name a thread: not emoji
+// shortcodes, links, or words without a letter or digit.
+func topicWords(text string) []string {
+	var words []string
+	for _, word := range strings.Fields(text) {
+		if emojiCodeRe.MatchString(word) || strings.Contains(word, "://") {
+			continue
+		}
+		if strings.IndexFunc(word, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+			continue
+		}
+		words = append(words, word)
+	}
+	return words
+}
+
+// threadAuthor returns the display name of the person who started a
+// thread, or "" when it is not known.
+func threadAuthor(parent slackapi.Message, users *parser.UserResolver) string {
+	if users != nil && parent.User != "" {
+		if name := users.Resolve(parent.User); name != parent.User {
+			return name
+		}
+	}
+	return parent.Username
+}
+
+// ThreadFolderName generates the Drive folder name for a thread:
+// "YYYY-MM-DD - Topic preview".
+func ThreadFolderName(threadTS, topicPreview string) string {
+	return fmt.Sprintf("%s - %s", tsToDate(threadTS), sanitizeFolderName(truncate(topicPreview, threadTopicLen)))
+}
+
+// LocalThreadDir returns the local markdown directory for a thread,
+// relative to the export directory. It mirrors the Drive layout:
+// {conversation}/threads/{YYYY-MM-DD}-{topic}/, holding one file per day.
+func LocalThreadDir(convType, convName, threadTS, topicPreview string) string {
+	name := tsToDate(threadTS)
+	if topic := sanitizeName(truncate(topicPreview, threadTopicLen)); topic != "" {
+		name += "-" + topic
+	}
+	return filepath.Join(SanitizeDirectoryName(convType, convName), "threads", name)
+}
+
+// EnsureDailyDoc creates or finds the Google Doc holding a date of a
+// conversation: a daily doc, or the doc of the date's week or month, or the
+// conversation's single doc, as its granularity sets.
 func (fs *FolderStructure) EnsureDailyDoc(ctx context.Context, convID, date string) (*DocExport, error) {
 	// Check if we already have it
 	doc := fs.index.GetDailyDoc(convID, date)
@@ -221,18 +440,39 @@ func (fs *FolderStructure) EnsureDailyDoc(ctx context.Context, convID, date stri
 		return nil, fmt.Errorf("conversation not found in index: %s", convID)
 	}
 
-	// Create the doc with date as title
-	title := date // e.g., "2026-02-03"
-	gdoc, err := fs.client.FindOrCreateDocument(ctx, title, conv.FolderID)
+	// A conversation with a coarser granularity shares a doc across the
+	// days of a week or month, or across its whole history
+	period := docPeriodOf(conv.granularity(), date)
+	folderID := conv.FolderID
+	if period.start != "" {
+		var err error
+		folderID, err = fs.dateFolder(ctx, conv, conv.FolderID, period.start, &conv.DateFolders)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Create the doc with its period as title, e.g. "2026-02-03"
+	gdoc, err := fs.client.FindOrCreateDocumentFromTemplate(ctx, period.title, folderID, fs.docTemplate)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create daily doc: %w", err)
 	}
+	fs.addItem(conv, folderID)
+
+	// A doc copied from the template, or one found in the folder, already
+	// has content; its end is where the first write starts (see
+	// DocExport.EndIndex). Unknown (0) when it cannot be read.
+	endIndex, err := fs.client.GetDocumentEndIndex(ctx, gdoc.ID)
+	if err != nil {
+		endIndex = 0
+	}
 
 	doc = &DocExport{
-		DocID:  gdoc.ID,
-		DocURL: gdoc.URL,
-		Title:  title,
-		Date:   date,
+		DocID:    gdoc.ID,
+		DocURL:   gdoc.URL,
+		Title:    period.title,
+		Date:     period.start,
+		EndIndex: endIndex,
 	}
 
 	fs.index.SetDailyDoc(convID, date, doc)
@@ -254,7 +494,7 @@ func (fs *FolderStructure) EnsureThreadDailyDoc(ctx context.Context, convID, thr
 
 	// Create the doc
 	title := date
-	gdoc, err := fs.client.FindOrCreateDocument(ctx, title, thread.FolderID)
+	gdoc, err := fs.client.FindOrCreateDocumentFromTemplate(ctx, title, thread.FolderID, fs.docTemplate)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create thread daily doc: %w", err)
 	}
@@ -274,6 +514,113 @@ func (fs *FolderStructure) EnsureThreadDailyDoc(ctx context.Context, convID, thr
 	return doc, nil
 }
 
+// dateFolder returns the folder that a doc or thread folder for date
+// (YYYY-MM-DD) belongs in under parentID: parentID itself with the flat
+// layout, otherwise its year folder ("2024") or month folder
+// ("2024/2024-01"), created on first use and cached in folders.
+func (fs *FolderStructure) dateFolder(ctx context.Context, conv *ConversationExport, parentID, date string, folders *map[string]string) (string, error) {
+	conv.mu.Lock()
+	layout := config.FolderLayout(conv.Layout)
+	conv.mu.Unlock()
+
+	var names []string
+	switch {
+	case layout == config.FolderLayoutYear && len(date) >= 4:
+		names = []string{date[:4]}
+	case layout == config.FolderLayoutMonth && len(date) >= 7:
+		names = []string{date[:4], date[:7]}
+	}
+
+	for _, name := range names {
+		conv.mu.Lock()
+		id := (*folders)[name]
+		conv.mu.Unlock()
+		if id == "" {
+			folder, err := fs.client.FindOrCreateFolder(ctx, name, parentID)
+			if err != nil {
+				return "", fmt.Errorf("failed to create %s folder: %w", name, err)
+			}
+			fs.addItem(conv, parentID)
+			id = folder.ID
+			conv.mu.Lock()
+			if *folders == nil {
+				*folders = make(map[string]string)
+			}
+			(*folders)[name] = id
+			conv.mu.Unlock()
+		}
+		parentID = id
+	}
+	return parentID, nil
+}
+
+// addItem counts a doc or folder created in folderID and warns, once per
+// run, when the folder reaches the warning threshold. When the conversation
+// folder or its Threads folder reaches it and the conversation has no
+// explicit layout, the automatic layout (if configured) is applied so new
+// docs go into date folders.
+func (fs *FolderStructure) addItem(conv *ConversationExport, folderID string) {
+	conv.mu.Lock()
+	if conv.FolderItems == nil {
+		conv.FolderItems = seedFolderItems(conv)
+	}
+	conv.FolderItems[folderID]++
+	n := conv.FolderItems[folderID]
+	switched := n >= fs.warnItems && conv.Layout == "" && fs.autoLayout != "" &&
+		(folderID == conv.FolderID || folderID == conv.ThreadsFolderID)
+	if switched {
+		conv.Layout = fs.autoLayout
+	}
+	name := conv.Name
+	conv.mu.Unlock()
+
+	if n < fs.warnItems || fs.onWarning == nil {
+		return
+	}
+	fs.mu.Lock()
+	warned := fs.warned[folderID]
+	fs.warned[folderID] = true
+	fs.mu.Unlock()
+
+	switch {
+	case switched:
+		fs.onWarning(fmt.Sprintf("Warning: a Drive folder of %s has %d items; new docs now go into %s folders (autoFolderLayout)", name, n, fs.autoLayout))
+	case !warned:
+		fs.onWarning(fmt.Sprintf("Warning: a Drive folder of %s has %d items; Drive lists large folders slowly. Set \"layout\": \"year\" for it in conversations.json", name, n))
+	}
+}
+
+// seedFolderItems estimates the item counts of a conversation exported
+// before counts were tracked, when every doc was in the conversation
+// folder. Caller must hold conv.mu.
+func seedFolderItems(conv *ConversationExport) map[string]int {
+	items := make(map[string]int)
+	if conv.FolderID != "" && len(conv.DateFolders) == 0 {
+		items[conv.FolderID] = len(conv.DailyDocs)
+		if conv.ThreadsFolderID != "" {
+			items[conv.FolderID]++
+		}
+	}
+	if conv.ThreadsFolderID != "" && len(conv.ThreadDateFolders) == 0 {
+		items[conv.ThreadsFolderID] = len(conv.Threads)
+	}
+	return items
+}
+
+// LargestFolder returns the item count of the conversation's fullest Drive
+// folder, as counted in FolderItems.
+func (c *ConversationExport) LargestFolder() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	largest := 0
+	for _, n := range c.FolderItems {
+		if n > largest {
+			largest = n
+		}
+	}
+	return largest
+}
+
 // GetDocForMessage returns the appropriate doc for a message based on its timestamp.
 func (fs *FolderStructure) GetDocForMessage(ctx context.Context, convID, messageTS string, isThread bool, threadTS string) (*DocExport, error) {
 	date := tsToDate(messageTS)
@@ -328,16 +675,10 @@ func truncate(s string, maxLen int) string {
 	return s[:maxLen-3] + "..."
 }
 
-// TSToTime converts a Slack timestamp to time.Time.
+// TSToTime converts a Slack timestamp to time.Time in the export's time
+// zone.
 func TSToTime(ts string) time.Time {
-	var sec int64
-	for i := 0; i < len(ts); i++ {
-		if ts[i] == '.' {
-			break
-		}
-		sec = sec*10 + int64(ts[i]-'0')
-	}
-	return time.Unix(sec, 0)
+	return parser.SlackTime(ts)
 }
 
 // DateFromTS extracts the date string from a Slack timestamp.
@@ -1,8 +1,16 @@
 package exporter
 
 import (
+	"context"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/jflowers/get-out/internal/testutil"
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/gdrive"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
 )
 
 func TestConversationFolderName(t *testing.T) {
@@ -30,6 +38,57 @@ func TestConversationFolderName(t *testing.T) {
 	}
 }
 
+func TestThreadNaming(t *testing.T) {
+	ts := "1700000000.000100"
+	date := DateFromTS(ts)
+
+	if got, want := ThreadFolderName(ts, "Launch plan: v2?"), date+" - Launch plan- v2"; got != want {
+		t.Errorf("ThreadFolderName() = %q, want %q", got, want)
+	}
+	if got, want := LocalThreadDir("channel", "general", ts, "Launch plan: v2?"), filepath.Join("channel-general", "threads", date+"-launch-plan-v2"); got != want {
+		t.Errorf("LocalThreadDir() = %q, want %q", got, want)
+	}
+	if got, want := LocalThreadDir("dm", "Alice", ts, "!!!"), filepath.Join("dm-alice", "threads", date); got != want {
+		t.Errorf("LocalThreadDir() with empty topic = %q, want %q", got, want)
+	}
+}
+
+func TestThreadTopic(t *testing.T) {
+	users := parser.NewUserResolver()
+	users.AddUser(&slackapi.User{ID: "U001", Name: "alice", Profile: slackapi.UserProfile{DisplayName: "Alice"}})
+	parent := slackapi.Message{User: "U001", Text: "ok", TS: "1.000100"}
+	replies := []slackapi.Message{
+		parent,
+		{User: "U002", Text: "thanks :tada:", TS: "1.000200"},
+		{User: "U003", Text: "The deploy plan for the new billing service is in the doc <https://example.com|here>", TS: "1.000300"},
+		{User: "U004", Text: "A much longer reply that comes too late to be considered for the topic", TS: "1.000400"},
+	}
+
+	tests := []struct {
+		name    string
+		parent  slackapi.Message
+		replies []slackapi.Message
+		users   *parser.UserResolver
+		want    string
+	}{
+		{"parent only", slackapi.Message{Text: "Ship it"}, nil, nil, "Ship it"},
+		{"empty", slackapi.Message{}, nil, nil, "Thread"},
+		{"author", slackapi.Message{User: "U001", Text: "Ship it"}, nil, users, "Ship it (Alice)"},
+		{"author only", slackapi.Message{User: "U001", Text: ":+1:"}, nil, users, "Thread (Alice)"},
+		{"bot author", slackapi.Message{Username: "deploybot", Text: "Deployed v2"}, nil, nil, "Deployed v2 (deploybot)"},
+		{"unknown author", slackapi.Message{User: "U999", Text: "Ship it"}, nil, users, "Ship it"},
+		{"longest reply", parent, replies, users, "The deploy plan for the new (Alice)"},
+		{"parent wins ties", slackapi.Message{Text: "Two words", TS: "1"}, []slackapi.Message{{Text: "Other two", TS: "2"}}, nil, "Two words"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ThreadTopic(tt.parent, tt.replies, tt.users, nil, nil); got != tt.want {
+				t.Errorf("ThreadTopic() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestSanitizeFolderName(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -87,3 +146,214 @@ func TestTruncate(t *testing.T) {
 		})
 	}
 }
+
+func TestExportConversation_YearLayout(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	conv.Layout = config.FolderLayoutYear
+	slack.Messages["C001"] = append([]slackapi.Message{{User: "U001", Text: "Last year", TS: "1703980800.000100"}}, slack.Messages["C001"]...) // 2023-12-31
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	ce := exp.index.GetConversation("C001")
+	if ce.Layout != "year" || len(ce.DateFolders) != 2 {
+		t.Fatalf("Layout = %q, DateFolders = %v; want year layout with 2023 and 2024", ce.Layout, ce.DateFolders)
+	}
+	for date, doc := range ce.DailyDocs {
+		folder := drive.DocumentFolder(doc.DocID)
+		if folder != ce.DateFolders[date[:4]] || drive.FolderName(folder) != date[:4] {
+			t.Errorf("doc %s is in folder %q (%s), want the %s folder", date, folder, drive.FolderName(folder), date[:4])
+		}
+	}
+
+	thread := exp.index.GetThread("C001", "1706792400.000200")
+	if thread == nil || len(ce.ThreadDateFolders) != 1 {
+		t.Fatalf("thread = %+v, ThreadDateFolders = %v", thread, ce.ThreadDateFolders)
+	}
+	if got := drive.FolderName(ce.ThreadDateFolders["2024"]); got != "2024" {
+		t.Errorf("thread year folder name = %q, want 2024", got)
+	}
+}
+
+func TestFolderStructure_FolderItemWarnings(t *testing.T) {
+	newStructure := func(auto config.FolderLayout) (*FolderStructure, *ExportIndex, *testutil.FakeDrive, *[]string) {
+		drive := testutil.NewFakeDrive()
+		idx := NewExportIndex("")
+		conv := idx.GetOrCreateConversation("C001", "general", "channel")
+		conv.FolderID = "conv-folder"
+		var warnings []string
+		fs := NewFolderStructure(drive, idx, &FolderStructureConfig{
+			WarnItems:  2,
+			AutoLayout: auto,
+			OnWarning:  func(msg string) { warnings = append(warnings, msg) },
+		})
+		return fs, idx, drive, &warnings
+	}
+	dates := []string{"2024-01-01", "2024-01-02", "2024-01-03", "2024-01-04"}
+
+	t.Run("warns once", func(t *testing.T) {
+		fs, idx, _, warnings := newStructure("")
+		for _, date := range dates {
+			if _, err := fs.EnsureDailyDoc(context.Background(), "C001", date); err != nil {
+				t.Fatal(err)
+			}
+		}
+		conv := idx.GetConversation("C001")
+		if conv.FolderItems["conv-folder"] != 4 || conv.LargestFolder() != 4 {
+			t.Errorf("FolderItems = %v, want 4 in the conversation folder", conv.FolderItems)
+		}
+		if len(*warnings) != 1 || !strings.Contains((*warnings)[0], `"layout": "year"`) {
+			t.Errorf("warnings = %q, want one suggesting the year layout", *warnings)
+		}
+	})
+
+	t.Run("auto layout", func(t *testing.T) {
+		fs, idx, drive, warnings := newStructure(config.FolderLayoutMonth)
+		var docs []*DocExport
+		for _, date := range dates[:3] {
+			doc, err := fs.EnsureDailyDoc(context.Background(), "C001", date)
+			if err != nil {
+				t.Fatal(err)
+			}
+			docs = append(docs, doc)
+		}
+		conv := idx.GetConversation("C001")
+		if conv.Layout != "month" {
+			t.Fatalf("Layout = %q, want month after reaching the threshold", conv.Layout)
+		}
+		if got := drive.DocumentFolder(docs[1].DocID); got != "conv-folder" {
+			t.Errorf("doc before the switch is in %q, want the conversation folder", got)
+		}
+		if got := drive.FolderName(drive.DocumentFolder(docs[2].DocID)); got != "2024-01" {
+			t.Errorf("doc after the switch is in %q, want the 2024-01 folder", got)
+		}
+		if len(*warnings) != 1 || !strings.Contains((*warnings)[0], "month folders") {
+			t.Errorf("warnings = %q, want one announcing the switch", *warnings)
+		}
+	})
+
+	t.Run("seeds counts for older indexes", func(t *testing.T) {
+		fs, idx, _, _ := newStructure("")
+		conv := idx.GetConversation("C001")
+		conv.DailyDocs["2023-12-31"] = &DocExport{DocID: "old"}
+		conv.ThreadsFolderID = "threads-folder"
+		if _, err := fs.EnsureDailyDoc(context.Background(), "C001", dates[0]); err != nil {
+			t.Fatal(err)
+		}
+		if conv.FolderItems["conv-folder"] != 3 {
+			t.Errorf("FolderItems = %v, want the old doc, Threads, and the new doc", conv.FolderItems)
+		}
+	})
+}
+
+func TestEnsureThreadFolder_TopicCollision(t *testing.T) {
+	drive := testutil.NewFakeDrive()
+	idx := NewExportIndex("")
+	conv := idx.GetOrCreateConversation("C001", "general", "channel")
+	conv.FolderID = "conv-folder"
+	fs := NewFolderStructure(drive, idx, nil)
+	ctx := context.Background()
+
+	first, err := fs.EnsureThreadFolder(ctx, "C001", "1700000000.000100", "ok (Alice)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := fs.EnsureThreadFolder(ctx, "C001", "1700000100.000100", "ok (Alice)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := DateFromTS("1700000000.000100")
+	if first.FolderName != date+" - ok (Alice)" || second.FolderName != date+" - ok (Alice) (2)" {
+		t.Errorf("folder names = %q, %q; want the second suffixed", first.FolderName, second.FolderName)
+	}
+	if first.FolderID == second.FolderID {
+		t.Error("threads with the same topic share a folder")
+	}
+
+	again, err := fs.EnsureThreadFolder(ctx, "C001", "1700000000.000100", "ok (Alice)")
+	if err != nil || again.FolderID != first.FolderID {
+		t.Errorf("EnsureThreadFolder() again = %+v, %v; want the first thread's folder", again, err)
+	}
+}
+
+func TestEnsureConversationFolder_NameCollision(t *testing.T) {
+	drive := testutil.NewFakeDrive()
+	idx := NewExportIndex("")
+	var warnings []string
+	fs := NewFolderStructure(drive, idx, &FolderStructureConfig{OnWarning: func(msg string) { warnings = append(warnings, msg) }})
+	ctx := context.Background()
+
+	first, err := fs.EnsureConversationFolder(ctx, "D01AAAAAAAA", "dm", "John Smith")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := fs.EnsureConversationFolder(ctx, "D02BBBBBBBB", "dm", "John Smith")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.FolderName != "DM - John Smith" || second.FolderName != "DM - John Smith (BBBBB)" {
+		t.Errorf("folder names = %q, %q; want the second suffixed with its ID", first.FolderName, second.FolderName)
+	}
+	if first.FolderID == second.FolderID {
+		t.Error("DMs with the same name share a folder")
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "D02BBBBBBBB") {
+		t.Errorf("warnings = %q, want one naming the suffixed conversation", warnings)
+	}
+
+	// Conversations exported before folder names were recorded are
+	// matched by the name their type and name give.
+	idx.GetConversation("D01AAAAAAAA").FolderName = ""
+	third, err := fs.EnsureConversationFolder(ctx, "D03CCCCCBBBBB", "dm", "John Smith")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third.FolderName != "DM - John Smith (D03CCCCCBBBBB)" {
+		t.Errorf("third folder name = %q, want the full ID once the short one is taken", third.FolderName)
+	}
+}
+
+func TestEnsureDailyDoc_Template(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+	}{
+		{"blank", ""},
+		{"from template", "template-doc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drive := testutil.NewFakeDrive()
+			if tt.template != "" {
+				tmpl, _ := drive.FindOrCreateDocument(context.Background(), "Template", "")
+				tt.template = tmpl.ID
+				_ = drive.BatchAppendMessages(context.Background(), tmpl.ID, []gdrive.MessageBlock{{Content: "Exported from Slack"}})
+			}
+			idx := NewExportIndex("")
+			conv := idx.GetOrCreateConversation("C001", "general", "channel")
+			conv.FolderID = "conv-folder"
+			conv.Threads["1700000000.000100"] = &ThreadExport{ThreadTS: "1700000000.000100", FolderID: "thread-folder"}
+			fs := NewFolderStructure(drive, idx, &FolderStructureConfig{DocTemplate: tt.template})
+			ctx := context.Background()
+
+			day, err := fs.EnsureDailyDoc(ctx, "C001", "2023-11-14")
+			if err != nil {
+				t.Fatalf("EnsureDailyDoc() error: %v", err)
+			}
+			threadDay, err := fs.EnsureThreadDailyDoc(ctx, "C001", "1700000000.000100", "2023-11-14")
+			if err != nil {
+				t.Fatalf("EnsureThreadDailyDoc() error: %v", err)
+			}
+			for _, doc := range []*DocExport{day, threadDay} {
+				if got := drive.DocumentTemplate(doc.DocID); got != tt.template {
+					t.Errorf("doc %s template = %q, want %q", doc.DocID, got, tt.template)
+				}
+			}
+			// The first write starts after whatever the copy holds.
+			if end, _ := drive.GetDocumentEndIndex(ctx, day.DocID); day.EndIndex != end || (tt.template != "") != (end > 1) {
+				t.Errorf("EndIndex = %d, want the new doc's end %d", day.EndIndex, end)
+			}
+		})
+	}
+}
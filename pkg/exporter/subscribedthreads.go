@@ -0,0 +1,173 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// exportSubscribedThreads writes each thread the exporting user follows in
+// Slack's Threads view into its own doc in the My Threads folder, so
+// discussions the user took part in are kept even when the conversations
+// they were posted in are not exported. Threads of conversations in the
+// export index are skipped: their thread folders already hold them. Each
+// run appends only replies posted since the last; a thread that fails is
+// reported and the others are still written.
+func (e *Exporter) exportSubscribedThreads(ctx context.Context, activity *ActivityIndex, result *ActivityResult) error {
+	threads, err := e.listSubscribedThreads(ctx)
+	if err != nil {
+		return activityListError(slackapi.MethodThreadsView, err)
+	}
+
+	feed := activity.feed(ActivityThreads)
+	var todo []slackapi.ThreadRoot
+	exported := 0
+	for _, root := range threads {
+		if e.index.GetConversation(root.Channel) != nil {
+			exported++
+			continue
+		}
+		if doc := feed.Threads[subscribedThreadKey(root)]; doc != nil && root.LatestReply != "" && doc.LastMessageTS >= root.LatestReply {
+			continue
+		}
+		todo = append(todo, root)
+	}
+	e.Detail("Found %d followed threads: %d with new replies, %d in exported conversations", len(threads), len(todo), exported)
+	if len(todo) == 0 {
+		e.Progress("No new %s", ActivityThreads)
+		result.FolderURL = feed.FolderURL
+		return nil
+	}
+
+	folderID, err := e.ensureActivityFolder(ctx, activity, ActivityThreads)
+	if err != nil {
+		return err
+	}
+	result.FolderURL = feed.FolderURL
+
+	items := make([]activityItem, len(todo))
+	for i, root := range todo {
+		items[i] = activityItem{channelID: root.Channel}
+	}
+	e.loadActivityChannels(ctx, items)
+
+	type fetched struct {
+		msgs []slackapi.Message
+		err  error
+	}
+	var errs []error
+	pipeline(e.fetches, len(todo), func(i int) fetched {
+		msgs, err := e.fetchReplies(ctx, todo[i].Channel, todo[i].TS)
+		return fetched{msgs, err}
+	}, func(i int, f fetched) bool {
+		if ctx.Err() != nil {
+			return false
+		}
+		err := f.err
+		if err == nil {
+			err = e.writeSubscribedThread(ctx, activity, folderID, todo[i], f.msgs, result)
+		}
+		if err != nil {
+			err = fmt.Errorf("thread %s in %s: %w", todo[i].TS, e.activityConversationLabel(todo[i].Channel), err)
+			e.Progress("Warning: %v", err)
+			errs = append(errs, err)
+		}
+		return true
+	})
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return errors.Join(errs...)
+}
+
+// listSubscribedThreads returns every thread in the exporting user's
+// Threads view, most recently active first.
+func (e *Exporter) listSubscribedThreads(ctx context.Context) ([]slackapi.ThreadRoot, error) {
+	var all []slackapi.ThreadRoot
+	seen := make(map[string]bool)
+	before := ""
+	for {
+		resp, err := e.slackClient.ListSubscribedThreads(ctx, before)
+		if err != nil {
+			return nil, err
+		}
+		added := 0
+		for _, t := range resp.Threads {
+			key := subscribedThreadKey(t.RootMsg)
+			if t.RootMsg.Channel == "" || t.RootMsg.TS == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			all = append(all, t.RootMsg)
+			added++
+		}
+		// Stop when a page adds nothing, so a cursor Slack does not
+		// advance cannot loop forever.
+		if !resp.HasMore || added == 0 {
+			return all, nil
+		}
+		last := all[len(all)-1]
+		before = orDefault(last.LatestReply, last.TS)
+	}
+}
+
+// writeSubscribedThread appends the thread's messages not yet in its doc,
+// creating the doc on the first run, and saves the activity index.
+func (e *Exporter) writeSubscribedThread(ctx context.Context, activity *ActivityIndex, folderID string, root slackapi.ThreadRoot, msgs []slackapi.Message, result *ActivityResult) error {
+	feed := activity.feed(ActivityThreads)
+	key := subscribedThreadKey(root)
+	doc := feed.Threads[key]
+
+	var fresh []slackapi.Message
+	for _, m := range msgs {
+		if doc == nil || m.TS > doc.LastMessageTS {
+			fresh = append(fresh, m)
+		}
+	}
+	if len(fresh) == 0 {
+		return nil
+	}
+	e.loadMessageAuthors(ctx, fresh)
+	e.loadMentionedChannels(ctx, fresh)
+
+	if doc == nil || doc.DocID == "" {
+		var replies []slackapi.Message
+		for _, m := range msgs {
+			if m.TS != root.TS {
+				replies = append(replies, m)
+			}
+		}
+		topic := ThreadTopic(root.Message, replies, e.userResolver, e.channelResolver, e.personResolver)
+		title := ThreadFolderName(root.TS, topic) + " in " + e.activityConversationLabel(root.Channel)
+		gdoc, err := e.gdriveClient.FindOrCreateDocumentFromTemplate(ctx, title, folderID, e.docTemplate)
+		if err != nil {
+			return fmt.Errorf("failed to create thread doc: %w", err)
+		}
+		doc = &DocExport{DocID: gdoc.ID, DocURL: gdoc.URL, Title: title}
+		feed.Threads[key] = doc
+		result.DocsCreated++
+	}
+
+	blocks := e.docWriter.BuildBlocks(ctx, root.Channel, folderID, fresh)
+	if len(blocks) > 0 {
+		if err := e.gdriveClient.BatchAppendMessages(ctx, doc.DocID, blocks); err != nil {
+			return fmt.Errorf("failed to write thread: %w", err)
+		}
+	}
+
+	doc.LastMessageTS = fresh[len(fresh)-1].TS
+	doc.MessageCount += len(fresh)
+	result.Messages += len(fresh)
+	e.addMessages(len(fresh))
+	if err := activity.Save(); err != nil {
+		e.Progress("Warning: %v", err)
+	}
+	return nil
+}
+
+// subscribedThreadKey returns the ActivityFeed.Threads key of a thread.
+func subscribedThreadKey(root slackapi.ThreadRoot) string {
+	return root.Channel + "/" + root.TS
+}
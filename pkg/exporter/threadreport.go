@@ -0,0 +1,139 @@
+package exporter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// threadParticipants merges the authors of msgs into existing and returns
+// the sorted, de-duplicated set.
+func threadParticipants(existing []string, msgs []slackapi.Message) []string {
+	seen := make(map[string]bool, len(existing))
+	for _, id := range existing {
+		seen[id] = true
+	}
+	for _, msg := range msgs {
+		if msg.User != "" {
+			seen[msg.User] = true
+		}
+	}
+	ids := make([]string, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// ThreadParticipation describes one exported thread a user started or
+// replied to.
+type ThreadParticipation struct {
+	ConversationID   string
+	ConversationName string
+	ConversationType string
+	ThreadTS         string
+	Topic            string
+	URL              string
+	Started          bool
+	ReplyCount       int
+	LastActivityTS   string
+}
+
+// ThreadsForUser returns every exported thread that userID started or
+// posted in, most recently active first.
+func (idx *ExportIndex) ThreadsForUser(userID string) []ThreadParticipation {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var result []ThreadParticipation
+	for _, conv := range idx.Conversations {
+		conv.mu.Lock()
+		for ts, thread := range conv.Threads {
+			started := thread.StartedBy == userID
+			if !started && !containsString(thread.Participants, userID) {
+				continue
+			}
+			last := thread.LastReplyTS
+			if last == "" {
+				last = ts
+			}
+			result = append(result, ThreadParticipation{
+				ConversationID:   conv.ID,
+				ConversationName: conv.Name,
+				ConversationType: conv.Type,
+				ThreadTS:         ts,
+				Topic:            thread.FolderName,
+				URL:              thread.FolderURL,
+				Started:          started,
+				ReplyCount:       thread.ReplyCount,
+				LastActivityTS:   last,
+			})
+		}
+		conv.mu.Unlock()
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].LastActivityTS != result[j].LastActivityTS {
+			return result[i].LastActivityTS > result[j].LastActivityTS
+		}
+		return result[i].ThreadTS > result[j].ThreadTS
+	})
+	return result
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderThreadReport renders a markdown report of the threads a person
+// started or replied to, with links, sizes, and last activity.
+func RenderThreadReport(name string, threads []ThreadParticipation) []byte {
+	started := 0
+	for _, t := range threads {
+		if t.Started {
+			started++
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Threads for %s\n\n", name)
+	fmt.Fprintf(&b, "%d threads (%d started, %d replied to)\n", len(threads), started, len(threads)-started)
+	if len(threads) == 0 {
+		return []byte(b.String())
+	}
+
+	b.WriteString("\n| Last activity | Conversation | Thread | Role | Messages |\n")
+	b.WriteString("|---|---|---|---|---|\n")
+	for _, t := range threads {
+		role := "replied"
+		if t.Started {
+			role = "started"
+		}
+		topic := escapeTableCell(t.Topic)
+		if topic == "" {
+			topic = t.ThreadTS
+		}
+		if t.URL != "" {
+			topic = fmt.Sprintf("[%s](%s)", topic, t.URL)
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %d |\n",
+			TSToTime(t.LastActivityTS).Format("2006-01-02 15:04"),
+			escapeTableCell(t.ConversationName), topic, role, t.ReplyCount)
+	}
+	return []byte(b.String())
+}
+
+// escapeTableCell makes s safe to place inside a markdown table cell.
+func escapeTableCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	s = strings.ReplaceAll(s, "\n", " ")
+	return s
+}
@@ -0,0 +1,71 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+func TestThreadParticipants(t *testing.T) {
+	got := threadParticipants([]string{"U003"}, []slackapi.Message{
+		{User: "U002"}, {User: "U001"}, {User: "U002"}, {BotID: "B001"},
+	})
+	if strings.Join(got, ",") != "U001,U002,U003" {
+		t.Errorf("threadParticipants() = %v", got)
+	}
+}
+
+func TestExportIndex_ThreadsForUser(t *testing.T) {
+	idx := NewExportIndex("")
+	general := idx.GetOrCreateConversation("C001", "general", "channel")
+	general.Threads["1700000000.000100"] = &ThreadExport{
+		ThreadTS: "1700000000.000100", FolderName: "Started by me", FolderURL: "https://drive/1",
+		StartedBy: "U001", Participants: []string{"U001", "U002"}, ReplyCount: 4, LastReplyTS: "1700000500.000100",
+	}
+	general.Threads["1700001000.000100"] = &ThreadExport{
+		ThreadTS: "1700001000.000100", FolderName: "Someone else's",
+		StartedBy: "U002", Participants: []string{"U002", "U003"}, ReplyCount: 2, LastReplyTS: "1700001100.000100",
+	}
+	dm := idx.GetOrCreateConversation("D001", "bob", "dm")
+	dm.Threads["1700002000.000100"] = &ThreadExport{
+		ThreadTS: "1700002000.000100", FolderName: "Replied",
+		StartedBy: "U002", Participants: []string{"U001", "U002"}, ReplyCount: 3,
+	}
+
+	threads := idx.ThreadsForUser("U001")
+	if len(threads) != 2 {
+		t.Fatalf("got %d threads, want 2", len(threads))
+	}
+	if threads[0].ConversationName != "bob" || threads[0].Started {
+		t.Errorf("threads[0] = %+v, want the more recent reply in bob", threads[0])
+	}
+	if threads[0].LastActivityTS != "1700002000.000100" {
+		t.Errorf("LastActivityTS = %q, want thread ts when no replies recorded", threads[0].LastActivityTS)
+	}
+	if !threads[1].Started || threads[1].ReplyCount != 4 || threads[1].URL != "https://drive/1" {
+		t.Errorf("threads[1] = %+v", threads[1])
+	}
+}
+
+func TestRenderThreadReport(t *testing.T) {
+	out := string(RenderThreadReport("Alice", []ThreadParticipation{
+		{ConversationName: "general", ThreadTS: "1700000000.000100", Topic: "a|b", URL: "https://drive/1", Started: true, ReplyCount: 4, LastActivityTS: "1700000500.000100"},
+		{ConversationName: "bob", ThreadTS: "1700002000.000100", ReplyCount: 3, LastActivityTS: "1700002000.000100"},
+	}))
+	for _, want := range []string{
+		"# Threads for Alice",
+		"2 threads (1 started, 1 replied to)",
+		"| general | [a\\|b](https://drive/1) | started | 4 |",
+		"| bob | 1700002000.000100 | replied | 3 |",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	empty := string(RenderThreadReport("Alice", nil))
+	if strings.Contains(empty, "|") {
+		t.Errorf("empty report should have no table:\n%s", empty)
+	}
+}
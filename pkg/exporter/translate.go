@@ -0,0 +1,243 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// Translator translates the text of one message, in Slack mrkdwn, into the
+// archive's working language. Returning the text unchanged, or "", means
+// it needs no translation.
+type Translator interface {
+	Translate(ctx context.Context, text string) (string, error)
+}
+
+// TargetLanguageEnv names the environment variable that gives a
+// CommandTranslator's command the language to translate into.
+const TargetLanguageEnv = "GET_OUT_TARGET_LANGUAGE"
+
+// CommandTranslator implements Translator by running an external command
+// per message, with the text on stdin and the target language in
+// TargetLanguageEnv; the translation is read from stdout.
+type CommandTranslator struct {
+	args    []string
+	target  string
+	timeout time.Duration
+}
+
+// NewCommandTranslator creates a CommandTranslator running args, each
+// translation limited to timeout.
+func NewCommandTranslator(args []string, target string, timeout time.Duration) *CommandTranslator {
+	return &CommandTranslator{args: args, target: target, timeout: timeout}
+}
+
+// Translate runs the command on text.
+func (t *CommandTranslator) Translate(ctx context.Context, text string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, t.args[0], t.args[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Env = append(os.Environ(), TargetLanguageEnv+"="+t.target)
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("translation command failed: %w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("translation command failed: %w", err)
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
+
+// HTTPTranslator implements Translator with an HTTP API that receives a
+// POST of {"text": ..., "target": ...} and answers {"text": ...}.
+type HTTPTranslator struct {
+	url        string
+	target     string
+	httpClient *http.Client
+}
+
+// NewHTTPTranslator creates an HTTPTranslator posting to url, each request
+// limited to timeout.
+func NewHTTPTranslator(url, target string, timeout time.Duration) *HTTPTranslator {
+	return &HTTPTranslator{url: url, target: target, httpClient: &http.Client{Timeout: timeout}}
+}
+
+// translationPayload is the request and response body of an HTTPTranslator.
+type translationPayload struct {
+	Text   string `json:"text"`
+	Target string `json:"target,omitempty"`
+}
+
+// Translate posts text to the API.
+func (t *HTTPTranslator) Translate(ctx context.Context, text string) (string, error) {
+	data, err := json.Marshal(translationPayload{Text: text, Target: t.target})
+	if err != nil {
+		return "", fmt.Errorf("translation: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("translation: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("translation: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("translation: unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var out translationPayload
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("translation: failed to decode response: %w", err)
+	}
+	return out.Text, nil
+}
+
+// NewTranslator returns the translator configured by cfg, or nil when
+// translation is disabled. cfg is expected to have passed settings
+// validation.
+func NewTranslator(cfg *config.TranslationConfig) Translator {
+	if cfg == nil || !cfg.Enabled {
+		return nil
+	}
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = config.DefaultTranslationTimeoutSeconds * time.Second
+	}
+	if len(cfg.Command) > 0 {
+		return NewCommandTranslator(cfg.Command, cfg.TargetLanguage, timeout)
+	}
+	return NewHTTPTranslator(cfg.URL, cfg.TargetLanguage, timeout)
+}
+
+// translation is the translated text of a message, "" when the translator
+// left it unchanged or failed, and the text it was made from.
+type translation struct {
+	original string
+	text     string
+}
+
+// translateMessages returns msgs with the text of each message replaced
+// by its translation, followed by the original as a quote. Messages the
+// translator leaves unchanged keep their text. A failed translation is
+// counted in result and the message keeps its original text; only the
+// first failure of a batch is reported. The json, slack, and sqlite formats
+// keep Slack's data as it is and are not translated. msgs is not modified.
+//
+// Messages are translated as they are written, after the sensitivity
+// filter, so messages it holds back from local files are never sent to
+// the translator. Each message of a conversation is translated once per
+// export, however many outputs (docs, markdown, digest) it is written to.
+func (e *Exporter) translateMessages(ctx context.Context, conv config.ConversationConfig, msgs []slackapi.Message, result *ExportResult) []slackapi.Message {
+	if e.translator == nil || len(msgs) == 0 {
+		return msgs
+	}
+	if f := conv.OutputFormat(); f == config.OutputFormatJSON || f == config.OutputFormatSlack || f == config.OutputFormatSQLite {
+		return msgs
+	}
+
+	translated := make([]slackapi.Message, len(msgs))
+	failed := 0
+	for i, msg := range msgs {
+		translated[i] = msg
+		original := parser.MessageText(msg)
+		if strings.TrimSpace(original) == "" || ctx.Err() != nil {
+			continue
+		}
+		text, ok := e.cachedTranslation(conv.ID, msg.TS, original)
+		if !ok {
+			var err error
+			text, err = e.translator.Translate(ctx, original)
+			if err != nil {
+				if ctx.Err() != nil {
+					continue
+				}
+				if failed == 0 {
+					e.Progress("Warning: failed to translate message %s: %v", msg.TS, err)
+				}
+				failed++
+				e.cacheTranslation(conv.ID, msg.TS, translation{original: original})
+				continue
+			}
+			if text = strings.TrimSpace(text); text == strings.TrimSpace(original) {
+				text = ""
+			}
+			e.cacheTranslation(conv.ID, msg.TS, translation{original: original, text: text})
+			if text != "" {
+				result.MessagesTranslated++
+			}
+		}
+		if text == "" {
+			continue
+		}
+		// The translation replaces the blocks it was made from.
+		translated[i].Text = withOriginal(text, original)
+		translated[i].Blocks = nil
+	}
+	if failed > 1 {
+		e.Progress("Warning: %d messages of %s could not be translated and keep their original text", failed, conv.Name)
+	}
+	result.TranslationErrors += failed
+	return translated
+}
+
+// cachedTranslation returns the translation made earlier in this export of
+// the message ts of convID, if its text has not changed since.
+func (e *Exporter) cachedTranslation(convID, ts, original string) (string, bool) {
+	e.translationsMu.Lock()
+	defer e.translationsMu.Unlock()
+	t, ok := e.translations[convID][ts]
+	if !ok || t.original != original {
+		return "", false
+	}
+	return t.text, true
+}
+
+// cacheTranslation records the translation of the message ts of convID.
+func (e *Exporter) cacheTranslation(convID, ts string, t translation) {
+	e.translationsMu.Lock()
+	defer e.translationsMu.Unlock()
+	if e.translations == nil {
+		e.translations = make(map[string]map[string]translation)
+	}
+	if e.translations[convID] == nil {
+		e.translations[convID] = make(map[string]translation)
+	}
+	e.translations[convID][ts] = t
+}
+
+// forgetTranslations drops the translations of convID once it is exported.
+func (e *Exporter) forgetTranslations(convID string) {
+	e.translationsMu.Lock()
+	delete(e.translations, convID)
+	e.translationsMu.Unlock()
+}
+
+// withOriginal returns a translation followed by the original mrkdwn text
+// as a quote under an "Original:" label.
+func withOriginal(translation, original string) string {
+	lines := strings.Split(strings.TrimSpace(original), "\n")
+	for i, line := range lines {
+		lines[i] = "&gt; " + line
+	}
+	return translation + "\n\n_Original:_\n" + strings.Join(lines, "\n")
+}
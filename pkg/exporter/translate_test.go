@@ -0,0 +1,163 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/config"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// upperTranslator "translates" by upper-casing, leaving text that is
+// already upper case unchanged, and fails on texts containing "fail".
+type upperTranslator struct{}
+
+func (upperTranslator) Translate(_ context.Context, text string) (string, error) {
+	if strings.Contains(text, "fail") {
+		return "", errors.New("translator unavailable")
+	}
+	return strings.ToUpper(text), nil
+}
+
+func TestExportConversation_Translation(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	conv.Format = config.OutputFormatMarkdown
+	slack.Messages["C001"][2].Text = "please fail"
+	exp, localDir := localFormatExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.translator = upperTranslator{}
+
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	// Two main messages and the thread's reply, the parent translated
+	// once for both files it is in; one failure.
+	if result.MessagesTranslated != 3 || result.TranslationErrors != 1 {
+		t.Errorf("MessagesTranslated = %d, TranslationErrors = %d, want 3 and 1", result.MessagesTranslated, result.TranslationErrors)
+	}
+
+	dir := SanitizeDirectoryName(string(conv.Type), conv.Name)
+	day := readFile(t, filepath.Join(localDir, dir, "2024-02-01.md"))
+	if !strings.Contains(day, "GOOD MORNING\n\n*Original:*\n> Good morning") {
+		t.Errorf("day file does not keep the original beneath the translation:\n%s", day)
+	}
+	next := readFile(t, filepath.Join(localDir, dir, "2024-02-02.md"))
+	if !strings.Contains(next, "please fail") || strings.Contains(next, "Original") {
+		t.Errorf("failed translation should keep the original text alone:\n%s", next)
+	}
+}
+
+// recordingTranslator upper-cases text and records what it was given.
+type recordingTranslator struct{ texts []string }
+
+func (r *recordingTranslator) Translate(_ context.Context, text string) (string, error) {
+	r.texts = append(r.texts, text)
+	return strings.ToUpper(text), nil
+}
+
+func TestExportConversation_TranslationAfterFilter(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	conv.Format = config.OutputFormatMarkdown
+	slack.Messages["C001"][2].Text = "the secret plan"
+	exp, _ := localFormatExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	translator := &recordingTranslator{}
+	exp.translator = translator
+	exp.messageFilter = newMockFilterThatFilters(func(msg slackapi.Message) bool {
+		return strings.Contains(msg.Text, "secret")
+	})
+
+	if _, err := exp.ExportConversation(context.Background(), conv); err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	for _, text := range translator.texts {
+		if strings.Contains(text, "secret") {
+			t.Errorf("translator was sent %q, which the sensitivity filter holds back", text)
+		}
+	}
+	if len(translator.texts) != 3 {
+		t.Errorf("translated %q, want each passed message once", translator.texts)
+	}
+}
+
+func TestExportConversation_TranslationSkipsJSON(t *testing.T) {
+	drive, slack, conv := fakeConversation()
+	conv.Format = config.OutputFormatJSON
+	exp, _ := localFormatExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.translator = upperTranslator{}
+
+	result, err := exp.ExportConversation(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("ExportConversation() error: %v", err)
+	}
+	if result.MessagesTranslated != 0 {
+		t.Errorf("MessagesTranslated = %d, want json left as Slack returned it", result.MessagesTranslated)
+	}
+}
+
+func TestCommandTranslator(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	tr := NewCommandTranslator([]string{"sh", "-c", `printf '[%s] ' "$GET_OUT_TARGET_LANGUAGE"; cat; echo`}, "en", 5*time.Second)
+	got, err := tr.Translate(context.Background(), "hola")
+	if err != nil || got != "[en] hola" {
+		t.Errorf("Translate() = %q, %v; want %q", got, err, "[en] hola")
+	}
+
+	tr = NewCommandTranslator([]string{"sh", "-c", "echo quota exceeded >&2; exit 3"}, "en", 5*time.Second)
+	if _, err := tr.Translate(context.Background(), "hola"); err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Errorf("Translate() error = %v, want the command's stderr", err)
+	}
+}
+
+func TestHTTPTranslator(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req translationPayload
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.Method != http.MethodPost {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if req.Text == "boom" {
+			http.Error(w, "model overloaded", http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(translationPayload{Text: req.Target + ":" + req.Text})
+	}))
+	defer srv.Close()
+
+	tr := NewHTTPTranslator(srv.URL, "en", 5*time.Second)
+	if got, err := tr.Translate(context.Background(), "bonjour"); err != nil || got != "en:bonjour" {
+		t.Errorf("Translate() = %q, %v; want %q", got, err, "en:bonjour")
+	}
+	if _, err := tr.Translate(context.Background(), "boom"); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("Translate() error = %v, want the status", err)
+	}
+}
+
+func TestNewTranslator(t *testing.T) {
+	if tr := NewTranslator(&config.TranslationConfig{Enabled: false, Command: []string{"x"}}); tr != nil {
+		t.Errorf("disabled: %T, want nil", tr)
+	}
+	if _, ok := NewTranslator(&config.TranslationConfig{Enabled: true, TargetLanguage: "en", Command: []string{"x"}}).(*CommandTranslator); !ok {
+		t.Error("command config did not give a CommandTranslator")
+	}
+	if _, ok := NewTranslator(&config.TranslationConfig{Enabled: true, TargetLanguage: "en", URL: "http://localhost"}).(*HTTPTranslator); !ok {
+		t.Error("url config did not give an HTTPTranslator")
+	}
+}
+
+func TestWithOriginal(t *testing.T) {
+	got := withOriginal("Hello\nworld", "Hallo\nWelt\n")
+	want := "Hello\nworld\n\n_Original:_\n&gt; Hallo\n&gt; Welt"
+	if got != want {
+		t.Errorf("withOriginal() = %q, want %q", got, want)
+	}
+}
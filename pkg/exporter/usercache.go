@@ -0,0 +1,196 @@
+package exporter
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+const (
+	// DefaultUserCacheTTL is how long a cached Slack user is trusted before
+	// it is fetched again, so renames show up in later exports.
+	DefaultUserCacheTTL = 7 * 24 * time.Hour
+
+	// userCacheShards is the number of files users are spread over. A
+	// workspace of 100k users keeps about 400 per shard.
+	userCacheShards = 256
+
+	// userCacheMaxShards is how many shards are kept in memory; the least
+	// recently used one is written back and dropped past this.
+	userCacheMaxShards = 32
+
+	// DefaultUserMemoryLimit is how many users the resolver keeps in
+	// memory; the least recently used are read back from the cache when
+	// needed again.
+	DefaultUserMemoryLimit = 5000
+)
+
+// DefaultUserCacheDir returns the directory of the on-disk Slack user cache.
+func DefaultUserCacheDir(configDir string) string {
+	return filepath.Join(configDir, "_metadata", "users")
+}
+
+// cachedUser is one user in a cache shard.
+type cachedUser struct {
+	User     *slackapi.User `json:"user"`
+	CachedAt time.Time      `json:"cached_at"`
+}
+
+// userShard is one cache file loaded into memory.
+type userShard struct {
+	name  string
+	users map[string]cachedUser
+	dirty bool
+	elem  *list.Element // position in the LRU list
+}
+
+// UserCacheStore is an on-disk Slack user cache, sharded by user ID so a
+// lookup loads only the shard holding that user. Entries older than the
+// TTL are treated as missing and dropped when their shard is written. It
+// implements parser.UserStore and is safe for concurrent use.
+type UserCacheStore struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+
+	mu     sync.Mutex
+	shards map[string]*userShard
+	lru    *list.List // front = most recently used shard name
+	err    error      // first write error, reported by Flush
+}
+
+// NewUserCacheStore creates a cache in dir. ttl <= 0 uses DefaultUserCacheTTL.
+func NewUserCacheStore(dir string, ttl time.Duration) *UserCacheStore {
+	if ttl <= 0 {
+		ttl = DefaultUserCacheTTL
+	}
+	return &UserCacheStore{
+		dir:    dir,
+		ttl:    ttl,
+		now:    time.Now,
+		shards: make(map[string]*userShard),
+		lru:    list.New(),
+	}
+}
+
+// shardName returns the shard file name for a user ID.
+func shardName(id string) string {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return fmt.Sprintf("%02x.json", h.Sum32()%userCacheShards)
+}
+
+// Get returns the cached user, or nil when it is absent or expired.
+func (c *UserCacheStore) Get(id string) *slackapi.User {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.shardLocked(shardName(id)).users[id]
+	if !ok || c.now().Sub(entry.CachedAt) > c.ttl {
+		return nil
+	}
+	return entry.User
+}
+
+// Put caches user. It is written to disk when its shard is evicted or on
+// Flush.
+func (c *UserCacheStore) Put(user *slackapi.User) {
+	if user == nil || user.ID == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	shard := c.shardLocked(shardName(user.ID))
+	shard.users[user.ID] = cachedUser{User: user, CachedAt: c.now()}
+	shard.dirty = true
+}
+
+// Flush writes every changed shard to disk and returns the first write
+// error since the last Flush.
+func (c *UserCacheStore) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, shard := range c.shards {
+		c.writeShardLocked(shard)
+	}
+	err := c.err
+	c.err = nil
+	return err
+}
+
+// shardLocked returns the named shard, loading it from disk and evicting
+// the least recently used shard as needed. Caller must hold c.mu.
+func (c *UserCacheStore) shardLocked(name string) *userShard {
+	if shard, ok := c.shards[name]; ok {
+		c.lru.MoveToFront(shard.elem)
+		return shard
+	}
+
+	shard := &userShard{name: name, users: make(map[string]cachedUser)}
+	// A missing or unreadable shard starts empty; users are fetched again.
+	if data, err := os.ReadFile(filepath.Join(c.dir, name)); err == nil {
+		_ = json.Unmarshal(data, &shard.users)
+		if shard.users == nil {
+			shard.users = make(map[string]cachedUser)
+		}
+	}
+	shard.elem = c.lru.PushFront(name)
+	c.shards[name] = shard
+
+	for c.lru.Len() > userCacheMaxShards {
+		oldest := c.lru.Back()
+		evicted := c.shards[oldest.Value.(string)]
+		c.writeShardLocked(evicted)
+		c.lru.Remove(oldest)
+		delete(c.shards, evicted.name)
+	}
+	return shard
+}
+
+// writeShardLocked writes a changed shard, dropping expired entries. The
+// first error is kept for Flush. Caller must hold c.mu.
+func (c *UserCacheStore) writeShardLocked(shard *userShard) {
+	if !shard.dirty {
+		return
+	}
+	now := c.now()
+	for id, entry := range shard.users {
+		if now.Sub(entry.CachedAt) > c.ttl {
+			delete(shard.users, id)
+		}
+	}
+	if err := c.writeFile(shard.name, shard.users); err != nil {
+		if c.err == nil {
+			c.err = err
+		}
+		return
+	}
+	shard.dirty = false
+}
+
+// writeFile atomically replaces a shard file.
+func (c *UserCacheStore) writeFile(name string, users map[string]cachedUser) error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create user cache directory: %w", err)
+	}
+	data, err := json.Marshal(users)
+	if err != nil {
+		return fmt.Errorf("failed to encode user cache: %w", err)
+	}
+	path := filepath.Join(c.dir, name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write user cache: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write user cache: %w", err)
+	}
+	return nil
+}
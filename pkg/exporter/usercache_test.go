@@ -0,0 +1,72 @@
+package exporter
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+func TestUserCacheStore_PersistsAcrossRuns(t *testing.T) {
+	dir := t.TempDir()
+	c := NewUserCacheStore(dir, time.Hour)
+	c.Put(&slackapi.User{ID: "U001", Name: "alice"})
+	if got := c.Get("U001"); got == nil || got.Name != "alice" {
+		t.Fatalf("Get(U001) = %+v, want alice", got)
+	}
+	if err := c.Flush(); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 1 {
+		t.Errorf("shard files = %d, want 1", len(files))
+	}
+
+	next := NewUserCacheStore(dir, time.Hour)
+	if got := next.Get("U001"); got == nil || got.Name != "alice" {
+		t.Errorf("Get(U001) in next run = %+v, want alice", got)
+	}
+	if got := next.Get("U999"); got != nil {
+		t.Errorf("Get(U999) = %+v, want nil", got)
+	}
+}
+
+func TestUserCacheStore_TTL(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewUserCacheStore(dir, time.Hour)
+	c.now = func() time.Time { return now }
+	c.Put(&slackapi.User{ID: "U001", Name: "alice"})
+
+	now = now.Add(2 * time.Hour)
+	if got := c.Get("U001"); got != nil {
+		t.Errorf("Get(U001) after TTL = %+v, want nil", got)
+	}
+
+	// Expired entries are dropped when the shard is written.
+	if err := c.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := NewUserCacheStore(dir, 100*time.Hour).Get("U001"); got != nil {
+		t.Errorf("expired U001 was written back: %+v", got)
+	}
+}
+
+func TestUserCacheStore_EvictsLeastRecentlyUsedShards(t *testing.T) {
+	dir := t.TempDir()
+	c := NewUserCacheStore(dir, time.Hour)
+	for i := 0; i < 2000; i++ {
+		c.Put(&slackapi.User{ID: fmt.Sprintf("U%04d", i)})
+	}
+	if n := len(c.shards); n > userCacheMaxShards {
+		t.Errorf("shards in memory = %d, want at most %d", n, userCacheMaxShards)
+	}
+
+	// Evicted shards were written back, so every user is still found.
+	for i := 0; i < 2000; i++ {
+		if c.Get(fmt.Sprintf("U%04d", i)) == nil {
+			t.Fatalf("U%04d lost after eviction", i)
+		}
+	}
+}
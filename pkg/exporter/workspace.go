@@ -0,0 +1,201 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/gdrive"
+	"github.com/jflowers/get-out/pkg/parser"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// AboutDocTitle is the title of the doc in the export root folder that
+// records which workspace the archive came from.
+const AboutDocTitle = "About this archive"
+
+// WorkspaceSnapshot records the Slack workspace an export was taken from,
+// as reported by team.info at export time, documenting the provenance of
+// the archive.
+type WorkspaceSnapshot struct {
+	CapturedAt time.Time `json:"captured_at"`
+
+	TeamID           string `json:"team_id"`
+	Name             string `json:"name"`
+	Domain           string `json:"domain,omitempty"`
+	URL              string `json:"url,omitempty"`
+	EmailDomain      string `json:"email_domain,omitempty"`
+	IconURL          string `json:"icon_url,omitempty"`
+	EnterpriseID     string `json:"enterprise_id,omitempty"`
+	EnterpriseName   string `json:"enterprise_name,omitempty"`
+	EnterpriseDomain string `json:"enterprise_domain,omitempty"`
+	Plan             string `json:"plan,omitempty"`
+
+	// ExportedBy is the Slack user ID of the account that ran the export.
+	ExportedBy string `json:"exported_by,omitempty"`
+
+	// GetOutVersion is the get-out version that took the snapshot.
+	GetOutVersion string `json:"get_out_version,omitempty"`
+
+	// AboutDocID and AboutDocURL locate the About doc in the export root.
+	AboutDocID  string `json:"about_doc_id,omitempty"`
+	AboutDocURL string `json:"about_doc_url,omitempty"`
+}
+
+// DefaultWorkspacePath returns the default path of the workspace snapshot.
+func DefaultWorkspacePath(configDir string) string {
+	return filepath.Join(configDir, "_metadata", "workspace.json")
+}
+
+// LoadWorkspaceSnapshot loads the workspace snapshot at path, or returns
+// nil when none has been taken.
+func LoadWorkspaceSnapshot(path string) (*WorkspaceSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workspace snapshot: %w", err)
+	}
+	var snap WorkspaceSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("failed to parse workspace snapshot: %w", err)
+	}
+	return &snap, nil
+}
+
+// Save writes the snapshot to path.
+func (s *WorkspaceSnapshot) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	return writeJSONFile(filepath.Dir(path), filepath.Base(path), s)
+}
+
+// sameWorkspace reports whether s and other describe the workspace the
+// same way, ignoring when they were taken and by whom.
+func (s *WorkspaceSnapshot) sameWorkspace(other *WorkspaceSnapshot) bool {
+	a, b := *s, *other
+	a.CapturedAt, b.CapturedAt = time.Time{}, time.Time{}
+	a.ExportedBy, b.ExportedBy = "", ""
+	a.GetOutVersion, b.GetOutVersion = "", ""
+	a.AboutDocID, b.AboutDocID = "", ""
+	a.AboutDocURL, b.AboutDocURL = "", ""
+	return a == b
+}
+
+// lines returns the snapshot as "Label: value" lines for the About doc.
+func (s *WorkspaceSnapshot) lines() []string {
+	var lines []string
+	add := func(label, value string) {
+		if value != "" {
+			lines = append(lines, label+": "+value)
+		}
+	}
+	add("Workspace", s.Name)
+	add("Team ID", s.TeamID)
+	add("Domain", s.Domain)
+	add("URL", s.URL)
+	add("Email domain", s.EmailDomain)
+	add("Enterprise", s.EnterpriseName)
+	add("Enterprise ID", s.EnterpriseID)
+	add("Enterprise domain", s.EnterpriseDomain)
+	add("Plan", s.Plan)
+	add("Icon", s.IconURL)
+	add("Exported by", s.ExportedBy)
+	add("get-out version", s.GetOutVersion)
+	return lines
+}
+
+// SnapshotWorkspace records the workspace being exported in
+// <config-dir>/_metadata/workspace.json and, when it is new or has changed
+// since the last export, in the About doc of the export root. When
+// team.info is restricted, the snapshot falls back to what auth.test
+// reports.
+func (e *Exporter) SnapshotWorkspace(ctx context.Context) (*WorkspaceSnapshot, error) {
+	snap := &WorkspaceSnapshot{CapturedAt: time.Now().UTC(), GetOutVersion: e.version}
+	if e.index != nil {
+		snap.ExportedBy = e.index.SelfUserID
+	}
+	team, err := e.slackClient.GetTeamInfo(ctx)
+	switch {
+	case err == nil:
+		snap.TeamID, snap.Name, snap.Domain, snap.URL = team.ID, team.Name, team.Domain, team.URL
+		snap.EmailDomain, snap.IconURL, snap.Plan = team.EmailDomain, team.Icon.LargestURL(), team.Plan
+		snap.EnterpriseID, snap.EnterpriseName, snap.EnterpriseDomain = team.EnterpriseID, team.EnterpriseName, team.EnterpriseDomain
+		if snap.URL == "" && snap.Domain != "" {
+			snap.URL = "https://" + snap.Domain + ".slack.com/"
+		}
+	case slackapi.IsRestrictedError(err):
+		e.Detail("team.info is restricted (%v); recording the workspace from auth.test", err)
+		auth, authErr := e.slackClient.ValidateAuth(ctx)
+		if authErr != nil {
+			return nil, fmt.Errorf("failed to identify workspace: %w", authErr)
+		}
+		snap.TeamID, snap.Name, snap.URL = auth.TeamID, auth.Team, auth.URL
+	default:
+		return nil, fmt.Errorf("failed to get workspace info: %w", err)
+	}
+
+	path := DefaultWorkspacePath(e.configDir)
+	previous, err := LoadWorkspaceSnapshot(path)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil {
+		snap.AboutDocID, snap.AboutDocURL = previous.AboutDocID, previous.AboutDocURL
+	}
+	if previous == nil || previous.AboutDocID == "" || !snap.sameWorkspace(previous) {
+		if err := e.writeAboutDoc(ctx, snap); err != nil {
+			e.Progress("Warning: %v", err)
+		}
+	}
+	if err := snap.Save(path); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// writeAboutDoc appends the snapshot to the About doc of the export root,
+// creating the doc on first use.
+func (e *Exporter) writeAboutDoc(ctx context.Context, snap *WorkspaceSnapshot) error {
+	if snap.AboutDocID == "" {
+		root, err := e.folderStructure.EnsureRootFolder(ctx)
+		if err != nil {
+			return err
+		}
+		doc, err := e.gdriveClient.FindOrCreateDocument(ctx, AboutDocTitle, root.ID)
+		if err != nil {
+			return fmt.Errorf("failed to create %s doc: %w", AboutDocTitle, err)
+		}
+		snap.AboutDocID, snap.AboutDocURL = doc.ID, doc.URL
+	}
+	block := gdrive.MessageBlock{
+		SenderName: "Workspace snapshot",
+		Timestamp:  parser.FormatTime(snap.CapturedAt),
+		Content:    strings.Join(snap.lines(), "\n"),
+	}
+	if err := e.gdriveClient.BatchAppendMessages(ctx, snap.AboutDocID, []gdrive.MessageBlock{block}); err != nil {
+		return fmt.Errorf("failed to write %s: %w", AboutDocTitle, err)
+	}
+	return nil
+}
+
+// snapshotWorkspace takes the workspace snapshot at the start of a run,
+// reporting failures without stopping the export. Digest-only runs, which
+// write no archive, take none.
+func (e *Exporter) snapshotWorkspace(ctx context.Context) {
+	if e.digestOnly {
+		return
+	}
+	snap, err := e.SnapshotWorkspace(ctx)
+	if err != nil {
+		e.Progress("Warning: could not record workspace metadata: %v", err)
+		return
+	}
+	e.Detail("Recorded workspace %s (%s)", snap.Name, snap.TeamID)
+}
@@ -0,0 +1,79 @@
+package exporter
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/internal/testutil"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+func TestSnapshotWorkspace(t *testing.T) {
+	drive, slack := testutil.NewFakeDrive(), testutil.NewFakeSlack()
+	slack.Team = slackapi.Team{
+		ID: "T001", Name: "Acme", Domain: "acme", EnterpriseID: "E001", EnterpriseName: "Acme Corp", Plan: "enterprise",
+		Icon: slackapi.TeamIcon{Image132: "https://example.com/132.png"},
+	}
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+	exp.version = "1.2.3"
+
+	snap, err := exp.SnapshotWorkspace(context.Background())
+	if err != nil {
+		t.Fatalf("SnapshotWorkspace() error: %v", err)
+	}
+	if snap.URL != "https://acme.slack.com/" || snap.IconURL != "https://example.com/132.png" || snap.GetOutVersion != "1.2.3" {
+		t.Errorf("snapshot = %+v", snap)
+	}
+
+	saved, err := LoadWorkspaceSnapshot(DefaultWorkspacePath(exp.configDir))
+	if err != nil || saved == nil {
+		t.Fatalf("LoadWorkspaceSnapshot() = %v, %v", saved, err)
+	}
+	if saved.EnterpriseName != "Acme Corp" || saved.AboutDocID == "" {
+		t.Errorf("saved snapshot = %+v, want the enterprise and About doc recorded", saved)
+	}
+	if got := drive.FolderName(drive.DocumentFolder(saved.AboutDocID)); got != "Test Exports" {
+		t.Errorf("About doc folder = %q, want the export root", got)
+	}
+	texts := appendedTexts(drive, saved.AboutDocID)
+	if len(texts) != 1 || !strings.Contains(texts[0], "Enterprise: Acme Corp") || !strings.Contains(texts[0], "Plan: enterprise") {
+		t.Errorf("About doc = %q", texts)
+	}
+
+	// An unchanged workspace is not written again; a renamed one is.
+	if _, err := exp.SnapshotWorkspace(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(drive.Appended(saved.AboutDocID)); n != 1 {
+		t.Errorf("About doc appends = %d after an unchanged snapshot, want 1", n)
+	}
+	slack.Team.Name = "Acme Rebranded"
+	if _, err := exp.SnapshotWorkspace(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(drive.Appended(saved.AboutDocID)); n != 2 {
+		t.Errorf("About doc appends = %d after a rename, want 2", n)
+	}
+}
+
+func TestSnapshotWorkspace_RestrictedTeamInfo(t *testing.T) {
+	drive, slack := testutil.NewFakeDrive(), testutil.NewFakeSlack()
+	slack.Errors["GetTeamInfo"] = &slackapi.APIError{Code: "missing_scope"}
+	exp := fakeExporter(t, drive, slack, t.TempDir()+"/export-index.json")
+
+	snap, err := exp.SnapshotWorkspace(context.Background())
+	if err != nil {
+		t.Fatalf("SnapshotWorkspace() error: %v", err)
+	}
+	if snap.TeamID != "T001" || snap.Name != "Test" {
+		t.Errorf("snapshot = %+v, want the auth.test team", snap)
+	}
+}
+
+func TestLoadWorkspaceSnapshot_Missing(t *testing.T) {
+	snap, err := LoadWorkspaceSnapshot(DefaultWorkspacePath(t.TempDir()))
+	if err != nil || snap != nil {
+		t.Errorf("LoadWorkspaceSnapshot() = %v, %v; want nil, nil", snap, err)
+	}
+}
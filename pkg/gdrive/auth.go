@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -17,6 +18,7 @@ import (
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/docs/v1"
 	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/gmail/v1"
 )
 
 // Scopes required for Drive and Docs access.
@@ -32,18 +34,173 @@ type Config struct {
 
 	// TokenPath is where to save/load the OAuth token
 	TokenPath string
+
+	// Quota, when set, counts every request against daily budgets and
+	// slows or pauses requests before they are exhausted.
+	Quota *QuotaTracker
+
+	// Observer, when set, is told about every request, e.g. for metrics.
+	Observer Observer
+
+	// ClientID and ClientSecret, when set, are used instead of the
+	// credentials.json in the secret store.
+	ClientID     string
+	ClientSecret string
+
+	// RefreshToken, when set, is used instead of the token in the secret
+	// store (tokens refreshed from it are still saved there).
+	RefreshToken string
+
+	// DeviceFlow selects the OAuth device-code flow instead of the local
+	// browser redirect when a new token is needed: the user enters a code
+	// at a Google URL on any device. Device tokens are limited to
+	// DeviceScopes.
+	DeviceFlow bool
+
+	// GmailSend adds gmail.send to the scopes requested, for email digests
+	// sent with the Gmail API. A token authorized without it cannot send
+	// mail until the user logs in again.
+	GmailSend bool
+
+	// Notify, when set, receives what would otherwise be printed: the
+	// authorization URL (and device code) when a new token is needed,
+	// warnings about saving tokens, and the client's rate limit retries.
+	// Programs embedding the client set it to show them in their own UI.
+	Notify func(msg string)
+}
+
+// scopes returns the scopes to authorize: Scopes, plus gmail.send when
+// GmailSend is set.
+func (c *Config) scopes() []string {
+	if c == nil || !c.GmailSend {
+		return Scopes
+	}
+	return append(append([]string(nil), Scopes...), gmail.GmailSendScope)
+}
+
+// notifier returns cfg.Notify, or nil for a nil cfg.
+func (c *Config) notifier() func(msg string) {
+	if c == nil {
+		return nil
+	}
+	return c.Notify
 }
 
-// DefaultConfig returns default paths for credentials and token.
+// notice sends msg to notify, or prints it to w when notify is nil.
+func notice(notify func(msg string), w io.Writer, msg string) {
+	if notify != nil {
+		notify(msg)
+		return
+	}
+	fmt.Fprintln(w, msg)
+}
+
+// Environment variables that supply OAuth client credentials and a refresh
+// token, for containers and other machines without credentials.json.
+const (
+	EnvClientID     = "GOOGLE_CLIENT_ID"
+	EnvClientSecret = "GOOGLE_CLIENT_SECRET"
+	EnvRefreshToken = "GOOGLE_REFRESH_TOKEN"
+)
+
+// DeviceScopes are requested by the device-code flow. Google does not
+// allow the Docs scope for device authorization; drive.file also covers
+// Docs API access to the documents get-out creates.
+var DeviceScopes = []string{drive.DriveFileScope}
+
+// DefaultConfig returns default paths for credentials and token, and picks
+// up client credentials and a refresh token from the environment.
 func DefaultConfig(configDir string) *Config {
 	return &Config{
 		CredentialsPath: filepath.Join(configDir, "credentials.json"),
 		TokenPath:       filepath.Join(configDir, "token.json"),
+		ClientID:        os.Getenv(EnvClientID),
+		ClientSecret:    os.Getenv(EnvClientSecret),
+		RefreshToken:    os.Getenv(EnvRefreshToken),
+	}
+}
+
+// HasEnvCredentials reports whether cfg carries its own client ID and
+// secret, so credentials.json is not needed.
+func (c *Config) HasEnvCredentials() bool {
+	return c != nil && c.ClientID != ""
+}
+
+// loadOAuthConfig returns the OAuth client configuration: from cfg's client ID
+// and secret when set, otherwise from the credentials in store.
+func loadOAuthConfig(cfg *Config, store secrets.SecretStore) (*oauth2.Config, error) {
+	if cfg.HasEnvCredentials() {
+		if cfg.ClientSecret == "" {
+			return nil, fmt.Errorf("%s is set but %s is not", EnvClientID, EnvClientSecret)
+		}
+		return &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			Endpoint:     google.Endpoint,
+			Scopes:       cfg.scopes(),
+		}, nil
+	}
+	credData, err := store.Get(secrets.KeyClientCredentials)
+	if err != nil {
+		return nil, fmt.Errorf("credentials not found in store (run 'get-out setup-google', or set %s and %s): %w", EnvClientID, EnvClientSecret, err)
+	}
+	conf, err := google.ConfigFromJSON([]byte(credData), cfg.scopes()...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse credentials: %w", err)
+	}
+	if conf.Endpoint.DeviceAuthURL == "" {
+		conf.Endpoint.DeviceAuthURL = google.Endpoint.DeviceAuthURL
+	}
+	return conf, nil
+}
+
+// loadToken returns the saved token, or one built from cfg.RefreshToken.
+// A saved token issued from a different refresh token is ignored.
+func loadToken(cfg *Config, store secrets.SecretStore) (*oauth2.Token, error) {
+	token, err := LoadTokenFromStore(store)
+	if cfg == nil || cfg.RefreshToken == "" {
+		return token, err
+	}
+	if err == nil && token.RefreshToken == cfg.RefreshToken {
+		return token, nil
 	}
+	return &oauth2.Token{RefreshToken: cfg.RefreshToken}, nil
 }
 
-// getTokenFromWeb starts a local server and initiates browser-based OAuth flow.
-func getTokenFromWeb(ctx context.Context, config *oauth2.Config) (*oauth2.Token, error) {
+// getTokenFromDevice runs the OAuth device-code flow: it prints a URL and
+// code for the user to enter on any device (or sends them to notify), then
+// polls until they approve.
+func getTokenFromDevice(ctx context.Context, config *oauth2.Config, notify func(msg string)) (*oauth2.Token, error) {
+	deviceConfig := *config
+	deviceConfig.Scopes = DeviceScopes
+	resp, err := deviceConfig.DeviceAuth(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("device authorization request failed (the OAuth client must be of type \"TVs and Limited Input devices\"): %w", err)
+	}
+
+	if notify != nil {
+		notify(fmt.Sprintf("To authorize this application, visit %s on any device and enter the code %s", resp.VerificationURI, resp.UserCode))
+	} else {
+		fmt.Println()
+		fmt.Println("To authorize this application, visit this URL on any device:")
+		fmt.Println()
+		fmt.Println("  ", resp.VerificationURI)
+		fmt.Println()
+		fmt.Println("and enter the code:", resp.UserCode)
+		fmt.Println()
+		fmt.Println("Waiting for authorization...")
+	}
+
+	token, err := deviceConfig.DeviceAccessToken(ctx, resp)
+	if err != nil {
+		return nil, fmt.Errorf("device authorization failed: %w", err)
+	}
+	return token, nil
+}
+
+// getTokenFromWeb starts a local server and initiates browser-based OAuth
+// flow. The URL to visit is printed, or sent to notify when set.
+func getTokenFromWeb(ctx context.Context, config *oauth2.Config, notify func(msg string)) (*oauth2.Token, error) {
 	// Use the explicit IPv4 loopback address to match the server bind address.
 	// Using "localhost" risks sending callbacks to [::1] on IPv6-preferring systems
 	// while the server only listens on 127.0.0.1, silently breaking the auth flow.
@@ -112,12 +269,16 @@ func getTokenFromWeb(ctx context.Context, config *oauth2.Config) (*oauth2.Token,
 	// Generate auth URL with the random state
 	authURL := config.AuthCodeURL(expectedState, oauth2.AccessTypeOffline)
 
-	fmt.Println()
-	fmt.Println("To authorize this application, visit this URL in your browser:")
-	fmt.Println()
-	fmt.Println("  ", authURL)
-	fmt.Println()
-	fmt.Println("Waiting for authorization...")
+	if notify != nil {
+		notify("To authorize this application, visit this URL in your browser: " + authURL)
+	} else {
+		fmt.Println()
+		fmt.Println("To authorize this application, visit this URL in your browser:")
+		fmt.Println()
+		fmt.Println("  ", authURL)
+		fmt.Println()
+		fmt.Println("Waiting for authorization...")
+	}
 
 	// Wait for code or error
 	var code string
@@ -184,15 +345,11 @@ func saveTokenToStore(store secrets.SecretStore, token *oauth2.Token) error {
 // store, or the token is expired without a refresh token. Errors are wrapped
 // with context describing the failure stage.
 func ClientFromStore(ctx context.Context, cfg *Config, store secrets.SecretStore) (*http.Client, error) {
-	credData, err := store.Get(secrets.KeyClientCredentials)
+	oauthConfig, err := loadOAuthConfig(cfg, store)
 	if err != nil {
-		return nil, fmt.Errorf("credentials not found in store: %w", err)
+		return nil, err
 	}
-	oauthConfig, err := google.ConfigFromJSON([]byte(credData), Scopes...)
-	if err != nil {
-		return nil, fmt.Errorf("unable to parse credentials: %w", err)
-	}
-	token, err := LoadTokenFromStore(store)
+	token, err := loadToken(cfg, store)
 	if err != nil {
 		return nil, fmt.Errorf("token not found in store: %w", err)
 	}
@@ -209,41 +366,44 @@ func ClientFromStore(ctx context.Context, cfg *Config, store secrets.SecretStore
 // which blocks until the user completes authorization.
 //
 // On success from the browser flow, the new token is saved to the store. If
-// saving fails, a warning is printed to stdout but no error is returned.
+// saving fails, a warning is printed to stdout (or sent to cfg.Notify) but no
+// error is returned.
 //
 // Returns a non-nil *http.Client on success, configured with the OAuth2 token
 // source. Returns (nil, error) if credentials are not found or unparseable in
 // the store, or if the browser-based token exchange fails. Errors are wrapped
 // with context.
 func AuthenticateWithStore(ctx context.Context, cfg *Config, store secrets.SecretStore) (*http.Client, error) {
-	// Read credentials from store
-	credData, err := store.Get(secrets.KeyClientCredentials)
-	if err != nil {
-		return nil, fmt.Errorf("credentials not found in store (run 'get-out auth login' after placing credentials.json): %w", err)
-	}
-
-	oauthConfig, err := google.ConfigFromJSON([]byte(credData), Scopes...)
+	oauthConfig, err := loadOAuthConfig(cfg, store)
 	if err != nil {
-		return nil, fmt.Errorf("unable to parse credentials: %w", err)
+		return nil, err
 	}
 
 	// Try to load existing token from store
-	token, err := LoadTokenFromStore(store)
+	token, err := loadToken(cfg, store)
 	if err == nil {
 		if token.Valid() || token.RefreshToken != "" {
 			return oauthConfig.Client(ctx, token), nil
 		}
 	}
 
-	// Need to get new token via browser flow
-	token, err = getTokenFromWeb(ctx, oauthConfig)
+	// Need to get new token via browser or device flow
+	if cfg != nil && cfg.DeviceFlow {
+		if cfg.GmailSend {
+			// Google does not allow Gmail scopes for device authorization.
+			return nil, fmt.Errorf("sending digests with Gmail cannot be authorized with the device flow; log in with a browser, or use the smtp transport")
+		}
+		token, err = getTokenFromDevice(ctx, oauthConfig, cfg.Notify)
+	} else {
+		token, err = getTokenFromWeb(ctx, oauthConfig, cfg.notifier())
+	}
 	if err != nil {
 		return nil, fmt.Errorf("unable to get token: %w", err)
 	}
 
 	// Save token to store
 	if err := saveTokenToStore(store, token); err != nil {
-		fmt.Printf("Warning: could not save token to store: %v\n", err)
+		notice(cfg.notifier(), os.Stdout, fmt.Sprintf("Warning: could not save token to store: %v", err))
 	}
 
 	return oauthConfig.Client(ctx, token), nil
@@ -255,7 +415,8 @@ func AuthenticateWithStore(ctx context.Context, cfg *Config, store secrets.Secre
 // If the token is already valid, it returns nil immediately without side effects.
 // If the token is expired and has a refresh token, it refreshes the token via
 // Google's token endpoint and saves the new token to the store. If saving the
-// refreshed token fails, a warning is printed to stderr but nil is still returned.
+// refreshed token fails, a warning is printed to stderr (or sent to cfg.Notify)
+// but nil is still returned.
 //
 // Returns nil on success (token is valid or was successfully refreshed).
 // Returns a non-nil error if no token is found in the store, the token is
@@ -263,7 +424,7 @@ func AuthenticateWithStore(ctx context.Context, cfg *Config, store secrets.Secre
 // the token refresh request fails. Errors are wrapped with actionable messages
 // (e.g., "run 'get-out auth login'").
 func EnsureTokenFreshWithStore(ctx context.Context, cfg *Config, store secrets.SecretStore) error {
-	token, err := LoadTokenFromStore(store)
+	token, err := loadToken(cfg, store)
 	if err != nil {
 		return fmt.Errorf("no saved Google token found, run 'get-out auth login' first: %w", err)
 	}
@@ -276,17 +437,11 @@ func EnsureTokenFreshWithStore(ctx context.Context, cfg *Config, store secrets.S
 		return fmt.Errorf("Google token expired and no refresh token available, run 'get-out auth login' to re-authenticate")
 	}
 
-	// Read credentials to build oauth config
-	credData, err := store.Get(secrets.KeyClientCredentials)
+	oauthConfig, err := loadOAuthConfig(cfg, store)
 	if err != nil {
 		return fmt.Errorf("unable to read credentials for token refresh: %w", err)
 	}
 
-	oauthConfig, err := google.ConfigFromJSON([]byte(credData), Scopes...)
-	if err != nil {
-		return fmt.Errorf("unable to parse credentials: %w", err)
-	}
-
 	tokenSource := oauthConfig.TokenSource(ctx, token)
 	newToken, err := tokenSource.Token()
 	if err != nil {
@@ -294,7 +449,7 @@ func EnsureTokenFreshWithStore(ctx context.Context, cfg *Config, store secrets.S
 	}
 
 	if err := saveTokenToStore(store, newToken); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: could not save refreshed token to store: %v\n", err)
+		notice(cfg.notifier(), os.Stderr, fmt.Sprintf("Warning: could not save refreshed token to store: %v", err))
 	}
 
 	return nil
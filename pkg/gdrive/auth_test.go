@@ -5,12 +5,14 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/jflowers/get-out/pkg/secrets"
 	"golang.org/x/oauth2"
+	"google.golang.org/api/gmail/v1"
 )
 
 // ---------------------------------------------------------------------------
@@ -656,3 +658,102 @@ func TestClientFromStore_ExpiredWithRefreshToken(t *testing.T) {
 		t.Error("expected configured transport")
 	}
 }
+
+// ---------------------------------------------------------------------------
+// Environment credentials and refresh token
+// ---------------------------------------------------------------------------
+
+func TestLoadOAuthConfig_EnvCredentialsOverrideStore(t *testing.T) {
+	t.Parallel()
+	store := testFileStore(t) // no credentials.json
+	cfg := &Config{ClientID: "env-id.apps.googleusercontent.com", ClientSecret: "env-secret"}
+
+	conf, err := loadOAuthConfig(cfg, store)
+	if err != nil {
+		t.Fatalf("loadOAuthConfig() error: %v", err)
+	}
+	if conf.ClientID != cfg.ClientID || conf.ClientSecret != "env-secret" {
+		t.Errorf("loadOAuthConfig() = %q/%q, want the environment client", conf.ClientID, conf.ClientSecret)
+	}
+	if conf.Endpoint.DeviceAuthURL == "" {
+		t.Error("environment config has no device authorization endpoint")
+	}
+
+	if _, err := loadOAuthConfig(&Config{ClientID: "id-only"}, store); err == nil || !strings.Contains(err.Error(), EnvClientSecret) {
+		t.Errorf("loadOAuthConfig() without secret error = %v, want it to name %s", err, EnvClientSecret)
+	}
+}
+
+func TestLoadOAuthConfig_StoreCredentialsGetDeviceEndpoint(t *testing.T) {
+	t.Parallel()
+	store := testFileStore(t)
+	writeCredentials(t, store)
+
+	conf, err := loadOAuthConfig(&Config{}, store)
+	if err != nil {
+		t.Fatalf("loadOAuthConfig() error: %v", err)
+	}
+	if conf.Endpoint.DeviceAuthURL == "" {
+		t.Error("stored credentials config has no device authorization endpoint")
+	}
+}
+
+func TestLoadOAuthConfig_GmailSendScope(t *testing.T) {
+	t.Parallel()
+	store := testFileStore(t)
+	writeCredentials(t, store)
+
+	for _, gmailSend := range []bool{false, true} {
+		conf, err := loadOAuthConfig(&Config{GmailSend: gmailSend}, store)
+		if err != nil {
+			t.Fatalf("loadOAuthConfig() error: %v", err)
+		}
+		if got := slices.Contains(conf.Scopes, gmail.GmailSendScope); got != gmailSend {
+			t.Errorf("GmailSend = %v: scopes %v", gmailSend, conf.Scopes)
+		}
+	}
+	if len(Scopes) != 2 {
+		t.Errorf("Scopes = %v, should not be changed by GmailSend", Scopes)
+	}
+}
+
+func TestLoadToken_EnvRefreshToken(t *testing.T) {
+	t.Parallel()
+	store := testFileStore(t)
+	cfg := &Config{RefreshToken: "1//env"}
+
+	// No saved token: one is built from the environment.
+	token, err := loadToken(cfg, store)
+	if err != nil || token.RefreshToken != "1//env" {
+		t.Fatalf("loadToken() = %+v, %v", token, err)
+	}
+
+	// A saved token refreshed from the same refresh token is reused.
+	writeToken(t, store, map[string]any{
+		"access_token":  "ya29.saved",
+		"refresh_token": "1//env",
+		"expiry":        time.Now().Add(time.Hour).Format(time.RFC3339),
+	})
+	if token, _ := loadToken(cfg, store); token.AccessToken != "ya29.saved" {
+		t.Errorf("loadToken() ignored the matching saved token: %+v", token)
+	}
+
+	// A saved token from another refresh token is ignored.
+	if token, _ := loadToken(&Config{RefreshToken: "1//other"}, store); token.AccessToken != "" || token.RefreshToken != "1//other" {
+		t.Errorf("loadToken() used a token for another refresh token: %+v", token)
+	}
+}
+
+func TestClientFromStore_EnvOnly(t *testing.T) {
+	t.Parallel()
+	store := testFileStore(t) // nothing stored at all
+	cfg := &Config{ClientID: "id", ClientSecret: "secret", RefreshToken: "1//env"}
+
+	client, err := ClientFromStore(context.Background(), cfg, store)
+	if err != nil {
+		t.Fatalf("ClientFromStore() with environment credentials: %v", err)
+	}
+	if client == nil {
+		t.Fatal("ClientFromStore() returned nil client")
+	}
+}
@@ -8,16 +8,35 @@ import (
 	"github.com/jflowers/get-out/pkg/secrets"
 	"google.golang.org/api/docs/v1"
 	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/gmail/v1"
 	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
 )
 
-// Client provides access to Google Drive and Docs APIs.
+// Client provides access to Google Drive, Docs, and Sheets APIs, and to
+// Gmail for sending email digests.
 type Client struct {
-	Drive *drive.Service
-	Docs  *docs.Service
+	Drive  *drive.Service
+	Docs   *docs.Service
+	Sheets *sheets.Service
+	Gmail  *gmail.Service
+
+	// styles is how messages are styled in docs; nil is the default
+	// styles (see SetStylePolicy).
+	styles *StylePolicy
+	// notify receives rate limit retry notices; nil prints them to
+	// stderr (see Config.Notify).
+	notify func(msg string)
+}
+
+// SetStylePolicy sets how messages are styled in the docs this client
+// writes. It must be called before the client is used.
+func (c *Client) SetStylePolicy(p *StylePolicy) {
+	c.styles = p
 }
 
-// NewClient creates a new Google Drive/Docs client from an authenticated HTTP client.
+// NewClient creates a new Google Drive/Docs/Sheets client from an
+// authenticated HTTP client.
 func NewClient(ctx context.Context, httpClient *http.Client) (*Client, error) {
 	driveService, err := drive.NewService(ctx, option.WithHTTPClient(httpClient))
 	if err != nil {
@@ -29,9 +48,25 @@ func NewClient(ctx context.Context, httpClient *http.Client) (*Client, error) {
 		return nil, fmt.Errorf("failed to create Docs service: %w", err)
 	}
 
+	// The drive.file scope covers the spreadsheets get-out creates, so
+	// Sheets needs no scope of its own.
+	sheetsService, err := sheets.NewService(ctx, option.WithHTTPClient(httpClient))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Sheets service: %w", err)
+	}
+
+	// Gmail is only used when the token carries GmailSendScope (see
+	// Config.GmailSend).
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(httpClient))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Gmail service: %w", err)
+	}
+
 	return &Client{
-		Drive: driveService,
-		Docs:  docsService,
+		Drive:  driveService,
+		Docs:   docsService,
+		Sheets: sheetsService,
+		Gmail:  gmailService,
 	}, nil
 }
 
@@ -42,6 +77,17 @@ func NewClientFromStore(ctx context.Context, cfg *Config, store secrets.SecretSt
 	if err != nil {
 		return nil, err
 	}
+	if cfg.Observer != nil {
+		httpClient = withObserver(httpClient, cfg.Observer)
+	}
+	if cfg.Quota != nil {
+		httpClient = withQuota(httpClient, cfg.Quota)
+	}
 
-	return NewClient(ctx, httpClient)
+	client, err := NewClient(ctx, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	client.notify = cfg.Notify
+	return client, nil
 }
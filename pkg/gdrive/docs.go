@@ -98,6 +98,56 @@ func (c *Client) FindOrCreateDocument(ctx context.Context, title string, folderI
 	return c.CreateDocument(ctx, title, folderID)
 }
 
+// CopyDocument creates a Google Doc in the specified folder as a copy of
+// the template doc identified by templateID (an ID or URL), keeping its
+// headers, footers, page setup, styles, and content.
+//
+// get-out's drive.file scope only covers files it created, so a template
+// made by hand cannot be found; the error then says so.
+func (c *Client) CopyDocument(ctx context.Context, templateID string, title string, folderID string) (*DocInfo, error) {
+	file := &drive.File{Name: title}
+	if folderID != "" {
+		file.Parents = []string{folderID}
+	}
+
+	created, err := c.Drive.Files.Copy(DocumentID(templateID), file).
+		Context(ctx).
+		Fields("id, name, webViewLink").
+		Do()
+	if err != nil {
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == 404 {
+			return nil, fmt.Errorf("failed to copy doc template %s: not found, or not a doc get-out created (it can only open its own files): %w", templateID, err)
+		}
+		return nil, fmt.Errorf("failed to copy doc template %s to %q: %w", templateID, title, err)
+	}
+
+	return &DocInfo{
+		ID:    created.Id,
+		Title: created.Name,
+		URL:   created.WebViewLink,
+	}, nil
+}
+
+// FindOrCreateDocumentFromTemplate finds a document or, if it doesn't
+// exist, creates it as a copy of the template doc templateID. An empty
+// templateID creates a blank doc, as FindOrCreateDocument does.
+func (c *Client) FindOrCreateDocumentFromTemplate(ctx context.Context, title string, folderID string, templateID string) (*DocInfo, error) {
+	if templateID == "" {
+		return c.FindOrCreateDocument(ctx, title, folderID)
+	}
+	doc, err := c.FindDocument(ctx, title, folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if doc != nil {
+		return doc, nil
+	}
+
+	return c.CopyDocument(ctx, templateID, title, folderID)
+}
+
 // AppendText appends the given text to the end of the Google Doc identified by
 // docID. It first reads the document to determine the current end index, then
 // inserts the text at that position.
@@ -133,7 +183,7 @@ func (c *Client) AppendText(ctx context.Context, docID string, text string) erro
 		},
 	}
 
-	if err := retryOnRateLimit(ctx, "append text", func() error {
+	if err := retryOnRateLimit(ctx, "append text", c.notify, func() error {
 		_, err := c.Docs.Documents.BatchUpdate(docID, &docs.BatchUpdateDocumentRequest{
 			Requests: requests,
 		}).Context(ctx).Do()
@@ -167,43 +217,29 @@ func (c *Client) InsertFormattedContent(ctx context.Context, docID string, conte
 	// Insert text in reverse order to maintain correct indices
 	for i := len(content) - 1; i >= 0; i-- {
 		fc := content[i]
+		text, _, endIndex := newDocRope(index).Insert(fc.Text)
+		if text == "" {
+			continue
+		}
 
 		// Insert the text
 		requests = append(requests, &docs.Request{
 			InsertText: &docs.InsertTextRequest{
 				Location: &docs.Location{Index: index},
-				Text:     fc.Text,
+				Text:     text,
 			},
 		})
 
 		// Apply formatting if specified
-		if fc.Bold || fc.Italic || fc.Monospace || fc.Link != "" {
-			textStyle := &docs.TextStyle{}
-
-			if fc.Bold {
-				textStyle.Bold = true
-			}
-			if fc.Italic {
-				textStyle.Italic = true
-			}
-			if fc.Monospace {
-				textStyle.WeightedFontFamily = &docs.WeightedFontFamily{
-					FontFamily: "Courier New",
-				}
-			}
-			if fc.Link != "" {
-				textStyle.Link = &docs.Link{Url: fc.Link}
-			}
-
-			endIndex := index + utf16Len(fc.Text)
+		if fields := getFieldMask(fc, c.styles); fields != "" {
 			requests = append(requests, &docs.Request{
 				UpdateTextStyle: &docs.UpdateTextStyleRequest{
 					Range: &docs.Range{
 						StartIndex: index,
 						EndIndex:   endIndex,
 					},
-					TextStyle: textStyle,
-					Fields:    getFieldMask(fc),
+					TextStyle: fc.textStyle(c.styles),
+					Fields:    fields,
 				},
 			})
 		}
@@ -213,7 +249,7 @@ func (c *Client) InsertFormattedContent(ctx context.Context, docID string, conte
 		return nil
 	}
 
-	if err := retryOnRateLimit(ctx, "insert formatted content", func() error {
+	if err := retryOnRateLimit(ctx, "insert formatted content", c.notify, func() error {
 		_, err := c.Docs.Documents.BatchUpdate(docID, &docs.BatchUpdateDocumentRequest{
 			Requests: requests,
 		}).Context(ctx).Do()
@@ -230,29 +266,74 @@ type FormattedText struct {
 	Text      string
 	Bold      bool
 	Italic    bool
+	Strike    bool
 	Monospace bool
 	Link      string
+	// CodeBlock shows the text as a code block: in BuildAppendRequests a
+	// shaded single-cell table in Courier New, elsewhere monospace text.
+	CodeBlock bool
+	// Quote indents the paragraphs of the text behind a bar, as a quote.
+	// Only BuildAppendRequests applies it.
+	Quote bool
+}
+
+// textStyle returns the text style of fc in the styles of p, for the
+// fields getFieldMask lists.
+func (fc FormattedText) textStyle(p *StylePolicy) *docs.TextStyle {
+	textStyle, _ := fc.style(p)
+	return textStyle
 }
 
 // getFieldMask returns the field mask for text style updates.
-func getFieldMask(fc FormattedText) string {
-	fields := ""
+func getFieldMask(fc FormattedText, p *StylePolicy) string {
+	_, fields := fc.style(p)
+	return fields
+}
+
+// style returns the text style of fc in the styles of p (the default
+// styles when nil) and its field mask.
+func (fc FormattedText) style(p *StylePolicy) (*docs.TextStyle, string) {
+	p = p.orDefault()
+	textStyle := &docs.TextStyle{}
+	var fields []string
 	if fc.Bold {
-		fields += "bold,"
+		textStyle.Bold = true
+		fields = append(fields, "bold")
 	}
 	if fc.Italic {
-		fields += "italic,"
-	}
-	if fc.Monospace {
-		fields += "weightedFontFamily,"
+		textStyle.Italic = true
+		fields = append(fields, "italic")
+	}
+	if fc.Strike {
+		textStyle.Strikethrough = true
+		fields = append(fields, "strikethrough")
+	}
+	var extra TextStyle
+	if fc.Monospace || fc.CodeBlock {
+		extra = p.Code
+	} else if fc.Quote {
+		extra = p.Quote
+	}
+	for _, f := range extra.apply(textStyle) {
+		if !containsField(fields, f) {
+			fields = append(fields, f)
+		}
 	}
 	if fc.Link != "" {
-		fields += "link,"
+		textStyle.Link = &docs.Link{Url: fc.Link}
+		fields = append(fields, "link")
 	}
-	if len(fields) > 0 {
-		fields = fields[:len(fields)-1] // Remove trailing comma
+	return textStyle, strings.Join(fields, ",")
+}
+
+// containsField reports whether fields lists f.
+func containsField(fields []string, f string) bool {
+	for _, existing := range fields {
+		if existing == f {
+			return true
+		}
 	}
-	return fields
+	return false
 }
 
 // GetDocumentContent retrieves the full plain-text content of the Google Doc
@@ -301,8 +382,9 @@ func (c *Client) GetDocumentEndIndex(ctx context.Context, docID string) (int64,
 
 // BatchAppendMessages appends multiple message blocks to the Google Doc
 // identified by docID, using a single batch update for efficiency. Each message
-// is formatted with the sender name in bold, followed by the timestamp, then
-// the message content body, with link annotations and inline images applied.
+// is a header of the sender name (in bold, unless SetStylePolicy says
+// otherwise) followed by the timestamp, then the message content body, with
+// link annotations and inline images applied.
 //
 // If messages is empty, it returns nil immediately without making any API calls.
 //
@@ -323,119 +405,350 @@ func (c *Client) BatchAppendMessages(ctx context.Context, docID string, messages
 		return err
 	}
 
-	var requests []*docs.Request
-	currentIndex := endIndex
+	requests := c.styles.AppendRequests(endIndex, messages)
 
-	for _, msg := range messages {
-		// Build the message text
-		header := fmt.Sprintf("%s  %s\n", msg.SenderName, msg.Timestamp)
-		body := msg.Content + "\n\n"
+	// Execute batch update
+	if err := retryOnRateLimit(ctx, "append messages", c.notify, func() error {
+		_, err := c.Docs.Documents.BatchUpdate(docID, &docs.BatchUpdateDocumentRequest{
+			Requests: requests,
+		}).Context(ctx).Do()
+		return err
+	}); err != nil {
+		return fmt.Errorf("failed to append messages: %w", err)
+	}
 
-		// Insert header
-		requests = append(requests, &docs.Request{
-			InsertText: &docs.InsertTextRequest{
-				Location: &docs.Location{Index: currentIndex},
-				Text:     header,
-			},
-		})
+	return nil
+}
+
+// ReplaceDocumentContent replaces the body of the Google Doc identified by
+// docID with messages, formatted as BatchAppendMessages formats them. The
+// old body is deleted in the same batch update that writes the new one, so
+// readers never see the doc empty.
+func (c *Client) ReplaceDocumentContent(ctx context.Context, docID string, messages []MessageBlock) error {
+	endIndex, err := c.GetDocumentEndIndex(ctx, docID)
+	if err != nil {
+		return err
+	}
 
-		// Bold the sender name
+	requests := c.styles.replaceRequests(endIndex, messages)
+	if len(requests) == 0 {
+		return nil
+	}
+	if err := retryOnRateLimit(ctx, "replace document content", c.notify, func() error {
+		_, err := c.Docs.Documents.BatchUpdate(docID, &docs.BatchUpdateDocumentRequest{
+			Requests: requests,
+		}).Context(ctx).Do()
+		return err
+	}); err != nil {
+		return fmt.Errorf("failed to replace document content: %w", err)
+	}
+
+	return nil
+}
+
+// BuildReplaceRequests returns the batchUpdate requests that replace the
+// body of a document ending at endIndex with messages: a delete of the
+// existing body, when there is one, then the requests that append messages
+// to the emptied doc.
+func BuildReplaceRequests(endIndex int64, messages []MessageBlock) []*docs.Request {
+	return DefaultStylePolicy().replaceRequests(endIndex, messages)
+}
+
+// replaceRequests returns the requests of BuildReplaceRequests in the
+// styles of p.
+func (p *StylePolicy) replaceRequests(endIndex int64, messages []MessageBlock) []*docs.Request {
+	var requests []*docs.Request
+	if endIndex > 1 {
 		requests = append(requests, &docs.Request{
-			UpdateTextStyle: &docs.UpdateTextStyleRequest{
-				Range: &docs.Range{
-					StartIndex: currentIndex,
-					EndIndex:   currentIndex + utf16Len(msg.SenderName),
-				},
-				TextStyle: &docs.TextStyle{Bold: true},
-				Fields:    "bold",
+			DeleteContentRange: &docs.DeleteContentRangeRequest{
+				Range: &docs.Range{StartIndex: 1, EndIndex: endIndex},
 			},
 		})
+	}
+	return append(requests, p.AppendRequests(1, messages)...)
+}
+
+// BuildAppendRequests returns the batchUpdate requests that append messages
+// to a document whose body currently ends at endIndex, in the default
+// styles. It makes no API calls, so the request stream can be inspected
+// offline.
+func BuildAppendRequests(endIndex int64, messages []MessageBlock) []*docs.Request {
+	return DefaultStylePolicy().AppendRequests(endIndex, messages)
+}
+
+// AppendedLength returns how much appending msg with BatchAppendMessages
+// lengthens a document, in UTF-16 code units, inline images counted as one.
+// Styles do not change it.
+func AppendedLength(msg MessageBlock) int64 {
+	_, end := DefaultStylePolicy().buildAppendRequests(1, []MessageBlock{msg})
+	return end - 1
+}
 
-		currentIndex += utf16Len(header)
+// buildAppendRequests returns the requests of AppendRequests and the end
+// index of the document once they are applied. Every piece is laid out on
+// a docRope, so indices stay exact however the text mixes emoji, links,
+// and characters the Docs API drops.
+func (p *StylePolicy) buildAppendRequests(endIndex int64, messages []MessageBlock) ([]*docs.Request, int64) {
+	p = p.orDefault()
+	var requests []*docs.Request
+	currentIndex := endIndex
 
-		// Insert body
-		bodyStart := currentIndex
+	for _, msg := range messages {
+		r := newDocRope(currentIndex)
+
+		// Insert the header: sender name, two spaces, timestamp, then a
+		// line break, or two spaces before a compact message's content
+		sender, senderStart, senderEnd := r.Insert(msg.SenderName)
+		gap, _, _ := r.Insert("  ")
+		timestamp, timestampStart, timestampEnd := r.Insert(msg.Timestamp)
+		headerBreak := "\n"
+		if msg.Compact {
+			headerBreak = "  "
+		}
+		newline, _, headerEnd := r.Insert(headerBreak)
 		requests = append(requests, &docs.Request{
 			InsertText: &docs.InsertTextRequest{
 				Location: &docs.Location{Index: currentIndex},
-				Text:     body,
+				Text:     sender + gap + timestamp + newline,
 			},
 		})
 
-		currentIndex += utf16Len(body)
+		// Style the header line, then the sender name and timestamp
+		if p.HeaderNamedStyle != "" && !msg.Compact {
+			requests = append(requests, &docs.Request{
+				UpdateParagraphStyle: &docs.UpdateParagraphStyleRequest{
+					Range:          &docs.Range{StartIndex: currentIndex, EndIndex: headerEnd},
+					ParagraphStyle: &docs.ParagraphStyle{NamedStyleType: p.HeaderNamedStyle},
+					Fields:         "namedStyleType",
+				},
+			})
+		}
+		if req := p.Sender.textStyleRequest(senderStart, senderEnd); req != nil {
+			requests = append(requests, req)
+		}
+		if req := p.Timestamp.textStyleRequest(timestampStart, timestampEnd); req != nil {
+			requests = append(requests, req)
+		}
+
+		// Insert body, styled when the message has formatted runs
+		body := msg.Content + "\n\n"
+		bodyOffset := r.Offset()
+		if runs := bodyRuns(msg); runs != nil {
+			requests = append(requests, p.buildFormattedBody(r, runs)...)
+		} else {
+			text, start, _ := r.Insert(body)
+			requests = append(requests, &docs.Request{
+				InsertText: &docs.InsertTextRequest{
+					Location: &docs.Location{Index: start},
+					Text:     text,
+				},
+			})
+		}
 
 		// Apply link annotations within the body
 		if len(msg.Links) > 0 {
 			// Search for each link annotation text in the body and apply hyperlink
 			for _, link := range msg.Links {
 				idx := strings.Index(body, link.Text)
-				if idx >= 0 {
-					linkStart := bodyStart + utf16Len(body[:idx])
-					linkEnd := linkStart + utf16Len(link.Text)
-					requests = append(requests, &docs.Request{
-						UpdateTextStyle: &docs.UpdateTextStyleRequest{
-							Range: &docs.Range{
-								StartIndex: linkStart,
-								EndIndex:   linkEnd,
-							},
-							TextStyle: &docs.TextStyle{
-								Link: &docs.Link{Url: link.URL},
-							},
-							Fields: "link",
-						},
-					})
+				if idx < 0 {
+					continue
+				}
+				linkStart, linkEnd := r.Range(bodyOffset+idx, bodyOffset+idx+len(link.Text))
+				if linkStart >= linkEnd {
+					continue
 				}
-			}
-		}
-
-		// Insert images if present
-		if len(msg.Images) > 0 {
-			for _, img := range msg.Images {
-				// Insert a newline before the image if there's content
 				requests = append(requests, &docs.Request{
-					InsertText: &docs.InsertTextRequest{
-						Location: &docs.Location{Index: currentIndex},
-						Text:     "\n",
+					UpdateTextStyle: &docs.UpdateTextStyleRequest{
+						Range: &docs.Range{
+							StartIndex: linkStart,
+							EndIndex:   linkEnd,
+						},
+						TextStyle: &docs.TextStyle{
+							Link: &docs.Link{Url: link.URL},
+						},
+						Fields: "link",
 					},
 				})
-				currentIndex++
+			}
+		}
 
-				// Insert the image
-				imgIdx := currentIndex
-				requests = append(requests, &docs.Request{
-					InsertInlineImage: &docs.InsertInlineImageRequest{
-						Location: &docs.Location{Index: imgIdx},
-						Uri:      img.URL,
-					},
-				})
-				// We don't increment currentIndex here because InsertInlineImage
-				// adds a character at the location, but we can't easily know its UTF16 length
-				// until it's inserted. However, for a batch update, it's usually 1.
-				currentIndex++
+		// Insert images if present, each in a paragraph of its own
+		for _, img := range msg.Images {
+			before, at, _ := r.Insert("\n")
+			requests = append(requests, &docs.Request{
+				InsertText: &docs.InsertTextRequest{
+					Location: &docs.Location{Index: at},
+					Text:     before,
+				},
+			})
+
+			// An inline image is one code unit long.
+			requests = append(requests, &docs.Request{
+				InsertInlineImage: &docs.InsertInlineImageRequest{
+					Location:   &docs.Location{Index: r.End()},
+					Uri:        img.URL,
+					ObjectSize: img.objectSize(),
+				},
+			})
+			r.Skip(1)
+
+			after, at, _ := r.Insert("\n")
+			requests = append(requests, &docs.Request{
+				InsertText: &docs.InsertTextRequest{
+					Location: &docs.Location{Index: at},
+					Text:     after,
+				},
+			})
 
-				// Add another newline after the image
+			// The caption goes in a paragraph of its own under the image
+			if img.Caption != "" {
+				caption, start, end := r.Insert(img.Caption)
+				newline, _, _ := r.Insert("\n")
 				requests = append(requests, &docs.Request{
 					InsertText: &docs.InsertTextRequest{
-						Location: &docs.Location{Index: currentIndex},
-						Text:     "\n",
+						Location: &docs.Location{Index: start},
+						Text:     caption + newline,
 					},
 				})
-				currentIndex++
+				if req := p.Caption.textStyleRequest(start, end); req != nil {
+					requests = append(requests, req)
+				}
 			}
 		}
+
+		currentIndex = r.End()
 	}
 
-	// Execute batch update
-	if err := retryOnRateLimit(ctx, "append messages", func() error {
-		_, err := c.Docs.Documents.BatchUpdate(docID, &docs.BatchUpdateDocumentRequest{
-			Requests: requests,
-		}).Context(ctx).Do()
-		return err
-	}); err != nil {
-		return fmt.Errorf("failed to append messages: %w", err)
+	return requests, currentIndex
+}
+
+// emptyTableLen is the length of a 1x1 table as inserted, counting the
+// newline inserted before it: the table, row and cell starts, the cell's
+// empty paragraph, and the table end. Its cell's text starts at the insert
+// location + emptyTableCellOffset.
+const (
+	emptyTableLen        = 6
+	emptyTableCellOffset = 4
+)
+
+// bodyRuns returns the runs of a message's body, "\n\n" included: its
+// formatted runs followed by the rest of its content. It returns nil when
+// the message has no formatted runs, or when they are not the start of
+// its content.
+func bodyRuns(msg MessageBlock) []FormattedText {
+	if len(msg.Formatted) == 0 {
+		return nil
+	}
+	var prefix strings.Builder
+	for _, r := range msg.Formatted {
+		prefix.WriteString(r.Text)
+	}
+	if !strings.HasPrefix(msg.Content, prefix.String()) {
+		return nil
 	}
+	runs := append([]FormattedText(nil), msg.Formatted...)
+	return append(runs, FormattedText{Text: msg.Content[prefix.Len():] + "\n\n"})
+}
 
-	return nil
+// buildFormattedBody returns the requests that insert runs at the end of
+// r and style them in the styles of p, laying the runs out on r.
+//
+// Each run of text between code blocks is inserted at once and then styled,
+// so no run inherits the style of the one before it. A code block becomes a
+// shaded single-cell table; the table's own paragraph breaks replace the
+// newlines around the block.
+func (p *StylePolicy) buildFormattedBody(r *docRope, runs []FormattedText) []*docs.Request {
+	var requests []*docs.Request
+	afterBlock := false
+
+	for i := 0; i < len(runs); {
+		if runs[i].CodeBlock {
+			var code strings.Builder
+			for ; i < len(runs) && runs[i].CodeBlock; i++ {
+				code.WriteString(runs[i].Text)
+			}
+			table := r.End()
+			r.Skip(emptyTableCellOffset)
+			text, cell, cellEnd := r.Insert(code.String())
+			r.Skip(emptyTableLen - emptyTableCellOffset)
+			requests = append(requests,
+				&docs.Request{InsertTable: &docs.InsertTableRequest{
+					Location: &docs.Location{Index: table},
+					Rows:     1,
+					Columns:  1,
+				}},
+				&docs.Request{InsertText: &docs.InsertTextRequest{
+					Location: &docs.Location{Index: cell},
+					Text:     text,
+				}},
+			)
+			if req := p.Code.textStyleRequest(cell, cellEnd); req != nil {
+				requests = append(requests, req)
+			}
+			if shading := docsColor(p.CodeBlockShading); shading != nil {
+				requests = append(requests, &docs.Request{UpdateTableCellStyle: &docs.UpdateTableCellStyleRequest{
+					TableStartLocation: &docs.Location{Index: table + 1},
+					TableCellStyle:     &docs.TableCellStyle{BackgroundColor: shading},
+					Fields:             "backgroundColor",
+				}})
+			}
+			afterBlock = true
+			continue
+		}
+
+		// Insert the text up to the next code block at once, dropping the
+		// newlines the table's paragraph breaks stand in for.
+		var piece []FormattedText
+		for ; i < len(runs) && !runs[i].CodeBlock; i++ {
+			piece = append(piece, runs[i])
+		}
+		if afterBlock && strings.HasPrefix(piece[0].Text, "\n") {
+			piece[0].Text = piece[0].Text[1:]
+			r.Omit("\n")
+		}
+		trailing := ""
+		if last := &piece[len(piece)-1]; i < len(runs) && strings.HasSuffix(last.Text, "\n") {
+			last.Text = strings.TrimSuffix(last.Text, "\n")
+			trailing = "\n"
+		}
+
+		var text strings.Builder
+		var styles []*docs.Request
+		at := r.End()
+		for _, run := range piece {
+			inserted, start, end := r.Insert(run.Text)
+			if start == end {
+				continue
+			}
+			if textStyle, fields := run.style(p); fields != "" {
+				styles = append(styles, &docs.Request{UpdateTextStyle: &docs.UpdateTextStyleRequest{
+					Range:     &docs.Range{StartIndex: start, EndIndex: end},
+					TextStyle: textStyle,
+					Fields:    fields,
+				}})
+			}
+			if run.Quote {
+				quoteStyle, fields := p.quoteParagraphStyle()
+				styles = append(styles, &docs.Request{UpdateParagraphStyle: &docs.UpdateParagraphStyleRequest{
+					Range:          &docs.Range{StartIndex: start, EndIndex: end},
+					ParagraphStyle: quoteStyle,
+					Fields:         fields,
+				}})
+			}
+			text.WriteString(inserted)
+		}
+		if text.Len() > 0 {
+			requests = append(requests, &docs.Request{InsertText: &docs.InsertTextRequest{
+				Location: &docs.Location{Index: at},
+				Text:     text.String(),
+			}})
+			requests = append(requests, styles...)
+		}
+		r.Omit(trailing)
+		afterBlock = false
+	}
+
+	return requests
 }
 
 // LinkAnnotation records a substring in message content that should be hyperlinked.
@@ -446,9 +759,28 @@ type LinkAnnotation struct {
 
 // ImageAnnotation represents an image to be embedded.
 type ImageAnnotation struct {
-	URL    string // Publicly accessible URL (from Drive)
+	URL string // Publicly accessible URL (from Drive)
+	// Width and Height are the size the image is shown at, in points.
+	// Either left 0 shows it at its own size, up to the page width.
 	Width  float64
 	Height float64
+
+	// Caption, when set, is a line of text under the image describing it.
+	// The Docs API cannot set an image's alt text, so the caption is what
+	// makes the image findable by search and screen readers.
+	Caption string
+}
+
+// objectSize returns the size to insert the image at, or nil to leave it
+// to the Docs API.
+func (img ImageAnnotation) objectSize() *docs.Size {
+	if img.Width <= 0 || img.Height <= 0 {
+		return nil
+	}
+	return &docs.Size{
+		Width:  &docs.Dimension{Magnitude: img.Width, Unit: "PT"},
+		Height: &docs.Dimension{Magnitude: img.Height, Unit: "PT"},
+	}
 }
 
 // MessageBlock represents a formatted message to insert into a doc.
@@ -456,8 +788,17 @@ type MessageBlock struct {
 	SenderName string
 	Timestamp  string
 	Content    string
-	Links      []LinkAnnotation  // Optional hyperlinks within Content
-	Images     []ImageAnnotation // Optional images to embed after the message
+	// Formatted optionally styles the start of Content: the texts of its
+	// runs, concatenated, are a prefix of Content. Runs that do not match
+	// Content are ignored.
+	Formatted []FormattedText
+	Links     []LinkAnnotation  // Optional hyperlinks within Content
+	Images    []ImageAnnotation // Optional images to embed after the message
+
+	// Compact puts Content on the header's line, for a message with next
+	// to nothing to it, such as a single emoji. The line is not given the
+	// header's paragraph style.
+	Compact bool
 }
 
 // ReplaceText performs a batch find-and-replace in a Google Doc.
@@ -482,7 +823,7 @@ func (c *Client) ReplaceText(ctx context.Context, docID string, replacements map
 	}
 
 	var totalReplaced int
-	err := retryOnRateLimit(ctx, "ReplaceText", func() error {
+	err := retryOnRateLimit(ctx, "ReplaceText", c.notify, func() error {
 		resp, err := c.Docs.Documents.BatchUpdate(docID, &docs.BatchUpdateDocumentRequest{
 			Requests: requests,
 		}).Context(ctx).Do()
@@ -511,8 +852,9 @@ const (
 )
 
 // retryOnRateLimit retries a Google API call on 429 rate limit errors.
-// It waits retryWaitSecs between attempts to let the per-minute quota reset.
-func retryOnRateLimit(ctx context.Context, operation string, fn func() error) error {
+// It waits retryWaitSecs between attempts to let the per-minute quota reset,
+// reporting each wait to notify (stderr when nil).
+func retryOnRateLimit(ctx context.Context, operation string, notify func(msg string), fn func() error) error {
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		err := fn()
 		if err == nil {
@@ -529,8 +871,8 @@ func retryOnRateLimit(ctx context.Context, operation string, fn func() error) er
 			return err // Exhausted retries
 		}
 
-		fmt.Fprintf(os.Stderr, "Rate limited on %s, waiting %ds (attempt %d/%d)\n",
-			operation, retryWaitSecs, attempt+1, maxRetries)
+		notice(notify, os.Stderr, fmt.Sprintf("Rate limited on %s, waiting %ds (attempt %d/%d)",
+			operation, retryWaitSecs, attempt+1, maxRetries))
 
 		select {
 		case <-ctx.Done():
@@ -2,6 +2,8 @@ package gdrive
 
 import (
 	"testing"
+
+	"google.golang.org/api/docs/v1"
 )
 
 func TestUtf16Len(t *testing.T) {
@@ -66,6 +68,16 @@ func TestGetFieldMask(t *testing.T) {
 			fc:   FormattedText{Bold: true, Italic: true, Monospace: true, Link: "https://x.com"},
 			want: "bold,italic,weightedFontFamily,link",
 		},
+		{
+			name: "strike and code block",
+			fc:   FormattedText{Strike: true, CodeBlock: true},
+			want: "strikethrough,weightedFontFamily",
+		},
+		{
+			name: "quote only",
+			fc:   FormattedText{Quote: true},
+			want: "",
+		},
 		{
 			name: "no formatting",
 			fc:   FormattedText{Text: "plain"},
@@ -75,10 +87,242 @@ func TestGetFieldMask(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := getFieldMask(tt.fc)
+			got := getFieldMask(tt.fc, nil)
 			if got != tt.want {
 				t.Errorf("getFieldMask(%+v) = %q, want %q", tt.fc, got, tt.want)
 			}
 		})
 	}
 }
+
+func TestBuildAppendRequests(t *testing.T) {
+	reqs := BuildAppendRequests(10, []MessageBlock{
+		{
+			SenderName: "Alice",
+			Timestamp:  "9:00 AM",
+			Content:    "see docs",
+			Links:      []LinkAnnotation{{Text: "docs", URL: "https://example.com"}},
+		},
+		{SenderName: "Bob", Timestamp: "9:05 AM", Content: "ok"},
+	})
+
+	// header insert, bold, body insert, link — then header, bold, body.
+	if len(reqs) != 7 {
+		t.Fatalf("got %d requests, want 7", len(reqs))
+	}
+	if got := reqs[0].InsertText; got == nil || got.Location.Index != 10 || got.Text != "Alice  9:00 AM\n" {
+		t.Errorf("reqs[0] = %+v", reqs[0].InsertText)
+	}
+	if got := reqs[1].UpdateTextStyle; got == nil || got.Range.StartIndex != 10 || got.Range.EndIndex != 15 {
+		t.Errorf("reqs[1] = %+v", reqs[1].UpdateTextStyle)
+	}
+	bodyStart := int64(10 + len("Alice  9:00 AM\n"))
+	if got := reqs[3].UpdateTextStyle; got == nil || got.Range.StartIndex != bodyStart+4 || got.TextStyle.Link.Url != "https://example.com" {
+		t.Errorf("reqs[3] = %+v", reqs[3].UpdateTextStyle)
+	}
+	secondStart := bodyStart + int64(len("see docs\n\n"))
+	if got := reqs[4].InsertText; got == nil || got.Location.Index != secondStart {
+		t.Errorf("reqs[4] = %+v, want insert at %d", reqs[4].InsertText, secondStart)
+	}
+
+	// Characters the Docs API drops are left out of the text and its
+	// indices, so they do not shift the link after them.
+	reqs = BuildAppendRequests(1, []MessageBlock{{
+		SenderName: "Al",
+		Timestamp:  "9:00",
+		Content:    "👍🏽\r\x00 see docs",
+		Links:      []LinkAnnotation{{Text: "docs", URL: "https://example.com"}},
+	}})
+	bodyStart = int64(1 + len("Al  9:00\n"))
+	if got := reqs[2].InsertText; got == nil || got.Text != "👍🏽 see docs\n\n" {
+		t.Errorf("reqs[2] = %+v, want the body without \\r and \\x00", reqs[2].InsertText)
+	}
+	if got := reqs[3].UpdateTextStyle; got == nil || got.Range.StartIndex != bodyStart+9 || got.Range.EndIndex != bodyStart+13 {
+		t.Errorf("reqs[3] = %+v, want the link at %d", reqs[3].UpdateTextStyle, bodyStart+9)
+	}
+
+	if reqs := BuildAppendRequests(1, nil); len(reqs) != 0 {
+		t.Errorf("no messages: got %d requests", len(reqs))
+	}
+}
+
+func TestBuildAppendRequests_Compact(t *testing.T) {
+	p := DefaultStylePolicy()
+	p.HeaderNamedStyle = "HEADING_4"
+	reqs := p.AppendRequests(1, []MessageBlock{{
+		SenderName: "Alice",
+		Timestamp:  "9:00 AM",
+		Content:    "GIF: cats",
+		Links:      []LinkAnnotation{{Text: "GIF: cats", URL: "https://giphy.com/gifs/cats"}},
+		Compact:    true,
+	}})
+
+	// header insert, bold, body insert, link; no header paragraph style
+	if len(reqs) != 4 {
+		t.Fatalf("got %d requests, want 4", len(reqs))
+	}
+	if got := reqs[0].InsertText; got == nil || got.Text != "Alice  9:00 AM  " {
+		t.Errorf("reqs[0] = %+v, want the header without a line break", reqs[0].InsertText)
+	}
+	bodyStart := int64(1 + len("Alice  9:00 AM  "))
+	if got := reqs[2].InsertText; got == nil || got.Location.Index != bodyStart || got.Text != "GIF: cats\n\n" {
+		t.Errorf("reqs[2] = %+v, want the content on the header's line", reqs[2].InsertText)
+	}
+	if got := reqs[3].UpdateTextStyle; got == nil || got.Range.StartIndex != bodyStart || got.TextStyle.Link == nil {
+		t.Errorf("reqs[3] = %+v, want the link at %d", reqs[3].UpdateTextStyle, bodyStart)
+	}
+}
+
+func TestBuildAppendRequests_ImageSize(t *testing.T) {
+	reqs := BuildAppendRequests(1, []MessageBlock{{
+		SenderName: "Al",
+		Timestamp:  "9:00",
+		Images: []ImageAnnotation{
+			{URL: "https://example.com/a.png", Width: 468, Height: 234},
+			{URL: "https://example.com/b.png"},
+		},
+	}})
+	var images []*docs.InsertInlineImageRequest
+	for _, req := range reqs {
+		if req.InsertInlineImage != nil {
+			images = append(images, req.InsertInlineImage)
+		}
+	}
+	if len(images) != 2 {
+		t.Fatalf("got %d image inserts, want 2", len(images))
+	}
+	if size := images[0].ObjectSize; size == nil || size.Width.Magnitude != 468 || size.Height.Magnitude != 234 || size.Width.Unit != "PT" {
+		t.Errorf("ObjectSize = %+v, want 468x234 PT", size)
+	}
+	if images[1].ObjectSize != nil {
+		t.Errorf("ObjectSize = %+v for an image without a size, want nil", images[1].ObjectSize)
+	}
+}
+
+func TestBuildAppendRequests_ImageCaption(t *testing.T) {
+	msg := MessageBlock{
+		SenderName: "Al",
+		Timestamp:  "9:00",
+		Images:     []ImageAnnotation{{URL: "https://example.com/a.png", Caption: "a.png · uploaded by Al"}},
+	}
+	reqs := BuildAppendRequests(1, []MessageBlock{msg})
+
+	// header insert, bold, body, then the newline, image, newline,
+	// caption, and the caption's style.
+	if len(reqs) != 8 {
+		t.Fatalf("got %d requests, want 8", len(reqs))
+	}
+	image := reqs[4].InsertInlineImage
+	if image == nil {
+		t.Fatalf("reqs[4] = %+v, want the image", reqs[4])
+	}
+	captionStart := image.Location.Index + 2
+	if got := reqs[6].InsertText; got == nil || got.Location.Index != captionStart || got.Text != "a.png · uploaded by Al\n" {
+		t.Errorf("reqs[6] = %+v, want the caption at %d", reqs[6].InsertText, captionStart)
+	}
+	captionEnd := captionStart + utf16Len("a.png · uploaded by Al")
+	if got := reqs[7].UpdateTextStyle; got == nil || got.Range.StartIndex != captionStart || got.Range.EndIndex != captionEnd || !got.TextStyle.Italic {
+		t.Errorf("reqs[7] = %+v, want the caption styled", reqs[7].UpdateTextStyle)
+	}
+	if got, want := AppendedLength(msg), captionEnd; got != want {
+		t.Errorf("AppendedLength() = %d, want %d", got, want)
+	}
+}
+
+func TestAppendedLength(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  MessageBlock
+		want int64
+	}{
+		{"plain", MessageBlock{SenderName: "Ann", Timestamp: "9:00 AM", Content: "hi"}, int64(len("Ann  9:00 AM\nhi\n\n"))},
+		{"utf-16", MessageBlock{SenderName: "Ann", Timestamp: "9:00 AM", Content: "😀"}, int64(len("Ann  9:00 AM\n")) + 2 + 2},
+		{"image", MessageBlock{SenderName: "Ann", Timestamp: "9:00 AM", Images: []ImageAnnotation{{URL: "https://example.com/a.png"}}}, int64(len("Ann  9:00 AM\n\n\n")) + 3},
+	}
+	for _, tt := range tests {
+		if got := AppendedLength(tt.msg); got != tt.want {
+			t.Errorf("%s: AppendedLength() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBuildAppendRequests_Formatted(t *testing.T) {
+	reqs := BuildAppendRequests(1, []MessageBlock{{
+		SenderName: "Al",
+		Timestamp:  "9:00",
+		Content:    "a bold\ncode\nquote\nafter",
+		Formatted: []FormattedText{
+			{Text: "a "}, {Text: "bold", Bold: true}, {Text: "\n"},
+			{Text: "code", CodeBlock: true},
+			{Text: "\n"}, {Text: "quote\n", Quote: true},
+		},
+		Links: []LinkAnnotation{{Text: "after", URL: "https://example.com"}},
+	}})
+
+	// Header and its bold, the text before the block and its bold, the
+	// table, its text, font and shading, then the text after it, its quote
+	// style, and the link.
+	if len(reqs) != 11 {
+		t.Fatalf("got %d requests, want 11", len(reqs))
+	}
+	bodyStart := int64(1 + len("Al  9:00\n"))
+	if got := reqs[2].InsertText; got == nil || got.Location.Index != bodyStart || got.Text != "a bold" {
+		t.Errorf("reqs[2] = %+v, want the text before the block without its newline", reqs[2].InsertText)
+	}
+	if got := reqs[3].UpdateTextStyle; got == nil || !got.TextStyle.Bold || got.Range.StartIndex != bodyStart+2 || got.Range.EndIndex != bodyStart+6 || got.Fields != "bold" {
+		t.Errorf("reqs[3] = %+v, want bold on \"bold\"", reqs[3].UpdateTextStyle)
+	}
+
+	tableAt := bodyStart + 6
+	if got := reqs[4].InsertTable; got == nil || got.Location.Index != tableAt || got.Rows != 1 || got.Columns != 1 {
+		t.Errorf("reqs[4] = %+v, want a 1x1 table at %d", reqs[4].InsertTable, tableAt)
+	}
+	if got := reqs[5].InsertText; got == nil || got.Location.Index != tableAt+4 || got.Text != "code" {
+		t.Errorf("reqs[5] = %+v, want the code in the cell", reqs[5].InsertText)
+	}
+	if got := reqs[6].UpdateTextStyle; got == nil || got.TextStyle.WeightedFontFamily.FontFamily != "Courier New" || got.Range.EndIndex != tableAt+8 {
+		t.Errorf("reqs[6] = %+v, want the code in Courier New", reqs[6].UpdateTextStyle)
+	}
+	if got := reqs[7].UpdateTableCellStyle; got == nil || got.TableStartLocation.Index != tableAt+1 || got.TableCellStyle.BackgroundColor == nil {
+		t.Errorf("reqs[7] = %+v, want the cell shaded", reqs[7].UpdateTableCellStyle)
+	}
+
+	afterTable := tableAt + emptyTableLen + 4
+	if got := reqs[8].InsertText; got == nil || got.Location.Index != afterTable || got.Text != "quote\nafter\n\n" {
+		t.Errorf("reqs[8] = %+v, want the rest inserted after the table without its newline", reqs[8].InsertText)
+	}
+	if got := reqs[9].UpdateParagraphStyle; got == nil || got.Range.StartIndex != afterTable || got.Range.EndIndex != afterTable+6 || got.ParagraphStyle.IndentStart == nil {
+		t.Errorf("reqs[9] = %+v, want the quote indented", reqs[9].UpdateParagraphStyle)
+	}
+	if got := reqs[10].UpdateTextStyle; got == nil || got.Range.StartIndex != afterTable+6 || got.TextStyle.Link.Url != "https://example.com" {
+		t.Errorf("reqs[10] = %+v, want the link after the table", reqs[10].UpdateTextStyle)
+	}
+
+	// Runs that do not match the content are ignored.
+	reqs = BuildAppendRequests(1, []MessageBlock{{
+		SenderName: "Al",
+		Content:    "edited",
+		Formatted:  []FormattedText{{Text: "original", Bold: true}},
+	}})
+	if len(reqs) != 3 || reqs[2].InsertText == nil || reqs[2].InsertText.Text != "edited\n\n" {
+		t.Errorf("mismatched runs: got %d requests, want the plain body", len(reqs))
+	}
+}
+
+func TestBuildReplaceRequests(t *testing.T) {
+	reqs := BuildReplaceRequests(42, []MessageBlock{{SenderName: "Status", Content: "ok"}})
+	if len(reqs) != 4 {
+		t.Fatalf("got %d requests, want a delete and 3 append requests", len(reqs))
+	}
+	if got := reqs[0].DeleteContentRange; got == nil || got.Range.StartIndex != 1 || got.Range.EndIndex != 42 {
+		t.Errorf("reqs[0] = %+v, want the body deleted", reqs[0].DeleteContentRange)
+	}
+	if got := reqs[1].InsertText; got == nil || got.Location.Index != 1 {
+		t.Errorf("reqs[1] = %+v, want an insert at the start of the body", reqs[1].InsertText)
+	}
+
+	// An empty doc has nothing to delete.
+	if reqs := BuildReplaceRequests(1, []MessageBlock{{SenderName: "Status", Content: "ok"}}); len(reqs) != 3 || reqs[0].DeleteContentRange != nil {
+		t.Errorf("empty doc: got %d requests starting with %+v, want only the append", len(reqs), reqs[0])
+	}
+}
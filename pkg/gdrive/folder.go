@@ -14,6 +14,8 @@ const (
 	MimeTypeFolder = "application/vnd.google-apps.folder"
 	// MimeTypeDoc is the MIME type for Google Docs.
 	MimeTypeDoc = "application/vnd.google-apps.document"
+	// MimeTypeSheet is the MIME type for Google Sheets.
+	MimeTypeSheet = "application/vnd.google-apps.spreadsheet"
 )
 
 // FolderInfo contains information about a Drive folder.
@@ -230,6 +232,63 @@ func (c *Client) ShareFolderWithWriter(ctx context.Context, folderID, email stri
 	return nil
 }
 
+// Permission is an entry of a file's or folder's sharing list.
+type Permission struct {
+	ID           string
+	Type         string // user, group, domain, or anyone
+	Role         string // owner, organizer, fileOrganizer, writer, commenter, or reader
+	EmailAddress string // of a user or group
+	Domain       string // of a domain
+	// Inherited reports that the permission comes from a parent folder of
+	// a shared drive and cannot be removed from this item.
+	Inherited bool
+}
+
+// ListPermissions lists who a file or folder is shared with.
+func (c *Client) ListPermissions(ctx context.Context, fileID string) ([]Permission, error) {
+	var perms []Permission
+	pageToken := ""
+	for {
+		call := c.Drive.Permissions.List(fileID).
+			Context(ctx).
+			SupportsAllDrives(true).
+			Fields("nextPageToken, permissions(id, type, role, emailAddress, domain, permissionDetails)")
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		res, err := call.Do()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list permissions of %s: %w", fileID, err)
+		}
+		for _, p := range res.Permissions {
+			inherited := len(p.PermissionDetails) > 0
+			for _, d := range p.PermissionDetails {
+				inherited = inherited && d.Inherited
+			}
+			perms = append(perms, Permission{
+				ID:           p.Id,
+				Type:         p.Type,
+				Role:         p.Role,
+				EmailAddress: p.EmailAddress,
+				Domain:       p.Domain,
+				Inherited:    inherited,
+			})
+		}
+		if res.NextPageToken == "" {
+			return perms, nil
+		}
+		pageToken = res.NextPageToken
+	}
+}
+
+// DeletePermission removes a permission from a file or folder.
+func (c *Client) DeletePermission(ctx context.Context, fileID, permissionID string) error {
+	if err := c.Drive.Permissions.Delete(fileID, permissionID).Context(ctx).SupportsAllDrives(true).Do(); err != nil {
+		return fmt.Errorf("failed to remove permission %s from %s: %w", permissionID, fileID, err)
+	}
+	return nil
+}
+
 // UploadFile uploads a file to Google Drive.
 func (c *Client) UploadFile(ctx context.Context, name string, mimeType string, data []byte, parentID string) (string, error) {
 	file := &drive.File{
@@ -251,6 +310,11 @@ func (c *Client) UploadFile(ctx context.Context, name string, mimeType string, d
 	return res.Id, nil
 }
 
+// FileURL returns the link that opens fileID in Drive's file viewer.
+func FileURL(fileID string) string {
+	return "https://drive.google.com/file/d/" + fileID + "/view"
+}
+
 // GetWebContentLink retrieves the web content link for a file.
 func (c *Client) GetWebContentLink(ctx context.Context, fileID string) (string, error) {
 	file, err := c.Drive.Files.Get(fileID).
@@ -276,6 +340,66 @@ func (c *Client) MakePublic(ctx context.Context, fileID string) error {
 	return nil
 }
 
+// DriveItem is a file or folder listed in a Drive folder.
+type DriveItem struct {
+	ID       string
+	Name     string
+	MimeType string
+}
+
+// IsFolder reports whether the item is a folder.
+func (i *DriveItem) IsFolder() bool {
+	return i.MimeType == MimeTypeFolder
+}
+
+// ListChildren lists the files and folders directly inside a folder,
+// excluding trashed ones.
+func (c *Client) ListChildren(ctx context.Context, parentID string) ([]*DriveItem, error) {
+	query := fmt.Sprintf("'%s' in parents and trashed = false", parentID)
+
+	var items []*DriveItem
+	pageToken := ""
+	for {
+		req := c.Drive.Files.List().
+			Context(ctx).
+			Q(query).
+			Fields("nextPageToken, files(id, name, mimeType)").
+			PageSize(100)
+		if pageToken != "" {
+			req = req.PageToken(pageToken)
+		}
+
+		result, err := req.Do()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list folder %s: %w", parentID, err)
+		}
+		for _, f := range result.Files {
+			items = append(items, &DriveItem{ID: f.Id, Name: f.Name, MimeType: f.MimeType})
+		}
+
+		pageToken = result.NextPageToken
+		if pageToken == "" {
+			break
+		}
+	}
+	return items, nil
+}
+
+// MoveFile moves a file or folder from one parent folder to another. Its
+// ID, and so every link to it, stays the same.
+func (c *Client) MoveFile(ctx context.Context, fileID, fromParentID, toParentID string) error {
+	_, err := c.Drive.Files.Update(fileID, &drive.File{}).
+		Context(ctx).
+		AddParents(toParentID).
+		RemoveParents(fromParentID).
+		Fields("id").
+		Do()
+	if err != nil {
+		return fmt.Errorf("failed to move %s: %w", fileID, err)
+	}
+	return nil
+}
+
 // DeleteFile deletes a file from Google Drive.
 func (c *Client) DeleteFile(ctx context.Context, fileID string) error {
 	if err := c.Drive.Files.Delete(fileID).Context(ctx).Do(); err != nil {
@@ -11,8 +11,10 @@ import (
 
 	"google.golang.org/api/docs/v1"
 	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/gmail/v1"
 	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
 )
 
 // testClient creates a Client backed by a test HTTP server.
@@ -36,7 +38,19 @@ func testClient(t *testing.T, handler http.Handler) *Client {
 	if err != nil {
 		t.Fatal(err)
 	}
-	return &Client{Drive: driveService, Docs: docsService}
+	sheetsService, err := sheets.NewService(context.Background(),
+		option.WithHTTPClient(httpClient),
+		option.WithEndpoint(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gmailService, err := gmail.NewService(context.Background(),
+		option.WithHTTPClient(httpClient),
+		option.WithEndpoint(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Client{Drive: driveService, Docs: docsService, Sheets: sheetsService, Gmail: gmailService}
 }
 
 // ---------------------------------------------------------------------------
@@ -45,7 +59,7 @@ func testClient(t *testing.T, handler http.Handler) *Client {
 
 func TestRetryOnRateLimit_ImmediateSuccess(t *testing.T) {
 	called := 0
-	err := retryOnRateLimit(context.Background(), "test-op", func() error {
+	err := retryOnRateLimit(context.Background(), "test-op", nil, func() error {
 		called++
 		return nil
 	})
@@ -60,7 +74,7 @@ func TestRetryOnRateLimit_ImmediateSuccess(t *testing.T) {
 func TestRetryOnRateLimit_Non429ErrorReturnsImmediately(t *testing.T) {
 	called := 0
 	wantErr := fmt.Errorf("some other error")
-	err := retryOnRateLimit(context.Background(), "test-op", func() error {
+	err := retryOnRateLimit(context.Background(), "test-op", nil, func() error {
 		called++
 		return wantErr
 	})
@@ -75,7 +89,7 @@ func TestRetryOnRateLimit_Non429ErrorReturnsImmediately(t *testing.T) {
 func TestRetryOnRateLimit_Non429GoogleAPIError(t *testing.T) {
 	called := 0
 	apiErr := &googleapi.Error{Code: 403, Message: "forbidden"}
-	err := retryOnRateLimit(context.Background(), "test-op", func() error {
+	err := retryOnRateLimit(context.Background(), "test-op", nil, func() error {
 		called++
 		return apiErr
 	})
@@ -92,10 +106,15 @@ func TestRetryOnRateLimit_429WithCancelledContext(t *testing.T) {
 	cancel() // cancel immediately
 
 	called := 0
-	err := retryOnRateLimit(ctx, "test-op", func() error {
+	var notices []string
+	notify := func(msg string) { notices = append(notices, msg) }
+	err := retryOnRateLimit(ctx, "test-op", notify, func() error {
 		called++
 		return &googleapi.Error{Code: 429, Message: "rate limited"}
 	})
+	if len(notices) != 1 || !strings.HasPrefix(notices[0], "Rate limited on test-op") {
+		t.Errorf("notices = %q, want the wait reported", notices)
+	}
 
 	if err != context.Canceled {
 		t.Fatalf("expected context.Canceled, got %v", err)
@@ -532,6 +551,101 @@ func TestCreateDocument_NoFolder(t *testing.T) {
 	}
 }
 
+func TestCopyDocument(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/files/template-id/copy", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+		var reqBody map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		if reqBody["name"] != "2026-02-03" {
+			t.Errorf("expected name 2026-02-03, got %v", reqBody["name"])
+		}
+		if parents, _ := reqBody["parents"].([]interface{}); len(parents) != 1 || parents[0] != "parent-folder" {
+			t.Errorf("expected parents [parent-folder], got %v", reqBody["parents"])
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"id":          "copy-id",
+			"name":        "2026-02-03",
+			"webViewLink": "https://docs.google.com/document/d/copy-id",
+		})
+	})
+	c := testClient(t, mux)
+
+	doc, err := c.CopyDocument(context.Background(), "https://docs.google.com/document/d/template-id/edit", "2026-02-03", "parent-folder")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if doc.ID != "copy-id" || doc.Title != "2026-02-03" {
+		t.Errorf("CopyDocument() = %+v, want copy-id titled 2026-02-03", doc)
+	}
+}
+
+func TestCopyDocument_NotFound(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/files/template-id/copy", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":{"code":404,"message":"File not found: template-id."}}`))
+	})
+	c := testClient(t, mux)
+
+	_, err := c.CopyDocument(context.Background(), "template-id", "2026-02-03", "parent-folder")
+	if err == nil || !strings.Contains(err.Error(), "get-out created") {
+		t.Errorf("CopyDocument() error = %v, want a hint about drive.file access", err)
+	}
+}
+
+func TestFindOrCreateDocumentFromTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing bool
+		template string
+		wantPath string
+	}{
+		{"existing doc is reused", true, "template-id", ""},
+		{"new doc is copied", false, "template-id", "/files/template-id/copy"},
+		{"no template creates a blank doc", false, "", "/files"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created string
+			mux := http.NewServeMux()
+			mux.HandleFunc("/files", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if r.Method == http.MethodGet {
+					var files []map[string]string
+					if tt.existing {
+						files = append(files, map[string]string{"id": "existing-id", "name": "2026-02-03"})
+					}
+					json.NewEncoder(w).Encode(map[string]interface{}{"files": files})
+					return
+				}
+				created = r.URL.Path
+				json.NewEncoder(w).Encode(map[string]string{"id": "blank-id", "name": "2026-02-03"})
+			})
+			mux.HandleFunc("/files/template-id/copy", func(w http.ResponseWriter, r *http.Request) {
+				created = r.URL.Path
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(map[string]string{"id": "copy-id", "name": "2026-02-03"})
+			})
+			c := testClient(t, mux)
+
+			if _, err := c.FindOrCreateDocumentFromTemplate(context.Background(), "2026-02-03", "parent-folder", tt.template); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if created != tt.wantPath {
+				t.Errorf("created via %q, want %q", created, tt.wantPath)
+			}
+		})
+	}
+}
+
 // ---------------------------------------------------------------------------
 // CreateFolder tests
 // ---------------------------------------------------------------------------
@@ -2446,6 +2560,50 @@ func TestDeleteFile_Success(t *testing.T) {
 	}
 }
 
+func TestMoveFile(t *testing.T) {
+	var gotMethod, gotAdd, gotRemove string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/files/doc-1", func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotAdd, gotRemove = r.URL.Query().Get("addParents"), r.URL.Query().Get("removeParents")
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": "doc-1"})
+	})
+
+	c := testClient(t, mux)
+	if err := c.MoveFile(context.Background(), "doc-1", "old-folder", "new-folder"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotMethod != http.MethodPatch || gotAdd != "new-folder" || gotRemove != "old-folder" {
+		t.Errorf("request = %s add=%q remove=%q, want a PATCH moving to new-folder", gotMethod, gotAdd, gotRemove)
+	}
+}
+
+func TestListChildren(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/files", func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query().Get("q"); q != "'folder-1' in parents and trashed = false" {
+			t.Errorf("q = %q", q)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"files": []map[string]string{
+				{"id": "d1", "name": "2024-01-02", "mimeType": MimeTypeDoc},
+				{"id": "f1", "name": "Threads", "mimeType": MimeTypeFolder},
+			},
+		})
+	})
+
+	c := testClient(t, mux)
+	items, err := c.ListChildren(context.Background(), "folder-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(items) != 2 || items[0].IsFolder() || !items[1].IsFolder() || items[1].Name != "Threads" {
+		t.Errorf("items = %+v", items)
+	}
+}
+
 func TestDeleteFile_APIError(t *testing.T) {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -2466,3 +2624,67 @@ func TestDeleteFile_APIError(t *testing.T) {
 		t.Fatal("expected error for HTTP 500")
 	}
 }
+
+func TestListPermissions_Pagination(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/files/folder-1/permissions", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("pageToken") == "" {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"nextPageToken": "page2",
+				"permissions": []map[string]interface{}{
+					{"id": "p1", "type": "user", "role": "owner", "emailAddress": "me@example.com"},
+					{"id": "p2", "type": "domain", "role": "reader", "domain": "example.com"},
+				},
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"permissions": []map[string]interface{}{
+				{"id": "p3", "type": "user", "role": "writer", "emailAddress": "bob@example.com",
+					"permissionDetails": []map[string]interface{}{{"inherited": true, "role": "writer"}}},
+			},
+		})
+	})
+	c := testClient(t, mux)
+
+	perms, err := c.ListPermissions(context.Background(), "folder-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(perms) != 3 {
+		t.Fatalf("expected 3 permissions, got %d", len(perms))
+	}
+	if perms[0].Role != "owner" || perms[0].EmailAddress != "me@example.com" || perms[0].Inherited {
+		t.Errorf("unexpected first permission: %+v", perms[0])
+	}
+	if perms[1].Type != "domain" || perms[1].Domain != "example.com" {
+		t.Errorf("unexpected domain permission: %+v", perms[1])
+	}
+	if !perms[2].Inherited {
+		t.Errorf("expected inherited permission: %+v", perms[2])
+	}
+}
+
+func TestDeletePermission(t *testing.T) {
+	var deleted string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/files/folder-1/permissions/p2", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("expected DELETE, got %s", r.Method)
+		}
+		deleted = r.URL.Path
+		w.WriteHeader(http.StatusNoContent)
+	})
+	c := testClient(t, mux)
+
+	if err := c.DeletePermission(context.Background(), "folder-1", "p2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if deleted == "" {
+		t.Error("permission was not deleted")
+	}
+	if err := c.DeletePermission(context.Background(), "folder-1", "missing"); err == nil {
+		t.Error("expected error for unknown permission")
+	}
+}
@@ -0,0 +1,42 @@
+package gdrive
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+)
+
+// SendMail sends msg, an RFC 5322 message, with the Gmail API as the
+// authorized account. The token must have been authorized with
+// Config.GmailSend.
+func (c *Client) SendMail(ctx context.Context, msg []byte) error {
+	raw := base64.URLEncoding.EncodeToString(msg)
+	_, err := c.Gmail.Users.Messages.Send("me", &gmail.Message{Raw: raw}).Context(ctx).Do()
+	if err != nil {
+		if isScopeError(err) {
+			return fmt.Errorf("failed to send mail: the Google token is not allowed to send mail (log in again with 'get-out auth login'): %w", err)
+		}
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	return nil
+}
+
+// isScopeError reports whether err is Google refusing a request because
+// the token was not authorized for its scope.
+func isScopeError(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusForbidden {
+		return false
+	}
+	for _, item := range apiErr.Errors {
+		if item.Reason == "insufficientPermissions" {
+			return true
+		}
+	}
+	return false
+}
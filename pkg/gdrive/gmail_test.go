@@ -0,0 +1,52 @@
+package gdrive
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSendMail(t *testing.T) {
+	var raw string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/gmail/v1/users/me/messages/send", func(w http.ResponseWriter, r *http.Request) {
+		var msg struct{ Raw string }
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		raw = msg.Raw
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"id": "msg-id"})
+	})
+
+	c := testClient(t, mux)
+	if err := c.SendMail(context.Background(), []byte("To: a@example.com\r\n\r\nhi")); err != nil {
+		t.Fatalf("SendMail() error: %v", err)
+	}
+	got, err := base64.URLEncoding.DecodeString(raw)
+	if err != nil || string(got) != "To: a@example.com\r\n\r\nhi" {
+		t.Errorf("raw = %q (%v), want the message base64url-encoded", got, err)
+	}
+}
+
+func TestSendMail_MissingScope(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/gmail/v1/users/me/messages/send", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{
+			"code":    403,
+			"message": "Request had insufficient authentication scopes.",
+			"errors":  []any{map[string]string{"reason": "insufficientPermissions"}},
+		}})
+	})
+
+	c := testClient(t, mux)
+	err := c.SendMail(context.Background(), []byte("hi"))
+	if err == nil || !strings.Contains(err.Error(), "auth login") {
+		t.Errorf("SendMail() error = %v, want it to say to log in again", err)
+	}
+}
@@ -0,0 +1,92 @@
+package gdrive
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Observer is told about every Google API request a client makes, e.g. to
+// export metrics. Implementations must be safe for concurrent use.
+type Observer interface {
+	// ObserveGoogleRequest is called after each request with its name
+	// (see RequestName), the HTTP status it was answered with, and the
+	// transport error, if any, in which case status is 0.
+	ObserveGoogleRequest(name string, status int, err error)
+}
+
+// RequestName names a Google API request after the method it calls, such
+// as "docs.batchUpdate", "drive.files.list", "drive.files.upload", or
+// "drive.permissions.create".
+func RequestName(req *http.Request) string {
+	path := req.URL.Path
+	if strings.Contains(req.URL.Host, "docs.googleapis.com") {
+		switch {
+		case strings.HasSuffix(path, ":batchUpdate"):
+			return "docs.batchUpdate"
+		case req.Method == http.MethodPost:
+			return "docs.create"
+		}
+		return "docs.get"
+	}
+
+	if strings.HasPrefix(path, "/upload/") {
+		return "drive.files.upload"
+	}
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	// drive/v3/files[/{id}[/permissions[/{id}]]]
+	for len(parts) > 0 && parts[0] != "files" {
+		parts = parts[1:]
+	}
+	resource, collection := "files", len(parts) <= 1
+	if len(parts) >= 3 {
+		resource, collection = parts[2], len(parts) == 3
+	}
+	var verb string
+	switch req.Method {
+	case http.MethodGet:
+		verb = "get"
+		if collection {
+			verb = "list"
+		}
+	case http.MethodPost:
+		verb = "create"
+		if len(parts) == 3 && parts[2] == "copy" {
+			resource, verb = "files", "copy"
+		}
+	case http.MethodPatch, http.MethodPut:
+		verb = "update"
+	case http.MethodDelete:
+		verb = "delete"
+	default:
+		verb = strings.ToLower(req.Method)
+	}
+	return "drive." + resource + "." + verb
+}
+
+// observeTransport tells an Observer about every request it sends.
+type observeTransport struct {
+	base     http.RoundTripper
+	observer Observer
+}
+
+func (o *observeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := o.base.RoundTrip(req)
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	o.observer.ObserveGoogleRequest(RequestName(req), status, err)
+	return resp, err
+}
+
+// withObserver returns a copy of httpClient whose requests are reported to
+// observer.
+func withObserver(httpClient *http.Client, observer Observer) *http.Client {
+	base := httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	wrapped := *httpClient
+	wrapped.Transport = &observeTransport{base: base, observer: observer}
+	return &wrapped
+}
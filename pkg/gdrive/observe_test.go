@@ -0,0 +1,56 @@
+package gdrive
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRequestName(t *testing.T) {
+	tests := []struct {
+		method, url string
+		want        string
+	}{
+		{http.MethodPost, "https://docs.googleapis.com/v1/documents/abc:batchUpdate", "docs.batchUpdate"},
+		{http.MethodGet, "https://docs.googleapis.com/v1/documents/abc", "docs.get"},
+		{http.MethodPost, "https://docs.googleapis.com/v1/documents", "docs.create"},
+		{http.MethodGet, "https://www.googleapis.com/drive/v3/files?q=x", "drive.files.list"},
+		{http.MethodGet, "https://www.googleapis.com/drive/v3/files/abc", "drive.files.get"},
+		{http.MethodPost, "https://www.googleapis.com/drive/v3/files", "drive.files.create"},
+		{http.MethodPatch, "https://www.googleapis.com/drive/v3/files/abc", "drive.files.update"},
+		{http.MethodDelete, "https://www.googleapis.com/drive/v3/files/abc", "drive.files.delete"},
+		{http.MethodPost, "https://www.googleapis.com/drive/v3/files/abc/copy", "drive.files.copy"},
+		{http.MethodGet, "https://www.googleapis.com/drive/v3/files/abc/permissions", "drive.permissions.list"},
+		{http.MethodPost, "https://www.googleapis.com/drive/v3/files/abc/permissions", "drive.permissions.create"},
+		{http.MethodDelete, "https://www.googleapis.com/drive/v3/files/abc/permissions/p1", "drive.permissions.delete"},
+		{http.MethodPost, "https://www.googleapis.com/upload/drive/v3/files?uploadType=multipart", "drive.files.upload"},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, tt.url, nil)
+		if got := RequestName(req); got != tt.want {
+			t.Errorf("RequestName(%s %s) = %q, want %q", tt.method, tt.url, got, tt.want)
+		}
+	}
+}
+
+type recordingObserver struct{ seen []string }
+
+func (o *recordingObserver) ObserveGoogleRequest(name string, status int, err error) {
+	o.seen = append(o.seen, name+" "+http.StatusText(status))
+}
+
+func TestWithObserver_ReportsRequests(t *testing.T) {
+	base := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusTooManyRequests, Body: http.NoBody, Request: req}, nil
+	})}
+	observer := &recordingObserver{}
+	client := withObserver(base, observer)
+	resp, err := client.Post("https://docs.googleapis.com/v1/documents/abc:batchUpdate", "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := strings.Join(observer.seen, "|"); got != "docs.batchUpdate Too Many Requests" {
+		t.Errorf("observed %q, want the batchUpdate and its status", got)
+	}
+}
@@ -0,0 +1,285 @@
+package gdrive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QuotaKind classifies a Google API request for quota accounting.
+type QuotaKind string
+
+const (
+	QuotaDocsWrite  QuotaKind = "docs_write"  // documents.create / batchUpdate
+	QuotaDocsRead   QuotaKind = "docs_read"   // documents.get
+	QuotaDriveQuery QuotaKind = "drive_query" // files.list / files.get
+	QuotaDriveWrite QuotaKind = "drive_write" // files.create, permissions, uploads, deletes
+)
+
+// quotaKinds lists every kind in display order.
+var quotaKinds = []QuotaKind{QuotaDocsWrite, QuotaDocsRead, QuotaDriveQuery, QuotaDriveWrite}
+
+// QuotaSlowdownAt is the fraction of a daily budget after which requests of
+// that kind are spread evenly over the rest of the quota window instead of
+// being sent as fast as possible.
+const QuotaSlowdownAt = 0.9
+
+// quotaSaveEvery is how many requests are counted between saves of the
+// usage file; Save writes the rest.
+const quotaSaveEvery = 25
+
+// QuotaLimits are daily request budgets per kind (0 = unlimited). They
+// should sit below the Google Cloud project's quota, which is shared by
+// every get-out run using the same credentials.
+type QuotaLimits map[QuotaKind]int
+
+// QuotaUsage is the persisted request count for one quota window.
+type QuotaUsage struct {
+	Window string            `json:"window"` // YYYY-MM-DD in Pacific time
+	Counts map[QuotaKind]int `json:"counts"`
+}
+
+// QuotaTracker counts Google API requests per kind against daily budgets,
+// persisting the counts so consecutive runs on the same day share them.
+// Google's daily quotas reset at midnight Pacific time. When a budget is
+// nearly used up, Wait slows requests down; when it is used up, Wait pauses
+// until the window rolls over. It is safe for concurrent use.
+type QuotaTracker struct {
+	path   string
+	limits QuotaLimits
+
+	// OnPause, when set, is told when a request waits for the next
+	// quota window.
+	OnPause func(kind QuotaKind, until time.Time)
+
+	mu      sync.Mutex
+	usage   QuotaUsage
+	run     map[QuotaKind]int // requests in this run
+	unsaved int
+	now     func() time.Time
+	sleep   func(ctx context.Context, d time.Duration) error
+}
+
+// DefaultQuotaPath returns the default quota usage file.
+func DefaultQuotaPath(configDir string) string {
+	return filepath.Join(configDir, "_metadata", "gdrive-quota.json")
+}
+
+// NewQuotaTracker loads the usage recorded at path and returns a tracker
+// enforcing limits. A missing file starts from zero.
+func NewQuotaTracker(path string, limits QuotaLimits) (*QuotaTracker, error) {
+	t := &QuotaTracker{
+		path:   path,
+		limits: limits,
+		run:    make(map[QuotaKind]int),
+		now:    time.Now,
+		sleep:  sleepContext,
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read quota usage: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &t.usage); err != nil {
+			return nil, fmt.Errorf("failed to parse quota usage %s: %w", path, err)
+		}
+	}
+	return t, nil
+}
+
+// Wait blocks until a request of kind may be sent, then counts it. Below
+// QuotaSlowdownAt of the budget it returns at once; above it, requests are
+// spaced so the rest of the budget lasts until the window rolls over; with
+// the budget used up it waits for the next window. It returns ctx.Err() if
+// the context is cancelled while waiting.
+func (t *QuotaTracker) Wait(ctx context.Context, kind QuotaKind) error {
+	for {
+		t.mu.Lock()
+		t.rollLocked()
+		limit := t.limits[kind]
+		used := t.usage.Counts[kind]
+		if limit <= 0 || float64(used) < QuotaSlowdownAt*float64(limit) {
+			t.countLocked(kind)
+			t.mu.Unlock()
+			return nil
+		}
+		rollover := nextQuotaWindow(t.now())
+		remaining := rollover.Sub(t.now())
+		if used < limit {
+			// Spread what is left over the rest of the window.
+			delay := remaining / time.Duration(limit-used+1)
+			t.countLocked(kind)
+			t.mu.Unlock()
+			return t.sleep(ctx, delay)
+		}
+		onPause := t.OnPause
+		t.saveLocked()
+		t.mu.Unlock()
+
+		if onPause != nil {
+			onPause(kind, rollover)
+		}
+		if err := t.sleep(ctx, remaining); err != nil {
+			return err
+		}
+	}
+}
+
+// countLocked records one request. Caller must hold t.mu.
+func (t *QuotaTracker) countLocked(kind QuotaKind) {
+	t.usage.Counts[kind]++
+	t.run[kind]++
+	t.unsaved++
+	if t.unsaved >= quotaSaveEvery {
+		t.saveLocked()
+	}
+}
+
+// rollLocked starts a new window when the day has changed. Caller must
+// hold t.mu.
+func (t *QuotaTracker) rollLocked() {
+	if window := quotaWindow(t.now()); t.usage.Window != window {
+		t.usage = QuotaUsage{Window: window}
+	}
+	if t.usage.Counts == nil {
+		t.usage.Counts = make(map[QuotaKind]int)
+	}
+}
+
+// Save writes the usage file. Errors are returned so callers can warn;
+// counting continues in memory either way.
+func (t *QuotaTracker) Save() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.saveLocked()
+}
+
+func (t *QuotaTracker) saveLocked() error {
+	t.unsaved = 0
+	data, err := json.MarshalIndent(t.usage, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode quota usage: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0755); err != nil {
+		return fmt.Errorf("failed to create quota directory: %w", err)
+	}
+	tmp := t.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write quota usage: %w", err)
+	}
+	if err := os.Rename(tmp, t.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write quota usage: %w", err)
+	}
+	return nil
+}
+
+// Summary describes this run's requests and today's usage against the
+// budgets, e.g. "docs_write 120 (today 940/1000), drive_query 48 (today 300)".
+func (t *QuotaTracker) Summary() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollLocked()
+	var parts []string
+	for _, kind := range quotaKinds {
+		if t.run[kind] == 0 && t.usage.Counts[kind] == 0 {
+			continue
+		}
+		today := fmt.Sprintf("%d", t.usage.Counts[kind])
+		if limit := t.limits[kind]; limit > 0 {
+			today += fmt.Sprintf("/%d", limit)
+		}
+		parts = append(parts, fmt.Sprintf("%s %d (today %s)", kind, t.run[kind], today))
+	}
+	if len(parts) == 0 {
+		return "no requests"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// RunCount returns how many requests of kind this run has made.
+func (t *QuotaTracker) RunCount(kind QuotaKind) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.run[kind]
+}
+
+// quotaLocation is the time zone in which Google's daily quotas reset.
+var quotaLocation = func() *time.Location {
+	if loc, err := time.LoadLocation("America/Los_Angeles"); err == nil {
+		return loc
+	}
+	return time.FixedZone("PST", -8*60*60)
+}()
+
+// quotaWindow returns the quota day containing now.
+func quotaWindow(now time.Time) string {
+	return now.In(quotaLocation).Format("2006-01-02")
+}
+
+// nextQuotaWindow returns when the quota day containing now ends.
+func nextQuotaWindow(now time.Time) time.Time {
+	local := now.In(quotaLocation)
+	y, m, d := local.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, quotaLocation)
+}
+
+// ClassifyRequest returns the quota kind of a Google API request.
+func ClassifyRequest(req *http.Request) QuotaKind {
+	if strings.Contains(req.URL.Host, "docs.googleapis.com") {
+		if req.Method == http.MethodGet {
+			return QuotaDocsRead
+		}
+		return QuotaDocsWrite
+	}
+	if req.Method == http.MethodGet && !strings.HasPrefix(req.URL.Path, "/upload/") {
+		return QuotaDriveQuery
+	}
+	return QuotaDriveWrite
+}
+
+// quotaTransport counts every request against a QuotaTracker before
+// sending it.
+type quotaTransport struct {
+	base    http.RoundTripper
+	tracker *QuotaTracker
+}
+
+func (q *quotaTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := q.tracker.Wait(req.Context(), ClassifyRequest(req)); err != nil {
+		return nil, err
+	}
+	return q.base.RoundTrip(req)
+}
+
+// withQuota returns a copy of httpClient whose requests are counted by
+// tracker.
+func withQuota(httpClient *http.Client, tracker *QuotaTracker) *http.Client {
+	base := httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	wrapped := *httpClient
+	wrapped.Transport = &quotaTransport{base: base, tracker: tracker}
+	return &wrapped
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,161 @@
+package gdrive
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testQuotaTracker returns a tracker at a fixed time whose sleeps are
+// recorded instead of taken; each sleep advances the clock.
+func testQuotaTracker(t *testing.T, path string, limits QuotaLimits, now time.Time) (*QuotaTracker, *[]time.Duration) {
+	t.Helper()
+	tracker, err := NewQuotaTracker(path, limits)
+	if err != nil {
+		t.Fatalf("NewQuotaTracker() error: %v", err)
+	}
+	var slept []time.Duration
+	tracker.now = func() time.Time { return now }
+	tracker.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		now = now.Add(d)
+		return ctx.Err()
+	}
+	return tracker, &slept
+}
+
+func TestQuotaTracker_SlowsThenPausesUntilRollover(t *testing.T) {
+	// 20:00 UTC on 2024-02-01 is noon Pacific; the window ends 12h later.
+	now := time.Date(2024, 2, 1, 20, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "quota.json")
+	tracker, slept := testQuotaTracker(t, path, QuotaLimits{QuotaDocsWrite: 10}, now)
+	var pausedUntil time.Time
+	tracker.OnPause = func(kind QuotaKind, until time.Time) { pausedUntil = until }
+	ctx := context.Background()
+
+	for i := 0; i < 9; i++ {
+		if err := tracker.Wait(ctx, QuotaDocsWrite); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(*slept) != 0 {
+		t.Fatalf("slept %v below the slowdown threshold", *slept)
+	}
+
+	// The 10th request is paced over the rest of the window.
+	if err := tracker.Wait(ctx, QuotaDocsWrite); err != nil {
+		t.Fatal(err)
+	}
+	if len(*slept) != 1 || (*slept)[0] != 6*time.Hour {
+		t.Fatalf("slept %v, want [6h]", *slept)
+	}
+
+	// The 11th waits for the next window, then starts a fresh count.
+	if err := tracker.Wait(ctx, QuotaDocsWrite); err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 2, 2, 8, 0, 0, 0, time.UTC); !pausedUntil.Equal(want) {
+		t.Errorf("paused until %v, want %v", pausedUntil, want)
+	}
+	if got := tracker.usage.Counts[QuotaDocsWrite]; got != 1 || tracker.usage.Window != "2024-02-02" {
+		t.Errorf("after rollover usage = %+v, want 1 in 2024-02-02", tracker.usage)
+	}
+	if got := tracker.RunCount(QuotaDocsWrite); got != 11 {
+		t.Errorf("RunCount() = %d, want 11", got)
+	}
+
+	// Unbudgeted kinds are counted but never slowed.
+	for i := 0; i < 50; i++ {
+		_ = tracker.Wait(ctx, QuotaDriveQuery)
+	}
+	if len(*slept) != 2 {
+		t.Errorf("unbudgeted requests slept: %v", *slept)
+	}
+}
+
+func TestQuotaTracker_PersistsWithinWindow(t *testing.T) {
+	now := time.Date(2024, 2, 1, 20, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "_metadata", "quota.json")
+	tracker, _ := testQuotaTracker(t, path, QuotaLimits{QuotaDriveQuery: 100}, now)
+	for i := 0; i < 3; i++ {
+		_ = tracker.Wait(context.Background(), QuotaDriveQuery)
+	}
+	if err := tracker.Save(); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	// A later run the same Pacific day continues the count.
+	again, _ := testQuotaTracker(t, path, QuotaLimits{QuotaDriveQuery: 100}, now.Add(time.Hour))
+	_ = again.Wait(context.Background(), QuotaDriveQuery)
+	if got := again.Summary(); got != "drive_query 1 (today 4/100)" {
+		t.Errorf("Summary() = %q", got)
+	}
+
+	// A run after midnight Pacific starts from zero.
+	next, _ := testQuotaTracker(t, path, nil, now.Add(13*time.Hour))
+	if got := next.Summary(); got != "no requests" {
+		t.Errorf("Summary() next day = %q", got)
+	}
+}
+
+func TestQuotaTracker_WaitCancelled(t *testing.T) {
+	now := time.Date(2024, 2, 1, 20, 0, 0, 0, time.UTC)
+	tracker, _ := testQuotaTracker(t, filepath.Join(t.TempDir(), "quota.json"), QuotaLimits{QuotaDocsWrite: 1}, now)
+	_ = tracker.Wait(context.Background(), QuotaDocsWrite)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := tracker.Wait(ctx, QuotaDocsWrite); err != context.Canceled {
+		t.Errorf("Wait() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestClassifyRequest(t *testing.T) {
+	tests := []struct {
+		method, url string
+		want        QuotaKind
+	}{
+		{http.MethodPost, "https://docs.googleapis.com/v1/documents/abc:batchUpdate", QuotaDocsWrite},
+		{http.MethodGet, "https://docs.googleapis.com/v1/documents/abc", QuotaDocsRead},
+		{http.MethodGet, "https://www.googleapis.com/drive/v3/files?q=x", QuotaDriveQuery},
+		{http.MethodPost, "https://www.googleapis.com/drive/v3/files", QuotaDriveWrite},
+		{http.MethodPost, "https://www.googleapis.com/upload/drive/v3/files", QuotaDriveWrite},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, tt.url, nil)
+		if got := ClassifyRequest(req); got != tt.want {
+			t.Errorf("ClassifyRequest(%s %s) = %s, want %s", tt.method, tt.url, got, tt.want)
+		}
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestWithQuota_CountsRequests(t *testing.T) {
+	tracker, err := NewQuotaTracker(filepath.Join(t.TempDir(), "quota.json"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})}
+	client := withQuota(base, tracker)
+	if _, ok := base.Transport.(roundTripFunc); !ok {
+		t.Fatal("withQuota modified the original client")
+	}
+	for _, url := range []string{"https://docs.googleapis.com/v1/documents", "https://www.googleapis.com/drive/v3/files"} {
+		resp, err := client.Post(url, "application/json", strings.NewReader("{}"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if tracker.RunCount(QuotaDocsWrite) != 1 || tracker.RunCount(QuotaDriveWrite) != 1 {
+		t.Errorf("Summary() = %q, want one docs write and one drive write", tracker.Summary())
+	}
+}
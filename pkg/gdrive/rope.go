@@ -0,0 +1,157 @@
+package gdrive
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// docRope lays out text appended to a document piece by piece (a header,
+// body runs, the parts of a table, images) and tracks the document index of
+// every piece in the UTF-16 code units the Docs API counts. Its source text
+// is the pieces as given, including ones left out of the document, so a
+// byte offset found by searching that text (e.g. for a link) can be turned
+// into a document index whatever was dropped or reshaped on the way.
+type docRope struct {
+	end    int64 // document index after the last piece
+	offset int   // byte length of the source text so far
+	segs   []ropeSegment
+}
+
+// ropeSegment is one piece of a docRope's source text.
+type ropeSegment struct {
+	offset   int    // byte offset in the source text
+	index    int64  // document index of its first code unit
+	text     string // source text
+	inserted bool   // whether text is in the document or was left out
+}
+
+// newDocRope returns an empty rope whose first piece goes at index.
+func newDocRope(index int64) *docRope {
+	return &docRope{end: index}
+}
+
+// End returns the document index after the last piece.
+func (r *docRope) End() int64 {
+	return r.end
+}
+
+// Offset returns the byte length of the source text so far, the offset the
+// next piece starts at.
+func (r *docRope) Offset() int {
+	return r.offset
+}
+
+// Insert adds text to the end of the rope. It returns the text to send,
+// with what the Docs API would drop or replace already dropped or replaced
+// (see docSafe), and the document range it occupies.
+func (r *docRope) Insert(text string) (string, int64, int64) {
+	safe := docSafe(text)
+	start := r.end
+	r.add(text, true)
+	r.end += utf16Len(safe)
+	return safe, start, r.end
+}
+
+// Omit adds text to the source text without inserting it, as when a
+// table's paragraph breaks stand in for the newlines around a code block.
+// Offsets within it map to where it would have gone.
+func (r *docRope) Omit(text string) {
+	r.add(text, false)
+}
+
+// Skip adds n code units inserted by something other than text, such as
+// an inline image or a table's structure.
+func (r *docRope) Skip(n int64) {
+	r.end += n
+}
+
+func (r *docRope) add(text string, inserted bool) {
+	if text == "" {
+		return
+	}
+	r.segs = append(r.segs, ropeSegment{offset: r.offset, index: r.end, text: text, inserted: inserted})
+	r.offset += len(text)
+}
+
+// Index returns the document index of a byte offset in the source text:
+// where what starts there is. Offsets past the end map to the end of the
+// last piece.
+func (r *docRope) Index(offset int) int64 {
+	return r.index(offset, false)
+}
+
+// Range returns the document range of the source text [start, end). Its
+// end is where the text before end stops, so a range ending at a code
+// block's text does not take in the table around it.
+func (r *docRope) Range(start, end int) (int64, int64) {
+	return r.index(start, false), r.index(end, true)
+}
+
+// index returns the document index of offset, looked up in the piece
+// starting at offset or, when atEnd, in the piece before it.
+func (r *docRope) index(offset int, atEnd bool) int64 {
+	i := sort.Search(len(r.segs), func(i int) bool {
+		if atEnd {
+			return r.segs[i].offset >= offset
+		}
+		return r.segs[i].offset > offset
+	}) - 1
+	if i < 0 {
+		if len(r.segs) == 0 {
+			return r.end
+		}
+		return r.segs[0].index
+	}
+	seg := r.segs[i]
+	if !seg.inserted {
+		return seg.index
+	}
+	return seg.index + docLen(seg.text[:min(offset-seg.offset, len(seg.text))])
+}
+
+// docDropsRune reports whether the Docs API strips c from inserted text:
+// control characters other than tab, newline, and vertical tab, and the
+// Basic Multilingual Plane's Private Use Area.
+func docDropsRune(c rune) bool {
+	return c <= 0x08 || (c >= 0x0c && c <= 0x1f) || (c >= 0xe000 && c <= 0xf8ff)
+}
+
+// docSafe returns text as a document holds it once inserted: the runes the
+// Docs API strips removed, and each byte of invalid UTF-8 replaced with
+// U+FFFD, as encoding it to JSON would.
+func docSafe(text string) string {
+	clean := true
+	for _, c := range text {
+		if c == utf8.RuneError || docDropsRune(c) {
+			clean = false
+			break
+		}
+	}
+	if clean {
+		return text
+	}
+	var b strings.Builder
+	for _, c := range text {
+		if !docDropsRune(c) {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// docLen returns the length of text once inserted into a document, in
+// UTF-16 code units: utf16Len of docSafe(text).
+func docLen(text string) int64 {
+	var n int64
+	for _, c := range text {
+		switch {
+		case docDropsRune(c):
+		case c >= 0x10000:
+			n += 2
+		default:
+			n++
+		}
+	}
+	return n
+}
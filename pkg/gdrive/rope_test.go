@@ -0,0 +1,275 @@
+package gdrive
+
+import (
+	"math/rand"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"testing/quick"
+	"unicode/utf16"
+
+	"google.golang.org/api/docs/v1"
+)
+
+func TestDocSafe(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "plain", text: "hi there\n", want: "hi there\n"},
+		{name: "emoji", text: "👍🏽 ok", want: "👍🏽 ok"},
+		{name: "tab and vertical tab kept", text: "a\tb\vc", want: "a\tb\vc"},
+		{name: "control characters dropped", text: "a\x00b\x08c\rd\x1fe", want: "abcde"},
+		{name: "private use dropped", text: "abc", want: "abc"},
+		{name: "invalid utf-8 replaced", text: "a\xffb", want: "a�b"},
+		{name: "empty", text: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := docSafe(tt.text); got != tt.want {
+				t.Errorf("docSafe(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+			if got, want := docLen(tt.text), utf16Len(tt.want); got != want {
+				t.Errorf("docLen(%q) = %d, want %d", tt.text, got, want)
+			}
+		})
+	}
+}
+
+func TestDocRope(t *testing.T) {
+	r := newDocRope(10)
+	if text, start, end := r.Insert("a😀\r"); text != "a😀" || start != 10 || end != 13 {
+		t.Errorf("Insert() = %q, %d, %d, want \"a😀\", 10, 13", text, start, end)
+	}
+	r.Omit("\n")
+	r.Skip(4)
+	if _, start, end := r.Insert("code"); start != 17 || end != 21 {
+		t.Errorf("Insert() after a skip = %d, %d, want 17, 21", start, end)
+	}
+	r.Skip(2)
+
+	tests := []struct {
+		name       string
+		start, end int
+		want       [2]int64
+	}{
+		{name: "ascii", start: 0, end: 1, want: [2]int64{10, 11}},
+		{name: "surrogate pair", start: 1, end: 5, want: [2]int64{11, 13}},
+		{name: "dropped character", start: 5, end: 6, want: [2]int64{13, 13}},
+		{name: "omitted newline", start: 6, end: 7, want: [2]int64{13, 13}},
+		{name: "code", start: 7, end: 11, want: [2]int64{17, 21}},
+		{name: "ending before code", start: 0, end: 7, want: [2]int64{10, 13}},
+		{name: "past the end", start: 11, end: 20, want: [2]int64{21, 21}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := r.Range(tt.start, tt.end)
+			if got := [2]int64{start, end}; got != tt.want {
+				t.Errorf("Range(%d, %d) = %v, want %v", tt.start, tt.end, got, tt.want)
+			}
+		})
+	}
+	if got := r.End(); got != 23 {
+		t.Errorf("End() = %d, want 23", got)
+	}
+	if got := r.Offset(); got != len("a😀\r\ncode") {
+		t.Errorf("Offset() = %d, want %d", got, len("a😀\r\ncode"))
+	}
+}
+
+// Structure units in a simulated document: tables and inline images.
+const simStructure = -1
+
+// simDoc applies append requests to a document of UTF-16 code units the
+// way the Docs API does, to check the indices the requests were built with.
+type simDoc struct {
+	units []int32
+}
+
+func newSimDoc(endIndex int64) *simDoc {
+	return &simDoc{units: make([]int32, endIndex)}
+}
+
+func (d *simDoc) insert(t *testing.T, index int64, units ...int32) {
+	t.Helper()
+	if index < 1 || index > int64(len(d.units)) {
+		t.Fatalf("insert at %d, document ends at %d", index, len(d.units))
+	}
+	d.units = slices.Insert(d.units, int(index), units...)
+}
+
+func (d *simDoc) apply(t *testing.T, reqs []*docs.Request) {
+	t.Helper()
+	for _, req := range reqs {
+		switch {
+		case req.InsertText != nil:
+			text := req.InsertText.Text
+			if docSafe(text) != text {
+				t.Fatalf("inserted text %q holds characters the API drops", text)
+			}
+			var units []int32
+			for _, u := range utf16.Encode([]rune(text)) {
+				units = append(units, int32(u))
+			}
+			d.insert(t, req.InsertText.Location.Index, units...)
+		case req.InsertInlineImage != nil:
+			d.insert(t, req.InsertInlineImage.Location.Index, simStructure)
+		case req.InsertTable != nil:
+			// The newline before the table, the table, row and cell
+			// starts, the cell's paragraph, and the table end.
+			d.insert(t, req.InsertTable.Location.Index, '\n', simStructure, simStructure, simStructure, '\n', simStructure)
+		}
+	}
+}
+
+// text returns the text in [start, end), or false when the range is out of
+// bounds or takes in a table or image.
+func (d *simDoc) text(start, end int64) (string, bool) {
+	if start < 1 || start > end || end > int64(len(d.units)) {
+		return "", false
+	}
+	var units []uint16
+	for _, u := range d.units[start:end] {
+		if u == simStructure {
+			return "", false
+		}
+		units = append(units, uint16(u))
+	}
+	return string(utf16.Decode(units)), true
+}
+
+// ropePieces are the pieces random messages are built from: emoji and ZWJ
+// sequences, characters the Docs API drops, and invalid UTF-8.
+var ropePieces = []string{
+	"a", "word", " ", "\n", "\t", "中文", "é", "😀", "👍🏽", "👩‍💻", "🇺🇸",
+	"\r", "\x00", "\x1b[0m", "\ue000", "\uf8ff", "\xff", "�",
+}
+
+// randomText returns one to n pieces of ropePieces.
+func randomText(rnd *rand.Rand, n int) string {
+	var b strings.Builder
+	for range 1 + rnd.Intn(n) {
+		b.WriteString(ropePieces[rnd.Intn(len(ropePieces))])
+	}
+	return b.String()
+}
+
+// ropeMessage is a random message with a link on one of its runs.
+type ropeMessage struct {
+	msg  MessageBlock
+	link int // index of the run the link is on
+	runs []FormattedText
+}
+
+func (ropeMessage) Generate(rnd *rand.Rand, size int) reflect.Value {
+	m := ropeMessage{msg: MessageBlock{
+		SenderName: "Al" + randomText(rnd, 3),
+		Timestamp:  "9:" + randomText(rnd, 2),
+	}}
+	var content strings.Builder
+	for range 1 + rnd.Intn(5) {
+		run := FormattedText{
+			Text:      randomText(rnd, 4),
+			Bold:      rnd.Intn(3) == 0,
+			CodeBlock: rnd.Intn(4) == 0,
+			Quote:     rnd.Intn(5) == 0,
+		}
+		m.runs = append(m.runs, run)
+		content.WriteString(run.Text)
+	}
+	m.msg.Content = content.String()
+	if rnd.Intn(2) == 0 {
+		m.msg.Formatted = m.runs
+	}
+	m.link = rnd.Intn(len(m.runs))
+	m.msg.Links = []LinkAnnotation{{Text: m.runs[m.link].Text, URL: "https://example.com"}}
+	for range rnd.Intn(3) {
+		img := ImageAnnotation{URL: "https://example.com/a.png"}
+		if rnd.Intn(2) == 0 {
+			img.Caption = randomText(rnd, 3)
+		}
+		m.msg.Images = append(m.msg.Images, img)
+	}
+	return reflect.ValueOf(m)
+}
+
+// TestBuildAppendRequests_Indices checks, for random messages, that the
+// requests apply in bounds, that the header is styled exactly over the
+// sender and timestamp, that a link covers exactly its text, and that the
+// document grows by AppendedLength.
+func TestBuildAppendRequests_Indices(t *testing.T) {
+	const endIndex = 5
+	policy := DefaultStylePolicy()
+	policy.Timestamp = TextStyle{Italic: true}
+	check := func(m ropeMessage) bool {
+		reqs := policy.AppendRequests(endIndex, []MessageBlock{m.msg})
+		doc := newSimDoc(endIndex)
+		doc.apply(t, reqs)
+
+		if got, want := int64(len(doc.units)), endIndex+AppendedLength(m.msg); got != want {
+			t.Logf("%+v: document ends at %d, want %d", m.msg, got, want)
+			return false
+		}
+
+		var styled []string
+		var link *docs.Range
+		for _, req := range reqs {
+			var rng *docs.Range
+			switch {
+			case req.UpdateTextStyle != nil:
+				rng = req.UpdateTextStyle.Range
+				if req.UpdateTextStyle.TextStyle.Link != nil {
+					link = rng
+				}
+			case req.UpdateParagraphStyle != nil:
+				rng = req.UpdateParagraphStyle.Range
+			default:
+				continue
+			}
+			if rng.StartIndex < endIndex || rng.StartIndex > rng.EndIndex || rng.EndIndex > int64(len(doc.units)) {
+				t.Logf("%+v: range %d-%d out of bounds", m.msg, rng.StartIndex, rng.EndIndex)
+				return false
+			}
+			text, _ := doc.text(rng.StartIndex, rng.EndIndex)
+			styled = append(styled, text)
+		}
+
+		// The sender and timestamp are styled first, in that order.
+		if len(styled) < 2 || styled[0] != docSafe(m.msg.SenderName) || styled[1] != docSafe(m.msg.Timestamp) {
+			t.Logf("%+v: header styled over %q", m.msg, styled)
+			return false
+		}
+
+		// A link on a run found where the run is, and not a code block
+		// whose newlines a table replaced, covers exactly its text.
+		run := m.runs[m.link]
+		offset := 0
+		for _, r := range m.runs[:m.link] {
+			offset += len(r.Text)
+		}
+		if strings.Index(m.msg.Content, run.Text) != offset {
+			return true
+		}
+		if m.msg.Formatted != nil && strings.Contains(run.Text, "\n") {
+			return true
+		}
+		want := docSafe(run.Text)
+		if want == "" {
+			return link == nil
+		}
+		if link == nil {
+			t.Logf("%+v: no link", m.msg)
+			return false
+		}
+		if got, ok := doc.text(link.StartIndex, link.EndIndex); !ok || got != want {
+			t.Logf("%+v: link over %q, want %q", m.msg, got, want)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(check, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,235 @@
+package gdrive
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/sheets/v4"
+)
+
+// SheetInfo contains information about a Google Sheet.
+type SheetInfo struct {
+	ID    string
+	Title string
+	URL   string
+}
+
+// SheetCell is one cell of a spreadsheet row: Text, or Number when
+// IsNumber is set, so the column sorts numerically.
+type SheetCell struct {
+	Text     string
+	Number   float64
+	IsNumber bool
+
+	// Bold sets the cell in bold, e.g. for a header row.
+	Bold bool
+
+	// URL, when set, links the cell's text to it.
+	URL string
+}
+
+// cellData returns the cell as the Sheets API writes it. Text is written
+// as a string value, never parsed as a formula, whatever it starts with.
+func (cell SheetCell) cellData() *sheets.CellData {
+	value := &sheets.ExtendedValue{}
+	if cell.IsNumber {
+		n := cell.Number
+		value.NumberValue = &n
+	} else {
+		s := cell.Text
+		value.StringValue = &s
+	}
+	format := &sheets.TextFormat{Bold: cell.Bold}
+	if cell.URL != "" {
+		format.Link = &sheets.Link{Uri: cell.URL}
+	}
+	return &sheets.CellData{
+		UserEnteredValue:  value,
+		UserEnteredFormat: &sheets.CellFormat{TextFormat: format},
+	}
+}
+
+// cellFields are the fields of each cell SheetCell sets.
+const cellFields = "userEnteredValue,userEnteredFormat.textFormat"
+
+// rowData converts rows to the Sheets API's rows, and returns the width of
+// the widest.
+func rowData(rows [][]SheetCell) ([]*sheets.RowData, int) {
+	data := make([]*sheets.RowData, len(rows))
+	width := 0
+	for i, row := range rows {
+		cells := make([]*sheets.CellData, len(row))
+		for j, cell := range row {
+			cells[j] = cell.cellData()
+		}
+		data[i] = &sheets.RowData{Values: cells}
+		width = max(width, len(row))
+	}
+	return data, width
+}
+
+// CreateSpreadsheet creates a new Google Sheet in the specified folder.
+func (c *Client) CreateSpreadsheet(ctx context.Context, title string, folderID string) (*SheetInfo, error) {
+	file := &drive.File{
+		Name:     title,
+		MimeType: MimeTypeSheet,
+	}
+	if folderID != "" {
+		file.Parents = []string{folderID}
+	}
+
+	created, err := c.Drive.Files.Create(file).
+		Context(ctx).
+		Fields("id, name, webViewLink").
+		Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spreadsheet %q: %w", title, err)
+	}
+
+	return &SheetInfo{
+		ID:    created.Id,
+		Title: created.Name,
+		URL:   created.WebViewLink,
+	}, nil
+}
+
+// FindSpreadsheet searches for a Google Sheet by exact title within the
+// specified folder, like FindDocument. Returns (nil, nil) if none is found.
+func (c *Client) FindSpreadsheet(ctx context.Context, title string, folderID string) (*SheetInfo, error) {
+	query := fmt.Sprintf("name = '%s' and mimeType = '%s' and trashed = false", escapeName(title), MimeTypeSheet)
+	if folderID != "" {
+		query += fmt.Sprintf(" and '%s' in parents", folderID)
+	}
+
+	result, err := c.Drive.Files.List().
+		Context(ctx).
+		Q(query).
+		Fields("files(id, name, webViewLink)").
+		PageSize(1).
+		Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for spreadsheet %q: %w", title, err)
+	}
+
+	if len(result.Files) == 0 {
+		return nil, nil
+	}
+
+	f := result.Files[0]
+	return &SheetInfo{
+		ID:    f.Id,
+		Title: f.Name,
+		URL:   f.WebViewLink,
+	}, nil
+}
+
+// FindOrCreateSpreadsheet finds a spreadsheet or creates it if it doesn't
+// exist.
+func (c *Client) FindOrCreateSpreadsheet(ctx context.Context, title string, folderID string) (*SheetInfo, error) {
+	sheet, err := c.FindSpreadsheet(ctx, title, folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if sheet != nil {
+		return sheet, nil
+	}
+
+	return c.CreateSpreadsheet(ctx, title, folderID)
+}
+
+// firstSheetID returns the ID of the first sheet (tab) of a spreadsheet.
+func (c *Client) firstSheetID(ctx context.Context, spreadsheetID string) (int64, error) {
+	var id int64
+	err := retryOnRateLimit(ctx, "GetSpreadsheet", c.notify, func() error {
+		ss, err := c.Sheets.Spreadsheets.Get(spreadsheetID).
+			Context(ctx).
+			Fields("sheets.properties.sheetId").
+			Do()
+		if err != nil {
+			return err
+		}
+		if len(ss.Sheets) == 0 || ss.Sheets[0].Properties == nil {
+			return fmt.Errorf("spreadsheet has no sheets")
+		}
+		id = ss.Sheets[0].Properties.SheetId
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read spreadsheet %s: %w", spreadsheetID, err)
+	}
+	return id, nil
+}
+
+// ReplaceSheetRows replaces the content of a spreadsheet's first sheet
+// with rows, the first of which is kept in view as a header when the
+// sheet scrolls. The sheet is resized to fit, so nothing of its earlier
+// content is left.
+func (c *Client) ReplaceSheetRows(ctx context.Context, spreadsheetID string, rows [][]SheetCell) error {
+	sheetID, err := c.firstSheetID(ctx, spreadsheetID)
+	if err != nil {
+		return err
+	}
+	data, width := rowData(rows)
+	requests := []*sheets.Request{
+		{
+			// One spare row, since a sheet cannot freeze all its rows
+			UpdateSheetProperties: &sheets.UpdateSheetPropertiesRequest{
+				Properties: &sheets.SheetProperties{
+					SheetId: sheetID,
+					GridProperties: &sheets.GridProperties{
+						RowCount:       int64(len(rows) + 1),
+						ColumnCount:    int64(max(width, 1)),
+						FrozenRowCount: int64(min(len(rows), 1)),
+					},
+				},
+				Fields: "gridProperties(rowCount,columnCount,frozenRowCount)",
+			},
+		},
+		{
+			UpdateCells: &sheets.UpdateCellsRequest{
+				Range:  &sheets.GridRange{SheetId: sheetID},
+				Rows:   data,
+				Fields: cellFields,
+			},
+		},
+	}
+	return c.batchUpdateSheet(ctx, "ReplaceSheetRows", spreadsheetID, requests)
+}
+
+// AppendSheetRows adds rows after the last row with content of a
+// spreadsheet's first sheet, leaving the rows above as they are.
+func (c *Client) AppendSheetRows(ctx context.Context, spreadsheetID string, rows [][]SheetCell) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	sheetID, err := c.firstSheetID(ctx, spreadsheetID)
+	if err != nil {
+		return err
+	}
+	data, _ := rowData(rows)
+	requests := []*sheets.Request{{
+		AppendCells: &sheets.AppendCellsRequest{
+			SheetId: sheetID,
+			Rows:    data,
+			Fields:  cellFields,
+		},
+	}}
+	return c.batchUpdateSheet(ctx, "AppendSheetRows", spreadsheetID, requests)
+}
+
+// batchUpdateSheet sends requests to a spreadsheet, retrying on rate
+// limits.
+func (c *Client) batchUpdateSheet(ctx context.Context, operation, spreadsheetID string, requests []*sheets.Request) error {
+	err := retryOnRateLimit(ctx, operation, c.notify, func() error {
+		_, err := c.Sheets.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+			Requests: requests,
+		}).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update spreadsheet %s: %w", spreadsheetID, err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package gdrive
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+func TestFindOrCreateSpreadsheet_Creates(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/files", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			if q := r.URL.Query().Get("q"); !strings.Contains(q, MimeTypeSheet) || !strings.Contains(q, "'root-folder' in parents") {
+				t.Errorf("query = %q, want spreadsheets in the folder", q)
+			}
+			json.NewEncoder(w).Encode(map[string]any{"files": []any{}})
+			return
+		}
+		var reqBody map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		if reqBody["mimeType"] != MimeTypeSheet {
+			t.Errorf("mimeType = %v, want %q", reqBody["mimeType"], MimeTypeSheet)
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"id":          "sheet-id",
+			"name":        "Export Index",
+			"webViewLink": "https://docs.google.com/spreadsheets/d/sheet-id",
+		})
+	})
+
+	c := testClient(t, mux)
+	sheet, err := c.FindOrCreateSpreadsheet(context.Background(), "Export Index", "root-folder")
+	if err != nil {
+		t.Fatalf("FindOrCreateSpreadsheet() error: %v", err)
+	}
+	if sheet.ID != "sheet-id" || sheet.URL != "https://docs.google.com/spreadsheets/d/sheet-id" {
+		t.Errorf("sheet = %+v", sheet)
+	}
+}
+
+// sheetServer serves a spreadsheet whose first sheet has ID 7 and records
+// the batchUpdate requests sent to it.
+func sheetServer(t *testing.T, got *[]*sheets.Request) http.Handler {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v4/spreadsheets/sheet-id", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"sheets": []any{map[string]any{"properties": map[string]any{"sheetId": 7}}},
+		})
+	})
+	mux.HandleFunc("/v4/spreadsheets/sheet-id:batchUpdate", func(w http.ResponseWriter, r *http.Request) {
+		var req sheets.BatchUpdateSpreadsheetRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		*got = append(*got, req.Requests...)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"spreadsheetId": "sheet-id"})
+	})
+	return mux
+}
+
+func TestReplaceSheetRows(t *testing.T) {
+	var got []*sheets.Request
+	c := testClient(t, sheetServer(t, &got))
+
+	rows := [][]SheetCell{
+		{{Text: "Conversation", Bold: true}, {Text: "Messages", Bold: true}},
+		{{Text: "=cmd()", URL: "https://drive/general"}, {Number: 42, IsNumber: true}},
+	}
+	if err := c.ReplaceSheetRows(context.Background(), "sheet-id", rows); err != nil {
+		t.Fatalf("ReplaceSheetRows() error: %v", err)
+	}
+	if len(got) != 2 || got[0].UpdateSheetProperties == nil || got[1].UpdateCells == nil {
+		t.Fatalf("requests = %+v, want a resize then the cells", got)
+	}
+	grid := got[0].UpdateSheetProperties.Properties.GridProperties
+	if got[0].UpdateSheetProperties.Properties.SheetId != 7 || grid.RowCount != 3 || grid.ColumnCount != 2 || grid.FrozenRowCount != 1 {
+		t.Errorf("resize = %+v, want sheet 7 fit to the rows with the header frozen", grid)
+	}
+
+	update := got[1].UpdateCells
+	if update.Range.SheetId != 7 || len(update.Rows) != 2 {
+		t.Fatalf("update = %+v, want both rows on sheet 7", update)
+	}
+	header := update.Rows[0].Values[0]
+	if *header.UserEnteredValue.StringValue != "Conversation" || !header.UserEnteredFormat.TextFormat.Bold {
+		t.Errorf("header cell = %+v, want bold text", header)
+	}
+	name := update.Rows[1].Values[0]
+	if name.UserEnteredValue.FormulaValue != nil || *name.UserEnteredValue.StringValue != "=cmd()" {
+		t.Errorf("name cell = %+v, want text never read as a formula", name.UserEnteredValue)
+	}
+	if link := name.UserEnteredFormat.TextFormat.Link; link == nil || link.Uri != "https://drive/general" {
+		t.Errorf("name link = %+v", link)
+	}
+	if count := update.Rows[1].Values[1].UserEnteredValue.NumberValue; count == nil || *count != 42 {
+		t.Errorf("count cell = %v, want the number 42", count)
+	}
+}
+
+func TestAppendSheetRows(t *testing.T) {
+	var got []*sheets.Request
+	c := testClient(t, sheetServer(t, &got))
+
+	if err := c.AppendSheetRows(context.Background(), "sheet-id", nil); err != nil || len(got) != 0 {
+		t.Fatalf("AppendSheetRows(nil) = %v with %d requests, want nothing sent", err, len(got))
+	}
+	if err := c.AppendSheetRows(context.Background(), "sheet-id", [][]SheetCell{{{Text: "Snapshot"}}}); err != nil {
+		t.Fatalf("AppendSheetRows() error: %v", err)
+	}
+	if len(got) != 1 || got[0].AppendCells == nil || got[0].AppendCells.SheetId != 7 || len(got[0].AppendCells.Rows) != 1 {
+		t.Errorf("requests = %+v, want the row appended to sheet 7", got)
+	}
+}
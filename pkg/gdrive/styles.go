@@ -0,0 +1,290 @@
+package gdrive
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"google.golang.org/api/docs/v1"
+)
+
+// StylePolicy is how messages are styled in docs: the look of each
+// message's header, of code, and of quotes. DefaultStylePolicy is what
+// get-out has always written; LoadStylePolicy reads a policy from a
+// template doc, so an archive can be restyled by editing one doc.
+type StylePolicy struct {
+	// HeaderNamedStyle is the named paragraph style ("HEADING_4", ...) of
+	// each message's header line, so the headers can be restyled in a
+	// doc with "Update heading to match" and appear in its outline. Empty
+	// leaves headers as normal text.
+	HeaderNamedStyle string
+
+	// Sender and Timestamp style the two parts of a message's header.
+	Sender    TextStyle
+	Timestamp TextStyle
+
+	// Code styles inline code and the text of code blocks, and
+	// CodeBlockShading is the background of a code block's cell.
+	Code             TextStyle
+	CodeBlockShading string
+
+	// Quote styles the text of quoted lines, which are indented by
+	// QuoteIndent points behind a bar QuoteBarWidth points wide.
+	Quote         TextStyle
+	QuoteIndent   float64
+	QuoteBarColor string
+	QuoteBarWidth float64
+
+	// Caption styles the caption line under an embedded image.
+	Caption TextStyle
+}
+
+// TextStyle is a text style set by a StylePolicy. Zero fields are left as
+// they are: a style cannot turn bold off, only on.
+type TextStyle struct {
+	Bold       bool
+	Italic     bool
+	FontFamily string
+	FontSize   float64 // points
+	Color      string  // #rrggbb
+	Background string  // #rrggbb
+}
+
+// DefaultStylePolicy returns the styles used without a template: a bold
+// sender, Courier New code in grey cells, quotes behind a grey bar, and
+// small grey italic image captions.
+func DefaultStylePolicy() *StylePolicy {
+	return &StylePolicy{
+		Sender:           TextStyle{Bold: true},
+		Code:             TextStyle{FontFamily: "Courier New"},
+		CodeBlockShading: "#f2f2f2",
+		QuoteIndent:      18,
+		QuoteBarColor:    "#cccccc",
+		QuoteBarWidth:    3,
+		Caption:          TextStyle{Italic: true, FontSize: 9, Color: "#666666"},
+	}
+}
+
+// orDefault returns p, or the default policy when p is nil.
+func (p *StylePolicy) orDefault() *StylePolicy {
+	if p == nil {
+		return DefaultStylePolicy()
+	}
+	return p
+}
+
+// AppendRequests returns the batchUpdate requests that append messages, in
+// this policy's styles, to a document whose body currently ends at
+// endIndex (see BuildAppendRequests).
+func (p *StylePolicy) AppendRequests(endIndex int64, messages []MessageBlock) []*docs.Request {
+	requests, _ := p.buildAppendRequests(endIndex, messages)
+	return requests
+}
+
+// apply sets the fields of s on ts and returns their field mask names.
+func (s TextStyle) apply(ts *docs.TextStyle) []string {
+	var fields []string
+	if s.Bold {
+		ts.Bold = true
+		fields = append(fields, "bold")
+	}
+	if s.Italic {
+		ts.Italic = true
+		fields = append(fields, "italic")
+	}
+	if s.FontFamily != "" {
+		ts.WeightedFontFamily = &docs.WeightedFontFamily{FontFamily: s.FontFamily}
+		fields = append(fields, "weightedFontFamily")
+	}
+	if s.FontSize > 0 {
+		ts.FontSize = &docs.Dimension{Magnitude: s.FontSize, Unit: "PT"}
+		fields = append(fields, "fontSize")
+	}
+	if c := docsColor(s.Color); c != nil {
+		ts.ForegroundColor = c
+		fields = append(fields, "foregroundColor")
+	}
+	if c := docsColor(s.Background); c != nil {
+		ts.BackgroundColor = c
+		fields = append(fields, "backgroundColor")
+	}
+	return fields
+}
+
+// textStyleRequest returns the request styling [start, end) with s, or nil
+// when s sets nothing.
+func (s TextStyle) textStyleRequest(start, end int64) *docs.Request {
+	ts := &docs.TextStyle{}
+	fields := s.apply(ts)
+	if len(fields) == 0 || start >= end {
+		return nil
+	}
+	return &docs.Request{UpdateTextStyle: &docs.UpdateTextStyleRequest{
+		Range:     &docs.Range{StartIndex: start, EndIndex: end},
+		TextStyle: ts,
+		Fields:    strings.Join(fields, ","),
+	}}
+}
+
+// quoteParagraphStyle returns the paragraph style of quoted lines and its
+// field mask.
+func (p *StylePolicy) quoteParagraphStyle() (*docs.ParagraphStyle, string) {
+	style := &docs.ParagraphStyle{
+		IndentStart:     &docs.Dimension{Magnitude: p.QuoteIndent, Unit: "PT"},
+		IndentFirstLine: &docs.Dimension{Magnitude: p.QuoteIndent, Unit: "PT"},
+	}
+	fields := "indentStart,indentFirstLine"
+	if p.QuoteBarWidth > 0 {
+		style.BorderLeft = &docs.ParagraphBorder{
+			Color:     docsColor(p.QuoteBarColor),
+			Width:     &docs.Dimension{Magnitude: p.QuoteBarWidth, Unit: "PT"},
+			Padding:   &docs.Dimension{Magnitude: 6, Unit: "PT"},
+			DashStyle: "SOLID",
+		}
+		fields += ",borderLeft"
+	}
+	return style, fields
+}
+
+// docsColor converts a #rrggbb color, or returns nil when s is not one.
+func docsColor(s string) *docs.OptionalColor {
+	if len(s) != 7 || s[0] != '#' {
+		return nil
+	}
+	v, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return nil
+	}
+	return &docs.OptionalColor{Color: &docs.Color{RgbColor: &docs.RgbColor{
+		Red:   float64(v>>16&0xff) / 255,
+		Green: float64(v>>8&0xff) / 255,
+		Blue:  float64(v&0xff) / 255,
+	}}}
+}
+
+// hexColor converts a Docs color to #rrggbb, or returns "" when it has
+// none.
+func hexColor(c *docs.OptionalColor) string {
+	if c == nil || c.Color == nil || c.Color.RgbColor == nil {
+		return ""
+	}
+	rgb := c.Color.RgbColor
+	to8 := func(f float64) int { return int(f*255 + 0.5) }
+	return fmt.Sprintf("#%02x%02x%02x", to8(rgb.Red), to8(rgb.Green), to8(rgb.Blue))
+}
+
+// templateTextStyle converts the style of a template's text run.
+func templateTextStyle(ts *docs.TextStyle) TextStyle {
+	if ts == nil {
+		return TextStyle{}
+	}
+	s := TextStyle{
+		Bold:       ts.Bold,
+		Italic:     ts.Italic,
+		Color:      hexColor(ts.ForegroundColor),
+		Background: hexColor(ts.BackgroundColor),
+	}
+	if ts.WeightedFontFamily != nil {
+		s.FontFamily = ts.WeightedFontFamily.FontFamily
+	}
+	if ts.FontSize != nil {
+		s.FontSize = ts.FontSize.Magnitude
+	}
+	return s
+}
+
+// docIDPattern finds the ID in a Google Docs URL.
+var docIDPattern = regexp.MustCompile(`/document/d/([a-zA-Z0-9_-]+)`)
+
+// DocumentID returns the document ID in a Google Docs URL, or s itself
+// when it is not a URL.
+func DocumentID(s string) string {
+	if m := docIDPattern.FindStringSubmatch(s); m != nil {
+		return m[1]
+	}
+	return strings.TrimSpace(s)
+}
+
+// LoadStylePolicy reads a StylePolicy from a template doc, identified by
+// ID or URL. The template holds one paragraph per style, whose text names
+// it and is styled as it should look:
+//
+//   - "Sender" and "Timestamp": the two parts of a message's header. The
+//     Sender paragraph's named style (e.g. Heading 4) is given to every
+//     header line.
+//   - "Code": inline code and the text of code blocks. Its highlight color
+//     is the code block background.
+//   - "Quote": quoted lines, with the paragraph's indent and left border.
+//   - "Caption": the caption line under an embedded image.
+//
+// Other paragraphs, such as notes on how to use the template, are ignored,
+// and styles the template does not name keep their default.
+func (c *Client) LoadStylePolicy(ctx context.Context, template string) (*StylePolicy, error) {
+	doc, err := c.Docs.Documents.Get(DocumentID(template)).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get style template: %w", err)
+	}
+	return StylePolicyFromDocument(doc), nil
+}
+
+// StylePolicyFromDocument reads a StylePolicy from a template doc (see
+// LoadStylePolicy).
+func StylePolicyFromDocument(doc *docs.Document) *StylePolicy {
+	p := DefaultStylePolicy()
+	if doc.Body == nil {
+		return p
+	}
+	for _, el := range doc.Body.Content {
+		para := el.Paragraph
+		if para == nil {
+			continue
+		}
+		var text strings.Builder
+		var runStyle *docs.TextStyle
+		for _, pe := range para.Elements {
+			if pe.TextRun == nil {
+				continue
+			}
+			if runStyle == nil && strings.TrimSpace(pe.TextRun.Content) != "" {
+				runStyle = pe.TextRun.TextStyle
+			}
+			text.WriteString(pe.TextRun.Content)
+		}
+		style := templateTextStyle(runStyle)
+
+		switch strings.ToLower(strings.TrimSpace(text.String())) {
+		case "sender":
+			p.Sender = style
+			p.HeaderNamedStyle = ""
+			if ps := para.ParagraphStyle; ps != nil && ps.NamedStyleType != "" && ps.NamedStyleType != "NORMAL_TEXT" {
+				p.HeaderNamedStyle = ps.NamedStyleType
+			}
+		case "timestamp":
+			p.Timestamp = style
+		case "code":
+			if style.Background != "" {
+				p.CodeBlockShading = style.Background
+				style.Background = ""
+			}
+			p.Code = style
+		case "quote":
+			p.Quote = style
+			if ps := para.ParagraphStyle; ps != nil {
+				p.QuoteIndent = 0
+				if ps.IndentStart != nil {
+					p.QuoteIndent = ps.IndentStart.Magnitude
+				}
+				p.QuoteBarWidth = 0
+				if b := ps.BorderLeft; b != nil && b.Width != nil && b.Width.Magnitude > 0 {
+					p.QuoteBarWidth = b.Width.Magnitude
+					p.QuoteBarColor = hexColor(b.Color)
+				}
+			}
+		case "caption":
+			p.Caption = style
+		}
+	}
+	return p
+}
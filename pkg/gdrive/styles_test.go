@@ -0,0 +1,100 @@
+package gdrive
+
+import (
+	"testing"
+
+	"google.golang.org/api/docs/v1"
+)
+
+func TestStylePolicy_AppendRequests(t *testing.T) {
+	p := &StylePolicy{
+		HeaderNamedStyle: "HEADING_4",
+		Sender:           TextStyle{Color: "#1155cc"},
+		Timestamp:        TextStyle{Italic: true, FontSize: 9},
+		Code:             TextStyle{FontFamily: "Roboto Mono"},
+	}
+	reqs := p.AppendRequests(1, []MessageBlock{{
+		SenderName: "Alice",
+		Timestamp:  "9:00 AM",
+		Content:    "run ls",
+		Formatted:  []FormattedText{{Text: "run "}, {Text: "ls", Monospace: true}},
+	}})
+
+	// header insert, named style, sender, timestamp, body insert, code.
+	if len(reqs) != 6 {
+		t.Fatalf("got %d requests, want 6", len(reqs))
+	}
+	if got := reqs[1].UpdateParagraphStyle; got == nil || got.ParagraphStyle.NamedStyleType != "HEADING_4" || got.Range.EndIndex != 1+int64(len("Alice  9:00 AM\n")) {
+		t.Errorf("reqs[1] = %+v", reqs[1].UpdateParagraphStyle)
+	}
+	if got := reqs[2].UpdateTextStyle; got == nil || got.Fields != "foregroundColor" || got.Range.EndIndex != 6 {
+		t.Errorf("sender style = %+v", reqs[2].UpdateTextStyle)
+	}
+	if got := reqs[3].UpdateTextStyle; got == nil || got.Fields != "italic,fontSize" || got.Range.StartIndex != 8 || got.Range.EndIndex != 15 {
+		t.Errorf("timestamp style = %+v", reqs[3].UpdateTextStyle)
+	}
+	if got := reqs[5].UpdateTextStyle; got == nil || got.TextStyle.WeightedFontFamily.FontFamily != "Roboto Mono" {
+		t.Errorf("code style = %+v", reqs[5].UpdateTextStyle)
+	}
+
+	// Styles do not move anything, so lengths match the default policy.
+	if n := len(BuildAppendRequests(1, []MessageBlock{{SenderName: "Alice", Timestamp: "9:00 AM", Content: "hi"}})); n != 3 {
+		t.Errorf("default policy: got %d requests, want 3", n)
+	}
+}
+
+func TestStylePolicyFromDocument(t *testing.T) {
+	para := func(text string, ts *docs.TextStyle, ps *docs.ParagraphStyle) *docs.StructuralElement {
+		return &docs.StructuralElement{Paragraph: &docs.Paragraph{
+			Elements:       []*docs.ParagraphElement{{TextRun: &docs.TextRun{Content: text + "\n", TextStyle: ts}}},
+			ParagraphStyle: ps,
+		}}
+	}
+	grey := &docs.OptionalColor{Color: &docs.Color{RgbColor: &docs.RgbColor{Red: 0.5, Green: 0.5, Blue: 0.5}}}
+	doc := &docs.Document{Body: &docs.Body{Content: []*docs.StructuralElement{
+		para("Edit the styles below to restyle the archive.", nil, nil),
+		para("Sender", &docs.TextStyle{Bold: true}, &docs.ParagraphStyle{NamedStyleType: "HEADING_3"}),
+		para("Timestamp", &docs.TextStyle{ForegroundColor: grey}, nil),
+		para("Code", &docs.TextStyle{WeightedFontFamily: &docs.WeightedFontFamily{FontFamily: "Source Code Pro"}, BackgroundColor: grey}, nil),
+		para("Quote", &docs.TextStyle{Italic: true}, &docs.ParagraphStyle{IndentStart: &docs.Dimension{Magnitude: 36}}),
+		para("Caption", &docs.TextStyle{Bold: true}, nil),
+	}}}
+
+	p := StylePolicyFromDocument(doc)
+	if p.HeaderNamedStyle != "HEADING_3" || !p.Sender.Bold {
+		t.Errorf("sender = %q %+v", p.HeaderNamedStyle, p.Sender)
+	}
+	if p.Timestamp.Color != "#808080" {
+		t.Errorf("timestamp = %+v", p.Timestamp)
+	}
+	if p.Code.FontFamily != "Source Code Pro" || p.Code.Background != "" || p.CodeBlockShading != "#808080" {
+		t.Errorf("code = %+v, shading %q", p.Code, p.CodeBlockShading)
+	}
+	if !p.Quote.Italic || p.QuoteIndent != 36 || p.QuoteBarWidth != 0 {
+		t.Errorf("quote = %+v, indent %v, bar %v", p.Quote, p.QuoteIndent, p.QuoteBarWidth)
+	}
+	if !p.Caption.Bold || p.Caption.Italic {
+		t.Errorf("caption = %+v", p.Caption)
+	}
+
+	// Styles a template leaves out keep their default.
+	p = StylePolicyFromDocument(&docs.Document{Body: &docs.Body{Content: []*docs.StructuralElement{
+		para("Timestamp", &docs.TextStyle{Italic: true}, nil),
+	}}})
+	if !p.Sender.Bold || p.Code.FontFamily != "Courier New" || !p.Timestamp.Italic {
+		t.Errorf("partial template = %+v", p)
+	}
+}
+
+func TestDocumentID(t *testing.T) {
+	tests := map[string]string{
+		"https://docs.google.com/document/d/1AbC-d_E/edit#heading=h.1": "1AbC-d_E",
+		"1AbC-d_E":   "1AbC-d_E",
+		" 1AbC-d_E ": "1AbC-d_E",
+	}
+	for in, want := range tests {
+		if got := DocumentID(in); got != want {
+			t.Errorf("DocumentID(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package gdrive
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// DefaultUploadChunkSize is the chunk size for resumable uploads. Files
+// larger than one chunk are sent with the resumable upload protocol, which
+// retries interrupted chunks instead of restarting the whole file.
+const DefaultUploadChunkSize = 16 << 20
+
+// UploadedFile describes a file uploaded with UploadLargeFile.
+type UploadedFile struct {
+	ID     string
+	Name   string
+	URL    string
+	Size   int64
+	MD5    string
+	Parent string
+}
+
+// UploadProgress reports bytes sent so far out of total for a single file.
+type UploadProgress func(sent, total int64)
+
+// UploadLargeFile uploads the file at path into parentID using resumable,
+// chunked uploads and verifies the upload by comparing the MD5 checksum
+// Drive computes with a local one. On a checksum or size mismatch the
+// uploaded copy is deleted and an error is returned.
+func (c *Client) UploadLargeFile(ctx context.Context, path, mimeType, parentID string, onProgress UploadProgress) (*UploadedFile, error) {
+	name := filepath.Base(path)
+
+	localMD5, size, err := fileMD5(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to checksum %s: %w", path, err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	meta := &drive.File{
+		Name:     name,
+		MimeType: mimeType,
+	}
+	if parentID != "" {
+		meta.Parents = []string{parentID}
+	}
+
+	call := c.Drive.Files.Create(meta).
+		Media(f, googleapi.ChunkSize(DefaultUploadChunkSize), googleapi.ContentType(mimeType)).
+		Context(ctx).
+		Fields("id, name, webViewLink, md5Checksum, size")
+	if onProgress != nil {
+		call = call.ProgressUpdater(func(current, _ int64) {
+			onProgress(current, size)
+		})
+	}
+
+	res, err := call.Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload %q: %w", name, err)
+	}
+
+	if res.Md5Checksum != localMD5 || res.Size != size {
+		_ = c.DeleteFile(ctx, res.Id)
+		return nil, fmt.Errorf("integrity check failed for %q: local md5 %s (%d bytes), Drive md5 %s (%d bytes)",
+			name, localMD5, size, res.Md5Checksum, res.Size)
+	}
+
+	if onProgress != nil {
+		onProgress(size, size)
+	}
+
+	return &UploadedFile{
+		ID:     res.Id,
+		Name:   res.Name,
+		URL:    res.WebViewLink,
+		Size:   res.Size,
+		MD5:    res.Md5Checksum,
+		Parent: parentID,
+	}, nil
+}
+
+// fileMD5 returns the hex MD5 checksum and size of the file at path.
+func fileMD5(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	h := md5.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
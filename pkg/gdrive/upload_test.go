@@ -0,0 +1,111 @@
+package gdrive
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func writeUploadFixture(t *testing.T, content string) (path, sum string) {
+	t.Helper()
+	path = filepath.Join(t.TempDir(), "archive-001.zip")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	h := md5.Sum([]byte(content))
+	return path, hex.EncodeToString(h[:])
+}
+
+func TestUploadLargeFile_Verified(t *testing.T) {
+	content := "PK fake zip content"
+	path, sum := writeUploadFixture(t, content)
+
+	var uploadPath string
+	var bodyHasContent bool
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+			http.Error(w, "unexpected", http.StatusBadRequest)
+			return
+		}
+		uploadPath = r.URL.Path
+		buf := new(strings.Builder)
+		_, _ = io.Copy(buf, r.Body)
+		bodyHasContent = strings.Contains(buf.String(), content)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":          "file-1",
+			"name":        "archive-001.zip",
+			"webViewLink": "https://drive.google.com/file/d/file-1/view",
+			"md5Checksum": sum,
+			"size":        strconv.Itoa(len(content)),
+		})
+	})
+
+	c := testClient(t, handler)
+	var lastSent, lastTotal int64
+	got, err := c.UploadLargeFile(context.Background(), path, "application/zip", "folder-1", func(sent, total int64) {
+		lastSent, lastTotal = sent, total
+	})
+	if err != nil {
+		t.Fatalf("UploadLargeFile() error: %v", err)
+	}
+
+	if !strings.HasPrefix(uploadPath, "/upload/") {
+		t.Errorf("upload path = %q, want /upload/...", uploadPath)
+	}
+	if !bodyHasContent {
+		t.Error("request body should contain the file content")
+	}
+	if got.ID != "file-1" || got.MD5 != sum || got.Size != int64(len(content)) || got.Parent != "folder-1" {
+		t.Errorf("UploadLargeFile() = %+v", got)
+	}
+	if lastSent != int64(len(content)) || lastTotal != int64(len(content)) {
+		t.Errorf("final progress = %d/%d, want %d/%d", lastSent, lastTotal, len(content), len(content))
+	}
+}
+
+func TestUploadLargeFile_ChecksumMismatchDeletes(t *testing.T) {
+	path, _ := writeUploadFixture(t, "PK fake zip content")
+
+	var deleted string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"id":          "file-bad",
+				"md5Checksum": "0000",
+				"size":        "19",
+			})
+		case http.MethodDelete:
+			deleted = r.URL.Path
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	c := testClient(t, handler)
+	_, err := c.UploadLargeFile(context.Background(), path, "application/zip", "", nil)
+	if err == nil || !strings.Contains(err.Error(), "integrity check failed") {
+		t.Fatalf("expected integrity error, got %v", err)
+	}
+	if !strings.HasSuffix(deleted, "/files/file-bad") {
+		t.Errorf("expected mismatched upload to be deleted, got %q", deleted)
+	}
+}
+
+func TestUploadLargeFile_MissingFile(t *testing.T) {
+	c := testClient(t, http.NotFoundHandler())
+	if _, err := c.UploadLargeFile(context.Background(), filepath.Join(t.TempDir(), "nope.zip"), "application/zip", "", nil); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
@@ -0,0 +1,148 @@
+// Package mailer sends HTML email over SMTP, or hands it to an API such as
+// Gmail's. It is used by the email digest destination to deliver rendered
+// conversation digests to an inbox.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPort is the SMTP submission port used when Config.Port is zero.
+const DefaultPort = 587
+
+// Config holds SMTP connection and addressing settings. Only From and To
+// are used when messages are sent with WithRawSender.
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// SendFunc matches the signature of net/smtp.SendMail. It is swappable so
+// tests can capture messages without a real SMTP server.
+type SendFunc func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+// RawSendFunc delivers an assembled RFC 5322 message, as the Gmail API's
+// users.messages.send does.
+type RawSendFunc func(ctx context.Context, msg []byte) error
+
+// Client sends HTML messages through a single SMTP server, or through a
+// RawSendFunc.
+type Client struct {
+	cfg      Config
+	sendMail SendFunc
+	sendRaw  RawSendFunc
+	now      func() time.Time
+}
+
+// Option configures the Client.
+type Option func(*Client)
+
+// WithSendFunc replaces net/smtp.SendMail (useful for testing).
+func WithSendFunc(fn SendFunc) Option {
+	return func(c *Client) {
+		c.sendMail = fn
+	}
+}
+
+// WithRawSender sends messages with fn instead of SMTP. A message without
+// a From address is sent as the account fn sends from.
+func WithRawSender(fn RawSendFunc) Option {
+	return func(c *Client) {
+		c.sendRaw = fn
+	}
+}
+
+// NewClient creates an SMTP client for the given config. A zero Port
+// defaults to DefaultPort. net/smtp.SendMail upgrades to TLS via STARTTLS
+// when the server supports it.
+func NewClient(cfg Config, opts ...Option) *Client {
+	if cfg.Port == 0 {
+		cfg.Port = DefaultPort
+	}
+	c := &Client{
+		cfg:      cfg,
+		sendMail: smtp.SendMail,
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// SendHTML sends one message with the given subject and HTML body to every
+// configured recipient. Over SMTP, PLAIN auth is used when a username is
+// configured; net/smtp does not support cancellation, so ctx only reaches
+// a RawSendFunc.
+func (c *Client) SendHTML(ctx context.Context, subject, htmlBody string) error {
+	if len(c.cfg.To) == 0 {
+		return fmt.Errorf("mailer: send: no recipients configured")
+	}
+
+	msg, err := buildMessage(c.cfg.From, c.cfg.To, subject, htmlBody, c.now())
+	if err != nil {
+		return fmt.Errorf("mailer: send: %w", err)
+	}
+	if c.sendRaw != nil {
+		if err := c.sendRaw(ctx, msg); err != nil {
+			return fmt.Errorf("mailer: send: %w", err)
+		}
+		return nil
+	}
+
+	var auth smtp.Auth
+	if c.cfg.Username != "" {
+		auth = smtp.PlainAuth("", c.cfg.Username, c.cfg.Password, c.cfg.Host)
+	}
+
+	addr := net.JoinHostPort(c.cfg.Host, strconv.Itoa(c.cfg.Port))
+	if err := c.sendMail(addr, auth, c.cfg.From, c.cfg.To, msg); err != nil {
+		return fmt.Errorf("mailer: send: %w", err)
+	}
+	return nil
+}
+
+// buildMessage assembles an RFC 5322 message with a quoted-printable
+// HTML body, leaving out an empty From. Header values are checked for
+// CR/LF to prevent injection.
+func buildMessage(from string, to []string, subject, htmlBody string, date time.Time) ([]byte, error) {
+	for _, v := range append([]string{from, subject}, to...) {
+		if strings.ContainsAny(v, "\r\n") {
+			return nil, fmt.Errorf("header value contains a line break: %q", v)
+		}
+	}
+
+	var b bytes.Buffer
+	if from != "" {
+		fmt.Fprintf(&b, "From: %s\r\n", from)
+	}
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	b.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&b)
+	if _, err := qp.Write([]byte(htmlBody)); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,139 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime/quotedprintable"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewClient_DefaultPort(t *testing.T) {
+	c := NewClient(Config{Host: "smtp.example.com"})
+	if c.cfg.Port != DefaultPort {
+		t.Errorf("Port = %d, want %d", c.cfg.Port, DefaultPort)
+	}
+}
+
+func TestSendHTML_Success(t *testing.T) {
+	var gotAddr, gotFrom string
+	var gotTo []string
+	var gotMsg []byte
+	var gotAuth smtp.Auth
+
+	c := NewClient(Config{
+		Host:     "smtp.example.com",
+		Port:     2525,
+		Username: "me@example.com",
+		Password: "secret",
+		From:     "get-out <me@example.com>",
+		To:       []string{"archive@example.com"},
+	}, WithSendFunc(func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotAuth, gotFrom, gotTo, gotMsg = addr, a, from, to, msg
+		return nil
+	}))
+
+	if err := c.SendHTML(context.Background(), "Slack digest — general", "<p>Hello world</p>"); err != nil {
+		t.Fatalf("SendHTML() error: %v", err)
+	}
+
+	if gotAddr != "smtp.example.com:2525" {
+		t.Errorf("addr = %q, want smtp.example.com:2525", gotAddr)
+	}
+	if gotAuth == nil {
+		t.Error("expected PLAIN auth when username is set")
+	}
+	if gotFrom != "get-out <me@example.com>" || len(gotTo) != 1 || gotTo[0] != "archive@example.com" {
+		t.Errorf("envelope = %q -> %v", gotFrom, gotTo)
+	}
+
+	msg := string(gotMsg)
+	for _, want := range []string{
+		"To: archive@example.com\r\n",
+		"Subject: =?utf-8?q?",
+		"Content-Type: text/html; charset=UTF-8\r\n",
+		"Content-Transfer-Encoding: quoted-printable\r\n",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message missing %q:\n%s", want, msg)
+		}
+	}
+
+	parts := strings.SplitN(msg, "\r\n\r\n", 2)
+	body, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(parts[1])))
+	if err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if string(body) != "<p>Hello world</p>" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestSendHTML_NoAuthWithoutUsername(t *testing.T) {
+	var gotAuth smtp.Auth = smtp.PlainAuth("", "x", "y", "z")
+	c := NewClient(Config{Host: "localhost", Port: 25, From: "a@example.com", To: []string{"b@example.com"}},
+		WithSendFunc(func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+			gotAuth = a
+			return nil
+		}))
+
+	if err := c.SendHTML(context.Background(), "s", "b"); err != nil {
+		t.Fatalf("SendHTML() error: %v", err)
+	}
+	if gotAuth != nil {
+		t.Error("expected no auth when username is empty")
+	}
+}
+
+func TestSendHTML_NoRecipients(t *testing.T) {
+	c := NewClient(Config{Host: "localhost", From: "a@example.com"})
+	if err := c.SendHTML(context.Background(), "s", "b"); err == nil {
+		t.Fatal("expected error with no recipients")
+	}
+}
+
+func TestSendHTML_SendError(t *testing.T) {
+	c := NewClient(Config{Host: "localhost", From: "a@example.com", To: []string{"b@example.com"}},
+		WithSendFunc(func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+			return errors.New("connection refused")
+		}))
+
+	err := c.SendHTML(context.Background(), "s", "b")
+	if err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Fatalf("expected wrapped send error, got %v", err)
+	}
+}
+
+func TestSendHTML_RawSender(t *testing.T) {
+	var gotMsg []byte
+	c := NewClient(Config{To: []string{"archive@example.com"}},
+		WithSendFunc(func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+			t.Error("SMTP used with a raw sender")
+			return nil
+		}),
+		WithRawSender(func(_ context.Context, msg []byte) error {
+			gotMsg = msg
+			return nil
+		}))
+
+	if err := c.SendHTML(context.Background(), "s", "b"); err != nil {
+		t.Fatalf("SendHTML() error: %v", err)
+	}
+	msg := string(gotMsg)
+	if !strings.Contains(msg, "To: archive@example.com\r\n") {
+		t.Errorf("message missing To header:\n%s", msg)
+	}
+	if strings.Contains(msg, "From:") {
+		t.Errorf("message has a From header without a From address:\n%s", msg)
+	}
+}
+
+func TestBuildMessage_RejectsHeaderInjection(t *testing.T) {
+	_, err := buildMessage("a@example.com", []string{"b@example.com"}, "hi\r\nBcc: evil@example.com", "body", time.Now())
+	if err == nil {
+		t.Fatal("expected error for subject containing CRLF")
+	}
+}
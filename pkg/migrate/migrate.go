@@ -0,0 +1,169 @@
+// Package migrate upgrades versioned JSON files (config files and the
+// export index) written by older releases. Each file records its format in
+// a top-level "version" field; files written before versioning are version
+// 1. Loading a file runs the steps from its version to the current one,
+// after copying the original aside, so a format change never breaks an
+// existing setup or silently drops what it held.
+package migrate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Step upgrades a decoded document by one version, in place.
+type Step struct {
+	// Description says what the step changes, e.g. "move
+	// googleDriveFolderId to folder_id".
+	Description string
+
+	Apply func(doc map[string]any) error
+}
+
+// Schema lists the versions of one kind of file. Steps[i] upgrades version
+// i+1 to i+2, so a schema without steps is at version 1.
+type Schema struct {
+	// Name names the file in messages, e.g. "settings.json".
+	Name  string
+	Steps []Step
+}
+
+// Current returns the version files are written at.
+func (s Schema) Current() int {
+	return len(s.Steps) + 1
+}
+
+// Version returns the version recorded in data: 1 when it has none, or 0
+// when data is not a JSON object with an integer version (the caller's
+// parser reports that).
+func Version(data []byte) int {
+	var header struct {
+		Version *int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return 0
+	}
+	if header.Version == nil {
+		return 1
+	}
+	return *header.Version
+}
+
+// Upgrade returns data upgraded to the current version and the version it
+// was at. Data already current, or not a JSON object, is returned as is.
+// Data from a newer release is an error: loading and saving it again would
+// drop whatever this release does not know about.
+func (s Schema) Upgrade(data []byte) ([]byte, int, error) {
+	from := Version(data)
+	current := s.Current()
+	switch {
+	case from > current:
+		return nil, from, fmt.Errorf("%s is format version %d, but this release of get-out supports up to version %d; upgrade get-out", s.Name, from, current)
+	case from == current || from < 1:
+		return data, from, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return data, from, nil
+	}
+	for v := from; v < current; v++ {
+		step := s.Steps[v-1]
+		if err := step.Apply(doc); err != nil {
+			return nil, from, fmt.Errorf("failed to migrate %s from version %d to %d (%s): %w", s.Name, v, v+1, step.Description, err)
+		}
+	}
+	doc["version"] = current
+	upgraded, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, from, fmt.Errorf("failed to encode migrated %s: %w", s.Name, err)
+	}
+	return append(upgraded, '\n'), from, nil
+}
+
+// ReadFile reads path and upgrades its contents in memory, leaving the
+// file untouched. Read errors are returned unwrapped so callers can test
+// them with os.IsNotExist.
+func (s Schema) ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	upgraded, _, err := s.Upgrade(data)
+	return upgraded, err
+}
+
+// LoadFile is ReadFile that also saves an upgrade: the original is copied
+// to BackupPath and path is replaced with the upgraded contents. When the
+// file cannot be written (e.g. a read-only config mount) the upgrade is
+// still returned and is simply applied again on the next load.
+func (s Schema) LoadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	upgraded, from, err := s.Upgrade(data)
+	if err != nil || from < 1 || from == s.Current() {
+		return upgraded, err
+	}
+	if err := backup(path, from, data); err == nil {
+		_ = replaceFile(path, upgraded)
+	}
+	return upgraded, nil
+}
+
+// BackupPath returns where LoadFile keeps the version-from original of
+// path, e.g. "settings.json.v1.bak".
+func BackupPath(path string, from int) string {
+	return fmt.Sprintf("%s.v%d.bak", path, from)
+}
+
+// backup writes data to BackupPath unless a backup of that version already
+// exists; the first one is the file as the older release left it.
+func backup(path string, from int, data []byte) error {
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	f, err := os.OpenFile(BackupPath(path, from), os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if os.IsExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// replaceFile atomically replaces path with data, keeping its permissions.
+func replaceFile(path string, data []byte) error {
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
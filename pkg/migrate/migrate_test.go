@@ -0,0 +1,141 @@
+package migrate
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testSchema() Schema {
+	return Schema{
+		Name: "test.json",
+		Steps: []Step{
+			{Description: "rename a to b", Apply: func(doc map[string]any) error {
+				doc["b"] = doc["a"]
+				delete(doc, "a")
+				return nil
+			}},
+			{Description: "add c", Apply: func(doc map[string]any) error {
+				doc["c"] = true
+				return nil
+			}},
+		},
+	}
+}
+
+func TestVersion(t *testing.T) {
+	tests := []struct {
+		data string
+		want int
+	}{
+		{`{}`, 1},
+		{`{"version": 3}`, 3},
+		{`not json`, 0},
+		{`{"version": "2"}`, 0},
+	}
+	for _, tt := range tests {
+		if got := Version([]byte(tt.data)); got != tt.want {
+			t.Errorf("Version(%s) = %d, want %d", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	s := testSchema()
+	out, from, err := s.Upgrade([]byte(`{"a": 12345678901234567890, "keep": "x"}`))
+	if err != nil {
+		t.Fatalf("Upgrade() error = %v", err)
+	}
+	if from != 1 {
+		t.Errorf("from = %d, want 1", from)
+	}
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatal(err)
+	}
+	// Numbers survive without losing precision.
+	if string(doc["b"]) != "12345678901234567890" || string(doc["c"]) != "true" || string(doc["version"]) != "3" || string(doc["keep"]) != `"x"` {
+		t.Errorf("upgraded = %s", out)
+	}
+
+	// Only the steps after the file's version run.
+	out, _, _ = s.Upgrade([]byte(`{"version": 2, "a": 1}`))
+	if !strings.Contains(string(out), `"a": 1`) {
+		t.Errorf("version 2 upgrade reran step 1: %s", out)
+	}
+
+	current := []byte(`{"version": 3}`)
+	if out, _, _ := s.Upgrade(current); string(out) != string(current) {
+		t.Errorf("current data changed: %s", out)
+	}
+	if _, _, err := s.Upgrade([]byte(`{"version": 4}`)); err == nil || !strings.Contains(err.Error(), "supports up to version 3") {
+		t.Errorf("newer version error = %v", err)
+	}
+}
+
+func TestUpgrade_StepError(t *testing.T) {
+	s := Schema{Name: "test.json", Steps: []Step{{Description: "fail", Apply: func(map[string]any) error { return errors.New("boom") }}}}
+	if _, _, err := s.Upgrade([]byte(`{}`)); err == nil || !strings.Contains(err.Error(), "from version 1 to 2 (fail): boom") {
+		t.Errorf("Upgrade() error = %v", err)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.json")
+	original := `{"a": 1}`
+	if err := os.WriteFile(path, []byte(original), 0640); err != nil {
+		t.Fatal(err)
+	}
+	s := testSchema()
+	data, err := s.LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	onDisk, _ := os.ReadFile(path)
+	if string(onDisk) != string(data) || Version(onDisk) != 3 {
+		t.Errorf("file = %s, want the upgraded data", onDisk)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0640 {
+		t.Errorf("mode = %v, want 0640", info.Mode().Perm())
+	}
+	backup, err := os.ReadFile(BackupPath(path, 1))
+	if err != nil || string(backup) != original {
+		t.Errorf("backup = %q, %v", backup, err)
+	}
+
+	// An existing backup of the same version is kept.
+	if err := os.WriteFile(path, []byte(`{"a": 2}`), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if backup, _ := os.ReadFile(BackupPath(path, 1)); string(backup) != original {
+		t.Errorf("backup overwritten: %s", backup)
+	}
+
+	if _, err := s.LoadFile(filepath.Join(t.TempDir(), "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("missing file error = %v, want not-exist", err)
+	}
+}
+
+func TestReadFile_LeavesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.json")
+	if err := os.WriteFile(path, []byte(`{"a": 1}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	data, err := testSchema().ReadFile(path)
+	if err != nil || Version(data) != 3 {
+		t.Fatalf("ReadFile() = %s, %v", data, err)
+	}
+	onDisk, _ := os.ReadFile(path)
+	if string(onDisk) != `{"a": 1}` {
+		t.Errorf("file changed: %s", onDisk)
+	}
+	if _, err := os.Stat(BackupPath(path, 1)); !os.IsNotExist(err) {
+		t.Error("ReadFile wrote a backup")
+	}
+}
@@ -0,0 +1,249 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// MessageText returns the mrkdwn text to export for msg. A message whose
+// blocks include anything other than rich text, such as a bot's or a
+// workflow's sections, is rendered from its blocks, as its text is only a
+// notification fallback. A message of rich text alone is rendered from its
+// text, which Slack keeps equal to the blocks, unless the text is empty.
+func MessageText(msg slackapi.Message) string {
+	if len(msg.Blocks) == 0 {
+		return msg.Text
+	}
+	richOnly := true
+	for _, b := range msg.Blocks {
+		if b.Type != "rich_text" {
+			richOnly = false
+			break
+		}
+	}
+	if richOnly && strings.TrimSpace(msg.Text) != "" {
+		return msg.Text
+	}
+	if text := BlocksToMrkdwn(msg.Blocks); text != "" {
+		return text
+	}
+	return msg.Text
+}
+
+// BlocksToMrkdwn renders Block Kit blocks as Slack mrkdwn, so they go
+// through the same conversion as message text. Headers are bold, sections
+// are their text followed by their fields, context blocks are their texts
+// on one line, and rich text keeps its formatting, lists, quotes and code.
+// Blocks with nothing to show, such as inputs, are skipped.
+func BlocksToMrkdwn(blocks []slackapi.Block) string {
+	var parts []string
+	for _, b := range blocks {
+		if text := blockMrkdwn(b); strings.TrimSpace(text) != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// blockMrkdwn renders one block.
+func blockMrkdwn(b slackapi.Block) string {
+	switch b.Type {
+	case "header":
+		if text := textObjectMrkdwn(b.Text); text != "" {
+			return "*" + text + "*"
+		}
+	case "section":
+		var lines []string
+		if text := textObjectMrkdwn(b.Text); text != "" {
+			lines = append(lines, text)
+		}
+		for _, f := range b.Fields {
+			if text := textObjectMrkdwn(f); text != "" {
+				lines = append(lines, text)
+			}
+		}
+		if b.Accessory != nil {
+			if text := elementMrkdwn(*b.Accessory); text != "" {
+				lines = append(lines, text)
+			}
+		}
+		return strings.Join(lines, "\n")
+	case "markdown":
+		return textObjectMrkdwn(b.Text)
+	case "context", "actions":
+		var items []string
+		for _, el := range b.Elements {
+			if text := elementMrkdwn(el); text != "" {
+				items = append(items, text)
+			}
+		}
+		return strings.Join(items, " · ")
+	case "divider":
+		return "---"
+	case "image":
+		label := textObjectMrkdwn(b.Title)
+		if label == "" {
+			label = escapeMrkdwn(b.AltText)
+		}
+		return linkMrkdwn(b.ImageURL, label)
+	case "rich_text":
+		return richTextMrkdwn(b.Elements)
+	}
+	return ""
+}
+
+// textObjectMrkdwn returns a text object's text as mrkdwn. Plain text is
+// escaped so it is not read as markup; mrkdwn already is.
+func textObjectMrkdwn(t *slackapi.TextObject) string {
+	if t == nil {
+		return ""
+	}
+	if t.Type == "plain_text" {
+		return escapeMrkdwn(t.Text)
+	}
+	return t.Text
+}
+
+// elementMrkdwn renders an element of a context, actions or section block.
+// Images and interactive elements without a link show nothing.
+func elementMrkdwn(el slackapi.BlockElement) string {
+	switch el.Type {
+	case "mrkdwn", "plain_text":
+		return textObjectMrkdwn(&slackapi.TextObject{Type: el.Type, Text: elementText(el)})
+	case "button":
+		if el.URL != "" {
+			return linkMrkdwn(el.URL, textObjectMrkdwn(el.Text))
+		}
+	}
+	return ""
+}
+
+// richTextMrkdwn renders the sections, lists, quotes and preformatted
+// text of a rich_text block, one per line.
+func richTextMrkdwn(elements []slackapi.BlockElement) string {
+	var lines []string
+	for _, el := range elements {
+		var text string
+		switch el.Type {
+		case "rich_text_section":
+			text = inlineMrkdwn(el.Elements, true)
+		case "rich_text_list":
+			text = richTextListMrkdwn(el)
+		case "rich_text_quote":
+			text = "> " + strings.ReplaceAll(strings.TrimRight(inlineMrkdwn(el.Elements, true), "\n"), "\n", "\n> ")
+		case "rich_text_preformatted":
+			text = "```" + strings.TrimRight(inlineMrkdwn(el.Elements, false), "\n") + "```"
+		}
+		lines = append(lines, strings.TrimRight(text, "\n"))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// richTextListMrkdwn renders a rich text list, one item per line, indented
+// four spaces per nesting level.
+func richTextListMrkdwn(list slackapi.BlockElement) string {
+	ordered := list.Style != nil && list.Style.Name == "ordered"
+	indent := strings.Repeat("    ", list.Indent)
+	lines := make([]string, 0, len(list.Elements))
+	for i, item := range list.Elements {
+		marker := "• "
+		if ordered {
+			marker = fmt.Sprintf("%d. ", list.Offset+i+1)
+		}
+		lines = append(lines, indent+marker+strings.TrimRight(inlineMrkdwn(item.Elements, true), "\n"))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// inlineMrkdwn renders the inline elements of a rich text section. Text
+// styles are dropped when styled is false, as in preformatted text.
+func inlineMrkdwn(elements []slackapi.BlockElement, styled bool) string {
+	var sb strings.Builder
+	for _, el := range elements {
+		switch el.Type {
+		case "text":
+			text := escapeMrkdwn(elementText(el))
+			if styled {
+				text = styleMrkdwn(text, el.Style)
+			}
+			sb.WriteString(text)
+		case "link":
+			sb.WriteString(linkMrkdwn(el.URL, escapeMrkdwn(elementText(el))))
+		case "user":
+			sb.WriteString("<@" + el.UserID + ">")
+		case "channel":
+			sb.WriteString("<#" + el.ChannelID + ">")
+		case "usergroup":
+			sb.WriteString("<!subteam^" + el.UsergroupID + ">")
+		case "broadcast":
+			sb.WriteString("<!" + el.Range + ">")
+		case "emoji":
+			sb.WriteString(":" + el.Name + ":")
+		case "date":
+			if el.Fallback != "" {
+				sb.WriteString(escapeMrkdwn(el.Fallback))
+			} else if el.Timestamp != 0 {
+				sb.WriteString(time.Unix(el.Timestamp, 0).UTC().Format("Jan 2, 2006 3:04 PM MST"))
+			}
+		case "color":
+			sb.WriteString(el.Value)
+		}
+	}
+	return sb.String()
+}
+
+// styleMrkdwn wraps text in the mrkdwn markers of style. Whitespace at the
+// ends stays outside the markers, which Slack requires.
+func styleMrkdwn(text string, style *slackapi.ElementStyle) string {
+	if style == nil {
+		return text
+	}
+	core := strings.TrimSpace(text)
+	if core == "" {
+		return text
+	}
+	start := strings.Index(text, core)
+	lead, trail := text[:start], text[start+len(core):]
+	if style.Code {
+		core = "`" + core + "`"
+	}
+	if style.Strike {
+		core = "~" + core + "~"
+	}
+	if style.Italic {
+		core = "_" + core + "_"
+	}
+	if style.Bold {
+		core = "*" + core + "*"
+	}
+	return lead + core + trail
+}
+
+// linkMrkdwn formats a link to url labelled text, or a bare link when
+// text is empty.
+func linkMrkdwn(url, text string) string {
+	if url == "" {
+		return text
+	}
+	if text == "" || text == url {
+		return "<" + url + ">"
+	}
+	return "<" + url + "|" + text + ">"
+}
+
+// elementText returns the text of el, or "" when it has none.
+func elementText(el slackapi.BlockElement) string {
+	if el.Text == nil {
+		return ""
+	}
+	return el.Text.Text
+}
+
+// escapeMrkdwn escapes the characters Slack escapes in message text, so
+// raw block text decodes like it.
+func escapeMrkdwn(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
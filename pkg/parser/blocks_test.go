@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+func decodeMessage(t *testing.T, data string) slackapi.Message {
+	t.Helper()
+	var msg slackapi.Message
+	if err := json.Unmarshal([]byte(data), &msg); err != nil {
+		t.Fatalf("decode message: %v", err)
+	}
+	return msg
+}
+
+func TestBlocksToMrkdwn_BotMessage(t *testing.T) {
+	msg := decodeMessage(t, `{"type":"message","bot_id":"B1","text":"Deploy finished","blocks":[
+		{"type":"header","text":{"type":"plain_text","text":"Deploy <prod>","emoji":true}},
+		{"type":"section","text":{"type":"mrkdwn","text":"*api* deployed by <@U001>"},
+		 "fields":[{"type":"mrkdwn","text":"*Version:*\nv1.2"},{"type":"plain_text","text":"Region: us-east"}],
+		 "accessory":{"type":"button","text":{"type":"plain_text","text":"Logs"},"url":"https://ci.example.com/1","style":"primary"}},
+		{"type":"divider"},
+		{"type":"context","elements":[{"type":"image","image_url":"https://example.com/i.png","alt_text":"icon"},{"type":"mrkdwn","text":"via CI"},{"type":"plain_text","text":"2 min"}]},
+		{"type":"actions","elements":[{"type":"button","text":{"type":"plain_text","text":"Approve"},"value":"ok"}]},
+		{"type":"image","image_url":"https://example.com/graph.png","alt_text":"latency graph"}
+	]}`)
+
+	want := "*Deploy &lt;prod&gt;*\n" +
+		"*api* deployed by <@U001>\n*Version:*\nv1.2\nRegion: us-east\n<https://ci.example.com/1|Logs>\n" +
+		"---\n" +
+		"via CI · 2 min\n" +
+		"<https://example.com/graph.png|latency graph>"
+	if got := BlocksToMrkdwn(msg.Blocks); got != want {
+		t.Errorf("BlocksToMrkdwn() =\n%q\nwant\n%q", got, want)
+	}
+	if got := MessageText(msg); got != want {
+		t.Errorf("MessageText() = %q, want the blocks", got)
+	}
+}
+
+func TestBlocksToMrkdwn_RichText(t *testing.T) {
+	msg := decodeMessage(t, `{"type":"message","text":"","blocks":[{"type":"rich_text","elements":[
+		{"type":"rich_text_section","elements":[
+			{"type":"text","text":"Hi "},{"type":"user","user_id":"U001"},
+			{"type":"text","text":" see ","style":{"bold":true}},
+			{"type":"link","url":"https://example.com","text":"docs"},
+			{"type":"text","text":" in "},{"type":"channel","channel_id":"C001"},
+			{"type":"text","text":" "},{"type":"emoji","name":"tada","unicode":"1f389"},
+			{"type":"text","text":" "},{"type":"broadcast","range":"here"},
+			{"type":"text","text":" x<y","style":{"code":true}},
+			{"type":"text","text":"\n"}]},
+		{"type":"rich_text_list","style":"ordered","indent":0,"elements":[
+			{"type":"rich_text_section","elements":[{"type":"text","text":"first","style":{"italic":true,"strike":true}}]},
+			{"type":"rich_text_section","elements":[{"type":"text","text":"second"}]}]},
+		{"type":"rich_text_list","style":"bullet","indent":1,"elements":[
+			{"type":"rich_text_section","elements":[{"type":"text","text":"nested"}]}]},
+		{"type":"rich_text_quote","elements":[{"type":"text","text":"quoted\nlines"}]},
+		{"type":"rich_text_preformatted","elements":[{"type":"text","text":"go test ./...","style":{"bold":true}}]},
+		{"type":"rich_text_section","elements":[{"type":"date","timestamp":1706788800,"format":"{date}","fallback":"Feb 1"}]}
+	]}]}`)
+
+	want := "Hi <@U001> *see* <https://example.com|docs> in <#C001> :tada: <!here> `x&lt;y`\n" +
+		"1. _~first~_\n2. second\n" +
+		"    • nested\n" +
+		"> quoted\n> lines\n" +
+		"```go test ./...```\n" +
+		"Feb 1"
+	if got := MessageText(msg); got != want {
+		t.Errorf("MessageText() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestMessageText(t *testing.T) {
+	richText := []slackapi.Block{{Type: "rich_text", Elements: []slackapi.BlockElement{{
+		Type:     "rich_text_section",
+		Elements: []slackapi.BlockElement{{Type: "text", Text: &slackapi.TextObject{Text: "from blocks"}}},
+	}}}}
+	section := []slackapi.Block{{Type: "section", Text: &slackapi.TextObject{Type: "mrkdwn", Text: "from *section*"}}}
+
+	tests := []struct {
+		name string
+		msg  slackapi.Message
+		want string
+	}{
+		{"no blocks", slackapi.Message{Text: "plain"}, "plain"},
+		{"rich text with text", slackapi.Message{Text: "from text", Blocks: richText}, "from text"},
+		{"rich text without text", slackapi.Message{Blocks: richText}, "from blocks"},
+		{"section over fallback", slackapi.Message{Text: "fallback", Blocks: section}, "from *section*"},
+		{"nothing to show", slackapi.Message{Text: "fallback", Blocks: []slackapi.Block{{Type: "input"}}}, "fallback"},
+	}
+	for _, tt := range tests {
+		if got := MessageText(tt.msg); got != tt.want {
+			t.Errorf("%s: MessageText() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestConvertMrkdwn_Blocks(t *testing.T) {
+	users := NewUserResolver()
+	msg := slackapi.Message{Blocks: []slackapi.Block{{Type: "rich_text", Elements: []slackapi.BlockElement{{
+		Type: "rich_text_section",
+		Elements: []slackapi.BlockElement{
+			{Type: "text", Text: &slackapi.TextObject{Text: "a < b & "}},
+			{Type: "link", URL: "https://example.com", Text: &slackapi.TextObject{Text: "c"}},
+		},
+	}}}}}
+	if got, want := ConvertMrkdwnToMarkdown(MessageText(msg), users, nil, nil), "a < b & [c](https://example.com)"; got != want {
+		t.Errorf("ConvertMrkdwnToMarkdown() = %q, want %q", got, want)
+	}
+}
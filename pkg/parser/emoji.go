@@ -0,0 +1,123 @@
+package parser
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// EmojiAPI is the subset of the Slack API client needed to load custom
+// emoji. It is satisfied by *slackapi.Client.
+type EmojiAPI interface {
+	ListEmoji(ctx context.Context) (map[string]string, error)
+}
+
+// Emoji is a resolved emoji shortcode.
+type Emoji struct {
+	// Name is the shortcode without colons, e.g. "tada" or
+	// "+1::skin-tone-3".
+	Name string
+	// Unicode is the emoji's characters; empty for custom emoji and
+	// unknown shortcodes.
+	Unicode string
+	// ImageURL is a custom emoji's image.
+	ImageURL string
+}
+
+// String returns the emoji's characters, or its :name: shortcode when it
+// has none.
+func (e Emoji) String() string {
+	if e.Unicode != "" {
+		return e.Unicode
+	}
+	return ":" + e.Name + ":"
+}
+
+// Custom reports whether e is a custom emoji with an image.
+func (e Emoji) Custom() bool {
+	return e.Unicode == "" && e.ImageURL != ""
+}
+
+// skinTones maps Slack's skin tone modifiers to Unicode's.
+var skinTones = map[string]string{
+	"skin-tone-2": "\U0001F3FB",
+	"skin-tone-3": "\U0001F3FC",
+	"skin-tone-4": "\U0001F3FD",
+	"skin-tone-5": "\U0001F3FE",
+	"skin-tone-6": "\U0001F3FF",
+}
+
+// maxEmojiAliases bounds how many aliases Lookup follows, so an alias
+// cycle in emoji.list cannot loop forever.
+const maxEmojiAliases = 5
+
+// EmojiResolver resolves emoji shortcodes to Unicode characters, or, for
+// the workspace's custom emoji, to their images. A nil *EmojiResolver
+// resolves nothing, so every emoji renders as its shortcode.
+type EmojiResolver struct {
+	mu     sync.RWMutex
+	custom map[string]string // name -> image URL or "alias:<name>"
+}
+
+// NewEmojiResolver creates a resolver for standard emoji. Call
+// LoadCustomEmoji to add the workspace's own.
+func NewEmojiResolver() *EmojiResolver {
+	return &EmojiResolver{custom: make(map[string]string)}
+}
+
+// LoadCustomEmoji fetches the workspace's custom emoji (emoji.list).
+func (r *EmojiResolver) LoadCustomEmoji(ctx context.Context, api EmojiAPI) error {
+	emoji, err := api.ListEmoji(ctx)
+	if err != nil {
+		return err
+	}
+	r.SetCustomEmoji(emoji)
+	return nil
+}
+
+// SetCustomEmoji replaces the custom emoji, mapping each name to an image
+// URL or to "alias:<name>".
+func (r *EmojiResolver) SetCustomEmoji(emoji map[string]string) {
+	custom := make(map[string]string, len(emoji))
+	for name, value := range emoji {
+		custom[name] = value
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.custom = custom
+}
+
+// Lookup resolves a shortcode without colons. Skin tone modifiers
+// ("+1::skin-tone-3") are applied to standard emoji. Custom emoji may
+// alias standard ones. An unknown shortcode resolves to an Emoji with
+// only its Name.
+func (r *EmojiResolver) Lookup(name string) Emoji {
+	e := Emoji{Name: name}
+	if r == nil {
+		return e
+	}
+	base, tone, _ := strings.Cut(name, "::")
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for i := 0; i <= maxEmojiAliases; i++ {
+		if u, ok := standardEmoji[base]; ok {
+			if modifier, ok := skinTones[tone]; ok {
+				u = strings.TrimSuffix(u, "\uFE0F") + modifier
+			}
+			e.Unicode = u
+			return e
+		}
+		value, ok := r.custom[base]
+		if !ok {
+			return e
+		}
+		alias, isAlias := strings.CutPrefix(value, "alias:")
+		if !isAlias {
+			e.ImageURL = value
+			return e
+		}
+		base = alias
+	}
+	return e
+}
@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type fakeEmojiAPI struct {
+	emoji map[string]string
+	err   error
+}
+
+func (f fakeEmojiAPI) ListEmoji(context.Context) (map[string]string, error) {
+	return f.emoji, f.err
+}
+
+func TestEmojiResolver_Lookup(t *testing.T) {
+	r := NewEmojiResolver()
+	err := r.LoadCustomEmoji(context.Background(), fakeEmojiAPI{emoji: map[string]string{
+		"party_parrot": "https://emoji.slack-edge.com/T001/party_parrot/abc.gif",
+		"parrot":       "alias:party_parrot",
+		"yes":          "alias:white_check_mark",
+		"loop_a":       "alias:loop_b",
+		"loop_b":       "alias:loop_a",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		wantStr   string
+		wantImage string
+	}{
+		{"tada", "🎉", ""},
+		{"thumbsup", "👍", ""},
+		{"+1", "👍", ""},
+		{"+1::skin-tone-3", "👍\U0001F3FC", ""},
+		{"v::skin-tone-2", "✌\U0001F3FB", ""},
+		{"heart", "❤️", ""},
+		{"party_parrot", ":party_parrot:", "https://emoji.slack-edge.com/T001/party_parrot/abc.gif"},
+		{"parrot", ":parrot:", "https://emoji.slack-edge.com/T001/party_parrot/abc.gif"},
+		{"yes", "✅", ""},
+		{"loop_a", ":loop_a:", ""},
+		{"no_such_emoji", ":no_such_emoji:", ""},
+	}
+	for _, tt := range tests {
+		got := r.Lookup(tt.name)
+		if got.String() != tt.wantStr || got.ImageURL != tt.wantImage {
+			t.Errorf("Lookup(%q) = %q (image %q), want %q (image %q)", tt.name, got.String(), got.ImageURL, tt.wantStr, tt.wantImage)
+		}
+		if got.Name != tt.name {
+			t.Errorf("Lookup(%q).Name = %q", tt.name, got.Name)
+		}
+	}
+	if !r.Lookup("parrot").Custom() || r.Lookup("tada").Custom() {
+		t.Error("Custom() should report only emoji with an image")
+	}
+}
+
+func TestEmojiResolver_Nil(t *testing.T) {
+	var r *EmojiResolver
+	if got := r.Lookup("tada").String(); got != ":tada:" {
+		t.Errorf("nil resolver Lookup(tada) = %q, want the shortcode", got)
+	}
+}
+
+func TestEmojiResolver_LoadError(t *testing.T) {
+	r := NewEmojiResolver()
+	if err := r.LoadCustomEmoji(context.Background(), fakeEmojiAPI{err: errors.New("missing_scope")}); err == nil {
+		t.Fatal("expected the emoji.list error")
+	}
+	if got := r.Lookup("tada").String(); got != "🎉" {
+		t.Errorf("Lookup(tada) = %q, want standard emoji to still resolve", got)
+	}
+}
@@ -0,0 +1,885 @@
+package parser
+
+// standardEmoji maps Slack's shortcodes for standard emoji, without the
+// colons, to their Unicode characters. It covers the emoji people react
+// with most, under both of Slack's names where it has two (thumbsup and
+// +1); a shortcode missing here renders as :name:. Characters whose
+// default presentation is text carry U+FE0F so they render as emoji.
+var standardEmoji = map[string]string{
+	"+1":                                    "👍",
+	"-1":                                    "👎",
+	"100":                                   "💯",
+	"1234":                                  "🔢",
+	"8ball":                                 "🎱",
+	"a":                                     "🅰\uFE0F",
+	"ab":                                    "🆎",
+	"abacus":                                "🧮",
+	"abc":                                   "🔤",
+	"abcd":                                  "🔡",
+	"admission_tickets":                     "🎟\uFE0F",
+	"adult":                                 "🧑",
+	"airplane":                              "✈\uFE0F",
+	"airplane_arriving":                     "🛬",
+	"airplane_departure":                    "🛫",
+	"alarm_clock":                           "⏰",
+	"alembic":                               "⚗\uFE0F",
+	"alien":                                 "👽",
+	"ambulance":                             "🚑",
+	"anchor":                                "⚓",
+	"anger":                                 "💢",
+	"angry":                                 "😠",
+	"anguished":                             "😧",
+	"ant":                                   "🐜",
+	"apple":                                 "🍎",
+	"arrow_backward":                        "◀\uFE0F",
+	"arrow_down":                            "⬇\uFE0F",
+	"arrow_down_small":                      "🔽",
+	"arrow_forward":                         "▶\uFE0F",
+	"arrow_heading_down":                    "⤵\uFE0F",
+	"arrow_heading_up":                      "⤴\uFE0F",
+	"arrow_left":                            "⬅\uFE0F",
+	"arrow_lower_left":                      "↙\uFE0F",
+	"arrow_lower_right":                     "↘\uFE0F",
+	"arrow_right":                           "➡\uFE0F",
+	"arrow_right_hook":                      "↪\uFE0F",
+	"arrow_up":                              "⬆\uFE0F",
+	"arrow_up_down":                         "↕\uFE0F",
+	"arrow_up_small":                        "🔼",
+	"arrow_upper_left":                      "↖\uFE0F",
+	"arrow_upper_right":                     "↗\uFE0F",
+	"arrows_clockwise":                      "🔃",
+	"arrows_counterclockwise":               "🔄",
+	"art":                                   "🎨",
+	"astonished":                            "😲",
+	"athletic_shoe":                         "👟",
+	"atm":                                   "🏧",
+	"atom_symbol":                           "⚛\uFE0F",
+	"avocado":                               "🥑",
+	"b":                                     "🅱\uFE0F",
+	"baby":                                  "👶",
+	"back":                                  "🔙",
+	"bacon":                                 "🥓",
+	"balloon":                               "🎈",
+	"ballot_box_with_ballot":                "🗳\uFE0F",
+	"ballot_box_with_check":                 "☑\uFE0F",
+	"bamboo":                                "🎍",
+	"banana":                                "🍌",
+	"bangbang":                              "‼\uFE0F",
+	"bank":                                  "🏦",
+	"bar_chart":                             "📊",
+	"baseball":                              "⚾",
+	"basket":                                "🧺",
+	"basketball":                            "🏀",
+	"bathtub":                               "🛁",
+	"battery":                               "🔋",
+	"beach_with_umbrella":                   "🏖\uFE0F",
+	"bear":                                  "🐻",
+	"bed":                                   "🛏\uFE0F",
+	"bee":                                   "🐝",
+	"beer":                                  "🍺",
+	"beers":                                 "🍻",
+	"beginner":                              "🔰",
+	"bell":                                  "🔔",
+	"bento":                                 "🍱",
+	"bike":                                  "🚲",
+	"bikini":                                "👙",
+	"biohazard_sign":                        "☣\uFE0F",
+	"bird":                                  "🐦",
+	"birthday":                              "🎂",
+	"black_circle":                          "⚫",
+	"black_circle_for_record":               "⏺\uFE0F",
+	"black_heart":                           "🖤",
+	"black_joker":                           "🃏",
+	"black_large_square":                    "⬛",
+	"black_medium_square":                   "◼\uFE0F",
+	"black_nib":                             "✒\uFE0F",
+	"black_small_square":                    "▪\uFE0F",
+	"black_square_button":                   "🔲",
+	"black_square_for_stop":                 "⏹\uFE0F",
+	"blue_book":                             "📘",
+	"blue_heart":                            "💙",
+	"blush":                                 "😊",
+	"boat":                                  "⛵",
+	"book":                                  "📖",
+	"bookmark":                              "🔖",
+	"bookmark_tabs":                         "📑",
+	"books":                                 "📚",
+	"boom":                                  "💥",
+	"bouquet":                               "💐",
+	"bow":                                   "🙇",
+	"bow_and_arrow":                         "🏹",
+	"bowling":                               "🎳",
+	"boy":                                   "👦",
+	"brain":                                 "🧠",
+	"bread":                                 "🍞",
+	"bridge_at_night":                       "🌉",
+	"briefcase":                             "💼",
+	"broken_heart":                          "💔",
+	"broom":                                 "🧹",
+	"brown_heart":                           "🤎",
+	"bug":                                   "🐛",
+	"bulb":                                  "💡",
+	"bullettrain_side":                      "🚄",
+	"burrito":                               "🌯",
+	"bus":                                   "🚌",
+	"busstop":                               "🚏",
+	"bust_in_silhouette":                    "👤",
+	"busts_in_silhouette":                   "👥",
+	"butterfly":                             "🦋",
+	"cactus":                                "🌵",
+	"cake":                                  "🍰",
+	"calendar":                              "📆",
+	"call_me_hand":                          "🤙",
+	"calling":                               "📲",
+	"camera":                                "📷",
+	"camera_with_flash":                     "📸",
+	"candle":                                "🕯\uFE0F",
+	"candy":                                 "🍬",
+	"capital_abcd":                          "🔠",
+	"car":                                   "🚗",
+	"card_file_box":                         "🗃\uFE0F",
+	"card_index":                            "📇",
+	"card_index_dividers":                   "🗂\uFE0F",
+	"carrot":                                "🥕",
+	"cat":                                   "🐱",
+	"cat2":                                  "🐈",
+	"cd":                                    "💿",
+	"chains":                                "⛓\uFE0F",
+	"champagne":                             "🍾",
+	"chart":                                 "💹",
+	"chart_with_downwards_trend":            "📉",
+	"chart_with_upwards_trend":              "📈",
+	"checkered_flag":                        "🏁",
+	"cheese_wedge":                          "🧀",
+	"cherries":                              "🍒",
+	"cherry_blossom":                        "🌸",
+	"chess_pawn":                            "♟\uFE0F",
+	"chicken":                               "🐔",
+	"child":                                 "🧒",
+	"children_crossing":                     "🚸",
+	"chipmunk":                              "🐿\uFE0F",
+	"chocolate_bar":                         "🍫",
+	"christmas_tree":                        "🎄",
+	"cinema":                                "🎦",
+	"city_sunset":                           "🌆",
+	"cl":                                    "🆑",
+	"clap":                                  "👏",
+	"clapper":                               "🎬",
+	"clinking_glasses":                      "🥂",
+	"clipboard":                             "📋",
+	"clock1":                                "🕐",
+	"clock12":                               "🕛",
+	"closed_book":                           "📕",
+	"closed_lock_with_key":                  "🔐",
+	"cloud":                                 "☁\uFE0F",
+	"clown_face":                            "🤡",
+	"clubs":                                 "♣\uFE0F",
+	"cn":                                    "🇨🇳",
+	"cocktail":                              "🍸",
+	"coffee":                                "☕",
+	"coffin":                                "⚰\uFE0F",
+	"cold_face":                             "🥶",
+	"cold_sweat":                            "😰",
+	"collision":                             "💥",
+	"comet":                                 "☄\uFE0F",
+	"compression":                           "🗜\uFE0F",
+	"computer":                              "💻",
+	"confetti_ball":                         "🎊",
+	"confounded":                            "😖",
+	"confused":                              "😕",
+	"construction":                          "🚧",
+	"cookie":                                "🍪",
+	"cool":                                  "🆒",
+	"copyright":                             "©\uFE0F",
+	"corn":                                  "🌽",
+	"couch_and_lamp":                        "🛋\uFE0F",
+	"cow":                                   "🐮",
+	"credit_card":                           "💳",
+	"crescent_moon":                         "🌙",
+	"croissant":                             "🥐",
+	"crossed_fingers":                       "🤞",
+	"crossed_flags":                         "🎌",
+	"crossed_swords":                        "⚔\uFE0F",
+	"crown":                                 "👑",
+	"cry":                                   "😢",
+	"crystal_ball":                          "🔮",
+	"cup_with_straw":                        "🥤",
+	"cupcake":                               "🧁",
+	"cupid":                                 "💘",
+	"curly_loop":                            "➰",
+	"cyclone":                               "🌀",
+	"dagger_knife":                          "🗡\uFE0F",
+	"dancer":                                "💃",
+	"dark_sunglasses":                       "🕶\uFE0F",
+	"dart":                                  "🎯",
+	"dash":                                  "💨",
+	"date":                                  "📅",
+	"de":                                    "🇩🇪",
+	"deciduous_tree":                        "🌳",
+	"desert_island":                         "🏝\uFE0F",
+	"desktop_computer":                      "🖥\uFE0F",
+	"diamond_shape_with_a_dot_inside":       "💠",
+	"diamonds":                              "♦\uFE0F",
+	"disappointed":                          "😞",
+	"disappointed_relieved":                 "😥",
+	"dizzy":                                 "💫",
+	"dizzy_face":                            "😵",
+	"dna":                                   "🧬",
+	"dog":                                   "🐶",
+	"dog2":                                  "🐕",
+	"dollar":                                "💵",
+	"dolls":                                 "🎎",
+	"dolphin":                               "🐬",
+	"door":                                  "🚪",
+	"double_vertical_bar":                   "⏸\uFE0F",
+	"doughnut":                              "🍩",
+	"dragon_face":                           "🐲",
+	"dress":                                 "👗",
+	"drooling_face":                         "🤤",
+	"droplet":                               "💧",
+	"drum_with_drumsticks":                  "🥁",
+	"duck":                                  "🦆",
+	"dvd":                                   "📀",
+	"e-mail":                                "📧",
+	"eagle":                                 "🦅",
+	"earth_africa":                          "🌍",
+	"earth_americas":                        "🌎",
+	"earth_asia":                            "🌏",
+	"egg":                                   "🥚",
+	"eggplant":                              "🍆",
+	"eight":                                 "8\uFE0F\u20E3",
+	"eight_pointed_black_star":              "✴\uFE0F",
+	"eight_spoked_asterisk":                 "✳\uFE0F",
+	"eject":                                 "⏏\uFE0F",
+	"electric_plug":                         "🔌",
+	"email":                                 "✉\uFE0F",
+	"end":                                   "🔚",
+	"envelope":                              "✉\uFE0F",
+	"envelope_with_arrow":                   "📩",
+	"es":                                    "🇪🇸",
+	"euro":                                  "💶",
+	"european_castle":                       "🏰",
+	"evergreen_tree":                        "🌲",
+	"exclamation":                           "❗",
+	"exploding_head":                        "🤯",
+	"expressionless":                        "😑",
+	"eye":                                   "👁\uFE0F",
+	"eyeglasses":                            "👓",
+	"eyes":                                  "👀",
+	"face_palm":                             "🤦",
+	"face_vomiting":                         "🤮",
+	"face_with_cowboy_hat":                  "🤠",
+	"face_with_hand_over_mouth":             "🤭",
+	"face_with_head_bandage":                "🤕",
+	"face_with_monocle":                     "🧐",
+	"face_with_raised_eyebrow":              "🤨",
+	"face_with_rolling_eyes":                "🙄",
+	"face_with_symbols_on_mouth":            "🤬",
+	"face_with_thermometer":                 "🤒",
+	"facepunch":                             "👊",
+	"factory":                               "🏭",
+	"fallen_leaf":                           "🍂",
+	"fast_forward":                          "⏩",
+	"fax":                                   "📠",
+	"fearful":                               "😨",
+	"file_cabinet":                          "🗄\uFE0F",
+	"file_folder":                           "📁",
+	"film_frames":                           "🎞\uFE0F",
+	"film_projector":                        "📽\uFE0F",
+	"fire":                                  "🔥",
+	"fire_engine":                           "🚒",
+	"fire_extinguisher":                     "🧯",
+	"fireworks":                             "🎆",
+	"first_place_medal":                     "🥇",
+	"fish":                                  "🐟",
+	"fishing_pole_and_fish":                 "🎣",
+	"fist":                                  "✊",
+	"five":                                  "5\uFE0F\u20E3",
+	"flag-au":                               "🇦🇺",
+	"flag-br":                               "🇧🇷",
+	"flag-ca":                               "🇨🇦",
+	"flag-ch":                               "🇨🇭",
+	"flag-cn":                               "🇨🇳",
+	"flag-de":                               "🇩🇪",
+	"flag-es":                               "🇪🇸",
+	"flag-eu":                               "🇪🇺",
+	"flag-fr":                               "🇫🇷",
+	"flag-gb":                               "🇬🇧",
+	"flag-ie":                               "🇮🇪",
+	"flag-in":                               "🇮🇳",
+	"flag-it":                               "🇮🇹",
+	"flag-jp":                               "🇯🇵",
+	"flag-kr":                               "🇰🇷",
+	"flag-mx":                               "🇲🇽",
+	"flag-nl":                               "🇳🇱",
+	"flag-se":                               "🇸🇪",
+	"flag-ua":                               "🇺🇦",
+	"flag-us":                               "🇺🇸",
+	"flags":                                 "🎏",
+	"flashlight":                            "🔦",
+	"fleur_de_lis":                          "⚜\uFE0F",
+	"floppy_disk":                           "💾",
+	"flushed":                               "😳",
+	"flying_saucer":                         "🛸",
+	"fog":                                   "🌫\uFE0F",
+	"foggy":                                 "🌁",
+	"football":                              "🏈",
+	"footprints":                            "👣",
+	"fork_and_knife":                        "🍴",
+	"four":                                  "4\uFE0F\u20E3",
+	"four_leaf_clover":                      "🍀",
+	"fox_face":                              "🦊",
+	"fr":                                    "🇫🇷",
+	"free":                                  "🆓",
+	"fried_egg":                             "🍳",
+	"fries":                                 "🍟",
+	"frog":                                  "🐸",
+	"frowning":                              "😦",
+	"fuelpump":                              "⛽",
+	"full_moon":                             "🌕",
+	"game_die":                              "🎲",
+	"gb":                                    "🇬🇧",
+	"gear":                                  "⚙\uFE0F",
+	"gem":                                   "💎",
+	"ghost":                                 "👻",
+	"gift":                                  "🎁",
+	"gift_heart":                            "💝",
+	"girl":                                  "👧",
+	"globe_with_meridians":                  "🌐",
+	"golf":                                  "⛳",
+	"grapes":                                "🍇",
+	"green_apple":                           "🍏",
+	"green_book":                            "📗",
+	"green_heart":                           "💚",
+	"grey_exclamation":                      "❕",
+	"grey_question":                         "❔",
+	"grimacing":                             "😬",
+	"grin":                                  "😁",
+	"grinning":                              "😀",
+	"guitar":                                "🎸",
+	"gun":                                   "🔫",
+	"hamburger":                             "🍔",
+	"hammer":                                "🔨",
+	"hammer_and_pick":                       "⚒\uFE0F",
+	"hammer_and_wrench":                     "🛠\uFE0F",
+	"hamster":                               "🐹",
+	"hand":                                  "✋",
+	"handbag":                               "👜",
+	"handshake":                             "🤝",
+	"hankey":                                "💩",
+	"hash":                                  "#\uFE0F\u20E3",
+	"headphones":                            "🎧",
+	"hear_no_evil":                          "🙉",
+	"heart":                                 "❤\uFE0F",
+	"heart_decoration":                      "💟",
+	"heart_eyes":                            "😍",
+	"heart_eyes_cat":                        "😻",
+	"heartbeat":                             "💓",
+	"heartpulse":                            "💗",
+	"hearts":                                "♥\uFE0F",
+	"heavy_check_mark":                      "✔\uFE0F",
+	"heavy_division_sign":                   "➗",
+	"heavy_exclamation_mark":                "❗",
+	"heavy_heart_exclamation_mark_ornament": "❣\uFE0F",
+	"heavy_minus_sign":                      "➖",
+	"heavy_multiplication_x":                "✖\uFE0F",
+	"heavy_plus_sign":                       "➕",
+	"helicopter":                            "🚁",
+	"herb":                                  "🌿",
+	"high_brightness":                       "🔆",
+	"honeybee":                              "🐝",
+	"horse":                                 "🐴",
+	"hospital":                              "🏥",
+	"hot_face":                              "🥵",
+	"hot_pepper":                            "🌶\uFE0F",
+	"hotdog":                                "🌭",
+	"hotel":                                 "🏨",
+	"hourglass":                             "⌛",
+	"hourglass_flowing_sand":                "⏳",
+	"house":                                 "🏠",
+	"house_with_garden":                     "🏡",
+	"hugging_face":                          "🤗",
+	"hushed":                                "😯",
+	"i_love_you_hand_sign":                  "🤟",
+	"ice_skate":                             "⛸\uFE0F",
+	"icecream":                              "🍦",
+	"id":                                    "🆔",
+	"imp":                                   "👿",
+	"inbox_tray":                            "📥",
+	"incoming_envelope":                     "📨",
+	"infinity":                              "♾\uFE0F",
+	"information_desk_person":               "💁",
+	"information_source":                    "ℹ\uFE0F",
+	"innocent":                              "😇",
+	"interrobang":                           "⁉\uFE0F",
+	"iphone":                                "📱",
+	"it":                                    "🇮🇹",
+	"izakaya_lantern":                       "🏮",
+	"jack_o_lantern":                        "🎃",
+	"japanese_ogre":                         "👹",
+	"jeans":                                 "👖",
+	"jigsaw":                                "🧩",
+	"joy":                                   "😂",
+	"joy_cat":                               "😹",
+	"joystick":                              "🕹\uFE0F",
+	"jp":                                    "🇯🇵",
+	"key":                                   "🔑",
+	"keyboard":                              "⌨\uFE0F",
+	"keycap_star":                           "*\uFE0F\u20E3",
+	"keycap_ten":                            "🔟",
+	"kiss":                                  "💋",
+	"kissing":                               "😗",
+	"kissing_closed_eyes":                   "😚",
+	"kissing_heart":                         "😘",
+	"kissing_smiling_eyes":                  "😙",
+	"knife_fork_plate":                      "🍽\uFE0F",
+	"koala":                                 "🐨",
+	"kr":                                    "🇰🇷",
+	"label":                                 "🏷\uFE0F",
+	"large_blue_circle":                     "🔵",
+	"large_blue_diamond":                    "🔷",
+	"large_blue_square":                     "🟦",
+	"large_brown_circle":                    "🟤",
+	"large_brown_square":                    "🟫",
+	"large_green_circle":                    "🟢",
+	"large_green_square":                    "🟩",
+	"large_orange_circle":                   "🟠",
+	"large_orange_diamond":                  "🔶",
+	"large_orange_square":                   "🟧",
+	"large_purple_circle":                   "🟣",
+	"large_purple_square":                   "🟪",
+	"large_red_square":                      "🟥",
+	"large_yellow_circle":                   "🟡",
+	"large_yellow_square":                   "🟨",
+	"laughing":                              "😆",
+	"ledger":                                "📒",
+	"left-facing_fist":                      "🤛",
+	"left_right_arrow":                      "↔\uFE0F",
+	"leftwards_arrow_with_hook":             "↩\uFE0F",
+	"lemon":                                 "🍋",
+	"lightning":                             "🌩\uFE0F",
+	"link":                                  "🔗",
+	"linked_paperclips":                     "🖇\uFE0F",
+	"lion_face":                             "🦁",
+	"lips":                                  "👄",
+	"lipstick":                              "💄",
+	"llama":                                 "🦙",
+	"lock":                                  "🔒",
+	"lock_with_ink_pen":                     "🔏",
+	"lollipop":                              "🍭",
+	"loop":                                  "➿",
+	"loud_sound":                            "🔊",
+	"loudspeaker":                           "📢",
+	"love_letter":                           "💌",
+	"low_brightness":                        "🔅",
+	"lower_left_ballpoint_pen":              "🖊\uFE0F",
+	"lower_left_crayon":                     "🖍\uFE0F",
+	"lower_left_fountain_pen":               "🖋\uFE0F",
+	"lower_left_paintbrush":                 "🖌\uFE0F",
+	"lying_face":                            "🤥",
+	"m":                                     "Ⓜ\uFE0F",
+	"mag":                                   "🔍",
+	"mag_right":                             "🔎",
+	"magnet":                                "🧲",
+	"mailbox":                               "📫",
+	"mailbox_with_mail":                     "📬",
+	"man":                                   "👨",
+	"man_dancing":                           "🕺",
+	"mans_shoe":                             "👞",
+	"mask":                                  "😷",
+	"medal":                                 "🎖\uFE0F",
+	"mega":                                  "📣",
+	"memo":                                  "📝",
+	"mens":                                  "🚹",
+	"metro":                                 "🚇",
+	"microphone":                            "🎤",
+	"microscope":                            "🔬",
+	"middle_finger":                         "🖕",
+	"minidisc":                              "💽",
+	"mobile_phone_off":                      "📴",
+	"money_mouth_face":                      "🤑",
+	"money_with_wings":                      "💸",
+	"moneybag":                              "💰",
+	"monkey":                                "🐒",
+	"monkey_face":                           "🐵",
+	"mortar_board":                          "🎓",
+	"motorcycle":                            "🏍\uFE0F",
+	"mountain":                              "⛰\uFE0F",
+	"mouse":                                 "🐭",
+	"movie_camera":                          "🎥",
+	"moyai":                                 "🗿",
+	"muscle":                                "💪",
+	"mushroom":                              "🍄",
+	"musical_keyboard":                      "🎹",
+	"musical_note":                          "🎵",
+	"musical_score":                         "🎼",
+	"mute":                                  "🔇",
+	"nail_care":                             "💅",
+	"name_badge":                            "📛",
+	"nauseated_face":                        "🤢",
+	"necktie":                               "👔",
+	"negative_squared_cross_mark":           "❎",
+	"nerd_face":                             "🤓",
+	"neutral_face":                          "😐",
+	"new":                                   "🆕",
+	"new_moon":                              "🌑",
+	"newspaper":                             "📰",
+	"ng":                                    "🆖",
+	"night_with_stars":                      "🌃",
+	"nine":                                  "9\uFE0F\u20E3",
+	"no_bell":                               "🔕",
+	"no_bicycles":                           "🚳",
+	"no_entry":                              "⛔",
+	"no_entry_sign":                         "🚫",
+	"no_good":                               "🙅",
+	"no_mouth":                              "😶",
+	"no_smoking":                            "🚭",
+	"notebook":                              "📓",
+	"notebook_with_decorative_cover":        "📔",
+	"notes":                                 "🎶",
+	"nut_and_bolt":                          "🔩",
+	"o":                                     "⭕",
+	"o2":                                    "🅾\uFE0F",
+	"ocean":                                 "🌊",
+	"octopus":                               "🐙",
+	"office":                                "🏢",
+	"ok":                                    "🆗",
+	"ok_hand":                               "👌",
+	"ok_woman":                              "🙆",
+	"old_key":                               "🗝\uFE0F",
+	"older_adult":                           "🧓",
+	"om_symbol":                             "🕉\uFE0F",
+	"on":                                    "🔛",
+	"one":                                   "1\uFE0F\u20E3",
+	"open_book":                             "📖",
+	"open_file_folder":                      "📂",
+	"open_hands":                            "👐",
+	"open_mouth":                            "😮",
+	"orange_book":                           "📙",
+	"orange_heart":                          "🧡",
+	"outbox_tray":                           "📤",
+	"owl":                                   "🦉",
+	"package":                               "📦",
+	"page_facing_up":                        "📄",
+	"page_with_curl":                        "📃",
+	"pager":                                 "📟",
+	"palm_tree":                             "🌴",
+	"palms_up_together":                     "🤲",
+	"pancakes":                              "🥞",
+	"panda_face":                            "🐼",
+	"paperclip":                             "📎",
+	"parking":                               "🅿\uFE0F",
+	"part_alternation_mark":                 "〽\uFE0F",
+	"partly_sunny":                          "⛅",
+	"partying_face":                         "🥳",
+	"peace_symbol":                          "☮\uFE0F",
+	"peach":                                 "🍑",
+	"pear":                                  "🍐",
+	"pencil":                                "📝",
+	"pencil2":                               "✏\uFE0F",
+	"penguin":                               "🐧",
+	"pensive":                               "😔",
+	"performing_arts":                       "🎭",
+	"persevere":                             "😣",
+	"person_frowning":                       "🙍",
+	"person_with_pouting_face":              "🙎",
+	"petri_dish":                            "🧫",
+	"phone":                                 "☎\uFE0F",
+	"pie":                                   "🥧",
+	"pig":                                   "🐷",
+	"pill":                                  "💊",
+	"pinching_hand":                         "🤏",
+	"pineapple":                             "🍍",
+	"pizza":                                 "🍕",
+	"place_of_worship":                      "🛐",
+	"pleading_face":                         "🥺",
+	"point_down":                            "👇",
+	"point_left":                            "👈",
+	"point_right":                           "👉",
+	"point_up":                              "☝\uFE0F",
+	"point_up_2":                            "👆",
+	"police_car":                            "🚓",
+	"poop":                                  "💩",
+	"popcorn":                               "🍿",
+	"postal_horn":                           "📯",
+	"postbox":                               "📮",
+	"potato":                                "🥔",
+	"pound":                                 "💷",
+	"pray":                                  "🙏",
+	"printer":                               "🖨\uFE0F",
+	"punch":                                 "👊",
+	"purple_heart":                          "💜",
+	"purse":                                 "👛",
+	"pushpin":                               "📌",
+	"put_litter_in_its_place":               "🚮",
+	"question":                              "❓",
+	"rabbit":                                "🐰",
+	"racing_car":                            "🏎\uFE0F",
+	"radio":                                 "📻",
+	"radio_button":                          "🔘",
+	"radioactive_sign":                      "☢\uFE0F",
+	"rage":                                  "😡",
+	"rain_cloud":                            "🌧\uFE0F",
+	"rainbow":                               "🌈",
+	"rainbow-flag":                          "🏳\uFE0F\u200D🌈",
+	"raised_back_of_hand":                   "🤚",
+	"raised_hand":                           "✋",
+	"raised_hand_with_fingers_splayed":      "🖐\uFE0F",
+	"raised_hands":                          "🙌",
+	"raising_hand":                          "🙋",
+	"ramen":                                 "🍜",
+	"receipt":                               "🧾",
+	"recycle":                               "♻\uFE0F",
+	"red_car":                               "🚗",
+	"red_circle":                            "🔴",
+	"registered":                            "®\uFE0F",
+	"relaxed":                               "☺\uFE0F",
+	"relieved":                              "😌",
+	"repeat":                                "🔁",
+	"repeat_one":                            "🔂",
+	"restroom":                              "🚻",
+	"revolving_hearts":                      "💞",
+	"rewind":                                "⏪",
+	"ribbon":                                "🎀",
+	"rice":                                  "🍚",
+	"rice_scene":                            "🎑",
+	"right-facing_fist":                     "🤜",
+	"ring":                                  "💍",
+	"robot_face":                            "🤖",
+	"rocket":                                "🚀",
+	"roll_of_paper":                         "🧻",
+	"rolled_up_newspaper":                   "🗞\uFE0F",
+	"rolling_on_the_floor_laughing":         "🤣",
+	"rose":                                  "🌹",
+	"rotating_light":                        "🚨",
+	"round_pushpin":                         "📍",
+	"rugby_football":                        "🏉",
+	"runner":                                "🏃",
+	"running":                               "🏃",
+	"running_shirt_with_sash":               "🎽",
+	"sailboat":                              "⛵",
+	"sake":                                  "🍶",
+	"sandwich":                              "🥪",
+	"satellite":                             "🛰\uFE0F",
+	"satellite_antenna":                     "📡",
+	"satisfied":                             "😆",
+	"saxophone":                             "🎷",
+	"scales":                                "⚖\uFE0F",
+	"school":                                "🏫",
+	"school_satchel":                        "🎒",
+	"scissors":                              "✂\uFE0F",
+	"scooter":                               "🛴",
+	"scream":                                "😱",
+	"scream_cat":                            "🙀",
+	"scroll":                                "📜",
+	"seat":                                  "💺",
+	"second_place_medal":                    "🥈",
+	"see_no_evil":                           "🙈",
+	"seedling":                              "🌱",
+	"selfie":                                "🤳",
+	"seven":                                 "7\uFE0F\u20E3",
+	"shark":                                 "🦈",
+	"shield":                                "🛡\uFE0F",
+	"ship":                                  "🚢",
+	"shirt":                                 "👕",
+	"shopping_bags":                         "🛍\uFE0F",
+	"shopping_trolley":                      "🛒",
+	"shower":                                "🚿",
+	"shrug":                                 "🤷",
+	"shushing_face":                         "🤫",
+	"signal_strength":                       "📶",
+	"six":                                   "6\uFE0F\u20E3",
+	"ski":                                   "🎿",
+	"skull":                                 "💀",
+	"skull_and_crossbones":                  "☠\uFE0F",
+	"sleeping":                              "😴",
+	"sleepy":                                "😪",
+	"slightly_frowning_face":                "🙁",
+	"slightly_smiling_face":                 "🙂",
+	"slot_machine":                          "🎰",
+	"sloth":                                 "🦥",
+	"small_airplane":                        "🛩\uFE0F",
+	"small_blue_diamond":                    "🔹",
+	"small_orange_diamond":                  "🔸",
+	"small_red_triangle":                    "🔺",
+	"small_red_triangle_down":               "🔻",
+	"smile":                                 "😄",
+	"smile_cat":                             "😸",
+	"smiley":                                "😃",
+	"smiley_cat":                            "😺",
+	"smiling_face_with_3_hearts":            "🥰",
+	"smiling_imp":                           "😈",
+	"smirk":                                 "😏",
+	"smoking":                               "🚬",
+	"snail":                                 "🐌",
+	"snake":                                 "🐍",
+	"sneezing_face":                         "🤧",
+	"snow_cloud":                            "🌨\uFE0F",
+	"snowflake":                             "❄\uFE0F",
+	"snowman":                               "⛄",
+	"soap":                                  "🧼",
+	"sob":                                   "😭",
+	"soccer":                                "⚽",
+	"soon":                                  "🔜",
+	"sos":                                   "🆘",
+	"sound":                                 "🔉",
+	"space_invader":                         "👾",
+	"spades":                                "♠\uFE0F",
+	"spaghetti":                             "🍝",
+	"sparkle":                               "❇\uFE0F",
+	"sparkler":                              "🎇",
+	"sparkles":                              "✨",
+	"sparkling_heart":                       "💖",
+	"speak_no_evil":                         "🙊",
+	"speaker":                               "🔈",
+	"speaking_head_in_silhouette":           "🗣\uFE0F",
+	"speech_balloon":                        "💬",
+	"speedboat":                             "🚤",
+	"spider":                                "🕷\uFE0F",
+	"spiral_calendar_pad":                   "🗓\uFE0F",
+	"spiral_note_pad":                       "🗒\uFE0F",
+	"spock-hand":                            "🖖",
+	"sponge":                                "🧽",
+	"sports_medal":                          "🏅",
+	"squirrel":                              "🐿\uFE0F",
+	"stadium":                               "🏟\uFE0F",
+	"star":                                  "⭐",
+	"star-struck":                           "🤩",
+	"star2":                                 "🌟",
+	"star_of_david":                         "✡\uFE0F",
+	"stars":                                 "🌠",
+	"statue_of_liberty":                     "🗽",
+	"steam_locomotive":                      "🚂",
+	"stopwatch":                             "⏱\uFE0F",
+	"straight_ruler":                        "📏",
+	"strawberry":                            "🍓",
+	"stuck_out_tongue":                      "😛",
+	"stuck_out_tongue_closed_eyes":          "😝",
+	"stuck_out_tongue_winking_eye":          "😜",
+	"studio_microphone":                     "🎙\uFE0F",
+	"sunflower":                             "🌻",
+	"sunglasses":                            "😎",
+	"sunny":                                 "☀\uFE0F",
+	"sunrise":                               "🌅",
+	"sushi":                                 "🍣",
+	"sweat":                                 "😓",
+	"sweat_drops":                           "💦",
+	"sweat_smile":                           "😅",
+	"symbols":                               "🔣",
+	"syringe":                               "💉",
+	"taco":                                  "🌮",
+	"tada":                                  "🎉",
+	"tanabata_tree":                         "🎋",
+	"tangerine":                             "🍊",
+	"taxi":                                  "🚕",
+	"tea":                                   "🍵",
+	"teddy_bear":                            "🧸",
+	"telephone":                             "☎\uFE0F",
+	"telephone_receiver":                    "📞",
+	"telescope":                             "🔭",
+	"tennis":                                "🎾",
+	"tent":                                  "⛺",
+	"test_tube":                             "🧪",
+	"the_horns":                             "🤘",
+	"thermometer":                           "🌡\uFE0F",
+	"thinking_face":                         "🤔",
+	"third_place_medal":                     "🥉",
+	"thought_balloon":                       "💭",
+	"three":                                 "3\uFE0F\u20E3",
+	"three_button_mouse":                    "🖱\uFE0F",
+	"thumbsdown":                            "👎",
+	"thumbsup":                              "👍",
+	"thunder_cloud_and_rain":                "⛈\uFE0F",
+	"ticket":                                "🎫",
+	"tiger":                                 "🐯",
+	"timer_clock":                           "⏲\uFE0F",
+	"tired_face":                            "😫",
+	"tm":                                    "™\uFE0F",
+	"toilet":                                "🚽",
+	"tongue":                                "👅",
+	"toolbox":                               "🧰",
+	"top":                                   "🔝",
+	"tophat":                                "🎩",
+	"tornado":                               "🌪\uFE0F",
+	"trackball":                             "🖲\uFE0F",
+	"tractor":                               "🚜",
+	"traffic_light":                         "🚥",
+	"train":                                 "🚋",
+	"triangular_flag_on_post":               "🚩",
+	"triangular_ruler":                      "📐",
+	"trident":                               "🔱",
+	"triumph":                               "😤",
+	"trophy":                                "🏆",
+	"tropical_drink":                        "🍹",
+	"tropical_fish":                         "🐠",
+	"truck":                                 "🚚",
+	"trumpet":                               "🎺",
+	"tulip":                                 "🌷",
+	"turtle":                                "🐢",
+	"tv":                                    "📺",
+	"twisted_rightwards_arrows":             "🔀",
+	"two":                                   "2\uFE0F\u20E3",
+	"two_hearts":                            "💕",
+	"umbrella":                              "☔",
+	"unamused":                              "😒",
+	"underage":                              "🔞",
+	"unicorn_face":                          "🦄",
+	"unlock":                                "🔓",
+	"up":                                    "🆙",
+	"upside_down_face":                      "🙃",
+	"us":                                    "🇺🇸",
+	"v":                                     "✌\uFE0F",
+	"vertical_traffic_light":                "🚦",
+	"vhs":                                   "📼",
+	"vibration_mode":                        "📳",
+	"video_camera":                          "📹",
+	"video_game":                            "🎮",
+	"violin":                                "🎻",
+	"volcano":                               "🌋",
+	"volleyball":                            "🏐",
+	"vs":                                    "🆚",
+	"walking":                               "🚶",
+	"warning":                               "⚠\uFE0F",
+	"wastebasket":                           "🗑\uFE0F",
+	"watch":                                 "⌚",
+	"watermelon":                            "🍉",
+	"wave":                                  "👋",
+	"waving_black_flag":                     "🏴",
+	"waving_white_flag":                     "🏳\uFE0F",
+	"wavy_dash":                             "〰\uFE0F",
+	"weary":                                 "😩",
+	"whale":                                 "🐳",
+	"wheel_of_dharma":                       "☸\uFE0F",
+	"wheelchair":                            "♿",
+	"white_check_mark":                      "✅",
+	"white_circle":                          "⚪",
+	"white_frowning_face":                   "☹\uFE0F",
+	"white_heart":                           "🤍",
+	"white_large_square":                    "⬜",
+	"white_medium_square":                   "◻\uFE0F",
+	"white_small_square":                    "▫\uFE0F",
+	"white_square_button":                   "🔳",
+	"wind_blowing_face":                     "🌬\uFE0F",
+	"wind_chime":                            "🎐",
+	"wine_glass":                            "🍷",
+	"wink":                                  "😉",
+	"wolf":                                  "🐺",
+	"woman":                                 "👩",
+	"womens":                                "🚺",
+	"woozy_face":                            "🥴",
+	"world_map":                             "🗺\uFE0F",
+	"worried":                               "😟",
+	"wrench":                                "🔧",
+	"writing_hand":                          "✍\uFE0F",
+	"x":                                     "❌",
+	"yawning_face":                          "🥱",
+	"yellow_heart":                          "💛",
+	"yen":                                   "💴",
+	"yin_yang":                              "☯\uFE0F",
+	"yum":                                   "😋",
+	"zany_face":                             "🤪",
+	"zap":                                   "⚡",
+	"zero":                                  "0\uFE0F\u20E3",
+	"zipper_mouth_face":                     "🤐",
+	"zzz":                                   "💤",
+}
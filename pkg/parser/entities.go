@@ -0,0 +1,119 @@
+package parser
+
+import (
+	"regexp"
+	"sort"
+)
+
+// EntityType is the kind of an Entity.
+type EntityType string
+
+const (
+	EntityUser    EntityType = "user"    // <@U123>
+	EntityChannel EntityType = "channel" // <#C123|general>
+	EntityLink    EntityType = "link"    // <https://example.com|Example>
+	EntitySpecial EntityType = "special" // <!here>, <!channel>, <!everyone>
+	EntityEmoji   EntityType = "emoji"   // :tada:
+)
+
+// Entity is a mention, link, or emoji found in a message's mrkdwn.
+type Entity struct {
+	Type EntityType `json:"type"`
+
+	// Raw is the entity's markup as it appears in the text, which it
+	// spans from byte offset Start to End.
+	Raw   string `json:"raw"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+
+	// ID is the user or channel ID of a mention, or the name of a special
+	// mention ("here", "channel", ...). Name is an emoji's shortcode
+	// without colons.
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+
+	// URL is a link's target, a mentioned person's mailto: link when
+	// people.json has their email, or a custom emoji's image.
+	URL string `json:"url,omitempty"`
+
+	// Text is the entity as ConvertMrkdwnWithLinks renders it, or an
+	// emoji's Unicode characters.
+	Text string `json:"text"`
+}
+
+// emojiShortcodePattern matches :name: and :name::skin-tone-N: shortcodes.
+var emojiShortcodePattern = regexp.MustCompile(`:([a-z0-9_+'-]+(?:::skin-tone-[2-6])?):`)
+
+// ExtractEntities returns the mentions, links, and emoji in text, in order
+// of appearance, rendered with the given resolvers, any of which may be
+// nil. Markup inside code is not an entity. Emoji are only reported when
+// emojiResolver knows them, since text such as 10:30:00 looks like a
+// shortcode.
+func ExtractEntities(text string, userResolver *UserResolver, channelResolver *ChannelResolver, personResolver *PersonResolver, emojiResolver *EmojiResolver) []Entity {
+	if text == "" {
+		return nil
+	}
+
+	var taken [][]int
+	for _, p := range []*regexp.Regexp{codeBlockPattern, inlineCodePattern} {
+		taken = append(taken, p.FindAllStringIndex(text, -1)...)
+	}
+	free := func(loc []int) bool {
+		for _, t := range taken {
+			if loc[0] < t[1] && t[0] < loc[1] {
+				return false
+			}
+		}
+		taken = append(taken, loc[:2])
+		return true
+	}
+
+	var entities []Entity
+	add := func(p *regexp.Regexp, build func(m []string) (Entity, bool)) {
+		for _, loc := range p.FindAllStringSubmatchIndex(text, -1) {
+			m := make([]string, len(loc)/2)
+			for i := range m {
+				if loc[2*i] >= 0 {
+					m[i] = text[loc[2*i]:loc[2*i+1]]
+				}
+			}
+			ent, ok := build(m)
+			if !ok || !free(loc) {
+				continue
+			}
+			ent.Raw, ent.Start, ent.End = m[0], loc[0], loc[1]
+			entities = append(entities, ent)
+		}
+	}
+
+	add(userMentionPattern, func(m []string) (Entity, bool) {
+		mention, link := resolveUserMention(m, userResolver, personResolver)
+		ent := Entity{Type: EntityUser, ID: m[1], Text: mention}
+		if link != nil {
+			ent.URL = link.URL
+		}
+		return ent, true
+	})
+	add(channelMentionPattern, func(m []string) (Entity, bool) {
+		return Entity{Type: EntityChannel, ID: m[1], Text: resolveChannelMention(m, channelResolver)}, true
+	})
+	add(urlWithTextPattern, func(m []string) (Entity, bool) {
+		return Entity{Type: EntityLink, URL: m[1], Text: m[2]}, true
+	})
+	add(urlOnlyPattern, func(m []string) (Entity, bool) {
+		return Entity{Type: EntityLink, URL: m[1], Text: m[1]}, true
+	})
+	add(specialMentionPattern, func(m []string) (Entity, bool) {
+		return Entity{Type: EntitySpecial, ID: m[1], Text: renderSpecialMention(m)}, true
+	})
+	add(emojiShortcodePattern, func(m []string) (Entity, bool) {
+		em := emojiResolver.Lookup(m[1])
+		if em.Unicode == "" && em.ImageURL == "" {
+			return Entity{}, false
+		}
+		return Entity{Type: EntityEmoji, Name: em.Name, URL: em.ImageURL, Text: em.String()}, true
+	})
+
+	sort.Slice(entities, func(i, j int) bool { return entities[i].Start < entities[j].Start })
+	return entities
+}
@@ -0,0 +1,43 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractEntities(t *testing.T) {
+	channels := NewChannelResolver()
+	channels.AddChannel("C001", "general")
+	emoji := NewEmojiResolver()
+	emoji.SetCustomEmoji(map[string]string{"partyparrot": "https://emoji.example.com/parrot.gif"})
+
+	text := "<!here> <@U001|ann> see <#C001> and <https://example.com|the docs> :tada: :partyparrot: at 10:30:00 `<@U002> :tada:`"
+	got := ExtractEntities(text, nil, channels, nil, emoji)
+	want := []Entity{
+		{Type: EntitySpecial, Raw: "<!here>", Start: 0, End: 7, ID: "here", Text: "@here"},
+		{Type: EntityUser, Raw: "<@U001|ann>", Start: 8, End: 19, ID: "U001", Text: "@ann"},
+		{Type: EntityChannel, Raw: "<#C001>", Start: 24, End: 31, ID: "C001", Text: "#general"},
+		{Type: EntityLink, Raw: "<https://example.com|the docs>", Start: 36, End: 66, URL: "https://example.com", Text: "the docs"},
+		{Type: EntityEmoji, Raw: ":tada:", Start: 67, End: 73, Name: "tada", Text: "🎉"},
+		{Type: EntityEmoji, Raw: ":partyparrot:", Start: 74, End: 87, Name: "partyparrot", URL: "https://emoji.example.com/parrot.gif", Text: ":partyparrot:"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractEntities() =\n%+v\nwant\n%+v", got, want)
+	}
+	for _, ent := range got {
+		if text[ent.Start:ent.End] != ent.Raw {
+			t.Errorf("%s entity spans %q, want %q", ent.Type, text[ent.Start:ent.End], ent.Raw)
+		}
+	}
+}
+
+func TestExtractEntities_BareURLAndEmpty(t *testing.T) {
+	if got := ExtractEntities("", nil, nil, nil, nil); got != nil {
+		t.Errorf("ExtractEntities(\"\") = %+v, want nil", got)
+	}
+	got := ExtractEntities("<https://example.com> :tada:", nil, nil, nil, nil)
+	want := []Entity{{Type: EntityLink, Raw: "<https://example.com>", Start: 0, End: 21, URL: "https://example.com", Text: "https://example.com"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractEntities() = %+v, want %+v (no emoji without a resolver)", got, want)
+	}
+}
@@ -50,6 +50,36 @@ func ConvertMrkdwn(text string, userResolver *UserResolver, channelResolver *Cha
 	return result
 }
 
+// ExtractUserMentions returns the unique user IDs @-mentioned in text, in
+// order of first appearance.
+func ExtractUserMentions(text string) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, m := range userMentionPattern.FindAllStringSubmatch(text, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			ids = append(ids, m[1])
+		}
+	}
+	return ids
+}
+
+// ExtractUnnamedChannelMentions returns the unique channel IDs #-mentioned
+// in text without an inline name (<#C123> rather than <#C123|general>), in
+// order of first appearance. These render by ID unless a ChannelResolver
+// knows the channel.
+func ExtractUnnamedChannelMentions(text string) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, m := range channelMentionPattern.FindAllStringSubmatch(text, -1) {
+		if m[2] == "" && !seen[m[1]] {
+			seen[m[1]] = true
+			ids = append(ids, m[1])
+		}
+	}
+	return ids
+}
+
 // LinkAnnotation records a substring in converted text that should become a hyperlink.
 type LinkAnnotation struct {
 	Text string // The display text (e.g., "@John Smith")
@@ -61,6 +91,10 @@ type LinkAnnotation struct {
 // Google Docs URL, or empty string if the target hasn't been exported.
 type SlackLinkResolver func(channelID, messageTS string) string
 
+// ChannelLinkResolver resolves a channel ID to the URL of its export, or
+// empty string if the channel hasn't been exported.
+type ChannelLinkResolver func(channelID string) string
+
 // resolveUserMention resolves a single user mention match and returns the
 // display text and optional link annotation.
 func resolveUserMention(matches []string, userResolver *UserResolver, personResolver *PersonResolver) (string, *LinkAnnotation) {
@@ -102,10 +136,67 @@ func resolveUserMention(matches []string, userResolver *UserResolver, personReso
 	return mention, nil
 }
 
+// resolveChannelMention returns the display text of a single channel mention
+// match: the inline name if present, else the resolved name, else the ID.
+func resolveChannelMention(matches []string, channelResolver *ChannelResolver) string {
+	if len(matches) >= 3 && matches[2] != "" {
+		return "#" + matches[2]
+	}
+	channelID := matches[1]
+	if channelResolver != nil {
+		return "#" + channelResolver.Resolve(channelID)
+	}
+	return "#" + channelID
+}
+
+// ChannelMentionLinks returns link annotations for the channel mentions in
+// text whose channel has been exported, as reported by channelLinkResolver.
+// The annotation text matches what ConvertMrkdwnWithLinks renders for the
+// mention, so the two can be combined.
+func ChannelMentionLinks(text string, channelResolver *ChannelResolver, channelLinkResolver ChannelLinkResolver) []LinkAnnotation {
+	if channelLinkResolver == nil {
+		return nil
+	}
+	var links []LinkAnnotation
+	seen := make(map[string]bool)
+	for _, m := range channelMentionPattern.FindAllStringSubmatch(text, -1) {
+		mention := resolveChannelMention(m, channelResolver)
+		if seen[mention] {
+			continue
+		}
+		seen[mention] = true
+		if url := channelLinkResolver(m[1]); url != "" {
+			links = append(links, LinkAnnotation{Text: mention, URL: url})
+		}
+	}
+	return links
+}
+
 // ConvertMrkdwnWithLinks converts Slack mrkdwn to plain text and returns link annotations
 // for @mentions that have Google email mappings via the PersonResolver.
 // If slackLinkResolver is non-nil, Slack archive URLs are replaced with Google Docs URLs.
 func ConvertMrkdwnWithLinks(text string, userResolver *UserResolver, channelResolver *ChannelResolver, personResolver *PersonResolver, slackLinkResolver SlackLinkResolver) (string, []LinkAnnotation) {
+	result, links := resolveMrkdwnMarkup(text, userResolver, channelResolver, personResolver, slackLinkResolver)
+
+	// Remove formatting markers but keep text
+	result = boldPattern.ReplaceAllString(result, "$1")
+	result = italicPattern.ReplaceAllString(result, "$1")
+	result = strikePattern.ReplaceAllString(result, "$1")
+
+	// Keep code blocks and inline code text
+	result = codeBlockPattern.ReplaceAllString(result, "$1")
+	result = inlineCodePattern.ReplaceAllString(result, "$1")
+
+	// Decode HTML entities
+	result = decodeHTMLEntities(result)
+
+	return result, links
+}
+
+// resolveMrkdwnMarkup replaces the Slack-specific markup of text (Slack
+// archive links, mentions and URLs) with display text, and returns link
+// annotations for it. Formatting markers and HTML entities are left as is.
+func resolveMrkdwnMarkup(text string, userResolver *UserResolver, channelResolver *ChannelResolver, personResolver *PersonResolver, slackLinkResolver SlackLinkResolver) (string, []LinkAnnotation) {
 	result := text
 	var links []LinkAnnotation
 
@@ -126,15 +217,7 @@ func ConvertMrkdwnWithLinks(text string, userResolver *UserResolver, channelReso
 
 	// Replace channel mentions
 	result = channelMentionPattern.ReplaceAllStringFunc(result, func(match string) string {
-		matches := channelMentionPattern.FindStringSubmatch(match)
-		if len(matches) >= 3 && matches[2] != "" {
-			return "#" + matches[2]
-		}
-		channelID := matches[1]
-		if channelResolver != nil {
-			return "#" + channelResolver.Resolve(channelID)
-		}
-		return "#" + channelID
+		return resolveChannelMention(channelMentionPattern.FindStringSubmatch(match), channelResolver)
 	})
 
 	// Replace URLs with text — track link annotations
@@ -162,37 +245,30 @@ func ConvertMrkdwnWithLinks(text string, userResolver *UserResolver, channelReso
 
 	// Replace special mentions
 	result = specialMentionPattern.ReplaceAllStringFunc(result, func(match string) string {
-		matches := specialMentionPattern.FindStringSubmatch(match)
-		switch matches[1] {
-		case "here":
-			return "@here"
-		case "channel":
-			return "@channel"
-		case "everyone":
-			return "@everyone"
-		default:
-			if len(matches) >= 3 && matches[2] != "" {
-				return matches[2]
-			}
-			return "@" + matches[1]
-		}
+		return renderSpecialMention(specialMentionPattern.FindStringSubmatch(match))
 	})
 
-	// Remove formatting markers but keep text
-	result = boldPattern.ReplaceAllString(result, "$1")
-	result = italicPattern.ReplaceAllString(result, "$1")
-	result = strikePattern.ReplaceAllString(result, "$1")
-
-	// Keep code blocks and inline code text
-	result = codeBlockPattern.ReplaceAllString(result, "$1")
-	result = inlineCodePattern.ReplaceAllString(result, "$1")
-
-	// Decode HTML entities
-	result = decodeHTMLEntities(result)
-
 	return result, links
 }
 
+// renderSpecialMention returns the display text of a single special
+// mention match such as <!here>.
+func renderSpecialMention(matches []string) string {
+	switch matches[1] {
+	case "here":
+		return "@here"
+	case "channel":
+		return "@channel"
+	case "everyone":
+		return "@everyone"
+	default:
+		if len(matches) >= 3 && matches[2] != "" {
+			return matches[2]
+		}
+		return "@" + matches[1]
+	}
+}
+
 // ConvertMrkdwnToMarkdown converts Slack mrkdwn to standard Markdown, preserving
 // formatting rather than stripping it. Bold (*text*) becomes **text**, italic
 // (_text_) becomes *text*, strikethrough (~text~) becomes ~~text~~, and code
@@ -237,15 +313,7 @@ func ConvertMrkdwnToMarkdown(text string, userResolver *UserResolver, channelRes
 
 	// Replace channel mentions
 	result = channelMentionPattern.ReplaceAllStringFunc(result, func(match string) string {
-		matches := channelMentionPattern.FindStringSubmatch(match)
-		if len(matches) >= 3 && matches[2] != "" {
-			return "#" + matches[2]
-		}
-		channelID := matches[1]
-		if channelResolver != nil {
-			return "#" + channelResolver.Resolve(channelID)
-		}
-		return "#" + channelID
+		return resolveChannelMention(channelMentionPattern.FindStringSubmatch(match), channelResolver)
 	})
 
 	// Replace URLs with text → markdown link syntax
@@ -264,20 +332,7 @@ func ConvertMrkdwnToMarkdown(text string, userResolver *UserResolver, channelRes
 
 	// Replace special mentions
 	result = specialMentionPattern.ReplaceAllStringFunc(result, func(match string) string {
-		matches := specialMentionPattern.FindStringSubmatch(match)
-		switch matches[1] {
-		case "here":
-			return "@here"
-		case "channel":
-			return "@channel"
-		case "everyone":
-			return "@everyone"
-		default:
-			if len(matches) >= 3 && matches[2] != "" {
-				return matches[2]
-			}
-			return "@" + matches[1]
-		}
+		return renderSpecialMention(specialMentionPattern.FindStringSubmatch(match))
 	})
 
 	// --- Phase 3: Convert formatting markers ---
@@ -400,16 +455,16 @@ func decodeHTMLEntities(text string) string {
 	return replacer.Replace(text)
 }
 
-// FormatTimestamp formats a Slack timestamp as a readable time string.
+// FormatTimestamp formats a Slack timestamp as a readable time string, in
+// the zone and time layout of the current TimeSettings.
 func FormatTimestamp(ts string) string {
-	t := tsToTime(ts)
-	return t.Format("3:04 PM")
+	return SlackTime(ts).Format(CurrentTimeSettings().TimeLayout)
 }
 
-// FormatTimestampFull formats a Slack timestamp with date and time.
+// FormatTimestampFull formats a Slack timestamp with date and time, in the
+// zone and layouts of the current TimeSettings.
 func FormatTimestampFull(ts string) string {
-	t := tsToTime(ts)
-	return t.Format("Jan 2, 2006 3:04 PM")
+	return FormatTime(tsToTime(ts))
 }
 
 // tsToTime converts a Slack timestamp to time.Time.
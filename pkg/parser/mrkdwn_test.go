@@ -197,3 +197,51 @@ func TestConvertMrkdwnToMarkdown(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractUserMentions(t *testing.T) {
+	got := ExtractUserMentions("hi <@U001> and <@U002|bob>, also <@U001> and <#C001|general> <!here>")
+	if len(got) != 2 || got[0] != "U001" || got[1] != "U002" {
+		t.Errorf("ExtractUserMentions() = %v, want [U001 U002]", got)
+	}
+	if got := ExtractUserMentions("no mentions"); len(got) != 0 {
+		t.Errorf("ExtractUserMentions() = %v, want none", got)
+	}
+}
+
+func TestExtractUnnamedChannelMentions(t *testing.T) {
+	got := ExtractUnnamedChannelMentions("see <#C001> and <#C002|general>, also <#C001> and <#C003> <@U001>")
+	if len(got) != 2 || got[0] != "C001" || got[1] != "C003" {
+		t.Errorf("ExtractUnnamedChannelMentions() = %v, want [C001 C003]", got)
+	}
+	if got := ExtractUnnamedChannelMentions("<#C002|general>"); len(got) != 0 {
+		t.Errorf("ExtractUnnamedChannelMentions() = %v, want none", got)
+	}
+}
+
+func TestChannelMentionLinks(t *testing.T) {
+	channels := NewChannelResolver()
+	channels.AddChannel("C001", "general")
+	resolve := func(id string) string {
+		if id == "C001" || id == "C002" {
+			return "https://example.com/" + id
+		}
+		return ""
+	}
+
+	got := ChannelMentionLinks("<#C001> <#C002|renamed> <#C003> <#C001>", channels, resolve)
+	want := []LinkAnnotation{
+		{Text: "#general", URL: "https://example.com/C001"},
+		{Text: "#renamed", URL: "https://example.com/C002"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ChannelMentionLinks() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ChannelMentionLinks()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+	if got := ChannelMentionLinks("<#C001>", channels, nil); got != nil {
+		t.Errorf("ChannelMentionLinks() without a resolver = %v, want nil", got)
+	}
+}
@@ -2,9 +2,12 @@
 package parser
 
 import (
+	"container/list"
 	"context"
+	"sort"
 	"sync"
 
+	"github.com/jflowers/get-out/pkg/config"
 	"github.com/jflowers/get-out/pkg/slackapi"
 )
 
@@ -17,17 +20,168 @@ type SlackAPI interface {
 	ListConversations(ctx context.Context, opts *slackapi.ListConversationsOptions) (*slackapi.ConversationsListResponse, error)
 }
 
+// UserStore is a persistent user cache behind a UserResolver, so users
+// fetched in earlier runs need not be fetched again.
+type UserStore interface {
+	// Get returns a cached user, or nil when it is absent or expired.
+	Get(id string) *slackapi.User
+	// Put caches a user fetched from Slack.
+	Put(user *slackapi.User)
+}
+
 // UserResolver resolves Slack user IDs to display names.
 type UserResolver struct {
-	mu    sync.RWMutex
-	users map[string]*slackapi.User
+	mu     sync.RWMutex
+	users  map[string]*slackapi.User
+	policy config.NamePolicy
+
+	// maxUsers caps how many users are kept in memory (0 = no limit); past
+	// it the least recently used one is dropped, to be read back from the
+	// store or fetched again when next needed. lru orders users by use,
+	// front = most recent, and evicted holds the IDs dropped while a store
+	// keeps them, so Users still lists them.
+	maxUsers int
+	lru      *list.List
+	elems    map[string]*list.Element
+	evicted  map[string]bool
+
+	// store, when set, is consulted on a cache miss before Slack and
+	// receives every user fetched.
+	store UserStore
+	// prefetchLimit caps how many uncached members LoadUsersForConversations
+	// fetches up front (0 = no limit).
+	prefetchLimit int
 }
 
 // NewUserResolver creates a new user resolver.
 func NewUserResolver() *UserResolver {
 	return &UserResolver{
-		users: make(map[string]*slackapi.User),
+		users:   make(map[string]*slackapi.User),
+		lru:     list.New(),
+		elems:   make(map[string]*list.Element),
+		evicted: make(map[string]bool),
+	}
+}
+
+// SetNamePolicy sets how resolved names combine display and real names.
+// The zero value is NamePolicyDisplayFirst.
+func (r *UserResolver) SetNamePolicy(policy config.NamePolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.policy = policy
+}
+
+// SetStore sets the persistent cache consulted before Slack. Users are then
+// read from it lazily, as they are resolved.
+func (r *UserResolver) SetStore(store UserStore) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.store = store
+}
+
+// SetPrefetchLimit caps how many uncached conversation members
+// LoadUsersForConversations fetches up front. Past the limit nothing is
+// prefetched, and callers look up users as messages reference them
+// (LoadUsersByID). 0 means no limit.
+func (r *UserResolver) SetPrefetchLimit(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prefetchLimit = n
+}
+
+// SetMaxUsers caps how many users are kept in memory, dropping the least
+// recently used past it. A dropped user is read back from the store, or
+// looked up in Slack again by callers that find it missing. 0 means no
+// limit.
+func (r *UserResolver) SetMaxUsers(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maxUsers = n
+	r.evictLocked()
+}
+
+// putLocked keeps user in memory as the most recently used, and in the
+// store when fetched from Slack. The caller must hold r.mu for writing.
+func (r *UserResolver) putLocked(user *slackapi.User, fetched bool) {
+	r.users[user.ID] = user
+	delete(r.evicted, user.ID)
+	if elem, ok := r.elems[user.ID]; ok {
+		r.lru.MoveToFront(elem)
+	} else {
+		r.elems[user.ID] = r.lru.PushFront(user.ID)
+	}
+	if fetched && r.store != nil {
+		r.store.Put(user)
 	}
+	r.evictLocked()
+}
+
+// evictLocked drops the least recently used users past maxUsers. The
+// caller must hold r.mu for writing.
+func (r *UserResolver) evictLocked() {
+	for r.maxUsers > 0 && len(r.users) > r.maxUsers {
+		oldest := r.lru.Back()
+		id := r.lru.Remove(oldest).(string)
+		delete(r.elems, id)
+		delete(r.users, id)
+		if r.store != nil {
+			r.evicted[id] = true
+		}
+	}
+}
+
+// lookupLocked returns the user for id from memory or, failing that, the
+// store, keeping a store hit in memory. The caller must hold r.mu for
+// writing.
+func (r *UserResolver) lookupLocked(id string) *slackapi.User {
+	if user, ok := r.users[id]; ok {
+		r.lru.MoveToFront(r.elems[id])
+		return user
+	}
+	if r.store == nil {
+		return nil
+	}
+	user := r.store.Get(id)
+	if user != nil {
+		r.putLocked(user, false)
+	}
+	return user
+}
+
+// lookup is lookupLocked for callers not holding r.mu.
+func (r *UserResolver) lookup(id string) *slackapi.User {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lookupLocked(id)
+}
+
+// FormatUserName returns the name for a user under the given policy.
+func FormatUserName(user *slackapi.User, policy config.NamePolicy) string {
+	display := user.Profile.DisplayName
+	realName := user.Profile.RealName
+	if realName == "" {
+		realName = user.RealName
+	}
+
+	switch policy {
+	case config.NamePolicyRealFirst:
+		if realName != "" {
+			return realName
+		}
+	case config.NamePolicyBoth:
+		handle := display
+		if handle == "" {
+			handle = user.Name
+		}
+		switch {
+		case realName == "":
+		case handle == "" || handle == realName:
+			return realName
+		default:
+			return realName + " (@" + handle + ")"
+		}
+	}
+	return user.GetDisplayName()
 }
 
 // LoadUsers fetches all users from Slack via paginated API calls and caches them
@@ -60,8 +214,7 @@ func (r *UserResolver) LoadUsers(ctx context.Context, client SlackAPI, onProgres
 		}
 
 		for i := range resp.Members {
-			user := &resp.Members[i]
-			r.users[user.ID] = user
+			r.putLocked(&resp.Members[i], true)
 		}
 
 		if progressFn != nil {
@@ -156,68 +309,222 @@ func (r *UserResolver) LoadUsersForConversations(ctx context.Context, client Sla
 		}
 	}
 
-	// Fetch user info for each unique member
-	fetched := 0
+	// Fetch user info for each unique member that is not cached. Too many
+	// for one up-front pass are left to be looked up lazily.
+	ids := make([]string, 0, len(memberSet))
 	for memberID := range memberSet {
-		if _, exists := r.users[memberID]; exists {
-			continue // Already cached
+		if r.lookupLocked(memberID) == nil {
+			ids = append(ids, memberID)
 		}
+	}
+	if r.prefetchLimit > 0 && len(ids) > r.prefetchLimit {
+		if progressFn != nil {
+			progressFn("lazy", len(ids))
+		}
+		return nil
+	}
+	return r.fetchUsers(ctx, client, ids, progressFn)
+}
 
-		user, err := client.GetUserInfo(ctx, memberID)
+// LoadUsersByID fetches each user in ids that is not already cached (see
+// fetchUsers). It is the fallback for workspaces where
+// conversations.members is restricted: callers collect user IDs from the
+// messages themselves. Users that cannot be fetched are skipped.
+//
+// Returns nil on success, or the context error if ctx is cancelled.
+func (r *UserResolver) LoadUsersByID(ctx context.Context, client SlackAPI, ids []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.fetchUsers(ctx, client, ids, nil)
+}
+
+// usersInfoWorkers is how many users.info calls fetchUsers keeps in
+// flight. The client's rate limiter still paces them; running a few at
+// once hides each call's latency behind the others' waits.
+const usersInfoWorkers = 3
+
+// usersListPageCost is what one users.list page costs in users.info calls:
+// the ratio of the two methods' rate limit intervals.
+var usersListPageCost = func() int {
+	tiers := slackapi.DefaultTierIntervals()
+	return int(tiers["users.list"] / tiers["users.info"])
+}()
+
+// fetchUsers caches each uncached user in ids, reporting progress to
+// progressFn with label "users". Slack has no batch users.info, so when
+// enough users are wanted for a users.list page to cost less than looking
+// them up one by one, it first pages through users.list keeping the wanted
+// users, for no more pages than the one-by-one lookups would cost. The rest
+// are fetched via users.info, usersInfoWorkers at a time. The caller must
+// hold r.mu.
+func (r *UserResolver) fetchUsers(ctx context.Context, client SlackAPI, ids []string, progressFn func(string, int)) error {
+	var missing []string
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if !seen[id] && r.lookupLocked(id) == nil {
+			missing = append(missing, id)
+		}
+		seen[id] = true
+	}
+
+	if pages := len(missing) / usersListPageCost; pages >= 2 {
+		var err error
+		if missing, err = r.listUsers(ctx, client, missing, pages, progressFn); err != nil {
+			return err
+		}
+	}
+	return r.fetchUserInfos(ctx, client, missing, progressFn)
+}
+
+// listUsers pages through users.list, up to maxPages pages, caching the
+// users in wanted, and returns those it did not find. It stops early once
+// all are found, and quietly when users.list fails. The caller must hold
+// r.mu.
+func (r *UserResolver) listUsers(ctx context.Context, client SlackAPI, wanted []string, maxPages int, progressFn func(string, int)) ([]string, error) {
+	want := make(map[string]bool, len(wanted))
+	for _, id := range wanted {
+		want[id] = true
+	}
+
+	cursor := ""
+	for page := 0; page < maxPages && len(want) > 0; page++ {
+		resp, err := client.GetUsers(ctx, cursor)
 		if err != nil {
-			continue // Skip users we can't fetch
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			break // Look the rest up one by one
+		}
+		for _, member := range resp.Members {
+			if !want[member.ID] {
+				continue
+			}
+			delete(want, member.ID)
+			user := member
+			r.putLocked(&user, true)
+		}
+		if progressFn != nil {
+			progressFn("users", len(wanted)-len(want))
+		}
+		if resp.ResponseMetadata.NextCursor == "" {
+			break
+		}
+		cursor = resp.ResponseMetadata.NextCursor
+	}
+
+	remaining := make([]string, 0, len(want))
+	for _, id := range wanted {
+		if want[id] {
+			remaining = append(remaining, id)
+		}
+	}
+	return remaining, nil
+}
+
+// fetchUserInfos caches each user in ids via users.info, usersInfoWorkers
+// calls at a time, reporting every 50 fetches to progressFn with label
+// "users". Users that cannot be fetched are skipped. The caller must hold
+// r.mu; the workers only call Slack.
+func (r *UserResolver) fetchUserInfos(ctx context.Context, client SlackAPI, ids []string, progressFn func(string, int)) error {
+	next := make(chan string)
+	results := make(chan *slackapi.User)
+	var wg sync.WaitGroup
+	for w := 0; w < min(usersInfoWorkers, len(ids)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range next {
+				user, err := client.GetUserInfo(ctx, id)
+				if err != nil {
+					user = nil // Skip users we can't fetch
+				}
+				results <- user
+			}
+		}()
+	}
+	go func() {
+		defer close(next)
+		for _, id := range ids {
+			select {
+			case next <- id:
+			case <-ctx.Done():
+				return
+			}
 		}
-		r.users[user.ID] = user
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	fetched := 0
+	for user := range results {
+		if user == nil {
+			continue
+		}
+		r.putLocked(user, true)
 		fetched++
 
 		if progressFn != nil && fetched%50 == 0 {
 			progressFn("users", fetched)
 		}
-
-		// Check for cancellation between user fetches
-		if ctx.Err() != nil {
-			return ctx.Err()
-		}
 	}
-
-	return nil
+	return ctx.Err()
 }
 
-// AddUser adds a single user to the cache.
+// AddUser adds a single user to the cache (and the store, when set).
 func (r *UserResolver) AddUser(user *slackapi.User) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.users[user.ID] = user
+	r.putLocked(user, true)
 }
 
-// GetUser returns a cached user by ID.
+// GetUser returns a cached user by ID, reading the store on a miss.
 func (r *UserResolver) GetUser(id string) *slackapi.User {
+	return r.lookup(id)
+}
+
+// Users returns every cached user, sorted by ID: those in memory and those
+// dropped from memory that the store still holds.
+func (r *UserResolver) Users() []*slackapi.User {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	return r.users[id]
+	users := make([]*slackapi.User, 0, len(r.users)+len(r.evicted))
+	for _, u := range r.users {
+		users = append(users, u)
+	}
+	for id := range r.evicted {
+		if u := r.store.Get(id); u != nil {
+			users = append(users, u)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+	return users
 }
 
 // Resolve returns the display name for a user ID.
 // Returns the ID itself if the user is not found.
 func (r *UserResolver) Resolve(id string) string {
+	user := r.lookup(id)
+	if user == nil {
+		return id
+	}
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-
-	if user, ok := r.users[id]; ok {
-		return user.GetDisplayName()
-	}
-	return id
+	return FormatUserName(user, r.policy)
 }
 
 // ResolveWithFallback returns the display name, or fetches it from Slack if not cached.
 func (r *UserResolver) ResolveWithFallback(ctx context.Context, client SlackAPI, id string) string {
 	// Check cache first
 	r.mu.RLock()
-	if user, ok := r.users[id]; ok {
-		r.mu.RUnlock()
-		return user.GetDisplayName()
-	}
+	policy := r.policy
 	r.mu.RUnlock()
+	if user := r.lookup(id); user != nil {
+		return FormatUserName(user, policy)
+	}
 
 	// Fetch from Slack
 	user, err := client.GetUserInfo(ctx, id)
@@ -226,10 +533,10 @@ func (r *UserResolver) ResolveWithFallback(ctx context.Context, client SlackAPI,
 	}
 
 	r.AddUser(user)
-	return user.GetDisplayName()
+	return FormatUserName(user, policy)
 }
 
-// Count returns the number of cached users.
+// Count returns the number of users in memory.
 func (r *UserResolver) Count() int {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -256,6 +563,14 @@ func (r *ChannelResolver) AddChannel(id, name string) {
 	r.channels[id] = name
 }
 
+// Has reports whether the resolver has an entry for id.
+func (r *ChannelResolver) Has(id string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.channels[id]
+	return ok
+}
+
 // Resolve returns the channel name for an ID.
 func (r *ChannelResolver) Resolve(id string) string {
 	r.mu.RLock()
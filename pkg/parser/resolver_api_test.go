@@ -9,8 +9,12 @@ import (
 	"github.com/jflowers/get-out/pkg/slackapi"
 )
 
-// mockSlackAPI implements SlackAPI for testing.
+// mockSlackAPI implements SlackAPI for testing. Calls are serialized, so
+// the funcs need no locking of their own; GetUsers fails when
+// getUsersFunc is unset, as when users.list is restricted.
 type mockSlackAPI struct {
+	mu sync.Mutex
+
 	getUsersFunc           func(ctx context.Context, cursor string) (*slackapi.UsersListResponse, error)
 	getConversationMembers func(ctx context.Context, channelID, cursor string) (*slackapi.MembersResponse, error)
 	getUserInfoFunc        func(ctx context.Context, userID string) (*slackapi.User, error)
@@ -18,18 +22,29 @@ type mockSlackAPI struct {
 }
 
 func (m *mockSlackAPI) GetUsers(ctx context.Context, cursor string) (*slackapi.UsersListResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.getUsersFunc == nil {
+		return nil, fmt.Errorf("restricted_action")
+	}
 	return m.getUsersFunc(ctx, cursor)
 }
 
 func (m *mockSlackAPI) GetConversationMembers(ctx context.Context, channelID, cursor string) (*slackapi.MembersResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	return m.getConversationMembers(ctx, channelID, cursor)
 }
 
 func (m *mockSlackAPI) GetUserInfo(ctx context.Context, userID string) (*slackapi.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	return m.getUserInfoFunc(ctx, userID)
 }
 
 func (m *mockSlackAPI) ListConversations(ctx context.Context, opts *slackapi.ListConversationsOptions) (*slackapi.ConversationsListResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	return m.listConversationsFunc(ctx, opts)
 }
 
@@ -328,6 +343,120 @@ func TestLoadUsersForConversations_UserInfoError(t *testing.T) {
 	}
 }
 
+func TestLoadUsersByID_SkipsCachedAndFailedUsers(t *testing.T) {
+	var calls []string
+	mock := &mockSlackAPI{
+		getUserInfoFunc: func(_ context.Context, userID string) (*slackapi.User, error) {
+			calls = append(calls, userID)
+			if userID == "U_BAD" {
+				return nil, fmt.Errorf("user_not_found")
+			}
+			return &slackapi.User{ID: userID, Name: "user-" + userID}, nil
+		},
+	}
+
+	r := NewUserResolver()
+	r.AddUser(&slackapi.User{ID: "U_CACHED", Name: "cached"})
+	if err := r.LoadUsersByID(context.Background(), mock, []string{"U_CACHED", "U_BAD", "U_GOOD"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(calls) != 2 {
+		t.Errorf("users.info calls = %v, want U_BAD and U_GOOD only", calls)
+	}
+	if r.GetUser("U_GOOD") == nil {
+		t.Error("expected U_GOOD to be cached")
+	}
+	if r.Count() != 2 {
+		t.Errorf("expected 2 users, got %d", r.Count())
+	}
+}
+
+func TestLoadUsersByID_UsersListPass(t *testing.T) {
+	// 20 wanted users allow 20/usersListPageCost users.list pages; the
+	// list's first two pages hold 16 of them, the rest are not listed.
+	ids := make([]string, 20)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("U%03d", i)
+	}
+	var listCalls int
+	var infoCalls []string
+	mock := &mockSlackAPI{
+		getUsersFunc: func(_ context.Context, cursor string) (*slackapi.UsersListResponse, error) {
+			listCalls++
+			// Pages 1 and 2 hold U000-U015, later pages other users.
+			first := 100 * listCalls
+			if listCalls <= 2 {
+				first = 8 * (listCalls - 1)
+			}
+			resp := &slackapi.UsersListResponse{OK: true, ResponseMetadata: slackapi.ResponseMetadata{NextCursor: "more"}}
+			for i := 0; i < 8; i++ {
+				resp.Members = append(resp.Members, slackapi.User{ID: fmt.Sprintf("U%03d", first+i), Name: "listed"})
+			}
+			return resp, nil
+		},
+		getUserInfoFunc: func(_ context.Context, userID string) (*slackapi.User, error) {
+			infoCalls = append(infoCalls, userID)
+			return &slackapi.User{ID: userID, Name: "fetched"}, nil
+		},
+	}
+
+	r := NewUserResolver()
+	if err := r.LoadUsersByID(context.Background(), mock, ids); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := len(ids) / usersListPageCost; listCalls != want {
+		t.Errorf("users.list calls = %d, want the %d pages the lookups would cost", listCalls, want)
+	}
+	if len(infoCalls) != 4 {
+		t.Errorf("users.info calls = %v, want the 4 users not on the listed pages", infoCalls)
+	}
+	if r.Count() != 20 {
+		t.Fatalf("expected 20 users, got %d", r.Count())
+	}
+	if got := r.GetUser("U003").Name; got != "listed" {
+		t.Errorf("U003 name = %q, want it from users.list", got)
+	}
+	if got := r.GetUser("U019").Name; got != "fetched" {
+		t.Errorf("U019 name = %q, want it from users.info", got)
+	}
+}
+
+func TestLoadUsersByID_UsersListStopsWhenAllFound(t *testing.T) {
+	ids := make([]string, 12)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("U%03d", i)
+	}
+	var listCalls int
+	mock := &mockSlackAPI{
+		getUsersFunc: func(_ context.Context, _ string) (*slackapi.UsersListResponse, error) {
+			listCalls++
+			resp := &slackapi.UsersListResponse{OK: true, ResponseMetadata: slackapi.ResponseMetadata{NextCursor: "more"}}
+			for _, id := range ids {
+				resp.Members = append(resp.Members, slackapi.User{ID: id})
+			}
+			resp.Members = append(resp.Members, slackapi.User{ID: "U_OTHER"})
+			return resp, nil
+		},
+		getUserInfoFunc: func(_ context.Context, userID string) (*slackapi.User, error) {
+			t.Errorf("users.info called for %s, which users.list returned", userID)
+			return nil, fmt.Errorf("unexpected")
+		},
+	}
+
+	r := NewUserResolver()
+	if err := r.LoadUsersByID(context.Background(), mock, ids); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if listCalls != 1 {
+		t.Errorf("users.list calls = %d, want 1", listCalls)
+	}
+	if r.Count() != 12 || r.GetUser("U_OTHER") != nil {
+		t.Errorf("Count() = %d, want the 12 wanted users only", r.Count())
+	}
+}
+
 // ---------------------------------------------------------------------------
 // LoadChannels tests
 // ---------------------------------------------------------------------------
@@ -1240,3 +1369,99 @@ func TestResolveWithFallback_ConcurrentAccess(t *testing.T) {
 		t.Errorf("Count() = %d, want 20", r.Count())
 	}
 }
+
+// ---------------------------------------------------------------------------
+// UserStore and prefetch limit tests
+// ---------------------------------------------------------------------------
+
+// mapUserStore is an in-memory UserStore.
+type mapUserStore map[string]*slackapi.User
+
+func (s mapUserStore) Get(id string) *slackapi.User { return s[id] }
+func (s mapUserStore) Put(user *slackapi.User)      { s[user.ID] = user }
+
+func TestUserResolver_StoreLazyLoadAndWriteThrough(t *testing.T) {
+	store := mapUserStore{"U001": {ID: "U001", Name: "alice", Profile: slackapi.UserProfile{DisplayName: "Alice"}}}
+	var infoCalls int
+	mock := &mockSlackAPI{
+		getConversationMembers: func(_ context.Context, _, _ string) (*slackapi.MembersResponse, error) {
+			return &slackapi.MembersResponse{OK: true, Members: []string{"U001", "U002"}}, nil
+		},
+		getUserInfoFunc: func(_ context.Context, id string) (*slackapi.User, error) {
+			infoCalls++
+			return &slackapi.User{ID: id, Name: "bob"}, nil
+		},
+	}
+
+	r := NewUserResolver()
+	r.SetStore(store)
+	if got := r.Resolve("U001"); got != "Alice" {
+		t.Errorf("Resolve(U001) = %q, want Alice from the store", got)
+	}
+	if err := r.LoadUsersForConversations(context.Background(), mock, []string{"C001"}); err != nil {
+		t.Fatal(err)
+	}
+	if infoCalls != 1 {
+		t.Errorf("users.info calls = %d, want 1 (U001 is in the store)", infoCalls)
+	}
+	if store["U002"] == nil {
+		t.Error("fetched user U002 was not written to the store")
+	}
+}
+
+func TestLoadUsersForConversations_PrefetchLimit(t *testing.T) {
+	mock := &mockSlackAPI{
+		getConversationMembers: func(_ context.Context, _, _ string) (*slackapi.MembersResponse, error) {
+			return &slackapi.MembersResponse{OK: true, Members: []string{"U001", "U002", "U003"}}, nil
+		},
+		getUserInfoFunc: func(_ context.Context, id string) (*slackapi.User, error) {
+			t.Errorf("users.info called for %s past the prefetch limit", id)
+			return nil, fmt.Errorf("unexpected")
+		},
+	}
+
+	r := NewUserResolver()
+	r.SetPrefetchLimit(2)
+	var lazy int
+	err := r.LoadUsersForConversations(context.Background(), mock, []string{"C001"}, func(label string, n int) {
+		if label == "lazy" {
+			lazy = n
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lazy != 3 || r.Count() != 0 {
+		t.Errorf("lazy = %d, Count() = %d; want 3 members left lazy and none loaded", lazy, r.Count())
+	}
+}
+
+func TestUserResolver_MaxUsers(t *testing.T) {
+	store := mapUserStore{}
+	r := NewUserResolver()
+	r.SetStore(store)
+	r.SetMaxUsers(2)
+
+	r.AddUser(&slackapi.User{ID: "U001", Name: "alice"})
+	r.AddUser(&slackapi.User{ID: "U002", Name: "bob"})
+	r.GetUser("U001") // U002 is now the least recently used
+	r.AddUser(&slackapi.User{ID: "U003", Name: "carol"})
+
+	if r.Count() != 2 {
+		t.Errorf("Count() = %d, want the cap of 2", r.Count())
+	}
+	if got := r.Resolve("U002"); got != "bob" {
+		t.Errorf("Resolve(U002) = %q, want the dropped user read back from the store", got)
+	}
+	if users := r.Users(); len(users) != 3 {
+		t.Errorf("Users() = %d users, want those dropped from memory too", len(users))
+	}
+
+	noStore := NewUserResolver()
+	noStore.SetMaxUsers(1)
+	noStore.AddUser(&slackapi.User{ID: "U001", Name: "alice"})
+	noStore.AddUser(&slackapi.User{ID: "U002", Name: "bob"})
+	if noStore.GetUser("U001") != nil || noStore.GetUser("U002") == nil {
+		t.Error("without a store, the least recently used user should be dropped")
+	}
+}
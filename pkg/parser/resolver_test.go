@@ -3,6 +3,7 @@ package parser
 import (
 	"testing"
 
+	"github.com/jflowers/get-out/pkg/config"
 	"github.com/jflowers/get-out/pkg/slackapi"
 )
 
@@ -51,6 +52,21 @@ func TestUserResolver_Count(t *testing.T) {
 	}
 }
 
+func TestUserResolver_Users(t *testing.T) {
+	r := NewUserResolver()
+	if got := r.Users(); len(got) != 0 {
+		t.Errorf("Users() on empty resolver = %v, want empty", got)
+	}
+
+	r.AddUser(&slackapi.User{ID: "U2", Name: "two"})
+	r.AddUser(&slackapi.User{ID: "U1", Name: "one"})
+
+	got := r.Users()
+	if len(got) != 2 || got[0].ID != "U1" || got[1].ID != "U2" {
+		t.Errorf("Users() = %v, want U1, U2 in order", got)
+	}
+}
+
 func TestUserResolver_GetUser(t *testing.T) {
 	r := NewUserResolver()
 
@@ -84,6 +100,9 @@ func TestChannelResolver_AddAndResolve(t *testing.T) {
 	if got != "general" {
 		t.Errorf("Resolve(C123) = %q, want %q", got, "general")
 	}
+	if !r.Has("C123") || r.Has("C999") {
+		t.Errorf("Has() = %v/%v, want true for C123 and false for C999", r.Has("C123"), r.Has("C999"))
+	}
 }
 
 func TestSlackapiGetDisplayName_Priority(t *testing.T) {
@@ -135,6 +154,50 @@ func TestSlackapiGetDisplayName_Priority(t *testing.T) {
 	}
 }
 
+func TestFormatUserName_Policies(t *testing.T) {
+	both := &slackapi.User{ID: "U1", Name: "jdoe", Profile: slackapi.UserProfile{RealName: "Jane Doe", DisplayName: "jd"}}
+	noDisplay := &slackapi.User{ID: "U2", Name: "jdoe", Profile: slackapi.UserProfile{RealName: "Jane Doe"}}
+	noReal := &slackapi.User{ID: "U3", Name: "jdoe", Profile: slackapi.UserProfile{DisplayName: "jd"}}
+	topLevelReal := &slackapi.User{ID: "U4", Name: "jdoe", RealName: "Jane Doe", Profile: slackapi.UserProfile{DisplayName: "jd"}}
+
+	tests := []struct {
+		name   string
+		user   *slackapi.User
+		policy config.NamePolicy
+		want   string
+	}{
+		{"default is display-first", both, "", "jd"},
+		{"display-first", both, config.NamePolicyDisplayFirst, "jd"},
+		{"real-first", both, config.NamePolicyRealFirst, "Jane Doe"},
+		{"real-first falls back to display", noReal, config.NamePolicyRealFirst, "jd"},
+		{"real-first uses top-level real name", topLevelReal, config.NamePolicyRealFirst, "Jane Doe"},
+		{"both", both, config.NamePolicyBoth, "Jane Doe (@jd)"},
+		{"both uses username as handle", noDisplay, config.NamePolicyBoth, "Jane Doe (@jdoe)"},
+		{"both without real name", noReal, config.NamePolicyBoth, "jd"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatUserName(tt.user, tt.policy); got != tt.want {
+				t.Errorf("FormatUserName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserResolver_SetNamePolicy(t *testing.T) {
+	r := NewUserResolver()
+	r.AddUser(&slackapi.User{ID: "U1", Name: "jdoe", Profile: slackapi.UserProfile{RealName: "Jane Doe", DisplayName: "jd"}})
+
+	if got := r.Resolve("U1"); got != "jd" {
+		t.Errorf("default Resolve() = %q, want %q", got, "jd")
+	}
+	r.SetNamePolicy(config.NamePolicyBoth)
+	if got := r.Resolve("U1"); got != "Jane Doe (@jd)" {
+		t.Errorf("Resolve() = %q, want %q", got, "Jane Doe (@jd)")
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Phase 3: Confidence-79 gap-specific tests
 // ---------------------------------------------------------------------------
@@ -0,0 +1,150 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Segment is a run of converted message text and the formatting Slack
+// shows it with.
+type Segment struct {
+	Text      string
+	Bold      bool
+	Italic    bool
+	Strike    bool
+	Code      bool // inline code
+	CodeBlock bool // a ``` block, shown apart from the text around it
+	Quote     bool // part of a > quoted line, without the >
+}
+
+// codePlaceholderPattern matches the placeholders code is swapped out for
+// while the rest of the text is resolved.
+var codePlaceholderPattern = regexp.MustCompile("\x00(CODEBLOCK|INLINECODE)(\\d+)\x00")
+
+// ParseMrkdwnSegments converts Slack mrkdwn like ConvertMrkdwnWithLinks, but
+// keeps its formatting: the text is split into segments that are bold,
+// italic, struck through, inline code, code blocks or quoted lines. The
+// segments' texts, concatenated, are the converted message. Mentions and
+// URLs are resolved outside code only, and the returned link annotations
+// are found in the segments' text as they are in ConvertMrkdwnWithLinks'.
+func ParseMrkdwnSegments(text string, userResolver *UserResolver, channelResolver *ChannelResolver, personResolver *PersonResolver, slackLinkResolver SlackLinkResolver) ([]Segment, []LinkAnnotation) {
+	if text == "" {
+		return nil, nil
+	}
+
+	// Swap code out first, so nothing inside it is resolved or styled.
+	var codeBlocks, inlineCodes []string
+	result := codeBlockPattern.ReplaceAllStringFunc(text, func(match string) string {
+		inner := codeBlockPattern.FindStringSubmatch(match)[1]
+		codeBlocks = append(codeBlocks, strings.TrimSuffix(strings.TrimPrefix(inner, "\n"), "\n"))
+		return fmt.Sprintf("\x00CODEBLOCK%d\x00", len(codeBlocks)-1)
+	})
+	result = inlineCodePattern.ReplaceAllStringFunc(result, func(match string) string {
+		inlineCodes = append(inlineCodes, inlineCodePattern.FindStringSubmatch(match)[1])
+		return fmt.Sprintf("\x00INLINECODE%d\x00", len(inlineCodes)-1)
+	})
+
+	result, links := resolveMrkdwnMarkup(result, userResolver, channelResolver, personResolver, slackLinkResolver)
+
+	var segments []Segment
+	lines := strings.Split(result, "\n")
+	for i, line := range lines {
+		quote := false
+		for _, marker := range []string{"&gt;", ">"} {
+			if strings.HasPrefix(line, marker) {
+				quote = true
+				line = strings.TrimPrefix(strings.TrimPrefix(line, marker), " ")
+				break
+			}
+		}
+		if i < len(lines)-1 {
+			line += "\n"
+		}
+		segments = append(segments, styleSegments(line, Segment{Quote: quote})...)
+	}
+
+	// Put the code back and decode the text around it.
+	var out []Segment
+	for _, seg := range segments {
+		last := 0
+		for _, loc := range codePlaceholderPattern.FindAllStringSubmatchIndex(seg.Text, -1) {
+			out = appendSegment(out, seg, decodeHTMLEntities(seg.Text[last:loc[0]]))
+			n, _ := strconv.Atoi(seg.Text[loc[4]:loc[5]])
+			if seg.Text[loc[2]:loc[3]] == "CODEBLOCK" {
+				out = appendSegment(out, Segment{CodeBlock: true}, decodeHTMLEntities(codeBlocks[n]))
+			} else {
+				code := seg
+				code.Code = true
+				out = appendSegment(out, code, decodeHTMLEntities(inlineCodes[n]))
+			}
+			last = loc[1]
+		}
+		out = appendSegment(out, seg, decodeHTMLEntities(seg.Text[last:]))
+	}
+	return out, links
+}
+
+// styleMarkers are the inline formatting markers of mrkdwn and the style
+// each gives its text.
+var styleMarkers = []struct {
+	pattern *regexp.Regexp
+	apply   func(*Segment)
+}{
+	{boldPattern, func(s *Segment) { s.Bold = true }},
+	{italicPattern, func(s *Segment) { s.Italic = true }},
+	{strikePattern, func(s *Segment) { s.Strike = true }},
+}
+
+// styleSegments splits one line of text at its formatting markers, which
+// may nest, into segments styled like style plus the markers around them.
+func styleSegments(text string, style Segment) []Segment {
+	var segments []Segment
+	for text != "" {
+		var first []int
+		var apply func(*Segment)
+		for _, m := range styleMarkers {
+			if loc := m.pattern.FindStringSubmatchIndex(text); loc != nil && (first == nil || loc[0] < first[0]) {
+				first, apply = loc, m.apply
+			}
+		}
+		if first == nil {
+			segments = append(segments, withText(style, text))
+			break
+		}
+		if first[0] > 0 {
+			segments = append(segments, withText(style, text[:first[0]]))
+		}
+		inner := style
+		apply(&inner)
+		segments = append(segments, styleSegments(text[first[2]:first[3]], inner)...)
+		text = text[first[1]:]
+	}
+	return segments
+}
+
+// withText returns style with text as its text.
+func withText(style Segment, text string) Segment {
+	style.Text = text
+	return style
+}
+
+// appendSegment appends text styled like style to segments, merging it
+// into the last segment when that has the same style.
+func appendSegment(segments []Segment, style Segment, text string) []Segment {
+	if text == "" {
+		return segments
+	}
+	style.Text = ""
+	if n := len(segments); n > 0 {
+		last := segments[n-1]
+		last.Text = ""
+		if last == style {
+			segments[n-1].Text += text
+			return segments
+		}
+	}
+	style.Text = text
+	return append(segments, style)
+}
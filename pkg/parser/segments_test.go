@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMrkdwnSegments(t *testing.T) {
+	users := NewUserResolver()
+	channels := NewChannelResolver()
+	channels.AddChannel("C001", "general")
+
+	tests := []struct {
+		name  string
+		input string
+		want  []Segment
+	}{
+		{"empty", "", nil},
+		{"plain", "just text &amp; more", []Segment{{Text: "just text & more"}}},
+		{
+			name:  "inline styles",
+			input: "a *bold* b _it_ c ~gone~ d `x < y` e",
+			want: []Segment{
+				{Text: "a "}, {Text: "bold", Bold: true}, {Text: " b "}, {Text: "it", Italic: true},
+				{Text: " c "}, {Text: "gone", Strike: true}, {Text: " d "}, {Text: "x < y", Code: true}, {Text: " e"},
+			},
+		},
+		{
+			name:  "nested styles",
+			input: "*bold _both_ `code`*",
+			want: []Segment{
+				{Text: "bold ", Bold: true}, {Text: "both", Bold: true, Italic: true},
+				{Text: " ", Bold: true}, {Text: "code", Bold: true, Code: true},
+			},
+		},
+		{
+			name:  "code is not resolved or styled",
+			input: "see <#C001> `*not bold* <#C001>`",
+			want:  []Segment{{Text: "see #general "}, {Text: "*not bold* <#C001>", Code: true}},
+		},
+		{
+			name:  "code block",
+			input: "Run:\n```\ngo test ./...\nmake &amp; ship\n```\ndone",
+			want: []Segment{
+				{Text: "Run:\n"}, {Text: "go test ./...\nmake & ship", CodeBlock: true}, {Text: "\ndone"},
+			},
+		},
+		{
+			name:  "quoted lines",
+			input: "&gt; first *line*\n&gt; second\nreply",
+			want: []Segment{
+				{Text: "first ", Quote: true}, {Text: "line", Bold: true, Quote: true},
+				{Text: "\nsecond\n", Quote: true}, {Text: "reply"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		got, _ := ParseMrkdwnSegments(tt.input, users, channels, nil, nil)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ParseMrkdwnSegments(%q) =\n%+v\nwant\n%+v", tt.name, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseMrkdwnSegments_Links(t *testing.T) {
+	segments, links := ParseMrkdwnSegments("*see <https://example.com|the docs>* or <https://example.org>", nil, nil, nil, nil)
+
+	var text string
+	for _, s := range segments {
+		text += s.Text
+	}
+	if want := "see the docs or https://example.org"; text != want {
+		t.Errorf("text = %q, want %q", text, want)
+	}
+	want := []LinkAnnotation{{Text: "the docs", URL: "https://example.com"}, {Text: "https://example.org", URL: "https://example.org"}}
+	if !reflect.DeepEqual(links, want) {
+		t.Errorf("links = %v, want %v", links, want)
+	}
+	if len(segments) == 0 || !segments[0].Bold {
+		t.Errorf("segments = %+v, want the link text bold", segments)
+	}
+}
@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"sync"
+	"time"
+)
+
+// Default layouts of exported message times and dates.
+const (
+	DefaultTimeLayout = "3:04 PM"
+	DefaultDateLayout = "Jan 2, 2006"
+)
+
+// TimeSettings controls how exported message times are shown and in which
+// time zone each day starts, so an export reads the same whichever machine
+// runs it.
+type TimeSettings struct {
+	// Location is the zone times are shown in and messages are grouped into
+	// days by. Nil is the machine's local zone.
+	Location *time.Location
+	// TimeLayout is the Go layout of a message's time; empty is
+	// DefaultTimeLayout.
+	TimeLayout string
+	// DateLayout is the Go layout of a date shown with a time; empty is
+	// DefaultDateLayout.
+	DateLayout string
+}
+
+var (
+	timeMu       sync.RWMutex
+	timeSettings TimeSettings
+)
+
+// SetTimeSettings sets the time zone and layouts used by FormatTimestamp,
+// FormatTimestampFull, FormatTime, TimestampDate and InZone.
+func SetTimeSettings(s TimeSettings) {
+	timeMu.Lock()
+	defer timeMu.Unlock()
+	timeSettings = s
+}
+
+// CurrentTimeSettings returns the settings in effect, defaults filled in.
+func CurrentTimeSettings() TimeSettings {
+	timeMu.RLock()
+	s := timeSettings
+	timeMu.RUnlock()
+	if s.Location == nil {
+		s.Location = time.Local
+	}
+	if s.TimeLayout == "" {
+		s.TimeLayout = DefaultTimeLayout
+	}
+	if s.DateLayout == "" {
+		s.DateLayout = DefaultDateLayout
+	}
+	return s
+}
+
+// InZone returns t in the configured time zone.
+func InZone(t time.Time) time.Time {
+	return t.In(CurrentTimeSettings().Location)
+}
+
+// FormatTime formats t with date and time, in the zone and layouts of the
+// current TimeSettings.
+func FormatTime(t time.Time) string {
+	s := CurrentTimeSettings()
+	return t.In(s.Location).Format(s.DateLayout + " " + s.TimeLayout)
+}
+
+// SlackTime converts a Slack timestamp to a time in the configured zone.
+func SlackTime(ts string) time.Time {
+	return InZone(tsToTime(ts))
+}
+
+// TimestampDate returns the date, as YYYY-MM-DD, of a Slack timestamp in
+// the configured zone: the day whose doc the message belongs to.
+func TimestampDate(ts string) string {
+	return SlackTime(ts).Format("2006-01-02")
+}
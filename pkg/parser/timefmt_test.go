@@ -0,0 +1,44 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeSettings(t *testing.T) {
+	t.Cleanup(func() { SetTimeSettings(TimeSettings{}) })
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("no time zone data: %v", err)
+	}
+
+	// 2024-01-31 23:30:00 UTC is already 2024-02-01 in Tokyo.
+	ts := "1706743800.000100"
+
+	SetTimeSettings(TimeSettings{Location: time.UTC})
+	if got := TimestampDate(ts); got != "2024-01-31" {
+		t.Errorf("UTC: TimestampDate() = %q, want 2024-01-31", got)
+	}
+	if got := FormatTimestamp(ts); got != "11:30 PM" {
+		t.Errorf("UTC: FormatTimestamp() = %q, want the default layout", got)
+	}
+
+	SetTimeSettings(TimeSettings{Location: tokyo, TimeLayout: "15:04", DateLayout: "2006-01-02"})
+	if got := TimestampDate(ts); got != "2024-02-01" {
+		t.Errorf("Tokyo: TimestampDate() = %q, want 2024-02-01", got)
+	}
+	if got := FormatTimestamp(ts); got != "08:30" {
+		t.Errorf("Tokyo: FormatTimestamp() = %q, want 08:30", got)
+	}
+	if got := FormatTimestampFull(ts); got != "2024-02-01 08:30" {
+		t.Errorf("Tokyo: FormatTimestampFull() = %q, want 2024-02-01 08:30", got)
+	}
+	if got := InZone(time.Unix(0, 0)).Location(); got != tokyo {
+		t.Errorf("InZone() location = %v, want Tokyo", got)
+	}
+
+	SetTimeSettings(TimeSettings{})
+	if got := CurrentTimeSettings(); got.Location != time.Local || got.TimeLayout != DefaultTimeLayout || got.DateLayout != DefaultDateLayout {
+		t.Errorf("CurrentTimeSettings() = %+v, want the defaults", got)
+	}
+}
@@ -0,0 +1,320 @@
+// Package s3sync uploads a local export directory to an Amazon S3 bucket
+// (or an S3-compatible store such as MinIO), so a headless run can keep
+// its markdown, JSON, or HTML export in object storage. Requests are
+// signed with AWS Signature Version 4 over net/http; only PutObject is
+// used.
+package s3sync
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Environment variables read by ConfigFromEnv. They are the AWS CLI's, so
+// a container configured for it needs nothing more.
+const (
+	EnvAccessKeyID     = "AWS_ACCESS_KEY_ID"
+	EnvSecretAccessKey = "AWS_SECRET_ACCESS_KEY"
+	EnvSessionToken    = "AWS_SESSION_TOKEN"
+	EnvRegion          = "AWS_REGION"
+	EnvDefaultRegion   = "AWS_DEFAULT_REGION"
+	EnvEndpoint        = "AWS_ENDPOINT_URL_S3"
+	EnvEndpointAll     = "AWS_ENDPOINT_URL"
+)
+
+// DefaultRegion is used when neither AWS_REGION nor AWS_DEFAULT_REGION is
+// set.
+const DefaultRegion = "us-east-1"
+
+// Config holds the destination and credentials of a sync.
+type Config struct {
+	Bucket string
+	Prefix string // Key prefix, without leading or trailing slashes
+
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Temporary credentials only
+
+	// Endpoint is the base URL of an S3-compatible store. Objects are then
+	// addressed path-style (endpoint/bucket/key); without it, AWS's
+	// virtual-hosted style (bucket.s3.region.amazonaws.com/key) is used.
+	Endpoint string
+}
+
+// ParseURL splits an s3://bucket/prefix URL into its bucket and prefix.
+func ParseURL(raw string) (bucket, prefix string, err error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "s3" || u.Host == "" {
+		return "", "", fmt.Errorf("invalid S3 URL %q (use s3://bucket/prefix)", raw)
+	}
+	return u.Host, strings.Trim(u.Path, "/"), nil
+}
+
+// ConfigFromEnv returns the Config for the s3://bucket/prefix URL raw,
+// with credentials, region, and endpoint from the environment.
+func ConfigFromEnv(raw string) (Config, error) {
+	bucket, prefix, err := ParseURL(raw)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg := Config{
+		Bucket:          bucket,
+		Prefix:          prefix,
+		Region:          firstEnv(EnvRegion, EnvDefaultRegion),
+		AccessKeyID:     os.Getenv(EnvAccessKeyID),
+		SecretAccessKey: os.Getenv(EnvSecretAccessKey),
+		SessionToken:    os.Getenv(EnvSessionToken),
+		Endpoint:        firstEnv(EnvEndpoint, EnvEndpointAll),
+	}
+	if cfg.Region == "" {
+		cfg.Region = DefaultRegion
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return Config{}, fmt.Errorf("S3 upload needs credentials: set %s and %s", EnvAccessKeyID, EnvSecretAccessKey)
+	}
+	return cfg, nil
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Client puts objects into one bucket.
+type Client struct {
+	cfg  Config
+	http *http.Client
+	now  func() time.Time
+}
+
+// Option configures the Client.
+type Option func(*Client)
+
+// WithHTTPClient sends requests with hc instead of http.DefaultClient.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.http = hc
+	}
+}
+
+// NewClient creates a client for cfg.
+func NewClient(cfg Config, opts ...Option) *Client {
+	c := &Client{cfg: cfg, http: http.DefaultClient, now: time.Now}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// objectURL returns the URL of the object with key.
+func (c *Client) objectURL(key string) (*url.URL, error) {
+	if c.cfg.Endpoint != "" {
+		u, err := url.Parse(strings.TrimRight(c.cfg.Endpoint, "/"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid S3 endpoint %q: %w", c.cfg.Endpoint, err)
+		}
+		object := "/" + c.cfg.Bucket + "/" + key
+		u.RawPath = u.EscapedPath() + escapePath(object)
+		u.Path += object
+		return u, nil
+	}
+	return &url.URL{
+		Scheme:  "https",
+		Host:    fmt.Sprintf("%s.s3.%s.amazonaws.com", c.cfg.Bucket, c.cfg.Region),
+		Path:    "/" + key,
+		RawPath: escapePath("/" + key),
+	}, nil
+}
+
+// escapePath percent-encodes every byte of p except unreserved characters
+// and slashes, as the canonical URI of a signed S3 request must be.
+func escapePath(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		ch := p[i]
+		if 'A' <= ch && ch <= 'Z' || 'a' <= ch && ch <= 'z' || '0' <= ch && ch <= '9' || strings.IndexByte("-._~/", ch) >= 0 {
+			b.WriteByte(ch)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", ch)
+	}
+	return b.String()
+}
+
+// PutObject stores body under key, replacing any object already there.
+func (c *Client) PutObject(ctx context.Context, key string, body []byte, contentType string) error {
+	u, err := c.objectURL(key)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	c.sign(req, body)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to upload %s: %s: %s", key, resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// sign adds an AWS Signature Version 4 Authorization header to req, whose
+// payload is body.
+func (c *Client) sign(req *http.Request, body []byte) {
+	now := c.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := hashHex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if c.cfg.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.cfg.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(req.Header.Get(name))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + c.cfg.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hashHex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+c.cfg.SecretAccessKey), day)
+	key = hmacSHA256(key, c.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.cfg.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// SyncDir uploads the files under dir that are new or changed since the
+// last sync to the bucket, under the configured prefix, and returns how
+// many it uploaded. What was uploaded is recorded in the JSON file at
+// statePath (by key and SHA-256), so unchanged files are not sent again.
+// Files uploaded before an error are recorded too.
+func (c *Client) SyncDir(ctx context.Context, dir, statePath string) (int, error) {
+	state := make(map[string]string)
+	if data, err := os.ReadFile(statePath); err == nil {
+		if err := json.Unmarshal(data, &state); err != nil {
+			return 0, fmt.Errorf("failed to read S3 sync state %s: %w", statePath, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return 0, err
+	}
+
+	uploaded := 0
+	walkErr := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		key := path.Join(c.cfg.Prefix, filepath.ToSlash(rel))
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		sum := hashHex(data)
+		if state[key] == sum {
+			return nil
+		}
+		if err := c.PutObject(ctx, key, data, contentType(p)); err != nil {
+			return err
+		}
+		state[key] = sum
+		uploaded++
+		return nil
+	})
+
+	if uploaded > 0 {
+		data, err := json.MarshalIndent(state, "", "  ")
+		if err == nil {
+			err = os.WriteFile(statePath, data, 0600)
+		}
+		if err != nil && walkErr == nil {
+			walkErr = fmt.Errorf("failed to save S3 sync state: %w", err)
+		}
+	}
+	return uploaded, walkErr
+}
+
+// contentType returns the MIME type of an exported file by its extension.
+func contentType(name string) string {
+	switch ext := strings.ToLower(filepath.Ext(name)); ext {
+	case ".md":
+		return "text/markdown; charset=utf-8"
+	default:
+		if t := mime.TypeByExtension(ext); t != "" {
+			return t
+		}
+		return "application/octet-stream"
+	}
+}
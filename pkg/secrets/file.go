@@ -21,6 +21,10 @@ func (f *FileStore) Get(key string) (string, error) {
 		return f.readFile("token.json")
 	case KeyClientCredentials:
 		return f.readFile("credentials.json")
+	case KeyLegalHoldSigningKey:
+		return f.readFile("legal-hold.key")
+	case KeySlackSession:
+		return f.readFile("slack-session.json")
 	default:
 		return "", fmt.Errorf("unknown key: %s", key)
 	}
@@ -33,6 +37,10 @@ func (f *FileStore) Set(key, value string) error {
 		return f.writeFile("token.json", value)
 	case KeyClientCredentials:
 		return f.writeFile("credentials.json", value)
+	case KeyLegalHoldSigningKey:
+		return f.writeFile("legal-hold.key", value)
+	case KeySlackSession:
+		return f.writeFile("slack-session.json", value)
 	default:
 		return fmt.Errorf("unknown key: %s", key)
 	}
@@ -45,6 +53,10 @@ func (f *FileStore) Delete(key string) error {
 		return f.deleteFile("token.json")
 	case KeyClientCredentials:
 		return f.deleteFile("credentials.json")
+	case KeyLegalHoldSigningKey:
+		return f.deleteFile("legal-hold.key")
+	case KeySlackSession:
+		return f.deleteFile("slack-session.json")
 	default:
 		return fmt.Errorf("unknown key: %s", key)
 	}
@@ -14,8 +14,10 @@ const ServiceName = "com.jflowers.get-out"
 
 // Well-known keys for stored secrets.
 const (
-	KeyOAuthToken        = "oauth-token"
-	KeyClientCredentials = "credentials-json"
+	KeyOAuthToken          = "oauth-token"
+	KeyClientCredentials   = "credentials-json"
+	KeyLegalHoldSigningKey = "legal-hold-signing-key"
+	KeySlackSession        = "slack-session"
 )
 
 // probeKey is the sentinel key used to detect keychain availability.
@@ -29,6 +29,7 @@ func TestKeychainStore_SetGetDelete(t *testing.T) {
 	}{
 		{"oauth-token", KeyOAuthToken},
 		{"credentials-json", KeyClientCredentials},
+		{"slack-session", KeySlackSession},
 	}
 
 	for _, tc := range tests {
@@ -125,6 +126,18 @@ func TestFileStore_SetGetDelete(t *testing.T) {
 			value:    `{"installed":{"client_id":"123.apps.googleusercontent.com","client_secret":"GOCSPX-test"}}`,
 			filename: "credentials.json",
 		},
+		{
+			name:     "legal-hold-signing-key",
+			key:      KeyLegalHoldSigningKey,
+			value:    "c2VlZA==",
+			filename: "legal-hold.key",
+		},
+		{
+			name:     "slack-session",
+			key:      KeySlackSession,
+			value:    `{"token":"xoxc-test","cookie":"xoxd-test"}`,
+			filename: "slack-session.json",
+		},
 	}
 
 	for _, tc := range tests {
@@ -0,0 +1,222 @@
+package slackapi
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"sync"
+)
+
+// Slack API methods tracked in a Capabilities matrix.
+const (
+	MethodAuthTest             = "auth.test"
+	MethodConversationsList    = "conversations.list"
+	MethodConversationsHistory = "conversations.history"
+	MethodConversationsReplies = "conversations.replies"
+	MethodConversationsMembers = "conversations.members"
+	MethodConversationsInfo    = "conversations.info"
+	MethodUsersList            = "users.list"
+	MethodUsersInfo            = "users.info"
+	MethodFilesList            = "files.list"
+	MethodFilesInfo            = "files.info"
+	MethodSearchMessages       = "search.messages"
+	MethodReactionsList        = "reactions.list"
+	MethodStarsList            = "stars.list"
+	MethodScheduledList        = "chat.scheduledMessages.list"
+	MethodRemindersList        = "reminders.list"
+	MethodThreadsView          = "subscriptions.thread.getView"
+	MethodTeamInfo             = "team.info"
+	MethodEmojiList            = "emoji.list"
+)
+
+// ProbedMethods lists the methods TestAccess probes, in probe order.
+var ProbedMethods = []string{
+	MethodAuthTest,
+	MethodConversationsList,
+	MethodConversationsHistory,
+	MethodConversationsReplies,
+	MethodConversationsMembers,
+	MethodConversationsInfo,
+	MethodUsersList,
+	MethodUsersInfo,
+	MethodFilesList,
+	MethodSearchMessages,
+	MethodEmojiList,
+}
+
+// Access is what a Capabilities matrix knows about one API method.
+type Access int
+
+const (
+	// AccessUnknown means the method has not been probed or called yet.
+	AccessUnknown Access = iota
+	// AccessAllowed means a call to the method has succeeded.
+	AccessAllowed
+	// AccessRestricted means the workspace or token does not permit the
+	// method (see IsRestrictedError).
+	AccessRestricted
+)
+
+// String returns "unknown", "allowed", or "restricted".
+func (a Access) String() string {
+	switch a {
+	case AccessAllowed:
+		return "allowed"
+	case AccessRestricted:
+		return "restricted"
+	default:
+		return "unknown"
+	}
+}
+
+// Capabilities records which Slack API methods work in the current
+// workspace. It is populated up front by TestAccess and updated as the
+// export calls each method, so later stages can switch to a fallback
+// strategy instead of failing. It is safe for concurrent use.
+type Capabilities struct {
+	mu      sync.RWMutex
+	access  map[string]Access
+	reasons map[string]string
+}
+
+// NewCapabilities returns a matrix in which every method is AccessUnknown.
+func NewCapabilities() *Capabilities {
+	return &Capabilities{
+		access:  make(map[string]Access),
+		reasons: make(map[string]string),
+	}
+}
+
+// Record updates method from the outcome of a call to it: a nil err marks
+// it allowed and a restriction error marks it restricted. Other errors
+// (rate limits, not-found, network failures) say nothing about access and
+// leave the matrix unchanged. A nil receiver ignores the call.
+func (c *Capabilities) Record(method string, err error) {
+	if c == nil {
+		return
+	}
+	switch {
+	case err == nil:
+		c.set(method, AccessAllowed, "")
+	case IsRestrictedError(err):
+		c.set(method, AccessRestricted, err.Error())
+	}
+}
+
+func (c *Capabilities) set(method string, a Access, reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// A method that has been seen to work stays allowed: per-object
+	// access_denied errors (a single private channel, say) must not switch
+	// the whole export to a fallback.
+	if a == AccessRestricted && c.access[method] == AccessAllowed {
+		return
+	}
+	c.access[method] = a
+	c.reasons[method] = reason
+}
+
+// Access returns what is known about method. A nil receiver knows nothing.
+func (c *Capabilities) Access(method string) Access {
+	if c == nil {
+		return AccessUnknown
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.access[method]
+}
+
+// Usable reports whether method is worth calling: it has not been found to
+// be restricted.
+func (c *Capabilities) Usable(method string) bool {
+	return c.Access(method) != AccessRestricted
+}
+
+// Reason returns the error that marked method restricted, or "".
+func (c *Capabilities) Reason(method string) string {
+	if c == nil {
+		return ""
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reasons[method]
+}
+
+// Restricted returns the restricted methods, sorted.
+func (c *Capabilities) Restricted() []string {
+	if c == nil {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var methods []string
+	for m, a := range c.access {
+		if a == AccessRestricted {
+			methods = append(methods, m)
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// AccessProber is the subset of the client that TestAccess calls. It is
+// satisfied by *Client.
+type AccessProber interface {
+	ValidateAuth(ctx context.Context) (*AuthTestResponse, error)
+	ListConversations(ctx context.Context, opts *ListConversationsOptions) (*ConversationsListResponse, error)
+	GetConversationHistory(ctx context.Context, channelID string, opts *HistoryOptions) (*HistoryResponse, error)
+	GetConversationReplies(ctx context.Context, channelID, threadTS string, opts *RepliesOptions) (*RepliesResponse, error)
+	GetConversationMembers(ctx context.Context, channelID, cursor string) (*MembersResponse, error)
+	GetConversationInfo(ctx context.Context, channelID string) (*Conversation, error)
+	GetUsers(ctx context.Context, cursor string) (*UsersListResponse, error)
+	GetUserInfo(ctx context.Context, userID string) (*User, error)
+	Probe(ctx context.Context, method string, params url.Values) error
+}
+
+// TestAccess probes each of ProbedMethods with one cheap call and returns
+// the resulting matrix. The conversation methods are probed against
+// channelID, or against the first channel conversations.list returns when
+// channelID is empty; they stay AccessUnknown if there is no channel to
+// try. Probe failures are recorded, not returned; call ValidateAuth to fail
+// fast on an expired session.
+func TestAccess(ctx context.Context, client AccessProber, channelID string) *Capabilities {
+	caps := NewCapabilities()
+
+	auth, err := client.ValidateAuth(ctx)
+	caps.Record(MethodAuthTest, err)
+
+	list, err := client.ListConversations(ctx, &ListConversationsOptions{Types: []string{"public_channel"}})
+	caps.Record(MethodConversationsList, err)
+	if channelID == "" && err == nil && len(list.Channels) > 0 {
+		channelID = list.Channels[0].ID
+	}
+
+	if channelID != "" {
+		history, err := client.GetConversationHistory(ctx, channelID, &HistoryOptions{Limit: 1})
+		caps.Record(MethodConversationsHistory, err)
+		if err == nil && len(history.Messages) > 0 {
+			_, err = client.GetConversationReplies(ctx, channelID, history.Messages[0].TS, &RepliesOptions{Limit: 1})
+			caps.Record(MethodConversationsReplies, err)
+		}
+
+		_, err = client.GetConversationMembers(ctx, channelID, "")
+		caps.Record(MethodConversationsMembers, err)
+
+		_, err = client.GetConversationInfo(ctx, channelID)
+		caps.Record(MethodConversationsInfo, err)
+	}
+
+	_, err = client.GetUsers(ctx, "")
+	caps.Record(MethodUsersList, err)
+
+	if auth != nil && auth.UserID != "" {
+		_, err = client.GetUserInfo(ctx, auth.UserID)
+		caps.Record(MethodUsersInfo, err)
+	}
+
+	caps.Record(MethodFilesList, client.Probe(ctx, MethodFilesList, url.Values{"count": {"1"}}))
+	caps.Record(MethodSearchMessages, client.Probe(ctx, MethodSearchMessages, url.Values{"query": {"a"}, "count": {"1"}}))
+	caps.Record(MethodEmojiList, client.Probe(ctx, MethodEmojiList, nil))
+
+	return caps
+}
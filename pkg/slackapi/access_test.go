@@ -0,0 +1,153 @@
+package slackapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestIsRestrictedError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"missing scope", &APIError{Code: ErrCodeMissingScope}, true},
+		{"access denied", &APIError{Code: ErrCodeAccessDenied}, true},
+		{"token type", &APIError{Code: ErrCodeNotAllowedTokenType}, true},
+		{"wrapped", fmt.Errorf("failed to fetch replies: %w", &APIError{Code: ErrCodeRestrictedAction}), true},
+		{"other api error", &APIError{Code: "channel_not_found"}, false},
+		{"rate limit", &RateLimitError{}, false},
+		{"plain", errors.New("boom"), false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRestrictedError(tt.err); got != tt.want {
+				t.Errorf("IsRestrictedError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCapabilities_Record(t *testing.T) {
+	caps := NewCapabilities()
+	if caps.Access(MethodUsersList) != AccessUnknown || !caps.Usable(MethodUsersList) {
+		t.Fatal("unprobed method should be unknown and usable")
+	}
+
+	caps.Record(MethodUsersList, &APIError{Code: ErrCodeMissingScope})
+	if caps.Usable(MethodUsersList) {
+		t.Error("users.list should be restricted after missing_scope")
+	}
+	if caps.Reason(MethodUsersList) == "" {
+		t.Error("restricted method should keep its reason")
+	}
+
+	caps.Record(MethodUsersInfo, errors.New("network down"))
+	if caps.Access(MethodUsersInfo) != AccessUnknown {
+		t.Error("non-restriction errors should not change access")
+	}
+
+	// Once a method has worked, a per-object denial does not restrict it.
+	caps.Record(MethodConversationsMembers, nil)
+	caps.Record(MethodConversationsMembers, &APIError{Code: ErrCodeAccessDenied})
+	if caps.Access(MethodConversationsMembers) != AccessAllowed {
+		t.Error("allowed method should stay allowed")
+	}
+
+	if got := caps.Restricted(); !reflect.DeepEqual(got, []string{MethodUsersList}) {
+		t.Errorf("Restricted() = %v", got)
+	}
+}
+
+func TestCapabilities_NilIsPermissive(t *testing.T) {
+	var caps *Capabilities
+	caps.Record(MethodUsersList, &APIError{Code: ErrCodeMissingScope})
+	if !caps.Usable(MethodUsersList) || caps.Restricted() != nil {
+		t.Error("nil matrix should treat every method as usable")
+	}
+}
+
+type stubProber struct {
+	errs           map[string]error
+	historyChannel string
+}
+
+func (s *stubProber) errFor(method string) error {
+	return s.errs[method]
+}
+
+func (s *stubProber) ValidateAuth(context.Context) (*AuthTestResponse, error) {
+	return &AuthTestResponse{OK: true, UserID: "U000"}, s.errFor(MethodAuthTest)
+}
+
+func (s *stubProber) ListConversations(context.Context, *ListConversationsOptions) (*ConversationsListResponse, error) {
+	return &ConversationsListResponse{OK: true, Channels: []Conversation{{ID: "C001"}}}, s.errFor(MethodConversationsList)
+}
+
+func (s *stubProber) GetConversationHistory(_ context.Context, channelID string, _ *HistoryOptions) (*HistoryResponse, error) {
+	s.historyChannel = channelID
+	return &HistoryResponse{OK: true, Messages: []Message{{TS: "1.000001"}}}, s.errFor(MethodConversationsHistory)
+}
+
+func (s *stubProber) GetConversationReplies(context.Context, string, string, *RepliesOptions) (*RepliesResponse, error) {
+	return &RepliesResponse{OK: true}, s.errFor(MethodConversationsReplies)
+}
+
+func (s *stubProber) GetConversationMembers(context.Context, string, string) (*MembersResponse, error) {
+	return &MembersResponse{OK: true}, s.errFor(MethodConversationsMembers)
+}
+
+func (s *stubProber) GetConversationInfo(context.Context, string) (*Conversation, error) {
+	return &Conversation{}, s.errFor(MethodConversationsInfo)
+}
+
+func (s *stubProber) GetUsers(context.Context, string) (*UsersListResponse, error) {
+	return &UsersListResponse{OK: true}, s.errFor(MethodUsersList)
+}
+
+func (s *stubProber) GetUserInfo(context.Context, string) (*User, error) {
+	return &User{}, s.errFor(MethodUsersInfo)
+}
+
+func (s *stubProber) Probe(_ context.Context, method string, _ url.Values) error {
+	return s.errFor(method)
+}
+
+func TestTestAccess(t *testing.T) {
+	stub := &stubProber{errs: map[string]error{
+		MethodUsersList:      &APIError{Code: ErrCodeMissingScope},
+		MethodSearchMessages: &APIError{Code: ErrCodeNotAllowedTokenType},
+	}}
+	caps := TestAccess(context.Background(), stub, "")
+
+	for _, method := range ProbedMethods {
+		want := AccessAllowed
+		if method == MethodUsersList || method == MethodSearchMessages {
+			want = AccessRestricted
+		}
+		if got := caps.Access(method); got != want {
+			t.Errorf("Access(%s) = %v, want %v", method, got, want)
+		}
+	}
+	if stub.historyChannel != "C001" {
+		t.Errorf("history probed in %q, want the first listed channel", stub.historyChannel)
+	}
+}
+
+func TestTestAccess_ExplicitChannel(t *testing.T) {
+	stub := &stubProber{}
+	TestAccess(context.Background(), stub, "C999")
+	if stub.historyChannel != "C999" {
+		t.Errorf("history probed in %q, want the given channel", stub.historyChannel)
+	}
+}
+
+var (
+	_ AccessProber = (*Client)(nil)
+	_ AccessProber = (*stubProber)(nil)
+)
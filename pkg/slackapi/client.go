@@ -5,11 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"net/http"
 	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -18,23 +20,78 @@ const (
 	defaultTimeout = 30 * time.Second
 )
 
+// DefaultMaxRetries is how many times a request is retried after a rate
+// limit or server error unless WithMaxRetries says otherwise.
+const DefaultMaxRetries = 3
+
+// serverRetryDelay is the base wait before retrying a request that failed
+// with a server error; it doubles on each further retry, with jitter.
+var serverRetryDelay = time.Second
+
 // Client is a Slack API client supporting both browser and bot authentication.
 type Client struct {
 	httpClient *http.Client
 	baseURL    string
-	token      string // xoxc- or xoxb- token
-	cookie     string // xoxd- cookie (only for browser mode)
 	mode       AuthMode
 	limiter    *RateLimiter
+	recorder   ResponseRecorder
+	observer   Observer
+	maxRetries int
+
+	// notify receives retry notices; nil prints them to stderr.
+	notify func(msg string)
+
+	// credMu guards token, cookie and credGen, which reauth replaces.
+	credMu  sync.RWMutex
+	token   string // xoxc- or xoxb- token
+	cookie  string // xoxd- cookie (only for browser mode)
+	credGen int    // incremented each time reauth replaces the credentials
+
+	// reauth, when set, supplies new credentials after an auth error;
+	// reauthMu makes concurrent requests share one reauthentication.
+	reauth   Reauthenticator
+	reauthMu sync.Mutex
+}
+
+// Reauthenticator returns fresh credentials for a client whose session
+// has expired, such as ones extracted again from the browser.
+type Reauthenticator func(ctx context.Context) (token, cookie string, err error)
+
+// ResponseRecorder receives the raw body of every successful (non-429)
+// Slack API response, e.g. to archive it for later re-rendering.
+// Implementations must be safe for concurrent use.
+type ResponseRecorder interface {
+	RecordResponse(endpoint string, params url.Values, body []byte)
+}
+
+// Observer is told about the client's API traffic, e.g. to export metrics.
+// Implementations must be safe for concurrent use.
+type Observer interface {
+	// ObserveRequest is called after each attempt at an API request, with
+	// the error it failed with, if any (a *RateLimitError for a 429).
+	ObserveRequest(endpoint string, err error)
+
+	// ObserveWait is called after the client slept for d before a
+	// request: WaitRateLimit for the rate limiter's pacing and 429
+	// backoff, WaitRetry for the backoff after a server error.
+	ObserveWait(endpoint string, reason WaitReason, d time.Duration)
 }
 
+// WaitReason says why the client slept before a request.
+type WaitReason string
+
+const (
+	WaitRateLimit WaitReason = "rate_limit"
+	WaitRetry     WaitReason = "retry"
+)
+
 // AuthMode represents the authentication mode.
 type AuthMode int
 
 const (
 	// AuthModeBrowser uses xoxc token + xoxd cookie (for DMs, groups)
 	AuthModeBrowser AuthMode = iota
-	// AuthModeAPI uses xoxb token (currently unused; retained for API compatibility)
+	// AuthModeAPI uses an xoxp or xoxb token on its own (static token exports)
 	AuthModeAPI
 )
 
@@ -62,6 +119,49 @@ func WithRateLimiter(rl *RateLimiter) ClientOption {
 	}
 }
 
+// WithMaxRetries sets how many times a request or download is retried after
+// a rate limit (429) or server error (5xx) before its error is returned
+// (default DefaultMaxRetries; 0 disables retries).
+func WithMaxRetries(n int) ClientOption {
+	return func(client *Client) {
+		client.maxRetries = max(n, 0)
+	}
+}
+
+// WithReauth sets how the client renews its credentials when Slack rejects
+// them mid-run (invalid_auth, token_revoked and the like). The failed
+// request is retried once with the new credentials; if reauth fails, the
+// auth error is returned.
+func WithReauth(fn Reauthenticator) ClientOption {
+	return func(client *Client) {
+		client.reauth = fn
+	}
+}
+
+// WithResponseRecorder sets a recorder that receives raw API response bodies.
+func WithResponseRecorder(r ResponseRecorder) ClientOption {
+	return func(client *Client) {
+		client.recorder = r
+	}
+}
+
+// WithObserver sets an observer that is told about every request attempt
+// and every wait before one.
+func WithObserver(o Observer) ClientOption {
+	return func(client *Client) {
+		client.observer = o
+	}
+}
+
+// WithNotifier sets where notices of retried requests (rate limits and
+// server errors) go, e.g. a program's own log. By default they are
+// printed to stderr.
+func WithNotifier(fn func(msg string)) ClientOption {
+	return func(client *Client) {
+		client.notify = fn
+	}
+}
+
 // NewBrowserClient creates a client using browser-extracted credentials.
 // This mode can access DMs and group messages.
 func NewBrowserClient(token, cookie string, opts ...ClientOption) *Client {
@@ -72,6 +172,7 @@ func NewBrowserClient(token, cookie string, opts ...ClientOption) *Client {
 		cookie:     cookie,
 		mode:       AuthModeBrowser,
 		limiter:    NewRateLimiter(DefaultTierIntervals()),
+		maxRetries: DefaultMaxRetries,
 	}
 	for _, opt := range opts {
 		opt(c)
@@ -79,8 +180,8 @@ func NewBrowserClient(token, cookie string, opts ...ClientOption) *Client {
 	return c
 }
 
-// NewAPIClient creates a client using an API token.
-// Currently unused by get-out (all exports use browser mode).
+// NewAPIClient creates a client using an API token. Used when exports are
+// given a static user or bot token instead of browser credentials.
 func NewAPIClient(token string, opts ...ClientOption) *Client {
 	c := &Client{
 		httpClient: &http.Client{Timeout: defaultTimeout},
@@ -88,6 +189,7 @@ func NewAPIClient(token string, opts ...ClientOption) *Client {
 		token:      token,
 		mode:       AuthModeAPI,
 		limiter:    NewRateLimiter(DefaultTierIntervals()),
+		maxRetries: DefaultMaxRetries,
 	}
 	for _, opt := range opts {
 		opt(c)
@@ -100,39 +202,137 @@ func (c *Client) Mode() AuthMode {
 	return c.mode
 }
 
+// notice reports a retry to the notifier, or to stderr without one.
+func (c *Client) notice(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if c.notify != nil {
+		c.notify(msg)
+		return
+	}
+	fmt.Fprintln(os.Stderr, "  "+msg)
+}
+
 // SetDebug enables or disables debug logging for the rate limiter.
 func (c *Client) SetDebug(debug bool) {
 	c.limiter.SetDebug(debug)
 }
 
-// request makes an API request to Slack with automatic rate-limit retry.
-// The rate limiter paces requests per endpoint to avoid 429 responses.
+// request makes an API request to Slack with automatic retry. The rate
+// limiter, shared by every goroutine using the client, paces requests per
+// endpoint to avoid 429 responses. A 429 is retried after the server's
+// Retry-After, which the limiter then applies to the endpoint; a server
+// error (5xx) is retried after a jittered exponential backoff.
 func (c *Client) request(ctx context.Context, method, endpoint string, params url.Values, result interface{}) error {
-	const maxRetries = 3
-
-	for attempt := 0; attempt <= maxRetries; attempt++ {
+	reauthed := false
+	for attempt := 0; ; attempt++ {
 		// Wait for rate limit clearance
+		waitStart := time.Now()
 		if err := c.limiter.Wait(ctx, endpoint); err != nil {
 			return err
 		}
+		c.observeWait(endpoint, WaitRateLimit, time.Since(waitStart))
 
+		_, _, gen := c.credentials()
 		err := c.doRequest(ctx, method, endpoint, params, result)
+		if c.observer != nil {
+			c.observer.ObserveRequest(endpoint, err)
+		}
 		if err == nil {
 			c.limiter.RecordSuccess(endpoint)
 			return nil
 		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if authErr, ok := err.(*AuthError); ok && c.reauth != nil && !reauthed {
+			if rerr := c.renewCredentials(ctx, gen); rerr != nil {
+				return fmt.Errorf("%w (re-authentication failed: %v)", authErr, rerr)
+			}
+			reauthed = true
+			continue
+		}
+		if attempt >= c.maxRetries {
+			return err
+		}
 
-		// If rate-limited, record the backoff and let the next Wait() handle the delay
-		rle, ok := err.(*RateLimitError)
-		if !ok || attempt == maxRetries {
+		switch e := err.(type) {
+		case *RateLimitError:
+			// Record the backoff and let the next Wait() handle the delay
+			c.limiter.RecordRateLimit(endpoint, e.RetryAfter)
+			c.notice("Rate limited on %s, backing off (attempt %d/%d)", endpoint, attempt+1, c.maxRetries)
+		case *ServerError:
+			wait := backoff(serverRetryDelay, attempt)
+			c.notice("Slack server error on %s (status %d), retrying in %v (attempt %d/%d)", endpoint, e.StatusCode, wait.Round(time.Millisecond), attempt+1, c.maxRetries)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+			c.observeWait(endpoint, WaitRetry, wait)
+		default:
 			return err
 		}
+	}
+}
+
+// minObservedWait is the shortest sleep reported to the observer; shorter
+// ones are scheduling noise rather than waits.
+const minObservedWait = time.Millisecond
+
+// observeWait reports a sleep of d before a request to the observer.
+func (c *Client) observeWait(endpoint string, reason WaitReason, d time.Duration) {
+	if c.observer != nil && d >= minObservedWait {
+		c.observer.ObserveWait(endpoint, reason, d)
+	}
+}
+
+// credentials returns the client's current token and cookie, and their
+// generation.
+func (c *Client) credentials() (token, cookie string, gen int) {
+	c.credMu.RLock()
+	defer c.credMu.RUnlock()
+	return c.token, c.cookie, c.credGen
+}
+
+// renewCredentials replaces the credentials of generation gen via
+// c.reauth. When another request has already replaced them, it returns at
+// once so the caller retries with the newer ones.
+func (c *Client) renewCredentials(ctx context.Context, gen int) error {
+	c.reauthMu.Lock()
+	defer c.reauthMu.Unlock()
+	if _, _, current := c.credentials(); current != gen {
+		return nil
+	}
 
-		c.limiter.RecordRateLimit(endpoint, rle.RetryAfter)
-		fmt.Fprintf(os.Stderr, "  Rate limited on %s, backing off (attempt %d/%d)\n", endpoint, attempt+1, maxRetries)
+	token, cookie, err := c.reauth(ctx)
+	if err != nil {
+		return err
 	}
+	c.credMu.Lock()
+	c.token, c.cookie = token, cookie
+	c.credGen++
+	c.credMu.Unlock()
+	return nil
+}
 
-	return fmt.Errorf("exhausted retries for %s", endpoint)
+// setAuthHeaders sets the token, and in browser mode the cookie, on req.
+func (c *Client) setAuthHeaders(req *http.Request) {
+	token, cookie, _ := c.credentials()
+	req.Header.Set("Authorization", "Bearer "+token)
+	if c.mode == AuthModeBrowser && cookie != "" {
+		req.Header.Set("Cookie", "d="+cookie)
+	}
+}
+
+// backoff returns the wait before retry attempt+1: base doubled attempt
+// times, with up to half of it removed at random so that clients retrying
+// together spread out.
+func backoff(base time.Duration, attempt int) time.Duration {
+	d := base << attempt
+	if half := int64(d / 2); half > 0 {
+		d -= time.Duration(rand.Int64N(half))
+	}
+	return d
 }
 
 // AuthTestResponse contains the result of an auth.test API call.
@@ -162,6 +362,53 @@ func (c *Client) ValidateAuth(ctx context.Context) (*AuthTestResponse, error) {
 	return &resp, nil
 }
 
+// GetTeamInfo retrieves the current workspace's name, domain, icon,
+// enterprise, and plan from team.info.
+func (c *Client) GetTeamInfo(ctx context.Context) (*Team, error) {
+	var resp TeamInfoResponse
+	if err := c.request(ctx, "POST", MethodTeamInfo, url.Values{}, &resp); err != nil {
+		return nil, err
+	}
+
+	if !resp.OK {
+		return nil, classifyError(resp.Error, 0)
+	}
+
+	return &resp.Team, nil
+}
+
+// ListEmoji returns the workspace's custom emoji, mapping each name to an
+// image URL or to "alias:<name>" (see EmojiListResponse).
+func (c *Client) ListEmoji(ctx context.Context) (map[string]string, error) {
+	var resp EmojiListResponse
+	if err := c.request(ctx, "POST", MethodEmojiList, url.Values{}, &resp); err != nil {
+		return nil, err
+	}
+
+	if !resp.OK {
+		return nil, classifyError(resp.Error, 0)
+	}
+
+	return resp.Emoji, nil
+}
+
+// Probe calls an API method with params and reports only whether Slack
+// accepted the call; the response body is discarded. It lets TestAccess
+// check methods the client has no typed wrapper for.
+func (c *Client) Probe(ctx context.Context, method string, params url.Values) error {
+	var resp struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error,omitempty"`
+	}
+	if err := c.request(ctx, "POST", method, params, &resp); err != nil {
+		return err
+	}
+	if !resp.OK {
+		return classifyError(resp.Error, 0)
+	}
+	return nil
+}
+
 // doRequest performs a single API request to Slack.
 func (c *Client) doRequest(ctx context.Context, method, endpoint string, params url.Values, result interface{}) error {
 	u := fmt.Sprintf("%s/%s", c.baseURL, endpoint)
@@ -176,14 +423,9 @@ func (c *Client) doRequest(ctx context.Context, method, endpoint string, params
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// Set headers
+	// Set headers, with the cookie in browser mode
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("Authorization", "Bearer "+c.token)
-
-	// Add cookie for browser mode
-	if c.mode == AuthModeBrowser && c.cookie != "" {
-		req.Header.Set("Cookie", "d="+c.cookie)
-	}
+	c.setAuthHeaders(req)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -191,7 +433,10 @@ func (c *Client) doRequest(ctx context.Context, method, endpoint string, params
 	}
 	defer resp.Body.Close()
 
-	// Check for rate limiting
+	// Check for rate limiting and server errors
+	if resp.StatusCode >= 500 {
+		return &ServerError{StatusCode: resp.StatusCode}
+	}
 	if resp.StatusCode == http.StatusTooManyRequests {
 		retryAfter := 1 * time.Second
 		if ra := resp.Header.Get("Retry-After"); ra != "" {
@@ -208,11 +453,29 @@ func (c *Client) doRequest(ctx context.Context, method, endpoint string, params
 		return fmt.Errorf("failed to read response: %w", err)
 	}
 
+	if c.recorder != nil {
+		c.recorder.RecordResponse(endpoint, params, data)
+	}
+
 	// Parse response
 	if err := json.Unmarshal(data, result); err != nil {
 		return fmt.Errorf("failed to parse response: %w", err)
 	}
 
+	// Surface an expired session as an error here, rather than as a
+	// response each caller classifies, so request can reauthenticate.
+	if c.reauth != nil {
+		var status struct {
+			OK    bool   `json:"ok"`
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &status) == nil && !status.OK {
+			if err, ok := classifyError(status.Error, 0).(*AuthError); ok {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -237,6 +500,9 @@ func (c *Client) GetConversationHistory(ctx context.Context, channelID string, o
 		if opts.Inclusive {
 			params.Set("inclusive", "true")
 		}
+		if opts.IncludeAllMetadata {
+			params.Set("include_all_metadata", "true")
+		}
 	} else {
 		params.Set("limit", "100")
 	}
@@ -260,6 +526,8 @@ type HistoryOptions struct {
 	Oldest    string // Only messages after this timestamp
 	Latest    string // Only messages before this timestamp
 	Inclusive bool   // Include messages with oldest/latest timestamp
+
+	IncludeAllMetadata bool // Return app message metadata (Message.Metadata)
 }
 
 // GetConversationReplies retrieves replies to a thread.
@@ -284,6 +552,9 @@ func (c *Client) GetConversationReplies(ctx context.Context, channelID, threadTS
 		if opts.Inclusive {
 			params.Set("inclusive", "true")
 		}
+		if opts.IncludeAllMetadata {
+			params.Set("include_all_metadata", "true")
+		}
 	} else {
 		params.Set("limit", "100")
 	}
@@ -307,6 +578,8 @@ type RepliesOptions struct {
 	Oldest    string
 	Latest    string
 	Inclusive bool
+
+	IncludeAllMetadata bool // Return app message metadata (Message.Metadata)
 }
 
 // GetUserInfo retrieves information about a user.
@@ -384,6 +657,24 @@ func (c *Client) GetConversationInfo(ctx context.Context, channelID string) (*Co
 	return &resp.Channel, nil
 }
 
+// GetFileInfo retrieves a file's details, including when a canvas or post
+// was last edited and the text of a post.
+func (c *Client) GetFileInfo(ctx context.Context, fileID string) (*File, error) {
+	params := url.Values{}
+	params.Set("file", fileID)
+
+	var resp FileInfoResponse
+	if err := c.request(ctx, "POST", MethodFilesInfo, params, &resp); err != nil {
+		return nil, err
+	}
+
+	if !resp.OK {
+		return nil, classifyError(resp.Error, 0)
+	}
+
+	return &resp.File, nil
+}
+
 // ListConversations retrieves a paginated list of Slack conversations. The opts
 // parameter controls pagination cursor, conversation types, and archive
 // filtering; opts may be nil for default behavior (up to 200 results, all types).
@@ -421,6 +712,126 @@ func (c *Client) ListConversations(ctx context.Context, opts *ListConversationsO
 	return &resp, nil
 }
 
+// SearchMessages runs a search.messages query and returns one page (from
+// 1) of up to 100 matches, newest first.
+func (c *Client) SearchMessages(ctx context.Context, query string, page int) (*SearchMessagesResponse, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("sort", "timestamp")
+	params.Set("sort_dir", "desc")
+	params.Set("count", "100")
+	params.Set("page", strconv.Itoa(max(page, 1)))
+
+	var resp SearchMessagesResponse
+	if err := c.request(ctx, "POST", MethodSearchMessages, params, &resp); err != nil {
+		return nil, err
+	}
+
+	if !resp.OK {
+		return nil, classifyError(resp.Error, 0)
+	}
+
+	return &resp, nil
+}
+
+// ListReactions retrieves a page of the items userID reacted to, most
+// recent reaction first, with the full reaction lists of each message.
+func (c *Client) ListReactions(ctx context.Context, userID, cursor string) (*ItemsListResponse, error) {
+	params := url.Values{}
+	params.Set("user", userID)
+	params.Set("full", "true")
+	params.Set("limit", "100")
+	if cursor != "" {
+		params.Set("cursor", cursor)
+	}
+	return c.listItems(ctx, MethodReactionsList, params)
+}
+
+// ListSaved retrieves a page of the current user's saved items, most
+// recently saved first.
+func (c *Client) ListSaved(ctx context.Context, cursor string) (*ItemsListResponse, error) {
+	params := url.Values{}
+	params.Set("limit", "100")
+	if cursor != "" {
+		params.Set("cursor", cursor)
+	}
+	return c.listItems(ctx, MethodStarsList, params)
+}
+
+func (c *Client) listItems(ctx context.Context, method string, params url.Values) (*ItemsListResponse, error) {
+	var resp ItemsListResponse
+	if err := c.request(ctx, "POST", method, params, &resp); err != nil {
+		return nil, err
+	}
+
+	if !resp.OK {
+		return nil, classifyError(resp.Error, 0)
+	}
+
+	return &resp, nil
+}
+
+// ListScheduledMessages retrieves a page of the current user's scheduled
+// messages that have not been posted yet.
+func (c *Client) ListScheduledMessages(ctx context.Context, cursor string) (*ScheduledMessagesResponse, error) {
+	params := url.Values{}
+	params.Set("limit", "100")
+	if cursor != "" {
+		params.Set("cursor", cursor)
+	}
+
+	var resp ScheduledMessagesResponse
+	if err := c.request(ctx, "POST", MethodScheduledList, params, &resp); err != nil {
+		return nil, err
+	}
+
+	if !resp.OK {
+		return nil, classifyError(resp.Error, 0)
+	}
+
+	return &resp, nil
+}
+
+// ListReminders retrieves the reminders created by or for the current
+// user, including completed ones. reminders.list is not paginated.
+func (c *Client) ListReminders(ctx context.Context) ([]Reminder, error) {
+	var resp RemindersResponse
+	if err := c.request(ctx, "POST", MethodRemindersList, url.Values{}, &resp); err != nil {
+		return nil, err
+	}
+
+	if !resp.OK {
+		return nil, classifyError(resp.Error, 0)
+	}
+
+	return resp.Reminders, nil
+}
+
+// ListSubscribedThreads retrieves a page of the threads the current user
+// follows, most recently active first, as the Slack client's Threads view
+// lists them. subscriptions.thread.getView is an undocumented client API
+// that only accepts browser-session tokens. Pass the LatestReply of the
+// last thread of a page as before to get the next page; pass "" for the
+// first.
+func (c *Client) ListSubscribedThreads(ctx context.Context, before string) (*SubscribedThreadsResponse, error) {
+	params := url.Values{}
+	params.Set("limit", "25")
+	if before != "" {
+		params.Set("current_ts", before)
+	}
+
+	var resp SubscribedThreadsResponse
+	if err := c.request(ctx, "POST", MethodThreadsView, params, &resp); err != nil {
+		return nil, err
+	}
+
+	if !resp.OK {
+		return nil, classifyError(resp.Error, 0)
+	}
+
+	return &resp, nil
+}
+
 // ListConversationsOptions configures ListConversations.
 type ListConversationsOptions struct {
 	Cursor          string
@@ -437,33 +848,58 @@ type ListConversationsOptions struct {
 // immediately and that error is returned to the caller.
 //
 // Rate limiting and retries are handled centrally by the client's rate limiter.
+// Cancelling ctx stops pagination before the next page, and interrupts any
+// rate limit or retry wait, returning ctx.Err().
 //
 // Returns nil on success (all pages consumed and all callbacks returned nil).
 // Returns a non-nil error if any API call fails or the callback returns an error.
 func (c *Client) GetAllMessages(ctx context.Context, channelID string, oldest, latest string, callback func([]Message) error) error {
+	return c.GetAllMessagesFrom(ctx, channelID, oldest, latest, "", func(batch []Message, _ string) error {
+		return callback(batch)
+	})
+}
+
+// GetAllMessagesFrom is GetAllMessages starting at a pagination cursor, so a
+// fetch that stopped partway can continue where it left off. An empty cursor
+// starts at the newest page. A cursor is only valid with the oldest and
+// latest it was returned for.
+//
+// The callback also receives the cursor of the page after the batch, which
+// is empty after the last page.
+func (c *Client) GetAllMessagesFrom(ctx context.Context, channelID string, oldest, latest, cursor string, callback func(batch []Message, next string) error) error {
 	opts := &HistoryOptions{
-		Limit:  200,
-		Oldest: oldest,
-		Latest: latest,
+		Limit:              200,
+		Cursor:             cursor,
+		Oldest:             oldest,
+		Latest:             latest,
+		IncludeAllMetadata: true,
 	}
 
 	for {
+		// Stop between pages once cancelled, however long the callback took.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		resp, err := c.GetConversationHistory(ctx, channelID, opts)
 		if err != nil {
 			return err
 		}
 
+		next := ""
+		if resp.HasMore {
+			next = resp.ResponseMetadata.NextCursor
+		}
 		if len(resp.Messages) > 0 {
-			if err := callback(resp.Messages); err != nil {
+			if err := callback(resp.Messages, next); err != nil {
 				return err
 			}
 		}
 
-		if !resp.HasMore || resp.ResponseMetadata.NextCursor == "" {
+		if next == "" {
 			break
 		}
 
-		opts.Cursor = resp.ResponseMetadata.NextCursor
+		opts.Cursor = next
 	}
 
 	return nil
@@ -478,15 +914,22 @@ func (c *Client) GetAllMessages(ctx context.Context, channelID string, oldest, l
 // error is returned to the caller.
 //
 // Rate limiting and retries are handled centrally by the client's rate limiter.
+// Cancelling ctx stops pagination before the next page, and interrupts any
+// rate limit or retry wait, returning ctx.Err().
 //
 // Returns nil on success (all pages consumed and all callbacks returned nil).
 // Returns a non-nil error if any API call fails or the callback returns an error.
 func (c *Client) GetAllReplies(ctx context.Context, channelID, threadTS string, callback func([]Message) error) error {
 	opts := &RepliesOptions{
-		Limit: 200,
+		Limit:              200,
+		IncludeAllMetadata: true,
 	}
 
 	for {
+		// Stop between pages once cancelled, however long the callback took.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		resp, err := c.GetConversationReplies(ctx, channelID, threadTS, opts)
 		if err != nil {
 			return err
@@ -508,36 +951,83 @@ func (c *Client) GetAllReplies(ctx context.Context, channelID, threadTS string,
 	return nil
 }
 
+// MaxDownloadSize is the largest file DownloadFile accepts (50 MB), to
+// bound memory use.
+const MaxDownloadSize = 50 * 1024 * 1024
+
+// downloadRetryDelay is the base wait before the first retry of a failed
+// download; it doubles on each further retry, with jitter. A 429's
+// Retry-After takes precedence.
+var downloadRetryDelay = time.Second
+
 // DownloadFile downloads a file from the given URL using the client's
 // authentication token (and browser cookie in browser auth mode).
 //
-// Returns the file contents as a byte slice on success, capped at 50 MB.
-// Returns (nil, error) if the HTTP request cannot be created, the download
-// fails, or the server returns a non-200 status code. Errors are wrapped with
-// context describing the failure stage.
+// Rate-limited (429) and server error (5xx) responses and connection
+// failures are retried, up to the client's max retries, with jittered
+// exponential backoff. Other non-200 statuses
+// fail at once. A file larger than MaxDownloadSize fails with an error
+// wrapping ErrFileTooLarge rather than being truncated.
 func (c *Client) DownloadFile(ctx context.Context, url string) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		data, retryAfter, err := c.downloadOnce(ctx, url)
+		if err == nil || retryAfter < 0 || attempt >= c.maxRetries || ctx.Err() != nil {
+			return data, err
+		}
+
+		wait := backoff(downloadRetryDelay, attempt)
+		if retryAfter > 0 {
+			wait = retryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// downloadOnce makes a single download attempt. On failure, retryAfter is
+// negative when the error is permanent, otherwise the wait the server
+// asked for (0 when it did not say).
+func (c *Client) downloadOnce(ctx context.Context, url string) (data []byte, retryAfter time.Duration, err error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, -1, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set auth headers
-	req.Header.Set("Authorization", "Bearer "+c.token)
-	if c.mode == AuthModeBrowser && c.cookie != "" {
-		req.Header.Set("Cookie", "d="+c.cookie)
-	}
+	c.setAuthHeaders(req)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to download file: %w", err)
+		return nil, 0, fmt.Errorf("failed to download file: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to download file: status %s", resp.Status)
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+			retryAfter = time.Duration(secs) * time.Second
+		}
+		return nil, retryAfter, fmt.Errorf("failed to download file: status %s", resp.Status)
+	case resp.StatusCode >= 500:
+		return nil, 0, fmt.Errorf("failed to download file: status %s", resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		return nil, -1, fmt.Errorf("failed to download file: status %s", resp.Status)
 	}
 
-	// Cap at 50 MB to prevent unbounded memory use for large file downloads.
-	const maxFileSize = 50 * 1024 * 1024
-	return io.ReadAll(io.LimitReader(resp.Body, maxFileSize))
+	if resp.ContentLength > MaxDownloadSize {
+		return nil, -1, fmt.Errorf("failed to download file: %w (%d bytes)", ErrFileTooLarge, resp.ContentLength)
+	}
+	// Read one byte past the limit to detect oversize bodies without a
+	// Content-Length.
+	data, err = io.ReadAll(io.LimitReader(resp.Body, MaxDownloadSize+1))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to download file: %w", err)
+	}
+	if len(data) > MaxDownloadSize {
+		return nil, -1, fmt.Errorf("failed to download file: %w", ErrFileTooLarge)
+	}
+	return data, 0, nil
 }
@@ -3,10 +3,14 @@ package slackapi
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"reflect"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -254,6 +258,145 @@ func TestRequest_ExhaustsRetries(t *testing.T) {
 	// On attempt 3 (== maxRetries), the rate limit error is returned directly.
 }
 
+func TestRequest_RetriesServerErrors(t *testing.T) {
+	defer setServerRetryDelay(time.Millisecond)()
+	var callCount int32
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/conversations.history": func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&callCount, 1) <= 2 {
+				http.Error(w, "<html>upstream error</html>", http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"ok":true,"messages":[]}`))
+		},
+	})
+	defer server.Close()
+
+	var notices []string
+	client := newBrowserTestClient(server)
+	WithNotifier(func(msg string) { notices = append(notices, msg) })(client)
+	var resp HistoryResponse
+	if err := client.request(context.Background(), "POST", "conversations.history", nil, &resp); err != nil {
+		t.Fatalf("expected success after retries, got %v", err)
+	}
+	if got := atomic.LoadInt32(&callCount); got != 3 {
+		t.Errorf("expected 3 calls (2 server errors + 1 success), got %d", got)
+	}
+	if len(notices) != 2 || !strings.HasPrefix(notices[0], "Slack server error on conversations.history (status 503)") {
+		t.Errorf("notices = %q, want one per retry", notices)
+	}
+}
+
+// recordingObserver records what a client reports to its Observer.
+type recordingObserver struct {
+	mu       sync.Mutex
+	requests []string
+	waits    []WaitReason
+}
+
+func (o *recordingObserver) ObserveRequest(endpoint string, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	result := "ok"
+	if err != nil {
+		result = fmt.Sprintf("%T", err)
+	}
+	o.requests = append(o.requests, endpoint+" "+result)
+}
+
+func (o *recordingObserver) ObserveWait(_ string, reason WaitReason, _ time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.waits = append(o.waits, reason)
+}
+
+func TestRequest_ReportsToObserver(t *testing.T) {
+	defer setServerRetryDelay(5 * time.Millisecond)()
+	var callCount int32
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/conversations.history": func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&callCount, 1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"ok":true,"messages":[]}`))
+		},
+	})
+	defer server.Close()
+
+	observer := &recordingObserver{}
+	client := NewBrowserClient("test-token", "test-cookie",
+		WithBaseURL(server.URL),
+		WithHTTPClient(server.Client()),
+		WithRateLimiter(NoOpRateLimiter()),
+		WithObserver(observer),
+	)
+	var resp HistoryResponse
+	if err := client.request(context.Background(), "POST", "conversations.history", nil, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(observer.requests, "|"), "conversations.history *slackapi.ServerError|conversations.history ok"; got != want {
+		t.Errorf("requests = %q, want %q", got, want)
+	}
+	if len(observer.waits) != 1 || observer.waits[0] != WaitRetry {
+		t.Errorf("waits = %v, want one retry wait", observer.waits)
+	}
+}
+
+func TestWithMaxRetries(t *testing.T) {
+	defer setServerRetryDelay(time.Millisecond)()
+	var callCount int32
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/conversations.history": func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&callCount, 1)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	})
+	defer server.Close()
+
+	for _, tt := range []struct {
+		retries   int
+		wantCalls int32
+	}{{1, 2}, {0, 1}, {-1, 1}} {
+		atomic.StoreInt32(&callCount, 0)
+		client := NewBrowserClient("test-token", "test-cookie",
+			WithBaseURL(server.URL),
+			WithHTTPClient(server.Client()),
+			WithRateLimiter(NoOpRateLimiter()),
+			WithMaxRetries(tt.retries),
+		)
+		var resp HistoryResponse
+		err := client.request(context.Background(), "POST", "conversations.history", nil, &resp)
+		var serverErr *ServerError
+		if !errors.As(err, &serverErr) || serverErr.StatusCode != http.StatusBadGateway {
+			t.Errorf("WithMaxRetries(%d): error = %v, want a 502 ServerError", tt.retries, err)
+		}
+		if got := atomic.LoadInt32(&callCount); got != tt.wantCalls {
+			t.Errorf("WithMaxRetries(%d): calls = %d, want %d", tt.retries, got, tt.wantCalls)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := 0; attempt < 4; attempt++ {
+		full := time.Second << attempt
+		for i := 0; i < 20; i++ {
+			if got := backoff(time.Second, attempt); got <= full/2 || got > full {
+				t.Fatalf("backoff(1s, %d) = %v, want in (%v, %v]", attempt, got, full/2, full)
+			}
+		}
+	}
+}
+
+// setServerRetryDelay sets serverRetryDelay and returns a func that
+// restores it.
+func setServerRetryDelay(d time.Duration) func() {
+	old := serverRetryDelay
+	serverRetryDelay = d
+	return func() { serverRetryDelay = old }
+}
+
 func TestRequest_ContextCancellation(t *testing.T) {
 	server := newTestServer(t, map[string]http.HandlerFunc{
 		"/conversations.history": func(w http.ResponseWriter, r *http.Request) {
@@ -275,6 +418,195 @@ func TestRequest_ContextCancellation(t *testing.T) {
 	}
 }
 
+func TestRequest_CancelledDuringServerErrorBackoff(t *testing.T) {
+	defer setServerRetryDelay(10 * time.Second)()
+	ctx, cancel := context.WithCancel(context.Background())
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/conversations.history": func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			time.AfterFunc(20*time.Millisecond, cancel)
+		},
+	})
+	defer server.Close()
+
+	client := newBrowserTestClient(server)
+	start := time.Now()
+	var resp HistoryResponse
+	err := client.request(ctx, "POST", "conversations.history", nil, &resp)
+	if err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("request took %v, expected quick cancellation", elapsed)
+	}
+}
+
+func TestGetAllMessages_StopsWhenCancelled(t *testing.T) {
+	var callCount int32
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/conversations.history": func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&callCount, 1)
+			w.Write([]byte(`{"ok": true, "messages": [{"ts": "1.1"}], "has_more": true, "response_metadata": {"next_cursor": "more"}}`))
+		},
+		"/conversations.replies": func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&callCount, 1)
+			w.Write([]byte(`{"ok": true, "messages": [{"ts": "1.1"}], "has_more": true, "response_metadata": {"next_cursor": "more"}}`))
+		},
+	})
+	defer server.Close()
+
+	client := newBrowserTestClient(server)
+	for name, getAll := range map[string]func(context.Context, func([]Message) error) error{
+		"GetAllMessages": func(ctx context.Context, cb func([]Message) error) error {
+			return client.GetAllMessages(ctx, "C123", "", "", cb)
+		},
+		"GetAllReplies": func(ctx context.Context, cb func([]Message) error) error {
+			return client.GetAllReplies(ctx, "C123", "1.1", cb)
+		},
+	} {
+		atomic.StoreInt32(&callCount, 0)
+		ctx, cancel := context.WithCancel(context.Background())
+		// Cancel while the first page is being processed.
+		err := getAll(ctx, func([]Message) error {
+			cancel()
+			return nil
+		})
+		if err != context.Canceled {
+			t.Errorf("%s() = %v, want context.Canceled", name, err)
+		}
+		if got := atomic.LoadInt32(&callCount); got != 1 {
+			t.Errorf("%s() made %d requests, want 1", name, got)
+		}
+	}
+}
+
+func TestGetAllMessagesFrom_Cursor(t *testing.T) {
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/conversations.history": func(w http.ResponseWriter, r *http.Request) {
+			_ = r.ParseForm()
+			switch r.FormValue("cursor") {
+			case "":
+				w.Write([]byte(`{"ok": true, "messages": [{"ts": "3.0"}], "has_more": true, "response_metadata": {"next_cursor": "page2"}}`))
+			case "page2":
+				w.Write([]byte(`{"ok": true, "messages": [{"ts": "2.0"}], "has_more": true, "response_metadata": {"next_cursor": "page3"}}`))
+			default:
+				w.Write([]byte(`{"ok": true, "messages": [{"ts": "1.0"}], "has_more": false}`))
+			}
+		},
+	})
+	defer server.Close()
+
+	client := newBrowserTestClient(server)
+	tests := []struct {
+		cursor    string
+		wantTS    []string
+		wantNexts []string
+	}{
+		{cursor: "", wantTS: []string{"3.0", "2.0", "1.0"}, wantNexts: []string{"page2", "page3", ""}},
+		{cursor: "page2", wantTS: []string{"2.0", "1.0"}, wantNexts: []string{"page3", ""}},
+	}
+	for _, tt := range tests {
+		var gotTS, gotNexts []string
+		err := client.GetAllMessagesFrom(context.Background(), "C123", "", "", tt.cursor, func(batch []Message, next string) error {
+			for _, m := range batch {
+				gotTS = append(gotTS, m.TS)
+			}
+			gotNexts = append(gotNexts, next)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("GetAllMessagesFrom(%q) error: %v", tt.cursor, err)
+		}
+		if !reflect.DeepEqual(gotTS, tt.wantTS) || !reflect.DeepEqual(gotNexts, tt.wantNexts) {
+			t.Errorf("GetAllMessagesFrom(%q) = %v with cursors %q, want %v with %q", tt.cursor, gotTS, gotNexts, tt.wantTS, tt.wantNexts)
+		}
+	}
+}
+
+func TestWithReauth(t *testing.T) {
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/conversations.history": func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer xoxc-new" || r.Header.Get("Cookie") != "d=xoxd-new" {
+				w.Write([]byte(`{"ok": false, "error": "invalid_auth"}`))
+				return
+			}
+			w.Write([]byte(`{"ok": true, "messages": []}`))
+		},
+	})
+	defer server.Close()
+
+	var reauths int32
+	client := NewBrowserClient("xoxc-old", "xoxd-old",
+		WithBaseURL(server.URL),
+		WithHTTPClient(server.Client()),
+		WithRateLimiter(NoOpRateLimiter()),
+		WithReauth(func(context.Context) (string, string, error) {
+			atomic.AddInt32(&reauths, 1)
+			time.Sleep(10 * time.Millisecond) // let concurrent requests pile up
+			return "xoxc-new", "xoxd-new", nil
+		}),
+	)
+
+	// Concurrent requests failing with the old credentials share one
+	// reauthentication and all succeed with the new ones.
+	errs := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		go func() {
+			_, err := client.GetConversationHistory(context.Background(), "C123", nil)
+			errs <- err
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("GetConversationHistory() error after reauth: %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&reauths); n != 1 {
+		t.Errorf("reauth called %d times, want 1", n)
+	}
+}
+
+func TestWithReauth_Fails(t *testing.T) {
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/conversations.history": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"ok": false, "error": "token_revoked"}`))
+		},
+	})
+	defer server.Close()
+
+	var reauths int32
+	client := NewBrowserClient("xoxc-old", "xoxd-old",
+		WithBaseURL(server.URL),
+		WithHTTPClient(server.Client()),
+		WithRateLimiter(NoOpRateLimiter()),
+		WithReauth(func(context.Context) (string, string, error) {
+			atomic.AddInt32(&reauths, 1)
+			return "xoxc-still-bad", "xoxd-still-bad", nil
+		}),
+	)
+	_, err := client.GetConversationHistory(context.Background(), "C123", nil)
+	var authErr *AuthError
+	if !errors.As(err, &authErr) || authErr.Code != ErrCodeTokenRevoked {
+		t.Errorf("error = %v, want a token_revoked AuthError", err)
+	}
+	if n := atomic.LoadInt32(&reauths); n != 1 {
+		t.Errorf("reauth called %d times, want 1 per request", n)
+	}
+
+	client = NewBrowserClient("xoxc-old", "xoxd-old",
+		WithBaseURL(server.URL),
+		WithHTTPClient(server.Client()),
+		WithRateLimiter(NoOpRateLimiter()),
+		WithReauth(func(context.Context) (string, string, error) {
+			return "", "", errors.New("no Slack tab")
+		}),
+	)
+	_, err = client.GetConversationHistory(context.Background(), "C123", nil)
+	if !errors.As(err, &authErr) || !strings.Contains(err.Error(), "no Slack tab") {
+		t.Errorf("error = %v, want the AuthError noting the failed reauth", err)
+	}
+}
+
 // ---------- GetConversationHistory tests ----------
 
 func TestGetConversationHistory_Success(t *testing.T) {
@@ -428,6 +760,45 @@ func TestGetConversationHistory_PassesOptions(t *testing.T) {
 	}
 }
 
+func TestGetAllMessages_RequestsMetadata(t *testing.T) {
+	var gotHistory, gotReplies string
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/conversations.history": func(w http.ResponseWriter, r *http.Request) {
+			_ = r.ParseForm()
+			gotHistory = r.FormValue("include_all_metadata")
+			w.Write([]byte(`{"ok": true, "messages": [{"ts": "1.1", "text": "deploy", "metadata": {"event_type": "deploy_finished", "event_payload": {"id": 42}}}]}`))
+		},
+		"/conversations.replies": func(w http.ResponseWriter, r *http.Request) {
+			_ = r.ParseForm()
+			gotReplies = r.FormValue("include_all_metadata")
+			json.NewEncoder(w).Encode(RepliesResponse{OK: true})
+		},
+	})
+	defer server.Close()
+
+	client := newBrowserTestClient(server)
+	var msgs []Message
+	if err := client.GetAllMessages(context.Background(), "C123", "", "", func(batch []Message) error {
+		msgs = append(msgs, batch...)
+		return nil
+	}); err != nil {
+		t.Fatalf("GetAllMessages() error: %v", err)
+	}
+	if err := client.GetAllReplies(context.Background(), "C123", "1.1", func([]Message) error { return nil }); err != nil {
+		t.Fatalf("GetAllReplies() error: %v", err)
+	}
+
+	if gotHistory != "true" || gotReplies != "true" {
+		t.Errorf("include_all_metadata = %q/%q, want true/true", gotHistory, gotReplies)
+	}
+	if len(msgs) != 1 || msgs[0].Metadata == nil {
+		t.Fatalf("expected metadata to be decoded, got %+v", msgs)
+	}
+	if msgs[0].Metadata.EventType != "deploy_finished" || string(msgs[0].Metadata.EventPayload) != `{"id": 42}` {
+		t.Errorf("Metadata = %+v", msgs[0].Metadata)
+	}
+}
+
 // ---------- GetConversationReplies tests ----------
 
 func TestGetConversationReplies_Success(t *testing.T) {
@@ -1219,6 +1590,8 @@ func TestDownloadFile_Non200Status(t *testing.T) {
 }
 
 func TestDownloadFile_RequestError(t *testing.T) {
+	defer setDownloadRetryDelay(time.Millisecond)()
+
 	client := newBrowserTestClient(httptest.NewServer(http.NotFoundHandler()))
 	// Use a URL that will fail to connect
 	data, err := client.DownloadFile(context.Background(), "http://127.0.0.1:1/nonexistent")
@@ -1679,16 +2052,13 @@ func TestListConversations_ReturnValueAssertions(t *testing.T) {
 	}
 }
 
-func TestDownloadFile_SizeCap(t *testing.T) {
-	// The maxFileSize constant is 50 * 1024 * 1024 = 52428800 bytes
-	const maxFileSize = 50 * 1024 * 1024
-	// Create a response body slightly larger than the cap
-	oversizeLen := maxFileSize + 1024
+func TestDownloadFile_SizeLimit(t *testing.T) {
+	// Write a body slightly larger than the limit, without a Content-Length.
+	oversizeLen := MaxDownloadSize + 1024
 
 	server := newTestServer(t, map[string]http.HandlerFunc{
 		"/files/large": func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Content-Type", "application/octet-stream")
-			// Write oversizeLen bytes of zeros
 			buf := make([]byte, 32*1024) // write in 32KB chunks
 			written := 0
 			for written < oversizeLen {
@@ -1705,19 +2075,92 @@ func TestDownloadFile_SizeCap(t *testing.T) {
 
 	client := newBrowserTestClient(server)
 	data, err := client.DownloadFile(context.Background(), server.URL+"/files/large")
+	if !errors.Is(err, ErrFileTooLarge) {
+		t.Fatalf("expected ErrFileTooLarge, got %v", err)
+	}
+	if data != nil {
+		t.Error("expected nil data for an oversize file")
+	}
+}
+
+func TestDownloadFile_RetriesTransientErrors(t *testing.T) {
+	defer setDownloadRetryDelay(time.Millisecond)()
+
+	var calls int
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/files/download": func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			switch calls {
+			case 1:
+				w.WriteHeader(http.StatusServiceUnavailable)
+			case 2:
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+			default:
+				w.Write([]byte("file content"))
+			}
+		},
+	})
+	defer server.Close()
+
+	client := newBrowserTestClient(server)
+	data, err := client.DownloadFile(context.Background(), server.URL+"/files/download")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	if string(data) != "file content" || calls != 3 {
+		t.Errorf("got %q after %d calls, want the content after 3", data, calls)
+	}
+}
+
+func TestDownloadFile_GivesUpAfterRetries(t *testing.T) {
+	defer setDownloadRetryDelay(time.Millisecond)()
 
-	// Contract assertion: downloaded data is capped at maxFileSize
-	if len(data) > maxFileSize {
-		t.Errorf("expected data length <= %d, got %d", maxFileSize, len(data))
+	var calls int
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/files/download": func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	})
+	defer server.Close()
+
+	client := newBrowserTestClient(server)
+	if _, err := client.DownloadFile(context.Background(), server.URL+"/files/download"); err == nil {
+		t.Fatal("expected error after exhausting retries")
+	}
+	if calls != 4 {
+		t.Errorf("calls = %d, want 4 (1 + 3 retries)", calls)
+	}
+}
+
+func TestDownloadFile_NoRetryOnClientError(t *testing.T) {
+	var calls int
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/files/download": func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(http.StatusNotFound)
+		},
+	})
+	defer server.Close()
+
+	client := newBrowserTestClient(server)
+	if _, err := client.DownloadFile(context.Background(), server.URL+"/files/download"); err == nil {
+		t.Fatal("expected error for 404")
 	}
-	if len(data) != maxFileSize {
-		t.Errorf("expected exactly %d bytes (cap), got %d", maxFileSize, len(data))
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
 	}
 }
 
+// setDownloadRetryDelay sets downloadRetryDelay and returns a func that
+// restores it.
+func setDownloadRetryDelay(d time.Duration) func() {
+	old := downloadRetryDelay
+	downloadRetryDelay = d
+	return func() { downloadRetryDelay = old }
+}
+
 // ---------------------------------------------------------------------------
 // Phase 2: Parameter forwarding and branch path contract tests
 // ---------------------------------------------------------------------------
@@ -1864,3 +2307,252 @@ func TestDownloadFile_APIModeNoCookie(t *testing.T) {
 		t.Errorf("expected data='ok', got %q", string(data))
 	}
 }
+
+// captureRecorder collects recorded responses.
+type captureRecorder struct {
+	endpoints []string
+	bodies    []string
+	channels  []string
+}
+
+func (r *captureRecorder) RecordResponse(endpoint string, params url.Values, body []byte) {
+	r.endpoints = append(r.endpoints, endpoint)
+	r.bodies = append(r.bodies, string(body))
+	r.channels = append(r.channels, params.Get("channel"))
+}
+
+func TestWithResponseRecorder(t *testing.T) {
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/conversations.history": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"ok":true,"messages":[]}`))
+		},
+	})
+	defer server.Close()
+
+	rec := &captureRecorder{}
+	client := NewBrowserClient("xoxc-test", "xoxd-test",
+		WithBaseURL(server.URL),
+		WithHTTPClient(server.Client()),
+		WithRateLimiter(NoOpRateLimiter()),
+		WithResponseRecorder(rec))
+
+	if _, err := client.GetConversationHistory(context.Background(), "C123", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(rec.endpoints) != 1 || rec.endpoints[0] != "conversations.history" {
+		t.Fatalf("recorded endpoints = %v", rec.endpoints)
+	}
+	if rec.bodies[0] != `{"ok":true,"messages":[]}` || rec.channels[0] != "C123" {
+		t.Errorf("recorded = %q / %q", rec.bodies[0], rec.channels[0])
+	}
+}
+
+// ---------- activity tests ----------
+
+func TestSearchMessages_Success(t *testing.T) {
+	var gotQuery, gotSort, gotPage string
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/search.messages": func(w http.ResponseWriter, r *http.Request) {
+			_ = r.ParseForm()
+			gotQuery, gotSort, gotPage = r.FormValue("query"), r.FormValue("sort"), r.FormValue("page")
+			fmt.Fprint(w, `{"ok": true, "messages": {
+				"matches": [{"ts": "1700000000.000100", "user": "U002", "text": "hi <@U001>",
+					"channel": {"id": "C001", "name": "general"}, "permalink": "https://x.slack.com/p1"}],
+				"paging": {"page": 2, "pages": 3}}}`)
+		},
+	})
+	defer server.Close()
+
+	resp, err := newBrowserTestClient(server).SearchMessages(context.Background(), "<@U001>", 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotQuery != "<@U001>" || gotSort != "timestamp" || gotPage != "2" {
+		t.Errorf("params = query %q, sort %q, page %q", gotQuery, gotSort, gotPage)
+	}
+	if len(resp.Messages.Matches) != 1 || resp.Messages.Paging.Pages != 3 {
+		t.Fatalf("response = %+v", resp.Messages)
+	}
+	m := resp.Messages.Matches[0]
+	if m.TS != "1700000000.000100" || m.Text != "hi <@U001>" || m.Channel.ID != "C001" || m.Permalink == "" {
+		t.Errorf("match = %+v", m)
+	}
+}
+
+func TestListReactions_Success(t *testing.T) {
+	var gotUser, gotCursor string
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/reactions.list": func(w http.ResponseWriter, r *http.Request) {
+			_ = r.ParseForm()
+			gotUser, gotCursor = r.FormValue("user"), r.FormValue("cursor")
+			fmt.Fprint(w, `{"ok": true, "items": [
+				{"type": "message", "channel": "C001", "message": {"ts": "1700000000.000100", "text": "nice"}},
+				{"type": "file"}],
+				"response_metadata": {"next_cursor": "next"}}`)
+		},
+	})
+	defer server.Close()
+
+	resp, err := newBrowserTestClient(server).ListReactions(context.Background(), "U001", "abc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotUser != "U001" || gotCursor != "abc" {
+		t.Errorf("params = user %q, cursor %q", gotUser, gotCursor)
+	}
+	if len(resp.Items) != 2 || resp.Items[0].Message == nil || resp.Items[1].Message != nil {
+		t.Fatalf("items = %+v", resp.Items)
+	}
+	if resp.ResponseMetadata.NextCursor != "next" {
+		t.Errorf("next cursor = %q", resp.ResponseMetadata.NextCursor)
+	}
+}
+
+func TestListSaved_APIError(t *testing.T) {
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/stars.list": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"ok": false, "error": "missing_scope"}`)
+		},
+	})
+	defer server.Close()
+
+	_, err := newBrowserTestClient(server).ListSaved(context.Background(), "")
+	if !IsRestrictedError(err) {
+		t.Errorf("expected a restricted error, got %v", err)
+	}
+}
+
+func TestListScheduledMessages_Success(t *testing.T) {
+	var gotCursor string
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/chat.scheduledMessages.list": func(w http.ResponseWriter, r *http.Request) {
+			_ = r.ParseForm()
+			gotCursor = r.FormValue("cursor")
+			fmt.Fprint(w, `{"ok": true, "scheduled_messages": [
+				{"id": "Q001", "channel_id": "C001", "post_at": 1700000000, "date_created": 1690000000, "text": "later"}],
+				"response_metadata": {"next_cursor": ""}}`)
+		},
+	})
+	defer server.Close()
+
+	resp, err := newBrowserTestClient(server).ListScheduledMessages(context.Background(), "abc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotCursor != "abc" {
+		t.Errorf("cursor = %q", gotCursor)
+	}
+	if len(resp.ScheduledMessages) != 1 || resp.ScheduledMessages[0].PostAt != 1700000000 || resp.ScheduledMessages[0].ChannelID != "C001" {
+		t.Errorf("scheduled messages = %+v", resp.ScheduledMessages)
+	}
+}
+
+func TestListReminders(t *testing.T) {
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/reminders.list": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"ok": true, "reminders": [
+				{"id": "Rm001", "creator": "U001", "user": "U001", "text": "water plants", "recurring": false, "time": 1700000000, "complete_ts": 0},
+				{"id": "Rm002", "creator": "U001", "user": "U001", "text": "standup", "recurring": true}]}`)
+		},
+	})
+	defer server.Close()
+
+	reminders, err := newBrowserTestClient(server).ListReminders(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(reminders) != 2 || reminders[0].Time != 1700000000 || !reminders[1].Recurring {
+		t.Errorf("reminders = %+v", reminders)
+	}
+}
+
+func TestListSubscribedThreads(t *testing.T) {
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/subscriptions.thread.getView": func(w http.ResponseWriter, r *http.Request) {
+			if got := r.FormValue("current_ts"); got != "1700000100.000000" {
+				t.Errorf("current_ts = %q, want the last thread of the previous page", got)
+			}
+			fmt.Fprint(w, `{"ok": true, "has_more": true, "threads": [
+				{"root_msg": {"channel": "C001", "user": "U001", "text": "plan", "ts": "1700000000.000100", "thread_ts": "1700000000.000100", "reply_count": 3, "latest_reply": "1700000050.000200"}}]}`)
+		},
+	})
+	defer server.Close()
+
+	resp, err := newBrowserTestClient(server).ListSubscribedThreads(context.Background(), "1700000100.000000")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.HasMore || len(resp.Threads) != 1 {
+		t.Fatalf("resp = %+v, want one thread and more to come", resp)
+	}
+	root := resp.Threads[0].RootMsg
+	if root.Channel != "C001" || root.TS != "1700000000.000100" || root.ReplyCount != 3 || root.LatestReply != "1700000050.000200" {
+		t.Errorf("root = %+v", root)
+	}
+}
+
+func TestGetFileInfo(t *testing.T) {
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/files.info": func(w http.ResponseWriter, r *http.Request) {
+			if r.FormValue("file") != "F001" {
+				fmt.Fprint(w, `{"ok": false, "error": "file_not_found"}`)
+				return
+			}
+			fmt.Fprint(w, `{"ok": true, "file": {"id": "F001", "title": "Launch plan", "filetype": "quip",
+				"updated": 1700000000, "url_private_download": "https://files.slack.com/F001/download"}}`)
+		},
+	})
+	defer server.Close()
+
+	client := newBrowserTestClient(server)
+	f, err := client.GetFileInfo(context.Background(), "F001")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f.Title != "Launch plan" || f.Filetype != "quip" || f.Updated != 1700000000 {
+		t.Errorf("file = %+v", f)
+	}
+	if _, err := client.GetFileInfo(context.Background(), "F404"); err == nil {
+		t.Error("GetFileInfo(F404) = nil error, want file_not_found")
+	}
+}
+
+func TestGetTeamInfo(t *testing.T) {
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/team.info": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"ok": true, "team": {"id": "T001", "name": "Acme", "domain": "acme",
+				"email_domain": "acme.com", "enterprise_id": "E001", "enterprise_name": "Acme Corp",
+				"icon": {"image_68": "https://example.com/68.png", "image_132": "https://example.com/132.png"}}}`)
+		},
+	})
+	defer server.Close()
+
+	team, err := newBrowserTestClient(server).GetTeamInfo(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if team.ID != "T001" || team.Domain != "acme" || team.EnterpriseName != "Acme Corp" {
+		t.Errorf("team = %+v", team)
+	}
+	if got := team.Icon.LargestURL(); got != "https://example.com/132.png" {
+		t.Errorf("LargestURL() = %q, want the 132px icon", got)
+	}
+}
+
+func TestListEmoji(t *testing.T) {
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/emoji.list": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"ok": true, "emoji": {"party_parrot": "https://emoji.slack-edge.com/T001/party_parrot/abc.gif", "shipit": "alias:squirrel"}}`)
+		},
+	})
+	defer server.Close()
+
+	emoji, err := newBrowserTestClient(server).ListEmoji(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(emoji) != 2 || emoji["shipit"] != "alias:squirrel" || !strings.HasSuffix(emoji["party_parrot"], "abc.gif") {
+		t.Errorf("emoji = %v", emoji)
+	}
+}
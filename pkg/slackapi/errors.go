@@ -1,6 +1,7 @@
 package slackapi
 
 import (
+	"errors"
 	"fmt"
 	"time"
 )
@@ -32,8 +33,16 @@ const (
 	ErrCodeNotInChannel    = "not_in_channel"
 	ErrCodeThreadNotFound  = "thread_not_found"
 	ErrCodeMessageNotFound = "message_not_found"
+
+	ErrCodeNotAllowedTokenType  = "not_allowed_token_type"
+	ErrCodeRestrictedAction     = "restricted_action"
+	ErrCodeTeamAccessNotGranted = "team_access_not_granted"
+	ErrCodeEKMAccessDenied      = "ekm_access_denied"
 )
 
+// ErrFileTooLarge indicates a file download exceeded MaxDownloadSize.
+var ErrFileTooLarge = errors.New("file exceeds the download size limit")
+
 // RateLimitError indicates the API rate limit was exceeded.
 type RateLimitError struct {
 	RetryAfter time.Duration
@@ -43,6 +52,16 @@ func (e *RateLimitError) Error() string {
 	return fmt.Sprintf("rate limited, retry after %v", e.RetryAfter)
 }
 
+// ServerError indicates Slack answered with a server error (5xx) status.
+// Requests failing this way are retried with backoff.
+type ServerError struct {
+	StatusCode int
+}
+
+func (e *ServerError) Error() string {
+	return fmt.Sprintf("slack server error: status %d", e.StatusCode)
+}
+
 // AuthError indicates an authentication failure.
 type AuthError struct {
 	Code    string
@@ -113,6 +132,25 @@ func IsNotFoundError(err error) bool {
 	return false
 }
 
+// IsRestrictedError reports whether err means the workspace or token does not
+// permit the API method, as opposed to a transient or not-found failure. It
+// returns true for an *APIError anywhere in err's chain whose Code is
+// ErrCodeMissingScope, ErrCodeAccessDenied, ErrCodeNotAllowedTokenType,
+// ErrCodeRestrictedAction, ErrCodeTeamAccessNotGranted, or
+// ErrCodeEKMAccessDenied.
+func IsRestrictedError(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.Code {
+	case ErrCodeMissingScope, ErrCodeAccessDenied, ErrCodeNotAllowedTokenType,
+		ErrCodeRestrictedAction, ErrCodeTeamAccessNotGranted, ErrCodeEKMAccessDenied:
+		return true
+	}
+	return false
+}
+
 // classifyError converts a Slack API error code to a typed error.
 func classifyError(code string, retryAfter time.Duration) error {
 	switch code {
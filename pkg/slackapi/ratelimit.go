@@ -71,10 +71,14 @@ func (rl *RateLimiter) getOrCreate(endpoint string) *endpointState {
 // immediately without blocking. If debug mode is enabled, wait durations are
 // logged to stderr.
 //
-// Returns nil on success. Returns ctx.Err() if the context is cancelled while
-// waiting. The endpoint state is updated regardless of whether the wait
-// completes or is cancelled.
+// Returns nil on success. Returns ctx.Err() at once, without reserving a slot,
+// if the context is already cancelled, and as soon as it is cancelled while
+// waiting; a slot reserved before the cancellation stays reserved.
 func (rl *RateLimiter) Wait(ctx context.Context, endpoint string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	rl.mu.Lock()
 	s := rl.getOrCreate(endpoint)
 	elapsed := time.Since(s.lastRequest)
@@ -92,6 +92,27 @@ func TestRateLimiter_Wait_ContextCancelled(t *testing.T) {
 }
 
 // 2.4 — RecordRateLimit behavior
+func TestRateLimiter_Wait_AlreadyCancelled(t *testing.T) {
+	rl := NewRateLimiter(map[string]time.Duration{
+		"blocked": 5 * time.Second,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := rl.Wait(ctx, "blocked"); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	// The cancelled call reserved no slot, so the next caller is not delayed.
+	start := time.Now()
+	if err := rl.Wait(context.Background(), "blocked"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("wait took %v after a cancelled call, expected immediate", elapsed)
+	}
+}
+
 func TestRateLimiter_RecordRateLimit_DoublesInterval(t *testing.T) {
 	rl := testLimiter()
 
@@ -2,7 +2,10 @@
 // browser-based (xoxc) and bot (xoxb) authentication modes.
 package slackapi
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // Message represents a Slack message from the API.
 type Message struct {
@@ -15,11 +18,49 @@ type Message struct {
 	ReplyUsers  []string     `json:"reply_users,omitempty"`
 	Reactions   []Reaction   `json:"reactions,omitempty"`
 	Attachments []Attachment `json:"attachments,omitempty"`
+	Blocks      []Block      `json:"blocks,omitempty"`
 	Files       []File       `json:"files,omitempty"`
 	Edited      *Edited      `json:"edited,omitempty"`
 	BotID       string       `json:"bot_id,omitempty"`
 	Username    string       `json:"username,omitempty"`
 	Subtype     string       `json:"subtype,omitempty"`
+
+	// Metadata is structured app metadata attached via chat.postMessage.
+	// Only returned when history/replies are requested with include_all_metadata.
+	Metadata *MessageMetadata `json:"metadata,omitempty"`
+
+	// Raw is the message's JSON as Slack returned it, fields this type
+	// does not model included. It is set for messages decoded from a
+	// MessageList, such as those of conversations.history and
+	// conversations.replies, and never encoded.
+	Raw json.RawMessage `json:"-"`
+}
+
+// MessageList is a list of messages that keeps each one's JSON in its
+// Raw field when decoded.
+type MessageList []Message
+
+// UnmarshalJSON decodes a JSON array of messages.
+func (l *MessageList) UnmarshalJSON(data []byte) error {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return err
+	}
+	msgs := make(MessageList, len(raws))
+	for i, raw := range raws {
+		if err := json.Unmarshal(raw, &msgs[i]); err != nil {
+			return err
+		}
+		msgs[i].Raw = raw
+	}
+	*l = msgs
+	return nil
+}
+
+// MessageMetadata is the app-defined event payload carried by a message.
+type MessageMetadata struct {
+	EventType    string          `json:"event_type"`
+	EventPayload json.RawMessage `json:"event_payload,omitempty"`
 }
 
 // Reaction represents an emoji reaction on a message.
@@ -55,6 +96,122 @@ type Attachment struct {
 	ChannelTeam   string `json:"channel_team,omitempty"`
 }
 
+// Block is a Block Kit layout block. Bot and workflow messages often carry
+// their content in blocks, leaving Text as a short fallback. Only the fields
+// of the block types get-out renders are decoded: header, section, context,
+// divider, image, actions, markdown and rich_text.
+type Block struct {
+	Type    string `json:"type"`
+	BlockID string `json:"block_id,omitempty"`
+	// Text is the text of a header, section or markdown block.
+	Text *TextObject `json:"text,omitempty"`
+	// Fields are the two-column texts of a section block.
+	Fields []*TextObject `json:"fields,omitempty"`
+	// Accessory is the element shown beside a section block's text.
+	Accessory *BlockElement `json:"accessory,omitempty"`
+	// Elements are the texts and images of a context block, the buttons
+	// of an actions block, and the sections, lists, quotes and
+	// preformatted text of a rich_text block.
+	Elements []BlockElement `json:"elements,omitempty"`
+	// ImageURL, AltText and Title describe an image block.
+	ImageURL string      `json:"image_url,omitempty"`
+	AltText  string      `json:"alt_text,omitempty"`
+	Title    *TextObject `json:"title,omitempty"`
+}
+
+// BlockElement is an element of a block: a text object or image in a
+// context block, an interactive element such as a button, or a rich text
+// element. Rich text sections, lists, quotes and preformatted text nest
+// further elements.
+type BlockElement struct {
+	Type string `json:"type"`
+	// Text is the text of a text object, button or rich text "text" or
+	// "link" element.
+	Text     *TextObject    `json:"text,omitempty"`
+	Elements []BlockElement `json:"elements,omitempty"`
+	// Style is a rich text list's "bullet" or "ordered", a button's
+	// "primary" or "danger", or a rich text element's formatting.
+	Style  *ElementStyle `json:"style,omitempty"`
+	Indent int           `json:"indent,omitempty"`
+	Offset int           `json:"offset,omitempty"`
+
+	URL         string `json:"url,omitempty"`
+	UserID      string `json:"user_id,omitempty"`
+	ChannelID   string `json:"channel_id,omitempty"`
+	UsergroupID string `json:"usergroup_id,omitempty"`
+	Range       string `json:"range,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Unicode     string `json:"unicode,omitempty"`
+	Value       string `json:"value,omitempty"`
+	Timestamp   int64  `json:"timestamp,omitempty"`
+	Format      string `json:"format,omitempty"`
+	Fallback    string `json:"fallback,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+	AltText     string `json:"alt_text,omitempty"`
+}
+
+// TextObject is a Block Kit text object. Rich text and context elements
+// carry their text as a bare string, which decodes to a TextObject with
+// only Text set and encodes back to a string.
+type TextObject struct {
+	Type     string `json:"type"`
+	Text     string `json:"text"`
+	Emoji    bool   `json:"emoji,omitempty"`
+	Verbatim bool   `json:"verbatim,omitempty"`
+
+	bare bool
+}
+
+// textObject has TextObject's fields without its JSON methods.
+type textObject TextObject
+
+// UnmarshalJSON decodes a text object or a bare string.
+func (t *TextObject) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		*t = TextObject{bare: true}
+		return json.Unmarshal(data, &t.Text)
+	}
+	return json.Unmarshal(data, (*textObject)(t))
+}
+
+// MarshalJSON encodes t in the form it was decoded from.
+func (t TextObject) MarshalJSON() ([]byte, error) {
+	if t.bare {
+		return json.Marshal(t.Text)
+	}
+	return json.Marshal(textObject(t))
+}
+
+// ElementStyle is a block element's style: a name such as "bullet" or
+// "primary", or rich text formatting.
+type ElementStyle struct {
+	Name   string `json:"-"`
+	Bold   bool   `json:"bold,omitempty"`
+	Italic bool   `json:"italic,omitempty"`
+	Strike bool   `json:"strike,omitempty"`
+	Code   bool   `json:"code,omitempty"`
+}
+
+// elementStyle has ElementStyle's fields without its JSON methods.
+type elementStyle ElementStyle
+
+// UnmarshalJSON decodes a style name or a formatting object.
+func (s *ElementStyle) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		*s = ElementStyle{}
+		return json.Unmarshal(data, &s.Name)
+	}
+	return json.Unmarshal(data, (*elementStyle)(s))
+}
+
+// MarshalJSON encodes s in the form it was decoded from.
+func (s ElementStyle) MarshalJSON() ([]byte, error) {
+	if s.Name != "" {
+		return json.Marshal(s.Name)
+	}
+	return json.Marshal(elementStyle(s))
+}
+
 // File represents an uploaded file.
 type File struct {
 	ID                 string `json:"id"`
@@ -75,6 +232,11 @@ type File struct {
 	URLPrivateDownload string `json:"url_private_download"`
 	Permalink          string `json:"permalink"`
 	PermalinkPublic    string `json:"permalink_public"`
+
+	// Updated is when a canvas or post was last edited, and PlainText the
+	// text of a post, as files.info reports them.
+	Updated   int64  `json:"updated,omitempty"`
+	PlainText string `json:"plain_text,omitempty"`
 }
 
 // Edited contains information about message edits.
@@ -185,7 +347,7 @@ type ResponseMetadata struct {
 type HistoryResponse struct {
 	OK               bool             `json:"ok"`
 	Error            string           `json:"error,omitempty"`
-	Messages         []Message        `json:"messages"`
+	Messages         MessageList      `json:"messages"`
 	HasMore          bool             `json:"has_more"`
 	ResponseMetadata ResponseMetadata `json:"response_metadata"`
 }
@@ -194,7 +356,7 @@ type HistoryResponse struct {
 type RepliesResponse struct {
 	OK               bool             `json:"ok"`
 	Error            string           `json:"error,omitempty"`
-	Messages         []Message        `json:"messages"`
+	Messages         MessageList      `json:"messages"`
 	HasMore          bool             `json:"has_more"`
 	ResponseMetadata ResponseMetadata `json:"response_metadata"`
 }
@@ -270,3 +432,168 @@ func parseInt(s string) int64 {
 	}
 	return n
 }
+
+// SearchMessagesResponse is the response from search.messages.
+type SearchMessagesResponse struct {
+	OK       bool   `json:"ok"`
+	Error    string `json:"error,omitempty"`
+	Messages struct {
+		Matches []SearchMatch `json:"matches"`
+		Paging  Paging        `json:"paging"`
+	} `json:"messages"`
+}
+
+// SearchMatch is one message found by search.messages, with the
+// conversation it was posted in.
+type SearchMatch struct {
+	Message
+	Channel   SearchChannel `json:"channel"`
+	Permalink string        `json:"permalink,omitempty"`
+}
+
+// SearchChannel identifies the conversation of a search match.
+type SearchChannel struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Paging is the page-number pagination used by search.messages.
+type Paging struct {
+	Page  int `json:"page"`
+	Pages int `json:"pages"`
+}
+
+// ItemsListResponse is the response from reactions.list and stars.list.
+type ItemsListResponse struct {
+	OK               bool             `json:"ok"`
+	Error            string           `json:"error,omitempty"`
+	Items            []ListedItem     `json:"items"`
+	ResponseMetadata ResponseMetadata `json:"response_metadata"`
+}
+
+// ListedItem is an item of reactions.list or stars.list. Only items of
+// type "message" carry a Message; files and other items are left empty.
+type ListedItem struct {
+	Type    string   `json:"type"`
+	Channel string   `json:"channel,omitempty"`
+	Message *Message `json:"message,omitempty"`
+}
+
+// EmojiListResponse is the response from emoji.list: the workspace's
+// custom emoji by name. A value is an image URL, or "alias:<name>" for an
+// alias of another emoji, custom or standard.
+type EmojiListResponse struct {
+	OK    bool              `json:"ok"`
+	Error string            `json:"error,omitempty"`
+	Emoji map[string]string `json:"emoji"`
+}
+
+// TeamInfoResponse is the response from team.info.
+type TeamInfoResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+	Team  Team   `json:"team"`
+}
+
+// Team describes a Slack workspace. The Enterprise fields are set for
+// workspaces in an Enterprise Grid organization; Plan is only returned to
+// some tokens.
+type Team struct {
+	ID               string   `json:"id"`
+	Name             string   `json:"name"`
+	URL              string   `json:"url,omitempty"`
+	Domain           string   `json:"domain"`
+	EmailDomain      string   `json:"email_domain,omitempty"`
+	Icon             TeamIcon `json:"icon"`
+	EnterpriseID     string   `json:"enterprise_id,omitempty"`
+	EnterpriseName   string   `json:"enterprise_name,omitempty"`
+	EnterpriseDomain string   `json:"enterprise_domain,omitempty"`
+	Plan             string   `json:"plan,omitempty"`
+}
+
+// TeamIcon holds the workspace icon URLs. ImageDefault is true when the
+// workspace has not uploaded an icon.
+type TeamIcon struct {
+	Image68       string `json:"image_68,omitempty"`
+	Image132      string `json:"image_132,omitempty"`
+	Image230      string `json:"image_230,omitempty"`
+	ImageOriginal string `json:"image_original,omitempty"`
+	ImageDefault  bool   `json:"image_default,omitempty"`
+}
+
+// LargestURL returns the largest icon available, or "".
+func (i TeamIcon) LargestURL() string {
+	for _, u := range []string{i.ImageOriginal, i.Image230, i.Image132, i.Image68} {
+		if u != "" {
+			return u
+		}
+	}
+	return ""
+}
+
+// ScheduledMessagesResponse is the response from chat.scheduledMessages.list.
+type ScheduledMessagesResponse struct {
+	OK                bool               `json:"ok"`
+	Error             string             `json:"error,omitempty"`
+	ScheduledMessages []ScheduledMessage `json:"scheduled_messages"`
+	ResponseMetadata  ResponseMetadata   `json:"response_metadata"`
+}
+
+// ScheduledMessage is a message the current user scheduled but Slack has
+// not posted yet.
+type ScheduledMessage struct {
+	ID          string `json:"id"`
+	ChannelID   string `json:"channel_id"`
+	PostAt      int64  `json:"post_at"`
+	DateCreated int64  `json:"date_created"`
+	Text        string `json:"text"`
+}
+
+// RemindersResponse is the response from reminders.list.
+type RemindersResponse struct {
+	OK        bool       `json:"ok"`
+	Error     string     `json:"error,omitempty"`
+	Reminders []Reminder `json:"reminders"`
+}
+
+// Reminder is a reminder created by or for the current user. Time is unset
+// for recurring reminders, and CompleteTS is set once a one-off reminder is
+// marked complete.
+type Reminder struct {
+	ID         string `json:"id"`
+	Creator    string `json:"creator"`
+	User       string `json:"user"`
+	Text       string `json:"text"`
+	Recurring  bool   `json:"recurring"`
+	Time       int64  `json:"time,omitempty"`
+	CompleteTS int64  `json:"complete_ts,omitempty"`
+}
+
+// SubscribedThreadsResponse is the response from
+// subscriptions.thread.getView.
+type SubscribedThreadsResponse struct {
+	OK      bool               `json:"ok"`
+	Error   string             `json:"error,omitempty"`
+	Threads []SubscribedThread `json:"threads"`
+	HasMore bool               `json:"has_more"`
+}
+
+// SubscribedThread is a thread the current user follows.
+type SubscribedThread struct {
+	RootMsg ThreadRoot `json:"root_msg"`
+}
+
+// ThreadRoot is the parent message of a followed thread, with the
+// conversation it was posted in and the timestamp of its latest reply.
+type ThreadRoot struct {
+	Message
+	Channel     string `json:"channel"`
+	LatestReply string `json:"latest_reply,omitempty"`
+}
+
+// FileInfoResponse is the response from files.info.
+type FileInfoResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+	File  File   `json:"file"`
+}
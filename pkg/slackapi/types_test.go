@@ -1,6 +1,8 @@
 package slackapi
 
 import (
+	"bytes"
+	"encoding/json"
 	"testing"
 	"time"
 )
@@ -230,3 +232,48 @@ func TestTSToTime_MalformedInput(t *testing.T) {
 		t.Errorf("TSToTime(\"not.a.number\").Unix() = %d, want 0", got.Unix())
 	}
 }
+
+func TestMessageBlocks_RoundTrip(t *testing.T) {
+	data := `{"type":"message","user":"","text":"fallback","ts":"1.000100","blocks":[` +
+		`{"type":"section","text":{"type":"mrkdwn","text":"*hi*"},"accessory":{"type":"button","text":{"type":"plain_text","text":"Go"},"style":"primary","url":"https://example.com"}},` +
+		`{"type":"rich_text","elements":[{"type":"rich_text_list","elements":[{"type":"rich_text_section","elements":[{"type":"text","text":"item","style":{"bold":true}}]}],"style":"bullet","indent":1}]}]}`
+	var msg Message
+	if err := json.Unmarshal([]byte(data), &msg); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	if got := msg.Blocks[0].Accessory.Style.Name; got != "primary" {
+		t.Errorf("button style = %q, want primary", got)
+	}
+	list := msg.Blocks[1].Elements[0]
+	if list.Style.Name != "bullet" || list.Indent != 1 {
+		t.Errorf("list = %+v, want an indented bullet list", list)
+	}
+	if text := list.Elements[0].Elements[0]; text.Text.Text != "item" || !text.Style.Bold {
+		t.Errorf("text element = %+v, want bold \"item\"", text)
+	}
+
+	out, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+	if string(out) != data {
+		t.Errorf("Marshal() =\n%s\nwant\n%s", out, data)
+	}
+}
+
+func TestMessageList_KeepsRawJSON(t *testing.T) {
+	data := []byte(`{"ok":true,"messages":[{"type":"message","ts":"1.000001","text":"hi","client_msg_id":"abc"}]}`)
+	var resp HistoryResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	if len(resp.Messages) != 1 || resp.Messages[0].Text != "hi" {
+		t.Fatalf("Messages = %+v", resp.Messages)
+	}
+	if got := string(resp.Messages[0].Raw); got != `{"type":"message","ts":"1.000001","text":"hi","client_msg_id":"abc"}` {
+		t.Errorf("Raw = %s, want the message as sent, unmodeled fields included", got)
+	}
+	if out, _ := json.Marshal(resp.Messages[0]); bytes.Contains(out, []byte("client_msg_id")) {
+		t.Errorf("Marshal() = %s, Raw should not be encoded", out)
+	}
+}
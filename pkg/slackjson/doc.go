@@ -0,0 +1,7 @@
+// Package slackjson reads and writes Slack's workspace export format: the
+// channels.json, groups.json, mpims.json, dms.json, and users.json index
+// files at the root of the export, and one directory per conversation
+// holding a <date>.json file of messages per day. Archives in this format
+// can be browsed with slack-export-viewer or imported into another
+// workspace.
+package slackjson
@@ -0,0 +1,352 @@
+package slackjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jflowers/get-out/pkg/models"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+// Index files at the root of a Slack export.
+const (
+	UsersFile    = "users.json"
+	ChannelsFile = "channels.json"
+	GroupsFile   = "groups.json"
+	MPIMsFile    = "mpims.json"
+	DMsFile      = "dms.json"
+)
+
+// Kind is the kind of conversation, which decides the index file that
+// lists it and how its directory is named.
+type Kind string
+
+const (
+	KindChannel Kind = "channel" // public channel, in channels.json
+	KindGroup   Kind = "group"   // private channel, in groups.json
+	KindMPIM    Kind = "mpim"    // group DM, in mpims.json
+	KindDM      Kind = "dm"      // DM, in dms.json
+)
+
+// KindOf returns the kind of a conversation of type t.
+func KindOf(t models.ConversationType) Kind {
+	switch t {
+	case models.ConversationTypePrivateChannel:
+		return KindGroup
+	case models.ConversationTypeMPIM:
+		return KindMPIM
+	case models.ConversationTypeDM:
+		return KindDM
+	}
+	return KindChannel
+}
+
+// File returns the index file that lists conversations of kind k.
+func (k Kind) File() string {
+	switch k {
+	case KindGroup:
+		return GroupsFile
+	case KindMPIM:
+		return MPIMsFile
+	case KindDM:
+		return DMsFile
+	}
+	return ChannelsFile
+}
+
+// Channel is an entry of channels.json, groups.json, or mpims.json.
+type Channel struct {
+	ID         string           `json:"id"`
+	Name       string           `json:"name"`
+	Created    int64            `json:"created"`
+	Creator    string           `json:"creator"`
+	IsArchived bool             `json:"is_archived"`
+	IsGeneral  bool             `json:"is_general"`
+	IsPrivate  bool             `json:"is_private"`
+	Members    []string         `json:"members"`
+	Topic      slackapi.Topic   `json:"topic"`
+	Purpose    slackapi.Purpose `json:"purpose"`
+}
+
+// DM is an entry of dms.json. DMs have no name; their directory is named
+// after the ID.
+type DM struct {
+	ID      string   `json:"id"`
+	Created int64    `json:"created"`
+	Members []string `json:"members"`
+}
+
+// DirName returns the name of a conversation's directory: the channel
+// name, as in Slack's export, or the ID for DMs and conversations without
+// a usable name.
+func DirName(k Kind, id, name string) string {
+	if k == KindDM {
+		return id
+	}
+	name = strings.NewReplacer("/", "-", `\`, "-").Replace(strings.TrimSpace(name))
+	if strings.Trim(name, ".") == "" {
+		return id
+	}
+	return name
+}
+
+// ReadDay reads the messages of the day file {dir}/{date}.json, or none
+// when it does not exist.
+func ReadDay(dir, date string) ([]slackapi.Message, error) {
+	data, err := os.ReadFile(filepath.Join(dir, date+".json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s.json: %w", date, err)
+	}
+	var msgs []slackapi.Message
+	if err := json.Unmarshal(data, &msgs); err != nil {
+		return nil, fmt.Errorf("failed to parse %s.json: %w", date, err)
+	}
+	return msgs, nil
+}
+
+// WriteDay merges msgs into the day file {dir}/{date}.json, creating dir
+// when needed, and returns the day's messages.
+func WriteDay(dir, date string, msgs []slackapi.Message) ([]slackapi.Message, error) {
+	return WriteDayAs(dir, date, msgs, nil)
+}
+
+// WriteDayAs is WriteDay, but the day file holds what encode makes of the
+// merged messages, such as the messages with fields of their own added.
+// ReadDay still reads Slack's fields back. A nil encode writes the
+// messages as they are.
+func WriteDayAs(dir, date string, msgs []slackapi.Message, encode func([]slackapi.Message) interface{}) ([]slackapi.Message, error) {
+	existing, err := ReadDay(dir, date)
+	if err != nil {
+		return nil, err
+	}
+	merged := MergeMessages(existing, msgs)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	var v interface{} = merged
+	if encode != nil {
+		v = encode(merged)
+	}
+	if err := WriteJSON(dir, date+".json", v); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// MergeMessages returns the union of existing and added, keyed by
+// timestamp with added taking precedence, sorted oldest first.
+func MergeMessages(existing, added []slackapi.Message) []slackapi.Message {
+	byTS := make(map[string]slackapi.Message, len(existing)+len(added))
+	for _, m := range existing {
+		byTS[m.TS] = m
+	}
+	for _, m := range added {
+		byTS[m.TS] = m
+	}
+	merged := make([]slackapi.Message, 0, len(byTS))
+	for _, m := range byTS {
+		merged = append(merged, m)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].TS < merged[j].TS
+	})
+	return merged
+}
+
+// Index holds the conversation index files of an export.
+type Index struct {
+	Channels []Channel
+	Groups   []Channel
+	MPIMs    []Channel
+	DMs      []DM
+}
+
+// ReadIndex reads the conversation index files at root. Missing files
+// read as empty.
+func ReadIndex(root string) (*Index, error) {
+	x := &Index{}
+	for _, f := range []struct {
+		name string
+		v    interface{}
+	}{
+		{ChannelsFile, &x.Channels},
+		{GroupsFile, &x.Groups},
+		{MPIMsFile, &x.MPIMs},
+		{DMsFile, &x.DMs},
+	} {
+		data, err := os.ReadFile(filepath.Join(root, f.name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", f.name, err)
+		}
+		if err := json.Unmarshal(data, f.v); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", f.name, err)
+		}
+	}
+	return x, nil
+}
+
+// Upsert adds ch to the index file of kind k, or updates the entry with
+// its ID. Members accumulate; the other fields are replaced when ch sets
+// them. For DMs only the ID, creation time, and members are kept.
+func (x *Index) Upsert(k Kind, ch Channel) {
+	if k == KindDM {
+		for i := range x.DMs {
+			if x.DMs[i].ID == ch.ID {
+				x.DMs[i].Members = mergeMembers(x.DMs[i].Members, ch.Members)
+				if ch.Created != 0 {
+					x.DMs[i].Created = ch.Created
+				}
+				return
+			}
+		}
+		x.DMs = append(x.DMs, DM{ID: ch.ID, Created: ch.Created, Members: mergeMembers(nil, ch.Members)})
+		return
+	}
+
+	list := x.list(k)
+	for i := range *list {
+		c := &(*list)[i]
+		if c.ID != ch.ID {
+			continue
+		}
+		members := mergeMembers(c.Members, ch.Members)
+		if ch.Name != "" {
+			c.Name = ch.Name
+		}
+		if ch.Created != 0 {
+			c.Created, c.Creator = ch.Created, ch.Creator
+		}
+		if ch.Topic.Value != "" {
+			c.Topic = ch.Topic
+		}
+		if ch.Purpose.Value != "" {
+			c.Purpose = ch.Purpose
+		}
+		c.IsArchived, c.IsGeneral, c.IsPrivate = ch.IsArchived, ch.IsGeneral, ch.IsPrivate
+		c.Members = members
+		return
+	}
+	ch.Members = mergeMembers(nil, ch.Members)
+	*list = append(*list, ch)
+}
+
+// list returns the channel list of kind k, which must not be KindDM.
+func (x *Index) list(k Kind) *[]Channel {
+	switch k {
+	case KindGroup:
+		return &x.Groups
+	case KindMPIM:
+		return &x.MPIMs
+	}
+	return &x.Channels
+}
+
+// Write writes every conversation index file to root, sorted by name (by
+// ID for DMs), including empty ones, which Slack's export also contains.
+func (x *Index) Write(root string) error {
+	for _, list := range []*[]Channel{&x.Channels, &x.Groups, &x.MPIMs} {
+		sort.Slice(*list, func(i, j int) bool {
+			return (*list)[i].Name < (*list)[j].Name
+		})
+	}
+	sort.Slice(x.DMs, func(i, j int) bool {
+		return x.DMs[i].ID < x.DMs[j].ID
+	})
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", root, err)
+	}
+	for _, f := range []struct {
+		name string
+		v    interface{}
+	}{
+		{ChannelsFile, nonNil(x.Channels)},
+		{GroupsFile, nonNil(x.Groups)},
+		{MPIMsFile, nonNil(x.MPIMs)},
+		{DMsFile, nonNilDMs(x.DMs)},
+	} {
+		if err := WriteJSON(root, f.name, f.v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteUsers writes users.json to root.
+func WriteUsers(root string, users []*slackapi.User) error {
+	if users == nil {
+		users = []*slackapi.User{}
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", root, err)
+	}
+	return WriteJSON(root, UsersFile, users)
+}
+
+// WriteJSON writes v as indented JSON to dir/name, through a temporary
+// file so readers never see a partial file.
+func WriteJSON(dir, name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", name, err)
+	}
+	tmp, err := os.CreateTemp(dir, "."+name+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	// CreateTemp uses 0600; export files are readable like any other.
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, name)); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// mergeMembers returns the sorted union of a and b, never nil.
+func mergeMembers(a, b []string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	members := []string{}
+	for _, list := range [][]string{a, b} {
+		for _, m := range list {
+			if m != "" && !seen[m] {
+				seen[m] = true
+				members = append(members, m)
+			}
+		}
+	}
+	sort.Strings(members)
+	return members
+}
+
+func nonNil(list []Channel) []Channel {
+	if list == nil {
+		return []Channel{}
+	}
+	return list
+}
+
+func nonNilDMs(list []DM) []DM {
+	if list == nil {
+		return []DM{}
+	}
+	return list
+}
@@ -0,0 +1,105 @@
+package slackjson
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jflowers/get-out/pkg/models"
+	"github.com/jflowers/get-out/pkg/slackapi"
+)
+
+func TestDirName(t *testing.T) {
+	tests := []struct {
+		kind     Kind
+		id, name string
+		want     string
+	}{
+		{KindChannel, "C001", "general", "general"},
+		{KindGroup, "G001", "secret", "secret"},
+		{KindMPIM, "G002", "mpdm-alice--bob-1", "mpdm-alice--bob-1"},
+		{KindDM, "D001", "alice", "D001"},
+		{KindChannel, "C002", "a/b", "a-b"},
+		{KindChannel, "C003", "..", "C003"},
+		{KindChannel, "C004", "", "C004"},
+	}
+	for _, tt := range tests {
+		if got := DirName(tt.kind, tt.id, tt.name); got != tt.want {
+			t.Errorf("DirName(%s, %s, %q) = %q, want %q", tt.kind, tt.id, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestKindOf(t *testing.T) {
+	if k := KindOf(models.ConversationTypePrivateChannel); k != KindGroup || k.File() != GroupsFile {
+		t.Errorf("private channel = %s (%s), want groups.json", k, k.File())
+	}
+	if k := KindOf(models.ConversationTypeDM); k.File() != DMsFile {
+		t.Errorf("DM file = %s, want dms.json", k.File())
+	}
+}
+
+func TestWriteDay_Merges(t *testing.T) {
+	dir := t.TempDir() + "/general"
+	if _, err := WriteDay(dir, "2024-02-01", []slackapi.Message{{TS: "2.0", Text: "old"}, {TS: "1.0", Text: "first"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WriteDay(dir, "2024-02-01", []slackapi.Message{{TS: "2.0", Text: "edited"}, {TS: "3.0", Text: "new"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadDay(dir, "2024-02-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	for _, m := range got {
+		texts = append(texts, m.Text)
+	}
+	if want := "first|edited|new"; strings.Join(texts, "|") != want {
+		t.Errorf("day = %q, want %q", texts, want)
+	}
+}
+
+func TestReadDay_Missing(t *testing.T) {
+	msgs, err := ReadDay(t.TempDir(), "2024-02-01")
+	if err != nil || msgs != nil {
+		t.Errorf("ReadDay() = %v, %v; want nil, nil", msgs, err)
+	}
+}
+
+func TestIndex_UpsertAndWrite(t *testing.T) {
+	root := t.TempDir()
+	x, err := ReadIndex(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.Upsert(KindChannel, Channel{ID: "C001", Name: "general", Members: []string{"U002"}})
+	x.Upsert(KindDM, Channel{ID: "D001", Name: "alice", Members: []string{"U001"}})
+	if err := x.Write(root); err != nil {
+		t.Fatal(err)
+	}
+
+	x, err = ReadIndex(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.Upsert(KindChannel, Channel{ID: "C001", Name: "general-renamed", Members: []string{"U001", "U002"}})
+	x.Upsert(KindDM, Channel{ID: "D001", Members: []string{"U003", ""}})
+	if err := x.Write(root); err != nil {
+		t.Fatal(err)
+	}
+
+	x, err = ReadIndex(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(x.Channels) != 1 || x.Channels[0].Name != "general-renamed" || strings.Join(x.Channels[0].Members, ",") != "U001,U002" {
+		t.Errorf("channels = %+v", x.Channels)
+	}
+	if len(x.DMs) != 1 || strings.Join(x.DMs[0].Members, ",") != "U001,U003" {
+		t.Errorf("dms = %+v", x.DMs)
+	}
+	if x.Groups == nil || x.MPIMs == nil {
+		t.Error("empty index files should be written as empty arrays")
+	}
+}
@@ -0,0 +1,312 @@
+// Package sqlitearchive writes exported conversations into a SQLite
+// database: tables of conversations, users, messages, reactions, and
+// files, and an FTS5 full-text index of the messages' rendered text, so a
+// multi-year export can be searched and queried with SQL instead of
+// re-parsing its files.
+//
+// The database is written through the sqlite3 command-line tool, which
+// must be on the PATH and built with FTS5 (as the builds shipped by macOS,
+// Homebrew, and most Linux distributions are), so get-out itself needs no
+// cgo or SQLite driver.
+package sqlitearchive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBinary is the sqlite3 command Open looks for on the PATH.
+const DefaultBinary = "sqlite3"
+
+// SchemaVersion is stored in the database's user_version.
+const SchemaVersion = 1
+
+// ErrNoSQLite is returned by Open when the sqlite3 command cannot be found.
+var ErrNoSQLite = errors.New("the sqlite format needs the sqlite3 command-line tool on the PATH")
+
+// schema creates the tables, the full-text index, and the triggers that
+// keep the index in step with the messages table.
+const schema = `
+CREATE TABLE IF NOT EXISTS conversations (
+	id   TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	type TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS users (
+	id           TEXT PRIMARY KEY,
+	name         TEXT NOT NULL,
+	real_name    TEXT NOT NULL,
+	display_name TEXT NOT NULL,
+	is_bot       INTEGER NOT NULL,
+	deleted      INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS messages (
+	conversation_id TEXT NOT NULL,
+	ts              TEXT NOT NULL,
+	thread_ts       TEXT,
+	user_id         TEXT,
+	subtype         TEXT,
+	date            TEXT NOT NULL,
+	text            TEXT NOT NULL,
+	rendered_text   TEXT NOT NULL,
+	reply_count     INTEGER NOT NULL,
+	edited_ts       TEXT,
+	raw             TEXT,
+	PRIMARY KEY (conversation_id, ts)
+);
+CREATE INDEX IF NOT EXISTS messages_thread ON messages (conversation_id, thread_ts);
+CREATE INDEX IF NOT EXISTS messages_user ON messages (user_id);
+CREATE INDEX IF NOT EXISTS messages_date ON messages (date);
+CREATE TABLE IF NOT EXISTS reactions (
+	conversation_id TEXT NOT NULL,
+	ts              TEXT NOT NULL,
+	name            TEXT NOT NULL,
+	user_id         TEXT NOT NULL,
+	PRIMARY KEY (conversation_id, ts, name, user_id)
+);
+CREATE TABLE IF NOT EXISTS files (
+	conversation_id TEXT NOT NULL,
+	ts              TEXT NOT NULL,
+	file_id         TEXT NOT NULL,
+	name            TEXT NOT NULL,
+	title           TEXT NOT NULL,
+	mimetype        TEXT NOT NULL,
+	size            INTEGER NOT NULL,
+	url             TEXT NOT NULL,
+	PRIMARY KEY (conversation_id, ts, file_id)
+);
+CREATE VIRTUAL TABLE IF NOT EXISTS messages_fts USING fts5 (
+	rendered_text,
+	content = 'messages',
+	content_rowid = 'rowid'
+);
+CREATE TRIGGER IF NOT EXISTS messages_fts_insert AFTER INSERT ON messages BEGIN
+	INSERT INTO messages_fts (rowid, rendered_text) VALUES (new.rowid, new.rendered_text);
+END;
+CREATE TRIGGER IF NOT EXISTS messages_fts_delete AFTER DELETE ON messages BEGIN
+	INSERT INTO messages_fts (messages_fts, rowid, rendered_text) VALUES ('delete', old.rowid, old.rendered_text);
+END;
+CREATE TRIGGER IF NOT EXISTS messages_fts_update AFTER UPDATE ON messages BEGIN
+	INSERT INTO messages_fts (messages_fts, rowid, rendered_text) VALUES ('delete', old.rowid, old.rendered_text);
+	INSERT INTO messages_fts (rowid, rendered_text) VALUES (new.rowid, new.rendered_text);
+END;
+`
+
+// Conversation is a row of the conversations table.
+type Conversation struct {
+	ID   string
+	Name string
+	Type string
+}
+
+// User is a row of the users table.
+type User struct {
+	ID          string
+	Name        string
+	RealName    string
+	DisplayName string
+	IsBot       bool
+	Deleted     bool
+}
+
+// Message is a row of the messages table, with its reactions and files.
+type Message struct {
+	ConversationID string
+	TS             string
+	ThreadTS       string
+	User           string
+	Subtype        string
+	Date           string // YYYY-MM-DD
+	Text           string // Slack's mrkdwn
+	RenderedText   string // As the other formats show it; full-text indexed
+	ReplyCount     int
+	EditedTS       string
+	Raw            []byte // The message's JSON
+
+	Reactions []Reaction
+	Files     []File
+}
+
+// Reaction is an emoji reaction; each of its users is a row of the
+// reactions table.
+type Reaction struct {
+	Name  string
+	Users []string
+}
+
+// File is a row of the files table.
+type File struct {
+	ID       string
+	Name     string
+	Title    string
+	Mimetype string
+	Size     int64
+	URL      string
+}
+
+// DB is a SQLite archive. It is safe for concurrent use; writes are
+// serialized.
+type DB struct {
+	path   string
+	binary string
+	mu     sync.Mutex
+}
+
+// Open creates the database at path, or opens the existing one, and makes
+// sure it has the archive's tables.
+func Open(ctx context.Context, path string) (*DB, error) {
+	binary, err := exec.LookPath(DefaultBinary)
+	if err != nil {
+		return nil, fmt.Errorf("%w (install SQLite, e.g. 'brew install sqlite' or 'apt install sqlite3')", ErrNoSQLite)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	db := &DB{path: path, binary: binary}
+	if err := db.exec(ctx, schema+"PRAGMA user_version = "+strconv.Itoa(SchemaVersion)+";\n"); err != nil {
+		if strings.Contains(err.Error(), "no such module: fts5") {
+			return nil, fmt.Errorf("%s was built without FTS5, which the sqlite format needs: %w", binary, err)
+		}
+		return nil, fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	return db, nil
+}
+
+// Path returns the database file.
+func (db *DB) Path() string {
+	return db.path
+}
+
+// PutConversation adds the conversation, or updates its name and type.
+func (db *DB) PutConversation(ctx context.Context, c Conversation) error {
+	return db.exec(ctx, fmt.Sprintf(
+		"INSERT INTO conversations (id, name, type) VALUES (%s, %s, %s)\n"+
+			"ON CONFLICT (id) DO UPDATE SET name = excluded.name, type = excluded.type;\n",
+		quote(c.ID), quote(c.Name), quote(c.Type)))
+}
+
+// PutUsers adds the users, or updates the ones already there.
+func (db *DB) PutUsers(ctx context.Context, users []User) error {
+	if len(users) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	b.WriteString("BEGIN;\n")
+	for _, u := range users {
+		fmt.Fprintf(&b, "INSERT INTO users (id, name, real_name, display_name, is_bot, deleted) VALUES (%s, %s, %s, %s, %d, %d)\n"+
+			"ON CONFLICT (id) DO UPDATE SET name = excluded.name, real_name = excluded.real_name, display_name = excluded.display_name, is_bot = excluded.is_bot, deleted = excluded.deleted;\n",
+			quote(u.ID), quote(u.Name), quote(u.RealName), quote(u.DisplayName), boolInt(u.IsBot), boolInt(u.Deleted))
+	}
+	b.WriteString("COMMIT;\n")
+	return db.exec(ctx, b.String())
+}
+
+// PutMessages adds the messages, or replaces the ones already there
+// (matched by conversation and timestamp) along with their reactions and
+// files, all in one transaction.
+func (db *DB) PutMessages(ctx context.Context, msgs []Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	b.WriteString("BEGIN;\n")
+	for _, m := range msgs {
+		conv, ts := quote(m.ConversationID), quote(m.TS)
+		fmt.Fprintf(&b, "INSERT INTO messages (conversation_id, ts, thread_ts, user_id, subtype, date, text, rendered_text, reply_count, edited_ts, raw)\n"+
+			"VALUES (%s, %s, %s, %s, %s, %s, %s, %s, %d, %s, %s)\n"+
+			"ON CONFLICT (conversation_id, ts) DO UPDATE SET thread_ts = excluded.thread_ts, user_id = excluded.user_id, subtype = excluded.subtype, "+
+			"date = excluded.date, text = excluded.text, rendered_text = excluded.rendered_text, reply_count = excluded.reply_count, "+
+			"edited_ts = excluded.edited_ts, raw = excluded.raw;\n",
+			conv, ts, nullable(m.ThreadTS), nullable(m.User), nullable(m.Subtype), quote(m.Date), quote(m.Text), quote(m.RenderedText),
+			m.ReplyCount, nullable(m.EditedTS), nullable(string(m.Raw)))
+
+		fmt.Fprintf(&b, "DELETE FROM reactions WHERE conversation_id = %s AND ts = %s;\n", conv, ts)
+		for _, r := range m.Reactions {
+			for _, user := range r.Users {
+				fmt.Fprintf(&b, "INSERT OR IGNORE INTO reactions (conversation_id, ts, name, user_id) VALUES (%s, %s, %s, %s);\n",
+					conv, ts, quote(r.Name), quote(user))
+			}
+		}
+		fmt.Fprintf(&b, "DELETE FROM files WHERE conversation_id = %s AND ts = %s;\n", conv, ts)
+		for _, f := range m.Files {
+			fmt.Fprintf(&b, "INSERT OR IGNORE INTO files (conversation_id, ts, file_id, name, title, mimetype, size, url) VALUES (%s, %s, %s, %s, %s, %s, %d, %s);\n",
+				conv, ts, quote(f.ID), quote(f.Name), quote(f.Title), quote(f.Mimetype), f.Size, quote(f.URL))
+		}
+	}
+	b.WriteString("COMMIT;\n")
+	return db.exec(ctx, b.String())
+}
+
+// Query runs a read-only SQL query and returns its rows as column-value
+// maps, with numbers as float64.
+func (db *DB) Query(ctx context.Context, query string) ([]map[string]interface{}, error) {
+	out, err := db.run(ctx, []string{"-json", "-readonly"}, query+";\n")
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, nil
+	}
+	var rows []map[string]interface{}
+	if err := json.Unmarshal(out, &rows); err != nil {
+		return nil, fmt.Errorf("failed to parse sqlite3 output: %w", err)
+	}
+	return rows, nil
+}
+
+// exec runs SQL statements, stopping at the first error. Writes are
+// serialized, and a database locked by another process is waited for.
+func (db *DB) exec(ctx context.Context, sql string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	_, err := db.run(ctx, nil, sql)
+	return err
+}
+
+// run feeds sql to sqlite3 on the database and returns what it printed.
+func (db *DB) run(ctx context.Context, flags []string, sql string) ([]byte, error) {
+	args := append([]string{"-batch", "-bail", "-cmd", ".timeout 10000"}, flags...)
+	cmd := exec.CommandContext(ctx, db.binary, append(args, db.path)...)
+	cmd.Stdin = strings.NewReader(sql)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("sqlite3: %s", msg)
+		}
+		return nil, fmt.Errorf("sqlite3: %w", err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// quote returns s as an SQL string literal. NUL bytes, which SQLite
+// would end the string at, are dropped.
+func quote(s string) string {
+	s = strings.ReplaceAll(s, "\x00", "")
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// nullable returns s as an SQL string literal, or NULL when it is empty.
+func nullable(s string) string {
+	if s == "" {
+		return "NULL"
+	}
+	return quote(s)
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
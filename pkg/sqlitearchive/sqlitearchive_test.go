@@ -0,0 +1,98 @@
+package sqlitearchive
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// openTest opens a database in a temp dir, skipping without sqlite3.
+func openTest(t *testing.T) *DB {
+	t.Helper()
+	if _, err := exec.LookPath(DefaultBinary); err != nil {
+		t.Skip("sqlite3 is not on the PATH")
+	}
+	db, err := Open(context.Background(), filepath.Join(t.TempDir(), "archive", "get-out.sqlite"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	return db
+}
+
+func TestOpen_Reopens(t *testing.T) {
+	db := openTest(t)
+	again, err := Open(context.Background(), db.Path())
+	if err != nil {
+		t.Fatalf("second Open() error: %v", err)
+	}
+	rows, err := again.Query(context.Background(), "PRAGMA user_version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0]["user_version"] != float64(SchemaVersion) {
+		t.Errorf("user_version = %v, want %d", rows, SchemaVersion)
+	}
+}
+
+func TestPutMessages(t *testing.T) {
+	ctx := context.Background()
+	db := openTest(t)
+	if err := db.PutConversation(ctx, Conversation{ID: "C001", Name: "general", Type: "channel"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.PutUsers(ctx, []User{{ID: "U001", Name: "alice", RealName: "Alice O'Neil"}}); err != nil {
+		t.Fatal(err)
+	}
+	msgs := []Message{
+		{
+			ConversationID: "C001", TS: "1706792400.000100", User: "U001", Date: "2024-02-01",
+			Text: "*Deploy* it's done", RenderedText: "Deploy it's done", Raw: []byte(`{"ts":"1706792400.000100"}`),
+			Reactions: []Reaction{{Name: "tada", Users: []string{"U001", "U002"}}},
+			Files:     []File{{ID: "F001", Name: "log.txt", Title: "Log", Mimetype: "text/plain", Size: 42, URL: "https://files/F001"}},
+		},
+		{ConversationID: "C001", TS: "1706792460.000200", ThreadTS: "1706792400.000100", User: "U002", Date: "2024-02-01", Text: "Rollback", RenderedText: "Rollback"},
+	}
+	if err := db.PutMessages(ctx, msgs); err != nil {
+		t.Fatalf("PutMessages() error: %v", err)
+	}
+
+	rows, err := db.Query(ctx, "SELECT m.ts FROM messages_fts JOIN messages m ON m.rowid = messages_fts.rowid WHERE messages_fts MATCH 'deploy'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0]["ts"] != "1706792400.000100" {
+		t.Errorf("search for deploy = %v, want the first message", rows)
+	}
+	rows, err = db.Query(ctx, "SELECT real_name FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0]["real_name"] != "Alice O'Neil" {
+		t.Errorf("users = %v, want the quoted name intact", rows)
+	}
+
+	// Writing a message again replaces it, its reactions, its files, and
+	// its indexed text.
+	msgs[0].RenderedText = "Shipped"
+	msgs[0].Reactions = nil
+	msgs[0].Files = nil
+	if err := db.PutMessages(ctx, msgs[:1]); err != nil {
+		t.Fatal(err)
+	}
+	for query, want := range map[string]float64{
+		"SELECT count(*) AS n FROM messages":                                        2,
+		"SELECT count(*) AS n FROM reactions":                                       0,
+		"SELECT count(*) AS n FROM files":                                           0,
+		"SELECT count(*) AS n FROM messages_fts WHERE messages_fts MATCH 'deploy'":  0,
+		"SELECT count(*) AS n FROM messages_fts WHERE messages_fts MATCH 'shipped'": 1,
+	} {
+		rows, err := db.Query(ctx, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rows[0]["n"] != want {
+			t.Errorf("%s = %v, want %v", query, rows[0]["n"], want)
+		}
+	}
+}